@@ -0,0 +1,128 @@
+package async
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+)
+
+// ErrPanic is wrapped by the errors the panics of the goroutines are converted to.
+var ErrPanic = stderrors.New("async: goroutine panicked")
+
+// options holds configuration options for the group.
+type options struct {
+	limit         int  // limit is the maximum number of goroutines running at once, or zero for no limit.
+	cancelOnError bool // cancelOnError cancels the context of the group on the first error.
+}
+
+// Option specifies group configuration options.
+type Option func(*options)
+
+// WithLimit bounds the number of goroutines of the group running at once to n: Go blocks until one of them returns.
+// There is no limit by default.
+func WithLimit(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.limit = n
+		}
+	}
+}
+
+// WithCancelOnError cancels the context of the group, with the error as cause, when a goroutine fails, so that the
+// others can stop early. The goroutines are not canceled by default.
+func WithCancelOnError() Option {
+	return func(opts *options) {
+		opts.cancelOnError = true
+	}
+}
+
+/*
+Group runs goroutines and waits for all of them, collecting their errors, like golang.org/x/sync/errgroup, but
+returning every error rather than the first one. The panics of the goroutines are converted to errors wrapping
+ErrPanic, with the stack trace of the panic, see errors.WithStack, rather than crashing the process.
+
+The goroutines receive the context of the group, derived from the context of NewGroup, and canceled once Wait
+returns, or on the first error with WithCancelOnError.
+
+Example usage:
+
+	group := async.NewGroup(ctx, async.WithLimit(10), async.WithCancelOnError())
+	for _, id := range ids {
+		group.Go(func(ctx context.Context) error {
+			return s.sync(ctx, id)
+		})
+	}
+	if err := group.Wait(); err != nil {
+		// Handle error
+	}
+*/
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	opts   options
+	sem    chan struct{} // sem holds a token per goroutine running, with WithLimit.
+	wg     sync.WaitGroup
+	errs   errors.MultiError
+}
+
+// NewGroup creates a group whose goroutines receive a context derived from ctx.
+func NewGroup(ctx context.Context, opts ...Option) *Group {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	g := &Group{opts: o}
+	g.ctx, g.cancel = context.WithCancelCause(ctx)
+	if o.limit > 0 {
+		g.sem = make(chan struct{}, o.limit)
+	}
+	return g
+}
+
+// Go runs fn in a goroutine with the context of the group. With WithLimit, it blocks while the group runs as many
+// goroutines as its limit, so it must not be called by the goroutines of the group then.
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+		if err := g.call(fn); err != nil {
+			g.errs.Add("", err)
+			if g.opts.cancelOnError {
+				g.cancel(err)
+			}
+		}
+	}()
+}
+
+// call calls fn, converting its panic to an error.
+func (g *Group) call(fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		if recoveredErr, ok := recovered.(error); ok {
+			err = errors.WithStack(fmt.Errorf("%w: %w", ErrPanic, recoveredErr))
+		} else {
+			err = errors.WithStack(fmt.Errorf("%w: %v", ErrPanic, recovered))
+		}
+	}()
+	return fn(g.ctx)
+}
+
+// Wait waits for the goroutines of the group, cancels its context, and returns their errors as an
+// *errors.MultiError, in the order they failed, or nil if none failed.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+	return g.errs.Err()
+}
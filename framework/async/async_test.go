@@ -0,0 +1,156 @@
+package async_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/async"
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+)
+
+func TestGroup_Wait(t *testing.T) {
+	t.Run("returns nil when no goroutine fails", func(t *testing.T) {
+		group := async.NewGroup(context.Background())
+		var done atomic.Int32
+		for i := 0; i < 5; i++ {
+			group.Go(func(ctx context.Context) error {
+				done.Add(1)
+				return nil
+			})
+		}
+		require.NoError(t, group.Wait())
+		assert.Equal(t, int32(5), done.Load())
+	})
+
+	t.Run("returns every error", func(t *testing.T) {
+		errFirst, errSecond := errors.New("first failed"), errors.New("second failed")
+		group := async.NewGroup(context.Background())
+		group.Go(func(ctx context.Context) error { return errFirst })
+		group.Go(func(ctx context.Context) error { return nil })
+		group.Go(func(ctx context.Context) error { return errSecond })
+
+		err := group.Wait()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, errFirst)
+		assert.ErrorIs(t, err, errSecond)
+		var multi *domain_error.MultiError
+		require.ErrorAs(t, err, &multi)
+		assert.Equal(t, 2, multi.Len())
+	})
+
+	t.Run("cancels the context once the goroutines returned", func(t *testing.T) {
+		group := async.NewGroup(context.Background())
+		var groupCtx context.Context
+		group.Go(func(ctx context.Context) error {
+			groupCtx = ctx
+			return nil
+		})
+		require.NoError(t, group.Wait())
+		assert.ErrorIs(t, groupCtx.Err(), context.Canceled)
+	})
+}
+
+func TestGroup_Limit(t *testing.T) {
+	group := async.NewGroup(context.Background(), async.WithLimit(3))
+	var running, maxRunning atomic.Int32
+	for i := 0; i < 20; i++ {
+		group.Go(func(ctx context.Context) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				max := maxRunning.Load()
+				if n <= max || maxRunning.CompareAndSwap(max, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+	}
+	require.NoError(t, group.Wait())
+	assert.Equal(t, int32(3), maxRunning.Load())
+}
+
+func TestGroup_Panic(t *testing.T) {
+	errBoom := errors.New("boom")
+	group := async.NewGroup(context.Background())
+	group.Go(func(ctx context.Context) error { panic("boom") })
+	group.Go(func(ctx context.Context) error { panic(errBoom) })
+
+	err := group.Wait()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, async.ErrPanic)
+	assert.ErrorIs(t, err, errBoom, "the error panicked with is wrapped")
+	var multi *domain_error.MultiError
+	require.ErrorAs(t, err, &multi)
+	require.Len(t, multi.Errors(), 2)
+	for _, panicErr := range multi.Errors() {
+		var tracer domain_error.StackTracer
+		require.ErrorAs(t, panicErr, &tracer)
+		frames := runtime.CallersFrames(tracer.StackTrace())
+		var panicked bool
+		for {
+			frame, more := frames.Next()
+			panicked = panicked || strings.HasPrefix(frame.Function, "github.com/kittipat1413/go-common/framework/async_test.TestGroup_Panic.func")
+			if !more {
+				break
+			}
+		}
+		assert.True(t, panicked, "the stack trace is the one of the panic")
+	}
+}
+
+func TestGroup_CancelOnError(t *testing.T) {
+	t.Run("cancels the other goroutines on the first error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		group := async.NewGroup(context.Background(), async.WithCancelOnError())
+		canceled := make(chan error, 1)
+		group.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			canceled <- context.Cause(ctx)
+			return nil
+		})
+		group.Go(func(ctx context.Context) error { return errFailed })
+
+		assert.ErrorIs(t, group.Wait(), errFailed)
+		assert.ErrorIs(t, <-canceled, errFailed, "the cause is the first error")
+	})
+
+	t.Run("does not cancel the other goroutines by default", func(t *testing.T) {
+		group := async.NewGroup(context.Background())
+		release := make(chan struct{})
+		group.Go(func(ctx context.Context) error {
+			<-release
+			return ctx.Err()
+		})
+		group.Go(func(ctx context.Context) error {
+			defer close(release)
+			return errors.New("failed")
+		})
+
+		err := group.Wait()
+		var multi *domain_error.MultiError
+		require.ErrorAs(t, err, &multi)
+		assert.Equal(t, 1, multi.Len())
+		assert.NotErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("cancels the goroutines with the parent context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		group := async.NewGroup(ctx)
+		group.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		cancel()
+		assert.ErrorIs(t, group.Wait(), context.Canceled)
+	})
+}
@@ -0,0 +1,179 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+)
+
+const (
+	// DefaultJWKSExpiration is the duration the key sets are cached for, unless the JWKS endpoint responds
+	// with a Cache-Control max-age, or another cache is set with WithCache.
+	DefaultJWKSExpiration = 15 * time.Minute
+	// DefaultJWKSRefreshInterval is the minimum interval between two fetches of the key set triggered by
+	// tokens signed with unknown keys.
+	DefaultJWKSRefreshInterval = time.Minute
+)
+
+// jwksOptions holds configuration options for the JWKS.
+type jwksOptions struct {
+	client          *http.Client
+	cache           cache.Cache[KeySet]
+	refreshInterval time.Duration
+}
+
+// JWKSOption specifies JWKS configuration options.
+type JWKSOption func(*jwksOptions)
+
+// WithHTTPClient sets the client fetching the key sets. It defaults to a client with a 10 seconds timeout.
+func WithHTTPClient(client *http.Client) JWKSOption {
+	return func(opts *jwksOptions) {
+		if client != nil {
+			opts.client = client
+		}
+	}
+}
+
+// WithCache sets the cache of the key sets, e.g., a remote cache shared by the instances of a service. It
+// defaults to a local cache expiring the key sets after DefaultJWKSExpiration.
+func WithCache(c cache.Cache[KeySet]) JWKSOption {
+	return func(opts *jwksOptions) {
+		if c != nil {
+			opts.cache = c
+		}
+	}
+}
+
+// WithRefreshInterval sets the minimum interval between two fetches of the key set triggered by tokens signed
+// with unknown keys, so that forged tokens cannot flood the JWKS endpoint. It defaults to
+// DefaultJWKSRefreshInterval.
+func WithRefreshInterval(d time.Duration) JWKSOption {
+	return func(opts *jwksOptions) {
+		opts.refreshInterval = d
+	}
+}
+
+/*
+JWKS is a KeySource fetching the keys from the JWKS endpoint of an identity provider, and caching them with the
+cache package. When a token is signed with a key missing from the cached key set, e.g., after a key rotation,
+the key set is fetched again, at most once per refresh interval.
+
+Example usage:
+
+	keys := jwt.NewJWKS("https://auth.example.com/.well-known/jwks.json")
+	verifier := jwt.NewVerifier(keys,
+		jwt.WithIssuer("https://auth.example.com/"),
+		jwt.WithAudience("orders-api"),
+	)
+*/
+type JWKS struct {
+	url  string
+	opts jwksOptions
+
+	mu          sync.Mutex
+	lastRefresh time.Time
+}
+
+// NewJWKS creates a JWKS fetching the key set from url.
+func NewJWKS(url string, opts ...JWKSOption) *JWKS {
+	o := &jwksOptions{
+		client:          &http.Client{Timeout: 10 * time.Second},
+		refreshInterval: DefaultJWKSRefreshInterval,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.cache == nil {
+		o.cache = localcache.New[KeySet](
+			localcache.WithDefaultExpiration(DefaultJWKSExpiration),
+			localcache.WithLazyExpiration(),
+		)
+	}
+	return &JWKS{url: url, opts: *o}
+}
+
+// Key implements the KeySource interface.
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	set, err := j.opts.cache.Get(ctx, j.url, j.fetch)
+	if err != nil {
+		return nil, err
+	}
+	if key, found := set.find(kid); found {
+		return publicKey(key)
+	}
+	if !j.refreshAllowed() {
+		return nil, ErrKeyNotFound
+	}
+
+	if err := j.opts.cache.Invalidate(ctx, j.url); err != nil {
+		return nil, err
+	}
+	set, err = j.opts.cache.Get(ctx, j.url, j.fetch)
+	if err != nil {
+		return nil, err
+	}
+	if key, found := set.find(kid); found {
+		return publicKey(key)
+	}
+	return nil, ErrKeyNotFound
+}
+
+// refreshAllowed reports whether the key set may be fetched again for an unknown key, and records the refresh.
+func (j *JWKS) refreshAllowed() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if time.Since(j.lastRefresh) < j.opts.refreshInterval {
+		return false
+	}
+	j.lastRefresh = time.Now()
+	return true
+}
+
+// fetch is the cache.Initializer of the key set, cached for the max-age of the response if any.
+func (j *JWKS) fetch(ctx context.Context, _ string) (KeySet, *time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return KeySet{}, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := j.opts.client.Do(req)
+	if err != nil {
+		return KeySet{}, nil, fmt.Errorf("fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return KeySet{}, nil, fmt.Errorf("fetching JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var set KeySet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return KeySet{}, nil, fmt.Errorf("decoding JWKS: %w", err)
+	}
+	return set, maxAge(resp.Header.Get("Cache-Control")), nil
+}
+
+// maxAge returns the max-age directive of a Cache-Control header, or nil if there is none.
+func maxAge(cacheControl string) *time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return nil
+		}
+		d := time.Duration(seconds) * time.Second
+		return &d
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultClockSkew is the tolerance of the time claims of the tokens verified, unless set with WithClockSkew, for
+	// the clocks of the issuer and the service not being exactly synchronized.
+	DefaultClockSkew = time.Minute
+	// DefaultTTL is the time to live of the tokens signed, unless set with WithTTL.
+	DefaultTTL = 15 * time.Minute
+	// DefaultRetainedKeys is the number of previous signing keys a Signer publishes after a rotation, unless set with
+	// WithRetainedKeys.
+	DefaultRetainedKeys = 1
+)
+
+var (
+	// ErrInvalidToken is wrapped by the errors of the tokens rejected by Verify: malformed, forged, expired or
+	// unexpected tokens.
+	ErrInvalidToken = errors.New("jwt: invalid token")
+	// ErrTokenExpired is returned by Verify for the tokens expired, beyond the clock skew.
+	ErrTokenExpired = fmt.Errorf("%w: token is expired", ErrInvalidToken)
+	// ErrTokenRevoked is returned by Verify for the tokens revoked, see WithRevocationCheck.
+	ErrTokenRevoked = fmt.Errorf("%w: token is revoked", ErrInvalidToken)
+	// ErrKeyNotFound is returned by the KeySources when no key has the ID of a token.
+	ErrKeyNotFound = fmt.Errorf("%w: signing key not found", ErrInvalidToken)
+	// ErrKeysUnavailable is returned by Verify when the KeySource fails to return the keys, e.g., when the JWKS
+	// endpoint is down, since the token may be valid. It wraps the error of the KeySource.
+	ErrKeysUnavailable = errors.New("jwt: signing keys are unavailable")
+	// ErrUnsupportedKey is returned by NewSigner and Rotate for the keys of unsupported types or algorithms.
+	ErrUnsupportedKey = errors.New("jwt: unsupported key")
+)
+
+// Algorithms lists the supported signing algorithms, see RFC 7518. Symmetric algorithms are not supported, since
+// the verifiers would be able to sign tokens too.
+var Algorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Claims are the claims of a token, a struct embedding RegisteredClaims, with the custom claims as fields with json
+// tags.
+type Claims interface {
+	// Registered returns the registered claims.
+	Registered() *RegisteredClaims
+}
+
+/*
+RegisteredClaims are the registered claims of RFC 7519, embedded in the claims of the tokens.
+
+Example usage:
+
+	type UserClaims struct {
+		jwt.RegisteredClaims
+		TenantID string   `json:"tenant_id"`
+		Roles    []string `json:"roles"`
+	}
+*/
+type RegisteredClaims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+}
+
+// Registered implements the Claims interface.
+func (c *RegisteredClaims) Registered() *RegisteredClaims {
+	return c
+}
+
+// Audience is the "aud" claim, a string or a list of strings.
+type Audience []string
+
+// MarshalJSON encodes a single audience as a string, and the others as a list.
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON accepts a string or a list of strings.
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = nil
+		if s != "" {
+			*a = Audience{s}
+		}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// contains reports whether a contains any of audiences.
+func (a Audience) contains(audiences []string) bool {
+	for _, audience := range audiences {
+		if contains(a, audience) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether values contains value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// NumericDate is a time claim, encoded as the number of seconds since the epoch.
+type NumericDate struct {
+	time.Time
+}
+
+// NewNumericDate returns the NumericDate of t, truncated to the second.
+func NewNumericDate(t time.Time) *NumericDate {
+	return &NumericDate{t.Truncate(time.Second)}
+}
+
+// MarshalJSON encodes the date as a number of seconds.
+func (d NumericDate) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(d.Unix(), 10)), nil
+}
+
+// UnmarshalJSON decodes a number of seconds, possibly fractional.
+func (d *NumericDate) UnmarshalJSON(b []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	seconds, err := n.Float64()
+	if err != nil {
+		return err
+	}
+	sec, frac := math.Modf(seconds)
+	d.Time = time.Unix(int64(sec), int64(frac*float64(time.Second)))
+	return nil
+}
+
+// RevocationCheck reports whether the token of claims is revoked, e.g., by looking its ID up in a deny list.
+type RevocationCheck func(ctx context.Context, claims *RegisteredClaims) (bool, error)
+
+// options holds configuration options for the Signer and the Verifier.
+type options struct {
+	issuers      []string        // issuers are the issuers accepted, the first one being the issuer of the tokens signed.
+	audiences    []string        // audiences are the audiences accepted, and the audience of the tokens signed.
+	ttl          time.Duration   // ttl is the time to live of the tokens signed.
+	retainedKeys int             // retainedKeys is the number of previous signing keys published.
+	clockSkew    time.Duration   // clockSkew is the tolerance of the time claims verified.
+	algorithms   map[string]bool // algorithms are the algorithms accepted.
+	revoked      RevocationCheck // revoked checks the revocation of the tokens verified, if set.
+	now          func() time.Time
+}
+
+// Option specifies Signer and Verifier configuration options.
+type Option func(*options)
+
+// WithIssuer sets the "iss" claim of the tokens signed to the first issuer, and accepts only the tokens verified
+// issued by one of issuers.
+func WithIssuer(issuers ...string) Option {
+	return func(opts *options) {
+		opts.issuers = append(opts.issuers, issuers...)
+	}
+}
+
+// WithAudience sets the "aud" claim of the tokens signed to audiences, and accepts only the tokens verified
+// intended for one of audiences, e.g., the identifier of the API. Without it, the tokens issued for other services
+// are accepted.
+func WithAudience(audiences ...string) Option {
+	return func(opts *options) {
+		opts.audiences = append(opts.audiences, audiences...)
+	}
+}
+
+// WithTTL sets the time to live of the tokens signed without "exp" claim. It defaults to DefaultTTL.
+func WithTTL(ttl time.Duration) Option {
+	return func(opts *options) {
+		if ttl > 0 {
+			opts.ttl = ttl
+		}
+	}
+}
+
+// WithRetainedKeys sets the number of previous signing keys a Signer publishes after a rotation, so that the tokens
+// they signed are verified until they expire. It defaults to DefaultRetainedKeys.
+func WithRetainedKeys(n int) Option {
+	return func(opts *options) {
+		if n >= 0 {
+			opts.retainedKeys = n
+		}
+	}
+}
+
+// WithClockSkew sets the tolerance of the "exp", "nbf" and "iat" claims of the tokens verified. It defaults to
+// DefaultClockSkew.
+func WithClockSkew(d time.Duration) Option {
+	return func(opts *options) {
+		if d >= 0 {
+			opts.clockSkew = d
+		}
+	}
+}
+
+// WithAlgorithms restricts the signing algorithms of the tokens verified, e.g., "RS256". It defaults to all the
+// Algorithms.
+func WithAlgorithms(algorithms ...string) Option {
+	return func(opts *options) {
+		opts.algorithms = make(map[string]bool, len(algorithms))
+		for _, alg := range algorithms {
+			if supported(alg) {
+				opts.algorithms[alg] = true
+			}
+		}
+	}
+}
+
+// WithRevocationCheck rejects the tokens verified revoked by check with ErrTokenRevoked, e.g., after a logout. It
+// is called once the signature and the claims of a token are verified.
+func WithRevocationCheck(check RevocationCheck) Option {
+	return func(opts *options) {
+		if check != nil {
+			opts.revoked = check
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		ttl:          DefaultTTL,
+		retainedKeys: DefaultRetainedKeys,
+		clockSkew:    DefaultClockSkew,
+		algorithms:   make(map[string]bool, len(Algorithms)),
+		now:          time.Now,
+	}
+	for _, alg := range Algorithms {
+		o.algorithms[alg] = true
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// supported reports whether alg is one of the Algorithms.
+func supported(alg string) bool {
+	return contains(Algorithms, alg)
+}
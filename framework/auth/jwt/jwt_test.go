@@ -0,0 +1,282 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/auth/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	issuer   = "https://auth.example.com/"
+	audience = "orders-api"
+)
+
+var (
+	rsaKey, _   = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _    = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	_, edKey, _ = ed25519.GenerateKey(rand.Reader)
+)
+
+type userClaims struct {
+	jwt.RegisteredClaims
+	TenantID string   `json:"tenant_id"`
+	Roles    []string `json:"roles"`
+}
+
+func newSigner(t *testing.T, key jwt.SigningKey, opts ...jwt.Option) *jwt.Signer {
+	t.Helper()
+	signer, err := jwt.NewSigner(key, append([]jwt.Option{jwt.WithIssuer(issuer), jwt.WithAudience(audience)}, opts...)...)
+	require.NoError(t, err)
+	return signer
+}
+
+func TestSignVerify(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         jwt.SigningKey
+		expectedAlg string
+	}{
+		{name: "RSA", key: jwt.SigningKey{ID: "rsa", Key: rsaKey}, expectedAlg: "RS256"},
+		{name: "RSA-PSS", key: jwt.SigningKey{ID: "rsa", Key: rsaKey, Algorithm: "PS512"}, expectedAlg: "PS512"},
+		{name: "ECDSA", key: jwt.SigningKey{ID: "ec", Key: ecKey}, expectedAlg: "ES384"},
+		{name: "Ed25519", key: jwt.SigningKey{ID: "ed", Key: edKey}, expectedAlg: "EdDSA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := newSigner(t, tt.key)
+			claims := &userClaims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: "user-1"},
+				TenantID:         "tenant-1",
+				Roles:            []string{"admin"},
+			}
+			token, err := signer.Sign(claims)
+			require.NoError(t, err)
+
+			var h map[string]string
+			decodeSegment(t, strings.Split(token, ".")[0], &h)
+			assert.Equal(t, map[string]string{"alg": tt.expectedAlg, "kid": tt.key.ID, "typ": "JWT"}, h)
+			assert.Equal(t, issuer, claims.Issuer, "the registered claims are set")
+			assert.Equal(t, jwt.Audience{audience}, claims.Audience)
+			assert.NotEmpty(t, claims.ID)
+			assert.WithinDuration(t, time.Now().Add(jwt.DefaultTTL), claims.ExpiresAt.Time, 2*time.Second)
+
+			verifier := jwt.NewVerifier(signer, jwt.WithIssuer(issuer), jwt.WithAudience(audience))
+			verified, err := jwt.Verify[userClaims](context.Background(), verifier, token)
+			require.NoError(t, err)
+			assert.Equal(t, claims, verified)
+		})
+	}
+}
+
+func TestNewSigner_UnsupportedKey(t *testing.T) {
+	_, err := jwt.NewSigner(jwt.SigningKey{Key: rsaKey, Algorithm: "ES256"})
+	assert.ErrorIs(t, err, jwt.ErrUnsupportedKey)
+	_, err = jwt.NewSigner(jwt.SigningKey{Key: ecKey, Algorithm: "ES256"}, jwt.WithTTL(time.Hour))
+	assert.ErrorIs(t, err, jwt.ErrUnsupportedKey, "the hash must match the curve")
+	_, err = jwt.NewSigner(jwt.SigningKey{})
+	assert.ErrorIs(t, err, jwt.ErrUnsupportedKey)
+}
+
+func TestSigner_Rotate(t *testing.T) {
+	signer := newSigner(t, jwt.SigningKey{ID: "1", Key: rsaKey}, jwt.WithRetainedKeys(1))
+	verifier := jwt.NewVerifier(signer)
+	ctx := context.Background()
+
+	first, err := signer.Sign(&jwt.RegisteredClaims{Subject: "user-1"})
+	require.NoError(t, err)
+	require.NoError(t, signer.Rotate(jwt.SigningKey{ID: "2", Key: ecKey}))
+	second, err := signer.Sign(&jwt.RegisteredClaims{Subject: "user-1"})
+	require.NoError(t, err)
+
+	_, err = jwt.Verify[jwt.RegisteredClaims](ctx, verifier, first)
+	assert.NoError(t, err, "the tokens of the retained key are verified")
+	_, err = jwt.Verify[jwt.RegisteredClaims](ctx, verifier, second)
+	assert.NoError(t, err)
+
+	require.NoError(t, signer.Rotate(jwt.SigningKey{ID: "3", Key: edKey}))
+	_, err = jwt.Verify[jwt.RegisteredClaims](ctx, verifier, first)
+	assert.ErrorIs(t, err, jwt.ErrKeyNotFound, "the keys beyond the retained ones are dropped")
+	_, err = jwt.Verify[jwt.RegisteredClaims](ctx, verifier, second)
+	assert.NoError(t, err)
+
+	// The public keys are served as a JSON Web Key Set.
+	rec := httptest.NewRecorder()
+	signer.ServeHTTP(rec, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+	var set jwt.KeySet
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&set))
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, "3", set.Keys[0].KeyID)
+	assert.Equal(t, "2", set.Keys[1].KeyID)
+}
+
+func TestVerify(t *testing.T) {
+	signer := newSigner(t, jwt.SigningKey{ID: "rsa", Key: rsaKey})
+	now := time.Now()
+	signToken := func(claims jwt.RegisteredClaims) string {
+		token, err := signer.Sign(&claims)
+		require.NoError(t, err)
+		return token
+	}
+	valid := signToken(jwt.RegisteredClaims{Subject: "user-1"})
+
+	tests := []struct {
+		name          string
+		token         string
+		options       []jwt.Option
+		keys          jwt.KeySource
+		expectedError error
+		expectedMsg   string
+	}{
+		{name: "valid", token: valid},
+		{name: "malformed", token: "not-a-token", expectedError: jwt.ErrInvalidToken, expectedMsg: "malformed token"},
+		{
+			name:          "expired",
+			token:         signToken(jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(-2 * time.Minute))}),
+			expectedError: jwt.ErrTokenExpired,
+		},
+		{
+			name:    "expired within the clock skew",
+			token:   signToken(jwt.RegisteredClaims{Subject: "user-1", ExpiresAt: jwt.NewNumericDate(now.Add(-30 * time.Second))}),
+			options: []jwt.Option{jwt.WithClockSkew(time.Minute)},
+		},
+		{
+			name:          "expired without clock skew",
+			token:         signToken(jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(-30 * time.Second))}),
+			options:       []jwt.Option{jwt.WithClockSkew(0)},
+			expectedError: jwt.ErrTokenExpired,
+		},
+		{
+			name:          "not valid yet",
+			token:         signToken(jwt.RegisteredClaims{NotBefore: jwt.NewNumericDate(now.Add(time.Hour))}),
+			expectedError: jwt.ErrInvalidToken,
+			expectedMsg:   "token is not valid yet",
+		},
+		{
+			name:          "unexpected issuer",
+			token:         signToken(jwt.RegisteredClaims{Issuer: "https://evil.example.com/"}),
+			expectedError: jwt.ErrInvalidToken,
+			expectedMsg:   `unexpected issuer "https://evil.example.com/"`,
+		},
+		{
+			name:          "unexpected audience",
+			token:         signToken(jwt.RegisteredClaims{Audience: jwt.Audience{"billing-api"}}),
+			expectedError: jwt.ErrInvalidToken,
+			expectedMsg:   "unexpected audience",
+		},
+		{
+			name:          "forged",
+			token:         valid[:strings.LastIndex(valid, ".")] + ".c2lnbmF0dXJl",
+			expectedError: jwt.ErrInvalidToken,
+			expectedMsg:   "invalid signature",
+		},
+		{
+			name:          "unsupported algorithm",
+			token:         valid,
+			options:       []jwt.Option{jwt.WithAlgorithms("ES256")},
+			expectedError: jwt.ErrInvalidToken,
+			expectedMsg:   `unsupported algorithm "RS256"`,
+		},
+		{
+			name:          "unknown key",
+			token:         valid,
+			keys:          jwt.StaticKeys{"other": &rsaKey.PublicKey},
+			expectedError: jwt.ErrKeyNotFound,
+		},
+		{
+			name:          "keys unavailable",
+			token:         valid,
+			keys:          failingKeys{},
+			expectedError: jwt.ErrKeysUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := tt.keys
+			if keys == nil {
+				keys = signer
+			}
+			opts := append([]jwt.Option{jwt.WithIssuer(issuer), jwt.WithAudience(audience)}, tt.options...)
+			claims, err := jwt.Verify[jwt.RegisteredClaims](context.Background(), jwt.NewVerifier(keys, opts...), tt.token)
+			if tt.expectedError == nil {
+				require.NoError(t, err)
+				assert.Equal(t, "user-1", claims.Subject)
+				return
+			}
+			assert.ErrorIs(t, err, tt.expectedError)
+			assert.Nil(t, claims)
+			if tt.expectedMsg != "" {
+				assert.ErrorContains(t, err, tt.expectedMsg)
+			}
+		})
+	}
+}
+
+func TestVerify_RevocationCheck(t *testing.T) {
+	signer := newSigner(t, jwt.SigningKey{ID: "ed", Key: edKey})
+	revoked := &jwt.RegisteredClaims{}
+	revokedToken, err := signer.Sign(revoked)
+	require.NoError(t, err)
+	validToken, err := signer.Sign(&jwt.RegisteredClaims{})
+	require.NoError(t, err)
+
+	verifier := jwt.NewVerifier(signer, jwt.WithRevocationCheck(func(_ context.Context, claims *jwt.RegisteredClaims) (bool, error) {
+		return claims.ID == revoked.ID, nil
+	}))
+	_, err = jwt.Verify[jwt.RegisteredClaims](context.Background(), verifier, revokedToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenRevoked)
+	assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+	_, err = jwt.Verify[jwt.RegisteredClaims](context.Background(), verifier, validToken)
+	assert.NoError(t, err)
+
+	errDenyList := errors.New("deny list unavailable")
+	verifier = jwt.NewVerifier(signer, jwt.WithRevocationCheck(func(context.Context, *jwt.RegisteredClaims) (bool, error) {
+		return false, errDenyList
+	}))
+	_, err = jwt.Verify[jwt.RegisteredClaims](context.Background(), verifier, validToken)
+	assert.ErrorIs(t, err, errDenyList)
+	assert.NotErrorIs(t, err, jwt.ErrInvalidToken)
+}
+
+func TestAudience_JSON(t *testing.T) {
+	b, err := json.Marshal(jwt.Audience{"a"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `"a"`, string(b))
+	b, err = json.Marshal(jwt.Audience{"a", "b"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `["a","b"]`, string(b))
+
+	var aud jwt.Audience
+	require.NoError(t, json.Unmarshal([]byte(`"a"`), &aud))
+	assert.Equal(t, jwt.Audience{"a"}, aud)
+	require.NoError(t, json.Unmarshal([]byte(`["a","b"]`), &aud))
+	assert.Equal(t, jwt.Audience{"a", "b"}, aud)
+}
+
+// failingKeys is a KeySource failing to return the keys.
+type failingKeys struct{}
+
+func (failingKeys) Key(context.Context, string) (crypto.PublicKey, error) {
+	return nil, errors.New("connection refused")
+}
+
+func decodeSegment(t *testing.T, segment string, v interface{}) {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, v))
+}
@@ -0,0 +1,242 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultFileCheckInterval is the minimum interval between two checks of the modification of the file of FileKeys.
+const DefaultFileCheckInterval = 10 * time.Second
+
+// KeySource returns the public keys verifying the signatures of the tokens.
+type KeySource interface {
+	// Key returns the public key with the ID kid, or ErrKeyNotFound.
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// StaticKeys is a KeySource of fixed keys, by key ID, e.g., for tests or services verifying tokens signed with a
+// single known key.
+type StaticKeys map[string]crypto.PublicKey
+
+// Key implements the KeySource interface.
+func (s StaticKeys) Key(_ context.Context, kid string) (crypto.PublicKey, error) {
+	key, found := s[kid]
+	if !found {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// JSONWebKey is a public key of a KeySet, see RFC 7517. Only the members of RSA, EC and OKP (Ed25519) public keys
+// are kept.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// KeySet is a JSON Web Key Set, as served by the JWKS endpoints of the identity providers. It is cached by JWKS as
+// fetched, so that remote caches can store it. It is a KeySource of its keys.
+type KeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// NewJSONWebKey returns the signing JSONWebKey of the RSA, ECDSA or Ed25519 public key, with the ID kid and the
+// algorithm alg, if set.
+func NewJSONWebKey(kid, alg string, key crypto.PublicKey) (JSONWebKey, error) {
+	jwk := JSONWebKey{KeyID: kid, Use: "sig", Algorithm: alg}
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		jwk.KeyType = "EC"
+		jwk.Curve = key.Curve.Params().Name
+		size := (key.Curve.Params().BitSize + 7) / 8
+		jwk.X = base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	default:
+		return JSONWebKey{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+	}
+	return jwk, nil
+}
+
+// PublicKey returns the public key of k.
+func (k JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 2 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, errX := decodeBigInt(k.X)
+		y, errY := decodeBigInt(k.Y)
+		if errX != nil || errY != nil || !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC point")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
+
+// publicKey returns the public key of k, with its key ID in the error.
+func publicKey(k JSONWebKey) (crypto.PublicKey, error) {
+	key, err := k.PublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", k.KeyID, err)
+	}
+	return key, nil
+}
+
+// decodeBigInt decodes a base64url-encoded unsigned big-endian integer.
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// find returns the signing key of s with the ID kid. An empty kid matches the only signing key of s.
+func (s KeySet) find(kid string) (JSONWebKey, bool) {
+	var match JSONWebKey
+	matches := 0
+	for _, key := range s.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if key.KeyID == kid {
+			return key, true
+		}
+		match = key
+		matches++
+	}
+	return match, kid == "" && matches == 1
+}
+
+// Key implements the KeySource interface.
+func (s KeySet) Key(_ context.Context, kid string) (crypto.PublicKey, error) {
+	key, found := s.find(kid)
+	if !found {
+		return nil, ErrKeyNotFound
+	}
+	return publicKey(key)
+}
+
+/*
+FileKeys is a KeySource reading the keys from a JSON Web Key Set file, e.g., mounted from a secret. The file is read
+again once it is modified, checked at most once per DefaultFileCheckInterval, or at once for a token signed with an
+unknown key, so that the keys can be rotated by updating the file.
+
+Example usage:
+
+	keys := jwt.NewFileKeys("/etc/secrets/jwks.json")
+	verifier := jwt.NewVerifier(keys, jwt.WithIssuer("https://auth.example.com/"))
+*/
+type FileKeys struct {
+	path string
+
+	mu        sync.Mutex
+	set       KeySet
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// NewFileKeys creates a FileKeys reading the key set of the file path.
+func NewFileKeys(path string) *FileKeys {
+	return &FileKeys{path: path}
+}
+
+// Key implements the KeySource interface.
+func (f *FileKeys) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	checked := time.Since(f.checkedAt) >= DefaultFileCheckInterval
+	if checked {
+		if err := f.reload(); err != nil {
+			return nil, err
+		}
+	}
+	if _, found := f.set.find(kid); !found && !checked {
+		// The key may have been added since the last check.
+		if err := f.reload(); err != nil {
+			return nil, err
+		}
+	}
+	return f.set.Key(ctx, kid)
+}
+
+// reload reads the key set of the file if it was modified since it was last read.
+func (f *FileKeys) reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("reading key set: %w", err)
+	}
+	f.checkedAt = time.Now()
+	if info.ModTime().Equal(f.modTime) && len(f.set.Keys) > 0 {
+		return nil
+	}
+	b, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("reading key set: %w", err)
+	}
+	var set KeySet
+	if err := json.Unmarshal(b, &set); err != nil {
+		return fmt.Errorf("decoding key set: %w", err)
+	}
+	f.set, f.modTime = set, info.ModTime()
+	return nil
+}
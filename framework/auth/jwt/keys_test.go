@@ -0,0 +1,99 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/auth/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJSONWebKey(t *testing.T) {
+	for _, key := range []interface {
+		Equal(x crypto.PublicKey) bool
+	}{&rsaKey.PublicKey, &ecKey.PublicKey, edKey.Public().(ed25519.PublicKey)} {
+		jwk, err := jwt.NewJSONWebKey("kid", "", key)
+		require.NoError(t, err)
+		assert.Equal(t, "sig", jwk.Use)
+
+		public, err := jwk.PublicKey()
+		require.NoError(t, err)
+		assert.True(t, key.Equal(public))
+	}
+
+	_, err := jwt.NewJSONWebKey("kid", "", "not a key")
+	assert.ErrorIs(t, err, jwt.ErrUnsupportedKey)
+}
+
+func TestFileKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeKeySet := func(signer *jwt.Signer, modTime time.Time) {
+		b, err := json.Marshal(signer.Keys())
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, b, 0o600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	signer := newSigner(t, jwt.SigningKey{ID: "1", Key: rsaKey}, jwt.WithRetainedKeys(0))
+	writeKeySet(signer, time.Now().Add(-time.Hour))
+	verifier := jwt.NewVerifier(jwt.NewFileKeys(path))
+	ctx := context.Background()
+
+	first, err := signer.Sign(&jwt.RegisteredClaims{})
+	require.NoError(t, err)
+	_, err = jwt.Verify[jwt.RegisteredClaims](ctx, verifier, first)
+	require.NoError(t, err)
+
+	// The file is read again for a token signed with an unknown key.
+	require.NoError(t, signer.Rotate(jwt.SigningKey{ID: "2", Key: edKey}))
+	writeKeySet(signer, time.Now())
+	second, err := signer.Sign(&jwt.RegisteredClaims{})
+	require.NoError(t, err)
+	_, err = jwt.Verify[jwt.RegisteredClaims](ctx, verifier, second)
+	require.NoError(t, err)
+	_, err = jwt.Verify[jwt.RegisteredClaims](ctx, verifier, first)
+	assert.ErrorIs(t, err, jwt.ErrKeyNotFound)
+
+	// A missing file makes the keys unavailable.
+	require.NoError(t, os.Remove(path))
+	_, err = jwt.Verify[jwt.RegisteredClaims](ctx, jwt.NewVerifier(jwt.NewFileKeys(path)), second)
+	assert.ErrorIs(t, err, jwt.ErrKeysUnavailable)
+}
+
+func TestJWKS(t *testing.T) {
+	signer := newSigner(t, jwt.SigningKey{ID: "1", Key: ecKey})
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		signer.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	verifier := jwt.NewVerifier(jwt.NewJWKS(server.URL, jwt.WithRefreshInterval(0)))
+	ctx := context.Background()
+
+	for range 3 {
+		token, err := signer.Sign(&jwt.RegisteredClaims{})
+		require.NoError(t, err)
+		_, err = jwt.Verify[jwt.RegisteredClaims](ctx, verifier, token)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), fetches.Load(), "the key set is cached")
+
+	// The key set is fetched again for a token signed with a rotated key.
+	require.NoError(t, signer.Rotate(jwt.SigningKey{ID: "2", Key: rsaKey}))
+	token, err := signer.Sign(&jwt.RegisteredClaims{})
+	require.NoError(t, err)
+	_, err = jwt.Verify[jwt.RegisteredClaims](ctx, verifier, token)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+}
@@ -0,0 +1,249 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+
+	"github.com/rs/xid"
+)
+
+// SigningKey is a private key signing tokens, with its key ID.
+type SigningKey struct {
+	// ID is the key ID, set as the "kid" header of the tokens signed, so that the verifiers find the public key.
+	ID string
+	// Key is the RSA, ECDSA or Ed25519 private key, or a crypto.Signer of a KMS holding it.
+	Key crypto.Signer
+	// Algorithm is the signing algorithm, e.g., "PS256". It defaults to RS256 for RSA keys, ES256, ES384 or ES512
+	// for ECDSA keys, by curve, and EdDSA for Ed25519 keys.
+	Algorithm string
+}
+
+// signingKey is a SigningKey checked, with its public JSONWebKey.
+type signingKey struct {
+	SigningKey
+	jwk JSONWebKey
+}
+
+/*
+Signer signs tokens with a SigningKey, rotated with Rotate. After a rotation, the previous keys are still published
+by Keys, so that the tokens they signed are verified until they expire: a Signer is a KeySource of its keys, and
+serves them as a JSON Web Key Set over HTTP.
+
+Example usage:
+
+	signer, err := jwt.NewSigner(jwt.SigningKey{ID: "2024-06", Key: privateKey},
+		jwt.WithIssuer("https://auth.example.com/"),
+		jwt.WithAudience("orders-api"),
+		jwt.WithTTL(time.Hour),
+	)
+	if err != nil {
+		// Handle error
+	}
+	token, err := signer.Sign(&UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: user.ID},
+		Roles:            user.Roles,
+	})
+
+	router.GET("/.well-known/jwks.json", gin.WrapH(signer))
+*/
+type Signer struct {
+	opts *options
+
+	mu       sync.RWMutex
+	current  signingKey
+	retained []JSONWebKey // retained are the public keys of the previous signing keys, the latest first.
+}
+
+// NewSigner creates a Signer of tokens signed with key. It returns ErrUnsupportedKey for the keys of unsupported
+// types or algorithms.
+func NewSigner(key SigningKey, opts ...Option) (*Signer, error) {
+	current, err := newSigningKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{opts: newOptions(opts), current: current}, nil
+}
+
+// Rotate makes key the signing key of s. The previous signing key is still published, up to the number of retained
+// keys. It returns ErrUnsupportedKey for the keys of unsupported types or algorithms.
+func (s *Signer) Rotate(key SigningKey) error {
+	next, err := newSigningKey(key)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	retained := append([]JSONWebKey{s.current.jwk}, s.retained...)
+	s.retained = retained[:min(len(retained), s.opts.retainedKeys)]
+	s.current = next
+	return nil
+}
+
+/*
+Sign signs a token of claims, a struct embedding RegisteredClaims. The registered claims not set are set first: the
+issuer, the audience, the issued-at and expiration times, and a unique ID.
+
+Example usage:
+
+	claims := &UserClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: user.ID}}
+	token, err := signer.Sign(claims)
+	if err != nil {
+		// Handle error
+	}
+	expiresAt := claims.ExpiresAt.Time
+*/
+func (s *Signer) Sign(claims Claims) (string, error) {
+	s.mu.RLock()
+	key := s.current
+	s.mu.RUnlock()
+
+	registered := claims.Registered()
+	now := s.opts.now()
+	if registered.Issuer == "" && len(s.opts.issuers) > 0 {
+		registered.Issuer = s.opts.issuers[0]
+	}
+	if len(registered.Audience) == 0 && len(s.opts.audiences) > 0 {
+		registered.Audience = append(Audience(nil), s.opts.audiences...)
+	}
+	if registered.IssuedAt == nil {
+		registered.IssuedAt = NewNumericDate(now)
+	}
+	if registered.ExpiresAt == nil {
+		registered.ExpiresAt = NewNumericDate(now.Add(s.opts.ttl))
+	}
+	if registered.ID == "" {
+		registered.ID = xid.New().String()
+	}
+
+	h, err := json.Marshal(header{Algorithm: key.Algorithm, KeyID: key.ID, Type: "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("jwt: encoding header: %w", err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("jwt: encoding claims: %w", err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature, err := sign(key, []byte(signed))
+	if err != nil {
+		return "", fmt.Errorf("jwt: signing token: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Keys returns the public keys of the signing key and of the retained previous keys.
+func (s *Signer) Keys() KeySet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := append([]JSONWebKey{s.current.jwk}, s.retained...)
+	return KeySet{Keys: keys}
+}
+
+// Key implements the KeySource interface, for the services signing and verifying their own tokens.
+func (s *Signer) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	return s.Keys().Key(ctx, kid)
+}
+
+// ServeHTTP serves the public keys as a JSON Web Key Set, for the JWKS endpoint of the issuer.
+func (s *Signer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.Keys())
+}
+
+// newSigningKey checks key, and sets its algorithm if not set.
+func newSigningKey(key SigningKey) (signingKey, error) {
+	if key.Key == nil {
+		return signingKey{}, fmt.Errorf("%w: no key", ErrUnsupportedKey)
+	}
+	public := key.Key.Public()
+	alg, err := keyAlgorithm(public, key.Algorithm)
+	if err != nil {
+		return signingKey{}, err
+	}
+	key.Algorithm = alg
+	jwk, err := NewJSONWebKey(key.ID, alg, public)
+	if err != nil {
+		return signingKey{}, err
+	}
+	return signingKey{SigningKey: key, jwk: jwk}, nil
+}
+
+// keyAlgorithm returns the algorithm alg if it matches the public key, or the default algorithm of the key if alg is
+// empty.
+func keyAlgorithm(public crypto.PublicKey, alg string) (string, error) {
+	var defaultAlg string
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		defaultAlg = "RS256"
+	case *ecdsa.PublicKey:
+		switch public.Curve.Params().BitSize {
+		case 256:
+			defaultAlg = "ES256"
+		case 384:
+			defaultAlg = "ES384"
+		case 521:
+			defaultAlg = "ES512"
+		default:
+			return "", fmt.Errorf("%w: curve %s", ErrUnsupportedKey, public.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+		defaultAlg = "EdDSA"
+	default:
+		return "", fmt.Errorf("%w: %T", ErrUnsupportedKey, public)
+	}
+	if alg == "" {
+		return defaultAlg, nil
+	}
+	// RSA keys sign with PKCS #1 v1.5 or PSS, and ECDSA keys with the hash of their curve.
+	matches := alg == defaultAlg || (defaultAlg == "RS256" && supported(alg) && (alg[:2] == "RS" || alg[:2] == "PS"))
+	if !matches {
+		return "", fmt.Errorf("%w: algorithm %s for %T", ErrUnsupportedKey, alg, public)
+	}
+	return alg, nil
+}
+
+// sign signs signed with key.
+func sign(key signingKey, signed []byte) ([]byte, error) {
+	if key.Algorithm == "EdDSA" {
+		return key.Key.Sign(rand.Reader, signed, crypto.Hash(0))
+	}
+
+	hash := algorithmHashes[key.Algorithm]
+	hasher := hash.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	switch key.Algorithm[:2] {
+	case "RS":
+		return key.Key.Sign(rand.Reader, digest, hash)
+	case "PS":
+		return key.Key.Sign(rand.Reader, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hash})
+	default:
+		der, err := key.Key.Sign(rand.Reader, digest, hash)
+		if err != nil {
+			return nil, err
+		}
+		// The ASN.1 signature of crypto.Signer is converted to the concatenation of r and s, each padded to the
+		// size of the curve.
+		var rs struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(der, &rs); err != nil {
+			return nil, fmt.Errorf("decoding signature: %w", err)
+		}
+		size := (ecdsaBitSize(key.Algorithm) + 7) / 8
+		signature := make([]byte, 2*size)
+		rs.R.FillBytes(signature[:size])
+		rs.S.FillBytes(signature[size:])
+		return signature, nil
+	}
+}
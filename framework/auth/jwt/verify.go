@@ -0,0 +1,239 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha256" // Register the hashes of the algorithms.
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// algorithmHashes are the hashes of the algorithms, except EdDSA.
+var algorithmHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// header is the JOSE header of a token.
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Type      string `json:"typ,omitempty"`
+}
+
+/*
+Verifier verifies the tokens signed with the keys of a KeySource, e.g., a JWKS: their signature, their expiration,
+not-before and issued-at times with the clock skew, their issuer and audience, and their revocation if a
+RevocationCheck is set. The tokens without expiration are rejected. The key of a token is the one with the ID of its
+"kid" header, so that the keys can be rotated.
+
+It is shared by the HTTP and gRPC authentication, see Verify.
+
+Example usage:
+
+	verifier := jwt.NewVerifier(jwt.NewJWKS("https://auth.example.com/.well-known/jwks.json"),
+		jwt.WithIssuer("https://auth.example.com/"),
+		jwt.WithAudience("orders-api"),
+	)
+	claims, err := jwt.Verify[UserClaims](ctx, verifier, token)
+*/
+type Verifier struct {
+	keys KeySource
+	opts *options
+}
+
+// NewVerifier creates a Verifier of the tokens signed with the keys of keys.
+func NewVerifier(keys KeySource, opts ...Option) *Verifier {
+	return &Verifier{keys: keys, opts: newOptions(opts)}
+}
+
+/*
+Verify verifies token with v, and returns its claims decoded into a C, a struct embedding RegisteredClaims. The
+tokens rejected return an error wrapping ErrInvalidToken, e.g., ErrTokenExpired. When the keys cannot be returned,
+it returns an error wrapping ErrKeysUnavailable.
+
+Example usage:
+
+	type UserClaims struct {
+		jwt.RegisteredClaims
+		Roles []string `json:"roles"`
+	}
+
+	claims, err := jwt.Verify[UserClaims](ctx, verifier, token)
+	if errors.Is(err, jwt.ErrInvalidToken) {
+		// Reject the request
+	}
+	if err != nil {
+		// Handle error
+	}
+	userID := claims.Subject
+*/
+func Verify[C any, PC interface {
+	*C
+	Claims
+}](ctx context.Context, v *Verifier, token string) (*C, error) {
+	claims := PC(new(C))
+	if err := v.verify(ctx, token, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verify verifies the signature and the claims of token, decoded into claims.
+func (v *Verifier) verify(ctx context.Context, token string, claims Claims) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return fmt.Errorf("%w: malformed header: %w", ErrInvalidToken, err)
+	}
+	if !v.opts.algorithms[h.Algorithm] {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, h.Algorithm)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature: %w", ErrInvalidToken, err)
+	}
+	key, err := v.keys.Key(ctx, h.KeyID)
+	if errors.Is(err, ErrKeyNotFound) {
+		return fmt.Errorf("%w %q", err, h.KeyID)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrKeysUnavailable, err)
+	}
+	if err := verifySignature(h.Algorithm, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return err
+	}
+
+	if err := decodeSegment(parts[1], claims); err != nil {
+		return fmt.Errorf("%w: malformed claims: %w", ErrInvalidToken, err)
+	}
+	registered := claims.Registered()
+	if err := v.validate(registered); err != nil {
+		return err
+	}
+	if v.opts.revoked != nil {
+		revoked, err := v.opts.revoked(ctx, registered)
+		if err != nil {
+			return fmt.Errorf("jwt: revocation check: %w", err)
+		}
+		if revoked {
+			return ErrTokenRevoked
+		}
+	}
+	return nil
+}
+
+// validate validates the registered claims of c: the expiration, not-before and issued-at times, with the clock
+// skew, the issuer and the audience.
+func (v *Verifier) validate(c *RegisteredClaims) error {
+	now := v.opts.now()
+	skew := v.opts.clockSkew
+	if c.ExpiresAt == nil {
+		return fmt.Errorf("%w: token has no expiration", ErrInvalidToken)
+	}
+	if !now.Before(c.ExpiresAt.Add(skew)) {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != nil && now.Add(skew).Before(c.NotBefore.Time) {
+		return fmt.Errorf("%w: token is not valid yet", ErrInvalidToken)
+	}
+	if c.IssuedAt != nil && now.Add(skew).Before(c.IssuedAt.Time) {
+		return fmt.Errorf("%w: token is issued in the future", ErrInvalidToken)
+	}
+	if len(v.opts.issuers) > 0 && !contains(v.opts.issuers, c.Issuer) {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, c.Issuer)
+	}
+	if len(v.opts.audiences) > 0 && !c.Audience.contains(v.opts.audiences) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	return nil
+}
+
+// decodeSegment decodes a base64url-encoded JSON segment of a token into v.
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// verifySignature verifies the signature of signed with key, for the algorithm alg.
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	errInvalid := fmt.Errorf("%w: invalid signature", ErrInvalidToken)
+	errMismatch := fmt.Errorf("%w: key does not match algorithm %s", ErrInvalidToken, alg)
+	if alg == "EdDSA" {
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return errMismatch
+		}
+		if !ed25519.Verify(edKey, signed, signature) {
+			return errInvalid
+		}
+		return nil
+	}
+
+	hash := algorithmHashes[alg]
+	hasher := hash.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errMismatch
+		}
+		var err error
+		if alg[0] == 'R' {
+			err = rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature)
+		} else {
+			err = rsa.VerifyPSS(rsaKey, hash, digest, signature, nil)
+		}
+		if err != nil {
+			return errInvalid
+		}
+		return nil
+	default:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve.Params().BitSize != ecdsaBitSize(alg) {
+			return errMismatch
+		}
+		// The signature is the concatenation of r and s, each padded to the size of the curve.
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errInvalid
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errInvalid
+		}
+		return nil
+	}
+}
+
+// ecdsaBitSize returns the size of the curve of an ECDSA algorithm.
+func ecdsaBitSize(alg string) int {
+	switch alg {
+	case "ES256":
+		return 256
+	case "ES384":
+		return 384
+	default:
+		return 521
+	}
+}
@@ -0,0 +1,133 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// DefaultArgon2Params are the parameters of the Argon2id hashes, unless set, following the recommendations of
+// OWASP: 64 MiB of memory, 3 iterations and 2 threads.
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Argon2Params are the parameters of the Argon2id hashes. The memory and the iterations make the hashes slower to
+// compute, and must be tuned to the resources of the service.
+type Argon2Params struct {
+	// Memory is the memory used by a hash, in KiB.
+	Memory uint32 `yaml:"memory" env:"MEMORY"`
+	// Iterations is the number of passes over the memory.
+	Iterations uint32 `yaml:"iterations" env:"ITERATIONS"`
+	// Parallelism is the number of threads computing a hash.
+	Parallelism uint8 `yaml:"parallelism" env:"PARALLELISM"`
+	// SaltLength is the length of the random salts, in bytes.
+	SaltLength uint32 `yaml:"salt_length" env:"SALT_LENGTH"`
+	// KeyLength is the length of the hashes, in bytes.
+	KeyLength uint32 `yaml:"key_length" env:"KEY_LENGTH"`
+}
+
+// withDefaults returns p, with the parameters not set set to the ones of DefaultArgon2Params.
+func (p Argon2Params) withDefaults() Argon2Params {
+	if p.Memory == 0 {
+		p.Memory = DefaultArgon2Params.Memory
+	}
+	if p.Iterations == 0 {
+		p.Iterations = DefaultArgon2Params.Iterations
+	}
+	if p.Parallelism == 0 {
+		p.Parallelism = DefaultArgon2Params.Parallelism
+	}
+	if p.SaltLength == 0 {
+		p.SaltLength = DefaultArgon2Params.SaltLength
+	}
+	if p.KeyLength == 0 {
+		p.KeyLength = DefaultArgon2Params.KeyLength
+	}
+	return p
+}
+
+/*
+Argon2idHasher is a Hasher of Argon2id hashes, the preferred algorithm, encoded in the PHC string format:
+"$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>".
+
+Example usage:
+
+	hasher := password.NewArgon2id(password.Argon2Params{Memory: 128 * 1024})
+	hash, err := hasher.Hash(password)
+*/
+type Argon2idHasher struct {
+	params Argon2Params
+}
+
+// NewArgon2id creates an Argon2idHasher with params. The parameters not set default to the ones of
+// DefaultArgon2Params.
+func NewArgon2id(params Argon2Params) *Argon2idHasher {
+	return &Argon2idHasher{params: params.withDefaults()}
+}
+
+// Hash implements the Hasher interface.
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("password: generating salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify implements the Hasher interface. The hashes computed with other parameters need a rehash.
+func (h *Argon2idHasher) Verify(password, hash string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+	computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, computed) != 1 {
+		return false, ErrMismatch
+	}
+	return params != h.params, nil
+}
+
+// decodeArgon2id decodes the parameters, the salt and the key of an encoded Argon2id hash.
+func decodeArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != Argon2id {
+		return Argon2Params{}, nil, nil, ErrInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	if version != argon2.Version {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: argon2id version %d", ErrUnsupportedAlgorithm, version)
+	}
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	if params.Iterations == 0 || params.Parallelism == 0 || len(key) == 0 {
+		return Argon2Params{}, nil, nil, ErrInvalidHash
+	}
+	params.SaltLength, params.KeyLength = uint32(len(salt)), uint32(len(key))
+	return params, salt, key, nil
+}
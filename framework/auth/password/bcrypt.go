@@ -0,0 +1,58 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultBcryptCost is the cost of the bcrypt hashes, unless set.
+const DefaultBcryptCost = 12
+
+/*
+BcryptHasher is a Hasher of bcrypt hashes, e.g., "$2a$12$<salt and hash>", for the systems not supporting Argon2id.
+The passwords longer than 72 bytes are rejected, since bcrypt ignores the following bytes.
+
+Example usage:
+
+	hasher := password.NewBcrypt(12)
+	hash, err := hasher.Hash(password)
+*/
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcrypt creates a BcryptHasher with cost, between bcrypt.MinCost and bcrypt.MaxCost. It defaults to
+// DefaultBcryptCost.
+func NewBcrypt(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = DefaultBcryptCost
+	}
+	return &BcryptHasher{cost: cost}
+}
+
+// Hash implements the Hasher interface.
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", fmt.Errorf("password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// Verify implements the Hasher interface. The hashes computed with another cost need a rehash.
+func (h *BcryptHasher) Verify(password, hash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, ErrMismatch
+	}
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	return cost != h.cost, nil
+}
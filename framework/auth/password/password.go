@@ -0,0 +1,145 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Algorithms of the hashes.
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+var (
+	// ErrMismatch is returned by Verify when the password does not match the hash.
+	ErrMismatch = errors.New("password: password does not match")
+	// ErrInvalidHash is returned by Verify for the malformed hashes.
+	ErrInvalidHash = errors.New("password: invalid hash")
+	// ErrUnsupportedAlgorithm is returned for the hashes or the configurations of unknown algorithms.
+	ErrUnsupportedAlgorithm = errors.New("password: unsupported algorithm")
+)
+
+/*
+Hasher hashes the passwords, and verifies them against their hashes. The hashes are encoded with their algorithm,
+its version and its parameters, e.g., "$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>", so that the passwords hashed
+with previous parameters are still verified.
+
+Example usage:
+
+	hash, err := hasher.Hash(password)
+	if err != nil {
+		// Handle error
+	}
+
+	needsRehash, err := hasher.Verify(password, user.PasswordHash)
+	if errors.Is(err, password.ErrMismatch) {
+		// Reject the login
+	}
+	if err != nil {
+		// Handle error
+	}
+	if needsRehash {
+		// Hash the password again with the current parameters, and store the new hash
+	}
+*/
+type Hasher interface {
+	// Hash returns the encoded hash of password, with a random salt.
+	Hash(password string) (string, error)
+	// Verify returns nil if password matches hash, or ErrMismatch. needsRehash reports whether hash was computed
+	// with another algorithm or other parameters than the ones of the Hasher, so that the password, known once
+	// verified, should be hashed again.
+	Verify(password, hash string) (needsRehash bool, err error)
+}
+
+/*
+Config configures the Hasher of New, e.g., loaded with the config package.
+
+Example usage:
+
+	type AppConfig struct {
+		Password password.Config `yaml:"password" env:"PASSWORD_"`
+	}
+*/
+type Config struct {
+	// Algorithm is the algorithm of the new hashes, Argon2id or Bcrypt. It defaults to Argon2id.
+	Algorithm string `yaml:"algorithm" env:"ALGORITHM"`
+	// Argon2 are the parameters of the Argon2id hashes.
+	Argon2 Argon2Params `yaml:"argon2" env:"ARGON2_"`
+	// BcryptCost is the cost of the bcrypt hashes, between 4 and 31. It defaults to DefaultBcryptCost.
+	BcryptCost int `yaml:"bcrypt_cost" env:"BCRYPT_COST"`
+}
+
+// hasher hashes the passwords with a preferred Hasher, and verifies the hashes of every algorithm.
+type hasher struct {
+	algorithm string
+	argon2    *Argon2idHasher
+	bcrypt    *BcryptHasher
+}
+
+/*
+New returns a Hasher hashing the passwords with the algorithm and the parameters of cfg, and verifying the hashes of
+every algorithm, so that the algorithm or the parameters can be changed: the hashes of another algorithm or other
+parameters need a rehash. The zero parameters default to the ones of DefaultArgon2Params and DefaultBcryptCost.
+
+Example usage:
+
+	hasher, err := password.New(cfg.Password)
+	if err != nil {
+		// Handle error
+	}
+*/
+func New(cfg Config) (Hasher, error) {
+	h := &hasher{
+		algorithm: strings.ToLower(cfg.Algorithm),
+		argon2:    NewArgon2id(cfg.Argon2),
+		bcrypt:    NewBcrypt(cfg.BcryptCost),
+	}
+	if h.algorithm == "" {
+		h.algorithm = Argon2id
+	}
+	if h.algorithm != Argon2id && h.algorithm != Bcrypt {
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedAlgorithm, cfg.Algorithm)
+	}
+	return h, nil
+}
+
+// Hash implements the Hasher interface.
+func (h *hasher) Hash(password string) (string, error) {
+	if h.algorithm == Bcrypt {
+		return h.bcrypt.Hash(password)
+	}
+	return h.argon2.Hash(password)
+}
+
+// Verify implements the Hasher interface.
+func (h *hasher) Verify(password, hash string) (bool, error) {
+	var verify Hasher
+	algorithm := algorithmOf(hash)
+	switch algorithm {
+	case Argon2id:
+		verify = h.argon2
+	case Bcrypt:
+		verify = h.bcrypt
+	default:
+		return false, ErrUnsupportedAlgorithm
+	}
+	needsRehash, err := verify.Verify(password, hash)
+	if err != nil {
+		return false, err
+	}
+	return needsRehash || algorithm != h.algorithm, nil
+}
+
+// algorithmOf returns the algorithm of an encoded hash, or "" if it is unknown.
+func algorithmOf(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return Argon2id
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return Bcrypt
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,125 @@
+package password_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/auth/password"
+	"github.com/kittipat1413/go-common/framework/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fastArgon2 are cheap parameters, so that the tests run fast.
+var fastArgon2 = password.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestHashers(t *testing.T) {
+	tests := []struct {
+		name           string
+		hasher         password.Hasher
+		expectedPrefix string
+	}{
+		{name: "argon2id", hasher: password.NewArgon2id(fastArgon2), expectedPrefix: "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{name: "bcrypt", hasher: password.NewBcrypt(bcrypt.MinCost), expectedPrefix: "$2a$04$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.hasher.Hash("correct horse battery staple")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(hash, tt.expectedPrefix), hash)
+
+			other, err := tt.hasher.Hash("correct horse battery staple")
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, other, "the hashes are salted")
+
+			needsRehash, err := tt.hasher.Verify("correct horse battery staple", hash)
+			require.NoError(t, err)
+			assert.False(t, needsRehash)
+
+			_, err = tt.hasher.Verify("wrong password", hash)
+			assert.ErrorIs(t, err, password.ErrMismatch)
+
+			_, err = tt.hasher.Verify("correct horse battery staple", hash[:len(hash)-10])
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, password.ErrMismatch)
+		})
+	}
+}
+
+func TestArgon2id_NeedsRehash(t *testing.T) {
+	hash, err := password.NewArgon2id(fastArgon2).Hash("secret")
+	require.NoError(t, err)
+
+	stronger := fastArgon2
+	stronger.Iterations = 2
+	needsRehash, err := password.NewArgon2id(stronger).Verify("secret", hash)
+	require.NoError(t, err, "the hashes of other parameters are verified")
+	assert.True(t, needsRehash)
+}
+
+func TestBcrypt_NeedsRehash(t *testing.T) {
+	hash, err := password.NewBcrypt(bcrypt.MinCost).Hash("secret")
+	require.NoError(t, err)
+
+	needsRehash, err := password.NewBcrypt(bcrypt.MinCost+1).Verify("secret", hash)
+	require.NoError(t, err)
+	assert.True(t, needsRehash)
+
+	_, err = password.NewBcrypt(bcrypt.MinCost).Hash(strings.Repeat("a", 73))
+	assert.Error(t, err, "the passwords longer than 72 bytes are rejected")
+}
+
+func TestNew(t *testing.T) {
+	argon2Hasher, err := password.New(password.Config{Argon2: fastArgon2, BcryptCost: bcrypt.MinCost})
+	require.NoError(t, err)
+	bcryptHasher, err := password.New(password.Config{Algorithm: password.Bcrypt, Argon2: fastArgon2, BcryptCost: bcrypt.MinCost})
+	require.NoError(t, err)
+
+	argon2Hash, err := argon2Hasher.Hash("secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(argon2Hash, "$argon2id$"), "the algorithm defaults to argon2id")
+	bcryptHash, err := bcryptHasher.Hash("secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(bcryptHash, "$2a$"))
+
+	// Both hashers verify the hashes of both algorithms, and rehash the ones of the other algorithm.
+	needsRehash, err := argon2Hasher.Verify("secret", argon2Hash)
+	require.NoError(t, err)
+	assert.False(t, needsRehash)
+	needsRehash, err = argon2Hasher.Verify("secret", bcryptHash)
+	require.NoError(t, err)
+	assert.True(t, needsRehash)
+	needsRehash, err = bcryptHasher.Verify("secret", argon2Hash)
+	require.NoError(t, err)
+	assert.True(t, needsRehash)
+	_, err = bcryptHasher.Verify("wrong", argon2Hash)
+	assert.ErrorIs(t, err, password.ErrMismatch)
+
+	_, err = argon2Hasher.Verify("secret", "$pbkdf2-sha256$29000$salt$hash")
+	assert.ErrorIs(t, err, password.ErrUnsupportedAlgorithm)
+	_, err = password.New(password.Config{Algorithm: "md5"})
+	assert.ErrorIs(t, err, password.ErrUnsupportedAlgorithm)
+}
+
+func TestConfig_Load(t *testing.T) {
+	env := map[string]string{
+		"APP_PASSWORD_ALGORITHM":          "bcrypt",
+		"APP_PASSWORD_BCRYPT_COST":        "5",
+		"APP_PASSWORD_ARGON2_MEMORY":      "2048",
+		"APP_PASSWORD_ARGON2_PARALLELISM": "4",
+	}
+	var cfg struct {
+		Password password.Config `yaml:"password" env:"PASSWORD_"`
+	}
+	err := config.Load(&cfg, config.WithEnvPrefix("APP_"), config.WithLookupEnv(func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, password.Config{
+		Algorithm:  password.Bcrypt,
+		Argon2:     password.Argon2Params{Memory: 2048, Parallelism: 4},
+		BcryptCost: 5,
+	}, cfg.Password)
+}
@@ -0,0 +1,476 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// Defaults of the batcher, unless set with the options.
+const (
+	DefaultMaxSize     = 100
+	DefaultMaxWait     = time.Second
+	DefaultConcurrency = 1
+	DefaultQueueSize   = 1000
+	DefaultName        = "default"
+)
+
+var (
+	// ErrClosed is returned by Add and Flush once the batcher is shut down.
+	ErrClosed = errors.New("batch: the batcher is closed")
+	// ErrQueueFull is returned by TryAdd when the queue is full.
+	ErrQueueFull = errors.New("batch: the queue is full")
+	// ErrPanic is wrapped by the errors the panics of the handler are converted to.
+	ErrPanic = errors.New("batch: handler panicked")
+	// ErrDiscarded is passed to the error handler with the batches left when the drain times out.
+	ErrDiscarded = errors.New("batch: batch discarded on shutdown")
+)
+
+// Handler handles a batch of items, e.g., inserts them in a single statement. It must return when ctx is done.
+type Handler[T any] func(ctx context.Context, items []T) error
+
+// Sizer is implemented by the items reporting their size in bytes, see WithMaxBytes. The size of the []byte and
+// string items is their length.
+type Sizer interface {
+	Size() int
+}
+
+// options holds configuration options for the batcher.
+type options struct {
+	name        string                               // name is the field of the logs.
+	maxSize     int                                  // maxSize is the number of items flushing a batch.
+	maxWait     time.Duration                        // maxWait is the time after the first item of a batch flushing it.
+	maxBytes    int                                  // maxBytes is the size of the items flushing a batch, or zero.
+	concurrency int                                  // concurrency is the number of batches handled concurrently.
+	queueSize   int                                  // queueSize is the number of items waiting to be batched.
+	logger      logger.Logger                        // logger logs the panics and the errors, or the logger of the context if nil.
+	onError     func(ctx context.Context, err error) // onError is called with the errors of the handler.
+}
+
+// Option specifies batcher configuration options.
+type Option func(*options)
+
+// WithName sets the name of the batcher, logged with its errors. It defaults to DefaultName.
+func WithName(name string) Option {
+	return func(opts *options) {
+		if name != "" {
+			opts.name = name
+		}
+	}
+}
+
+// WithMaxSize sets the maximum number of items of a batch, flushed as soon as it is reached. It defaults to
+// DefaultMaxSize.
+func WithMaxSize(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.maxSize = n
+		}
+	}
+}
+
+// WithMaxWait sets the maximum time a batch waits for more items after its first one, before it is flushed
+// anyway. It defaults to DefaultMaxWait.
+func WithMaxWait(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.maxWait = d
+		}
+	}
+}
+
+// WithMaxBytes sets the maximum size of the items of a batch, see Sizer, e.g., the maximum size of a request. A
+// batch is flushed before an item would make it exceed n, so that only the items larger than n alone exceed it.
+// There is no maximum by default.
+func WithMaxBytes(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.maxBytes = n
+		}
+	}
+}
+
+// WithConcurrency sets the number of batches handled concurrently. It defaults to DefaultConcurrency, which
+// handles the batches in order.
+func WithConcurrency(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.concurrency = n
+		}
+	}
+}
+
+// WithQueueSize sets the number of items waiting to be batched while the handlers are busy, beyond which Add
+// blocks and TryAdd fails. It defaults to DefaultQueueSize; zero hands the items over to the batcher directly.
+func WithQueueSize(n int) Option {
+	return func(opts *options) {
+		if n >= 0 {
+			opts.queueSize = n
+		}
+	}
+}
+
+// WithLogger sets the logger of the panics and, by default, of the errors of the handler. It defaults to the
+// logger of the background context, see logger.FromContext.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		if l != nil {
+			opts.logger = l
+		}
+	}
+}
+
+// WithErrorHandler sets the function called with the errors of the handler, including its panics, and with the
+// batches discarded on shutdown, e.g., to report them. It defaults to logging them at error level.
+func WithErrorHandler(onError func(ctx context.Context, err error)) Option {
+	return func(opts *options) {
+		if onError != nil {
+			opts.onError = onError
+		}
+	}
+}
+
+// entry is an item, or a flush request, waiting in the queue.
+type entry[T any] struct {
+	item  T
+	flush chan []chan struct{} // flush receives the batches in flight, if the entry is a flush request.
+}
+
+// batch is a batch of items handed over to the handlers.
+type batch[T any] struct {
+	items []T
+	done  chan struct{} // done is closed once the batch is handled.
+}
+
+/*
+Batcher collects items and hands them over to a handler in batches, e.g., to insert rows or publish messages in
+bulk. A batch is flushed as soon as it has WithMaxSize items, or WithMaxBytes bytes, or WithMaxWait after its first
+item. The batches are handled by WithConcurrency handlers; while they are all busy, the items wait in a bounded
+queue, and Add blocks once it is full, so that the producers slow down to the pace of the handler.
+
+The batcher is a lifecycle.Runnable and lifecycle.Shutdowner: Shutdown stops accepting items, flushes the items
+queued and the last batch, and waits for the handlers to finish. If they do not finish in time, the handlers are
+canceled, and the batches left are discarded.
+
+Example usage:
+
+	batcher := batch.New(func(ctx context.Context, events []AuditEvent) error {
+		return repo.InsertAuditEvents(ctx, events)
+	},
+		batch.WithName("audit-events"),
+		batch.WithMaxSize(500),
+		batch.WithMaxWait(2*time.Second),
+		batch.WithConcurrency(4),
+	)
+	manager.Add("audit-events", batcher, lifecycle.WithTimeout(30*time.Second))
+
+	err := batcher.Add(ctx, event)
+*/
+type Batcher[T any] struct {
+	handler Handler[T]
+	opts    options
+	queue   chan entry[T]
+	batches chan batch[T]
+	pending atomic.Int64 // pending is the number of items added and not handled yet.
+
+	ctx    context.Context // ctx is the context of the handlers, canceled when the drain times out.
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	closed  bool
+	closing chan struct{}  // closing is closed once the batcher stops accepting items.
+	adders  sync.WaitGroup // adders are the calls to Add and Flush in progress.
+	workers sync.WaitGroup
+	done    chan struct{} // done is closed once the handlers returned.
+}
+
+// New creates a batcher handing the batches over to handler, and starts it.
+func New[T any](handler Handler[T], opts ...Option) *Batcher[T] {
+	o := options{
+		name:        DefaultName,
+		maxSize:     DefaultMaxSize,
+		maxWait:     DefaultMaxWait,
+		concurrency: DefaultConcurrency,
+		queueSize:   DefaultQueueSize,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.onError == nil {
+		o.onError = func(ctx context.Context, err error) {
+			loggerOrContext(ctx, o.logger).Error(ctx, "Batch failed", err, logger.Fields{"batcher": o.name})
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &Batcher[T]{
+		handler: handler,
+		opts:    o,
+		queue:   make(chan entry[T], o.queueSize),
+		batches: make(chan batch[T]),
+		ctx:     ctx,
+		cancel:  cancel,
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go b.collect()
+	b.workers.Add(o.concurrency)
+	for i := 0; i < o.concurrency; i++ {
+		go b.work()
+	}
+	go func() {
+		b.workers.Wait()
+		close(b.done)
+	}()
+	return b
+}
+
+// Add queues item, blocking while the queue is full, and returns once it is queued, or with the error of ctx if it
+// is done first, or ErrClosed if the batcher is shut down. The errors of the handler are not returned, but passed
+// to the error handler.
+func (b *Batcher[T]) Add(ctx context.Context, item T) error {
+	return b.enqueue(ctx, entry[T]{item: item}, true)
+}
+
+// TryAdd queues item if the queue is not full, and returns ErrQueueFull otherwise, e.g., to drop the items rather
+// than slow the producer down.
+func (b *Batcher[T]) TryAdd(item T) error {
+	return b.enqueue(context.Background(), entry[T]{item: item}, false)
+}
+
+// Flush flushes the items added before it without waiting for the batch to be full, and returns once they are
+// handled, or with the error of ctx if it is done first.
+func (b *Batcher[T]) Flush(ctx context.Context) error {
+	flush := make(chan []chan struct{}, 1)
+	if err := b.enqueue(ctx, entry[T]{flush: flush}, true); err != nil {
+		return err
+	}
+	var inflight []chan struct{}
+	select {
+	case inflight = <-flush:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	for _, done := range inflight {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Len returns the number of items added and not handled yet.
+func (b *Batcher[T]) Len() int {
+	return int(b.pending.Load())
+}
+
+func (b *Batcher[T]) enqueue(ctx context.Context, e entry[T], wait bool) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	b.adders.Add(1)
+	b.mu.Unlock()
+	defer b.adders.Done()
+
+	if e.flush == nil {
+		b.pending.Add(1)
+	}
+	var err error
+	if !wait {
+		select {
+		case b.queue <- e:
+		default:
+			err = ErrQueueFull
+		}
+	} else {
+		select {
+		case b.queue <- e:
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-b.closing:
+			err = ErrClosed
+		}
+	}
+	if err != nil && e.flush == nil {
+		b.pending.Add(-1)
+	}
+	return err
+}
+
+// Run blocks until the batcher is shut down, or ctx is done, in which case the handlers are canceled and the
+// batches left are discarded. It returns nil.
+func (b *Batcher[T]) Run(ctx context.Context) error {
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		b.cancel()
+		b.close()
+		<-b.done
+	}
+	return nil
+}
+
+// Shutdown stops accepting items, flushes the items queued, and returns once they are handled. If ctx is done
+// first, the handlers are canceled, the batches left are discarded, and the error of ctx is returned.
+func (b *Batcher[T]) Shutdown(ctx context.Context) error {
+	b.close()
+	select {
+	case <-b.done:
+		b.cancel()
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+// close stops accepting items, and closes the queue once the calls to Add in progress returned.
+func (b *Batcher[T]) close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	close(b.closing)
+	b.mu.Unlock()
+
+	b.adders.Wait()
+	close(b.queue)
+}
+
+// collect batches the items of the queue until it is closed, and hands the batches over to the workers.
+func (b *Batcher[T]) collect() {
+	defer close(b.batches)
+	var (
+		items    []T
+		size     int
+		timeout  <-chan time.Time
+		timer    *time.Timer
+		inflight []chan struct{}
+	)
+	flush := func() {
+		if len(items) == 0 {
+			return
+		}
+		timer.Stop()
+		timeout = nil
+		done := make(chan struct{})
+		// Blocks while the workers are busy, so that the queue fills up.
+		b.batches <- batch[T]{items: items, done: done}
+		inflight = append(running(inflight), done)
+		items, size = nil, 0
+	}
+	for {
+		select {
+		case e, ok := <-b.queue:
+			if !ok {
+				flush()
+				return
+			}
+			if e.flush != nil {
+				flush()
+				inflight = running(inflight)
+				e.flush <- append([]chan struct{}(nil), inflight...)
+				continue
+			}
+			itemSize := sizeOf(e.item)
+			if b.opts.maxBytes > 0 && size+itemSize > b.opts.maxBytes {
+				flush()
+			}
+			if len(items) == 0 {
+				items = make([]T, 0, b.opts.maxSize)
+				timer = time.NewTimer(b.opts.maxWait)
+				timeout = timer.C
+			}
+			items = append(items, e.item)
+			size += itemSize
+			if len(items) >= b.opts.maxSize || (b.opts.maxBytes > 0 && size >= b.opts.maxBytes) {
+				flush()
+			}
+		case <-timeout:
+			flush()
+		}
+	}
+}
+
+// work handles the batches until they are all handed over.
+func (b *Batcher[T]) work() {
+	defer b.workers.Done()
+	for bt := range b.batches {
+		if b.ctx.Err() != nil {
+			b.opts.onError(context.WithoutCancel(b.ctx), fmt.Errorf("%w: %d items", ErrDiscarded, len(bt.items)))
+		} else if err := b.call(b.ctx, bt.items); err != nil {
+			b.opts.onError(context.WithoutCancel(b.ctx), err)
+		}
+		b.pending.Add(-int64(len(bt.items)))
+		close(bt.done)
+	}
+}
+
+// call calls the handler with items, converting its panic to an error.
+func (b *Batcher[T]) call(ctx context.Context, items []T) (err error) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		if recoveredErr, ok := recovered.(error); ok {
+			err = fmt.Errorf("%w: %w", ErrPanic, recoveredErr)
+		} else {
+			err = fmt.Errorf("%w: %v", ErrPanic, recovered)
+		}
+		loggerOrContext(ctx, b.opts.logger).Error(ctx, "Batch handler panicked", err, logger.Fields{
+			"batcher":                           b.opts.name,
+			logger.DefaultPanicKey:              fmt.Sprintf("%v", recovered),
+			logger.DefaultSJsonFmtStackTraceKey: string(debug.Stack()),
+		})
+	}()
+	return b.handler(ctx, items)
+}
+
+// running returns the channels of inflight not closed yet, i.e., of the batches still being handled.
+func running(inflight []chan struct{}) []chan struct{} {
+	n := 0
+	for _, done := range inflight {
+		select {
+		case <-done:
+		default:
+			inflight[n] = done
+			n++
+		}
+	}
+	return inflight[:n]
+}
+
+// sizeOf returns the size of item in bytes, see Sizer.
+func sizeOf(item any) int {
+	switch v := item.(type) {
+	case Sizer:
+		return v.Size()
+	case []byte:
+		return len(v)
+	case string:
+		return len(v)
+	default:
+		return 0
+	}
+}
+
+// loggerOrContext returns l, or the logger of ctx if l is nil.
+func loggerOrContext(ctx context.Context, l logger.Logger) logger.Logger {
+	if l != nil {
+		return l
+	}
+	return logger.FromContext(ctx)
+}
@@ -0,0 +1,260 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/kittipat1413/go-common/framework/batch"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// recorder records the batches handled, and the errors.
+type recorder[T any] struct {
+	mu      sync.Mutex
+	batches [][]T
+	errs    []error
+}
+
+func (r *recorder[T]) handle(ctx context.Context, items []T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, items)
+	return nil
+}
+
+func (r *recorder[T]) record(ctx context.Context, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, err)
+}
+
+func (r *recorder[T]) handled() [][]T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]T(nil), r.batches...)
+}
+
+func (r *recorder[T]) errors() []error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]error(nil), r.errs...)
+}
+
+func TestBatcher_Flushes(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	t.Run("by size", func(t *testing.T) {
+		r := &recorder[int]{}
+		batcher := batch.New(r.handle, batch.WithMaxSize(3), batch.WithMaxWait(time.Hour))
+		for i := 1; i <= 7; i++ {
+			require.NoError(t, batcher.Add(context.Background(), i))
+		}
+		require.Eventually(t, func() bool { return len(r.handled()) == 2 }, time.Second, time.Millisecond)
+		assert.Equal(t, 1, batcher.Len())
+		require.NoError(t, batcher.Shutdown(context.Background()))
+		assert.Equal(t, [][]int{{1, 2, 3}, {4, 5, 6}, {7}}, r.handled())
+		assert.Zero(t, batcher.Len())
+	})
+
+	t.Run("by time", func(t *testing.T) {
+		r := &recorder[int]{}
+		batcher := batch.New(r.handle, batch.WithMaxWait(20*time.Millisecond))
+		start := time.Now()
+		require.NoError(t, batcher.Add(context.Background(), 1))
+		require.NoError(t, batcher.Add(context.Background(), 2))
+		require.Eventually(t, func() bool { return len(r.handled()) == 1 }, time.Second, time.Millisecond)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		require.NoError(t, batcher.Add(context.Background(), 3))
+		require.Eventually(t, func() bool { return len(r.handled()) == 2 }, time.Second, time.Millisecond)
+		require.NoError(t, batcher.Shutdown(context.Background()))
+		assert.Equal(t, [][]int{{1, 2}, {3}}, r.handled())
+	})
+
+	t.Run("by bytes", func(t *testing.T) {
+		r := &recorder[string]{}
+		batcher := batch.New(r.handle, batch.WithMaxBytes(10), batch.WithMaxWait(time.Hour))
+		for _, item := range []string{"aaaa", "bbbb", "cccc", "dddddddddddd", "ee", "ffffffff"} {
+			require.NoError(t, batcher.Add(context.Background(), item))
+		}
+		require.NoError(t, batcher.Shutdown(context.Background()))
+		assert.Equal(t, [][]string{{"aaaa", "bbbb"}, {"cccc"}, {"dddddddddddd"}, {"ee", "ffffffff"}}, r.handled())
+	})
+
+	t.Run("on demand", func(t *testing.T) {
+		var handled atomic.Int32
+		batcher := batch.New(func(ctx context.Context, items []int) error {
+			time.Sleep(10 * time.Millisecond)
+			handled.Add(int32(len(items)))
+			return nil
+		}, batch.WithMaxSize(2), batch.WithMaxWait(time.Hour), batch.WithConcurrency(2))
+		for i := 0; i < 5; i++ {
+			require.NoError(t, batcher.Add(context.Background(), i))
+		}
+		require.NoError(t, batcher.Flush(context.Background()))
+		assert.Equal(t, int32(5), handled.Load())
+		assert.Zero(t, batcher.Len())
+		require.NoError(t, batcher.Shutdown(context.Background()))
+	})
+}
+
+type event struct {
+	payload string
+}
+
+func (e event) Size() int {
+	return len(e.payload)
+}
+
+func TestBatcher_Sizer(t *testing.T) {
+	r := &recorder[event]{}
+	batcher := batch.New(r.handle, batch.WithMaxBytes(5), batch.WithMaxWait(time.Hour))
+	for _, payload := range []string{"abc", "de", "fgh"} {
+		require.NoError(t, batcher.Add(context.Background(), event{payload: payload}))
+	}
+	require.NoError(t, batcher.Shutdown(context.Background()))
+	assert.Equal(t, [][]event{{{"abc"}, {"de"}}, {{"fgh"}}}, r.handled())
+}
+
+func TestBatcher_BoundsConcurrency(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	var handled atomic.Int32
+	batcher := batch.New(func(ctx context.Context, items []int) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			max := maxRunning.Load()
+			if n <= max || maxRunning.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		handled.Add(int32(len(items)))
+		return nil
+	}, batch.WithMaxSize(2), batch.WithConcurrency(3))
+	for i := 0; i < 40; i++ {
+		require.NoError(t, batcher.Add(context.Background(), i))
+	}
+	require.NoError(t, batcher.Shutdown(context.Background()))
+	assert.Equal(t, int32(3), maxRunning.Load())
+	assert.Equal(t, int32(40), handled.Load())
+}
+
+func TestBatcher_Backpressure(t *testing.T) {
+	release := make(chan struct{})
+	batcher := batch.New(func(ctx context.Context, items []int) error {
+		<-release
+		return nil
+	}, batch.WithMaxSize(1), batch.WithQueueSize(1))
+
+	// The handler holds the 1st item, the collector the 2nd, and the queue the 3rd.
+	for i := 0; i < 3; i++ {
+		require.NoError(t, batcher.Add(context.Background(), i))
+	}
+	assert.ErrorIs(t, batcher.TryAdd(3), batch.ErrQueueFull)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, batcher.Add(ctx, 3), context.DeadlineExceeded)
+	assert.Equal(t, 3, batcher.Len())
+
+	close(release)
+	require.NoError(t, batcher.Shutdown(context.Background()))
+	assert.Zero(t, batcher.Len())
+}
+
+func TestBatcher_Errors(t *testing.T) {
+	t.Run("reports the errors of the handler", func(t *testing.T) {
+		r := &recorder[int]{}
+		errHandler := errors.New("insert failed")
+		batcher := batch.New(func(ctx context.Context, items []int) error { return errHandler }, batch.WithErrorHandler(r.record))
+		require.NoError(t, batcher.Add(context.Background(), 1))
+		require.NoError(t, batcher.Shutdown(context.Background()))
+		assert.Equal(t, []error{errHandler}, r.errors())
+	})
+
+	t.Run("logs the errors by default", func(t *testing.T) {
+		log, logs := logger.NewTestLogger()
+		batcher := batch.New(func(ctx context.Context, items []int) error {
+			return errors.New("insert failed")
+		}, batch.WithName("audit-events"), batch.WithLogger(log))
+		require.NoError(t, batcher.Add(context.Background(), 1))
+		require.NoError(t, batcher.Shutdown(context.Background()))
+		logs.AssertLogged(t, logger.ERROR, "Batch failed", logger.HasField("batcher", "audit-events"))
+	})
+
+	t.Run("recovers the panics", func(t *testing.T) {
+		log, logs := logger.NewTestLogger()
+		r := &recorder[int]{}
+		batcher := batch.New(func(ctx context.Context, items []int) error {
+			if items[0] == 1 {
+				panic("boom")
+			}
+			return r.handle(ctx, items)
+		}, batch.WithMaxSize(1), batch.WithLogger(log), batch.WithErrorHandler(r.record))
+		require.NoError(t, batcher.Add(context.Background(), 1))
+		require.NoError(t, batcher.Add(context.Background(), 2))
+		require.NoError(t, batcher.Shutdown(context.Background()))
+
+		assert.Equal(t, [][]int{{2}}, r.handled())
+		errs := r.errors()
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], batch.ErrPanic)
+		entries := logs.Find(logger.ERROR, "Batch handler panicked")
+		require.Len(t, entries, 1)
+		assert.Contains(t, entries[0].Fields[logger.DefaultSJsonFmtStackTraceKey], "batch_test.go")
+	})
+}
+
+func TestBatcher_Shutdown(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	t.Run("cancels the handlers and discards the batches left when the drain times out", func(t *testing.T) {
+		r := &recorder[int]{}
+		started := make(chan struct{})
+		batcher := batch.New(func(ctx context.Context, items []int) error {
+			if items[0] == 1 {
+				close(started)
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			t.Error("the batch left must be discarded")
+			return nil
+		}, batch.WithMaxSize(1), batch.WithErrorHandler(r.record))
+		require.NoError(t, batcher.Add(context.Background(), 1))
+		require.NoError(t, batcher.Add(context.Background(), 2))
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, batcher.Shutdown(ctx), context.DeadlineExceeded)
+		require.NoError(t, batcher.Run(context.Background()))
+
+		errs := r.errors()
+		require.Len(t, errs, 2)
+		assert.ErrorIs(t, errs[0], context.Canceled)
+		assert.ErrorIs(t, errs[1], batch.ErrDiscarded)
+		assert.True(t, strings.HasSuffix(errs[1].Error(), "1 items"))
+	})
+
+	t.Run("stops when the context of Run is done", func(t *testing.T) {
+		r := &recorder[int]{}
+		batcher := batch.New(r.handle)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- batcher.Run(ctx) }()
+		cancel()
+		require.NoError(t, <-done)
+		assert.ErrorIs(t, batcher.Add(context.Background(), 1), batch.ErrClosed)
+		assert.ErrorIs(t, batcher.TryAdd(1), batch.ErrClosed)
+		assert.ErrorIs(t, batcher.Flush(context.Background()), batch.ErrClosed)
+	})
+}
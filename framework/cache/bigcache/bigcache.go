@@ -0,0 +1,287 @@
+package bigcache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	cache "github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/internal/flight"
+	"github.com/kittipat1413/go-common/util/pointer"
+	syncutil "github.com/kittipat1413/go-common/util/sync"
+)
+
+const (
+	// NoExpireDuration makes items persist until bigcache evicts them, after its LifeWindow.
+	NoExpireDuration      time.Duration = -1
+	defaultExpireDuration time.Duration = NoExpireDuration
+	// headerSize is the size of the expiration stored before every value.
+	headerSize = 8
+)
+
+/*
+Client is the subset of bigcache operations used by the cache. A *bigcache.BigCache of
+github.com/allegro/bigcache/v3 implements it:
+
+	client, err := bigcache.New(ctx, bigcache.DefaultConfig(time.Hour))
+	if err != nil {
+		// Handle error
+	}
+	users := bigcachecache.New[User](client, bigcachecache.WithMissErrors[User](bigcache.ErrEntryNotFound))
+*/
+type Client interface {
+	// Get returns the value of key, or a miss error if it does not exist.
+	Get(key string) ([]byte, error)
+	// Set stores value under key.
+	Set(key string, value []byte) error
+	// Delete removes key, and may return a miss error if it does not exist.
+	Delete(key string) error
+	// Reset removes all keys.
+	Reset() error
+}
+
+type config[T any] struct {
+	defaultExpireDuration time.Duration
+	codec                 cache.Codec[T]
+	missErrors            []error
+	errorHandler          func(ctx context.Context, key string, err error)
+}
+
+type Option[T any] func(*config[T])
+
+// WithDefaultExpiration sets the default expiration duration for cache items.
+func WithDefaultExpiration[T any](expiration time.Duration) Option[T] {
+	return func(c *config[T]) {
+		c.defaultExpireDuration = expiration
+	}
+}
+
+// WithCodec sets the codec used to serialize the cached values, e.g., cache.MsgpackCodec for a compact
+// binary format. Defaults to cache.JSONCodec.
+func WithCodec[T any](codec cache.Codec[T]) Option[T] {
+	return func(c *config[T]) {
+		c.codec = codec
+	}
+}
+
+// WithMissErrors sets the errors returned by the Client when a key does not exist (e.g., bigcache.ErrEntryNotFound).
+// They are translated to cache.ErrCacheMiss.
+func WithMissErrors[T any](errs ...error) Option[T] {
+	return func(c *config[T]) {
+		c.missErrors = append(c.missErrors, errs...)
+	}
+}
+
+// WithErrorHandler sets a function called when Set fails, since it does not return errors.
+func WithErrorHandler[T any](handler func(ctx context.Context, key string, err error)) Option[T] {
+	return func(c *config[T]) {
+		c.errorHandler = handler
+	}
+}
+
+func newConfig[T any](opts ...Option[T]) *config[T] {
+	c := &config[T]{
+		defaultExpireDuration: defaultExpireDuration,
+		codec:                 cache.JSONCodec[T]{},
+		missErrors:            []error{cache.ErrCacheMiss},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type bigcache[T any] struct {
+	client Client
+	group  syncutil.Group[string, T]
+	stats  cache.StatsRecorder
+	config[T]
+}
+
+/*
+New creates a cache backed by bigcache, which keeps the serialized values in a few large byte slices that the
+garbage collector does not scan, with optional configurations. It suits caches of millions of entries.
+
+Bigcache expires all entries after the same LifeWindow, so the cache stores the expiration of each item with its
+value, and treats expired items as missing: items are kept for the shorter of their duration and the LifeWindow.
+
+Example usage:
+
+	users := bigcachecache.New[User](client,
+		bigcachecache.WithMissErrors[User](bigcache.ErrEntryNotFound),
+		bigcachecache.WithDefaultExpiration[User](10*time.Minute),
+	)
+*/
+func New[T any](client Client, opts ...Option[T]) cache.BatchCache[T] {
+	cfg := newConfig(opts...)
+	return &bigcache[T]{
+		client: client,
+		config: pointer.GetValue(cfg),
+	}
+}
+
+// Get retrieves a value from the cache. If the key is missing and an initializer
+// is provided, it uses the initializer to obtain the value. Get returns ctx.Err() as soon as ctx is done, including
+// while waiting for the initializer of another caller, which goes on and stores the value for the other callers.
+func (c *bigcache[T]) Get(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
+	data, err := c.get(key)
+	if err == nil {
+		c.stats.RecordHits(1)
+		return data, nil
+	}
+	if errors.Is(err, cache.ErrCacheMiss) {
+		c.stats.RecordMisses(1)
+	}
+	if !errors.Is(err, cache.ErrCacheMiss) || initializer == nil {
+		var zero T
+		return zero, err
+	}
+	return c.initialize(ctx, key, initializer)
+}
+
+// Set adds an item to the cache with the specified key and duration.
+// If duration is nil, the default expiration is used.
+// If duration is NoExpireDuration, the item does not expire before the LifeWindow of bigcache.
+func (c *bigcache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	if err := c.set(key, value, duration); err != nil && c.errorHandler != nil {
+		c.errorHandler(ctx, key, err)
+	}
+}
+
+// Invalidate removes key from the cache. Removing a missing key is not an error.
+func (c *bigcache[T]) Invalidate(ctx context.Context, key string) error {
+	if err := c.client.Delete(key); err != nil && !c.isMiss(err) {
+		return err
+	}
+	return nil
+}
+
+func (c *bigcache[T]) InvalidateAll(ctx context.Context) error {
+	return c.client.Reset()
+}
+
+// GetMulti returns the values of the keys found in the cache. Missing and expired keys are absent from the result.
+func (c *bigcache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	values := make(map[string]T, len(keys))
+	for _, key := range keys {
+		value, err := c.get(key)
+		if errors.Is(err, cache.ErrCacheMiss) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	c.stats.RecordHits(len(values))
+	c.stats.RecordMisses(len(keys) - len(values))
+	return values, nil
+}
+
+// SetMulti adds every item to the cache with the same duration.
+func (c *bigcache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	for key, value := range items {
+		c.Set(ctx, key, value, duration)
+	}
+}
+
+// InvalidateMulti removes the keys from the cache. Removing missing keys is not an error.
+func (c *bigcache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	var errs []error
+	for _, key := range keys {
+		if err := c.Invalidate(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CacheStats returns the hits, misses and initializer metrics of the cache, implementing cache.StatsProvider.
+// Entries and evictions are managed by bigcache, which reports them in its own Stats.
+func (c *bigcache[T]) CacheStats() cache.Stats {
+	return c.stats.Snapshot()
+}
+
+// get returns the value of key, and cache.ErrCacheMiss if it is missing or expired.
+func (c *bigcache[T]) get(key string) (T, error) {
+	var zero T
+	data, err := c.client.Get(key)
+	if err != nil {
+		if c.isMiss(err) {
+			return zero, cache.ErrCacheMiss
+		}
+		return zero, err
+	}
+	if len(data) < headerSize {
+		return zero, fmt.Errorf("failed to decode cached value: entry of %d bytes is too short", len(data))
+	}
+	if expires := int64(binary.BigEndian.Uint64(data)); expires != 0 && time.Now().UnixNano() >= expires {
+		return zero, cache.ErrCacheMiss
+	}
+	value, err := c.codec.Unmarshal(data[headerSize:])
+	if err != nil {
+		return zero, fmt.Errorf("failed to decode cached value: %w", err)
+	}
+	return value, nil
+}
+
+// set stores value under key, preceded by its expiration in Unix nanoseconds, zero meaning no expiration.
+func (c *bigcache[T]) set(key string, value T, duration *time.Duration) error {
+	encoded, err := c.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached value: %w", err)
+	}
+	if duration == nil {
+		duration = pointer.ToPointer(c.defaultExpireDuration)
+	}
+	var expires int64
+	if d := pointer.GetValue(duration); d != NoExpireDuration {
+		if d <= 0 {
+			// The item expires immediately, and the previous value of key is stale.
+			if err := c.client.Delete(key); err != nil && !c.isMiss(err) {
+				return err
+			}
+			return nil
+		}
+		expires = time.Now().Add(d).UnixNano()
+	}
+
+	data := make([]byte, headerSize+len(encoded))
+	binary.BigEndian.PutUint64(data, uint64(expires))
+	copy(data[headerSize:], encoded)
+	return c.client.Set(key, data)
+}
+
+func (c *bigcache[T]) initialize(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
+	return flight.Do(ctx, &c.group, key, func() (T, error) {
+		// Double-check if the item was initialized by another goroutine
+		if value, err := c.get(key); err == nil {
+			return value, nil
+		}
+
+		start := time.Now()
+		result, duration, err := initializer(ctx, key)
+		c.stats.RecordInitializer(time.Since(start), err)
+		if err != nil {
+			return result, err
+		}
+
+		// Set the item in the cache
+		c.Set(ctx, key, result, duration)
+
+		return result, nil
+	})
+}
+
+func (c *bigcache[T]) isMiss(err error) bool {
+	for _, missErr := range c.missErrors {
+		if errors.Is(err, missErr) {
+			return true
+		}
+	}
+	return false
+}
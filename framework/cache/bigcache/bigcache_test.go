@@ -0,0 +1,149 @@
+package bigcache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/bigcache"
+)
+
+var errEntryNotFound = errors.New("Entry not found")
+
+// fakeClient is an in-memory bigcache.Client.
+type fakeClient struct {
+	mu    sync.Mutex
+	items map[string][]byte
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{items: make(map[string][]byte)}
+}
+
+func (c *fakeClient) Get(key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.items[key]
+	if !ok {
+		return nil, errEntryNotFound
+	}
+	return value, nil
+}
+
+func (c *fakeClient) Set(key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = value
+	return nil
+}
+
+func (c *fakeClient) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[key]; !ok {
+		return errEntryNotFound
+	}
+	delete(c.items, key)
+	return nil
+}
+
+func (c *fakeClient) Reset() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = make(map[string][]byte)
+	return nil
+}
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestBigCache(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	c := bigcache.New[user](client, bigcache.WithMissErrors[user](errEntryNotFound))
+
+	c.Set(ctx, "a", user{ID: 1, Name: "Alice"}, nil)
+	value, err := c.Get(ctx, "a", nil)
+	require.NoError(t, err)
+	require.Equal(t, user{ID: 1, Name: "Alice"}, value)
+
+	// The miss errors of the client are translated to cache.ErrCacheMiss.
+	_, err = c.Get(ctx, "missing", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+	require.NoError(t, c.Invalidate(ctx, "missing"))
+
+	loaded, err := c.Get(ctx, "b", func(ctx context.Context, key string) (user, *time.Duration, error) {
+		return user{ID: 2}, nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, user{ID: 2}, loaded)
+
+	values, err := c.GetMulti(ctx, []string{"a", "b", "missing"})
+	require.NoError(t, err)
+	require.Len(t, values, 2)
+
+	require.NoError(t, c.InvalidateMulti(ctx, []string{"a", "b"}))
+	_, err = c.Get(ctx, "a", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	stats := c.(cache.StatsProvider).CacheStats()
+	require.Equal(t, uint64(1), stats.InitializerCalls)
+}
+
+func TestBigCache_Expiration(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	c := bigcache.New[string](client, bigcache.WithMissErrors[string](errEntryNotFound))
+
+	// Items expire after their own duration, although bigcache has a single LifeWindow.
+	short := 10 * time.Millisecond
+	c.Set(ctx, "short", "value", &short)
+	c.Set(ctx, "forever", "value", nil)
+	time.Sleep(20 * time.Millisecond)
+	_, err := c.Get(ctx, "short", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+	_, err = c.Get(ctx, "forever", nil)
+	require.NoError(t, err)
+
+	// A non-positive duration removes the previous value.
+	zero := time.Duration(0)
+	c.Set(ctx, "forever", "value", &zero)
+	_, err = c.Get(ctx, "forever", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	// Entries not written by the cache are reported as errors, not misses.
+	require.NoError(t, client.Set("corrupted", []byte{1}))
+	_, err = c.Get(ctx, "corrupted", nil)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, cache.ErrCacheMiss)
+}
+
+func TestBigCache_Get_InitializerPanic(t *testing.T) {
+	ctx := context.Background()
+	c := bigcache.New[user](newFakeClient(), bigcache.WithMissErrors[user](errEntryNotFound))
+
+	// The panic of the initializer is raised on the goroutine of the caller, which can recover it.
+	recovered := func() (r interface{}) {
+		defer func() { r = recover() }()
+		_, _ = c.Get(ctx, "key", func(ctx context.Context, key string) (user, *time.Duration, error) {
+			panic("initializer failed")
+		})
+		return nil
+	}()
+	require.NotNil(t, recovered)
+	require.ErrorContains(t, recovered.(error), "initializer failed")
+
+	// The cache remains usable.
+	value, err := c.Get(ctx, "key", func(ctx context.Context, key string) (user, *time.Duration, error) {
+		return user{ID: 1}, nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, user{ID: 1}, value)
+}
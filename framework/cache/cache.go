@@ -9,7 +9,17 @@ import (
 //go:generate mockgen -source=./cache.go -destination=./mocks/cache.go -package=cache_mocks
 var ErrCacheMiss = errors.New("cache miss")
 
-type Initializer[T any] func() (T, *time.Duration, error)
+// ErrTooManyInitializers is returned by Get when the number of concurrent initializers of the cache is limited,
+// and no slot frees up within the configured wait.
+var ErrTooManyInitializers = errors.New("too many concurrent cache initializers")
+
+// ErrNotNumeric is returned by Counter methods when the cached values are not integers.
+var ErrNotNumeric = errors.New("cached value is not an integer")
+
+// Initializer loads the value of key on a cache miss, and returns it with the duration to cache it for
+// (nil for the default expiration of the cache). ctx is the context of the Get call that triggered the load,
+// so that downstream calls are cancelled with it, and key lets a single initializer be reused for all keys.
+type Initializer[T any] func(ctx context.Context, key string) (T, *time.Duration, error)
 
 type Cache[T any] interface {
 	Get(ctx context.Context, key string, initializer Initializer[T]) (T, error)
@@ -17,3 +27,86 @@ type Cache[T any] interface {
 	Invalidate(ctx context.Context, key string) error
 	InvalidateAll(ctx context.Context) error
 }
+
+// BatchCache is implemented by caches supporting multi-key operations, so that remote backends can
+// pipeline requests and local caches take their lock once. Use GetMulti, SetMulti and InvalidateMulti
+// to fall back to per-key operations for caches not implementing it.
+type BatchCache[T any] interface {
+	// The Cache[T] methods are listed rather than embedded, since mockgen does not support embedded generic interfaces.
+	Get(ctx context.Context, key string, initializer Initializer[T]) (T, error)
+	Set(ctx context.Context, key string, value T, duration *time.Duration)
+	Invalidate(ctx context.Context, key string) error
+	InvalidateAll(ctx context.Context) error
+	// GetMulti returns the values of the keys found in the cache. Missing keys are absent from the result.
+	GetMulti(ctx context.Context, keys []string) (map[string]T, error)
+	// SetMulti sets every item with the same duration.
+	SetMulti(ctx context.Context, items map[string]T, duration *time.Duration)
+	// InvalidateMulti removes the keys from the cache.
+	InvalidateMulti(ctx context.Context, keys []string) error
+}
+
+// ConditionalSetter is implemented by caches able to populate a key only if it is missing, atomically, so that
+// callers already holding a value do not race each other nor go through an Initializer.
+type ConditionalSetter[T any] interface {
+	// SetIfAbsent stores value under key if key is missing or expired, and reports whether it was stored.
+	SetIfAbsent(ctx context.Context, key string, value T, duration *time.Duration) bool
+	// GetOrSet returns the value of key if it is in the cache, with loaded true. Otherwise, it stores value
+	// and returns it, with loaded false.
+	GetOrSet(ctx context.Context, key string, value T, duration *time.Duration) (actual T, loaded bool)
+}
+
+// Counter is implemented by caches able to add to integer values atomically, e.g., for rate limits and quotas,
+// where a Get followed by a Set loses concurrent updates. A missing key starts from zero and expires after
+// duration (nil for the default expiration of the cache). Existing keys keep their expiration, so a counter
+// created for a time window is reset when the window ends.
+type Counter interface {
+	// Increment adds delta to the value of key and returns the new value.
+	Increment(ctx context.Context, key string, delta int64, duration *time.Duration) (int64, error)
+	// Decrement subtracts delta from the value of key and returns the new value.
+	Decrement(ctx context.Context, key string, delta int64, duration *time.Duration) (int64, error)
+}
+
+// GetMulti returns the values of the keys found in c, using BatchCache.GetMulti if c implements it.
+// Missing keys are absent from the result.
+func GetMulti[T any](ctx context.Context, c Cache[T], keys []string) (map[string]T, error) {
+	if batch, ok := c.(BatchCache[T]); ok {
+		return batch.GetMulti(ctx, keys)
+	}
+	values := make(map[string]T, len(keys))
+	for _, key := range keys {
+		value, err := c.Get(ctx, key, nil)
+		if errors.Is(err, ErrCacheMiss) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// SetMulti sets every item in c with the same duration, using BatchCache.SetMulti if c implements it.
+func SetMulti[T any](ctx context.Context, c Cache[T], items map[string]T, duration *time.Duration) {
+	if batch, ok := c.(BatchCache[T]); ok {
+		batch.SetMulti(ctx, items, duration)
+		return
+	}
+	for key, value := range items {
+		c.Set(ctx, key, value, duration)
+	}
+}
+
+// InvalidateMulti removes the keys from c, using BatchCache.InvalidateMulti if c implements it.
+func InvalidateMulti[T any](ctx context.Context, c Cache[T], keys []string) error {
+	if batch, ok := c.(BatchCache[T]); ok {
+		return batch.InvalidateMulti(ctx, keys)
+	}
+	var errs []error
+	for _, key := range keys {
+		if err := c.Invalidate(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
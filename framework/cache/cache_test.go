@@ -0,0 +1,53 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	cache_mocks "github.com/kittipat1413/go-common/framework/cache/mocks"
+)
+
+func TestBatchHelpers_FallBackToPerKeyOperations(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	c := cache_mocks.NewMockCache[string](ctrl)
+
+	c.EXPECT().Get(ctx, "a", gomock.Nil()).Return("1", nil)
+	c.EXPECT().Get(ctx, "missing", gomock.Nil()).Return("", cache.ErrCacheMiss)
+	values, err := cache.GetMulti[string](ctx, c, []string{"a", "missing"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "1"}, values)
+
+	c.EXPECT().Get(ctx, "a", gomock.Nil()).Return("", errors.New("connection refused"))
+	_, err = cache.GetMulti[string](ctx, c, []string{"a"})
+	require.EqualError(t, err, "connection refused")
+
+	c.EXPECT().Set(ctx, "a", "1", nil)
+	cache.SetMulti[string](ctx, c, map[string]string{"a": "1"}, nil)
+
+	c.EXPECT().Invalidate(ctx, "a").Return(nil)
+	c.EXPECT().Invalidate(ctx, "b").Return(errors.New("connection refused"))
+	require.EqualError(t, cache.InvalidateMulti[string](ctx, c, []string{"a", "b"}), "connection refused")
+}
+
+func TestBatchHelpers_UseBatchCache(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	c := cache_mocks.NewMockBatchCache[string](ctrl)
+
+	c.EXPECT().GetMulti(ctx, []string{"a", "b"}).Return(map[string]string{"a": "1"}, nil)
+	values, err := cache.GetMulti[string](ctx, c, []string{"a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "1"}, values)
+
+	c.EXPECT().SetMulti(ctx, map[string]string{"a": "1"}, nil)
+	cache.SetMulti[string](ctx, c, map[string]string{"a": "1"}, nil)
+
+	c.EXPECT().InvalidateMulti(ctx, []string{"a"}).Return(nil)
+	require.NoError(t, cache.InvalidateMulti[string](ctx, c, []string{"a"}))
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"context"
+	"errors"
+)
+
+// Closer is implemented by caches owning goroutines, e.g., a cleanup or background reloads, or connections, to
+// release them on shutdown. Close may be called more than once. Wrappers implement Closer by closing the cache
+// they wrap, so that closing the outermost cache closes the whole chain.
+type Closer interface {
+	Close() error
+}
+
+// Close closes c if it implements Closer, and does nothing otherwise.
+func Close(c interface{}) error {
+	if closer, ok := c.(Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+/*
+CloseAll closes the caches implementing Closer concurrently, e.g., on shutdown, and returns their errors. If ctx
+is done first, e.g., while a write-back cache flushes its pending writes, it returns ctx.Err() and leaves the
+remaining caches closing in the background.
+
+Example usage:
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := cache.CloseAll(ctx, users, sessions); err != nil {
+		// Handle error
+	}
+*/
+func CloseAll(ctx context.Context, caches ...interface{}) error {
+	errs := make(chan error, len(caches))
+	for _, c := range caches {
+		go func(c interface{}) {
+			errs <- Close(c)
+		}(c)
+	}
+
+	var joined []error
+	for range caches {
+		select {
+		case err := <-errs:
+			joined = append(joined, err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return errors.Join(joined...)
+}
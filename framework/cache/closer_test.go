@@ -0,0 +1,67 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// closingCache is a cache counting its Close calls.
+type closingCache struct {
+	cache.Cache[string]
+	closed int
+	err    error
+	block  chan struct{}
+}
+
+func (c *closingCache) Close() error {
+	if c.block != nil {
+		<-c.block
+	}
+	c.closed++
+	return c.err
+}
+
+func TestClose(t *testing.T) {
+	base, _ := cache.NewTestCache[string]()
+	require.NoError(t, cache.Close(base), "Expected caches not implementing Closer to be ignored")
+
+	// Closing the outermost wrapper closes the whole chain.
+	closing := &closingCache{Cache: base}
+	c := cache.Chain[string](closing,
+		cache.Tracing[string]("users"),
+		cache.Logging[string]("users", logger.NewNoopLogger()),
+		cache.Metrics[string]("users", nil),
+		cache.TTLPolicy[string](cache.WithMaxTTL(time.Hour)),
+	)
+	require.NoError(t, cache.Close(c))
+	require.Equal(t, 1, closing.closed)
+
+	// A namespace does not close the cache it shares with the other namespaces.
+	require.NoError(t, cache.Close(cache.WithNamespace[string](closing, "ns")))
+	require.Equal(t, 1, closing.closed)
+}
+
+func TestCloseAll(t *testing.T) {
+	base, _ := cache.NewTestCache[string]()
+	failure := errors.New("close failed")
+	first := &closingCache{Cache: base}
+	second := &closingCache{Cache: base, err: failure}
+
+	require.ErrorIs(t, cache.CloseAll(context.Background(), first, second, base), failure)
+	require.Equal(t, 1, first.closed)
+	require.Equal(t, 1, second.closed)
+
+	// CloseAll gives up once the context is done.
+	blocked := &closingCache{Cache: base, block: make(chan struct{})}
+	defer close(blocked.block)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, cache.CloseAll(ctx, blocked), context.DeadlineExceeded)
+}
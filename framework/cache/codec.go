@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Codec serializes the values of the caches storing them outside of the process, such as memcache.
+type Codec[T any] interface {
+	Marshal(value T) ([]byte, error)
+	Unmarshal(data []byte) (T, error)
+}
+
+// JSONCodec is a Codec serializing values as JSON. It is readable by other languages and tools.
+type JSONCodec[T any] struct{}
+
+// Marshal implements the Codec interface.
+func (JSONCodec[T]) Marshal(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Unmarshal implements the Codec interface.
+func (JSONCodec[T]) Unmarshal(data []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+// GobCodec is a Codec serializing values with encoding/gob. Each value carries its type definition,
+// so it suits large structs better than many small values, and it can only be read by Go programs.
+type GobCodec[T any] struct{}
+
+// Marshal implements the Codec interface.
+func (GobCodec[T]) Marshal(value T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal implements the Codec interface.
+func (GobCodec[T]) Unmarshal(data []byte) (T, error) {
+	var value T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return value, err
+}
+
+// msgpackHandle configures the MessagePack encoding. Struct fields are encoded as maps keyed by field name,
+// so that adding or reordering fields does not break the values already cached.
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// MsgpackCodec is a Codec serializing values as MessagePack, a compact binary format readable by other languages.
+// Struct fields are named after their `codec`, then `json` tag, and default to the field name.
+type MsgpackCodec[T any] struct{}
+
+// Marshal implements the Codec interface.
+func (MsgpackCodec[T]) Marshal(value T) ([]byte, error) {
+	var data []byte
+	err := codec.NewEncoderBytes(&data, msgpackHandle).Encode(value)
+	return data, err
+}
+
+// Unmarshal implements the Codec interface.
+func (MsgpackCodec[T]) Unmarshal(data []byte) (T, error) {
+	var value T
+	err := codec.NewDecoderBytes(data, msgpackHandle).Decode(&value)
+	return value, err
+}
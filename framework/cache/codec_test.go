@@ -0,0 +1,60 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+)
+
+type profile struct {
+	ID        int               `json:"id"`
+	Name      string            `json:"name"`
+	Tags      []string          `json:"tags"`
+	Settings  map[string]string `json:"settings"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	value := profile{
+		ID:        1,
+		Name:      "Alice",
+		Tags:      []string{"admin", "beta"},
+		Settings:  map[string]string{"theme": "dark"},
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	for name, codec := range map[string]cache.Codec[profile]{
+		"json":    cache.JSONCodec[profile]{},
+		"gob":     cache.GobCodec[profile]{},
+		"msgpack": cache.MsgpackCodec[profile]{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := codec.Marshal(value)
+			require.NoError(t, err)
+
+			decoded, err := codec.Unmarshal(data)
+			require.NoError(t, err)
+			require.Equal(t, value.ID, decoded.ID)
+			require.Equal(t, value.Name, decoded.Name)
+			require.Equal(t, value.Tags, decoded.Tags)
+			require.Equal(t, value.Settings, decoded.Settings)
+			require.True(t, value.CreatedAt.Equal(decoded.CreatedAt))
+
+			_, err = codec.Unmarshal([]byte{0xc1})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestMsgpackCodec_IsCompact(t *testing.T) {
+	value := profile{ID: 1, Name: "Alice", Tags: []string{"admin"}}
+
+	jsonData, err := cache.JSONCodec[profile]{}.Marshal(value)
+	require.NoError(t, err)
+	msgpackData, err := cache.MsgpackCodec[profile]{}.Marshal(value)
+	require.NoError(t, err)
+	require.Less(t, len(msgpackData), len(jsonData))
+}
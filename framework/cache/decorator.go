@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// Decorator wraps a cache to add a cross-cutting concern, such as logging, metrics or tracing, to any backend.
+type Decorator[T any] func(c Cache[T]) Cache[T]
+
+/*
+Chain wraps c with the decorators. The first decorator is the outermost: Chain(c, A, B) returns A(B(c)), so A
+sees every call first and its result last. The recommended order is:
+
+ 1. Tracing, so that the spans include the time spent in the other decorators.
+ 2. Logging and Metrics, so that they see the calls as made by the service.
+ 3. Namespace, and other decorators rewriting the keys, last.
+
+The returned cache implements BatchCache if the outermost decorator does, as all decorators of this package do.
+
+Example usage:
+
+	users := cache.Chain(localcache.New[User](),
+		cache.Tracing[User]("users"),
+		cache.Logging[User]("users", log),
+		cache.Metrics[User]("users", exporter),
+		cache.Namespace[User]("users"),
+	)
+*/
+func Chain[T any](c Cache[T], decorators ...Decorator[T]) Cache[T] {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		c = decorators[i](c)
+	}
+	return c
+}
+
+// Tracing returns a Decorator recording an OpenTelemetry span for every operation, see WithTracing.
+func Tracing[T any](name string, options ...TracingOption) Decorator[T] {
+	return func(c Cache[T]) Cache[T] {
+		return WithTracing(c, name, options...)
+	}
+}
+
+// Logging returns a Decorator logging every operation with l, see WithLogging.
+func Logging[T any](name string, l logger.Logger) Decorator[T] {
+	return func(c Cache[T]) Cache[T] {
+		return WithLogging(c, name, l)
+	}
+}
+
+// Metrics returns a Decorator recording the hits, misses and initializer calls of the cache, see WithMetrics.
+// If exporter is not nil, the decorated cache is registered with it under name, replacing the cache already
+// registered under name, if any, e.g., a previous instance.
+func Metrics[T any](name string, exporter *StatsExporter) Decorator[T] {
+	return func(c Cache[T]) Cache[T] {
+		metered := WithMetrics(c)
+		if exporter != nil {
+			exporter.replace(name, metered.(StatsProvider))
+		}
+		return metered
+	}
+}
+
+// Namespace returns a Decorator prefixing every key with ns, see WithNamespace.
+func Namespace[T any](ns string) Decorator[T] {
+	return func(c Cache[T]) Cache[T] {
+		return WithNamespace(c, ns)
+	}
+}
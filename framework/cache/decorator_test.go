@@ -0,0 +1,89 @@
+package cache_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// orderedCache records the name of its decorator when Get is called.
+type orderedCache struct {
+	cache.Cache[string]
+	name  string
+	calls *[]string
+}
+
+func (c *orderedCache) Get(ctx context.Context, key string, initializer cache.Initializer[string]) (string, error) {
+	*c.calls = append(*c.calls, c.name)
+	return c.Cache.Get(ctx, key, initializer)
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	decorator := func(name string) cache.Decorator[string] {
+		return func(c cache.Cache[string]) cache.Cache[string] {
+			return &orderedCache{Cache: c, name: name, calls: &calls}
+		}
+	}
+
+	c := cache.Chain(localcache.New[string](), decorator("outer"), decorator("inner"))
+	_, _ = c.Get(ctx, "key", nil)
+	require.Equal(t, []string{"outer", "inner"}, calls)
+}
+
+func TestChain_LoggingAndMetrics(t *testing.T) {
+	ctx := context.Background()
+	log, recorder := logger.NewTestLogger()
+	exporter := cache.NewStatsExporter("")
+	base := localcache.New[string]()
+	c := cache.Chain(base,
+		cache.Logging[string]("users", log),
+		cache.Metrics[string]("users", exporter),
+		cache.Namespace[string]("users"),
+	)
+	load := func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "value", nil, nil
+	}
+
+	_, err := c.Get(ctx, "1", load)
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "1", load)
+	require.NoError(t, err)
+	failure := errors.New("database down")
+	_, err = c.Get(ctx, "2", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "", nil, failure
+	})
+	require.ErrorIs(t, err, failure)
+	_, err = c.(cache.BatchCache[string]).GetMulti(ctx, []string{"1", "3"})
+	require.NoError(t, err)
+
+	// The namespace is applied below the logging and the metrics.
+	_, err = base.Get(ctx, "users:1", nil)
+	require.NoError(t, err)
+
+	recorder.AssertLogged(t, logger.DEBUG, "cache get", logger.HasField("hit", false), logger.HasField("initializer_invoked", true))
+	recorder.AssertLogged(t, logger.DEBUG, "cache get", logger.HasField("hit", true), logger.HasField("cache", "users"))
+	recorder.AssertLogged(t, logger.ERROR, "cache get failed")
+	require.Empty(t, recorder.Find(logger.DEBUG, "1"), "Expected the keys not to be logged")
+
+	// Replacing the cache registered under the same name, e.g., a previous instance, is not an error.
+	c = cache.Chain(localcache.New[string](), cache.Metrics[string]("other", exporter))
+	c = cache.Chain(c, cache.Metrics[string]("other", exporter))
+
+	var buf bytes.Buffer
+	_, err = exporter.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), `cache_hits_total{cache="users"} 2`)
+	require.Contains(t, buf.String(), `cache_misses_total{cache="users"} 3`)
+	require.Contains(t, buf.String(), `cache_initializer_errors_total{cache="users"} 1`)
+	require.Contains(t, buf.String(), `cache_hits_total{cache="other"} 0`)
+}
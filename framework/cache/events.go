@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType is the kind of cache activity reported by an Event.
+type EventType int
+
+const (
+	// EventSet means a value was stored, by Set or by loading a missing key.
+	EventSet EventType = iota + 1
+	// EventHit means a key was found in the cache.
+	EventHit
+	// EventMiss means a key was missing from the cache, or expired.
+	EventMiss
+	// EventExpire means an expired item was removed from the cache.
+	EventExpire
+	// EventEvict means an item was evicted because the cache is full.
+	EventEvict
+	// EventInvalidate means an item was removed by Invalidate, InvalidateMulti or InvalidateAll.
+	EventInvalidate
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventSet:
+		return "set"
+	case EventHit:
+		return "hit"
+	case EventMiss:
+		return "miss"
+	case EventExpire:
+		return "expire"
+	case EventEvict:
+		return "evict"
+	case EventInvalidate:
+		return "invalidate"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is an activity of a cache on a key.
+type Event struct {
+	Type EventType
+	Key  string
+	Time time.Time
+}
+
+// EventSource is implemented by caches streaming their activity as events.
+type EventSource interface {
+	// Subscribe returns a channel receiving the events of the cache, buffering up to buffer events, and a function
+	// ending the subscription and closing the channel. Events are dropped while the buffer is full, so that a slow
+	// subscriber never slows down the cache.
+	Subscribe(buffer int) (events <-chan Event, unsubscribe func())
+}
+
+/*
+EventBus delivers the events of a cache to its subscribers. Backends use it to implement EventSource, publishing
+their activity with Publish. Publish never blocks: an event is dropped for a subscriber whose buffer is full,
+and counted by Dropped. The zero value is ready to use.
+
+Example usage:
+
+	events, unsubscribe := users.(cache.EventSource).Subscribe(1024)
+	defer unsubscribe()
+	for event := range events {
+		if event.Type == cache.EventExpire {
+			warmer.Schedule(event.Key)
+		}
+	}
+*/
+type EventBus struct {
+	mutex       sync.RWMutex
+	subscribers map[chan Event]struct{}
+	active      atomic.Int32
+	dropped     atomic.Uint64
+}
+
+// Subscribe implements EventSource. unsubscribe may be called more than once.
+func (b *EventBus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, max(buffer, 0))
+
+	b.mutex.Lock()
+	if b.subscribers == nil {
+		b.subscribers = make(map[chan Event]struct{})
+	}
+	b.subscribers[ch] = struct{}{}
+	b.active.Add(1)
+	b.mutex.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+			delete(b.subscribers, ch)
+			b.active.Add(-1)
+			close(ch)
+		})
+	}
+}
+
+// Active reports whether the bus has subscribers, so that publishers may skip the work of building events.
+func (b *EventBus) Active() bool {
+	return b.active.Load() > 0
+}
+
+// Publish sends an event of type typ for key to every subscriber whose buffer is not full.
+func (b *EventBus) Publish(typ EventType, key string) {
+	if !b.Active() {
+		return
+	}
+	event := Event{Type: typ, Key: key, Time: time.Now()}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped returns the number of events dropped because the buffer of a subscriber was full.
+func (b *EventBus) Dropped() uint64 {
+	return b.dropped.Load()
+}
@@ -0,0 +1,35 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+)
+
+func TestEventBus(t *testing.T) {
+	var bus cache.EventBus
+	require.False(t, bus.Active())
+	bus.Publish(cache.EventSet, "ignored")
+
+	events, unsubscribe := bus.Subscribe(1)
+	require.True(t, bus.Active())
+	bus.Publish(cache.EventSet, "a")
+	bus.Publish(cache.EventHit, "a")
+
+	// The second event is dropped since the buffer is full.
+	event := <-events
+	require.Equal(t, cache.EventSet, event.Type)
+	require.Equal(t, "a", event.Key)
+	require.False(t, event.Time.IsZero())
+	require.Equal(t, uint64(1), bus.Dropped())
+
+	unsubscribe()
+	unsubscribe()
+	_, open := <-events
+	require.False(t, open, "Expected the channel to be closed")
+	require.False(t, bus.Active())
+	bus.Publish(cache.EventSet, "b")
+	require.Equal(t, uint64(1), bus.Dropped())
+}
@@ -14,7 +14,7 @@ func main() {
 	c := localcache.New[string](localcache.WithDefaultExpiration(10*time.Minute), localcache.WithCleanupInterval(5*time.Minute))
 
 	// Initializer function
-	initializer := func() (string, *time.Duration, error) {
+	initializer := func(ctx context.Context, key string) (string, *time.Duration, error) {
 		// Simulate data fetching or computation
 		time.Sleep(100 * time.Millisecond)
 
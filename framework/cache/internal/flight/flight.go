@@ -0,0 +1,87 @@
+// Package flight shares the loads of the cache backends between the concurrent Get calls of a key, with the panics
+// of the initializers surfacing on the goroutines of the callers.
+package flight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/kittipat1413/go-common/util/sync"
+)
+
+// errLoadCancelled is returned by a shared load whose caller's context was done, so that the other callers
+// waiting for it load the value again instead of failing with an error that is not theirs.
+var errLoadCancelled = errors.New("load cancelled by its caller")
+
+// PanicError is a panic recovered from an initializer, with the stack of the goroutine that panicked.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("cache initializer panicked: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Recover recovers a panic of the calling function into *err, as a *PanicError. It must be deferred directly:
+//
+//	defer flight.Recover(&err)
+func Recover(err *error) {
+	if r := recover(); r != nil {
+		*err = &PanicError{Value: r, Stack: debug.Stack()}
+	}
+}
+
+/*
+Do calls load once at a time for key with group.DoContext, sharing its result with the concurrent calls of key, and
+waits for it until ctx is done. The load goes on when its callers give up, so that it stores the value for the next
+ones. If load fails once the context of the caller running it is done, the callers waiting for it call load again
+with their own context. A panic of load is re-panicked as a *PanicError on the goroutines of all the callers, like
+golang.org/x/sync/singleflight.Group.Do, rather than returned as a sync.ErrPanic error.
+
+Example usage:
+
+	return flight.Do(ctx, &c.group, key, func() (T, error) {
+		result, duration, err := initializer(ctx, key)
+		if err != nil {
+			return result, err
+		}
+		c.Set(ctx, key, result, duration)
+		return result, nil
+	})
+*/
+func Do[T any](ctx context.Context, group *sync.Group[string, T], key string, load func() (T, error)) (T, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			var zero T
+			return zero, err
+		}
+		value, err, _ := group.DoContext(ctx, key, func() (value T, err error) {
+			defer Recover(&err)
+			value, err = load()
+			var panicked *PanicError
+			if err != nil && ctx.Err() != nil && !errors.As(err, &panicked) {
+				return value, errLoadCancelled
+			}
+			return value, err
+		})
+
+		var panicked *PanicError
+		if errors.As(err, &panicked) {
+			panic(panicked)
+		}
+		if errors.Is(err, errLoadCancelled) {
+			// The caller running load gave up, load the value with this context.
+			continue
+		}
+		return value, err
+	}
+}
@@ -0,0 +1,117 @@
+package flight_test
+
+import (
+	"context"
+	"errors"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache/internal/flight"
+	"github.com/kittipat1413/go-common/util/sync"
+)
+
+func TestDo(t *testing.T) {
+	var g sync.Group[string, string]
+	value, err := flight.Do(context.Background(), &g, "key", func() (string, error) {
+		return "value", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+
+	errCause := errors.New("cause")
+	_, err = flight.Do(context.Background(), &g, "key", func() (string, error) {
+		return "", errCause
+	})
+	require.ErrorIs(t, err, errCause)
+}
+
+func TestDo_CallerGivesUp(t *testing.T) {
+	var g sync.Group[string, string]
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	leader := make(chan error)
+	go func() {
+		_, err := flight.Do(ctx, &g, "key", func() (string, error) {
+			close(started)
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+		leader <- err
+	}()
+	<-started
+
+	// The caller waiting for the cancelled load loads the value again with its own context.
+	follower := make(chan string)
+	go func() {
+		value, err := flight.Do(context.Background(), &g, "key", func() (string, error) {
+			return "value", nil
+		})
+		require.NoError(t, err)
+		follower <- value
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	require.ErrorIs(t, <-leader, context.Canceled)
+	require.Equal(t, "value", <-follower)
+
+	_, err := flight.Do(ctx, &g, "key", func() (string, error) {
+		t.Fatal("load is not called once ctx is done")
+		return "", nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDo_Panic(t *testing.T) {
+	var g sync.Group[string, string]
+	errCause := errors.New("cause")
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var once stdsync.Once
+
+	// The panic is re-panicked on the goroutine of every caller sharing the call.
+	const callers = 3
+	recovered := make([]interface{}, callers)
+	var wg stdsync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { recovered[i] = recover() }()
+			_, _ = flight.Do(context.Background(), &g, "key", func() (string, error) {
+				once.Do(func() { close(started) })
+				<-release
+				panic(errCause)
+			})
+		}()
+		if i == 0 {
+			<-started
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for _, r := range recovered {
+		var p *flight.PanicError
+		require.ErrorAs(t, r.(error), &p)
+		require.Equal(t, errCause, p.Value)
+		require.ErrorIs(t, p, errCause)
+		require.NotEmpty(t, p.Stack)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	call := func() (err error) {
+		defer flight.Recover(&err)
+		panic("failed")
+	}
+	err := call()
+	var p *flight.PanicError
+	require.ErrorAs(t, err, &p)
+	require.Equal(t, "failed", p.Value)
+	require.Contains(t, err.Error(), "cache initializer panicked: failed")
+}
@@ -0,0 +1,60 @@
+package goredisclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kittipat1413/go-common/framework/cache/invalidation"
+)
+
+var _ invalidation.Transport = (*Transport)(nil)
+
+/*
+Transport is the invalidation.Transport of a go-redis client, e.g., a *redis.Client, a *redis.ClusterClient or a
+*redis.Ring, which broadcasts the invalidations with Redis pub/sub. It is a module of its own, so that the services
+using invalidation with another transport do not depend on go-redis.
+
+Example usage:
+
+	rdb := redis.NewClient(&redis.Options{Addr: "redis:6379"})
+	users, err := invalidation.New[User](ctx, "users", tieredcache.New[User](l1, l2), l1, goredisclient.New(rdb))
+*/
+type Transport struct {
+	client redis.UniversalClient
+}
+
+// New creates the invalidation.Transport of client. The client is not owned by it.
+func New(client redis.UniversalClient) *Transport {
+	return &Transport{client: client}
+}
+
+// Publish sends payload to the subscribers of channel.
+func (t *Transport) Publish(ctx context.Context, channel string, payload []byte) error {
+	return t.client.Publish(ctx, channel, payload).Err()
+}
+
+// Subscribe calls handler with the payloads published on channel until unsubscribe is called. It returns once the
+// subscription is confirmed by the server, and unsubscribe returns once handler is no longer called.
+func (t *Transport) Subscribe(ctx context.Context, channel string, handler func(payload []byte)) (func() error, error) {
+	pubsub := t.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for msg := range pubsub.Channel() {
+			handler([]byte(msg.Payload))
+		}
+	}()
+	return func() error {
+		err := pubsub.Close()
+		wg.Wait()
+		return err
+	}, nil
+}
@@ -0,0 +1,43 @@
+package goredisclient_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache/invalidation/goredisclient"
+)
+
+func TestTransport(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	transport := goredisclient.New(rdb)
+
+	received := make(chan string, 10)
+	unsubscribe, err := transport.Subscribe(ctx, "cache:invalidation:users", func(payload []byte) {
+		received <- string(payload)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cache:invalidation:users"}, server.PubSubChannels(""), "the subscription is confirmed")
+
+	require.NoError(t, transport.Publish(ctx, "cache:invalidation:users", []byte(`{"keys":["1"]}`)))
+	require.NoError(t, transport.Publish(ctx, "cache:invalidation:orders", []byte(`{"all":true}`)))
+	select {
+	case payload := <-received:
+		assert.Equal(t, `{"keys":["1"]}`, payload)
+	case <-time.After(time.Second):
+		t.Fatal("the payload was not received")
+	}
+
+	require.NoError(t, unsubscribe())
+	require.NoError(t, transport.Publish(ctx, "cache:invalidation:users", []byte(`{"keys":["2"]}`)))
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, received, "the payloads of the other channels and after unsubscribe are not received")
+}
@@ -0,0 +1,247 @@
+package invalidation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	cache "github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/util/pointer"
+)
+
+// Transport broadcasts invalidation messages between instances, e.g., over Redis pub/sub. The
+// invalidation/goredisclient module implements it with go-redis.
+type Transport interface {
+	// Publish sends payload to the subscribers of channel, including the publishing instance.
+	Publish(ctx context.Context, channel string, payload []byte) error
+	// Subscribe calls handler with the payloads published on channel until unsubscribe is called.
+	Subscribe(ctx context.Context, channel string, handler func(payload []byte)) (unsubscribe func() error, err error)
+}
+
+// message is an invalidation broadcast to the other instances.
+type message struct {
+	// Source is the ID of the publishing instance, which ignores its own messages.
+	Source string   `json:"source"`
+	Keys   []string `json:"keys,omitempty"`
+	All    bool     `json:"all,omitempty"`
+}
+
+type config struct {
+	channelPrefix string
+	errorHandler  func(ctx context.Context, err error)
+}
+
+type Option func(*config)
+
+// WithChannelPrefix sets the prefix of the channel the invalidations are published on. Defaults to "cache:invalidation:".
+func WithChannelPrefix(prefix string) Option {
+	return func(c *config) {
+		c.channelPrefix = prefix
+	}
+}
+
+// WithErrorHandler sets a function called when publishing an invalidation from Set or SetMulti fails,
+// or when applying an invalidation received from another instance fails.
+func WithErrorHandler(handler func(ctx context.Context, err error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
+
+func newConfig(opts ...Option) *config {
+	c := &config{
+		channelPrefix: "cache:invalidation:",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type invalidatingCache[T any] struct {
+	cache       cache.Cache[T]
+	local       cache.Cache[T]
+	transport   Transport
+	channel     string
+	instanceID  string
+	unsubscribe func() error
+	config
+}
+
+/*
+New wraps c to broadcast its invalidations to the other instances over transport, and invalidates local when
+another instance invalidates a key of the cache named name. local holds the copies of the instance: c itself for
+a localcache, or the L1 cache of a tieredcache. Set and SetMulti also invalidate the key on the other instances,
+since their local copies become stale.
+
+Call Close, e.g., with cache.Close(c), to stop receiving invalidations and close c.
+
+Example usage:
+
+	l1 := localcache.New[User]()
+	users, err := invalidation.New[User](ctx, "users", tieredcache.New(l1, l2), l1, transport)
+*/
+func New[T any](ctx context.Context, name string, c, local cache.Cache[T], transport Transport, opts ...Option) (cache.BatchCache[T], error) {
+	cfg := newConfig(opts...)
+	ic := &invalidatingCache[T]{
+		cache:      c,
+		local:      local,
+		transport:  transport,
+		channel:    cfg.channelPrefix + name,
+		instanceID: uuid.NewString(),
+		config:     pointer.GetValue(cfg),
+	}
+
+	unsubscribe, err := transport.Subscribe(ctx, ic.channel, ic.handle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", ic.channel, err)
+	}
+	ic.unsubscribe = unsubscribe
+	return ic, nil
+}
+
+// Get retrieves a value from the wrapped cache.
+func (c *invalidatingCache[T]) Get(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
+	return c.cache.Get(ctx, key, initializer)
+}
+
+// Set adds an item to the wrapped cache and invalidates key on the other instances.
+func (c *invalidatingCache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	c.cache.Set(ctx, key, value, duration)
+	if err := c.publish(ctx, message{Keys: []string{key}}); err != nil {
+		c.handleError(ctx, err)
+	}
+}
+
+// Invalidate removes key from the wrapped cache and from the other instances.
+func (c *invalidatingCache[T]) Invalidate(ctx context.Context, key string) error {
+	return errors.Join(c.cache.Invalidate(ctx, key), c.publish(ctx, message{Keys: []string{key}}))
+}
+
+// InvalidateAll removes all keys from the wrapped cache and from the other instances.
+func (c *invalidatingCache[T]) InvalidateAll(ctx context.Context) error {
+	return errors.Join(c.cache.InvalidateAll(ctx), c.publish(ctx, message{All: true}))
+}
+
+// GetMulti returns the values of the keys found in the wrapped cache.
+func (c *invalidatingCache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	return cache.GetMulti(ctx, c.cache, keys)
+}
+
+// SetMulti adds every item to the wrapped cache and invalidates their keys on the other instances.
+func (c *invalidatingCache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	cache.SetMulti(ctx, c.cache, items, duration)
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	if err := c.publish(ctx, message{Keys: keys}); err != nil {
+		c.handleError(ctx, err)
+	}
+}
+
+// InvalidateMulti removes the keys from the wrapped cache and from the other instances.
+func (c *invalidatingCache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	return errors.Join(cache.InvalidateMulti(ctx, c.cache, keys), c.publish(ctx, message{Keys: keys}))
+}
+
+// Close stops receiving the invalidations of the other instances, and closes the wrapped cache.
+func (c *invalidatingCache[T]) Close() error {
+	return errors.Join(c.unsubscribe(), cache.Close(c.cache))
+}
+
+func (c *invalidatingCache[T]) publish(ctx context.Context, msg message) error {
+	msg.Source = c.instanceID
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if err := c.transport.Publish(ctx, c.channel, payload); err != nil {
+		return fmt.Errorf("failed to publish invalidation: %w", err)
+	}
+	return nil
+}
+
+// handle applies an invalidation received from another instance to the local cache.
+func (c *invalidatingCache[T]) handle(payload []byte) {
+	ctx := context.Background()
+	var msg message
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		c.handleError(ctx, fmt.Errorf("failed to decode invalidation: %w", err))
+		return
+	}
+	if msg.Source == c.instanceID {
+		return
+	}
+
+	var err error
+	if msg.All {
+		err = c.local.InvalidateAll(ctx)
+	} else {
+		err = cache.InvalidateMulti(ctx, c.local, msg.Keys)
+	}
+	if err != nil {
+		c.handleError(ctx, err)
+	}
+}
+
+func (c *invalidatingCache[T]) handleError(ctx context.Context, err error) {
+	if c.errorHandler != nil {
+		c.errorHandler(ctx, err)
+	}
+}
+
+// MemoryTransport is a Transport delivering the messages to the subscribers of the same process, synchronously.
+// It is meant for tests, and for running several caches in one process as if they were separate instances.
+type MemoryTransport struct {
+	mutex       sync.RWMutex
+	nextID      int
+	subscribers map[string]map[int]func(payload []byte)
+}
+
+// NewMemoryTransport creates a MemoryTransport.
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{
+		subscribers: make(map[string]map[int]func(payload []byte)),
+	}
+}
+
+// Publish implements the Transport interface.
+func (t *MemoryTransport) Publish(ctx context.Context, channel string, payload []byte) error {
+	t.mutex.RLock()
+	handlers := make([]func(payload []byte), 0, len(t.subscribers[channel]))
+	for _, handler := range t.subscribers[channel] {
+		handlers = append(handlers, handler)
+	}
+	t.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(payload)
+	}
+	return nil
+}
+
+// Subscribe implements the Transport interface.
+func (t *MemoryTransport) Subscribe(ctx context.Context, channel string, handler func(payload []byte)) (func() error, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	id := t.nextID
+	t.nextID++
+	if t.subscribers[channel] == nil {
+		t.subscribers[channel] = make(map[int]func(payload []byte))
+	}
+	t.subscribers[channel][id] = handler
+
+	return func() error {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		delete(t.subscribers[channel], id)
+		return nil
+	}, nil
+}
@@ -0,0 +1,121 @@
+package invalidation_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/invalidation"
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+	"github.com/kittipat1413/go-common/framework/cache/tieredcache"
+)
+
+// instance is the cache of one instance, with its local L1 and the L2 shared with the other instances.
+type instance struct {
+	cache cache.BatchCache[string]
+	l1    cache.Cache[string]
+}
+
+func newInstances(t *testing.T, transport invalidation.Transport, n int) []instance {
+	t.Helper()
+	l2 := localcache.New[string]()
+	instances := make([]instance, n)
+	for i := range instances {
+		l1 := localcache.New[string]()
+		c, err := invalidation.New[string](context.Background(), "users", tieredcache.New(l1, l2), l1, transport)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, c.(interface{ Close() error }).Close()) })
+		instances[i] = instance{cache: c, l1: l1}
+	}
+	return instances
+}
+
+func TestInvalidation_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	instances := newInstances(t, invalidation.NewMemoryTransport(), 2)
+	a, b := instances[0], instances[1]
+
+	a.cache.Set(ctx, "key1", "value1", nil)
+	a.cache.Set(ctx, "key2", "value2", nil)
+	for _, key := range []string{"key1", "key2"} {
+		// Populate the L1 cache of b from L2.
+		_, err := b.cache.Get(ctx, key, nil)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, a.cache.Invalidate(ctx, "key1"))
+	_, err := b.l1.Get(ctx, "key1", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss, "Expected the local copy of b to be invalidated")
+	value, err := b.l1.Get(ctx, "key2", nil)
+	require.NoError(t, err)
+	require.Equal(t, "value2", value)
+
+	require.NoError(t, a.cache.InvalidateAll(ctx))
+	_, err = b.l1.Get(ctx, "key2", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+}
+
+func TestInvalidation_SetInvalidatesOtherInstances(t *testing.T) {
+	ctx := context.Background()
+	instances := newInstances(t, invalidation.NewMemoryTransport(), 2)
+	a, b := instances[0], instances[1]
+
+	a.cache.Set(ctx, "key", "old", nil)
+	_, err := b.cache.Get(ctx, "key", nil)
+	require.NoError(t, err)
+
+	a.cache.SetMulti(ctx, map[string]string{"key": "new"}, nil)
+	value, err := b.cache.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, "new", value, "Expected b to read the new value from L2")
+
+	// The publishing instance keeps its own copy.
+	value, err = a.l1.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, "new", value)
+}
+
+type failingTransport struct {
+	*invalidation.MemoryTransport
+	err error
+}
+
+func (t *failingTransport) Publish(ctx context.Context, channel string, payload []byte) error {
+	return t.err
+}
+
+func TestInvalidation_PublishError(t *testing.T) {
+	ctx := context.Background()
+	transport := &failingTransport{MemoryTransport: invalidation.NewMemoryTransport(), err: errors.New("connection refused")}
+
+	var handled error
+	l1 := localcache.New[string]()
+	c, err := invalidation.New[string](ctx, "users", l1, l1, transport,
+		invalidation.WithErrorHandler(func(_ context.Context, err error) { handled = err }),
+	)
+	require.NoError(t, err)
+
+	c.Set(ctx, "key", "value", nil)
+	require.ErrorIs(t, handled, transport.err)
+
+	// The local invalidation is applied even if it cannot be published.
+	require.ErrorIs(t, c.Invalidate(ctx, "key"), transport.err)
+	_, err = l1.Get(ctx, "key", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+}
+
+func TestInvalidation_Close(t *testing.T) {
+	ctx := context.Background()
+	transport := invalidation.NewMemoryTransport()
+	instances := newInstances(t, transport, 2)
+	a, b := instances[0], instances[1]
+
+	b.l1.Set(ctx, "key", "value", nil)
+	require.NoError(t, b.cache.(interface{ Close() error }).Close())
+	require.NoError(t, a.cache.Invalidate(ctx, "key"))
+	_, err := b.l1.Get(ctx, "key", nil)
+	require.NoError(t, err, "Expected b to stop receiving invalidations once closed")
+}
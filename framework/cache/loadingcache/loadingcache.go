@@ -0,0 +1,267 @@
+package loadingcache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cache "github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/internal/flight"
+	"github.com/kittipat1413/go-common/util/pointer"
+	syncutil "github.com/kittipat1413/go-common/util/sync"
+)
+
+type config struct {
+	maxStaleness time.Duration
+	errorHandler func(ctx context.Context, key string, err error)
+}
+
+type Option func(*config)
+
+// WithMaxStaleness bounds how long a value is served after its last successful load while its reloads fail.
+// Past this bound, the key is removed and the next Get loads it again, returning the error if the load fails.
+// Zero or a negative value serves the last loaded value until a reload succeeds, the default.
+func WithMaxStaleness(d time.Duration) Option {
+	return func(c *config) {
+		c.maxStaleness = d
+	}
+}
+
+// WithErrorHandler sets a function called when a scheduled reload fails, since no caller receives its error.
+func WithErrorHandler(handler func(ctx context.Context, key string, err error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
+
+func newConfig(opts ...Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// entry is a loaded key, reloaded by its timer.
+type entry[T any] struct {
+	value    T
+	loadedAt time.Time
+	interval time.Duration
+	loader   cache.Initializer[T]
+	timer    *time.Timer
+}
+
+type loadingcache[T any] struct {
+	mutex           sync.RWMutex
+	group           syncutil.Group[string, T]
+	entries         map[string]*entry[T]
+	loader          cache.Initializer[T]
+	refreshInterval time.Duration
+	closed          bool
+	stats           cache.StatsRecorder
+	config
+}
+
+/*
+New creates a cache loading its keys with loader, and reloading each of them every refreshInterval in the
+background, whether or not it is read, like the refreshing caches of Caffeine. It suits configuration and
+reference data: reads never wait for a reload, and the last loaded value keeps being served while reloads fail
+(see WithMaxStaleness).
+
+The duration returned by the loader, if not nil, overrides refreshInterval for the key. The initializer passed
+to Get, if not nil, is used instead of the loader to load and reload the key. Keys are reloaded until they are
+invalidated or the cache is closed with cache.Close(c).
+
+Example usage:
+
+	flags := loadingcache.New[Flags](func(ctx context.Context, key string) (Flags, *time.Duration, error) {
+		flags, err := repository.GetFlags(ctx, key)
+		return flags, nil, err
+	}, time.Minute, loadingcache.WithMaxStaleness(10*time.Minute))
+	defer cache.Close(flags)
+
+	value, err := flags.Get(ctx, "checkout", nil)
+*/
+func New[T any](loader cache.Initializer[T], refreshInterval time.Duration, opts ...Option) cache.Cache[T] {
+	cfg := newConfig(opts...)
+	return &loadingcache[T]{
+		entries:         make(map[string]*entry[T]),
+		loader:          loader,
+		refreshInterval: refreshInterval,
+		config:          pointer.GetValue(cfg),
+	}
+}
+
+// Get returns the last loaded value of key. If the key is missing, or staler than WithMaxStaleness, it loads the
+// value with initializer, or with the loader of the cache if initializer is nil, and schedules its reloads.
+// Get returns ctx.Err() as soon as ctx is done, while the load goes on for the other callers.
+func (c *loadingcache[T]) Get(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
+	c.mutex.RLock()
+	e, found := c.entries[key]
+	var value T
+	fresh := found && !c.stale(e, time.Now())
+	if fresh {
+		value = e.value
+	}
+	c.mutex.RUnlock()
+
+	if fresh {
+		c.stats.RecordHits(1)
+		return value, nil
+	}
+	c.stats.RecordMisses(1)
+	if initializer == nil {
+		initializer = c.loader
+	}
+	if initializer == nil {
+		return value, cache.ErrCacheMiss
+	}
+	return c.load(ctx, key, initializer)
+}
+
+// Set stores value under key and schedules its next reload after duration, or after the refresh interval of
+// the cache if duration is nil. The key keeps the loader it was last loaded with.
+func (c *loadingcache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var loader cache.Initializer[T]
+	if e, found := c.entries[key]; found {
+		loader = e.loader
+	}
+	c.store(key, value, duration, loader)
+}
+
+// Invalidate removes key from the cache and stops reloading it.
+func (c *loadingcache[T]) Invalidate(ctx context.Context, key string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.delete(key)
+	return nil
+}
+
+// InvalidateAll removes all keys from the cache and stops reloading them.
+func (c *loadingcache[T]) InvalidateAll(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for key := range c.entries {
+		c.delete(key)
+	}
+	return nil
+}
+
+// Close stops reloading the keys. The loaded values are kept, and Get still loads missing keys, without
+// scheduling their reloads.
+func (c *loadingcache[T]) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.closed = true
+	for _, e := range c.entries {
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+	}
+	return nil
+}
+
+// CacheStats returns the metrics of the cache. InitializerCalls includes the scheduled reloads.
+func (c *loadingcache[T]) CacheStats() cache.Stats {
+	stats := c.stats.Snapshot()
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	stats.Entries = len(c.entries)
+	return stats
+}
+
+// load loads key with loader, sharing the call with the concurrent Get calls of key, and stores the value.
+func (c *loadingcache[T]) load(ctx context.Context, key string, loader cache.Initializer[T]) (T, error) {
+	return flight.Do(ctx, &c.group, key, func() (T, error) {
+		result, duration, err := c.call(ctx, key, loader)
+		if err != nil {
+			return result, err
+		}
+
+		c.mutex.Lock()
+		c.store(key, result, duration, loader)
+		c.mutex.Unlock()
+
+		return result, nil
+	})
+}
+
+// reload is run by the timer of key. On failure, the last loaded value is kept until it exceeds WithMaxStaleness,
+// and the reload is tried again after the interval of the key.
+func (c *loadingcache[T]) reload(key string, e *entry[T]) {
+	ctx := context.Background()
+	result, duration, err := c.call(ctx, key, e.loader)
+	if err != nil && c.errorHandler != nil {
+		c.errorHandler(ctx, key, err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if current, found := c.entries[key]; !found || current != e || c.closed {
+		// The key was set, invalidated or reloaded by a Get meanwhile, which scheduled its own reload.
+		return
+	}
+	if err == nil {
+		c.store(key, result, duration, e.loader)
+		return
+	}
+	if c.stale(e, time.Now()) {
+		c.delete(key)
+		return
+	}
+	e.timer.Reset(e.interval)
+}
+
+// call calls loader, recording its metrics. A panic of loader is returned as a *flight.PanicError, so that a
+// scheduled reload panicking fails like any other instead of crashing the process from the goroutine of its timer.
+func (c *loadingcache[T]) call(ctx context.Context, key string, loader cache.Initializer[T]) (result T, duration *time.Duration, err error) {
+	start := time.Now()
+	defer func() { c.stats.RecordInitializer(time.Since(start), err) }()
+	defer flight.Recover(&err)
+	return loader(ctx, key)
+}
+
+// store sets the value of key and schedules its next reload. The caller must hold the write lock.
+func (c *loadingcache[T]) store(key string, value T, duration *time.Duration, loader cache.Initializer[T]) {
+	c.delete(key)
+	if loader == nil {
+		loader = c.loader
+	}
+
+	e := &entry[T]{
+		value:    value,
+		loadedAt: time.Now(),
+		interval: c.refreshInterval,
+		loader:   loader,
+	}
+	if duration != nil {
+		e.interval = pointer.GetValue(duration)
+	}
+	c.entries[key] = e
+	if !c.closed && e.interval > 0 && e.loader != nil {
+		e.timer = time.AfterFunc(e.interval, func() { c.reload(key, e) })
+	}
+}
+
+// delete removes key and stops its reloads. The caller must hold the write lock.
+func (c *loadingcache[T]) delete(key string) {
+	if e, found := c.entries[key]; found {
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+		delete(c.entries, key)
+	}
+}
+
+// stale reports whether e exceeds WithMaxStaleness at now.
+func (c *loadingcache[T]) stale(e *entry[T], now time.Time) bool {
+	return c.maxStaleness > 0 && now.Sub(e.loadedAt) > c.maxStaleness
+}
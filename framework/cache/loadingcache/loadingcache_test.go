@@ -0,0 +1,173 @@
+package loadingcache_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/loadingcache"
+)
+
+// versionLoader returns a loader returning the number of times it was called, or err once fail is set.
+func versionLoader(calls *atomic.Int32, fail *atomic.Bool) cache.Initializer[int] {
+	return func(ctx context.Context, key string) (int, *time.Duration, error) {
+		n := calls.Add(1)
+		if fail != nil && fail.Load() {
+			return 0, nil, errors.New("downstream unavailable")
+		}
+		return int(n), nil, nil
+	}
+}
+
+func TestLoadingCache_ReloadsOnSchedule(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	c := loadingcache.New(versionLoader(&calls, nil), 20*time.Millisecond)
+	defer c.(interface{ Close() error }).Close()
+
+	value, err := c.Get(ctx, "config", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+
+	// The value is reloaded without being read.
+	require.Eventually(t, func() bool { return calls.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	value, err = c.Get(ctx, "config", nil)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, value, 2)
+
+	// Invalidated keys are no longer reloaded.
+	require.NoError(t, c.Invalidate(ctx, "config"))
+	stopped := calls.Load()
+	time.Sleep(60 * time.Millisecond)
+	require.Equal(t, stopped, calls.Load())
+}
+
+func TestLoadingCache_ServesStaleValuesOnFailure(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	var fail atomic.Bool
+	var failures atomic.Int32
+	c := loadingcache.New(versionLoader(&calls, &fail), 20*time.Millisecond,
+		loadingcache.WithMaxStaleness(150*time.Millisecond),
+		loadingcache.WithErrorHandler(func(ctx context.Context, key string, err error) {
+			failures.Add(1)
+		}),
+	)
+	defer c.(interface{ Close() error }).Close()
+
+	value, err := c.Get(ctx, "config", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+
+	// Failed reloads keep serving the last loaded value.
+	fail.Store(true)
+	require.Eventually(t, func() bool { return failures.Load() >= 2 }, time.Second, 5*time.Millisecond)
+	value, err = c.Get(ctx, "config", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+
+	// Past the staleness bound, Get loads the value again and returns its error.
+	time.Sleep(150 * time.Millisecond)
+	_, err = c.Get(ctx, "config", nil)
+	require.Error(t, err)
+
+	fail.Store(false)
+	value, err = c.Get(ctx, "config", nil)
+	require.NoError(t, err)
+	require.Greater(t, value, 1)
+}
+
+func TestLoadingCache_ReloadPanic(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	failures := make(chan error, 10)
+	c := loadingcache.New(func(ctx context.Context, key string) (int, *time.Duration, error) {
+		if calls.Add(1) > 1 {
+			panic("reload failed")
+		}
+		return 1, nil, nil
+	}, 20*time.Millisecond, loadingcache.WithErrorHandler(func(ctx context.Context, key string, err error) {
+		failures <- err
+	}))
+	defer c.(interface{ Close() error }).Close()
+
+	value, err := c.Get(ctx, "config", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+
+	// A panicking reload fails like any other: the error handler receives the panic, and the last loaded value
+	// is kept.
+	select {
+	case err := <-failures:
+		require.ErrorContains(t, err, "reload failed")
+	case <-time.After(time.Second):
+		t.Fatal("the reload did not fail")
+	}
+	value, err = c.Get(ctx, "config", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+	require.GreaterOrEqual(t, c.(cache.StatsProvider).CacheStats().InitializerErrors, uint64(1))
+}
+
+func TestLoadingCache_SetAndInitializer(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	c := loadingcache.New(versionLoader(&calls, nil), time.Hour)
+	defer c.(interface{ Close() error }).Close()
+
+	// The duration of Set overrides the refresh interval of the key.
+	short := 20 * time.Millisecond
+	c.Set(ctx, "config", 100, &short)
+	value, err := c.Get(ctx, "config", nil)
+	require.NoError(t, err)
+	require.Equal(t, 100, value)
+	require.Eventually(t, func() bool {
+		value, err := c.Get(ctx, "config", nil)
+		return err == nil && value != 100
+	}, time.Second, 5*time.Millisecond)
+
+	// The initializer passed to Get is used instead of the loader.
+	value, err = c.Get(ctx, "other", func(ctx context.Context, key string) (int, *time.Duration, error) {
+		return 42, nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 42, value)
+
+	require.NoError(t, c.InvalidateAll(ctx))
+	require.Equal(t, 0, c.(cache.StatsProvider).CacheStats().Entries)
+}
+
+func TestLoadingCache_WithoutLoader(t *testing.T) {
+	c := loadingcache.New[int](nil, time.Minute)
+	_, err := c.Get(context.Background(), "key", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+}
+
+func TestLoadingCache_Get_InitializerPanic(t *testing.T) {
+	ctx := context.Background()
+	c := loadingcache.New[string](nil, time.Hour)
+	defer c.(interface{ Close() error }).Close()
+
+	// The panic of the initializer is raised on the goroutine of the caller, which can recover it.
+	recovered := func() (r interface{}) {
+		defer func() { r = recover() }()
+		_, _ = c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+			panic("initializer failed")
+		})
+		return nil
+	}()
+	require.NotNil(t, recovered)
+	require.ErrorContains(t, recovered.(error), "initializer failed")
+
+	// The cache remains usable.
+	value, err := c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "value", nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+}
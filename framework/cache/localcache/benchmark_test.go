@@ -0,0 +1,49 @@
+package localcache_test
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+)
+
+// benchmarkGoroutines is the number of goroutines using the cache concurrently in the parallel benchmarks.
+const benchmarkGoroutines = 128
+
+// BenchmarkLocalCache_Parallel measures the throughput of a cache used by many goroutines, with 90% of reads and
+// 10% of writes, for an increasing number of shards.
+func BenchmarkLocalCache_Parallel(b *testing.B) {
+	ctx := context.Background()
+	keys := make([]string, 10000)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+	}
+
+	for _, shards := range []int{1, 4, 16, 64} {
+		b.Run("shards="+strconv.Itoa(shards), func(b *testing.B) {
+			c := localcache.New[int](localcache.WithShards(shards), localcache.WithMaxEntries(len(keys)))
+			defer c.(interface{ StopCleanup() }).StopCleanup()
+			for i, key := range keys {
+				c.Set(ctx, key, i, nil)
+			}
+
+			b.SetParallelism((benchmarkGoroutines + runtime.GOMAXPROCS(0) - 1) / runtime.GOMAXPROCS(0))
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := keys[i%len(keys)]
+					if i%10 == 0 {
+						c.Set(ctx, key, i, nil)
+					} else {
+						_, _ = c.Get(ctx, key, nil)
+					}
+					i += 7
+				}
+			})
+		})
+	}
+}
@@ -1,13 +1,22 @@
 package localcache
 
 import (
+	"container/list"
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	cache "github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/internal/flight"
+	"github.com/kittipat1413/go-common/util/clock"
 	"github.com/kittipat1413/go-common/util/pointer"
-	"golang.org/x/sync/singleflight"
+	syncutil "github.com/kittipat1413/go-common/util/sync"
 )
 
 const (
@@ -16,14 +25,72 @@ const (
 	defaultCleanupInterval time.Duration = 5 * time.Minute
 )
 
+// Reason is the reason an item was removed from the cache, passed to the WithEvictionCallback function.
+type Reason int
+
+const (
+	// ReasonExpired means the item expired and was removed by the cleanup, or replaced after expiring.
+	ReasonExpired Reason = iota + 1
+	// ReasonInvalidated means the item was removed by Invalidate, InvalidateMulti or InvalidateAll.
+	ReasonInvalidated
+	// ReasonCapacity means the item was evicted because of WithMaxEntries or WithMaxCost.
+	ReasonCapacity
+	// ReasonReplaced means the item was replaced by a new value of its key, e.g., by Set or a refresh-ahead.
+	ReasonReplaced
+)
+
+// String returns the name of the reason.
+func (r Reason) String() string {
+	switch r {
+	case ReasonExpired:
+		return "expired"
+	case ReasonInvalidated:
+		return "invalidated"
+	case ReasonCapacity:
+		return "capacity"
+	case ReasonReplaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
 type item[T any] struct {
 	data    T
 	expires *time.Time
+	// ttl is the duration the item was stored for, used to decide when to refresh it ahead of expiry.
+	ttl time.Duration
+	// initializer is the last initializer used to load the item, used to refresh it ahead of expiry.
+	initializer cache.Initializer[T]
+	// cost is the cost of the item computed by the cost function, only tracked when a cost function is set.
+	cost int64
+	// element is the position of the item in the LRU list, only tracked when the cache is bounded.
+	element *list.Element
+}
+
+// expired reports whether the item has expired at now. If expiration is nil, the item never expires.
+func (i item[T]) expired(now time.Time) bool {
+	return i.expires != nil && !now.Before(pointer.GetValue(i.expires))
 }
 
 type config struct {
 	defaultExpireDuration time.Duration
 	cleanupInterval       time.Duration
+	cleanupBatchSize      int
+	shards                int
+	maxInitializers       int
+	initializerWait       time.Duration
+	maxEntries            int
+	maxCost               int64
+	cost                  func(value interface{}) int64
+	refreshAhead          float64
+	ttlJitter             float64
+	slidingExpiration     time.Duration
+	snapshotFormat        SnapshotFormat
+	errorTTL              time.Duration
+	cacheableError        func(err error) bool
+	onEvict               func(key string, value interface{}, reason Reason)
+	clock                 clock.Clock
 	stopCleanupChannel    chan struct{}
 }
 
@@ -37,16 +104,165 @@ func WithDefaultExpiration(expiration time.Duration) Option {
 }
 
 // WithCleanupInterval sets the interval for automatically cleaning up expired items.
+// Zero or a negative value disables the cleanup, like WithLazyExpiration.
 func WithCleanupInterval(interval time.Duration) Option {
 	return func(c *config) {
 		c.cleanupInterval = interval
 	}
 }
 
+// WithCleanupBatchSize bounds the number of items the cleanup scans per run, so that a large cache does not hold
+// its lock for a full scan. Each run scans items in random order, so expired items are reclaimed over a few runs.
+// Zero or a negative value scans every item, the default.
+func WithCleanupBatchSize(n int) Option {
+	return func(c *config) {
+		c.cleanupBatchSize = n
+	}
+}
+
+// WithLazyExpiration disables the background cleanup. Expired items are only removed when they are accessed
+// or set again, or when DeleteExpired is called.
+func WithLazyExpiration() Option {
+	return func(c *config) {
+		c.cleanupInterval = 0
+	}
+}
+
+// WithMaxConcurrentInitializers limits the number of initializers running at once for distinct keys, so that
+// a cold cache does not open as many connections to the downstream systems as there are missing keys. A Get
+// exceeding the limit waits for a slot for up to wait, and then returns cache.ErrTooManyInitializers.
+// A zero wait waits until the context of the Get is done, a negative wait does not wait.
+func WithMaxConcurrentInitializers(n int, wait time.Duration) Option {
+	return func(c *config) {
+		c.maxInitializers = n
+		c.initializerWait = wait
+	}
+}
+
+// WithMaxEntries bounds the number of items in the cache. When the cap is reached,
+// the least recently used item is evicted. Zero or a negative value means no limit.
+func WithMaxEntries(n int) Option {
+	return func(c *config) {
+		c.maxEntries = n
+	}
+}
+
+// WithCost sets the function computing the cost of an item, e.g., its approximate size in bytes.
+// T must be the type of the cache values. Combined with WithMaxCost, it bounds the cache by memory rather than entry count.
+func WithCost[T any](cost func(value T) int64) Option {
+	return func(c *config) {
+		c.cost = func(value interface{}) int64 {
+			return cost(value.(T))
+		}
+	}
+}
+
+// WithMaxCost bounds the total cost of the items in the cache, as computed by the WithCost function.
+// When the budget is exceeded, the least recently used items are evicted, and items costing more than
+// the whole budget are not cached. Zero or a negative value means no limit.
+func WithMaxCost(maxCost int64) Option {
+	return func(c *config) {
+		c.maxCost = maxCost
+	}
+}
+
+// WithRefreshAhead makes Get refresh items in the background when they are read during the last fraction
+// of their lifetime, using the last initializer they were loaded with, e.g., 0.2 refreshes items read during
+// the last 20% of their TTL. Hot keys stay warm without the latency of a miss. The fraction must be in (0, 1).
+func WithRefreshAhead(fraction float64) Option {
+	return func(c *config) {
+		if fraction > 0 && fraction < 1 {
+			c.refreshAhead = fraction
+		}
+	}
+}
+
+// WithTTLJitter randomizes the duration of each stored item by up to ±percent%, e.g., 10 stores an item set for
+// 10 minutes for 9 to 11 minutes. It spreads the expiration of keys populated at the same time with the same TTL,
+// so they are not all reloaded at once. The percent must be in (0, 100).
+func WithTTLJitter(percent float64) Option {
+	return func(c *config) {
+		if percent > 0 && percent < 100 {
+			c.ttlJitter = percent / 100
+		}
+	}
+}
+
+// WithSlidingExpiration extends the expiration of an item to d from now every time it is read, so that
+// activity keeps the item alive, e.g., for sessions. The expiration of an item is never shortened: an item
+// set for longer than d keeps its expiration until it gets closer than d. Items that never expire are not affected.
+func WithSlidingExpiration(d time.Duration) Option {
+	return func(c *config) {
+		c.slidingExpiration = d
+	}
+}
+
+// WithErrorCaching caches the errors returned by initializers for ttl, so that a failing dependency is not called
+// again by every Get of the key: until the error expires, Get returns it without calling the initializer.
+// cacheable decides which errors are cached, e.g., to cache timeouts but not validation errors. If cacheable is nil,
+// all errors are cached. Set and Invalidate clear the cached error of a key.
+func WithErrorCaching(ttl time.Duration, cacheable func(err error) bool) Option {
+	return func(c *config) {
+		if ttl > 0 {
+			c.errorTTL = ttl
+			c.cacheableError = cacheable
+		}
+	}
+}
+
+// WithEvictionCallback sets a function called with every item removed from the cache and the reason it was removed,
+// e.g., to release resources tied to the cached values. T must be the type of the cache values.
+// The callback is called after the cache lock is released, so it may use the cache.
+func WithEvictionCallback[T any](callback func(key string, value T, reason Reason)) Option {
+	return func(c *config) {
+		c.onEvict = func(key string, value interface{}, reason Reason) {
+			callback(key, value.(T), reason)
+		}
+	}
+}
+
+// WithClock sets the clock of the expirations and the cleanup, e.g., a clock.Fake to expire items in tests without
+// sleeping. It defaults to the real clock.
+func WithClock(c clock.Clock) Option {
+	return func(cfg *config) {
+		if c != nil {
+			cfg.clock = c
+		}
+	}
+}
+
+// Stats holds the usage and eviction metrics of a localcache.
+type Stats struct {
+	// Entries is the number of items in the cache, including expired items not cleaned up yet.
+	Entries int
+	// Cost is the total cost of the items in the cache, when a cost function is set.
+	Cost int64
+	// Evictions is the number of items evicted, or not cached, because of WithMaxEntries or WithMaxCost.
+	Evictions uint64
+	// CachedErrors is the number of initializer errors cached by WithErrorCaching, including expired errors not cleaned up yet.
+	CachedErrors int
+	// CachedErrorHits is the number of Get calls that returned a cached error instead of calling the initializer.
+	CachedErrorHits uint64
+}
+
+// eviction is an item removed from the cache, pending notification to the eviction callback.
+type eviction[T any] struct {
+	key    string
+	value  T
+	reason Reason
+}
+
+// cachedError is an initializer error cached by WithErrorCaching.
+type cachedError struct {
+	err     error
+	expires time.Time
+}
+
 func newConfig(opts ...Option) *config {
 	c := &config{
 		defaultExpireDuration: defaultExpireDuration,
 		cleanupInterval:       defaultCleanupInterval,
+		clock:                 clock.Real(),
 		stopCleanupChannel:    make(chan struct{}),
 	}
 
@@ -59,8 +275,28 @@ func newConfig(opts ...Option) *config {
 
 type localcache[T any] struct {
 	mutex sync.RWMutex
-	group singleflight.Group
+	group syncutil.Group[string, T]
 	items map[string]item[T]
+	// lru holds the keys from the most to the least recently used, only when the cache is bounded.
+	lru       *list.List
+	totalCost int64
+	evictions uint64
+	// refreshing holds the keys being refreshed ahead of expiry.
+	refreshing sync.Map
+	// cachedErrors holds the initializer errors cached by WithErrorCaching.
+	cachedErrors    map[string]cachedError
+	cachedErrorHits atomic.Uint64
+	stats           cache.StatsRecorder
+	// evicted holds the items removed while holding the lock, notified to the eviction callback once it is released.
+	evicted []eviction[T]
+	// initializerSlots holds a value per running initializer, when WithMaxConcurrentInitializers is set.
+	initializerSlots chan struct{}
+	// events delivers the activity of the cache to the subscribers of Subscribe.
+	events *cache.EventBus
+	// background tracks the cleanup and the refreshes running in the background, waited for by Close.
+	background      sync.WaitGroup
+	backgroundMutex sync.Mutex
+	closed          bool
 	config
 }
 
@@ -68,29 +304,56 @@ type localcache[T any] struct {
 // It applies defaults if no options are provided.
 func New[T any](opts ...Option) cache.Cache[T] {
 	cfg := newConfig(opts...)
+	var initializerSlots chan struct{}
+	if cfg.maxInitializers > 0 {
+		initializerSlots = make(chan struct{}, cfg.maxInitializers)
+	}
+	events := &cache.EventBus{}
+	if cfg.shards > 1 {
+		return newSharded[T](cfg, initializerSlots, events)
+	}
+	return newLocalcache[T](cfg, initializerSlots, events)
+}
+
+// newLocalcache creates a localcache with cfg, sharing initializerSlots and events with the other shards, if any.
+func newLocalcache[T any](cfg *config, initializerSlots chan struct{}, events *cache.EventBus) *localcache[T] {
 	c := &localcache[T]{
-		items:  make(map[string]item[T]),
-		config: pointer.GetValue(cfg),
+		items:            make(map[string]item[T]),
+		lru:              list.New(),
+		cachedErrors:     make(map[string]cachedError),
+		initializerSlots: initializerSlots,
+		events:           events,
+		config:           pointer.GetValue(cfg),
 	}
 
 	// Start the cleanup process if a valid interval is provided
 	if c.cleanupInterval > 0 {
-		go c.startCleanup()
+		c.goBackground(c.startCleanup)
 	}
 
 	return c
 }
 
 // Get retrieves a value from the cache. If the key is missing and an initializer
-// is provided, it uses the initializer to obtain the value.
+// is provided, it uses the initializer to obtain the value. Get returns ctx.Err() as soon as ctx is done, including
+// while waiting for the initializer of another caller, which goes on and stores the value for the other callers.
 func (c *localcache[T]) Get(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
-	if data, ok := c.get(key); ok {
-		return data, nil
+	if itm, ok := c.get(key); ok {
+		c.stats.RecordHits(1)
+		c.events.Publish(cache.EventHit, key)
+		c.refreshAheadIfNeeded(ctx, key, itm, initializer)
+		return itm.data, nil
 	} else {
+		c.stats.RecordMisses(1)
+		c.events.Publish(cache.EventMiss, key)
 		if initializer == nil {
 			var zero T
 			return zero, cache.ErrCacheMiss
 		}
+		if err := c.cachedError(key); err != nil {
+			var zero T
+			return zero, err
+		}
 		return c.initialize(ctx, key, initializer)
 	}
 }
@@ -100,104 +363,656 @@ func (c *localcache[T]) Get(ctx context.Context, key string, initializer cache.I
 // If duration is NoExpireDuration, the item does not expire.
 func (c *localcache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	defer c.unlockAndNotify()
+
+	c.set(key, value, duration, nil)
+}
 
+// set adds an item to the cache. If initializer is nil, the initializer of the existing item is kept.
+// The caller must hold the write lock.
+func (c *localcache[T]) set(key string, value T, duration *time.Duration, initializer cache.Initializer[T]) {
 	var expiration *time.Time
+	var ttl time.Duration
 	if duration != nil && pointer.GetValue(duration) != NoExpireDuration { // set expiration with input duration if it's not NoExpireDuration
-		expTime := time.Now().Add(pointer.GetValue(duration))
-		expiration = pointer.ToPointer(expTime)
+		ttl = c.jitter(pointer.GetValue(duration))
+		expiration = pointer.ToPointer(c.clock.Now().Add(ttl))
 	} else if duration == nil && c.defaultExpireDuration != NoExpireDuration { // set expiration with defaultExpireDuration if it's not NoExpireDuration
-		expTime := time.Now().Add(c.defaultExpireDuration)
-		expiration = pointer.ToPointer(expTime)
+		ttl = c.jitter(c.defaultExpireDuration)
+		expiration = pointer.ToPointer(c.clock.Now().Add(ttl))
+	}
+	c.store(key, value, expiration, ttl, initializer)
+}
+
+// store adds an item to the cache expiring at expiration, nil meaning no expiration. If initializer is nil,
+// the initializer of the existing item is kept. The caller must hold the write lock.
+func (c *localcache[T]) store(key string, value T, expiration *time.Time, ttl time.Duration, initializer cache.Initializer[T]) {
+	itm := item[T]{
+		data:        value,
+		expires:     expiration,
+		ttl:         ttl,
+		initializer: initializer,
+	}
+	if c.cost != nil {
+		itm.cost = c.cost(value)
+		if c.maxCost > 0 && itm.cost > c.maxCost {
+			// The item alone exceeds the budget, the previous value of key is stale.
+			c.delete(key, ReasonCapacity)
+			c.evictions++
+			return
+		}
+	}
+	delete(c.cachedErrors, key)
+	if existing, found := c.items[key]; found {
+		reason := ReasonReplaced
+		if existing.expired(c.clock.Now()) {
+			reason = ReasonExpired
+		}
+		c.evict(key, existing.data, reason)
+		c.totalCost -= existing.cost
+		itm.element = existing.element
+		if itm.initializer == nil {
+			itm.initializer = existing.initializer
+		}
+	}
+	if c.bounded() {
+		if itm.element != nil {
+			c.lru.MoveToFront(itm.element)
+		} else {
+			itm.element = c.lru.PushFront(key)
+		}
+	}
+	c.items[key] = itm
+	c.totalCost += itm.cost
+	c.events.Publish(cache.EventSet, key)
+
+	// Evict the least recently used items once the cap or the budget is exceeded.
+	for (c.maxEntries > 0 && len(c.items) > c.maxEntries) || (c.maxCost > 0 && c.totalCost > c.maxCost) {
+		c.delete(c.lru.Back().Value.(string), ReasonCapacity)
+		c.evictions++
+	}
+}
+
+// SetIfAbsent stores value under key if key is missing or expired, and reports whether it was stored.
+func (c *localcache[T]) SetIfAbsent(ctx context.Context, key string, value T, duration *time.Duration) bool {
+	c.mutex.Lock()
+	defer c.unlockAndNotify()
+
+	if _, ok := c.peek(key, c.clock.Now()); ok {
+		return false
+	}
+	c.set(key, value, duration, nil)
+	return true
+}
+
+// GetOrSet returns the value of key if it is in the cache, with loaded true. Otherwise, it stores value
+// and returns it, with loaded false.
+func (c *localcache[T]) GetOrSet(ctx context.Context, key string, value T, duration *time.Duration) (T, bool) {
+	c.mutex.Lock()
+	defer c.unlockAndNotify()
+
+	if itm, ok := c.lookup(key, c.clock.Now()); ok {
+		c.stats.RecordHits(1)
+		c.events.Publish(cache.EventHit, key)
+		return itm.data, true
+	}
+	c.stats.RecordMisses(1)
+	c.events.Publish(cache.EventMiss, key)
+	c.set(key, value, duration, nil)
+	return value, false
+}
+
+// Increment adds delta to the value of key and returns the new value. It returns cache.ErrNotNumeric if T is not
+// an integer type, and an error if the result does not fit in T. A missing key starts from zero and expires after
+// duration, an existing key keeps its expiration.
+func (c *localcache[T]) Increment(ctx context.Context, key string, delta int64, duration *time.Duration) (int64, error) {
+	c.mutex.Lock()
+	defer c.unlockAndNotify()
+
+	itm, found := c.peek(key, c.clock.Now())
+	value, result, err := addDelta(itm.data, delta)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment %q: %w", key, err)
+	}
+	if found {
+		c.store(key, value, itm.expires, itm.ttl, nil)
+	} else {
+		c.set(key, value, duration, nil)
+	}
+	return result, nil
+}
+
+// Decrement subtracts delta from the value of key and returns the new value, like Increment.
+func (c *localcache[T]) Decrement(ctx context.Context, key string, delta int64, duration *time.Duration) (int64, error) {
+	return c.Increment(ctx, key, -delta, duration)
+}
+
+// GetMulti returns the values of the keys found in the cache, taking the lock once.
+// Missing and expired keys are absent from the result.
+func (c *localcache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	c.lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+	values := make(map[string]T, len(keys))
+	for _, key := range keys {
+		if itm, ok := c.lookup(key, now); ok {
+			values[key] = itm.data
+			c.events.Publish(cache.EventHit, key)
+		} else {
+			c.events.Publish(cache.EventMiss, key)
+		}
 	}
+	c.stats.RecordHits(len(values))
+	c.stats.RecordMisses(len(keys) - len(values))
+	return values, nil
+}
+
+// SetMulti adds every item to the cache with the same duration, taking the lock once.
+func (c *localcache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	c.mutex.Lock()
+	defer c.unlockAndNotify()
 
-	c.items[key] = item[T]{
-		data:    value,
-		expires: expiration,
+	for key, value := range items {
+		c.set(key, value, duration, nil)
 	}
 }
 
+// InvalidateMulti removes the keys from the cache, taking the lock once.
+func (c *localcache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	c.mutex.Lock()
+	defer c.unlockAndNotify()
+
+	for _, key := range keys {
+		c.delete(key, ReasonInvalidated)
+	}
+	return nil
+}
+
+// Stats returns the usage and eviction metrics of the cache.
+func (c *localcache[T]) Stats() Stats {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return Stats{
+		Entries:         len(c.items),
+		Cost:            c.totalCost,
+		Evictions:       c.evictions,
+		CachedErrors:    len(c.cachedErrors),
+		CachedErrorHits: c.cachedErrorHits.Load(),
+	}
+}
+
+// GetWithTTL returns the value of key and its remaining time to live, or NoExpireDuration if it never expires.
+// It returns cache.ErrCacheMiss if key is missing or expired. Unlike Get, it does not count as a use of the item
+// for the LRU eviction and the statistics, so that admin and debugging tools do not skew them.
+func (c *localcache[T]) GetWithTTL(ctx context.Context, key string) (T, time.Duration, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	now := c.clock.Now()
+	itm, ok := c.peek(key, now)
+	if !ok {
+		var zero T
+		return zero, 0, cache.ErrCacheMiss
+	}
+	if itm.expires == nil {
+		return itm.data, NoExpireDuration, nil
+	}
+	return itm.data, pointer.GetValue(itm.expires).Sub(now), nil
+}
+
+// Exists reports whether key is in the cache and has not expired, without counting as a use of the item.
+func (c *localcache[T]) Exists(ctx context.Context, key string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	_, ok := c.peek(key, c.clock.Now())
+	return ok
+}
+
+// Keys returns the keys of the items that have not expired, in no particular order.
+func (c *localcache[T]) Keys(ctx context.Context) []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	now := c.clock.Now()
+	keys := make([]string, 0, len(c.items))
+	for key, itm := range c.items {
+		if !itm.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Len returns the number of items that have not expired. Stats.Entries also counts expired items not cleaned up yet.
+func (c *localcache[T]) Len(ctx context.Context) int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	now := c.clock.Now()
+	n := 0
+	for _, itm := range c.items {
+		if !itm.expired(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// Subscribe streams the activity of the cache, implementing cache.EventSource: the items set, the keys hit and
+// missed by Get, GetMulti and GetOrSet, and the items expired, evicted and invalidated. Replacing the value of
+// a key only publishes a cache.EventSet. Publishing never blocks, so a slow subscriber does not slow down the
+// cache, but loses the events published while its buffer is full.
+func (c *localcache[T]) Subscribe(buffer int) (<-chan cache.Event, func()) {
+	return c.events.Subscribe(buffer)
+}
+
+// CacheStats returns the metrics common to all cache backends, implementing cache.StatsProvider.
+func (c *localcache[T]) CacheStats() cache.Stats {
+	stats := c.stats.Snapshot()
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	stats.Entries = len(c.items)
+	stats.Evictions = c.evictions
+	return stats
+}
+
 func (c *localcache[T]) Invalidate(ctx context.Context, key string) error {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	defer c.unlockAndNotify()
 
-	delete(c.items, key)
+	c.delete(key, ReasonInvalidated)
 	return nil
 }
 
 func (c *localcache[T]) InvalidateAll(ctx context.Context) error {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	defer c.unlockAndNotify()
 
+	for key, itm := range c.items {
+		c.evict(key, itm.data, ReasonInvalidated)
+	}
 	c.items = make(map[string]item[T])
+	c.cachedErrors = make(map[string]cachedError)
+	c.lru.Init()
+	c.totalCost = 0
 	return nil
 }
 
-func (c *localcache[T]) get(key string) (result T, ok bool) {
+// An expired item of key is removed from the cache.
+func (c *localcache[T]) get(key string) (item[T], bool) {
+	c.lock()
+	itm, ok := c.lookup(key, c.clock.Now())
+	_, found := c.items[key]
+	c.unlock()
+
+	if !ok && found {
+		c.deleteIfExpired(key)
+	}
+	return itm, ok
+}
+
+// deleteIfExpired removes the item of key if it has expired.
+func (c *localcache[T]) deleteIfExpired(key string) {
+	c.mutex.Lock()
+	defer c.unlockAndNotify()
+
+	if itm, found := c.items[key]; found && itm.expired(c.clock.Now()) {
+		c.delete(key, ReasonExpired)
+	}
+}
+
+// lookup returns the item of key if it has not expired at now, marks it as recently used and extends
+// its expiration if sliding expiration is enabled.
+// The caller must hold the lock returned by lock.
+func (c *localcache[T]) lookup(key string, now time.Time) (item[T], bool) {
+	itm, ok := c.peek(key, now)
+	if !ok {
+		return itm, false
+	}
+	if itm.element != nil {
+		c.lru.MoveToFront(itm.element)
+	}
+	if c.slidingExpiration > 0 && itm.expires != nil {
+		if extended := now.Add(c.slidingExpiration); extended.After(pointer.GetValue(itm.expires)) {
+			itm.expires = pointer.ToPointer(extended)
+			c.items[key] = itm
+		}
+	}
+	return itm, true
+}
+
+// peek returns the item of key if it has not expired at now, without marking it as recently used.
+// The caller must hold the read lock.
+func (c *localcache[T]) peek(key string, now time.Time) (item[T], bool) {
+	if itm, found := c.items[key]; found && !itm.expired(now) {
+		return itm, true
+	}
+	return item[T]{}, false
+}
+
+// addDelta adds delta to value, which must be of an integer type, and returns the result as T and as an int64.
+func addDelta[T any](value T, delta int64) (T, int64, error) {
+	v := reflect.ValueOf(&value).Elem()
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		result := v.Int() + delta
+		if (delta > 0 && result < v.Int()) || (delta < 0 && result > v.Int()) || v.OverflowInt(result) {
+			return value, 0, fmt.Errorf("result overflows %s", v.Type())
+		}
+		v.SetInt(result)
+		return value, result, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		current := v.Uint()
+		if (delta < 0 && uint64(-delta) > current) || current > math.MaxInt64 {
+			return value, 0, fmt.Errorf("result overflows %s", v.Type())
+		}
+		result := int64(current) + delta
+		if result < 0 || v.OverflowUint(uint64(result)) {
+			return value, 0, fmt.Errorf("result overflows %s", v.Type())
+		}
+		v.SetUint(uint64(result))
+		return value, result, nil
+	default:
+		return value, 0, cache.ErrNotNumeric
+	}
+}
+
+// cachedError returns the unexpired initializer error cached for key, if any.
+func (c *localcache[T]) cachedError(key string) error {
+	if c.errorTTL <= 0 {
+		return nil
+	}
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
+	if cached, found := c.cachedErrors[key]; found && c.clock.Now().Before(cached.expires) {
+		c.cachedErrorHits.Add(1)
+		return cached.err
+	}
+	return nil
+}
+
+// cacheError caches err for key if error caching is enabled and err is cacheable.
+func (c *localcache[T]) cacheError(key string, err error) {
+	if c.errorTTL <= 0 || (c.cacheableError != nil && !c.cacheableError(err)) {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.cachedErrors[key] = cachedError{err: err, expires: c.clock.Now().Add(c.errorTTL)}
+}
+
+// load calls initializer for key, recording its latency.
+func (c *localcache[T]) load(ctx context.Context, key string, initializer cache.Initializer[T]) (result T, duration *time.Duration, err error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return result, nil, err
+	}
+	defer release()
+
+	start := c.clock.Now()
+	defer func() { c.stats.RecordInitializer(c.clock.Since(start), err) }()
+	defer flight.Recover(&err)
+	return initializer(ctx, key)
+}
+
+// acquire takes an initializer slot when their number is limited, waiting for one to free up according to
+// WithMaxConcurrentInitializers, and returns the function releasing it.
+func (c *localcache[T]) acquire(ctx context.Context) (func(), error) {
+	if c.initializerSlots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-c.initializerSlots }
+	select {
+	case c.initializerSlots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	if c.initializerWait < 0 {
+		return nil, cache.ErrTooManyInitializers
+	}
+
+	var timeout <-chan time.Time
+	if c.initializerWait > 0 {
+		timer := c.clock.NewTimer(c.initializerWait)
+		defer timer.Stop()
+		timeout = timer.C()
+	}
+	select {
+	case c.initializerSlots <- struct{}{}:
+		return release, nil
+	case <-timeout:
+		return nil, cache.ErrTooManyInitializers
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// jitter randomizes duration by up to ±ttlJitter of its value.
+func (c *localcache[T]) jitter(duration time.Duration) time.Duration {
+	if c.ttlJitter <= 0 || duration <= 0 {
+		return duration
+	}
+	return duration + time.Duration(float64(duration)*c.ttlJitter*(2*rand.Float64()-1))
+}
+
+// refreshAheadIfNeeded reloads itm in the background if it is in the refresh-ahead window of its lifetime.
+// The initializer passed to Get takes precedence over the one the item was loaded with.
+func (c *localcache[T]) refreshAheadIfNeeded(ctx context.Context, key string, itm item[T], initializer cache.Initializer[T]) {
+	if c.refreshAhead <= 0 || itm.expires == nil || itm.ttl <= 0 {
+		return
+	}
+	if initializer == nil {
+		initializer = itm.initializer
+	}
+	if initializer == nil || itm.expires.Sub(c.clock.Now()) > time.Duration(float64(itm.ttl)*c.refreshAhead) {
+		return
+	}
+	// Refresh each key once at a time.
+	if _, refreshing := c.refreshing.LoadOrStore(key, struct{}{}); refreshing {
+		return
+	}
+
+	// The refresh outlives the request that triggered it.
+	ctx = context.WithoutCancel(ctx)
+	started := c.goBackground(func() {
+		defer c.refreshing.Delete(key)
+		_ = c.refresh(ctx, key, initializer)
+	})
+	if !started {
+		c.refreshing.Delete(key)
+	}
+}
+
+// refresh reloads key with initializer. On failure, including a panic, the current value is served until it
+// expires, and the failure is counted in the initializer errors of CacheStats.
+func (c *localcache[T]) refresh(ctx context.Context, key string, initializer cache.Initializer[T]) (err error) {
+	defer flight.Recover(&err)
+	result, duration, err := c.load(ctx, key, initializer)
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.unlockAndNotify()
+	c.set(key, result, duration, initializer)
+	return nil
+}
+
+// goBackground runs f in a goroutine waited for by Close, and reports whether it was started, which it is not
+// once the cache is closed.
+func (c *localcache[T]) goBackground(f func()) bool {
+	c.backgroundMutex.Lock()
+	defer c.backgroundMutex.Unlock()
+
+	if c.closed {
+		return false
+	}
+	c.background.Add(1)
+	go func() {
+		defer c.background.Done()
+		f()
+	}()
+	return true
+}
+
+// lock acquires the lock required for reads. Reads update the LRU list of bounded caches and the expiration
+// of sliding items, which requires the write lock.
+func (c *localcache[T]) lock() {
+	if c.writesOnRead() {
+		c.mutex.Lock()
+	} else {
+		c.mutex.RLock()
+	}
+}
+
+// unlock releases the lock acquired by lock.
+func (c *localcache[T]) unlock() {
+	if c.writesOnRead() {
+		c.mutex.Unlock()
+	} else {
+		c.mutex.RUnlock()
+	}
+}
+
+// delete removes key from the items and the LRU list for reason. The caller must hold the write lock.
+func (c *localcache[T]) delete(key string, reason Reason) {
+	delete(c.cachedErrors, key)
 	if itm, found := c.items[key]; found {
-		// If expiration is nil, the item never expires
-		if itm.expires == nil || time.Now().Before(pointer.GetValue(itm.expires)) {
-			result, ok = itm.data, true
-			return
+		if itm.element != nil {
+			c.lru.Remove(itm.element)
 		}
+		c.totalCost -= itm.cost
+		delete(c.items, key)
+		c.evict(key, itm.data, reason)
+	}
+}
+
+// evict records the removal of key for the eviction callback, and publishes it to the subscribers of Subscribe.
+// The caller must hold the write lock.
+func (c *localcache[T]) evict(key string, value T, reason Reason) {
+	switch reason {
+	case ReasonExpired:
+		c.events.Publish(cache.EventExpire, key)
+	case ReasonCapacity:
+		c.events.Publish(cache.EventEvict, key)
+	case ReasonInvalidated:
+		c.events.Publish(cache.EventInvalidate, key)
+	}
+	if c.onEvict != nil {
+		c.evicted = append(c.evicted, eviction[T]{key: key, value: value, reason: reason})
 	}
-	return
+}
+
+// unlockAndNotify releases the write lock, then calls the eviction callback with the items removed while holding it.
+func (c *localcache[T]) unlockAndNotify() {
+	evicted := c.evicted
+	c.evicted = nil
+	c.mutex.Unlock()
+
+	for _, e := range evicted {
+		c.onEvict(e.key, e.value, e.reason)
+	}
+}
+
+// writesOnRead reports whether reads modify the cache.
+func (c *localcache[T]) writesOnRead() bool {
+	return c.bounded() || c.slidingExpiration > 0
+}
+
+// bounded reports whether items are evicted when the cache is full, which requires tracking their recency.
+func (c *localcache[T]) bounded() bool {
+	return c.maxEntries > 0 || c.maxCost > 0
 }
 
 func (c *localcache[T]) initialize(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
-	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+	return flight.Do(ctx, &c.group, key, func() (T, error) {
 		// Double-check if the item was initialized by another goroutine
-		if data, ok := c.get(key); ok {
-			return data, nil
+		if itm, ok := c.get(key); ok {
+			return itm.data, nil
 		}
 
-		result, duration, err := initializer()
+		result, duration, err := c.load(ctx, key, initializer)
 		if err != nil {
-			var zero T
-			return zero, err
+			var panicked *flight.PanicError
+			// Panics are re-panicked by the callers, and the errors of cancelled loads are not theirs: neither is cached.
+			if !errors.As(err, &panicked) && ctx.Err() == nil && !errors.Is(err, cache.ErrTooManyInitializers) {
+				c.cacheError(key, err)
+			}
+			return result, err
 		}
 
-		// Set the item in the cache
-		c.Set(ctx, key, result, duration)
+		// Set the item in the cache, remembering its initializer for refresh-ahead
+		c.mutex.Lock()
+		c.set(key, result, duration, initializer)
+		c.unlockAndNotify()
 
 		return result, nil
 	})
-	if err != nil {
-		var zero T
-		return zero, err
-	}
-	return v.(T), nil
 }
 
 // startCleanup runs a background goroutine to periodically remove expired items.
 func (c *localcache[T]) startCleanup() {
-	ticker := time.NewTicker(c.cleanupInterval)
+	ticker := c.clock.NewTicker(c.cleanupInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			c.deleteExpired()
+		case <-ticker.C():
+			c.deleteExpired(c.cleanupBatchSize)
 		case <-c.stopCleanupChannel:
 			return
 		}
 	}
 }
 
-// deleteExpired removes all expired items from the cache.
-func (c *localcache[T]) deleteExpired() {
+// DeleteExpired removes all expired items from the cache immediately, regardless of the cleanup options,
+// and returns the number of items removed.
+func (c *localcache[T]) DeleteExpired(ctx context.Context) int {
+	return c.deleteExpired(0)
+}
+
+// deleteExpired removes the expired items from the cache, scanning at most limit items if limit is positive,
+// and returns the number of items removed. Map iteration starts at a random item, so successive limited runs
+// scan different items.
+func (c *localcache[T]) deleteExpired(limit int) int {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	defer c.unlockAndNotify()
 
-	now := time.Now()
+	now := c.clock.Now()
+	scanned, removed := 0, 0
 	for key, itm := range c.items {
-		if itm.expires != nil && itm.expires.Before(now) {
-			delete(c.items, key)
+		if limit > 0 && scanned >= limit {
+			break
+		}
+		scanned++
+		if itm.expired(now) {
+			c.delete(key, ReasonExpired)
+			removed++
+		}
+	}
+	for key, cached := range c.cachedErrors {
+		if cached.expires.Before(now) {
+			delete(c.cachedErrors, key)
 		}
 	}
+	return removed
+}
+
+// Close stops the background cleanup and refreshes, and waits for the running ones to finish, implementing
+// cache.Closer. The cache remains usable: Get still loads missing keys, without refreshing them ahead of expiry,
+// and expired items are removed when accessed or by DeleteExpired.
+func (c *localcache[T]) Close() error {
+	c.backgroundMutex.Lock()
+	c.closed = true
+	c.backgroundMutex.Unlock()
+
+	c.StopCleanup()
+	c.background.Wait()
+	return nil
 }
 
 // StopCleanup stops the background cleanup process.
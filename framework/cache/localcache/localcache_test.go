@@ -4,14 +4,18 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
 
 	"github.com/kittipat1413/go-common/framework/cache"
 	"github.com/kittipat1413/go-common/framework/cache/localcache"
+	"github.com/kittipat1413/go-common/util/clock"
 )
 
 func TestLocalCache_SetAndGet(t *testing.T) {
@@ -40,7 +44,7 @@ func TestLocalCache_GetWithInitializer(t *testing.T) {
 	duration := time.Minute
 
 	var initializerCalled int
-	initializer := func() (int, *time.Duration, error) {
+	initializer := func(ctx context.Context, key string) (int, *time.Duration, error) {
 		initializerCalled++
 		return expectedValue, &duration, nil
 	}
@@ -59,6 +63,23 @@ func TestLocalCache_GetWithInitializer(t *testing.T) {
 	require.Equal(t, 0, initializerCalled, "Initializer should not have been called again")
 }
 
+func TestLocalCache_GetWithInitializer_ContextAndKey(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	c := localcache.New[string]()
+
+	// A single initializer loads any key, with the context of the Get call.
+	initializer := func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return ctx.Value(ctxKey{}).(string) + ":" + key, nil, nil
+	}
+
+	for _, key := range []string{"a", "b"} {
+		value, err := c.Get(ctx, key, initializer)
+		require.NoError(t, err)
+		require.Equal(t, "request:"+key, value)
+	}
+}
+
 func TestLocalCache_Get_CacheMiss(t *testing.T) {
 	ctx := context.Background()
 	c := localcache.New[string]()
@@ -77,7 +98,7 @@ func TestLocalCache_Get_InitializerError(t *testing.T) {
 	key := "initErrorKey"
 	expectedErr := errors.New("initializer error")
 
-	initializer := func() (string, *time.Duration, error) {
+	initializer := func(ctx context.Context, key string) (string, *time.Duration, error) {
 		return "", nil, expectedErr
 	}
 
@@ -257,7 +278,7 @@ func TestLocalCache_Concurrency(t *testing.T) {
 	var initializerCallCount int
 	var mu sync.Mutex
 
-	initializer := func() (int, *time.Duration, error) {
+	initializer := func(ctx context.Context, key string) (int, *time.Duration, error) {
 		mu.Lock()
 		initializerCallCount++
 		mu.Unlock()
@@ -283,3 +304,828 @@ func TestLocalCache_Concurrency(t *testing.T) {
 
 	require.Equal(t, 1, initializerCallCount, "Initializer should have been called exactly once")
 }
+
+func TestLocalCache_MaxEntries(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[int](localcache.WithMaxEntries(2))
+
+	c.Set(ctx, "a", 1, nil)
+	c.Set(ctx, "b", 2, nil)
+
+	// Reading "a" makes "b" the least recently used item.
+	_, err := c.Get(ctx, "a", nil)
+	require.NoError(t, err)
+
+	c.Set(ctx, "c", 3, nil)
+
+	_, err = c.Get(ctx, "b", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss, "Expected the least recently used item to be evicted")
+	for key, expected := range map[string]int{"a": 1, "c": 3} {
+		value, err := c.Get(ctx, key, nil)
+		require.NoError(t, err)
+		require.Equal(t, expected, value)
+	}
+
+	// Updating an existing key does not evict anything.
+	c.Set(ctx, "a", 10, nil)
+	value, err := c.Get(ctx, "c", nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, value)
+
+	// Invalidated keys free their slot.
+	require.NoError(t, c.Invalidate(ctx, "a"))
+	c.Set(ctx, "d", 4, nil)
+	value, err = c.Get(ctx, "c", nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, value)
+
+	val := reflect.ValueOf(c).Elem().FieldByName("items")
+	require.Equal(t, 2, val.Len(), "Expected the cache to hold at most MaxEntries items")
+}
+
+func TestLocalCache_MaxEntries_Concurrency(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[int](localcache.WithMaxEntries(10))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := string(rune('a' + (i+j)%26))
+				c.Set(ctx, key, j, nil)
+				_, _ = c.Get(ctx, key, nil)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	val := reflect.ValueOf(c).Elem().FieldByName("items")
+	require.LessOrEqual(t, val.Len(), 10)
+}
+
+func TestLocalCache_MaxCost(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[string](
+		localcache.WithCost(func(value string) int64 { return int64(len(value)) }),
+		localcache.WithMaxCost(10),
+	)
+	stats, ok := c.(interface{ Stats() localcache.Stats })
+	require.True(t, ok, "Expected localcache to have a Stats method")
+
+	c.Set(ctx, "a", "aaaa", nil)
+	c.Set(ctx, "b", "bbbb", nil)
+	require.Equal(t, localcache.Stats{Entries: 2, Cost: 8}, stats.Stats())
+
+	// Reading "a" makes "b" the least recently used item.
+	_, err := c.Get(ctx, "a", nil)
+	require.NoError(t, err)
+
+	c.Set(ctx, "c", "cccc", nil)
+	_, err = c.Get(ctx, "b", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss, "Expected the least recently used item to be evicted")
+	require.Equal(t, localcache.Stats{Entries: 2, Cost: 8, Evictions: 1}, stats.Stats())
+
+	// Replacing a value updates the total cost.
+	c.Set(ctx, "a", "a", nil)
+	require.Equal(t, localcache.Stats{Entries: 2, Cost: 5, Evictions: 1}, stats.Stats())
+
+	// An item exceeding the whole budget is not cached and removes the stale value of its key.
+	c.Set(ctx, "c", "ccccccccccc", nil)
+	_, err = c.Get(ctx, "c", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+	require.Equal(t, localcache.Stats{Entries: 1, Cost: 1, Evictions: 2}, stats.Stats())
+
+	require.NoError(t, c.Invalidate(ctx, "a"))
+	require.Equal(t, localcache.Stats{Entries: 0, Cost: 0, Evictions: 2}, stats.Stats())
+}
+
+func TestLocalCache_BatchOperations(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[int](localcache.WithMaxEntries(3))
+	batch, ok := c.(cache.BatchCache[int])
+	require.True(t, ok, "Expected localcache to implement BatchCache")
+
+	expired := time.Nanosecond
+	batch.SetMulti(ctx, map[string]int{"a": 1, "b": 2}, nil)
+	c.Set(ctx, "expired", 0, &expired)
+	time.Sleep(time.Millisecond)
+
+	values, err := batch.GetMulti(ctx, []string{"a", "b", "expired", "missing"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"a": 1, "b": 2}, values)
+
+	require.NoError(t, batch.InvalidateMulti(ctx, []string{"a", "missing"}))
+	values, err = batch.GetMulti(ctx, []string{"a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"b": 2}, values)
+}
+
+func TestLocalCache_RefreshAhead(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[int](localcache.WithRefreshAhead(0.5))
+
+	var calls atomic.Int32
+	duration := 200 * time.Millisecond
+	initializer := func(ctx context.Context, key string) (int, *time.Duration, error) {
+		return int(calls.Add(1)), &duration, nil
+	}
+
+	value, err := c.Get(ctx, "key", initializer)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+
+	// Reads early in the lifetime of the item do not refresh it.
+	value, err = c.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+	require.Equal(t, int32(1), calls.Load())
+
+	// A read in the refresh-ahead window serves the current value and refreshes it in the background,
+	// with the initializer the item was loaded with.
+	time.Sleep(120 * time.Millisecond)
+	value, err = c.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+	require.Eventually(t, func() bool {
+		value, err := c.Get(ctx, "key", nil)
+		return err == nil && value == 2
+	}, time.Second, 5*time.Millisecond)
+
+	// The refreshed item got a new lifetime.
+	time.Sleep(120 * time.Millisecond)
+	_, err = c.Get(ctx, "key", nil)
+	require.NoError(t, err)
+}
+
+func TestLocalCache_RefreshAhead_Panic(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[int](localcache.WithRefreshAhead(0.5))
+	defer cache.Close(c)
+
+	var calls atomic.Int32
+	duration := 200 * time.Millisecond
+	initializer := func(ctx context.Context, key string) (int, *time.Duration, error) {
+		if calls.Add(1) > 1 {
+			panic("refresh failed")
+		}
+		return 1, &duration, nil
+	}
+	value, err := c.Get(ctx, "key", initializer)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+
+	// A panicking refresh fails like any other: the current value is served, and the failure is counted.
+	time.Sleep(120 * time.Millisecond)
+	value, err = c.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+	require.Eventually(t, func() bool {
+		return c.(cache.StatsProvider).CacheStats().InitializerErrors == 1
+	}, time.Second, 5*time.Millisecond)
+	value, err = c.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+}
+
+func TestLocalCache_TTLJitter(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[int](localcache.WithTTLJitter(10), localcache.WithDefaultExpiration(time.Hour))
+
+	duration := 10 * time.Minute
+	noExpiration := localcache.NoExpireDuration
+	for i := 0; i < 100; i++ {
+		c.Set(ctx, strconv.Itoa(i), i, &duration)
+	}
+	c.Set(ctx, "default", 0, nil)
+	c.Set(ctx, "persistent", 0, &noExpiration)
+
+	items := reflect.ValueOf(c).Elem().FieldByName("items")
+	ttl := func(key string) time.Duration {
+		return time.Duration(items.MapIndex(reflect.ValueOf(key)).FieldByName("ttl").Int())
+	}
+	durations := make(map[time.Duration]struct{})
+	for i := 0; i < 100; i++ {
+		d := ttl(strconv.Itoa(i))
+		require.GreaterOrEqual(t, d, 9*time.Minute)
+		require.LessOrEqual(t, d, 11*time.Minute)
+		durations[d] = struct{}{}
+	}
+	require.Greater(t, len(durations), 1, "Expected the durations to be randomized")
+
+	require.InDelta(t, time.Hour, ttl("default"), float64(6*time.Minute))
+	require.True(t, items.MapIndex(reflect.ValueOf("persistent")).FieldByName("expires").IsNil(), "Expected the persistent item to never expire")
+}
+
+func TestLocalCache_ErrorCaching(t *testing.T) {
+	ctx := context.Background()
+	errUnavailable := errors.New("service unavailable")
+	errNotFound := errors.New("not found")
+	c := localcache.New[string](localcache.WithErrorCaching(50*time.Millisecond, func(err error) bool {
+		return errors.Is(err, errUnavailable)
+	}))
+	stats := c.(interface{ Stats() localcache.Stats })
+
+	var calls int
+	failing := func(err error) cache.Initializer[string] {
+		return func(ctx context.Context, key string) (string, *time.Duration, error) {
+			calls++
+			return "", nil, err
+		}
+	}
+
+	// A cacheable error is returned without calling the initializer until it expires.
+	_, err := c.Get(ctx, "key", failing(errUnavailable))
+	require.ErrorIs(t, err, errUnavailable)
+	_, err = c.Get(ctx, "key", failing(errUnavailable))
+	require.ErrorIs(t, err, errUnavailable)
+	require.Equal(t, 1, calls, "Initializer should have been called once")
+	require.Equal(t, localcache.Stats{CachedErrors: 1, CachedErrorHits: 1}, stats.Stats())
+
+	// Get without initializer still reports a miss.
+	_, err = c.Get(ctx, "key", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	time.Sleep(60 * time.Millisecond)
+	value, err := c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) { return "value", nil, nil })
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+	require.Equal(t, localcache.Stats{Entries: 1, CachedErrorHits: 1}, stats.Stats())
+
+	// Errors rejected by the predicate are not cached.
+	calls = 0
+	_, err = c.Get(ctx, "other", failing(errNotFound))
+	require.ErrorIs(t, err, errNotFound)
+	_, err = c.Get(ctx, "other", failing(errNotFound))
+	require.ErrorIs(t, err, errNotFound)
+	require.Equal(t, 2, calls)
+
+	// Set clears the cached error of a key.
+	_, err = c.Get(ctx, "other", failing(errUnavailable))
+	require.ErrorIs(t, err, errUnavailable)
+	c.Set(ctx, "other", "set", nil)
+	value, err = c.Get(ctx, "other", failing(errUnavailable))
+	require.NoError(t, err)
+	require.Equal(t, "set", value)
+	require.Equal(t, 0, stats.Stats().CachedErrors)
+}
+
+func TestLocalCache_EvictionCallback(t *testing.T) {
+	ctx := context.Background()
+
+	type evicted struct {
+		key    string
+		value  int
+		reason localcache.Reason
+	}
+	var mu sync.Mutex
+	var got []evicted
+	var c cache.Cache[int]
+	c = localcache.New[int](
+		localcache.WithMaxEntries(2),
+		localcache.WithCleanupInterval(10*time.Millisecond),
+		localcache.WithEvictionCallback(func(key string, value int, reason localcache.Reason) {
+			// The callback may use the cache.
+			_, _ = c.Get(ctx, key, nil)
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, evicted{key, value, reason})
+		}),
+	)
+	defer c.(interface{ StopCleanup() }).StopCleanup()
+
+	short := 20 * time.Millisecond
+	c.Set(ctx, "a", 1, nil)
+	c.Set(ctx, "a", 2, nil)
+	c.Set(ctx, "b", 3, nil)
+	c.Set(ctx, "c", 4, &short)
+	require.NoError(t, c.Invalidate(ctx, "b"))
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) == 4
+	}, time.Second, 5*time.Millisecond)
+	c.Set(ctx, "d", 5, nil)
+	require.NoError(t, c.InvalidateAll(ctx))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []evicted{
+		{"a", 1, localcache.ReasonReplaced},
+		{"a", 2, localcache.ReasonCapacity},
+		{"b", 3, localcache.ReasonInvalidated},
+		{"c", 4, localcache.ReasonExpired},
+		{"d", 5, localcache.ReasonInvalidated},
+	}, got)
+}
+
+func TestLocalCache_CacheStats(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[int](localcache.WithMaxEntries(1))
+	provider, ok := c.(cache.StatsProvider)
+	require.True(t, ok, "Expected localcache to implement StatsProvider")
+
+	initializer := func(ctx context.Context, key string) (int, *time.Duration, error) {
+		time.Sleep(time.Millisecond)
+		return len(key), nil, nil
+	}
+	_, err := c.Get(ctx, "a", initializer)
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "a", initializer)
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "missing", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+	_, err = c.Get(ctx, "bb", func(ctx context.Context, key string) (int, *time.Duration, error) {
+		return 0, nil, errors.New("failed")
+	})
+	require.Error(t, err)
+	c.Set(ctx, "bb", 2, nil)
+
+	_, err = c.(cache.BatchCache[int]).GetMulti(ctx, []string{"a", "bb"})
+	require.NoError(t, err)
+
+	stats := provider.CacheStats()
+	require.GreaterOrEqual(t, stats.InitializerDuration, time.Millisecond)
+	stats.InitializerDuration = 0
+	require.Equal(t, cache.Stats{
+		Hits:              2,
+		Misses:            4,
+		Evictions:         1,
+		Entries:           1,
+		InitializerCalls:  2,
+		InitializerErrors: 1,
+	}, stats)
+}
+
+func TestLocalCache_Introspection(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[string](localcache.WithMaxEntries(3))
+	inspector := c.(interface {
+		GetWithTTL(ctx context.Context, key string) (string, time.Duration, error)
+		Exists(ctx context.Context, key string) bool
+		Keys(ctx context.Context) []string
+		Len(ctx context.Context) int
+	})
+
+	minute := time.Minute
+	expired := time.Nanosecond
+	noExpiration := localcache.NoExpireDuration
+	c.Set(ctx, "a", "1", &minute)
+	c.Set(ctx, "b", "2", &noExpiration)
+	c.Set(ctx, "expired", "3", &expired)
+	time.Sleep(time.Millisecond)
+
+	value, ttl, err := inspector.GetWithTTL(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, "1", value)
+	require.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	value, ttl, err = inspector.GetWithTTL(ctx, "b")
+	require.NoError(t, err)
+	require.Equal(t, "2", value)
+	require.Equal(t, localcache.NoExpireDuration, ttl)
+
+	_, _, err = inspector.GetWithTTL(ctx, "expired")
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	require.True(t, inspector.Exists(ctx, "a"))
+	require.False(t, inspector.Exists(ctx, "expired"))
+	require.False(t, inspector.Exists(ctx, "missing"))
+	require.ElementsMatch(t, []string{"a", "b"}, inspector.Keys(ctx))
+	require.Equal(t, 2, inspector.Len(ctx))
+
+	// Introspection does not count as a use: "a" is still the least recently used item.
+	c.Set(ctx, "c", "4", nil)
+	require.False(t, inspector.Exists(ctx, "a"))
+	require.Equal(t, cache.Stats{Evictions: 1, Entries: 3}, c.(cache.StatsProvider).CacheStats())
+}
+
+func TestLocalCache_SlidingExpiration(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[string](localcache.WithSlidingExpiration(100 * time.Millisecond))
+
+	duration := 100 * time.Millisecond
+	c.Set(ctx, "session", "active", &duration)
+	c.Set(ctx, "idle", "value", &duration)
+
+	// Reading the item keeps it alive past its initial expiration.
+	for i := 0; i < 4; i++ {
+		time.Sleep(40 * time.Millisecond)
+		value, err := c.Get(ctx, "session", nil)
+		require.NoError(t, err)
+		require.Equal(t, "active", value)
+	}
+	_, err := c.Get(ctx, "idle", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	// Without reads, the item expires once the sliding window elapses.
+	time.Sleep(110 * time.Millisecond)
+	_, err = c.Get(ctx, "session", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	// A longer expiration is not shortened by reads.
+	long := time.Hour
+	c.Set(ctx, "long", "value", &long)
+	_, err = c.Get(ctx, "long", nil)
+	require.NoError(t, err)
+	_, ttl, err := c.(interface {
+		GetWithTTL(ctx context.Context, key string) (string, time.Duration, error)
+	}).GetWithTTL(ctx, "long")
+	require.NoError(t, err)
+	require.Greater(t, ttl, 59*time.Minute)
+}
+
+func TestLocalCache_ConditionalSet(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[string]()
+	setter, ok := c.(cache.ConditionalSetter[string])
+	require.True(t, ok, "Expected localcache to implement cache.ConditionalSetter")
+
+	require.True(t, setter.SetIfAbsent(ctx, "key", "first", nil))
+	require.False(t, setter.SetIfAbsent(ctx, "key", "second", nil))
+	value, err := c.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, "first", value)
+
+	// Expired items count as absent.
+	expired := time.Nanosecond
+	c.Set(ctx, "expired", "stale", &expired)
+	time.Sleep(time.Millisecond)
+	require.True(t, setter.SetIfAbsent(ctx, "expired", "fresh", nil))
+
+	actual, loaded := setter.GetOrSet(ctx, "key", "other", nil)
+	require.True(t, loaded)
+	require.Equal(t, "first", actual)
+	actual, loaded = setter.GetOrSet(ctx, "new", "value", nil)
+	require.False(t, loaded)
+	require.Equal(t, "value", actual)
+
+	// Concurrent callers agree on a single winner.
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	stored := make([]bool, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var loaded bool
+			results[i], loaded = setter.GetOrSet(ctx, "race", strconv.Itoa(i), nil)
+			stored[i] = !loaded
+		}(i)
+	}
+	wg.Wait()
+	winners := 0
+	for i := range results {
+		require.Equal(t, results[0], results[i])
+		if stored[i] {
+			winners++
+		}
+	}
+	require.Equal(t, 1, winners)
+}
+
+func TestLocalCache_Counter(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[int]()
+	counter, ok := c.(cache.Counter)
+	require.True(t, ok, "Expected localcache to implement cache.Counter")
+
+	// A missing key starts from zero.
+	value, err := counter.Increment(ctx, "hits", 5, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), value)
+	value, err = counter.Decrement(ctx, "hits", 7, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(-2), value)
+	cached, err := c.Get(ctx, "hits", nil)
+	require.NoError(t, err)
+	require.Equal(t, -2, cached)
+
+	// Existing keys keep the expiration of the window they were created for.
+	window := 50 * time.Millisecond
+	_, err = counter.Increment(ctx, "window", 1, &window)
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+	long := time.Hour
+	_, err = counter.Increment(ctx, "window", 1, &long)
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+	value, err = counter.Increment(ctx, "window", 1, &window)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), value, "Expected the counter to restart once its window expired")
+
+	// Concurrent increments are not lost.
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = counter.Increment(ctx, "concurrent", 1, nil)
+		}()
+	}
+	wg.Wait()
+	cached, err = c.Get(ctx, "concurrent", nil)
+	require.NoError(t, err)
+	require.Equal(t, 100, cached)
+
+	// Results must fit in T.
+	small := localcache.New[uint8]().(cache.Counter)
+	_, err = small.Increment(ctx, "key", 255, nil)
+	require.NoError(t, err)
+	_, err = small.Increment(ctx, "key", 1, nil)
+	require.Error(t, err)
+	_, err = small.Decrement(ctx, "other", 1, nil)
+	require.Error(t, err)
+
+	_, err = localcache.New[string]().(cache.Counter).Increment(ctx, "key", 1, nil)
+	require.ErrorIs(t, err, cache.ErrNotNumeric)
+}
+
+func TestLocalCache_CleanupOptions(t *testing.T) {
+	ctx := context.Background()
+	itemsLen := func(c cache.Cache[string]) int {
+		return reflect.ValueOf(c).Elem().FieldByName("items").Len()
+	}
+	expired := time.Nanosecond
+
+	// Lazy expiration only reclaims items on access or on demand.
+	c := localcache.New[string](localcache.WithLazyExpiration())
+	for i := 0; i < 10; i++ {
+		c.Set(ctx, strconv.Itoa(i), "value", &expired)
+	}
+	c.Set(ctx, "fresh", "value", nil)
+	time.Sleep(time.Millisecond)
+	require.Equal(t, 11, itemsLen(c))
+
+	_, err := c.Get(ctx, "0", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+	require.Equal(t, 10, itemsLen(c), "Expected the expired item to be removed when read")
+
+	purger := c.(interface{ DeleteExpired(ctx context.Context) int })
+	require.Equal(t, 9, purger.DeleteExpired(ctx))
+	require.Equal(t, 1, itemsLen(c))
+
+	// The cleanup scans a bounded number of items per run.
+	var reclaimed atomic.Int32
+	c = localcache.New[string](
+		localcache.WithCleanupInterval(10*time.Millisecond),
+		localcache.WithCleanupBatchSize(3),
+		localcache.WithEvictionCallback(func(key string, value string, reason localcache.Reason) {
+			reclaimed.Add(1)
+		}),
+	)
+	defer c.(interface{ StopCleanup() }).StopCleanup()
+	for i := 0; i < 30; i++ {
+		c.Set(ctx, strconv.Itoa(i), "value", &expired)
+	}
+	require.Eventually(t, func() bool {
+		return reclaimed.Load() == 30
+	}, time.Second, 5*time.Millisecond, "Expected the batches to reclaim every expired item")
+}
+
+func TestLocalCache_Events(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[string](localcache.WithMaxEntries(2), localcache.WithLazyExpiration())
+	events, unsubscribe := c.(cache.EventSource).Subscribe(16)
+
+	c.Set(ctx, "a", "value", nil)
+	_, err := c.Get(ctx, "a", nil)
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "b", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+	expired := time.Nanosecond
+	c.Set(ctx, "c", "value", &expired)
+	time.Sleep(time.Millisecond)
+	c.(interface{ DeleteExpired(ctx context.Context) int }).DeleteExpired(ctx)
+	c.Set(ctx, "d", "value", nil)
+	c.Set(ctx, "e", "value", nil) // evicts a, the least recently used
+	require.NoError(t, c.Invalidate(ctx, "d"))
+	unsubscribe()
+
+	var got []string
+	for event := range events {
+		got = append(got, event.Type.String()+" "+event.Key)
+	}
+	require.Equal(t, []string{
+		"set a", "hit a", "miss b", "set c", "expire c", "set d", "set e", "evict a", "invalidate d",
+	}, got)
+}
+
+func TestLocalCache_Close(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	ctx := context.Background()
+
+	// Close waits for the cleanup and the running refreshes.
+	var refreshed atomic.Bool
+	release := make(chan struct{})
+	c := localcache.New[string](
+		localcache.WithCleanupInterval(time.Millisecond),
+		localcache.WithRefreshAhead(0.9),
+		localcache.WithShards(2),
+	)
+	ttl := 100 * time.Millisecond
+	_, err := c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		if refreshed.Load() {
+			<-release
+		}
+		refreshed.Store(true)
+		return "value", &ttl, nil
+	})
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = c.Get(ctx, "key", nil) // starts a refresh, blocked until released
+	require.NoError(t, err)
+
+	closed := make(chan error)
+	go func() {
+		closed <- cache.Close(c)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Expected Close to wait for the refresh")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	require.NoError(t, <-closed)
+
+	// The cache remains usable, without background work.
+	require.NoError(t, cache.Close(c))
+	value, err := c.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+}
+
+func TestLocalCache_Get_ContextCancellation(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[string]()
+
+	release := make(chan struct{})
+	var calls atomic.Int32
+	initializer := func(ctx context.Context, key string) (string, *time.Duration, error) {
+		calls.Add(1)
+		select {
+		case <-release:
+			return "value", nil, nil
+		case <-ctx.Done():
+			return "", nil, ctx.Err()
+		}
+	}
+
+	// A caller waiting for the initializer of another caller returns once its context is done.
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, err := c.Get(ctx, "key", initializer)
+		leaderDone <- err
+	}()
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.Get(timeout, "key", initializer)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// The shared load goes on and stores the value.
+	close(release)
+	require.NoError(t, <-leaderDone)
+	value, err := c.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+
+	// When the caller running the initializer gives up, the other callers load the value themselves.
+	leader, cancelLeader := context.WithCancel(ctx)
+	started := make(chan struct{})
+	go func() {
+		_, _ = c.Get(leader, "other", func(ctx context.Context, key string) (string, *time.Duration, error) {
+			close(started)
+			<-ctx.Done()
+			return "", nil, ctx.Err()
+		})
+	}()
+	<-started
+	waiterDone := make(chan error, 1)
+	go func() {
+		value, err := c.Get(ctx, "other", func(ctx context.Context, key string) (string, *time.Duration, error) {
+			return "loaded by waiter", nil, nil
+		})
+		if err == nil && value != "loaded by waiter" {
+			err = errors.New("unexpected value " + value)
+		}
+		waiterDone <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancelLeader()
+	require.NoError(t, <-waiterDone)
+}
+
+func TestLocalCache_MaxConcurrentInitializers(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[string](localcache.WithMaxConcurrentInitializers(2, 0))
+
+	var running, peak atomic.Int32
+	initializer := func(ctx context.Context, key string) (string, *time.Duration, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			current := peak.Load()
+			if n <= current || peak.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return key, nil, nil
+	}
+
+	// Loads of distinct keys queue for a slot.
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = c.Get(ctx, strconv.Itoa(i), initializer)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(2), peak.Load(), "Expected at most 2 initializers to run at once")
+
+	// A Get gives up once the wait elapses, without caching the error.
+	c = localcache.New[string](
+		localcache.WithMaxConcurrentInitializers(1, 10*time.Millisecond),
+		localcache.WithErrorCaching(time.Minute, nil),
+	)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_, _ = c.Get(ctx, "slow", func(ctx context.Context, key string) (string, *time.Duration, error) {
+			close(started)
+			<-release
+			return "slow", nil, nil
+		})
+	}()
+	<-started
+	_, err := c.Get(ctx, "key", initializer)
+	require.ErrorIs(t, err, cache.ErrTooManyInitializers)
+	close(release)
+	require.Eventually(t, func() bool {
+		value, err := c.Get(ctx, "key", initializer)
+		return err == nil && value == "key"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestLocalCache_Clock(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	c := localcache.New[string](localcache.WithClock(fake), localcache.WithCleanupInterval(time.Minute))
+	defer c.(interface{ StopCleanup() }).StopCleanup()
+
+	duration := 30 * time.Second
+	c.Set(ctx, "key", "value", &duration)
+	c.Set(ctx, "other", "value", &duration)
+
+	fake.Advance(29 * time.Second)
+	value, err := c.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+
+	fake.Advance(time.Second)
+	_, err = c.Get(ctx, "key", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss, "the item expires with the clock")
+
+	// The cleanup runs on the ticks of the clock.
+	stats := c.(interface{ Stats() localcache.Stats })
+	require.Equal(t, 1, stats.Stats().Entries)
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	require.Eventually(t, func() bool {
+		return stats.Stats().Entries == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestLocalCache_Get_InitializerPanic(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[string]()
+
+	// The panic of the initializer is raised on the goroutine of the caller, which can recover it.
+	recovered := func() (r interface{}) {
+		defer func() { r = recover() }()
+		_, _ = c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+			panic("initializer failed")
+		})
+		return nil
+	}()
+	require.NotNil(t, recovered)
+	require.ErrorContains(t, recovered.(error), "initializer failed")
+
+	// The cache remains usable.
+	value, err := c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "value", nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+}
@@ -0,0 +1,268 @@
+package localcache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	cache "github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/util/pointer"
+)
+
+// WithShards splits the cache into n shards, each with its own lock, selected by a hash of the keys, so that
+// writes of different keys do not contend on a single lock. It pays off for hot caches written by many
+// goroutines, see BenchmarkLocalCache_Parallel. One or less means a single shard, the default.
+//
+// WithMaxEntries and WithMaxCost are split evenly between the shards, so the least recently used item is evicted
+// per shard, and a single item may not cost more than the budget of its shard. The other options apply to every
+// shard, except WithMaxConcurrentInitializers, whose limit is shared. Subscribe streams the events of all shards.
+func WithShards(n int) Option {
+	return func(c *config) {
+		c.shards = n
+	}
+}
+
+type shardedcache[T any] struct {
+	shards         []*localcache[T]
+	snapshotFormat SnapshotFormat
+}
+
+func newSharded[T any](cfg *config, initializerSlots chan struct{}, events *cache.EventBus) *shardedcache[T] {
+	shardCfg := pointer.GetValue(cfg)
+	shardCfg.maxEntries = divideCeil(cfg.maxEntries, cfg.shards)
+	shardCfg.maxCost = divideCeil(cfg.maxCost, int64(cfg.shards))
+
+	c := &shardedcache[T]{
+		shards:         make([]*localcache[T], cfg.shards),
+		snapshotFormat: cfg.snapshotFormat,
+	}
+	for i := range c.shards {
+		c.shards[i] = newLocalcache[T](&shardCfg, initializerSlots, events)
+	}
+	return c
+}
+
+func (c *shardedcache[T]) Get(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
+	return c.shard(key).Get(ctx, key, initializer)
+}
+
+func (c *shardedcache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	c.shard(key).Set(ctx, key, value, duration)
+}
+
+func (c *shardedcache[T]) Invalidate(ctx context.Context, key string) error {
+	return c.shard(key).Invalidate(ctx, key)
+}
+
+func (c *shardedcache[T]) InvalidateAll(ctx context.Context) error {
+	var errs []error
+	for _, shard := range c.shards {
+		errs = append(errs, shard.InvalidateAll(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *shardedcache[T]) SetIfAbsent(ctx context.Context, key string, value T, duration *time.Duration) bool {
+	return c.shard(key).SetIfAbsent(ctx, key, value, duration)
+}
+
+func (c *shardedcache[T]) GetOrSet(ctx context.Context, key string, value T, duration *time.Duration) (T, bool) {
+	return c.shard(key).GetOrSet(ctx, key, value, duration)
+}
+
+func (c *shardedcache[T]) Increment(ctx context.Context, key string, delta int64, duration *time.Duration) (int64, error) {
+	return c.shard(key).Increment(ctx, key, delta, duration)
+}
+
+func (c *shardedcache[T]) Decrement(ctx context.Context, key string, delta int64, duration *time.Duration) (int64, error) {
+	return c.shard(key).Decrement(ctx, key, delta, duration)
+}
+
+// GetMulti returns the values of the keys found in the cache, taking the lock of each shard once.
+func (c *shardedcache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	values := make(map[string]T, len(keys))
+	for i, shardKeys := range c.groupKeys(keys) {
+		found, err := c.shards[i].GetMulti(ctx, shardKeys)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range found {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// SetMulti adds every item to the cache, taking the lock of each shard once.
+func (c *shardedcache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	shardItems := make(map[int]map[string]T)
+	for key, value := range items {
+		i := c.shardIndex(key)
+		if shardItems[i] == nil {
+			shardItems[i] = make(map[string]T)
+		}
+		shardItems[i][key] = value
+	}
+	for i, items := range shardItems {
+		c.shards[i].SetMulti(ctx, items, duration)
+	}
+}
+
+// InvalidateMulti removes the keys from the cache, taking the lock of each shard once.
+func (c *shardedcache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	var errs []error
+	for i, shardKeys := range c.groupKeys(keys) {
+		errs = append(errs, c.shards[i].InvalidateMulti(ctx, shardKeys))
+	}
+	return errors.Join(errs...)
+}
+
+// Stats returns the statistics of the cache, summed over the shards.
+func (c *shardedcache[T]) Stats() Stats {
+	var stats Stats
+	for _, shard := range c.shards {
+		s := shard.Stats()
+		stats.Entries += s.Entries
+		stats.Cost += s.Cost
+		stats.Evictions += s.Evictions
+		stats.CachedErrors += s.CachedErrors
+		stats.CachedErrorHits += s.CachedErrorHits
+	}
+	return stats
+}
+
+// Subscribe streams the activity of every shard, the shards sharing their subscribers.
+func (c *shardedcache[T]) Subscribe(buffer int) (<-chan cache.Event, func()) {
+	return c.shards[0].Subscribe(buffer)
+}
+
+// CacheStats returns the metrics of the cache, summed over the shards.
+func (c *shardedcache[T]) CacheStats() cache.Stats {
+	var stats cache.Stats
+	for _, shard := range c.shards {
+		s := shard.CacheStats()
+		stats.Hits += s.Hits
+		stats.Misses += s.Misses
+		stats.Evictions += s.Evictions
+		stats.Entries += s.Entries
+		stats.InitializerCalls += s.InitializerCalls
+		stats.InitializerErrors += s.InitializerErrors
+		stats.InitializerDuration += s.InitializerDuration
+	}
+	return stats
+}
+
+func (c *shardedcache[T]) GetWithTTL(ctx context.Context, key string) (T, time.Duration, error) {
+	return c.shard(key).GetWithTTL(ctx, key)
+}
+
+func (c *shardedcache[T]) Exists(ctx context.Context, key string) bool {
+	return c.shard(key).Exists(ctx, key)
+}
+
+func (c *shardedcache[T]) Keys(ctx context.Context) []string {
+	var keys []string
+	for _, shard := range c.shards {
+		keys = append(keys, shard.Keys(ctx)...)
+	}
+	return keys
+}
+
+func (c *shardedcache[T]) Len(ctx context.Context) int {
+	n := 0
+	for _, shard := range c.shards {
+		n += shard.Len(ctx)
+	}
+	return n
+}
+
+func (c *shardedcache[T]) DeleteExpired(ctx context.Context) int {
+	removed := 0
+	for _, shard := range c.shards {
+		removed += shard.DeleteExpired(ctx)
+	}
+	return removed
+}
+
+// Close stops the background cleanup and refreshes of every shard, and waits for the running ones to finish.
+func (c *shardedcache[T]) Close() error {
+	var errs []error
+	for _, shard := range c.shards {
+		errs = append(errs, shard.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// StopCleanup stops the background cleanup of every shard.
+func (c *shardedcache[T]) StopCleanup() {
+	for _, shard := range c.shards {
+		shard.StopCleanup()
+	}
+}
+
+// SaveSnapshot writes the items of every shard to w, in the format of a single cache. Each shard is locked in
+// turn, so the snapshot is consistent per shard only.
+func (c *shardedcache[T]) SaveSnapshot(w io.Writer) error {
+	now := c.shards[0].clock.Now()
+	var entries []snapshotEntry[T]
+	for _, shard := range c.shards {
+		entries = append(entries, shard.snapshotEntries(now)...)
+	}
+	return writeSnapshot(w, c.snapshotFormat, entries)
+}
+
+// LoadSnapshot adds the items of a snapshot to their shard. The snapshot may come from a cache with another
+// number of shards.
+func (c *shardedcache[T]) LoadSnapshot(r io.Reader) error {
+	entries, err := readSnapshot[T](r, c.snapshotFormat)
+	if err != nil {
+		return err
+	}
+	shardEntries := make(map[int][]snapshotEntry[T])
+	for _, entry := range entries {
+		i := c.shardIndex(entry.Key)
+		shardEntries[i] = append(shardEntries[i], entry)
+	}
+	for i, entries := range shardEntries {
+		c.shards[i].loadSnapshotEntries(entries)
+	}
+	return nil
+}
+
+func (c *shardedcache[T]) shard(key string) *localcache[T] {
+	return c.shards[c.shardIndex(key)]
+}
+
+// shardIndex returns the index of the shard of key, using the FNV-1a hash of key, computed inline since
+// hash/fnv allocates.
+func (c *shardedcache[T]) shardIndex(key string) int {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= prime32
+	}
+	return int(h % uint32(len(c.shards)))
+}
+
+// groupKeys groups keys by the index of their shard.
+func (c *shardedcache[T]) groupKeys(keys []string) map[int][]string {
+	groups := make(map[int][]string)
+	for _, key := range keys {
+		i := c.shardIndex(key)
+		groups[i] = append(groups[i], key)
+	}
+	return groups
+}
+
+// divideCeil divides a limit between n shards, rounding up. Zero or a negative limit means no limit.
+func divideCeil[N int | int64](limit, n N) N {
+	if limit <= 0 {
+		return limit
+	}
+	return (limit + n - 1) / n
+}
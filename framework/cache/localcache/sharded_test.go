@@ -0,0 +1,133 @@
+package localcache_test
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+)
+
+func TestLocalCache_Shards(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[string](localcache.WithShards(8))
+	defer c.(interface{ StopCleanup() }).StopCleanup()
+
+	items := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		items[strconv.Itoa(i)] = "value" + strconv.Itoa(i)
+	}
+	c.(cache.BatchCache[string]).SetMulti(ctx, items, nil)
+
+	value, err := c.Get(ctx, "42", nil)
+	require.NoError(t, err)
+	require.Equal(t, "value42", value)
+	values, err := c.(cache.BatchCache[string]).GetMulti(ctx, []string{"1", "2", "missing"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"1": "value1", "2": "value2"}, values)
+
+	inspector := c.(interface {
+		Keys(ctx context.Context) []string
+		Len(ctx context.Context) int
+	})
+	require.Len(t, inspector.Keys(ctx), 100)
+	require.Equal(t, 100, inspector.Len(ctx))
+
+	require.NoError(t, c.(cache.BatchCache[string]).InvalidateMulti(ctx, []string{"1", "2"}))
+	require.NoError(t, c.Invalidate(ctx, "3"))
+	require.Equal(t, 97, inspector.Len(ctx))
+	require.Equal(t, cache.Stats{Hits: 3, Misses: 1, Entries: 97}, c.(cache.StatsProvider).CacheStats())
+
+	require.NoError(t, c.InvalidateAll(ctx))
+	require.Equal(t, 0, inspector.Len(ctx))
+}
+
+func TestLocalCache_Shards_Events(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[int](localcache.WithShards(4))
+	defer c.(interface{ StopCleanup() }).StopCleanup()
+	events, unsubscribe := c.(cache.EventSource).Subscribe(100)
+
+	for i := 0; i < 20; i++ {
+		c.Set(ctx, strconv.Itoa(i), i, nil)
+	}
+	unsubscribe()
+
+	// The events of every shard reach the subscriber.
+	keys := make(map[string]bool)
+	for event := range events {
+		require.Equal(t, cache.EventSet, event.Type)
+		keys[event.Key] = true
+	}
+	require.Len(t, keys, 20)
+}
+
+func TestLocalCache_Shards_MaxEntries(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[int](localcache.WithShards(4), localcache.WithMaxEntries(100))
+
+	for i := 0; i < 1000; i++ {
+		c.Set(ctx, strconv.Itoa(i), i, nil)
+	}
+	// The cap is split between the shards, so the cache holds at most the cap rounded up per shard.
+	stats := c.(interface{ Stats() localcache.Stats }).Stats()
+	require.LessOrEqual(t, stats.Entries, 100)
+	require.Greater(t, stats.Entries, 80, "Expected the keys to spread evenly between the shards")
+	require.Equal(t, uint64(1000-stats.Entries), stats.Evictions)
+}
+
+func TestLocalCache_Shards_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	sharded := localcache.New[string](localcache.WithShards(4))
+	minute := time.Minute
+	for i := 0; i < 20; i++ {
+		sharded.Set(ctx, strconv.Itoa(i), "value"+strconv.Itoa(i), &minute)
+	}
+
+	// Snapshots do not depend on the number of shards.
+	var buf bytes.Buffer
+	require.NoError(t, sharded.(snapshotter).SaveSnapshot(&buf))
+	single := localcache.New[string]()
+	require.NoError(t, single.(snapshotter).LoadSnapshot(bytes.NewReader(buf.Bytes())))
+	resharded := localcache.New[string](localcache.WithShards(3))
+	require.NoError(t, resharded.(snapshotter).LoadSnapshot(bytes.NewReader(buf.Bytes())))
+
+	for i := 0; i < 20; i++ {
+		for _, c := range []cache.Cache[string]{single, resharded} {
+			value, err := c.Get(ctx, strconv.Itoa(i), nil)
+			require.NoError(t, err)
+			require.Equal(t, "value"+strconv.Itoa(i), value)
+		}
+	}
+}
+
+func TestLocalCache_Shards_Concurrency(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[int](localcache.WithShards(16))
+	counter := c.(cache.Counter)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 100; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				key := strconv.Itoa(i)
+				c.Set(ctx, key, g, nil)
+				_, _ = c.Get(ctx, key, nil)
+				_, _ = counter.Increment(ctx, "total", 1, nil)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	total, err := c.Get(ctx, "total", nil)
+	require.NoError(t, err)
+	require.Equal(t, 10000, total)
+}
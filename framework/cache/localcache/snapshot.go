@@ -0,0 +1,149 @@
+package localcache
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kittipat1413/go-common/util/pointer"
+)
+
+// snapshotVersion is the version of the snapshot layout, checked when loading a snapshot.
+const snapshotVersion = 1
+
+var ErrInvalidSnapshot = errors.New("invalid cache snapshot")
+
+// SnapshotFormat is the encoding of the snapshots written by SaveSnapshot.
+type SnapshotFormat int
+
+const (
+	// SnapshotGob encodes snapshots with encoding/gob. It is compact and the default format.
+	SnapshotGob SnapshotFormat = iota
+	// SnapshotJSON encodes snapshots as JSON, e.g., to inspect them or to cache types gob cannot encode.
+	SnapshotJSON
+)
+
+// WithSnapshotFormat sets the encoding of the snapshots written by SaveSnapshot and read by LoadSnapshot.
+// The cache values must be encodable in this format. Defaults to SnapshotGob.
+func WithSnapshotFormat(format SnapshotFormat) Option {
+	return func(c *config) {
+		c.snapshotFormat = format
+	}
+}
+
+// snapshot is the content of a snapshot.
+type snapshot[T any] struct {
+	Version int                `json:"version"`
+	Entries []snapshotEntry[T] `json:"entries"`
+}
+
+// snapshotEntry is an item of a snapshot. ExpiresAt is nil for items that never expire.
+type snapshotEntry[T any] struct {
+	Key       string     `json:"key"`
+	Value     T          `json:"value"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+/*
+SaveSnapshot writes the items of the cache to w, with their expiration, so that a restarted service can restore
+them with LoadSnapshot instead of reloading every key from downstream systems. Expired items are skipped.
+
+Example usage:
+
+	snapshotter := c.(interface {
+		SaveSnapshot(w io.Writer) error
+		LoadSnapshot(r io.Reader) error
+	})
+	// On shutdown
+	err := snapshotter.SaveSnapshot(file)
+	// On startup
+	err := snapshotter.LoadSnapshot(file)
+*/
+func (c *localcache[T]) SaveSnapshot(w io.Writer) error {
+	return writeSnapshot(w, c.snapshotFormat, c.snapshotEntries(c.clock.Now()))
+}
+
+// LoadSnapshot adds the items of a snapshot written by SaveSnapshot to the cache. Items keep the expiration
+// they had when the snapshot was saved, so the time the service was down counts, and items expired since
+// are skipped. Items already in the cache are replaced.
+func (c *localcache[T]) LoadSnapshot(r io.Reader) error {
+	entries, err := readSnapshot[T](r, c.snapshotFormat)
+	if err != nil {
+		return err
+	}
+	c.loadSnapshotEntries(entries)
+	return nil
+}
+
+// snapshotEntries returns the items of the cache not expired at now.
+func (c *localcache[T]) snapshotEntries(now time.Time) []snapshotEntry[T] {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	entries := make([]snapshotEntry[T], 0, len(c.items))
+	for key, itm := range c.items {
+		if !itm.expired(now) {
+			entries = append(entries, snapshotEntry[T]{Key: key, Value: itm.data, ExpiresAt: itm.expires})
+		}
+	}
+	return entries
+}
+
+// loadSnapshotEntries adds the entries of a snapshot to the cache, skipping the expired ones.
+func (c *localcache[T]) loadSnapshotEntries(entries []snapshotEntry[T]) {
+	c.mutex.Lock()
+	defer c.unlockAndNotify()
+
+	now := c.clock.Now()
+	for _, entry := range entries {
+		var ttl time.Duration
+		if entry.ExpiresAt != nil {
+			ttl = pointer.GetValue(entry.ExpiresAt).Sub(now)
+			if ttl <= 0 {
+				continue
+			}
+		}
+		c.store(entry.Key, entry.Value, entry.ExpiresAt, ttl, nil)
+	}
+}
+
+// writeSnapshot encodes entries to w in format.
+func writeSnapshot[T any](w io.Writer, format SnapshotFormat, entries []snapshotEntry[T]) error {
+	s := snapshot[T]{
+		Version: snapshotVersion,
+		Entries: entries,
+	}
+	var err error
+	switch format {
+	case SnapshotJSON:
+		err = json.NewEncoder(w).Encode(s)
+	default:
+		err = gob.NewEncoder(w).Encode(s)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode cache snapshot: %w", err)
+	}
+	return nil
+}
+
+// readSnapshot decodes the entries of a snapshot written by writeSnapshot in format.
+func readSnapshot[T any](r io.Reader, format SnapshotFormat) ([]snapshotEntry[T], error) {
+	var s snapshot[T]
+	var err error
+	switch format {
+	case SnapshotJSON:
+		err = json.NewDecoder(r).Decode(&s)
+	default:
+		err = gob.NewDecoder(r).Decode(&s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	if s.Version != snapshotVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, s.Version)
+	}
+	return s.Entries, nil
+}
@@ -0,0 +1,93 @@
+package localcache_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+)
+
+type snapshotter interface {
+	SaveSnapshot(w io.Writer) error
+	LoadSnapshot(r io.Reader) error
+}
+
+type user struct {
+	ID   int
+	Name string
+}
+
+func TestLocalCache_Snapshot(t *testing.T) {
+	for name, format := range map[string]localcache.SnapshotFormat{
+		"gob":  localcache.SnapshotGob,
+		"json": localcache.SnapshotJSON,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			saved := localcache.New[user](localcache.WithSnapshotFormat(format))
+
+			minute := time.Minute
+			expired := time.Nanosecond
+			noExpiration := localcache.NoExpireDuration
+			saved.Set(ctx, "alice", user{ID: 1, Name: "Alice"}, &minute)
+			saved.Set(ctx, "bob", user{ID: 2, Name: "Bob"}, &noExpiration)
+			saved.Set(ctx, "expired", user{ID: 3}, &expired)
+			time.Sleep(time.Millisecond)
+
+			var buf bytes.Buffer
+			require.NoError(t, saved.(snapshotter).SaveSnapshot(&buf))
+
+			restored := localcache.New[user](localcache.WithSnapshotFormat(format))
+			require.NoError(t, restored.(snapshotter).LoadSnapshot(&buf))
+
+			inspector := restored.(interface {
+				GetWithTTL(ctx context.Context, key string) (user, time.Duration, error)
+			})
+			value, ttl, err := inspector.GetWithTTL(ctx, "alice")
+			require.NoError(t, err)
+			require.Equal(t, user{ID: 1, Name: "Alice"}, value)
+			require.InDelta(t, time.Minute, ttl, float64(time.Second), "Expected the remaining TTL to be preserved")
+
+			value, ttl, err = inspector.GetWithTTL(ctx, "bob")
+			require.NoError(t, err)
+			require.Equal(t, user{ID: 2, Name: "Bob"}, value)
+			require.Equal(t, localcache.NoExpireDuration, ttl)
+
+			_, err = restored.Get(ctx, "expired", nil)
+			require.ErrorIs(t, err, cache.ErrCacheMiss)
+		})
+	}
+}
+
+func TestLocalCache_LoadSnapshot_SkipsExpiredItems(t *testing.T) {
+	ctx := context.Background()
+	c := localcache.New[string](localcache.WithSnapshotFormat(localcache.SnapshotJSON))
+
+	snapshot := `{"version":1,"entries":[` +
+		`{"key":"stale","value":"a","expires_at":"` + time.Now().Add(-time.Minute).Format(time.RFC3339Nano) + `"},` +
+		`{"key":"fresh","value":"b","expires_at":"` + time.Now().Add(time.Minute).Format(time.RFC3339Nano) + `"}]}`
+	require.NoError(t, c.(snapshotter).LoadSnapshot(strings.NewReader(snapshot)))
+
+	_, err := c.Get(ctx, "stale", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+	value, err := c.Get(ctx, "fresh", nil)
+	require.NoError(t, err)
+	require.Equal(t, "b", value)
+}
+
+func TestLocalCache_LoadSnapshot_Invalid(t *testing.T) {
+	c := localcache.New[string](localcache.WithSnapshotFormat(localcache.SnapshotJSON))
+
+	err := c.(snapshotter).LoadSnapshot(strings.NewReader("not json"))
+	require.ErrorIs(t, err, localcache.ErrInvalidSnapshot)
+
+	err = c.(snapshotter).LoadSnapshot(strings.NewReader(`{"version":2,"entries":[]}`))
+	require.ErrorIs(t, err, localcache.ErrInvalidSnapshot)
+}
@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+/*
+WithLogging wraps c to log every operation with l: at debug level with its duration, and whether Get was a hit,
+and at error level when it fails. Cache misses are not failures. Like WithTracing, entries carry the cache name
+and a hash of the key rather than the key, since keys may contain personal data.
+
+The returned cache implements BatchCache, falling back to per-key operations if c does not.
+
+Example usage:
+
+	users := cache.WithLogging(localcache.New[User](), "users", log)
+*/
+func WithLogging[T any](c Cache[T], name string, l logger.Logger) BatchCache[T] {
+	return &loggedCache[T]{
+		cache:  c,
+		name:   name,
+		logger: l,
+	}
+}
+
+type loggedCache[T any] struct {
+	cache  Cache[T]
+	name   string
+	logger logger.Logger
+}
+
+func (c *loggedCache[T]) Get(ctx context.Context, key string, initializer Initializer[T]) (T, error) {
+	start := time.Now()
+	var invoked atomic.Bool
+	logged := initializer
+	if initializer != nil {
+		logged = func(ctx context.Context, key string) (T, *time.Duration, error) {
+			invoked.Store(true)
+			return initializer(ctx, key)
+		}
+	}
+
+	value, err := c.cache.Get(ctx, key, logged)
+	fields := c.fields("Get", start, logger.Fields{
+		"key_hash":            hashKey(key),
+		"hit":                 !invoked.Load() && err == nil,
+		"initializer_invoked": invoked.Load(),
+	})
+	if errors.Is(err, ErrCacheMiss) {
+		err = nil
+	}
+	c.log(ctx, "cache get", err, fields)
+	return value, err
+}
+
+func (c *loggedCache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	start := time.Now()
+	c.cache.Set(ctx, key, value, duration)
+	c.log(ctx, "cache set", nil, c.fields("Set", start, logger.Fields{"key_hash": hashKey(key)}))
+}
+
+func (c *loggedCache[T]) Invalidate(ctx context.Context, key string) error {
+	start := time.Now()
+	err := c.cache.Invalidate(ctx, key)
+	c.log(ctx, "cache invalidate", err, c.fields("Invalidate", start, logger.Fields{"key_hash": hashKey(key)}))
+	return err
+}
+
+func (c *loggedCache[T]) InvalidateAll(ctx context.Context) error {
+	start := time.Now()
+	err := c.cache.InvalidateAll(ctx)
+	c.log(ctx, "cache invalidate all", err, c.fields("InvalidateAll", start, nil))
+	return err
+}
+
+func (c *loggedCache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	start := time.Now()
+	values, err := GetMulti(ctx, c.cache, keys)
+	c.log(ctx, "cache get multi", err, c.fields("GetMulti", start, logger.Fields{"key_count": len(keys), "hits": len(values)}))
+	return values, err
+}
+
+func (c *loggedCache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	start := time.Now()
+	SetMulti(ctx, c.cache, items, duration)
+	c.log(ctx, "cache set multi", nil, c.fields("SetMulti", start, logger.Fields{"key_count": len(items)}))
+}
+
+func (c *loggedCache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	start := time.Now()
+	err := InvalidateMulti(ctx, c.cache, keys)
+	c.log(ctx, "cache invalidate multi", err, c.fields("InvalidateMulti", start, logger.Fields{"key_count": len(keys)}))
+	return err
+}
+
+// Close closes the wrapped cache, implementing Closer.
+func (c *loggedCache[T]) Close() error {
+	return Close(c.cache)
+}
+
+// fields returns the fields of an operation started at start, with the cache name and extra.
+func (c *loggedCache[T]) fields(operation string, start time.Time, extra logger.Fields) logger.Fields {
+	fields := logger.Fields{
+		"cache":     c.name,
+		"operation": operation,
+		"duration":  time.Since(start).String(),
+	}
+	for key, value := range extra {
+		fields[key] = value
+	}
+	return fields
+}
+
+// log logs an operation at debug level, or at error level if err is not nil.
+func (c *loggedCache[T]) log(ctx context.Context, msg string, err error, fields logger.Fields) {
+	if err != nil {
+		c.logger.Error(ctx, msg+" failed", err, fields)
+		return
+	}
+	c.logger.Debug(ctx, msg, fields)
+}
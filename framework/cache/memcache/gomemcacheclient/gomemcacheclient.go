@@ -0,0 +1,107 @@
+package gomemcacheclient
+
+import (
+	"errors"
+
+	"github.com/bradfitz/gomemcache/memcache"
+
+	cache "github.com/kittipat1413/go-common/framework/cache"
+	memcachecache "github.com/kittipat1413/go-common/framework/cache/memcache"
+)
+
+var (
+	_ memcachecache.Client      = (*Client)(nil)
+	_ memcachecache.MultiGetter = (*Client)(nil)
+	_ memcachecache.Adder       = (*Client)(nil)
+	_ memcachecache.Incrementer = (*Client)(nil)
+)
+
+/*
+Client is the memcache.Client of a gomemcache client, also implementing MultiGetter, Adder and Incrementer. It is a
+module of its own, so that the services using the memcache cache with another client do not depend on gomemcache.
+
+The misses of gomemcache are returned as cache.ErrCacheMiss, and the keys not stored by Add as memcache.ErrNotStored,
+so that the cache needs neither WithMissErrors nor WithNotStoredErrors.
+
+Example usage:
+
+	client := memcache.New("memcached:11211")
+	c := memcachecache.New[User](gomemcacheclient.New(client),
+		memcachecache.WithKeyPrefix[User]("users:"),
+		memcachecache.WithDefaultExpiration[User](10*time.Minute),
+	)
+*/
+type Client struct {
+	client *memcache.Client
+}
+
+// New creates the memcache.Client of client. The client is not owned by it.
+func New(client *memcache.Client) *Client {
+	return &Client{client: client}
+}
+
+// Get returns the value of key, or cache.ErrCacheMiss if it does not exist.
+func (c *Client) Get(key string) ([]byte, error) {
+	item, err := c.client.Get(key)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return item.Value, nil
+}
+
+// Set stores value under key. expiration is in seconds, zero means no expiration.
+func (c *Client) Set(key string, value []byte, expiration int32) error {
+	return c.client.Set(&memcache.Item{Key: key, Value: value, Expiration: expiration})
+}
+
+// Delete removes key, and returns cache.ErrCacheMiss if it does not exist.
+func (c *Client) Delete(key string) error {
+	return translate(c.client.Delete(key))
+}
+
+// DeleteAll removes all keys from the servers.
+func (c *Client) DeleteAll() error {
+	return c.client.DeleteAll()
+}
+
+// GetMulti returns the values of the keys found, in a round trip per server.
+func (c *Client) GetMulti(keys []string) (map[string][]byte, error) {
+	items, err := c.client.GetMulti(keys)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string][]byte, len(items))
+	for key, item := range items {
+		values[key] = item.Value
+	}
+	return values, nil
+}
+
+// Add stores value under key if key does not exist, and returns memcache.ErrNotStored otherwise.
+func (c *Client) Add(key string, value []byte, expiration int32) error {
+	return translate(c.client.Add(&memcache.Item{Key: key, Value: value, Expiration: expiration}))
+}
+
+// Increment adds delta to the value of key, and returns cache.ErrCacheMiss if it does not exist.
+func (c *Client) Increment(key string, delta uint64) (uint64, error) {
+	value, err := c.client.Increment(key, delta)
+	return value, translate(err)
+}
+
+// Decrement subtracts delta from the value of key, stopping at zero, and returns cache.ErrCacheMiss if it does not
+// exist.
+func (c *Client) Decrement(key string, delta uint64) (uint64, error) {
+	value, err := c.client.Decrement(key, delta)
+	return value, translate(err)
+}
+
+// translate returns the errors of the cache for the misses and the keys not stored of gomemcache.
+func translate(err error) error {
+	switch {
+	case errors.Is(err, memcache.ErrCacheMiss):
+		return cache.ErrCacheMiss
+	case errors.Is(err, memcache.ErrNotStored):
+		return memcachecache.ErrNotStored
+	}
+	return err
+}
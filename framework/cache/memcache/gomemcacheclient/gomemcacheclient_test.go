@@ -0,0 +1,197 @@
+package gomemcacheclient_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cache "github.com/kittipat1413/go-common/framework/cache"
+	memcachecache "github.com/kittipat1413/go-common/framework/cache/memcache"
+	"github.com/kittipat1413/go-common/framework/cache/memcache/gomemcacheclient"
+)
+
+// fakeServer is an in-memory memcached server speaking the text protocol commands used by gomemcache. The items do
+// not expire.
+type fakeServer struct {
+	mu    sync.Mutex
+	items map[string][]byte
+}
+
+// newClient returns a gomemcache client of a fakeServer.
+func newClient(t *testing.T) *memcache.Client {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	server := &fakeServer{items: make(map[string][]byte)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return memcache.New(listener.Addr().String())
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return
+		}
+		if err := s.handle(rw, fields); err != nil {
+			return
+		}
+		if err := rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) handle(rw *bufio.ReadWriter, fields []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch command, args := fields[0], fields[1:]; command {
+	case "gets":
+		for _, key := range args {
+			if value, ok := s.items[key]; ok {
+				fmt.Fprintf(rw, "VALUE %s 0 %d 1\r\n%s\r\n", key, len(value), value)
+			}
+		}
+		_, err := rw.WriteString("END\r\n")
+		return err
+	case "set", "add":
+		size, err := strconv.Atoi(args[3])
+		if err != nil {
+			return err
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(rw, value); err != nil {
+			return err
+		}
+		if _, ok := s.items[args[0]]; ok && command == "add" {
+			_, err = rw.WriteString("NOT_STORED\r\n")
+			return err
+		}
+		s.items[args[0]] = value[:size]
+		_, err = rw.WriteString("STORED\r\n")
+		return err
+	case "delete":
+		if _, ok := s.items[args[0]]; !ok {
+			_, err := rw.WriteString("NOT_FOUND\r\n")
+			return err
+		}
+		delete(s.items, args[0])
+		_, err := rw.WriteString("DELETED\r\n")
+		return err
+	case "incr", "decr":
+		value, ok := s.items[args[0]]
+		if !ok {
+			_, err := rw.WriteString("NOT_FOUND\r\n")
+			return err
+		}
+		current, err := strconv.ParseUint(string(value), 10, 64)
+		if err != nil {
+			return err
+		}
+		delta, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		if command == "incr" {
+			current += delta
+		} else {
+			current -= min(delta, current)
+		}
+		s.items[args[0]] = []byte(strconv.FormatUint(current, 10))
+		_, err = fmt.Fprintf(rw, "%d\r\n", current)
+		return err
+	case "flush_all":
+		s.items = make(map[string][]byte)
+		_, err := rw.WriteString("OK\r\n")
+		return err
+	}
+	return fmt.Errorf("unknown command %q", fields[0])
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	c := memcachecache.New[string](gomemcacheclient.New(newClient(t)), memcachecache.WithKeyPrefix[string]("users:"))
+
+	_, err := c.Get(ctx, "1", nil)
+	assert.ErrorIs(t, err, cache.ErrCacheMiss, "the misses are translated without WithMissErrors")
+
+	value, err := c.Get(ctx, "1", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "alice", nil, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", value)
+	value, err = c.Get(ctx, "1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", value)
+
+	c.Set(ctx, "2", "bob", nil)
+	values, err := c.(cache.BatchCache[string]).GetMulti(ctx, []string{"1", "2", "3"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"1": "alice", "2": "bob"}, values)
+
+	require.NoError(t, c.Invalidate(ctx, "1"))
+	require.NoError(t, c.Invalidate(ctx, "1"), "invalidating a missing key succeeds")
+	_, err = c.Get(ctx, "1", nil)
+	assert.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	require.NoError(t, c.InvalidateAll(ctx))
+	_, err = c.Get(ctx, "2", nil)
+	assert.ErrorIs(t, err, cache.ErrCacheMiss)
+}
+
+func TestCache_SetIfAbsent(t *testing.T) {
+	ctx := context.Background()
+	var errs []error
+	c := memcachecache.New[string](gomemcacheclient.New(newClient(t)),
+		memcachecache.WithErrorHandler[string](func(ctx context.Context, key string, err error) { errs = append(errs, err) }),
+	)
+
+	setter := c.(cache.ConditionalSetter[string])
+
+	assert.True(t, setter.SetIfAbsent(ctx, "1", "alice", nil))
+	assert.False(t, setter.SetIfAbsent(ctx, "1", "bob", nil), "the keys not stored are translated without WithNotStoredErrors")
+	value, loaded := setter.GetOrSet(ctx, "1", "bob", nil)
+	assert.True(t, loaded)
+	assert.Equal(t, "alice", value)
+	assert.Empty(t, errs)
+}
+
+func TestCache_Increment(t *testing.T) {
+	ctx := context.Background()
+	counter := memcachecache.New[int64](gomemcacheclient.New(newClient(t))).(cache.Counter)
+
+	value, err := counter.Increment(ctx, "visits", 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), value)
+	value, err = counter.Increment(ctx, "visits", 3, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), value)
+	value, err = counter.Decrement(ctx, "visits", 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), value, "decrementing stops at zero")
+}
@@ -0,0 +1,640 @@
+package memcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/rs/xid"
+
+	cache "github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/internal/flight"
+	"github.com/kittipat1413/go-common/framework/lock"
+	"github.com/kittipat1413/go-common/util/pointer"
+	syncutil "github.com/kittipat1413/go-common/util/sync"
+)
+
+const (
+	// NoExpireDuration makes items persist until they are evicted by memcached.
+	NoExpireDuration      time.Duration = -1
+	defaultExpireDuration time.Duration = NoExpireDuration
+	// maxRelativeExpiration is the largest expiration memcached interprets as relative,
+	// larger values are interpreted as a Unix timestamp.
+	maxRelativeExpiration = 30 * 24 * time.Hour
+	// lockKeySuffix is appended to a key to build the key of its distributed lock.
+	lockKeySuffix = ":lock"
+	// lockPollInterval is the interval at which instances waiting for a lock check whether the value was stored.
+	lockPollInterval = 50 * time.Millisecond
+	// getOrSetAttempts is the number of times GetOrSet and Increment try again when the key appears or disappears
+	// between two commands.
+	getOrSetAttempts = 3
+)
+
+var (
+	// ErrNotStored is the default error expected from Adder.Add when the key already exists.
+	ErrNotStored = errors.New("memcache: item not stored")
+	// ErrAddNotSupported is reported by SetIfAbsent and GetOrSet when the Client does not implement Adder.
+	ErrAddNotSupported = errors.New("memcache: client does not implement Adder")
+	// ErrIncrementNotSupported is returned by Increment and Decrement when the Client does not implement Incrementer and Adder.
+	ErrIncrementNotSupported = errors.New("memcache: client does not implement Incrementer and Adder")
+)
+
+/*
+Client is the subset of memcached operations used by the cache. The gomemcacheclient module implements it, and the
+optional interfaces below, with github.com/bradfitz/gomemcache:
+
+	import "github.com/kittipat1413/go-common/framework/cache/memcache/gomemcacheclient"
+
+	c := memcache.New[User](gomemcacheclient.New(gomemcache.New("memcached:11211")))
+
+Another client declares its cache miss error with WithMissErrors.
+*/
+type Client interface {
+	// Get returns the value of key, or a miss error if it does not exist.
+	Get(key string) ([]byte, error)
+	// Set stores value under key. expiration is in seconds, zero means no expiration.
+	Set(key string, value []byte, expiration int32) error
+	// Delete removes key, and returns a miss error if it does not exist.
+	Delete(key string) error
+	// DeleteAll removes all keys from the servers.
+	DeleteAll() error
+}
+
+// MultiGetter is implemented by clients able to fetch several keys in a single round trip. When the Client
+// implements it, GetMulti uses it instead of one Get per key.
+type MultiGetter interface {
+	// GetMulti returns the values of the keys found. Missing keys are absent from the result.
+	GetMulti(keys []string) (map[string][]byte, error)
+}
+
+// Adder is implemented by clients able to store a key only if it does not exist yet. SetIfAbsent and GetOrSet
+// require it. Another client than gomemcacheclient declares its error with WithNotStoredErrors.
+type Adder interface {
+	// Add stores value under key if key does not exist, and returns a not stored error otherwise.
+	// expiration is in seconds, zero means no expiration.
+	Add(key string, value []byte, expiration int32) error
+}
+
+// Incrementer is implemented by clients able to add to the decimal values of memcached atomically. When the Client
+// implements it and Adder, the cache implements cache.Counter.
+type Incrementer interface {
+	// Increment adds delta to the value of key, and returns a miss error if it does not exist.
+	Increment(key string, delta uint64) (uint64, error)
+	// Decrement subtracts delta from the value of key, stopping at zero, and returns a miss error if it does not exist.
+	Decrement(key string, delta uint64) (uint64, error)
+}
+
+type config[T any] struct {
+	defaultExpireDuration time.Duration
+	keyPrefix             string
+	codec                 cache.Codec[T]
+	missErrors            []error
+	errorHandler          func(ctx context.Context, key string, err error)
+	ttlJitter             float64
+	lockBackend           lock.Backend
+	lockTTL               time.Duration
+	lockWait              time.Duration
+	notStoredErrors       []error
+	maxInitializers       int
+	initializerWait       time.Duration
+}
+
+type Option[T any] func(*config[T])
+
+// WithDefaultExpiration sets the default expiration duration for cache items.
+func WithDefaultExpiration[T any](expiration time.Duration) Option[T] {
+	return func(c *config[T]) {
+		c.defaultExpireDuration = expiration
+	}
+}
+
+// WithKeyPrefix sets a prefix prepended to every key, e.g., to share memcached servers between services.
+func WithKeyPrefix[T any](prefix string) Option[T] {
+	return func(c *config[T]) {
+		c.keyPrefix = prefix
+	}
+}
+
+// WithCodec sets the codec used to serialize the cached values, e.g., cache.MsgpackCodec for a compact
+// binary format. Defaults to cache.JSONCodec.
+func WithCodec[T any](codec cache.Codec[T]) Option[T] {
+	return func(c *config[T]) {
+		c.codec = codec
+	}
+}
+
+// WithMissErrors sets the errors returned by the Client when a key does not exist (e.g., memcache.ErrCacheMiss).
+// They are translated to cache.ErrCacheMiss.
+func WithMissErrors[T any](errs ...error) Option[T] {
+	return func(c *config[T]) {
+		c.missErrors = append(c.missErrors, errs...)
+	}
+}
+
+// WithErrorHandler sets a function called when Set, SetIfAbsent or GetOrSet fails, since they do not return errors.
+func WithErrorHandler[T any](handler func(ctx context.Context, key string, err error)) Option[T] {
+	return func(c *config[T]) {
+		c.errorHandler = handler
+	}
+}
+
+// WithTTLJitter randomizes the expiration of each stored item by up to ±percent%, e.g., 10 stores an item set for
+// 10 minutes for 9 to 11 minutes. It spreads the expiration of keys populated at the same time with the same TTL,
+// so they are not all reloaded at once. The percent must be in (0, 100).
+func WithTTLJitter[T any](percent float64) Option[T] {
+	return func(c *config[T]) {
+		if percent > 0 && percent < 100 {
+			c.ttlJitter = percent / 100
+		}
+	}
+}
+
+// WithDistributedLock protects the initializers against stampedes across instances: on a miss, an instance
+// takes the lock of the key from backend for ttl before calling the initializer, e.g., a Redis SET NX PX with
+// redislock, while the other instances wait up to wait for the value to be stored, then call the initializer
+// themselves. ttl should exceed the duration of the initializer. The lock holds a unique token, and is only released
+// by its holder.
+func WithDistributedLock[T any](backend lock.Backend, ttl, wait time.Duration) Option[T] {
+	return func(c *config[T]) {
+		c.lockBackend = backend
+		c.lockTTL = ttl
+		c.lockWait = wait
+	}
+}
+
+// WithNotStoredErrors sets the errors returned by Adder.Add when the key already exists (e.g., memcache.ErrNotStored).
+func WithNotStoredErrors[T any](errs ...error) Option[T] {
+	return func(c *config[T]) {
+		c.notStoredErrors = append(c.notStoredErrors, errs...)
+	}
+}
+
+// WithMaxConcurrentInitializers limits the number of initializers running at once for distinct keys, so that
+// a cold cache does not open as many connections to the downstream systems as there are missing keys. A Get
+// exceeding the limit waits for a slot for up to wait, and then returns cache.ErrTooManyInitializers.
+// A zero wait waits until the context of the Get is done, a negative wait does not wait.
+func WithMaxConcurrentInitializers[T any](n int, wait time.Duration) Option[T] {
+	return func(c *config[T]) {
+		c.maxInitializers = n
+		c.initializerWait = wait
+	}
+}
+
+func newConfig[T any](opts ...Option[T]) *config[T] {
+	c := &config[T]{
+		defaultExpireDuration: defaultExpireDuration,
+		codec:                 cache.JSONCodec[T]{},
+		missErrors:            []error{cache.ErrCacheMiss},
+		notStoredErrors:       []error{ErrNotStored},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type memcache[T any] struct {
+	client Client
+	group  syncutil.Group[string, T]
+	stats  cache.StatsRecorder
+	// initializerSlots holds a value per running initializer, when WithMaxConcurrentInitializers is set.
+	initializerSlots chan struct{}
+	config[T]
+}
+
+// New creates a new memcached-backed cache instance using client, with optional configurations.
+func New[T any](client Client, opts ...Option[T]) cache.Cache[T] {
+	cfg := newConfig(opts...)
+	c := &memcache[T]{
+		client: client,
+		config: pointer.GetValue(cfg),
+	}
+	if c.maxInitializers > 0 {
+		c.initializerSlots = make(chan struct{}, c.maxInitializers)
+	}
+	return c
+}
+
+// Get retrieves a value from the cache. If the key is missing and an initializer
+// is provided, it uses the initializer to obtain the value. Get returns ctx.Err() as soon as ctx is done, including
+// while waiting for the initializer of another caller, which goes on and stores the value for the other callers.
+func (c *memcache[T]) Get(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
+	if err := ctx.Err(); err != nil {
+		var zero T
+		return zero, err
+	}
+	data, err := c.get(key)
+	if err == nil {
+		c.stats.RecordHits(1)
+		return data, nil
+	}
+	if errors.Is(err, cache.ErrCacheMiss) {
+		c.stats.RecordMisses(1)
+	}
+	if !errors.Is(err, cache.ErrCacheMiss) || initializer == nil {
+		var zero T
+		return zero, err
+	}
+	return c.initialize(ctx, key, initializer)
+}
+
+// Set adds an item to the cache with the specified key and duration.
+// If duration is nil, the default expiration is used.
+// If duration is NoExpireDuration, the item does not expire.
+func (c *memcache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	if err := c.set(key, value, duration); err != nil && c.errorHandler != nil {
+		c.errorHandler(ctx, key, err)
+	}
+}
+
+// Invalidate removes key from the cache. Removing a missing key is not an error.
+func (c *memcache[T]) Invalidate(ctx context.Context, key string) error {
+	if err := c.client.Delete(c.keyPrefix + key); err != nil && !c.isMiss(err) {
+		return err
+	}
+	return nil
+}
+
+// InvalidateAll removes all keys from the memcached servers, including the keys of other prefixes.
+func (c *memcache[T]) InvalidateAll(ctx context.Context) error {
+	return c.client.DeleteAll()
+}
+
+// SetIfAbsent stores value under key if key does not exist, and reports whether it was stored, using the memcached
+// add command. The Client must implement Adder. Errors are reported to the WithErrorHandler function.
+func (c *memcache[T]) SetIfAbsent(ctx context.Context, key string, value T, duration *time.Duration) bool {
+	stored, err := c.add(key, value, duration)
+	if err != nil && c.errorHandler != nil {
+		c.errorHandler(ctx, key, err)
+	}
+	return stored
+}
+
+// GetOrSet returns the value of key if it exists, with loaded true. Otherwise, it stores value and returns it,
+// with loaded false. The Client must implement Adder. Errors are reported to the WithErrorHandler function,
+// and value is returned with loaded false.
+func (c *memcache[T]) GetOrSet(ctx context.Context, key string, value T, duration *time.Duration) (T, bool) {
+	for attempt := 0; attempt < getOrSetAttempts; attempt++ {
+		stored, err := c.add(key, value, duration)
+		if err != nil {
+			if c.errorHandler != nil {
+				c.errorHandler(ctx, key, err)
+			}
+			return value, false
+		}
+		if stored {
+			c.stats.RecordMisses(1)
+			return value, false
+		}
+
+		existing, err := c.get(key)
+		if err == nil {
+			c.stats.RecordHits(1)
+			return existing, true
+		}
+		if !errors.Is(err, cache.ErrCacheMiss) {
+			if c.errorHandler != nil {
+				c.errorHandler(ctx, key, err)
+			}
+			return value, false
+		}
+		// The key expired or was invalidated between the add and the get, try again.
+	}
+	return value, false
+}
+
+/*
+Increment adds delta to the value of key with the memcached incr command and returns the new value. A missing key
+is created with delta and expires after duration, an existing key keeps its expiration. The Client must implement
+Incrementer and Adder.
+
+Memcached stores counters as unsigned decimal text: decrementing stops at zero, and the values can be read with
+Get when T is an integer type and the codec encodes integers as decimal text, such as cache.JSONCodec.
+*/
+func (c *memcache[T]) Increment(ctx context.Context, key string, delta int64, duration *time.Duration) (int64, error) {
+	incrementer, ok := c.client.(Incrementer)
+	if !ok {
+		return 0, ErrIncrementNotSupported
+	}
+	adder, ok := c.client.(Adder)
+	if !ok {
+		return 0, ErrIncrementNotSupported
+	}
+	if duration == nil {
+		duration = pointer.ToPointer(c.defaultExpireDuration)
+	}
+
+	for attempt := 0; attempt < getOrSetAttempts; attempt++ {
+		var value uint64
+		var err error
+		if delta >= 0 {
+			value, err = incrementer.Increment(c.keyPrefix+key, uint64(delta))
+		} else {
+			value, err = incrementer.Decrement(c.keyPrefix+key, uint64(-delta))
+		}
+		if err == nil {
+			return int64(value), nil
+		}
+		if !c.isMiss(err) {
+			return 0, fmt.Errorf("failed to increment %q: %w", key, err)
+		}
+
+		initial := max(delta, 0)
+		err = adder.Add(c.keyPrefix+key, []byte(strconv.FormatInt(initial, 10)), expiration(c.jitter(pointer.GetValue(duration))))
+		if err == nil {
+			return initial, nil
+		}
+		if !c.isNotStored(err) {
+			return 0, fmt.Errorf("failed to create counter %q: %w", key, err)
+		}
+		// Another instance created the key between the incr and the add, increment it.
+	}
+	return 0, fmt.Errorf("failed to increment %q: the key was removed repeatedly", key)
+}
+
+// Decrement subtracts delta from the value of key and returns the new value, like Increment.
+func (c *memcache[T]) Decrement(ctx context.Context, key string, delta int64, duration *time.Duration) (int64, error) {
+	return c.Increment(ctx, key, -delta, duration)
+}
+
+// GetMulti returns the values of the keys found in the cache, in a single round trip if the Client implements MultiGetter.
+// Missing keys are absent from the result.
+func (c *memcache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	values := make(map[string]T, len(keys))
+	getter, ok := c.client.(MultiGetter)
+	if !ok {
+		for _, key := range keys {
+			value, err := c.get(key)
+			if errors.Is(err, cache.ErrCacheMiss) {
+				c.stats.RecordMisses(1)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			c.stats.RecordHits(1)
+			values[key] = value
+		}
+		return values, nil
+	}
+
+	prefixedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		prefixedKeys[i] = c.keyPrefix + key
+	}
+	data, err := getter.GetMulti(prefixedKeys)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		raw, found := data[c.keyPrefix+key]
+		if !found {
+			continue
+		}
+		value, err := c.codec.Unmarshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode cached value of %q: %w", key, err)
+		}
+		values[key] = value
+	}
+	c.stats.RecordHits(len(values))
+	c.stats.RecordMisses(len(keys) - len(values))
+	return values, nil
+}
+
+// SetMulti adds every item to the cache with the same duration.
+func (c *memcache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	for key, value := range items {
+		c.Set(ctx, key, value, duration)
+	}
+}
+
+// InvalidateMulti removes the keys from the cache. Removing missing keys is not an error.
+func (c *memcache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	var errs []error
+	for _, key := range keys {
+		if err := c.Invalidate(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CacheStats returns the hits, misses and initializer metrics of the cache, implementing cache.StatsProvider.
+// Entries and evictions are managed by memcached and are not reported.
+func (c *memcache[T]) CacheStats() cache.Stats {
+	return c.stats.Snapshot()
+}
+
+func (c *memcache[T]) get(key string) (T, error) {
+	var zero T
+	data, err := c.client.Get(c.keyPrefix + key)
+	if err != nil {
+		if c.isMiss(err) {
+			return zero, cache.ErrCacheMiss
+		}
+		return zero, err
+	}
+	value, err := c.codec.Unmarshal(data)
+	if err != nil {
+		return zero, fmt.Errorf("failed to decode cached value: %w", err)
+	}
+	return value, nil
+}
+
+func (c *memcache[T]) set(key string, value T, duration *time.Duration) error {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached value: %w", err)
+	}
+	if duration == nil {
+		duration = pointer.ToPointer(c.defaultExpireDuration)
+	}
+	return c.client.Set(c.keyPrefix+key, data, expiration(c.jitter(pointer.GetValue(duration))))
+}
+
+// add stores value under key if key does not exist. It reports whether value was stored, and returns an error
+// if the add failed for another reason than the key existing.
+func (c *memcache[T]) add(key string, value T, duration *time.Duration) (bool, error) {
+	adder, ok := c.client.(Adder)
+	if !ok {
+		return false, ErrAddNotSupported
+	}
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode cached value: %w", err)
+	}
+	if duration == nil {
+		duration = pointer.ToPointer(c.defaultExpireDuration)
+	}
+
+	err = adder.Add(c.keyPrefix+key, data, expiration(c.jitter(pointer.GetValue(duration))))
+	if err == nil {
+		return true, nil
+	}
+	if c.isNotStored(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (c *memcache[T]) initialize(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
+	return flight.Do(ctx, &c.group, key, func() (T, error) {
+		var zero T
+		unlock, locked := c.lock(ctx, key)
+		if !locked {
+			// Another instance is loading key, wait for it to store the value.
+			if value, ok := c.waitForValue(ctx, key); ok {
+				return value, nil
+			}
+			if err := ctx.Err(); err != nil {
+				return zero, err
+			}
+		} else {
+			defer unlock()
+		}
+
+		release, err := c.acquire(ctx)
+		if err != nil {
+			return zero, err
+		}
+		defer release()
+
+		start := time.Now()
+		result, duration, err := initializer(ctx, key)
+		c.stats.RecordInitializer(time.Since(start), err)
+		if err != nil {
+			return result, err
+		}
+
+		// Set the item in the cache
+		c.Set(ctx, key, result, duration)
+
+		return result, nil
+	})
+}
+
+// acquire takes an initializer slot when their number is limited, waiting for one to free up according to
+// WithMaxConcurrentInitializers, and returns the function releasing it.
+func (c *memcache[T]) acquire(ctx context.Context) (func(), error) {
+	if c.initializerSlots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-c.initializerSlots }
+	select {
+	case c.initializerSlots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	if c.initializerWait < 0 {
+		return nil, cache.ErrTooManyInitializers
+	}
+
+	var timeout <-chan time.Time
+	if c.initializerWait > 0 {
+		timer := time.NewTimer(c.initializerWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case c.initializerSlots <- struct{}{}:
+		return release, nil
+	case <-timeout:
+		return nil, cache.ErrTooManyInitializers
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// jitter randomizes duration by up to ±ttlJitter of its value.
+func (c *memcache[T]) jitter(duration time.Duration) time.Duration {
+	if c.ttlJitter <= 0 || duration <= 0 {
+		return duration
+	}
+	return duration + time.Duration(float64(duration)*c.ttlJitter*(2*rand.Float64()-1))
+}
+
+// lock takes the distributed lock of key, if enabled. It returns false if another instance holds the lock.
+// If the lock cannot be taken for another reason, e.g., the backend is unavailable, it returns true so that the key
+// is loaded without protection rather than not at all.
+func (c *memcache[T]) lock(ctx context.Context, key string) (unlock func(), locked bool) {
+	noop := func() {}
+	if c.lockBackend == nil || c.lockTTL <= 0 {
+		return noop, true
+	}
+
+	lockKey := c.keyPrefix + key + lockKeySuffix
+	token := xid.New().String()
+	acquired, err := c.lockBackend.Acquire(ctx, lockKey, token, c.lockTTL)
+	if err != nil {
+		return noop, true
+	}
+	if !acquired {
+		return nil, false
+	}
+	// The lock may have expired while the initializer ran, and been taken by another instance since: the backend
+	// only releases it if it still holds token.
+	return func() { _, _ = c.lockBackend.Release(context.WithoutCancel(ctx), lockKey, token) }, true
+}
+
+// waitForValue polls the value of key until it is stored, for up to the lock wait duration.
+func (c *memcache[T]) waitForValue(ctx context.Context, key string) (T, bool) {
+	timeout := time.NewTimer(c.lockWait)
+	defer timeout.Stop()
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, false
+		case <-timeout.C:
+			var zero T
+			return zero, false
+		case <-ticker.C:
+			if value, err := c.get(key); err == nil {
+				return value, true
+			}
+		}
+	}
+}
+
+func (c *memcache[T]) isNotStored(err error) bool {
+	for _, notStoredErr := range c.notStoredErrors {
+		if errors.Is(err, notStoredErr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *memcache[T]) isMiss(err error) bool {
+	for _, missErr := range c.missErrors {
+		if errors.Is(err, missErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// expiration converts duration to a memcached expiration: seconds up to 30 days, a Unix timestamp beyond.
+func expiration(duration time.Duration) int32 {
+	if duration == NoExpireDuration {
+		return 0
+	}
+	if duration <= 0 {
+		// A negative expiration makes memcached expire the item immediately.
+		return -1
+	}
+	if duration > maxRelativeExpiration {
+		return int32(time.Now().Add(duration).Unix())
+	}
+	// Round up, since an expiration of zero means no expiration.
+	return int32((duration + time.Second - 1) / time.Second)
+}
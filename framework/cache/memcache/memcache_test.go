@@ -0,0 +1,670 @@
+package memcache_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/memcache"
+)
+
+var errFakeMiss = errors.New("memcache: cache miss")
+
+// fakeClient is an in-memory memcache.Client recording the expirations of the stored items.
+type fakeClient struct {
+	mu          sync.Mutex
+	items       map[string][]byte
+	expirations map[string]int32
+	err         error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{items: make(map[string][]byte), expirations: make(map[string]int32)}
+}
+
+func (c *fakeClient) Get(key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	value, ok := c.items[key]
+	if !ok {
+		return nil, errFakeMiss
+	}
+	return value, nil
+}
+
+func (c *fakeClient) Set(key string, value []byte, expiration int32) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.items[key] = value
+	c.expirations[key] = expiration
+	return nil
+}
+
+func (c *fakeClient) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[key]; !ok {
+		return errFakeMiss
+	}
+	delete(c.items, key)
+	return nil
+}
+
+func (c *fakeClient) DeleteAll() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = make(map[string][]byte)
+	return nil
+}
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestMemcache_SetAndGet(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	c := memcache.New[user](client, memcache.WithMissErrors[user](errFakeMiss), memcache.WithKeyPrefix[user]("svc:"))
+
+	duration := time.Minute
+	c.Set(ctx, "user:1", user{ID: 1, Name: "Alice"}, &duration)
+
+	got, err := c.Get(ctx, "user:1", nil)
+	require.NoError(t, err)
+	require.Equal(t, user{ID: 1, Name: "Alice"}, got)
+	require.Equal(t, `{"id":1,"name":"Alice"}`, string(client.items["svc:user:1"]))
+	require.Equal(t, int32(60), client.expirations["svc:user:1"])
+}
+
+func TestMemcache_Get_CacheMiss(t *testing.T) {
+	c := memcache.New[string](newFakeClient(), memcache.WithMissErrors[string](errFakeMiss))
+
+	_, err := c.Get(context.Background(), "missing", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+}
+
+func TestMemcache_GetWithInitializer(t *testing.T) {
+	ctx := context.Background()
+	c := memcache.New[int](newFakeClient(), memcache.WithMissErrors[int](errFakeMiss))
+
+	var initializerCalled int
+	initializer := func(ctx context.Context, key string) (int, *time.Duration, error) {
+		initializerCalled++
+		return 42, nil, nil
+	}
+
+	value, err := c.Get(ctx, "number", initializer)
+	require.NoError(t, err)
+	require.Equal(t, 42, value)
+
+	value, err = c.Get(ctx, "number", initializer)
+	require.NoError(t, err)
+	require.Equal(t, 42, value)
+	require.Equal(t, 1, initializerCalled, "Initializer should have been called once")
+}
+
+func TestMemcache_ClientError(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	client.err = errors.New("connection refused")
+
+	var setErr error
+	c := memcache.New[string](client,
+		memcache.WithMissErrors[string](errFakeMiss),
+		memcache.WithErrorHandler[string](func(_ context.Context, _ string, err error) { setErr = err }),
+	)
+
+	// Errors other than a miss do not trigger the initializer.
+	_, err := c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		t.Fatal("initializer should not be called")
+		return "", nil, nil
+	})
+	require.ErrorIs(t, err, client.err)
+
+	c.Set(ctx, "key", "value", nil)
+	require.ErrorIs(t, setErr, client.err)
+}
+
+func TestMemcache_DecodeError(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	client.items["key"] = []byte("not json")
+	c := memcache.New[int](client)
+
+	_, err := c.Get(ctx, "key", nil)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, cache.ErrCacheMiss)
+}
+
+type stringCodec struct{}
+
+func (stringCodec) Marshal(value int) ([]byte, error)  { return []byte(strconv.Itoa(value)), nil }
+func (stringCodec) Unmarshal(data []byte) (int, error) { return strconv.Atoi(string(data)) }
+
+func TestMemcache_WithCodec(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	c := memcache.New[int](client, memcache.WithCodec[int](stringCodec{}))
+
+	c.Set(ctx, "key", 7, nil)
+	require.Equal(t, "7", string(client.items["key"]))
+
+	got, err := c.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, 7, got)
+}
+
+func TestMemcache_Expiration(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	c := memcache.New[string](client, memcache.WithDefaultExpiration[string](90*time.Second))
+
+	noExpiration := memcache.NoExpireDuration
+	subSecond := 100 * time.Millisecond
+	long := 60 * 24 * time.Hour
+
+	c.Set(ctx, "default", "v", nil)
+	c.Set(ctx, "persistent", "v", &noExpiration)
+	c.Set(ctx, "sub-second", "v", &subSecond)
+	c.Set(ctx, "long", "v", &long)
+
+	require.Equal(t, int32(90), client.expirations["default"])
+	require.Equal(t, int32(0), client.expirations["persistent"])
+	require.Equal(t, int32(1), client.expirations["sub-second"])
+	// Expirations longer than 30 days are sent as a Unix timestamp.
+	require.InDelta(t, time.Now().Add(long).Unix(), client.expirations["long"], 2)
+}
+
+func TestMemcache_TTLJitter(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	c := memcache.New[int](client, memcache.WithTTLJitter[int](10))
+
+	duration := 100 * time.Second
+	noExpiration := memcache.NoExpireDuration
+	for i := 0; i < 100; i++ {
+		c.Set(ctx, strconv.Itoa(i), i, &duration)
+	}
+	c.Set(ctx, "persistent", 0, &noExpiration)
+
+	expirations := make(map[int32]struct{})
+	for i := 0; i < 100; i++ {
+		expiration := client.expirations[strconv.Itoa(i)]
+		require.GreaterOrEqual(t, expiration, int32(90))
+		require.LessOrEqual(t, expiration, int32(110))
+		expirations[expiration] = struct{}{}
+	}
+	require.Greater(t, len(expirations), 1, "Expected the expirations to be randomized")
+	require.Equal(t, int32(0), client.expirations["persistent"])
+}
+
+func TestMemcache_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	c := memcache.New[string](newFakeClient(), memcache.WithMissErrors[string](errFakeMiss))
+
+	c.Set(ctx, "key1", "value1", nil)
+	c.Set(ctx, "key2", "value2", nil)
+
+	require.NoError(t, c.Invalidate(ctx, "key1"))
+	require.NoError(t, c.Invalidate(ctx, "key1"), "Invalidating a missing key should not fail")
+	_, err := c.Get(ctx, "key1", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	require.NoError(t, c.InvalidateAll(ctx))
+	_, err = c.Get(ctx, "key2", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+}
+
+func TestMemcache_CacheStats(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	c := memcache.New[int](client, memcache.WithMissErrors[int](errFakeMiss))
+	provider, ok := c.(cache.StatsProvider)
+	require.True(t, ok, "Expected memcache to implement StatsProvider")
+
+	initializer := func(ctx context.Context, key string) (int, *time.Duration, error) {
+		return 1, nil, nil
+	}
+	_, err := c.Get(ctx, "a", initializer)
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "a", initializer)
+	require.NoError(t, err)
+
+	// Client errors are neither hits nor misses.
+	client.err = errors.New("connection refused")
+	_, err = c.Get(ctx, "a", nil)
+	require.Error(t, err)
+
+	stats := provider.CacheStats()
+	stats.InitializerDuration = 0
+	require.Equal(t, cache.Stats{Hits: 1, Misses: 1, InitializerCalls: 1}, stats)
+}
+
+// addClient is a fakeClient implementing memcache.Adder.
+type addClient struct {
+	*fakeClient
+}
+
+func (c *addClient) Add(key string, value []byte, expiration int32) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[key]; ok {
+		return memcache.ErrNotStored
+	}
+	c.items[key] = value
+	c.expirations[key] = expiration
+	return nil
+}
+
+// fakeLockBackend is an in-memory lock.Backend, whose locks do not expire.
+type fakeLockBackend struct {
+	mu    sync.Mutex
+	locks map[string]string
+}
+
+func newFakeLockBackend() *fakeLockBackend {
+	return &fakeLockBackend{locks: make(map[string]string)}
+}
+
+func (b *fakeLockBackend) Acquire(_ context.Context, key, token string, _ time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.locks[key]; ok {
+		return false, nil
+	}
+	b.locks[key] = token
+	return true, nil
+}
+
+func (b *fakeLockBackend) Renew(_ context.Context, key, token string, _ time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.locks[key] == token, nil
+}
+
+func (b *fakeLockBackend) Release(_ context.Context, key, token string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.locks[key] != token {
+		return false, nil
+	}
+	delete(b.locks, key)
+	return true, nil
+}
+
+func (b *fakeLockBackend) holder(key string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	token, ok := b.locks[key]
+	return token, ok
+}
+
+func TestMemcache_DistributedLock(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	locks := newFakeLockBackend()
+
+	var calls atomic.Int32
+	initializer := func(ctx context.Context, key string) (string, *time.Duration, error) {
+		calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		return "value", nil, nil
+	}
+
+	// Instances sharing the memcached servers load a missing key once.
+	var wg sync.WaitGroup
+	values := make([]string, 3)
+	errs := make([]error, 3)
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := memcache.New[string](client,
+				memcache.WithMissErrors[string](errFakeMiss),
+				memcache.WithKeyPrefix[string]("users:"),
+				memcache.WithDistributedLock[string](locks, time.Second, time.Second),
+			)
+			values[i], errs[i] = c.Get(ctx, "key", initializer)
+		}(i)
+	}
+	wg.Wait()
+	for i := range values {
+		require.NoError(t, errs[i])
+		require.Equal(t, "value", values[i])
+	}
+	require.Equal(t, int32(1), calls.Load(), "Initializer should have been called once across instances")
+
+	// The lock is released once the value is stored.
+	_, held := locks.holder("users:key:lock")
+	require.False(t, held)
+}
+
+func TestMemcache_DistributedLock_WaitTimeout(t *testing.T) {
+	ctx := context.Background()
+	locks := newFakeLockBackend()
+	c := memcache.New[string](newFakeClient(),
+		memcache.WithMissErrors[string](errFakeMiss),
+		memcache.WithDistributedLock[string](locks, time.Second, 100*time.Millisecond),
+	)
+
+	// Another instance holds the lock but never stores the value.
+	acquired, err := locks.Acquire(ctx, "key:lock", "other", time.Second)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	start := time.Now()
+	value, err := c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "value", nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "Expected to wait for the lock holder")
+
+	// A cancelled context stops the wait.
+	require.NoError(t, c.Invalidate(ctx, "key"))
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = c.Get(cancelled, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		t.Fatal("initializer should not be called")
+		return "", nil, nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestMemcache_DistributedLock_Expired(t *testing.T) {
+	ctx := context.Background()
+	locks := newFakeLockBackend()
+	c := memcache.New[string](newFakeClient(),
+		memcache.WithMissErrors[string](errFakeMiss),
+		memcache.WithDistributedLock[string](locks, time.Second, time.Second),
+	)
+	value, err := c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		// The lock expires while the initializer runs, and another instance takes it.
+		locks.mu.Lock()
+		locks.locks["key:lock"] = "other"
+		locks.mu.Unlock()
+		return "value", nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+
+	// The lock of the other instance is not released.
+	token, held := locks.holder("key:lock")
+	require.True(t, held)
+	require.Equal(t, "other", token)
+}
+
+// multiGetClient is a fakeClient implementing memcache.MultiGetter.
+type multiGetClient struct {
+	*fakeClient
+	multiGetCalls int
+}
+
+func (c *multiGetClient) GetMulti(keys []string) (map[string][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.multiGetCalls++
+	values := make(map[string][]byte)
+	for _, key := range keys {
+		if value, ok := c.items[key]; ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func TestMemcache_BatchOperations(t *testing.T) {
+	ctx := context.Background()
+
+	for name, client := range map[string]memcache.Client{
+		"per-key client":   newFakeClient(),
+		"multi-get client": &multiGetClient{fakeClient: newFakeClient()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := memcache.New[int](client, memcache.WithMissErrors[int](errFakeMiss), memcache.WithKeyPrefix[int]("svc:"))
+			batch, ok := c.(cache.BatchCache[int])
+			require.True(t, ok, "Expected memcache to implement BatchCache")
+
+			batch.SetMulti(ctx, map[string]int{"a": 1, "b": 2, "c": 3}, nil)
+
+			values, err := batch.GetMulti(ctx, []string{"a", "b", "missing"})
+			require.NoError(t, err)
+			require.Equal(t, map[string]int{"a": 1, "b": 2}, values)
+
+			require.NoError(t, batch.InvalidateMulti(ctx, []string{"a", "missing"}))
+			values, err = batch.GetMulti(ctx, []string{"a", "b", "c"})
+			require.NoError(t, err)
+			require.Equal(t, map[string]int{"b": 2, "c": 3}, values)
+
+			if multiGet, ok := client.(*multiGetClient); ok {
+				require.Equal(t, 2, multiGet.multiGetCalls)
+			}
+		})
+	}
+}
+
+func TestMemcache_ConditionalSet(t *testing.T) {
+	ctx := context.Background()
+	client := &addClient{fakeClient: newFakeClient()}
+	c := memcache.New[string](client, memcache.WithMissErrors[string](errFakeMiss))
+	setter, ok := c.(cache.ConditionalSetter[string])
+	require.True(t, ok, "Expected memcache to implement cache.ConditionalSetter")
+
+	require.True(t, setter.SetIfAbsent(ctx, "key", "first", nil))
+	require.False(t, setter.SetIfAbsent(ctx, "key", "second", nil))
+
+	actual, loaded := setter.GetOrSet(ctx, "key", "other", nil)
+	require.True(t, loaded)
+	require.Equal(t, "first", actual)
+	actual, loaded = setter.GetOrSet(ctx, "new", "value", nil)
+	require.False(t, loaded)
+	require.Equal(t, "value", actual)
+	value, err := c.Get(ctx, "new", nil)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+}
+
+func TestMemcache_ConditionalSet_AddNotSupported(t *testing.T) {
+	ctx := context.Background()
+	var errs []error
+	c := memcache.New[string](newFakeClient(),
+		memcache.WithMissErrors[string](errFakeMiss),
+		memcache.WithErrorHandler[string](func(ctx context.Context, key string, err error) {
+			errs = append(errs, err)
+		}),
+	)
+	setter := c.(cache.ConditionalSetter[string])
+
+	require.False(t, setter.SetIfAbsent(ctx, "key", "value", nil))
+	actual, loaded := setter.GetOrSet(ctx, "key", "value", nil)
+	require.False(t, loaded)
+	require.Equal(t, "value", actual)
+	require.Len(t, errs, 2)
+	require.ErrorIs(t, errs[0], memcache.ErrAddNotSupported)
+}
+
+// counterClient is an addClient implementing memcache.Incrementer.
+type counterClient struct {
+	*addClient
+}
+
+func (c *counterClient) Increment(key string, delta uint64) (uint64, error) {
+	return c.update(key, func(value uint64) uint64 { return value + delta })
+}
+
+func (c *counterClient) Decrement(key string, delta uint64) (uint64, error) {
+	return c.update(key, func(value uint64) uint64 { return value - min(value, delta) })
+}
+
+func (c *counterClient) update(key string, fn func(value uint64) uint64) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.items[key]
+	if !ok {
+		return 0, errFakeMiss
+	}
+	value, err := strconv.ParseUint(string(data), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	value = fn(value)
+	c.items[key] = []byte(strconv.FormatUint(value, 10))
+	return value, nil
+}
+
+func TestMemcache_Counter(t *testing.T) {
+	ctx := context.Background()
+	client := &counterClient{addClient: &addClient{fakeClient: newFakeClient()}}
+	c := memcache.New[int](client, memcache.WithMissErrors[int](errFakeMiss))
+	counter, ok := c.(cache.Counter)
+	require.True(t, ok, "Expected memcache to implement cache.Counter")
+
+	// A missing key is created with delta and the given expiration.
+	minute := time.Minute
+	value, err := counter.Increment(ctx, "hits", 5, &minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), value)
+	require.Equal(t, int32(60), client.expirations["hits"])
+
+	value, err = counter.Increment(ctx, "hits", 2, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), value)
+	value, err = counter.Decrement(ctx, "hits", 10, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), value, "Expected memcached counters to stop at zero")
+
+	// Counters are readable with the default codec.
+	_, err = counter.Increment(ctx, "hits", 3, nil)
+	require.NoError(t, err)
+	cached, err := c.Get(ctx, "hits", nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, cached)
+
+	// Concurrent increments are not lost.
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = counter.Increment(ctx, "concurrent", 1, nil)
+		}()
+	}
+	wg.Wait()
+	cached, err = c.Get(ctx, "concurrent", nil)
+	require.NoError(t, err)
+	require.Equal(t, 50, cached)
+
+	// The client must support incr and add.
+	unsupported := memcache.New[int](newFakeClient()).(cache.Counter)
+	_, err = unsupported.Increment(ctx, "hits", 1, nil)
+	require.ErrorIs(t, err, memcache.ErrIncrementNotSupported)
+}
+
+func TestMemcache_Get_ContextCancellation(t *testing.T) {
+	ctx := context.Background()
+	c := memcache.New[string](newFakeClient(), memcache.WithMissErrors[string](errFakeMiss))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, err := c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+			close(started)
+			<-release
+			return "value", nil, nil
+		})
+		leaderDone <- err
+	}()
+	<-started
+
+	// A caller waiting for the initializer of another caller returns once its context is done.
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err := c.Get(timeout, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "other", nil, nil
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, <-leaderDone)
+	value, err := c.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+
+	// The backend is not called once the context is done.
+	cancelled, cancelNow := context.WithCancel(ctx)
+	cancelNow()
+	_, err = c.Get(cancelled, "key", nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestMemcache_MaxConcurrentInitializers(t *testing.T) {
+	ctx := context.Background()
+	c := memcache.New[string](newFakeClient(),
+		memcache.WithMissErrors[string](errFakeMiss),
+		memcache.WithMaxConcurrentInitializers[string](1, -1),
+	)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Get(ctx, "slow", func(ctx context.Context, key string) (string, *time.Duration, error) {
+			close(started)
+			<-release
+			return "slow", nil, nil
+		})
+		done <- err
+	}()
+	<-started
+
+	// Without a wait, a Get exceeding the limit fails right away.
+	initializer := func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "value", nil, nil
+	}
+	_, err := c.Get(ctx, "key", initializer)
+	require.ErrorIs(t, err, cache.ErrTooManyInitializers)
+
+	close(release)
+	require.NoError(t, <-done)
+	value, err := c.Get(ctx, "key", initializer)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+}
+
+func TestMemcache_Get_InitializerPanic(t *testing.T) {
+	ctx := context.Background()
+	c := memcache.New[string](newFakeClient(), memcache.WithMissErrors[string](errFakeMiss))
+
+	// The panic of the initializer is raised on the goroutine of the caller, which can recover it.
+	recovered := func() (r interface{}) {
+		defer func() { r = recover() }()
+		_, _ = c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+			panic("initializer failed")
+		})
+		return nil
+	}()
+	require.NotNil(t, recovered)
+	require.ErrorContains(t, recovered.(error), "initializer failed")
+
+	// The cache remains usable.
+	value, err := c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "value", nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+}
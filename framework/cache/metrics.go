@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+/*
+WithMetrics wraps c to record its hits, misses and initializer calls, so that any backend reports the same Stats.
+The returned cache implements StatsProvider, taking the entries and evictions from c if c implements
+StatsProvider. Register it with a StatsExporter to expose the metrics.
+
+The returned cache implements BatchCache, falling back to per-key operations if c does not.
+
+Example usage:
+
+	users := cache.WithMetrics(memcache.New[User](client))
+	_ = exporter.Register("users", users.(cache.StatsProvider))
+*/
+func WithMetrics[T any](c Cache[T]) BatchCache[T] {
+	return &meteredCache[T]{cache: c}
+}
+
+type meteredCache[T any] struct {
+	cache Cache[T]
+	stats StatsRecorder
+}
+
+func (c *meteredCache[T]) Get(ctx context.Context, key string, initializer Initializer[T]) (T, error) {
+	// The initializer may be kept by c and called again later in the background, e.g., by a refresh-ahead.
+	var invoked atomic.Bool
+	metered := initializer
+	if initializer != nil {
+		metered = func(ctx context.Context, key string) (T, *time.Duration, error) {
+			invoked.Store(true)
+			start := time.Now()
+			value, duration, err := initializer(ctx, key)
+			c.stats.RecordInitializer(time.Since(start), err)
+			return value, duration, err
+		}
+	}
+
+	value, err := c.cache.Get(ctx, key, metered)
+	switch {
+	case invoked.Load() || errors.Is(err, ErrCacheMiss):
+		c.stats.RecordMisses(1)
+	case err == nil:
+		c.stats.RecordHits(1)
+	}
+	return value, err
+}
+
+func (c *meteredCache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	c.cache.Set(ctx, key, value, duration)
+}
+
+func (c *meteredCache[T]) Invalidate(ctx context.Context, key string) error {
+	return c.cache.Invalidate(ctx, key)
+}
+
+func (c *meteredCache[T]) InvalidateAll(ctx context.Context) error {
+	return c.cache.InvalidateAll(ctx)
+}
+
+func (c *meteredCache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	values, err := GetMulti(ctx, c.cache, keys)
+	if err == nil {
+		c.stats.RecordHits(len(values))
+		c.stats.RecordMisses(len(keys) - len(values))
+	}
+	return values, err
+}
+
+func (c *meteredCache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	SetMulti(ctx, c.cache, items, duration)
+}
+
+func (c *meteredCache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	return InvalidateMulti(ctx, c.cache, keys)
+}
+
+// Close closes the wrapped cache, implementing Closer.
+func (c *meteredCache[T]) Close() error {
+	return Close(c.cache)
+}
+
+// CacheStats returns the recorded metrics, with the entries and evictions of the wrapped cache if it reports them.
+func (c *meteredCache[T]) CacheStats() Stats {
+	stats := c.stats.Snapshot()
+	if provider, ok := c.cache.(StatsProvider); ok {
+		wrapped := provider.CacheStats()
+		stats.Entries = wrapped.Entries
+		stats.Evictions = wrapped.Evictions
+	}
+	return stats
+}
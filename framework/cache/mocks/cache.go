@@ -90,3 +90,227 @@ func (mr *MockCacheMockRecorder[T]) Set(ctx, key, value, duration interface{}) *
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCache[T])(nil).Set), ctx, key, value, duration)
 }
+
+// MockBatchCache is a mock of BatchCache interface.
+type MockBatchCache[T any] struct {
+	ctrl     *gomock.Controller
+	recorder *MockBatchCacheMockRecorder[T]
+}
+
+// MockBatchCacheMockRecorder is the mock recorder for MockBatchCache.
+type MockBatchCacheMockRecorder[T any] struct {
+	mock *MockBatchCache[T]
+}
+
+// NewMockBatchCache creates a new mock instance.
+func NewMockBatchCache[T any](ctrl *gomock.Controller) *MockBatchCache[T] {
+	mock := &MockBatchCache[T]{ctrl: ctrl}
+	mock.recorder = &MockBatchCacheMockRecorder[T]{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBatchCache[T]) EXPECT() *MockBatchCacheMockRecorder[T] {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockBatchCache[T]) Get(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key, initializer)
+	ret0, _ := ret[0].(T)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockBatchCacheMockRecorder[T]) Get(ctx, key, initializer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockBatchCache[T])(nil).Get), ctx, key, initializer)
+}
+
+// GetMulti mocks base method.
+func (m *MockBatchCache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMulti", ctx, keys)
+	ret0, _ := ret[0].(map[string]T)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMulti indicates an expected call of GetMulti.
+func (mr *MockBatchCacheMockRecorder[T]) GetMulti(ctx, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMulti", reflect.TypeOf((*MockBatchCache[T])(nil).GetMulti), ctx, keys)
+}
+
+// Invalidate mocks base method.
+func (m *MockBatchCache[T]) Invalidate(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Invalidate", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Invalidate indicates an expected call of Invalidate.
+func (mr *MockBatchCacheMockRecorder[T]) Invalidate(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invalidate", reflect.TypeOf((*MockBatchCache[T])(nil).Invalidate), ctx, key)
+}
+
+// InvalidateAll mocks base method.
+func (m *MockBatchCache[T]) InvalidateAll(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidateAll", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidateAll indicates an expected call of InvalidateAll.
+func (mr *MockBatchCacheMockRecorder[T]) InvalidateAll(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateAll", reflect.TypeOf((*MockBatchCache[T])(nil).InvalidateAll), ctx)
+}
+
+// InvalidateMulti mocks base method.
+func (m *MockBatchCache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidateMulti", ctx, keys)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidateMulti indicates an expected call of InvalidateMulti.
+func (mr *MockBatchCacheMockRecorder[T]) InvalidateMulti(ctx, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateMulti", reflect.TypeOf((*MockBatchCache[T])(nil).InvalidateMulti), ctx, keys)
+}
+
+// Set mocks base method.
+func (m *MockBatchCache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Set", ctx, key, value, duration)
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockBatchCacheMockRecorder[T]) Set(ctx, key, value, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockBatchCache[T])(nil).Set), ctx, key, value, duration)
+}
+
+// SetMulti mocks base method.
+func (m *MockBatchCache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetMulti", ctx, items, duration)
+}
+
+// SetMulti indicates an expected call of SetMulti.
+func (mr *MockBatchCacheMockRecorder[T]) SetMulti(ctx, items, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMulti", reflect.TypeOf((*MockBatchCache[T])(nil).SetMulti), ctx, items, duration)
+}
+
+// MockConditionalSetter is a mock of ConditionalSetter interface.
+type MockConditionalSetter[T any] struct {
+	ctrl     *gomock.Controller
+	recorder *MockConditionalSetterMockRecorder[T]
+}
+
+// MockConditionalSetterMockRecorder is the mock recorder for MockConditionalSetter.
+type MockConditionalSetterMockRecorder[T any] struct {
+	mock *MockConditionalSetter[T]
+}
+
+// NewMockConditionalSetter creates a new mock instance.
+func NewMockConditionalSetter[T any](ctrl *gomock.Controller) *MockConditionalSetter[T] {
+	mock := &MockConditionalSetter[T]{ctrl: ctrl}
+	mock.recorder = &MockConditionalSetterMockRecorder[T]{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConditionalSetter[T]) EXPECT() *MockConditionalSetterMockRecorder[T] {
+	return m.recorder
+}
+
+// GetOrSet mocks base method.
+func (m *MockConditionalSetter[T]) GetOrSet(ctx context.Context, key string, value T, duration *time.Duration) (T, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrSet", ctx, key, value, duration)
+	ret0, _ := ret[0].(T)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetOrSet indicates an expected call of GetOrSet.
+func (mr *MockConditionalSetterMockRecorder[T]) GetOrSet(ctx, key, value, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrSet", reflect.TypeOf((*MockConditionalSetter[T])(nil).GetOrSet), ctx, key, value, duration)
+}
+
+// SetIfAbsent mocks base method.
+func (m *MockConditionalSetter[T]) SetIfAbsent(ctx context.Context, key string, value T, duration *time.Duration) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetIfAbsent", ctx, key, value, duration)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// SetIfAbsent indicates an expected call of SetIfAbsent.
+func (mr *MockConditionalSetterMockRecorder[T]) SetIfAbsent(ctx, key, value, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIfAbsent", reflect.TypeOf((*MockConditionalSetter[T])(nil).SetIfAbsent), ctx, key, value, duration)
+}
+
+// MockCounter is a mock of Counter interface.
+type MockCounter struct {
+	ctrl     *gomock.Controller
+	recorder *MockCounterMockRecorder
+}
+
+// MockCounterMockRecorder is the mock recorder for MockCounter.
+type MockCounterMockRecorder struct {
+	mock *MockCounter
+}
+
+// NewMockCounter creates a new mock instance.
+func NewMockCounter(ctrl *gomock.Controller) *MockCounter {
+	mock := &MockCounter{ctrl: ctrl}
+	mock.recorder = &MockCounterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCounter) EXPECT() *MockCounterMockRecorder {
+	return m.recorder
+}
+
+// Decrement mocks base method.
+func (m *MockCounter) Decrement(ctx context.Context, key string, delta int64, duration *time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decrement", ctx, key, delta, duration)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decrement indicates an expected call of Decrement.
+func (mr *MockCounterMockRecorder) Decrement(ctx, key, delta, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decrement", reflect.TypeOf((*MockCounter)(nil).Decrement), ctx, key, delta, duration)
+}
+
+// Increment mocks base method.
+func (m *MockCounter) Increment(ctx context.Context, key string, delta int64, duration *time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Increment", ctx, key, delta, duration)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Increment indicates an expected call of Increment.
+func (mr *MockCounterMockRecorder) Increment(ctx, key, delta, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Increment", reflect.TypeOf((*MockCounter)(nil).Increment), ctx, key, delta, duration)
+}
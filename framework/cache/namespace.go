@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// namespaceSeparator separates the namespace from the keys.
+const namespaceSeparator = ":"
+
+var ErrNamespaceInvalidationNotSupported = errors.New("cache does not support invalidating a namespace")
+
+// KeyLister is implemented by caches able to list their keys, such as localcache.
+type KeyLister interface {
+	// Keys returns the keys of the items in the cache, in no particular order.
+	Keys(ctx context.Context) []string
+}
+
+/*
+WithNamespace wraps c to prefix every key with ns and a colon, so that several features sharing a backend cannot
+collide. Initializers receive the keys without the namespace.
+
+InvalidateAll only removes the keys of the namespace. It requires c to implement KeyLister, and returns
+ErrNamespaceInvalidationNotSupported otherwise (e.g., Memcached cannot list its keys), rather than removing the
+keys of the other namespaces.
+
+The returned cache implements BatchCache, falling back to per-key operations if c does not, and KeyLister if c does.
+Unlike other wrappers, it does not implement Closer, since c is usually shared between namespaces: close c itself.
+
+Example usage:
+
+	shared := localcache.New[string]()
+	sessions := cache.WithNamespace(shared, "sessions")
+	profiles := cache.WithNamespace(shared, "profiles")
+	sessions.Set(ctx, "1", "...", nil) // stored as "sessions:1"
+	err := profiles.InvalidateAll(ctx) // keeps the sessions
+*/
+func WithNamespace[T any](c Cache[T], ns string) BatchCache[T] {
+	return &namespacedCache[T]{
+		cache:  c,
+		prefix: ns + namespaceSeparator,
+	}
+}
+
+type namespacedCache[T any] struct {
+	cache  Cache[T]
+	prefix string
+}
+
+func (c *namespacedCache[T]) Get(ctx context.Context, key string, initializer Initializer[T]) (T, error) {
+	if initializer == nil {
+		return c.cache.Get(ctx, c.prefix+key, nil)
+	}
+	return c.cache.Get(ctx, c.prefix+key, func(ctx context.Context, _ string) (T, *time.Duration, error) {
+		return initializer(ctx, key)
+	})
+}
+
+func (c *namespacedCache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	c.cache.Set(ctx, c.prefix+key, value, duration)
+}
+
+func (c *namespacedCache[T]) Invalidate(ctx context.Context, key string) error {
+	return c.cache.Invalidate(ctx, c.prefix+key)
+}
+
+// InvalidateAll removes the keys of the namespace.
+func (c *namespacedCache[T]) InvalidateAll(ctx context.Context) error {
+	if !listsKeys(c.cache) {
+		return ErrNamespaceInvalidationNotSupported
+	}
+	var keys []string
+	for _, key := range c.cache.(KeyLister).Keys(ctx) {
+		if strings.HasPrefix(key, c.prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return InvalidateMulti(ctx, c.cache, keys)
+}
+
+func (c *namespacedCache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	values, err := GetMulti(ctx, c.cache, c.prefixed(keys))
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]T, len(values))
+	for key, value := range values {
+		result[strings.TrimPrefix(key, c.prefix)] = value
+	}
+	return result, nil
+}
+
+func (c *namespacedCache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	prefixed := make(map[string]T, len(items))
+	for key, value := range items {
+		prefixed[c.prefix+key] = value
+	}
+	SetMulti(ctx, c.cache, prefixed, duration)
+}
+
+func (c *namespacedCache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	return InvalidateMulti(ctx, c.cache, c.prefixed(keys))
+}
+
+// Keys returns the keys of the namespace, without the namespace. It returns nil if the wrapped cache does not implement KeyLister.
+func (c *namespacedCache[T]) Keys(ctx context.Context) []string {
+	lister, ok := c.cache.(KeyLister)
+	if !ok {
+		return nil
+	}
+	var keys []string
+	for _, key := range lister.Keys(ctx) {
+		if strings.HasPrefix(key, c.prefix) {
+			keys = append(keys, strings.TrimPrefix(key, c.prefix))
+		}
+	}
+	return keys
+}
+
+// listsKeys reports whether the wrapped cache can list its keys.
+func (c *namespacedCache[T]) listsKeys() bool {
+	return listsKeys(c.cache)
+}
+
+// listsKeys reports whether c can list its keys. A namespace can only list its keys if the cache it wraps can.
+func listsKeys(c any) bool {
+	if namespaced, ok := c.(interface{ listsKeys() bool }); ok {
+		return namespaced.listsKeys()
+	}
+	_, ok := c.(KeyLister)
+	return ok
+}
+
+func (c *namespacedCache[T]) prefixed(keys []string) []string {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	return prefixed
+}
@@ -0,0 +1,78 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+	cache_mocks "github.com/kittipat1413/go-common/framework/cache/mocks"
+)
+
+func TestWithNamespace(t *testing.T) {
+	ctx := context.Background()
+	shared := localcache.New[string]()
+	sessions := cache.WithNamespace(shared, "sessions")
+	profiles := cache.WithNamespace(shared, "profiles")
+
+	sessions.Set(ctx, "1", "session", nil)
+	profiles.Set(ctx, "1", "profile", nil)
+
+	value, err := sessions.Get(ctx, "1", nil)
+	require.NoError(t, err)
+	require.Equal(t, "session", value)
+	value, err = shared.Get(ctx, "profiles:1", nil)
+	require.NoError(t, err)
+	require.Equal(t, "profile", value)
+
+	// Initializers receive the keys without the namespace.
+	value, err = profiles.Get(ctx, "2", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "loaded " + key, nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "loaded 2", value)
+
+	require.ElementsMatch(t, []string{"1", "2"}, profiles.(cache.KeyLister).Keys(ctx))
+
+	// InvalidateAll only removes the keys of the namespace.
+	require.NoError(t, profiles.InvalidateAll(ctx))
+	_, err = profiles.Get(ctx, "1", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+	value, err = sessions.Get(ctx, "1", nil)
+	require.NoError(t, err)
+	require.Equal(t, "session", value)
+}
+
+func TestWithNamespace_BatchOperations(t *testing.T) {
+	ctx := context.Background()
+	shared := localcache.New[int]()
+	c := cache.WithNamespace(cache.WithNamespace(shared, "app"), "counters")
+
+	c.SetMulti(ctx, map[string]int{"a": 1, "b": 2}, nil)
+	values, err := c.GetMulti(ctx, []string{"a", "b", "missing"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"a": 1, "b": 2}, values)
+
+	require.NoError(t, c.InvalidateMulti(ctx, []string{"a"}))
+	values, err = cache.GetMulti(ctx, shared, []string{"app:counters:a", "app:counters:b"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"app:counters:b": 2}, values)
+}
+
+func TestWithNamespace_InvalidateAllNotSupported(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	mock := cache_mocks.NewMockCache[string](ctrl)
+
+	// The wrapped cache cannot list its keys, and is never flushed as a whole.
+	for _, c := range []cache.Cache[string]{
+		cache.WithNamespace[string](mock, "ns"),
+		cache.WithNamespace(cache.WithNamespace[string](mock, "outer"), "inner"),
+	} {
+		require.ErrorIs(t, c.InvalidateAll(ctx), cache.ErrNamespaceInvalidationNotSupported)
+	}
+}
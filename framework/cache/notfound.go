@@ -0,0 +1,233 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+)
+
+// notFoundFilterOptions holds configuration options for the not found filter.
+type notFoundFilterOptions struct {
+	capacity          int
+	falsePositiveRate float64
+	rotationInterval  time.Duration
+}
+
+// NotFoundFilterOption specifies not found filter configuration options.
+type NotFoundFilterOption func(*notFoundFilterOptions)
+
+// WithFilterCapacity sizes the bloom filter for n keys with the given false positive rate. The filter uses about
+// 1.44·log2(1/rate) bits per key, e.g., 1.2 MB for a million keys at 1%. Defaults to 100,000 keys at 1%.
+func WithFilterCapacity(n int, falsePositiveRate float64) NotFoundFilterOption {
+	return func(opts *notFoundFilterOptions) {
+		if n > 0 && falsePositiveRate > 0 && falsePositiveRate < 1 {
+			opts.capacity = n
+			opts.falsePositiveRate = falsePositiveRate
+		}
+	}
+}
+
+// WithFilterRotation sets how long the keys are remembered: the filter is replaced by an empty one every interval,
+// keeping the previous one for another interval, so keys are remembered for one to two intervals. Keys created in
+// the downstream system are therefore found again after at most two intervals. Defaults to an hour.
+func WithFilterRotation(interval time.Duration) NotFoundFilterOption {
+	return func(opts *notFoundFilterOptions) {
+		if interval > 0 {
+			opts.rotationInterval = interval
+		}
+	}
+}
+
+/*
+WithNotFoundFilter wraps c to remember, in a bloom filter, the keys whose initializer returned notFound (checked
+with errors.Is), so that repeated lookups of keys that do not exist, e.g., from scrapers or enumeration attacks,
+do not reach the initializer and the database every time. Get then returns notFound without calling the
+initializer, unless the key was stored in c meanwhile.
+
+A bloom filter never forgets a key and may report keys it never saw (1% of them by default, see
+WithFilterCapacity), so a key may be reported as not found while it exists downstream until the filter rotates
+(see WithFilterRotation). Set the key, or call InvalidateAll, which clears the filter, to find it immediately.
+
+The returned cache implements BatchCache, falling back to per-key operations if c does not.
+
+Example usage:
+
+	users := cache.WithNotFoundFilter(localcache.New[User](), sql.ErrNoRows)
+	user, err := users.Get(ctx, "user:1", loadUser) // later lookups of a missing user return sql.ErrNoRows
+*/
+func WithNotFoundFilter[T any](c Cache[T], notFound error, options ...NotFoundFilterOption) BatchCache[T] {
+	opts := &notFoundFilterOptions{
+		capacity:          100_000,
+		falsePositiveRate: 0.01,
+		rotationInterval:  time.Hour,
+	}
+	for _, opt := range options {
+		opt(opts)
+	}
+	return &notFoundFilteredCache[T]{
+		cache:    c,
+		notFound: notFound,
+		filter:   newRotatingBloomFilter(opts.capacity, opts.falsePositiveRate, opts.rotationInterval),
+	}
+}
+
+type notFoundFilteredCache[T any] struct {
+	cache    Cache[T]
+	notFound error
+	filter   *rotatingBloomFilter
+}
+
+// Get retrieves a value from the wrapped cache. If key is known not to exist, it returns the notFound error
+// without calling the initializer.
+func (c *notFoundFilteredCache[T]) Get(ctx context.Context, key string, initializer Initializer[T]) (T, error) {
+	if initializer == nil {
+		return c.cache.Get(ctx, key, nil)
+	}
+	if c.filter.contains(key) {
+		value, err := c.cache.Get(ctx, key, nil)
+		if errors.Is(err, ErrCacheMiss) {
+			return value, c.notFound
+		}
+		return value, err
+	}
+	return c.cache.Get(ctx, key, func(ctx context.Context, key string) (T, *time.Duration, error) {
+		value, duration, err := initializer(ctx, key)
+		if errors.Is(err, c.notFound) {
+			c.filter.add(key)
+		}
+		return value, duration, err
+	})
+}
+
+func (c *notFoundFilteredCache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	c.cache.Set(ctx, key, value, duration)
+}
+
+func (c *notFoundFilteredCache[T]) Invalidate(ctx context.Context, key string) error {
+	return c.cache.Invalidate(ctx, key)
+}
+
+// InvalidateAll removes all keys from the wrapped cache and clears the filter.
+func (c *notFoundFilteredCache[T]) InvalidateAll(ctx context.Context) error {
+	c.filter.reset()
+	return c.cache.InvalidateAll(ctx)
+}
+
+func (c *notFoundFilteredCache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	return GetMulti(ctx, c.cache, keys)
+}
+
+func (c *notFoundFilteredCache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	SetMulti(ctx, c.cache, items, duration)
+}
+
+func (c *notFoundFilteredCache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	return InvalidateMulti(ctx, c.cache, keys)
+}
+
+// Close closes the wrapped cache, implementing Closer.
+func (c *notFoundFilteredCache[T]) Close() error {
+	return Close(c.cache)
+}
+
+// rotatingBloomFilter is a bloom filter forgetting its keys after one to two rotation intervals, by checking
+// a current and a previous filter, and replacing the previous filter by the current one every interval.
+type rotatingBloomFilter struct {
+	mutex     sync.Mutex
+	current   []uint64
+	previous  []uint64
+	hashes    int
+	interval  time.Duration
+	rotatedAt time.Time
+}
+
+func newRotatingBloomFilter(capacity int, falsePositiveRate float64, interval time.Duration) *rotatingBloomFilter {
+	// Optimal number of bits m = -n·ln(p)/ln(2)², and of hash functions k = m/n·ln(2).
+	bits := math.Ceil(-float64(capacity) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	hashes := int(math.Max(1, math.Round(bits/float64(capacity)*math.Ln2)))
+	words := int(bits+63) / 64
+	return &rotatingBloomFilter{
+		current:   make([]uint64, words),
+		previous:  make([]uint64, words),
+		hashes:    hashes,
+		interval:  interval,
+		rotatedAt: time.Now(),
+	}
+}
+
+func (f *rotatingBloomFilter) add(key string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.rotateIfNeeded()
+	size := uint64(len(f.current) * 64)
+	h1, h2 := bloomHashes(key)
+	for i := 0; i < f.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % size
+		f.current[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (f *rotatingBloomFilter) contains(key string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.rotateIfNeeded()
+	h1, h2 := bloomHashes(key)
+	return bloomContains(f.current, f.hashes, h1, h2) || bloomContains(f.previous, f.hashes, h1, h2)
+}
+
+func (f *rotatingBloomFilter) reset() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	clear(f.current)
+	clear(f.previous)
+	f.rotatedAt = time.Now()
+}
+
+// rotateIfNeeded replaces the previous filter by the current one once the interval elapsed. The caller must hold
+// the lock.
+func (f *rotatingBloomFilter) rotateIfNeeded() {
+	now := time.Now()
+	switch elapsed := now.Sub(f.rotatedAt); {
+	case elapsed >= 2*f.interval:
+		// Both filters are older than the interval.
+		clear(f.current)
+		clear(f.previous)
+	case elapsed >= f.interval:
+		f.current, f.previous = f.previous, f.current
+		clear(f.current)
+	default:
+		return
+	}
+	f.rotatedAt = now
+}
+
+func bloomContains(bits []uint64, hashes int, h1, h2 uint64) bool {
+	size := uint64(len(bits) * 64)
+	for i := 0; i < hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % size
+		if bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes returns the two hashes of key combined to derive the hash functions of the filter
+// (Kirsch-Mitzenmacher double hashing).
+func bloomHashes(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	h1 := h.Sum64()
+	// Derive the second hash by mixing the first one (murmur3 finalizer), and make it odd so that it
+	// is never zero.
+	h2 := h1 ^ (h1 >> 33)
+	h2 *= 0xff51afd7ed558ccd
+	h2 ^= h2 >> 33
+	return h1, h2 | 1
+}
@@ -0,0 +1,106 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+)
+
+var errUserNotFound = errors.New("user not found")
+
+func TestWithNotFoundFilter(t *testing.T) {
+	ctx := context.Background()
+	c := cache.WithNotFoundFilter(localcache.New[string](), errUserNotFound)
+
+	calls := 0
+	initializer := func(ctx context.Context, key string) (string, *time.Duration, error) {
+		calls++
+		if key == "missing" {
+			return "", nil, errUserNotFound
+		}
+		return "user " + key, nil, nil
+	}
+
+	// Keys known not to exist do not reach the initializer again.
+	for i := 0; i < 3; i++ {
+		_, err := c.Get(ctx, "missing", initializer)
+		require.ErrorIs(t, err, errUserNotFound)
+	}
+	require.Equal(t, 1, calls)
+
+	value, err := c.Get(ctx, "1", initializer)
+	require.NoError(t, err)
+	require.Equal(t, "user 1", value)
+	require.Equal(t, 2, calls)
+
+	// A key set meanwhile is found.
+	c.Set(ctx, "missing", "created", nil)
+	value, err = c.Get(ctx, "missing", initializer)
+	require.NoError(t, err)
+	require.Equal(t, "created", value)
+
+	// InvalidateAll clears the filter.
+	require.NoError(t, c.InvalidateAll(ctx))
+	_, err = c.Get(ctx, "missing", initializer)
+	require.ErrorIs(t, err, errUserNotFound)
+	require.Equal(t, 3, calls)
+}
+
+func TestWithNotFoundFilter_Rotation(t *testing.T) {
+	ctx := context.Background()
+	c := cache.WithNotFoundFilter(localcache.New[string](), errUserNotFound,
+		cache.WithFilterRotation(20*time.Millisecond),
+	)
+
+	calls := 0
+	initializer := func(ctx context.Context, key string) (string, *time.Duration, error) {
+		calls++
+		return "", nil, errUserNotFound
+	}
+	_, err := c.Get(ctx, "missing", initializer)
+	require.ErrorIs(t, err, errUserNotFound)
+
+	// The key is remembered for one to two intervals.
+	time.Sleep(25 * time.Millisecond)
+	_, err = c.Get(ctx, "missing", initializer)
+	require.ErrorIs(t, err, errUserNotFound)
+	require.Equal(t, 1, calls)
+
+	time.Sleep(45 * time.Millisecond)
+	_, err = c.Get(ctx, "missing", initializer)
+	require.ErrorIs(t, err, errUserNotFound)
+	require.Equal(t, 2, calls)
+}
+
+func TestWithNotFoundFilter_FalsePositiveRate(t *testing.T) {
+	ctx := context.Background()
+	c := cache.WithNotFoundFilter(localcache.New[string](), errUserNotFound,
+		cache.WithFilterCapacity(1000, 0.01),
+	)
+
+	notFound := func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "", nil, errUserNotFound
+	}
+	for i := 0; i < 1000; i++ {
+		_, _ = c.Get(ctx, "missing:"+strconv.Itoa(i), notFound)
+	}
+
+	// Keys never seen rarely hit the filter.
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		_, err := c.Get(ctx, "user:"+strconv.Itoa(i), func(ctx context.Context, key string) (string, *time.Duration, error) {
+			return "user", nil, nil
+		})
+		if err != nil {
+			falsePositives++
+		}
+	}
+	require.Less(t, falsePositives, 300, "Expected about 1% of false positives")
+}
@@ -0,0 +1,252 @@
+package ristretto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	cache "github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/internal/flight"
+	"github.com/kittipat1413/go-common/util/pointer"
+	syncutil "github.com/kittipat1413/go-common/util/sync"
+)
+
+const (
+	// NoExpireDuration makes items persist until they are evicted by ristretto.
+	NoExpireDuration      time.Duration = -1
+	defaultExpireDuration time.Duration = NoExpireDuration
+)
+
+var (
+	// ErrNotAdmitted is reported to the WithErrorHandler function when ristretto drops a Set, because of its
+	// admission policy or because its write buffers are full.
+	ErrNotAdmitted = errors.New("ristretto: item not admitted")
+)
+
+/*
+Client is the subset of ristretto operations used by the cache. A *ristretto.Cache[string, T] of
+github.com/dgraph-io/ristretto/v2 implements it:
+
+	client, err := ristretto.NewCache(&ristretto.Config[string, User]{
+		NumCounters: 1e7,
+		MaxCost:     1 << 30,
+		BufferItems: 64,
+	})
+	if err != nil {
+		// Handle error
+	}
+	users := ristrettocache.New[User](client)
+*/
+type Client[T any] interface {
+	// Get returns the value of key, and false if it does not exist.
+	Get(key string) (T, bool)
+	// SetWithTTL stores value under key with cost, expiring after ttl, zero meaning no expiration. It returns false
+	// if the item was dropped.
+	SetWithTTL(key string, value T, cost int64, ttl time.Duration) bool
+	// Del removes key.
+	Del(key string)
+	// Clear removes all keys.
+	Clear()
+}
+
+// Waiter is implemented by clients able to wait for their buffered writes to be applied, such as ristretto.
+type Waiter interface {
+	Wait()
+}
+
+type config[T any] struct {
+	defaultExpireDuration time.Duration
+	cost                  func(value T) int64
+	waitOnSet             bool
+	errorHandler          func(ctx context.Context, key string, err error)
+}
+
+type Option[T any] func(*config[T])
+
+// WithDefaultExpiration sets the default expiration duration for cache items.
+func WithDefaultExpiration[T any](expiration time.Duration) Option[T] {
+	return func(c *config[T]) {
+		c.defaultExpireDuration = expiration
+	}
+}
+
+// WithCost sets the function computing the cost of an item, weighed by the admission policy and counted against
+// the MaxCost of ristretto. Defaults to a cost of zero, which lets the Cost function of the ristretto config
+// compute it.
+func WithCost[T any](cost func(value T) int64) Option[T] {
+	return func(c *config[T]) {
+		c.cost = cost
+	}
+}
+
+// WithWaitOnSet makes Set wait until ristretto applied the write, so that a Get right after a Set finds the
+// value. Ristretto applies writes asynchronously, which is faster but may briefly miss a value just set.
+// The Client must implement Waiter, otherwise the option has no effect.
+func WithWaitOnSet[T any]() Option[T] {
+	return func(c *config[T]) {
+		c.waitOnSet = true
+	}
+}
+
+// WithErrorHandler sets a function called when Set fails, e.g., with ErrNotAdmitted, since Set does not return errors.
+func WithErrorHandler[T any](handler func(ctx context.Context, key string, err error)) Option[T] {
+	return func(c *config[T]) {
+		c.errorHandler = handler
+	}
+}
+
+func newConfig[T any](opts ...Option[T]) *config[T] {
+	c := &config[T]{
+		defaultExpireDuration: defaultExpireDuration,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type ristrettoCache[T any] struct {
+	client Client[T]
+	group  syncutil.Group[string, T]
+	stats  cache.StatsRecorder
+	config[T]
+}
+
+/*
+New creates a cache backed by a ristretto cache, whose admission policy only keeps the keys likely to be read
+again, with optional configurations. Values are stored as is, without serialization. Ristretto applies writes
+asynchronously and may drop them, so a Get right after a Set may miss: see WithWaitOnSet.
+
+Example usage:
+
+	users := ristrettocache.New[User](client,
+		ristrettocache.WithDefaultExpiration[User](10*time.Minute),
+		ristrettocache.WithCost[User](func(user User) int64 { return int64(len(user.Name)) }),
+	)
+*/
+func New[T any](client Client[T], opts ...Option[T]) cache.BatchCache[T] {
+	cfg := newConfig(opts...)
+	return &ristrettoCache[T]{
+		client: client,
+		config: pointer.GetValue(cfg),
+	}
+}
+
+// Get retrieves a value from the cache. If the key is missing and an initializer
+// is provided, it uses the initializer to obtain the value. Get returns ctx.Err() as soon as ctx is done, including
+// while waiting for the initializer of another caller, which goes on and stores the value for the other callers.
+func (c *ristrettoCache[T]) Get(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
+	if value, ok := c.client.Get(key); ok {
+		c.stats.RecordHits(1)
+		return value, nil
+	}
+	c.stats.RecordMisses(1)
+	if initializer == nil {
+		var zero T
+		return zero, cache.ErrCacheMiss
+	}
+	return c.initialize(ctx, key, initializer)
+}
+
+// Set adds an item to the cache with the specified key and duration.
+// If duration is nil, the default expiration is used.
+// If duration is NoExpireDuration, the item does not expire.
+func (c *ristrettoCache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	if err := c.set(key, value, duration); err != nil && c.errorHandler != nil {
+		c.errorHandler(ctx, key, err)
+	}
+}
+
+func (c *ristrettoCache[T]) Invalidate(ctx context.Context, key string) error {
+	c.client.Del(key)
+	return nil
+}
+
+func (c *ristrettoCache[T]) InvalidateAll(ctx context.Context) error {
+	c.client.Clear()
+	return nil
+}
+
+// GetMulti returns the values of the keys found in the cache. Missing keys are absent from the result.
+func (c *ristrettoCache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	values := make(map[string]T, len(keys))
+	for _, key := range keys {
+		if value, ok := c.client.Get(key); ok {
+			values[key] = value
+		}
+	}
+	c.stats.RecordHits(len(values))
+	c.stats.RecordMisses(len(keys) - len(values))
+	return values, nil
+}
+
+// SetMulti adds every item to the cache with the same duration.
+func (c *ristrettoCache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	for key, value := range items {
+		c.Set(ctx, key, value, duration)
+	}
+}
+
+func (c *ristrettoCache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		c.client.Del(key)
+	}
+	return nil
+}
+
+// CacheStats returns the hits, misses and initializer metrics of the cache, implementing cache.StatsProvider.
+// Entries and evictions are managed by ristretto, which reports them in its own metrics.
+func (c *ristrettoCache[T]) CacheStats() cache.Stats {
+	return c.stats.Snapshot()
+}
+
+func (c *ristrettoCache[T]) set(key string, value T, duration *time.Duration) error {
+	if duration == nil {
+		duration = pointer.ToPointer(c.defaultExpireDuration)
+	}
+	ttl := pointer.GetValue(duration)
+	switch {
+	case ttl == NoExpireDuration:
+		ttl = 0
+	case ttl <= 0:
+		// The item expires immediately, and the previous value of key is stale.
+		c.client.Del(key)
+		return nil
+	}
+
+	var cost int64
+	if c.cost != nil {
+		cost = c.cost(value)
+	}
+	if !c.client.SetWithTTL(key, value, cost, ttl) {
+		return fmt.Errorf("failed to set %q: %w", key, ErrNotAdmitted)
+	}
+	if waiter, ok := c.client.(Waiter); ok && c.waitOnSet {
+		waiter.Wait()
+	}
+	return nil
+}
+
+func (c *ristrettoCache[T]) initialize(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
+	return flight.Do(ctx, &c.group, key, func() (T, error) {
+		// Double-check if the item was initialized by another goroutine
+		if value, ok := c.client.Get(key); ok {
+			return value, nil
+		}
+
+		start := time.Now()
+		result, duration, err := initializer(ctx, key)
+		c.stats.RecordInitializer(time.Since(start), err)
+		if err != nil {
+			return result, err
+		}
+
+		// Set the item in the cache
+		c.Set(ctx, key, result, duration)
+
+		return result, nil
+	})
+}
@@ -0,0 +1,155 @@
+package ristretto_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/ristretto"
+)
+
+// fakeClient is an in-memory ristretto.Client recording the costs and TTLs of the stored items, and applying
+// writes only once Wait is called, like ristretto.
+type fakeClient struct {
+	mu      sync.Mutex
+	items   map[string]string
+	pending map[string]string
+	costs   map[string]int64
+	ttls    map[string]time.Duration
+	reject  bool
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		items:   make(map[string]string),
+		pending: make(map[string]string),
+		costs:   make(map[string]int64),
+		ttls:    make(map[string]time.Duration),
+	}
+}
+
+func (c *fakeClient) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.items[key]
+	return value, ok
+}
+
+func (c *fakeClient) SetWithTTL(key string, value string, cost int64, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reject {
+		return false
+	}
+	c.pending[key] = value
+	c.costs[key] = cost
+	c.ttls[key] = ttl
+	return true
+}
+
+func (c *fakeClient) Del(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+	delete(c.pending, key)
+}
+
+func (c *fakeClient) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = make(map[string]string)
+	c.pending = make(map[string]string)
+}
+
+func (c *fakeClient) Wait() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, value := range c.pending {
+		c.items[key] = value
+	}
+	c.pending = make(map[string]string)
+}
+
+func TestRistretto(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	var handled []error
+	c := ristretto.New[string](client,
+		ristretto.WithWaitOnSet[string](),
+		ristretto.WithCost[string](func(value string) int64 { return int64(len(value)) }),
+		ristretto.WithErrorHandler[string](func(ctx context.Context, key string, err error) {
+			handled = append(handled, err)
+		}),
+	)
+
+	minute := time.Minute
+	c.Set(ctx, "a", "value", &minute)
+	value, err := c.Get(ctx, "a", nil)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+	require.Equal(t, int64(5), client.costs["a"])
+	require.Equal(t, time.Minute, client.ttls["a"])
+
+	// Items set without expiration are stored with a zero TTL.
+	c.Set(ctx, "b", "value", nil)
+	require.Equal(t, time.Duration(0), client.ttls["b"])
+
+	_, err = c.Get(ctx, "missing", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	loaded, err := c.Get(ctx, "c", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "loaded", nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "loaded", loaded)
+	value, err = c.Get(ctx, "c", nil)
+	require.NoError(t, err)
+	require.Equal(t, "loaded", value)
+
+	client.reject = true
+	c.Set(ctx, "d", "value", nil)
+	require.Len(t, handled, 1)
+	require.ErrorIs(t, handled[0], ristretto.ErrNotAdmitted)
+	client.reject = false
+
+	values, err := c.GetMulti(ctx, []string{"a", "b", "missing"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "value", "b": "value"}, values)
+
+	require.NoError(t, c.Invalidate(ctx, "a"))
+	_, err = c.Get(ctx, "a", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+	require.NoError(t, c.InvalidateAll(ctx))
+	_, err = c.Get(ctx, "b", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	stats := c.(cache.StatsProvider).CacheStats()
+	require.Equal(t, uint64(1), stats.InitializerCalls)
+}
+
+func TestRistretto_Get_InitializerPanic(t *testing.T) {
+	ctx := context.Background()
+	c := ristretto.New[string](newFakeClient())
+
+	// The panic of the initializer is raised on the goroutine of the caller, which can recover it.
+	recovered := func() (r interface{}) {
+		defer func() { r = recover() }()
+		_, _ = c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+			panic("initializer failed")
+		})
+		return nil
+	}()
+	require.NotNil(t, recovered)
+	require.ErrorContains(t, recovered.(error), "initializer failed")
+
+	// The cache remains usable.
+	value, err := c.Get(ctx, "key", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "value", nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+}
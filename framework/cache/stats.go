@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+var ErrDuplicateCacheName = errors.New("cache name already registered")
+
+// Stats holds the metrics common to all cache backends.
+type Stats struct {
+	// Hits is the number of keys found in the cache by Get and GetMulti.
+	Hits uint64
+	// Misses is the number of keys missing from the cache, or expired, in Get and GetMulti.
+	Misses uint64
+	// Evictions is the number of items evicted because the cache is full, when the backend tracks it.
+	Evictions uint64
+	// Entries is the number of items in the cache, when the backend tracks it.
+	Entries int
+	// InitializerCalls is the number of times an initializer was called to load a missing key.
+	InitializerCalls uint64
+	// InitializerErrors is the number of initializer calls that returned an error, including the panics recovered
+	// by the backends refreshing keys in the background.
+	InitializerErrors uint64
+	// InitializerDuration is the total time spent in initializers. Divide it by InitializerCalls for the average latency.
+	InitializerDuration time.Duration
+}
+
+// StatsProvider is implemented by caches reporting their Stats.
+type StatsProvider interface {
+	CacheStats() Stats
+}
+
+// StatsRecorder records the hits, misses and initializer calls of a cache. Backends use it to implement
+// StatsProvider, filling in the metrics they track themselves. The zero value is ready to use.
+type StatsRecorder struct {
+	hits                atomic.Uint64
+	misses              atomic.Uint64
+	initializerCalls    atomic.Uint64
+	initializerErrors   atomic.Uint64
+	initializerDuration atomic.Int64
+}
+
+// RecordHits records n keys found in the cache.
+func (r *StatsRecorder) RecordHits(n int) {
+	r.hits.Add(uint64(n))
+}
+
+// RecordMisses records n keys missing from the cache.
+func (r *StatsRecorder) RecordMisses(n int) {
+	r.misses.Add(uint64(n))
+}
+
+// RecordInitializer records an initializer call that took duration and returned err.
+func (r *StatsRecorder) RecordInitializer(duration time.Duration, err error) {
+	r.initializerCalls.Add(1)
+	r.initializerDuration.Add(int64(duration))
+	if err != nil {
+		r.initializerErrors.Add(1)
+	}
+}
+
+// Snapshot returns the recorded metrics.
+func (r *StatsRecorder) Snapshot() Stats {
+	return Stats{
+		Hits:                r.hits.Load(),
+		Misses:              r.misses.Load(),
+		InitializerCalls:    r.initializerCalls.Load(),
+		InitializerErrors:   r.initializerErrors.Load(),
+		InitializerDuration: time.Duration(r.initializerDuration.Load()),
+	}
+}
+
+/*
+StatsExporter exposes the Stats of named caches in the Prometheus text format, labelled by cache name, with the
+metrics package. It has no dependency on a Prometheus client library: serve it on the metrics endpoint, write it
+with WriteTo, or register it as a collector of a metrics.Registry.
+
+Example usage:
+
+	exporter := cache.NewStatsExporter("myapp")
+	users := localcache.New[User]()
+	if err := exporter.Register("users", users.(cache.StatsProvider)); err != nil {
+		// Handle error
+	}
+	http.Handle("/metrics/cache", exporter)
+
+exposes metrics such as:
+
+	myapp_cache_hits_total{cache="users"} 42
+*/
+type StatsExporter struct {
+	namespace string
+	mutex     sync.RWMutex
+	caches    map[string]StatsProvider
+}
+
+// NewStatsExporter creates a StatsExporter. namespace prefixes the metric names, it may be empty.
+func NewStatsExporter(namespace string) *StatsExporter {
+	return &StatsExporter{
+		namespace: namespace,
+		caches:    make(map[string]StatsProvider),
+	}
+}
+
+// Register adds the cache provider under name. It returns ErrDuplicateCacheName if name is already registered.
+func (e *StatsExporter) Register(name string, provider StatsProvider) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if _, found := e.caches[name]; found {
+		return fmt.Errorf("%w: %s", ErrDuplicateCacheName, name)
+	}
+	e.caches[name] = provider
+	return nil
+}
+
+// replace registers provider under name, replacing the cache already registered under name, if any.
+func (e *StatsExporter) replace(name string, provider StatsProvider) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.caches[name] = provider
+}
+
+// Unregister removes the cache registered under name.
+func (e *StatsExporter) Unregister(name string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	delete(e.caches, name)
+}
+
+// statsMetric is a metric exported for every cache.
+type statsMetric struct {
+	name  string
+	gauge bool
+	help  string
+	value func(s Stats) float64
+}
+
+var statsMetrics = []statsMetric{
+	{"cache_hits_total", false, "Number of keys found in the cache.", func(s Stats) float64 { return float64(s.Hits) }},
+	{"cache_misses_total", false, "Number of keys missing from the cache.", func(s Stats) float64 { return float64(s.Misses) }},
+	{"cache_evictions_total", false, "Number of items evicted because the cache is full.", func(s Stats) float64 { return float64(s.Evictions) }},
+	{"cache_entries", true, "Number of items in the cache.", func(s Stats) float64 { return float64(s.Entries) }},
+	{"cache_initializer_calls_total", false, "Number of initializer calls loading missing keys.", func(s Stats) float64 { return float64(s.InitializerCalls) }},
+	{"cache_initializer_errors_total", false, "Number of initializer calls that returned an error.", func(s Stats) float64 { return float64(s.InitializerErrors) }},
+	{"cache_initializer_duration_seconds_total", false, "Total time spent in initializers.", func(s Stats) float64 { return s.InitializerDuration.Seconds() }},
+}
+
+// WriteTo writes the metrics of the registered caches to w in the Prometheus text format.
+func (e *StatsExporter) WriteTo(w io.Writer) (int64, error) {
+	e.mutex.RLock()
+	stats := make(map[string]Stats, len(e.caches))
+	for name, provider := range e.caches {
+		stats[name] = provider.CacheStats()
+	}
+	e.mutex.RUnlock()
+
+	// The stats are snapshots of the providers, written in a registry of the scrape, so that the unregistered
+	// caches are not written.
+	registry := metrics.NewRegistry(metrics.WithNamespace(e.namespace), metrics.WithMaxSeries(len(stats)+1))
+	for _, metric := range statsMetrics {
+		if metric.gauge {
+			gauge := metrics.Must(registry.NewGauge(metric.name, metric.help, "cache"))
+			for name, s := range stats {
+				gauge.Set(metric.value(s), name)
+			}
+			continue
+		}
+		counter := metrics.Must(registry.NewCounter(metric.name, metric.help, "cache"))
+		for name, s := range stats {
+			counter.Add(metric.value(s), name)
+		}
+	}
+	return registry.WriteTo(w)
+}
+
+// ServeHTTP writes the metrics of the registered caches in the Prometheus text format.
+func (e *StatsExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = e.WriteTo(w)
+}
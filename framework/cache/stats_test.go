@@ -0,0 +1,66 @@
+package cache_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+)
+
+type staticStats cache.Stats
+
+func (s staticStats) CacheStats() cache.Stats { return cache.Stats(s) }
+
+func TestStatsRecorder(t *testing.T) {
+	var recorder cache.StatsRecorder
+	recorder.RecordHits(3)
+	recorder.RecordMisses(2)
+	recorder.RecordInitializer(100*time.Millisecond, nil)
+	recorder.RecordInitializer(50*time.Millisecond, errors.New("timeout"))
+
+	require.Equal(t, cache.Stats{
+		Hits:                3,
+		Misses:              2,
+		InitializerCalls:    2,
+		InitializerErrors:   1,
+		InitializerDuration: 150 * time.Millisecond,
+	}, recorder.Snapshot())
+}
+
+func TestStatsExporter(t *testing.T) {
+	exporter := cache.NewStatsExporter("myapp")
+	require.NoError(t, exporter.Register("users", staticStats{Hits: 42, Misses: 8, Entries: 10, InitializerCalls: 8, InitializerDuration: 2 * time.Second}))
+	require.NoError(t, exporter.Register(`a "quoted"\name`, staticStats{Evictions: 1}))
+	require.ErrorIs(t, exporter.Register("users", staticStats{}), cache.ErrDuplicateCacheName)
+
+	recorder := httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+	body := recorder.Body.String()
+	for _, line := range []string{
+		"# TYPE myapp_cache_hits_total counter",
+		`myapp_cache_hits_total{cache="users"} 42`,
+		`myapp_cache_misses_total{cache="users"} 8`,
+		"# TYPE myapp_cache_entries gauge",
+		`myapp_cache_entries{cache="users"} 10`,
+		`myapp_cache_initializer_calls_total{cache="users"} 8`,
+		`myapp_cache_initializer_duration_seconds_total{cache="users"} 2`,
+		`myapp_cache_evictions_total{cache="a \"quoted\"\\name"} 1`,
+	} {
+		require.Contains(t, body, line+"\n")
+	}
+	// Caches are sorted by name.
+	require.Less(t, strings.Index(body, `cache="a \"quoted\"`), strings.Index(body, `cache="users"`))
+
+	exporter.Unregister("users")
+	var buf strings.Builder
+	_, err := exporter.WriteTo(&buf)
+	require.NoError(t, err)
+	require.NotContains(t, buf.String(), "users")
+}
@@ -0,0 +1,257 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TestingT is the subset of testing.TB used by the Recorder assertions.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Operation is a Cache method recorded by a Recorder.
+type Operation string
+
+const (
+	OpGet           Operation = "Get"
+	OpSet           Operation = "Set"
+	OpInvalidate    Operation = "Invalidate"
+	OpInvalidateAll Operation = "InvalidateAll"
+)
+
+// Call is a Cache call captured by a Recorder.
+type Call struct {
+	Op  Operation
+	Key string
+	// Value is the value set by Set, or returned by Get.
+	Value interface{}
+	// Duration is the duration passed to Set, or returned by the initializer of Get.
+	Duration *time.Duration
+	// Hit reports whether Get found the key in the cache.
+	Hit bool
+	// Loaded reports whether Get called the initializer.
+	Loaded bool
+	// Err is the error returned by Get or Invalidate.
+	Err error
+}
+
+/*
+NewTestCache returns an in-memory Cache that captures every call in a Recorder, so unit tests can verify how
+a service uses its cache without a real backend. Items expire after the duration passed to Set or returned by
+the initializer, nil or negative durations never expire. Concurrent Get calls of a missing key each call the
+initializer.
+
+For strict expectations on the calls, use the gomock mocks of the mocks package instead.
+
+Example usage:
+
+	users, recorder := cache.NewTestCache[User]()
+	service := NewService(users)
+
+	service.GetUser(ctx, 42)
+	service.GetUser(ctx, 42)
+
+	recorder.AssertCalled(t, cache.OpGet, "user:42")
+	require.Len(t, recorder.Find(cache.OpGet, "user:42", cache.Loaded()), 1)
+*/
+func NewTestCache[T any]() (Cache[T], *Recorder) {
+	recorder := &Recorder{}
+	return &testCache[T]{
+		items:    make(map[string]testItem[T]),
+		recorder: recorder,
+	}, recorder
+}
+
+type testItem[T any] struct {
+	value   T
+	expires time.Time
+}
+
+type testCache[T any] struct {
+	mu       sync.Mutex
+	items    map[string]testItem[T]
+	recorder *Recorder
+}
+
+func (c *testCache[T]) Get(ctx context.Context, key string, initializer Initializer[T]) (T, error) {
+	if value, ok := c.lookup(key); ok {
+		c.recorder.record(Call{Op: OpGet, Key: key, Value: value, Hit: true})
+		return value, nil
+	}
+	if initializer == nil {
+		var zero T
+		c.recorder.record(Call{Op: OpGet, Key: key, Err: ErrCacheMiss})
+		return zero, ErrCacheMiss
+	}
+
+	value, duration, err := initializer(ctx, key)
+	call := Call{Op: OpGet, Key: key, Duration: duration, Loaded: true, Err: err}
+	if err != nil {
+		c.recorder.record(call)
+		var zero T
+		return zero, err
+	}
+	c.store(key, value, duration)
+	call.Value = value
+	c.recorder.record(call)
+	return value, nil
+}
+
+func (c *testCache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	c.store(key, value, duration)
+	c.recorder.record(Call{Op: OpSet, Key: key, Value: value, Duration: duration})
+}
+
+func (c *testCache[T]) Invalidate(ctx context.Context, key string) error {
+	c.mu.Lock()
+	delete(c.items, key)
+	c.mu.Unlock()
+	c.recorder.record(Call{Op: OpInvalidate, Key: key})
+	return nil
+}
+
+func (c *testCache[T]) InvalidateAll(ctx context.Context) error {
+	c.mu.Lock()
+	c.items = make(map[string]testItem[T])
+	c.mu.Unlock()
+	c.recorder.record(Call{Op: OpInvalidateAll})
+	return nil
+}
+
+func (c *testCache[T]) lookup(key string) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	itm, ok := c.items[key]
+	if !ok || (!itm.expires.IsZero() && !time.Now().Before(itm.expires)) {
+		var zero T
+		return zero, false
+	}
+	return itm.value, true
+}
+
+func (c *testCache[T]) store(key string, value T, duration *time.Duration) {
+	itm := testItem[T]{value: value}
+	if duration != nil && *duration >= 0 {
+		itm.expires = time.Now().Add(*duration)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = itm
+}
+
+// CallMatcher reports whether a recorded call matches an expectation.
+type CallMatcher func(call Call) bool
+
+// Hit returns a CallMatcher that matches the Get calls finding the key in the cache.
+func Hit() CallMatcher {
+	return func(call Call) bool {
+		return call.Op == OpGet && call.Hit
+	}
+}
+
+// Loaded returns a CallMatcher that matches the Get calls calling the initializer.
+func Loaded() CallMatcher {
+	return func(call Call) bool {
+		return call.Op == OpGet && call.Loaded
+	}
+}
+
+// Failed returns a CallMatcher that matches the calls returning err, checked with errors.Is.
+func Failed(err error) CallMatcher {
+	return func(call Call) bool {
+		return errors.Is(call.Err, err)
+	}
+}
+
+// Recorder captures the calls of the cache returned by NewTestCache. It is safe for concurrent use.
+type Recorder struct {
+	mu    sync.RWMutex
+	calls []Call
+}
+
+func (r *Recorder) record(call Call) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+// Calls returns all captured calls in the order they were made.
+func (r *Recorder) Calls() []Call {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// Len returns the number of captured calls.
+func (r *Recorder) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.calls)
+}
+
+// Reset discards all captured calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+// Find returns the captured calls of op for key that match all the given matchers. An empty key matches all keys.
+func (r *Recorder) Find(op Operation, key string, matchers ...CallMatcher) []Call {
+	var found []Call
+	for _, call := range r.Calls() {
+		if call.Op != op || (key != "" && call.Key != key) {
+			continue
+		}
+		matched := true
+		for _, matcher := range matchers {
+			if matcher != nil && !matcher(call) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			found = append(found, call)
+		}
+	}
+	return found
+}
+
+// AssertCalled asserts that op was called at least once for key, matching the given matchers.
+func (r *Recorder) AssertCalled(t TestingT, op Operation, key string, matchers ...CallMatcher) bool {
+	t.Helper()
+	if len(r.Find(op, key, matchers...)) == 0 {
+		t.Errorf("expected %s to be called for %q, got:\n%s", op, key, r.dump())
+		return false
+	}
+	return true
+}
+
+// AssertNotCalled asserts that op was not called for key with the given matchers.
+func (r *Recorder) AssertNotCalled(t TestingT, op Operation, key string, matchers ...CallMatcher) bool {
+	t.Helper()
+	if found := r.Find(op, key, matchers...); len(found) > 0 {
+		t.Errorf("expected %s not to be called for %q, found %d calls", op, key, len(found))
+		return false
+	}
+	return true
+}
+
+// dump returns a human-readable list of the captured calls for assertion failures.
+func (r *Recorder) dump() string {
+	calls := r.Calls()
+	if len(calls) == 0 {
+		return "  (no calls)"
+	}
+	var sb strings.Builder
+	for _, call := range calls {
+		fmt.Fprintf(&sb, "  %s %q value=%v hit=%t loaded=%t err=%v\n", call.Op, call.Key, call.Value, call.Hit, call.Loaded, call.Err)
+	}
+	return sb.String()
+}
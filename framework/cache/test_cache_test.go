@@ -0,0 +1,72 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+)
+
+// fakeT records the failures of the Recorder assertions.
+type fakeT struct {
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestNewTestCache(t *testing.T) {
+	ctx := context.Background()
+	c, recorder := cache.NewTestCache[string]()
+
+	initializer := func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "loaded " + key, nil, nil
+	}
+	value, err := c.Get(ctx, "a", initializer)
+	require.NoError(t, err)
+	require.Equal(t, "loaded a", value)
+	value, err = c.Get(ctx, "a", initializer)
+	require.NoError(t, err)
+	require.Equal(t, "loaded a", value)
+
+	short := time.Nanosecond
+	c.Set(ctx, "b", "set", &short)
+	time.Sleep(time.Millisecond)
+	_, err = c.Get(ctx, "b", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	errDown := errors.New("down")
+	_, err = c.Get(ctx, "c", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "", nil, errDown
+	})
+	require.ErrorIs(t, err, errDown)
+	require.NoError(t, c.Invalidate(ctx, "a"))
+	require.NoError(t, c.InvalidateAll(ctx))
+
+	require.Equal(t, 7, recorder.Len())
+	require.Len(t, recorder.Find(cache.OpGet, "a", cache.Loaded()), 1)
+	require.Len(t, recorder.Find(cache.OpGet, "a", cache.Hit()), 1)
+	require.Len(t, recorder.Find(cache.OpGet, "", cache.Failed(cache.ErrCacheMiss)), 1)
+	require.Equal(t, "set", recorder.Find(cache.OpSet, "b")[0].Value)
+	require.Equal(t, &short, recorder.Find(cache.OpSet, "b")[0].Duration)
+
+	require.True(t, recorder.AssertCalled(t, cache.OpGet, "c", cache.Failed(errDown)))
+	require.True(t, recorder.AssertNotCalled(t, cache.OpSet, "a"))
+
+	fake := &fakeT{}
+	require.False(t, recorder.AssertCalled(fake, cache.OpInvalidate, "b"))
+	require.False(t, recorder.AssertNotCalled(fake, cache.OpInvalidateAll, ""))
+	require.Len(t, fake.errors, 2)
+	require.Contains(t, fake.errors[0], `Invalidate "a"`)
+
+	recorder.Reset()
+	require.Empty(t, recorder.Calls())
+}
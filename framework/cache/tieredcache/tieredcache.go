@@ -0,0 +1,149 @@
+package tieredcache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	cache "github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/util/pointer"
+)
+
+type config struct {
+	l1MaxTTL time.Duration
+}
+
+type Option func(*config)
+
+// WithL1MaxTTL caps the duration of the items stored in the L1 cache, so that a local copy does not outlive
+// changes made by other instances to the L2 cache by more than ttl. Items promoted from L2, whose remaining
+// duration is unknown, are stored for ttl. Zero or a negative value means no cap.
+func WithL1MaxTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.l1MaxTTL = ttl
+	}
+}
+
+func newConfig(opts ...Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type tieredcache[T any] struct {
+	l1 cache.Cache[T]
+	l2 cache.Cache[T]
+	config
+}
+
+// New creates a cache composing a fast L1 cache (e.g., a localcache) in front of a shared L2 cache (e.g., a memcache).
+// Reads go through L1, then L2, then the initializer, and values found in L2 are promoted to L1.
+// Writes and invalidations are propagated to both tiers.
+func New[T any](l1, l2 cache.Cache[T], opts ...Option) cache.BatchCache[T] {
+	cfg := newConfig(opts...)
+	return &tieredcache[T]{
+		l1:     l1,
+		l2:     l2,
+		config: pointer.GetValue(cfg),
+	}
+}
+
+// Get retrieves a value from L1, then from L2. If the key is missing from both tiers and an initializer
+// is provided, it uses the initializer to obtain the value and stores it in both tiers.
+func (c *tieredcache[T]) Get(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
+	return c.l1.Get(ctx, key, func(ctx context.Context, key string) (T, *time.Duration, error) {
+		// The duration returned by the initializer is only known if the value was loaded, not if it was found in L2.
+		var duration *time.Duration
+		var l2Initializer cache.Initializer[T]
+		if initializer != nil {
+			l2Initializer = func(ctx context.Context, key string) (T, *time.Duration, error) {
+				value, d, err := initializer(ctx, key)
+				duration = d
+				return value, d, err
+			}
+		}
+
+		value, err := c.l2.Get(ctx, key, l2Initializer)
+		if err != nil {
+			var zero T
+			return zero, nil, err
+		}
+		return value, c.l1Duration(duration), nil
+	})
+}
+
+// Set adds an item to both tiers. The L1 duration is capped by WithL1MaxTTL.
+func (c *tieredcache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	c.l2.Set(ctx, key, value, duration)
+	c.l1.Set(ctx, key, value, c.l1Duration(duration))
+}
+
+// Invalidate removes key from both tiers.
+func (c *tieredcache[T]) Invalidate(ctx context.Context, key string) error {
+	return errors.Join(c.l2.Invalidate(ctx, key), c.l1.Invalidate(ctx, key))
+}
+
+// InvalidateAll removes all keys from both tiers.
+func (c *tieredcache[T]) InvalidateAll(ctx context.Context) error {
+	return errors.Join(c.l2.InvalidateAll(ctx), c.l1.InvalidateAll(ctx))
+}
+
+// GetMulti returns the values of the keys found in L1, then in L2. Values found in L2 are promoted to L1.
+// Missing keys are absent from the result.
+func (c *tieredcache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	values, err := cache.GetMulti(ctx, c.l1, keys)
+	if err != nil {
+		return nil, err
+	}
+	missing := make([]string, 0, len(keys)-len(values))
+	for _, key := range keys {
+		if _, found := values[key]; !found {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	promoted, err := cache.GetMulti(ctx, c.l2, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(promoted) > 0 {
+		cache.SetMulti(ctx, c.l1, promoted, c.l1Duration(nil))
+	}
+	for key, value := range promoted {
+		values[key] = value
+	}
+	return values, nil
+}
+
+// SetMulti adds every item to both tiers with the same duration. The L1 duration is capped by WithL1MaxTTL.
+func (c *tieredcache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	cache.SetMulti(ctx, c.l2, items, duration)
+	cache.SetMulti(ctx, c.l1, items, c.l1Duration(duration))
+}
+
+// InvalidateMulti removes the keys from both tiers.
+func (c *tieredcache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	return errors.Join(cache.InvalidateMulti(ctx, c.l2, keys), cache.InvalidateMulti(ctx, c.l1, keys))
+}
+
+// Close closes both tiers, implementing cache.Closer.
+func (c *tieredcache[T]) Close() error {
+	return errors.Join(cache.Close(c.l2), cache.Close(c.l1))
+}
+
+// l1Duration returns duration capped by the L1 max TTL. A nil duration is the default expiration of L1,
+// and a negative duration means no expiration.
+func (c *tieredcache[T]) l1Duration(duration *time.Duration) *time.Duration {
+	if c.l1MaxTTL <= 0 {
+		return duration
+	}
+	if duration == nil || pointer.GetValue(duration) < 0 || pointer.GetValue(duration) > c.l1MaxTTL {
+		return pointer.ToPointer(c.l1MaxTTL)
+	}
+	return duration
+}
@@ -0,0 +1,132 @@
+package tieredcache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+	"github.com/kittipat1413/go-common/framework/cache/tieredcache"
+)
+
+func TestTieredCache_GetWithInitializer(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := localcache.New[string](), localcache.New[string]()
+	c := tieredcache.New(l1, l2)
+
+	var initializerCalled int
+	initializer := func(ctx context.Context, key string) (string, *time.Duration, error) {
+		initializerCalled++
+		return "value", nil, nil
+	}
+
+	value, err := c.Get(ctx, "key", initializer)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+	require.Equal(t, 1, initializerCalled)
+
+	// The value is stored in both tiers.
+	for _, tier := range []cache.Cache[string]{l1, l2} {
+		value, err = tier.Get(ctx, "key", nil)
+		require.NoError(t, err)
+		require.Equal(t, "value", value)
+	}
+
+	value, err = c.Get(ctx, "key", initializer)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+	require.Equal(t, 1, initializerCalled, "Initializer should have been called once")
+
+	_, err = c.Get(ctx, "missing", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+}
+
+func TestTieredCache_PromotesFromL2(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := localcache.New[string](), localcache.New[string]()
+	c := tieredcache.New(l1, l2, tieredcache.WithL1MaxTTL(50*time.Millisecond))
+
+	l2.Set(ctx, "key", "from l2", nil)
+	value, err := c.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, "from l2", value)
+
+	value, err = l1.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, "from l2", value, "Expected the value to be promoted to L1")
+
+	// The promoted copy expires after the L1 max TTL, picking up changes made to L2.
+	l2.Set(ctx, "key", "updated", nil)
+	time.Sleep(60 * time.Millisecond)
+	value, err = c.Get(ctx, "key", nil)
+	require.NoError(t, err)
+	require.Equal(t, "updated", value)
+}
+
+func TestTieredCache_L1MaxTTL(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := localcache.New[string](), localcache.New[string]()
+	c := tieredcache.New(l1, l2, tieredcache.WithL1MaxTTL(50*time.Millisecond))
+
+	long := time.Hour
+	noExpiration := localcache.NoExpireDuration
+	c.Set(ctx, "long", "v", &long)
+	c.Set(ctx, "persistent", "v", &noExpiration)
+	_, err := c.Get(ctx, "loaded", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "v", &long, nil
+	})
+	require.NoError(t, err)
+
+	time.Sleep(60 * time.Millisecond)
+	for _, key := range []string{"long", "persistent", "loaded"} {
+		_, err = l1.Get(ctx, key, nil)
+		require.ErrorIs(t, err, cache.ErrCacheMiss, "Expected the L1 copy of %q to expire", key)
+		_, err = l2.Get(ctx, key, nil)
+		require.NoError(t, err, "Expected the L2 copy of %q not to expire", key)
+	}
+}
+
+func TestTieredCache_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := localcache.New[string](), localcache.New[string]()
+	c := tieredcache.New(l1, l2)
+
+	c.Set(ctx, "key1", "value1", nil)
+	c.Set(ctx, "key2", "value2", nil)
+
+	require.NoError(t, c.Invalidate(ctx, "key1"))
+	for _, tier := range []cache.Cache[string]{l1, l2} {
+		_, err := tier.Get(ctx, "key1", nil)
+		require.ErrorIs(t, err, cache.ErrCacheMiss)
+	}
+
+	require.NoError(t, c.InvalidateAll(ctx))
+	for _, tier := range []cache.Cache[string]{l1, l2} {
+		_, err := tier.Get(ctx, "key2", nil)
+		require.ErrorIs(t, err, cache.ErrCacheMiss)
+	}
+}
+
+func TestTieredCache_BatchOperations(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := localcache.New[int](), localcache.New[int]()
+	c := tieredcache.New(l1, l2)
+
+	c.SetMulti(ctx, map[string]int{"a": 1, "b": 2}, nil)
+	l2.Set(ctx, "c", 3, nil)
+
+	values, err := c.GetMulti(ctx, []string{"a", "b", "c", "missing"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"a": 1, "b": 2, "c": 3}, values)
+	value, err := l1.Get(ctx, "c", nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, value, "Expected the value to be promoted to L1")
+
+	require.NoError(t, c.InvalidateMulti(ctx, []string{"a", "c"}))
+	values, err = cache.GetMulti(ctx, l2, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"b": 2}, values)
+}
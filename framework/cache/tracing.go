@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/kittipat1413/go-common/framework/cache"
+
+// Span attributes recorded by WithTracing.
+const (
+	AttributeCacheName           = attribute.Key("cache.name")
+	AttributeKeyHash             = attribute.Key("cache.key_hash")
+	AttributeKeyCount            = attribute.Key("cache.key_count")
+	AttributeHit                 = attribute.Key("cache.hit")
+	AttributeHits                = attribute.Key("cache.hits")
+	AttributeInitializerInvoked  = attribute.Key("cache.initializer.invoked")
+	AttributeInitializerDuration = attribute.Key("cache.initializer.duration_ms")
+)
+
+// tracingOptions holds configuration options for the tracing decorator.
+type tracingOptions struct {
+	tracerProvider oteltrace.TracerProvider // tracerProvider is the OpenTelemetry tracer provider to use.
+}
+
+// TracingOption specifies tracing configuration options.
+type TracingOption func(*tracingOptions)
+
+// WithTracerProvider specifies a tracer provider to use for creating a tracer. Defaults to the global tracer provider.
+func WithTracerProvider(provider oteltrace.TracerProvider) TracingOption {
+	return func(opts *tracingOptions) {
+		if provider != nil {
+			opts.tracerProvider = provider
+		}
+	}
+}
+
+/*
+WithTracing wraps c to record an OpenTelemetry span for every operation, so that the latency added by the cache
+shows up in traces. Spans carry the cache name, a hash of the key (keys may contain personal data), whether Get
+was a hit and whether the initializer was invoked, with its duration. Initializers run within the Get span.
+
+The returned cache implements BatchCache, falling back to per-key operations if c does not. Other extension
+interfaces of c, such as StatsProvider, must be used on c itself.
+
+Example usage:
+
+	users := cache.WithTracing(localcache.New[User](), "users")
+	user, err := users.Get(ctx, "user:1", loadUser)
+*/
+func WithTracing[T any](c Cache[T], name string, options ...TracingOption) BatchCache[T] {
+	opts := &tracingOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	if opts.tracerProvider == nil {
+		opts.tracerProvider = otel.GetTracerProvider()
+	}
+	return &tracedCache[T]{
+		cache:  c,
+		name:   name,
+		tracer: opts.tracerProvider.Tracer(tracerName),
+	}
+}
+
+type tracedCache[T any] struct {
+	cache  Cache[T]
+	name   string
+	tracer oteltrace.Tracer
+}
+
+func (c *tracedCache[T]) Get(ctx context.Context, key string, initializer Initializer[T]) (T, error) {
+	ctx, span := c.start(ctx, "cache.Get", AttributeKeyHash.String(hashKey(key)))
+	defer span.End()
+
+	// The initializer may be kept by c and called again later in the background, e.g., by a refresh-ahead.
+	var invoked atomic.Bool
+	traced := initializer
+	if initializer != nil {
+		traced = func(ctx context.Context, key string) (T, *time.Duration, error) {
+			invoked.Store(true)
+			start := time.Now()
+			defer func() {
+				span.SetAttributes(AttributeInitializerDuration.Float64(float64(time.Since(start)) / float64(time.Millisecond)))
+			}()
+			return initializer(ctx, key)
+		}
+	}
+
+	value, err := c.cache.Get(ctx, key, traced)
+	span.SetAttributes(
+		AttributeHit.Bool(!invoked.Load() && err == nil),
+		AttributeInitializerInvoked.Bool(invoked.Load()),
+	)
+	if err != nil && !errors.Is(err, ErrCacheMiss) {
+		recordError(span, err)
+	}
+	return value, err
+}
+
+func (c *tracedCache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	ctx, span := c.start(ctx, "cache.Set", AttributeKeyHash.String(hashKey(key)))
+	defer span.End()
+
+	c.cache.Set(ctx, key, value, duration)
+}
+
+func (c *tracedCache[T]) Invalidate(ctx context.Context, key string) error {
+	ctx, span := c.start(ctx, "cache.Invalidate", AttributeKeyHash.String(hashKey(key)))
+	defer span.End()
+
+	err := c.cache.Invalidate(ctx, key)
+	recordError(span, err)
+	return err
+}
+
+func (c *tracedCache[T]) InvalidateAll(ctx context.Context) error {
+	ctx, span := c.start(ctx, "cache.InvalidateAll")
+	defer span.End()
+
+	err := c.cache.InvalidateAll(ctx)
+	recordError(span, err)
+	return err
+}
+
+func (c *tracedCache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	ctx, span := c.start(ctx, "cache.GetMulti", AttributeKeyCount.Int(len(keys)))
+	defer span.End()
+
+	values, err := GetMulti(ctx, c.cache, keys)
+	span.SetAttributes(AttributeHits.Int(len(values)))
+	recordError(span, err)
+	return values, err
+}
+
+func (c *tracedCache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	ctx, span := c.start(ctx, "cache.SetMulti", AttributeKeyCount.Int(len(items)))
+	defer span.End()
+
+	SetMulti(ctx, c.cache, items, duration)
+}
+
+func (c *tracedCache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	ctx, span := c.start(ctx, "cache.InvalidateMulti", AttributeKeyCount.Int(len(keys)))
+	defer span.End()
+
+	err := InvalidateMulti(ctx, c.cache, keys)
+	recordError(span, err)
+	return err
+}
+
+// Close closes the wrapped cache, implementing Closer.
+func (c *tracedCache[T]) Close() error {
+	return Close(c.cache)
+}
+
+// start starts a span for the operation, with the cache name and attributes.
+func (c *tracedCache[T]) start(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, oteltrace.Span) {
+	return c.tracer.Start(ctx, operation,
+		oteltrace.WithSpanKind(oteltrace.SpanKindInternal),
+		oteltrace.WithAttributes(append(attributes, AttributeCacheName.String(c.name))...),
+	)
+}
+
+// recordError records err on span, if any.
+func recordError(span oteltrace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// hashKey returns the FNV-1a hash of key, so that spans can be correlated by key without exposing it.
+func hashKey(key string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
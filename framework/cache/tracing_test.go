@@ -0,0 +1,119 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+	cache_mocks "github.com/kittipat1413/go-common/framework/cache/mocks"
+)
+
+func newTracedCache(t *testing.T, c cache.Cache[string]) (cache.BatchCache[string], *tracetest.SpanRecorder) {
+	t.Helper()
+	sr := tracetest.NewSpanRecorder()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(sr))
+	return cache.WithTracing(c, "users", cache.WithTracerProvider(tp)), sr
+}
+
+func spanAttributes(span tracesdk.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attributes := make(map[attribute.Key]attribute.Value)
+	for _, attr := range span.Attributes() {
+		attributes[attr.Key] = attr.Value
+	}
+	return attributes
+}
+
+func TestWithTracing_Get(t *testing.T) {
+	ctx := context.Background()
+	c, sr := newTracedCache(t, localcache.New[string]())
+
+	initializer := func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "Alice", nil, nil
+	}
+	value, err := c.Get(ctx, "user:1", initializer)
+	require.NoError(t, err)
+	require.Equal(t, "Alice", value)
+	value, err = c.Get(ctx, "user:1", initializer)
+	require.NoError(t, err)
+	require.Equal(t, "Alice", value)
+	_, err = c.Get(ctx, "missing", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 3)
+	for _, span := range spans {
+		require.Equal(t, "cache.Get", span.Name())
+		require.Equal(t, "users", spanAttributes(span)[cache.AttributeCacheName].AsString())
+		// A miss is not an error.
+		require.Equal(t, otelcodes.Unset, span.Status().Code)
+	}
+
+	miss := spanAttributes(spans[0])
+	require.False(t, miss[cache.AttributeHit].AsBool())
+	require.True(t, miss[cache.AttributeInitializerInvoked].AsBool())
+	require.Contains(t, miss, cache.AttributeInitializerDuration)
+	require.NotEqual(t, "user:1", miss[cache.AttributeKeyHash].AsString(), "Keys should be hashed")
+
+	hit := spanAttributes(spans[1])
+	require.True(t, hit[cache.AttributeHit].AsBool())
+	require.False(t, hit[cache.AttributeInitializerInvoked].AsBool())
+	require.Equal(t, miss[cache.AttributeKeyHash], hit[cache.AttributeKeyHash])
+
+	require.False(t, spanAttributes(spans[2])[cache.AttributeHit].AsBool())
+}
+
+func TestWithTracing_Errors(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	mock := cache_mocks.NewMockCache[string](ctrl)
+	c, sr := newTracedCache(t, mock)
+
+	errUnavailable := errors.New("connection refused")
+	mock.EXPECT().Get(gomock.Any(), "key", gomock.Nil()).Return("", errUnavailable)
+	mock.EXPECT().Invalidate(gomock.Any(), "key").Return(errUnavailable)
+	mock.EXPECT().Set(gomock.Any(), "key", "value", gomock.Nil())
+	mock.EXPECT().InvalidateAll(gomock.Any()).Return(nil)
+
+	_, err := c.Get(ctx, "key", nil)
+	require.ErrorIs(t, err, errUnavailable)
+	require.ErrorIs(t, c.Invalidate(ctx, "key"), errUnavailable)
+	c.Set(ctx, "key", "value", nil)
+	require.NoError(t, c.InvalidateAll(ctx))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 4)
+	require.Equal(t, otelcodes.Error, spans[0].Status().Code)
+	require.Equal(t, "cache.Invalidate", spans[1].Name())
+	require.Equal(t, otelcodes.Error, spans[1].Status().Code)
+	require.Equal(t, "cache.Set", spans[2].Name())
+	require.Equal(t, "cache.InvalidateAll", spans[3].Name())
+	require.Equal(t, otelcodes.Unset, spans[3].Status().Code)
+}
+
+func TestWithTracing_BatchOperations(t *testing.T) {
+	ctx := context.Background()
+	c, sr := newTracedCache(t, localcache.New[string]())
+
+	c.SetMulti(ctx, map[string]string{"a": "1", "b": "2"}, nil)
+	values, err := c.GetMulti(ctx, []string{"a", "b", "missing"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, values)
+	require.NoError(t, c.InvalidateMulti(ctx, []string{"a"}))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 3)
+	require.Equal(t, "cache.GetMulti", spans[1].Name())
+	attributes := spanAttributes(spans[1])
+	require.Equal(t, int64(3), attributes[cache.AttributeKeyCount].AsInt64())
+	require.Equal(t, int64(2), attributes[cache.AttributeHits].AsInt64())
+}
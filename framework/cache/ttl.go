@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// NoExpiration is the duration of items that never expire, equal to the NoExpireDuration of the backends.
+const NoExpiration time.Duration = -1
+
+// ErrInvalidTTL is returned by Get, and reported to the WithInvalidTTLHandler function by Set, for negative
+// durations other than NoExpiration.
+var ErrInvalidTTL = errors.New("invalid cache TTL")
+
+// ttlPolicyOptions holds configuration options for the TTL policy.
+type ttlPolicyOptions struct {
+	defaultTTL     time.Duration
+	maxTTL         time.Duration
+	invalidHandler func(ctx context.Context, key string, err error)
+}
+
+// TTLPolicyOption specifies TTL policy configuration options.
+type TTLPolicyOption func(*ttlPolicyOptions)
+
+// WithDefaultTTL sets the duration of the items set, or loaded, with a nil or zero duration. Without it, nil
+// durations use the default expiration of the backend, and zero durations expire the items immediately.
+func WithDefaultTTL(d time.Duration) TTLPolicyOption {
+	return func(opts *ttlPolicyOptions) {
+		if d > 0 || d == NoExpiration {
+			opts.defaultTTL = d
+		}
+	}
+}
+
+// WithMaxTTL clamps the durations longer than d to d, including NoExpiration, so that no item outlives d.
+// Nil durations use the default expiration of the backend, which is not clamped, unless WithDefaultTTL is set.
+func WithMaxTTL(d time.Duration) TTLPolicyOption {
+	return func(opts *ttlPolicyOptions) {
+		if d > 0 {
+			opts.maxTTL = d
+		}
+	}
+}
+
+// WithInvalidTTLHandler sets a function called when Set is called with an invalid duration, since Set does not
+// return errors. The item is not stored.
+func WithInvalidTTLHandler(handler func(ctx context.Context, key string, err error)) TTLPolicyOption {
+	return func(opts *ttlPolicyOptions) {
+		opts.invalidHandler = handler
+	}
+}
+
+/*
+WithTTLPolicy wraps c to enforce the same durations whatever the backend: nil and zero durations get the
+WithDefaultTTL duration, durations longer than WithMaxTTL are clamped, and negative durations other than
+NoExpiration are rejected with ErrInvalidTTL. Durations returned by initializers follow the same rules, and
+a value loaded with an invalid duration is returned as an error, without being cached.
+
+The returned cache implements BatchCache, falling back to per-key operations if c does not.
+
+Example usage:
+
+	users := cache.WithTTLPolicy(memcache.New[User](client),
+		cache.WithDefaultTTL(10*time.Minute),
+		cache.WithMaxTTL(time.Hour),
+	)
+*/
+func WithTTLPolicy[T any](c Cache[T], options ...TTLPolicyOption) BatchCache[T] {
+	opts := &ttlPolicyOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	return &ttlPolicyCache[T]{
+		cache: c,
+		opts:  *opts,
+	}
+}
+
+// TTLPolicy returns a Decorator enforcing the durations of the cache, see WithTTLPolicy.
+func TTLPolicy[T any](options ...TTLPolicyOption) Decorator[T] {
+	return func(c Cache[T]) Cache[T] {
+		return WithTTLPolicy(c, options...)
+	}
+}
+
+type ttlPolicyCache[T any] struct {
+	cache Cache[T]
+	opts  ttlPolicyOptions
+}
+
+func (c *ttlPolicyCache[T]) Get(ctx context.Context, key string, initializer Initializer[T]) (T, error) {
+	if initializer == nil {
+		return c.cache.Get(ctx, key, nil)
+	}
+	return c.cache.Get(ctx, key, func(ctx context.Context, key string) (T, *time.Duration, error) {
+		value, duration, err := initializer(ctx, key)
+		if err != nil {
+			return value, duration, err
+		}
+		duration, err = c.apply(duration)
+		if err != nil {
+			var zero T
+			return zero, nil, fmt.Errorf("initializer of %q returned %w", key, err)
+		}
+		return value, duration, nil
+	})
+}
+
+func (c *ttlPolicyCache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	duration, err := c.apply(duration)
+	if err != nil {
+		c.handleInvalid(ctx, key, err)
+		return
+	}
+	c.cache.Set(ctx, key, value, duration)
+}
+
+func (c *ttlPolicyCache[T]) Invalidate(ctx context.Context, key string) error {
+	return c.cache.Invalidate(ctx, key)
+}
+
+func (c *ttlPolicyCache[T]) InvalidateAll(ctx context.Context) error {
+	return c.cache.InvalidateAll(ctx)
+}
+
+func (c *ttlPolicyCache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	return GetMulti(ctx, c.cache, keys)
+}
+
+func (c *ttlPolicyCache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	duration, err := c.apply(duration)
+	if err != nil {
+		for key := range items {
+			c.handleInvalid(ctx, key, err)
+		}
+		return
+	}
+	SetMulti(ctx, c.cache, items, duration)
+}
+
+func (c *ttlPolicyCache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	return InvalidateMulti(ctx, c.cache, keys)
+}
+
+// Close closes the wrapped cache, implementing Closer.
+func (c *ttlPolicyCache[T]) Close() error {
+	return Close(c.cache)
+}
+
+// apply returns the duration to store an item for, or an error wrapping ErrInvalidTTL.
+func (c *ttlPolicyCache[T]) apply(duration *time.Duration) (*time.Duration, error) {
+	if duration == nil || *duration == 0 {
+		if c.opts.defaultTTL == 0 {
+			return duration, nil
+		}
+		d := c.opts.defaultTTL
+		duration = &d
+	}
+	d := *duration
+	if d < 0 && d != NoExpiration {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTTL, d)
+	}
+	if c.opts.maxTTL > 0 && (d == NoExpiration || d > c.opts.maxTTL) {
+		d = c.opts.maxTTL
+	}
+	return &d, nil
+}
+
+func (c *ttlPolicyCache[T]) handleInvalid(ctx context.Context, key string, err error) {
+	if c.opts.invalidHandler != nil {
+		c.opts.invalidHandler(ctx, key, err)
+	}
+}
@@ -0,0 +1,75 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+)
+
+func TestWithTTLPolicy(t *testing.T) {
+	ctx := context.Background()
+	base, recorder := cache.NewTestCache[string]()
+	var invalid []error
+	c := cache.WithTTLPolicy(base,
+		cache.WithDefaultTTL(10*time.Minute),
+		cache.WithMaxTTL(time.Hour),
+		cache.WithInvalidTTLHandler(func(ctx context.Context, key string, err error) {
+			invalid = append(invalid, err)
+		}),
+	)
+	duration := func(d time.Duration) *time.Duration { return &d }
+	setDuration := func(key string) time.Duration {
+		calls := recorder.Find(cache.OpSet, key)
+		require.Len(t, calls, 1)
+		return *calls[0].Duration
+	}
+
+	c.Set(ctx, "nil", "value", nil)
+	require.Equal(t, 10*time.Minute, setDuration("nil"))
+	c.Set(ctx, "zero", "value", duration(0))
+	require.Equal(t, 10*time.Minute, setDuration("zero"))
+	c.Set(ctx, "long", "value", duration(24*time.Hour))
+	require.Equal(t, time.Hour, setDuration("long"))
+	c.Set(ctx, "forever", "value", duration(cache.NoExpiration))
+	require.Equal(t, time.Hour, setDuration("forever"))
+	c.Set(ctx, "short", "value", duration(time.Minute))
+	require.Equal(t, time.Minute, setDuration("short"))
+
+	// Negative durations are rejected.
+	c.Set(ctx, "negative", "value", duration(-time.Minute))
+	recorder.AssertNotCalled(t, cache.OpSet, "negative")
+	require.Len(t, invalid, 1)
+	require.ErrorIs(t, invalid[0], cache.ErrInvalidTTL)
+
+	// The durations returned by initializers follow the same rules.
+	_, err := c.Get(ctx, "loaded", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "value", duration(48 * time.Hour), nil
+	})
+	require.NoError(t, err)
+	loaded := recorder.Find(cache.OpGet, "loaded", cache.Loaded())
+	require.Len(t, loaded, 1)
+	require.Equal(t, time.Hour, *loaded[0].Duration)
+
+	_, err = c.Get(ctx, "invalid", func(ctx context.Context, key string) (string, *time.Duration, error) {
+		return "value", duration(-2 * time.Second), nil
+	})
+	require.ErrorIs(t, err, cache.ErrInvalidTTL)
+	_, err = base.Get(ctx, "invalid", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+}
+
+func TestWithTTLPolicy_NoDefault(t *testing.T) {
+	ctx := context.Background()
+	base, recorder := cache.NewTestCache[string]()
+	c := cache.WithTTLPolicy(base, cache.WithMaxTTL(time.Hour))
+
+	// Without a default TTL, nil durations are left to the backend.
+	c.Set(ctx, "nil", "value", nil)
+	calls := recorder.Find(cache.OpSet, "nil")
+	require.Len(t, calls, 1)
+	require.Nil(t, calls[0].Duration)
+}
@@ -0,0 +1,295 @@
+package writethrough
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	cache "github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/util/pointer"
+)
+
+// StoreFunc writes value under key to the system of record, e.g., a database.
+type StoreFunc[T any] func(ctx context.Context, key string, value T) error
+
+// DeleteFunc removes key from the system of record.
+type DeleteFunc func(ctx context.Context, key string) error
+
+type config struct {
+	writeBack     bool
+	flushInterval time.Duration
+	maxPending    int
+	errorHandler  func(ctx context.Context, key string, err error)
+}
+
+type Option func(*config)
+
+// WithWriteBack makes Set only update the cache, and write the values to the store in the background every
+// interval, or as soon as maxPending keys are waiting (zero or a negative value means no limit). Successive
+// writes of a key are coalesced into the last one. Writes lost on a crash are the price of the lower latency:
+// call Close on shutdown to flush the pending writes.
+func WithWriteBack(interval time.Duration, maxPending int) Option {
+	return func(c *config) {
+		c.writeBack = true
+		c.flushInterval = interval
+		c.maxPending = maxPending
+	}
+}
+
+// WithErrorHandler sets a function called when writing a value to the store fails, since Set does not
+// return errors.
+func WithErrorHandler(handler func(ctx context.Context, key string, err error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
+
+func newConfig(opts ...Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type writeThroughCache[T any] struct {
+	cache cache.Cache[T]
+	store StoreFunc[T]
+	del   DeleteFunc
+	// writeMutex serializes the writes and deletes of the store, so that a flush cannot write a key back
+	// after it was deleted.
+	writeMutex sync.Mutex
+	// pendingMutex guards pending and closed.
+	pendingMutex sync.Mutex
+	pending      map[string]T
+	closed       bool
+	flushSignal  chan struct{}
+	done         chan struct{}
+	stopped      chan struct{}
+	config
+}
+
+/*
+New wraps c to keep it consistent with a system of record: Set writes the value to the store with store, then
+to the cache, and Invalidate deletes the key from the store with del, then from the cache. A failed store write
+leaves the cache untouched, and is reported to the WithErrorHandler function. With WithWriteBack, Set updates
+the cache immediately and the store in the background.
+
+Get only reads the cache: pass an initializer reading the store to load missing keys. InvalidateAll only
+clears the cache.
+
+Call Close, e.g., with cache.Close(c), to flush the pending writes, stop the background writes of WithWriteBack
+and close c. Flush, e.g., c.(interface{ Flush(ctx context.Context) error }).Flush(ctx), writes
+the pending writes immediately.
+
+Example usage:
+
+	users := writethrough.New[User](localcache.New[User](),
+		func(ctx context.Context, key string, user User) error { return repository.Save(ctx, user) },
+		func(ctx context.Context, key string) error { return repository.Delete(ctx, key) },
+		writethrough.WithErrorHandler(func(ctx context.Context, key string, err error) {
+			log.Error(ctx, "failed to save user", err)
+		}),
+	)
+*/
+func New[T any](c cache.Cache[T], store StoreFunc[T], del DeleteFunc, opts ...Option) cache.BatchCache[T] {
+	cfg := newConfig(opts...)
+	wc := &writeThroughCache[T]{
+		cache:       c,
+		store:       store,
+		del:         del,
+		pending:     make(map[string]T),
+		flushSignal: make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+		config:      pointer.GetValue(cfg),
+	}
+	if wc.writeBack {
+		go wc.flushLoop()
+	} else {
+		close(wc.stopped)
+	}
+	return wc
+}
+
+// Get retrieves a value from the wrapped cache.
+func (c *writeThroughCache[T]) Get(ctx context.Context, key string, initializer cache.Initializer[T]) (T, error) {
+	return c.cache.Get(ctx, key, initializer)
+}
+
+// Set writes value to the store, then to the cache. With WithWriteBack, it writes value to the cache, and
+// queues the write to the store.
+func (c *writeThroughCache[T]) Set(ctx context.Context, key string, value T, duration *time.Duration) {
+	if c.writeBack {
+		c.cache.Set(ctx, key, value, duration)
+		c.enqueue(map[string]T{key: value})
+		return
+	}
+
+	c.writeMutex.Lock()
+	err := c.store(ctx, key, value)
+	c.writeMutex.Unlock()
+	if err != nil {
+		c.handleError(ctx, key, fmt.Errorf("failed to write %q to the store: %w", key, err))
+		return
+	}
+	c.cache.Set(ctx, key, value, duration)
+}
+
+// Invalidate deletes key from the store, discarding its pending write, then from the cache. The key stays in
+// the cache if the store delete fails.
+func (c *writeThroughCache[T]) Invalidate(ctx context.Context, key string) error {
+	c.writeMutex.Lock()
+	c.pendingMutex.Lock()
+	delete(c.pending, key)
+	c.pendingMutex.Unlock()
+	err := c.del(ctx, key)
+	c.writeMutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to delete %q from the store: %w", key, err)
+	}
+	return c.cache.Invalidate(ctx, key)
+}
+
+// InvalidateAll removes all keys from the wrapped cache. The store and the pending writes are left untouched.
+func (c *writeThroughCache[T]) InvalidateAll(ctx context.Context) error {
+	return c.cache.InvalidateAll(ctx)
+}
+
+// GetMulti returns the values of the keys found in the wrapped cache.
+func (c *writeThroughCache[T]) GetMulti(ctx context.Context, keys []string) (map[string]T, error) {
+	return cache.GetMulti(ctx, c.cache, keys)
+}
+
+// SetMulti writes every item to the store, then the items written successfully to the cache.
+// With WithWriteBack, it writes the items to the cache, and queues their writes to the store.
+func (c *writeThroughCache[T]) SetMulti(ctx context.Context, items map[string]T, duration *time.Duration) {
+	if c.writeBack {
+		cache.SetMulti(ctx, c.cache, items, duration)
+		c.enqueue(items)
+		return
+	}
+
+	stored := make(map[string]T, len(items))
+	c.writeMutex.Lock()
+	for key, value := range items {
+		if err := c.store(ctx, key, value); err != nil {
+			c.handleError(ctx, key, fmt.Errorf("failed to write %q to the store: %w", key, err))
+			continue
+		}
+		stored[key] = value
+	}
+	c.writeMutex.Unlock()
+	cache.SetMulti(ctx, c.cache, stored, duration)
+}
+
+// InvalidateMulti deletes the keys from the store, then the keys deleted successfully from the cache.
+func (c *writeThroughCache[T]) InvalidateMulti(ctx context.Context, keys []string) error {
+	var errs []error
+	for _, key := range keys {
+		if err := c.Invalidate(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush writes the pending writes of WithWriteBack to the store, and returns the errors of the failed writes,
+// which are kept pending to be tried again.
+func (c *writeThroughCache[T]) Flush(ctx context.Context) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	c.pendingMutex.Lock()
+	pending := c.pending
+	c.pending = make(map[string]T)
+	c.pendingMutex.Unlock()
+
+	var errs []error
+	for key, value := range pending {
+		err := c.store(ctx, key, value)
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("failed to write %q to the store: %w", key, err)
+		errs = append(errs, err)
+		c.handleError(ctx, key, err)
+
+		c.pendingMutex.Lock()
+		if _, found := c.pending[key]; !found {
+			// Try again on the next flush, unless the key was set again meanwhile.
+			c.pending[key] = value
+		}
+		c.pendingMutex.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops the background writes of WithWriteBack, flushes the pending writes, and closes the wrapped cache.
+// Later Set calls write to the store directly.
+func (c *writeThroughCache[T]) Close() error {
+	c.pendingMutex.Lock()
+	if c.closed {
+		c.pendingMutex.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.pendingMutex.Unlock()
+
+	close(c.done)
+	<-c.stopped
+	return errors.Join(c.Flush(context.Background()), cache.Close(c.cache))
+}
+
+// enqueue adds items to the pending writes, and requests a flush once maxPending keys are waiting.
+// After Close, the items are written to the store directly.
+func (c *writeThroughCache[T]) enqueue(items map[string]T) {
+	c.pendingMutex.Lock()
+	for key, value := range items {
+		c.pending[key] = value
+	}
+	closed := c.closed
+	full := c.maxPending > 0 && len(c.pending) >= c.maxPending
+	c.pendingMutex.Unlock()
+
+	if closed {
+		_ = c.Flush(context.Background())
+		return
+	}
+	if full {
+		select {
+		case c.flushSignal <- struct{}{}:
+		default:
+			// A flush is already requested.
+		}
+	}
+}
+
+func (c *writeThroughCache[T]) flushLoop() {
+	defer close(c.stopped)
+
+	var tick <-chan time.Time
+	if c.flushInterval > 0 {
+		ticker := time.NewTicker(c.flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-tick:
+		case <-c.flushSignal:
+		case <-c.done:
+			return
+		}
+		// Failed writes are reported to the error handler and kept pending.
+		_ = c.Flush(context.Background())
+	}
+}
+
+func (c *writeThroughCache[T]) handleError(ctx context.Context, key string, err error) {
+	if c.errorHandler != nil {
+		c.errorHandler(ctx, key, err)
+	}
+}
@@ -0,0 +1,186 @@
+package writethrough_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+	"github.com/kittipat1413/go-common/framework/cache/writethrough"
+)
+
+var errStoreDown = errors.New("store down")
+
+// fakeStore is an in-memory system of record counting its writes.
+type fakeStore struct {
+	mu     sync.Mutex
+	items  map[string]string
+	writes int
+	err    error
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{items: make(map[string]string)}
+}
+
+func (s *fakeStore) Store(ctx context.Context, key string, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.items[key] = value
+	s.writes++
+	return nil
+}
+
+func (s *fakeStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.items, key)
+	return nil
+}
+
+func (s *fakeStore) get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.items[key]
+	return value, ok
+}
+
+func (s *fakeStore) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func TestWriteThrough(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	var handled []error
+	c := writethrough.New[string](localcache.New[string](), store.Store, store.Delete,
+		writethrough.WithErrorHandler(func(ctx context.Context, key string, err error) {
+			handled = append(handled, err)
+		}),
+	)
+
+	c.Set(ctx, "a", "1", nil)
+	value, ok := store.get("a")
+	require.True(t, ok)
+	require.Equal(t, "1", value)
+	cached, err := c.Get(ctx, "a", nil)
+	require.NoError(t, err)
+	require.Equal(t, "1", cached)
+
+	// A failed write leaves the cache untouched.
+	store.setErr(errStoreDown)
+	c.Set(ctx, "a", "2", nil)
+	require.Len(t, handled, 1)
+	require.ErrorIs(t, handled[0], errStoreDown)
+	cached, err = c.Get(ctx, "a", nil)
+	require.NoError(t, err)
+	require.Equal(t, "1", cached)
+
+	// A failed delete keeps the key in the cache.
+	require.ErrorIs(t, c.Invalidate(ctx, "a"), errStoreDown)
+	_, err = c.Get(ctx, "a", nil)
+	require.NoError(t, err)
+
+	store.setErr(nil)
+	require.NoError(t, c.Invalidate(ctx, "a"))
+	_, ok = store.get("a")
+	require.False(t, ok)
+	_, err = c.Get(ctx, "a", nil)
+	require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	c.SetMulti(ctx, map[string]string{"b": "1", "c": "2"}, nil)
+	values, err := c.GetMulti(ctx, []string{"b", "c"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"b": "1", "c": "2"}, values)
+	require.NoError(t, c.InvalidateMulti(ctx, []string{"b", "c"}))
+	_, ok = store.get("b")
+	require.False(t, ok)
+}
+
+func TestWriteBack(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	c := writethrough.New[string](localcache.New[string](), store.Store, store.Delete,
+		writethrough.WithWriteBack(time.Hour, 0),
+	)
+
+	// Writes update the cache immediately and are coalesced until the flush.
+	c.Set(ctx, "a", "1", nil)
+	c.Set(ctx, "a", "2", nil)
+	cached, err := c.Get(ctx, "a", nil)
+	require.NoError(t, err)
+	require.Equal(t, "2", cached)
+	_, ok := store.get("a")
+	require.False(t, ok)
+
+	flusher := c.(interface {
+		Flush(ctx context.Context) error
+	})
+	require.NoError(t, flusher.Flush(ctx))
+	value, _ := store.get("a")
+	require.Equal(t, "2", value)
+	require.Equal(t, 1, store.writes)
+
+	// Failed writes are kept pending.
+	store.setErr(errStoreDown)
+	c.Set(ctx, "b", "1", nil)
+	require.ErrorIs(t, flusher.Flush(ctx), errStoreDown)
+	store.setErr(nil)
+
+	// Invalidate discards the pending write of the key.
+	c.Set(ctx, "c", "1", nil)
+	require.NoError(t, c.Invalidate(ctx, "c"))
+
+	// Close flushes the pending writes.
+	require.NoError(t, c.(interface{ Close() error }).Close())
+	value, _ = store.get("b")
+	require.Equal(t, "1", value)
+	_, ok = store.get("c")
+	require.False(t, ok)
+
+	// After Close, writes reach the store directly.
+	c.Set(ctx, "d", "1", nil)
+	value, _ = store.get("d")
+	require.Equal(t, "1", value)
+}
+
+func TestWriteBack_BackgroundFlush(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+
+	// Writes are flushed once maxPending keys are waiting.
+	c := writethrough.New[string](localcache.New[string](), store.Store, store.Delete,
+		writethrough.WithWriteBack(time.Hour, 2),
+	)
+	defer c.(interface{ Close() error }).Close()
+	c.Set(ctx, "a", "1", nil)
+	c.Set(ctx, "b", "1", nil)
+	require.Eventually(t, func() bool {
+		_, ok := store.get("b")
+		return ok
+	}, time.Second, 5*time.Millisecond)
+
+	// Writes are flushed every interval.
+	c = writethrough.New[string](localcache.New[string](), store.Store, store.Delete,
+		writethrough.WithWriteBack(10*time.Millisecond, 0),
+	)
+	defer c.(interface{ Close() error }).Close()
+	c.Set(ctx, "c", "1", nil)
+	require.Eventually(t, func() bool {
+		_, ok := store.get("c")
+		return ok
+	}, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/validator"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrInvalidTarget is returned by Load when its target is not a non-nil pointer to a struct.
+	ErrInvalidTarget = stderrors.New("config: target must be a non-nil pointer to a struct")
+	// ErrUnsupportedFormat is returned for files whose extension is not .yaml, .yml or .json.
+	ErrUnsupportedFormat = stderrors.New("unsupported file format")
+	// ErrRequired is returned for required fields left empty by every source.
+	ErrRequired = stderrors.New("required value is missing")
+	// ErrInvalidValue is returned for values which cannot be parsed as the type of their field.
+	ErrInvalidValue = stderrors.New("invalid value")
+	// ErrUnsupportedType is returned for fields of a type which cannot be loaded.
+	ErrUnsupportedType = stderrors.New("unsupported type")
+)
+
+// file is a configuration file to load.
+type file struct {
+	path     string
+	optional bool
+}
+
+// loadOptions holds configuration options for Load and NewWatcher.
+type loadOptions struct {
+	files     []file
+	envPrefix string
+	lookupEnv func(key string) (string, bool)
+	validator *validator.Validator
+
+	reloadInterval time.Duration
+	errorHandler   func(err error)
+}
+
+// Option specifies Load and NewWatcher configuration options.
+type Option func(*loadOptions)
+
+// WithFile loads the YAML or JSON file at path, by its extension. Files are loaded in order, so that the values of
+// a file override the ones of the previous files. A missing file is an error.
+func WithFile(path string) Option {
+	return func(opts *loadOptions) {
+		opts.files = append(opts.files, file{path: path})
+	}
+}
+
+// WithOptionalFile is like WithFile, but a missing file is ignored, e.g., for local overrides.
+func WithOptionalFile(path string) Option {
+	return func(opts *loadOptions) {
+		opts.files = append(opts.files, file{path: path, optional: true})
+	}
+}
+
+// WithEnvPrefix prepends prefix to the names of the environment variables, e.g., "ORDER_SERVICE_".
+func WithEnvPrefix(prefix string) Option {
+	return func(opts *loadOptions) {
+		opts.envPrefix = prefix
+	}
+}
+
+// WithLookupEnv sets the function looking up the environment variables. It defaults to os.LookupEnv.
+func WithLookupEnv(lookupEnv func(key string) (string, bool)) Option {
+	return func(opts *loadOptions) {
+		if lookupEnv != nil {
+			opts.lookupEnv = lookupEnv
+		}
+	}
+}
+
+// WithValidator validates the loaded configuration with v, using the validate tags of its fields, see the
+// framework/validator package. The validation only runs if the configuration was loaded without errors.
+func WithValidator(v *validator.Validator) Option {
+	return func(opts *loadOptions) {
+		opts.validator = v
+	}
+}
+
+func newLoadOptions(opts []Option) *loadOptions {
+	o := &loadOptions{
+		lookupEnv:      os.LookupEnv,
+		reloadInterval: DefaultReloadInterval,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+/*
+Load populates the struct pointed to by dst from its default values, the configuration files and the environment
+variables, in this order of precedence, and then checks its required fields. It returns an *errors.MultiError
+holding the errors of every field, labeled with the path of the field, so that all the mistakes of a configuration
+are reported at once.
+
+The fields are configured with struct tags:
+  - default:"value" sets the value of the field if it is empty, i.e., fields already set in dst are kept.
+  - yaml:"name" or json:"name" sets the key of the field in the files. It defaults to the name of the field,
+    matched case-insensitively.
+  - env:"NAME" sets the environment variable of the field, prefixed with the WithEnvPrefix prefix. On a nested
+    struct, it is a prefix for the environment variables of its fields.
+  - required:"true" reports an ErrRequired error if the field is still empty once loaded.
+
+Fields may be strings, booleans, integers, floats, time.Duration, types implementing encoding.TextUnmarshaler,
+nested structs, and slices of these, which are comma-separated in the default values and the environment
+variables.
+
+Example usage:
+
+	type Config struct {
+		Port     int           `yaml:"port" env:"PORT" default:"8080"`
+		Timeout  time.Duration `yaml:"timeout" env:"TIMEOUT" default:"5s"`
+		Database struct {
+			DSN      string `yaml:"dsn" env:"DSN" required:"true"`
+			MaxConns int    `yaml:"max_conns" env:"MAX_CONNS" default:"10" validate:"min=1"`
+		} `yaml:"database" env:"DB_"`
+	}
+
+	var cfg Config
+	err := config.Load(&cfg,
+		config.WithFile("config.yaml"),
+		config.WithOptionalFile("config.local.yaml"),
+		config.WithEnvPrefix("APP_"), // APP_PORT, APP_DB_DSN, ...
+	)
+	if err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+*/
+func Load(dst interface{}, opts ...Option) error {
+	return load(dst, newLoadOptions(opts))
+}
+
+func load(dst interface{}, o *loadOptions) error {
+	target := reflect.ValueOf(dst)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return ErrInvalidTarget
+	}
+	root := target.Elem()
+
+	var errs errors.MultiError
+	walk(root, "", "", func(f field) {
+		if value, ok := f.tag.Lookup("default"); ok && f.value.IsZero() {
+			if err := setString(f.value, value); err != nil {
+				errs.Add(f.path, fmt.Errorf("default: %w", err))
+			}
+		}
+	})
+
+	for _, file := range o.files {
+		values, err := readFile(file)
+		if err != nil {
+			errs.Add(file.path, err)
+			continue
+		}
+		if values != nil {
+			setMap(root, "", values, "file "+file.path, &errs)
+		}
+	}
+
+	walk(root, "", "", func(f field) {
+		if f.env == "" {
+			return
+		}
+		name := o.envPrefix + f.env
+		if value, ok := o.lookupEnv(name); ok {
+			if err := setString(f.value, value); err != nil {
+				errs.Add(f.path, fmt.Errorf("env %s: %w", name, err))
+			}
+		}
+	})
+
+	walk(root, "", "", func(f field) {
+		if f.tag.Get("required") == "true" && f.value.IsZero() {
+			if f.env != "" {
+				errs.Add(f.path, fmt.Errorf("%w (env %s)", ErrRequired, o.envPrefix+f.env))
+			} else {
+				errs.Add(f.path, ErrRequired)
+			}
+		}
+	})
+
+	if errs.Len() == 0 && o.validator != nil {
+		errs.Add("", o.validator.ValidateStruct(dst))
+	}
+	return errs.Err()
+}
+
+// readFile reads the values of file, or returns nil if the file is optional and missing.
+func readFile(file file) (map[string]interface{}, error) {
+	data, err := os.ReadFile(file.path)
+	if err != nil {
+		if file.optional && stderrors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var values map[string]interface{}
+	switch strings.ToLower(filepath.Ext(file.path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&values)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return values, nil
+}
@@ -0,0 +1,199 @@
+package config_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/config"
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type databaseConfig struct {
+	DSN      string `yaml:"dsn" json:"dsn" env:"DSN" required:"true"`
+	MaxConns int    `yaml:"max_conns" json:"max_conns" env:"MAX_CONNS" default:"10" validate:"min=1"`
+}
+
+type testConfig struct {
+	Name     string         `env:"NAME" default:"service"`
+	Port     int            `yaml:"port" json:"port" env:"PORT" default:"8080"`
+	Debug    bool           `yaml:"debug" json:"debug" env:"DEBUG"`
+	Ratio    float64        `yaml:"ratio" json:"ratio" env:"RATIO" default:"0.5"`
+	Timeout  time.Duration  `yaml:"timeout" json:"timeout" env:"TIMEOUT" default:"5s"`
+	Hosts    []string       `yaml:"hosts" json:"hosts" env:"HOSTS"`
+	IP       net.IP         `yaml:"ip" json:"ip" env:"IP"`
+	Database databaseConfig `yaml:"database" json:"database" env:"DB_"`
+	Ignored  string         `yaml:"-" json:"-"`
+	internal string
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func lookupEnv(env map[string]string) config.Option {
+	return config.WithLookupEnv(func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	})
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg := testConfig{Port: 9090}
+	err := config.Load(&cfg, lookupEnv(map[string]string{"DB_DSN": "postgres://localhost"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "service", cfg.Name)
+	assert.Equal(t, 9090, cfg.Port, "fields already set are kept")
+	assert.Equal(t, 0.5, cfg.Ratio)
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+	assert.Equal(t, 10, cfg.Database.MaxConns)
+	assert.Equal(t, "postgres://localhost", cfg.Database.DSN)
+}
+
+func TestLoad_Files(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `
+port: 9000
+debug: true
+timeout: 2m
+hosts: [a.example.com, b.example.com]
+ip: 10.0.0.1
+database:
+  dsn: postgres://db
+  max_conns: 20
+Ignored: value
+unknown: value
+`,
+		},
+		{
+			name: "json",
+			file: "config.json",
+			content: `{
+	"port": 9000,
+	"debug": true,
+	"timeout": "2m",
+	"hosts": ["a.example.com", "b.example.com"],
+	"ip": "10.0.0.1",
+	"database": {"dsn": "postgres://db", "max_conns": 20},
+	"Ignored": "value",
+	"unknown": "value"
+}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg testConfig
+			err := config.Load(&cfg, config.WithFile(writeFile(t, tt.file, tt.content)), lookupEnv(nil))
+			require.NoError(t, err)
+
+			assert.Equal(t, "service", cfg.Name)
+			assert.Equal(t, 9000, cfg.Port)
+			assert.True(t, cfg.Debug)
+			assert.Equal(t, 2*time.Minute, cfg.Timeout)
+			assert.Equal(t, []string{"a.example.com", "b.example.com"}, cfg.Hosts)
+			assert.Equal(t, "10.0.0.1", cfg.IP.String())
+			assert.Equal(t, databaseConfig{DSN: "postgres://db", MaxConns: 20}, cfg.Database)
+			assert.Empty(t, cfg.Ignored)
+		})
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	base := writeFile(t, "base.yaml", "port: 9000\nname: base\ndatabase:\n  dsn: postgres://base\n")
+	override := writeFile(t, "override.yml", "port: 9001\n")
+
+	var cfg testConfig
+	err := config.Load(&cfg,
+		config.WithFile(base),
+		config.WithFile(override),
+		config.WithOptionalFile(filepath.Join(t.TempDir(), "missing.yaml")),
+		config.WithEnvPrefix("APP_"),
+		lookupEnv(map[string]string{
+			"APP_NAME":      "env",
+			"APP_HOSTS":     "a, b,",
+			"APP_DB_DSN":    "postgres://env",
+			"PORT":          "1",
+			"APP_DB_UNUSED": "1",
+		}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "env", cfg.Name)
+	assert.Equal(t, 9001, cfg.Port)
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	assert.Equal(t, "postgres://env", cfg.Database.DSN)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	toml := writeFile(t, "config.toml", "port = 1")
+
+	var cfg testConfig
+	err := config.Load(&cfg,
+		config.WithFile(writeFile(t, "config.yaml", "debug: maybe\ndatabase: postgres://db\n")),
+		config.WithFile(missing),
+		config.WithFile(toml),
+		config.WithEnvPrefix("APP_"),
+		lookupEnv(map[string]string{"APP_PORT": "http", "APP_TIMEOUT": "5"}),
+	)
+	require.Error(t, err)
+
+	var errs *domain_error.MultiError
+	require.True(t, errors.As(err, &errs))
+	assert.Equal(t, []string{"Debug", "Database", missing, toml, "Port", "Timeout", "Database.DSN"}, errs.Labels())
+	assert.ErrorIs(t, err, config.ErrInvalidValue)
+	assert.ErrorIs(t, err, config.ErrRequired)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.ErrorIs(t, err, config.ErrUnsupportedFormat)
+	assert.Contains(t, err.Error(), `Port: env APP_PORT: invalid value "http" for int`)
+	assert.Contains(t, err.Error(), "Database.DSN: required value is missing (env APP_DB_DSN)")
+}
+
+func TestLoad_Validator(t *testing.T) {
+	v, err := validator.NewValidator()
+	require.NoError(t, err)
+
+	var cfg testConfig
+	err = config.Load(&cfg,
+		config.WithValidator(v),
+		lookupEnv(map[string]string{"DB_DSN": "postgres://localhost", "DB_MAX_CONNS": "0"}),
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "MaxConns must be 1 or greater")
+
+	err = config.Load(&cfg, config.WithValidator(v), lookupEnv(map[string]string{"DB_MAX_CONNS": "1"}))
+	require.NoError(t, err)
+}
+
+func TestLoad_InvalidTarget(t *testing.T) {
+	var cfg testConfig
+	var nilConfig *testConfig
+	for _, dst := range []interface{}{cfg, nilConfig, new(int), nil} {
+		assert.ErrorIs(t, config.Load(dst), config.ErrInvalidTarget)
+	}
+}
+
+func TestLoad_UnsupportedType(t *testing.T) {
+	var cfg struct {
+		Values map[string]string `env:"VALUES"`
+	}
+	err := config.Load(&cfg, lookupEnv(map[string]string{"VALUES": "a=b"}))
+	assert.ErrorIs(t, err, config.ErrUnsupportedType)
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// field is a configurable field of a configuration struct.
+type field struct {
+	value reflect.Value
+	tag   reflect.StructTag
+	// path is the dot-separated path of the field from the configuration struct, e.g., "Database.DSN".
+	path string
+	// env is the name of the environment variable of the field, without the WithEnvPrefix prefix.
+	env string
+}
+
+// isNested reports whether fields of type t are structs whose fields are configured one by one.
+func isNested(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// joinPath appends name to the path of a field.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// walk calls fn with the exported fields of the struct v, descending into the nested structs.
+func walk(v reflect.Value, path, envPrefix string, fn func(f field)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+		fieldPath := joinPath(path, structField.Name)
+		env := structField.Tag.Get("env")
+		if isNested(structField.Type) {
+			walk(v.Field(i), fieldPath, envPrefix+env, fn)
+			continue
+		}
+		if env != "" {
+			env = envPrefix + env
+		}
+		fn(field{value: v.Field(i), tag: structField.Tag, path: fieldPath, env: env})
+	}
+}
+
+// fileKey returns the key of a field in the files, and false if the field is excluded from them.
+func fileKey(structField reflect.StructField) (string, bool) {
+	for _, tag := range []string{"yaml", "json"} {
+		name, _, _ := strings.Cut(structField.Tag.Get(tag), ",")
+		if name == "-" {
+			return "", false
+		}
+		if name != "" {
+			return name, true
+		}
+	}
+	return "", true
+}
+
+// lookupKey returns the value of the key of a field in values, matching the name of the field case-insensitively
+// if the field has no key.
+func lookupKey(values map[string]interface{}, structField reflect.StructField) (interface{}, bool) {
+	key, ok := fileKey(structField)
+	if !ok {
+		return nil, false
+	}
+	if key != "" {
+		value, found := values[key]
+		return value, found
+	}
+	for k, value := range values {
+		if strings.EqualFold(k, structField.Name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// setMap sets the fields of the struct v from the values of a file, adding the errors to errs.
+func setMap(v reflect.Value, path string, values map[string]interface{}, source string, errs *errors.MultiError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+		value, found := lookupKey(values, structField)
+		if !found || value == nil {
+			continue
+		}
+		fieldPath := joinPath(path, structField.Name)
+		if isNested(structField.Type) {
+			nested, ok := value.(map[string]interface{})
+			if !ok {
+				errs.Add(fieldPath, fmt.Errorf("%s: %w: expected a mapping, got %v", source, ErrInvalidValue, value))
+				continue
+			}
+			setMap(v.Field(i), fieldPath, nested, source, errs)
+			continue
+		}
+		if err := setValue(v.Field(i), value); err != nil {
+			errs.Add(fieldPath, fmt.Errorf("%s: %w", source, err))
+		}
+	}
+}
+
+// setValue sets v to value, as decoded from a file.
+func setValue(v reflect.Value, value interface{}) error {
+	switch value := value.(type) {
+	case []interface{}:
+		if v.Kind() != reflect.Slice || v.Addr().Type().Implements(textUnmarshalerType) {
+			return fmt.Errorf("%w: unexpected list for %s", ErrInvalidValue, v.Type())
+		}
+		slice := reflect.MakeSlice(v.Type(), len(value), len(value))
+		for i, item := range value {
+			if err := setValue(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case map[string]interface{}:
+		return fmt.Errorf("%w: unexpected mapping for %s", ErrInvalidValue, v.Type())
+	case time.Time:
+		return setString(v, value.Format(time.RFC3339Nano))
+	default:
+		return setString(v, fmt.Sprint(value))
+	}
+}
+
+// setString parses s as the type of v and sets v. Slices are comma-separated.
+func setString(v reflect.Value, s string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("%w %q for %s: %v", ErrInvalidValue, s, v.Type(), err)
+		}
+		return nil
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%w %q for %s: %v", ErrInvalidValue, s, v.Type(), err)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			v.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(s, 10, v.Type().Bits()); err == nil {
+			v.SetInt(i)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		if u, err = strconv.ParseUint(s, 10, v.Type().Bits()); err == nil {
+			v.SetUint(u)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(s, v.Type().Bits()); err == nil {
+			v.SetFloat(f)
+		}
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setString(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("%w %s", ErrUnsupportedType, v.Type())
+	}
+	if err != nil {
+		return fmt.Errorf("%w %q for %s: %v", ErrInvalidValue, s, v.Type(), err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultReloadInterval is the interval between checks of the configuration files of a Watcher, unless
+// WithReloadInterval is set.
+const DefaultReloadInterval = 10 * time.Second
+
+// WithReloadInterval sets the interval between checks of the configuration files of a Watcher, which reloads the
+// configuration when a file is modified, created or removed. A negative interval disables the checks, so that the
+// configuration is only reloaded by Reload, e.g., on SIGHUP. It is ignored by Load.
+func WithReloadInterval(d time.Duration) Option {
+	return func(opts *loadOptions) {
+		if d != 0 {
+			opts.reloadInterval = d
+		}
+	}
+}
+
+// WithReloadErrorHandler sets a function called with the errors of the reloads of a Watcher in the background,
+// e.g., to log them. The previous configuration is kept when a reload fails. It is ignored by Load.
+func WithReloadErrorHandler(handler func(err error)) Option {
+	return func(opts *loadOptions) {
+		opts.errorHandler = handler
+	}
+}
+
+// Change describes a reload of the configuration of a Watcher.
+type Change[T any] struct {
+	// Old and New are the previous and the new configuration. They must not be modified.
+	Old, New *T
+	// Keys are the paths of the fields changed, e.g., "Log.Level".
+	Keys []string
+}
+
+// Changed reports whether one of the fields at paths, or one of their fields for nested structs, has changed.
+func (c Change[T]) Changed(paths ...string) bool {
+	for _, key := range c.Keys {
+		for _, path := range paths {
+			if key == path || strings.HasPrefix(key, path+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// subscriber is a function subscribed to the changes of a Watcher.
+type subscriber[T any] struct {
+	id   uint64
+	fn   func(change Change[T])
+	keys []string
+}
+
+// fileStat is the state of a configuration file, to detect its modifications.
+type fileStat struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+/*
+Watcher holds a configuration of type T loaded like Load, and reloads it in the background when its files change,
+so that settings like the log level or feature toggles can change without restarts. Every reload loads a new
+configuration, and swaps it atomically with the previous one if it is valid, so that readers always get a complete
+and valid snapshot. The subscribers are then notified of the changed fields.
+
+The files are checked every reload interval, see WithReloadInterval: call Close to stop the checks. The environment
+variables of a process do not change, so they are only read again by the reloads.
+
+Example usage:
+
+	watcher, err := config.NewWatcher[Config](
+		config.WithFile("/etc/service/config.yaml"),
+		config.WithEnvPrefix("APP_"),
+		config.WithReloadErrorHandler(func(err error) {
+			log.Error(ctx, "invalid configuration, keeping the previous one", err, nil)
+		}),
+	)
+	if err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	defer watcher.Close()
+
+	watcher.Subscribe(func(change config.Change[Config]) {
+		logger.SetLevel(change.New.Log.Level)
+	}, "Log.Level")
+
+	if watcher.Get().Features.NewCheckout {
+		// ...
+	}
+*/
+type Watcher[T any] struct {
+	opts    *loadOptions
+	current atomic.Pointer[T]
+
+	// reloadMutex serializes the reloads, so that the subscribers are notified in order.
+	reloadMutex sync.Mutex
+	stats       []fileStat
+
+	subscribersMutex sync.Mutex
+	subscribers      []subscriber[T]
+	nextID           uint64
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewWatcher loads the configuration with opts, see Load, and starts checking its files. It returns the errors of
+// the initial load, in which case no Watcher is started.
+func NewWatcher[T any](opts ...Option) (*Watcher[T], error) {
+	w := &Watcher[T]{
+		opts: newLoadOptions(opts),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	w.stats = w.statFiles()
+	cfg := new(T)
+	if err := load(cfg, w.opts); err != nil {
+		return nil, err
+	}
+	w.current.Store(cfg)
+
+	if w.opts.reloadInterval > 0 {
+		go w.watchLoop(w.opts.reloadInterval)
+	} else {
+		close(w.done)
+	}
+	return w, nil
+}
+
+// Get returns the current configuration. It must not be modified, since it is shared by all readers.
+func (w *Watcher[T]) Get() *T {
+	return w.current.Load()
+}
+
+/*
+Subscribe calls fn after every reload changing the configuration, or only changing one of keys, the paths of the
+fields, e.g., "Log.Level", or of nested structs, e.g., "Log", if keys are set. The subscribers are called in order
+of subscription, one reload at a time, and must not block. It returns a function unsubscribing fn.
+*/
+func (w *Watcher[T]) Subscribe(fn func(change Change[T]), keys ...string) (unsubscribe func()) {
+	w.subscribersMutex.Lock()
+	defer w.subscribersMutex.Unlock()
+
+	w.nextID++
+	id := w.nextID
+	w.subscribers = append(w.subscribers, subscriber[T]{id: id, fn: fn, keys: keys})
+	return func() {
+		w.subscribersMutex.Lock()
+		defer w.subscribersMutex.Unlock()
+
+		for i, s := range w.subscribers {
+			if s.id == id {
+				w.subscribers = append(w.subscribers[:i:i], w.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Reload loads the configuration again, and swaps it with the current one and notifies the subscribers if it is
+// valid and changed. It returns the errors of the load, in which case the current configuration is kept.
+func (w *Watcher[T]) Reload() error {
+	w.reloadMutex.Lock()
+	defer w.reloadMutex.Unlock()
+
+	w.stats = w.statFiles()
+	return w.reload()
+}
+
+// reload loads the configuration and notifies the subscribers. It must be called with the reload mutex held.
+func (w *Watcher[T]) reload() error {
+	cfg := new(T)
+	if err := load(cfg, w.opts); err != nil {
+		return err
+	}
+	old := w.current.Load()
+	keys := changedKeys(reflect.ValueOf(old).Elem(), reflect.ValueOf(cfg).Elem(), "")
+	if len(keys) == 0 {
+		return nil
+	}
+	w.current.Store(cfg)
+
+	change := Change[T]{Old: old, New: cfg, Keys: keys}
+	w.subscribersMutex.Lock()
+	subscribers := append([]subscriber[T](nil), w.subscribers...)
+	w.subscribersMutex.Unlock()
+	for _, s := range subscribers {
+		if len(s.keys) == 0 || change.Changed(s.keys...) {
+			s.fn(change)
+		}
+	}
+	return nil
+}
+
+// statFiles returns the state of the configuration files.
+func (w *Watcher[T]) statFiles() []fileStat {
+	stats := make([]fileStat, len(w.opts.files))
+	for i, file := range w.opts.files {
+		if info, err := os.Stat(file.path); err == nil {
+			stats[i] = fileStat{exists: true, modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stats
+}
+
+// check reloads the configuration if its files changed since the last reload.
+func (w *Watcher[T]) check() {
+	w.reloadMutex.Lock()
+	defer w.reloadMutex.Unlock()
+
+	stats := w.statFiles()
+	if reflect.DeepEqual(stats, w.stats) {
+		return
+	}
+	w.stats = stats
+	if err := w.reload(); err != nil && w.opts.errorHandler != nil {
+		w.opts.errorHandler(err)
+	}
+}
+
+func (w *Watcher[T]) watchLoop(interval time.Duration) {
+	defer close(w.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.check()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// Close stops checking the files and waits for the running reload to return. The Watcher remains usable, and
+// Reload still reloads the configuration.
+func (w *Watcher[T]) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+	return nil
+}
+
+// changedKeys returns the paths of the fields of the structs a and b which differ, descending into nested structs.
+func changedKeys(a, b reflect.Value, path string) []string {
+	var keys []string
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+		fieldPath := joinPath(path, structField.Name)
+		if isNested(structField.Type) {
+			keys = append(keys, changedKeys(a.Field(i), b.Field(i), fieldPath)...)
+			continue
+		}
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			keys = append(keys, fieldPath)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,132 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+type logConfig struct {
+	Level  string `yaml:"level" default:"info"`
+	Format string `yaml:"format" default:"json"`
+}
+
+type reloadableConfig struct {
+	Log      logConfig       `yaml:"log"`
+	Features map[string]bool `yaml:"-"`
+	Flags    []string        `yaml:"flags"`
+	Port     int             `yaml:"port" required:"true"`
+}
+
+// updateFile writes content to path, and bumps its modification time so that the change is detected even on file
+// systems with coarse timestamps.
+func updateFile(t *testing.T, path, content string, age time.Duration) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	modTime := time.Now().Add(age)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	updateFile(t, path, "port: 8080\nflags: [a]\n", -time.Hour)
+
+	watcher, err := config.NewWatcher[reloadableConfig](config.WithFile(path), config.WithReloadInterval(-1))
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	initial := watcher.Get()
+	assert.Equal(t, reloadableConfig{Log: logConfig{Level: "info", Format: "json"}, Flags: []string{"a"}, Port: 8080}, *initial)
+
+	var all, logs, ports []config.Change[reloadableConfig]
+	watcher.Subscribe(func(change config.Change[reloadableConfig]) { all = append(all, change) })
+	watcher.Subscribe(func(change config.Change[reloadableConfig]) { logs = append(logs, change) }, "Log")
+	unsubscribe := watcher.Subscribe(func(change config.Change[reloadableConfig]) { ports = append(ports, change) }, "Port")
+	unsubscribe()
+
+	// Unchanged configuration
+	require.NoError(t, watcher.Reload())
+	assert.Same(t, initial, watcher.Get())
+	assert.Empty(t, all)
+
+	updateFile(t, path, "port: 8080\nflags: [a, b]\nlog:\n  level: debug\n", -time.Minute)
+	require.NoError(t, watcher.Reload())
+	current := watcher.Get()
+	assert.Equal(t, "debug", current.Log.Level)
+	assert.Equal(t, []string{"a", "b"}, current.Flags)
+	assert.Equal(t, "info", initial.Log.Level, "the previous snapshot is not modified")
+
+	require.Len(t, all, 1)
+	assert.Same(t, initial, all[0].Old)
+	assert.Same(t, current, all[0].New)
+	assert.Equal(t, []string{"Log.Level", "Flags"}, all[0].Keys)
+	assert.True(t, all[0].Changed("Log"))
+	assert.False(t, all[0].Changed("Port", "Log.Format"))
+	assert.Len(t, logs, 1)
+	assert.Empty(t, ports)
+
+	// Invalid configuration
+	updateFile(t, path, "flags: [c]\n", 0)
+	assert.ErrorIs(t, watcher.Reload(), config.ErrRequired)
+	assert.Same(t, current, watcher.Get())
+	assert.Len(t, all, 1)
+}
+
+func TestWatcher_WatchFiles(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	updateFile(t, path, "port: 8080\n", -time.Hour)
+
+	var mutex sync.Mutex
+	var errs []error
+	watcher, err := config.NewWatcher[reloadableConfig](
+		config.WithFile(path),
+		config.WithReloadInterval(5*time.Millisecond),
+		config.WithReloadErrorHandler(func(err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			errs = append(errs, err)
+		}),
+	)
+	require.NoError(t, err)
+
+	changes := make(chan config.Change[reloadableConfig], 10)
+	watcher.Subscribe(func(change config.Change[reloadableConfig]) { changes <- change })
+
+	updateFile(t, path, "port: 9090\n", -time.Minute)
+	select {
+	case change := <-changes:
+		assert.Equal(t, []string{"Port"}, change.Keys)
+		assert.Equal(t, 9090, watcher.Get().Port)
+	case <-time.After(time.Second):
+		t.Fatal("the change was not detected")
+	}
+
+	updateFile(t, path, "port: http\n", 0)
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(errs) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 9090, watcher.Get().Port)
+
+	require.NoError(t, watcher.Close())
+	require.NoError(t, watcher.Close())
+}
+
+func TestNewWatcher_Error(t *testing.T) {
+	watcher, err := config.NewWatcher[reloadableConfig](config.WithLookupEnv(func(string) (string, bool) { return "", false }))
+	assert.ErrorIs(t, err, config.ErrRequired)
+	assert.Nil(t, watcher)
+
+	_, err = config.NewWatcher[int]()
+	assert.ErrorIs(t, err, config.ErrInvalidTarget)
+}
@@ -0,0 +1,488 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/util/clock"
+)
+
+// DefaultLockTTL is the time the lock of a run is held, unless set with WithLock.
+const DefaultLockTTL = time.Minute
+
+// LockKeyPrefix is the prefix of the keys of the locks of the runs, followed by the name of the job and the Unix time
+// of the run.
+const LockKeyPrefix = "cron:"
+
+var (
+	// ErrDuplicateJob is returned when a job is added with the name of another job.
+	ErrDuplicateJob = errors.New("cron: duplicate job")
+	// ErrNoLocker is returned when a job is added with WithLock to a scheduler without WithLocker.
+	ErrNoLocker = errors.New("cron: the scheduler has no locker")
+	// ErrClosed is returned when a job is added to a scheduler shut down.
+	ErrClosed = errors.New("cron: the scheduler is closed")
+	// ErrPanic is wrapped by the errors the panics of the jobs are converted to.
+	ErrPanic = errors.New("cron: job panicked")
+)
+
+// Job is the function run by the scheduler. It must return when ctx is done.
+type Job func(ctx context.Context) error
+
+/*
+Locker acquires distributed locks, e.g., with Redis, so that a run of a job happens on a single replica. The lock
+of a run is keyed by the job and the time of the run, and is not released: it expires after its TTL, so that the
+replicas starting the run a bit later, e.g., because of clock skew, do not run it again.
+*/
+type Locker interface {
+	// TryLock acquires the lock of key for ttl, and reports whether it was acquired, or held by someone else.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// Overlap is the policy of a job for a run due while its previous run is not finished.
+type Overlap int
+
+const (
+	// OverlapSkip skips the run. It is the default policy.
+	OverlapSkip Overlap = iota
+	// OverlapQueue starts the run once the previous one is finished. At most one run waits, the next ones are
+	// skipped.
+	OverlapQueue
+	// OverlapConcurrent starts the run at once, concurrently with the previous one.
+	OverlapConcurrent
+)
+
+// options holds configuration options for the scheduler.
+type options struct {
+	location *time.Location // location is the time zone of the schedules.
+	logger   logger.Logger  // logger logs the runs, or the logger of the context of Run if nil.
+	metrics  *Metrics       // metrics records the runs if set.
+	locker   Locker         // locker locks the runs of the jobs with WithLock.
+	clock    clock.Clock    // clock is the clock of the schedules.
+}
+
+// Option specifies scheduler configuration options.
+type Option func(*options)
+
+// WithLocation sets the time zone of the cron expressions. It defaults to time.Local.
+func WithLocation(location *time.Location) Option {
+	return func(opts *options) {
+		if location != nil {
+			opts.location = location
+		}
+	}
+}
+
+// WithLogger sets the logger of the runs. It defaults to the logger of the context of Run, see logger.FromContext.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		if l != nil {
+			opts.logger = l
+		}
+	}
+}
+
+// WithMetrics records the runs of the jobs in m. They are not recorded by default.
+func WithMetrics(m *Metrics) Option {
+	return func(opts *options) {
+		if m != nil {
+			opts.metrics = m
+		}
+	}
+}
+
+// WithLocker sets the locker of the jobs added with WithLock.
+func WithLocker(locker Locker) Option {
+	return func(opts *options) {
+		if locker != nil {
+			opts.locker = locker
+		}
+	}
+}
+
+// WithClock sets the clock of the schedules, e.g., a clock.Fake to trigger the runs in tests without waiting for
+// them. It defaults to the real clock.
+func WithClock(c clock.Clock) Option {
+	return func(opts *options) {
+		if c != nil {
+			opts.clock = c
+		}
+	}
+}
+
+// jobOptions holds configuration options for a job.
+type jobOptions struct {
+	timeout time.Duration // timeout bounds every run, or zero for no timeout.
+	overlap Overlap       // overlap is the policy of the runs due while the previous one is not finished.
+	jitter  time.Duration // jitter is the maximum random delay of every run.
+	lock    bool          // lock runs the job on a single replica.
+	lockTTL time.Duration // lockTTL is the time the lock of a run is held.
+}
+
+// JobOption specifies job configuration options.
+type JobOption func(*jobOptions)
+
+// WithTimeout bounds the duration of every run of the job, whose context is canceled after d. There is no timeout
+// by default.
+func WithTimeout(d time.Duration) JobOption {
+	return func(opts *jobOptions) {
+		if d > 0 {
+			opts.timeout = d
+		}
+	}
+}
+
+// WithOverlap sets the policy of the runs due while the previous run of the job is not finished. It defaults to
+// OverlapSkip.
+func WithOverlap(overlap Overlap) JobOption {
+	return func(opts *jobOptions) {
+		opts.overlap = overlap
+	}
+}
+
+// WithJitter delays every run of the job by a random duration up to d, e.g., so that the jobs of many services
+// scheduled at midnight do not hit a shared database at once. There is no jitter by default.
+func WithJitter(d time.Duration) JobOption {
+	return func(opts *jobOptions) {
+		if d > 0 {
+			opts.jitter = d
+		}
+	}
+}
+
+// WithLock runs every run of the job on a single replica, the first one acquiring its lock with the Locker of the
+// scheduler. The lock is held for ttl, or DefaultLockTTL if not positive, which must exceed the clock skew and the
+// jitter between the replicas.
+func WithLock(ttl time.Duration) JobOption {
+	return func(opts *jobOptions) {
+		opts.lock = true
+		opts.lockTTL = ttl
+		if ttl <= 0 {
+			opts.lockTTL = DefaultLockTTL
+		}
+	}
+}
+
+// entry is a job added to the scheduler.
+type entry struct {
+	name     string
+	schedule Schedule
+	job      Job
+	opts     jobOptions
+	ticks    chan time.Time // ticks are the runs waiting for the previous one, with OverlapSkip and OverlapQueue.
+}
+
+/*
+Scheduler runs jobs on schedules, given by cron expressions or intervals. It is a lifecycle.Runnable and
+lifecycle.Shutdowner: Shutdown stops scheduling the runs, and waits for the runs in progress to finish. If they do
+not finish in time, they are canceled.
+
+Every run is logged, at info level with its duration, or at error level with its error, and recorded by the
+metrics of WithMetrics. The panics of the jobs are recovered and logged with their stack.
+
+Example usage:
+
+	scheduler := cron.New(
+		cron.WithLocation(time.UTC),
+		cron.WithMetrics(cronMetrics),
+		cron.WithLocker(locker),
+	)
+	err := scheduler.Add("cleanup-sessions", "0 3 * * *", sessions.Cleanup,
+		cron.WithTimeout(10*time.Minute),
+		cron.WithJitter(time.Minute),
+		cron.WithLock(time.Hour),
+	)
+	if err != nil {
+		// Handle error
+	}
+	err = scheduler.AddSchedule("refresh-rates", cron.Every(5*time.Minute), rates.Refresh,
+		cron.WithOverlap(cron.OverlapQueue),
+	)
+	manager.Add("cron", scheduler)
+*/
+type Scheduler struct {
+	opts options
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	started bool
+	closed  bool
+	ctx     context.Context // ctx is the parent of the contexts of the runs, canceled when the drain times out.
+	cancel  context.CancelFunc
+
+	stop     chan struct{} // stop is closed by Shutdown.
+	stopOnce sync.Once
+	loops    sync.WaitGroup // loops are the goroutines scheduling and running the jobs.
+}
+
+// New creates a scheduler. The jobs are run once Run is called.
+func New(opts ...Option) *Scheduler {
+	o := options{location: time.Local, clock: clock.Real()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Scheduler{opts: o, entries: make(map[string]*entry), stop: make(chan struct{})}
+}
+
+// Add adds the job name, run on the schedule of the cron expression spec, see Parse.
+func (s *Scheduler) Add(name, spec string, job Job, opts ...JobOption) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	return s.AddSchedule(name, schedule, job, opts...)
+}
+
+// AddSchedule adds the job name, run on schedule, e.g., Every. If the scheduler is running, the job is scheduled
+// at once.
+func (s *Scheduler) AddSchedule(name string, schedule Schedule, job Job, opts ...JobOption) error {
+	var o jobOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.lock && s.opts.locker == nil {
+		return fmt.Errorf("%w: job %s", ErrNoLocker, name)
+	}
+	e := &entry{name: name, schedule: schedule, job: job, opts: o}
+	switch o.overlap {
+	case OverlapSkip:
+		e.ticks = make(chan time.Time)
+	case OverlapQueue:
+		e.ticks = make(chan time.Time, 1)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if _, ok := s.entries[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateJob, name)
+	}
+	s.entries[name] = e
+	if s.started {
+		s.start(e)
+	}
+	return nil
+}
+
+// Run runs the jobs until Shutdown is called, or ctx is done, in which case the runs in progress are canceled. It
+// returns nil. The runs have the values of ctx, e.g., its logger.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.started || s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.started = true
+	s.ctx, s.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	for _, e := range s.entries {
+		s.start(e)
+	}
+	s.mu.Unlock()
+	defer s.cancel()
+
+	select {
+	case <-s.stop:
+	case <-ctx.Done():
+		s.cancel()
+		s.close()
+	}
+	s.loops.Wait()
+	return nil
+}
+
+// Shutdown stops scheduling the runs, and returns once the runs in progress are finished. If ctx is done first, the
+// runs are canceled, and the error of ctx is returned.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.close()
+	done := make(chan struct{})
+	go func() {
+		s.loops.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		if s.cancel != nil {
+			s.cancel()
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// close stops scheduling the runs.
+func (s *Scheduler) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// start starts the goroutines of e. It must be called with s.mu held.
+func (s *Scheduler) start(e *entry) {
+	s.loops.Add(1)
+	go s.schedule(e)
+	if e.ticks != nil {
+		s.loops.Add(1)
+		go s.runner(e)
+	}
+}
+
+// schedule dispatches the runs of e until the scheduler stops.
+func (s *Scheduler) schedule(e *entry) {
+	defer s.loops.Done()
+	next := e.schedule.Next(s.opts.clock.Now().In(s.opts.location))
+	for !next.IsZero() {
+		delay := next.Sub(s.opts.clock.Now())
+		if e.opts.jitter > 0 {
+			delay += rand.N(e.opts.jitter)
+		}
+		timer := s.opts.clock.NewTimer(delay)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+		s.dispatch(e, next)
+
+		// The runs missed, e.g., while the process was suspended, are skipped.
+		tick := next
+		if now := s.opts.clock.Now().In(s.opts.location); next.Before(now) {
+			tick = now
+		}
+		next = e.schedule.Next(tick)
+	}
+}
+
+// dispatch starts the run of e at tick, following its overlap policy.
+func (s *Scheduler) dispatch(e *entry, tick time.Time) {
+	if e.ticks == nil {
+		s.loops.Add(1)
+		go func() {
+			defer s.loops.Done()
+			s.run(e, tick)
+		}()
+		return
+	}
+	select {
+	case e.ticks <- tick:
+	default:
+		s.log().Warn(s.ctx, "Job run skipped", logger.Fields{"job": e.name, "scheduled_at": tick, "reason": "previous run not finished"})
+		s.observe(e, StatusSkipped, 0)
+	}
+}
+
+// runner runs the runs of e one after the other until the scheduler stops.
+func (s *Scheduler) runner(e *entry) {
+	defer s.loops.Done()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case tick := <-e.ticks:
+			s.run(e, tick)
+		}
+	}
+}
+
+// run runs e for tick, if it acquires its lock.
+func (s *Scheduler) run(e *entry, tick time.Time) {
+	fields := logger.Fields{"job": e.name, "scheduled_at": tick}
+	if e.opts.lock {
+		key := LockKeyPrefix + e.name + ":" + strconv.FormatInt(tick.UnixMilli(), 10)
+		acquired, err := s.opts.locker.TryLock(s.ctx, key, e.opts.lockTTL)
+		if err != nil {
+			s.log().Error(s.ctx, "Failed to lock job", err, fields)
+			s.observe(e, StatusError, 0)
+			return
+		}
+		if !acquired {
+			s.log().Debug(s.ctx, "Job run by another replica", fields)
+			s.observe(e, StatusLocked, 0)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	if e.opts.timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, e.opts.timeout)
+		defer cancelTimeout()
+	}
+
+	start := s.opts.clock.Now()
+	err := s.call(ctx, e, fields)
+	duration := s.opts.clock.Since(start)
+	fields["duration_ms"] = duration.Milliseconds()
+	status := StatusSuccess
+	switch {
+	case errors.Is(err, ErrPanic):
+		status = StatusPanic
+	case err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		status = StatusTimeout
+	case err != nil:
+		status = StatusError
+	}
+	if err != nil {
+		s.log().Error(ctx, "Job failed", err, fields)
+	} else {
+		s.log().Info(ctx, "Job finished", fields)
+	}
+	s.observe(e, status, duration)
+}
+
+// call calls the job of e, converting its panic to an error.
+func (s *Scheduler) call(ctx context.Context, e *entry, fields logger.Fields) (err error) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		if recoveredErr, ok := recovered.(error); ok {
+			err = fmt.Errorf("%w: %w", ErrPanic, recoveredErr)
+		} else {
+			err = fmt.Errorf("%w: %v", ErrPanic, recovered)
+		}
+		s.log().Error(ctx, "Job panicked", err, logger.Fields{
+			"job":                               e.name,
+			logger.DefaultPanicKey:              fmt.Sprintf("%v", recovered),
+			logger.DefaultSJsonFmtStackTraceKey: string(debug.Stack()),
+		})
+	}()
+	return e.job(ctx)
+}
+
+// observe records a run of e finished with status after d, or not started if d is zero.
+func (s *Scheduler) observe(e *entry, status string, d time.Duration) {
+	m := s.opts.metrics
+	if m == nil {
+		return
+	}
+	m.runs.Inc(e.name, status)
+	if status == StatusSuccess {
+		m.lastSuccess.Set(float64(s.opts.clock.Now().Unix()), e.name)
+	}
+	if d > 0 {
+		m.duration.Observe(d.Seconds(), e.name)
+	}
+}
+
+// log returns the logger of the scheduler, or the logger of the context of Run.
+func (s *Scheduler) log() logger.Logger {
+	if s.opts.logger != nil {
+		return s.opts.logger
+	}
+	return logger.FromContext(s.ctx)
+}
@@ -0,0 +1,341 @@
+package cron_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cron"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/metrics"
+	"github.com/kittipat1413/go-common/util/clock"
+)
+
+// interval is the interval of the jobs of the tests.
+const interval = 20 * time.Millisecond
+
+// memoryLocker is a Locker in memory, shared by the schedulers of a test like Redis by the replicas of a service.
+type memoryLocker struct {
+	mu    sync.Mutex
+	keys  map[string]bool
+	calls int
+	err   error
+}
+
+func (l *memoryLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls++
+	if l.err != nil {
+		return false, l.err
+	}
+	if l.keys[key] {
+		return false, nil
+	}
+	if l.keys == nil {
+		l.keys = make(map[string]bool)
+	}
+	l.keys[key] = true
+	return true, nil
+}
+
+// start runs scheduler until the end of the test.
+func start(t *testing.T, scheduler *cron.Scheduler) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- scheduler.Run(context.Background()) }()
+	t.Cleanup(func() {
+		require.NoError(t, scheduler.Shutdown(context.Background()))
+		require.NoError(t, <-done)
+	})
+}
+
+func TestScheduler_Run(t *testing.T) {
+	log, logs := logger.NewTestLogger()
+	scheduler := cron.New(cron.WithLogger(log))
+	var runs atomic.Int32
+	require.NoError(t, scheduler.AddSchedule("count", cron.Every(interval), func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}))
+	start(t, scheduler)
+
+	require.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+	logs.AssertLogged(t, logger.INFO, "Job finished", logger.HasField("job", "count"))
+}
+
+func TestScheduler_Clock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	scheduler := cron.New(cron.WithClock(fake), cron.WithLocation(time.UTC), cron.WithLogger(logger.NewNoopLogger()))
+	runs := make(chan time.Time, 10)
+	require.NoError(t, scheduler.Add("nightly", "0 3 * * *", func(ctx context.Context) error {
+		runs <- fake.Now()
+		return nil
+	}))
+	start(t, scheduler)
+
+	// The daily job runs when the clock reaches 03:00, without waiting for it.
+	fake.BlockUntil(1)
+	fake.Advance(2 * time.Hour)
+	select {
+	case <-runs:
+		t.Fatal("the job ran before its schedule")
+	case <-time.After(10 * time.Millisecond):
+	}
+	fake.Advance(time.Hour)
+	select {
+	case ran := <-runs:
+		assert.Equal(t, time.Date(2024, time.January, 1, 3, 0, 0, 0, time.UTC), ran)
+	case <-time.After(time.Second):
+		t.Fatal("the job did not run")
+	}
+}
+
+func TestScheduler_Add(t *testing.T) {
+	job := func(ctx context.Context) error { return nil }
+
+	t.Run("fails with an invalid spec", func(t *testing.T) {
+		assert.ErrorIs(t, cron.New().Add("job", "* * *", job), cron.ErrInvalidSpec)
+	})
+
+	t.Run("fails with a duplicate name", func(t *testing.T) {
+		scheduler := cron.New()
+		require.NoError(t, scheduler.Add("job", "@hourly", job))
+		assert.ErrorIs(t, scheduler.Add("job", "@daily", job), cron.ErrDuplicateJob)
+	})
+
+	t.Run("fails to lock without a locker", func(t *testing.T) {
+		assert.ErrorIs(t, cron.New().Add("job", "@hourly", job, cron.WithLock(0)), cron.ErrNoLocker)
+	})
+
+	t.Run("fails once the scheduler is shut down", func(t *testing.T) {
+		scheduler := cron.New()
+		require.NoError(t, scheduler.Shutdown(context.Background()))
+		assert.ErrorIs(t, scheduler.Add("job", "@hourly", job), cron.ErrClosed)
+	})
+
+	t.Run("schedules the job at once if the scheduler is running", func(t *testing.T) {
+		scheduler := cron.New()
+		start(t, scheduler)
+		ran := make(chan struct{}, 1)
+		require.NoError(t, scheduler.AddSchedule("job", cron.Every(interval), func(ctx context.Context) error {
+			select {
+			case ran <- struct{}{}:
+			default:
+			}
+			return nil
+		}))
+		<-ran
+	})
+}
+
+func TestScheduler_Overlap(t *testing.T) {
+	tests := []struct {
+		name       string
+		overlap    cron.Overlap
+		concurrent bool
+		skipped    bool
+	}{
+		{name: "skip", overlap: cron.OverlapSkip, skipped: true},
+		{name: "queue", overlap: cron.OverlapQueue, skipped: true},
+		{name: "concurrent", overlap: cron.OverlapConcurrent, concurrent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, logs := logger.NewTestLogger()
+			scheduler := cron.New(cron.WithLogger(log))
+			var runs, running, maxRunning atomic.Int32
+			require.NoError(t, scheduler.AddSchedule("slow", cron.Every(interval), func(ctx context.Context) error {
+				runs.Add(1)
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					max := maxRunning.Load()
+					if n <= max || maxRunning.CompareAndSwap(max, n) {
+						break
+					}
+				}
+				time.Sleep(3 * interval)
+				return nil
+			}, cron.WithOverlap(tt.overlap)))
+			start(t, scheduler)
+
+			require.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond)
+			assert.Equal(t, tt.concurrent, maxRunning.Load() > 1)
+			assert.Equal(t, tt.skipped, len(logs.Find(logger.WARN, "Job run skipped", logger.HasField("job", "slow"))) > 0)
+		})
+	}
+}
+
+func TestScheduler_Failures(t *testing.T) {
+	t.Run("logs the errors", func(t *testing.T) {
+		log, logs := logger.NewTestLogger()
+		scheduler := cron.New(cron.WithLogger(log))
+		require.NoError(t, scheduler.AddSchedule("failing", cron.Every(interval), func(ctx context.Context) error {
+			return errors.New("job failed")
+		}))
+		start(t, scheduler)
+
+		require.Eventually(t, func() bool { return len(logs.Find(logger.ERROR, "Job failed")) > 0 }, time.Second, time.Millisecond)
+		logs.AssertLogged(t, logger.ERROR, "Job failed", logger.HasField("job", "failing"))
+	})
+
+	t.Run("recovers the panics", func(t *testing.T) {
+		log, logs := logger.NewTestLogger()
+		scheduler := cron.New(cron.WithLogger(log))
+		var runs atomic.Int32
+		require.NoError(t, scheduler.AddSchedule("panicking", cron.Every(interval), func(ctx context.Context) error {
+			runs.Add(1)
+			panic("boom")
+		}))
+		start(t, scheduler)
+
+		require.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond)
+		entries := logs.Find(logger.ERROR, "Job panicked", logger.HasField("job", "panicking"))
+		require.NotEmpty(t, entries)
+		assert.Contains(t, entries[0].Fields[logger.DefaultSJsonFmtStackTraceKey], "cron_test.go")
+	})
+
+	t.Run("times out the runs", func(t *testing.T) {
+		scheduler := cron.New()
+		errs := make(chan error, 1)
+		require.NoError(t, scheduler.AddSchedule("blocking", cron.Every(interval), func(ctx context.Context) error {
+			<-ctx.Done()
+			select {
+			case errs <- ctx.Err():
+			default:
+			}
+			return ctx.Err()
+		}, cron.WithTimeout(time.Millisecond)))
+		start(t, scheduler)
+
+		assert.ErrorIs(t, <-errs, context.DeadlineExceeded)
+	})
+}
+
+func TestScheduler_Lock(t *testing.T) {
+	t.Run("runs every run on a single replica", func(t *testing.T) {
+		locker := &memoryLocker{}
+		var runs atomic.Int32
+		var replicas []*cron.Scheduler
+		for i := 0; i < 3; i++ {
+			scheduler := cron.New(cron.WithLocker(locker))
+			require.NoError(t, scheduler.AddSchedule("locked", cron.Every(interval), func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			}, cron.WithLock(time.Minute)))
+			start(t, scheduler)
+			replicas = append(replicas, scheduler)
+		}
+
+		require.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+		for _, scheduler := range replicas {
+			require.NoError(t, scheduler.Shutdown(context.Background()))
+		}
+		locker.mu.Lock()
+		defer locker.mu.Unlock()
+		assert.Equal(t, int(runs.Load()), len(locker.keys), "a run per lock")
+		assert.Greater(t, locker.calls, len(locker.keys), "the replicas compete for the locks")
+	})
+
+	t.Run("skips the run when the locker fails", func(t *testing.T) {
+		log, logs := logger.NewTestLogger()
+		scheduler := cron.New(cron.WithLogger(log), cron.WithLocker(&memoryLocker{err: errors.New("connection refused")}))
+		require.NoError(t, scheduler.AddSchedule("locked", cron.Every(interval), func(ctx context.Context) error {
+			t.Error("the run must be skipped")
+			return nil
+		}, cron.WithLock(0)))
+		start(t, scheduler)
+
+		require.Eventually(t, func() bool { return len(logs.Find(logger.ERROR, "Failed to lock job")) > 0 }, time.Second, time.Millisecond)
+	})
+}
+
+func TestScheduler_Shutdown(t *testing.T) {
+	t.Run("waits for the runs in progress", func(t *testing.T) {
+		scheduler := cron.New()
+		started := make(chan struct{})
+		var finished atomic.Bool
+		var once sync.Once
+		require.NoError(t, scheduler.AddSchedule("slow", cron.Every(interval), func(ctx context.Context) error {
+			once.Do(func() { close(started) })
+			time.Sleep(2 * interval)
+			finished.Store(true)
+			return nil
+		}))
+		done := make(chan error, 1)
+		go func() { done <- scheduler.Run(context.Background()) }()
+
+		<-started
+		require.NoError(t, scheduler.Shutdown(context.Background()))
+		assert.True(t, finished.Load())
+		require.NoError(t, <-done)
+	})
+
+	t.Run("cancels the runs in progress when the drain times out", func(t *testing.T) {
+		scheduler := cron.New()
+		started := make(chan struct{})
+		errs := make(chan error, 1)
+		require.NoError(t, scheduler.AddSchedule("blocking", cron.Every(interval), func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			errs <- ctx.Err()
+			return ctx.Err()
+		}))
+		done := make(chan error, 1)
+		go func() { done <- scheduler.Run(context.Background()) }()
+
+		<-started
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, scheduler.Shutdown(ctx), context.DeadlineExceeded)
+		assert.ErrorIs(t, <-errs, context.Canceled)
+		require.NoError(t, <-done)
+	})
+
+	t.Run("stops when the context of Run is done", func(t *testing.T) {
+		scheduler := cron.New()
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- scheduler.Run(ctx) }()
+		cancel()
+		require.NoError(t, <-done)
+		assert.ErrorIs(t, scheduler.Add("job", "@hourly", func(ctx context.Context) error { return nil }), cron.ErrClosed)
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m, err := cron.NewMetrics(registry)
+	require.NoError(t, err)
+
+	scheduler := cron.New(cron.WithMetrics(m))
+	var runs atomic.Int32
+	require.NoError(t, scheduler.AddSchedule("flaky", cron.Every(interval), func(ctx context.Context) error {
+		if runs.Add(1) == 1 {
+			return errors.New("job failed")
+		}
+		return nil
+	}))
+	start(t, scheduler)
+	require.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+	require.NoError(t, scheduler.Shutdown(context.Background()))
+
+	var output bytes.Buffer
+	_, err = registry.WriteTo(&output)
+	require.NoError(t, err)
+	assert.Contains(t, output.String(), `cron_runs_total{cron_job="flaky",status="error"} 1`)
+	assert.Contains(t, output.String(), `cron_runs_total{cron_job="flaky",status="success"}`)
+	assert.Contains(t, output.String(), `cron_run_seconds_count{cron_job="flaky"}`)
+	assert.Contains(t, output.String(), `cron_last_success_timestamp_seconds{cron_job="flaky"}`)
+
+	_, err = cron.NewMetrics(registry)
+	assert.NoError(t, err, "the metrics are shared")
+}
@@ -0,0 +1,57 @@
+package cron
+
+import (
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+// Values of the status label of the metrics.
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+	StatusPanic   = "panic"
+	StatusTimeout = "timeout"
+	// StatusSkipped is the status of the runs skipped because the previous run of the job is not finished.
+	StatusSkipped = "skipped"
+	// StatusLocked is the status of the runs skipped because another replica holds the lock of the job.
+	StatusLocked = "locked"
+)
+
+/*
+Metrics records the runs of the jobs in a metrics.Registry, labelled by cron_job, as job is reserved by Prometheus:
+
+	cron_runs_total{cron_job="cleanup",status="success"} 42
+	cron_run_seconds_bucket{cron_job="cleanup",le="1"} 40
+	cron_last_success_timestamp_seconds{cron_job="cleanup"} 1.7e+09
+
+Alert on the last success timestamp to detect the jobs that stopped succeeding, whatever their schedule.
+
+Example usage:
+
+	cronMetrics, err := cron.NewMetrics(metrics.Default())
+	if err != nil {
+		// Handle error
+	}
+	scheduler := cron.New(cron.WithMetrics(cronMetrics))
+*/
+type Metrics struct {
+	runs        *metrics.Counter
+	duration    *metrics.Histogram
+	lastSuccess *metrics.Gauge
+}
+
+// NewMetrics creates the metrics of the jobs in registry, or returns the ones already created.
+func NewMetrics(registry *metrics.Registry) (*Metrics, error) {
+	runs, err := registry.NewCounter("cron_runs_total", "Number of runs of a job.", "cron_job", "status")
+	if err != nil {
+		return nil, err
+	}
+	duration, err := registry.NewHistogram("cron_run_seconds", "Duration of the runs of a job in seconds.", nil, "cron_job")
+	if err != nil {
+		return nil, err
+	}
+	lastSuccess, err := registry.NewGauge("cron_last_success_timestamp_seconds", "Unix time of the last successful run of a job.", "cron_job")
+	if err != nil {
+		return nil, err
+	}
+	return &Metrics{runs: runs, duration: duration, lastSuccess: lastSuccess}, nil
+}
@@ -0,0 +1,237 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSpec is returned by Parse when the expression is not valid.
+var ErrInvalidSpec = errors.New("cron: invalid spec")
+
+// Schedule returns the times a job runs at.
+type Schedule interface {
+	// Next returns the first time the job runs after t, or the zero time if it never does.
+	Next(t time.Time) time.Time
+}
+
+// every runs a job at fixed intervals.
+type every time.Duration
+
+// Every returns a Schedule running a job every d, at the multiples of d since the zero time, e.g., every 15 minutes
+// at :00, :15, :30 and :45, so that the replicas of a service agree on the times of the runs. It panics if d is not
+// positive, like time.NewTicker.
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		panic("cron: non-positive interval for Every")
+	}
+	return every(d)
+}
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Truncate(time.Duration(e)).Add(time.Duration(e))
+}
+
+// field is the range of a field of an expression.
+type field struct {
+	name     string
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	minutes = field{name: "minute", min: 0, max: 59}
+	hours   = field{name: "hour", min: 0, max: 23}
+	days    = field{name: "day of month", min: 1, max: 31}
+	months  = field{name: "month", min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	weekdays = field{name: "day of week", min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// descriptors are the shorthands of the common expressions.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// spec is a Schedule parsed from a cron expression, with a bit set per field.
+type spec struct {
+	minute, hour, dom, month, dow uint64
+	// anyDay is true if the day of month or the day of week is *, in which case a day must match both fields. It
+	// must match either of them otherwise, like in the standard cron.
+	anyDay bool
+}
+
+// Parse parses a standard cron expression of five fields, minute, hour, day of month, month and day of week, e.g.,
+// "0,30 9-17 * * MON-FRI". The fields are lists of values, ranges and steps, e.g., "*/15" or "10-50/20"; the months
+// and days of week can be given by their three-letter English names, and Sunday is either 0 or 7. When both the day
+// of month and the day of week are set, a day matching either runs the job, like in the standard cron.
+//
+// The descriptors @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly are supported, as well as
+// "@every <duration>", e.g., "@every 1h30m", see Every.
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%w: invalid interval in %q", ErrInvalidSpec, expr)
+		}
+		return Every(d), nil
+	}
+	if descriptor, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = descriptor
+	} else if strings.HasPrefix(expr, "@") {
+		return nil, fmt.Errorf("%w: unknown descriptor %q", ErrInvalidSpec, expr)
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: %q has %d fields, expected 5", ErrInvalidSpec, expr, len(fields))
+	}
+	var s spec
+	var err error
+	for i, f := range []struct {
+		bits  *uint64
+		field field
+	}{{&s.minute, minutes}, {&s.hour, hours}, {&s.dom, days}, {&s.month, months}, {&s.dow, weekdays}} {
+		if *f.bits, err = parseField(fields[i], f.field); err != nil {
+			return nil, err
+		}
+	}
+	// Sunday is both 0 and 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.anyDay = fields[2] == "*" || fields[4] == "*"
+	return &s, nil
+}
+
+// MustParse is like Parse but panics if the expression is not valid, e.g., for the constant expressions.
+func MustParse(expr string) Schedule {
+	schedule, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+// parseField returns the bit set of the values of the comma-separated list expr of f.
+func parseField(expr string, f field) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, step := part, uint(1)
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.ParseUint(part[i+1:], 10, 8)
+			if err != nil || n == 0 {
+				return 0, fmt.Errorf("%w: invalid step in %s %q", ErrInvalidSpec, f.name, part)
+			}
+			rangeExpr, step = part[:i], uint(n)
+		}
+
+		var low, high uint
+		switch i := strings.IndexByte(rangeExpr, '-'); {
+		case rangeExpr == "*":
+			low, high = f.min, f.max
+		case i >= 0:
+			var err error
+			if low, err = parseValue(rangeExpr[:i], f); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(rangeExpr[i+1:], f); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("%w: invalid range in %s %q", ErrInvalidSpec, f.name, part)
+			}
+		default:
+			value, err := parseValue(rangeExpr, f)
+			if err != nil {
+				return 0, err
+			}
+			low, high = value, value
+			if step > 1 {
+				// "5/15" is "5-59/15".
+				high = f.max
+			}
+		}
+		for v := low; v <= high; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// parseValue returns the value, or the name of a value, of f.
+func parseValue(expr string, f field) (uint, error) {
+	if value, ok := f.names[strings.ToLower(expr)]; ok {
+		return value, nil
+	}
+	value, err := strconv.ParseUint(expr, 10, 8)
+	if err != nil || uint(value) < f.min || uint(value) > f.max {
+		return 0, fmt.Errorf("%w: invalid %s %q", ErrInvalidSpec, f.name, expr)
+	}
+	return uint(value), nil
+}
+
+// has reports whether the bit v of set is set.
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+// maxYears bounds the search of Next, e.g., for "0 0 30 2 *" which never runs.
+const maxYears = 5
+
+// Next returns the first minute after t matching the expression, in the location of t.
+func (s *spec) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxYears, 0, 0)
+
+	for t.Before(limit) {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			// The minutes are added rather than set, for the locations with daylight saving time or offsets that are
+			// not whole hours.
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			// The next minute set in this hour, if any, or the next hour.
+			if rest := s.minute >> uint(t.Minute()+1); rest != 0 {
+				t = t.Add(time.Duration(bits.TrailingZeros64(rest)+1) * time.Minute)
+			} else {
+				t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			}
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchDay reports whether the day of t matches the day of month and the day of week of the expression.
+func (s *spec) matchDay(t time.Time) bool {
+	dom, dow := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+	if s.anyDay {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,113 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cron"
+)
+
+func TestParse(t *testing.T) {
+	date := func(s string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", s)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	tests := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{expr: "* * * * *", from: "2024-05-10 10:07", want: "2024-05-10 10:08"},
+		{expr: "*/15 * * * *", from: "2024-05-10 10:07", want: "2024-05-10 10:15"},
+		{expr: "*/15 * * * *", from: "2024-05-10 10:45", want: "2024-05-10 11:00"},
+		{expr: "5/20 * * * *", from: "2024-05-10 10:26", want: "2024-05-10 10:45"},
+		{expr: "10-50/20 * * * *", from: "2024-05-10 10:31", want: "2024-05-10 10:50"},
+		{expr: "0,30 9-17 * * *", from: "2024-05-10 17:30", want: "2024-05-11 09:00"},
+		{expr: "0 3 * * *", from: "2024-05-10 03:00", want: "2024-05-11 03:00"},
+		{expr: "0 9 * * MON-FRI", from: "2024-05-10 10:00", want: "2024-05-13 09:00"},
+		{expr: "0 0 * * 7", from: "2024-05-10 10:00", want: "2024-05-12 00:00"},
+		{expr: "0 0 1 jan *", from: "2024-05-10 10:00", want: "2025-01-01 00:00"},
+		{expr: "0 0 29 2 *", from: "2023-03-01 00:00", want: "2024-02-29 00:00"},
+		{expr: "0 0 31 * *", from: "2024-04-01 00:00", want: "2024-05-31 00:00"},
+		// The day of month or the day of week.
+		{expr: "0 0 13 * FRI", from: "2024-05-01 00:00", want: "2024-05-03 00:00"},
+		{expr: "0 0 13 * FRI", from: "2024-05-10 00:00", want: "2024-05-13 00:00"},
+		{expr: "@hourly", from: "2024-05-10 10:07", want: "2024-05-10 11:00"},
+		{expr: "@daily", from: "2024-05-10 10:07", want: "2024-05-11 00:00"},
+		{expr: "@weekly", from: "2024-05-10 10:07", want: "2024-05-12 00:00"},
+		{expr: "@monthly", from: "2024-05-10 10:07", want: "2024-06-01 00:00"},
+		{expr: "@every 15m", from: "2024-05-10 10:07", want: "2024-05-10 10:15"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr+" from "+tt.from, func(t *testing.T) {
+			schedule, err := cron.Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, date(tt.want), schedule.Next(date(tt.from)))
+		})
+	}
+}
+
+func TestParse_Never(t *testing.T) {
+	schedule, err := cron.Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(time.Now()).IsZero())
+}
+
+func TestParse_Location(t *testing.T) {
+	// The offsets that are not whole hours.
+	india := time.FixedZone("IST", 5*3600+1800)
+	schedule := cron.MustParse("0 * * * *")
+	from := time.Date(2024, 5, 10, 10, 7, 0, 0, india)
+	assert.Equal(t, time.Date(2024, 5, 10, 11, 0, 0, 0, india), schedule.Next(from))
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	// 02:30 does not exist on the day the clocks go forward.
+	schedule = cron.MustParse("30 2 * * *")
+	from = time.Date(2024, 3, 10, 0, 0, 0, 0, newYork)
+	assert.Equal(t, time.Date(2024, 3, 11, 2, 30, 0, 0, newYork), schedule.Next(from))
+	// 01:30 happens twice on the day the clocks go back, and the job runs once.
+	schedule = cron.MustParse("30 1 * * *")
+	from = time.Date(2024, 11, 3, 0, 0, 0, 0, newYork)
+	first := schedule.Next(from)
+	assert.Equal(t, time.Date(2024, 11, 3, 1, 30, 0, 0, newYork), first)
+	assert.Equal(t, time.Date(2024, 11, 4, 1, 30, 0, 0, newYork), schedule.Next(first.Add(time.Hour)))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@often",
+		"@every",
+		"@every -1s",
+	} {
+		_, err := cron.Parse(expr)
+		assert.ErrorIs(t, err, cron.ErrInvalidSpec, expr)
+	}
+}
+
+func TestEvery(t *testing.T) {
+	schedule := cron.Every(15 * time.Minute)
+	from := time.Date(2024, 5, 10, 10, 7, 30, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 5, 10, 10, 15, 0, 0, time.UTC), schedule.Next(from))
+	assert.Equal(t, time.Date(2024, 5, 10, 10, 30, 0, 0, time.UTC), schedule.Next(schedule.Next(from)))
+	assert.Panics(t, func() { cron.Every(0) })
+}
@@ -0,0 +1,315 @@
+package envelope
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Format of the ciphertexts: a format version, a mode, the key reference of the mode, a random nonce, and the
+// AES-256-GCM sealed payload. The header, up to the nonce, is authenticated with the associated data.
+const (
+	formatVersion byte = 1
+	// modeKeyring ciphertexts reference the version of the Keyring key, as a big-endian uint32.
+	modeKeyring byte = 1
+	// modeKMS ciphertexts reference the KMS key ID and the encrypted data key, each prefixed by its big-endian
+	// uint16 length.
+	modeKMS byte = 2
+)
+
+var (
+	// ErrInvalidKey is returned for the keys which are not AES-256 keys, or the invalid sets of keys.
+	ErrInvalidKey = errors.New("envelope: invalid key")
+	// ErrNoKey is returned by New when neither a Keyring nor a KMS is set.
+	ErrNoKey = errors.New("envelope: no keyring or KMS")
+	// ErrMalformed is returned by Decrypt for the ciphertexts which are not produced by a Cipher.
+	ErrMalformed = errors.New("envelope: malformed ciphertext")
+	// ErrKeyNotFound is returned by Decrypt for the ciphertexts of a key missing from the Keyring, or of a KMS
+	// while no KMS is set.
+	ErrKeyNotFound = errors.New("envelope: key not found")
+	// ErrDecrypt is returned by Decrypt for the ciphertexts or the associated data which were tampered with, or
+	// encrypted with another key.
+	ErrDecrypt = errors.New("envelope: message authentication failed")
+)
+
+/*
+KMS generates the data keys encrypting the payloads, encrypted with a master key which never leaves the KMS, e.g.,
+AWS KMS or Google Cloud KMS. Wrap the client of the KMS to use it, e.g., with AWS KMS:
+
+	type awsKMS struct {
+		client *kms.Client
+	}
+
+	func (k *awsKMS) GenerateDataKey(ctx context.Context, keyID string) ([]byte, []byte, error) {
+		out, err := k.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{KeyId: &keyID, KeySpec: types.DataKeySpecAes256})
+		if err != nil {
+			return nil, nil, err
+		}
+		return out.Plaintext, out.CiphertextBlob, nil
+	}
+
+	func (k *awsKMS) Decrypt(ctx context.Context, keyID string, encrypted []byte) ([]byte, error) {
+		out, err := k.client.Decrypt(ctx, &kms.DecryptInput{KeyId: &keyID, CiphertextBlob: encrypted})
+		if err != nil {
+			return nil, err
+		}
+		return out.Plaintext, nil
+	}
+*/
+type KMS interface {
+	// GenerateDataKey returns a new AES-256 data key, in plaintext and encrypted with the master key keyID.
+	GenerateDataKey(ctx context.Context, keyID string) (plaintext, encrypted []byte, err error)
+	// Decrypt returns the plaintext of a data key encrypted with the master key keyID.
+	Decrypt(ctx context.Context, keyID string, encrypted []byte) ([]byte, error)
+}
+
+// options holds configuration options for the Cipher.
+type options struct {
+	keyring  *Keyring // keyring encrypts the payloads unless kms is set, and decrypts the keyring ciphertexts.
+	kms      KMS      // kms generates the data keys of the payloads, if set.
+	kmsKeyID string   // kmsKeyID is the master key of the data keys generated.
+}
+
+// Option specifies Cipher configuration options.
+type Option func(*options)
+
+// WithKeyring sets the keys encrypting the payloads, unless a KMS is set, and decrypting the payloads they
+// encrypted.
+func WithKeyring(keyring *Keyring) Option {
+	return func(opts *options) {
+		if keyring != nil {
+			opts.keyring = keyring
+		}
+	}
+}
+
+// WithKMS encrypts each payload with a new data key generated by kms with the master key keyID, stored encrypted
+// in the ciphertext.
+func WithKMS(kms KMS, keyID string) Option {
+	return func(opts *options) {
+		if kms != nil {
+			opts.kms = kms
+			opts.kmsKeyID = keyID
+		}
+	}
+}
+
+/*
+Cipher encrypts small payloads, e.g., the personal data of a database column, with AES-256-GCM and random nonces.
+The ciphertexts are self-describing: they reference the key encrypting them, a version of a Keyring or a data key
+encrypted by a KMS, so that the keys can be rotated, and a Cipher with both decrypts the ciphertexts of both.
+
+The associated data of Encrypt is authenticated but not encrypted, and must be passed again to Decrypt: binding the
+ciphertexts to their context, e.g., the ID of their row, prevents them from being copied to another row.
+
+Example usage:
+
+	keyring, err := envelope.NewKeyring(keys...)
+	if err != nil {
+		// Handle error
+	}
+	c, err := envelope.New(envelope.WithKeyring(keyring))
+	if err != nil {
+		// Handle error
+	}
+
+	ssn, err := c.EncryptString(ctx, user.SSN, "users.ssn:"+user.ID)
+	...
+	plain, err := c.DecryptString(ctx, row.SSN, "users.ssn:"+row.ID)
+*/
+type Cipher struct {
+	opts options
+}
+
+// New creates a Cipher. It returns ErrNoKey if neither WithKeyring nor WithKMS is set.
+func New(opts ...Option) (*Cipher, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.keyring == nil && o.kms == nil {
+		return nil, ErrNoKey
+	}
+	return &Cipher{opts: o}, nil
+}
+
+// Encrypt encrypts plaintext, authenticated with associatedData, and returns the ciphertext.
+func (c *Cipher) Encrypt(ctx context.Context, plaintext, associatedData []byte) ([]byte, error) {
+	if c.opts.kms == nil {
+		header := binary.BigEndian.AppendUint32([]byte{formatVersion, modeKeyring}, c.opts.keyring.primary)
+		return seal(c.opts.keyring.aeads[c.opts.keyring.primary], header, plaintext, associatedData)
+	}
+
+	if len(c.opts.kmsKeyID) > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: KMS key ID too long", ErrInvalidKey)
+	}
+	dataKey, encryptedKey, err := c.opts.kms.GenerateDataKey(ctx, c.opts.kmsKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: generating data key: %w", err)
+	}
+	defer clear(dataKey)
+	if len(encryptedKey) > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: encrypted data key too long", ErrInvalidKey)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: data key: %w", ErrInvalidKey, err)
+	}
+	header := []byte{formatVersion, modeKMS}
+	header = binary.BigEndian.AppendUint16(header, uint16(len(c.opts.kmsKeyID)))
+	header = append(header, c.opts.kmsKeyID...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(encryptedKey)))
+	header = append(header, encryptedKey...)
+	return seal(aead, header, plaintext, associatedData)
+}
+
+// Decrypt decrypts a ciphertext of Encrypt, authenticated with associatedData.
+func (c *Cipher) Decrypt(ctx context.Context, ciphertext, associatedData []byte) ([]byte, error) {
+	h, err := parseHeader(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	var aead cipher.AEAD
+	switch h.mode {
+	case modeKeyring:
+		if c.opts.keyring == nil || c.opts.keyring.aeads[h.version] == nil {
+			return nil, fmt.Errorf("%w: version %d", ErrKeyNotFound, h.version)
+		}
+		aead = c.opts.keyring.aeads[h.version]
+	default:
+		if c.opts.kms == nil {
+			return nil, fmt.Errorf("%w: KMS key %q", ErrKeyNotFound, h.kmsKeyID)
+		}
+		dataKey, err := c.opts.kms.Decrypt(ctx, h.kmsKeyID, h.encryptedKey)
+		if err != nil {
+			return nil, fmt.Errorf("envelope: decrypting data key: %w", err)
+		}
+		defer clear(dataKey)
+		if aead, err = newAEAD(dataKey); err != nil {
+			return nil, fmt.Errorf("%w: data key: %w", ErrInvalidKey, err)
+		}
+	}
+
+	body := ciphertext[h.size:]
+	if len(body) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, sealed := body[:aead.NonceSize()], body[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, additionalData(ciphertext[:h.size], associatedData))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// EncryptString encrypts plaintext like Encrypt, and returns the ciphertext encoded in base64url, e.g., for a
+// text column.
+func (c *Cipher) EncryptString(ctx context.Context, plaintext, associatedData string) (string, error) {
+	ciphertext, err := c.Encrypt(ctx, []byte(plaintext), []byte(associatedData))
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptString decrypts a ciphertext of EncryptString.
+func (c *Cipher) DecryptString(ctx context.Context, ciphertext, associatedData string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	plaintext, err := c.Decrypt(ctx, b, []byte(associatedData))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsReencrypt reports whether ciphertext was not encrypted with the current key of c: the primary key of the
+// Keyring, or the master key of the KMS, so that the payloads encrypted with the previous keys can be encrypted
+// again after a rotation.
+func (c *Cipher) NeedsReencrypt(ciphertext []byte) bool {
+	h, err := parseHeader(ciphertext)
+	if err != nil {
+		return false
+	}
+	if c.opts.kms != nil {
+		return h.mode != modeKMS || h.kmsKeyID != c.opts.kmsKeyID
+	}
+	return h.mode != modeKeyring || h.version != c.opts.keyring.primary
+}
+
+// header is the parsed header of a ciphertext.
+type header struct {
+	mode         byte
+	version      uint32 // version is the Keyring key version of the modeKeyring ciphertexts.
+	kmsKeyID     string // kmsKeyID is the KMS master key of the modeKMS ciphertexts.
+	encryptedKey []byte // encryptedKey is the encrypted data key of the modeKMS ciphertexts.
+	size         int    // size is the length of the header.
+}
+
+// parseHeader parses the header of ciphertext.
+func parseHeader(ciphertext []byte) (header, error) {
+	if len(ciphertext) < 2 || ciphertext[0] != formatVersion {
+		return header{}, ErrMalformed
+	}
+	h := header{mode: ciphertext[1]}
+	rest := ciphertext[2:]
+	switch h.mode {
+	case modeKeyring:
+		if len(rest) < 4 {
+			return header{}, ErrMalformed
+		}
+		h.version = binary.BigEndian.Uint32(rest)
+		h.size = 6
+	case modeKMS:
+		keyID, rest, ok := readLengthPrefixed(rest)
+		if !ok {
+			return header{}, ErrMalformed
+		}
+		encryptedKey, rest, ok := readLengthPrefixed(rest)
+		if !ok {
+			return header{}, ErrMalformed
+		}
+		h.kmsKeyID, h.encryptedKey = string(keyID), encryptedKey
+		h.size = len(ciphertext) - len(rest)
+	default:
+		return header{}, ErrMalformed
+	}
+	return h, nil
+}
+
+// readLengthPrefixed reads a value prefixed by its big-endian uint16 length from b, and returns the rest of b.
+func readLengthPrefixed(b []byte) ([]byte, []byte, bool) {
+	if len(b) < 2 {
+		return nil, nil, false
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return nil, nil, false
+	}
+	return b[2 : 2+n], b[2+n:], true
+}
+
+// seal encrypts plaintext with aead and a random nonce, and returns the ciphertext of header.
+func seal(aead cipher.AEAD, header, plaintext, associatedData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("envelope: generating nonce: %w", err)
+	}
+	ciphertext := append(header[:len(header):len(header)], nonce...)
+	return aead.Seal(ciphertext, nonce, plaintext, additionalData(header, associatedData)), nil
+}
+
+// additionalData returns the data authenticated with the payload: the header of the ciphertext and the associated
+// data, prefixed by the length of the header.
+func additionalData(header, associatedData []byte) []byte {
+	data := binary.BigEndian.AppendUint32(nil, uint32(len(header)))
+	data = append(data, header...)
+	return append(data, associatedData...)
+}
@@ -0,0 +1,183 @@
+package envelope_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/crypto/envelope"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKMS wraps the data keys with the AES-GCM master keys it holds, by key ID.
+type fakeKMS struct {
+	masterKeys map[string][]byte
+	generated  int
+}
+
+func newFakeKMS(t *testing.T, keyIDs ...string) *fakeKMS {
+	k := &fakeKMS{masterKeys: map[string][]byte{}}
+	for _, keyID := range keyIDs {
+		key := make([]byte, 32)
+		_, err := rand.Read(key)
+		require.NoError(t, err)
+		k.masterKeys[keyID] = key
+	}
+	return k
+}
+
+func (k *fakeKMS) aead(keyID string) (cipher.AEAD, error) {
+	masterKey, found := k.masterKeys[keyID]
+	if !found {
+		return nil, errors.New("key not found")
+	}
+	block, _ := aes.NewCipher(masterKey)
+	return cipher.NewGCM(block)
+}
+
+func (k *fakeKMS) GenerateDataKey(_ context.Context, keyID string) ([]byte, []byte, error) {
+	aead, err := k.aead(keyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	k.generated++
+	dataKey := make([]byte, 32)
+	_, _ = rand.Read(dataKey)
+	nonce := make([]byte, aead.NonceSize())
+	_, _ = rand.Read(nonce)
+	return dataKey, aead.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+func (k *fakeKMS) Decrypt(_ context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	aead, err := k.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, encrypted[:aead.NonceSize()], encrypted[aead.NonceSize():], nil)
+}
+
+func newKeyring(t *testing.T, versions ...uint32) *envelope.Keyring {
+	t.Helper()
+	var keys []envelope.Key
+	for _, version := range versions {
+		key, err := envelope.NewKey(version)
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	keyring, err := envelope.NewKeyring(keys...)
+	require.NoError(t, err)
+	return keyring
+}
+
+func TestCipher(t *testing.T) {
+	tests := []struct {
+		name    string
+		options func(t *testing.T) []envelope.Option
+	}{
+		{
+			name: "keyring",
+			options: func(t *testing.T) []envelope.Option {
+				return []envelope.Option{envelope.WithKeyring(newKeyring(t, 1))}
+			},
+		},
+		{
+			name: "KMS",
+			options: func(t *testing.T) []envelope.Option {
+				return []envelope.Option{envelope.WithKMS(newFakeKMS(t, "master"), "master")}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := envelope.New(tt.options(t)...)
+			require.NoError(t, err)
+			ctx := context.Background()
+
+			ciphertext, err := c.Encrypt(ctx, []byte("123-45-6789"), []byte("user-1"))
+			require.NoError(t, err)
+			assert.False(t, bytes.Contains(ciphertext, []byte("123-45-6789")))
+			other, err := c.Encrypt(ctx, []byte("123-45-6789"), []byte("user-1"))
+			require.NoError(t, err)
+			assert.NotEqual(t, ciphertext, other, "the nonces are random")
+
+			plaintext, err := c.Decrypt(ctx, ciphertext, []byte("user-1"))
+			require.NoError(t, err)
+			assert.Equal(t, "123-45-6789", string(plaintext))
+
+			_, err = c.Decrypt(ctx, ciphertext, []byte("user-2"))
+			assert.ErrorIs(t, err, envelope.ErrDecrypt, "the associated data is authenticated")
+
+			tampered := bytes.Clone(ciphertext)
+			tampered[len(tampered)-1] ^= 1
+			_, err = c.Decrypt(ctx, tampered, []byte("user-1"))
+			assert.ErrorIs(t, err, envelope.ErrDecrypt)
+
+			_, err = c.Decrypt(ctx, ciphertext[:len(ciphertext)-30], []byte("user-1"))
+			assert.Error(t, err)
+			_, err = c.Decrypt(ctx, []byte("not a ciphertext"), nil)
+			assert.ErrorIs(t, err, envelope.ErrMalformed)
+
+			encoded, err := c.EncryptString(ctx, "4111 1111 1111 1111", "")
+			require.NoError(t, err)
+			decoded, err := c.DecryptString(ctx, encoded, "")
+			require.NoError(t, err)
+			assert.Equal(t, "4111 1111 1111 1111", decoded)
+		})
+	}
+}
+
+func TestCipher_Rotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey, err := envelope.NewKey(1)
+	require.NoError(t, err)
+	newKey, err := envelope.NewKey(2)
+	require.NoError(t, err)
+
+	oldKeyring, err := envelope.NewKeyring(oldKey)
+	require.NoError(t, err)
+	oldCipher, err := envelope.New(envelope.WithKeyring(oldKeyring))
+	require.NoError(t, err)
+	ciphertext, err := oldCipher.Encrypt(ctx, []byte("secret"), nil)
+	require.NoError(t, err)
+
+	keyring, err := envelope.NewKeyring(newKey, oldKey)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), keyring.Primary())
+	c, err := envelope.New(envelope.WithKeyring(keyring))
+	require.NoError(t, err)
+
+	plaintext, err := c.Decrypt(ctx, ciphertext, nil)
+	require.NoError(t, err, "the previous keys decrypt their ciphertexts")
+	assert.Equal(t, "secret", string(plaintext))
+	assert.True(t, c.NeedsReencrypt(ciphertext))
+
+	reencrypted, err := c.Encrypt(ctx, plaintext, nil)
+	require.NoError(t, err)
+	assert.False(t, c.NeedsReencrypt(reencrypted))
+	_, err = oldCipher.Decrypt(ctx, reencrypted, nil)
+	assert.ErrorIs(t, err, envelope.ErrKeyNotFound)
+
+	// A Cipher with both a keyring and a KMS encrypts with the KMS, and decrypts both.
+	kms := newFakeKMS(t, "master")
+	migrating, err := envelope.New(envelope.WithKeyring(keyring), envelope.WithKMS(kms, "master"))
+	require.NoError(t, err)
+	assert.True(t, migrating.NeedsReencrypt(reencrypted))
+	plaintext, err = migrating.Decrypt(ctx, reencrypted, nil)
+	require.NoError(t, err)
+	kmsCiphertext, err := migrating.Encrypt(ctx, plaintext, nil)
+	require.NoError(t, err)
+	assert.False(t, migrating.NeedsReencrypt(kmsCiphertext))
+	assert.Equal(t, 1, kms.generated, "each payload is encrypted with a new data key")
+	_, err = c.Decrypt(ctx, kmsCiphertext, nil)
+	assert.ErrorIs(t, err, envelope.ErrKeyNotFound)
+}
+
+func TestNew_NoKey(t *testing.T) {
+	_, err := envelope.New()
+	assert.ErrorIs(t, err, envelope.ErrNoKey)
+}
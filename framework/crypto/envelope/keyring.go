@@ -0,0 +1,134 @@
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeySize is the size of the keys, in bytes, for AES-256.
+const KeySize = 32
+
+// Key is a versioned key of a Keyring.
+type Key struct {
+	// Version identifies the key in the ciphertexts it encrypts. The key of the highest version encrypts the new
+	// payloads.
+	Version uint32
+	// Secret is the AES-256 key, of KeySize bytes.
+	Secret []byte
+}
+
+// NewKey returns a Key of version with a random secret.
+func NewKey(version uint32) (Key, error) {
+	secret := make([]byte, KeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, fmt.Errorf("envelope: generating key: %w", err)
+	}
+	return Key{Version: version, Secret: secret}, nil
+}
+
+/*
+ParseKeys parses keys formatted as comma-separated "version:base64-secret" pairs, e.g., from an environment
+variable or a secret: "1:<base64>,2:<base64>".
+
+Example usage:
+
+	keys, err := envelope.ParseKeys(os.Getenv("ENCRYPTION_KEYS"))
+	if err != nil {
+		// Handle error
+	}
+	keyring, err := envelope.NewKeyring(keys...)
+*/
+func ParseKeys(s string) ([]Key, error) {
+	var keys []Key
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		version, secret, found := strings.Cut(pair, ":")
+		if !found {
+			return nil, fmt.Errorf("%w: missing version", ErrInvalidKey)
+		}
+		v, err := strconv.ParseUint(version, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: version %q", ErrInvalidKey, version)
+		}
+		b, err := base64.StdEncoding.DecodeString(secret)
+		if err != nil {
+			return nil, fmt.Errorf("%w: version %d: %w", ErrInvalidKey, v, err)
+		}
+		keys = append(keys, Key{Version: uint32(v), Secret: b})
+	}
+	return keys, nil
+}
+
+/*
+Keyring holds the versioned keys of a Cipher. The key of the highest version, the primary key, encrypts the new
+payloads, and the others decrypt the payloads they encrypted, so that the keys can be rotated by adding a key of a
+higher version, and removed once no payload is encrypted with them, see Cipher.KeyVersion.
+
+Example usage:
+
+	keyring, err := envelope.NewKeyring(
+		envelope.Key{Version: 1, Secret: oldSecret},
+		envelope.Key{Version: 2, Secret: newSecret}, // primary
+	)
+*/
+type Keyring struct {
+	primary uint32
+	aeads   map[uint32]cipher.AEAD
+}
+
+// NewKeyring creates a Keyring of keys. It returns ErrInvalidKey if there are no keys, if a key is not of KeySize
+// bytes, or if two keys have the same version.
+func NewKeyring(keys ...Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no keys", ErrInvalidKey)
+	}
+	k := &Keyring{aeads: make(map[uint32]cipher.AEAD, len(keys))}
+	for _, key := range keys {
+		if _, found := k.aeads[key.Version]; found {
+			return nil, fmt.Errorf("%w: duplicate version %d", ErrInvalidKey, key.Version)
+		}
+		aead, err := newAEAD(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("%w: version %d: %w", ErrInvalidKey, key.Version, err)
+		}
+		k.aeads[key.Version] = aead
+		k.primary = max(k.primary, key.Version)
+	}
+	return k, nil
+}
+
+// Primary returns the version of the primary key.
+func (k *Keyring) Primary() uint32 {
+	return k.primary
+}
+
+// Versions returns the versions of the keys, in ascending order.
+func (k *Keyring) Versions() []uint32 {
+	versions := make([]uint32, 0, len(k.aeads))
+	for version := range k.aeads {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+// newAEAD returns the AES-256-GCM AEAD of secret.
+func newAEAD(secret []byte) (cipher.AEAD, error) {
+	if len(secret) != KeySize {
+		return nil, fmt.Errorf("key of %d bytes instead of %d", len(secret), KeySize)
+	}
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,46 @@
+package envelope_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/crypto/envelope"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKeyring(t *testing.T) {
+	key1, err := envelope.NewKey(1)
+	require.NoError(t, err)
+	key3, err := envelope.NewKey(3)
+	require.NoError(t, err)
+
+	keyring, err := envelope.NewKeyring(key3, key1)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), keyring.Primary(), "the key of the highest version is the primary key")
+	assert.Equal(t, []uint32{1, 3}, keyring.Versions())
+
+	_, err = envelope.NewKeyring()
+	assert.ErrorIs(t, err, envelope.ErrInvalidKey)
+	_, err = envelope.NewKeyring(key1, key1)
+	assert.ErrorIs(t, err, envelope.ErrInvalidKey)
+	_, err = envelope.NewKeyring(envelope.Key{Version: 1, Secret: []byte("too short")})
+	assert.ErrorIs(t, err, envelope.ErrInvalidKey)
+}
+
+func TestParseKeys(t *testing.T) {
+	secret1 := strings.Repeat("a", envelope.KeySize)
+	secret2 := strings.Repeat("b", envelope.KeySize)
+	keys, err := envelope.ParseKeys("1:" + base64.StdEncoding.EncodeToString([]byte(secret1)) + ", 2:" + base64.StdEncoding.EncodeToString([]byte(secret2)))
+	require.NoError(t, err)
+	assert.Equal(t, []envelope.Key{
+		{Version: 1, Secret: []byte(secret1)},
+		{Version: 2, Secret: []byte(secret2)},
+	}, keys)
+
+	for _, invalid := range []string{"no-version", "x:YWJj", "1:not base64!"} {
+		_, err := envelope.ParseKeys(invalid)
+		assert.ErrorIs(t, err, envelope.ErrInvalidKey, invalid)
+	}
+}
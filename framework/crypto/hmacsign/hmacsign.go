@@ -0,0 +1,159 @@
+package hmacsign
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+)
+
+const (
+	// SignatureHeader carries the signatures of the requests signed by a Signer: "t=<unix seconds>,v1=<hex>".
+	SignatureHeader = "X-Signature"
+	// DefaultTolerance is the maximum age of the timestamps of the signatures verified, unless set with
+	// WithTolerance, so that the captured requests cannot be replayed later.
+	DefaultTolerance = 5 * time.Minute
+	// DefaultMaxBodySize is the maximum size of the bodies verified, unless set with WithMaxBodySize.
+	DefaultMaxBodySize = 1 << 20
+)
+
+// Codes of the errors returned by Verify and the Middleware.
+const (
+	CodeMissingSignature = "missing_signature"
+	CodeInvalidSignature = "invalid_signature"
+	CodeExpiredSignature = "expired_signature"
+	CodeBodyTooLarge     = "body_too_large"
+)
+
+var (
+	// ErrMissingSignature is returned for the requests without signature, with the 401 status code.
+	ErrMissingSignature = errors.NewCodedError(errors.KindUnauthenticated, CodeMissingSignature, "missing signature")
+	// ErrInvalidSignature is returned for the requests whose signature is malformed or does not match any secret,
+	// with the 401 status code.
+	ErrInvalidSignature = errors.NewCodedError(errors.KindUnauthenticated, CodeInvalidSignature, "invalid signature")
+	// ErrExpiredSignature is returned for the requests whose signature timestamp is outside of the tolerance, e.g.,
+	// replayed requests, with the 401 status code.
+	ErrExpiredSignature = errors.NewCodedError(errors.KindUnauthenticated, CodeExpiredSignature, "signature timestamp is outside of the tolerance")
+	// ErrBodyTooLarge is returned for the requests whose body is larger than the maximum body size, with the 400
+	// status code.
+	ErrBodyTooLarge = errors.InvalidArgument(CodeBodyTooLarge, "request body is too large")
+)
+
+// options holds configuration options for the Signer and the Verifier.
+type options struct {
+	secrets     [][]byte      // secrets are the additional secrets verifying the signatures.
+	tolerance   time.Duration // tolerance is the maximum age of the timestamps verified.
+	maxBodySize int64         // maxBodySize is the maximum size of the bodies signed or verified.
+	handler     func(w http.ResponseWriter, r *http.Request, err error)
+	now         func() time.Time
+}
+
+// Option specifies Signer and Verifier configuration options.
+type Option func(*options)
+
+// WithAdditionalSecrets sets the secrets verifying the signatures in addition to the secret of the Verifier, e.g.,
+// the previous secret while the senders switch to a new one.
+func WithAdditionalSecrets(secrets ...[]byte) Option {
+	return func(opts *options) {
+		opts.secrets = append(opts.secrets, secrets...)
+	}
+}
+
+// WithTolerance sets the maximum difference between the timestamps of the signatures verified and the current
+// time, in both directions for the clocks of the senders. It defaults to DefaultTolerance.
+func WithTolerance(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.tolerance = d
+		}
+	}
+}
+
+// WithMaxBodySize sets the maximum size of the bodies of the requests signed or verified, read in memory. It
+// defaults to DefaultMaxBodySize.
+func WithMaxBodySize(size int64) Option {
+	return func(opts *options) {
+		if size > 0 {
+			opts.maxBodySize = size
+		}
+	}
+}
+
+// WithProblemWriter writes the responses of the requests rejected by the Middleware with problems. It defaults to
+// an errors.ProblemWriter without options.
+func WithProblemWriter(problems *errors.ProblemWriter) Option {
+	return func(opts *options) {
+		if problems != nil {
+			opts.handler = problems.Write
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		tolerance:   DefaultTolerance,
+		maxBodySize: DefaultMaxBodySize,
+		handler:     errors.NewProblemWriter().Write,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// computeHMAC returns the HMAC-SHA256 of message with secret.
+func computeHMAC(secret, message []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// requestMessage returns the message signed for a request: the timestamp, the method, the path with the query, and
+// the body, separated by newlines.
+func requestMessage(timestamp int64, method, uri string, body []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(strconv.FormatInt(timestamp, 10))
+	b.WriteByte('\n')
+	b.WriteString(strings.ToUpper(method))
+	b.WriteByte('\n')
+	b.WriteString(uri)
+	b.WriteByte('\n')
+	b.Write(body)
+	return b.Bytes()
+}
+
+// readBody reads the body of r, at most maxBodySize bytes, and replaces it so that it can be read again.
+func readBody(r io.ReadCloser, maxBodySize int64) ([]byte, io.ReadCloser, error) {
+	if r == nil || r == http.NoBody {
+		return nil, r, nil
+	}
+	defer r.Close()
+	body, err := io.ReadAll(io.LimitReader(r, maxBodySize+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("hmacsign: reading body: %w", err)
+	}
+	if int64(len(body)) > maxBodySize {
+		return nil, nil, ErrBodyTooLarge
+	}
+	return body, io.NopCloser(bytes.NewReader(body)), nil
+}
+
+// hexSignatures decodes the hex-encoded signatures, skipping the malformed ones.
+func hexSignatures(values ...string) [][]byte {
+	var signatures [][]byte
+	for _, value := range values {
+		if signature, err := hex.DecodeString(value); err == nil {
+			signatures = append(signatures, signature)
+		}
+	}
+	return signatures
+}
@@ -0,0 +1,140 @@
+package hmacsign_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/crypto/hmacsign"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("whsec_test")
+
+func hmacHex(secret []byte, message string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSigner_Transport(t *testing.T) {
+	var received string
+	verifier := hmacsign.NewVerifier(hmacsign.SchemeRequest, secret)
+	server := httptest.NewServer(verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+	})))
+	t.Cleanup(server.Close)
+
+	client := &http.Client{Transport: hmacsign.NewSigner(secret).Transport(http.DefaultTransport)}
+	resp, err := client.Post(server.URL+"/orders?dry_run=true", "application/json", strings.NewReader(`{"amount":42}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"amount":42}`, received, "the body is readable once verified")
+
+	other := &http.Client{Transport: hmacsign.NewSigner([]byte("other secret")).Transport(http.DefaultTransport)}
+	resp, err = other.Get(server.URL + "/orders")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/orders")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestVerifier_Request(t *testing.T) {
+	signer := hmacsign.NewSigner(secret)
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/orders?id=1", strings.NewReader(body))
+		require.NoError(t, signer.Sign(req))
+		return req
+	}
+	verifier := hmacsign.NewVerifier(hmacsign.SchemeRequest, secret)
+
+	body, err := verifier.Verify(newRequest("payload"))
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(body))
+
+	req := newRequest("payload")
+	req.Body = io.NopCloser(strings.NewReader("tampered"))
+	_, err = verifier.Verify(req)
+	assert.ErrorIs(t, err, hmacsign.ErrInvalidSignature, "the body is signed")
+
+	req = newRequest("payload")
+	req.Method = http.MethodDelete
+	_, err = verifier.Verify(req)
+	assert.ErrorIs(t, err, hmacsign.ErrInvalidSignature, "the method is signed")
+
+	req = newRequest("payload")
+	req.URL.RawQuery = "id=2"
+	_, err = verifier.Verify(req)
+	assert.ErrorIs(t, err, hmacsign.ErrInvalidSignature, "the query is signed")
+
+	// A replayed request is rejected once outside of the tolerance.
+	old := time.Now().Add(-10 * time.Minute).Unix()
+	req = httptest.NewRequest(http.MethodPost, "/orders?id=1", strings.NewReader("payload"))
+	req.Header.Set(hmacsign.SignatureHeader, fmt.Sprintf("t=%d,v1=%s", old, hmacHex(secret, strconv.FormatInt(old, 10)+"\nPOST\n/orders?id=1\npayload")))
+	_, err = verifier.Verify(req)
+	assert.ErrorIs(t, err, hmacsign.ErrExpiredSignature)
+	req.Body = io.NopCloser(strings.NewReader("payload"))
+	_, err = hmacsign.NewVerifier(hmacsign.SchemeRequest, secret, hmacsign.WithTolerance(time.Hour)).Verify(req)
+	assert.NoError(t, err)
+
+	_, err = hmacsign.NewVerifier(hmacsign.SchemeRequest, secret, hmacsign.WithMaxBodySize(3)).Verify(newRequest("payload"))
+	assert.ErrorIs(t, err, hmacsign.ErrBodyTooLarge)
+}
+
+func TestVerifier_Stripe(t *testing.T) {
+	body := `{"type":"charge.succeeded"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	newRequest := func(header string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", strings.NewReader(body))
+		req.Header.Set("Stripe-Signature", header)
+		return req
+	}
+	previous := []byte("whsec_previous")
+	verifier := hmacsign.NewVerifier(hmacsign.SchemeStripe, secret, hmacsign.WithAdditionalSecrets(previous))
+
+	_, err := verifier.Verify(newRequest("t=" + now + ",v1=" + hmacHex(secret, now+"."+body)))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(newRequest("t=" + now + ",v1=deadbeef,v1=" + hmacHex(secret, now+"."+body) + ",v0=ignored"))
+	assert.NoError(t, err, "any of the signatures may match")
+	_, err = verifier.Verify(newRequest("t=" + now + ",v1=" + hmacHex(previous, now+"."+body)))
+	assert.NoError(t, err, "the additional secrets are accepted")
+
+	_, err = verifier.Verify(newRequest("t=" + now + ",v1=" + hmacHex([]byte("other"), now+"."+body)))
+	assert.ErrorIs(t, err, hmacsign.ErrInvalidSignature)
+	_, err = verifier.Verify(newRequest("v1=" + hmacHex(secret, now+"."+body)))
+	assert.ErrorIs(t, err, hmacsign.ErrInvalidSignature)
+	_, err = verifier.Verify(newRequest(""))
+	assert.ErrorIs(t, err, hmacsign.ErrMissingSignature)
+}
+
+func TestVerifier_GitHub(t *testing.T) {
+	body := `{"action":"opened"}`
+	newRequest := func(header string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/github", strings.NewReader(body))
+		req.Header.Set("X-Hub-Signature-256", header)
+		return req
+	}
+	verifier := hmacsign.NewVerifier(hmacsign.SchemeGitHub, secret)
+
+	_, err := verifier.Verify(newRequest("sha256=" + hmacHex(secret, body)))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(newRequest("sha1=" + hmacHex(secret, body)))
+	assert.ErrorIs(t, err, hmacsign.ErrInvalidSignature)
+	_, err = verifier.Verify(newRequest("sha256=" + hmacHex(secret, body+" ")))
+	assert.ErrorIs(t, err, hmacsign.ErrInvalidSignature)
+}
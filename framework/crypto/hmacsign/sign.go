@@ -0,0 +1,61 @@
+package hmacsign
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+)
+
+/*
+Signer signs the outbound requests with a shared secret: an HMAC-SHA256 over the timestamp, the method, the path
+with the query, and the body of the request, set in the SignatureHeader as "t=<unix seconds>,v1=<hex>". The
+requests are verified by a Verifier with SchemeRequest.
+
+Example usage:
+
+	signer := hmacsign.NewSigner(secret)
+	client := httpclient.New(httpclient.WithTransport(signer.Transport(http.DefaultTransport)))
+*/
+type Signer struct {
+	secret []byte
+	opts   *options
+}
+
+// NewSigner creates a Signer of requests with secret. Only the WithMaxBodySize option applies.
+func NewSigner(secret []byte, opts ...Option) *Signer {
+	return &Signer{secret: secret, opts: newOptions(opts)}
+}
+
+// Sign sets the signature of req in its SignatureHeader. The body of req is read, and replaced so that it can be
+// sent. It returns ErrBodyTooLarge for the bodies larger than the maximum body size.
+func (s *Signer) Sign(req *http.Request) error {
+	body, replaced, err := readBody(req.Body, s.opts.maxBodySize)
+	if err != nil {
+		return err
+	}
+	req.Body = replaced
+	timestamp := s.opts.now().Unix()
+	signature := computeHMAC(s.secret, requestMessage(timestamp, req.Method, req.URL.RequestURI(), body))
+	req.Header.Set(SignatureHeader, fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(signature)))
+	return nil
+}
+
+// Transport returns a transport signing the requests before sending them with next, e.g., for httpclient.New.
+func (s *Signer) Transport(next http.RoundTripper) http.RoundTripper {
+	return &signingTransport{next: next, signer: s}
+}
+
+// signingTransport signs the requests.
+type signingTransport struct {
+	next   http.RoundTripper
+	signer *Signer
+}
+
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request.
+	req = req.Clone(req.Context())
+	if err := t.signer.Sign(req); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
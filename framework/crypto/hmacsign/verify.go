@@ -0,0 +1,178 @@
+package hmacsign
+
+import (
+	"crypto/hmac"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signed is the signed message of a request, extracted by a Scheme.
+type Signed struct {
+	// Message is the message signed, e.g., the body of the request.
+	Message []byte
+	// Signatures are the HMAC-SHA256 signatures of the request, valid if any of them matches.
+	Signatures [][]byte
+	// Timestamp is the time of the signature, checked against the replay window, or the zero time if the scheme
+	// has none.
+	Timestamp time.Time
+}
+
+// Scheme extracts the signed message and the signatures of the requests of a sender.
+type Scheme interface {
+	// Extract returns the signed message of r, whose body is body. It returns ErrMissingSignature if r has no
+	// signature, and ErrInvalidSignature if it is malformed.
+	Extract(r *http.Request, body []byte) (Signed, error)
+}
+
+var (
+	// SchemeRequest verifies the requests of a Signer.
+	SchemeRequest Scheme = requestScheme{}
+	// SchemeStripe verifies the webhooks of Stripe: the Stripe-Signature header holds "t=<unix seconds>,v1=<hex>",
+	// the HMAC-SHA256 of the timestamp and the body separated by a dot.
+	SchemeStripe Scheme = stripeScheme{}
+	// SchemeGitHub verifies the webhooks of GitHub: the X-Hub-Signature-256 header holds "sha256=<hex>", the
+	// HMAC-SHA256 of the body. The scheme has no timestamp, so that the replay window is not enforced: deduplicate
+	// the deliveries with their X-GitHub-Delivery header.
+	SchemeGitHub Scheme = githubScheme{}
+)
+
+// requestScheme is the Scheme of the Signer.
+type requestScheme struct{}
+
+func (requestScheme) Extract(r *http.Request, body []byte) (Signed, error) {
+	timestamp, signatures, err := parseTimestamped(r.Header.Get(SignatureHeader))
+	if err != nil {
+		return Signed{}, err
+	}
+	return Signed{
+		Message:    requestMessage(timestamp, r.Method, r.URL.RequestURI(), body),
+		Signatures: signatures,
+		Timestamp:  time.Unix(timestamp, 0),
+	}, nil
+}
+
+// stripeScheme is the Scheme of the webhooks of Stripe.
+type stripeScheme struct{}
+
+func (stripeScheme) Extract(r *http.Request, body []byte) (Signed, error) {
+	timestamp, signatures, err := parseTimestamped(r.Header.Get("Stripe-Signature"))
+	if err != nil {
+		return Signed{}, err
+	}
+	message := append([]byte(strconv.FormatInt(timestamp, 10)+"."), body...)
+	return Signed{Message: message, Signatures: signatures, Timestamp: time.Unix(timestamp, 0)}, nil
+}
+
+// githubScheme is the Scheme of the webhooks of GitHub.
+type githubScheme struct{}
+
+func (githubScheme) Extract(r *http.Request, body []byte) (Signed, error) {
+	header := r.Header.Get("X-Hub-Signature-256")
+	if header == "" {
+		return Signed{}, ErrMissingSignature
+	}
+	signature, found := strings.CutPrefix(header, "sha256=")
+	if !found {
+		return Signed{}, ErrInvalidSignature
+	}
+	return Signed{Message: body, Signatures: hexSignatures(signature)}, nil
+}
+
+// parseTimestamped parses a "t=<unix seconds>,v1=<hex>" signature header, with any number of v1 signatures.
+func parseTimestamped(header string) (int64, [][]byte, error) {
+	if header == "" {
+		return 0, nil, ErrMissingSignature
+	}
+	var timestamp int64
+	var values []string
+	for _, item := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(item), "=")
+		switch key {
+		case "t":
+			t, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return 0, nil, ErrInvalidSignature
+			}
+			timestamp = t
+		case "v1":
+			values = append(values, value)
+		}
+	}
+	if timestamp == 0 || len(values) == 0 {
+		return 0, nil, ErrInvalidSignature
+	}
+	return timestamp, hexSignatures(values...), nil
+}
+
+/*
+Verifier verifies the signatures of the inbound requests, e.g., the webhooks of a provider, with a Scheme and a
+shared secret: the signatures are compared in constant time, and the timestamps of the schemes having one must be
+within the replay window.
+
+Example usage:
+
+	verifier := hmacsign.NewVerifier(hmacsign.SchemeStripe, []byte(os.Getenv("STRIPE_WEBHOOK_SECRET")))
+	mux.Handle("/webhooks/stripe", verifier.Middleware(stripeHandler))
+
+	func (h *stripeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body) // The body verified
+		...
+	}
+*/
+type Verifier struct {
+	scheme  Scheme
+	secrets [][]byte
+	opts    *options
+}
+
+// NewVerifier creates a Verifier of the requests signed with secret, or one of the secrets of
+// WithAdditionalSecrets, following scheme.
+func NewVerifier(scheme Scheme, secret []byte, opts ...Option) *Verifier {
+	o := newOptions(opts)
+	return &Verifier{scheme: scheme, secrets: append([][]byte{secret}, o.secrets...), opts: o}
+}
+
+// Verify verifies the signature of r, and returns its body. The body of r is read, and replaced so that it can be
+// read again. It returns ErrMissingSignature, ErrInvalidSignature, ErrExpiredSignature or ErrBodyTooLarge for the
+// requests rejected.
+func (v *Verifier) Verify(r *http.Request) ([]byte, error) {
+	body, replaced, err := readBody(r.Body, v.opts.maxBodySize)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = replaced
+
+	signed, err := v.scheme.Extract(r, body)
+	if err != nil {
+		return nil, err
+	}
+	if !signed.Timestamp.IsZero() {
+		age := v.opts.now().Sub(signed.Timestamp)
+		if age > v.opts.tolerance || age < -v.opts.tolerance {
+			return nil, ErrExpiredSignature
+		}
+	}
+	for _, secret := range v.secrets {
+		expected := computeHMAC(secret, signed.Message)
+		for _, signature := range signed.Signatures {
+			if hmac.Equal(expected, signature) {
+				return body, nil
+			}
+		}
+	}
+	return nil, ErrInvalidSignature
+}
+
+// Middleware rejects the requests whose signature is not verified, with the errors of Verify written by the
+// problem writer.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := v.Verify(r); err != nil {
+			v.opts.handler(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
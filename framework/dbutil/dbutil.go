@@ -0,0 +1,173 @@
+package dbutil
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	// ErrUnmappedColumn is returned when a column of the result of a query has no field in the struct it is
+	// scanned into.
+	ErrUnmappedColumn = stderrors.New("dbutil: no field for column")
+	// ErrColumnCount is returned when a result scanned into a type other than a struct has more than one column.
+	ErrColumnCount = stderrors.New("dbutil: single column expected")
+)
+
+// Querier runs queries on a database. It is implemented by *sql.DB, *sql.Tx and *sql.Conn, so that the helpers run
+// as well in or outside a transaction.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+/*
+Select runs the query with the arguments and scans the rows of its result into values of T.
+
+If T is a struct, or a pointer to a struct, each column is scanned into the field of the same name: the name set
+with the db tag, e.g., `db:"created_at"`, or else the snake_case name of the field. The fields of the embedded
+structs are mapped as the fields of the struct, and the fields tagged with `db:"-"` are ignored. A column with no
+field fails with ErrUnmappedColumn, and the fields without a column are left to their zero value. The nullable
+columns are scanned into pointer fields or sql.Null types.
+
+Otherwise, e.g., for a string, an int64, a time.Time or a sql.Scanner, the result must have a single column.
+
+If q is a Querier returned by LogQueries, the query is logged with the number of rows scanned.
+
+Example usage:
+
+	type User struct {
+		ID        int64     `db:"id"`
+		Email     string    `db:"email"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+
+	users, err := dbutil.Select[User](ctx, db, "SELECT id, email, created_at FROM users WHERE active = $1", true)
+	if err != nil {
+		// Handle error
+	}
+*/
+func Select[T any](ctx context.Context, q Querier, query string, args ...any) ([]T, error) {
+	var values []T
+	err := queryRows(ctx, q, query, args, func(rows *sql.Rows) (int, error) {
+		scan, err := scanner[T](rows)
+		if err != nil {
+			return 0, err
+		}
+		for rows.Next() {
+			value, err := scan()
+			if err != nil {
+				return len(values), err
+			}
+			values = append(values, value)
+		}
+		return len(values), rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+/*
+Get runs the query with the arguments and scans the first row of its result into a value of T, as Select does. It
+returns sql.ErrNoRows if the result is empty.
+
+Example usage:
+
+	user, err := dbutil.Get[User](ctx, db, "SELECT id, email, created_at FROM users WHERE id = $1", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Handle not found
+	}
+*/
+func Get[T any](ctx context.Context, q Querier, query string, args ...any) (T, error) {
+	var value T
+	err := queryRows(ctx, q, query, args, func(rows *sql.Rows) (int, error) {
+		scan, err := scanner[T](rows)
+		if err != nil {
+			return 0, err
+		}
+		if !rows.Next() {
+			if err := rows.Err(); err != nil {
+				return 0, err
+			}
+			return 0, sql.ErrNoRows
+		}
+		value, err = scan()
+		return 1, err
+	})
+	return value, err
+}
+
+// queryRows runs the query with the arguments and passes its rows to scan, which returns the number of rows
+// scanned, logging the query if q is a Querier returned by LogQueries.
+func queryRows(ctx context.Context, q Querier, query string, args []any, scan func(rows *sql.Rows) (int, error)) error {
+	logged, ok := q.(*loggingQuerier)
+	if ok {
+		q = logged.querier
+	}
+	start := time.Now()
+	n, err := func() (int, error) {
+		rows, err := q.QueryContext(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+		return scan(rows)
+	}()
+	if ok {
+		logged.log(ctx, query, time.Since(start), int64(n), err)
+	}
+	return err
+}
+
+// scanner returns a function scanning the current row of rows into a value of T.
+func scanner[T any](rows *sql.Rows) (func() (T, error), error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	typ := reflect.TypeFor[T]()
+	structType, isPointer := typ, false
+	if typ.Kind() == reflect.Pointer {
+		structType, isPointer = typ.Elem(), true
+	}
+	if !isStruct(structType) {
+		if len(columns) != 1 {
+			return nil, fmt.Errorf("%w: got %d columns for %s", ErrColumnCount, len(columns), typ)
+		}
+		return func() (T, error) {
+			var value T
+			err := rows.Scan(&value)
+			return value, err
+		}, nil
+	}
+
+	fields := fieldsOf(structType)
+	indexes := make([][]int, len(columns))
+	for i, column := range columns {
+		index, ok := fields[column]
+		if !ok {
+			return nil, fmt.Errorf("%w %q in %s", ErrUnmappedColumn, column, structType)
+		}
+		indexes[i] = index
+	}
+	dest := make([]any, len(columns))
+	return func() (T, error) {
+		var value T
+		target := reflect.ValueOf(&value).Elem()
+		if isPointer {
+			target.Set(reflect.New(structType))
+			target = target.Elem()
+		}
+		for i, index := range indexes {
+			dest[i] = target.FieldByIndex(index).Addr().Interface()
+		}
+		err := rows.Scan(dest...)
+		return value, err
+	}, nil
+}
@@ -0,0 +1,241 @@
+package dbutil_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/dbutil"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// fakeDB is a database/sql driver returning the scripted columns and rows to the queries, and recording the
+// queries.
+type fakeDB struct {
+	mu       sync.Mutex
+	columns  []string
+	rows     [][]driver.Value
+	queryErr error
+	pingErr  error
+	queries  []string
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+func (c fakeConn) Ping(context.Context) error          { return c.db.pingErr }
+
+func (c fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.queries = append(c.db.queries, "BEGIN")
+	return fakeTx{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func (c fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.queries = append(c.db.queries, query)
+	return driver.RowsAffected(3), nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.queries = append(c.db.queries, query)
+	if c.db.queryErr != nil {
+		return nil, c.db.queryErr
+	}
+	return &fakeRows{columns: c.db.columns, values: c.db.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+type audit struct {
+	CreatedAt time.Time
+	UpdatedBy *string `db:"updated_by"`
+}
+
+type user struct {
+	audit
+	ID       int64  `db:"id"`
+	Email    string `db:"email"`
+	Internal string `db:"-"`
+}
+
+func TestSelect(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("scans the rows into structs by tag and snake_case name", func(t *testing.T) {
+		fake := &fakeDB{
+			columns: []string{"id", "email", "created_at", "updated_by"},
+			rows: [][]driver.Value{
+				{int64(1), "a@example.com", createdAt, nil},
+				{int64(2), "b@example.com", createdAt, "admin"},
+			},
+		}
+		users, err := dbutil.Select[user](context.Background(), sql.OpenDB(fake), "SELECT * FROM users")
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		assert.Equal(t, int64(1), users[0].ID)
+		assert.Equal(t, "a@example.com", users[0].Email)
+		assert.Equal(t, createdAt, users[0].CreatedAt, "the fields of the embedded structs are mapped")
+		assert.Nil(t, users[0].UpdatedBy, "NULL is scanned into a nil pointer")
+		require.NotNil(t, users[1].UpdatedBy)
+		assert.Equal(t, "admin", *users[1].UpdatedBy)
+	})
+
+	t.Run("scans the rows into pointers to structs", func(t *testing.T) {
+		fake := &fakeDB{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}, {int64(2)}}}
+		users, err := dbutil.Select[*user](context.Background(), sql.OpenDB(fake), "SELECT id FROM users")
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		assert.Equal(t, int64(2), users[1].ID)
+	})
+
+	t.Run("scans a single column into scalars", func(t *testing.T) {
+		fake := &fakeDB{columns: []string{"email"}, rows: [][]driver.Value{{"a@example.com"}, {"b@example.com"}}}
+		emails, err := dbutil.Select[string](context.Background(), sql.OpenDB(fake), "SELECT email FROM users")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a@example.com", "b@example.com"}, emails)
+
+		fake = &fakeDB{columns: []string{"created_at"}, rows: [][]driver.Value{{createdAt}}}
+		times, err := dbutil.Select[time.Time](context.Background(), sql.OpenDB(fake), "SELECT created_at FROM users")
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{createdAt}, times, "time.Time is scanned as a whole")
+	})
+
+	t.Run("fails on a column with no field", func(t *testing.T) {
+		fake := &fakeDB{columns: []string{"id", "internal"}, rows: [][]driver.Value{{int64(1), "x"}}}
+		_, err := dbutil.Select[user](context.Background(), sql.OpenDB(fake), "SELECT id, internal FROM users")
+		assert.ErrorIs(t, err, dbutil.ErrUnmappedColumn)
+	})
+
+	t.Run("fails on several columns for a scalar", func(t *testing.T) {
+		fake := &fakeDB{columns: []string{"id", "email"}}
+		_, err := dbutil.Select[int64](context.Background(), sql.OpenDB(fake), "SELECT id, email FROM users")
+		assert.ErrorIs(t, err, dbutil.ErrColumnCount)
+	})
+
+	t.Run("returns the error of the query", func(t *testing.T) {
+		errQuery := errors.New("connection reset")
+		fake := &fakeDB{queryErr: errQuery}
+		_, err := dbutil.Select[user](context.Background(), sql.OpenDB(fake), "SELECT * FROM users")
+		assert.ErrorIs(t, err, errQuery)
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Run("scans the first row", func(t *testing.T) {
+		fake := &fakeDB{columns: []string{"id", "email"}, rows: [][]driver.Value{{int64(1), "a@example.com"}, {int64(2), "b@example.com"}}}
+		u, err := dbutil.Get[user](context.Background(), sql.OpenDB(fake), "SELECT id, email FROM users WHERE id = $1", 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), u.ID)
+		assert.Equal(t, "a@example.com", u.Email)
+	})
+
+	t.Run("returns sql.ErrNoRows for an empty result", func(t *testing.T) {
+		fake := &fakeDB{columns: []string{"id"}}
+		count, err := dbutil.Get[int64](context.Background(), sql.OpenDB(fake), "SELECT id FROM users WHERE id = $1", 1)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.Zero(t, count)
+	})
+}
+
+func TestLogQueries(t *testing.T) {
+	t.Run("logs the queries with the number of rows scanned", func(t *testing.T) {
+		log, recorder := logger.NewTestLogger()
+		fake := &fakeDB{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}, {int64(2)}}}
+		db := dbutil.LogQueries(sql.OpenDB(fake), dbutil.WithLogger(log))
+
+		_, err := dbutil.Select[int64](context.Background(), db, "SELECT id FROM users")
+		require.NoError(t, err)
+		recorder.AssertLogged(t, logger.DEBUG, "SQL query",
+			logger.HasField("sql", "SELECT id FROM users"),
+			logger.HasField("rows", int64(2)),
+			logger.HasFieldKey("duration_ms"),
+		)
+		assert.Equal(t, 1, recorder.Len(), "the query is logged once")
+	})
+
+	t.Run("logs the rows affected", func(t *testing.T) {
+		log, recorder := logger.NewTestLogger()
+		db := dbutil.LogQueries(sql.OpenDB(&fakeDB{}), dbutil.WithLogger(log))
+
+		_, err := db.ExecContext(context.Background(), "DELETE FROM users")
+		require.NoError(t, err)
+		recorder.AssertLogged(t, logger.DEBUG, "SQL query", logger.HasField("rows", int64(3)))
+	})
+
+	t.Run("truncates the SQL", func(t *testing.T) {
+		log, recorder := logger.NewTestLogger()
+		db := dbutil.LogQueries(sql.OpenDB(&fakeDB{}), dbutil.WithLogger(log), dbutil.WithMaxSQLLength(10))
+
+		_, err := db.ExecContext(context.Background(), "DELETE FROM users WHERE "+strings.Repeat("x", 100))
+		require.NoError(t, err)
+		recorder.AssertLogged(t, logger.DEBUG, "SQL query", logger.HasField("sql", "DELETE FRO..."))
+	})
+
+	t.Run("logs the failed queries at error level", func(t *testing.T) {
+		log, recorder := logger.NewTestLogger()
+		fake := &fakeDB{queryErr: errors.New("connection reset")}
+		db := dbutil.LogQueries(sql.OpenDB(fake), dbutil.WithLogger(log))
+
+		_, err := dbutil.Select[int64](context.Background(), db, "SELECT id FROM users")
+		require.Error(t, err)
+		recorder.AssertLogged(t, logger.ERROR, "SQL query")
+	})
+
+	t.Run("does not log sql.ErrNoRows as a failure", func(t *testing.T) {
+		log, recorder := logger.NewTestLogger()
+		db := dbutil.LogQueries(sql.OpenDB(&fakeDB{columns: []string{"id"}}), dbutil.WithLogger(log))
+
+		_, err := dbutil.Get[int64](context.Background(), db, "SELECT id FROM users")
+		require.ErrorIs(t, err, sql.ErrNoRows)
+		recorder.AssertLogged(t, logger.DEBUG, "SQL query", logger.HasField("rows", int64(0)))
+		recorder.AssertNotLogged(t, logger.ERROR, "SQL query")
+	})
+
+	t.Run("logs the slow queries at warn level", func(t *testing.T) {
+		log, recorder := logger.NewTestLogger()
+		db := dbutil.LogQueries(sql.OpenDB(&fakeDB{}), dbutil.WithLogger(log), dbutil.WithSlowThreshold(time.Nanosecond))
+
+		_, err := db.ExecContext(context.Background(), "DELETE FROM users")
+		require.NoError(t, err)
+		recorder.AssertLogged(t, logger.WARN, "SQL query", logger.HasField("slow", true))
+	})
+}
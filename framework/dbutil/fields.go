@@ -0,0 +1,86 @@
+package dbutil
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// fieldsCache holds the fields of the struct types, by type.
+var fieldsCache sync.Map // map[reflect.Type]map[string][]int
+
+var (
+	scannerType = reflect.TypeFor[sql.Scanner]()
+	timeType    = reflect.TypeFor[time.Time]()
+)
+
+// isStruct reports whether the values of typ are mapped field by field, i.e., whether typ is a struct which is not
+// scanned as a whole, like time.Time or a sql.Scanner.
+func isStruct(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && typ != timeType && !reflect.PointerTo(typ).Implements(scannerType)
+}
+
+// fieldsOf returns the indexes of the fields of the struct type typ, by column name. The fields of the struct take
+// precedence over the fields of its embedded structs.
+func fieldsOf(typ reflect.Type) map[string][]int {
+	if fields, ok := fieldsCache.Load(typ); ok {
+		return fields.(map[string][]int)
+	}
+
+	fields := make(map[string][]int)
+	type level struct {
+		typ   reflect.Type
+		index []int
+	}
+	for levels := []level{{typ: typ}}; len(levels) > 0; {
+		var embedded []level
+		for _, l := range levels {
+			for i := 0; i < l.typ.NumField(); i++ {
+				field := l.typ.Field(i)
+				tag, _, _ := strings.Cut(field.Tag.Get("db"), ",")
+				if tag == "-" {
+					continue
+				}
+				index := append(append([]int(nil), l.index...), i)
+				if field.Anonymous && tag == "" && isStruct(field.Type) {
+					embedded = append(embedded, level{typ: field.Type, index: index})
+					continue
+				}
+				if !field.IsExported() {
+					continue
+				}
+				name := tag
+				if name == "" {
+					name = snakeCase(field.Name)
+				}
+				if _, ok := fields[name]; !ok {
+					fields[name] = index
+				}
+			}
+		}
+		levels = embedded
+	}
+
+	fieldsCache.Store(typ, fields)
+	return fields
+}
+
+// snakeCase returns the snake_case form of the name of a field, e.g., user_id for UserID.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package dbutil
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/health"
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+// DefaultStatsInterval is the interval the statistics of the connection pools are sampled at, unless set with
+// WithStatsInterval.
+const DefaultStatsInterval = 15 * time.Second
+
+/*
+RegisterHealthCheck registers a readiness check named name in registry, pinging the database db, e.g., a *sql.DB,
+so that the instance is not ready while its database is unreachable.
+
+Example usage:
+
+	err := dbutil.RegisterHealthCheck(healthRegistry, "postgres", db, health.WithCheckTimeout(time.Second))
+	if err != nil {
+		// Handle error
+	}
+*/
+func RegisterHealthCheck(registry *health.Registry, name string, db health.Pinger, opts ...health.CheckOption) error {
+	return registry.Register(name, health.PingCheck(db), opts...)
+}
+
+// statsOptions holds configuration options for the StatsCollector.
+type statsOptions struct {
+	interval time.Duration // interval is the interval the statistics are sampled at by Run.
+}
+
+// StatsOption specifies StatsCollector configuration options.
+type StatsOption func(*statsOptions)
+
+// WithStatsInterval sets the interval the statistics are sampled at by Run. It defaults to DefaultStatsInterval.
+func WithStatsInterval(d time.Duration) StatsOption {
+	return func(opts *statsOptions) {
+		if d > 0 {
+			opts.interval = d
+		}
+	}
+}
+
+/*
+StatsCollector samples the statistics of the connection pool of a database, see sql.DBStats, into a registry,
+labelled with the name of the database: the connections open, in use and idle, and the waits for a connection, which
+tell that the pool is too small. Run samples them periodically. The collectors of several databases, e.g., a
+primary and its replicas, share the metrics of a registry.
+
+Example usage:
+
+	collector, err := dbutil.NewStatsCollector(metrics.Default(), "postgres", db)
+	if err != nil {
+		// Handle error
+	}
+	manager.Add("postgres-metrics", collector)
+
+exposes metrics such as:
+
+	db_in_use_connections{db="postgres"} 7
+	db_wait_duration_seconds_total{db="postgres"} 0.25
+*/
+type StatsCollector struct {
+	opts              statsOptions
+	name              string
+	db                *sql.DB
+	maxOpen           *metrics.Gauge
+	open              *metrics.Gauge
+	inUse             *metrics.Gauge
+	idle              *metrics.Gauge
+	waitCount         *metrics.Counter
+	waitDuration      *metrics.Counter
+	maxIdleClosed     *metrics.Counter
+	maxIdleTimeClosed *metrics.Counter
+	maxLifetimeClosed *metrics.Counter
+	mutex             sync.Mutex
+	last              sql.DBStats
+}
+
+// NewStatsCollector creates a StatsCollector sampling the statistics of db, labelled with name, into registry, and
+// samples them once.
+func NewStatsCollector(registry *metrics.Registry, name string, db *sql.DB, opts ...StatsOption) (*StatsCollector, error) {
+	o := statsOptions{interval: DefaultStatsInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	c := &StatsCollector{opts: o, name: name, db: db}
+
+	var err error
+	gauges := []struct {
+		gauge **metrics.Gauge
+		name  string
+		help  string
+	}{
+		{&c.maxOpen, "db_max_open_connections", "Maximum number of open connections to the database."},
+		{&c.open, "db_open_connections", "Number of established connections, in use and idle."},
+		{&c.inUse, "db_in_use_connections", "Number of connections in use."},
+		{&c.idle, "db_idle_connections", "Number of idle connections."},
+	}
+	for _, g := range gauges {
+		if *g.gauge, err = registry.NewGauge(g.name, g.help, "db"); err != nil {
+			return nil, err
+		}
+	}
+	counters := []struct {
+		counter **metrics.Counter
+		name    string
+		help    string
+	}{
+		{&c.waitCount, "db_wait_total", "Total number of waits for a connection."},
+		{&c.waitDuration, "db_wait_duration_seconds_total", "Total time blocked waiting for a connection in seconds."},
+		{&c.maxIdleClosed, "db_max_idle_closed_total", "Total number of connections closed due to the maximum of idle connections."},
+		{&c.maxIdleTimeClosed, "db_max_idle_time_closed_total", "Total number of connections closed due to the maximum idle time."},
+		{&c.maxLifetimeClosed, "db_max_lifetime_closed_total", "Total number of connections closed due to the maximum lifetime."},
+	}
+	for _, counter := range counters {
+		if *counter.counter, err = registry.NewCounter(counter.name, counter.help, "db"); err != nil {
+			return nil, err
+		}
+	}
+
+	c.Collect()
+	return c, nil
+}
+
+// Run samples the statistics at the interval of WithStatsInterval until ctx is done. It implements
+// lifecycle.Runnable.
+func (c *StatsCollector) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			c.Collect()
+		}
+	}
+}
+
+// Collect samples the statistics once.
+func (c *StatsCollector) Collect() {
+	stats := c.db.Stats()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.maxOpen.Set(float64(stats.MaxOpenConnections), c.name)
+	c.open.Set(float64(stats.OpenConnections), c.name)
+	c.inUse.Set(float64(stats.InUse), c.name)
+	c.idle.Set(float64(stats.Idle), c.name)
+	// The counters are incremented by the changes of the cumulative statistics since the last sample.
+	c.waitCount.Add(float64(stats.WaitCount-c.last.WaitCount), c.name)
+	c.waitDuration.Add((stats.WaitDuration - c.last.WaitDuration).Seconds(), c.name)
+	c.maxIdleClosed.Add(float64(stats.MaxIdleClosed-c.last.MaxIdleClosed), c.name)
+	c.maxIdleTimeClosed.Add(float64(stats.MaxIdleTimeClosed-c.last.MaxIdleTimeClosed), c.name)
+	c.maxLifetimeClosed.Add(float64(stats.MaxLifetimeClosed-c.last.MaxLifetimeClosed), c.name)
+	c.last = stats
+}
@@ -0,0 +1,83 @@
+package dbutil_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/dbutil"
+	"github.com/kittipat1413/go-common/framework/health"
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+func TestRegisterHealthCheck(t *testing.T) {
+	fake := &fakeDB{}
+	registry := health.NewRegistry(health.WithCacheDuration(0))
+	require.NoError(t, dbutil.RegisterHealthCheck(registry, "postgres", sql.OpenDB(fake)))
+
+	assert.Equal(t, health.StatusUp, registry.Readiness(context.Background()).Status)
+
+	fake.pingErr = errors.New("connection refused")
+	report := registry.Readiness(context.Background())
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, "connection refused", report.Checks["postgres"].Error)
+}
+
+func TestStatsCollector(t *testing.T) {
+	ctx := context.Background()
+	registry := metrics.NewRegistry()
+	db := sql.OpenDB(&fakeDB{})
+	db.SetMaxOpenConns(1)
+	collector, err := dbutil.NewStatsCollector(registry, "primary", db)
+	require.NoError(t, err)
+	_, err = dbutil.NewStatsCollector(registry, "replica", sql.OpenDB(&fakeDB{}), dbutil.WithStatsInterval(time.Second))
+	require.NoError(t, err, "the collectors share the metrics of the registry")
+
+	// A ping waits for the connection held.
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	pinged := make(chan error)
+	go func() { pinged <- db.PingContext(ctx) }()
+	require.Eventually(t, func() bool { return db.Stats().WaitCount == 1 }, time.Second, time.Millisecond)
+	collector.Collect()
+	require.NoError(t, conn.Close())
+	require.NoError(t, <-pinged)
+
+	var buf bytes.Buffer
+	_, err = registry.WriteTo(&buf)
+	require.NoError(t, err)
+	output := buf.String()
+	for _, line := range []string{
+		"# TYPE db_in_use_connections gauge",
+		`db_max_open_connections{db="primary"} 1`,
+		`db_open_connections{db="primary"} 1`,
+		`db_in_use_connections{db="primary"} 1`,
+		`db_idle_connections{db="primary"} 0`,
+		"# TYPE db_wait_total counter",
+		`db_wait_total{db="primary"} 1`,
+		`db_wait_total{db="replica"} 0`,
+		`db_max_lifetime_closed_total{db="primary"} 0`,
+	} {
+		assert.Contains(t, output, line+"\n")
+	}
+	assert.Contains(t, output, `db_wait_duration_seconds_total{db="primary"}`)
+}
+
+func TestStatsCollector_Run(t *testing.T) {
+	registry := metrics.NewRegistry()
+	collector, err := dbutil.NewStatsCollector(registry, "primary", sql.OpenDB(&fakeDB{}), dbutil.WithStatsInterval(time.Millisecond))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- collector.Run(ctx) }()
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,138 @@
+package dbutil
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// DefaultMaxSQLLength is the maximum length of the queries logged, unless set with WithMaxSQLLength.
+const DefaultMaxSQLLength = 1000
+
+// options holds configuration options for the logging of the queries.
+type options struct {
+	logger        logger.Logger // logger logs the queries, or the logger of the context if nil.
+	maxSQLLength  int           // maxSQLLength is the length the queries logged are truncated to.
+	slowThreshold time.Duration // slowThreshold is the duration from which the queries are logged at warn level.
+}
+
+// Option specifies query logging configuration options.
+type Option func(*options)
+
+// WithLogger sets the logger of the queries. It defaults to the logger of the context.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		if l != nil {
+			opts.logger = l
+		}
+	}
+}
+
+// WithMaxSQLLength sets the length the queries logged are truncated to. It defaults to DefaultMaxSQLLength.
+func WithMaxSQLLength(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.maxSQLLength = n
+		}
+	}
+}
+
+// WithSlowThreshold logs the queries lasting d or more at warn level, rather than at debug level. The slow queries
+// are not logged differently by default.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.slowThreshold = d
+		}
+	}
+}
+
+/*
+LogQueries returns a Querier running the queries with q and logging them: at debug level with the SQL, truncated,
+the duration and the number of rows, at warn level when they are slow, see WithSlowThreshold, and at error level
+when they fail. The arguments of the queries are not logged, as they may hold personal data.
+
+The number of rows is the number of rows affected by ExecContext, and the number of rows scanned by Select and
+Get. It is not logged for QueryContext and QueryRowContext, whose duration is the time to the first row.
+
+Example usage:
+
+	db := dbutil.LogQueries(sqlDB, dbutil.WithSlowThreshold(200*time.Millisecond))
+	users, err := dbutil.Select[User](ctx, db, "SELECT id, email, created_at FROM users")
+*/
+func LogQueries(q Querier, opts ...Option) Querier {
+	o := options{maxSQLLength: DefaultMaxSQLLength}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &loggingQuerier{querier: q, opts: o}
+}
+
+// loggingQuerier is the Querier returned by LogQueries.
+type loggingQuerier struct {
+	querier Querier
+	opts    options
+}
+
+func (q *loggingQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	result, err := q.querier.ExecContext(ctx, query, args...)
+	rows := int64(-1)
+	if err == nil {
+		if n, rowsErr := result.RowsAffected(); rowsErr == nil {
+			rows = n
+		}
+	}
+	q.log(ctx, query, time.Since(start), rows, err)
+	return result, err
+}
+
+func (q *loggingQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := q.querier.QueryContext(ctx, query, args...)
+	q.log(ctx, query, time.Since(start), -1, err)
+	return rows, err
+}
+
+func (q *loggingQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	start := time.Now()
+	row := q.querier.QueryRowContext(ctx, query, args...)
+	q.log(ctx, query, time.Since(start), -1, row.Err())
+	return row
+}
+
+// log writes the entry of the query, with the number of rows if it is not negative. sql.ErrNoRows is not a failure.
+func (q *loggingQuerier) log(ctx context.Context, query string, duration time.Duration, rows int64, err error) {
+	if len(query) > q.opts.maxSQLLength {
+		end := q.opts.maxSQLLength
+		for end > 0 && !utf8.RuneStart(query[end]) {
+			end--
+		}
+		query = query[:end] + "..."
+	}
+	fields := logger.Fields{
+		"sql":         query,
+		"duration_ms": duration.Milliseconds(),
+	}
+	if rows >= 0 {
+		fields["rows"] = rows
+	}
+
+	l := q.opts.logger
+	if l == nil {
+		l = logger.FromContext(ctx)
+	}
+	switch {
+	case err != nil && !stderrors.Is(err, sql.ErrNoRows):
+		l.Error(ctx, "SQL query", err, fields)
+	case q.opts.slowThreshold > 0 && duration >= q.opts.slowThreshold:
+		fields["slow"] = true
+		l.Warn(ctx, "SQL query", fields)
+	default:
+		l.Debug(ctx, "SQL query", fields)
+	}
+}
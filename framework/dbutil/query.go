@@ -0,0 +1,265 @@
+package dbutil
+
+import (
+	"database/sql/driver"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrMissingArgument is returned by Named when a named parameter of the query has no value.
+	ErrMissingArgument = stderrors.New("dbutil: missing named argument")
+	// ErrArgumentCount is returned by In when the number of arguments differs from the number of placeholders.
+	ErrArgumentCount = stderrors.New("dbutil: wrong number of arguments")
+	// ErrEmptySlice is returned by Named and In for an empty slice argument, which would expand to an invalid IN ().
+	ErrEmptySlice = stderrors.New("dbutil: empty slice argument")
+)
+
+// Dialect is the placeholder style of the queries of a database.
+type Dialect int
+
+const (
+	// Postgres is the dialect of PostgreSQL, with the $1, $2, ... placeholders.
+	Postgres Dialect = iota
+	// MySQL is the dialect of MySQL and SQLite, with the ? placeholders.
+	MySQL
+)
+
+/*
+Rebind returns the query, written with ? placeholders, e.g., by Named or In, with the placeholders of the dialect.
+
+Example usage:
+
+	query, args, err := dbutil.In("SELECT * FROM users WHERE id IN (?) AND active = ?", ids, true)
+	if err != nil {
+		// Handle error
+	}
+	users, err := dbutil.Select[User](ctx, db, dbutil.Rebind(dbutil.Postgres, query), args...)
+*/
+func Rebind(dialect Dialect, query string) string {
+	if dialect == MySQL {
+		return query
+	}
+	n := 0
+	return walk(query, func(rest string) (string, int) {
+		if rest[0] != '?' {
+			return "", 0
+		}
+		n++
+		return "$" + strconv.Itoa(n), 1
+	})
+}
+
+/*
+Named returns the query with its :name parameters replaced by ? placeholders, and the values of the parameters, in
+order, taken from arg: a map with string keys, or a struct, or a pointer to a struct, whose fields are named as for
+Select. A parameter with no value fails with ErrMissingArgument. A slice value, other than []byte or a
+driver.Valuer, is expanded to a placeholder per element, e.g., for the IN clauses.
+
+The quoted strings and identifiers, the comments and the PostgreSQL :: casts are left as they are.
+
+Example usage:
+
+	query, args, err := dbutil.Named(
+		"UPDATE users SET email = :email WHERE id = :id AND status IN (:statuses)",
+		map[string]any{"id": id, "email": email, "statuses": []string{"active", "pending"}},
+	)
+	if err != nil {
+		// Handle error
+	}
+	_, err = db.ExecContext(ctx, dbutil.Rebind(dbutil.Postgres, query), args...)
+*/
+func Named(query string, arg any) (string, []any, error) {
+	lookup, err := namedValues(arg)
+	if err != nil {
+		return "", nil, err
+	}
+	var args []any
+	query = walk(query, func(rest string) (string, int) {
+		if rest[0] != ':' || err != nil {
+			return "", 0
+		}
+		if strings.HasPrefix(rest, "::") {
+			return "::", 2
+		}
+		end := 1
+		for end < len(rest) && isNameByte(rest[end], end == 1) {
+			end++
+		}
+		if end == 1 {
+			return "", 0
+		}
+		name := rest[1:end]
+		value, ok := lookup(name)
+		if !ok {
+			err = fmt.Errorf("%w %q", ErrMissingArgument, name)
+			return "", 0
+		}
+		var expanded []any
+		if expanded, err = expand(value); err != nil {
+			err = fmt.Errorf("%w %q", err, name)
+			return "", 0
+		}
+		args = append(args, expanded...)
+		return placeholders(len(expanded)), end
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return query, args, nil
+}
+
+/*
+In returns the query, written with ? placeholders, with the placeholders of the slice arguments, other than []byte
+or a driver.Valuer, expanded to a placeholder per element, and the arguments flattened accordingly. The number of
+arguments must be the number of placeholders, or it fails with ErrArgumentCount.
+
+Example usage:
+
+	query, args, err := dbutil.In("SELECT * FROM users WHERE id IN (?)", []int64{1, 2, 3})
+	// SELECT * FROM users WHERE id IN (?, ?, ?), [1 2 3]
+*/
+func In(query string, args ...any) (string, []any, error) {
+	var flattened []any
+	var err error
+	n := 0
+	query = walk(query, func(rest string) (string, int) {
+		if rest[0] != '?' || err != nil {
+			return "", 0
+		}
+		if n >= len(args) {
+			err = fmt.Errorf("%w: more placeholders than the %d arguments", ErrArgumentCount, len(args))
+			return "", 0
+		}
+		var expanded []any
+		if expanded, err = expand(args[n]); err != nil {
+			err = fmt.Errorf("%w at position %d", err, n+1)
+			return "", 0
+		}
+		n++
+		flattened = append(flattened, expanded...)
+		return placeholders(len(expanded)), 1
+	})
+	if err == nil && n != len(args) {
+		err = fmt.Errorf("%w: %d placeholders for %d arguments", ErrArgumentCount, n, len(args))
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return query, flattened, nil
+}
+
+// walk returns the query with the parameters replaced: replace is called at each position of the query outside the
+// quoted strings and identifiers and the comments, with the rest of the query, and returns the replacement of its
+// first n bytes, or zero to keep the next byte as it is.
+func walk(query string, replace func(rest string) (replacement string, n int)) string {
+	var b strings.Builder
+	start := 0
+	for i := 0; i < len(query); {
+		if end := skipQuoted(query, i); end > i {
+			i = end
+			continue
+		}
+		if replacement, n := replace(query[i:]); n > 0 {
+			b.WriteString(query[start:i])
+			b.WriteString(replacement)
+			i += n
+			start = i
+			continue
+		}
+		i++
+	}
+	b.WriteString(query[start:])
+	return b.String()
+}
+
+// skipQuoted returns the end of the quoted string or identifier, or the comment, starting at i in the query, or i if
+// there is none.
+func skipQuoted(query string, i int) int {
+	switch {
+	case query[i] == '\'' || query[i] == '"' || query[i] == '`':
+		quote := query[i]
+		for j := i + 1; j < len(query); j++ {
+			if query[j] != quote {
+				continue
+			}
+			if j+1 < len(query) && query[j+1] == quote { // doubled quote
+				j++
+				continue
+			}
+			return j + 1
+		}
+		return len(query)
+	case strings.HasPrefix(query[i:], "--"):
+		if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+			return i + end + 1
+		}
+		return len(query)
+	case strings.HasPrefix(query[i:], "/*"):
+		if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+			return i + 2 + end + 2
+		}
+		return len(query)
+	}
+	return i
+}
+
+// isNameByte reports whether c is part of the name of a parameter, first being whether it is its first byte.
+func isNameByte(c byte, first bool) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || (!first && '0' <= c && c <= '9')
+}
+
+// placeholders returns n ? placeholders separated by commas.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// namedValues returns the function looking up the values of the named parameters in arg.
+func namedValues(arg any) (func(name string) (any, bool), error) {
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		return func(name string) (any, bool) {
+			value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !value.IsValid() {
+				return nil, false
+			}
+			return value.Interface(), true
+		}, nil
+	case v.Kind() == reflect.Struct:
+		fields := fieldsOf(v.Type())
+		return func(name string) (any, bool) {
+			index, ok := fields[name]
+			if !ok {
+				return nil, false
+			}
+			return v.FieldByIndex(index).Interface(), true
+		}, nil
+	}
+	return nil, fmt.Errorf("dbutil: named arguments must be a map with string keys or a struct, got %T", arg)
+}
+
+// expand returns the elements of value if it is a slice to expand, or value.
+func expand(value any) ([]any, error) {
+	if _, ok := value.(driver.Valuer); ok {
+		return []any{value}, nil
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+		return []any{value}, nil
+	}
+	if v.Len() == 0 {
+		return nil, ErrEmptySlice
+	}
+	values := make([]any, v.Len())
+	for i := range values {
+		values[i] = v.Index(i).Interface()
+	}
+	return values, nil
+}
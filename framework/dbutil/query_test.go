@@ -0,0 +1,91 @@
+package dbutil_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/dbutil"
+)
+
+func TestNamed(t *testing.T) {
+	t.Run("replaces the parameters with the values of a map", func(t *testing.T) {
+		query, args, err := dbutil.Named(
+			"UPDATE users SET email = :email WHERE id = :id AND status IN (:statuses) AND id <> :id",
+			map[string]any{"id": 1, "email": "a@example.com", "statuses": []string{"active", "pending"}},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "UPDATE users SET email = ? WHERE id = ? AND status IN (?, ?) AND id <> ?", query)
+		assert.Equal(t, []any{"a@example.com", 1, "active", "pending", 1}, args)
+	})
+
+	t.Run("replaces the parameters with the fields of a struct", func(t *testing.T) {
+		u := user{ID: 1, Email: "a@example.com"}
+		query, args, err := dbutil.Named("INSERT INTO users (id, email) VALUES (:id, :email)", &u)
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO users (id, email) VALUES (?, ?)", query)
+		assert.Equal(t, []any{int64(1), "a@example.com"}, args)
+	})
+
+	t.Run("leaves the quoted strings, comments and casts as they are", func(t *testing.T) {
+		query, args, err := dbutil.Named(
+			"SELECT ':a', \"b:c\", '10:30''s' -- :d\nFROM t /* :e */ WHERE x = :x::int",
+			map[string]any{"x": 1},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT ':a', \"b:c\", '10:30''s' -- :d\nFROM t /* :e */ WHERE x = ?::int", query)
+		assert.Equal(t, []any{1}, args)
+	})
+
+	t.Run("does not expand []byte and driver.Valuer", func(t *testing.T) {
+		payload := []byte("payload")
+		name := sql.NullString{String: "a", Valid: true}
+		_, args, err := dbutil.Named("INSERT INTO t VALUES (:payload, :name)", map[string]any{"payload": payload, "name": name})
+		require.NoError(t, err)
+		assert.Equal(t, []any{payload, name}, args)
+	})
+
+	t.Run("fails on a missing argument", func(t *testing.T) {
+		_, _, err := dbutil.Named("SELECT * FROM users WHERE id = :id", map[string]any{})
+		assert.ErrorIs(t, err, dbutil.ErrMissingArgument)
+	})
+
+	t.Run("fails on an empty slice", func(t *testing.T) {
+		_, _, err := dbutil.Named("SELECT * FROM users WHERE id IN (:ids)", map[string]any{"ids": []int{}})
+		assert.ErrorIs(t, err, dbutil.ErrEmptySlice)
+	})
+
+	t.Run("fails on an argument which is neither a map nor a struct", func(t *testing.T) {
+		_, _, err := dbutil.Named("SELECT :id", 1)
+		assert.Error(t, err)
+	})
+}
+
+func TestIn(t *testing.T) {
+	t.Run("expands the slice arguments", func(t *testing.T) {
+		query, args, err := dbutil.In("SELECT * FROM users WHERE id IN (?) AND active = ? AND note <> '?'", []int64{1, 2, 3}, true)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM users WHERE id IN (?, ?, ?) AND active = ? AND note <> '?'", query)
+		assert.Equal(t, []any{int64(1), int64(2), int64(3), true}, args)
+	})
+
+	t.Run("fails on a wrong number of arguments", func(t *testing.T) {
+		_, _, err := dbutil.In("SELECT * FROM users WHERE id = ?")
+		assert.ErrorIs(t, err, dbutil.ErrArgumentCount)
+		_, _, err = dbutil.In("SELECT * FROM users WHERE id = ?", 1, 2)
+		assert.ErrorIs(t, err, dbutil.ErrArgumentCount)
+	})
+
+	t.Run("fails on an empty slice", func(t *testing.T) {
+		_, _, err := dbutil.In("SELECT * FROM users WHERE id IN (?)", []int64{})
+		assert.ErrorIs(t, err, dbutil.ErrEmptySlice)
+	})
+}
+
+func TestRebind(t *testing.T) {
+	query := "SELECT * FROM users WHERE id IN (?, ?) AND note <> '?' AND active = ?"
+	assert.Equal(t, "SELECT * FROM users WHERE id IN ($1, $2) AND note <> '?' AND active = $3", dbutil.Rebind(dbutil.Postgres, query))
+	assert.Equal(t, query, dbutil.Rebind(dbutil.MySQL, query))
+}
@@ -0,0 +1,256 @@
+package dbutil
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+const (
+	// DefaultCheckInterval is the interval the replicas are pinged at, unless set with WithCheckInterval.
+	DefaultCheckInterval = 5 * time.Second
+	// DefaultCheckTimeout is the timeout of the pings of the replicas, unless set with WithCheckTimeout.
+	DefaultCheckTimeout = time.Second
+)
+
+// clusterOptions holds configuration options for the Cluster.
+type clusterOptions struct {
+	checkInterval time.Duration // checkInterval is the interval the replicas are pinged at by Run.
+	checkTimeout  time.Duration // checkTimeout is the timeout of each ping.
+}
+
+// ClusterOption specifies Cluster configuration options.
+type ClusterOption func(*clusterOptions)
+
+// WithCheckInterval sets the interval the replicas are pinged at by Run. It defaults to DefaultCheckInterval.
+func WithCheckInterval(d time.Duration) ClusterOption {
+	return func(opts *clusterOptions) {
+		if d > 0 {
+			opts.checkInterval = d
+		}
+	}
+}
+
+// WithCheckTimeout sets the timeout of the pings of the replicas. It defaults to DefaultCheckTimeout.
+func WithCheckTimeout(d time.Duration) ClusterOption {
+	return func(opts *clusterOptions) {
+		if d > 0 {
+			opts.checkTimeout = d
+		}
+	}
+}
+
+// forcePrimaryKey is the context key forcing the reads to the primary.
+type forcePrimaryKey struct{}
+
+// ForcePrimary returns a copy of ctx routing the reads of a Cluster to the primary, e.g., to read the writes just
+// made, which the replicas may not have replicated yet.
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryKey{}, true)
+}
+
+// IsPrimaryForced reports whether ctx routes the reads of a Cluster to the primary, see ForcePrimary.
+func IsPrimaryForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(forcePrimaryKey{}).(bool)
+	return forced
+}
+
+/*
+Cluster is a Querier routing the writes to a primary database, and the reads to its replicas, in turn.
+
+A query run with QueryContext or QueryRowContext is a read if it is a SELECT, WITH or SHOW statement without a
+keyword writing or locking rows, e.g., INSERT or FOR UPDATE; the other queries, and the ones run with ExecContext,
+run on the primary. The reads calling functions with side effects, e.g., nextval, must be forced to the primary
+with ForcePrimary, as must the reads expecting the writes just made, which the replicas may not have replicated
+yet. The transactions run on the primary, unless they are read-only.
+
+Run pings the replicas periodically: the reads are not routed to a replica failing its ping until it succeeds
+again, and are routed to the primary while no replica is healthy.
+
+Example usage:
+
+	cluster := dbutil.NewCluster(primary, []*sql.DB{replica1, replica2})
+	manager.Add("postgres-replicas", cluster)
+
+	users, err := dbutil.Select[User](ctx, cluster, "SELECT id, email FROM users") // a replica
+	_, err = cluster.ExecContext(ctx, "UPDATE users SET email = $1 WHERE id = $2", email, id) // the primary
+	user, err := dbutil.Get[User](dbutil.ForcePrimary(ctx), cluster, "SELECT id, email FROM users WHERE id = $1", id)
+*/
+type Cluster struct {
+	opts     clusterOptions
+	primary  *sql.DB
+	replicas []*sql.DB
+	healthy  []atomic.Bool // healthy tells whether each replica passed its last ping.
+	next     atomic.Uint64 // next is the turn of the replicas.
+}
+
+// NewCluster creates a Cluster of the primary database and its replicas, healthy until their first ping fails.
+func NewCluster(primary *sql.DB, replicas []*sql.DB, opts ...ClusterOption) *Cluster {
+	o := clusterOptions{checkInterval: DefaultCheckInterval, checkTimeout: DefaultCheckTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	c := &Cluster{
+		opts:     o,
+		primary:  primary,
+		replicas: replicas,
+		healthy:  make([]atomic.Bool, len(replicas)),
+	}
+	for i := range c.healthy {
+		c.healthy[i].Store(true)
+	}
+	return c
+}
+
+// Primary returns the primary database.
+func (c *Cluster) Primary() *sql.DB {
+	return c.primary
+}
+
+// Replica returns the database the next read of ctx is routed to: a healthy replica, or the primary if the
+// primary is forced, see ForcePrimary, or no replica is healthy.
+func (c *Cluster) Replica(ctx context.Context) *sql.DB {
+	if len(c.replicas) == 0 || IsPrimaryForced(ctx) {
+		return c.primary
+	}
+	start := c.next.Add(1)
+	for i := range c.replicas {
+		n := (start + uint64(i)) % uint64(len(c.replicas))
+		if c.healthy[n].Load() {
+			return c.replicas[n]
+		}
+	}
+	return c.primary
+}
+
+// ExecContext runs the query on the primary.
+func (c *Cluster) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.primary.ExecContext(ctx, query, args...)
+}
+
+// QueryContext runs the query on a replica if it is a read, or on the primary.
+func (c *Cluster) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return c.route(ctx, query).QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext runs the query on a replica if it is a read, or on the primary.
+func (c *Cluster) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return c.route(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx starts a transaction on a replica if it is read-only, or on the primary.
+func (c *Cluster) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if opts != nil && opts.ReadOnly {
+		return c.Replica(ctx).BeginTx(ctx, opts)
+	}
+	return c.primary.BeginTx(ctx, opts)
+}
+
+// PingContext pings the primary, so that the Cluster is a health.Pinger of the primary.
+func (c *Cluster) PingContext(ctx context.Context) error {
+	return c.primary.PingContext(ctx)
+}
+
+// Close closes the primary and the replicas.
+func (c *Cluster) Close() error {
+	errs := []error{c.primary.Close()}
+	for _, replica := range c.replicas {
+		errs = append(errs, replica.Close())
+	}
+	return stderrors.Join(errs...)
+}
+
+// Run pings the replicas at the interval of WithCheckInterval until ctx is done. It implements
+// lifecycle.Runnable.
+func (c *Cluster) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.opts.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			c.Check(ctx)
+		}
+	}
+}
+
+// Check pings the replicas once, concurrently, and routes the reads to the healthy ones only. A replica becoming
+// unhealthy is logged at error level, and a replica recovering at info level.
+func (c *Cluster) Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i, replica := range c.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pingCtx, cancel := context.WithTimeout(ctx, c.opts.checkTimeout)
+			defer cancel()
+			err := replica.PingContext(pingCtx)
+			if ctx.Err() != nil {
+				return // The ping was interrupted, e.g., by the shutdown.
+			}
+			if c.healthy[i].Swap(err == nil) == (err == nil) {
+				return
+			}
+			fields := logger.Fields{"replica": i}
+			if err != nil {
+				logger.FromContext(ctx).Error(ctx, "Database replica unhealthy", err, fields)
+			} else {
+				logger.FromContext(ctx).Info(ctx, "Database replica recovered", fields)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// route returns the database the query runs on.
+func (c *Cluster) route(ctx context.Context, query string) *sql.DB {
+	if readOnly(query) {
+		return c.Replica(ctx)
+	}
+	return c.primary
+}
+
+// writeKeywords are the keywords of the statements writing or locking rows, e.g., INSERT in a WITH statement, or
+// FOR UPDATE and LOCK IN SHARE MODE in a SELECT statement.
+var writeKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "REPLACE": true,
+	"INTO": true, "SHARE": true, "LOCK": true,
+}
+
+// readOnly reports whether the query is a SELECT, WITH or SHOW statement without a keyword writing or locking rows,
+// out of its quoted strings and identifiers, and its comments.
+func readOnly(query string) bool {
+	first := true
+	for i := 0; i < len(query); {
+		if end := skipQuoted(query, i); end > i {
+			i = end
+			continue
+		}
+		if !isNameByte(query[i], true) {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(query) && isNameByte(query[j], false) {
+			j++
+		}
+		keyword := strings.ToUpper(query[i:j])
+		if first {
+			if keyword != "SELECT" && keyword != "WITH" && keyword != "SHOW" {
+				return false
+			}
+			first = false
+		} else if writeKeywords[keyword] {
+			return false
+		}
+		i = j
+	}
+	return !first
+}
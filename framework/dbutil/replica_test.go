@@ -0,0 +1,134 @@
+package dbutil_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/dbutil"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+func TestCluster(t *testing.T) {
+	newCluster := func() (*dbutil.Cluster, *fakeDB, []*fakeDB) {
+		primary := &fakeDB{columns: []string{"id"}}
+		replicas := []*fakeDB{{columns: []string{"id"}}, {columns: []string{"id"}}}
+		return dbutil.NewCluster(sql.OpenDB(primary), []*sql.DB{sql.OpenDB(replicas[0]), sql.OpenDB(replicas[1])}), primary, replicas
+	}
+
+	t.Run("routes the reads to the replicas in turn and the writes to the primary", func(t *testing.T) {
+		cluster, primary, replicas := newCluster()
+		ctx := context.Background()
+
+		for range 4 {
+			_, err := dbutil.Select[int64](ctx, cluster, "SELECT id FROM users")
+			require.NoError(t, err)
+		}
+		_, err := cluster.ExecContext(ctx, "UPDATE users SET email = $1", "a@example.com")
+		require.NoError(t, err)
+		_, err = dbutil.Select[int64](ctx, cluster, "INSERT INTO users (email) VALUES ($1) RETURNING id", "a@example.com")
+		require.NoError(t, err)
+
+		assert.Len(t, replicas[0].queries, 2)
+		assert.Len(t, replicas[1].queries, 2)
+		assert.Equal(t, []string{
+			"UPDATE users SET email = $1",
+			"INSERT INTO users (email) VALUES ($1) RETURNING id",
+		}, primary.queries)
+	})
+
+	t.Run("routes the reads forced to the primary", func(t *testing.T) {
+		cluster, primary, replicas := newCluster()
+		ctx := dbutil.ForcePrimary(context.Background())
+		assert.True(t, dbutil.IsPrimaryForced(ctx))
+		assert.False(t, dbutil.IsPrimaryForced(context.Background()))
+
+		_, err := dbutil.Get[int64](ctx, cluster, "SELECT id FROM users WHERE id = $1", 1)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.Equal(t, []string{"SELECT id FROM users WHERE id = $1"}, primary.queries)
+		assert.Empty(t, replicas[0].queries)
+		assert.Empty(t, replicas[1].queries)
+		assert.Same(t, cluster.Primary(), cluster.Replica(ctx))
+	})
+
+	t.Run("routes the read-only transactions to the replicas", func(t *testing.T) {
+		cluster, primary, replicas := newCluster()
+		ctx := context.Background()
+
+		tx, err := cluster.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+		tx, err = cluster.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		assert.Equal(t, []string{"BEGIN"}, primary.queries)
+		assert.Len(t, append(replicas[0].queries, replicas[1].queries...), 1)
+	})
+
+	t.Run("fails over from the unhealthy replicas", func(t *testing.T) {
+		cluster, primary, replicas := newCluster()
+		log, recorder := logger.NewTestLogger()
+		ctx := logger.NewContext(context.Background(), log)
+
+		replicas[0].pingErr = errors.New("connection refused")
+		cluster.Check(ctx)
+		recorder.AssertLogged(t, logger.ERROR, "Database replica unhealthy", logger.HasField("replica", 0))
+		for range 4 {
+			assert.Same(t, cluster.Replica(ctx), cluster.Replica(ctx))
+			_, err := dbutil.Select[int64](ctx, cluster, "SELECT id FROM users")
+			require.NoError(t, err)
+		}
+		assert.Empty(t, replicas[0].queries)
+		assert.Len(t, replicas[1].queries, 4)
+
+		replicas[1].pingErr = errors.New("connection refused")
+		cluster.Check(ctx)
+		_, err := dbutil.Select[int64](ctx, cluster, "SELECT id FROM users")
+		require.NoError(t, err)
+		assert.Len(t, primary.queries, 1, "the reads fall back to the primary")
+
+		replicas[0].pingErr = nil
+		cluster.Check(ctx)
+		recorder.AssertLogged(t, logger.INFO, "Database replica recovered", logger.HasField("replica", 0))
+		_, err = dbutil.Select[int64](ctx, cluster, "SELECT id FROM users")
+		require.NoError(t, err)
+		assert.Len(t, replicas[0].queries, 1)
+	})
+}
+
+func TestCluster_ReadOnly(t *testing.T) {
+	tests := []struct {
+		query   string
+		replica bool
+	}{
+		{"SELECT id FROM users", true},
+		{"  -- users\n/* all */ select id FROM users", true},
+		{"WITH recent AS (SELECT id FROM users) SELECT * FROM recent", true},
+		{"SHOW max_connections", true},
+		{"SELECT id FROM users WHERE note = 'insert into' AND \"update\" = 1", true},
+		{"SELECT id FROM users FOR UPDATE", false},
+		{"SELECT id FROM users FOR SHARE", false},
+		{"SELECT id FROM users LOCK IN SHARE MODE", false},
+		{"WITH deleted AS (DELETE FROM users RETURNING id) SELECT * FROM deleted", false},
+		{"SELECT * INTO archive FROM users", false},
+		{"INSERT INTO users (email) VALUES ($1) RETURNING id", false},
+		{"-- SELECT\nUPDATE users SET email = $1 RETURNING id", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			primary, replica := &fakeDB{}, &fakeDB{}
+			cluster := dbutil.NewCluster(sql.OpenDB(primary), []*sql.DB{sql.OpenDB(replica)})
+			rows, err := cluster.QueryContext(context.Background(), tt.query)
+			require.NoError(t, err)
+			require.NoError(t, rows.Close())
+			assert.Equal(t, tt.replica, len(replica.queries) == 1)
+			assert.Equal(t, !tt.replica, len(primary.queries) == 1)
+		})
+	}
+}
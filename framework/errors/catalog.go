@@ -0,0 +1,184 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+/*
+CatalogEntry documents an error code for the consumers of an API: the ProblemWriter renders the errors of the
+code with its title, status and documentation URL, and the gRPC statuses of grpcstatus.ToStatus have the gRPC
+code of its kind and a link to its documentation.
+*/
+type CatalogEntry struct {
+	// Code is the stable code of the errors, e.g., "payment.insufficient_funds".
+	Code string
+	// Kind is the kind of the errors. It defaults to the kind of Status.
+	Kind Kind
+	// Status is the HTTP status code of the errors. It defaults to the status code of Kind.
+	Status int
+	// Title is a short summary of the errors, identical for all the errors of the code, e.g., "Insufficient
+	// funds". It is the message of the errors created by Register, and the title of their problem details.
+	Title string
+	// Description explains the errors and how to handle them, e.g., for the catalog of the API.
+	Description string
+	// DocumentationURL is the URL of the documentation of the errors, used as the type of their problem details.
+	DocumentationURL string
+}
+
+/*
+Catalog is a registry of the error codes of a service, so that the errors of a code are rendered identically by
+the ProblemWriter and grpcstatus.ToStatus, wherever they are returned, and the codes can be published for the
+consumers of the API with Handler. Register the codes at initialization, e.g., in package-level variables. The
+codes of DefaultCatalog are used by default. A Catalog is safe for concurrent use.
+
+Example usage:
+
+	var ErrInsufficientFunds = errors.Register(errors.CatalogEntry{
+		Code:             "payment.insufficient_funds",
+		Kind:             errors.KindInvalidArgument,
+		Status:           http.StatusPaymentRequired,
+		Title:            "Insufficient funds",
+		Description:      "The balance of the account does not cover the amount of the payment.",
+		DocumentationURL: "https://docs.example.com/errors/payment.insufficient_funds",
+	})
+
+	func (s *paymentService) Charge(ctx context.Context, accountID string, amount int64) error {
+		if balance < amount {
+			return ErrInsufficientFunds.WithField("account_id", accountID)
+		}
+		...
+	}
+
+	mux.Handle("/errors", errors.DefaultCatalog.Handler())
+*/
+type Catalog struct {
+	mutex   sync.RWMutex
+	entries map[string]CatalogEntry
+}
+
+// DefaultCatalog is the catalog of Register, used by the ProblemWriter and grpcstatus.ToStatus by default.
+var DefaultCatalog = NewCatalog()
+
+// NewCatalog creates an empty Catalog.
+func NewCatalog() *Catalog {
+	return &Catalog{entries: make(map[string]CatalogEntry)}
+}
+
+// Register registers the code of entry in DefaultCatalog, see Catalog.Register.
+func Register(entry CatalogEntry) *CodedError {
+	err := DefaultCatalog.register(entry)
+	err.stack = callers(1)
+	return err
+}
+
+// Register registers the code of entry, and returns a CodedError of the code, with the kind of entry and its
+// title as message, to be returned or wrapped for the errors of the code. It panics if the code is empty or
+// already registered, since the codes are registered at initialization.
+func (c *Catalog) Register(entry CatalogEntry) *CodedError {
+	err := c.register(entry)
+	err.stack = callers(1)
+	return err
+}
+
+func (c *Catalog) register(entry CatalogEntry) *CodedError {
+	if entry.Code == "" {
+		panic("errors: the code of a catalog entry must not be empty")
+	}
+	if entry.Kind == KindUnknown && entry.Status != 0 {
+		entry.Kind = kindOfHTTPStatus(entry.Status)
+	}
+	if entry.Status == 0 {
+		entry.Status = entry.Kind.HTTPStatus()
+	}
+	if entry.Title == "" {
+		entry.Title = http.StatusText(entry.Status)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, found := c.entries[entry.Code]; found {
+		panic(fmt.Sprintf("errors: the code %q is already registered", entry.Code))
+	}
+	c.entries[entry.Code] = entry
+	return &CodedError{kind: entry.Kind, code: entry.Code, message: entry.Title}
+}
+
+// Lookup returns the entry of code, if it is registered.
+func (c *Catalog) Lookup(code string) (CatalogEntry, bool) {
+	if c == nil || code == "" {
+		return CatalogEntry{}, false
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	entry, found := c.entries[code]
+	return entry, found
+}
+
+// Entries returns the registered entries, sorted by code.
+func (c *Catalog) Entries() []CatalogEntry {
+	c.mutex.RLock()
+	entries := make([]CatalogEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		entries = append(entries, entry)
+	}
+	c.mutex.RUnlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Code < entries[j].Code })
+	return entries
+}
+
+// catalogEntryJSON is the JSON form of a CatalogEntry served by the Handler.
+type catalogEntryJSON struct {
+	Code             string `json:"code"`
+	Kind             string `json:"kind"`
+	Status           int    `json:"status"`
+	Title            string `json:"title"`
+	Description      string `json:"description,omitempty"`
+	DocumentationURL string `json:"documentation_url,omitempty"`
+}
+
+/*
+Handler returns an http.Handler serving the entries of the catalog as JSON, sorted by code, so that the consumers
+of the API can generate their error handling from it:
+
+	{
+		"errors": [
+			{
+				"code": "payment.insufficient_funds",
+				"kind": "InvalidArgument",
+				"status": 402,
+				"title": "Insufficient funds",
+				"description": "The balance of the account does not cover the amount of the payment.",
+				"documentation_url": "https://docs.example.com/errors/payment.insufficient_funds"
+			}
+		]
+	}
+*/
+func (c *Catalog) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		entries := c.Entries()
+		body := struct {
+			Errors []catalogEntryJSON `json:"errors"`
+		}{Errors: make([]catalogEntryJSON, len(entries))}
+		for i, entry := range entries {
+			body.Errors[i] = catalogEntryJSON{
+				Code:             entry.Code,
+				Kind:             entry.Kind.String(),
+				Status:           entry.Status,
+				Title:            entry.Title,
+				Description:      entry.Description,
+				DocumentationURL: entry.DocumentationURL,
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	})
+}
@@ -0,0 +1,144 @@
+package errors_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog_Register(t *testing.T) {
+	catalog := domain_error.NewCatalog()
+	errOverdrawn := catalog.Register(domain_error.CatalogEntry{
+		Code:             "account.overdrawn",
+		Kind:             domain_error.KindInvalidArgument,
+		Status:           http.StatusPaymentRequired,
+		Title:            "Account overdrawn",
+		Description:      "The account has a negative balance.",
+		DocumentationURL: "https://docs.example.com/errors/account.overdrawn",
+	})
+	assert.Equal(t, "account.overdrawn", errOverdrawn.Code())
+	assert.Equal(t, domain_error.KindInvalidArgument, errOverdrawn.Kind())
+	assert.Equal(t, "Account overdrawn", errOverdrawn.Error(), "the title is the message")
+	assert.NotEmpty(t, errOverdrawn.StackTrace())
+
+	errFrozen := catalog.Register(domain_error.CatalogEntry{Code: "account.frozen", Status: http.StatusLocked})
+	assert.Equal(t, domain_error.KindInvalidArgument, errFrozen.Kind(), "the kind of the status")
+	errGone := catalog.Register(domain_error.CatalogEntry{Code: "account.closed", Kind: domain_error.KindNotFound})
+
+	entry, found := catalog.Lookup("account.closed")
+	require.True(t, found)
+	assert.Equal(t, http.StatusNotFound, entry.Status, "the status of the kind")
+	assert.Equal(t, "Not Found", entry.Title, "the text of the status")
+	assert.Equal(t, "account.closed", errGone.Code())
+	entry, found = catalog.Lookup("account.frozen")
+	require.True(t, found)
+	assert.Equal(t, "Locked", entry.Title)
+	_, found = catalog.Lookup("account.unknown")
+	assert.False(t, found)
+
+	entries := catalog.Entries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, []string{"account.closed", "account.frozen", "account.overdrawn"}, []string{entries[0].Code, entries[1].Code, entries[2].Code})
+
+	assert.PanicsWithValue(t, `errors: the code "account.frozen" is already registered`, func() {
+		catalog.Register(domain_error.CatalogEntry{Code: "account.frozen"})
+	})
+	assert.Panics(t, func() { catalog.Register(domain_error.CatalogEntry{}) })
+}
+
+func TestCatalog_ProblemWriter(t *testing.T) {
+	catalog := domain_error.NewCatalog()
+	errOverdrawn := catalog.Register(domain_error.CatalogEntry{
+		Code:             "account.overdrawn",
+		Status:           http.StatusPaymentRequired,
+		Title:            "Account overdrawn",
+		DocumentationURL: "https://docs.example.com/errors/account.overdrawn",
+	})
+	catalog.Register(domain_error.CatalogEntry{Code: "account.frozen", Kind: domain_error.KindConflict, Title: "Account frozen"})
+
+	problems := domain_error.NewProblemWriter(
+		domain_error.WithCatalog(catalog),
+		domain_error.WithTypeBaseURI("https://errors.example.com/"),
+		domain_error.WithProductionMode(true),
+	)
+	request := httptest.NewRequest(http.MethodPost, "/payments", nil)
+
+	problem := problems.Problem(request, fmt.Errorf("charge: %w", errOverdrawn.WithField("account_id", "acc-1")))
+	assert.Equal(t, "https://docs.example.com/errors/account.overdrawn", problem.Type)
+	assert.Equal(t, "Account overdrawn", problem.Title)
+	assert.Equal(t, http.StatusPaymentRequired, problem.Status)
+	assert.Equal(t, "acc-1", problem.Extensions["account_id"])
+
+	// The errors of a code are rendered identically, whatever their origin.
+	other := domain_error.InvalidArgument("account.frozen", "the account is frozen")
+	problem = problems.Problem(request, other)
+	assert.Equal(t, "https://errors.example.com/account.frozen", problem.Type)
+	assert.Equal(t, "Account frozen", problem.Title)
+	assert.Equal(t, http.StatusConflict, problem.Status)
+	assert.Equal(t, "the account is frozen", problem.Detail)
+
+	problem = domain_error.NewProblemWriter(
+		domain_error.WithCatalog(catalog),
+		domain_error.WithCodeStatus("account.frozen", http.StatusLocked),
+	).Problem(request, other)
+	assert.Equal(t, http.StatusLocked, problem.Status, "WithCodeStatus overrides the catalog")
+
+	problem = problems.Problem(request, errors.New("boom"))
+	assert.Equal(t, "Internal Server Error", problem.Title)
+}
+
+func TestCatalog_Handler(t *testing.T) {
+	catalog := domain_error.NewCatalog()
+	catalog.Register(domain_error.CatalogEntry{
+		Code:             "payment.insufficient_funds",
+		Kind:             domain_error.KindInvalidArgument,
+		Status:           http.StatusPaymentRequired,
+		Title:            "Insufficient funds",
+		Description:      "The balance does not cover the amount.",
+		DocumentationURL: "https://docs.example.com/errors/payment.insufficient_funds",
+	})
+	catalog.Register(domain_error.CatalogEntry{Code: "account.closed", Kind: domain_error.KindNotFound, Title: "Account closed"})
+
+	recorder := httptest.NewRecorder()
+	catalog.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/errors", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"errors": [
+		{"code": "account.closed", "kind": "NotFound", "status": 404, "title": "Account closed"},
+		{
+			"code": "payment.insufficient_funds",
+			"kind": "InvalidArgument",
+			"status": 402,
+			"title": "Insufficient funds",
+			"description": "The balance does not cover the amount.",
+			"documentation_url": "https://docs.example.com/errors/payment.insufficient_funds"
+		}
+	]}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	domain_error.NewCatalog().Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/errors", nil))
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, []interface{}{}, body["errors"], "an empty catalog has an empty list")
+
+	recorder = httptest.NewRecorder()
+	catalog.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/errors", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
+
+func TestRegister(t *testing.T) {
+	errLimit := domain_error.Register(domain_error.CatalogEntry{Code: "catalog_test.limit_exceeded", Kind: domain_error.KindUnavailable, Title: "Limit exceeded"})
+	entry, found := domain_error.DefaultCatalog.Lookup("catalog_test.limit_exceeded")
+	require.True(t, found)
+	assert.Equal(t, http.StatusServiceUnavailable, entry.Status)
+
+	problem := domain_error.NewProblemWriter().Problem(nil, errLimit)
+	assert.Equal(t, "Limit exceeded", problem.Title, "the DefaultCatalog is used by default")
+}
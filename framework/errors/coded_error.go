@@ -0,0 +1,263 @@
+package errors
+
+import (
+	stderrors "errors"
+	"net/http"
+	"runtime"
+)
+
+// Kind is the category of a CodedError, telling callers how to react to it independently of its code.
+type Kind int
+
+const (
+	// KindUnknown is the kind of errors not carrying a kind.
+	KindUnknown Kind = iota
+	// KindInvalidArgument is the kind of errors caused by invalid input.
+	KindInvalidArgument
+	// KindNotFound is the kind of errors caused by a missing resource.
+	KindNotFound
+	// KindConflict is the kind of errors caused by the current state of a resource, e.g., a duplicate.
+	KindConflict
+	// KindUnauthenticated is the kind of errors caused by missing or invalid credentials.
+	KindUnauthenticated
+	// KindPermissionDenied is the kind of errors caused by insufficient permissions.
+	KindPermissionDenied
+	// KindUnavailable is the kind of transient errors, e.g., a dependency being down, that may be retried.
+	KindUnavailable
+	// KindInternal is the kind of unexpected errors.
+	KindInternal
+)
+
+// String returns the name of the kind, e.g., "NotFound".
+func (k Kind) String() string {
+	switch k {
+	case KindInvalidArgument:
+		return "InvalidArgument"
+	case KindNotFound:
+		return "NotFound"
+	case KindConflict:
+		return "Conflict"
+	case KindUnauthenticated:
+		return "Unauthenticated"
+	case KindPermissionDenied:
+		return "PermissionDenied"
+	case KindUnavailable:
+		return "Unavailable"
+	case KindInternal:
+		return "Internal"
+	default:
+		return "Unknown"
+	}
+}
+
+// HTTPStatus returns the HTTP status code of the kind. KindUnknown maps to 500.
+func (k Kind) HTTPStatus() int {
+	switch k {
+	case KindInvalidArgument:
+		return http.StatusBadRequest
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindUnauthenticated:
+		return http.StatusUnauthorized
+	case KindPermissionDenied:
+		return http.StatusForbidden
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// kindOfHTTPStatus returns the kind of an HTTP status code, used for DomainErrors.
+func kindOfHTTPStatus(status int) Kind {
+	switch {
+	case status == http.StatusNotFound:
+		return KindNotFound
+	case status == http.StatusConflict:
+		return KindConflict
+	case status == http.StatusUnauthorized:
+		return KindUnauthenticated
+	case status == http.StatusForbidden:
+		return KindPermissionDenied
+	case status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout:
+		return KindUnavailable
+	case status >= 400 && status < 500:
+		return KindInvalidArgument
+	case status >= 500:
+		return KindInternal
+	default:
+		return KindUnknown
+	}
+}
+
+/*
+CodedError is an error carrying a stable code, e.g., "payment.insufficient_funds", a Kind, a human message,
+metadata fields, the error that caused it, and the stack trace of where it was created. Loggers of the
+framework/logger package write this stack trace under stack_trace.
+
+Two CodedErrors with the same code match with errors.Is, so that package-level CodedErrors can be used as
+sentinels, and WithField, WithFields and Wrap return copies annotated at the point they are called.
+
+Example usage:
+
+	var ErrInsufficientFunds = errors.NewCodedError(errors.KindInvalidArgument,
+		"payment.insufficient_funds", "insufficient funds")
+
+	func (s *paymentService) Charge(ctx context.Context, accountID string, amount int64) error {
+		if balance < amount {
+			return ErrInsufficientFunds.WithField("account_id", accountID)
+		}
+		...
+	}
+
+	if stderrors.Is(err, ErrInsufficientFunds) {
+		// Handle error
+	}
+*/
+type CodedError struct {
+	kind    Kind
+	code    string
+	message string
+	fields  map[string]interface{}
+	cause   error
+	stack   []uintptr
+}
+
+// NewCodedError creates a CodedError with the stack trace of where it is called.
+func NewCodedError(kind Kind, code, message string) *CodedError {
+	return &CodedError{kind: kind, code: code, message: message, stack: callers(1)}
+}
+
+// NotFound creates a CodedError of kind KindNotFound.
+func NotFound(code, message string) *CodedError {
+	return &CodedError{kind: KindNotFound, code: code, message: message, stack: callers(1)}
+}
+
+// InvalidArgument creates a CodedError of kind KindInvalidArgument.
+func InvalidArgument(code, message string) *CodedError {
+	return &CodedError{kind: KindInvalidArgument, code: code, message: message, stack: callers(1)}
+}
+
+// Internal creates a CodedError of kind KindInternal.
+func Internal(code, message string) *CodedError {
+	return &CodedError{kind: KindInternal, code: code, message: message, stack: callers(1)}
+}
+
+// Unavailable creates a CodedError of kind KindUnavailable.
+func Unavailable(code, message string) *CodedError {
+	return &CodedError{kind: KindUnavailable, code: code, message: message, stack: callers(1)}
+}
+
+// callers returns the stack trace of the function calling callers, skipping skip more frames.
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	// Skip runtime.Callers and callers.
+	n := runtime.Callers(2+skip, pcs)
+	return pcs[:n]
+}
+
+// Kind returns the kind of the error.
+func (e *CodedError) Kind() Kind {
+	return e.kind
+}
+
+// Code returns the stable code of the error.
+func (e *CodedError) Code() string {
+	return e.code
+}
+
+// Message returns the human message of the error, without its cause.
+func (e *CodedError) Message() string {
+	return e.message
+}
+
+// Fields returns a copy of the metadata fields of the error.
+func (e *CodedError) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(e.fields))
+	for key, value := range e.fields {
+		fields[key] = value
+	}
+	return fields
+}
+
+// Error returns the message of the error, followed by the message of its cause if any.
+func (e *CodedError) Error() string {
+	if e.cause == nil {
+		return e.message
+	}
+	return e.message + ": " + e.cause.Error()
+}
+
+// Unwrap returns the cause of the error.
+func (e *CodedError) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is a CodedError with the same code.
+func (e *CodedError) Is(target error) bool {
+	t, ok := target.(*CodedError)
+	return ok && t.code == e.code
+}
+
+// StackTrace implements the StackTracer interface.
+func (e *CodedError) StackTrace() []uintptr {
+	return e.stack
+}
+
+// WithField returns a copy of the error with the field key set to value, and the stack trace of where it is called.
+func (e *CodedError) WithField(key string, value interface{}) *CodedError {
+	clone := e.clone()
+	clone.fields[key] = value
+	return clone
+}
+
+// WithFields returns a copy of the error with fields added, and the stack trace of where it is called.
+func (e *CodedError) WithFields(fields map[string]interface{}) *CodedError {
+	clone := e.clone()
+	for key, value := range fields {
+		clone.fields[key] = value
+	}
+	return clone
+}
+
+// Wrap returns a copy of the error caused by cause, and the stack trace of where it is called.
+func (e *CodedError) Wrap(cause error) *CodedError {
+	clone := e.clone()
+	clone.cause = cause
+	return clone
+}
+
+// clone returns a copy of e with the stack trace of the caller of the method calling clone.
+func (e *CodedError) clone() *CodedError {
+	clone := *e
+	clone.fields = e.Fields()
+	clone.stack = callers(2)
+	return &clone
+}
+
+// CodeOf returns the code of the first CodedError, or DomainError, in the chain of err, or "" if there is none.
+func CodeOf(err error) string {
+	var coded *CodedError
+	if stderrors.As(err, &coded) {
+		return coded.Code()
+	}
+	if domainErr := UnwrapDomainError(err); domainErr != nil {
+		return domainErr.Code()
+	}
+	return ""
+}
+
+// KindOf returns the kind of the first CodedError in the chain of err, or the kind matching the HTTP status code
+// of the first DomainError. It returns KindUnknown if there is neither.
+func KindOf(err error) Kind {
+	var coded *CodedError
+	if stderrors.As(err, &coded) {
+		return coded.Kind()
+	}
+	if domainErr := UnwrapDomainError(err); domainErr != nil {
+		return kindOfHTTPStatus(domainErr.GetHTTPCode())
+	}
+	return KindUnknown
+}
@@ -0,0 +1,87 @@
+package errors_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"testing"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errInsufficientFunds = domain_error.InvalidArgument("payment.insufficient_funds", "insufficient funds")
+
+func TestCodedError(t *testing.T) {
+	cause := errors.New("balance is 10")
+	err := errInsufficientFunds.WithField("account_id", "acc-1").Wrap(cause)
+
+	assert.Equal(t, "insufficient funds: balance is 10", err.Error())
+	assert.Equal(t, "insufficient funds", err.Message())
+	assert.Equal(t, "payment.insufficient_funds", err.Code())
+	assert.Equal(t, domain_error.KindInvalidArgument, err.Kind())
+	assert.Equal(t, map[string]interface{}{"account_id": "acc-1"}, err.Fields())
+	assert.Empty(t, errInsufficientFunds.Fields(), "copies should not modify the original error")
+
+	wrapped := fmt.Errorf("charge: %w", err)
+	assert.ErrorIs(t, wrapped, errInsufficientFunds)
+	assert.ErrorIs(t, wrapped, cause)
+	assert.NotErrorIs(t, wrapped, domain_error.InvalidArgument("payment.invalid_amount", "invalid amount"))
+
+	var coded *domain_error.CodedError
+	require.ErrorAs(t, wrapped, &coded)
+	assert.Same(t, err, coded)
+
+	var tracer domain_error.StackTracer
+	require.ErrorAs(t, wrapped, &tracer)
+	frame, _ := runtime.CallersFrames(tracer.StackTrace()).Next()
+	assert.Contains(t, frame.Function, "TestCodedError", "the first frame should be where the copy was made")
+	frame, _ = runtime.CallersFrames(domain_error.NotFound("user.not_found", "user not found").StackTrace()).Next()
+	assert.Contains(t, frame.Function, "TestCodedError", "the first frame should be the caller of the constructor")
+}
+
+func TestCodeOfAndKindOf(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode string
+		expectedKind domain_error.Kind
+	}{
+		{name: "nil", err: nil, expectedCode: "", expectedKind: domain_error.KindUnknown},
+		{name: "plain error", err: errors.New("boom"), expectedCode: "", expectedKind: domain_error.KindUnknown},
+		{
+			name:         "wrapped coded error",
+			err:          fmt.Errorf("get user: %w", domain_error.NotFound("user.not_found", "user not found")),
+			expectedCode: "user.not_found",
+			expectedKind: domain_error.KindNotFound,
+		},
+		{
+			name:         "outermost coded error",
+			err:          domain_error.Unavailable("user.store_unavailable", "unavailable").Wrap(domain_error.Internal("db.timeout", "timeout")),
+			expectedCode: "user.store_unavailable",
+			expectedKind: domain_error.KindUnavailable,
+		},
+		{
+			name:         "domain error",
+			err:          fmt.Errorf("get user: %w", domain_error.NewNotFoundError("user not found", nil)),
+			expectedCode: domain_error.GetFullCode(domain_error.StatusCodeGenericNotFoundError),
+			expectedKind: domain_error.KindNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedCode, domain_error.CodeOf(tt.err))
+			assert.Equal(t, tt.expectedKind, domain_error.KindOf(tt.err))
+		})
+	}
+}
+
+func TestKind(t *testing.T) {
+	assert.Equal(t, "NotFound", domain_error.KindNotFound.String())
+	assert.Equal(t, "Unknown", domain_error.KindUnknown.String())
+	assert.Equal(t, http.StatusNotFound, domain_error.KindNotFound.HTTPStatus())
+	assert.Equal(t, http.StatusServiceUnavailable, domain_error.KindUnavailable.HTTPStatus())
+	assert.Equal(t, http.StatusInternalServerError, domain_error.KindUnknown.HTTPStatus())
+}
@@ -0,0 +1,203 @@
+package grpcstatus
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// kindCodes maps the kinds of framework errors to gRPC codes.
+var kindCodes = map[errors.Kind]codes.Code{
+	errors.KindUnknown:          codes.Unknown,
+	errors.KindInvalidArgument:  codes.InvalidArgument,
+	errors.KindNotFound:         codes.NotFound,
+	errors.KindConflict:         codes.AlreadyExists,
+	errors.KindUnauthenticated:  codes.Unauthenticated,
+	errors.KindPermissionDenied: codes.PermissionDenied,
+	errors.KindUnavailable:      codes.Unavailable,
+	errors.KindInternal:         codes.Internal,
+}
+
+// CodeOf returns the gRPC code of kind.
+func CodeOf(kind errors.Kind) codes.Code {
+	if code, found := kindCodes[kind]; found {
+		return code
+	}
+	return codes.Unknown
+}
+
+// KindOf returns the kind of the gRPC code. Codes without a matching kind, e.g., codes.DeadlineExceeded, map to
+// the closest kind.
+func KindOf(code codes.Code) errors.Kind {
+	switch code {
+	case codes.OK:
+		return errors.KindUnknown
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return errors.KindInvalidArgument
+	case codes.NotFound:
+		return errors.KindNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return errors.KindConflict
+	case codes.Unauthenticated:
+		return errors.KindUnauthenticated
+	case codes.PermissionDenied:
+		return errors.KindPermissionDenied
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return errors.KindUnavailable
+	case codes.Internal, codes.DataLoss, codes.Unimplemented:
+		return errors.KindInternal
+	default:
+		return errors.KindUnknown
+	}
+}
+
+/*
+ToStatus converts err to a gRPC status. A CodedError, or a DomainError, in the chain of err gives the gRPC code
+of its kind and its message, and its code and fields are sent in an errdetails.ErrorInfo detail: the code as
+reason, the service prefix as domain, and the fields, formatted with fmt.Sprint, as metadata. The codes registered
+in errors.DefaultCatalog give the gRPC code of the kind of their entry, and an errdetails.Help detail linking to
+its documentation URL, if any. Errors already carrying a gRPC status, e.g., returned by a gRPC client, keep it,
+and other errors give codes.Unknown. A nil err gives an OK status.
+
+Example usage:
+
+	func (s *paymentServer) Charge(ctx context.Context, req *pb.ChargeRequest) (*pb.ChargeResponse, error) {
+		if err := s.service.Charge(ctx, req.AccountId, req.Amount); err != nil {
+			return nil, grpcstatus.ToStatus(err).Err()
+		}
+		...
+	}
+*/
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+
+	var coded *errors.CodedError
+	var info *errdetails.ErrorInfo
+	var st *status.Status
+	if stderrors.As(err, &coded) {
+		st = status.New(CodeOf(coded.Kind()), coded.Message())
+		info = &errdetails.ErrorInfo{Reason: coded.Code(), Domain: errors.GetServicePrefix(), Metadata: metadata(coded.Fields())}
+	} else if domainErr := errors.UnwrapDomainError(err); domainErr != nil {
+		st = status.New(CodeOf(errors.KindOf(domainErr)), domainErr.GetMessage())
+		info = &errdetails.ErrorInfo{Reason: domainErr.Code(), Domain: errors.GetServicePrefix()}
+	} else if s, ok := status.FromError(err); ok {
+		return s
+	} else {
+		return status.New(codes.Unknown, err.Error())
+	}
+
+	// The codes of the catalog have the gRPC code of their kind and a link to their documentation.
+	if entry, found := errors.DefaultCatalog.Lookup(info.GetReason()); found {
+		st = status.New(CodeOf(entry.Kind), st.Message())
+		if entry.DocumentationURL != "" {
+			help := &errdetails.Help{Links: []*errdetails.Help_Link{{Description: entry.Title, Url: entry.DocumentationURL}}}
+			if withDetails, detailsErr := st.WithDetails(info, help); detailsErr == nil {
+				return withDetails
+			}
+			return st
+		}
+	}
+	if withDetails, detailsErr := st.WithDetails(info); detailsErr == nil {
+		return withDetails
+	}
+	return st
+}
+
+// metadata formats fields as ErrorInfo metadata.
+func metadata(fields map[string]interface{}) map[string]string {
+	if len(fields) == 0 {
+		return nil
+	}
+	md := make(map[string]string, len(fields))
+	for key, value := range fields {
+		md[key] = fmt.Sprint(value)
+	}
+	return md
+}
+
+/*
+FromStatus converts a gRPC status to a CodedError, restoring the code and fields sent by ToStatus in an
+errdetails.ErrorInfo detail, and the kind matching the gRPC code. Without an ErrorInfo detail, the code of the
+error is empty. It returns nil for an OK status.
+
+The returned error has the stack trace of the call to FromStatus, and can be wrapped again on the client side.
+*/
+func FromStatus(st *status.Status) *errors.CodedError {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+
+	var code string
+	fields := make(map[string]interface{})
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			code = info.GetReason()
+			for key, value := range info.GetMetadata() {
+				fields[key] = value
+			}
+			break
+		}
+	}
+	return errors.NewCodedError(KindOf(st.Code()), code, st.Message()).WithFields(fields)
+}
+
+/*
+FromError converts an error returned by a gRPC client to a CodedError, see FromStatus. Errors not carrying a
+gRPC status, e.g., a context error, are returned unchanged.
+
+Example usage:
+
+	_, err := client.Charge(ctx, req)
+	if err := grpcstatus.FromError(err); err != nil {
+		if stderrors.Is(err, ErrInsufficientFunds) {
+			// Handle error
+		}
+		return err
+	}
+*/
+func FromError(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.OK {
+		return err
+	}
+	return FromStatus(st)
+}
+
+// UnaryServerInterceptor converts the errors returned by the handlers with ToStatus.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, ToStatus(err).Err()
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor converts the errors returned by the stream handlers with ToStatus.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return ToStatus(err).Err()
+		}
+		return nil
+	}
+}
+
+// UnaryClientInterceptor converts the errors returned by the calls with FromError.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return FromError(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
@@ -0,0 +1,112 @@
+package grpcstatus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/errors/grpcstatus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errInsufficientFunds = domain_error.InvalidArgument("payment.insufficient_funds", "insufficient funds")
+
+func TestToStatus(t *testing.T) {
+	assert.Equal(t, codes.OK, grpcstatus.ToStatus(nil).Code())
+
+	st := grpcstatus.ToStatus(fmt.Errorf("charge: %w", errInsufficientFunds.WithField("account_id", "acc-1").Wrap(errors.New("balance is 10"))))
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, "insufficient funds", st.Message(), "the cause should not cross the boundary")
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, "payment.insufficient_funds", info.GetReason())
+	assert.Equal(t, domain_error.GetServicePrefix(), info.GetDomain())
+	assert.Equal(t, map[string]string{"account_id": "acc-1"}, info.GetMetadata())
+
+	st = grpcstatus.ToStatus(domain_error.NewNotFoundError("user not found", nil))
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "user not found", st.Message())
+
+	original := status.Error(codes.ResourceExhausted, "quota exceeded")
+	assert.Equal(t, codes.ResourceExhausted, grpcstatus.ToStatus(fmt.Errorf("call: %w", original)).Code())
+	assert.Equal(t, codes.Unknown, grpcstatus.ToStatus(errors.New("boom")).Code())
+}
+
+func TestToStatus_Catalog(t *testing.T) {
+	errQuotaExceeded := domain_error.Register(domain_error.CatalogEntry{
+		Code:             "grpcstatus_test.quota_exceeded",
+		Kind:             domain_error.KindUnavailable,
+		Title:            "Quota exceeded",
+		DocumentationURL: "https://docs.example.com/errors/quota_exceeded",
+	})
+	domain_error.Register(domain_error.CatalogEntry{Code: "grpcstatus_test.conflict", Kind: domain_error.KindConflict})
+
+	st := grpcstatus.ToStatus(errQuotaExceeded.WithField("limit", 10))
+	assert.Equal(t, codes.Unavailable, st.Code())
+	assert.Equal(t, "Quota exceeded", st.Message())
+	require.Len(t, st.Details(), 2)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, "grpcstatus_test.quota_exceeded", info.GetReason())
+	help, ok := st.Details()[1].(*errdetails.Help)
+	require.True(t, ok)
+	require.Len(t, help.GetLinks(), 1)
+	assert.Equal(t, "https://docs.example.com/errors/quota_exceeded", help.GetLinks()[0].GetUrl())
+	assert.Equal(t, "Quota exceeded", help.GetLinks()[0].GetDescription())
+
+	// The errors of a code have the gRPC code of the catalog, whatever their kind.
+	st = grpcstatus.ToStatus(domain_error.InvalidArgument("grpcstatus_test.conflict", "duplicate order"))
+	assert.Equal(t, codes.AlreadyExists, st.Code())
+	assert.Equal(t, "duplicate order", st.Message())
+	assert.Len(t, st.Details(), 1)
+}
+
+func TestFromError(t *testing.T) {
+	assert.NoError(t, grpcstatus.FromError(nil))
+	assert.Same(t, context.Canceled, grpcstatus.FromError(context.Canceled))
+
+	sent := errInsufficientFunds.WithField("account_id", "acc-1")
+	received := grpcstatus.FromError(grpcstatus.ToStatus(sent).Err())
+	assert.ErrorIs(t, received, errInsufficientFunds)
+	assert.Equal(t, domain_error.KindInvalidArgument, domain_error.KindOf(received))
+	var coded *domain_error.CodedError
+	require.ErrorAs(t, received, &coded)
+	assert.Equal(t, "insufficient funds", coded.Message())
+	assert.Equal(t, map[string]interface{}{"account_id": "acc-1"}, coded.Fields())
+
+	received = grpcstatus.FromError(status.Error(codes.DeadlineExceeded, "deadline exceeded"))
+	assert.Equal(t, domain_error.KindUnavailable, domain_error.KindOf(received))
+	assert.Empty(t, domain_error.CodeOf(received))
+}
+
+func TestInterceptors(t *testing.T) {
+	server := grpcstatus.UnaryServerInterceptor()
+	_, err := server(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errInsufficientFunds
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	stream := grpcstatus.StreamServerInterceptor()
+	err = stream(nil, nil, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		return errInsufficientFunds
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.NoError(t, stream(nil, nil, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		return nil
+	}))
+
+	client := grpcstatus.UnaryClientInterceptor()
+	err = client(context.Background(), "/payment.Payments/Charge", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return err
+		})
+	assert.ErrorIs(t, err, errInsufficientFunds)
+}
@@ -0,0 +1,101 @@
+package errors
+
+import (
+	"strings"
+	"sync"
+)
+
+/*
+MultiError collects the errors of batch operations or parallel fan-outs, each with a label, e.g., the key or the
+task that failed, so that they are reported together. Like errors.Join, it implements Unwrap() []error, so that
+errors.Is and errors.As match any of its members, and loggers of the framework/logger package write every member,
+with its label, in the error chain.
+
+The zero value is ready to use, and MultiError is safe for concurrent use.
+
+Example usage:
+
+	var errs errors.MultiError
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			if err := s.sync(ctx, id); err != nil {
+				errs.Add(id, err)
+			}
+		}(id)
+	}
+	wg.Wait()
+	return errs.Err()
+*/
+type MultiError struct {
+	mutex  sync.Mutex
+	errs   []error
+	labels []string
+}
+
+// Add adds err with label, which may be empty. It does nothing if err is nil.
+func (m *MultiError) Add(label string, err error) {
+	if err == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.errs = append(m.errs, err)
+	m.labels = append(m.labels, label)
+}
+
+// Len returns the number of errors added.
+func (m *MultiError) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return len(m.errs)
+}
+
+// Err returns m if errors were added, and nil otherwise, so that it can be returned as an error.
+func (m *MultiError) Err() error {
+	if m.Len() == 0 {
+		return nil
+	}
+	return m
+}
+
+// Errors returns the errors added, in order.
+func (m *MultiError) Errors() []error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]error(nil), m.errs...)
+}
+
+// Labels returns the labels of the errors added, in the same order as Errors.
+func (m *MultiError) Labels() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]string(nil), m.labels...)
+}
+
+// Error returns the messages of the errors, prefixed with their label, separated by semicolons.
+func (m *MultiError) Error() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	messages := make([]string, len(m.errs))
+	for i, err := range m.errs {
+		if m.labels[i] == "" {
+			messages[i] = err.Error()
+		} else {
+			messages[i] = m.labels[i] + ": " + err.Error()
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors added, for errors.Is and errors.As.
+func (m *MultiError) Unwrap() []error {
+	return m.Errors()
+}
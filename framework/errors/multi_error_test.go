@@ -0,0 +1,50 @@
+package errors_test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiError(t *testing.T) {
+	var errs domain_error.MultiError
+	assert.NoError(t, errs.Err())
+
+	notFound := domain_error.NotFound("user.not_found", "user not found")
+	timeout := errors.New("timeout")
+	errs.Add("user-1", notFound)
+	errs.Add("user-2", nil)
+	errs.Add("", fmt.Errorf("load: %w", timeout))
+
+	err := errs.Err()
+	require.Error(t, err)
+	assert.Equal(t, 2, errs.Len())
+	assert.Equal(t, "user-1: user not found; load: timeout", err.Error())
+	assert.Equal(t, []string{"user-1", ""}, errs.Labels())
+	assert.ErrorIs(t, err, notFound)
+	assert.ErrorIs(t, fmt.Errorf("sync: %w", err), timeout)
+	var coded *domain_error.CodedError
+	require.ErrorAs(t, err, &coded)
+	assert.Equal(t, "user.not_found", domain_error.CodeOf(err))
+}
+
+func TestMultiError_Concurrent(t *testing.T) {
+	var errs domain_error.MultiError
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs.Add(strconv.Itoa(i), errors.New("failed"))
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 50, errs.Len())
+	assert.Len(t, errs.Errors(), 50)
+}
@@ -0,0 +1,207 @@
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the content type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+/*
+Problem is an RFC 7807 problem details object. Extensions are written as additional members of the JSON object,
+e.g., the "code" of the error.
+*/
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// MarshalJSON writes the problem as a single JSON object, with the extensions as top-level members.
+// Extensions cannot override the standard members.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+	members["type"] = p.Type
+	members["title"] = p.Title
+	members["status"] = p.Status
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	} else {
+		delete(members, "detail")
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	} else {
+		delete(members, "instance")
+	}
+	return json.Marshal(members)
+}
+
+/*
+HTTPStatusOf returns the HTTP status code of err: the status code of the kind of the first CodedError in its
+chain, or the status code of the first DomainError. It returns 500 for other errors.
+*/
+func HTTPStatusOf(err error) int {
+	var coded *CodedError
+	if stderrors.As(err, &coded) {
+		return coded.Kind().HTTPStatus()
+	}
+	if domainErr := UnwrapDomainError(err); domainErr != nil {
+		return domainErr.GetHTTPCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// problemOptions holds configuration options for the ProblemWriter.
+type problemOptions struct {
+	typeBaseURI string
+	production  bool
+	statuses    map[string]int
+	catalog     *Catalog
+}
+
+// ProblemOption specifies ProblemWriter configuration options.
+type ProblemOption func(*problemOptions)
+
+// WithTypeBaseURI sets the URI the codes of the errors are appended to, to build the problem types, e.g.,
+// "https://errors.example.com/" gives "https://errors.example.com/payment.insufficient_funds". Without it,
+// the problem type is "about:blank".
+func WithTypeBaseURI(uri string) ProblemOption {
+	return func(opts *problemOptions) {
+		opts.typeBaseURI = uri
+	}
+}
+
+// WithProductionMode hides the internal details of errors from the problems: server errors have no detail
+// and no extension other than their code, and client errors have their message only, without their cause.
+func WithProductionMode(production bool) ProblemOption {
+	return func(opts *problemOptions) {
+		opts.production = production
+	}
+}
+
+// WithCodeStatus overrides the HTTP status code of the errors with code, e.g., to report a conflict as 422.
+func WithCodeStatus(code string, status int) ProblemOption {
+	return func(opts *problemOptions) {
+		opts.statuses[code] = status
+	}
+}
+
+// WithCatalog sets the catalog of the error codes, rendering the errors of its codes with their title, status and
+// documentation URL. It defaults to DefaultCatalog.
+func WithCatalog(catalog *Catalog) ProblemOption {
+	return func(opts *problemOptions) {
+		if catalog != nil {
+			opts.catalog = catalog
+		}
+	}
+}
+
+/*
+ProblemWriter renders errors as RFC 7807 problem details, so that every service reports errors in the same format.
+The problem status is given by HTTPStatusOf unless overridden with WithCodeStatus, and the problem carries the
+code of the error, the fields of a CodedError and the data of a DomainError as extensions. The errors whose code is
+registered in the Catalog have the status, the title and the documentation URL, as type, of its entry.
+
+Example usage:
+
+	problems := errors.NewProblemWriter(
+		errors.WithTypeBaseURI("https://errors.example.com/"),
+		errors.WithProductionMode(env == "production"),
+	)
+
+	func (h *paymentHandler) Charge(w http.ResponseWriter, r *http.Request) {
+		if err := h.service.Charge(r.Context(), accountID, amount); err != nil {
+			problems.Write(w, r, err)
+			return
+		}
+		...
+	}
+*/
+type ProblemWriter struct {
+	opts problemOptions
+}
+
+// NewProblemWriter creates a ProblemWriter.
+func NewProblemWriter(options ...ProblemOption) *ProblemWriter {
+	opts := &problemOptions{statuses: make(map[string]int), catalog: DefaultCatalog}
+	for _, opt := range options {
+		opt(opts)
+	}
+	return &ProblemWriter{opts: *opts}
+}
+
+// Problem returns the problem details of err, for the request r. r may be nil, in which case the problem has
+// no instance.
+func (w *ProblemWriter) Problem(r *http.Request, err error) Problem {
+	code := CodeOf(err)
+	entry, cataloged := w.opts.catalog.Lookup(code)
+	status := HTTPStatusOf(err)
+	if cataloged {
+		status = entry.Status
+	}
+	if override, found := w.opts.statuses[code]; found {
+		status = override
+	}
+
+	problem := Problem{
+		Type:       "about:blank",
+		Title:      http.StatusText(status),
+		Status:     status,
+		Extensions: make(map[string]interface{}),
+	}
+	if code != "" {
+		problem.Extensions["code"] = code
+		if w.opts.typeBaseURI != "" {
+			problem.Type = strings.TrimSuffix(w.opts.typeBaseURI, "/") + "/" + code
+		}
+	}
+	if cataloged {
+		problem.Title = entry.Title
+		if entry.DocumentationURL != "" {
+			problem.Type = entry.DocumentationURL
+		}
+	}
+	if r != nil && r.URL != nil {
+		problem.Instance = r.URL.Path
+	}
+	if w.opts.production && status >= http.StatusInternalServerError {
+		return problem
+	}
+
+	var coded *CodedError
+	if stderrors.As(err, &coded) {
+		for key, value := range coded.fields {
+			if _, reserved := problem.Extensions[key]; !reserved {
+				problem.Extensions[key] = value
+			}
+		}
+		problem.Detail = coded.Message()
+	} else if domainErr := UnwrapDomainError(err); domainErr != nil {
+		if data := domainErr.GetData(); data != nil {
+			problem.Extensions["data"] = data
+		}
+		problem.Detail = domainErr.GetMessage()
+	}
+	if !w.opts.production && err != nil {
+		problem.Detail = err.Error()
+	}
+	return problem
+}
+
+// Write writes the problem details of err as the response to r, with the ProblemContentType content type.
+func (w *ProblemWriter) Write(rw http.ResponseWriter, r *http.Request, err error) {
+	problem := w.Problem(r, err)
+	rw.Header().Set("Content-Type", ProblemContentType)
+	rw.WriteHeader(problem.Status)
+	_ = json.NewEncoder(rw).Encode(problem)
+}
@@ -0,0 +1,125 @@
+package errors_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPStatusOf(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, domain_error.HTTPStatusOf(fmt.Errorf("get: %w", domain_error.NotFound("user.not_found", "user not found"))))
+	assert.Equal(t, http.StatusConflict, domain_error.HTTPStatusOf(domain_error.NewConflictError("duplicate", nil)))
+	assert.Equal(t, http.StatusInternalServerError, domain_error.HTTPStatusOf(errors.New("boom")))
+}
+
+func TestProblemWriter(t *testing.T) {
+	cause := errors.New("balance is 10")
+	clientErr := fmt.Errorf("charge: %w", errInsufficientFunds.WithField("account_id", "acc-1").Wrap(cause))
+	serverErr := domain_error.Internal("db.timeout", "database timeout").WithField("query", "SELECT 1")
+
+	tests := []struct {
+		name            string
+		options         []domain_error.ProblemOption
+		err             error
+		expectedProblem map[string]interface{}
+	}{
+		{
+			name:    "client error",
+			options: []domain_error.ProblemOption{domain_error.WithTypeBaseURI("https://errors.example.com/")},
+			err:     clientErr,
+			expectedProblem: map[string]interface{}{
+				"type":       "https://errors.example.com/payment.insufficient_funds",
+				"title":      "Bad Request",
+				"status":     float64(http.StatusBadRequest),
+				"detail":     "charge: insufficient funds: balance is 10",
+				"instance":   "/payments",
+				"code":       "payment.insufficient_funds",
+				"account_id": "acc-1",
+			},
+		},
+		{
+			name:    "client error in production mode",
+			options: []domain_error.ProblemOption{domain_error.WithProductionMode(true)},
+			err:     clientErr,
+			expectedProblem: map[string]interface{}{
+				"type":       "about:blank",
+				"title":      "Bad Request",
+				"status":     float64(http.StatusBadRequest),
+				"detail":     "insufficient funds",
+				"instance":   "/payments",
+				"code":       "payment.insufficient_funds",
+				"account_id": "acc-1",
+			},
+		},
+		{
+			name:    "server error in production mode",
+			options: []domain_error.ProblemOption{domain_error.WithProductionMode(true)},
+			err:     serverErr,
+			expectedProblem: map[string]interface{}{
+				"type":     "about:blank",
+				"title":    "Internal Server Error",
+				"status":   float64(http.StatusInternalServerError),
+				"instance": "/payments",
+				"code":     "db.timeout",
+			},
+		},
+		{
+			name:    "overridden status",
+			options: []domain_error.ProblemOption{domain_error.WithCodeStatus("payment.insufficient_funds", http.StatusPaymentRequired), domain_error.WithProductionMode(true)},
+			err:     errInsufficientFunds,
+			expectedProblem: map[string]interface{}{
+				"type":     "about:blank",
+				"title":    "Payment Required",
+				"status":   float64(http.StatusPaymentRequired),
+				"detail":   "insufficient funds",
+				"instance": "/payments",
+				"code":     "payment.insufficient_funds",
+			},
+		},
+		{
+			name:    "domain error",
+			options: []domain_error.ProblemOption{domain_error.WithProductionMode(true)},
+			err:     domain_error.NewNotFoundError("user not found", map[string]string{"id": "42"}),
+			expectedProblem: map[string]interface{}{
+				"type":     "about:blank",
+				"title":    "Not Found",
+				"status":   float64(http.StatusNotFound),
+				"detail":   "user not found",
+				"instance": "/payments",
+				"code":     domain_error.GetFullCode(domain_error.StatusCodeGenericNotFoundError),
+				"data":     map[string]interface{}{"id": "42"},
+			},
+		},
+		{
+			name:    "plain error in production mode",
+			options: []domain_error.ProblemOption{domain_error.WithProductionMode(true)},
+			err:     errors.New("connection refused"),
+			expectedProblem: map[string]interface{}{
+				"type":     "about:blank",
+				"title":    "Internal Server Error",
+				"status":   float64(http.StatusInternalServerError),
+				"instance": "/payments",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/payments", nil)
+			domain_error.NewProblemWriter(tt.options...).Write(recorder, request, tt.err)
+
+			assert.Equal(t, int(tt.expectedProblem["status"].(float64)), recorder.Code)
+			assert.Equal(t, domain_error.ProblemContentType, recorder.Header().Get("Content-Type"))
+			var problem map[string]interface{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+			assert.Equal(t, tt.expectedProblem, problem)
+		})
+	}
+}
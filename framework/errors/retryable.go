@@ -0,0 +1,100 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+)
+
+// RetryableError is implemented by errors telling whether the operation that failed may succeed if retried.
+// Use MarkRetryable and MarkPermanent to classify errors where they originate.
+type RetryableError interface {
+	error
+	Retryable() bool
+}
+
+// retryableError classifies an error as retryable or permanent.
+type retryableError struct {
+	err       error
+	retryable bool
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// Retryable implements the RetryableError interface.
+func (e *retryableError) Retryable() bool {
+	return e.retryable
+}
+
+/*
+MarkRetryable marks err as transient, e.g., a timeout or a rate limit, so that retries and circuit breakers retry
+the operation. If err is nil, MarkRetryable returns nil.
+
+Example usage:
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.MarkRetryable(err)
+	}
+	if resp.StatusCode >= 500 {
+		return errors.MarkRetryable(fmt.Errorf("payment provider returned %s", resp.Status))
+	}
+*/
+func MarkRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryableError{err: err, retryable: true}
+}
+
+// MarkPermanent marks err as permanent, e.g., a validation error, so that retries stop at once. If err is nil,
+// MarkPermanent returns nil.
+func MarkPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryableError{err: err, retryable: false}
+}
+
+// retryClassification returns whether err is retryable, and whether it is classified at all: by the outermost
+// RetryableError of its chain, or else by the kind of its CodedError or DomainError, or as a context error.
+func retryClassification(err error) (retryable, classified bool) {
+	if err == nil {
+		return false, false
+	}
+	var marked RetryableError
+	if stderrors.As(err, &marked) {
+		return marked.Retryable(), true
+	}
+	if stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+		return false, true
+	}
+	switch KindOf(err) {
+	case KindUnavailable:
+		return true, true
+	case KindInvalidArgument, KindNotFound, KindConflict, KindUnauthenticated, KindPermissionDenied:
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// Retryable reports whether err is marked retryable with MarkRetryable, or is a CodedError, or DomainError, of
+// kind KindUnavailable. Errors not classified, e.g., plain errors, are not retryable.
+func Retryable(err error) bool {
+	retryable, _ := retryClassification(err)
+	return retryable
+}
+
+// IsPermanent reports whether err is marked permanent with MarkPermanent, is a context error, or is a CodedError,
+// or DomainError, of a client kind, e.g., KindInvalidArgument. Unlike Retryable, errors not classified are not
+// permanent, so that retries of unknown errors can be allowed by checking !IsPermanent(err).
+func IsPermanent(err error) bool {
+	retryable, classified := retryClassification(err)
+	return classified && !retryable
+}
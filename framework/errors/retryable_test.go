@@ -0,0 +1,53 @@
+package errors_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryable(t *testing.T) {
+	timeout := errors.New("timeout")
+	tests := []struct {
+		name              string
+		err               error
+		expectedRetryable bool
+		expectedPermanent bool
+	}{
+		{name: "nil", err: nil},
+		{name: "plain error", err: timeout},
+		{name: "marked retryable", err: fmt.Errorf("call: %w", domain_error.MarkRetryable(timeout)), expectedRetryable: true},
+		{name: "marked permanent", err: domain_error.MarkPermanent(timeout), expectedPermanent: true},
+		{
+			name:              "outermost mark wins",
+			err:               domain_error.MarkPermanent(domain_error.MarkRetryable(timeout)),
+			expectedPermanent: true,
+		},
+		{name: "unavailable kind", err: domain_error.Unavailable("db.unavailable", "database unavailable"), expectedRetryable: true},
+		{name: "client kind", err: domain_error.NotFound("user.not_found", "user not found"), expectedPermanent: true},
+		{name: "internal kind", err: domain_error.Internal("db.corrupted", "corrupted row")},
+		{name: "domain error", err: domain_error.NewBadRequestError("invalid id", nil), expectedPermanent: true},
+		{name: "context canceled", err: fmt.Errorf("call: %w", context.Canceled), expectedPermanent: true},
+		{
+			name:              "marked retryable coded error",
+			err:               domain_error.MarkRetryable(domain_error.Internal("db.deadlock", "deadlock")),
+			expectedRetryable: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedRetryable, domain_error.Retryable(tt.err))
+			assert.Equal(t, tt.expectedPermanent, domain_error.IsPermanent(tt.err))
+		})
+	}
+
+	marked := domain_error.MarkRetryable(timeout)
+	assert.ErrorIs(t, marked, timeout)
+	assert.Equal(t, "timeout", marked.Error())
+	assert.NoError(t, domain_error.MarkRetryable(nil))
+	assert.NoError(t, domain_error.MarkPermanent(nil))
+}
@@ -0,0 +1,73 @@
+package errors
+
+import (
+	"runtime"
+)
+
+// maxStackDepth is the maximum number of frames recorded by WithStack.
+const maxStackDepth = 32
+
+// StackTracer is implemented by errors carrying the stack trace of where they were created.
+// The stack trace is a list of program counters, as returned by runtime.Callers.
+type StackTracer interface {
+	StackTrace() []uintptr
+}
+
+// stackError annotates an error with the stack trace of where it was created.
+type stackError struct {
+	err   error
+	stack []uintptr
+}
+
+func (e *stackError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stackError) Unwrap() error {
+	return e.err
+}
+
+// StackTrace implements the StackTracer interface.
+func (e *stackError) StackTrace() []uintptr {
+	return e.stack
+}
+
+/*
+WithStack annotates err with the stack trace at the point WithStack was called, so loggers can report where the error
+originated instead of where it was logged. If err is nil, WithStack returns nil. If err already carries a stack trace,
+it is returned unchanged.
+
+Example usage:
+
+	func (r *userRepository) Get(ctx context.Context, id string) (*User, error) {
+		user, err := r.db.Get(ctx, id)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return user, nil
+	}
+*/
+func WithStack(err error) error {
+	if err == nil || HasStackTrace(err) {
+		return err
+	}
+	pcs := make([]uintptr, maxStackDepth)
+	// Skip runtime.Callers and WithStack.
+	n := runtime.Callers(2, pcs)
+	return &stackError{err: err, stack: pcs[:n]}
+}
+
+// HasStackTrace reports whether err or one of the errors it wraps implements StackTracer.
+func HasStackTrace(err error) bool {
+	for err != nil {
+		if _, ok := err.(StackTracer); ok {
+			return true
+		}
+		unwrapper, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return false
+		}
+		err = unwrapper.Unwrap()
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package errors_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithStack(t *testing.T) {
+	assert.Nil(t, domain_error.WithStack(nil))
+
+	original := errors.New("database unavailable")
+	err := domain_error.WithStack(original)
+	require.Error(t, err)
+	assert.Equal(t, "database unavailable", err.Error())
+	assert.ErrorIs(t, err, original)
+	assert.True(t, domain_error.HasStackTrace(err))
+	assert.False(t, domain_error.HasStackTrace(original))
+
+	var tracer domain_error.StackTracer
+	require.ErrorAs(t, err, &tracer)
+	frame, _ := runtime.CallersFrames(tracer.StackTrace()).Next()
+	assert.Contains(t, frame.Function, "TestWithStack", "the first frame should be the caller of WithStack")
+
+	// Errors already carrying a stack trace are not annotated again.
+	wrapped := fmt.Errorf("get user: %w", err)
+	assert.Same(t, wrapped, domain_error.WithStack(wrapped))
+}
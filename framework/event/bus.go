@@ -0,0 +1,144 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+// DefaultMaxAttempts is the number of times a message is handled before it is dropped, unless set with
+// WithMaxAttempts.
+const DefaultMaxAttempts = 3
+
+// DefaultBackoff is the delay between the attempts to handle a message, unless set with WithBackoff.
+var DefaultBackoff = retry.Exponential(100*time.Millisecond, 2.0, 0.2)
+
+// ErrClosed is returned by the publishers and the subscribers once they are closed.
+var ErrClosed = errors.New("event: the bus is closed")
+
+// Message is an event published on a topic, with a payload of type T.
+type Message[T any] struct {
+	// ID identifies the message. It is generated on publishing if empty.
+	ID string
+	// Topic is the topic the message is published on. It is set on publishing.
+	Topic string
+	// Key routes the messages, e.g., to a Kafka partition: the messages with the same key are handled in order.
+	Key string
+	// Payload is the content of the message.
+	Payload T
+	// Metadata are the headers of the message, e.g., the trace context injected by PublisherTracing.
+	Metadata map[string]string
+	// Timestamp is the time the message was published. It is set on publishing if zero.
+	Timestamp time.Time
+	// Attempt is the number of the delivery of the message to the handler, from 1.
+	Attempt int
+}
+
+// Handler handles the messages of a subscription. A message whose handler returns an error is handled again, up to
+// the attempts of WithMaxAttempts, unless the error is permanent, see errors.IsPermanent.
+type Handler[T any] func(ctx context.Context, msg Message[T]) error
+
+// Publisher publishes messages on topics.
+type Publisher[T any] interface {
+	// Publish publishes the messages on topic. It returns once they are stored by the bus, before they are handled.
+	Publish(ctx context.Context, topic string, msgs ...Message[T]) error
+}
+
+// Subscriber subscribes to topics.
+type Subscriber[T any] interface {
+	// Subscribe handles the messages of topic with handler until ctx is done, and returns nil then. The messages are
+	// delivered at least once: those not handled when ctx is done are delivered again to the next subscription of
+	// the group. It blocks, like a lifecycle.Runnable.
+	Subscribe(ctx context.Context, topic string, handler Handler[T], opts ...SubscribeOption) error
+}
+
+// SubscribeOptions holds the configuration of a subscription, read by the implementations of Subscriber with
+// NewSubscribeOptions.
+type SubscribeOptions struct {
+	// Group is the consumer group of the subscription: every group receives every message of the topic, and the
+	// subscriptions of a group share its messages.
+	Group string
+	// MaxAttempts is the number of times a message is handled before it is dropped.
+	MaxAttempts int
+	// Backoff is the delay between the attempts to handle a message.
+	Backoff retry.Backoff
+	// OnDropped is called with the messages dropped after their attempts, with the error of the last one.
+	OnDropped func(ctx context.Context, topic, id string, err error)
+}
+
+// SubscribeOption specifies subscription configuration options.
+type SubscribeOption func(*SubscribeOptions)
+
+// WithGroup sets the consumer group of the subscription. It defaults to the empty group.
+func WithGroup(group string) SubscribeOption {
+	return func(opts *SubscribeOptions) {
+		opts.Group = group
+	}
+}
+
+// WithMaxAttempts sets the number of times a message is handled before it is dropped. It defaults to
+// DefaultMaxAttempts.
+func WithMaxAttempts(n int) SubscribeOption {
+	return func(opts *SubscribeOptions) {
+		if n > 0 {
+			opts.MaxAttempts = n
+		}
+	}
+}
+
+// WithBackoff sets the delay between the attempts to handle a message. It defaults to DefaultBackoff.
+func WithBackoff(backoff retry.Backoff) SubscribeOption {
+	return func(opts *SubscribeOptions) {
+		if backoff != nil {
+			opts.Backoff = backoff
+		}
+	}
+}
+
+// WithOnDropped sets the function called with the messages dropped after their attempts, e.g., to store them in a
+// dead letter queue. It defaults to logging them with the logger of the context, see logger.FromContext.
+func WithOnDropped(onDropped func(ctx context.Context, topic, id string, err error)) SubscribeOption {
+	return func(opts *SubscribeOptions) {
+		if onDropped != nil {
+			opts.OnDropped = onDropped
+		}
+	}
+}
+
+// NewSubscribeOptions returns the subscription options set with opts and the defaults.
+func NewSubscribeOptions(opts ...SubscribeOption) SubscribeOptions {
+	o := SubscribeOptions{
+		MaxAttempts: DefaultMaxAttempts,
+		Backoff:     DefaultBackoff,
+		OnDropped: func(ctx context.Context, topic, id string, err error) {
+			logger.FromContext(ctx).Error(ctx, "Event dropped", err, logger.Fields{"topic": topic, "id": id})
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Deliver handles msg with handler, with the attempts and the backoff of opts, and calls OnDropped if it is not
+// handled. It returns the error of the last attempt, or the error of ctx if it is done before the message is
+// handled, in which case the message must be delivered again. It is meant for the implementations of Subscriber.
+func Deliver[T any](ctx context.Context, msg Message[T], handler Handler[T], opts SubscribeOptions) error {
+	attempt := 0
+	err := retry.Do(ctx, func(ctx context.Context) error {
+		attempt++
+		msg.Attempt = attempt
+		return handler(ctx, msg)
+	}, retry.WithMaxAttempts(opts.MaxAttempts), retry.WithBackoff(opts.Backoff))
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	opts.OnDropped(ctx, msg.Topic, msg.ID, err)
+	return err
+}
@@ -0,0 +1,91 @@
+package event_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+func TestDeliver(t *testing.T) {
+	errHandle := errors.New("handle failed")
+	tests := []struct {
+		name     string
+		failures int
+		err      error
+		attempts []int
+		dropped  bool
+	}{
+		{name: "handled", attempts: []int{1}},
+		{name: "handled after a retry", failures: 1, err: errHandle, attempts: []int{1, 2}},
+		{name: "dropped after the attempts", failures: 3, err: errHandle, attempts: []int{1, 2, 3}, dropped: true},
+		{name: "dropped on a permanent error", failures: 3, err: domain_error.MarkPermanent(errHandle), attempts: []int{1}, dropped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts []int
+			handler := func(ctx context.Context, msg event.Message[string]) error {
+				attempts = append(attempts, msg.Attempt)
+				if len(attempts) <= tt.failures {
+					return tt.err
+				}
+				return nil
+			}
+			var dropped []string
+			opts := event.NewSubscribeOptions(
+				event.WithBackoff(retry.Constant(0)),
+				event.WithOnDropped(func(ctx context.Context, topic, id string, err error) {
+					assert.ErrorIs(t, err, errHandle)
+					dropped = append(dropped, topic+"/"+id)
+				}),
+			)
+
+			err := event.Deliver(context.Background(), event.Message[string]{ID: "1", Topic: "orders"}, handler, opts)
+			assert.Equal(t, tt.attempts, attempts)
+			if tt.dropped {
+				assert.ErrorIs(t, err, errHandle)
+				assert.Equal(t, []string{"orders/1"}, dropped)
+			} else {
+				assert.NoError(t, err)
+				assert.Empty(t, dropped)
+			}
+		})
+	}
+}
+
+func TestDeliver_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := func(ctx context.Context, msg event.Message[string]) error {
+		cancel()
+		return errors.New("interrupted")
+	}
+	var dropped bool
+	opts := event.NewSubscribeOptions(event.WithOnDropped(func(context.Context, string, string, error) { dropped = true }))
+
+	err := event.Deliver(ctx, event.Message[string]{ID: "1"}, handler, opts)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, dropped, "a message interrupted by the end of the subscription must be delivered again")
+}
+
+func TestNewSubscribeOptions(t *testing.T) {
+	opts := event.NewSubscribeOptions(event.WithMaxAttempts(0), event.WithBackoff(nil), event.WithOnDropped(nil))
+	assert.Equal(t, event.DefaultMaxAttempts, opts.MaxAttempts)
+	assert.Empty(t, opts.Group)
+	require.NotNil(t, opts.Backoff)
+
+	log, recorder := logger.NewTestLogger()
+	ctx := logger.NewContext(context.Background(), log)
+	opts.OnDropped(ctx, "orders", "1", errors.New("handle failed"))
+	recorder.AssertLogged(t, logger.ERROR, "Event dropped", logger.HasField("topic", "orders"), logger.HasField("id", "1"))
+
+	opts = event.NewSubscribeOptions(event.WithGroup("billing"), event.WithMaxAttempts(5))
+	assert.Equal(t, "billing", opts.Group)
+	assert.Equal(t, 5, opts.MaxAttempts)
+}
@@ -0,0 +1,80 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Content types of the codecs, sent by the buses in a header of the messages.
+const (
+	ContentTypeJSON  = "application/json"
+	ContentTypeProto = "application/x-protobuf"
+)
+
+// Codec encodes the payloads of the messages for the buses over a broker, and decodes them back.
+type Codec[T any] interface {
+	// ContentType returns the content type of the encoded payloads.
+	ContentType() string
+	// Marshal encodes payload.
+	Marshal(payload T) ([]byte, error)
+	// Unmarshal decodes data.
+	Unmarshal(data []byte) (T, error)
+}
+
+type jsonCodec[T any] struct{}
+
+// JSONCodec returns a Codec encoding the payloads in JSON. It is the default codec.
+func JSONCodec[T any]() Codec[T] {
+	return jsonCodec[T]{}
+}
+
+func (jsonCodec[T]) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (jsonCodec[T]) Marshal(payload T) ([]byte, error) {
+	return json.Marshal(payload)
+}
+
+func (jsonCodec[T]) Unmarshal(data []byte) (T, error) {
+	var payload T
+	err := json.Unmarshal(data, &payload)
+	return payload, err
+}
+
+type protoCodec[T proto.Message] struct{}
+
+/*
+ProtoCodec returns a Codec encoding the payloads, generated protocol buffer messages, in the protobuf binary
+format.
+
+Example usage:
+
+	bus := kafkabus.New[*orderpb.OrderPlaced](producer, consumers,
+		kafkabus.WithCodec(event.ProtoCodec[*orderpb.OrderPlaced]()),
+	)
+*/
+func ProtoCodec[T proto.Message]() Codec[T] {
+	return protoCodec[T]{}
+}
+
+func (protoCodec[T]) ContentType() string {
+	return ContentTypeProto
+}
+
+func (protoCodec[T]) Marshal(payload T) ([]byte, error) {
+	return proto.Marshal(payload)
+}
+
+func (protoCodec[T]) Unmarshal(data []byte) (T, error) {
+	// The generated messages reflect their type even when nil, so a new one can be created from the zero value.
+	var zero T
+	payload, ok := zero.ProtoReflect().New().Interface().(T)
+	if !ok {
+		return zero, fmt.Errorf("event: cannot create a %T", zero)
+	}
+	err := proto.Unmarshal(data, payload)
+	return payload, err
+}
@@ -0,0 +1,46 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/kittipat1413/go-common/framework/event"
+)
+
+type codecOrder struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+func TestJSONCodec(t *testing.T) {
+	codec := event.JSONCodec[codecOrder]()
+	assert.Equal(t, event.ContentTypeJSON, codec.ContentType())
+
+	data, err := codec.Marshal(codecOrder{ID: "1", Amount: 42})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"1","amount":42}`, string(data))
+	decoded, err := codec.Unmarshal(data)
+	require.NoError(t, err)
+	assert.Equal(t, codecOrder{ID: "1", Amount: 42}, decoded)
+
+	_, err = codec.Unmarshal([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestProtoCodec(t *testing.T) {
+	codec := event.ProtoCodec[*wrapperspb.StringValue]()
+	assert.Equal(t, event.ContentTypeProto, codec.ContentType())
+
+	data, err := codec.Marshal(wrapperspb.String("order:1"))
+	require.NoError(t, err)
+	decoded, err := codec.Unmarshal(data)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(wrapperspb.String("order:1"), decoded))
+
+	_, err = codec.Unmarshal([]byte{0xff})
+	assert.Error(t, err)
+}
@@ -0,0 +1,252 @@
+package event
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+// Metadata set by HandlerDeadLetter on the messages published to a dead-letter topic, in addition to their own.
+const (
+	MetadataDeadLetterTopic    = "dead-letter-topic"
+	MetadataDeadLetterHandler  = "dead-letter-handler"
+	MetadataDeadLetterError    = "dead-letter-error"
+	MetadataDeadLetterAttempts = "dead-letter-attempts"
+	MetadataDeadLetterFailedAt = "dead-letter-failed-at"
+)
+
+// ErrPanic is wrapped by the errors HandlerRecovery converts the panics of the handlers to.
+var ErrPanic = stderrors.New("event: handler panicked")
+
+// consumerOptions holds configuration options for the consumer middlewares.
+type consumerOptions struct {
+	handler         string                    // handler is the name of the handler, logged and used as metrics label.
+	logger          logger.Logger             // logger is the logger, or the logger of the context if nil.
+	metrics         *Metrics                  // metrics records the retries, dead letters and panics if set.
+	deadLetterTopic func(topic string) string // deadLetterTopic returns the dead-letter topic of a topic.
+	poisonAttempts  int                       // poisonAttempts is the attempt from which a failing message is poison.
+}
+
+// ConsumerOption specifies consumer middleware configuration options.
+type ConsumerOption func(*consumerOptions)
+
+// WithHandlerName sets the name of the handler, logged and used as handler label of the metrics, e.g., the group of
+// the subscription. It defaults to the empty name.
+func WithHandlerName(name string) ConsumerOption {
+	return func(opts *consumerOptions) {
+		opts.handler = name
+	}
+}
+
+// WithLogger sets the logger of the middleware. It defaults to the logger of the context, see logger.FromContext.
+func WithLogger(l logger.Logger) ConsumerOption {
+	return func(opts *consumerOptions) {
+		if l != nil {
+			opts.logger = l
+		}
+	}
+}
+
+// WithMetrics records the retries, dead letters and panics of the handler in m. They are not recorded by default.
+func WithMetrics(m *Metrics) ConsumerOption {
+	return func(opts *consumerOptions) {
+		if m != nil {
+			opts.metrics = m
+		}
+	}
+}
+
+// WithDeadLetterTopic sets the function returning the dead-letter topic of a topic. It defaults to DeadLetterTopic.
+func WithDeadLetterTopic(deadLetterTopic func(topic string) string) ConsumerOption {
+	return func(opts *consumerOptions) {
+		if deadLetterTopic != nil {
+			opts.deadLetterTopic = deadLetterTopic
+		}
+	}
+}
+
+// WithPoisonAttempts sets the attempt from which a message whose handler fails is poison, and published to the
+// dead-letter topic. It defaults to DefaultMaxAttempts, the last attempt of the subscriptions by default; 1
+// dead-letters the messages at their first failure, e.g., once HandlerRetry gave up. The messages without attempt,
+// e.g., from a Pub/Sub subscription without dead-letter policy, are dead-lettered on permanent errors only.
+func WithPoisonAttempts(n int) ConsumerOption {
+	return func(opts *consumerOptions) {
+		if n > 0 {
+			opts.poisonAttempts = n
+		}
+	}
+}
+
+func newConsumerOptions(opts []ConsumerOption) consumerOptions {
+	o := consumerOptions{deadLetterTopic: DeadLetterTopic, poisonAttempts: DefaultMaxAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// consumerFields returns the log fields of msg handled by the handler of o.
+func consumerFields[T any](o consumerOptions, msg Message[T]) logger.Fields {
+	return logger.Fields{"topic": msg.Topic, "id": msg.ID, "attempt": msg.Attempt, "handler": o.handler}
+}
+
+// DeadLetterTopic returns the default dead-letter topic of topic, "<topic>.dead-letter".
+func DeadLetterTopic(topic string) string {
+	return topic + ".dead-letter"
+}
+
+/*
+HandlerRetry returns a Middleware retrying the handler in place, up to maxAttempts times with the backoff, or
+DefaultBackoff if nil, unless its error is permanent, see errors.IsPermanent. It returns the error of the last
+attempt. Every retry is logged at warn level, and counted if WithMetrics is set.
+
+It suits the transient failures of the dependencies of the handler, retried without redelivering the message, e.g.,
+with Pub/Sub, whose redeliveries are bound by the dead-letter policy of the subscription.
+
+Example usage:
+
+	handler := event.ChainHandler(handleOrder,
+		event.HandlerRetry[Order](5, retry.Exponential(100*time.Millisecond, 2.0, 0.2), event.WithHandlerName("billing")),
+	)
+*/
+func HandlerRetry[T any](maxAttempts int, backoff retry.Backoff, opts ...ConsumerOption) Middleware[T] {
+	o := newConsumerOptions(opts)
+	if backoff == nil {
+		backoff = DefaultBackoff
+	}
+	return func(next Handler[T]) Handler[T] {
+		return func(ctx context.Context, msg Message[T]) error {
+			err := retry.Do(ctx, func(ctx context.Context) error {
+				return next(ctx, msg)
+			},
+				retry.WithMaxAttempts(maxAttempts),
+				retry.WithBackoff(backoff),
+				retry.WithOnRetry(func(attempt int, err error, delay time.Duration) {
+					fields := consumerFields(o, msg)
+					fields["retry"] = attempt
+					fields["delay_ms"] = delay.Milliseconds()
+					fields["error"] = err.Error()
+					loggerOrContext(ctx, o.logger).Warn(ctx, "Retrying event", fields)
+					if o.metrics != nil {
+						o.metrics.retried.Inc(msg.Topic, o.handler)
+					}
+				}),
+			)
+			var retryErr *retry.Error
+			if stderrors.As(err, &retryErr) {
+				return retryErr.Err
+			}
+			return err
+		}
+	}
+}
+
+/*
+HandlerDeadLetter returns a Middleware publishing the poison messages to their dead-letter topic with publisher,
+rather than failing: the messages whose handler fails with a permanent error, see errors.IsPermanent, or at the
+attempt of WithPoisonAttempts or later. The messages keep their ID, key, payload and metadata, to which the
+failure is added: the topic, the handler, the error, the attempt and the time, see MetadataDeadLetterTopic. They
+are logged at error level, and counted if WithMetrics is set.
+
+Once a message is published to the dead-letter topic, the middleware returns nil, so that the bus acknowledges it.
+If it cannot be published, the middleware returns the errors of the handler and the publisher, so that the message
+is not lost while its attempts are left.
+
+Example usage:
+
+	handler := event.ChainHandler(handleOrder,
+		event.HandlerDeadLetter[Order](bus, event.WithHandlerName("billing"), event.WithMetrics(eventMetrics)),
+		event.HandlerRecovery[Order](event.WithHandlerName("billing")),
+	)
+	err := bus.Subscribe(ctx, "orders", handler, event.WithGroup("billing"))
+*/
+func HandlerDeadLetter[T any](publisher Publisher[T], opts ...ConsumerOption) Middleware[T] {
+	o := newConsumerOptions(opts)
+	return func(next Handler[T]) Handler[T] {
+		return func(ctx context.Context, msg Message[T]) error {
+			err := next(ctx, msg)
+			if err == nil || ctx.Err() != nil {
+				return err
+			}
+			if !errors.IsPermanent(err) && msg.Attempt < o.poisonAttempts {
+				return err
+			}
+
+			topic := o.deadLetterTopic(msg.Topic)
+			fields := consumerFields(o, msg)
+			fields["dead_letter_topic"] = topic
+			log := loggerOrContext(ctx, o.logger)
+
+			deadLetter := msg
+			deadLetter.Topic = ""
+			deadLetter.Attempt = 0
+			deadLetter.Metadata = make(map[string]string, len(msg.Metadata)+5)
+			for key, value := range msg.Metadata {
+				deadLetter.Metadata[key] = value
+			}
+			deadLetter.Metadata[MetadataDeadLetterTopic] = msg.Topic
+			deadLetter.Metadata[MetadataDeadLetterHandler] = o.handler
+			deadLetter.Metadata[MetadataDeadLetterError] = err.Error()
+			deadLetter.Metadata[MetadataDeadLetterAttempts] = strconv.Itoa(msg.Attempt)
+			deadLetter.Metadata[MetadataDeadLetterFailedAt] = time.Now().UTC().Format(time.RFC3339Nano)
+			if publishErr := publisher.Publish(ctx, topic, deadLetter); publishErr != nil {
+				log.Error(ctx, "Failed to publish event to dead-letter topic", publishErr, fields)
+				return stderrors.Join(err, publishErr)
+			}
+
+			log.Error(ctx, "Event dead-lettered", err, fields)
+			if o.metrics != nil {
+				o.metrics.deadLettered.Inc(msg.Topic, o.handler)
+			}
+			return nil
+		}
+	}
+}
+
+/*
+HandlerRecovery returns a Middleware recovering the panics of the handler, so that they do not kill the
+subscription: it converts the panic to an error wrapping ErrPanic, and the panic value if it is an error, and logs
+it with the stack of the panic at error level. The message is then retried like any failure, and dead-lettered by
+HandlerDeadLetter if it keeps panicking. Install it last, so that it recovers the panics of the other middlewares
+too.
+
+Example usage:
+
+	handler := event.ChainHandler(handleOrder,
+		event.HandlerLogging[Order](log),
+		event.HandlerRecovery[Order](event.WithLogger(log)),
+	)
+*/
+func HandlerRecovery[T any](opts ...ConsumerOption) Middleware[T] {
+	o := newConsumerOptions(opts)
+	return func(next Handler[T]) Handler[T] {
+		return func(ctx context.Context, msg Message[T]) (err error) {
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recoveredErr, ok := recovered.(error); ok {
+					err = fmt.Errorf("%w: %w", ErrPanic, recoveredErr)
+				} else {
+					err = fmt.Errorf("%w: %v", ErrPanic, recovered)
+				}
+				fields := consumerFields(o, msg)
+				fields[logger.DefaultPanicKey] = fmt.Sprintf("%v", recovered)
+				fields[logger.DefaultSJsonFmtStackTraceKey] = string(debug.Stack())
+				loggerOrContext(ctx, o.logger).Error(ctx, "Event handler panicked", err, fields)
+				if o.metrics != nil {
+					o.metrics.panics.Inc(msg.Topic, o.handler)
+				}
+			}()
+			return next(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package event_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/metrics"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+func TestHandlerRetry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retries until the handler succeeds", func(t *testing.T) {
+		log, recorder := logger.NewTestLogger()
+		calls := 0
+		handler := event.ChainHandler(func(ctx context.Context, msg event.Message[string]) error {
+			calls++
+			if calls < 3 {
+				return errors.New("unavailable")
+			}
+			return nil
+		}, event.HandlerRetry[string](5, retry.Constant(0), event.WithLogger(log), event.WithHandlerName("billing")))
+
+		require.NoError(t, handler(ctx, event.Message[string]{Topic: "orders", ID: "1"}))
+		assert.Equal(t, 3, calls)
+		assert.Len(t, recorder.Find(logger.WARN, "Retrying event", logger.HasField("handler", "billing")), 2)
+	})
+
+	t.Run("returns the error of the last attempt", func(t *testing.T) {
+		errHandler := errors.New("unavailable")
+		calls := 0
+		handler := event.ChainHandler(func(ctx context.Context, msg event.Message[string]) error {
+			calls++
+			return errHandler
+		}, event.HandlerRetry[string](3, retry.Constant(0)))
+
+		err := handler(ctx, event.Message[string]{Topic: "orders"})
+		assert.Equal(t, errHandler, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("does not retry the permanent errors", func(t *testing.T) {
+		calls := 0
+		handler := event.ChainHandler(func(ctx context.Context, msg event.Message[string]) error {
+			calls++
+			return domain_error.MarkPermanent(errors.New("invalid order"))
+		}, event.HandlerRetry[string](3, retry.Constant(0)))
+
+		assert.Error(t, handler(ctx, event.Message[string]{Topic: "orders"}))
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestHandlerDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	errHandler := errors.New("cannot handle")
+	failing := func(ctx context.Context, msg event.Message[string]) error {
+		return errHandler
+	}
+
+	t.Run("fails before the poison attempt", func(t *testing.T) {
+		publisher := &recordingPublisher{}
+		handler := event.ChainHandler(failing, event.HandlerDeadLetter[string](publisher))
+
+		err := handler(ctx, event.Message[string]{Topic: "orders", Attempt: 2})
+		assert.Equal(t, errHandler, err)
+		assert.Empty(t, publisher.msgs)
+	})
+
+	t.Run("publishes the poison messages with the failure", func(t *testing.T) {
+		publisher := &recordingPublisher{}
+		log, recorder := logger.NewTestLogger()
+		handler := event.ChainHandler(failing,
+			event.HandlerDeadLetter[string](publisher, event.WithHandlerName("billing"), event.WithLogger(log)))
+
+		err := handler(ctx, event.Message[string]{
+			ID:       "1",
+			Topic:    "orders",
+			Key:      "o1",
+			Payload:  "order",
+			Metadata: map[string]string{"tenant": "a"},
+			Attempt:  event.DefaultMaxAttempts,
+		})
+		require.NoError(t, err)
+
+		require.Len(t, publisher.msgs, 1)
+		deadLetter := publisher.msgs[0]
+		assert.Equal(t, "orders.dead-letter", deadLetter.Topic)
+		assert.Equal(t, "1", deadLetter.ID)
+		assert.Equal(t, "o1", deadLetter.Key)
+		assert.Equal(t, "order", deadLetter.Payload)
+		assert.Equal(t, "a", deadLetter.Metadata["tenant"])
+		assert.Equal(t, "orders", deadLetter.Metadata[event.MetadataDeadLetterTopic])
+		assert.Equal(t, "billing", deadLetter.Metadata[event.MetadataDeadLetterHandler])
+		assert.Equal(t, "cannot handle", deadLetter.Metadata[event.MetadataDeadLetterError])
+		assert.Equal(t, "3", deadLetter.Metadata[event.MetadataDeadLetterAttempts])
+		assert.NotEmpty(t, deadLetter.Metadata[event.MetadataDeadLetterFailedAt])
+		recorder.AssertLogged(t, logger.ERROR, "Event dead-lettered", logger.HasField("dead_letter_topic", "orders.dead-letter"))
+	})
+
+	t.Run("publishes the messages failing with a permanent error at once", func(t *testing.T) {
+		publisher := &recordingPublisher{}
+		handler := event.ChainHandler(func(ctx context.Context, msg event.Message[string]) error {
+			return domain_error.MarkPermanent(errHandler)
+		}, event.HandlerDeadLetter[string](publisher, event.WithDeadLetterTopic(func(topic string) string {
+			return "dlq-" + topic
+		})))
+
+		require.NoError(t, handler(ctx, event.Message[string]{Topic: "orders", Attempt: 1}))
+		require.Len(t, publisher.msgs, 1)
+		assert.Equal(t, "dlq-orders", publisher.msgs[0].Topic)
+	})
+
+	t.Run("fails if the dead letter cannot be published", func(t *testing.T) {
+		errPublish := errors.New("publish failed")
+		publisher := &recordingPublisher{err: errPublish}
+		handler := event.ChainHandler(failing, event.HandlerDeadLetter[string](publisher, event.WithPoisonAttempts(1)))
+
+		err := handler(ctx, event.Message[string]{Topic: "orders", Attempt: 1})
+		assert.ErrorIs(t, err, errHandler)
+		assert.ErrorIs(t, err, errPublish)
+	})
+
+	t.Run("does not publish when the context is done", func(t *testing.T) {
+		publisher := &recordingPublisher{}
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		handler := event.ChainHandler(func(ctx context.Context, msg event.Message[string]) error {
+			return ctx.Err()
+		}, event.HandlerDeadLetter[string](publisher, event.WithPoisonAttempts(1)))
+
+		assert.ErrorIs(t, handler(ctx, event.Message[string]{Topic: "orders", Attempt: 1}), context.Canceled)
+		assert.Empty(t, publisher.msgs)
+	})
+}
+
+func TestHandlerRecovery(t *testing.T) {
+	ctx := context.Background()
+	log, recorder := logger.NewTestLogger()
+	handler := event.ChainHandler(func(ctx context.Context, msg event.Message[string]) error {
+		panic("boom")
+	}, event.HandlerRecovery[string](event.WithLogger(log)))
+
+	err := handler(ctx, event.Message[string]{Topic: "orders", ID: "1"})
+	assert.ErrorIs(t, err, event.ErrPanic)
+	assert.Contains(t, err.Error(), "boom")
+	entries := recorder.Find(logger.ERROR, "Event handler panicked", logger.HasField("id", "1"))
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0].Fields[logger.DefaultSJsonFmtStackTraceKey], "consumer_test.go")
+
+	errPanic := errors.New("nil pointer")
+	handler = event.ChainHandler(func(ctx context.Context, msg event.Message[string]) error {
+		panic(errPanic)
+	}, event.HandlerRecovery[string](event.WithLogger(log)))
+	err = handler(ctx, event.Message[string]{Topic: "orders"})
+	assert.ErrorIs(t, err, event.ErrPanic)
+	assert.ErrorIs(t, err, errPanic)
+}
+
+func TestConsumerMiddlewares(t *testing.T) {
+	ctx := context.Background()
+	registry := metrics.NewRegistry()
+	m, err := event.NewMetrics(registry)
+	require.NoError(t, err)
+	log, _ := logger.NewTestLogger()
+	opts := []event.ConsumerOption{event.WithHandlerName("billing"), event.WithLogger(log), event.WithMetrics(m)}
+
+	// A panicking handler is retried in place, then dead-lettered at its first delivery.
+	publisher := &recordingPublisher{}
+	calls := 0
+	handler := event.ChainHandler(func(ctx context.Context, msg event.Message[string]) error {
+		calls++
+		panic("boom")
+	},
+		event.HandlerDeadLetter[string](publisher, append(opts, event.WithPoisonAttempts(1))...),
+		event.HandlerRetry[string](2, retry.Constant(0), opts...),
+		event.HandlerRecovery[string](opts...),
+	)
+	require.NoError(t, handler(ctx, event.Message[string]{Topic: "orders", Attempt: 1}))
+	assert.Equal(t, 2, calls)
+	require.Len(t, publisher.msgs, 1)
+
+	var output bytes.Buffer
+	_, err = registry.WriteTo(&output)
+	require.NoError(t, err)
+	assert.Contains(t, output.String(), `event_retried_total{topic="orders",handler="billing"} 1`)
+	assert.Contains(t, output.String(), `event_dead_lettered_total{topic="orders",handler="billing"} 1`)
+	assert.Contains(t, output.String(), `event_panics_total{topic="orders",handler="billing"} 2`)
+}
@@ -0,0 +1,58 @@
+package event
+
+import "context"
+
+// Decorator wraps a publisher to add a cross-cutting concern, such as logging, metrics or tracing, to any bus.
+type Decorator[T any] func(p Publisher[T]) Publisher[T]
+
+// Middleware wraps a handler to add a cross-cutting concern, such as logging, metrics or tracing, to any
+// subscription.
+type Middleware[T any] func(next Handler[T]) Handler[T]
+
+/*
+Chain wraps p with the decorators. The first decorator is the outermost: Chain(p, A, B) returns A(B(p)), so A
+sees every call first and its result last. Like for the cache decorators, put tracing first, so that the logs
+carry the trace_id of the spans.
+
+Example usage:
+
+	publisher := event.Chain[Order](bus,
+		event.PublisherTracing[Order](),
+		event.PublisherLogging[Order](log),
+		event.PublisherMetrics[Order](metrics),
+	)
+*/
+func Chain[T any](p Publisher[T], decorators ...Decorator[T]) Publisher[T] {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		p = decorators[i](p)
+	}
+	return p
+}
+
+/*
+ChainHandler wraps handler with the middlewares, the first one being the outermost, like Chain. Every attempt to
+handle a message goes through them.
+
+Example usage:
+
+	handler := event.ChainHandler(handleOrder,
+		event.HandlerTracing[Order](),
+		event.HandlerLogging[Order](log),
+		event.HandlerMetrics[Order](metrics),
+	)
+	err := bus.Subscribe(ctx, "orders", handler, event.WithGroup("billing"))
+*/
+func ChainHandler[T any](handler Handler[T], middlewares ...Middleware[T]) Handler[T] {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// PublisherFunc is a Publisher calling the function.
+type PublisherFunc[T any] func(ctx context.Context, topic string, msgs ...Message[T]) error
+
+// Publish calls f(ctx, topic, msgs...).
+func (f PublisherFunc[T]) Publish(ctx context.Context, topic string, msgs ...Message[T]) error {
+	return f(ctx, topic, msgs...)
+}
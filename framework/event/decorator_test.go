@@ -0,0 +1,125 @@
+package event_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+// recordingPublisher records the messages published, and fails with err if set.
+type recordingPublisher struct {
+	msgs []event.Message[string]
+	err  error
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, topic string, msgs ...event.Message[string]) error {
+	for _, msg := range msgs {
+		msg.Topic = topic
+		p.msgs = append(p.msgs, msg)
+	}
+	return p.err
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	decorator := func(name string) event.Decorator[string] {
+		return func(p event.Publisher[string]) event.Publisher[string] {
+			return event.PublisherFunc[string](func(ctx context.Context, topic string, msgs ...event.Message[string]) error {
+				calls = append(calls, name)
+				return p.Publish(ctx, topic, msgs...)
+			})
+		}
+	}
+
+	publisher := &recordingPublisher{}
+	err := event.Chain[string](publisher, decorator("outer"), decorator("inner")).Publish(context.Background(), "orders", event.Message[string]{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer", "inner"}, calls)
+	assert.Len(t, publisher.msgs, 1)
+}
+
+func TestChainHandler(t *testing.T) {
+	var calls []string
+	middleware := func(name string) event.Middleware[string] {
+		return func(next event.Handler[string]) event.Handler[string] {
+			return func(ctx context.Context, msg event.Message[string]) error {
+				calls = append(calls, name)
+				return next(ctx, msg)
+			}
+		}
+	}
+	handler := func(ctx context.Context, msg event.Message[string]) error {
+		calls = append(calls, "handler")
+		return nil
+	}
+
+	err := event.ChainHandler(handler, middleware("outer"), middleware("inner"))(context.Background(), event.Message[string]{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer", "inner", "handler"}, calls)
+}
+
+func TestLogging(t *testing.T) {
+	ctx := context.Background()
+	errPublish := errors.New("publish failed")
+	log, recorder := logger.NewTestLogger()
+
+	publisher := &recordingPublisher{}
+	logged := event.Chain[string](publisher, event.PublisherLogging[string](log))
+	require.NoError(t, logged.Publish(ctx, "orders", event.Message[string]{}, event.Message[string]{}))
+	recorder.AssertLogged(t, logger.DEBUG, "event publish", logger.HasField("topic", "orders"), logger.HasField("messages", 2))
+	publisher.err = errPublish
+	require.ErrorIs(t, logged.Publish(ctx, "orders", event.Message[string]{}), errPublish)
+	recorder.AssertLogged(t, logger.ERROR, "event publish", logger.HasField("topic", "orders"))
+
+	recorder.Reset()
+	handler := event.ChainHandler(func(ctx context.Context, msg event.Message[string]) error {
+		if msg.Attempt > 1 {
+			return nil
+		}
+		return errors.New("handle failed")
+	}, event.HandlerLogging[string](nil))
+	ctx = logger.NewContext(ctx, log)
+	msg := event.Message[string]{ID: "1", Topic: "orders", Attempt: 1}
+	require.Error(t, handler(ctx, msg))
+	recorder.AssertLogged(t, logger.ERROR, "event handle", logger.HasField("id", "1"), logger.HasField("attempt", 1))
+	msg.Attempt = 2
+	require.NoError(t, handler(ctx, msg))
+	recorder.AssertLogged(t, logger.DEBUG, "event handle", logger.HasField("topic", "orders"), logger.HasField("attempt", 2))
+}
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	registry := metrics.NewRegistry()
+	m, err := event.NewMetrics(registry)
+	require.NoError(t, err)
+
+	publisher := &recordingPublisher{}
+	measured := event.Chain[string](publisher, event.PublisherMetrics[string](m))
+	require.NoError(t, measured.Publish(ctx, "orders", event.Message[string]{}, event.Message[string]{}))
+	publisher.err = errors.New("publish failed")
+	require.Error(t, measured.Publish(ctx, "orders", event.Message[string]{}))
+
+	handler := event.ChainHandler(func(ctx context.Context, msg event.Message[string]) error {
+		return nil
+	}, event.HandlerMetrics[string](m))
+	require.NoError(t, handler(ctx, event.Message[string]{Topic: "orders"}))
+
+	var output bytes.Buffer
+	_, err = registry.WriteTo(&output)
+	require.NoError(t, err)
+	assert.Contains(t, output.String(), `event_published_total{topic="orders",status="success"} 2`)
+	assert.Contains(t, output.String(), `event_published_total{topic="orders",status="error"} 1`)
+	assert.Contains(t, output.String(), `event_handled_total{topic="orders",status="success"} 1`)
+	assert.Contains(t, output.String(), `event_handling_seconds_count{topic="orders"} 1`)
+
+	_, err = event.NewMetrics(registry)
+	assert.NoError(t, err, "the metrics are shared")
+}
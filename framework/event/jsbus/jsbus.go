@@ -0,0 +1,312 @@
+package jsbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/kittipat1413/go-common/framework/event"
+)
+
+// Headers of the messages set by the bus, in addition to the metadata of the messages.
+const (
+	// HeaderMsgID is the header JetStream deduplicates the messages on, within the duplicate window of the stream.
+	HeaderMsgID       = "Nats-Msg-Id"
+	HeaderKey         = "Event-Key"
+	HeaderContentType = "Content-Type"
+)
+
+// DefaultFetchBatch is the maximum number of messages fetched at once, unless set with WithFetchBatch.
+const DefaultFetchBatch = 10
+
+// DefaultAckWait is the time JetStream waits for the acknowledgement of a message before delivering it again,
+// unless set with WithAckWait.
+const DefaultAckWait = 30 * time.Second
+
+// Errors returned by the bus.
+var (
+	ErrNoStream      = errors.New("jsbus: no stream")
+	ErrInvalidStream = errors.New("jsbus: invalid stream configuration")
+)
+
+// Storage is the storage of a stream.
+type Storage int
+
+const (
+	// FileStorage stores the messages on disk. It is the default storage.
+	FileStorage Storage = iota
+	// MemoryStorage stores the messages in memory, for transient events.
+	MemoryStorage
+)
+
+// StreamConfig is the configuration of a JetStream stream, provisioned with EnsureStream.
+type StreamConfig struct {
+	// Name is the name of the stream.
+	Name string
+	// Subjects are the subjects of the messages stored by the stream, i.e., the topics of the bus, with wildcards.
+	Subjects []string
+	// MaxAge is the age after which the messages are discarded. Zero keeps them.
+	MaxAge time.Duration
+	// MaxMsgs is the number of messages after which the oldest ones are discarded. Zero keeps them.
+	MaxMsgs int64
+	// Replicas is the number of replicas of the stream in a cluster. It defaults to 1.
+	Replicas int
+	// Storage is the storage of the stream. It defaults to FileStorage.
+	Storage Storage
+	// DuplicateWindow is the window within which the messages with the same ID are published once. Zero uses the
+	// default of the server, two minutes.
+	DuplicateWindow time.Duration
+}
+
+// ConsumerConfig is the configuration of a durable pull consumer, created by the bus for the consumer groups.
+type ConsumerConfig struct {
+	// Durable is the name of the consumer, shared by the subscriptions of the group.
+	Durable string
+	// FilterSubject is the subject of the messages delivered to the consumer, i.e., the topic of the subscription.
+	FilterSubject string
+	// MaxDeliver is the number of times a message is delivered before JetStream stops delivering it.
+	MaxDeliver int
+	// AckWait is the time JetStream waits for the acknowledgement of a message before delivering it again.
+	AckWait time.Duration
+	// MaxAckPending is the number of messages delivered and not acknowledged yet after which JetStream stops
+	// delivering them. Zero uses the default of the server.
+	MaxAckPending int
+}
+
+/*
+JetStream is the JetStream API used by the bus. It is a thin adapter over a NATS client, e.g., the jetstream
+package of nats.go, so that the bus does not depend on a client version.
+*/
+type JetStream interface {
+	// Publish publishes data on subject with the headers, and returns once it is stored by the stream.
+	Publish(ctx context.Context, subject string, data []byte, headers map[string][]string) error
+	// CreateOrUpdateStream creates the stream, or updates its configuration.
+	CreateOrUpdateStream(ctx context.Context, cfg StreamConfig) error
+	// CreateOrUpdateConsumer creates the durable pull consumer of stream, or updates its configuration.
+	CreateOrUpdateConsumer(ctx context.Context, stream string, cfg ConsumerConfig) (Consumer, error)
+}
+
+// Consumer is a durable pull consumer of a stream.
+type Consumer interface {
+	// Fetch returns up to batch messages, blocking until there is at least one or ctx is done.
+	Fetch(ctx context.Context, batch int) ([]Msg, error)
+}
+
+// Msg is a message delivered by a Consumer, to acknowledge once handled.
+type Msg interface {
+	Subject() string
+	Data() []byte
+	Headers() map[string][]string
+	// NumDelivered is the number of times the message was delivered, from 1.
+	NumDelivered() uint64
+	// Timestamp is the time the message was stored by the stream.
+	Timestamp() time.Time
+	// Ack acknowledges the message as handled, waiting for the confirmation of the server.
+	Ack(ctx context.Context) error
+	// Nak asks for the message to be delivered again after delay.
+	Nak(delay time.Duration) error
+	// Term stops the delivery of the message.
+	Term() error
+}
+
+/*
+EnsureStream creates the stream of cfg, or updates its configuration, with FileStorage and one replica unless set.
+It is meant to be called on startup, so that the services provision the streams they publish on.
+
+Example usage:
+
+	err := jsbus.EnsureStream(ctx, js, jsbus.StreamConfig{
+		Name:     "ORDERS",
+		Subjects: []string{"orders.>"},
+		MaxAge:   7 * 24 * time.Hour,
+	})
+*/
+func EnsureStream(ctx context.Context, js JetStream, cfg StreamConfig) error {
+	if cfg.Name == "" || strings.ContainsAny(cfg.Name, invalidNameChars) {
+		return fmt.Errorf("%w: invalid name %q", ErrInvalidStream, cfg.Name)
+	}
+	if len(cfg.Subjects) == 0 {
+		return fmt.Errorf("%w: no subjects", ErrInvalidStream)
+	}
+	if cfg.Replicas < 1 {
+		cfg.Replicas = 1
+	}
+	if err := js.CreateOrUpdateStream(ctx, cfg); err != nil {
+		return fmt.Errorf("jsbus: provision stream %s: %w", cfg.Name, err)
+	}
+	return nil
+}
+
+// invalidNameChars are the characters not allowed in the names of the streams and of the consumers.
+const invalidNameChars = " \t\r\n.*>/\\"
+
+// options holds configuration options for the bus.
+type options[T any] struct {
+	codec         event.Codec[T] // codec encodes the payloads.
+	fetchBatch    int            // fetchBatch is the maximum number of messages fetched at once.
+	ackWait       time.Duration  // ackWait is the time JetStream waits for the acknowledgement of a message.
+	maxAckPending int            // maxAckPending is the number of messages delivered and not acknowledged yet.
+}
+
+// Option specifies bus configuration options.
+type Option[T any] func(*options[T])
+
+// WithCodec sets the codec of the payloads. It defaults to event.JSONCodec.
+func WithCodec[T any](codec event.Codec[T]) Option[T] {
+	return func(opts *options[T]) {
+		if codec != nil {
+			opts.codec = codec
+		}
+	}
+}
+
+// WithFetchBatch sets the maximum number of messages fetched at once. It defaults to DefaultFetchBatch.
+func WithFetchBatch[T any](n int) Option[T] {
+	return func(opts *options[T]) {
+		if n > 0 {
+			opts.fetchBatch = n
+		}
+	}
+}
+
+// WithAckWait sets the time JetStream waits for the acknowledgement of a message before delivering it again. It
+// must exceed the time to handle a message. It defaults to DefaultAckWait.
+func WithAckWait[T any](d time.Duration) Option[T] {
+	return func(opts *options[T]) {
+		if d > 0 {
+			opts.ackWait = d
+		}
+	}
+}
+
+// WithMaxAckPending sets the number of messages delivered to a group and not acknowledged yet after which
+// JetStream stops delivering them. It defaults to the default of the server.
+func WithMaxAckPending[T any](n int) Option[T] {
+	return func(opts *options[T]) {
+		if n > 0 {
+			opts.maxAckPending = n
+		}
+	}
+}
+
+/*
+Bus is an event.Publisher and event.Subscriber over NATS JetStream, for the deployments not needing Kafka.
+
+The topics are subjects of the stream of the bus. The messages are encoded with the event.Codec, with their ID in
+the HeaderMsgID header, so that JetStream deduplicates them, their key in HeaderKey and their metadata as headers.
+
+Every consumer group is a durable pull consumer named after the group and the topic, created on subscribing. The
+retries are delivered by JetStream: a message whose handler fails is not acknowledged, and delivered again after
+the backoff of the subscription, to any subscription of the group, up to its attempts. It is then terminated and
+passed to OnDropped, like the messages failing with a permanent error or that cannot be decoded. The attempt of the
+messages is the number of times JetStream delivered them.
+
+Example usage:
+
+	if err := jsbus.EnsureStream(ctx, js, jsbus.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}}); err != nil {
+		// Handle error
+	}
+	bus := jsbus.New[OrderPlaced](js, "ORDERS")
+	err := bus.Publish(ctx, "orders.placed", event.Message[OrderPlaced]{Payload: orderPlaced})
+
+	manager.Add("orders-consumer", bus.Subscription("orders.placed", handleOrder, event.WithGroup("billing")))
+*/
+type Bus[T any] struct {
+	js     JetStream
+	stream string
+	opts   options[T]
+}
+
+// New creates a bus publishing and subscribing with js, on the subjects of stream.
+func New[T any](js JetStream, stream string, opts ...Option[T]) *Bus[T] {
+	o := options[T]{
+		codec:      event.JSONCodec[T](),
+		fetchBatch: DefaultFetchBatch,
+		ackWait:    DefaultAckWait,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Bus[T]{js: js, stream: stream, opts: o}
+}
+
+// Publish publishes the messages on the subject topic one by one, setting their ID if empty, and returns once they
+// are stored by the stream, or with the first error.
+func (b *Bus[T]) Publish(ctx context.Context, topic string, msgs ...event.Message[T]) error {
+	for _, msg := range msgs {
+		if msg.ID == "" {
+			msg.ID = xid.New().String()
+		}
+		data, err := b.opts.codec.Marshal(msg.Payload)
+		if err != nil {
+			return fmt.Errorf("jsbus: encode message %s: %w", msg.ID, err)
+		}
+		headers := make(map[string][]string, len(msg.Metadata)+3)
+		for key, value := range msg.Metadata {
+			headers[key] = []string{value}
+		}
+		headers[HeaderMsgID] = []string{msg.ID}
+		headers[HeaderContentType] = []string{b.opts.codec.ContentType()}
+		if msg.Key != "" {
+			headers[HeaderKey] = []string{msg.Key}
+		}
+		if err := b.js.Publish(ctx, topic, data, headers); err != nil {
+			return fmt.Errorf("jsbus: publish message %s: %w", msg.ID, err)
+		}
+	}
+	return nil
+}
+
+// Subscribe handles the messages of topic as a member of the consumer group of opts until ctx is done, and returns
+// nil then. The messages not handled by then are delivered again. See Subscription to drain them instead.
+func (b *Bus[T]) Subscribe(ctx context.Context, topic string, handler event.Handler[T], opts ...event.SubscribeOption) error {
+	return b.consume(ctx, nil, topic, handler, event.NewSubscribeOptions(opts...))
+}
+
+// durableName returns the name of the durable consumer of group for topic. The characters not allowed in the names
+// of the consumers are replaced by an underscore.
+func durableName(group, topic string) string {
+	if group == "" {
+		group = "default"
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(invalidNameChars, r) {
+			return '_'
+		}
+		return r
+	}, group+"-"+topic)
+}
+
+// decode returns the message of m.
+func (b *Bus[T]) decode(m Msg) (event.Message[T], error) {
+	msg := event.Message[T]{
+		Topic:     m.Subject(),
+		Timestamp: m.Timestamp(),
+		Attempt:   int(m.NumDelivered()),
+		Metadata:  make(map[string]string, len(m.Headers())),
+	}
+	for key, values := range m.Headers() {
+		if len(values) == 0 {
+			continue
+		}
+		switch key {
+		case HeaderMsgID:
+			msg.ID = values[0]
+		case HeaderKey:
+			msg.Key = values[0]
+		case HeaderContentType:
+		default:
+			msg.Metadata[key] = values[0]
+		}
+	}
+	payload, err := b.opts.codec.Unmarshal(m.Data())
+	if err != nil {
+		return msg, fmt.Errorf("jsbus: decode message %s: %w", msg.ID, err)
+	}
+	msg.Payload = payload
+	return msg, nil
+}
@@ -0,0 +1,276 @@
+package jsbus_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/event/jsbus"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+type order struct {
+	ID string `json:"id"`
+}
+
+type published struct {
+	subject string
+	data    string
+	headers map[string][]string
+}
+
+// fakeJetStream records the messages published, the streams and the consumers created, and delivers the batches
+// sent to its consumer.
+type fakeJetStream struct {
+	mu        sync.Mutex
+	published []published
+	streams   []jsbus.StreamConfig
+	consumers []jsbus.ConsumerConfig
+	batches   chan []jsbus.Msg
+}
+
+func newFakeJetStream(batches ...[]jsbus.Msg) *fakeJetStream {
+	js := &fakeJetStream{batches: make(chan []jsbus.Msg, len(batches))}
+	for _, batch := range batches {
+		js.batches <- batch
+	}
+	return js
+}
+
+func (js *fakeJetStream) Publish(ctx context.Context, subject string, data []byte, headers map[string][]string) error {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	js.published = append(js.published, published{subject: subject, data: string(data), headers: headers})
+	return nil
+}
+
+func (js *fakeJetStream) CreateOrUpdateStream(ctx context.Context, cfg jsbus.StreamConfig) error {
+	js.streams = append(js.streams, cfg)
+	return nil
+}
+
+func (js *fakeJetStream) CreateOrUpdateConsumer(ctx context.Context, stream string, cfg jsbus.ConsumerConfig) (jsbus.Consumer, error) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	js.consumers = append(js.consumers, cfg)
+	return js, nil
+}
+
+func (js *fakeJetStream) Fetch(ctx context.Context, batch int) ([]jsbus.Msg, error) {
+	select {
+	case msgs := <-js.batches:
+		return msgs, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fakeMsg is a message recording how it is acknowledged.
+type fakeMsg struct {
+	data      string
+	headers   map[string][]string
+	delivered uint64
+
+	mu    sync.Mutex
+	acked string
+	delay time.Duration
+}
+
+func newFakeMsg(id, data string, delivered uint64) *fakeMsg {
+	return &fakeMsg{data: data, headers: map[string][]string{jsbus.HeaderMsgID: {id}}, delivered: delivered}
+}
+
+func (m *fakeMsg) Subject() string               { return "orders.placed" }
+func (m *fakeMsg) Data() []byte                  { return []byte(m.data) }
+func (m *fakeMsg) Headers() map[string][]string  { return m.headers }
+func (m *fakeMsg) NumDelivered() uint64          { return m.delivered }
+func (m *fakeMsg) Timestamp() time.Time          { return time.Unix(1700000000, 0) }
+func (m *fakeMsg) Ack(ctx context.Context) error { m.settle("ack", 0); return nil }
+func (m *fakeMsg) Nak(delay time.Duration) error { m.settle("nak", delay); return nil }
+func (m *fakeMsg) Term() error                   { m.settle("term", 0); return nil }
+
+func (m *fakeMsg) settle(acked string, delay time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.acked, m.delay = acked, delay
+}
+
+func (m *fakeMsg) result() (string, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.acked, m.delay
+}
+
+func TestEnsureStream(t *testing.T) {
+	ctx := context.Background()
+	js := newFakeJetStream()
+
+	require.NoError(t, jsbus.EnsureStream(ctx, js, jsbus.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}}))
+	require.Len(t, js.streams, 1)
+	assert.Equal(t, 1, js.streams[0].Replicas)
+	assert.Equal(t, jsbus.FileStorage, js.streams[0].Storage)
+
+	assert.ErrorIs(t, jsbus.EnsureStream(ctx, js, jsbus.StreamConfig{Subjects: []string{"orders.>"}}), jsbus.ErrInvalidStream)
+	assert.ErrorIs(t, jsbus.EnsureStream(ctx, js, jsbus.StreamConfig{Name: "orders.v1", Subjects: []string{"orders.>"}}), jsbus.ErrInvalidStream)
+	assert.ErrorIs(t, jsbus.EnsureStream(ctx, js, jsbus.StreamConfig{Name: "ORDERS"}), jsbus.ErrInvalidStream)
+}
+
+func TestBus_Publish(t *testing.T) {
+	js := newFakeJetStream()
+	bus := jsbus.New[order](js, "ORDERS")
+
+	err := bus.Publish(context.Background(), "orders.placed",
+		event.Message[order]{ID: "1", Key: "customer:1", Payload: order{ID: "1"}, Metadata: map[string]string{"traceparent": "00-1"}},
+		event.Message[order]{Payload: order{ID: "2"}},
+	)
+	require.NoError(t, err)
+	require.Len(t, js.published, 2)
+	assert.Equal(t, published{subject: "orders.placed", data: `{"id":"1"}`, headers: map[string][]string{
+		jsbus.HeaderMsgID:       {"1"},
+		jsbus.HeaderKey:         {"customer:1"},
+		jsbus.HeaderContentType: {event.ContentTypeJSON},
+		"traceparent":           {"00-1"},
+	}}, js.published[0])
+	assert.NotEmpty(t, js.published[1].headers[jsbus.HeaderMsgID], "the ID is generated for the deduplication")
+	assert.NotContains(t, js.published[1].headers, jsbus.HeaderKey)
+}
+
+func TestBus_Subscribe(t *testing.T) {
+	errHandle := errors.New("handle failed")
+	handled := newFakeMsg("handled", `{"id":"1"}`, 1)
+	handled.headers[jsbus.HeaderKey] = []string{"customer:1"}
+	handled.headers["traceparent"] = []string{"00-1"}
+	retried := newFakeMsg("retried", `{"id":"2"}`, 2)
+	exhausted := newFakeMsg("exhausted", `{"id":"3"}`, 3)
+	permanent := newFakeMsg("permanent", `{"id":"4"}`, 1)
+	invalid := newFakeMsg("invalid", `{`, 1)
+	js := newFakeJetStream([]jsbus.Msg{handled, retried, exhausted, permanent, invalid})
+	bus := jsbus.New[order](js, "ORDERS", jsbus.WithAckWait[order](time.Minute), jsbus.WithMaxAckPending[order](100))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var msgs []event.Message[order]
+	var dropped []string
+	err := bus.Subscribe(ctx, "orders.placed", func(ctx context.Context, msg event.Message[order]) error {
+		msgs = append(msgs, msg)
+		switch msg.ID {
+		case "handled":
+			return nil
+		case "permanent":
+			return domain_error.MarkPermanent(errHandle)
+		}
+		return errHandle
+	},
+		event.WithGroup("billing"),
+		event.WithBackoff(retry.Constant(time.Second)),
+		event.WithOnDropped(func(ctx context.Context, topic, id string, err error) {
+			assert.Equal(t, "orders.placed", topic)
+			dropped = append(dropped, id)
+			if id == "invalid" {
+				cancel()
+			}
+		}),
+	)
+	require.NoError(t, err)
+
+	require.Len(t, js.consumers, 1)
+	assert.Equal(t, jsbus.ConsumerConfig{
+		Durable:       "billing-orders_placed",
+		FilterSubject: "orders.placed",
+		MaxDeliver:    event.DefaultMaxAttempts,
+		AckWait:       time.Minute,
+		MaxAckPending: 100,
+	}, js.consumers[0])
+
+	require.Len(t, msgs, 4, "the message that cannot be decoded is not handled")
+	assert.Equal(t, event.Message[order]{
+		ID:        "handled",
+		Topic:     "orders.placed",
+		Key:       "customer:1",
+		Payload:   order{ID: "1"},
+		Metadata:  map[string]string{"traceparent": "00-1"},
+		Timestamp: time.Unix(1700000000, 0),
+		Attempt:   1,
+	}, msgs[0])
+
+	for _, tt := range []struct {
+		msg   *fakeMsg
+		acked string
+		delay time.Duration
+	}{
+		{msg: handled, acked: "ack"},
+		{msg: retried, acked: "nak", delay: time.Second},
+		{msg: exhausted, acked: "term"},
+		{msg: permanent, acked: "term"},
+		{msg: invalid, acked: "term"},
+	} {
+		acked, delay := tt.msg.result()
+		assert.Equal(t, tt.acked, acked, tt.msg.headers[jsbus.HeaderMsgID][0])
+		assert.Equal(t, tt.delay, delay, tt.msg.headers[jsbus.HeaderMsgID][0])
+	}
+	assert.Equal(t, []string{"exhausted", "permanent", "invalid"}, dropped)
+}
+
+func TestSubscription_Drain(t *testing.T) {
+	first, second := newFakeMsg("1", `{"id":"1"}`, 1), newFakeMsg("2", `{"id":"2"}`, 1)
+	js := newFakeJetStream([]jsbus.Msg{first, second})
+	bus := jsbus.New[order](js, "ORDERS")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	subscription := bus.Subscription("orders.placed", func(ctx context.Context, msg event.Message[order]) error {
+		if msg.ID == "1" {
+			close(started)
+			<-release
+		}
+		return nil
+	})
+	done := make(chan error)
+	go func() { done <- subscription.Run(context.Background()) }()
+	<-started
+
+	shutdown := make(chan error)
+	go func() { shutdown <- subscription.Shutdown(context.Background()) }()
+	select {
+	case <-shutdown:
+		t.Fatal("Shutdown returned before the messages fetched were handled")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	require.NoError(t, <-shutdown)
+	require.NoError(t, <-done)
+	for _, msg := range []*fakeMsg{first, second} {
+		acked, _ := msg.result()
+		assert.Equal(t, "ack", acked)
+	}
+	assert.Equal(t, "default-orders_placed", js.consumers[0].Durable)
+}
+
+func TestSubscription_Canceled(t *testing.T) {
+	first, second := newFakeMsg("1", `{"id":"1"}`, 1), newFakeMsg("2", `{"id":"2"}`, 1)
+	js := newFakeJetStream([]jsbus.Msg{first, second})
+	bus := jsbus.New[order](js, "ORDERS")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var dropped bool
+	subscription := bus.Subscription("orders.placed", func(ctx context.Context, msg event.Message[order]) error {
+		cancel()
+		return ctx.Err()
+	}, event.WithOnDropped(func(context.Context, string, string, error) { dropped = true }))
+
+	require.NoError(t, subscription.Run(ctx))
+	assert.False(t, dropped)
+	for _, msg := range []*fakeMsg{first, second} {
+		acked, delay := msg.result()
+		assert.Equal(t, "nak", acked, "the messages not handled are delivered again at once")
+		assert.Zero(t, delay)
+	}
+
+	assert.ErrorIs(t, jsbus.New[order](js, "").Subscribe(context.Background(), "orders.placed", nil), jsbus.ErrNoStream)
+}
@@ -0,0 +1,186 @@
+package natsclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/kittipat1413/go-common/framework/event/jsbus"
+)
+
+var (
+	_ jsbus.JetStream = (*JetStream)(nil)
+	_ jsbus.Consumer  = (*consumer)(nil)
+	_ jsbus.Msg       = (*msg)(nil)
+)
+
+/*
+JetStream is the jsbus.JetStream of the jetstream package of nats.go. It is a module of its own, so that the
+services using jsbus with another client do not depend on nats.go.
+
+Example usage:
+
+	nc, err := nats.Connect(nats.DefaultURL)
+	if err != nil {
+		// Handle error
+	}
+	defer nc.Drain()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		// Handle error
+	}
+
+	client := natsclient.New(js)
+	err = jsbus.EnsureStream(ctx, client, jsbus.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}})
+	bus := jsbus.New[OrderPlaced](client, "ORDERS")
+*/
+type JetStream struct {
+	js jetstream.JetStream
+}
+
+// New creates the jsbus.JetStream of js. The connection of js is not owned by it: drain it after the subscriptions
+// end, e.g., with lifecycle.Manager.AddCloser.
+func New(js jetstream.JetStream) *JetStream {
+	return &JetStream{js: js}
+}
+
+// Publish publishes data on subject with the headers, and returns once it is stored by the stream.
+func (c *JetStream) Publish(ctx context.Context, subject string, data []byte, headers map[string][]string) error {
+	_, err := c.js.PublishMsg(ctx, &nats.Msg{Subject: subject, Data: data, Header: nats.Header(headers)})
+	return err
+}
+
+// CreateOrUpdateStream creates the stream, or updates its configuration.
+func (c *JetStream) CreateOrUpdateStream(ctx context.Context, cfg jsbus.StreamConfig) error {
+	_, err := c.js.CreateOrUpdateStream(ctx, streamConfig(cfg))
+	return err
+}
+
+// CreateOrUpdateConsumer creates the durable pull consumer of stream, with explicit acknowledgements, or updates
+// its configuration.
+func (c *JetStream) CreateOrUpdateConsumer(ctx context.Context, stream string, cfg jsbus.ConsumerConfig) (jsbus.Consumer, error) {
+	cons, err := c.js.CreateOrUpdateConsumer(ctx, stream, consumerConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	return &consumer{consumer: cons}, nil
+}
+
+// streamConfig returns the configuration of the stream of cfg, unlimited where cfg has zero values.
+func streamConfig(cfg jsbus.StreamConfig) jetstream.StreamConfig {
+	storage := jetstream.FileStorage
+	if cfg.Storage == jsbus.MemoryStorage {
+		storage = jetstream.MemoryStorage
+	}
+	maxMsgs := cfg.MaxMsgs
+	if maxMsgs <= 0 {
+		maxMsgs = -1
+	}
+	return jetstream.StreamConfig{
+		Name:       cfg.Name,
+		Subjects:   cfg.Subjects,
+		MaxAge:     cfg.MaxAge,
+		MaxMsgs:    maxMsgs,
+		Replicas:   cfg.Replicas,
+		Storage:    storage,
+		Duplicates: cfg.DuplicateWindow,
+	}
+}
+
+// consumerConfig returns the configuration of the durable pull consumer of cfg.
+func consumerConfig(cfg jsbus.ConsumerConfig) jetstream.ConsumerConfig {
+	return jetstream.ConsumerConfig{
+		Durable:       cfg.Durable,
+		FilterSubject: cfg.FilterSubject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		MaxDeliver:    cfg.MaxDeliver,
+		AckWait:       cfg.AckWait,
+		MaxAckPending: cfg.MaxAckPending,
+	}
+}
+
+// consumer is the jsbus.Consumer of a jetstream.Consumer.
+type consumer struct {
+	consumer jetstream.Consumer
+}
+
+// Fetch returns up to batch messages, pulling them until there is at least one or ctx is done.
+func (c *consumer) Fetch(ctx context.Context, batch int) ([]jsbus.Msg, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// The pull request expires with ctx, or after the default wait of the client without deadline.
+		b, err := c.consumer.Fetch(batch, jetstream.FetchContext(ctx))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		var msgs []jsbus.Msg
+		for m := range b.Messages() {
+			msgs = append(msgs, &msg{msg: m})
+		}
+		if len(msgs) > 0 {
+			return msgs, nil
+		}
+		if err := b.Error(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// msg is the jsbus.Msg of a jetstream.Msg.
+type msg struct {
+	msg jetstream.Msg
+}
+
+func (m *msg) Subject() string {
+	return m.msg.Subject()
+}
+
+func (m *msg) Data() []byte {
+	return m.msg.Data()
+}
+
+func (m *msg) Headers() map[string][]string {
+	return m.msg.Headers()
+}
+
+// NumDelivered is the number of times the message was delivered, from 1, or 1 if its metadata cannot be parsed.
+func (m *msg) NumDelivered() uint64 {
+	meta, err := m.msg.Metadata()
+	if err != nil || meta.NumDelivered == 0 {
+		return 1
+	}
+	return meta.NumDelivered
+}
+
+// Timestamp is the time the message was stored by the stream, or zero if its metadata cannot be parsed.
+func (m *msg) Timestamp() time.Time {
+	meta, err := m.msg.Metadata()
+	if err != nil {
+		return time.Time{}
+	}
+	return meta.Timestamp
+}
+
+// Ack acknowledges the message, and waits for the confirmation of the server.
+func (m *msg) Ack(ctx context.Context) error {
+	return m.msg.DoubleAck(ctx)
+}
+
+// Nak asks for the message to be delivered again after delay, at once if zero.
+func (m *msg) Nak(delay time.Duration) error {
+	if delay <= 0 {
+		return m.msg.Nak()
+	}
+	return m.msg.NakWithDelay(delay)
+}
+
+func (m *msg) Term() error {
+	return m.msg.Term()
+}
@@ -0,0 +1,247 @@
+package natsclient_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/event/jsbus"
+	"github.com/kittipat1413/go-common/framework/event/jsbus/natsclient"
+)
+
+// fakeJetStream records the messages published, the streams and the consumers created.
+type fakeJetStream struct {
+	jetstream.JetStream
+	published []*nats.Msg
+	streams   []jetstream.StreamConfig
+	consumers []jetstream.ConsumerConfig
+	consumer  *fakeConsumer
+}
+
+func (js *fakeJetStream) PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	js.published = append(js.published, msg)
+	return &jetstream.PubAck{Stream: "ORDERS", Sequence: uint64(len(js.published))}, nil
+}
+
+func (js *fakeJetStream) CreateOrUpdateStream(ctx context.Context, cfg jetstream.StreamConfig) (jetstream.Stream, error) {
+	js.streams = append(js.streams, cfg)
+	return nil, nil
+}
+
+func (js *fakeJetStream) CreateOrUpdateConsumer(ctx context.Context, stream string, cfg jetstream.ConsumerConfig) (jetstream.Consumer, error) {
+	js.consumers = append(js.consumers, cfg)
+	return js.consumer, nil
+}
+
+// fakeConsumer returns its batches, then batches expiring with the context of the fetch.
+type fakeConsumer struct {
+	jetstream.Consumer
+	mu      sync.Mutex
+	batches []*fakeBatch
+	fetches int
+}
+
+func (c *fakeConsumer) Fetch(batch int, opts ...jetstream.FetchOpt) (jetstream.MessageBatch, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetches++
+	if len(c.batches) == 0 {
+		time.Sleep(time.Millisecond)
+		return newFakeBatch(nil), nil
+	}
+	b := c.batches[0]
+	c.batches = c.batches[1:]
+	return b, nil
+}
+
+type fakeBatch struct {
+	msgs chan jetstream.Msg
+	err  error
+}
+
+func newFakeBatch(err error, msgs ...jetstream.Msg) *fakeBatch {
+	b := &fakeBatch{msgs: make(chan jetstream.Msg, len(msgs)), err: err}
+	for _, m := range msgs {
+		b.msgs <- m
+	}
+	close(b.msgs)
+	return b
+}
+
+func (b *fakeBatch) Messages() <-chan jetstream.Msg { return b.msgs }
+func (b *fakeBatch) Error() error                   { return b.err }
+
+// fakeMsg records how it was settled.
+type fakeMsg struct {
+	jetstream.Msg
+	subject  string
+	data     []byte
+	headers  nats.Header
+	meta     *jetstream.MsgMetadata
+	settled  string
+	nakDelay time.Duration
+}
+
+func (m *fakeMsg) Subject() string      { return m.subject }
+func (m *fakeMsg) Data() []byte         { return m.data }
+func (m *fakeMsg) Headers() nats.Header { return m.headers }
+
+func (m *fakeMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	if m.meta == nil {
+		return nil, errors.New("not a JetStream message")
+	}
+	return m.meta, nil
+}
+
+func (m *fakeMsg) DoubleAck(ctx context.Context) error {
+	m.settled = "ack"
+	return nil
+}
+
+func (m *fakeMsg) Nak() error {
+	m.settled = "nak"
+	return nil
+}
+
+func (m *fakeMsg) NakWithDelay(delay time.Duration) error {
+	m.settled, m.nakDelay = "nak", delay
+	return nil
+}
+
+func (m *fakeMsg) Term() error {
+	m.settled = "term"
+	return nil
+}
+
+type order struct {
+	ID string `json:"id"`
+}
+
+func TestJetStream_Publish(t *testing.T) {
+	js := &fakeJetStream{}
+	bus := jsbus.New[order](natsclient.New(js), "ORDERS")
+
+	err := bus.Publish(context.Background(), "orders.placed", event.Message[order]{ID: "1", Key: "order-1", Payload: order{ID: "order-1"}})
+	require.NoError(t, err)
+	require.Len(t, js.published, 1)
+	msg := js.published[0]
+	assert.Equal(t, "orders.placed", msg.Subject)
+	assert.JSONEq(t, `{"id":"order-1"}`, string(msg.Data))
+	assert.Equal(t, "1", msg.Header.Get(jsbus.HeaderMsgID))
+	assert.Equal(t, "order-1", msg.Header.Get(jsbus.HeaderKey))
+}
+
+func TestJetStream_CreateOrUpdateStream(t *testing.T) {
+	js := &fakeJetStream{}
+	err := jsbus.EnsureStream(context.Background(), natsclient.New(js), jsbus.StreamConfig{
+		Name:            "ORDERS",
+		Subjects:        []string{"orders.>"},
+		MaxAge:          time.Hour,
+		Storage:         jsbus.MemoryStorage,
+		DuplicateWindow: time.Minute,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []jetstream.StreamConfig{{
+		Name:       "ORDERS",
+		Subjects:   []string{"orders.>"},
+		MaxAge:     time.Hour,
+		MaxMsgs:    -1,
+		Replicas:   1,
+		Storage:    jetstream.MemoryStorage,
+		Duplicates: time.Minute,
+	}}, js.streams)
+}
+
+func TestJetStream_CreateOrUpdateConsumer(t *testing.T) {
+	js := &fakeJetStream{consumer: &fakeConsumer{}}
+	consumer, err := natsclient.New(js).CreateOrUpdateConsumer(context.Background(), "ORDERS", jsbus.ConsumerConfig{
+		Durable:       "billing",
+		FilterSubject: "orders.placed",
+		MaxDeliver:    3,
+		AckWait:       time.Minute,
+		MaxAckPending: 100,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, consumer)
+	assert.Equal(t, []jetstream.ConsumerConfig{{
+		Durable:       "billing",
+		FilterSubject: "orders.placed",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		MaxDeliver:    3,
+		AckWait:       time.Minute,
+		MaxAckPending: 100,
+	}}, js.consumers)
+}
+
+func TestConsumer_Fetch(t *testing.T) {
+	ctx := context.Background()
+	stored := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := &fakeMsg{
+		subject: "orders.placed",
+		data:    []byte(`{}`),
+		headers: nats.Header{jsbus.HeaderMsgID: {"1"}},
+		meta:    &jetstream.MsgMetadata{NumDelivered: 2, Timestamp: stored},
+	}
+	errFetch := errors.New("connection closed")
+	cons := &fakeConsumer{batches: []*fakeBatch{newFakeBatch(nil), newFakeBatch(nil, m), newFakeBatch(errFetch)}}
+	consumer, err := natsclient.New(&fakeJetStream{consumer: cons}).CreateOrUpdateConsumer(ctx, "ORDERS", jsbus.ConsumerConfig{Durable: "billing"})
+	require.NoError(t, err)
+
+	// The expired pull requests are sent again until there is a message.
+	msgs, err := consumer.Fetch(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, 2, cons.fetches)
+	msg := msgs[0]
+	assert.Equal(t, "orders.placed", msg.Subject())
+	assert.Equal(t, []byte(`{}`), msg.Data())
+	assert.Equal(t, map[string][]string{jsbus.HeaderMsgID: {"1"}}, msg.Headers())
+	assert.Equal(t, uint64(2), msg.NumDelivered())
+	assert.Equal(t, stored, msg.Timestamp())
+
+	_, err = consumer.Fetch(ctx, 10)
+	assert.ErrorIs(t, err, errFetch)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = consumer.Fetch(timeoutCtx, 10)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMsg_Settle(t *testing.T) {
+	ctx := context.Background()
+	msgs := []*fakeMsg{{}, {}, {}, {}}
+	batch := make([]jetstream.Msg, len(msgs))
+	for i, m := range msgs {
+		batch[i] = m
+	}
+	cons := &fakeConsumer{batches: []*fakeBatch{newFakeBatch(nil, batch...)}}
+	consumer, err := natsclient.New(&fakeJetStream{consumer: cons}).CreateOrUpdateConsumer(ctx, "ORDERS", jsbus.ConsumerConfig{Durable: "billing"})
+	require.NoError(t, err)
+	fetched, err := consumer.Fetch(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, fetched, 4)
+
+	require.NoError(t, fetched[0].Ack(ctx))
+	require.NoError(t, fetched[1].Nak(0))
+	require.NoError(t, fetched[2].Nak(time.Second))
+	require.NoError(t, fetched[3].Term())
+	assert.Equal(t, "ack", msgs[0].settled)
+	assert.Equal(t, "nak", msgs[1].settled)
+	assert.Zero(t, msgs[1].nakDelay)
+	assert.Equal(t, "nak", msgs[2].settled)
+	assert.Equal(t, time.Second, msgs[2].nakDelay)
+	assert.Equal(t, "term", msgs[3].settled)
+
+	// The messages without metadata are first deliveries.
+	assert.Equal(t, uint64(1), fetched[0].NumDelivered())
+	assert.True(t, fetched[0].Timestamp().IsZero())
+}
@@ -0,0 +1,145 @@
+package jsbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+/*
+Subscription is a subscription to a topic run as a lifecycle.Runnable, draining its messages on shutdown: Shutdown
+stops fetching, and waits for the messages fetched to be handled and acknowledged.
+
+Example usage:
+
+	manager := lifecycle.NewManager(lifecycle.WithShutdownTimeout(30 * time.Second))
+	manager.Add("orders-consumer", bus.Subscription("orders.placed", handleOrder, event.WithGroup("billing")))
+	manager.AddCloser("nats", func(ctx context.Context) error {
+		return nc.Drain()
+	})
+	err := manager.Run(ctx)
+*/
+type Subscription[T any] struct {
+	bus     *Bus[T]
+	topic   string
+	handler event.Handler[T]
+	opts    event.SubscribeOptions
+
+	stopOnce sync.Once
+	stop     chan struct{} // stop is closed by Shutdown.
+	done     chan struct{} // done is closed when Run returns.
+}
+
+// Subscription returns a subscription to topic, handling its messages with handler once run.
+func (b *Bus[T]) Subscription(topic string, handler event.Handler[T], opts ...event.SubscribeOption) *Subscription[T] {
+	return &Subscription[T]{
+		bus:     b,
+		topic:   topic,
+		handler: handler,
+		opts:    event.NewSubscribeOptions(opts...),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Run handles the messages of the topic until Shutdown is called or ctx is done, and returns nil then.
+func (s *Subscription[T]) Run(ctx context.Context) error {
+	defer close(s.done)
+	return s.bus.consume(ctx, s.stop, s.topic, s.handler, s.opts)
+}
+
+// Shutdown stops fetching messages, and returns once the messages fetched are handled and acknowledged, or ctx is
+// done.
+func (s *Subscription[T]) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// consume handles the messages of topic until stop is closed or ctx is done. The messages fetched are handled before
+// returning if stop is closed, and delivered again at once if ctx is done.
+func (b *Bus[T]) consume(ctx context.Context, stop <-chan struct{}, topic string, handler event.Handler[T], opts event.SubscribeOptions) error {
+	if b.stream == "" {
+		return ErrNoStream
+	}
+	consumer, err := b.js.CreateOrUpdateConsumer(ctx, b.stream, ConsumerConfig{
+		Durable:       durableName(opts.Group, topic),
+		FilterSubject: topic,
+		MaxDeliver:    opts.MaxAttempts,
+		AckWait:       b.opts.ackWait,
+		MaxAckPending: b.opts.maxAckPending,
+	})
+	if err != nil {
+		return fmt.Errorf("jsbus: create consumer: %w", err)
+	}
+
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-fetchCtx.Done():
+		}
+	}()
+
+	for {
+		msgs, err := consumer.Fetch(fetchCtx, b.opts.fetchBatch)
+		if err != nil && fetchCtx.Err() == nil {
+			return fmt.Errorf("jsbus: fetch: %w", err)
+		}
+		for i, m := range msgs {
+			if ctx.Err() != nil {
+				// The messages not handled are delivered again at once, rather than after the ack wait.
+				for _, m := range msgs[i:] {
+					_ = m.Nak(0)
+				}
+				return nil
+			}
+			b.handle(ctx, m, handler, opts)
+		}
+		if fetchCtx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// handle handles m with handler, then acknowledges it, asks for it to be delivered again after the backoff, or
+// terminates it once its attempts are exhausted.
+func (b *Bus[T]) handle(ctx context.Context, m Msg, handler event.Handler[T], opts event.SubscribeOptions) {
+	msg, err := b.decode(m)
+	if err != nil {
+		// The messages that cannot be decoded would fail the same way on every attempt.
+		b.settle(ctx, msg, m.Term())
+		opts.OnDropped(ctx, msg.Topic, msg.ID, err)
+		return
+	}
+
+	err = handler(ctx, msg)
+	switch {
+	case err == nil:
+		b.settle(ctx, msg, m.Ack(ctx))
+	case ctx.Err() != nil:
+		b.settle(ctx, msg, m.Nak(0))
+	case errors.IsPermanent(err) || msg.Attempt >= opts.MaxAttempts:
+		b.settle(ctx, msg, m.Term())
+		opts.OnDropped(ctx, msg.Topic, msg.ID, err)
+	default:
+		b.settle(ctx, msg, m.Nak(opts.Backoff(msg.Attempt)))
+	}
+}
+
+// settle logs the error of the acknowledgement of msg, if any. The message is delivered again after the ack wait.
+func (b *Bus[T]) settle(ctx context.Context, msg event.Message[T], err error) {
+	if err != nil {
+		logger.FromContext(ctx).Error(ctx, "Failed to acknowledge event", err, logger.Fields{"topic": msg.Topic, "id": msg.ID})
+	}
+}
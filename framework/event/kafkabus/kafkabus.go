@@ -0,0 +1,282 @@
+package kafkabus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/kittipat1413/go-common/framework/event"
+)
+
+// Headers of the records set by the bus, in addition to the metadata of the messages.
+const (
+	HeaderID          = "event-id"
+	HeaderContentType = "content-type"
+)
+
+// AnyPartition is the partition of the records left to the partitioner of the Producer, which partitions them by key.
+const AnyPartition int32 = -1
+
+// DefaultCommitInterval is the interval between the commits of the CommitInterval strategy, unless set with
+// WithCommitInterval.
+const DefaultCommitInterval = time.Second
+
+// finalCommitTimeout bounds the commit of the handled records when a subscription ends, its context being done.
+const finalCommitTimeout = 5 * time.Second
+
+// Errors returned by the bus created without a Producer or a ConsumerFactory.
+var (
+	ErrNoProducer = errors.New("kafkabus: no producer")
+	ErrNoConsumer = errors.New("kafkabus: no consumer factory")
+)
+
+// Header is a header of a Kafka record.
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Record is a Kafka record, produced or consumed.
+type Record struct {
+	Topic string
+	// Partition is the partition of the record. Produced records have AnyPartition unless set with WithPartitioner.
+	Partition int32
+	// Offset is the offset of a consumed record in its partition.
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   []Header
+	Timestamp time.Time
+}
+
+/*
+Producer produces records to Kafka. It is a thin adapter over the producer of a Kafka client, e.g., a franz-go
+*kgo.Client or a segmentio *kafka.Writer, which partitions the records by key, batches and compresses them.
+
+Produce returns once the records are acknowledged by the brokers, or with the first error.
+*/
+type Producer interface {
+	Produce(ctx context.Context, records ...Record) error
+}
+
+/*
+Consumer is a member of a Kafka consumer group, reading the partitions of a topic assigned to it. It is a thin
+adapter over the consumer of a Kafka client, with auto-commit disabled: the bus commits the offsets of the records
+once handled, following its CommitStrategy.
+*/
+type Consumer interface {
+	// Fetch returns the next records, blocking until there are some or ctx is done.
+	Fetch(ctx context.Context) ([]Record, error)
+	// Commit commits the offsets following the records, so that the group resumes after them.
+	Commit(ctx context.Context, records ...Record) error
+	// Close leaves the consumer group.
+	Close() error
+}
+
+// ConsumerFactory creates a Consumer of topic, member of the consumer group.
+type ConsumerFactory func(topic, group string) (Consumer, error)
+
+// CommitStrategy is when the offsets of the handled records are committed. The records handled but not committed
+// when a consumer stops are handled again by the group, so the fewer commits, the more redeliveries.
+type CommitStrategy int
+
+const (
+	// CommitPerBatch commits the records of every fetch once handled. It is the default strategy.
+	CommitPerBatch CommitStrategy = iota
+	// CommitPerMessage commits every record once handled, trading throughput for fewer redeliveries.
+	CommitPerMessage
+	// CommitInterval commits the handled records at most every interval set with WithCommitInterval, and when the
+	// subscription ends.
+	CommitInterval
+)
+
+// options holds configuration options for the bus.
+type options[T any] struct {
+	codec          event.Codec[T]                    // codec encodes the payloads.
+	keyFunc        func(msg event.Message[T]) string // keyFunc returns the key of the messages without one.
+	partitioner    func(msg event.Message[T]) int32  // partitioner returns the partition of the messages.
+	commitStrategy CommitStrategy                    // commitStrategy is when the handled records are committed.
+	commitInterval time.Duration                     // commitInterval is the interval of CommitInterval.
+}
+
+// Option specifies bus configuration options.
+type Option[T any] func(*options[T])
+
+// WithCodec sets the codec of the payloads. It defaults to event.JSONCodec.
+func WithCodec[T any](codec event.Codec[T]) Option[T] {
+	return func(opts *options[T]) {
+		if codec != nil {
+			opts.codec = codec
+		}
+	}
+}
+
+// WithKeyFunc sets the function returning the key of the messages published without one, e.g., the ID of the
+// aggregate, so that the messages of an aggregate land in the same partition and are handled in order.
+func WithKeyFunc[T any](keyFunc func(msg event.Message[T]) string) Option[T] {
+	return func(opts *options[T]) {
+		if keyFunc != nil {
+			opts.keyFunc = keyFunc
+		}
+	}
+}
+
+// WithPartitioner sets the function returning the partition of the messages, for a Producer using a manual
+// partitioner. It defaults to AnyPartition, leaving the partitioning by key to the Producer.
+func WithPartitioner[T any](partitioner func(msg event.Message[T]) int32) Option[T] {
+	return func(opts *options[T]) {
+		if partitioner != nil {
+			opts.partitioner = partitioner
+		}
+	}
+}
+
+// WithCommitStrategy sets when the offsets of the handled records are committed. It defaults to CommitPerBatch.
+func WithCommitStrategy[T any](strategy CommitStrategy) Option[T] {
+	return func(opts *options[T]) {
+		opts.commitStrategy = strategy
+	}
+}
+
+// WithCommitInterval sets the interval between the commits of the CommitInterval strategy. It defaults to
+// DefaultCommitInterval.
+func WithCommitInterval[T any](d time.Duration) Option[T] {
+	return func(opts *options[T]) {
+		if d > 0 {
+			opts.commitInterval = d
+		}
+	}
+}
+
+/*
+Bus is an event.Publisher and event.Subscriber over Kafka.
+
+The messages are encoded with the event.Codec into the values of the records, with their key, their metadata as headers,
+and their ID and the content type in the HeaderID and HeaderContentType headers. The consumer groups of the
+subscriptions are Kafka consumer groups: the records of a partition are handled in order by one subscription of
+the group, and their offsets committed once handled, following the CommitStrategy. The bus does not own the
+clients: close them after the subscriptions end, e.g., with lifecycle.Manager.AddCloser.
+
+Example usage:
+
+	bus := kafkabus.New[OrderPlaced](producer, consumers, kafkabus.WithKeyFunc(func(msg event.Message[OrderPlaced]) string {
+		return msg.Payload.OrderID
+	}))
+	err := bus.Publish(ctx, "orders", event.Message[OrderPlaced]{Payload: orderPlaced})
+
+	manager.Add("orders-consumer", bus.Subscription("orders", handleOrder, event.WithGroup("billing")))
+*/
+type Bus[T any] struct {
+	producer  Producer
+	consumers ConsumerFactory
+	opts      options[T]
+}
+
+// New creates a bus publishing with producer and subscribing with the consumers created by consumers. Either can be
+// nil for a bus only subscribing or publishing.
+func New[T any](producer Producer, consumers ConsumerFactory, opts ...Option[T]) *Bus[T] {
+	o := options[T]{
+		codec:          event.JSONCodec[T](),
+		keyFunc:        func(event.Message[T]) string { return "" },
+		partitioner:    func(event.Message[T]) int32 { return AnyPartition },
+		commitStrategy: CommitPerBatch,
+		commitInterval: DefaultCommitInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Bus[T]{producer: producer, consumers: consumers, opts: o}
+}
+
+// Publish produces the messages to topic, setting their ID and timestamp if empty, and returns once they are
+// acknowledged by the brokers.
+func (b *Bus[T]) Publish(ctx context.Context, topic string, msgs ...event.Message[T]) error {
+	if b.producer == nil {
+		return ErrNoProducer
+	}
+	records := make([]Record, 0, len(msgs))
+	now := time.Now()
+	for _, msg := range msgs {
+		msg.Topic = topic
+		if msg.ID == "" {
+			msg.ID = xid.New().String()
+		}
+		if msg.Timestamp.IsZero() {
+			msg.Timestamp = now
+		}
+		if msg.Key == "" {
+			msg.Key = b.opts.keyFunc(msg)
+		}
+		record, err := b.encode(msg)
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return b.producer.Produce(ctx, records...)
+}
+
+// Subscribe handles the messages of topic as a member of the consumer group of opts until ctx is done, and returns
+// nil then. The records not handled by then are handled again by the group. See Subscription to drain them instead.
+func (b *Bus[T]) Subscribe(ctx context.Context, topic string, handler event.Handler[T], opts ...event.SubscribeOption) error {
+	return b.consume(ctx, nil, topic, handler, event.NewSubscribeOptions(opts...))
+}
+
+// encode returns the record of msg.
+func (b *Bus[T]) encode(msg event.Message[T]) (Record, error) {
+	value, err := b.opts.codec.Marshal(msg.Payload)
+	if err != nil {
+		return Record{}, fmt.Errorf("kafkabus: encode message %s: %w", msg.ID, err)
+	}
+	headers := make([]Header, 0, len(msg.Metadata)+2)
+	for key, value := range msg.Metadata {
+		headers = append(headers, Header{Key: key, Value: []byte(value)})
+	}
+	headers = append(headers,
+		Header{Key: HeaderID, Value: []byte(msg.ID)},
+		Header{Key: HeaderContentType, Value: []byte(b.opts.codec.ContentType())},
+	)
+	record := Record{
+		Topic:     msg.Topic,
+		Partition: b.opts.partitioner(msg),
+		Value:     value,
+		Headers:   headers,
+		Timestamp: msg.Timestamp,
+	}
+	if msg.Key != "" {
+		record.Key = []byte(msg.Key)
+	}
+	return record, nil
+}
+
+// decode returns the message of record. Its ID defaults to the topic, partition and offset of the record.
+func (b *Bus[T]) decode(record Record) (event.Message[T], error) {
+	msg := event.Message[T]{
+		ID:        fmt.Sprintf("%s/%d/%d", record.Topic, record.Partition, record.Offset),
+		Topic:     record.Topic,
+		Key:       string(record.Key),
+		Timestamp: record.Timestamp,
+		Metadata:  make(map[string]string, len(record.Headers)),
+	}
+	for _, header := range record.Headers {
+		switch header.Key {
+		case HeaderID:
+			msg.ID = string(header.Value)
+		case HeaderContentType:
+		default:
+			msg.Metadata[header.Key] = string(header.Value)
+		}
+	}
+	payload, err := b.opts.codec.Unmarshal(record.Value)
+	if err != nil {
+		return msg, fmt.Errorf("kafkabus: decode message %s: %w", msg.ID, err)
+	}
+	msg.Payload = payload
+	return msg, nil
+}
@@ -0,0 +1,322 @@
+package kafkabus_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/event/kafkabus"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+type order struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+// producerFunc is a kafkabus.Producer calling the function.
+type producerFunc func(ctx context.Context, records ...kafkabus.Record) error
+
+func (f producerFunc) Produce(ctx context.Context, records ...kafkabus.Record) error {
+	return f(ctx, records...)
+}
+
+// fakeConsumer returns the batches sent to it, and records the commits.
+type fakeConsumer struct {
+	batches chan []kafkabus.Record
+
+	mu      sync.Mutex
+	commits [][]int64
+	closed  bool
+}
+
+func newFakeConsumer(batches ...[]kafkabus.Record) *fakeConsumer {
+	c := &fakeConsumer{batches: make(chan []kafkabus.Record, len(batches)+10)}
+	for _, batch := range batches {
+		c.batches <- batch
+	}
+	return c
+}
+
+func (c *fakeConsumer) Fetch(ctx context.Context) ([]kafkabus.Record, error) {
+	select {
+	case batch := <-c.batches:
+		return batch, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *fakeConsumer) Commit(ctx context.Context, records ...kafkabus.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	offsets := make([]int64, len(records))
+	for i, record := range records {
+		offsets[i] = record.Offset
+	}
+	c.commits = append(c.commits, offsets)
+	return nil
+}
+
+func (c *fakeConsumer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *fakeConsumer) committed() [][]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]int64(nil), c.commits...)
+}
+
+// factory returns a kafkabus.ConsumerFactory returning consumer for the group "billing" of "orders".
+func factory(t *testing.T, consumer kafkabus.Consumer) kafkabus.ConsumerFactory {
+	return func(topic, group string) (kafkabus.Consumer, error) {
+		assert.Equal(t, "orders", topic)
+		assert.Equal(t, "billing", group)
+		return consumer, nil
+	}
+}
+
+// records returns the records of orders with the offsets, encoded in JSON.
+func records(offsets ...int64) []kafkabus.Record {
+	var records []kafkabus.Record
+	for _, offset := range offsets {
+		records = append(records, kafkabus.Record{
+			Topic:   "orders",
+			Offset:  offset,
+			Value:   []byte(`{"id":"order","amount":1}`),
+			Headers: []kafkabus.Header{{Key: kafkabus.HeaderContentType, Value: []byte(event.ContentTypeJSON)}},
+		})
+	}
+	return records
+}
+
+func TestBus_Publish(t *testing.T) {
+	var produced []kafkabus.Record
+	producer := producerFunc(func(ctx context.Context, records ...kafkabus.Record) error {
+		produced = append(produced, records...)
+		return nil
+	})
+	bus := kafkabus.New[order](producer, nil, kafkabus.WithKeyFunc(func(msg event.Message[order]) string {
+		return msg.Payload.ID
+	}))
+
+	err := bus.Publish(context.Background(), "orders",
+		event.Message[order]{ID: "event:1", Key: "customer:1", Payload: order{ID: "1"}, Metadata: map[string]string{"source": "checkout"}},
+		event.Message[order]{Payload: order{ID: "2", Amount: 42}},
+	)
+	require.NoError(t, err)
+	require.Len(t, produced, 2)
+
+	first := produced[0]
+	assert.Equal(t, "orders", first.Topic)
+	assert.Equal(t, kafkabus.AnyPartition, first.Partition)
+	assert.Equal(t, "customer:1", string(first.Key))
+	assert.JSONEq(t, `{"id":"1","amount":0}`, string(first.Value))
+	assert.ElementsMatch(t, []kafkabus.Header{
+		{Key: "source", Value: []byte("checkout")},
+		{Key: kafkabus.HeaderID, Value: []byte("event:1")},
+		{Key: kafkabus.HeaderContentType, Value: []byte(event.ContentTypeJSON)},
+	}, first.Headers)
+	assert.False(t, first.Timestamp.IsZero())
+
+	second := produced[1]
+	assert.Equal(t, "2", string(second.Key), "the key defaults to the key function")
+	assert.Contains(t, second.Headers, kafkabus.Header{Key: kafkabus.HeaderContentType, Value: []byte(event.ContentTypeJSON)})
+
+	assert.ErrorIs(t, kafkabus.New[order](nil, nil).Publish(context.Background(), "orders"), kafkabus.ErrNoProducer)
+}
+
+func TestBus_PublishPartitioner(t *testing.T) {
+	errProduce := errors.New("produce failed")
+	var produced []kafkabus.Record
+	producer := producerFunc(func(ctx context.Context, records ...kafkabus.Record) error {
+		produced = append(produced, records...)
+		return errProduce
+	})
+	bus := kafkabus.New[order](producer, nil, kafkabus.WithPartitioner(func(msg event.Message[order]) int32 {
+		return int32(msg.Payload.Amount % 3)
+	}))
+
+	err := bus.Publish(context.Background(), "orders", event.Message[order]{Payload: order{Amount: 5}})
+	assert.ErrorIs(t, err, errProduce)
+	require.Len(t, produced, 1)
+	assert.Equal(t, int32(2), produced[0].Partition)
+	assert.Nil(t, produced[0].Key)
+}
+
+func TestBus_Subscribe(t *testing.T) {
+	batch := records(10, 11)
+	batch[0].Headers = append(batch[0].Headers, kafkabus.Header{Key: kafkabus.HeaderID, Value: []byte("event:1")}, kafkabus.Header{Key: "traceparent", Value: []byte("00-1")})
+	batch[0].Key = []byte("customer:1")
+	batch = append(batch, kafkabus.Record{Topic: "orders", Offset: 12, Value: []byte("{")})
+	consumer := newFakeConsumer(batch)
+	bus := kafkabus.New[order](nil, factory(t, consumer))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handled := make(chan event.Message[order], 3)
+	dropped := make(chan string, 1)
+	done := make(chan error)
+	go func() {
+		done <- bus.Subscribe(ctx, "orders", func(ctx context.Context, msg event.Message[order]) error {
+			handled <- msg
+			return nil
+		}, event.WithGroup("billing"), event.WithOnDropped(func(ctx context.Context, topic, id string, err error) {
+			dropped <- id
+		}))
+	}()
+
+	msg := <-handled
+	assert.Equal(t, "event:1", msg.ID)
+	assert.Equal(t, "customer:1", msg.Key)
+	assert.Equal(t, order{ID: "order", Amount: 1}, msg.Payload)
+	assert.Equal(t, map[string]string{"traceparent": "00-1"}, msg.Metadata)
+	msg = <-handled
+	assert.Equal(t, "orders/0/11", msg.ID, "the ID defaults to the position of the record")
+	assert.Equal(t, "orders/0/12", <-dropped, "the records that cannot be decoded are dropped")
+
+	require.Eventually(t, func() bool { return len(consumer.committed()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, [][]int64{{10, 11, 12}}, consumer.committed())
+	cancel()
+	require.NoError(t, <-done)
+	assert.True(t, consumer.closed)
+
+	assert.ErrorIs(t, kafkabus.New[order](nil, nil).Subscribe(context.Background(), "orders", nil), kafkabus.ErrNoConsumer)
+}
+
+func TestBus_CommitStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy kafkabus.CommitStrategy
+		commits  [][]int64
+	}{
+		{name: "per batch", strategy: kafkabus.CommitPerBatch, commits: [][]int64{{1, 2}, {3}}},
+		{name: "per message", strategy: kafkabus.CommitPerMessage, commits: [][]int64{{1}, {2}, {3}}},
+		{name: "interval", strategy: kafkabus.CommitInterval, commits: [][]int64{{1, 2, 3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := newFakeConsumer(records(1, 2), records(3))
+			bus := kafkabus.New[order](nil, factory(t, consumer),
+				kafkabus.WithCommitStrategy[order](tt.strategy),
+				kafkabus.WithCommitInterval[order](time.Hour),
+			)
+			handled := make(chan struct{}, 3)
+			subscription := bus.Subscription("orders", func(ctx context.Context, msg event.Message[order]) error {
+				handled <- struct{}{}
+				return nil
+			}, event.WithGroup("billing"))
+			done := make(chan error)
+			go func() { done <- subscription.Run(context.Background()) }()
+
+			for i := 0; i < 3; i++ {
+				<-handled
+			}
+			require.NoError(t, subscription.Shutdown(context.Background()))
+			require.NoError(t, <-done)
+			assert.Equal(t, tt.commits, consumer.committed())
+		})
+	}
+}
+
+func TestSubscription_Drain(t *testing.T) {
+	consumer := newFakeConsumer(records(1, 2))
+	bus := kafkabus.New[order](nil, factory(t, consumer))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var handled []int64
+	subscription := bus.Subscription("orders", func(ctx context.Context, msg event.Message[order]) error {
+		if len(handled) == 0 {
+			close(started)
+			<-release
+		}
+		handled = append(handled, int64(len(handled)+1))
+		return nil
+	}, event.WithGroup("billing"))
+	done := make(chan error)
+	go func() { done <- subscription.Run(context.Background()) }()
+	<-started
+
+	shutdown := make(chan error)
+	go func() { shutdown <- subscription.Shutdown(context.Background()) }()
+	select {
+	case <-shutdown:
+		t.Fatal("Shutdown returned before the records fetched were handled")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	require.NoError(t, <-shutdown)
+	require.NoError(t, <-done)
+	assert.Equal(t, []int64{1, 2}, handled)
+	assert.Equal(t, [][]int64{{1, 2}}, consumer.committed())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, bus.Subscription("orders", nil).Shutdown(ctx), context.Canceled)
+}
+
+func TestSubscription_Canceled(t *testing.T) {
+	consumer := newFakeConsumer(records(1, 2))
+	bus := kafkabus.New[order](nil, factory(t, consumer))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var dropped bool
+	subscription := bus.Subscription("orders", func(ctx context.Context, msg event.Message[order]) error {
+		if msg.ID == "orders/0/1" {
+			return nil
+		}
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	},
+		event.WithGroup("billing"),
+		event.WithBackoff(retry.Constant(0)),
+		event.WithOnDropped(func(context.Context, string, string, error) { dropped = true }),
+	)
+
+	require.NoError(t, subscription.Run(ctx))
+	assert.False(t, dropped)
+	assert.Equal(t, [][]int64{{1}}, consumer.committed(), "the record interrupted is handled again by the group")
+}
+
+func TestSubscription_CanceledAfterHandled(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy kafkabus.CommitStrategy
+		commits  [][]int64
+	}{
+		{name: "per batch", strategy: kafkabus.CommitPerBatch, commits: [][]int64{{1, 2}}},
+		{name: "per message", strategy: kafkabus.CommitPerMessage, commits: [][]int64{{1}, {2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := newFakeConsumer(records(1, 2))
+			bus := kafkabus.New[order](nil, factory(t, consumer), kafkabus.WithCommitStrategy[order](tt.strategy))
+			ctx, cancel := context.WithCancel(context.Background())
+
+			subscription := bus.Subscription("orders", func(ctx context.Context, msg event.Message[order]) error {
+				if msg.ID == "orders/0/2" {
+					cancel()
+				}
+				return nil
+			}, event.WithGroup("billing"))
+
+			// The record handled as ctx is done is committed by the final commit.
+			require.NoError(t, subscription.Run(ctx))
+			assert.Equal(t, tt.commits, consumer.committed())
+		})
+	}
+}
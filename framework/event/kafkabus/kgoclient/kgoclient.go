@@ -0,0 +1,178 @@
+package kgoclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/kittipat1413/go-common/framework/event/kafkabus"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+var (
+	_ kafkabus.Producer = (*Producer)(nil)
+	_ kafkabus.Consumer = (*Consumer)(nil)
+)
+
+// ErrNoGroup is returned by the ConsumerFactory of NewConsumerFactory for the subscriptions without consumer group,
+// as the offsets are committed to the group.
+var ErrNoGroup = errors.New("kgoclient: no consumer group, see event.WithGroup")
+
+/*
+Producer is the kafkabus.Producer of a franz-go client. It is a module of its own, so that the services using
+kafkabus with another client do not depend on franz-go.
+
+The records are partitioned by the partitioner of the client, by key by default. The partitions set with
+kafkabus.WithPartitioner are only used by a client created with kgo.RecordPartitioner(kgo.ManualPartitioner()).
+
+Example usage:
+
+	client, err := kgo.NewClient(kgo.SeedBrokers(brokers...))
+	if err != nil {
+		// Handle error
+	}
+	manager.AddCloser("kafka-producer", func(ctx context.Context) error { client.Close(); return nil })
+
+	bus := kafkabus.New[OrderPlaced](kgoclient.NewProducer(client), kgoclient.NewConsumerFactory(kgo.SeedBrokers(brokers...)))
+*/
+type Producer struct {
+	client *kgo.Client
+}
+
+// NewProducer creates the kafkabus.Producer of client. The client is not owned by it: close it after the last
+// message is published.
+func NewProducer(client *kgo.Client) *Producer {
+	return &Producer{client: client}
+}
+
+// Produce produces the records, and returns once they are acknowledged by the brokers, or with the first error.
+func (p *Producer) Produce(ctx context.Context, records ...kafkabus.Record) error {
+	rs := make([]*kgo.Record, len(records))
+	for i, record := range records {
+		rs[i] = &kgo.Record{
+			Topic:     record.Topic,
+			Key:       record.Key,
+			Value:     record.Value,
+			Headers:   headers(record.Headers),
+			Timestamp: record.Timestamp,
+		}
+		if record.Partition != kafkabus.AnyPartition {
+			rs[i].Partition = record.Partition
+		}
+	}
+	return p.client.ProduceSync(ctx, rs...).FirstErr()
+}
+
+/*
+NewConsumerFactory returns the kafkabus.ConsumerFactory creating a franz-go client with opts for every
+subscription, e.g., with kgo.SeedBrokers, consuming its topic in its consumer group with auto-commit disabled. The
+clients are closed with the subscriptions.
+*/
+func NewConsumerFactory(opts ...kgo.Opt) kafkabus.ConsumerFactory {
+	return func(topic, group string) (kafkabus.Consumer, error) {
+		if group == "" {
+			return nil, ErrNoGroup
+		}
+		client, err := kgo.NewClient(append(opts[:len(opts):len(opts)],
+			kgo.ConsumeTopics(topic),
+			kgo.ConsumerGroup(group),
+			kgo.DisableAutoCommit(),
+		)...)
+		if err != nil {
+			return nil, err
+		}
+		return NewConsumer(client), nil
+	}
+}
+
+// Consumer is the kafkabus.Consumer of a franz-go client consuming in a consumer group, with auto-commit disabled.
+type Consumer struct {
+	client *kgo.Client
+}
+
+// NewConsumer creates the kafkabus.Consumer of client, created with kgo.ConsumerGroup and kgo.DisableAutoCommit.
+// The client is owned by it, and closed by Close.
+func NewConsumer(client *kgo.Client) *Consumer {
+	return &Consumer{client: client}
+}
+
+// Fetch returns the next records, blocking until there are some or ctx is done. It fails when the client is closed,
+// or on the authorization errors; the errors of the other partitions are logged and skipped.
+func (c *Consumer) Fetch(ctx context.Context) ([]kafkabus.Record, error) {
+	fetches := c.client.PollFetches(ctx)
+	if fetches.IsClientClosed() {
+		return nil, kgo.ErrClientClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, fetchErr := range fetches.Errors() {
+		if fatal(fetchErr.Err) {
+			return nil, fmt.Errorf("fetch %s partition %d: %w", fetchErr.Topic, fetchErr.Partition, fetchErr.Err)
+		}
+		// The other errors are informational, e.g., a data loss the client resumed after, or are not fixed by
+		// stopping the subscription, e.g., a corrupt batch: the records of the other partitions are handled.
+		logger.FromContext(ctx).Warn(ctx, "kgoclient: fetch error skipped", logger.Fields{
+			"topic":     fetchErr.Topic,
+			"partition": fetchErr.Partition,
+			"error":     fetchErr.Err.Error(),
+		})
+	}
+	records := make([]kafkabus.Record, 0, fetches.NumRecords())
+	fetches.EachRecord(func(r *kgo.Record) {
+		record := kafkabus.Record{
+			Topic:     r.Topic,
+			Partition: r.Partition,
+			Offset:    r.Offset,
+			Key:       r.Key,
+			Value:     r.Value,
+			Timestamp: r.Timestamp,
+		}
+		for _, header := range r.Headers {
+			record.Headers = append(record.Headers, kafkabus.Header{Key: header.Key, Value: header.Value})
+		}
+		records = append(records, record)
+	})
+	return records, nil
+}
+
+// Commit commits the offsets following the records to the consumer group.
+func (c *Consumer) Commit(ctx context.Context, records ...kafkabus.Record) error {
+	rs := make([]*kgo.Record, len(records))
+	for i, record := range records {
+		// The leader epochs of the records are not kept, -1 commits the offsets without.
+		rs[i] = &kgo.Record{Topic: record.Topic, Partition: record.Partition, Offset: record.Offset, LeaderEpoch: -1}
+	}
+	return c.client.CommitRecords(ctx, rs...)
+}
+
+// Close leaves the consumer group, and closes the client.
+func (c *Consumer) Close() error {
+	c.client.Close()
+	return nil
+}
+
+// fatal reports whether the fetch error err is not fixed without the intervention of an operator: the client is
+// closed, or it is not authorized to consume.
+func fatal(err error) bool {
+	return errors.Is(err, kgo.ErrClientClosed) ||
+		errors.Is(err, kerr.SaslAuthenticationFailed) ||
+		errors.Is(err, kerr.TopicAuthorizationFailed) ||
+		errors.Is(err, kerr.GroupAuthorizationFailed) ||
+		errors.Is(err, kerr.ClusterAuthorizationFailed)
+}
+
+// headers returns the kgo headers of headers.
+func headers(headers []kafkabus.Header) []kgo.RecordHeader {
+	if len(headers) == 0 {
+		return nil
+	}
+	rs := make([]kgo.RecordHeader, len(headers))
+	for i, header := range headers {
+		rs[i] = kgo.RecordHeader{Key: header.Key, Value: header.Value}
+	}
+	return rs
+}
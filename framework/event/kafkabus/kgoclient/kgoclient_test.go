@@ -0,0 +1,158 @@
+package kgoclient_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/event/kafkabus"
+	"github.com/kittipat1413/go-common/framework/event/kafkabus/kgoclient"
+)
+
+type order struct {
+	ID string `json:"id"`
+}
+
+// newCluster returns an in-memory Kafka cluster with the topic "orders" of 2 partitions.
+func newCluster(t *testing.T) *kfake.Cluster {
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(2, "orders"))
+	require.NoError(t, err)
+	t.Cleanup(cluster.Close)
+	return cluster
+}
+
+// newBus returns a bus over cluster.
+func newBus(t *testing.T, cluster *kfake.Cluster) *kafkabus.Bus[order] {
+	producer, err := kgo.NewClient(kgo.SeedBrokers(cluster.ListenAddrs()...))
+	require.NoError(t, err)
+	t.Cleanup(producer.Close)
+	consumers := kgoclient.NewConsumerFactory(
+		kgo.SeedBrokers(cluster.ListenAddrs()...),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)
+	return kafkabus.New[order](kgoclient.NewProducer(producer), consumers)
+}
+
+// consume handles the messages of "orders" in the group "billing", until n messages are handled.
+func consume(t *testing.T, bus *kafkabus.Bus[order], n int) []event.Message[order] {
+	msgs, err := subscribe(bus, n)
+	require.NoError(t, err)
+	require.Len(t, msgs, n, "the subscription timed out")
+	return msgs
+}
+
+// subscribe handles the messages of "orders" in the group "billing", until n messages are handled or the
+// subscription fails.
+func subscribe(bus *kafkabus.Bus[order], n int) ([]event.Message[order], error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var (
+		mu   sync.Mutex
+		msgs []event.Message[order]
+	)
+	err := bus.Subscribe(ctx, "orders", func(ctx context.Context, msg event.Message[order]) error {
+		mu.Lock()
+		defer mu.Unlock()
+		msgs = append(msgs, msg)
+		if len(msgs) == n {
+			cancel()
+		}
+		return nil
+	}, event.WithGroup("billing"))
+	mu.Lock()
+	defer mu.Unlock()
+	return msgs, err
+}
+
+func TestBus(t *testing.T) {
+	ctx := context.Background()
+	bus := newBus(t, newCluster(t))
+
+	err := bus.Publish(ctx, "orders",
+		event.Message[order]{ID: "1", Key: "order-1", Payload: order{ID: "order-1"}, Metadata: map[string]string{"source": "checkout"}},
+		event.Message[order]{ID: "2", Key: "order-2", Payload: order{ID: "order-2"}},
+	)
+	require.NoError(t, err)
+
+	msgs := consume(t, bus, 2)
+	byID := make(map[string]event.Message[order])
+	for _, msg := range msgs {
+		byID[msg.ID] = msg
+	}
+	require.Contains(t, byID, "1")
+	require.Contains(t, byID, "2")
+	assert.Equal(t, "orders", byID["1"].Topic)
+	assert.Equal(t, "order-1", byID["1"].Key)
+	assert.Equal(t, order{ID: "order-1"}, byID["1"].Payload)
+	assert.Equal(t, "checkout", byID["1"].Metadata["source"])
+	assert.False(t, byID["1"].Timestamp.IsZero())
+
+	// The offsets of the handled records are committed to the group, which resumes after them.
+	require.NoError(t, bus.Publish(ctx, "orders", event.Message[order]{ID: "3", Key: "order-3", Payload: order{ID: "order-3"}}))
+	msgs = consume(t, bus, 1)
+	assert.Equal(t, "3", msgs[0].ID)
+}
+
+func TestConsumerFactory_NoGroup(t *testing.T) {
+	_, err := kgoclient.NewConsumerFactory(kgo.SeedBrokers("localhost:9092"))("orders", "")
+	assert.ErrorIs(t, err, kgoclient.ErrNoGroup)
+}
+
+func TestConsumer_FetchErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     *kerr.Error
+		wantErr bool
+	}{
+		{name: "skipped", err: kerr.UnknownServerError},
+		{name: "fatal", err: kerr.TopicAuthorizationFailed, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := newCluster(t)
+			bus := newBus(t, cluster)
+			require.NoError(t, bus.Publish(context.Background(), "orders",
+				event.Message[order]{ID: "1", Key: "order-1", Payload: order{ID: "order-1"}},
+				event.Message[order]{ID: "2", Key: "order-2", Payload: order{ID: "order-2"}},
+			))
+
+			// The first fetch fails for the partition 1.
+			cluster.ControlKey(int16(kmsg.Fetch), func(req kmsg.Request) (kmsg.Response, error, bool) {
+				fetchReq := req.(*kmsg.FetchRequest)
+				resp := fetchReq.ResponseKind().(*kmsg.FetchResponse)
+				for _, topic := range fetchReq.Topics {
+					respTopic := kmsg.NewFetchResponseTopic()
+					respTopic.Topic, respTopic.TopicID = topic.Topic, topic.TopicID
+					for _, partition := range topic.Partitions {
+						respPartition := kmsg.NewFetchResponseTopicPartition()
+						respPartition.Partition = partition.Partition
+						respPartition.HighWatermark = partition.FetchOffset
+						if partition.Partition == 1 {
+							respPartition.ErrorCode = tt.err.Code
+						}
+						respTopic.Partitions = append(respTopic.Partitions, respPartition)
+					}
+					resp.Topics = append(resp.Topics, respTopic)
+				}
+				return resp, nil, true
+			})
+
+			msgs, err := subscribe(bus, 2)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, msgs, 2, "the subscription goes on after the errors of the partitions")
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package kafkabus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/event"
+)
+
+/*
+Subscription is a subscription to a topic run as a lifecycle.Runnable, draining its records on shutdown: Shutdown
+stops fetching, and waits for the records fetched to be handled and committed, so that they are not handled again
+by the group after a deployment.
+
+Example usage:
+
+	manager := lifecycle.NewManager(lifecycle.WithShutdownTimeout(30 * time.Second))
+	manager.Add("orders-consumer", bus.Subscription("orders", handleOrder, event.WithGroup("billing")))
+	manager.AddCloser("kafka-client", func(ctx context.Context) error {
+		client.Close()
+		return nil
+	})
+	err := manager.Run(ctx)
+*/
+type Subscription[T any] struct {
+	bus     *Bus[T]
+	topic   string
+	handler event.Handler[T]
+	opts    event.SubscribeOptions
+
+	stopOnce sync.Once
+	stop     chan struct{} // stop is closed by Shutdown.
+	done     chan struct{} // done is closed when Run returns.
+}
+
+// Subscription returns a subscription to topic, handling its messages with handler once run.
+func (b *Bus[T]) Subscription(topic string, handler event.Handler[T], opts ...event.SubscribeOption) *Subscription[T] {
+	return &Subscription[T]{
+		bus:     b,
+		topic:   topic,
+		handler: handler,
+		opts:    event.NewSubscribeOptions(opts...),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Run handles the messages of the topic until Shutdown is called or ctx is done, and returns nil then.
+func (s *Subscription[T]) Run(ctx context.Context) error {
+	defer close(s.done)
+	return s.bus.consume(ctx, s.stop, s.topic, s.handler, s.opts)
+}
+
+// Shutdown stops fetching records, and returns once the records fetched are handled and committed, or ctx is done.
+func (s *Subscription[T]) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// consume handles the records of topic until stop is closed or ctx is done. The records fetched are handled before
+// returning if stop is closed, and handled again by the group if ctx is done.
+func (b *Bus[T]) consume(ctx context.Context, stop <-chan struct{}, topic string, handler event.Handler[T], opts event.SubscribeOptions) (err error) {
+	if b.consumers == nil {
+		return ErrNoConsumer
+	}
+	consumer, err := b.consumers(topic, opts.Group)
+	if err != nil {
+		return fmt.Errorf("kafkabus: create consumer: %w", err)
+	}
+	c := &committer{consumer: consumer}
+	defer func() {
+		// The handled records are committed even if ctx is done, so that they are not handled again.
+		commitCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalCommitTimeout)
+		defer cancel()
+		err = errors.Join(err, c.commit(commitCtx), consumer.Close())
+	}()
+
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-fetchCtx.Done():
+		}
+	}()
+
+	// The records that cannot be committed once ctx is done stay pending, for the final commit.
+	commit := func() error {
+		if err := c.commit(ctx); err != nil && ctx.Err() == nil {
+			return err
+		}
+		return nil
+	}
+
+	lastCommit := time.Now()
+	for {
+		records, err := consumer.Fetch(fetchCtx)
+		if fetchCtx.Err() != nil {
+			// Fetch may return records along with the error of the context, to be handled again by the group.
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("kafkabus: fetch: %w", err)
+		}
+
+		for _, record := range records {
+			if !b.handle(ctx, record, handler, opts) {
+				return nil
+			}
+			c.handled(record)
+			if b.opts.commitStrategy == CommitPerMessage {
+				if err := commit(); err != nil {
+					return err
+				}
+			}
+		}
+
+		switch b.opts.commitStrategy {
+		case CommitPerBatch:
+			if err := commit(); err != nil {
+				return err
+			}
+		case CommitInterval:
+			if time.Since(lastCommit) >= b.opts.commitInterval {
+				if err := commit(); err != nil {
+					return err
+				}
+				lastCommit = time.Now()
+			}
+		}
+	}
+}
+
+// handle delivers record to handler, and reports whether it is handled or dropped. The records that cannot be
+// decoded are dropped at once, as their attempts would fail the same way.
+func (b *Bus[T]) handle(ctx context.Context, record Record, handler event.Handler[T], opts event.SubscribeOptions) bool {
+	msg, err := b.decode(record)
+	if err != nil {
+		opts.OnDropped(ctx, msg.Topic, msg.ID, err)
+		return true
+	}
+	if err := event.Deliver(ctx, msg, handler, opts); err != nil && ctx.Err() != nil {
+		return false
+	}
+	return true
+}
+
+// committer holds the records handled but not committed yet.
+type committer struct {
+	consumer Consumer
+	pending  []Record
+}
+
+// handled adds record to the records to commit.
+func (c *committer) handled(record Record) {
+	c.pending = append(c.pending, record)
+}
+
+// commit commits the pending records, if any.
+func (c *committer) commit(ctx context.Context) error {
+	if len(c.pending) == 0 {
+		return nil
+	}
+	if err := c.consumer.Commit(ctx, c.pending...); err != nil {
+		return fmt.Errorf("kafkabus: commit: %w", err)
+	}
+	c.pending = c.pending[:0]
+	return nil
+}
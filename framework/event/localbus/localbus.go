@@ -0,0 +1,272 @@
+package localbus
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/kittipat1413/go-common/framework/event"
+)
+
+// DefaultCapacity is the number of messages a topic retains, unless set with WithCapacity.
+const DefaultCapacity = 10000
+
+// ErrFull is returned by Publish when the messages would exceed the capacity of the topic, because its groups have not
+// handled the messages retained yet.
+var ErrFull = errors.New("localbus: the topic is full")
+
+// options holds configuration options for the bus.
+type options struct {
+	capacity int // capacity is the number of messages a topic retains.
+}
+
+// Option specifies bus configuration options.
+type Option func(*options)
+
+// WithCapacity sets the number of messages a topic retains. It defaults to DefaultCapacity.
+func WithCapacity(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.capacity = n
+		}
+	}
+}
+
+/*
+Bus is an in-memory event.Publisher and event.Subscriber, for tests and for the modules of a monolith.
+
+Every topic keeps a log of its last messages, up to its capacity, and every consumer group an offset in it: each
+group receives every message of the topic, from the first one retained when the group subscribes for the first
+time, and the subscriptions of a group share its messages. So a subscription started in the background receives
+the messages published before it runs. The messages are delivered at least once: those whose subscription ends
+before they are handled are delivered again to the next subscription of the group. The messages handled, or
+dropped after their attempts, by every group make room for the new ones; Publish returns ErrFull if there is no
+room. With several subscriptions in a group, the messages are handled concurrently, so their order is not
+guaranteed.
+
+Example usage:
+
+	bus := localbus.New[Order]()
+	defer bus.Close()
+
+	go bus.Subscribe(ctx, "orders", func(ctx context.Context, msg event.Message[Order]) error {
+		return bill(ctx, msg.Payload)
+	}, event.WithGroup("billing"))
+
+	err := bus.Publish(ctx, "orders", event.Message[Order]{Key: order.ID, Payload: order})
+*/
+type Bus[T any] struct {
+	opts options
+
+	mu     sync.Mutex
+	topics map[string]*topic[T]
+	closed bool
+	// wake is closed and replaced whenever a message becomes available, to wake up the waiting subscriptions.
+	wake chan struct{}
+}
+
+// topic is the log of the messages of a topic, from offset base, and the offsets of its groups in it.
+type topic[T any] struct {
+	base     int
+	messages []event.Message[T]
+	groups   map[string]*group
+}
+
+// group is the state of a consumer group in a topic log.
+type group struct {
+	next     int              // next is the offset of the next message never delivered to the group.
+	released []int            // released are the offsets of the messages to deliver again.
+	inFlight map[int]struct{} // inFlight are the offsets of the messages being handled.
+}
+
+// New creates an in-memory bus.
+func New[T any](opts ...Option) *Bus[T] {
+	o := options{capacity: DefaultCapacity}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Bus[T]{
+		opts:   o,
+		topics: make(map[string]*topic[T]),
+		wake:   make(chan struct{}),
+	}
+}
+
+// Publish appends the messages to the log of topic, setting their topic, and their ID and timestamp if empty. The
+// messages are all published, or none if the capacity of the topic would be exceeded.
+func (b *Bus[T]) Publish(ctx context.Context, topic string, msgs ...event.Message[T]) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return event.ErrClosed
+	}
+	t := b.topic(topic)
+	if len(t.messages)+len(msgs) > b.opts.capacity {
+		t.trim()
+		if len(t.messages)+len(msgs) > b.opts.capacity {
+			return ErrFull
+		}
+	}
+	now := time.Now()
+	for _, msg := range msgs {
+		msg.Topic = topic
+		if msg.ID == "" {
+			msg.ID = xid.New().String()
+		}
+		if msg.Timestamp.IsZero() {
+			msg.Timestamp = now
+		}
+		msg.Metadata = maps.Clone(msg.Metadata)
+		msg.Attempt = 0
+		t.messages = append(t.messages, msg)
+	}
+	if len(msgs) > 0 {
+		b.notify()
+	}
+	return nil
+}
+
+// Subscribe handles the messages of topic for the group of opts until ctx is done, and returns nil then, or
+// event.ErrClosed once the bus is closed. It delivers the messages with event.Deliver.
+func (b *Bus[T]) Subscribe(ctx context.Context, topic string, handler event.Handler[T], opts ...event.SubscribeOption) error {
+	o := event.NewSubscribeOptions(opts...)
+	for {
+		msg, offset, wake, err := b.claim(topic, o.Group)
+		if err != nil {
+			return err
+		}
+		if wake != nil {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-wake:
+				continue
+			}
+		}
+
+		if err := event.Deliver(ctx, msg, handler, o); err != nil && ctx.Err() != nil {
+			b.release(topic, o.Group, offset)
+			return nil
+		}
+		b.ack(topic, o.Group, offset)
+	}
+}
+
+// Close closes the bus: the subscriptions return event.ErrClosed once their message is handled, and Publish
+// returns event.ErrClosed. The messages not handled yet are discarded.
+func (b *Bus[T]) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		b.topics = nil
+		close(b.wake)
+	}
+	return nil
+}
+
+// claim returns the next message of topic to deliver to group and its offset, or a channel closed once a message
+// may be available if there is none.
+func (b *Bus[T]) claim(topic, groupName string) (event.Message[T], int, <-chan struct{}, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return event.Message[T]{}, 0, nil, event.ErrClosed
+	}
+	t := b.topic(topic)
+	g, ok := t.groups[groupName]
+	if !ok {
+		g = &group{next: t.base, inFlight: make(map[int]struct{})}
+		t.groups[groupName] = g
+	}
+
+	var offset int
+	switch {
+	case len(g.released) > 0:
+		offset = g.released[0]
+		g.released = g.released[1:]
+	case g.next < t.base+len(t.messages):
+		offset = g.next
+		g.next++
+	default:
+		return event.Message[T]{}, 0, b.wake, nil
+	}
+	g.inFlight[offset] = struct{}{}
+	msg := t.messages[offset-t.base]
+	msg.Metadata = maps.Clone(msg.Metadata) // The groups must not see the changes of each other.
+	return msg, offset, nil, nil
+}
+
+// release makes the message at offset available again to group.
+func (b *Bus[T]) release(topic, groupName string, offset int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	g := b.topics[topic].groups[groupName]
+	delete(g.inFlight, offset)
+	g.released = append(g.released, offset)
+	b.notify()
+}
+
+// ack marks the message at offset as handled by group.
+func (b *Bus[T]) ack(topic, groupName string, offset int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	delete(b.topics[topic].groups[groupName].inFlight, offset)
+}
+
+// trim removes the messages handled by every group from the log. None are removed if the topic has no group.
+func (t *topic[T]) trim() {
+	if len(t.groups) == 0 {
+		return
+	}
+	low := t.base + len(t.messages)
+	for _, g := range t.groups {
+		low = min(low, g.low())
+	}
+	if n := low - t.base; n > 0 {
+		clear(t.messages[:n])
+		t.messages = t.messages[n:]
+		t.base = low
+	}
+}
+
+// low returns the lowest offset the group has not handled yet.
+func (g *group) low() int {
+	low := g.next
+	for _, offset := range g.released {
+		low = min(low, offset)
+	}
+	for offset := range g.inFlight {
+		low = min(low, offset)
+	}
+	return low
+}
+
+// topic returns the log of the topic, creating it if needed. b.mu must be held.
+func (b *Bus[T]) topic(name string) *topic[T] {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic[T]{groups: make(map[string]*group)}
+		b.topics[name] = t
+	}
+	return t
+}
+
+// notify wakes up the waiting subscriptions. b.mu must be held.
+func (b *Bus[T]) notify() {
+	close(b.wake)
+	b.wake = make(chan struct{})
+}
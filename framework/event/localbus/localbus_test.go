@@ -0,0 +1,208 @@
+package localbus_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/event/localbus"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+// subscribe runs a subscription in the background, sending the payloads it handles to the returned channel, and
+// returns a function ending the subscription and returning its error.
+func subscribe(t *testing.T, bus *localbus.Bus[string], topic string, opts ...event.SubscribeOption) (<-chan event.Message[string], func() error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	handled := make(chan event.Message[string], 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- bus.Subscribe(ctx, topic, func(ctx context.Context, msg event.Message[string]) error {
+			handled <- msg
+			return nil
+		}, opts...)
+	}()
+	stop := func() error {
+		cancel()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(time.Second):
+			t.Fatal("Subscribe did not return once the context was done")
+			return nil
+		}
+	}
+	t.Cleanup(func() { _ = stop() })
+	return handled, stop
+}
+
+// receive returns the next message of handled.
+func receive(t *testing.T, handled <-chan event.Message[string]) event.Message[string] {
+	t.Helper()
+	select {
+	case msg := <-handled:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message handled")
+		return event.Message[string]{}
+	}
+}
+
+func TestBus_Groups(t *testing.T) {
+	ctx := context.Background()
+	bus := localbus.New[string]()
+	defer bus.Close()
+
+	require.NoError(t, bus.Publish(ctx, "orders", event.Message[string]{Key: "order:1", Payload: "first"}))
+	billing, _ := subscribe(t, bus, "orders", event.WithGroup("billing"))
+	shipping, _ := subscribe(t, bus, "orders", event.WithGroup("shipping"))
+	other, _ := subscribe(t, bus, "users", event.WithGroup("billing"))
+	require.NoError(t, bus.Publish(ctx, "orders", event.Message[string]{Payload: "second", Metadata: map[string]string{"source": "checkout"}}))
+
+	for _, handled := range []<-chan event.Message[string]{billing, shipping} {
+		msg := receive(t, handled)
+		assert.Equal(t, "first", msg.Payload)
+		assert.Equal(t, "orders", msg.Topic)
+		assert.Equal(t, "order:1", msg.Key)
+		assert.NotEmpty(t, msg.ID)
+		assert.False(t, msg.Timestamp.IsZero())
+		assert.Equal(t, 1, msg.Attempt)
+
+		msg = receive(t, handled)
+		assert.Equal(t, "second", msg.Payload)
+		assert.Equal(t, "checkout", msg.Metadata["source"])
+	}
+	select {
+	case msg := <-other:
+		t.Fatalf("message of another topic handled: %v", msg)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestBus_SharedGroup(t *testing.T) {
+	ctx := context.Background()
+	bus := localbus.New[string]()
+	defer bus.Close()
+
+	first, _ := subscribe(t, bus, "orders", event.WithGroup("billing"))
+	second, _ := subscribe(t, bus, "orders", event.WithGroup("billing"))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, bus.Publish(ctx, "orders", event.Message[string]{}))
+	}
+
+	ids := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		select {
+		case msg := <-first:
+			ids[msg.ID] = true
+		case msg := <-second:
+			ids[msg.ID] = true
+		case <-time.After(time.Second):
+			t.Fatal("no message handled")
+		}
+	}
+	assert.Len(t, ids, 10, "every message is handled once by the group")
+}
+
+func TestBus_Retry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := localbus.New[string]()
+	defer bus.Close()
+
+	var mu sync.Mutex
+	var attempts []int
+	dropped := make(chan string, 1)
+	go func() {
+		_ = bus.Subscribe(ctx, "orders", func(ctx context.Context, msg event.Message[string]) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts = append(attempts, msg.Attempt)
+			return errors.New("handle failed")
+		},
+			event.WithMaxAttempts(2),
+			event.WithBackoff(retry.Constant(0)),
+			event.WithOnDropped(func(ctx context.Context, topic, id string, err error) { dropped <- id }),
+		)
+	}()
+	require.NoError(t, bus.Publish(ctx, "orders", event.Message[string]{ID: "1"}))
+
+	select {
+	case id := <-dropped:
+		assert.Equal(t, "1", id)
+	case <-time.After(time.Second):
+		t.Fatal("the message was not dropped")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int{1, 2}, attempts)
+}
+
+func TestBus_Redelivery(t *testing.T) {
+	bus := localbus.New[string]()
+	defer bus.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- bus.Subscribe(ctx, "orders", func(ctx context.Context, msg event.Message[string]) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}, event.WithGroup("billing"))
+	}()
+	require.NoError(t, bus.Publish(context.Background(), "orders", event.Message[string]{ID: "1"}))
+	<-started
+	cancel()
+	require.NoError(t, <-done)
+
+	handled, _ := subscribe(t, bus, "orders", event.WithGroup("billing"))
+	msg := receive(t, handled)
+	assert.Equal(t, "1", msg.ID, "the message interrupted is delivered again to the group")
+}
+
+func TestBus_Capacity(t *testing.T) {
+	ctx := context.Background()
+	bus := localbus.New[string](localbus.WithCapacity(2))
+	defer bus.Close()
+
+	require.NoError(t, bus.Publish(ctx, "orders", event.Message[string]{}))
+	assert.ErrorIs(t, bus.Publish(ctx, "orders", event.Message[string]{}, event.Message[string]{}), localbus.ErrFull)
+	require.NoError(t, bus.Publish(ctx, "users", event.Message[string]{}, event.Message[string]{}))
+
+	handled, _ := subscribe(t, bus, "orders")
+	receive(t, handled)
+	require.Eventually(t, func() bool {
+		return bus.Publish(ctx, "orders", event.Message[string]{}, event.Message[string]{}) == nil
+	}, time.Second, time.Millisecond, "the messages handled by every group are released")
+}
+
+func TestBus_Close(t *testing.T) {
+	ctx := context.Background()
+	bus := localbus.New[string]()
+	done := make(chan error)
+	go func() {
+		done <- bus.Subscribe(ctx, "orders", func(context.Context, event.Message[string]) error { return nil })
+	}()
+
+	require.NoError(t, bus.Close())
+	require.NoError(t, bus.Close())
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, event.ErrClosed)
+	case <-time.After(time.Second):
+		t.Fatal("Subscribe did not return once the bus was closed")
+	}
+	assert.ErrorIs(t, bus.Publish(ctx, "orders", event.Message[string]{}), event.ErrClosed)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, localbus.New[string]().Publish(canceled, "orders"), context.Canceled)
+}
@@ -0,0 +1,65 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// PublisherLogging returns a Decorator logging every call to Publish with l, or with the logger of the context if
+// l is nil: at debug level with the topic, the number of messages and the duration, and at error level when it
+// fails.
+func PublisherLogging[T any](l logger.Logger) Decorator[T] {
+	return func(p Publisher[T]) Publisher[T] {
+		return PublisherFunc[T](func(ctx context.Context, topic string, msgs ...Message[T]) error {
+			start := time.Now()
+			err := p.Publish(ctx, topic, msgs...)
+			fields := logger.Fields{
+				"topic":       topic,
+				"messages":    len(msgs),
+				"duration_ms": time.Since(start).Milliseconds(),
+			}
+			log := loggerOrContext(ctx, l)
+			if err != nil {
+				log.Error(ctx, "event publish", err, fields)
+			} else {
+				log.Debug(ctx, "event publish", fields)
+			}
+			return err
+		})
+	}
+}
+
+// HandlerLogging returns a Middleware logging every attempt to handle a message with l, or with the logger of the
+// context if l is nil: at debug level with the topic, the ID and the attempt of the message and the duration, and
+// at error level when it fails.
+func HandlerLogging[T any](l logger.Logger) Middleware[T] {
+	return func(next Handler[T]) Handler[T] {
+		return func(ctx context.Context, msg Message[T]) error {
+			start := time.Now()
+			err := next(ctx, msg)
+			fields := logger.Fields{
+				"topic":       msg.Topic,
+				"id":          msg.ID,
+				"attempt":     msg.Attempt,
+				"duration_ms": time.Since(start).Milliseconds(),
+			}
+			log := loggerOrContext(ctx, l)
+			if err != nil {
+				log.Error(ctx, "event handle", err, fields)
+			} else {
+				log.Debug(ctx, "event handle", fields)
+			}
+			return err
+		}
+	}
+}
+
+// loggerOrContext returns l, or the logger of ctx if l is nil.
+func loggerOrContext(ctx context.Context, l logger.Logger) logger.Logger {
+	if l != nil {
+		return l
+	}
+	return logger.FromContext(ctx)
+}
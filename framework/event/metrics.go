@@ -0,0 +1,113 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+// Values of the status label of the metrics.
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+/*
+Metrics records the messages published and handled in a metrics.Registry, labelled by topic and status:
+
+	event_published_total{topic="orders",status="success"} 1024
+	event_handled_total{topic="orders",status="error"} 3
+	event_handling_seconds_bucket{topic="orders",le="0.1"} 1019
+
+and the retries, dead letters and panics of the consumer middlewares, labelled by topic and handler:
+
+	event_retried_total{topic="orders",handler="billing"} 12
+	event_dead_lettered_total{topic="orders",handler="billing"} 1
+	event_panics_total{topic="orders",handler="billing"} 0
+
+Example usage:
+
+	eventMetrics, err := event.NewMetrics(metrics.Default())
+	if err != nil {
+		// Handle error
+	}
+	publisher := event.Chain[Order](bus, event.PublisherMetrics[Order](eventMetrics))
+*/
+type Metrics struct {
+	published *metrics.Counter
+	handled   *metrics.Counter
+	handling  *metrics.Histogram
+
+	retried      *metrics.Counter
+	deadLettered *metrics.Counter
+	panics       *metrics.Counter
+}
+
+// NewMetrics creates the metrics of the messages in registry, or returns the ones already created.
+func NewMetrics(registry *metrics.Registry) (*Metrics, error) {
+	published, err := registry.NewCounter("event_published_total", "Number of messages published.", "topic", "status")
+	if err != nil {
+		return nil, err
+	}
+	handled, err := registry.NewCounter("event_handled_total", "Number of attempts to handle a message.", "topic", "status")
+	if err != nil {
+		return nil, err
+	}
+	handling, err := registry.NewHistogram("event_handling_seconds", "Duration of the attempts to handle a message in seconds.", nil, "topic")
+	if err != nil {
+		return nil, err
+	}
+	retried, err := registry.NewCounter("event_retried_total", "Number of retries of a handler.", "topic", "handler")
+	if err != nil {
+		return nil, err
+	}
+	deadLettered, err := registry.NewCounter("event_dead_lettered_total", "Number of messages published to a dead-letter topic.", "topic", "handler")
+	if err != nil {
+		return nil, err
+	}
+	panics, err := registry.NewCounter("event_panics_total", "Number of panics of a handler.", "topic", "handler")
+	if err != nil {
+		return nil, err
+	}
+	return &Metrics{
+		published:    published,
+		handled:      handled,
+		handling:     handling,
+		retried:      retried,
+		deadLettered: deadLettered,
+		panics:       panics,
+	}, nil
+}
+
+// status returns the status label of err.
+func status(err error) string {
+	if err != nil {
+		return StatusError
+	}
+	return StatusSuccess
+}
+
+// PublisherMetrics returns a Decorator counting the messages published in m.
+func PublisherMetrics[T any](m *Metrics) Decorator[T] {
+	return func(p Publisher[T]) Publisher[T] {
+		return PublisherFunc[T](func(ctx context.Context, topic string, msgs ...Message[T]) error {
+			err := p.Publish(ctx, topic, msgs...)
+			m.published.Add(float64(len(msgs)), topic, status(err))
+			return err
+		})
+	}
+}
+
+// HandlerMetrics returns a Middleware counting the attempts to handle a message, and their duration, in m.
+func HandlerMetrics[T any](m *Metrics) Middleware[T] {
+	return func(next Handler[T]) Handler[T] {
+		return func(ctx context.Context, msg Message[T]) error {
+			start := time.Now()
+			err := next(ctx, msg)
+			m.handling.Observe(time.Since(start).Seconds(), msg.Topic)
+			m.handled.Inc(msg.Topic, status(err))
+			return err
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// DefaultTable is the name of the outbox table, unless set with WithTable.
+const DefaultTable = "event_outbox"
+
+// Dialect is the SQL dialect of the database of the outbox.
+type Dialect int
+
+const (
+	// Postgres is the dialect of PostgreSQL. It is the default dialect.
+	Postgres Dialect = iota
+	// MySQL is the dialect of MySQL 8 and later.
+	MySQL
+)
+
+// placeholder returns the placeholder of the nth argument of a query, from 1.
+func (d Dialect) placeholder(n int) string {
+	if d == MySQL {
+		return "?"
+	}
+	return "$" + strconv.Itoa(n)
+}
+
+// placeholders returns the placeholders of the arguments of a query, from the nth, separated by commas.
+func (d Dialect) placeholders(n, count int) string {
+	placeholders := make([]string, count)
+	for i := range placeholders {
+		placeholders[i] = d.placeholder(n + i)
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+/*
+Schema returns the statements creating the outbox table in the dialect.
+
+The columns are:
+  - id: the ID of the event, published as the ID of the message, so that the consumers deduplicate the events
+    published more than once, e.g., when the relay fails before marking them as published.
+  - topic, event_key, payload and metadata: the message to publish, its payload encoded with the codec of the Writer.
+  - created_at: the time the event was written, giving the order of publishing.
+  - published_at: the time the event was published, or NULL.
+  - attempts and last_error: the failed attempts to publish the event.
+*/
+func (d Dialect) Schema(table string) []string {
+	payload, timestamp := "BYTEA", "TIMESTAMPTZ"
+	if d == MySQL {
+		payload, timestamp = "LONGBLOB", "DATETIME(6)"
+	}
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR(64) PRIMARY KEY,
+	topic VARCHAR(255) NOT NULL,
+	event_key VARCHAR(255) NOT NULL,
+	payload %s NOT NULL,
+	metadata TEXT NOT NULL,
+	created_at %s NOT NULL,
+	published_at %s NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT NULL
+)`, table, payload, timestamp, timestamp),
+		fmt.Sprintf(`CREATE INDEX %s_unpublished ON %s (published_at, created_at)`, table, table),
+	}
+}
+
+// options holds configuration options for the outbox.
+type options struct {
+	table     string        // table is the name of the outbox table.
+	dialect   Dialect       // dialect is the SQL dialect of the database.
+	interval  time.Duration // interval is the interval between the polls of the relay when the outbox is empty.
+	batchSize int           // batchSize is the number of events published by the relay per transaction.
+	retention time.Duration // retention is the time the published events are kept, or zero to keep them.
+	logger    logger.Logger // logger logs the failures of the relay, or the logger of the context if nil.
+}
+
+// Option specifies outbox configuration options.
+type Option func(*options)
+
+// WithTable sets the name of the outbox table. It defaults to DefaultTable.
+func WithTable(table string) Option {
+	return func(opts *options) {
+		if table != "" {
+			opts.table = table
+		}
+	}
+}
+
+// WithDialect sets the SQL dialect of the database. It defaults to Postgres.
+func WithDialect(dialect Dialect) Option {
+	return func(opts *options) {
+		opts.dialect = dialect
+	}
+}
+
+// WithRelayInterval sets the interval between the polls of the relay when the outbox is empty. It defaults to
+// DefaultRelayInterval.
+func WithRelayInterval(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.interval = d
+		}
+	}
+}
+
+// WithBatchSize sets the number of events published by the relay per transaction. It defaults to DefaultBatchSize.
+func WithBatchSize(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.batchSize = n
+		}
+	}
+}
+
+// WithRetention sets the time the published events are kept in the outbox, e.g., to investigate, after which the
+// relay deletes them. They are kept by default.
+func WithRetention(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.retention = d
+		}
+	}
+}
+
+// WithLogger sets the logger of the failures of the relay. It defaults to the logger of the context.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		if l != nil {
+			opts.logger = l
+		}
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{table: DefaultTable, dialect: Postgres, interval: DefaultRelayInterval, batchSize: DefaultBatchSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Execer executes a statement, e.g., an *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+/*
+Writer writes events to the outbox within the transaction of the caller, so that they are published by the Relay
+if, and only if, the transaction commits. It closes the gap of writing to the database and publishing to the bus
+in two steps, one of which may fail.
+
+Example usage:
+
+	events := outbox.NewWriter[OrderPlaced](nil)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := orders.Insert(ctx, tx, order); err != nil {
+		return err
+	}
+	if err := events.Write(ctx, tx, "orders", event.Message[OrderPlaced]{Key: order.ID, Payload: orderPlaced}); err != nil {
+		return err
+	}
+	return tx.Commit()
+*/
+type Writer[T any] struct {
+	codec event.Codec[T]
+	opts  options
+}
+
+// NewWriter creates a writer encoding the payloads with codec, or event.JSONCodec if nil.
+func NewWriter[T any](codec event.Codec[T], opts ...Option) *Writer[T] {
+	if codec == nil {
+		codec = event.JSONCodec[T]()
+	}
+	return &Writer[T]{codec: codec, opts: newOptions(opts)}
+}
+
+// Write inserts the messages to publish on topic in the outbox with tx, setting their ID and timestamp if empty.
+func (w *Writer[T]) Write(ctx context.Context, tx Execer, topic string, msgs ...event.Message[T]) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	const columns = 6
+	values := make([]string, 0, len(msgs))
+	args := make([]any, 0, len(msgs)*columns)
+	now := time.Now().UTC()
+	for _, msg := range msgs {
+		if msg.ID == "" {
+			msg.ID = xid.New().String()
+		}
+		if msg.Timestamp.IsZero() {
+			msg.Timestamp = now
+		}
+		payload, err := w.codec.Marshal(msg.Payload)
+		if err != nil {
+			return fmt.Errorf("outbox: encode message %s: %w", msg.ID, err)
+		}
+		metadata, err := json.Marshal(msg.Metadata)
+		if err != nil {
+			return fmt.Errorf("outbox: encode metadata of message %s: %w", msg.ID, err)
+		}
+		values = append(values, "("+w.opts.dialect.placeholders(len(args)+1, columns)+")")
+		args = append(args, msg.ID, topic, msg.Key, payload, string(metadata), msg.Timestamp.UTC())
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (id, topic, event_key, payload, metadata, created_at) VALUES %s",
+		w.opts.table, strings.Join(values, ", "))
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("outbox: write messages: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/event/outbox"
+)
+
+type order struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+// execerFunc is an outbox.Execer calling the function.
+type execerFunc func(ctx context.Context, query string, args ...any) (sql.Result, error)
+
+func (f execerFunc) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return f(ctx, query, args...)
+}
+
+func TestSchema(t *testing.T) {
+	postgres := outbox.Postgres.Schema("events")
+	require.Len(t, postgres, 2)
+	assert.Contains(t, postgres[0], "CREATE TABLE IF NOT EXISTS events")
+	assert.Contains(t, postgres[0], "payload BYTEA")
+	assert.Contains(t, postgres[1], "CREATE INDEX events_unpublished ON events")
+
+	mysql := outbox.MySQL.Schema("events")
+	assert.Contains(t, mysql[0], "payload LONGBLOB")
+	assert.Contains(t, mysql[0], "DATETIME(6)")
+}
+
+func TestWriter(t *testing.T) {
+	t.Run("inserts the messages in one statement", func(t *testing.T) {
+		var query string
+		var args []any
+		tx := execerFunc(func(ctx context.Context, q string, a ...any) (sql.Result, error) {
+			query, args = q, a
+			return nil, nil
+		})
+
+		writer := outbox.NewWriter[order](nil)
+		err := writer.Write(context.Background(), tx, "orders",
+			event.Message[order]{Key: "o1", Payload: order{ID: "o1", Amount: 10}, Metadata: map[string]string{"tenant": "a"}},
+			event.Message[order]{ID: "id-2", Payload: order{ID: "o2"}},
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, "INSERT INTO event_outbox (id, topic, event_key, payload, metadata, created_at) "+
+			"VALUES ($1, $2, $3, $4, $5, $6), ($7, $8, $9, $10, $11, $12)", query)
+		require.Len(t, args, 12)
+		assert.NotEmpty(t, args[0])
+		assert.Equal(t, "orders", args[1])
+		assert.Equal(t, "o1", args[2])
+		assert.JSONEq(t, `{"id":"o1","amount":10}`, string(args[3].([]byte)))
+		assert.JSONEq(t, `{"tenant":"a"}`, args[4].(string))
+		assert.Equal(t, time.UTC, args[5].(time.Time).Location())
+		assert.Equal(t, "id-2", args[6])
+		assert.Equal(t, "null", args[10])
+	})
+
+	t.Run("uses the table and dialect", func(t *testing.T) {
+		var query string
+		tx := execerFunc(func(ctx context.Context, q string, a ...any) (sql.Result, error) {
+			query = q
+			return nil, nil
+		})
+
+		writer := outbox.NewWriter[order](nil, outbox.WithTable("events"), outbox.WithDialect(outbox.MySQL))
+		require.NoError(t, writer.Write(context.Background(), tx, "orders", event.Message[order]{}))
+		assert.True(t, strings.HasPrefix(query, "INSERT INTO events "))
+		assert.True(t, strings.HasSuffix(query, "VALUES (?, ?, ?, ?, ?, ?)"))
+	})
+
+	t.Run("does nothing without messages", func(t *testing.T) {
+		tx := execerFunc(func(ctx context.Context, q string, a ...any) (sql.Result, error) {
+			t.Fatal("unexpected statement")
+			return nil, nil
+		})
+		require.NoError(t, outbox.NewWriter[order](nil).Write(context.Background(), tx, "orders"))
+	})
+
+	t.Run("returns the error of the statement", func(t *testing.T) {
+		errExec := errors.New("exec failed")
+		tx := execerFunc(func(ctx context.Context, q string, a ...any) (sql.Result, error) {
+			return nil, errExec
+		})
+		err := outbox.NewWriter[order](nil).Write(context.Background(), tx, "orders", event.Message[order]{})
+		assert.ErrorIs(t, err, errExec)
+	})
+}
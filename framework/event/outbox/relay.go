@@ -0,0 +1,194 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// Defaults of the Relay, unless set with WithRelayInterval and WithBatchSize.
+const (
+	DefaultRelayInterval = time.Second
+	DefaultBatchSize     = 100
+)
+
+/*
+Relay publishes the events of the outbox with an event.Publisher, in the order they were written, and marks them
+as published. It is a lifecycle.Runnable.
+
+The events are published at least once: if the relay stops between publishing an event and marking it, the event
+is published again, with the same ID, so that the consumers deduplicate it. The events are locked while being
+published, with FOR UPDATE SKIP LOCKED, so several replicas can run a relay. An event that cannot be published
+stops the batch, to keep the order of the events, and is published again on the next poll.
+
+With event.JSONCodec, a Relay[json.RawMessage] publishes the events of any type without decoding them.
+
+Example usage:
+
+	bus := kafkabus.New[json.RawMessage](producer, nil)
+	relay := outbox.NewRelay[json.RawMessage](db, bus, nil, outbox.WithRetention(7*24*time.Hour))
+	manager.Add("outbox-relay", relay)
+*/
+type Relay[T any] struct {
+	db        *sql.DB
+	publisher event.Publisher[T]
+	codec     event.Codec[T]
+	opts      options
+}
+
+// NewRelay creates a relay publishing the events of the outbox of db with publisher, decoding their payloads with
+// codec, or event.JSONCodec if nil. The table and dialect must match the ones of the Writer.
+func NewRelay[T any](db *sql.DB, publisher event.Publisher[T], codec event.Codec[T], opts ...Option) *Relay[T] {
+	if codec == nil {
+		codec = event.JSONCodec[T]()
+	}
+	return &Relay[T]{db: db, publisher: publisher, codec: codec, opts: newOptions(opts)}
+}
+
+// Run relays the events of the outbox until ctx is done, and returns nil then.
+func (r *Relay[T]) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+
+		n, err := r.RelayOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.log(ctx).Error(ctx, "Failed to relay outbox events", err, nil)
+		}
+		if err == nil && n == r.opts.batchSize {
+			// More events are waiting.
+			timer.Reset(0)
+		} else {
+			timer.Reset(r.opts.interval)
+		}
+	}
+}
+
+// record is an event read from the outbox.
+type record struct {
+	id        string
+	topic     string
+	key       string
+	payload   []byte
+	metadata  string
+	createdAt time.Time
+}
+
+// RelayOnce publishes a batch of the events of the outbox, and returns the number of events published.
+func (r *Relay[T]) RelayOnce(ctx context.Context) (n int, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("outbox: begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	records, err := r.fetch(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	var published []any
+	var publishErr error
+	for _, rec := range records {
+		if publishErr = r.publish(ctx, rec); publishErr != nil {
+			r.log(ctx).Error(ctx, "Failed to publish outbox event", publishErr, logger.Fields{"id": rec.id, "topic": rec.topic})
+			query := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = %s WHERE id = %s",
+				r.opts.table, r.opts.dialect.placeholder(1), r.opts.dialect.placeholder(2))
+			if _, err := tx.ExecContext(ctx, query, publishErr.Error(), rec.id); err != nil {
+				return 0, fmt.Errorf("outbox: record failure: %w", err)
+			}
+			break
+		}
+		published = append(published, rec.id)
+	}
+
+	if len(published) > 0 {
+		query := fmt.Sprintf("UPDATE %s SET published_at = %s WHERE id IN (%s)",
+			r.opts.table, r.opts.dialect.placeholder(1), r.opts.dialect.placeholders(2, len(published)))
+		if _, err := tx.ExecContext(ctx, query, append([]any{time.Now().UTC()}, published...)...); err != nil {
+			return 0, fmt.Errorf("outbox: mark published: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("outbox: commit: %w", err)
+	}
+
+	if r.opts.retention > 0 {
+		query := fmt.Sprintf("DELETE FROM %s WHERE published_at < %s", r.opts.table, r.opts.dialect.placeholder(1))
+		if _, err := r.db.ExecContext(ctx, query, time.Now().UTC().Add(-r.opts.retention)); err != nil {
+			return len(published), fmt.Errorf("outbox: delete published events: %w", err)
+		}
+	}
+	if publishErr != nil {
+		return len(published), fmt.Errorf("outbox: publish: %w", publishErr)
+	}
+	return len(published), nil
+}
+
+// fetch locks and returns the next events to publish.
+func (r *Relay[T]) fetch(ctx context.Context, tx *sql.Tx) ([]record, error) {
+	query := fmt.Sprintf(`SELECT id, topic, event_key, payload, metadata, created_at FROM %s
+WHERE published_at IS NULL ORDER BY created_at, id LIMIT %d FOR UPDATE SKIP LOCKED`, r.opts.table, r.opts.batchSize)
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("outbox: fetch events: %w", err)
+	}
+	defer rows.Close()
+
+	var records []record
+	for rows.Next() {
+		var rec record
+		if err := rows.Scan(&rec.id, &rec.topic, &rec.key, &rec.payload, &rec.metadata, &rec.createdAt); err != nil {
+			return nil, fmt.Errorf("outbox: fetch events: %w", err)
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox: fetch events: %w", err)
+	}
+	return records, nil
+}
+
+// publish publishes the event of rec.
+func (r *Relay[T]) publish(ctx context.Context, rec record) error {
+	payload, err := r.codec.Unmarshal(rec.payload)
+	if err != nil {
+		return fmt.Errorf("decode message %s: %w", rec.id, err)
+	}
+	var metadata map[string]string
+	if err := json.Unmarshal([]byte(rec.metadata), &metadata); err != nil {
+		return fmt.Errorf("decode metadata of message %s: %w", rec.id, err)
+	}
+	msg := event.Message[T]{
+		ID:        rec.id,
+		Key:       rec.key,
+		Payload:   payload,
+		Metadata:  metadata,
+		Timestamp: rec.createdAt,
+	}
+	if err := r.publisher.Publish(ctx, rec.topic, msg); err != nil {
+		return err
+	}
+	return nil
+}
+
+// log returns the logger of the relay, or the logger of ctx.
+func (r *Relay[T]) log(ctx context.Context) logger.Logger {
+	if r.opts.logger != nil {
+		return r.opts.logger
+	}
+	return logger.FromContext(ctx)
+}
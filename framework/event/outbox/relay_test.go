@@ -0,0 +1,279 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/event/outbox"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// statement is a statement executed by the fake database.
+type statement struct {
+	query string
+	args  []driver.Value
+}
+
+// fakeDB is a database/sql driver returning the scripted rows to the queries, and recording the statements and the
+// outcome of the transactions.
+type fakeDB struct {
+	mu         sync.Mutex
+	rows       [][][]driver.Value // rows are the results of the next queries.
+	queryErr   error
+	statements []statement
+	commits    int
+	rollbacks  int
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+func (db *fakeDB) executed() []statement {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]statement(nil), db.statements...)
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return fakeTx(c), nil }
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	c.db.statements = append(c.db.statements, statement{query: query, args: values})
+	return driver.RowsAffected(1), nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.statements = append(c.db.statements, statement{query: query})
+	if c.db.queryErr != nil {
+		return nil, c.db.queryErr
+	}
+	rows := &fakeRows{}
+	if len(c.db.rows) > 0 {
+		rows.values, c.db.rows = c.db.rows[0], c.db.rows[1:]
+	}
+	return rows, nil
+}
+
+type fakeTx fakeConn
+
+func (tx fakeTx) Commit() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.commits++
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.rollbacks++
+	return nil
+}
+
+type fakeRows struct{ values [][]driver.Value }
+
+func (r *fakeRows) Columns() []string {
+	return []string{"id", "topic", "event_key", "payload", "metadata", "created_at"}
+}
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// row returns the row of the outbox of an order.
+func row(id string, o order, createdAt time.Time) []driver.Value {
+	payload, _ := json.Marshal(o)
+	return []driver.Value{id, "orders", o.ID, payload, `{"tenant":"a"}`, createdAt}
+}
+
+// publisherFunc is an event.Publisher calling the function.
+type publisherFunc[T any] func(ctx context.Context, topic string, msgs ...event.Message[T]) error
+
+func (f publisherFunc[T]) Publish(ctx context.Context, topic string, msgs ...event.Message[T]) error {
+	return f(ctx, topic, msgs...)
+}
+
+func TestRelayOnce(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("publishes the events in order and marks them as published", func(t *testing.T) {
+		fake := &fakeDB{rows: [][][]driver.Value{{
+			row("id-1", order{ID: "o1", Amount: 10}, createdAt),
+			row("id-2", order{ID: "o2", Amount: 20}, createdAt),
+		}}}
+		var published []event.Message[order]
+		publisher := publisherFunc[order](func(ctx context.Context, topic string, msgs ...event.Message[order]) error {
+			assert.Equal(t, "orders", topic)
+			published = append(published, msgs...)
+			return nil
+		})
+
+		relay := outbox.NewRelay[order](sql.OpenDB(fake), publisher, nil)
+		n, err := relay.RelayOnce(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		require.Len(t, published, 2)
+		assert.Equal(t, event.Message[order]{
+			ID:        "id-1",
+			Key:       "o1",
+			Payload:   order{ID: "o1", Amount: 10},
+			Metadata:  map[string]string{"tenant": "a"},
+			Timestamp: createdAt,
+		}, published[0])
+		assert.Equal(t, "id-2", published[1].ID)
+
+		statements := fake.executed()
+		require.Len(t, statements, 2)
+		assert.Contains(t, statements[0].query, "FROM event_outbox")
+		assert.Contains(t, statements[0].query, "LIMIT 100 FOR UPDATE SKIP LOCKED")
+		assert.Equal(t, "UPDATE event_outbox SET published_at = $1 WHERE id IN ($2, $3)", statements[1].query)
+		assert.Equal(t, []driver.Value{"id-1", "id-2"}, statements[1].args[1:])
+		assert.Equal(t, 1, fake.commits)
+	})
+
+	t.Run("stops the batch at the first failure", func(t *testing.T) {
+		fake := &fakeDB{rows: [][][]driver.Value{{
+			row("id-1", order{ID: "o1"}, createdAt),
+			row("id-2", order{ID: "o2"}, createdAt),
+			row("id-3", order{ID: "o3"}, createdAt),
+		}}}
+		errPublish := errors.New("broker unavailable")
+		var published []string
+		publisher := publisherFunc[order](func(ctx context.Context, topic string, msgs ...event.Message[order]) error {
+			if msgs[0].ID == "id-2" {
+				return errPublish
+			}
+			published = append(published, msgs[0].ID)
+			return nil
+		})
+		log, recorder := logger.NewTestLogger()
+
+		relay := outbox.NewRelay[order](sql.OpenDB(fake), publisher, nil, outbox.WithDialect(outbox.MySQL), outbox.WithLogger(log))
+		n, err := relay.RelayOnce(context.Background())
+		assert.ErrorIs(t, err, errPublish)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []string{"id-1"}, published)
+
+		statements := fake.executed()
+		require.Len(t, statements, 3)
+		assert.Equal(t, "UPDATE event_outbox SET attempts = attempts + 1, last_error = ? WHERE id = ?", statements[1].query)
+		assert.Equal(t, []driver.Value{"broker unavailable", "id-2"}, statements[1].args)
+		assert.Equal(t, "UPDATE event_outbox SET published_at = ? WHERE id IN (?)", statements[2].query)
+		assert.Equal(t, 1, fake.commits)
+		recorder.AssertLogged(t, logger.ERROR, "Failed to publish outbox event", logger.HasField("id", "id-2"))
+	})
+
+	t.Run("deletes the events published before the retention", func(t *testing.T) {
+		fake := &fakeDB{}
+		publisher := publisherFunc[order](func(ctx context.Context, topic string, msgs ...event.Message[order]) error {
+			return nil
+		})
+
+		relay := outbox.NewRelay[order](sql.OpenDB(fake), publisher, nil, outbox.WithTable("events"), outbox.WithRetention(time.Hour))
+		n, err := relay.RelayOnce(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+
+		statements := fake.executed()
+		require.Len(t, statements, 2)
+		assert.Equal(t, "DELETE FROM events WHERE published_at < $1", statements[1].query)
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), statements[1].args[0].(time.Time), time.Minute)
+	})
+
+	t.Run("rolls back when the events cannot be fetched", func(t *testing.T) {
+		errQuery := errors.New("query failed")
+		fake := &fakeDB{queryErr: errQuery}
+		publisher := publisherFunc[order](func(ctx context.Context, topic string, msgs ...event.Message[order]) error {
+			return nil
+		})
+
+		_, err := outbox.NewRelay[order](sql.OpenDB(fake), publisher, nil).RelayOnce(context.Background())
+		assert.ErrorIs(t, err, errQuery)
+		assert.Equal(t, 0, fake.commits)
+		assert.Equal(t, 1, fake.rollbacks)
+	})
+
+	t.Run("publishes raw JSON payloads", func(t *testing.T) {
+		fake := &fakeDB{rows: [][][]driver.Value{{row("id-1", order{ID: "o1", Amount: 10}, createdAt)}}}
+		var payload json.RawMessage
+		publisher := publisherFunc[json.RawMessage](func(ctx context.Context, topic string, msgs ...event.Message[json.RawMessage]) error {
+			payload = msgs[0].Payload
+			return nil
+		})
+
+		_, err := outbox.NewRelay[json.RawMessage](sql.OpenDB(fake), publisher, nil).RelayOnce(context.Background())
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"o1","amount":10}`, string(payload))
+	})
+}
+
+func TestRelayRun(t *testing.T) {
+	createdAt := time.Now()
+	fake := &fakeDB{rows: [][][]driver.Value{
+		{row("id-1", order{ID: "o1"}, createdAt), row("id-2", order{ID: "o2"}, createdAt)},
+		{row("id-3", order{ID: "o3"}, createdAt)},
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	var published []string
+	publisher := publisherFunc[order](func(ctx context.Context, topic string, msgs ...event.Message[order]) error {
+		mu.Lock()
+		defer mu.Unlock()
+		published = append(published, msgs[0].ID)
+		if len(published) == 3 {
+			cancel()
+		}
+		return nil
+	})
+
+	// The full batch is followed by another poll at once, rather than after the interval.
+	relay := outbox.NewRelay[order](sql.OpenDB(fake), publisher, nil, outbox.WithBatchSize(2), outbox.WithRelayInterval(time.Hour))
+	done := make(chan error, 1)
+	go func() { done <- relay.Run(ctx) }()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("relay did not stop")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"id-1", "id-2", "id-3"}, published)
+	for _, s := range fake.executed() {
+		if strings.HasPrefix(strings.TrimSpace(s.query), "SELECT") {
+			assert.Contains(t, s.query, "LIMIT 2")
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package psbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// EnvEmulatorHost is the environment variable holding the address of the Pub/Sub emulator, e.g., localhost:8085.
+// The Google Cloud client connects to the emulator when it is set.
+const EnvEmulatorHost = "PUBSUB_EMULATOR_HOST"
+
+// Bounds of the dead-letter and retry policies enforced by Pub/Sub.
+const (
+	MinMaxDeliveryAttempts = 5
+	MaxMaxDeliveryAttempts = 100
+	MaxRetryBackoff        = 600 * time.Second
+)
+
+// ErrInvalidSubscription is returned by Provision when the configuration is not accepted by Pub/Sub.
+var ErrInvalidSubscription = errors.New("psbus: invalid subscription configuration")
+
+// DeadLetterPolicy forwards the messages delivered MaxDeliveryAttempts times without being acknowledged to
+// DeadLetterTopic.
+type DeadLetterPolicy struct {
+	DeadLetterTopic string
+	// MaxDeliveryAttempts is the number of deliveries before the message is forwarded, between 5 and 100.
+	MaxDeliveryAttempts int
+}
+
+// RetryPolicy is the delay before a message not acknowledged is delivered again, growing exponentially from
+// MinimumBackoff to MaximumBackoff, up to 600 seconds.
+type RetryPolicy struct {
+	MinimumBackoff time.Duration
+	MaximumBackoff time.Duration
+}
+
+// SubscriptionConfig is the configuration of a Pub/Sub subscription, provisioned with Provision.
+type SubscriptionConfig struct {
+	// Name is the ID of the subscription, see SubscriptionName.
+	Name string
+	// Topic is the ID of the topic of the subscription.
+	Topic string
+	// AckDeadline is the time Pub/Sub waits for the acknowledgement of a message before delivering it again. The
+	// client extends it while the message is handled. Zero uses the default of the server, 10 seconds.
+	AckDeadline time.Duration
+	// EnableMessageOrdering delivers the messages with the same ordering key in order, see WithOrderingKeys.
+	EnableMessageOrdering bool
+	// DeadLetterPolicy forwards the messages failing repeatedly to a dead-letter topic, if set.
+	DeadLetterPolicy *DeadLetterPolicy
+	// RetryPolicy delays the deliveries of the messages not acknowledged, if set. They are delivered again at once
+	// otherwise.
+	RetryPolicy *RetryPolicy
+}
+
+// Admin is the administration API of Pub/Sub used by Provision. It is a thin adapter over the Google Cloud client.
+type Admin interface {
+	// EnsureTopic creates the topic if it does not exist.
+	EnsureTopic(ctx context.Context, topic string) error
+	// EnsureSubscription creates the subscription if it does not exist, or updates its configuration.
+	EnsureSubscription(ctx context.Context, cfg SubscriptionConfig) error
+}
+
+/*
+Provision creates the topic, the dead-letter topic and the subscription of cfg if they do not exist, after
+validating the configuration. It is meant to be called on startup, or by the tests against the emulator.
+
+The service account of Pub/Sub must be allowed to publish to the dead-letter topic and to acknowledge the messages
+of the subscription, which is not granted by Provision.
+
+Example usage:
+
+	err := psbus.Provision(ctx, admin, psbus.SubscriptionConfig{
+		Name:  psbus.SubscriptionName("orders", "billing"),
+		Topic: "orders",
+		DeadLetterPolicy: &psbus.DeadLetterPolicy{
+			DeadLetterTopic:     "orders-dead-letter",
+			MaxDeliveryAttempts: 5,
+		},
+		RetryPolicy: &psbus.RetryPolicy{MinimumBackoff: time.Second, MaximumBackoff: time.Minute},
+	})
+*/
+func Provision(ctx context.Context, admin Admin, cfg SubscriptionConfig) error {
+	if err := validate(cfg); err != nil {
+		return err
+	}
+	if err := admin.EnsureTopic(ctx, cfg.Topic); err != nil {
+		return fmt.Errorf("psbus: provision topic %s: %w", cfg.Topic, err)
+	}
+	if cfg.DeadLetterPolicy != nil {
+		if err := admin.EnsureTopic(ctx, cfg.DeadLetterPolicy.DeadLetterTopic); err != nil {
+			return fmt.Errorf("psbus: provision topic %s: %w", cfg.DeadLetterPolicy.DeadLetterTopic, err)
+		}
+	}
+	if err := admin.EnsureSubscription(ctx, cfg); err != nil {
+		return fmt.Errorf("psbus: provision subscription %s: %w", cfg.Name, err)
+	}
+	return nil
+}
+
+// validate returns an error if cfg is not accepted by Pub/Sub.
+func validate(cfg SubscriptionConfig) error {
+	if cfg.Name == "" || cfg.Topic == "" {
+		return fmt.Errorf("%w: no name or topic", ErrInvalidSubscription)
+	}
+	if policy := cfg.DeadLetterPolicy; policy != nil {
+		if policy.DeadLetterTopic == "" || policy.DeadLetterTopic == cfg.Topic {
+			return fmt.Errorf("%w: invalid dead-letter topic %q", ErrInvalidSubscription, policy.DeadLetterTopic)
+		}
+		if policy.MaxDeliveryAttempts < MinMaxDeliveryAttempts || policy.MaxDeliveryAttempts > MaxMaxDeliveryAttempts {
+			return fmt.Errorf("%w: max delivery attempts %d not between %d and %d", ErrInvalidSubscription,
+				policy.MaxDeliveryAttempts, MinMaxDeliveryAttempts, MaxMaxDeliveryAttempts)
+		}
+	}
+	if policy := cfg.RetryPolicy; policy != nil {
+		if policy.MinimumBackoff < 0 || policy.MaximumBackoff > MaxRetryBackoff || policy.MinimumBackoff > policy.MaximumBackoff {
+			return fmt.Errorf("%w: invalid retry backoff from %s to %s", ErrInvalidSubscription, policy.MinimumBackoff, policy.MaximumBackoff)
+		}
+	}
+	return nil
+}
+
+// EmulatorHost returns the address of the Pub/Sub emulator, and whether it is set, e.g., to skip the integration
+// tests when it is not running.
+func EmulatorHost() (string, bool) {
+	host := os.Getenv(EnvEmulatorHost)
+	return host, host != ""
+}
@@ -0,0 +1,81 @@
+package psbus_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/event/psbus"
+)
+
+// fakeAdmin records the topics and the subscriptions provisioned.
+type fakeAdmin struct {
+	topics        []string
+	subscriptions []psbus.SubscriptionConfig
+}
+
+func (a *fakeAdmin) EnsureTopic(ctx context.Context, topic string) error {
+	a.topics = append(a.topics, topic)
+	return nil
+}
+
+func (a *fakeAdmin) EnsureSubscription(ctx context.Context, cfg psbus.SubscriptionConfig) error {
+	a.subscriptions = append(a.subscriptions, cfg)
+	return nil
+}
+
+func TestProvision(t *testing.T) {
+	ctx := context.Background()
+	admin := &fakeAdmin{}
+	cfg := psbus.SubscriptionConfig{
+		Name:                  psbus.SubscriptionName("orders", "billing"),
+		Topic:                 "orders",
+		EnableMessageOrdering: true,
+		DeadLetterPolicy:      &psbus.DeadLetterPolicy{DeadLetterTopic: "orders-dead-letter", MaxDeliveryAttempts: 5},
+		RetryPolicy:           &psbus.RetryPolicy{MinimumBackoff: time.Second, MaximumBackoff: time.Minute},
+	}
+
+	require.NoError(t, psbus.Provision(ctx, admin, cfg))
+	assert.Equal(t, []string{"orders", "orders-dead-letter"}, admin.topics)
+	assert.Equal(t, []psbus.SubscriptionConfig{cfg}, admin.subscriptions)
+	assert.Equal(t, "billing-orders", cfg.Name)
+	assert.Equal(t, "default-orders", psbus.SubscriptionName("orders", ""))
+}
+
+func TestProvision_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  psbus.SubscriptionConfig
+	}{
+		{name: "no topic", cfg: psbus.SubscriptionConfig{Name: "billing-orders"}},
+		{name: "dead letter to the topic", cfg: psbus.SubscriptionConfig{Name: "billing-orders", Topic: "orders",
+			DeadLetterPolicy: &psbus.DeadLetterPolicy{DeadLetterTopic: "orders", MaxDeliveryAttempts: 5}}},
+		{name: "too few delivery attempts", cfg: psbus.SubscriptionConfig{Name: "billing-orders", Topic: "orders",
+			DeadLetterPolicy: &psbus.DeadLetterPolicy{DeadLetterTopic: "orders-dead-letter", MaxDeliveryAttempts: 3}}},
+		{name: "too long backoff", cfg: psbus.SubscriptionConfig{Name: "billing-orders", Topic: "orders",
+			RetryPolicy: &psbus.RetryPolicy{MaximumBackoff: time.Hour}}},
+		{name: "inverted backoff", cfg: psbus.SubscriptionConfig{Name: "billing-orders", Topic: "orders",
+			RetryPolicy: &psbus.RetryPolicy{MinimumBackoff: time.Minute, MaximumBackoff: time.Second}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			admin := &fakeAdmin{}
+			assert.ErrorIs(t, psbus.Provision(context.Background(), admin, tt.cfg), psbus.ErrInvalidSubscription)
+			assert.Empty(t, admin.topics)
+		})
+	}
+}
+
+func TestEmulatorHost(t *testing.T) {
+	t.Setenv(psbus.EnvEmulatorHost, "")
+	_, ok := psbus.EmulatorHost()
+	assert.False(t, ok)
+
+	t.Setenv(psbus.EnvEmulatorHost, "localhost:8085")
+	host, ok := psbus.EmulatorHost()
+	assert.True(t, ok)
+	assert.Equal(t, "localhost:8085", host)
+}
@@ -0,0 +1,247 @@
+package psbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/kittipat1413/go-common/framework/event"
+)
+
+// Attributes of the messages set by the bus, in addition to the metadata of the messages.
+const (
+	AttributeID          = "event-id"
+	AttributeKey         = "event-key"
+	AttributeContentType = "content-type"
+)
+
+// DefaultMaxOutstandingMessages is the number of messages handled concurrently by a subscription, unless set with
+// WithMaxOutstandingMessages.
+const DefaultMaxOutstandingMessages = 100
+
+// ErrNoClient is returned by the bus created without a Client.
+var ErrNoClient = errors.New("psbus: no client")
+
+// OutgoingMessage is a message published to Pub/Sub.
+type OutgoingMessage struct {
+	Data       []byte
+	Attributes map[string]string
+	// OrderingKey delivers the messages with the same key in order to the subscriptions with message ordering.
+	OrderingKey string
+}
+
+// ReceiveSettings are the flow control settings of the streaming pull of a subscription.
+type ReceiveSettings struct {
+	// MaxOutstandingMessages is the number of messages received and not acknowledged yet, i.e., handled
+	// concurrently.
+	MaxOutstandingMessages int
+	// NumGoroutines is the number of streams pulling messages. Zero uses the default of the client.
+	NumGoroutines int
+}
+
+// Msg is a message received from a subscription, to acknowledge once handled.
+type Msg interface {
+	Data() []byte
+	Attributes() map[string]string
+	OrderingKey() string
+	// PublishTime is the time the message was published.
+	PublishTime() time.Time
+	// DeliveryAttempt is the number of times the message was delivered, from 1, if the subscription has a dead-letter
+	// policy. It is nil otherwise.
+	DeliveryAttempt() *int
+	// Ack acknowledges the message as handled.
+	Ack()
+	// Nack asks for the message to be delivered again, following the retry policy of the subscription.
+	Nack()
+}
+
+/*
+Client is the Pub/Sub API used by the bus. It is a thin adapter over the Google Cloud client, whose publishers have
+message ordering enabled if WithOrderingKeys is used.
+*/
+type Client interface {
+	// Publish publishes the messages to topic, and returns once they are stored, or with the first error.
+	Publish(ctx context.Context, topic string, msgs ...OutgoingMessage) error
+	// ResumePublish resumes the publishing of the messages with orderingKey to topic, paused after an error.
+	ResumePublish(topic, orderingKey string)
+	// Receive calls f concurrently with the messages of subscription, until ctx is done and the calls return.
+	Receive(ctx context.Context, subscription string, settings ReceiveSettings, f func(ctx context.Context, msg Msg)) error
+}
+
+// options holds configuration options for the bus.
+type options[T any] struct {
+	codec        event.Codec[T]  // codec encodes the payloads.
+	orderingKeys bool            // orderingKeys publishes the keys of the messages as ordering keys.
+	settings     ReceiveSettings // settings are the flow control settings of the subscriptions.
+}
+
+// Option specifies bus configuration options.
+type Option[T any] func(*options[T])
+
+// WithCodec sets the codec of the payloads. It defaults to event.JSONCodec.
+func WithCodec[T any](codec event.Codec[T]) Option[T] {
+	return func(opts *options[T]) {
+		if codec != nil {
+			opts.codec = codec
+		}
+	}
+}
+
+// WithOrderingKeys publishes the keys of the messages as ordering keys, so that the subscriptions with
+// EnableMessageOrdering handle the messages with the same key in order. The publishers of the Client must have
+// message ordering enabled.
+func WithOrderingKeys[T any]() Option[T] {
+	return func(opts *options[T]) {
+		opts.orderingKeys = true
+	}
+}
+
+// WithMaxOutstandingMessages sets the number of messages handled concurrently by a subscription. It defaults to
+// DefaultMaxOutstandingMessages.
+func WithMaxOutstandingMessages[T any](n int) Option[T] {
+	return func(opts *options[T]) {
+		if n > 0 {
+			opts.settings.MaxOutstandingMessages = n
+		}
+	}
+}
+
+// WithNumGoroutines sets the number of streams pulling the messages of a subscription. It defaults to the default
+// of the Client.
+func WithNumGoroutines[T any](n int) Option[T] {
+	return func(opts *options[T]) {
+		if n > 0 {
+			opts.settings.NumGoroutines = n
+		}
+	}
+}
+
+/*
+Bus is an event.Publisher and event.Subscriber over Google Cloud Pub/Sub.
+
+The messages are encoded with the event.Codec, with their ID, key and metadata as attributes. The consumer groups
+are Pub/Sub subscriptions named with SubscriptionName, to provision with Provision. The messages are received by
+streaming pull and handled concurrently, up to WithMaxOutstandingMessages.
+
+The retries are delivered by Pub/Sub: a message whose handler fails is not acknowledged, and delivered again
+following the retry policy of the subscription, until it is forwarded to the dead-letter topic of its dead-letter
+policy, which bounds the deliveries. So event.WithMaxAttempts and event.WithBackoff are not used. The attempt of
+the messages is the number of deliveries counted by Pub/Sub with a dead-letter policy, zero otherwise. The messages
+failing with a permanent error or that cannot be decoded are acknowledged and passed to OnDropped at once.
+
+Example usage:
+
+	bus := psbus.New[OrderPlaced](client, psbus.WithOrderingKeys[OrderPlaced]())
+	err := bus.Publish(ctx, "orders", event.Message[OrderPlaced]{Key: order.ID, Payload: orderPlaced})
+
+	manager.Add("orders-consumer", bus.Subscription("orders", handleOrder, event.WithGroup("billing")))
+*/
+type Bus[T any] struct {
+	client Client
+	opts   options[T]
+}
+
+// New creates a bus publishing and subscribing with client.
+func New[T any](client Client, opts ...Option[T]) *Bus[T] {
+	o := options[T]{
+		codec:    event.JSONCodec[T](),
+		settings: ReceiveSettings{MaxOutstandingMessages: DefaultMaxOutstandingMessages},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Bus[T]{client: client, opts: o}
+}
+
+// SubscriptionName returns the ID of the subscription of group to topic: "<group>-<topic>", or "default-<topic>"
+// for the empty group.
+func SubscriptionName(topic, group string) string {
+	if group == "" {
+		group = "default"
+	}
+	return group + "-" + topic
+}
+
+// Publish publishes the messages to topic, setting their ID if empty, and returns once they are stored. After an
+// error, the publishing of their ordering keys is resumed, the caller being in charge of publishing them again.
+func (b *Bus[T]) Publish(ctx context.Context, topic string, msgs ...event.Message[T]) error {
+	if b.client == nil {
+		return ErrNoClient
+	}
+	outgoing := make([]OutgoingMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.ID == "" {
+			msg.ID = xid.New().String()
+		}
+		data, err := b.opts.codec.Marshal(msg.Payload)
+		if err != nil {
+			return fmt.Errorf("psbus: encode message %s: %w", msg.ID, err)
+		}
+		attributes := make(map[string]string, len(msg.Metadata)+3)
+		for key, value := range msg.Metadata {
+			attributes[key] = value
+		}
+		attributes[AttributeID] = msg.ID
+		attributes[AttributeContentType] = b.opts.codec.ContentType()
+		out := OutgoingMessage{Data: data, Attributes: attributes}
+		if msg.Key != "" {
+			attributes[AttributeKey] = msg.Key
+			if b.opts.orderingKeys {
+				out.OrderingKey = msg.Key
+			}
+		}
+		outgoing = append(outgoing, out)
+	}
+	if len(outgoing) == 0 {
+		return nil
+	}
+
+	if err := b.client.Publish(ctx, topic, outgoing...); err != nil {
+		for _, out := range outgoing {
+			if out.OrderingKey != "" {
+				b.client.ResumePublish(topic, out.OrderingKey)
+			}
+		}
+		return fmt.Errorf("psbus: publish: %w", err)
+	}
+	return nil
+}
+
+// Subscribe handles the messages of topic received by the subscription of the group of opts until ctx is done, and
+// returns nil then. The messages not handled by then are delivered again. See Subscription to drain them instead.
+func (b *Bus[T]) Subscribe(ctx context.Context, topic string, handler event.Handler[T], opts ...event.SubscribeOption) error {
+	return b.receive(ctx, nil, topic, handler, event.NewSubscribeOptions(opts...))
+}
+
+// decode returns the message of m, received on topic.
+func (b *Bus[T]) decode(topic string, m Msg) (event.Message[T], error) {
+	msg := event.Message[T]{
+		Topic:     topic,
+		Key:       m.OrderingKey(),
+		Timestamp: m.PublishTime(),
+		Metadata:  make(map[string]string, len(m.Attributes())),
+	}
+	if attempt := m.DeliveryAttempt(); attempt != nil {
+		msg.Attempt = *attempt
+	}
+	for key, value := range m.Attributes() {
+		switch key {
+		case AttributeID:
+			msg.ID = value
+		case AttributeKey:
+			msg.Key = value
+		case AttributeContentType:
+		default:
+			msg.Metadata[key] = value
+		}
+	}
+	payload, err := b.opts.codec.Unmarshal(m.Data())
+	if err != nil {
+		return msg, fmt.Errorf("psbus: decode message %s: %w", msg.ID, err)
+	}
+	msg.Payload = payload
+	return msg, nil
+}
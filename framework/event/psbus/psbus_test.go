@@ -0,0 +1,266 @@
+package psbus_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/event/psbus"
+)
+
+type order struct {
+	ID string `json:"id"`
+}
+
+// fakeClient records the messages published and the ordering keys resumed, and calls the receiver with the
+// messages sent to it, concurrently up to the maximum outstanding messages.
+type fakeClient struct {
+	publishErr error
+
+	mu        sync.Mutex
+	published []psbus.OutgoingMessage
+	resumed   []string
+	received  []string
+	settings  psbus.ReceiveSettings
+	msgs      chan psbus.Msg
+}
+
+func newFakeClient(msgs ...psbus.Msg) *fakeClient {
+	c := &fakeClient{msgs: make(chan psbus.Msg, len(msgs))}
+	for _, msg := range msgs {
+		c.msgs <- msg
+	}
+	return c
+}
+
+func (c *fakeClient) Publish(ctx context.Context, topic string, msgs ...psbus.OutgoingMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.published = append(c.published, msgs...)
+	return c.publishErr
+}
+
+func (c *fakeClient) ResumePublish(topic, orderingKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resumed = append(c.resumed, topic+"/"+orderingKey)
+}
+
+func (c *fakeClient) Receive(ctx context.Context, subscription string, settings psbus.ReceiveSettings, f func(ctx context.Context, msg psbus.Msg)) error {
+	c.mu.Lock()
+	c.received = append(c.received, subscription)
+	c.settings = settings
+	c.mu.Unlock()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	outstanding := make(chan struct{}, settings.MaxOutstandingMessages)
+	for {
+		select {
+		case msg := <-c.msgs:
+			outstanding <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-outstanding }()
+				f(ctx, msg)
+			}()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// fakeMsg is a message recording how it is acknowledged.
+type fakeMsg struct {
+	data       string
+	attributes map[string]string
+	attempt    *int
+
+	mu    sync.Mutex
+	acked string
+	done  chan struct{}
+}
+
+func newFakeMsg(id, data string) *fakeMsg {
+	return &fakeMsg{data: data, attributes: map[string]string{psbus.AttributeID: id}, done: make(chan struct{})}
+}
+
+func (m *fakeMsg) Data() []byte                  { return []byte(m.data) }
+func (m *fakeMsg) Attributes() map[string]string { return m.attributes }
+func (m *fakeMsg) OrderingKey() string           { return "" }
+func (m *fakeMsg) PublishTime() time.Time        { return time.Unix(1700000000, 0) }
+func (m *fakeMsg) DeliveryAttempt() *int         { return m.attempt }
+func (m *fakeMsg) Ack()                          { m.settle("ack") }
+func (m *fakeMsg) Nack()                         { m.settle("nack") }
+
+func (m *fakeMsg) settle(acked string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.acked = acked
+	close(m.done)
+}
+
+// result waits for the message to be acknowledged, and returns how.
+func (m *fakeMsg) result(t *testing.T) string {
+	t.Helper()
+	select {
+	case <-m.done:
+	case <-time.After(time.Second):
+		t.Fatal("the message was not acknowledged")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.acked
+}
+
+func TestBus_Publish(t *testing.T) {
+	client := newFakeClient()
+	bus := psbus.New[order](client, psbus.WithOrderingKeys[order]())
+
+	err := bus.Publish(context.Background(), "orders",
+		event.Message[order]{ID: "1", Key: "customer:1", Payload: order{ID: "1"}, Metadata: map[string]string{"traceparent": "00-1"}},
+		event.Message[order]{Payload: order{ID: "2"}},
+	)
+	require.NoError(t, err)
+	require.Len(t, client.published, 2)
+	assert.Equal(t, psbus.OutgoingMessage{
+		Data: []byte(`{"id":"1"}`),
+		Attributes: map[string]string{
+			psbus.AttributeID:          "1",
+			psbus.AttributeKey:         "customer:1",
+			psbus.AttributeContentType: event.ContentTypeJSON,
+			"traceparent":              "00-1",
+		},
+		OrderingKey: "customer:1",
+	}, client.published[0])
+	assert.NotEmpty(t, client.published[1].Attributes[psbus.AttributeID])
+	assert.Empty(t, client.published[1].OrderingKey)
+
+	client.publishErr = errors.New("publish failed")
+	err = bus.Publish(context.Background(), "orders", event.Message[order]{Key: "customer:1"})
+	assert.ErrorIs(t, err, client.publishErr)
+	assert.Equal(t, []string{"orders/customer:1"}, client.resumed, "the ordering key is resumed after an error")
+
+	assert.ErrorIs(t, psbus.New[order](nil).Publish(context.Background(), "orders"), psbus.ErrNoClient)
+}
+
+func TestBus_PublishWithoutOrdering(t *testing.T) {
+	client := newFakeClient()
+	bus := psbus.New[order](client)
+
+	require.NoError(t, bus.Publish(context.Background(), "orders", event.Message[order]{Key: "customer:1"}))
+	assert.Empty(t, client.published[0].OrderingKey)
+	assert.Equal(t, "customer:1", client.published[0].Attributes[psbus.AttributeKey])
+}
+
+func TestBus_Subscribe(t *testing.T) {
+	errHandle := errors.New("handle failed")
+	attempt := 2
+	handled := newFakeMsg("handled", `{"id":"1"}`)
+	handled.attempt = &attempt
+	handled.attributes[psbus.AttributeKey] = "customer:1"
+	handled.attributes["traceparent"] = "00-1"
+	failed := newFakeMsg("failed", `{"id":"2"}`)
+	permanent := newFakeMsg("permanent", `{"id":"3"}`)
+	invalid := newFakeMsg("invalid", `{`)
+	client := newFakeClient(handled, failed, permanent, invalid)
+	bus := psbus.New[order](client, psbus.WithMaxOutstandingMessages[order](2), psbus.WithNumGoroutines[order](1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan event.Message[order], 4)
+	var mu sync.Mutex
+	var dropped []string
+	done := make(chan error)
+	go func() {
+		done <- bus.Subscribe(ctx, "orders", func(ctx context.Context, msg event.Message[order]) error {
+			received <- msg
+			switch msg.ID {
+			case "handled":
+				return nil
+			case "permanent":
+				return domain_error.MarkPermanent(errHandle)
+			}
+			return errHandle
+		}, event.WithGroup("billing"), event.WithOnDropped(func(ctx context.Context, topic, id string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			dropped = append(dropped, id)
+		}))
+	}()
+
+	assert.Equal(t, "ack", handled.result(t))
+	assert.Equal(t, "nack", failed.result(t), "the failed messages are delivered again by Pub/Sub")
+	assert.Equal(t, "ack", permanent.result(t))
+	assert.Equal(t, "ack", invalid.result(t))
+	cancel()
+	require.NoError(t, <-done)
+
+	assert.Equal(t, []string{"billing-orders"}, client.received)
+	assert.Equal(t, psbus.ReceiveSettings{MaxOutstandingMessages: 2, NumGoroutines: 1}, client.settings)
+	assert.ElementsMatch(t, []string{"permanent", "invalid"}, dropped)
+	close(received)
+	for msg := range received {
+		if msg.ID == "handled" {
+			assert.Equal(t, event.Message[order]{
+				ID:        "handled",
+				Topic:     "orders",
+				Key:       "customer:1",
+				Payload:   order{ID: "1"},
+				Metadata:  map[string]string{"traceparent": "00-1"},
+				Timestamp: time.Unix(1700000000, 0),
+				Attempt:   2,
+			}, msg)
+		}
+	}
+}
+
+func TestSubscription_Drain(t *testing.T) {
+	msg := newFakeMsg("1", `{"id":"1"}`)
+	client := newFakeClient(msg)
+	bus := psbus.New[order](client)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	subscription := bus.Subscription("orders", func(ctx context.Context, msg event.Message[order]) error {
+		close(started)
+		<-release
+		return ctx.Err()
+	})
+	done := make(chan error)
+	go func() { done <- subscription.Run(context.Background()) }()
+	<-started
+
+	shutdown := make(chan error)
+	go func() { shutdown <- subscription.Shutdown(context.Background()) }()
+	select {
+	case <-shutdown:
+		t.Fatal("Shutdown returned before the messages received were handled")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	require.NoError(t, <-shutdown)
+	require.NoError(t, <-done)
+	assert.Equal(t, "ack", msg.result(t), "the handlers are not canceled on shutdown")
+}
+
+func TestSubscription_Canceled(t *testing.T) {
+	msg := newFakeMsg("1", `{"id":"1"}`)
+	client := newFakeClient(msg)
+	bus := psbus.New[order](client)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	subscription := bus.Subscription("orders", func(ctx context.Context, msg event.Message[order]) error {
+		cancel()
+		return domain_error.MarkPermanent(ctx.Err())
+	})
+	require.NoError(t, subscription.Run(ctx))
+	assert.Equal(t, "nack", msg.result(t), "the messages interrupted are delivered again")
+}
@@ -0,0 +1,223 @@
+package pubsubclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/kittipat1413/go-common/framework/event/psbus"
+)
+
+var (
+	_ psbus.Client = (*Client)(nil)
+	_ psbus.Admin  = (*Admin)(nil)
+	_ psbus.Msg    = (*msg)(nil)
+)
+
+/*
+Client is the psbus.Client of the Google Cloud Pub/Sub client. It is a module of its own, so that the services using
+psbus with another client do not depend on the Google Cloud libraries.
+
+The publishers of the topics are created on first use, with message ordering enabled, so that the ordering keys of
+psbus.WithOrderingKeys are accepted. The messages without ordering key are not ordered.
+
+Example usage:
+
+	client, err := pubsub.NewClient(ctx, projectID)
+	if err != nil {
+		// Handle error
+	}
+	pubsubClient := pubsubclient.New(client)
+	manager.AddCloser("pubsub", func(ctx context.Context) error {
+		pubsubClient.Stop()
+		return client.Close()
+	})
+
+	bus := psbus.New[OrderPlaced](pubsubClient, psbus.WithOrderingKeys[OrderPlaced]())
+*/
+type Client struct {
+	client *pubsub.Client
+
+	mu         sync.Mutex
+	publishers map[string]*pubsub.Publisher
+}
+
+// New creates the psbus.Client of client. The client is not owned by it: call Stop, then close the client after the
+// last message is published and the subscriptions end.
+func New(client *pubsub.Client) *Client {
+	return &Client{client: client, publishers: make(map[string]*pubsub.Publisher)}
+}
+
+// Publish publishes the messages to topic, and returns once they are stored, or with the first error.
+func (c *Client) Publish(ctx context.Context, topic string, msgs ...psbus.OutgoingMessage) error {
+	publisher := c.publisher(topic)
+	results := make([]*pubsub.PublishResult, len(msgs))
+	for i, m := range msgs {
+		results[i] = publisher.Publish(ctx, &pubsub.Message{Data: m.Data, Attributes: m.Attributes, OrderingKey: m.OrderingKey})
+	}
+	for _, result := range results {
+		if _, err := result.Get(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResumePublish resumes the publishing of the messages with orderingKey to topic, paused after an error.
+func (c *Client) ResumePublish(topic, orderingKey string) {
+	c.publisher(topic).ResumePublish(orderingKey)
+}
+
+// Receive calls f concurrently with the messages of subscription, until ctx is done and the calls return.
+func (c *Client) Receive(ctx context.Context, subscription string, settings psbus.ReceiveSettings, f func(ctx context.Context, msg psbus.Msg)) error {
+	subscriber := c.client.Subscriber(subscription)
+	if settings.MaxOutstandingMessages > 0 {
+		subscriber.ReceiveSettings.MaxOutstandingMessages = settings.MaxOutstandingMessages
+	}
+	if settings.NumGoroutines > 0 {
+		subscriber.ReceiveSettings.NumGoroutines = settings.NumGoroutines
+	}
+	return subscriber.Receive(ctx, func(ctx context.Context, m *pubsub.Message) {
+		f(ctx, &msg{msg: m})
+	})
+}
+
+// Stop publishes the messages pending, and stops the publishers of the topics.
+func (c *Client) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for topic, publisher := range c.publishers {
+		publisher.Stop()
+		delete(c.publishers, topic)
+	}
+}
+
+// publisher returns the publisher of topic, created on first use.
+func (c *Client) publisher(topic string) *pubsub.Publisher {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	publisher, ok := c.publishers[topic]
+	if !ok {
+		publisher = c.client.Publisher(topic)
+		publisher.EnableMessageOrdering = true
+		c.publishers[topic] = publisher
+	}
+	return publisher
+}
+
+/*
+Admin is the psbus.Admin of the Google Cloud Pub/Sub client, provisioning the topics and the subscriptions of its
+project.
+
+Example usage:
+
+	err := psbus.Provision(ctx, pubsubclient.NewAdmin(client), psbus.SubscriptionConfig{
+		Name:  psbus.SubscriptionName("orders", "billing"),
+		Topic: "orders",
+	})
+*/
+type Admin struct {
+	client *pubsub.Client
+}
+
+// NewAdmin creates the psbus.Admin of client. The client is not owned by it.
+func NewAdmin(client *pubsub.Client) *Admin {
+	return &Admin{client: client}
+}
+
+// EnsureTopic creates the topic if it does not exist.
+func (a *Admin) EnsureTopic(ctx context.Context, topic string) error {
+	_, err := a.client.TopicAdminClient.CreateTopic(ctx, &pubsubpb.Topic{Name: a.topicName(topic)})
+	if status.Code(err) == codes.AlreadyExists {
+		return nil
+	}
+	return err
+}
+
+// EnsureSubscription creates the subscription if it does not exist, or updates its acknowledgement deadline, its
+// dead-letter and its retry policies. The message ordering of a subscription cannot be updated.
+func (a *Admin) EnsureSubscription(ctx context.Context, cfg psbus.SubscriptionConfig) error {
+	subscription := a.subscription(cfg)
+	_, err := a.client.SubscriptionAdminClient.CreateSubscription(ctx, subscription)
+	if status.Code(err) != codes.AlreadyExists {
+		return err
+	}
+	paths := []string{"dead_letter_policy", "retry_policy"}
+	if subscription.AckDeadlineSeconds > 0 {
+		paths = append(paths, "ack_deadline_seconds")
+	}
+	_, err = a.client.SubscriptionAdminClient.UpdateSubscription(ctx, &pubsubpb.UpdateSubscriptionRequest{
+		Subscription: subscription,
+		UpdateMask:   &fieldmaskpb.FieldMask{Paths: paths},
+	})
+	return err
+}
+
+// subscription returns the subscription of cfg.
+func (a *Admin) subscription(cfg psbus.SubscriptionConfig) *pubsubpb.Subscription {
+	subscription := &pubsubpb.Subscription{
+		Name:                  fmt.Sprintf("projects/%s/subscriptions/%s", a.client.Project(), cfg.Name),
+		Topic:                 a.topicName(cfg.Topic),
+		AckDeadlineSeconds:    int32(cfg.AckDeadline / time.Second),
+		EnableMessageOrdering: cfg.EnableMessageOrdering,
+	}
+	if policy := cfg.DeadLetterPolicy; policy != nil {
+		subscription.DeadLetterPolicy = &pubsubpb.DeadLetterPolicy{
+			DeadLetterTopic:     a.topicName(policy.DeadLetterTopic),
+			MaxDeliveryAttempts: int32(policy.MaxDeliveryAttempts),
+		}
+	}
+	if policy := cfg.RetryPolicy; policy != nil {
+		subscription.RetryPolicy = &pubsubpb.RetryPolicy{
+			MinimumBackoff: durationpb.New(policy.MinimumBackoff),
+			MaximumBackoff: durationpb.New(policy.MaximumBackoff),
+		}
+	}
+	return subscription
+}
+
+// topicName returns the full name of the topic of the project.
+func (a *Admin) topicName(topic string) string {
+	return fmt.Sprintf("projects/%s/topics/%s", a.client.Project(), topic)
+}
+
+// msg is the psbus.Msg of a pubsub.Message.
+type msg struct {
+	msg *pubsub.Message
+}
+
+func (m *msg) Data() []byte {
+	return m.msg.Data
+}
+
+func (m *msg) Attributes() map[string]string {
+	return m.msg.Attributes
+}
+
+func (m *msg) OrderingKey() string {
+	return m.msg.OrderingKey
+}
+
+func (m *msg) PublishTime() time.Time {
+	return m.msg.PublishTime
+}
+
+func (m *msg) DeliveryAttempt() *int {
+	return m.msg.DeliveryAttempt
+}
+
+func (m *msg) Ack() {
+	m.msg.Ack()
+}
+
+func (m *msg) Nack() {
+	m.msg.Nack()
+}
@@ -0,0 +1,134 @@
+package pubsubclient_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"cloud.google.com/go/pubsub/v2/pstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/event/psbus"
+	"github.com/kittipat1413/go-common/framework/event/psbus/pubsubclient"
+)
+
+type order struct {
+	ID string `json:"id"`
+}
+
+// newClient returns a Pub/Sub client of the project "project" of an in-memory server.
+func newClient(t *testing.T) *pubsub.Client {
+	srv := pstest.NewServer()
+	t.Cleanup(func() { srv.Close() })
+	conn, err := grpc.NewClient(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	client, err := pubsub.NewClient(context.Background(), "project", option.WithGRPCConn(conn))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestBus(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+	err := psbus.Provision(ctx, pubsubclient.NewAdmin(client), psbus.SubscriptionConfig{
+		Name:                  psbus.SubscriptionName("orders", "billing"),
+		Topic:                 "orders",
+		EnableMessageOrdering: true,
+	})
+	require.NoError(t, err)
+
+	pubsubClient := pubsubclient.New(client)
+	defer pubsubClient.Stop()
+	bus := psbus.New[order](pubsubClient, psbus.WithOrderingKeys[order]())
+	err = bus.Publish(ctx, "orders",
+		event.Message[order]{ID: "1", Key: "customer-1", Payload: order{ID: "order-1"}, Metadata: map[string]string{"source": "checkout"}},
+		event.Message[order]{ID: "2", Key: "customer-1", Payload: order{ID: "order-2"}},
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	var (
+		mu   sync.Mutex
+		msgs []event.Message[order]
+	)
+	err = bus.Subscribe(ctx, "orders", func(ctx context.Context, msg event.Message[order]) error {
+		mu.Lock()
+		defer mu.Unlock()
+		msgs = append(msgs, msg)
+		if len(msgs) == 2 {
+			cancel()
+		}
+		return nil
+	}, event.WithGroup("billing"))
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, msgs, 2, "the subscription timed out")
+	assert.Equal(t, "1", msgs[0].ID, "the messages with the same key are delivered in order")
+	assert.Equal(t, "2", msgs[1].ID)
+	assert.Equal(t, "orders", msgs[0].Topic)
+	assert.Equal(t, "customer-1", msgs[0].Key)
+	assert.Equal(t, order{ID: "order-1"}, msgs[0].Payload)
+	assert.Equal(t, "checkout", msgs[0].Metadata["source"])
+	assert.False(t, msgs[0].Timestamp.IsZero())
+}
+
+func TestPublish_NoTopic(t *testing.T) {
+	pubsubClient := pubsubclient.New(newClient(t))
+	defer pubsubClient.Stop()
+
+	err := psbus.New[order](pubsubClient).Publish(context.Background(), "orders", event.Message[order]{Payload: order{ID: "order-1"}})
+	assert.Error(t, err)
+}
+
+func TestAdmin_EnsureSubscription(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+	admin := pubsubclient.NewAdmin(client)
+	cfg := psbus.SubscriptionConfig{
+		Name:        psbus.SubscriptionName("orders", "billing"),
+		Topic:       "orders",
+		AckDeadline: 30 * time.Second,
+		DeadLetterPolicy: &psbus.DeadLetterPolicy{
+			DeadLetterTopic:     "orders-dead-letter",
+			MaxDeliveryAttempts: 5,
+		},
+	}
+	require.NoError(t, psbus.Provision(ctx, admin, cfg))
+
+	subscription := func() *pubsubpb.Subscription {
+		subscription, err := client.SubscriptionAdminClient.GetSubscription(ctx, &pubsubpb.GetSubscriptionRequest{
+			Subscription: "projects/project/subscriptions/billing-orders",
+		})
+		require.NoError(t, err)
+		return subscription
+	}
+	got := subscription()
+	assert.Equal(t, "projects/project/topics/orders", got.GetTopic())
+	assert.Equal(t, int32(30), got.GetAckDeadlineSeconds())
+	assert.Equal(t, "projects/project/topics/orders-dead-letter", got.GetDeadLetterPolicy().GetDeadLetterTopic())
+	assert.Equal(t, int32(5), got.GetDeadLetterPolicy().GetMaxDeliveryAttempts())
+
+	// Provisioning again updates the configuration of the existing subscription.
+	cfg.AckDeadline = time.Minute
+	cfg.DeadLetterPolicy.MaxDeliveryAttempts = 10
+	cfg.RetryPolicy = &psbus.RetryPolicy{MinimumBackoff: time.Second, MaximumBackoff: time.Minute}
+	require.NoError(t, psbus.Provision(ctx, admin, cfg))
+	got = subscription()
+	assert.Equal(t, int32(60), got.GetAckDeadlineSeconds())
+	assert.Equal(t, int32(10), got.GetDeadLetterPolicy().GetMaxDeliveryAttempts())
+	assert.Equal(t, time.Second, got.GetRetryPolicy().GetMinimumBackoff().AsDuration())
+	assert.Equal(t, time.Minute, got.GetRetryPolicy().GetMaximumBackoff().AsDuration())
+}
@@ -0,0 +1,112 @@
+package psbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/event"
+)
+
+/*
+Subscription is a subscription to a topic run as a lifecycle.Runnable, draining its messages on shutdown: Shutdown
+stops receiving, and waits for the messages received to be handled and acknowledged.
+
+Example usage:
+
+	manager := lifecycle.NewManager(lifecycle.WithShutdownTimeout(30 * time.Second))
+	manager.Add("orders-consumer", bus.Subscription("orders", handleOrder, event.WithGroup("billing")))
+	manager.AddCloser("pubsub", func(ctx context.Context) error {
+		return client.Close()
+	})
+	err := manager.Run(ctx)
+*/
+type Subscription[T any] struct {
+	bus     *Bus[T]
+	topic   string
+	handler event.Handler[T]
+	opts    event.SubscribeOptions
+
+	stopOnce sync.Once
+	stop     chan struct{} // stop is closed by Shutdown.
+	done     chan struct{} // done is closed when Run returns.
+}
+
+// Subscription returns a subscription to topic, handling its messages with handler once run.
+func (b *Bus[T]) Subscription(topic string, handler event.Handler[T], opts ...event.SubscribeOption) *Subscription[T] {
+	return &Subscription[T]{
+		bus:     b,
+		topic:   topic,
+		handler: handler,
+		opts:    event.NewSubscribeOptions(opts...),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Run handles the messages of the topic until Shutdown is called or ctx is done, and returns nil then.
+func (s *Subscription[T]) Run(ctx context.Context) error {
+	defer close(s.done)
+	return s.bus.receive(ctx, s.stop, s.topic, s.handler, s.opts)
+}
+
+// Shutdown stops receiving messages, and returns once the messages received are handled and acknowledged, or ctx
+// is done.
+func (s *Subscription[T]) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// receive handles the messages of topic until stop is closed or ctx is done. The messages received are handled
+// before returning if stop is closed, and delivered again if ctx is done.
+func (b *Bus[T]) receive(ctx context.Context, stop <-chan struct{}, topic string, handler event.Handler[T], opts event.SubscribeOptions) error {
+	if b.client == nil {
+		return ErrNoClient
+	}
+	receiveCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-receiveCtx.Done():
+		}
+	}()
+
+	// The messages are handled with ctx rather than the context of the client, canceled on shutdown.
+	err := b.client.Receive(receiveCtx, SubscriptionName(topic, opts.Group), b.opts.settings, func(_ context.Context, m Msg) {
+		b.handle(ctx, topic, m, handler, opts)
+	})
+	if err != nil && receiveCtx.Err() == nil {
+		return fmt.Errorf("psbus: receive: %w", err)
+	}
+	return nil
+}
+
+// handle handles m with handler, then acknowledges it, or asks for it to be delivered again if it failed.
+func (b *Bus[T]) handle(ctx context.Context, topic string, m Msg, handler event.Handler[T], opts event.SubscribeOptions) {
+	msg, err := b.decode(topic, m)
+	if err != nil {
+		// The messages that cannot be decoded would fail the same way on every delivery.
+		m.Ack()
+		opts.OnDropped(ctx, msg.Topic, msg.ID, err)
+		return
+	}
+
+	err = handler(ctx, msg)
+	switch {
+	case err == nil:
+		m.Ack()
+	case ctx.Err() == nil && errors.IsPermanent(err):
+		m.Ack()
+		opts.OnDropped(ctx, msg.Topic, msg.ID, err)
+	default:
+		m.Nack()
+	}
+}
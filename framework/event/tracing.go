@@ -0,0 +1,142 @@
+package event
+
+import (
+	"context"
+	"maps"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/kittipat1413/go-common/framework/event"
+
+// Span attributes recorded by the tracing decorators, in addition to the messaging semantic conventions.
+const (
+	AttributeMessageCount   = attribute.Key("messaging.batch.message_count")
+	AttributeMessageAttempt = attribute.Key("messaging.message.attempt")
+)
+
+// tracingOptions holds configuration options for the tracing decorators.
+type tracingOptions struct {
+	tracerProvider oteltrace.TracerProvider      // tracerProvider is the OpenTelemetry tracer provider to use.
+	propagators    propagation.TextMapPropagator // propagators carry the trace context in the metadata of the messages.
+}
+
+// TracingOption specifies tracing configuration options.
+type TracingOption func(*tracingOptions)
+
+// WithTracerProvider specifies a tracer provider to use for creating a tracer. Defaults to the global tracer provider.
+func WithTracerProvider(provider oteltrace.TracerProvider) TracingOption {
+	return func(opts *tracingOptions) {
+		if provider != nil {
+			opts.tracerProvider = provider
+		}
+	}
+}
+
+// WithPropagators specifies the propagators carrying the trace context in the metadata of the messages. Defaults to
+// the global propagators.
+func WithPropagators(propagators propagation.TextMapPropagator) TracingOption {
+	return func(opts *tracingOptions) {
+		if propagators != nil {
+			opts.propagators = propagators
+		}
+	}
+}
+
+func newTracingOptions(options []TracingOption) *tracingOptions {
+	opts := &tracingOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	if opts.tracerProvider == nil {
+		opts.tracerProvider = otel.GetTracerProvider()
+	}
+	if opts.propagators == nil {
+		opts.propagators = otel.GetTextMapPropagator()
+	}
+	return opts
+}
+
+/*
+PublisherTracing returns a Decorator recording a producer span "<topic> publish" for every call to Publish, and
+injecting its trace context in the metadata of the messages, so that HandlerTracing continues the trace on the
+subscriber side. The metadata of the messages are copied, not modified.
+
+Example usage:
+
+	publisher := event.Chain[Order](bus, event.PublisherTracing[Order]())
+*/
+func PublisherTracing[T any](options ...TracingOption) Decorator[T] {
+	opts := newTracingOptions(options)
+	tracer := opts.tracerProvider.Tracer(tracerName)
+	return func(p Publisher[T]) Publisher[T] {
+		return PublisherFunc[T](func(ctx context.Context, topic string, msgs ...Message[T]) error {
+			ctx, span := tracer.Start(ctx, topic+" publish",
+				oteltrace.WithSpanKind(oteltrace.SpanKindProducer),
+				oteltrace.WithAttributes(
+					semconv.MessagingOperationPublish,
+					semconv.MessagingDestinationName(topic),
+					AttributeMessageCount.Int(len(msgs)),
+				),
+			)
+			defer span.End()
+
+			traced := make([]Message[T], len(msgs))
+			for i, msg := range msgs {
+				metadata := make(map[string]string, len(msg.Metadata)+2)
+				maps.Copy(metadata, msg.Metadata)
+				opts.propagators.Inject(ctx, propagation.MapCarrier(metadata))
+				msg.Metadata = metadata
+				traced[i] = msg
+			}
+			err := p.Publish(ctx, topic, traced...)
+			recordError(span, err)
+			return err
+		})
+	}
+}
+
+/*
+HandlerTracing returns a Middleware recording a consumer span "<topic> process" for every attempt to handle a
+message, as a child of the trace context injected in its metadata by PublisherTracing, if any.
+
+Example usage:
+
+	handler := event.ChainHandler(handleOrder, event.HandlerTracing[Order]())
+*/
+func HandlerTracing[T any](options ...TracingOption) Middleware[T] {
+	opts := newTracingOptions(options)
+	tracer := opts.tracerProvider.Tracer(tracerName)
+	return func(next Handler[T]) Handler[T] {
+		return func(ctx context.Context, msg Message[T]) error {
+			ctx = opts.propagators.Extract(ctx, propagation.MapCarrier(msg.Metadata))
+			ctx, span := tracer.Start(ctx, msg.Topic+" process",
+				oteltrace.WithSpanKind(oteltrace.SpanKindConsumer),
+				oteltrace.WithAttributes(
+					semconv.MessagingOperationProcess,
+					semconv.MessagingDestinationName(msg.Topic),
+					semconv.MessagingMessageID(msg.ID),
+					AttributeMessageAttempt.Int(msg.Attempt),
+				),
+			)
+			defer span.End()
+
+			err := next(ctx, msg)
+			recordError(span, err)
+			return err
+		}
+	}
+}
+
+// recordError records err on span, if any.
+func recordError(span oteltrace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
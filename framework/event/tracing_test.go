@@ -0,0 +1,59 @@
+package event_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/kittipat1413/go-common/framework/event"
+)
+
+func TestTracing(t *testing.T) {
+	ctx := context.Background()
+	sr := tracetest.NewSpanRecorder()
+	options := []event.TracingOption{
+		event.WithTracerProvider(tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(sr))),
+		event.WithPropagators(propagation.TraceContext{}),
+	}
+
+	publisher := &recordingPublisher{}
+	metadata := map[string]string{"source": "checkout"}
+	traced := event.Chain[string](publisher, event.PublisherTracing[string](options...))
+	require.NoError(t, traced.Publish(ctx, "orders", event.Message[string]{ID: "1", Metadata: metadata}))
+	require.Len(t, publisher.msgs, 1)
+	msg := publisher.msgs[0]
+	assert.Equal(t, "checkout", msg.Metadata["source"])
+	assert.NotEmpty(t, msg.Metadata["traceparent"])
+	assert.NotContains(t, metadata, "traceparent", "the metadata of the caller must not be modified")
+
+	msg.Attempt = 1
+	handler := event.ChainHandler(func(ctx context.Context, msg event.Message[string]) error {
+		assert.True(t, oteltrace.SpanContextFromContext(ctx).IsValid())
+		return errors.New("handle failed")
+	}, event.HandlerTracing[string](options...))
+	require.Error(t, handler(ctx, msg))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	publish, process := spans[0], spans[1]
+	assert.Equal(t, "orders publish", publish.Name())
+	assert.Equal(t, oteltrace.SpanKindProducer, publish.SpanKind())
+	assert.Contains(t, publish.Attributes(), semconv.MessagingDestinationName("orders"))
+
+	assert.Equal(t, "orders process", process.Name())
+	assert.Equal(t, oteltrace.SpanKindConsumer, process.SpanKind())
+	assert.Equal(t, publish.SpanContext().TraceID(), process.SpanContext().TraceID(), "the trace continues on the subscriber side")
+	assert.Equal(t, publish.SpanContext().SpanID(), process.Parent().SpanID())
+	assert.Contains(t, process.Attributes(), semconv.MessagingMessageID("1"))
+	assert.Contains(t, process.Attributes(), event.AttributeMessageAttempt.Int(1))
+	assert.Equal(t, otelcodes.Error, process.Status().Code)
+}
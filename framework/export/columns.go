@@ -0,0 +1,187 @@
+package export
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TagName is the name of the struct tags of the columns.
+const TagName = "export"
+
+// mapper maps the rows of a type to the values of their columns.
+type mapper struct {
+	columns []string // columns are the names of the columns of the struct rows.
+	fields  [][]int  // fields are the indexes of the fields of the columns of the struct rows.
+	slice   bool     // slice tells whether the rows are slices of values.
+}
+
+// mappers caches the mappers, by type.
+var mappers sync.Map
+
+/*
+Columns returns the names of the columns of the rows of type T, a struct or a pointer to a struct. The exported
+fields are the columns, in order, named by their export tag, or by their name without tag:
+
+	type Order struct {
+		ID        string    `export:"Order ID"`
+		Customer  string    // Column "Customer"
+		Total     float64   `export:"Total (USD)"`
+		CreatedAt time.Time `export:"Created At"`
+		Internal  string    `export:"-"` // Not exported
+	}
+
+The fields of the embedded structs are columns of the struct, unless the embedded struct has a tag.
+*/
+func Columns[T any]() ([]string, error) {
+	m, err := mapperOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	return m.columns, nil
+}
+
+// mapperOf returns the mapper of the rows of type T.
+func mapperOf[T any]() (*mapper, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if m, ok := mappers.Load(t); ok {
+		return m.(*mapper), nil
+	}
+	m := &mapper{}
+	row := t
+	if row.Kind() == reflect.Pointer {
+		row = row.Elem()
+	}
+	switch row.Kind() {
+	case reflect.Struct:
+		m.addFields(row, nil)
+	case reflect.Slice, reflect.Array:
+		m.slice = true
+	default:
+		return nil, fmt.Errorf("export: the rows of type %s are not structs or slices", t)
+	}
+	mappers.Store(t, m)
+	return m, nil
+}
+
+// addFields adds the columns of the fields of the struct type t, whose index in the row is index.
+func (m *mapper) addFields(t reflect.Type, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, tagged := field.Tag.Lookup(TagName)
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && !tagged && fieldType.Kind() == reflect.Struct {
+			m.addFields(fieldType, fieldIndex)
+			continue
+		}
+		name := field.Name
+		if tag != "" {
+			name = tag
+		}
+		m.columns = append(m.columns, name)
+		m.fields = append(m.fields, fieldIndex)
+	}
+}
+
+// values returns the values of the columns of row.
+func (m *mapper) values(row any) []any {
+	v := reflect.ValueOf(row)
+	if m.slice {
+		values := make([]any, v.Len())
+		for i := range values {
+			values[i] = v.Index(i).Interface()
+		}
+		return values
+	}
+	values := make([]any, len(m.fields))
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return values
+		}
+		v = v.Elem()
+	}
+	for i, index := range m.fields {
+		// The fields of the nil embedded pointers are nil.
+		if field, err := v.FieldByIndexErr(index); err == nil {
+			values[i] = field.Interface()
+		}
+	}
+	return values
+}
+
+// cellKind is the type of the value of a cell.
+type cellKind int
+
+const (
+	cellEmpty cellKind = iota
+	cellString
+	cellNumber
+	cellBool
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// formatCell returns the type and the text of the cell of value, see Encoder.
+func formatCell(value any, timeFormat string) (cellKind, string) {
+	if value == nil {
+		return cellEmpty, ""
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return cellEmpty, ""
+		}
+		v = v.Elem()
+	}
+	switch {
+	case v.Type() == timeType:
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return cellEmpty, ""
+		}
+		return cellString, t.Format(timeFormat)
+	case v.Type().Implements(textMarshalerType):
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return cellString, fmt.Sprint(v.Interface())
+		}
+		return cellString, string(text)
+	case v.Type().Implements(stringerType):
+		return cellString, v.Interface().(fmt.Stringer).String()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return cellString, v.String()
+	case reflect.Bool:
+		return cellBool, strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cellNumber, strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cellNumber, strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		text := strconv.FormatFloat(f, 'f', -1, v.Type().Bits())
+		if strings.ContainsAny(text, "NI") {
+			// NaN and infinities are not numbers of the spreadsheets.
+			return cellString, text
+		}
+		return cellNumber, text
+	default:
+		return cellString, fmt.Sprint(v.Interface())
+	}
+}
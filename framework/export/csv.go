@@ -0,0 +1,52 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// utf8BOM is the UTF-8 byte order mark.
+const utf8BOM = "\xEF\xBB\xBF"
+
+// formulaPrefixes are the first characters of the cells run as formulas by the spreadsheets.
+const formulaPrefixes = "=+-@\t\r"
+
+// csvEncoder is the Encoder of FormatCSV.
+type csvEncoder struct {
+	w      *csv.Writer
+	opts   *options
+	record []string
+}
+
+func newCSVEncoder(w io.Writer, opts *options) (*csvEncoder, error) {
+	if opts.bom {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return nil, err
+		}
+	}
+	writer := csv.NewWriter(w)
+	writer.Comma = opts.delimiter
+	return &csvEncoder{w: writer, opts: opts}, nil
+}
+
+func (e *csvEncoder) WriteRow(values []any) error {
+	e.record = e.record[:0]
+	for _, value := range values {
+		kind, text := formatCell(value, e.opts.timeFormat)
+		if kind == cellString && e.opts.escapeFormulas && text != "" && strings.ContainsRune(formulaPrefixes, rune(text[0])) {
+			text = "'" + text
+		}
+		e.record = append(e.record, text)
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) Close() error {
+	return e.Flush()
+}
@@ -0,0 +1,370 @@
+package export
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/storage"
+)
+
+// Format is the file format of an export.
+type Format string
+
+// Formats of the exports.
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ContentType returns the media type of the files of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return storage.DefaultContentType
+	}
+}
+
+// Codes of the errors returned by the package.
+const (
+	CodeUnsupportedFormat = "export_unsupported_format"
+	CodeTooManyRows       = "export_too_many_rows"
+)
+
+var (
+	// ErrUnsupportedFormat is returned for the formats other than FormatCSV and FormatXLSX.
+	ErrUnsupportedFormat = errors.InvalidArgument(CodeUnsupportedFormat, "unsupported export format")
+	// ErrTooManyRows is returned when an XLSX export exceeds MaxXLSXRows rows, including the header.
+	ErrTooManyRows = errors.InvalidArgument(CodeTooManyRows, "too many rows for the export format")
+)
+
+const (
+	// DefaultTimeFormat is the format of the time.Time values, unless set with WithTimeFormat.
+	DefaultTimeFormat = time.RFC3339
+	// DefaultFlushRows is the number of rows written between flushes, unless set with WithFlushRows.
+	DefaultFlushRows = 1000
+	// DefaultSheetName is the name of the worksheet of the XLSX exports, unless set with WithSheetName.
+	DefaultSheetName = "Sheet1"
+)
+
+// options holds configuration options for the exports.
+type options struct {
+	header         bool         // header writes the header row.
+	columns        []string     // columns are the names of the columns, overriding the ones of the struct tags.
+	timeFormat     string       // timeFormat is the format of the time.Time values.
+	flushRows      int          // flushRows is the number of rows written between flushes.
+	delimiter      rune         // delimiter separates the fields of the CSV exports.
+	bom            bool         // bom writes a UTF-8 byte order mark at the beginning of the CSV exports.
+	escapeFormulas bool         // escapeFormulas escapes the strings of the CSV exports starting like formulas.
+	sheetName      string       // sheetName is the name of the worksheet of the XLSX exports.
+	flush          func() error // flush is called after the encoder is flushed, e.g., to flush a response.
+}
+
+// Option specifies export configuration options.
+type Option func(*options)
+
+// WithoutHeader omits the header row.
+func WithoutHeader() Option {
+	return func(opts *options) {
+		opts.header = false
+	}
+}
+
+// WithColumns sets the names of the columns of the header, in order, instead of the ones of the struct tags. It is
+// needed for the header of the rows which are not structs, e.g., []any.
+func WithColumns(columns ...string) Option {
+	return func(opts *options) {
+		opts.columns = columns
+	}
+}
+
+// WithTimeFormat sets the layout of the time.Time values, see time.Time.Format.
+func WithTimeFormat(layout string) Option {
+	return func(opts *options) {
+		if layout != "" {
+			opts.timeFormat = layout
+		}
+	}
+}
+
+// WithFlushRows sets the number of rows buffered before they are written out, bounding the memory of an export.
+func WithFlushRows(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.flushRows = n
+		}
+	}
+}
+
+// WithDelimiter sets the field delimiter of the CSV exports, ',' by default, e.g., ';' for the locales whose
+// decimal separator is a comma.
+func WithDelimiter(delimiter rune) Option {
+	return func(opts *options) {
+		if delimiter != 0 {
+			opts.delimiter = delimiter
+		}
+	}
+}
+
+// WithBOM writes a UTF-8 byte order mark at the beginning of the CSV exports, so that Excel reads them as UTF-8.
+func WithBOM() Option {
+	return func(opts *options) {
+		opts.bom = true
+	}
+}
+
+// WithoutFormulaEscaping writes the strings of the CSV exports as they are. By default, the strings starting with
+// '=', '+', '-', '@', a tab or a carriage return are prefixed with a single quote, so that spreadsheets do not run
+// them as formulas (CSV injection).
+func WithoutFormulaEscaping() Option {
+	return func(opts *options) {
+		opts.escapeFormulas = false
+	}
+}
+
+// WithSheetName sets the name of the worksheet of the XLSX exports, truncated to 31 characters.
+func WithSheetName(name string) Option {
+	return func(opts *options) {
+		if name != "" {
+			opts.sheetName = name
+		}
+	}
+}
+
+// withFlush sets a function called after the encoder is flushed.
+func withFlush(flush func() error) Option {
+	return func(opts *options) {
+		opts.flush = flush
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		header:         true,
+		timeFormat:     DefaultTimeFormat,
+		flushRows:      DefaultFlushRows,
+		delimiter:      ',',
+		escapeFormulas: true,
+		sheetName:      DefaultSheetName,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+/*
+Encoder writes the rows of an export in a format, buffering them until Flush. The values are formatted as follows:
+  - nil and nil pointers are empty cells;
+  - strings, booleans and numbers are cells of their type in XLSX;
+  - integers of more than 15 digits, e.g., IDs, are strings in XLSX, so that the spreadsheets do not round them;
+  - time.Time values are formatted with WithTimeFormat;
+  - fmt.Stringer and encoding.TextMarshaler values are their text;
+  - the other values are formatted with fmt.Sprint.
+
+Close writes the end of the file, and must be called for the XLSX exports to be valid. It does not close the
+underlying writer.
+*/
+type Encoder interface {
+	WriteRow(values []any) error
+	Flush() error
+	Close() error
+}
+
+// NewEncoder creates an Encoder writing the rows in format to w, or returns ErrUnsupportedFormat.
+func NewEncoder(w io.Writer, format Format, opts ...Option) (Encoder, error) {
+	o := newOptions(opts)
+	switch format {
+	case FormatCSV:
+		return newCSVEncoder(w, o)
+	case FormatXLSX:
+		return newXLSXEncoder(w, o)
+	default:
+		return nil, ErrUnsupportedFormat.WithField("format", string(format))
+	}
+}
+
+/*
+Write writes the rows emitted by source to w in format, flushing them every WithFlushRows rows, so that the memory
+of the export does not grow with the number of rows. The rows are structs, or pointers to structs, whose exported
+fields are the columns, see the struct tags of Columns, or slices of values, e.g., []any with WithColumns.
+
+Example usage:
+
+	file, err := os.Create("orders.xlsx")
+	if err != nil {
+		// Handle error
+	}
+	defer file.Close()
+	err = export.Write(ctx, file, export.FormatXLSX, export.FromSlice(orders))
+*/
+func Write[T any](ctx context.Context, w io.Writer, format Format, source Source[T], opts ...Option) error {
+	o := newOptions(opts)
+	encoder, err := NewEncoder(w, format, opts...)
+	if err != nil {
+		return err
+	}
+	m, err := mapperOf[T]()
+	if err != nil {
+		return err
+	}
+	if o.header {
+		columns := o.columns
+		if columns == nil {
+			columns = m.columns
+		}
+		if len(columns) > 0 {
+			header := make([]any, len(columns))
+			for i, column := range columns {
+				header[i] = column
+			}
+			if err := encoder.WriteRow(header); err != nil {
+				return err
+			}
+		}
+	}
+
+	rows := 0
+	flush := func() error {
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+		if o.flush != nil {
+			return o.flush()
+		}
+		return nil
+	}
+	err = source(ctx, func(row T) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := encoder.WriteRow(m.values(row)); err != nil {
+			return err
+		}
+		if rows++; rows%o.flushRows == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	if o.flush != nil {
+		return o.flush()
+	}
+	return nil
+}
+
+/*
+WriteResponse writes the rows emitted by source to w as an attachment named filename, with the content type of
+format, flushing the response every WithFlushRows rows so that the client receives the file as it is written.
+
+WriteResponse returns the error of the export if nothing was written to w yet, so that the handler can respond
+with an error instead. Once the response is started, the errors abort it with http.ErrAbortHandler, so that the
+client does not silently receive a truncated file.
+
+Example usage:
+
+	func (h *Handler) ExportOrders(w http.ResponseWriter, r *http.Request) {
+		source := func(ctx context.Context, emit func(Order) error) error {
+			return h.repo.EachOrder(ctx, emit)
+		}
+		if err := export.WriteResponse(r.Context(), w, "orders.csv", export.FormatCSV, source); err != nil {
+			httpresponse.Error(w, r, err)
+		}
+	}
+*/
+func WriteResponse[T any](ctx context.Context, w http.ResponseWriter, filename string, format Format, source Source[T], opts ...Option) error {
+	headers := map[string]string{
+		"Content-Type":           format.ContentType(),
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": filename}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "no-store",
+	}
+	for name, value := range headers {
+		w.Header().Set(name, value)
+	}
+	response := &responseWriter{w: w}
+	controller := http.NewResponseController(w)
+	flush := func() error {
+		if !response.started {
+			return nil
+		}
+		if err := controller.Flush(); err != nil && !stderrors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+	err := Write(ctx, response, format, source, append(opts, withFlush(flush))...)
+	if err == nil {
+		return nil
+	}
+	if !response.started {
+		for name := range headers {
+			w.Header().Del(name)
+		}
+		return err
+	}
+	// Abort the response, closing the connection instead of ending the body.
+	panic(http.ErrAbortHandler)
+}
+
+// responseWriter records whether the response is started.
+type responseWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.started = true
+	return w.w.Write(p)
+}
+
+/*
+Upload writes the rows emitted by source to the object key of store, with the content type of format, streaming
+them to the store as they are written, see Write.
+
+Example usage:
+
+	info, err := export.Upload(ctx, store, "reports/2024-05/orders.xlsx", export.FormatXLSX, source)
+	if err != nil {
+		// Handle error
+	}
+	url, err := store.SignedURL(ctx, info.Key)
+*/
+func Upload[T any](ctx context.Context, store storage.Store, key string, format Format, source Source[T], opts ...Option) (storage.ObjectInfo, error) {
+	if _, err := NewEncoder(io.Discard, format); err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	reader, writer := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := Write(ctx, writer, format, source, opts...)
+		writer.CloseWithError(err)
+		done <- err
+	}()
+	info, err := store.Put(ctx, key, reader, storage.WithContentType(format.ContentType()))
+	// Stop the export if the store stopped reading it.
+	reader.CloseWithError(errUploadEnded)
+	if exportErr := <-done; exportErr != nil && !stderrors.Is(exportErr, errUploadEnded) {
+		return storage.ObjectInfo{}, exportErr
+	}
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	return info, nil
+}
+
+// errUploadEnded stops the exports of Upload when the store stopped reading them.
+var errUploadEnded = stderrors.New("export: the upload ended")
@@ -0,0 +1,261 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/export"
+	"github.com/kittipat1413/go-common/framework/storage"
+	"github.com/kittipat1413/go-common/framework/storage/localstorage"
+)
+
+type Audit struct {
+	CreatedBy string `export:"Created By"`
+}
+
+type Order struct {
+	ID        string    `export:"Order ID"`
+	Customer  string    // Column "Customer"
+	Total     float64   `export:"Total (USD)"`
+	Paid      bool      `export:"Paid"`
+	Note      *string   `export:"Note"`
+	CreatedAt time.Time `export:"Created At"`
+	Internal  string    `export:"-"`
+	secret    string
+	*Audit
+}
+
+var createdAt = time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+func orders() []Order {
+	note := "gift, wrap"
+	return []Order{
+		{ID: "A-1", Customer: "Alice", Total: 12.5, Paid: true, Note: &note, CreatedAt: createdAt, Internal: "x", secret: "y", Audit: &Audit{CreatedBy: "bob"}},
+		{ID: "A-2", Customer: "=HYPERLINK(\"http://evil\")", Total: -3},
+	}
+}
+
+func TestColumns(t *testing.T) {
+	columns, err := export.Columns[Order]()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Order ID", "Customer", "Total (USD)", "Paid", "Note", "Created At", "Created By"}, columns)
+
+	columns, err = export.Columns[*Order]()
+	require.NoError(t, err)
+	assert.Len(t, columns, 7)
+
+	columns, err = export.Columns[[]any]()
+	require.NoError(t, err)
+	assert.Empty(t, columns)
+
+	_, err = export.Columns[int]()
+	assert.Error(t, err)
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	err := export.Write(context.Background(), &b, export.FormatCSV, export.FromSlice(orders()))
+	require.NoError(t, err)
+	assert.Equal(t, "Order ID,Customer,Total (USD),Paid,Note,Created At,Created By\n"+
+		"A-1,Alice,12.5,true,\"gift, wrap\",2024-05-01T12:30:00Z,bob\n"+
+		"A-2,\"'=HYPERLINK(\"\"http://evil\"\")\",-3,false,,,\n", b.String())
+}
+
+func TestWriteCSVOptions(t *testing.T) {
+	var b bytes.Buffer
+	err := export.Write(context.Background(), &b, export.FormatCSV, export.FromSlice([]*Order{{ID: "-1", CreatedAt: createdAt}, nil}),
+		export.WithoutHeader(),
+		export.WithDelimiter(';'),
+		export.WithBOM(),
+		export.WithoutFormulaEscaping(),
+		export.WithTimeFormat("2006-01-02"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "\xEF\xBB\xBF-1;;0;false;;2024-05-01;\n;;;;;;\n", b.String())
+}
+
+func TestWriteCSVSliceRows(t *testing.T) {
+	var b bytes.Buffer
+	rows := [][]any{{"a", 1, nil}, {"b", 2.5, true}}
+	err := export.Write(context.Background(), &b, export.FormatCSV, export.FromSlice(rows), export.WithColumns("Name", "Value", "Flag"))
+	require.NoError(t, err)
+	assert.Equal(t, "Name,Value,Flag\na,1,\nb,2.5,true\n", b.String())
+}
+
+func TestWriteUnsupported(t *testing.T) {
+	err := export.Write(context.Background(), io.Discard, export.Format("pdf"), export.FromSlice(orders()))
+	assert.ErrorIs(t, err, export.ErrUnsupportedFormat)
+	assert.Equal(t, domain_error.KindInvalidArgument, domain_error.KindOf(err))
+	var coded *domain_error.CodedError
+	require.True(t, errors.As(err, &coded))
+	assert.Equal(t, "pdf", coded.Fields()["format"])
+
+	err = export.Write(context.Background(), io.Discard, export.FormatCSV, export.FromSlice([]int{1}))
+	assert.Error(t, err)
+}
+
+func TestWriteSourceError(t *testing.T) {
+	errSource := errors.New("query failed")
+	source := func(ctx context.Context, emit func(Order) error) error {
+		if err := emit(Order{ID: "A-1"}); err != nil {
+			return err
+		}
+		return errSource
+	}
+	err := export.Write(context.Background(), io.Discard, export.FormatCSV, source)
+	assert.ErrorIs(t, err, errSource)
+}
+
+// sheet returns the worksheet and the workbook of the XLSX file b.
+func sheet(t *testing.T, b []byte) (string, string) {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	require.NoError(t, err)
+	parts := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		parts[f.Name] = string(content)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		assert.Contains(t, parts, name)
+	}
+	return parts["xl/worksheets/sheet1.xml"], parts["xl/workbook.xml"]
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var b bytes.Buffer
+	rows := append(orders(), Order{ID: "A-3", Customer: "<Carol & Dave>"})
+	err := export.Write(context.Background(), &b, export.FormatXLSX, export.FromSlice(rows), export.WithSheetName("Orders: May/2024 and the rest of the year"), export.WithFlushRows(1))
+	require.NoError(t, err)
+
+	worksheet, workbook := sheet(t, b.Bytes())
+	assert.Contains(t, workbook, `<sheet name="Orders_ May_2024 and the rest o" sheetId="1" r:id="rId1"/>`)
+	assert.Contains(t, worksheet, `state="frozen"`)
+	assert.Contains(t, worksheet, `<row><c s="1" t="inlineStr"><is><t xml:space="preserve">Order ID</t></is></c>`)
+	assert.Contains(t, worksheet, `<row><c t="inlineStr"><is><t xml:space="preserve">A-1</t></is></c>`+
+		`<c t="inlineStr"><is><t xml:space="preserve">Alice</t></is></c>`+
+		`<c><v>12.5</v></c>`+
+		`<c t="b"><v>1</v></c>`+
+		`<c t="inlineStr"><is><t xml:space="preserve">gift, wrap</t></is></c>`+
+		`<c t="inlineStr"><is><t xml:space="preserve">2024-05-01T12:30:00Z</t></is></c>`+
+		`<c t="inlineStr"><is><t xml:space="preserve">bob</t></is></c></row>`)
+	// The formulas are not escaped, since the inline strings are never run.
+	assert.Contains(t, worksheet, `<t xml:space="preserve">=HYPERLINK(&#34;http://evil&#34;)</t>`)
+	assert.Contains(t, worksheet, `<c><v>-3</v></c><c t="b"><v>0</v></c><c/><c/><c/></row>`)
+	assert.Contains(t, worksheet, `<t xml:space="preserve">&lt;Carol &amp; Dave&gt;</t>`)
+	assert.True(t, strings.HasSuffix(worksheet, "</sheetData></worksheet>"))
+}
+
+func TestWriteXLSXWithoutHeader(t *testing.T) {
+	var b bytes.Buffer
+	err := export.Write(context.Background(), &b, export.FormatXLSX, export.FromSlice([][]any{{"a", int64(1234567890123456789)}}), export.WithoutHeader())
+	require.NoError(t, err)
+	worksheet, workbook := sheet(t, b.Bytes())
+	assert.Contains(t, workbook, `<sheet name="Sheet1"`)
+	assert.NotContains(t, worksheet, "frozen")
+	// The integers with more than 15 digits are strings, so that they are not rounded.
+	assert.Contains(t, worksheet, `<sheetData><row><c t="inlineStr"><is><t xml:space="preserve">a</t></is></c>`+
+		`<c t="inlineStr"><is><t xml:space="preserve">1234567890123456789</t></is></c></row></sheetData>`)
+}
+
+func TestWriteResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := export.WriteResponse(context.Background(), rec, "orders May.csv", export.FormatCSV, export.FromSlice(orders()), export.WithFlushRows(1))
+	require.NoError(t, err)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="orders May.csv"`, rec.Header().Get("Content-Disposition"))
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	assert.True(t, rec.Flushed)
+	assert.True(t, strings.HasPrefix(rec.Body.String(), "Order ID,"))
+}
+
+func TestWriteResponseError(t *testing.T) {
+	errSource := errors.New("query failed")
+	failing := func(ctx context.Context, emit func(Order) error) error {
+		return errSource
+	}
+	rec := httptest.NewRecorder()
+	err := export.WriteResponse(context.Background(), rec, "orders.csv", export.FormatCSV, failing)
+	assert.ErrorIs(t, err, errSource)
+	assert.Empty(t, rec.Header().Get("Content-Disposition"))
+	assert.Zero(t, rec.Body.Len())
+
+	// The errors abort the started responses.
+	failingLater := func(ctx context.Context, emit func(Order) error) error {
+		if err := emit(Order{ID: "A-1"}); err != nil {
+			return err
+		}
+		return errSource
+	}
+	rec = httptest.NewRecorder()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		_ = export.WriteResponse(context.Background(), rec, "orders.csv", export.FormatCSV, failingLater, export.WithFlushRows(1))
+	})
+}
+
+func TestUpload(t *testing.T) {
+	store, err := localstorage.New(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	info, err := export.Upload(ctx, store, "reports/orders.xlsx", export.FormatXLSX, export.FromSlice(orders()))
+	require.NoError(t, err)
+	assert.Equal(t, "reports/orders.xlsx", info.Key)
+	assert.Equal(t, export.FormatXLSX.ContentType(), info.ContentType)
+
+	object, err := store.Get(ctx, "reports/orders.xlsx")
+	require.NoError(t, err)
+	defer object.Close()
+	content, err := io.ReadAll(object)
+	require.NoError(t, err)
+	worksheet, _ := sheet(t, content)
+	assert.Contains(t, worksheet, "Alice")
+
+	_, err = export.Upload(ctx, store, "reports/orders.pdf", export.Format("pdf"), export.FromSlice(orders()))
+	assert.ErrorIs(t, err, export.ErrUnsupportedFormat)
+
+	errSource := errors.New("query failed")
+	failing := func(ctx context.Context, emit func(Order) error) error {
+		return errSource
+	}
+	_, err = export.Upload(ctx, store, "reports/failed.csv", export.FormatCSV, failing)
+	assert.ErrorIs(t, err, errSource)
+	_, err = store.Stat(ctx, "reports/failed.csv")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	_, err = export.Upload(ctx, store, "../invalid.csv", export.FormatCSV, export.FromSlice(orders()))
+	assert.ErrorIs(t, err, storage.ErrInvalidKey)
+}
+
+func TestFromChannel(t *testing.T) {
+	rows := make(chan Order, 2)
+	rows <- Order{ID: "A-1"}
+	rows <- Order{ID: "A-2"}
+	close(rows)
+	var b bytes.Buffer
+	err := export.Write(context.Background(), &b, export.FormatCSV, export.FromChannel(rows), export.WithoutHeader())
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(b.String(), "\n"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = export.Write(ctx, io.Discard, export.FormatCSV, export.FromChannel(make(chan Order)))
+	assert.ErrorIs(t, err, context.Canceled)
+}
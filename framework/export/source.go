@@ -0,0 +1,62 @@
+package export
+
+import "context"
+
+/*
+Source emits the rows of an export in order, calling emit for every row, and returns the first error of emit, or
+its own error. The rows are emitted as they are read, e.g., from the rows of a database query, so that they are
+never all in memory.
+
+Example usage:
+
+	source := func(ctx context.Context, emit func(Order) error) error {
+		rows, err := db.QueryContext(ctx, "SELECT id, customer, total, created_at FROM orders")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var order Order
+			if err := rows.Scan(&order.ID, &order.Customer, &order.Total, &order.CreatedAt); err != nil {
+				return err
+			}
+			if err := emit(order); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	}
+*/
+type Source[T any] func(ctx context.Context, emit func(row T) error) error
+
+// FromSlice returns a Source emitting rows.
+func FromSlice[T any](rows []T) Source[T] {
+	return func(_ context.Context, emit func(row T) error) error {
+		for _, row := range rows {
+			if err := emit(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// FromChannel returns a Source emitting the rows received from rows until it is closed, or ctx is done. The
+// producer should stop sending when ctx is done, since the rows are not received anymore.
+func FromChannel[T any](rows <-chan T) Source[T] {
+	return func(ctx context.Context, emit func(row T) error) error {
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case row, ok := <-rows:
+				if !ok {
+					return nil
+				}
+				if err := emit(row); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
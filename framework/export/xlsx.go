@@ -0,0 +1,178 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+const (
+	// MaxXLSXRows is the maximum number of rows of a worksheet, including the header.
+	MaxXLSXRows = 1 << 20
+	// maxSheetNameLength is the maximum length of the name of a worksheet.
+	maxSheetNameLength = 31
+	// maxXLSXDigits is the number of significant digits of the numbers of the spreadsheets.
+	maxXLSXDigits = 15
+)
+
+// Parts of the XLSX packages, besides the worksheet.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`
+	xlsxRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbookRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`
+	// xlsxWorkbook is the workbook, formatted with the escaped name of the worksheet.
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="{{name}}" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+	// xlsxStyles has the default style, and the bold style 1 of the header.
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`
+	// xlsxFrozenHeader keeps the header visible while scrolling.
+	xlsxFrozenHeader = `<sheetViews><sheetView workbookViewId="0">` +
+		`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>` +
+		`</sheetView></sheetViews>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+/*
+xlsxEncoder is the Encoder of FormatXLSX. It writes the parts of the package first, then streams the rows of the
+worksheet, with inline strings instead of a table of shared strings, which would have to be written after them and
+kept in memory. The first row is the bold, frozen header, unless WithoutHeader is set.
+*/
+type xlsxEncoder struct {
+	zip  *zip.Writer
+	w    *bufio.Writer
+	opts *options
+	rows int
+}
+
+func newXLSXEncoder(w io.Writer, opts *options) (*xlsxEncoder, error) {
+	zw := zip.NewWriter(w)
+	name := sheetName(opts.sheetName)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRelationships},
+		{"xl/workbook.xml", strings.Replace(xlsxWorkbook, "{{name}}", escapeXML(name), 1)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRelationships},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(pw, part.content); err != nil {
+			return nil, err
+		}
+	}
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	e := &xlsxEncoder{zip: zw, w: bufio.NewWriter(sheet), opts: opts}
+	e.w.WriteString(xlsxSheetStart)
+	if opts.header {
+		e.w.WriteString(xlsxFrozenHeader)
+	}
+	e.w.WriteString("<sheetData>")
+	return e, nil
+}
+
+func (e *xlsxEncoder) WriteRow(values []any) error {
+	if e.rows >= MaxXLSXRows {
+		return ErrTooManyRows
+	}
+	e.rows++
+	style := ""
+	if e.rows == 1 && e.opts.header {
+		style = ` s="1"`
+	}
+	e.w.WriteString("<row>")
+	for _, value := range values {
+		kind, text := formatCell(value, e.opts.timeFormat)
+		if kind == cellNumber && !strings.Contains(text, ".") && len(strings.TrimPrefix(text, "-")) > maxXLSXDigits {
+			// The spreadsheets would round the integers, e.g., the IDs, to 15 significant digits.
+			kind = cellString
+		}
+		switch kind {
+		case cellEmpty:
+			e.w.WriteString("<c" + style + "/>")
+		case cellNumber:
+			e.w.WriteString("<c" + style + "><v>" + text + "</v></c>")
+		case cellBool:
+			b := "0"
+			if text == "true" {
+				b = "1"
+			}
+			e.w.WriteString(`<c` + style + ` t="b"><v>` + b + "</v></c>")
+		default:
+			e.w.WriteString(`<c` + style + ` t="inlineStr"><is><t xml:space="preserve">`)
+			e.w.WriteString(escapeXML(text))
+			e.w.WriteString("</t></is></c>")
+		}
+	}
+	_, err := e.w.WriteString("</row>")
+	return err
+}
+
+func (e *xlsxEncoder) Flush() error {
+	if err := e.w.Flush(); err != nil {
+		return err
+	}
+	return e.zip.Flush()
+}
+
+func (e *xlsxEncoder) Close() error {
+	e.w.WriteString(xlsxSheetEnd)
+	if err := e.w.Flush(); err != nil {
+		return err
+	}
+	return e.zip.Close()
+}
+
+// sheetName returns name without the characters forbidden in the names of the worksheets, truncated.
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > maxSheetNameLength {
+		name = string(runes[:maxSheetNameLength])
+	}
+	return name
+}
+
+// escapeXML returns s escaped for the text and the attributes of XML, with the invalid characters replaced.
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
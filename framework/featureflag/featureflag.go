@@ -0,0 +1,321 @@
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// DefaultBucketAttribute is the attribute bucketing the percent rollouts, unless set in the Flag.
+const DefaultBucketAttribute = "user_id"
+
+// ErrFlagNotFound is returned by the providers for the flags they do not define, so that the default value is
+// served.
+var ErrFlagNotFound = errors.New("featureflag: flag not found")
+
+/*
+Flag is the definition of a feature flag, returned by a Provider. The value served is, in order:
+  - the default value of the call site, if the flag is disabled;
+  - the value of the first rule matching the attributes of the context, if the attributes are within the rollout
+    percentage of the rule;
+  - the value of the flag, if the attributes are within the rollout percentage of the flag;
+  - the default value of the call site otherwise.
+
+Example definition, in a file of a FileProvider:
+
+	new-checkout:
+	  enabled: true
+	  value: true
+	  percentage: 20          # 20% of the users
+	  rules:
+	    - attribute: country  # all the users of Thailand
+	      values: [TH]
+	    - attribute: plan     # half of the premium users
+	      values: [premium]
+	      percentage: 50
+*/
+type Flag struct {
+	// Enabled is false to serve the default value of the call site to all.
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Value is the value served: a bool, a string, or a number.
+	Value any `json:"value" yaml:"value"`
+	// Percentage is the percentage of the rollout of the flag, between 0 and 100, or nil to serve its value to all.
+	Percentage *float64 `json:"percentage,omitempty" yaml:"percentage,omitempty"`
+	// BucketBy is the attribute bucketing the rollouts, so that an attribute value gets the same value on every
+	// evaluation. It defaults to DefaultBucketAttribute.
+	BucketBy string `json:"bucket_by,omitempty" yaml:"bucket_by,omitempty"`
+	// Rules are the targeting rules, evaluated in order.
+	Rules []Rule `json:"rules,omitempty" yaml:"rules,omitempty"`
+}
+
+// Rule targets the contexts whose attribute is one of the values.
+type Rule struct {
+	// Attribute is the attribute of the context matched, e.g., "country".
+	Attribute string `json:"attribute" yaml:"attribute"`
+	// Values are the values of the attribute matched.
+	Values []string `json:"values" yaml:"values"`
+	// Percentage is the percentage of the rollout among the contexts matched, or nil to serve the value to all.
+	Percentage *float64 `json:"percentage,omitempty" yaml:"percentage,omitempty"`
+	// Value is the value served to the contexts matched, or nil to serve the value of the flag.
+	Value any `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// Attributes are the attributes of the contexts targeted by the rules, e.g., the user ID, the country, or the plan.
+type Attributes map[string]string
+
+// AttributeExtractor extracts attributes from a context, e.g., the claims of the authenticated user.
+type AttributeExtractor func(ctx context.Context) Attributes
+
+// contextKey is an unexported type for context keys defined in this package.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying attrs, in addition to the attributes of ctx.
+func NewContext(ctx context.Context, attrs Attributes) context.Context {
+	merged := Attributes{}
+	for k, v := range AttributesFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range attrs {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+// AttributesFromContext returns the attributes carried by ctx. It is the AttributeExtractor of the Client, in
+// addition to the ones of WithAttributeExtractor.
+func AttributesFromContext(ctx context.Context) Attributes {
+	attrs, _ := ctx.Value(contextKey{}).(Attributes)
+	return attrs
+}
+
+// options holds configuration options for the Client.
+type options struct {
+	extractors   []AttributeExtractor                             // extractors extract the attributes of the contexts.
+	cache        cache.Cache[Flag]                                // cache caches the flags of the provider.
+	errorHandler func(ctx context.Context, key string, err error) // errorHandler is called when a flag cannot be loaded.
+}
+
+// Option specifies Client configuration options.
+type Option func(*options)
+
+// WithAttributeExtractor adds extractors of the attributes of the contexts, e.g., reading the claims of the
+// authenticated user. The attributes of NewContext take precedence.
+func WithAttributeExtractor(extractors ...AttributeExtractor) Option {
+	return func(opts *options) {
+		opts.extractors = append(opts.extractors, extractors...)
+	}
+}
+
+// WithCache caches the flags of the provider in c, e.g., a localcache for a remote provider, for the expiration of
+// the cache. The flags not found are not cached.
+func WithCache(c cache.Cache[Flag]) Option {
+	return func(opts *options) {
+		opts.cache = c
+	}
+}
+
+// WithErrorHandler sets a function called when the provider fails to return a flag, other than ErrFlagNotFound,
+// before the default value is served. It defaults to logging a warning with the logger of the context.
+func WithErrorHandler(handler func(ctx context.Context, key string, err error)) Option {
+	return func(opts *options) {
+		if handler != nil {
+			opts.errorHandler = handler
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		errorHandler: func(ctx context.Context, key string, err error) {
+			logger.FromContext(ctx).Warn(ctx, "feature flag evaluation failed, serving the default value",
+				logger.Fields{"flag": key, "error": err.Error()})
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+/*
+Client evaluates the feature flags of a Provider against the attributes of the contexts. The evaluations never
+fail: the default value of the call site is served when the flag is not found, cannot be loaded, is disabled, is not
+rolled out to the context, or has a value of another type.
+
+Example usage:
+
+	client := featureflag.New(
+		featureflag.Chain(featureflag.NewEnvProvider("FEATURE_"), fileProvider),
+		featureflag.WithAttributeExtractor(func(ctx context.Context) featureflag.Attributes {
+			return featureflag.Attributes{"user_id": auth.UserID(ctx)}
+		}),
+	)
+
+	if client.Bool(ctx, "new-checkout", false) {
+		...
+	}
+	limit := client.Int(ctx, "export-row-limit", 10000)
+*/
+type Client struct {
+	provider Provider
+	opts     *options
+}
+
+// New creates a Client of the flags of provider.
+func New(provider Provider, opts ...Option) *Client {
+	return &Client{provider: provider, opts: newOptions(opts)}
+}
+
+// Bool returns the value of the flag key for ctx, or defaultValue. The strings "true" and "false" are booleans.
+func (c *Client) Bool(ctx context.Context, key string, defaultValue bool) bool {
+	switch v := c.Value(ctx, key).(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// String returns the value of the flag key for ctx, or defaultValue.
+func (c *Client) String(ctx context.Context, key string, defaultValue string) string {
+	if v, ok := c.Value(ctx, key).(string); ok {
+		return v
+	}
+	return defaultValue
+}
+
+// Int returns the value of the flag key for ctx, or defaultValue. The integral numbers and the strings of integers
+// are integers.
+func (c *Client) Int(ctx context.Context, key string, defaultValue int) int {
+	switch v := c.Value(ctx, key).(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case uint64:
+		return int(v)
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v)
+		}
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+// Float64 returns the value of the flag key for ctx, or defaultValue. The numbers and the strings of numbers are
+// floats.
+func (c *Client) Float64(ctx context.Context, key string, defaultValue float64) float64 {
+	switch v := c.Value(ctx, key).(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+// Value returns the value of the flag key for ctx, or nil to serve the default value.
+func (c *Client) Value(ctx context.Context, key string) any {
+	flag, err := c.flag(ctx, key)
+	if err != nil {
+		if !errors.Is(err, ErrFlagNotFound) {
+			c.opts.errorHandler(ctx, key, err)
+		}
+		return nil
+	}
+	return evaluate(key, flag, c.attributes(ctx))
+}
+
+// flag returns the flag key of the provider, through the cache if any.
+func (c *Client) flag(ctx context.Context, key string) (Flag, error) {
+	if c.opts.cache == nil {
+		return c.provider.Flag(ctx, key)
+	}
+	return c.opts.cache.Get(ctx, key, func(ctx context.Context, key string) (Flag, *time.Duration, error) {
+		flag, err := c.provider.Flag(ctx, key)
+		return flag, nil, err
+	})
+}
+
+// attributes returns the attributes of ctx.
+func (c *Client) attributes(ctx context.Context) Attributes {
+	attrs := Attributes{}
+	for _, extractor := range c.opts.extractors {
+		for k, v := range extractor(ctx) {
+			attrs[k] = v
+		}
+	}
+	for k, v := range AttributesFromContext(ctx) {
+		attrs[k] = v
+	}
+	return attrs
+}
+
+// evaluate returns the value of flag for attrs, or nil to serve the default value.
+func evaluate(key string, flag Flag, attrs Attributes) any {
+	if !flag.Enabled {
+		return nil
+	}
+	bucketBy := flag.BucketBy
+	if bucketBy == "" {
+		bucketBy = DefaultBucketAttribute
+	}
+	for i, rule := range flag.Rules {
+		value, ok := attrs[rule.Attribute]
+		if !ok || !slices.Contains(rule.Values, value) {
+			continue
+		}
+		// Each rule buckets separately, so that the rollouts of the rules are independent.
+		if !rolledOut(key+"/rules/"+strconv.Itoa(i), rule.Percentage, attrs[bucketBy]) {
+			continue
+		}
+		if rule.Value != nil {
+			return rule.Value
+		}
+		return flag.Value
+	}
+	if !rolledOut(key, flag.Percentage, attrs[bucketBy]) {
+		return nil
+	}
+	return flag.Value
+}
+
+// rolledOut reports whether the bucket of the attribute value is within the rollout percentage. The contexts
+// without the attribute are not rolled out, unless the percentage is nil or 100.
+func rolledOut(salt string, percentage *float64, value string) bool {
+	switch {
+	case percentage == nil || *percentage >= 100:
+		return true
+	case *percentage <= 0 || value == "":
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(salt))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.TrimSpace(value)))
+	// The buckets are thousandths of percent.
+	return float64(h.Sum32()%100000) < *percentage*1000
+}
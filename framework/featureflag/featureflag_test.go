@@ -0,0 +1,121 @@
+package featureflag_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+	"github.com/kittipat1413/go-common/framework/featureflag"
+	"github.com/kittipat1413/go-common/util/pointer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_TypedValues(t *testing.T) {
+	client := featureflag.New(featureflag.Static(map[string]featureflag.Flag{
+		"bool":     {Enabled: true, Value: true},
+		"bool-str": {Enabled: true, Value: "false"},
+		"string":   {Enabled: true, Value: "blue"},
+		"int":      {Enabled: true, Value: 500},
+		"float":    {Enabled: true, Value: 1.5},
+		"int-str":  {Enabled: true, Value: "42"},
+		"disabled": {Enabled: false, Value: true},
+	}))
+	ctx := context.Background()
+
+	assert.True(t, client.Bool(ctx, "bool", false))
+	assert.False(t, client.Bool(ctx, "bool-str", true))
+	assert.Equal(t, "blue", client.String(ctx, "string", "red"))
+	assert.Equal(t, 500, client.Int(ctx, "int", 0))
+	assert.Equal(t, 42, client.Int(ctx, "int-str", 0))
+	assert.Equal(t, 1.5, client.Float64(ctx, "float", 0))
+	assert.Equal(t, 500.0, client.Float64(ctx, "int", 0))
+
+	// The default value is served otherwise.
+	assert.False(t, client.Bool(ctx, "disabled", false))
+	assert.True(t, client.Bool(ctx, "missing", true))
+	assert.Equal(t, 7, client.Int(ctx, "float", 7), "not an integer")
+	assert.Equal(t, "red", client.String(ctx, "int", "red"), "not a string")
+}
+
+func TestClient_Rollout(t *testing.T) {
+	client := featureflag.New(featureflag.Static(map[string]featureflag.Flag{
+		"new-checkout": {Enabled: true, Value: true, Percentage: pointer.ToPointer(20.0)},
+	}))
+
+	enabled := 0
+	for i := range 10000 {
+		ctx := featureflag.NewContext(context.Background(), featureflag.Attributes{"user_id": fmt.Sprint(i)})
+		value := client.Bool(ctx, "new-checkout", false)
+		assert.Equal(t, value, client.Bool(ctx, "new-checkout", false), "a user gets the same value")
+		if value {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 2000, enabled, 200)
+
+	assert.False(t, client.Bool(context.Background(), "new-checkout", false), "the contexts without attribute are not rolled out")
+}
+
+func TestClient_Rules(t *testing.T) {
+	client := featureflag.New(featureflag.Static(map[string]featureflag.Flag{
+		"checkout-theme": {
+			Enabled:    true,
+			Value:      "blue",
+			Percentage: pointer.ToPointer(0.0),
+			Rules: []featureflag.Rule{
+				{Attribute: "country", Values: []string{"TH", "VN"}, Value: "green"},
+				{Attribute: "plan", Values: []string{"premium"}},
+			},
+		},
+	}), featureflag.WithAttributeExtractor(func(ctx context.Context) featureflag.Attributes {
+		return featureflag.Attributes{"country": "TH", "plan": "premium"}
+	}))
+
+	ctx := context.Background()
+	assert.Equal(t, "green", client.String(ctx, "checkout-theme", "red"), "the first rule matching is served")
+
+	ctx = featureflag.NewContext(ctx, featureflag.Attributes{"country": "US"})
+	assert.Equal(t, featureflag.Attributes{"country": "US"}, featureflag.AttributesFromContext(ctx))
+	assert.Equal(t, "blue", client.String(ctx, "checkout-theme", "red"), "the rule without value serves the value of the flag")
+
+	ctx = featureflag.NewContext(ctx, featureflag.Attributes{"plan": "free"})
+	assert.Equal(t, featureflag.Attributes{"country": "US", "plan": "free"}, featureflag.AttributesFromContext(ctx))
+	assert.Equal(t, "red", client.String(ctx, "checkout-theme", "red"), "no rule matches, and the flag is not rolled out")
+}
+
+func TestClient_ProviderError(t *testing.T) {
+	var handled error
+	calls := 0
+	failing := featureflag.ProviderFunc(func(ctx context.Context, key string) (featureflag.Flag, error) {
+		calls++
+		if key == "missing" {
+			return featureflag.Flag{}, featureflag.ErrFlagNotFound
+		}
+		return featureflag.Flag{}, errors.New("connection refused")
+	})
+	client := featureflag.New(failing, featureflag.WithErrorHandler(func(ctx context.Context, key string, err error) {
+		handled = err
+	}))
+
+	assert.True(t, client.Bool(context.Background(), "missing", true))
+	assert.NoError(t, handled, "the flags not found are not errors")
+	assert.True(t, client.Bool(context.Background(), "new-checkout", true))
+	assert.EqualError(t, handled, "connection refused")
+	assert.Equal(t, 2, calls)
+}
+
+func TestClient_Cache(t *testing.T) {
+	calls := 0
+	provider := featureflag.ProviderFunc(func(ctx context.Context, key string) (featureflag.Flag, error) {
+		calls++
+		return featureflag.Flag{Enabled: true, Value: true}, nil
+	})
+	client := featureflag.New(provider, featureflag.WithCache(localcache.New[featureflag.Flag]()))
+
+	for range 3 {
+		assert.True(t, client.Bool(context.Background(), "new-checkout", false))
+	}
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,176 @@
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Provider returns the definitions of the flags, e.g., from the environment, a file, or a remote system. Wrap the
+remote providers with WithCache so that the flags are not requested on every evaluation.
+
+Example usage:
+
+	type remoteProvider struct{ client *flagsapi.Client }
+
+	func (p *remoteProvider) Flag(ctx context.Context, key string) (featureflag.Flag, error) {
+		flag, err := p.client.GetFlag(ctx, key)
+		if errors.Is(err, flagsapi.ErrNotFound) {
+			return featureflag.Flag{}, featureflag.ErrFlagNotFound
+		}
+		...
+	}
+*/
+type Provider interface {
+	// Flag returns the flag key. It returns ErrFlagNotFound if the flag is not defined.
+	Flag(ctx context.Context, key string) (Flag, error)
+}
+
+// ProviderFunc is a function implementing Provider.
+type ProviderFunc func(ctx context.Context, key string) (Flag, error)
+
+// Flag calls f(ctx, key).
+func (f ProviderFunc) Flag(ctx context.Context, key string) (Flag, error) {
+	return f(ctx, key)
+}
+
+// Static returns a Provider of flags, e.g., in tests.
+func Static(flags map[string]Flag) Provider {
+	return ProviderFunc(func(_ context.Context, key string) (Flag, error) {
+		flag, ok := flags[key]
+		if !ok {
+			return Flag{}, ErrFlagNotFound
+		}
+		return flag, nil
+	})
+}
+
+// Chain returns a Provider of the flags of the first of providers defining them, e.g., the environment overriding a
+// file. The errors other than ErrFlagNotFound are returned.
+func Chain(providers ...Provider) Provider {
+	return ProviderFunc(func(ctx context.Context, key string) (Flag, error) {
+		for _, provider := range providers {
+			flag, err := provider.Flag(ctx, key)
+			if !errors.Is(err, ErrFlagNotFound) {
+				return flag, err
+			}
+		}
+		return Flag{}, ErrFlagNotFound
+	})
+}
+
+/*
+EnvProvider is a Provider of the flags set in environment variables: the flag "new-checkout" is the variable
+<prefix>NEW_CHECKOUT. The values are:
+  - "25%": the flag is true for 25% of the contexts, bucketed by DefaultBucketAttribute;
+  - "off": the flag is disabled;
+  - anything else: the value of the flag, e.g., "true" or "500", converted by the typed evaluations.
+
+Example usage:
+
+	// FEATURE_NEW_CHECKOUT=true
+	client := featureflag.New(featureflag.NewEnvProvider("FEATURE_"))
+	client.Bool(ctx, "new-checkout", false) // true
+*/
+type EnvProvider struct {
+	prefix string
+}
+
+// NewEnvProvider creates an EnvProvider of the environment variables prefixed with prefix.
+func NewEnvProvider(prefix string) *EnvProvider {
+	return &EnvProvider{prefix: prefix}
+}
+
+// Flag returns the flag key of its environment variable.
+func (p *EnvProvider) Flag(_ context.Context, key string) (Flag, error) {
+	name := p.prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return Flag{}, ErrFlagNotFound
+	}
+	value = strings.TrimSpace(value)
+	if value == "off" {
+		return Flag{Enabled: false}, nil
+	}
+	if percent, found := strings.CutSuffix(value, "%"); found {
+		percentage, err := strconv.ParseFloat(percent, 64)
+		if err != nil {
+			return Flag{}, fmt.Errorf("featureflag: invalid percentage of %s: %w", name, err)
+		}
+		return Flag{Enabled: true, Value: true, Percentage: &percentage}, nil
+	}
+	return Flag{Enabled: true, Value: value}, nil
+}
+
+/*
+FileProvider is a Provider of the flags defined in a YAML or JSON file, by key. The file is read on creation and on
+Reload.
+
+Example file:
+
+	new-checkout:
+	  enabled: true
+	  value: true
+	  percentage: 20
+	export-row-limit:
+	  enabled: true
+	  value: 50000
+
+Example usage:
+
+	provider, err := featureflag.NewFileProvider("config/flags.yaml")
+	if err != nil {
+		return err
+	}
+	client := featureflag.New(provider)
+*/
+type FileProvider struct {
+	path string
+
+	mu    sync.RWMutex
+	flags map[string]Flag
+}
+
+// NewFileProvider creates a FileProvider of the flags of the file path.
+func NewFileProvider(path string) (*FileProvider, error) {
+	p := &FileProvider{path: path}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload reads the file again, e.g., on SIGHUP or from a cron job. The flags are kept if the file cannot be read.
+func (p *FileProvider) Reload() error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("featureflag: reading %s: %w", p.path, err)
+	}
+	// JSON is valid YAML.
+	var flags map[string]Flag
+	if err := yaml.Unmarshal(data, &flags); err != nil {
+		return fmt.Errorf("featureflag: parsing %s: %w", p.path, err)
+	}
+	p.mu.Lock()
+	p.flags = flags
+	p.mu.Unlock()
+	return nil
+}
+
+// Flag returns the flag key of the file.
+func (p *FileProvider) Flag(_ context.Context, key string) (Flag, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	flag, ok := p.flags[key]
+	if !ok {
+		return Flag{}, ErrFlagNotFound
+	}
+	return flag, nil
+}
@@ -0,0 +1,92 @@
+package featureflag_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/featureflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("FEATURE_NEW_CHECKOUT", "true")
+	t.Setenv("FEATURE_EXPORT_ROW_LIMIT", "500")
+	t.Setenv("FEATURE_DARK_MODE", "off")
+	t.Setenv("FEATURE_BETA", "50%")
+	t.Setenv("FEATURE_BROKEN", "half%")
+	provider := featureflag.NewEnvProvider("FEATURE_")
+	client := featureflag.New(provider)
+	ctx := context.Background()
+
+	assert.True(t, client.Bool(ctx, "new-checkout", false))
+	assert.Equal(t, 500, client.Int(ctx, "export.row-limit", 0))
+	assert.True(t, client.Bool(ctx, "dark-mode", true), "a disabled flag serves the default value")
+
+	flag, err := provider.Flag(ctx, "beta")
+	require.NoError(t, err)
+	require.NotNil(t, flag.Percentage)
+	assert.Equal(t, 50.0, *flag.Percentage)
+	assert.Equal(t, true, flag.Value)
+
+	_, err = provider.Flag(ctx, "broken")
+	assert.Error(t, err)
+	_, err = provider.Flag(ctx, "missing")
+	assert.ErrorIs(t, err, featureflag.ErrFlagNotFound)
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+new-checkout:
+  enabled: true
+  value: true
+  rules:
+    - attribute: country
+      values: [TH]
+      value: false
+export-row-limit:
+  enabled: true
+  value: 50000
+`), 0o600))
+	provider, err := featureflag.NewFileProvider(path)
+	require.NoError(t, err)
+	client := featureflag.New(provider)
+	ctx := context.Background()
+
+	assert.True(t, client.Bool(ctx, "new-checkout", false))
+	assert.False(t, client.Bool(featureflag.NewContext(ctx, featureflag.Attributes{"country": "TH"}), "new-checkout", true))
+	assert.Equal(t, 50000, client.Int(ctx, "export-row-limit", 0))
+
+	// JSON is accepted, and the flags are kept when the file is invalid.
+	require.NoError(t, os.WriteFile(path, []byte(`{"new-checkout": {"enabled": false}}`), 0o600))
+	require.NoError(t, provider.Reload())
+	assert.False(t, client.Bool(ctx, "new-checkout", false))
+	assert.Equal(t, 7, client.Int(ctx, "export-row-limit", 7))
+
+	require.NoError(t, os.WriteFile(path, []byte(`new-checkout: [`), 0o600))
+	assert.Error(t, provider.Reload())
+	_, err = provider.Flag(ctx, "new-checkout")
+	assert.NoError(t, err)
+
+	_, err = featureflag.NewFileProvider(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestChain(t *testing.T) {
+	t.Setenv("FEATURE_NEW_CHECKOUT", "false")
+	client := featureflag.New(featureflag.Chain(
+		featureflag.NewEnvProvider("FEATURE_"),
+		featureflag.Static(map[string]featureflag.Flag{
+			"new-checkout": {Enabled: true, Value: true},
+			"dark-mode":    {Enabled: true, Value: true},
+		}),
+	))
+	ctx := context.Background()
+
+	assert.False(t, client.Bool(ctx, "new-checkout", true), "the first provider defining the flag wins")
+	assert.True(t, client.Bool(ctx, "dark-mode", false))
+	assert.Equal(t, "x", client.String(ctx, "missing", "x"))
+}
@@ -0,0 +1,239 @@
+package fsm
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+)
+
+// Codes of the errors returned by the package.
+const (
+	CodeIllegalTransition = "fsm_illegal_transition"
+	CodeUnknownEvent      = "fsm_unknown_event"
+	CodeGuardRejected     = "fsm_guard_rejected"
+	CodeUnknownState      = "fsm_unknown_state"
+)
+
+var (
+	// ErrIllegalTransition is returned when an event is fired in a state it has no transition from.
+	ErrIllegalTransition = errors.NewCodedError(errors.KindConflict, CodeIllegalTransition, "illegal state transition")
+	// ErrUnknownEvent is returned when an event is not defined by the machine.
+	ErrUnknownEvent = errors.InvalidArgument(CodeUnknownEvent, "unknown event")
+	// ErrGuardRejected wraps the errors of the guards rejecting a transition.
+	ErrGuardRejected = errors.NewCodedError(errors.KindConflict, CodeGuardRejected, "state transition rejected")
+	// ErrUnknownState is returned when restoring a state which is not defined by the machine.
+	ErrUnknownState = errors.InvalidArgument(CodeUnknownState, "unknown state")
+	// ErrInvalidDefinition is wrapped by the errors of New for the invalid definitions.
+	ErrInvalidDefinition = stderrors.New("fsm: invalid definition")
+)
+
+// Guard allows a transition of entity, or returns the reason why it is not allowed, e.g., an order which is not
+// paid cannot be shipped.
+type Guard[T any] func(ctx context.Context, entity T) error
+
+// Transition is the transition to the state To when Event is fired in one of the states From, if Guard allows it.
+type Transition[S comparable, T any] struct {
+	// Event is the name of the event, e.g., "ship". An event may have transitions from different states.
+	Event string
+	// From are the states the transition starts from.
+	From []S
+	// To is the state the transition ends in.
+	To S
+	// Guard allows the transition, or rejects it with its error. It is optional.
+	Guard Guard[T]
+}
+
+// options holds configuration options for the machines.
+type options[S comparable, T any] struct {
+	states []S          // states are the states without transitions, e.g., the final ones.
+	before []Hook[S, T] // before are the hooks called before the state changes.
+	after  []Hook[S, T] // after are the hooks called after the state changed.
+}
+
+// Option specifies machine configuration options.
+type Option[S comparable, T any] func(*options[S, T])
+
+// WithStates declares states that no transition starts from or ends in, so that they can be restored, e.g., the
+// legacy states of the entities stored.
+func WithStates[S comparable, T any](states ...S) Option[S, T] {
+	return func(opts *options[S, T]) {
+		opts.states = append(opts.states, states...)
+	}
+}
+
+// WithBeforeTransition adds a hook called before the state changes, after the guard allowed the transition. Its
+// error aborts the transition, and is returned by Fire.
+func WithBeforeTransition[S comparable, T any](hook Hook[S, T]) Option[S, T] {
+	return func(opts *options[S, T]) {
+		if hook != nil {
+			opts.before = append(opts.before, hook)
+		}
+	}
+}
+
+// WithAfterTransition adds a hook called after the state changed, e.g., PublishTransitions or LogTransitions. The
+// hooks are all called, and their errors are returned by Fire, while the state stays changed.
+func WithAfterTransition[S comparable, T any](hook Hook[S, T]) Option[S, T] {
+	return func(opts *options[S, T]) {
+		if hook != nil {
+			opts.after = append(opts.after, hook)
+		}
+	}
+}
+
+// edge is a transition from a state.
+type edge[S comparable, T any] struct {
+	to    S
+	guard Guard[T]
+}
+
+/*
+Machine is the definition of the states of a type of entity, e.g., the orders, and of the transitions between
+them, triggered by events. A machine is immutable and safe for concurrent use; the state of an entity is held by an
+Instance, created with Start or Restore.
+
+Example usage:
+
+	type OrderStatus string
+
+	const (
+		Pending   OrderStatus = "pending"
+		Paid      OrderStatus = "paid"
+		Shipped   OrderStatus = "shipped"
+		Cancelled OrderStatus = "cancelled"
+	)
+
+	orders, err := fsm.New("order", Pending, []fsm.Transition[OrderStatus, *Order]{
+		{Event: "pay", From: []OrderStatus{Pending}, To: Paid},
+		{Event: "ship", From: []OrderStatus{Paid}, To: Shipped, Guard: func(ctx context.Context, o *Order) error {
+			if o.Address == "" {
+				return errors.New("the order has no shipping address")
+			}
+			return nil
+		}},
+		{Event: "cancel", From: []OrderStatus{Pending, Paid}, To: Cancelled},
+	},
+		fsm.WithAfterTransition(fsm.LogTransitions[OrderStatus, *Order](log)),
+	)
+	if err != nil {
+		// Handle error
+	}
+
+	order, err := orders.Restore(o, o.Status)
+	if err := order.Fire(ctx, "ship"); errors.Is(err, fsm.ErrIllegalTransition) {
+		// Handle error
+	}
+	o.Status = order.State()
+*/
+type Machine[S comparable, T any] struct {
+	name    string
+	initial S
+	states  map[S]bool
+	edges   map[string]map[S]edge[S, T] // edges are the transitions of the events, by state.
+	events  []string                    // events are the events, in the order of their first transition.
+	opts    options[S, T]
+}
+
+// New creates a machine named name, e.g., the type of its entities, starting in initial. It returns an error
+// wrapping ErrInvalidDefinition if a transition has no event or no states, or if an event has more than one
+// transition from a state.
+func New[S comparable, T any](name string, initial S, transitions []Transition[S, T], opts ...Option[S, T]) (*Machine[S, T], error) {
+	m := &Machine[S, T]{
+		name:    name,
+		initial: initial,
+		states:  map[S]bool{initial: true},
+		edges:   map[string]map[S]edge[S, T]{},
+	}
+	for _, opt := range opts {
+		opt(&m.opts)
+	}
+	for _, state := range m.opts.states {
+		m.states[state] = true
+	}
+	for _, t := range transitions {
+		if t.Event == "" {
+			return nil, fmt.Errorf("%w: a transition to %v has no event", ErrInvalidDefinition, t.To)
+		}
+		if len(t.From) == 0 {
+			return nil, fmt.Errorf("%w: the transition %q has no state to start from", ErrInvalidDefinition, t.Event)
+		}
+		edges, ok := m.edges[t.Event]
+		if !ok {
+			edges = map[S]edge[S, T]{}
+			m.edges[t.Event] = edges
+			m.events = append(m.events, t.Event)
+		}
+		for _, from := range t.From {
+			if _, ok := edges[from]; ok {
+				return nil, fmt.Errorf("%w: the event %q has more than one transition from %v", ErrInvalidDefinition, t.Event, from)
+			}
+			edges[from] = edge[S, T]{to: t.To, guard: t.Guard}
+			m.states[from] = true
+		}
+		m.states[t.To] = true
+	}
+	return m, nil
+}
+
+// Name returns the name of the machine.
+func (m *Machine[S, T]) Name() string {
+	return m.name
+}
+
+// Initial returns the state the instances start in.
+func (m *Machine[S, T]) Initial() S {
+	return m.initial
+}
+
+// Start returns an instance of entity in the initial state, e.g., for a new entity.
+func (m *Machine[S, T]) Start(entity T) *Instance[S, T] {
+	return &Instance[S, T]{machine: m, entity: entity, state: m.initial}
+}
+
+// Restore returns an instance of entity in state, e.g., the state of an entity stored, or ErrUnknownState if the
+// machine does not define it.
+func (m *Machine[S, T]) Restore(entity T, state S) (*Instance[S, T], error) {
+	return m.RestoreSnapshot(entity, Snapshot[S]{Machine: m.name, State: state})
+}
+
+// RestoreSnapshot returns an instance of entity in the state of snapshot, with its version, see Instance.Snapshot.
+// It returns ErrUnknownState if the snapshot is not one of the machine, or its state is not defined.
+func (m *Machine[S, T]) RestoreSnapshot(entity T, snapshot Snapshot[S]) (*Instance[S, T], error) {
+	if snapshot.Machine != m.name {
+		return nil, ErrUnknownState.WithFields(map[string]interface{}{"machine": m.name, "snapshot_machine": snapshot.Machine})
+	}
+	if !m.states[snapshot.State] {
+		return nil, ErrUnknownState.WithFields(map[string]interface{}{"machine": m.name, "state": fmt.Sprint(snapshot.State)})
+	}
+	return &Instance[S, T]{machine: m, entity: entity, state: snapshot.State, version: snapshot.Version}, nil
+}
+
+// Events returns the events fired in state, whatever their guards, in the order of their definition.
+func (m *Machine[S, T]) Events(state S) []string {
+	var events []string
+	for _, event := range m.events {
+		if _, ok := m.edges[event][state]; ok {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// transition returns the transition of event from state, or ErrUnknownEvent or ErrIllegalTransition.
+func (m *Machine[S, T]) transition(event string, state S) (edge[S, T], error) {
+	edges, ok := m.edges[event]
+	if !ok {
+		return edge[S, T]{}, ErrUnknownEvent.WithFields(map[string]interface{}{"machine": m.name, "event": event})
+	}
+	e, ok := edges[state]
+	if !ok {
+		return edge[S, T]{}, ErrIllegalTransition.WithFields(map[string]interface{}{
+			"machine": m.name,
+			"event":   event,
+			"state":   fmt.Sprint(state),
+		})
+	}
+	return e, nil
+}
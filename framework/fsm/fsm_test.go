@@ -0,0 +1,237 @@
+package fsm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/fsm"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+type OrderStatus string
+
+const (
+	Pending   OrderStatus = "pending"
+	Paid      OrderStatus = "paid"
+	Shipped   OrderStatus = "shipped"
+	Cancelled OrderStatus = "cancelled"
+	Archived  OrderStatus = "archived"
+)
+
+type Order struct {
+	ID      string
+	Address string
+}
+
+var errNoAddress = errors.New("the order has no shipping address")
+
+func transitions() []fsm.Transition[OrderStatus, *Order] {
+	return []fsm.Transition[OrderStatus, *Order]{
+		{Event: "pay", From: []OrderStatus{Pending}, To: Paid},
+		{Event: "ship", From: []OrderStatus{Paid}, To: Shipped, Guard: func(ctx context.Context, o *Order) error {
+			if o.Address == "" {
+				return errNoAddress
+			}
+			return nil
+		}},
+		{Event: "cancel", From: []OrderStatus{Pending, Paid}, To: Cancelled},
+	}
+}
+
+func newMachine(t *testing.T, opts ...fsm.Option[OrderStatus, *Order]) *fsm.Machine[OrderStatus, *Order] {
+	t.Helper()
+	m, err := fsm.New("order", Pending, transitions(), opts...)
+	require.NoError(t, err)
+	return m
+}
+
+func TestNew(t *testing.T) {
+	m := newMachine(t)
+	assert.Equal(t, "order", m.Name())
+	assert.Equal(t, Pending, m.Initial())
+	assert.Equal(t, []string{"pay", "cancel"}, m.Events(Pending))
+	assert.Equal(t, []string{"ship", "cancel"}, m.Events(Paid))
+	assert.Empty(t, m.Events(Shipped))
+
+	invalid := [][]fsm.Transition[OrderStatus, *Order]{
+		{{From: []OrderStatus{Pending}, To: Paid}},
+		{{Event: "pay", To: Paid}},
+		{{Event: "pay", From: []OrderStatus{Pending}, To: Paid}, {Event: "pay", From: []OrderStatus{Pending}, To: Cancelled}},
+	}
+	for _, transitions := range invalid {
+		_, err := fsm.New("order", Pending, transitions)
+		assert.ErrorIs(t, err, fsm.ErrInvalidDefinition)
+	}
+}
+
+func TestInstance_Fire(t *testing.T) {
+	ctx := context.Background()
+	order := newMachine(t).Start(&Order{ID: "42"})
+	assert.Equal(t, Pending, order.State())
+	assert.Equal(t, []string{"pay", "cancel"}, order.Events())
+
+	require.NoError(t, order.Fire(ctx, "pay"))
+	assert.Equal(t, Paid, order.State())
+
+	t.Run("rejects the events without transition from the state", func(t *testing.T) {
+		err := order.Fire(ctx, "pay")
+		assert.ErrorIs(t, err, fsm.ErrIllegalTransition)
+		assert.Equal(t, domain_error.KindConflict, domain_error.KindOf(err))
+		var coded *domain_error.CodedError
+		require.True(t, errors.As(err, &coded))
+		assert.Equal(t, map[string]interface{}{"machine": "order", "event": "pay", "state": "paid"}, coded.Fields())
+		assert.Equal(t, Paid, order.State())
+	})
+
+	t.Run("rejects the unknown events", func(t *testing.T) {
+		err := order.Fire(ctx, "refund")
+		assert.ErrorIs(t, err, fsm.ErrUnknownEvent)
+		assert.Equal(t, domain_error.KindInvalidArgument, domain_error.KindOf(err))
+	})
+
+	t.Run("rejects the transitions not allowed by their guard", func(t *testing.T) {
+		err := order.Can(ctx, "ship")
+		assert.ErrorIs(t, err, fsm.ErrGuardRejected)
+		assert.ErrorIs(t, err, errNoAddress)
+		assert.ErrorIs(t, order.Fire(ctx, "ship"), errNoAddress)
+		assert.Equal(t, Paid, order.State())
+
+		order.Entity().Address = "1 Main Street"
+		require.NoError(t, order.Can(ctx, "ship"))
+		require.NoError(t, order.Fire(ctx, "ship"))
+		assert.Equal(t, Shipped, order.State())
+		assert.Empty(t, order.Events())
+	})
+}
+
+func TestInstance_Hooks(t *testing.T) {
+	ctx := context.Background()
+	var changes []fsm.Change[OrderStatus, *Order]
+	errBefore := errors.New("before failed")
+	errAfter := errors.New("after failed")
+	failBefore, failAfter := false, false
+	m := newMachine(t,
+		fsm.WithBeforeTransition(func(ctx context.Context, change fsm.Change[OrderStatus, *Order]) error {
+			if failBefore {
+				return errBefore
+			}
+			return nil
+		}),
+		fsm.WithAfterTransition(func(ctx context.Context, change fsm.Change[OrderStatus, *Order]) error {
+			changes = append(changes, change)
+			if failAfter {
+				return errAfter
+			}
+			return nil
+		}),
+	)
+	entity := &Order{ID: "42"}
+	order := m.Start(entity)
+
+	require.NoError(t, order.Fire(ctx, "pay"))
+	assert.Equal(t, []fsm.Change[OrderStatus, *Order]{
+		{Machine: "order", Event: "pay", From: Pending, To: Paid, Version: 1, Entity: entity},
+	}, changes)
+
+	failBefore = true
+	assert.ErrorIs(t, order.Fire(ctx, "cancel"), errBefore)
+	assert.Equal(t, Paid, order.State())
+	assert.Len(t, changes, 1)
+
+	failBefore, failAfter = false, true
+	assert.ErrorIs(t, order.Fire(ctx, "cancel"), errAfter)
+	assert.Equal(t, Cancelled, order.State(), "the state stays changed when the hooks after the transition fail")
+	assert.Equal(t, int64(2), order.Snapshot().Version)
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	m := newMachine(t, fsm.WithStates[OrderStatus, *Order](Archived))
+	order := m.Start(&Order{ID: "42"})
+	require.NoError(t, order.Fire(ctx, "pay"))
+
+	data, err := json.Marshal(order)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"machine":"order","state":"paid","version":1}`, string(data))
+
+	var snapshot fsm.Snapshot[OrderStatus]
+	require.NoError(t, json.Unmarshal(data, &snapshot))
+	restored, err := m.RestoreSnapshot(&Order{ID: "42"}, snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot, restored.Snapshot())
+	require.NoError(t, restored.Fire(ctx, "cancel"))
+	assert.Equal(t, fsm.Snapshot[OrderStatus]{Machine: "order", State: Cancelled, Version: 2}, restored.Snapshot())
+
+	archived, err := m.Restore(&Order{ID: "43"}, Archived)
+	require.NoError(t, err)
+	assert.Equal(t, Archived, archived.State())
+	assert.ErrorIs(t, archived.Fire(ctx, "pay"), fsm.ErrIllegalTransition)
+
+	_, err = m.Restore(&Order{ID: "44"}, OrderStatus("lost"))
+	assert.ErrorIs(t, err, fsm.ErrUnknownState)
+	_, err = m.RestoreSnapshot(&Order{ID: "44"}, fsm.Snapshot[OrderStatus]{Machine: "invoice", State: Paid})
+	assert.ErrorIs(t, err, fsm.ErrUnknownState)
+}
+
+func TestInstance_Concurrent(t *testing.T) {
+	m, err := fsm.New("counter", 0, []fsm.Transition[int, struct{}]{
+		{Event: "next", From: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, To: 0},
+	})
+	require.NoError(t, err)
+	counter := m.Start(struct{}{})
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, counter.Fire(context.Background(), "next"))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(20), counter.Snapshot().Version)
+}
+
+// publisher records the messages published.
+type publisher[T any] struct {
+	topic string
+	msgs  []event.Message[T]
+}
+
+func (p *publisher[T]) Publish(ctx context.Context, topic string, msgs ...event.Message[T]) error {
+	p.topic = topic
+	p.msgs = append(p.msgs, msgs...)
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	ctx := context.Background()
+	bus := &publisher[fsm.Transitioned[OrderStatus]]{}
+	log, logs := logger.NewTestLogger()
+	m := newMachine(t,
+		fsm.WithAfterTransition(fsm.PublishTransitions[OrderStatus](bus, "order.transitions", func(o *Order) string {
+			return o.ID
+		})),
+		fsm.WithAfterTransition(fsm.LogTransitions[OrderStatus, *Order](log)),
+	)
+	order := m.Start(&Order{ID: "42"})
+	require.NoError(t, order.Fire(ctx, "pay"))
+
+	assert.Equal(t, "order.transitions", bus.topic)
+	assert.Equal(t, []event.Message[fsm.Transitioned[OrderStatus]]{{
+		Key:     "42",
+		Payload: fsm.Transitioned[OrderStatus]{Machine: "order", Event: "pay", From: Pending, To: Paid, Version: 1},
+	}}, bus.msgs)
+	logs.AssertLogged(t, logger.INFO, "State transition",
+		logger.HasField("machine", "order"),
+		logger.HasField("from", "pending"),
+		logger.HasField("to", "paid"),
+	)
+}
@@ -0,0 +1,89 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kittipat1413/go-common/framework/event"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// Change is a transition of an instance, passed to the hooks.
+type Change[S comparable, T any] struct {
+	// Machine is the name of the machine.
+	Machine string
+	// Event is the event fired.
+	Event string
+	// From is the state before the transition.
+	From S
+	// To is the state after the transition.
+	To S
+	// Version is the version of the instance after the transition.
+	Version int64
+	// Entity is the entity of the instance.
+	Entity T
+}
+
+// Hook is called with the transitions of the instances, see WithBeforeTransition and WithAfterTransition.
+type Hook[S comparable, T any] func(ctx context.Context, change Change[S, T]) error
+
+// Transitioned is the payload of the events published by PublishTransitions.
+type Transitioned[S comparable] struct {
+	Machine string `json:"machine"`
+	Event   string `json:"event"`
+	From    S      `json:"from"`
+	To      S      `json:"to"`
+	Version int64  `json:"version"`
+}
+
+/*
+PublishTransitions returns a hook publishing the transitions on topic with publisher, keyed by the result of key,
+e.g., the ID of the entity, so that the transitions of an entity are handled in order. key is optional.
+
+Published after the transition, the events are lost if the publisher fails; to publish them in the transaction
+storing the entity, write them to an outbox in a hook instead, see the outbox package of framework/event.
+
+Example usage:
+
+	orders, err := fsm.New("order", Pending, transitions,
+		fsm.WithAfterTransition(fsm.PublishTransitions[OrderStatus](bus, "order.transitions", func(o *Order) string {
+			return o.ID
+		})),
+	)
+*/
+func PublishTransitions[S comparable, T any](publisher event.Publisher[Transitioned[S]], topic string, key func(entity T) string) Hook[S, T] {
+	return func(ctx context.Context, change Change[S, T]) error {
+		msg := event.Message[Transitioned[S]]{
+			Payload: Transitioned[S]{
+				Machine: change.Machine,
+				Event:   change.Event,
+				From:    change.From,
+				To:      change.To,
+				Version: change.Version,
+			},
+		}
+		if key != nil {
+			msg.Key = key(change.Entity)
+		}
+		return publisher.Publish(ctx, topic, msg)
+	}
+}
+
+// LogTransitions returns a hook logging the transitions at info level with l, or with the logger of the context if
+// l is nil, see logger.FromContext.
+func LogTransitions[S comparable, T any](l logger.Logger) Hook[S, T] {
+	return func(ctx context.Context, change Change[S, T]) error {
+		log := l
+		if log == nil {
+			log = logger.FromContext(ctx)
+		}
+		log.Info(ctx, "State transition", logger.Fields{
+			"machine": change.Machine,
+			"event":   change.Event,
+			"from":    fmt.Sprint(change.From),
+			"to":      fmt.Sprint(change.To),
+			"version": change.Version,
+		})
+		return nil
+	}
+}
@@ -0,0 +1,140 @@
+package fsm
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"sync"
+)
+
+// Snapshot is the serializable state of an Instance, e.g., to store it with its entity, or in a JSON column:
+//
+//	{"machine":"order","state":"paid","version":2}
+type Snapshot[S comparable] struct {
+	// Machine is the name of the machine of the instance.
+	Machine string `json:"machine"`
+	// State is the current state of the instance.
+	State S `json:"state"`
+	// Version is the number of transitions of the instance, e.g., for the optimistic locking of its entity.
+	Version int64 `json:"version"`
+}
+
+/*
+Instance is the state of an entity in a Machine. Fire changes it with the transitions of the machine; the instances
+are safe for concurrent use, and their transitions, including their guards and hooks, run one at a time.
+
+Example usage:
+
+	order := orders.Start(o)
+	if err := order.Fire(ctx, "pay"); err != nil {
+		// Handle error
+	}
+	data, err := json.Marshal(order) // {"machine":"order","state":"paid","version":1}
+*/
+type Instance[S comparable, T any] struct {
+	machine *Machine[S, T]
+	entity  T
+
+	mu      sync.Mutex
+	state   S
+	version int64
+}
+
+// State returns the current state of the instance.
+func (i *Instance[S, T]) State() S {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.state
+}
+
+// Entity returns the entity of the instance.
+func (i *Instance[S, T]) Entity() T {
+	return i.entity
+}
+
+// Snapshot returns the serializable state of the instance, restored with Machine.RestoreSnapshot.
+func (i *Instance[S, T]) Snapshot() Snapshot[S] {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return Snapshot[S]{Machine: i.machine.name, State: i.state, Version: i.version}
+}
+
+// MarshalJSON implements the json.Marshaler interface, marshaling the Snapshot of the instance.
+func (i *Instance[S, T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.Snapshot())
+}
+
+// Events returns the events fired in the current state, whatever their guards, see Machine.Events.
+func (i *Instance[S, T]) Events() []string {
+	return i.machine.Events(i.State())
+}
+
+// Can returns nil if event can be fired in the current state, or the error Fire would return before calling the
+// hooks: ErrUnknownEvent, ErrIllegalTransition or ErrGuardRejected.
+func (i *Instance[S, T]) Can(ctx context.Context, event string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	_, err := i.check(ctx, event)
+	return err
+}
+
+/*
+Fire fires event, changing the state of the instance with the transition of event from the current state:
+  - it returns ErrUnknownEvent if the machine does not define event, and ErrIllegalTransition if event has no
+    transition from the current state;
+  - the guard of the transition is called, and its error is returned wrapped in ErrGuardRejected;
+  - the hooks of WithBeforeTransition are called, and the first error is returned as is;
+  - the state changes, and the hooks of WithAfterTransition are called, whose errors are returned joined.
+
+The state is unchanged if an error is returned before the hooks of WithAfterTransition are called. The guards and
+the hooks must not call the methods of the instance.
+*/
+func (i *Instance[S, T]) Fire(ctx context.Context, event string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	e, err := i.check(ctx, event)
+	if err != nil {
+		return err
+	}
+	change := Change[S, T]{
+		Machine: i.machine.name,
+		Event:   event,
+		From:    i.state,
+		To:      e.to,
+		Version: i.version + 1,
+		Entity:  i.entity,
+	}
+	for _, hook := range i.machine.opts.before {
+		if err := hook(ctx, change); err != nil {
+			return err
+		}
+	}
+	i.state = change.To
+	i.version = change.Version
+	var errs []error
+	for _, hook := range i.machine.opts.after {
+		if err := hook(ctx, change); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// check returns the transition of event from the current state if its guard allows it.
+func (i *Instance[S, T]) check(ctx context.Context, event string) (edge[S, T], error) {
+	e, err := i.machine.transition(event, i.state)
+	if err != nil {
+		return edge[S, T]{}, err
+	}
+	if e.guard != nil {
+		if err := e.guard(ctx, i.entity); err != nil {
+			return edge[S, T]{}, ErrGuardRejected.WithFields(map[string]interface{}{
+				"machine": i.machine.name,
+				"event":   event,
+				"state":   fmt.Sprint(i.state),
+			}).Wrap(err)
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/errors/grpcstatus"
+)
+
+// CodeCircuitOpen is the code of the errors of the calls failed fast by an open circuit breaker, the one of the
+// httpclient package.
+const CodeCircuitOpen = "circuit_open"
+
+// ErrCircuitOpen is returned, with the "target" field, when the circuit breaker of the target of a call is open, or
+// half-open with too many calls. It is an Unavailable error, not retried by the client. It matches the
+// ErrCircuitOpen of the httpclient package with errors.Is, since they have the same code.
+var ErrCircuitOpen = errors.Unavailable(CodeCircuitOpen, "circuit breaker is open")
+
+// Failure reports whether a call failing with err tells that its target is failing, and counts as a failure for the
+// circuit breakers: its gRPC code is codes.Unknown, codes.DeadlineExceeded, codes.Internal, codes.Unavailable or
+// codes.DataLoss. The errors of the calls, e.g., codes.InvalidArgument, and the calls canceled by the caller, are
+// not failures.
+func Failure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch grpcstatus.ToStatus(err).Code() {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+// breakers fails fast the calls to the failing targets, with a circuit breaker per target.
+type breakers struct {
+	settings gobreaker.Settings
+	mu       sync.Mutex
+	breakers map[string]*gobreaker.CircuitBreaker
+}
+
+func newBreakers(settings gobreaker.Settings) *breakers {
+	if settings.IsSuccessful == nil {
+		settings.IsSuccessful = func(err error) bool {
+			return !Failure(err)
+		}
+	}
+	return &breakers{settings: settings, breakers: make(map[string]*gobreaker.CircuitBreaker)}
+}
+
+// invoke makes the call with invoker through the circuit breaker of the target of cc.
+func (b *breakers) invoke(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	target := cc.Target()
+	_, err := b.breaker(target).Execute(func() (interface{}, error) {
+		return nil, invoker(ctx, method, req, reply, cc, opts...)
+	})
+	if stderrors.Is(err, gobreaker.ErrOpenState) || stderrors.Is(err, gobreaker.ErrTooManyRequests) {
+		return ErrCircuitOpen.WithField("target", target).Wrap(err)
+	}
+	return err
+}
+
+// breaker returns the circuit breaker of target, created on first use.
+func (b *breakers) breaker(target string) *gobreaker.CircuitBreaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cb, ok := b.breakers[target]
+	if !ok {
+		settings := b.settings
+		settings.Name = target
+		cb = gobreaker.NewCircuitBreaker(settings)
+		b.breakers[target] = cb
+	}
+	return cb
+}
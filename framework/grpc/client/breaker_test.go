@@ -0,0 +1,65 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/grpc/client"
+	"github.com/kittipat1413/go-common/framework/httpclient"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+func TestFailure(t *testing.T) {
+	assert.False(t, client.Failure(nil))
+	assert.True(t, client.Failure(status.Error(codes.Unavailable, "connection refused")))
+	assert.True(t, client.Failure(status.Error(codes.Internal, "boom")))
+	assert.True(t, client.Failure(status.Error(codes.DeadlineExceeded, "deadline exceeded")))
+	assert.False(t, client.Failure(status.Error(codes.InvalidArgument, "invalid")))
+	assert.False(t, client.Failure(status.Error(codes.Canceled, "canceled")))
+	assert.False(t, client.Failure(status.Error(codes.ResourceExhausted, "rate limited")))
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	server, dial := newClient(t)
+	health := dial(
+		client.WithCircuitBreaker(gobreaker.Settings{Timeout: time.Minute}),
+		client.WithRetry(retry.WithBackoff(retry.Constant(time.Millisecond)), retry.WithMaxAttempts(10)),
+	)
+	ctx := context.Background()
+
+	// The errors of the calls are not failures.
+	server.script(codes.InvalidArgument, codes.InvalidArgument, codes.InvalidArgument, codes.InvalidArgument,
+		codes.InvalidArgument, codes.InvalidArgument, codes.InvalidArgument)
+	for i := 0; i < 7; i++ {
+		_, err := health.Check(ctx, &healthpb.HealthCheckRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	server.script(codes.Unavailable, codes.Unavailable, codes.Unavailable, codes.Unavailable, codes.Unavailable,
+		codes.Unavailable)
+	_, err := health.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, client.ErrCircuitOpen, "the retries stop when the circuit breaker opens")
+	assert.ErrorIs(t, err, httpclient.ErrCircuitOpen)
+	assert.Equal(t, domain_error.KindUnavailable, domain_error.KindOf(err))
+	var coded *domain_error.CodedError
+	require.True(t, errors.As(err, &coded))
+	assert.Equal(t, "passthrough:///bufnet", coded.Fields()["target"])
+	attempts, _ := server.called()
+	assert.Equal(t, 6, attempts)
+
+	_, err = health.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.ErrorIs(t, err, client.ErrCircuitOpen)
+	attempts, _ = server.called()
+	assert.Equal(t, 6, attempts, "the calls are failed fast")
+}
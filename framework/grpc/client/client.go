@@ -0,0 +1,312 @@
+package client
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+const (
+	// DefaultTimeout is the deadline of the unary calls made without one, retries included, unless set with
+	// WithTimeout.
+	DefaultTimeout = 30 * time.Second
+	// DefaultKeepaliveTime is the time without activity after which the connections are pinged, unless set with
+	// WithKeepalive. It is the minimum time between pings allowed by the servers by default, so that they do not
+	// close the connections pinging too often.
+	DefaultKeepaliveTime = 5 * time.Minute
+	// DefaultKeepaliveTimeout is the time to wait for the answer of a ping before closing the connection, unless set
+	// with WithKeepalive.
+	DefaultKeepaliveTimeout = 20 * time.Second
+)
+
+// options holds configuration options for the clients.
+type options struct {
+	timeout        time.Duration
+	methodTimeouts map[string]time.Duration
+	retry          bool
+	retryOptions   []retry.Option
+	methodRetries  map[string][]retry.Option
+	breaker        *gobreaker.Settings
+	logging        bool
+	logger         logger.Logger
+	keepalive      keepalive.ClientParameters
+	credentials    credentials.TransportCredentials
+	dialOptions    []grpc.DialOption
+}
+
+// Option specifies client configuration options.
+type Option func(*options)
+
+// WithTimeout sets the deadline of the unary calls made without one, retries included. It defaults to
+// DefaultTimeout. The streams have no default deadline, since they may last as long as the connection, see
+// WithMethodTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.timeout = d
+		}
+	}
+}
+
+// WithMethodTimeout sets the deadline of the calls to fullMethod made without one, e.g.,
+// "/payment.Payments/Charge", instead of the one of WithTimeout. It applies to the streams too.
+func WithMethodTimeout(fullMethod string, d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.methodTimeouts[fullMethod] = d
+		}
+	}
+}
+
+// WithRetry retries the unary calls failing with a retryable error, see Retryable, with the retry options opts,
+// e.g., retry.WithMaxAttempts and retry.WithBackoff. The calls are not retried by default.
+func WithRetry(opts ...retry.Option) Option {
+	return func(o *options) {
+		o.retry = true
+		o.retryOptions = append(o.retryOptions, opts...)
+	}
+}
+
+// WithMethodRetry sets the retry policy of the calls to fullMethod, instead of the one of WithRetry, e.g., more
+// attempts for an idempotent read, or retry.WithMaxAttempts(1) not to retry a call which is not idempotent.
+func WithMethodRetry(fullMethod string, opts ...retry.Option) Option {
+	return func(o *options) {
+		o.methodRetries[fullMethod] = append(o.methodRetries[fullMethod], opts...)
+	}
+}
+
+// WithCircuitBreaker fails fast the unary calls to the targets failing too often, with a circuit breaker per target
+// created with settings, named after the target. The errors of the server, e.g., codes.Unavailable, are failures,
+// see Failure. The zero settings trip the circuit breakers after more than 5 consecutive failures.
+func WithCircuitBreaker(settings gobreaker.Settings) Option {
+	return func(opts *options) {
+		opts.breaker = &settings
+	}
+}
+
+// WithLogger logs every attempt of the calls with l, or with the logger of the call context if l is nil, see
+// logger.FromContext. The calls are not logged by default.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		opts.logging = true
+		opts.logger = l
+	}
+}
+
+// WithKeepalive sets the keepalive parameters of the connections. They default to DefaultKeepaliveTime and
+// DefaultKeepaliveTimeout, without pings while there are no active calls. Set a shorter time only if the servers
+// allow it with their keepalive.EnforcementPolicy.
+func WithKeepalive(params keepalive.ClientParameters) Option {
+	return func(opts *options) {
+		opts.keepalive = params
+	}
+}
+
+// WithTransportCredentials sets the credentials of the connections, e.g., credentials.NewTLS. They default to
+// insecure credentials, for the calls within a trusted network or a service mesh.
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return func(opts *options) {
+		if creds != nil {
+			opts.credentials = creds
+		}
+	}
+}
+
+// WithDialOptions adds the dial options opts, e.g., grpc.WithChainUnaryInterceptor for the interceptors of the
+// service, which run within the interceptors of the client.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+// newOptions returns the options set with opts and the defaults.
+func newOptions(opts []Option) *options {
+	o := &options{
+		timeout:        DefaultTimeout,
+		methodTimeouts: make(map[string]time.Duration),
+		methodRetries:  make(map[string][]retry.Option),
+		keepalive:      keepalive.ClientParameters{Time: DefaultKeepaliveTime, Timeout: DefaultKeepaliveTimeout},
+		credentials:    insecure.NewCredentials(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+/*
+Dial creates a client connection to target with the DialOptions of opts, see grpc.NewClient. Like grpc.NewClient,
+it does not connect: the connection is established by the first call.
+
+Example usage:
+
+	conn, err := client.Dial("dns:///payments.internal:443",
+		client.WithTransportCredentials(credentials.NewTLS(nil)),
+		client.WithTimeout(5*time.Second),
+		client.WithRetry(
+			retry.WithMaxAttempts(3),
+			retry.WithBackoff(retry.Exponential(100*time.Millisecond, 2.0, 0.2)),
+		),
+		client.WithMethodRetry("/payment.Payments/Charge", retry.WithMaxAttempts(1)),
+		client.WithCircuitBreaker(gobreaker.Settings{Timeout: 30 * time.Second}),
+		client.WithLogger(log),
+	)
+	if err != nil {
+		// Handle error
+	}
+	defer conn.Close()
+	payments := pb.NewPaymentsClient(conn)
+*/
+func Dial(target string, opts ...Option) (*grpc.ClientConn, error) {
+	return grpc.NewClient(target, DialOptions(opts...)...)
+}
+
+// DialOptions returns the dial options of a connection configured with opts: its credentials, its keepalive
+// parameters, the interceptors of UnaryClientInterceptor and StreamClientInterceptor, and the dial options of
+// WithDialOptions.
+func DialOptions(opts ...Option) []grpc.DialOption {
+	o := newOptions(opts)
+	return append([]grpc.DialOption{
+		grpc.WithTransportCredentials(o.credentials),
+		grpc.WithKeepaliveParams(o.keepalive),
+		grpc.WithChainUnaryInterceptor(o.unaryInterceptor()),
+		grpc.WithChainStreamInterceptor(o.streamInterceptor()),
+	}, o.dialOptions...)
+}
+
+/*
+UnaryClientInterceptor returns the interceptor of the unary calls configured with opts, which, from the outermost:
+  - Sets the deadline of the calls made without one, see WithTimeout and WithMethodTimeout.
+  - Retries the calls failing with a retryable error, with WithRetry or WithMethodRetry.
+  - Fails fast the calls to the failing targets, with WithCircuitBreaker.
+  - Logs every attempt, with WithLogger.
+*/
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	return newOptions(opts).unaryInterceptor()
+}
+
+// StreamClientInterceptor returns the interceptor of the streams configured with opts: it sets the deadline of the
+// streams to the methods of WithMethodTimeout, and logs the streams when they end, with WithLogger. The streams are
+// neither retried nor counted by the circuit breakers.
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	return newOptions(opts).streamInterceptor()
+}
+
+func (o *options) unaryInterceptor() grpc.UnaryClientInterceptor {
+	var breakers *breakers
+	if o.breaker != nil {
+		breakers = newBreakers(*o.breaker)
+	}
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			timeout, found := o.methodTimeouts[method]
+			if !found {
+				timeout = o.timeout
+			}
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		attempt := func(ctx context.Context) error {
+			invoke := invoker
+			if o.logging {
+				invoke = o.loggingInvoker(invoke)
+			}
+			if breakers != nil {
+				return breakers.invoke(ctx, method, req, reply, cc, invoke, callOpts...)
+			}
+			return invoke(ctx, method, req, reply, cc, callOpts...)
+		}
+		if retryOptions, ok := o.retryPolicy(method); ok {
+			return retryCall(ctx, attempt, retryOptions)
+		}
+		return attempt(ctx)
+	}
+}
+
+func (o *options) streamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		var finishers []func(s *clientStream, err error)
+		if timeout, found := o.methodTimeouts[method]; found {
+			if _, ok := ctx.Deadline(); !ok {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				finishers = append(finishers, func(*clientStream, error) { cancel() })
+			}
+		}
+		if o.logging {
+			finishers = append(finishers, o.streamLogger(ctx, method, cc.Target()))
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, callOpts...)
+		if len(finishers) == 0 {
+			return stream, err
+		}
+		cs := &clientStream{ClientStream: stream, finishers: finishers}
+		if err != nil {
+			cs.finish(err)
+			return nil, err
+		}
+		return cs, nil
+	}
+}
+
+// retryPolicy returns the retry options of the calls to method, and whether they are retried.
+func (o *options) retryPolicy(method string) ([]retry.Option, bool) {
+	if opts, found := o.methodRetries[method]; found {
+		return opts, true
+	}
+	return o.retryOptions, o.retry
+}
+
+// clientStream is a grpc.ClientStream calling its finishers when it ends, counting the messages.
+type clientStream struct {
+	grpc.ClientStream
+	finishers []func(s *clientStream, err error)
+	once      sync.Once
+	sent      atomic.Int64
+	received  atomic.Int64
+}
+
+// finish calls the finishers of the stream, once, with the error of the stream, nil if it ended successfully.
+func (s *clientStream) finish(err error) {
+	s.once.Do(func() {
+		for _, finisher := range s.finishers {
+			finisher(s, err)
+		}
+	})
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		s.received.Add(1)
+	case err == io.EOF:
+		s.finish(nil)
+	default:
+		s.finish(err)
+	}
+	return err
+}
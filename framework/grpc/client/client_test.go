@@ -0,0 +1,202 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/kittipat1413/go-common/framework/grpc/client"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+const (
+	checkMethod = "/grpc.health.v1.Health/Check"
+	watchMethod = "/grpc.health.v1.Health/Watch"
+)
+
+// healthServer is a test service returning the scripted codes, OK once they are exhausted, and recording the
+// deadlines of the calls.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	mu        sync.Mutex
+	codes     []codes.Code
+	calls     int
+	deadlines []time.Duration
+}
+
+// script sets the codes returned by the next calls.
+func (s *healthServer) script(codes ...codes.Code) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes = codes
+	s.calls = 0
+}
+
+// next records a call with ctx, and returns its error.
+func (s *healthServer) next(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	var remaining time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining = time.Until(deadline)
+	}
+	s.deadlines = append(s.deadlines, remaining)
+	if len(s.codes) == 0 {
+		return nil
+	}
+	code := s.codes[0]
+	s.codes = s.codes[1:]
+	if code == codes.OK {
+		return nil
+	}
+	return status.Error(code, code.String())
+}
+
+// called returns the number of calls since the last script, and the remaining time of the deadline of the last
+// call, 0 without a deadline.
+func (s *healthServer) called() (int, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls, s.deadlines[len(s.deadlines)-1]
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if err := s.next(ctx); err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	for i := 0; i < 2; i++ {
+		if err := stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}); err != nil {
+			return err
+		}
+	}
+	return s.next(stream.Context())
+}
+
+// newClient starts a server, and returns it with the function dialing it with the client options.
+func newClient(t *testing.T) (*healthServer, func(opts ...client.Option) healthpb.HealthClient) {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	service := &healthServer{}
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, service)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	return service, func(opts ...client.Option) healthpb.HealthClient {
+		t.Helper()
+		opts = append(opts, client.WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		})))
+		conn, err := client.Dial("passthrough:///bufnet", opts...)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		return healthpb.NewHealthClient(conn)
+	}
+}
+
+// watch reads the stream of Watch to the end, and returns its error.
+func watch(ctx context.Context, health healthpb.HealthClient) (int, error) {
+	stream, err := health.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return 0, err
+	}
+	for received := 0; ; received++ {
+		if _, err := stream.Recv(); err != nil {
+			return received, err
+		}
+	}
+}
+
+func TestDial_Timeouts(t *testing.T) {
+	server, dial := newClient(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		opts     []client.Option
+		ctx      func() (context.Context, context.CancelFunc)
+		expected time.Duration
+	}{
+		{name: "default", expected: client.DefaultTimeout},
+		{name: "timeout", opts: []client.Option{client.WithTimeout(10 * time.Second)}, expected: 10 * time.Second},
+		{
+			name:     "method timeout",
+			opts:     []client.Option{client.WithTimeout(10 * time.Second), client.WithMethodTimeout(checkMethod, 5*time.Second)},
+			expected: 5 * time.Second,
+		},
+		{
+			name:     "caller deadline",
+			opts:     []client.Option{client.WithTimeout(10 * time.Second)},
+			ctx:      func() (context.Context, context.CancelFunc) { return context.WithTimeout(ctx, time.Minute) },
+			expected: time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callCtx := ctx
+			if tt.ctx != nil {
+				var cancel context.CancelFunc
+				callCtx, cancel = tt.ctx()
+				defer cancel()
+			}
+			_, err := dial(tt.opts...).Check(callCtx, &healthpb.HealthCheckRequest{})
+			require.NoError(t, err)
+			_, remaining := server.called()
+			assert.InDelta(t, tt.expected.Seconds(), remaining.Seconds(), 1)
+		})
+	}
+}
+
+func TestDial_StreamTimeouts(t *testing.T) {
+	server, dial := newClient(t)
+
+	received, err := watch(context.Background(), dial(client.WithTimeout(time.Second)))
+	assert.Equal(t, 2, received)
+	assert.Equal(t, io.EOF, err)
+	_, remaining := server.called()
+	assert.Zero(t, remaining, "the streams have no default deadline")
+
+	_, err = watch(context.Background(), dial(client.WithMethodTimeout(watchMethod, 5*time.Second)))
+	assert.Equal(t, io.EOF, err)
+	_, remaining = server.called()
+	assert.InDelta(t, 5, remaining.Seconds(), 1)
+}
+
+func TestStreamClientInterceptor_Logging(t *testing.T) {
+	server, dial := newClient(t)
+	log, recorder := logger.NewTestLogger()
+	health := dial(client.WithLogger(log))
+
+	server.script(codes.NotFound)
+	received, err := watch(context.Background(), health)
+	assert.Equal(t, 2, received)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, logger.WARN, entries[0].Level)
+	assert.Equal(t, "gRPC client request", entries[0].Message)
+	request := entries[0].Fields["request"].(logger.Fields)
+	assert.Equal(t, "Watch", request["method"])
+	assert.Equal(t, "passthrough:///bufnet", request["target"])
+	assert.Equal(t, int64(1), request["messages"])
+	response := entries[0].Fields["response"].(logger.Fields)
+	assert.Equal(t, "NotFound", response["code"])
+	assert.Equal(t, int64(2), response["messages"])
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/kittipat1413/go-common/framework/errors/grpcstatus"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// loggingInvoker returns invoker logging every call.
+func (o *options) loggingInvoker(invoker grpc.UnaryInvoker) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		o.log(ctx, method, cc.Target(), err, time.Since(start), nil)
+		return err
+	}
+}
+
+// streamLogger returns the finisher logging the stream to method, started now.
+func (o *options) streamLogger(ctx context.Context, method, target string) func(s *clientStream, err error) {
+	start := time.Now()
+	return func(s *clientStream, err error) {
+		o.log(ctx, method, target, err, time.Since(start), s)
+	}
+}
+
+// log writes the entry of the call to fullMethod, with the message counts of stream if it is not nil.
+func (o *options) log(ctx context.Context, fullMethod, target string, err error, latency time.Duration, stream *clientStream) {
+	service, method, found := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !found {
+		service, method = "unknown", "unknown"
+	}
+	request := logger.Fields{
+		"service": service,
+		"method":  method,
+		"target":  target,
+	}
+	code := grpcstatus.ToStatus(err).Code()
+	response := logger.Fields{
+		"code":       code.String(),
+		"latency_ms": latency.Milliseconds(),
+		"latency_s":  latency.Seconds(),
+	}
+	if stream != nil {
+		request["messages"] = stream.sent.Load()
+		response["messages"] = stream.received.Load()
+	}
+	fields := logger.Fields{"request": request, "response": response}
+
+	l := o.logger
+	if l == nil {
+		l = logger.FromContext(ctx)
+	}
+	switch {
+	case code == codes.OK:
+		l.Info(ctx, "gRPC client request", fields)
+	case Failure(err):
+		l.Error(ctx, "gRPC client request", err, fields)
+	default:
+		fields[logger.DefaultErrorKey] = err
+		l.Warn(ctx, "gRPC client request", fields)
+	}
+}
@@ -0,0 +1,58 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/kittipat1413/go-common/framework/grpc/client"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+func TestWithLogger(t *testing.T) {
+	server, dial := newClient(t)
+	log, recorder := logger.NewTestLogger()
+	health := dial(client.WithLogger(log), client.WithRetry(retry.WithBackoff(retry.Constant(time.Millisecond))))
+
+	server.script(codes.Unavailable, codes.NotFound)
+	_, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.Error(t, err)
+	_, err = health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 3, "every attempt is logged")
+	for i, expected := range []struct {
+		level logger.LogLevel
+		code  string
+	}{
+		{level: logger.ERROR, code: "Unavailable"},
+		{level: logger.WARN, code: "NotFound"},
+		{level: logger.INFO, code: "OK"},
+	} {
+		assert.Equal(t, expected.level, entries[i].Level)
+		assert.Equal(t, "gRPC client request", entries[i].Message)
+		assert.Equal(t, logger.Fields{"service": "grpc.health.v1.Health", "method": "Check", "target": "passthrough:///bufnet"}, entries[i].Fields["request"])
+		response := entries[i].Fields["response"].(logger.Fields)
+		assert.Equal(t, expected.code, response["code"])
+		assert.Contains(t, response, "latency_ms")
+	}
+	assert.Error(t, entries[0].Error)
+	assert.Contains(t, entries[1].Fields, logger.DefaultErrorKey)
+	assert.NotContains(t, entries[2].Fields, logger.DefaultErrorKey)
+}
+
+func TestWithLogger_ContextLogger(t *testing.T) {
+	_, dial := newClient(t)
+	log, recorder := logger.NewTestLogger()
+
+	_, err := dial(client.WithLogger(nil)).Check(logger.NewContext(context.Background(), log), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	recorder.AssertLogged(t, logger.INFO, "gRPC client request")
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	stderrors "errors"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/errors/grpcstatus"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+// Retryable reports whether a call failing with err may succeed if retried: its gRPC code is of the
+// errors.KindUnavailable kind, i.e., codes.Unavailable, codes.ResourceExhausted or codes.DeadlineExceeded, see
+// grpcstatus.KindOf, or err is marked retryable, see errors.Retryable. The calls failed fast by an open circuit
+// breaker are not retryable.
+func Retryable(err error) bool {
+	if stderrors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	return errors.Retryable(grpcstatus.FromError(err))
+}
+
+// retryCall calls attempt until it succeeds, returns an error which is not retryable, or the attempts of opts are
+// exhausted. It returns the error of the last attempt, or the status of the context error if ctx was done first.
+func retryCall(ctx context.Context, attempt func(ctx context.Context) error, opts []retry.Option) error {
+	opts = append([]retry.Option{retry.WithRetryIf(Retryable)}, opts...)
+	err := retry.Do(ctx, attempt, opts...)
+	var retryErr *retry.Error
+	if !stderrors.As(err, &retryErr) {
+		return err
+	}
+	if retryErr.ContextErr != nil {
+		return status.FromContextError(retryErr.ContextErr).Err()
+	}
+	// The caller gets the error of the last attempt, like with a single attempt.
+	return retryErr.Err
+}
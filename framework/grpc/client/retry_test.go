@@ -0,0 +1,112 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/grpc/client"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err       error
+		retryable bool
+	}{
+		{err: status.Error(codes.Unavailable, "connection refused"), retryable: true},
+		{err: status.Error(codes.ResourceExhausted, "rate limited"), retryable: true},
+		{err: status.Error(codes.DeadlineExceeded, "deadline exceeded"), retryable: true},
+		{err: status.Error(codes.InvalidArgument, "invalid"), retryable: false},
+		{err: status.Error(codes.Internal, "boom"), retryable: false},
+		{err: domain_error.MarkRetryable(errors.New("flaky")), retryable: true},
+		{err: client.ErrCircuitOpen.WithField("target", "payments"), retryable: false},
+		{err: errors.New("unknown"), retryable: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tt.retryable, client.Retryable(tt.err))
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	server, dial := newClient(t)
+	fast := retry.WithBackoff(retry.Constant(time.Millisecond))
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		opts     []client.Option
+		script   []codes.Code
+		code     codes.Code
+		attempts int
+	}{
+		{name: "no retry by default", script: []codes.Code{codes.Unavailable}, code: codes.Unavailable, attempts: 1},
+		{
+			name:     "retryable",
+			opts:     []client.Option{client.WithRetry(fast)},
+			script:   []codes.Code{codes.Unavailable, codes.ResourceExhausted},
+			code:     codes.OK,
+			attempts: 3,
+		},
+		{
+			name:     "not retryable",
+			opts:     []client.Option{client.WithRetry(fast)},
+			script:   []codes.Code{codes.InvalidArgument},
+			code:     codes.InvalidArgument,
+			attempts: 1,
+		},
+		{
+			name:     "attempts exhausted",
+			opts:     []client.Option{client.WithRetry(fast, retry.WithMaxAttempts(2))},
+			script:   []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable},
+			code:     codes.Unavailable,
+			attempts: 2,
+		},
+		{
+			name:     "method retry",
+			opts:     []client.Option{client.WithRetry(fast), client.WithMethodRetry(checkMethod, retry.WithMaxAttempts(1))},
+			script:   []codes.Code{codes.Unavailable},
+			code:     codes.Unavailable,
+			attempts: 1,
+		},
+		{
+			name:     "method retry only",
+			opts:     []client.Option{client.WithMethodRetry(checkMethod, fast)},
+			script:   []codes.Code{codes.Unavailable},
+			code:     codes.OK,
+			attempts: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.script(tt.script...)
+			_, err := dial(tt.opts...).Check(ctx, &healthpb.HealthCheckRequest{})
+			assert.Equal(t, tt.code, status.Code(err), fmt.Sprint(err))
+			attempts, _ := server.called()
+			assert.Equal(t, tt.attempts, attempts)
+		})
+	}
+}
+
+func TestWithRetry_Deadline(t *testing.T) {
+	server, dial := newClient(t)
+	server.script(codes.Unavailable, codes.Unavailable)
+	health := dial(client.WithTimeout(50*time.Millisecond), client.WithRetry(retry.WithBackoff(retry.Constant(time.Second))))
+
+	_, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.Error(t, err)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err), "the deadline covers the retries")
+	attempts, _ := server.called()
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/kittipat1413/go-common/framework/health"
+)
+
+// DefaultHealthWatchInterval is the interval the readiness of the service is checked at for the Watch streams,
+// unless set with WithHealthWatchInterval.
+const DefaultHealthWatchInterval = 5 * time.Second
+
+// healthOptions holds configuration options for the health service.
+type healthOptions struct {
+	watchInterval time.Duration
+	reflection    bool
+}
+
+// HealthOption specifies health service configuration options.
+type HealthOption func(*healthOptions)
+
+// WithHealthWatchInterval sets the interval the readiness of the service is checked at for the Watch streams, which
+// receive the serving status when it changes. It defaults to DefaultHealthWatchInterval.
+func WithHealthWatchInterval(d time.Duration) HealthOption {
+	return func(opts *healthOptions) {
+		if d > 0 {
+			opts.watchInterval = d
+		}
+	}
+}
+
+// WithReflection registers the server reflection service too with RegisterHealth, so that tools like grpcurl list
+// the services of the server.
+func WithReflection() HealthOption {
+	return func(opts *healthOptions) {
+		opts.reflection = true
+	}
+}
+
+// HealthServer is the grpc.health.v1 service reporting the readiness of a health.Registry: the services are SERVING
+// while the readiness report is up or degraded, and NOT_SERVING when it is down, e.g., when a critical check fails
+// or when the registry is set not ready on shutdown, see lifecycle.WithReadiness.
+type HealthServer struct {
+	healthpb.UnimplementedHealthServer
+	server   *grpc.Server
+	registry *health.Registry
+	opts     *healthOptions
+	once     sync.Once
+	shutdown chan struct{}
+}
+
+// NewHealthServer returns the health service reporting the readiness of registry for the services of srv, and for
+// the empty service name, which stands for the whole server. srv may be nil to report the whole server only.
+func NewHealthServer(srv *grpc.Server, registry *health.Registry, opts ...HealthOption) *HealthServer {
+	o := &healthOptions{watchInterval: DefaultHealthWatchInterval}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &HealthServer{server: srv, registry: registry, opts: o, shutdown: make(chan struct{})}
+}
+
+/*
+RegisterHealth registers on srv the health service reporting the readiness of registry, see NewHealthServer, and
+the server reflection service with WithReflection. Call the Shutdown method of the returned HealthServer before
+stopping srv gracefully, so that the Watch streams end.
+
+Example usage:
+
+	registry := health.NewRegistry()
+	_ = registry.Register("postgres", health.PingCheck(db))
+
+	srv := grpc.NewServer(interceptors.ServerOptions()...)
+	pb.RegisterPaymentsServer(srv, payments)
+	healthServer := server.RegisterHealth(srv, registry, server.WithReflection())
+
+	manager := lifecycle.NewManager(lifecycle.WithReadiness(registry))
+	manager.Add("grpc", lifecycle.RunFunc(func(ctx context.Context) error {
+		go func() {
+			<-ctx.Done()
+			healthServer.Shutdown()
+			srv.GracefulStop()
+		}()
+		return srv.Serve(listener)
+	}))
+*/
+func RegisterHealth(srv *grpc.Server, registry *health.Registry, opts ...HealthOption) *HealthServer {
+	s := NewHealthServer(srv, registry, opts...)
+	healthpb.RegisterHealthServer(srv, s)
+	if s.opts.reflection {
+		reflection.Register(srv)
+	}
+	return s
+}
+
+// Shutdown sets every service NOT_SERVING, whatever the readiness of the registry, and ends the Watch streams after
+// sending them the status, so that they do not block grpc.Server.GracefulStop.
+func (s *HealthServer) Shutdown() {
+	s.once.Do(func() { close(s.shutdown) })
+}
+
+// Check returns the serving status of the service of req, or codes.NotFound if the server has no such service.
+func (s *HealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	servingStatus := s.servingStatus(ctx, req.GetService())
+	if servingStatus == healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+// Watch sends the serving status of the service of req, then every change of it, checked at the interval of
+// WithHealthWatchInterval. The unknown services are SERVICE_UNKNOWN, since they may be registered later.
+func (s *HealthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(s.opts.watchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		servingStatus := s.servingStatus(ctx, req.GetService())
+		if servingStatus != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: servingStatus}); err != nil {
+				return err
+			}
+			last = servingStatus
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-s.shutdown:
+			if last != healthpb.HealthCheckResponse_NOT_SERVING {
+				return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING})
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// servingStatus returns the serving status of service.
+func (s *HealthServer) servingStatus(ctx context.Context, service string) healthpb.HealthCheckResponse_ServingStatus {
+	if !s.known(service) {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+	select {
+	case <-s.shutdown:
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	default:
+	}
+	if s.registry.Readiness(ctx).Status == health.StatusDown {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+// known tells whether service is the whole server or one of its services.
+func (s *HealthServer) known(service string) bool {
+	if service == "" {
+		return true
+	}
+	if s.server == nil {
+		return false
+	}
+	_, found := s.server.GetServiceInfo()[service]
+	return found
+}
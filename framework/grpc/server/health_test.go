@@ -0,0 +1,157 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/kittipat1413/go-common/framework/grpc/server"
+	"github.com/kittipat1413/go-common/framework/health"
+)
+
+// newHealthClient starts a server with the health service of registry, and returns a connection to it.
+func newHealthClient(t *testing.T, registry *health.Registry, opts ...server.HealthOption) (*server.HealthServer, *grpc.ClientConn) {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	healthServer := server.RegisterHealth(srv, registry, opts...)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return healthServer, conn
+}
+
+func TestHealthServer_Check(t *testing.T) {
+	var failing atomic.Bool
+	registry := health.NewRegistry(health.WithCacheDuration(0))
+	require.NoError(t, registry.Register("postgres", func(ctx context.Context) error {
+		if failing.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	}))
+	require.NoError(t, registry.Register("cache", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}, health.WithCritical(false)))
+	healthServer, conn := newHealthClient(t, registry)
+	client := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	check := func(service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		return resp.GetStatus(), err
+	}
+
+	servingStatus, err := check("")
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus, "degraded is serving")
+	servingStatus, err = check("grpc.health.v1.Health")
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus)
+	_, err = check("payment.Payments")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	failing.Store(true)
+	servingStatus, err = check("")
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus, "a critical check failed")
+
+	failing.Store(false)
+	registry.SetReady(false)
+	servingStatus, err = check("")
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus, "the registry is not ready")
+
+	registry.SetReady(true)
+	healthServer.Shutdown()
+	servingStatus, err = check("grpc.health.v1.Health")
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus, "the server is shutting down")
+}
+
+func TestHealthServer_Watch(t *testing.T) {
+	registry := health.NewRegistry(health.WithCacheDuration(0))
+	healthServer, conn := newHealthClient(t, registry, server.WithHealthWatchInterval(10*time.Millisecond))
+	client := healthpb.NewHealthClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	recv := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		return resp.GetStatus()
+	}
+
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, recv())
+	registry.SetReady(false)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, recv())
+	registry.SetReady(true)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, recv())
+
+	healthServer.Shutdown()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, recv())
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err, "the stream ends on shutdown")
+
+	unknown, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "payment.Payments"})
+	require.NoError(t, err)
+	resp, err := unknown.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVICE_UNKNOWN, resp.GetStatus())
+}
+
+func TestWithReflection(t *testing.T) {
+	listServices := func(conn *grpc.ClientConn) ([]string, error) {
+		stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		if err := stream.Send(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+		}); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		var services []string
+		for _, service := range resp.GetListServicesResponse().GetService() {
+			services = append(services, service.GetName())
+		}
+		return services, nil
+	}
+
+	_, conn := newHealthClient(t, health.NewRegistry(), server.WithReflection())
+	services, err := listServices(conn)
+	require.NoError(t, err)
+	assert.Contains(t, services, "grpc.health.v1.Health")
+
+	_, conn = newHealthClient(t, health.NewRegistry())
+	_, err = listServices(conn)
+	assert.Equal(t, codes.Unimplemented, status.Code(err), "no reflection by default")
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+
+	"github.com/kittipat1413/go-common/framework/errors/grpcstatus"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+/*
+UnaryLoggingInterceptor logs every request as "gRPC request" with its request fields (service, method, type and
+peer address) and response fields (code, latency), at the INFO level, WARN for the errors of the clients, e.g.,
+codes.InvalidArgument, and ERROR for the errors of the server, e.g., codes.Internal, with the error. The code of
+the errors of the framework errors package is the one grpcstatus.ToStatus gives them, so install the interceptor
+after (inside of) the grpcstatus interceptor, so that it logs the errors with their cause and fields.
+
+Example usage:
+
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		grpcstatus.UnaryServerInterceptor(),
+		server.UnaryLoggingInterceptor(server.WithLogger(log)),
+	))
+*/
+func UnaryLoggingInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if o.skipMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		o.log(ctx, info.FullMethod, typeUnary, err, time.Since(start), nil)
+		return resp, err
+	}
+}
+
+// StreamLoggingInterceptor logs every stream like UnaryLoggingInterceptor, with the number of messages received
+// and sent.
+func StreamLoggingInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if o.skipMethods[info.FullMethod] {
+			return handler(srv, ss)
+		}
+		start := time.Now()
+		stream := wrapServerStream(ss.Context(), ss)
+		err := handler(srv, stream)
+		o.log(ss.Context(), info.FullMethod, streamType(info), err, time.Since(start), stream)
+		return err
+	}
+}
+
+// log writes the entry of the request to fullMethod, with the message counts of stream if it is not nil.
+func (o *options) log(ctx context.Context, fullMethod, rpcType string, err error, latency time.Duration, stream *serverStream) {
+	service, method := splitMethod(fullMethod)
+	request := logger.Fields{
+		"service": service,
+		"method":  method,
+		"type":    rpcType,
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		request["peer"] = p.Addr.String()
+	}
+
+	code := grpcstatus.ToStatus(err).Code()
+	response := logger.Fields{
+		"code":       code.String(),
+		"latency_ms": latency.Milliseconds(),
+		"latency_s":  latency.Seconds(),
+	}
+	if stream != nil {
+		request["messages"] = stream.received.Load()
+		response["messages"] = stream.sent.Load()
+	}
+	fields := logger.Fields{"request": request, "response": response}
+
+	l := o.loggerFor(ctx)
+	switch {
+	case code == codes.OK:
+		l.Info(ctx, "gRPC request", fields)
+	case serverError(code):
+		l.Error(ctx, "gRPC request", err, fields)
+	default:
+		fields[logger.DefaultErrorKey] = err
+		l.Warn(ctx, "gRPC request", fields)
+	}
+}
@@ -0,0 +1,105 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/kittipat1413/go-common/framework/errors/grpcstatus"
+	"github.com/kittipat1413/go-common/framework/grpc/server"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+func TestLoggingInterceptor(t *testing.T) {
+	tests := []struct {
+		service string
+		level   logger.LogLevel
+		code    string
+	}{
+		{service: "", level: logger.INFO, code: "OK"},
+		{service: "unknown", level: logger.WARN, code: "NotFound"},
+		{service: "failing", level: logger.ERROR, code: "Unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			log, recorder := logger.NewTestLogger()
+			client := newClient(t, grpc.ChainUnaryInterceptor(
+				grpcstatus.UnaryServerInterceptor(),
+				server.UnaryLoggingInterceptor(server.WithLogger(log)),
+			))
+
+			_, _ = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: tt.service})
+
+			entries := recorder.Entries()
+			require.Len(t, entries, 1)
+			assert.Equal(t, tt.level, entries[0].Level)
+			assert.Equal(t, "gRPC request", entries[0].Message)
+			request := entries[0].Fields["request"].(logger.Fields)
+			assert.Equal(t, "grpc.health.v1.Health", request["service"])
+			assert.Equal(t, "Check", request["method"])
+			assert.Equal(t, "unary", request["type"])
+			assert.NotEmpty(t, request["peer"])
+			response := entries[0].Fields["response"].(logger.Fields)
+			assert.Equal(t, tt.code, response["code"])
+			assert.Contains(t, response, "latency_ms")
+			if tt.level == logger.INFO {
+				assert.NotContains(t, entries[0].Fields, logger.DefaultErrorKey)
+			} else {
+				assert.Contains(t, entries[0].Fields, logger.DefaultErrorKey)
+			}
+		})
+	}
+}
+
+func TestStreamLoggingInterceptor(t *testing.T) {
+	log, recorder := logger.NewTestLogger()
+	client := newClient(t, grpc.ChainStreamInterceptor(
+		grpcstatus.StreamServerInterceptor(),
+		server.StreamLoggingInterceptor(server.WithLogger(log)),
+	))
+
+	received, err := watch(context.Background(), client, "unknown")
+	assert.Equal(t, 2, received)
+	require.Error(t, err)
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, logger.WARN, entries[0].Level)
+	request := entries[0].Fields["request"].(logger.Fields)
+	assert.Equal(t, "Watch", request["method"])
+	assert.Equal(t, "server_stream", request["type"])
+	assert.Equal(t, int64(1), request["messages"])
+	response := entries[0].Fields["response"].(logger.Fields)
+	assert.Equal(t, "NotFound", response["code"])
+	assert.Equal(t, int64(2), response["messages"])
+}
+
+func TestLoggingInterceptor_SkipMethods(t *testing.T) {
+	log, recorder := logger.NewTestLogger()
+	client := newClient(t,
+		grpc.UnaryInterceptor(server.UnaryLoggingInterceptor(server.WithLogger(log), server.WithSkipMethods(checkMethod))),
+		grpc.StreamInterceptor(server.StreamLoggingInterceptor(server.WithLogger(log), server.WithSkipMethods(checkMethod))),
+	)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Zero(t, recorder.Len())
+
+	_, err = watch(context.Background(), client, "")
+	require.Error(t, err)
+	recorder.AssertLogged(t, logger.INFO, "gRPC request")
+}
+
+func TestLoggingInterceptor_ContextLogger(t *testing.T) {
+	log, recorder := logger.NewTestLogger()
+	interceptor := server.UnaryLoggingInterceptor()
+
+	_, err := interceptor(logger.NewContext(context.Background(), log), nil, &grpc.UnaryServerInfo{FullMethod: checkMethod},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+	require.NoError(t, err)
+	recorder.AssertLogged(t, logger.INFO, "gRPC request")
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/kittipat1413/go-common/framework/errors/grpcstatus"
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets of the latency histogram, unless set with
+// NewMetrics.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+/*
+Metrics records the metrics of the requests of a server with its interceptors, and exposes them in the
+Prometheus text format, labelled by service, method and RPC type, with the metric names of the
+go-grpc-prometheus interceptors, so that the existing dashboards work. The metrics are recorded in a registry of
+the metrics package, with no dependency on a Prometheus client library: serve it on the metrics endpoint, write it
+with WriteTo, or register it as a collector of a metrics.Registry.
+
+Example usage:
+
+	metrics := server.NewMetrics("myapp")
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(metrics.StreamServerInterceptor()),
+	)
+	http.Handle("/metrics/grpc", metrics)
+
+exposes metrics such as:
+
+	myapp_grpc_server_handled_total{grpc_service="payment.Payments",grpc_method="Charge",grpc_type="unary",grpc_code="OK"} 1024
+*/
+type Metrics struct {
+	registry *metrics.Registry
+	started  *metrics.Counter
+	handled  *metrics.Counter
+	received *metrics.Counter
+	sent     *metrics.Counter
+	handling *metrics.Histogram
+}
+
+// NewMetrics creates a Metrics. namespace prefixes the metric names, it may be empty. buckets are the upper bounds,
+// in seconds, of the buckets of the latency histogram, DefaultBuckets if empty.
+func NewMetrics(namespace string, buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	registry := metrics.NewRegistry(metrics.WithNamespace(namespace))
+	labels := []string{"grpc_service", "grpc_method", "grpc_type"}
+	return &Metrics{
+		registry: registry,
+		started:  metrics.Must(registry.NewCounter("grpc_server_started_total", "Number of RPCs started on the server.", labels...)),
+		handled: metrics.Must(registry.NewCounter("grpc_server_handled_total",
+			"Number of RPCs completed on the server, regardless of success or failure.", append(labels, "grpc_code")...)),
+		received: metrics.Must(registry.NewCounter("grpc_server_msg_received_total", "Number of RPC messages received on the server.", labels...)),
+		sent:     metrics.Must(registry.NewCounter("grpc_server_msg_sent_total", "Number of RPC messages sent by the server.", labels...)),
+		handling: metrics.Must(registry.NewHistogram("grpc_server_handling_seconds",
+			"Histogram of response latency (seconds) of the RPCs handled by the server.", buckets, labels...)),
+	}
+}
+
+// start records a request to fullMethod, creating the series of its messages, and returns its label values.
+func (m *Metrics) start(fullMethod, rpcType string) []string {
+	service, method := splitMethod(fullMethod)
+	labels := []string{service, method, rpcType}
+	m.started.Inc(labels...)
+	m.received.Add(0, labels...)
+	m.sent.Add(0, labels...)
+	return labels
+}
+
+// record records a request handled with err after latency.
+func (m *Metrics) record(labels []string, err error, latency time.Duration) {
+	code := grpcstatus.ToStatus(err).Code()
+	m.handled.Inc(append(labels[:len(labels):len(labels)], code.String())...)
+	m.handling.Observe(latency.Seconds(), labels...)
+}
+
+// UnaryServerInterceptor records the metrics of the requests.
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		labels := m.start(info.FullMethod, typeUnary)
+		m.received.Inc(labels...)
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		if err == nil {
+			m.sent.Inc(labels...)
+		}
+		m.record(labels, err, time.Since(start))
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records the metrics of the streams, and of their messages.
+func (m *Metrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		labels := m.start(info.FullMethod, streamType(info))
+		start := time.Now()
+		err := handler(srv, &meteredServerStream{ServerStream: ss, metrics: m, labels: labels})
+		m.record(labels, err, time.Since(start))
+		return err
+	}
+}
+
+// meteredServerStream counts the messages of a stream.
+type meteredServerStream struct {
+	grpc.ServerStream
+	metrics *Metrics
+	labels  []string
+}
+
+func (s *meteredServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.metrics.received.Inc(s.labels...)
+	}
+	return err
+}
+
+func (s *meteredServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.metrics.sent.Inc(s.labels...)
+	}
+	return err
+}
+
+// WriteTo writes the metrics of the methods to w in the Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	return m.registry.WriteTo(w)
+}
+
+// ServeHTTP writes the metrics of the methods in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
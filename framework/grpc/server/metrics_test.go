@@ -0,0 +1,69 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/kittipat1413/go-common/framework/grpc/server"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := server.NewMetrics("myapp", 10, 0.5)
+	client := newClient(t,
+		grpc.UnaryInterceptor(metrics.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(metrics.StreamServerInterceptor()),
+	)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+	}
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	require.Error(t, err)
+	_, err = watch(ctx, client, "")
+	require.Error(t, err)
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+	body := recorder.Body.String()
+	check := `grpc_service="grpc.health.v1.Health",grpc_method="Check",grpc_type="unary"`
+	watch := `grpc_service="grpc.health.v1.Health",grpc_method="Watch",grpc_type="server_stream"`
+	for _, line := range []string{
+		"# TYPE myapp_grpc_server_started_total counter",
+		"myapp_grpc_server_started_total{" + check + "} 3",
+		"myapp_grpc_server_started_total{" + watch + "} 1",
+		"myapp_grpc_server_handled_total{" + check + `,grpc_code="OK"} 2`,
+		// Without the grpcstatus interceptor, the framework errors are unknown errors for gRPC, but not for the
+		// metrics.
+		"myapp_grpc_server_handled_total{" + check + `,grpc_code="NotFound"} 1`,
+		"myapp_grpc_server_handled_total{" + watch + `,grpc_code="OK"} 1`,
+		"myapp_grpc_server_msg_received_total{" + check + "} 3",
+		"myapp_grpc_server_msg_sent_total{" + check + "} 2",
+		"myapp_grpc_server_msg_received_total{" + watch + "} 1",
+		"myapp_grpc_server_msg_sent_total{" + watch + "} 2",
+		"# TYPE myapp_grpc_server_handling_seconds histogram",
+		"myapp_grpc_server_handling_seconds_bucket{" + check + `,le="0.5"} 3`,
+		"myapp_grpc_server_handling_seconds_bucket{" + check + `,le="10"} 3`,
+		"myapp_grpc_server_handling_seconds_bucket{" + check + `,le="+Inf"} 3`,
+		"myapp_grpc_server_handling_seconds_count{" + check + "} 3",
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+	assert.NotContains(t, body, `grpc_code="Internal"`, "only the codes seen are written")
+	assert.Contains(t, body, "myapp_grpc_server_handling_seconds_sum{"+check+"} ")
+}
+
+func TestMetrics_Empty(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	server.NewMetrics("").ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, recorder.Body.String(), "# TYPE grpc_server_handled_total counter\n")
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/errors/grpcstatus"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/middleware/recovery"
+)
+
+// CodeInternal is the code of the errors the panics are converted to, the one of the recovery middleware.
+const CodeInternal = recovery.CodeInternal
+
+/*
+UnaryRecoveryInterceptor recovers the panics of the handlers, so that they do not kill the server: it converts
+the panic to an Internal *errors.CodedError with the CodeInternal code, wrapping the panic value if it is an
+error, logs it with the stack of the panic at the ERROR level, and returns it as a codes.Internal status, see
+grpcstatus.ToStatus. Install it last, so that it recovers the panics of the other interceptors too.
+
+Example usage:
+
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		server.UnaryLoggingInterceptor(),
+		server.UnaryRecoveryInterceptor(),
+	))
+*/
+func UnaryRecoveryInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = o.recovered(ctx, info.FullMethod, recovered)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecoveryInterceptor recovers the panics of the stream handlers like UnaryRecoveryInterceptor.
+func StreamRecoveryInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = o.recovered(ss.Context(), info.FullMethod, recovered)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// recovered logs the value recovered from the panic of the handler of fullMethod, and returns its status error.
+// It must be called by the deferred function, while the stack of the panic is still there.
+func (o *options) recovered(ctx context.Context, fullMethod string, recovered interface{}) error {
+	err := errors.Internal(CodeInternal, "internal server error").Wrap(panicError(recovered))
+	service, method := splitMethod(fullMethod)
+	o.loggerFor(ctx).Error(ctx, "Panic recovered", err, logger.Fields{
+		logger.DefaultPanicKey: fmt.Sprintf("%v", recovered),
+		"request": logger.Fields{
+			"service": service,
+			"method":  method,
+		},
+	})
+	return grpcstatus.ToStatus(err).Err()
+}
+
+// panicError returns the panic value recovered as an error.
+func panicError(recovered interface{}) error {
+	if err, ok := recovered.(error); ok {
+		return err
+	}
+	return fmt.Errorf("panic: %v", recovered)
+}
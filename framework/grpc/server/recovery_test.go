@@ -0,0 +1,48 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/kittipat1413/go-common/framework/grpc/server"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+func TestRecoveryInterceptor(t *testing.T) {
+	log, recorder := logger.NewTestLogger()
+	client := newClient(t,
+		grpc.UnaryInterceptor(server.UnaryRecoveryInterceptor(server.WithLogger(log))),
+		grpc.StreamInterceptor(server.StreamRecoveryInterceptor(server.WithLogger(log))),
+	)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "panic"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "internal server error", st.Message(), "the panic should not cross the boundary")
+	require.Len(t, st.Details(), 1)
+	assert.Equal(t, server.CodeInternal, st.Details()[0].(*errdetails.ErrorInfo).GetReason())
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, logger.ERROR, entries[0].Level)
+	assert.Equal(t, "Panic recovered", entries[0].Message)
+	assert.Equal(t, "boom", entries[0].Fields[logger.DefaultPanicKey])
+	assert.Equal(t, logger.Fields{"service": "grpc.health.v1.Health", "method": "Check"}, entries[0].Fields["request"])
+	assert.ErrorContains(t, entries[0].Error, "panic: boom")
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	received, err := watch(context.Background(), client, "panic")
+	assert.Equal(t, 2, received, "the messages sent before the panic are received")
+	assert.Equal(t, codes.Internal, status.Code(err))
+	recorder.AssertLogged(t, logger.ERROR, "Panic recovered", logger.HasField("request", logger.Fields{"service": "grpc.health.v1.Health", "method": "Watch"}))
+}
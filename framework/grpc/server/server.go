@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/kittipat1413/go-common/framework/errors/grpcstatus"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// options holds configuration options for the interceptors.
+type options struct {
+	logger         logger.Logger
+	tracerProvider oteltrace.TracerProvider
+	propagators    propagation.TextMapPropagator
+	skipMethods    map[string]bool
+}
+
+// Option specifies interceptor configuration options.
+type Option func(*options)
+
+// WithLogger sets the logger of the requests and the panics. It defaults to the logger of the request context, see
+// logger.FromContext.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		opts.logger = l
+	}
+}
+
+// WithTracerProvider sets the tracer provider creating the spans. It defaults to the global tracer provider.
+func WithTracerProvider(provider oteltrace.TracerProvider) Option {
+	return func(opts *options) {
+		if provider != nil {
+			opts.tracerProvider = provider
+		}
+	}
+}
+
+// WithPropagators sets the propagators extracting the parent span and the baggage from the request metadata. It
+// defaults to the global propagators, or the W3C Trace Context and Baggage propagators if none are set.
+func WithPropagators(propagators propagation.TextMapPropagator) Option {
+	return func(opts *options) {
+		if propagators != nil {
+			opts.propagators = propagators
+		}
+	}
+}
+
+// WithSkipMethods skips logging and tracing the requests to the full methods, e.g.,
+// "/grpc.health.v1.Health/Check".
+func WithSkipMethods(fullMethods ...string) Option {
+	return func(opts *options) {
+		for _, method := range fullMethods {
+			opts.skipMethods[method] = true
+		}
+	}
+}
+
+// newOptions returns the options set with opts and the defaults.
+func newOptions(opts []Option) *options {
+	o := &options{skipMethods: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.tracerProvider == nil {
+		o.tracerProvider = otel.GetTracerProvider()
+	}
+	if o.propagators == nil {
+		o.propagators = otel.GetTextMapPropagator()
+		if len(o.propagators.Fields()) == 0 {
+			// No global propagators are set: the default one propagates nothing.
+			o.propagators = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+		}
+	}
+	return o
+}
+
+// loggerFor returns the logger of the requests with ctx.
+func (o *options) loggerFor(ctx context.Context) logger.Logger {
+	if o.logger != nil {
+		return o.logger
+	}
+	return logger.FromContext(ctx)
+}
+
+// Config configures the interceptors of DefaultInterceptors.
+type Config struct {
+	// Logger logs the requests and the panics. If nil, the logger of the request context is used.
+	Logger logger.Logger
+	// Metrics records the metrics of the requests. If nil, no metrics are recorded.
+	Metrics *Metrics
+	// TracerProvider creates the spans of the requests. If nil, the global tracer provider is used.
+	TracerProvider oteltrace.TracerProvider
+	// Propagators extract the parent span from the request metadata. If nil, the global propagators are used.
+	Propagators propagation.TextMapPropagator
+	// DisableTracing disables the spans of the requests.
+	DisableTracing bool
+	// SkipMethods are the full methods neither logged nor traced, e.g., "/grpc.health.v1.Health/Check".
+	SkipMethods []string
+}
+
+// Interceptors are the interceptors of a server, in the order they run.
+type Interceptors struct {
+	Unary  []grpc.UnaryServerInterceptor
+	Stream []grpc.StreamServerInterceptor
+}
+
+// ServerOptions returns the server options chaining the interceptors, for grpc.NewServer.
+func (i Interceptors) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.Unary...),
+		grpc.ChainStreamInterceptor(i.Stream...),
+	}
+}
+
+/*
+DefaultInterceptors returns the interceptors every server needs, in the order they run:
+  - Tracing, so that the logs of the requests carry the trace_id and span_id of their span.
+  - Metrics, if cfg.Metrics is set.
+  - The conversion of the errors of the framework errors package to gRPC statuses, see grpcstatus.ToStatus.
+  - Logging, with the errors returned by the handlers before their conversion.
+  - Panic recovery, converting the panics to codes.Internal.
+
+Append the interceptors of the service, e.g., authentication, to the returned ones, so that they run within them.
+
+Example usage:
+
+	metrics := server.NewMetrics("myapp")
+	interceptors := server.DefaultInterceptors(server.Config{
+		Logger:      log,
+		Metrics:     metrics,
+		SkipMethods: []string{"/grpc.health.v1.Health/Check"},
+	})
+	interceptors.Unary = append(interceptors.Unary, authInterceptor)
+	srv := grpc.NewServer(interceptors.ServerOptions()...)
+	http.Handle("/metrics/grpc", metrics)
+*/
+func DefaultInterceptors(cfg Config) Interceptors {
+	opts := []Option{
+		WithLogger(cfg.Logger),
+		WithTracerProvider(cfg.TracerProvider),
+		WithPropagators(cfg.Propagators),
+		WithSkipMethods(cfg.SkipMethods...),
+	}
+
+	var interceptors Interceptors
+	if !cfg.DisableTracing {
+		interceptors.Unary = append(interceptors.Unary, UnaryTracingInterceptor(opts...))
+		interceptors.Stream = append(interceptors.Stream, StreamTracingInterceptor(opts...))
+	}
+	if cfg.Metrics != nil {
+		interceptors.Unary = append(interceptors.Unary, cfg.Metrics.UnaryServerInterceptor())
+		interceptors.Stream = append(interceptors.Stream, cfg.Metrics.StreamServerInterceptor())
+	}
+	interceptors.Unary = append(interceptors.Unary,
+		grpcstatus.UnaryServerInterceptor(),
+		UnaryLoggingInterceptor(opts...),
+		UnaryRecoveryInterceptor(opts...),
+	)
+	interceptors.Stream = append(interceptors.Stream,
+		grpcstatus.StreamServerInterceptor(),
+		StreamLoggingInterceptor(opts...),
+		StreamRecoveryInterceptor(opts...),
+	)
+	return interceptors
+}
+
+// Types of the RPCs, for the logs and the metrics.
+const (
+	typeUnary        = "unary"
+	typeClientStream = "client_stream"
+	typeServerStream = "server_stream"
+	typeBidiStream   = "bidi_stream"
+)
+
+// streamType returns the type of the streaming RPC of info.
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return typeBidiStream
+	case info.IsClientStream:
+		return typeClientStream
+	default:
+		return typeServerStream
+	}
+}
+
+// splitMethod returns the service and the method of fullMethod, e.g., "payment.Payments" and "Charge" for
+// "/payment.Payments/Charge".
+func splitMethod(fullMethod string) (string, string) {
+	service, method, found := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !found {
+		return "unknown", "unknown"
+	}
+	return service, method
+}
+
+// serverError reports whether code tells a failure of the server, rather than of the request of the client.
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+// serverStream is a grpc.ServerStream with the context of the interceptor, counting the messages.
+type serverStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	received atomic.Int64
+	sent     atomic.Int64
+}
+
+func wrapServerStream(ctx context.Context, ss grpc.ServerStream) *serverStream {
+	return &serverStream{ServerStream: ss, ctx: ctx}
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received.Add(1)
+	}
+	return err
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+	return err
+}
@@ -0,0 +1,188 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/grpc/server"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+const (
+	checkMethod = "/grpc.health.v1.Health/Check"
+	watchMethod = "/grpc.health.v1.Health/Watch"
+)
+
+var errServiceNotFound = domain_error.NotFound("health.service_not_found", "service not found")
+
+// healthServer is a test service, whose behavior depends on the service name of the requests.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	switch req.GetService() {
+	case "panic":
+		panic("boom")
+	case "unknown":
+		return nil, errServiceNotFound.WithField("service", req.GetService())
+	case "failing":
+		return nil, errors.New("connection refused")
+	case "traced":
+		if !oteltrace.SpanContextFromContext(ctx).IsValid() {
+			return nil, errors.New("no span in the context")
+		}
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	for i := 0; i < 2; i++ {
+		if err := stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}); err != nil {
+			return err
+		}
+	}
+	switch req.GetService() {
+	case "panic":
+		panic(errors.New("boom"))
+	case "unknown":
+		return errServiceNotFound
+	}
+	return nil
+}
+
+// newClient starts a server with opts serving healthServer, and returns a client of the server.
+func newClient(t *testing.T, opts ...grpc.ServerOption) healthpb.HealthClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(opts...)
+	healthpb.RegisterHealthServer(srv, &healthServer{})
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func newTracerProvider() (*tracesdk.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	return tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder)), recorder
+}
+
+// watch reads the stream of Watch to the end, and returns its error.
+func watch(ctx context.Context, client healthpb.HealthClient, service string) (int, error) {
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return 0, err
+	}
+	for received := 0; ; received++ {
+		if _, err := stream.Recv(); err != nil {
+			return received, err
+		}
+	}
+}
+
+func TestDefaultInterceptors(t *testing.T) {
+	log, recorder := logger.NewTestLogger()
+	tp, spans := newTracerProvider()
+	metrics := server.NewMetrics("")
+	interceptors := server.DefaultInterceptors(server.Config{
+		Logger:         log,
+		Metrics:        metrics,
+		TracerProvider: tp,
+	})
+	require.Len(t, interceptors.Unary, 5)
+	require.Len(t, interceptors.Stream, 5)
+	client := newClient(t, interceptors.ServerOptions()...)
+	ctx := context.Background()
+
+	// The framework errors are converted to statuses, after being logged with their fields.
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+	require.Len(t, st.Details(), 1)
+	assert.Equal(t, "health.service_not_found", st.Details()[0].(*errdetails.ErrorInfo).GetReason())
+	recorder.AssertLogged(t, logger.WARN, "gRPC request", func(fields logger.Fields) bool {
+		return fields["response"].(logger.Fields)["code"] == "NotFound" && errors.Is(fields[logger.DefaultErrorKey].(error), errServiceNotFound)
+	})
+
+	// The panics are converted to codes.Internal.
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "panic"})
+	st = status.Convert(err)
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "internal server error", st.Message())
+	recorder.AssertLogged(t, logger.ERROR, "Panic recovered", logger.HasField(logger.DefaultPanicKey, "boom"))
+	recorder.AssertLogged(t, logger.ERROR, "gRPC request")
+
+	// The handlers get the span of the request.
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "traced"})
+	require.NoError(t, err)
+
+	received, err := watch(ctx, client, "panic")
+	assert.Equal(t, 2, received)
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	assert.Len(t, spans.Ended(), 4)
+	var buf strings.Builder
+	_, err = metrics.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), `grpc_server_handled_total{grpc_service="grpc.health.v1.Health",grpc_method="Check",grpc_type="unary",grpc_code="Internal"} 1`+"\n")
+	assert.Contains(t, buf.String(), `grpc_server_handled_total{grpc_service="grpc.health.v1.Health",grpc_method="Watch",grpc_type="server_stream",grpc_code="Internal"} 1`+"\n")
+}
+
+func TestDefaultInterceptors_SkipMethods(t *testing.T) {
+	log, recorder := logger.NewTestLogger()
+	tp, spans := newTracerProvider()
+	client := newClient(t, server.DefaultInterceptors(server.Config{
+		Logger:         log,
+		TracerProvider: tp,
+		Propagators:    propagation.TraceContext{},
+		SkipMethods:    []string{checkMethod},
+	}).ServerOptions()...)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Zero(t, recorder.Len())
+	assert.Empty(t, spans.Ended())
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "panic"})
+	assert.Equal(t, codes.Internal, status.Code(err), "the skipped methods still recover from panics")
+}
+
+func TestDefaultInterceptors_DisableTracing(t *testing.T) {
+	log, _ := logger.NewTestLogger()
+	interceptors := server.DefaultInterceptors(server.Config{Logger: log, DisableTracing: true})
+	assert.Len(t, interceptors.Unary, 3)
+	assert.Len(t, interceptors.Stream, 3)
+
+	client := newClient(t, interceptors.ServerOptions()...)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "traced"})
+	assert.Equal(t, codes.Unknown, status.Code(err))
+}
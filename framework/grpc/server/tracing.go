@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/kittipat1413/go-common/framework/errors/grpcstatus"
+)
+
+// tracerName is the instrumentation name of the tracer of the interceptors.
+const tracerName = "github.com/kittipat1413/go-common/framework/grpc/server"
+
+/*
+UnaryTracingInterceptor starts a server span for every request, as the child of the span propagated in its
+metadata, e.g., the W3C traceparent key, and passes it to the handler in the request context with the propagated
+baggage.
+
+The span is named after the full method of the request, e.g., "payment.Payments/Charge", and records the RPC
+attributes of the request and its gRPC status code. Its status is an error for the errors of the server, e.g.,
+codes.Internal, and for panics, which are recorded with their stack trace and re-panicked.
+
+The logs written with the request context carry the trace_id and span_id of the span, so install the interceptor
+before (outside of) the logging and recovery interceptors.
+
+Example usage:
+
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		server.UnaryTracingInterceptor(server.WithTracerProvider(tracerProvider)),
+		server.UnaryLoggingInterceptor(),
+	))
+*/
+func UnaryTracingInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+	tracer := o.tracerProvider.Tracer(tracerName)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		if o.skipMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		ctx, span := o.startSpan(ctx, tracer, info.FullMethod)
+		defer func() {
+			recovered := recover()
+			finish(span, err, recovered)
+			span.End()
+			if recovered != nil {
+				panic(recovered)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamTracingInterceptor starts a server span for every stream like UnaryTracingInterceptor.
+func StreamTracingInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	tracer := o.tracerProvider.Tracer(tracerName)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		if o.skipMethods[info.FullMethod] {
+			return handler(srv, ss)
+		}
+		ctx, span := o.startSpan(ss.Context(), tracer, info.FullMethod)
+		defer func() {
+			recovered := recover()
+			finish(span, err, recovered)
+			span.End()
+			if recovered != nil {
+				panic(recovered)
+			}
+		}()
+		return handler(srv, wrapServerStream(ctx, ss))
+	}
+}
+
+// startSpan starts the span of the request to fullMethod, as the child of the span propagated in its metadata.
+func (o *options) startSpan(ctx context.Context, tracer oteltrace.Tracer, fullMethod string) (context.Context, oteltrace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = o.propagators.Extract(ctx, metadataCarrier(md))
+
+	service, method := splitMethod(fullMethod)
+	attributes := []attribute.KeyValue{semconv.RPCSystemGRPC, semconv.RPCService(service), semconv.RPCMethod(method)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if ip, port, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			attributes = append(attributes, semconv.NetSockPeerAddr(ip))
+			if portNum, err := strconv.Atoi(port); err == nil {
+				attributes = append(attributes, semconv.NetSockPeerPort(portNum))
+			}
+		}
+	}
+	return tracer.Start(ctx, strings.TrimPrefix(fullMethod, "/"),
+		oteltrace.WithSpanKind(oteltrace.SpanKindServer),
+		oteltrace.WithAttributes(attributes...),
+	)
+}
+
+// finish records the status of the request on its span, or the value recovered from the panic of its handler.
+func finish(span oteltrace.Span, err error, recovered interface{}) {
+	if recovered != nil {
+		panicErr, ok := recovered.(error)
+		if !ok {
+			panicErr = fmt.Errorf("%v", recovered)
+		}
+		span.RecordError(panicErr, oteltrace.WithStackTrace(true))
+		span.SetStatus(otelcodes.Error, "panic: "+panicErr.Error())
+		return
+	}
+
+	st := grpcstatus.ToStatus(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(st.Code())))
+	// The errors of the clients, e.g., codes.InvalidArgument, are not errors of the server.
+	if serverError(st.Code()) {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, st.Message())
+	}
+}
+
+// metadataCarrier adapts the metadata of a request to a propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
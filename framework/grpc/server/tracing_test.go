@@ -0,0 +1,94 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/kittipat1413/go-common/framework/errors/grpcstatus"
+	"github.com/kittipat1413/go-common/framework/grpc/server"
+)
+
+func attributes(span tracesdk.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func TestTracingInterceptor(t *testing.T) {
+	tp, recorder := newTracerProvider()
+	client := newClient(t, grpc.ChainUnaryInterceptor(
+		server.UnaryTracingInterceptor(server.WithTracerProvider(tp)),
+		grpcstatus.UnaryServerInterceptor(),
+	))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "traced"})
+	require.NoError(t, err)
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+	require.Error(t, err)
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "failing"})
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	span := spans[0]
+	assert.Equal(t, "grpc.health.v1.Health/Check", span.Name())
+	assert.Equal(t, oteltrace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+	attrs := attributes(span)
+	assert.Equal(t, "grpc", attrs[semconv.RPCSystemKey].AsString())
+	assert.Equal(t, "grpc.health.v1.Health", attrs[semconv.RPCServiceKey].AsString())
+	assert.Equal(t, "Check", attrs[semconv.RPCMethodKey].AsString())
+	assert.Equal(t, int64(0), attrs[semconv.RPCGRPCStatusCodeKey].AsInt64())
+
+	assert.Equal(t, codes.Unset, spans[1].Status().Code, "the errors of the clients are not errors of the server")
+	assert.Equal(t, int64(5), attributes(spans[1])[semconv.RPCGRPCStatusCodeKey].AsInt64())
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+	assert.Equal(t, "connection refused", spans[2].Status().Description)
+}
+
+func TestTracingInterceptor_Panic(t *testing.T) {
+	tp, recorder := newTracerProvider()
+	interceptor := server.UnaryTracingInterceptor(server.WithTracerProvider(tp))
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_, _ = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: checkMethod},
+			func(ctx context.Context, req interface{}) (interface{}, error) { panic("boom") })
+	})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "panic: boom", spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "exception", spans[0].Events()[0].Name)
+}
+
+func TestStreamTracingInterceptor(t *testing.T) {
+	tp, recorder := newTracerProvider()
+	client := newClient(t, grpc.StreamInterceptor(server.StreamTracingInterceptor(server.WithTracerProvider(tp))))
+
+	received, err := watch(context.Background(), client, "")
+	assert.Equal(t, 2, received)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "grpc.health.v1.Health/Watch", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+}
@@ -0,0 +1,22 @@
+package health
+
+import "context"
+
+// Pinger is implemented by the clients checking their connection with PingContext, e.g., *sql.DB and *sql.Conn.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+/*
+PingCheck returns a check pinging p, e.g., a database.
+
+Clients pinging with another method are checked with a CheckFunc, e.g., a *redis.Client of
+github.com/redis/go-redis:
+
+	registry.Register("redis", func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	})
+*/
+func PingCheck(p Pinger) CheckFunc {
+	return p.PingContext
+}
@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Paths of the probes, by convention.
+const (
+	LivenessPath  = "/livez"
+	ReadinessPath = "/readyz"
+)
+
+// LivenessHandler returns a handler responding with the JSON liveness report, with the 503 status if it is down,
+// and 200 otherwise.
+func (r *Registry) LivenessHandler() http.Handler {
+	return reportHandler(r.Liveness)
+}
+
+// ReadinessHandler returns a handler responding with the JSON readiness report, with the 503 status if it is down,
+// e.g., while the instance shuts down, and 200 otherwise.
+func (r *Registry) ReadinessHandler() http.Handler {
+	return reportHandler(r.Readiness)
+}
+
+/*
+RegisterHandlers registers the liveness and readiness handlers on mux, at LivenessPath and ReadinessPath.
+
+Example usage:
+
+	mux := http.NewServeMux()
+	registry.RegisterHandlers(mux)
+*/
+func (r *Registry) RegisterHandlers(mux *http.ServeMux) {
+	mux.Handle(LivenessPath, r.LivenessHandler())
+	mux.Handle(ReadinessPath, r.ReadinessHandler())
+}
+
+func reportHandler(report func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rep := report(req.Context())
+		status := http.StatusOK
+		if rep.Status == StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(rep)
+	})
+}
@@ -0,0 +1,69 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/health"
+)
+
+func TestHandlers(t *testing.T) {
+	registry := health.NewRegistry(health.WithCacheDuration(0))
+	require.NoError(t, registry.RegisterLiveness("goroutines", ok))
+	require.NoError(t, registry.Register("db", ok))
+	dbErr := error(nil)
+	require.NoError(t, registry.Register("postgres", func(ctx context.Context) error { return dbErr }))
+	require.NoError(t, registry.Register("cache", failing, health.WithCritical(false)))
+
+	mux := http.NewServeMux()
+	registry.RegisterHandlers(mux)
+
+	serve := func(path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec, body
+	}
+
+	rec, body := serve(health.LivenessPath)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	assert.Equal(t, "up", body["status"])
+
+	rec, body = serve(health.ReadinessPath)
+	assert.Equal(t, http.StatusOK, rec.Code, "degraded instances are ready")
+	assert.Equal(t, "degraded", body["status"])
+	assert.NotEmpty(t, body["checked_at"])
+	checks := body["checks"].(map[string]interface{})
+	cache := checks["cache"].(map[string]interface{})
+	assert.Equal(t, "down", cache["status"])
+	assert.Equal(t, false, cache["critical"])
+	assert.Equal(t, "connection refused", cache["error"])
+	assert.NotEmpty(t, cache["duration"])
+	db := checks["db"].(map[string]interface{})
+	assert.Equal(t, "up", db["status"])
+	assert.NotContains(t, db, "error")
+
+	dbErr = errors.New("too many connections")
+	rec, body = serve(health.ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "down", body["status"])
+
+	dbErr = nil
+	registry.SetReady(false)
+	rec, body = serve(health.ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "down", body["status"])
+	assert.NotContains(t, body, "checks")
+	rec, _ = serve(health.LivenessPath)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,329 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultTimeout is the time a check may take before it fails, unless set with WithDefaultTimeout or
+	// WithCheckTimeout.
+	DefaultTimeout = 5 * time.Second
+	// DefaultCacheDuration is the time the report of the checks is reused for, unless set with WithCacheDuration.
+	DefaultCacheDuration = time.Second
+)
+
+var (
+	// ErrInvalidName is returned when registering a check without a name.
+	ErrInvalidName = errors.New("health: the check name is empty")
+	// ErrDuplicateCheck is returned when registering a check under the name of a registered one.
+	ErrDuplicateCheck = errors.New("health: a check is already registered with this name")
+	// ErrTimeout is the error of the checks taking longer than their timeout.
+	ErrTimeout = errors.New("health check timed out")
+)
+
+// Status is the status of a check, or of a report.
+type Status string
+
+const (
+	// StatusUp tells that the check, or all the checks of the report, succeeded.
+	StatusUp Status = "up"
+	// StatusDegraded tells that some non-critical checks of the report failed: the service still works, without
+	// some of its features.
+	StatusDegraded Status = "degraded"
+	// StatusDown tells that the check, or a critical check of the report, failed, or that the service is not
+	// ready.
+	StatusDown Status = "down"
+)
+
+// CheckFunc checks a dependency or a component of the service, e.g., pings a database. It returns nil if it is
+// healthy. It must return when ctx is done, and may be called concurrently.
+type CheckFunc func(ctx context.Context) error
+
+// options holds configuration options for the Registry.
+type options struct {
+	defaultTimeout time.Duration
+	cacheDuration  time.Duration
+}
+
+// Option specifies Registry configuration options.
+type Option func(*options)
+
+// WithDefaultTimeout sets the time the checks may take before they fail, unless set with WithCheckTimeout. It
+// defaults to DefaultTimeout.
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.defaultTimeout = d
+		}
+	}
+}
+
+// WithCacheDuration sets the time the report of the checks is reused for, so that frequent probes of several
+// instances do not overload the dependencies. It defaults to DefaultCacheDuration, and 0 disables the cache.
+func WithCacheDuration(d time.Duration) Option {
+	return func(opts *options) {
+		if d >= 0 {
+			opts.cacheDuration = d
+		}
+	}
+}
+
+// checkOptions holds configuration options for a check.
+type checkOptions struct {
+	timeout  time.Duration
+	critical bool
+}
+
+// CheckOption specifies check configuration options.
+type CheckOption func(*checkOptions)
+
+// WithCheckTimeout sets the time the check may take before it fails with ErrTimeout. It defaults to the timeout
+// of WithDefaultTimeout.
+func WithCheckTimeout(d time.Duration) CheckOption {
+	return func(opts *checkOptions) {
+		if d > 0 {
+			opts.timeout = d
+		}
+	}
+}
+
+// WithCritical tells whether the service is down when the check fails, which is the default. The failures of
+// non-critical checks, e.g., of a cache the service works without, only degrade the service.
+func WithCritical(critical bool) CheckOption {
+	return func(opts *checkOptions) {
+		opts.critical = critical
+	}
+}
+
+// check is a registered check.
+type check struct {
+	name string
+	fn   CheckFunc
+	checkOptions
+}
+
+/*
+Registry holds the liveness and readiness checks of a service, and reports their status, e.g., to the /livez and
+/readyz probes of Kubernetes with LivenessHandler and ReadinessHandler.
+
+Liveness checks tell whether the process works, and should not depend on external dependencies: a failing
+liveness probe restarts the instance. Readiness checks tell whether the instance can serve traffic, e.g., whether
+its database is reachable: a failing readiness probe only removes the instance from the load balancers. The
+instance is ready once created, and SetReady(false) makes it not ready regardless of its checks, e.g., while it
+shuts down.
+
+Example usage:
+
+	registry := health.NewRegistry()
+	_ = registry.Register("postgres", health.PingCheck(db), health.WithCheckTimeout(time.Second))
+	_ = registry.Register("redis", func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	}, health.WithCritical(false))
+
+	mux.Handle("/livez", registry.LivenessHandler())
+	mux.Handle("/readyz", registry.ReadinessHandler())
+*/
+type Registry struct {
+	opts      options
+	ready     atomic.Bool
+	liveness  checkSet
+	readiness checkSet
+}
+
+// NewRegistry creates a Registry without checks, ready.
+func NewRegistry(opts ...Option) *Registry {
+	r := &Registry{
+		opts: options{
+			defaultTimeout: DefaultTimeout,
+			cacheDuration:  DefaultCacheDuration,
+		},
+	}
+	for _, opt := range opts {
+		opt(&r.opts)
+	}
+	r.ready.Store(true)
+	return r
+}
+
+// Register adds a readiness check named name. It returns ErrInvalidName if name is empty, and ErrDuplicateCheck
+// if a readiness check is already registered with name.
+func (r *Registry) Register(name string, fn CheckFunc, opts ...CheckOption) error {
+	return r.readiness.add(r.newCheck(name, fn, opts))
+}
+
+// RegisterLiveness adds a liveness check named name, e.g., detecting a deadlock. It returns ErrInvalidName if
+// name is empty, and ErrDuplicateCheck if a liveness check is already registered with name.
+func (r *Registry) RegisterLiveness(name string, fn CheckFunc, opts ...CheckOption) error {
+	return r.liveness.add(r.newCheck(name, fn, opts))
+}
+
+func (r *Registry) newCheck(name string, fn CheckFunc, opts []CheckOption) *check {
+	c := &check{
+		name:         name,
+		fn:           fn,
+		checkOptions: checkOptions{timeout: r.opts.defaultTimeout, critical: true},
+	}
+	for _, opt := range opts {
+		opt(&c.checkOptions)
+	}
+	return c
+}
+
+// SetReady sets whether the instance is ready to serve traffic. While it is not, the readiness checks are not
+// run and the readiness report is down, e.g., while the instance shuts down and drains its requests.
+func (r *Registry) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+// Ready reports whether the instance is ready to serve traffic, as set with SetReady.
+func (r *Registry) Ready() bool {
+	return r.ready.Load()
+}
+
+// Liveness runs the liveness checks, or returns their report of less than the cache duration ago.
+func (r *Registry) Liveness(ctx context.Context) Report {
+	return r.liveness.report(ctx, r.opts.cacheDuration)
+}
+
+// Readiness runs the readiness checks, or returns their report of less than the cache duration ago. Its status is
+// down without running the checks if the instance is not ready, see SetReady.
+func (r *Registry) Readiness(ctx context.Context) Report {
+	if !r.Ready() {
+		return Report{Status: StatusDown, CheckedAt: time.Now()}
+	}
+	return r.readiness.report(ctx, r.opts.cacheDuration)
+}
+
+// Report is the result of the checks of a Registry. It must not be modified, since it may be cached.
+type Report struct {
+	// Status is down if a critical check failed, degraded if a non-critical check failed, and up otherwise.
+	Status Status `json:"status"`
+	// Checks are the results of the checks, by name.
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+	// CheckedAt is the time the checks were run at.
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// CheckResult is the result of a check.
+type CheckResult struct {
+	Status   Status `json:"status"`
+	Critical bool   `json:"critical"`
+	// Error is the error message of the failed checks.
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"-"`
+}
+
+// MarshalJSON encodes the result with its duration in a human-readable form, e.g., "1.5ms".
+func (r CheckResult) MarshalJSON() ([]byte, error) {
+	type result CheckResult
+	return json.Marshal(struct {
+		result
+		Duration string `json:"duration"`
+	}{result(r), r.Duration.String()})
+}
+
+// checkSet is a set of checks, run together, and their cached report.
+type checkSet struct {
+	mu      sync.Mutex
+	checks  []*check
+	version int
+
+	// runMu serializes the runs, so that concurrent probes share a report.
+	runMu         sync.Mutex
+	cached        *Report
+	cachedVersion int
+}
+
+func (s *checkSet) add(c *check) error {
+	if c.name == "" {
+		return ErrInvalidName
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, registered := range s.checks {
+		if registered.name == c.name {
+			return fmt.Errorf("%w: %s", ErrDuplicateCheck, c.name)
+		}
+	}
+	s.checks = append(s.checks, c)
+	s.version++
+	return nil
+}
+
+// report runs the checks concurrently, unless their report is less than cacheDuration old.
+func (s *checkSet) report(ctx context.Context, cacheDuration time.Duration) Report {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	s.mu.Lock()
+	checks, version := s.checks, s.version
+	s.mu.Unlock()
+	if s.cached != nil && s.cachedVersion == version && time.Since(s.cached.CheckedAt) < cacheDuration {
+		return *s.cached
+	}
+
+	// The report is shared by the callers until it expires: the first one must not cancel it.
+	ctx = context.WithoutCancel(ctx)
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks)), CheckedAt: time.Now()}
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for i, c := range checks {
+		result := results[i]
+		report.Checks[c.name] = result
+		if result.Status == StatusDown {
+			if c.critical {
+				report.Status = StatusDown
+			} else if report.Status == StatusUp {
+				report.Status = StatusDegraded
+			}
+		}
+	}
+	s.cached, s.cachedVersion = &report, version
+	return report
+}
+
+// run runs the check with its timeout. The checks not returning in time, or panicking, fail.
+func (c *check) run(ctx context.Context) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errs := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errs <- fmt.Errorf("health check panicked: %v", recovered)
+			}
+		}()
+		errs <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		err = fmt.Errorf("%w after %s", ErrTimeout, c.timeout)
+	}
+
+	result := CheckResult{Status: StatusUp, Critical: c.critical, Duration: time.Since(start)}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
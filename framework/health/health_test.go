@@ -0,0 +1,199 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/health"
+)
+
+func ok(ctx context.Context) error { return nil }
+
+func failing(ctx context.Context) error { return errors.New("connection refused") }
+
+func TestRegistry_Register(t *testing.T) {
+	registry := health.NewRegistry()
+
+	require.NoError(t, registry.Register("db", ok))
+	assert.ErrorIs(t, registry.Register("db", ok), health.ErrDuplicateCheck)
+	assert.ErrorIs(t, registry.Register("", ok), health.ErrInvalidName)
+	// Liveness and readiness checks have separate names.
+	assert.NoError(t, registry.RegisterLiveness("db", ok))
+}
+
+func TestRegistry_Readiness(t *testing.T) {
+	tests := []struct {
+		name           string
+		register       func(r *health.Registry)
+		expectedStatus health.Status
+	}{
+		{
+			name:           "no checks",
+			register:       func(r *health.Registry) {},
+			expectedStatus: health.StatusUp,
+		},
+		{
+			name: "all up",
+			register: func(r *health.Registry) {
+				_ = r.Register("db", ok)
+				_ = r.Register("cache", ok, health.WithCritical(false))
+			},
+			expectedStatus: health.StatusUp,
+		},
+		{
+			name: "non-critical check down",
+			register: func(r *health.Registry) {
+				_ = r.Register("db", ok)
+				_ = r.Register("cache", failing, health.WithCritical(false))
+			},
+			expectedStatus: health.StatusDegraded,
+		},
+		{
+			name: "critical check down",
+			register: func(r *health.Registry) {
+				_ = r.Register("db", failing)
+				_ = r.Register("cache", failing, health.WithCritical(false))
+			},
+			expectedStatus: health.StatusDown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := health.NewRegistry()
+			tt.register(registry)
+
+			report := registry.Readiness(context.Background())
+			assert.Equal(t, tt.expectedStatus, report.Status)
+			assert.False(t, report.CheckedAt.IsZero())
+		})
+	}
+}
+
+func TestRegistry_CheckResults(t *testing.T) {
+	registry := health.NewRegistry()
+	require.NoError(t, registry.Register("db", ok))
+	require.NoError(t, registry.Register("cache", failing, health.WithCritical(false)))
+	require.NoError(t, registry.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, health.WithCheckTimeout(10*time.Millisecond)))
+	require.NoError(t, registry.Register("stuck", func(ctx context.Context) error {
+		// The check ignores its context: it fails on timeout anyway.
+		time.Sleep(time.Second)
+		return nil
+	}, health.WithCheckTimeout(10*time.Millisecond)))
+	require.NoError(t, registry.Register("panicking", func(ctx context.Context) error {
+		panic("boom")
+	}))
+
+	start := time.Now()
+	report := registry.Readiness(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "the checks run concurrently with their timeout")
+
+	assert.Equal(t, health.StatusDown, report.Status)
+	require.Len(t, report.Checks, 5)
+	assert.Equal(t, health.StatusUp, report.Checks["db"].Status)
+	assert.True(t, report.Checks["db"].Critical)
+	assert.Empty(t, report.Checks["db"].Error)
+
+	assert.Equal(t, health.CheckResult{Status: health.StatusDown, Critical: false, Error: "connection refused", Duration: report.Checks["cache"].Duration}, report.Checks["cache"])
+	assert.Equal(t, "health check timed out after 10ms", report.Checks["slow"].Error)
+	assert.GreaterOrEqual(t, report.Checks["slow"].Duration, 10*time.Millisecond)
+	assert.Equal(t, "health check timed out after 10ms", report.Checks["stuck"].Error)
+	assert.Equal(t, "health check panicked: boom", report.Checks["panicking"].Error)
+}
+
+func TestRegistry_Cache(t *testing.T) {
+	var calls atomic.Int32
+	counting := func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		calls.Store(0)
+		registry := health.NewRegistry(health.WithCacheDuration(50 * time.Millisecond))
+		require.NoError(t, registry.Register("db", counting))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				registry.Readiness(context.Background())
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), calls.Load(), "concurrent probes share a report")
+
+		time.Sleep(60 * time.Millisecond)
+		registry.Readiness(context.Background())
+		assert.Equal(t, int32(2), calls.Load(), "the report expires")
+
+		require.NoError(t, registry.Register("cache", ok))
+		report := registry.Readiness(context.Background())
+		assert.Len(t, report.Checks, 2, "registering a check invalidates the report")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		calls.Store(0)
+		registry := health.NewRegistry(health.WithCacheDuration(0))
+		require.NoError(t, registry.RegisterLiveness("goroutines", counting))
+
+		registry.Liveness(context.Background())
+		registry.Liveness(context.Background())
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
+
+func TestRegistry_SetReady(t *testing.T) {
+	var calls atomic.Int32
+	registry := health.NewRegistry(health.WithCacheDuration(0))
+	require.NoError(t, registry.Register("db", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}))
+	require.NoError(t, registry.RegisterLiveness("goroutines", ok))
+	assert.True(t, registry.Ready())
+
+	registry.SetReady(false)
+	assert.False(t, registry.Ready())
+	report := registry.Readiness(context.Background())
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Empty(t, report.Checks)
+	assert.Zero(t, calls.Load(), "the checks are not run")
+	assert.Equal(t, health.StatusUp, registry.Liveness(context.Background()).Status, "the instance is still alive")
+
+	registry.SetReady(true)
+	assert.Equal(t, health.StatusUp, registry.Readiness(context.Background()).Status)
+}
+
+func TestRegistry_CanceledContext(t *testing.T) {
+	registry := health.NewRegistry()
+	require.NoError(t, registry.Register("db", func(ctx context.Context) error {
+		return ctx.Err()
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// The report is shared with the other probes: the canceled probe does not fail it.
+	assert.Equal(t, health.StatusUp, registry.Readiness(ctx).Status)
+}
+
+type pinger struct {
+	err error
+}
+
+func (p pinger) PingContext(ctx context.Context) error { return p.err }
+
+func TestPingCheck(t *testing.T) {
+	assert.NoError(t, health.PingCheck(pinger{})(context.Background()))
+	assert.EqualError(t, health.PingCheck(pinger{err: errors.New("bad connection")})(context.Background()), "bad connection")
+}
@@ -0,0 +1,173 @@
+package httpbind
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/validator"
+)
+
+// DefaultMaxBodySize is the maximum size of the request bodies, in bytes, unless set with WithMaxBodySize.
+const DefaultMaxBodySize int64 = 1 << 20
+
+// CodeBodyTooLarge is the code of the errors of the request bodies larger than the maximum size.
+const CodeBodyTooLarge = "body_too_large"
+
+var (
+	// ErrEmptyBody is returned when the request has no body.
+	ErrEmptyBody = errors.InvalidArgument(validator.CodeInvalidBody, "request body is empty")
+	// ErrInvalidBody is returned when the body is not a single JSON value of the bound type.
+	ErrInvalidBody = errors.InvalidArgument(validator.CodeInvalidBody, "invalid request body")
+	// ErrBodyTooLarge is returned when the body is larger than the maximum size, with the "max_bytes" field. It is
+	// an InvalidArgument error: map its code to 413 with errors.WithCodeStatus if needed.
+	ErrBodyTooLarge = errors.InvalidArgument(CodeBodyTooLarge, "request body is too large")
+)
+
+// options holds configuration options for the binding of the requests.
+type options struct {
+	maxBodySize           int64
+	disallowUnknownFields bool
+	validator             *validator.Validator
+	validate              bool
+}
+
+// Option specifies the binding of the requests configuration options.
+type Option func(*options)
+
+// WithMaxBodySize sets the maximum size of the request bodies, in bytes. It defaults to DefaultMaxBodySize.
+func WithMaxBodySize(size int64) Option {
+	return func(opts *options) {
+		if size > 0 {
+			opts.maxBodySize = size
+		}
+	}
+}
+
+// WithDisallowUnknownFields rejects the bodies with fields unknown to the bound type, instead of ignoring them.
+func WithDisallowUnknownFields() Option {
+	return func(opts *options) {
+		opts.disallowUnknownFields = true
+	}
+}
+
+// WithValidator sets the Validator validating the bound values. It defaults to validator.Default().
+func WithValidator(v *validator.Validator) Option {
+	return func(opts *options) {
+		if v != nil {
+			opts.validator = v
+		}
+	}
+}
+
+// WithoutValidation disables the validation of the bound values.
+func WithoutValidation() Option {
+	return func(opts *options) {
+		opts.validate = false
+	}
+}
+
+/*
+JSON decodes the JSON body of r into a value of type T, and validates it with the `validate` tags of T if it is a
+struct or a pointer to a struct. The errors are InvalidArgument *errors.CodedError, ready for the errors.ProblemWriter
+or httpresponse.Error:
+  - ErrEmptyBody or ErrInvalidBody, with the "field" field for unknown fields and fields of the wrong type, if the
+    body cannot be decoded.
+  - ErrBodyTooLarge if the body is larger than the maximum size.
+  - An error with the validator.CodeValidationFailed code and the "violations" field if the value is invalid.
+
+Example usage:
+
+	type createOrderRequest struct {
+		ProductID string `json:"product_id" validate:"required"`
+		Quantity  int    `json:"quantity" validate:"min=1"`
+	}
+
+	func (h *orderHandler) Create(w http.ResponseWriter, r *http.Request) {
+		req, err := httpbind.JSON[createOrderRequest](r, httpbind.WithDisallowUnknownFields())
+		if err != nil {
+			httpresponse.Error(w, r, err)
+			return
+		}
+		...
+	}
+*/
+func JSON[T any](r *http.Request, opts ...Option) (T, error) {
+	o := options{maxBodySize: DefaultMaxBodySize, validate: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var value T
+	if r.Body == nil || r.Body == http.NoBody {
+		return value, ErrEmptyBody
+	}
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, o.maxBodySize))
+	if o.disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(&value); err != nil {
+		return value, decodeError(err, o.maxBodySize)
+	}
+	if err := decoder.Decode(&json.RawMessage{}); !stderrors.Is(err, io.EOF) {
+		if err != nil {
+			return value, decodeError(err, o.maxBodySize)
+		}
+		return value, errors.InvalidArgument(validator.CodeInvalidBody, "request body must contain a single JSON value")
+	}
+
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		// The body is null.
+		return value, ErrInvalidBody
+	}
+	if o.validate && isStruct(reflect.TypeOf(value)) {
+		v := o.validator
+		if v == nil {
+			v = validator.Default()
+		}
+		if err := v.ValidateRequest(value); err != nil {
+			return value, err
+		}
+	}
+	return value, nil
+}
+
+// decodeError returns the error of a decoding error of a body.
+func decodeError(err error, maxBodySize int64) error {
+	var maxBytesErr *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case stderrors.Is(err, io.EOF):
+		return ErrEmptyBody
+	case stderrors.As(err, &maxBytesErr):
+		return ErrBodyTooLarge.WithField("max_bytes", maxBodySize)
+	case stderrors.As(err, &typeErr) && typeErr.Field != "":
+		return errors.InvalidArgument(validator.CodeInvalidBody, fmt.Sprintf("invalid type for field %q", typeErr.Field)).
+			WithField("field", typeErr.Field).
+			Wrap(err)
+	}
+	// The decoder does not type the errors of the unknown fields.
+	if field, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		field = strings.Trim(field, `"`)
+		return errors.InvalidArgument(validator.CodeInvalidBody, fmt.Sprintf("unknown field %q", field)).
+			WithField("field", field).
+			Wrap(err)
+	}
+	return ErrInvalidBody.Wrap(err)
+}
+
+// isStruct reports whether t is a struct or a pointer to a struct.
+func isStruct(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
@@ -0,0 +1,176 @@
+package httpbind_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/httpbind"
+	"github.com/kittipat1413/go-common/framework/validator"
+)
+
+type createOrderRequest struct {
+	ProductID string `json:"product_id" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"min=1"`
+}
+
+func newRequest(body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name            string
+		request         *http.Request
+		opts            []httpbind.Option
+		expected        createOrderRequest
+		expectedCode    string
+		expectedMessage string
+		expectedFields  map[string]interface{}
+	}{
+		{
+			name:     "valid body",
+			request:  newRequest(`{"product_id": "p-1", "quantity": 2}`),
+			expected: createOrderRequest{ProductID: "p-1", Quantity: 2},
+		},
+		{
+			name:     "unknown fields ignored",
+			request:  newRequest(`{"product_id": "p-1", "quantity": 2, "coupon": "SALE"}`),
+			expected: createOrderRequest{ProductID: "p-1", Quantity: 2},
+		},
+		{
+			name:            "unknown fields disallowed",
+			request:         newRequest(`{"product_id": "p-1", "quantity": 2, "coupon": "SALE"}`),
+			opts:            []httpbind.Option{httpbind.WithDisallowUnknownFields()},
+			expectedCode:    validator.CodeInvalidBody,
+			expectedMessage: `unknown field "coupon"`,
+			expectedFields:  map[string]interface{}{"field": "coupon"},
+		},
+		{
+			name:            "no body",
+			request:         httptest.NewRequest(http.MethodPost, "/orders", nil),
+			expectedCode:    validator.CodeInvalidBody,
+			expectedMessage: "request body is empty",
+		},
+		{
+			name:            "empty body",
+			request:         newRequest(""),
+			expectedCode:    validator.CodeInvalidBody,
+			expectedMessage: "request body is empty",
+		},
+		{
+			name:            "null body",
+			request:         newRequest("null"),
+			expected:        createOrderRequest{},
+			expectedCode:    validator.CodeValidationFailed,
+			expectedMessage: "validation failed",
+		},
+		{
+			name:            "malformed body",
+			request:         newRequest(`{"product_id": `),
+			expectedCode:    validator.CodeInvalidBody,
+			expectedMessage: "invalid request body",
+		},
+		{
+			name:            "wrong type",
+			request:         newRequest(`{"product_id": "p-1", "quantity": "two"}`),
+			expectedCode:    validator.CodeInvalidBody,
+			expectedMessage: `invalid type for field "quantity"`,
+			expectedFields:  map[string]interface{}{"field": "quantity"},
+		},
+		{
+			name:            "several values",
+			request:         newRequest(`{"product_id": "p-1", "quantity": 2} {}`),
+			expectedCode:    validator.CodeInvalidBody,
+			expectedMessage: "request body must contain a single JSON value",
+		},
+		{
+			name:            "trailing garbage",
+			request:         newRequest(`{"product_id": "p-1", "quantity": 2} ]`),
+			expectedCode:    validator.CodeInvalidBody,
+			expectedMessage: "invalid request body",
+		},
+		{
+			name:            "body too large",
+			request:         newRequest(`{"product_id": "` + strings.Repeat("p", 100) + `", "quantity": 2}`),
+			opts:            []httpbind.Option{httpbind.WithMaxBodySize(64)},
+			expectedCode:    httpbind.CodeBodyTooLarge,
+			expectedMessage: "request body is too large",
+			expectedFields:  map[string]interface{}{"max_bytes": int64(64)},
+		},
+		{
+			name:            "invalid value",
+			request:         newRequest(`{"quantity": 0}`),
+			expectedCode:    validator.CodeValidationFailed,
+			expectedMessage: "validation failed",
+			expectedFields: map[string]interface{}{"violations": []validator.Violation{
+				{Field: "product_id", Rule: "required", Message: "product_id is a required field"},
+				{Field: "quantity", Rule: "min", Param: "1", Message: "quantity must be 1 or greater"},
+			}},
+		},
+		{
+			name:     "validation disabled",
+			request:  newRequest(`{"quantity": 0}`),
+			opts:     []httpbind.Option{httpbind.WithoutValidation()},
+			expected: createOrderRequest{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := httpbind.JSON[createOrderRequest](tt.request, tt.opts...)
+			if tt.expectedCode == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, req)
+				return
+			}
+			var coded *domain_error.CodedError
+			require.ErrorAs(t, err, &coded)
+			assert.Equal(t, domain_error.KindInvalidArgument, coded.Kind())
+			assert.Equal(t, tt.expectedCode, coded.Code())
+			assert.Equal(t, tt.expectedMessage, coded.Message())
+			if tt.expectedFields != nil {
+				assert.Equal(t, tt.expectedFields, coded.Fields())
+			}
+			assert.Equal(t, http.StatusBadRequest, domain_error.HTTPStatusOf(err))
+		})
+	}
+}
+
+func TestJSON_Pointer(t *testing.T) {
+	req, err := httpbind.JSON[*createOrderRequest](newRequest(`{"product_id": "p-1", "quantity": 2}`))
+	require.NoError(t, err)
+	assert.Equal(t, &createOrderRequest{ProductID: "p-1", Quantity: 2}, req)
+
+	_, err = httpbind.JSON[*createOrderRequest](newRequest(`{"product_id": "p-1"}`))
+	assert.Equal(t, validator.CodeValidationFailed, domain_error.CodeOf(err))
+
+	_, err = httpbind.JSON[*createOrderRequest](newRequest("null"))
+	assert.ErrorIs(t, err, httpbind.ErrInvalidBody)
+}
+
+func TestJSON_NonStruct(t *testing.T) {
+	ids, err := httpbind.JSON[[]string](newRequest(`["a", "b"]`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, ids)
+
+	_, err = httpbind.JSON[[]string](newRequest(`{"a": "b"}`))
+	assert.ErrorIs(t, err, httpbind.ErrInvalidBody)
+}
+
+func TestJSON_CustomValidator(t *testing.T) {
+	v, err := validator.NewValidator()
+	require.NoError(t, err)
+
+	_, err = httpbind.JSON[createOrderRequest](newRequest(`{"quantity": 1}`), httpbind.WithValidator(v))
+	var coded *domain_error.CodedError
+	require.ErrorAs(t, err, &coded)
+	violations, ok := coded.Fields()["violations"].([]validator.Violation)
+	require.True(t, ok)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "ProductID", violations[0].Field, "the validator names the fields after the struct fields")
+}
@@ -0,0 +1,75 @@
+package httpclient
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"sync"
+
+	"github.com/sony/gobreaker"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+)
+
+// CodeCircuitOpen is the code of the errors of the requests failed fast by an open circuit breaker.
+const CodeCircuitOpen = "circuit_open"
+
+// ErrCircuitOpen is returned, with the "host" field, when the circuit breaker of the host of a request is open, or
+// half-open with too many requests. It is an Unavailable error, not retried by the client.
+var ErrCircuitOpen = errors.Unavailable(CodeCircuitOpen, "circuit breaker is open")
+
+// errServerFailure is the error of the responses with a 5xx status, counted as failures by the circuit breakers.
+var errServerFailure = stderrors.New("httpclient: server failure")
+
+// breakerTransport fails fast the requests to the failing hosts, with a circuit breaker per host.
+type breakerTransport struct {
+	next     http.RoundTripper
+	settings gobreaker.Settings
+	mu       sync.Mutex
+	breakers map[string]*gobreaker.CircuitBreaker
+}
+
+func newBreakerTransport(next http.RoundTripper, settings gobreaker.Settings) *breakerTransport {
+	if settings.IsSuccessful == nil {
+		settings.IsSuccessful = func(err error) bool {
+			// Requests canceled by the caller say nothing of the health of the host.
+			return err == nil || stderrors.Is(err, context.Canceled)
+		}
+	}
+	return &breakerTransport{next: next, settings: settings, breakers: make(map[string]*gobreaker.CircuitBreaker)}
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	var resp *http.Response
+	_, err := t.breaker(host).Execute(func() (interface{}, error) {
+		var err error
+		resp, err = t.next.RoundTrip(req)
+		if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+			return nil, errServerFailure
+		}
+		return nil, err
+	})
+	switch {
+	case err == nil || stderrors.Is(err, errServerFailure):
+		return resp, nil
+	case stderrors.Is(err, gobreaker.ErrOpenState) || stderrors.Is(err, gobreaker.ErrTooManyRequests):
+		return nil, ErrCircuitOpen.WithField("host", host).Wrap(err)
+	default:
+		return nil, err
+	}
+}
+
+// breaker returns the circuit breaker of host, created on first use.
+func (t *breakerTransport) breaker(host string) *gobreaker.CircuitBreaker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cb, ok := t.breakers[host]
+	if !ok {
+		settings := t.settings
+		settings.Name = host
+		cb = gobreaker.NewCircuitBreaker(settings)
+		t.breakers[host] = cb
+	}
+	return cb
+}
@@ -0,0 +1,413 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+)
+
+const (
+	// DefaultCacheMaxBodySize is the maximum size of the bodies of the cached responses, unless set with
+	// WithCacheMaxBodySize.
+	DefaultCacheMaxBodySize = 1 << 20
+	// DefaultCacheStaleRetention is the time the stale responses with a validator are kept to be revalidated, unless
+	// set with WithCacheStaleRetention.
+	DefaultCacheStaleRetention = time.Hour
+)
+
+// CacheStatusHeader is the header added to the responses of the caching transport, telling whether they come from
+// the cache: CacheHit, CacheRevalidated or CacheMiss.
+const CacheStatusHeader = "X-Cache-Status"
+
+// Values of CacheStatusHeader.
+const (
+	// CacheHit is the cache status of the fresh responses served from the cache.
+	CacheHit = "HIT"
+	// CacheRevalidated is the cache status of the stale responses served from the cache after a 304 response.
+	CacheRevalidated = "REVALIDATED"
+	// CacheMiss is the cache status of the responses of the server.
+	CacheMiss = "MISS"
+)
+
+// CachedResponse is a response stored in the cache by the caching transport. Its fields are exported so that the
+// remote caches can serialize it, e.g., with cache.JSONCodec.
+type CachedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	// StoredAt is when the response was received or revalidated.
+	StoredAt time.Time `json:"stored_at"`
+	// Vary holds the values of the request headers named by the Vary header of the response, which the requests
+	// served by the response must match.
+	Vary map[string]string `json:"vary,omitempty"`
+}
+
+// cacheOptions holds configuration options for the caching transport.
+type cacheOptions struct {
+	key            func(req *http.Request) string
+	maxBodySize    int64
+	staleRetention time.Duration
+}
+
+// CacheOption specifies caching transport configuration options.
+type CacheOption func(*cacheOptions)
+
+// WithCacheKey sets the function returning the cache key of a request. It defaults to "httpclient:" followed by
+// the URL of the request. Prefix the keys of a cache shared by several clients, or add the identity of the caller
+// for the responses which depend on it without a Vary header.
+func WithCacheKey(key func(req *http.Request) string) CacheOption {
+	return func(opts *cacheOptions) {
+		if key != nil {
+			opts.key = key
+		}
+	}
+}
+
+// WithCacheMaxBodySize sets the maximum size of the bodies of the cached responses, in bytes. Larger responses are
+// not cached. It defaults to DefaultCacheMaxBodySize.
+func WithCacheMaxBodySize(size int64) CacheOption {
+	return func(opts *cacheOptions) {
+		if size > 0 {
+			opts.maxBodySize = size
+		}
+	}
+}
+
+// WithCacheStaleRetention sets the time the stale responses with a validator, i.e., an ETag or Last-Modified
+// header, are kept after their expiration to be revalidated. It defaults to DefaultCacheStaleRetention.
+func WithCacheStaleRetention(d time.Duration) CacheOption {
+	return func(opts *cacheOptions) {
+		if d >= 0 {
+			opts.staleRetention = d
+		}
+	}
+}
+
+// WithCache caches the GET responses in c, see NewCachingTransport. The cache is the outermost layer of the
+// transport, so that the fresh responses served from the cache are neither retried nor logged.
+func WithCache(c cache.Cache[CachedResponse], opts ...CacheOption) Option {
+	return func(o *options) {
+		o.cache = c
+		o.cacheOptions = opts
+	}
+}
+
+/*
+NewCachingTransport returns a transport caching the GET responses of next in c, any cache.Cache backend, following
+their Cache-Control, Expires, ETag and Last-Modified headers, so that the clients of slow APIs get transparent
+caching:
+  - The responses with max-age, or Expires, are served from the cache while fresh.
+  - The stale responses with an ETag or Last-Modified header are revalidated with If-None-Match or
+    If-Modified-Since, and served from the cache on a 304 response.
+  - The responses with no-store, Vary: *, or a body larger than the maximum size are not cached. The responses
+    with no-cache are revalidated every time.
+  - The requests with no-store, or their own conditional headers, bypass the cache, and the requests with no-cache
+    are revalidated.
+  - The successful POST, PUT, PATCH and DELETE requests invalidate the cached response of their URL.
+
+The cache acts as a private cache for the service: the responses depending on the caller, e.g., on the
+Authorization header, must name it in their Vary header, or the caller must be part of the key, see WithCacheKey.
+
+Example usage:
+
+	responses := localcache.New[httpclient.CachedResponse](localcache.WithMaxEntries(10000))
+	client := &http.Client{Transport: httpclient.NewCachingTransport(http.DefaultTransport, responses)}
+*/
+func NewCachingTransport(next http.RoundTripper, c cache.Cache[CachedResponse], opts ...CacheOption) http.RoundTripper {
+	o := cacheOptions{
+		key:            func(req *http.Request) string { return "httpclient:" + req.URL.String() },
+		maxBodySize:    DefaultCacheMaxBodySize,
+		staleRetention: DefaultCacheStaleRetention,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &cachingTransport{next: next, cache: c, opts: o}
+}
+
+// cachingTransport caches the GET responses.
+type cachingTransport struct {
+	next  http.RoundTripper
+	cache cache.Cache[CachedResponse]
+	opts  cacheOptions
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		resp, err := t.next.RoundTrip(req)
+		if err == nil && resp.StatusCode < http.StatusBadRequest {
+			get := req.Clone(ctx)
+			get.Method = http.MethodGet
+			_ = t.cache.Invalidate(ctx, t.opts.key(get))
+		}
+		return resp, err
+	default:
+		return t.next.RoundTrip(req)
+	}
+
+	requestCC := parseCacheControl(req.Header)
+	if _, noStore := requestCC["no-store"]; noStore || conditional(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	key := t.opts.key(req)
+	cached, err := t.cache.Get(ctx, key, nil)
+	if err != nil || !cached.matches(req) {
+		// Cache errors are misses: the cache must not fail the requests.
+		return t.fetch(req, key, nil)
+	}
+	_, noCache := requestCC["no-cache"]
+	if !noCache && cached.fresh(time.Now()) {
+		return cached.response(req, CacheHit), nil
+	}
+	if !cached.revalidable() {
+		return t.fetch(req, key, nil)
+	}
+
+	revalidation := req.Clone(ctx)
+	if etag := cached.Header.Get("ETag"); etag != "" {
+		revalidation.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+		revalidation.Header.Set("If-Modified-Since", lastModified)
+	}
+	return t.fetch(revalidation, key, &cached)
+}
+
+// fetch gets the response of req from the server, and caches it if it can be. cached is the stale response being
+// revalidated, if any.
+func (t *cachingTransport) fetch(req *http.Request, key string, cached *CachedResponse) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		drain(resp)
+		// The 304 response updates the headers of the stored response.
+		revalidated := *cached
+		revalidated.Header = cached.Header.Clone()
+		for name, values := range resp.Header {
+			if name != "Content-Length" {
+				revalidated.Header[name] = values
+			}
+		}
+		revalidated.StoredAt = now
+		t.store(req.Context(), key, revalidated)
+		return revalidated.response(req, CacheRevalidated), nil
+	}
+
+	resp.Header.Set(CacheStatusHeader, CacheMiss)
+	if !storable(req, resp) {
+		return resp, nil
+	}
+	body, complete, err := readLimited(resp.Body, t.opts.maxBodySize)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	if !complete {
+		// The body is too large: it is returned without being cached.
+		resp.Body = &multiReadCloser{Reader: io.MultiReader(bytes.NewReader(body), resp.Body), Closer: resp.Body}
+		return resp, nil
+	}
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	stored := CachedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+		StoredAt:   now,
+		Vary:       varyValues(req, resp.Header),
+	}
+	stored.Header.Del(CacheStatusHeader)
+	t.store(req.Context(), key, stored)
+	return resp, nil
+}
+
+// store caches response for its freshness lifetime, and the stale retention if it can be revalidated.
+func (t *cachingTransport) store(ctx context.Context, key string, response CachedResponse) {
+	duration := response.lifetime() - response.initialAge()
+	if response.revalidable() {
+		duration += t.opts.staleRetention
+	}
+	if duration <= 0 {
+		return
+	}
+	t.cache.Set(ctx, key, response, &duration)
+}
+
+// matches reports whether r can serve req, by the values of the request headers named by its Vary header.
+func (r *CachedResponse) matches(req *http.Request) bool {
+	for name, value := range r.Vary {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// lifetime returns the freshness lifetime of r: its max-age, or the time from its Date to its Expires header.
+func (r *CachedResponse) lifetime() time.Duration {
+	cc := parseCacheControl(r.Header)
+	if _, noCache := cc["no-cache"]; noCache {
+		return 0
+	}
+	if maxAge, ok := cc["max-age"]; ok {
+		if seconds, err := strconv.Atoi(maxAge); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		return 0
+	}
+	if expires := r.Header.Get("Expires"); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil {
+			// Invalid dates, e.g., "0", are in the past.
+			return 0
+		}
+		date := r.StoredAt
+		if d, err := http.ParseTime(r.Header.Get("Date")); err == nil {
+			date = d
+		}
+		return expiresAt.Sub(date)
+	}
+	return 0
+}
+
+// initialAge returns the age of r when it was stored, from its Age header.
+func (r *CachedResponse) initialAge() time.Duration {
+	if seconds, err := strconv.Atoi(r.Header.Get("Age")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
+
+// age returns the age of r at now.
+func (r *CachedResponse) age(now time.Time) time.Duration {
+	return r.initialAge() + now.Sub(r.StoredAt)
+}
+
+// fresh reports whether r can be served without revalidation at now.
+func (r *CachedResponse) fresh(now time.Time) bool {
+	return r.age(now) < r.lifetime()
+}
+
+// revalidable reports whether r has a validator, i.e., an ETag or Last-Modified header.
+func (r *CachedResponse) revalidable() bool {
+	return r.Header.Get("ETag") != "" || r.Header.Get("Last-Modified") != ""
+}
+
+// response returns r as the response of req.
+func (r *CachedResponse) response(req *http.Request, status string) *http.Response {
+	header := r.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(r.age(time.Now()).Seconds())))
+	header.Set(CacheStatusHeader, status)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// cacheableStatuses are the statuses of the responses which can be cached.
+var cacheableStatuses = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusPermanentRedirect:    true,
+}
+
+// storable reports whether the response resp of req can be cached.
+func storable(req *http.Request, resp *http.Response) bool {
+	if !cacheableStatuses[resp.StatusCode] || resp.Header.Get("Vary") == "*" {
+		return false
+	}
+	if _, noStore := parseCacheControl(resp.Header)["no-store"]; noStore {
+		return false
+	}
+	_, noStore := parseCacheControl(req.Header)["no-store"]
+	return !noStore
+}
+
+// conditional reports whether req has conditional headers of its own.
+func conditional(req *http.Request) bool {
+	for _, name := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range", "Range"} {
+		if req.Header.Get(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// varyValues returns the values of the request headers named by the Vary header of the response.
+func varyValues(req *http.Request, header http.Header) map[string]string {
+	var values map[string]string
+	for _, vary := range header.Values("Vary") {
+		for _, name := range strings.Split(vary, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if values == nil {
+				values = make(map[string]string)
+			}
+			values[name] = req.Header.Get(name)
+		}
+	}
+	return values
+}
+
+// parseCacheControl returns the directives of the Cache-Control headers, by lower-case name.
+func parseCacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name != "" {
+				directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+			}
+		}
+	}
+	return directives
+}
+
+// readLimited reads r up to limit bytes, and reports whether it was read completely.
+func readLimited(r io.Reader, limit int64) ([]byte, bool, error) {
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(body)) > limit {
+		return body, false, nil
+	}
+	return body, true, nil
+}
+
+// multiReadCloser reads the body read ahead by the transport, then the rest of the body.
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
+}
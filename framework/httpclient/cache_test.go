@@ -0,0 +1,244 @@
+package httpclient_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+	"github.com/kittipat1413/go-common/framework/httpclient"
+)
+
+// cachedServer serves versioned bodies with the headers set by its handler, and counts the requests.
+type cachedServer struct {
+	*httptest.Server
+	requests   atomic.Int32
+	notChanged atomic.Int32
+	version    atomic.Int32
+}
+
+func newCachedServer(t *testing.T, header func(h http.Header)) *cachedServer {
+	t.Helper()
+	s := &cachedServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		etag := fmt.Sprintf(`"v%d"`, s.version.Load())
+		header(w.Header())
+		if w.Header().Get("ETag") != "" {
+			w.Header().Set("ETag", etag)
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			s.notChanged.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "version %d for %s", s.version.Load(), r.Header.Get("Accept-Language"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newCachingClient(opts ...httpclient.CacheOption) *http.Client {
+	return httpclient.New(httpclient.WithCache(localcache.New[httpclient.CachedResponse](), opts...))
+}
+
+func fetch(t *testing.T, client *http.Client, method, url string, header http.Header) (string, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body), resp.Header.Get(httpclient.CacheStatusHeader)
+}
+
+func TestCache_Fresh(t *testing.T) {
+	server := newCachedServer(t, func(h http.Header) { h.Set("Cache-Control", "public, max-age=60") })
+	client := newCachingClient()
+
+	body, status := fetch(t, client, http.MethodGet, server.URL, nil)
+	assert.Equal(t, "version 0 for ", body)
+	assert.Equal(t, httpclient.CacheMiss, status)
+
+	server.version.Store(1)
+	body, status = fetch(t, client, http.MethodGet, server.URL, nil)
+	assert.Equal(t, "version 0 for ", body, "the fresh response is served from the cache")
+	assert.Equal(t, httpclient.CacheHit, status)
+	assert.Equal(t, int32(1), server.requests.Load())
+
+	body, status = fetch(t, client, http.MethodGet, server.URL+"?page=2", nil)
+	assert.Equal(t, "version 1 for ", body, "the URLs are cached apart")
+	assert.Equal(t, httpclient.CacheMiss, status)
+}
+
+func TestCache_Revalidation(t *testing.T) {
+	server := newCachedServer(t, func(h http.Header) {
+		h.Set("Cache-Control", "max-age=0")
+		h.Set("ETag", "set by the server")
+	})
+	client := newCachingClient()
+
+	body, status := fetch(t, client, http.MethodGet, server.URL, nil)
+	assert.Equal(t, "version 0 for ", body)
+	assert.Equal(t, httpclient.CacheMiss, status)
+
+	body, status = fetch(t, client, http.MethodGet, server.URL, nil)
+	assert.Equal(t, "version 0 for ", body)
+	assert.Equal(t, httpclient.CacheRevalidated, status)
+	assert.Equal(t, int32(1), server.notChanged.Load())
+
+	server.version.Store(1)
+	body, status = fetch(t, client, http.MethodGet, server.URL, nil)
+	assert.Equal(t, "version 1 for ", body, "the changed response replaces the stale one")
+	assert.Equal(t, httpclient.CacheMiss, status)
+
+	body, status = fetch(t, client, http.MethodGet, server.URL, nil)
+	assert.Equal(t, "version 1 for ", body)
+	assert.Equal(t, httpclient.CacheRevalidated, status)
+	assert.Equal(t, int32(4), server.requests.Load())
+}
+
+func TestCache_NotStored(t *testing.T) {
+	tests := []struct {
+		name   string
+		header func(h http.Header)
+		opts   []httpclient.CacheOption
+	}{
+		{name: "no-store", header: func(h http.Header) { h.Set("Cache-Control", "no-store, max-age=60") }},
+		{name: "no freshness nor validator", header: func(h http.Header) {}},
+		{name: "vary star", header: func(h http.Header) { h.Set("Cache-Control", "max-age=60"); h.Set("Vary", "*") }},
+		{name: "expired", header: func(h http.Header) { h.Set("Expires", time.Now().Add(-time.Hour).Format(http.TimeFormat)) }},
+		{
+			name:   "body too large",
+			header: func(h http.Header) { h.Set("Cache-Control", "max-age=60") },
+			opts:   []httpclient.CacheOption{httpclient.WithCacheMaxBodySize(4)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newCachedServer(t, tt.header)
+			client := newCachingClient(tt.opts...)
+
+			for i := 0; i < 2; i++ {
+				body, status := fetch(t, client, http.MethodGet, server.URL, nil)
+				assert.Equal(t, "version 0 for ", body)
+				assert.Equal(t, httpclient.CacheMiss, status)
+			}
+			assert.Equal(t, int32(2), server.requests.Load())
+		})
+	}
+}
+
+func TestCache_Expires(t *testing.T) {
+	server := newCachedServer(t, func(h http.Header) {
+		h.Set("Expires", time.Now().Add(time.Hour).Format(http.TimeFormat))
+	})
+	client := newCachingClient()
+
+	fetch(t, client, http.MethodGet, server.URL, nil)
+	_, status := fetch(t, client, http.MethodGet, server.URL, nil)
+	assert.Equal(t, httpclient.CacheHit, status)
+}
+
+func TestCache_RequestDirectives(t *testing.T) {
+	server := newCachedServer(t, func(h http.Header) {
+		h.Set("Cache-Control", "max-age=60")
+		h.Set("ETag", "set by the server")
+	})
+	client := newCachingClient()
+	fetch(t, client, http.MethodGet, server.URL, nil)
+
+	_, status := fetch(t, client, http.MethodGet, server.URL, http.Header{"Cache-Control": {"no-cache"}})
+	assert.Equal(t, httpclient.CacheRevalidated, status)
+
+	_, status = fetch(t, client, http.MethodGet, server.URL, http.Header{"Cache-Control": {"no-store"}})
+	assert.Empty(t, status, "the requests with no-store bypass the cache")
+
+	resp, err := client.Do(mustRequest(t, http.MethodGet, server.URL, http.Header{"If-None-Match": {`"v0"`}}))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode, "the conditional requests of the caller are not answered by the cache")
+}
+
+func TestCache_Vary(t *testing.T) {
+	server := newCachedServer(t, func(h http.Header) {
+		h.Set("Cache-Control", "max-age=60")
+		h.Set("Vary", "Accept-Language")
+	})
+	client := newCachingClient()
+
+	body, _ := fetch(t, client, http.MethodGet, server.URL, http.Header{"Accept-Language": {"en"}})
+	assert.Equal(t, "version 0 for en", body)
+	body, status := fetch(t, client, http.MethodGet, server.URL, http.Header{"Accept-Language": {"en"}})
+	assert.Equal(t, "version 0 for en", body)
+	assert.Equal(t, httpclient.CacheHit, status)
+
+	body, status = fetch(t, client, http.MethodGet, server.URL, http.Header{"Accept-Language": {"fr"}})
+	assert.Equal(t, "version 0 for fr", body)
+	assert.Equal(t, httpclient.CacheMiss, status)
+}
+
+func TestCache_Invalidation(t *testing.T) {
+	server := newCachedServer(t, func(h http.Header) { h.Set("Cache-Control", "max-age=60") })
+	client := newCachingClient()
+	fetch(t, client, http.MethodGet, server.URL, nil)
+	server.version.Store(1)
+
+	fetch(t, client, http.MethodPut, server.URL, nil)
+
+	body, status := fetch(t, client, http.MethodGet, server.URL, nil)
+	assert.Equal(t, "version 1 for ", body)
+	assert.Equal(t, httpclient.CacheMiss, status)
+}
+
+func TestCache_Key(t *testing.T) {
+	server := newCachedServer(t, func(h http.Header) { h.Set("Cache-Control", "max-age=60") })
+	client := newCachingClient(httpclient.WithCacheKey(func(req *http.Request) string {
+		return req.URL.Path
+	}))
+
+	fetch(t, client, http.MethodGet, server.URL+"/orders?page=1", nil)
+	body, status := fetch(t, client, http.MethodGet, server.URL+"/orders?page=2", nil)
+	assert.Equal(t, "version 0 for ", body)
+	assert.Equal(t, httpclient.CacheHit, status)
+}
+
+func TestCache_LargeBody(t *testing.T) {
+	large := strings.Repeat("a", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte(large))
+	}))
+	defer server.Close()
+	client := newCachingClient(httpclient.WithCacheMaxBodySize(10))
+
+	body, _ := fetch(t, client, http.MethodGet, server.URL, nil)
+	assert.Equal(t, large, body, "the body read ahead is returned with the rest")
+}
+
+func mustRequest(t *testing.T, method, url string, header http.Header) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	return req
+}
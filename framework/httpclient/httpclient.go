@@ -0,0 +1,203 @@
+package httpclient
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+// DefaultTimeout is the time limit of the requests, retries included, unless set with WithTimeout.
+const DefaultTimeout = 30 * time.Second
+
+// options holds configuration options for the clients.
+type options struct {
+	timeout      time.Duration
+	transport    http.RoundTripper
+	tuning       transportOptions
+	metrics      *Metrics
+	cache        cache.Cache[CachedResponse]
+	cacheOptions []CacheOption
+	retry        bool
+	retryOptions []retry.Option
+	breaker      *gobreaker.Settings
+	logging      bool
+	logger       logger.Logger
+	headers      []string
+	keyPatterns  []string
+	propagators  propagation.TextMapPropagator
+}
+
+// Option specifies client configuration options.
+type Option func(*options)
+
+// WithTimeout sets the time limit of the requests, retries included, reading of the response body included. It
+// defaults to DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.timeout = d
+		}
+	}
+}
+
+// WithTransport sets the transport making the requests. It defaults to a clone of http.DefaultTransport, tuned with
+// the transport options, e.g., WithMaxIdleConnsPerHost, which are ignored with WithTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(opts *options) {
+		if transport != nil {
+			opts.transport = transport
+		}
+	}
+}
+
+// WithRetry retries the idempotent requests failing with a network error or a retryable status, see Retryable, with
+// the retry options opts, e.g., retry.WithMaxAttempts and retry.WithBackoff.
+func WithRetry(opts ...retry.Option) Option {
+	return func(o *options) {
+		o.retry = true
+		o.retryOptions = append(o.retryOptions, opts...)
+	}
+}
+
+// WithCircuitBreaker fails fast the requests to the hosts failing too often, with a circuit breaker per host created
+// with settings, named after the host. Network errors and 5xx responses are failures. The zero settings trip the
+// circuit breakers after more than 5 consecutive failures.
+func WithCircuitBreaker(settings gobreaker.Settings) Option {
+	return func(opts *options) {
+		opts.breaker = &settings
+	}
+}
+
+// WithLogger logs every attempt of the requests with l, or with the logger of the request context if l is nil, see
+// logger.FromContext. The requests are not logged by default.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		opts.logging = true
+		opts.logger = l
+	}
+}
+
+// WithLogHeaders logs the request headers names, e.g., "Content-Type". Headers with sensitive names, e.g.,
+// "Authorization", are redacted, see WithRedaction.
+func WithLogHeaders(names ...string) Option {
+	return func(opts *options) {
+		opts.headers = append(opts.headers, names...)
+	}
+}
+
+// WithRedaction sets the patterns of the sensitive headers and query parameters: the ones whose name contains one
+// of keyPatterns, case-insensitively, are logged as logger.DefaultRedactionMask. It defaults to
+// logger.DefaultRedactKeyPatterns.
+func WithRedaction(keyPatterns ...string) Option {
+	return func(opts *options) {
+		opts.keyPatterns = keyPatterns
+	}
+}
+
+// WithPropagators sets the propagators injecting the span and the baggage of the request context in the request
+// headers. It defaults to the global propagators, or the W3C Trace Context and Baggage propagators if none are set.
+func WithPropagators(propagators propagation.TextMapPropagator) Option {
+	return func(opts *options) {
+		if propagators != nil {
+			opts.propagators = propagators
+		}
+	}
+}
+
+/*
+New creates an *http.Client making the requests through a transport which, from the outermost:
+  - Caches the GET responses, with WithCache.
+  - Retries the idempotent requests, with WithRetry.
+  - Fails fast the requests to the failing hosts, with WithCircuitBreaker.
+  - Injects the trace context and the baggage of the request context in the request headers.
+  - Logs every attempt, with WithLogger.
+  - Records the connection metrics, with WithMetrics.
+
+Example usage:
+
+	client := httpclient.New(
+		httpclient.WithTimeout(10*time.Second),
+		httpclient.WithRetry(
+			retry.WithMaxAttempts(3),
+			retry.WithBackoff(retry.Exponential(100*time.Millisecond, 2.0, 0.2)),
+		),
+		httpclient.WithCircuitBreaker(gobreaker.Settings{Timeout: 30 * time.Second}),
+		httpclient.WithLogger(log),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://payments.internal/charges/42", nil)
+	...
+	resp, err := client.Do(req)
+*/
+func New(opts ...Option) *http.Client {
+	o := newOptions(opts)
+	return &http.Client{Transport: o.newTransport(), Timeout: o.timeout}
+}
+
+// NewTransport creates the transport of the clients of New, to use it in a custom *http.Client. WithTimeout is
+// ignored.
+func NewTransport(opts ...Option) http.RoundTripper {
+	return newOptions(opts).newTransport()
+}
+
+func (o *options) newTransport() http.RoundTripper {
+	transport := o.transport
+	if o.metrics != nil {
+		transport = &metricsTransport{next: transport, metrics: o.metrics}
+	}
+	transport = &propagatingTransport{next: transport, propagators: o.propagators}
+	if o.logging {
+		transport = newLoggingTransport(transport, o)
+	}
+	if o.breaker != nil {
+		transport = newBreakerTransport(transport, *o.breaker)
+	}
+	if o.retry {
+		transport = &retryTransport{next: transport, opts: o.retryOptions}
+	}
+	if o.cache != nil {
+		transport = NewCachingTransport(transport, o.cache, o.cacheOptions...)
+	}
+	return transport
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		timeout:     DefaultTimeout,
+		keyPatterns: logger.DefaultRedactKeyPatterns,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.transport == nil {
+		o.transport = o.tuning.newTransport()
+	}
+	if o.propagators == nil {
+		o.propagators = otel.GetTextMapPropagator()
+		if len(o.propagators.Fields()) == 0 {
+			// No global propagators are set: the default one propagates nothing.
+			o.propagators = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+		}
+	}
+	return o
+}
+
+// propagatingTransport injects the trace context and the baggage of the request context in the request headers.
+type propagatingTransport struct {
+	next        http.RoundTripper
+	propagators propagation.TextMapPropagator
+}
+
+func (t *propagatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request.
+	req = req.Clone(req.Context())
+	t.propagators.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,321 @@
+package httpclient_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/httpclient"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+// flakyServer fails the first failures requests with status, then succeeds, and records the bodies received.
+type flakyServer struct {
+	*httptest.Server
+	requests atomic.Int32
+	bodies   chan string
+}
+
+func newFlakyServer(t *testing.T, failures int32, status int) *flakyServer {
+	t.Helper()
+	s := &flakyServer{bodies: make(chan string, 10)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.bodies <- string(body)
+		if s.requests.Add(1) <= failures {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("failure"))
+			return
+		}
+		_, _ = w.Write([]byte("success"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func do(t *testing.T, client *http.Client, method, url, body string, header http.Header) (*http.Response, string, error) {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	require.NoError(t, err)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(data), nil
+}
+
+func newRetryingClient() *http.Client {
+	return httpclient.New(httpclient.WithRetry(retry.WithMaxAttempts(3), retry.WithBackoff(retry.Constant(time.Millisecond))))
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		body             string
+		header           http.Header
+		failures         int32
+		status           int
+		expectedStatus   int
+		expectedBody     string
+		expectedRequests int32
+	}{
+		{
+			name:             "get retried",
+			method:           http.MethodGet,
+			failures:         2,
+			status:           http.StatusServiceUnavailable,
+			expectedStatus:   http.StatusOK,
+			expectedBody:     "success",
+			expectedRequests: 3,
+		},
+		{
+			name:             "attempts exhausted",
+			method:           http.MethodGet,
+			failures:         5,
+			status:           http.StatusBadGateway,
+			expectedStatus:   http.StatusBadGateway,
+			expectedBody:     "failure",
+			expectedRequests: 3,
+		},
+		{
+			name:             "status not retried",
+			method:           http.MethodGet,
+			failures:         1,
+			status:           http.StatusInternalServerError,
+			expectedStatus:   http.StatusInternalServerError,
+			expectedBody:     "failure",
+			expectedRequests: 1,
+		},
+		{
+			name:             "post not retried",
+			method:           http.MethodPost,
+			body:             `{"amount": 100}`,
+			failures:         1,
+			status:           http.StatusServiceUnavailable,
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedBody:     "failure",
+			expectedRequests: 1,
+		},
+		{
+			name:             "post with idempotency key retried",
+			method:           http.MethodPost,
+			body:             `{"amount": 100}`,
+			header:           http.Header{"Idempotency-Key": {"charge-42"}},
+			failures:         1,
+			status:           http.StatusTooManyRequests,
+			expectedStatus:   http.StatusOK,
+			expectedBody:     "success",
+			expectedRequests: 2,
+		},
+		{
+			name:             "put retried with its body",
+			method:           http.MethodPut,
+			body:             `{"name": "order"}`,
+			failures:         2,
+			status:           http.StatusGatewayTimeout,
+			expectedStatus:   http.StatusOK,
+			expectedBody:     "success",
+			expectedRequests: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFlakyServer(t, tt.failures, tt.status)
+
+			resp, body, err := do(t, newRetryingClient(), tt.method, server.URL, tt.body, tt.header)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			assert.Equal(t, tt.expectedBody, body)
+			assert.Equal(t, tt.expectedRequests, server.requests.Load())
+			for i := int32(0); i < tt.expectedRequests; i++ {
+				assert.Equal(t, tt.body, <-server.bodies, "every attempt sends the body")
+			}
+		})
+	}
+}
+
+func TestRetry_NetworkError(t *testing.T) {
+	var attempts atomic.Int32
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempts.Add(1)
+		return nil, errors.New("connection refused")
+	})
+	client := httpclient.New(
+		httpclient.WithTransport(transport),
+		httpclient.WithRetry(retry.WithMaxAttempts(3), retry.WithBackoff(retry.Constant(time.Millisecond))),
+	)
+
+	_, _, err := do(t, client, http.MethodGet, "http://payments.internal/charges", "", nil)
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestRetry_ContextCanceled(t *testing.T) {
+	server := newFlakyServer(t, 10, http.StatusServiceUnavailable)
+	client := httpclient.New(httpclient.WithRetry(retry.WithMaxAttempts(10), retry.WithBackoff(retry.Constant(time.Second))))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int32(1), server.requests.Load())
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	failing := newFlakyServer(t, 100, http.StatusInternalServerError)
+	healthy := newFlakyServer(t, 0, http.StatusOK)
+	client := httpclient.New(
+		httpclient.WithRetry(retry.WithMaxAttempts(3), retry.WithBackoff(retry.Constant(time.Millisecond))),
+		httpclient.WithCircuitBreaker(gobreaker.Settings{
+			ReadyToTrip: func(counts gobreaker.Counts) bool { return counts.ConsecutiveFailures >= 2 },
+			Timeout:     time.Minute,
+		}),
+	)
+
+	for i := 0; i < 2; i++ {
+		resp, _, err := do(t, client, http.MethodGet, failing.URL, "", nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	}
+
+	_, _, err := do(t, client, http.MethodGet, failing.URL, "", nil)
+	assert.ErrorIs(t, err, httpclient.ErrCircuitOpen)
+	assert.ErrorIs(t, err, gobreaker.ErrOpenState)
+	assert.Equal(t, domain_error.KindUnavailable, domain_error.KindOf(err))
+	assert.Equal(t, int32(2), failing.requests.Load(), "the open circuit is not retried")
+
+	resp, body, err := do(t, client, http.MethodGet, healthy.URL, "", nil)
+	require.NoError(t, err, "the circuit breakers are per host")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "success", body)
+}
+
+func TestPropagation(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer server.Close()
+
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "checkout")
+	defer span.End()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := httpclient.New().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	received := <-headers
+	extracted := propagation.TraceContext{}.Extract(context.Background(), propagation.HeaderCarrier(received))
+	assert.Contains(t, received.Get("traceparent"), span.SpanContext().TraceID().String())
+	assert.Equal(t, span.SpanContext().TraceID(), oteltrace.SpanContextFromContext(extracted).TraceID())
+	assert.Empty(t, req.Header.Get("traceparent"), "the request of the caller is not modified")
+}
+
+func TestLogging(t *testing.T) {
+	server := newFlakyServer(t, 1, http.StatusServiceUnavailable)
+	recorder := &logger.Recorder{}
+	log, err := logger.NewLogger(logger.Config{Level: logger.DEBUG, Output: io.Discard, Hooks: []logger.Hook{recorder}})
+	require.NoError(t, err)
+	client := httpclient.New(
+		httpclient.WithLogger(log),
+		httpclient.WithLogHeaders("Authorization", "Accept"),
+		httpclient.WithRetry(retry.WithBackoff(retry.Constant(time.Millisecond))),
+	)
+
+	_, _, err = do(t, client, http.MethodGet, server.URL+"/charges?access_token=s3cr3t&page=2", "", http.Header{
+		"Authorization": {"Bearer s3cr3t"},
+		"Accept":        {"application/json"},
+	})
+	require.NoError(t, err)
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 2, "every attempt is logged")
+	assert.Equal(t, logger.WARN, entries[0].Level)
+	assert.Equal(t, logger.INFO, entries[1].Level)
+	for _, entry := range entries {
+		assert.Equal(t, "HTTP client request", entry.Message)
+		request := entry.Fields["request"].(logger.Fields)
+		assert.Equal(t, http.MethodGet, request["method"])
+		assert.Equal(t, "/charges", request["path"])
+		assert.Equal(t, "access_token=[REDACTED]&page=2", request["query"])
+		assert.Equal(t, logger.Fields{"authorization": "[REDACTED]", "accept": "application/json"}, request["headers"])
+	}
+	assert.Equal(t, http.StatusOK, entries[1].Fields["response"].(logger.Fields)["status_code"])
+
+	failing := httpclient.New(
+		httpclient.WithLogger(log),
+		httpclient.WithTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		})),
+	)
+	_, _, err = do(t, failing, http.MethodGet, server.URL, "", nil)
+	require.Error(t, err)
+	recorder.AssertLogged(t, logger.ERROR, "HTTP client request failed")
+}
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	_, _, err := do(t, httpclient.New(httpclient.WithTimeout(20*time.Millisecond)), http.MethodGet, server.URL, "", nil)
+	var netErr interface{ Timeout() bool }
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+}
+
+func TestIdempotent(t *testing.T) {
+	get, _ := http.NewRequest(http.MethodGet, "http://payments.internal", nil)
+	post, _ := http.NewRequest(http.MethodPost, "http://payments.internal", strings.NewReader("{}"))
+	keyed, _ := http.NewRequest(http.MethodPost, "http://payments.internal", strings.NewReader("{}"))
+	keyed.Header.Set("Idempotency-Key", "charge-42")
+	unreplayable, _ := http.NewRequest(http.MethodPut, "http://payments.internal", io.NopCloser(strings.NewReader("{}")))
+
+	assert.True(t, httpclient.Idempotent(get))
+	assert.False(t, httpclient.Idempotent(post))
+	assert.True(t, httpclient.Idempotent(keyed))
+	assert.False(t, httpclient.Idempotent(unreplayable), "the body cannot be sent again")
+}
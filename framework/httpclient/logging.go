@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// loggingTransport logs every attempt of the requests.
+type loggingTransport struct {
+	next        http.RoundTripper
+	logger      logger.Logger
+	headers     []string
+	keyPatterns []string
+}
+
+func newLoggingTransport(next http.RoundTripper, o *options) *loggingTransport {
+	t := &loggingTransport{next: next, logger: o.logger, headers: o.headers}
+	for _, pattern := range o.keyPatterns {
+		if pattern != "" {
+			t.keyPatterns = append(t.keyPatterns, strings.ToLower(pattern))
+		}
+	}
+	return t
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	latency := time.Since(start)
+
+	request := logger.Fields{
+		"method": req.Method,
+		"host":   req.URL.Host,
+		"path":   req.URL.Path,
+		"query":  t.redactQuery(req.URL.RawQuery),
+	}
+	if len(t.headers) > 0 {
+		headers := logger.Fields{}
+		for _, name := range t.headers {
+			if value := req.Header.Get(name); value != "" {
+				headers[strings.ToLower(name)] = t.redact(name, value)
+			}
+		}
+		request["headers"] = headers
+	}
+	fields := logger.Fields{"request": request}
+
+	l := t.logger
+	if l == nil {
+		l = logger.FromContext(req.Context())
+	}
+	ctx := req.Context()
+	if err != nil {
+		fields["latency_ms"] = latency.Milliseconds()
+		l.Error(ctx, "HTTP client request failed", err, fields)
+		return nil, err
+	}
+	fields["response"] = logger.Fields{
+		"status_code": resp.StatusCode,
+		"latency_ms":  latency.Milliseconds(),
+		"latency_s":   latency.Seconds(),
+		"size":        resp.ContentLength,
+	}
+	level := logger.INFO
+	if resp.StatusCode >= http.StatusInternalServerError {
+		level = logger.WARN
+	}
+	l.Log(ctx, level, "HTTP client request", fields)
+	return resp, nil
+}
+
+// redact returns the value of key, hidden if key is sensitive.
+func (t *loggingTransport) redact(key, value string) string {
+	key = strings.ToLower(key)
+	for _, pattern := range t.keyPatterns {
+		if strings.Contains(key, pattern) {
+			return logger.DefaultRedactionMask
+		}
+	}
+	return value
+}
+
+// redactQuery hides the values of the sensitive parameters of the query.
+func (t *loggingTransport) redactQuery(query string) string {
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		rawKey, _, found := strings.Cut(param, "=")
+		if !found {
+			continue
+		}
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		if redacted := t.redact(key, ""); redacted != "" {
+			params[i] = rawKey + "=" + redacted
+		}
+	}
+	return strings.Join(params, "&")
+}
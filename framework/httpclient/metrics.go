@@ -0,0 +1,197 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+// WithMetrics records the connection metrics of the requests in metrics: the connections created and reused, and
+// the DNS lookups, dials and TLS handshakes, per host.
+func WithMetrics(metrics *Metrics) Option {
+	return func(opts *options) {
+		opts.metrics = metrics
+	}
+}
+
+/*
+Metrics records the connection metrics of the clients with WithMetrics, and exposes them in the Prometheus text
+format, labelled by host, to diagnose connection churn: a client creating connections all the time instead of
+reusing them, or spending its time in DNS lookups and TLS handshakes. The metrics are recorded in a registry of the
+metrics package, with no dependency on a Prometheus client library: serve it on the metrics endpoint, write it with
+WriteTo, or register it as a collector of a metrics.Registry.
+
+Example usage:
+
+	metrics := httpclient.NewMetrics("myapp")
+	client := httpclient.New(httpclient.WithMetrics(metrics), httpclient.WithMaxIdleConnsPerHost(32))
+	http.Handle("/metrics/httpclient", metrics)
+
+exposes metrics such as:
+
+	myapp_http_client_connections_created_total{host="payments.internal:443"} 3
+	myapp_http_client_connections_reused_total{host="payments.internal:443"} 1024
+*/
+type Metrics struct {
+	registry             *metrics.Registry
+	inFlight             *metrics.Gauge
+	connsCreated         *metrics.Counter
+	connsReused          *metrics.Counter
+	connsIdleReused      *metrics.Counter
+	connsIdleDuration    *metrics.Counter
+	dnsLookups           *metrics.Counter
+	dnsErrors            *metrics.Counter
+	dnsDuration          *metrics.Counter
+	dials                *metrics.Counter
+	dialErrors           *metrics.Counter
+	dialDuration         *metrics.Counter
+	tlsHandshakes        *metrics.Counter
+	tlsErrors            *metrics.Counter
+	tlsHandshakeDuration *metrics.Counter
+
+	mutex sync.RWMutex
+	hosts map[string]bool
+}
+
+// NewMetrics creates a Metrics. namespace prefixes the metric names, it may be empty.
+func NewMetrics(namespace string) *Metrics {
+	registry := metrics.NewRegistry(metrics.WithNamespace(namespace))
+	counter := func(name, help string) *metrics.Counter {
+		return metrics.Must(registry.NewCounter(name, help, "host"))
+	}
+	return &Metrics{
+		registry:             registry,
+		inFlight:             metrics.Must(registry.NewGauge("http_client_requests_in_flight", "Number of requests in flight.", "host")),
+		connsCreated:         counter("http_client_connections_created_total", "Number of connections created."),
+		connsReused:          counter("http_client_connections_reused_total", "Number of requests reusing a connection."),
+		connsIdleReused:      counter("http_client_connections_idle_reused_total", "Number of requests reusing an idle connection."),
+		connsIdleDuration:    counter("http_client_connections_idle_seconds_total", "Total time the reused idle connections were idle."),
+		dnsLookups:           counter("http_client_dns_lookups_total", "Number of DNS lookups."),
+		dnsErrors:            counter("http_client_dns_errors_total", "Number of DNS lookups that failed."),
+		dnsDuration:          counter("http_client_dns_duration_seconds_total", "Total time spent in DNS lookups."),
+		dials:                counter("http_client_dials_total", "Number of TCP connection attempts."),
+		dialErrors:           counter("http_client_dial_errors_total", "Number of TCP connection attempts that failed."),
+		dialDuration:         counter("http_client_dial_duration_seconds_total", "Total time spent in TCP connection attempts."),
+		tlsHandshakes:        counter("http_client_tls_handshakes_total", "Number of TLS handshakes."),
+		tlsErrors:            counter("http_client_tls_errors_total", "Number of TLS handshakes that failed."),
+		tlsHandshakeDuration: counter("http_client_tls_handshake_duration_seconds_total", "Total time spent in TLS handshakes."),
+		hosts:                make(map[string]bool),
+	}
+}
+
+// addHost creates the series of host on its first request, so that the metrics of the hosts are written from the
+// start, with their zero values.
+func (m *Metrics) addHost(host string) {
+	m.mutex.RLock()
+	added := m.hosts[host]
+	m.mutex.RUnlock()
+	if added {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.hosts[host] {
+		return
+	}
+	m.inFlight.Add(0, host)
+	for _, counter := range []*metrics.Counter{
+		m.connsCreated, m.connsReused, m.connsIdleReused, m.connsIdleDuration,
+		m.dnsLookups, m.dnsErrors, m.dnsDuration,
+		m.dials, m.dialErrors, m.dialDuration,
+		m.tlsHandshakes, m.tlsErrors, m.tlsHandshakeDuration,
+	} {
+		counter.Add(0, host)
+	}
+	m.hosts[host] = true
+}
+
+// WriteTo writes the metrics of the hosts to w in the Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	return m.registry.WriteTo(w)
+}
+
+// ServeHTTP writes the metrics of the hosts in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+// metricsTransport records the connection metrics of the requests with an httptrace.ClientTrace.
+type metricsTransport struct {
+	next    http.RoundTripper
+	metrics *Metrics
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	m, host := t.metrics, req.URL.Host
+	m.addHost(host)
+	m.inFlight.Inc(host)
+	defer m.inFlight.Dec(host)
+
+	// The callbacks of the dials may be called concurrently, e.g., for the IPv4 and IPv6 addresses of a host.
+	var mu sync.Mutex
+	var dnsStart, tlsStart time.Time
+	dialStarts := make(map[string]time.Time)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if !info.Reused {
+				return
+			}
+			m.connsReused.Inc(host)
+			if info.WasIdle {
+				m.connsIdleReused.Inc(host)
+				m.connsIdleDuration.Add(info.IdleTime.Seconds(), host)
+			}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			dnsStart = time.Now()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			m.dnsLookups.Inc(host)
+			m.dnsDuration.Add(time.Since(dnsStart).Seconds(), host)
+			if info.Err != nil {
+				m.dnsErrors.Inc(host)
+			}
+		},
+		ConnectStart: func(network, addr string) {
+			mu.Lock()
+			defer mu.Unlock()
+			dialStarts[network+addr] = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			m.dials.Inc(host)
+			m.dialDuration.Add(time.Since(dialStarts[network+addr]).Seconds(), host)
+			if err != nil {
+				m.dialErrors.Inc(host)
+			} else {
+				m.connsCreated.Inc(host)
+			}
+		},
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			m.tlsHandshakes.Inc(host)
+			m.tlsHandshakeDuration.Add(time.Since(tlsStart).Seconds(), host)
+			if err != nil {
+				m.tlsErrors.Inc(host)
+			}
+		},
+	}
+	return t.next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
@@ -0,0 +1,103 @@
+package httpclient_test
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/httpclient"
+)
+
+// newTLSServer starts an HTTP/2 server with TLS, and returns the TLS configuration trusting its certificate.
+func newTLSServer(t *testing.T) (*httptest.Server, *tls.Config) {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	config := server.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	// The certificate of the server is valid for example.com.
+	config.ServerName = "example.com"
+	return server, config
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestWithHTTP2(t *testing.T) {
+	server, config := newTLSServer(t)
+
+	assert.Equal(t, "HTTP/2.0", get(t, httpclient.New(httpclient.WithTLSConfig(config)), server.URL))
+	assert.Equal(t, "HTTP/1.1", get(t, httpclient.New(httpclient.WithTLSConfig(config), httpclient.WithHTTP2(false)), server.URL))
+}
+
+func TestMetrics(t *testing.T) {
+	server, config := newTLSServer(t)
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	host := strings.TrimPrefix(url, "https://")
+	metrics := httpclient.NewMetrics("myapp")
+	client := httpclient.New(
+		httpclient.WithMetrics(metrics),
+		httpclient.WithTLSConfig(config),
+		httpclient.WithHTTP2(false),
+		httpclient.WithMaxIdleConnsPerHost(4),
+		httpclient.WithMaxConnsPerHost(1),
+	)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "HTTP/1.1", get(t, client, url))
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+	body := recorder.Body.String()
+	for _, line := range []string{
+		"# TYPE myapp_http_client_connections_created_total counter",
+		`myapp_http_client_connections_created_total{host="` + host + `"} 1`,
+		`myapp_http_client_connections_reused_total{host="` + host + `"} 2`,
+		`myapp_http_client_connections_idle_reused_total{host="` + host + `"} 2`,
+		`myapp_http_client_dns_lookups_total{host="` + host + `"} 1`,
+		`myapp_http_client_dns_errors_total{host="` + host + `"} 0`,
+		`myapp_http_client_tls_handshakes_total{host="` + host + `"} 1`,
+		`myapp_http_client_tls_errors_total{host="` + host + `"} 0`,
+		"# TYPE myapp_http_client_requests_in_flight gauge",
+		`myapp_http_client_requests_in_flight{host="` + host + `"} 0`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+	assert.Contains(t, body, "myapp_http_client_tls_handshake_duration_seconds_total{host=")
+}
+
+func TestMetrics_Errors(t *testing.T) {
+	server, _ := newTLSServer(t)
+	metrics := httpclient.NewMetrics("")
+	// The certificate of the server is not trusted without its TLS configuration.
+	client := httpclient.New(httpclient.WithMetrics(metrics))
+
+	_, err := client.Get(server.URL)
+	require.Error(t, err)
+
+	var buf strings.Builder
+	_, err = metrics.WriteTo(&buf)
+	require.NoError(t, err)
+	host := strings.TrimPrefix(server.URL, "https://")
+	assert.Contains(t, buf.String(), `http_client_tls_errors_total{host="`+host+`"} 1`+"\n")
+	assert.Contains(t, buf.String(), `http_client_dials_total{host="`+host+`"} 1`+"\n")
+}
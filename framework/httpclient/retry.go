@@ -0,0 +1,132 @@
+package httpclient
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+// idempotencyKeyHeader is the header of the idempotency keys, see the idempotency middleware. The requests
+// carrying it are retried whatever their method.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// Idempotent reports whether req may be retried: its method is idempotent, e.g., GET or PUT, or it carries an
+// Idempotency-Key header, and its body, if any, can be replayed with GetBody.
+func Idempotent(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(idempotencyKeyHeader) != ""
+}
+
+// Retryable reports whether a response with status may succeed if retried: 429, 502, 503 and 504.
+func Retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// statusError is the error of the attempts with a retryable status.
+type statusError struct {
+	status string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("httpclient: %s", e.status)
+}
+
+// retryTransport retries the idempotent requests.
+type retryTransport struct {
+	next http.RoundTripper
+	opts []retry.Option
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !Idempotent(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	var last *http.Response
+	attempts := 0
+	opts := append([]retry.Option{retry.WithRetryIf(retryable)}, t.opts...)
+	resp, err := retry.DoValue(req.Context(), func(ctx context.Context) (*http.Response, error) {
+		attempt := req
+		if attempts++; attempts > 1 {
+			// The response of the previous attempt is discarded.
+			drain(last)
+			last = nil
+			var err error
+			if attempt, err = rewind(req); err != nil {
+				return nil, errors.MarkPermanent(err)
+			}
+		}
+		resp, err := t.next.RoundTrip(attempt)
+		if err != nil {
+			return nil, err
+		}
+		if Retryable(resp.StatusCode) {
+			last = resp
+			return nil, errors.MarkRetryable(&statusError{status: resp.Status})
+		}
+		return resp, nil
+	}, opts...)
+	if err == nil {
+		return resp, nil
+	}
+
+	var retryErr *retry.Error
+	if !stderrors.As(err, &retryErr) {
+		drain(last)
+		return nil, err
+	}
+	if retryErr.ContextErr != nil {
+		drain(last)
+		return nil, retryErr.ContextErr
+	}
+	if last != nil {
+		// The attempts are exhausted: the caller gets the last response.
+		return last, nil
+	}
+	// The caller gets the error of the last attempt, like with a single attempt.
+	return nil, retryErr.Err
+}
+
+// retryable reports whether an attempt failing with err is retried: not for open circuit breakers, permanent
+// errors, e.g., context errors, and errors of the rewind of the body.
+func retryable(err error) bool {
+	return !stderrors.Is(err, ErrCircuitOpen) && !errors.IsPermanent(err)
+}
+
+// rewind returns req with a fresh body for the attempts after the first one.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	attempt := req.Clone(req.Context())
+	attempt.Body = body
+	return attempt, nil
+}
+
+// drain reads up to 4 KB of the body of resp, if any, and closes it, so that the connection can be reused.
+func drain(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	_, _ = io.CopyN(io.Discard, resp.Body, 4<<10)
+	_ = resp.Body.Close()
+}
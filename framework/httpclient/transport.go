@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// transportOptions holds the tuning of the default transport.
+type transportOptions struct {
+	maxIdleConns        *int
+	maxIdleConnsPerHost *int
+	maxConnsPerHost     *int
+	idleConnTimeout     *time.Duration
+	dialTimeout         *time.Duration
+	tlsHandshakeTimeout *time.Duration
+	tlsConfig           *tls.Config
+	http2               *bool
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections kept for all the hosts, 0 for no limit. It
+// defaults to 100, like http.DefaultTransport. It is ignored with WithTransport, like all the transport tuning.
+func WithMaxIdleConns(n int) Option {
+	return func(opts *options) {
+		opts.tuning.maxIdleConns = &n
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle connections kept per host. It defaults to
+// http.DefaultMaxIdleConnsPerHost, 2, which makes the clients of a busy host close and open connections all the time.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(opts *options) {
+		opts.tuning.maxIdleConnsPerHost = &n
+	}
+}
+
+// WithMaxConnsPerHost sets the maximum number of connections per host, the requests waiting for a connection
+// beyond it, 0 for no limit. It defaults to 0.
+func WithMaxConnsPerHost(n int) Option {
+	return func(opts *options) {
+		opts.tuning.maxConnsPerHost = &n
+	}
+}
+
+// WithIdleConnTimeout sets the time an idle connection is kept, 0 for no limit. It defaults to 90 seconds.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		opts.tuning.idleConnTimeout = &d
+	}
+}
+
+// WithDialTimeout sets the time limit of the establishment of the TCP connections. It defaults to 30 seconds.
+func WithDialTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		opts.tuning.dialTimeout = &d
+	}
+}
+
+// WithTLSHandshakeTimeout sets the time limit of the TLS handshakes, 0 for no limit. It defaults to 10 seconds.
+func WithTLSHandshakeTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		opts.tuning.tlsHandshakeTimeout = &d
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the connections, e.g., the certificates of a private CA.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(opts *options) {
+		opts.tuning.tlsConfig = config
+	}
+}
+
+// WithHTTP2 enables or disables HTTP/2 over TLS. It is enabled by default, multiplexing the requests to a host on a
+// single connection. Disable it to spread the requests over several connections, e.g., behind a load balancer
+// balancing the connections.
+func WithHTTP2(enabled bool) Option {
+	return func(opts *options) {
+		opts.tuning.http2 = &enabled
+	}
+}
+
+// newTransport returns a clone of http.DefaultTransport tuned with o.
+func (o *transportOptions) newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if o.maxIdleConns != nil {
+		transport.MaxIdleConns = *o.maxIdleConns
+	}
+	if o.maxIdleConnsPerHost != nil {
+		transport.MaxIdleConnsPerHost = *o.maxIdleConnsPerHost
+	}
+	if o.maxConnsPerHost != nil {
+		transport.MaxConnsPerHost = *o.maxConnsPerHost
+	}
+	if o.idleConnTimeout != nil {
+		transport.IdleConnTimeout = *o.idleConnTimeout
+	}
+	if o.dialTimeout != nil {
+		dialer := &net.Dialer{Timeout: *o.dialTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+	}
+	if o.tlsHandshakeTimeout != nil {
+		transport.TLSHandshakeTimeout = *o.tlsHandshakeTimeout
+	}
+	if o.tlsConfig != nil {
+		transport.TLSClientConfig = o.tlsConfig.Clone()
+	}
+	if o.http2 != nil {
+		transport.ForceAttemptHTTP2 = *o.http2
+		if !*o.http2 {
+			// A non-nil empty map disables HTTP/2.
+			transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+	}
+	return transport
+}
@@ -0,0 +1,243 @@
+package httpresponse
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/middleware/requestid"
+)
+
+// ContentType is the content type of the envelopes.
+const ContentType = "application/json"
+
+// CodeEncodingFailed is the code of the error responses written when the data of a response cannot be encoded.
+const CodeEncodingFailed = "response_encoding_failed"
+
+/*
+Envelope is the body of every JSON response: the data of the successful responses, or the error of the failed
+ones, and the metadata of both.
+
+	{"data": {"id": "42"}, "meta": {"request_id": "cn1s5v8", "pagination": {"limit": 20, "next_cursor": "eyJpZCI6NDJ9"}}}
+	{"error": {"code": "order_not_found", "message": "order 42 not found"}, "meta": {"request_id": "cn1s5v8"}}
+*/
+type Envelope struct {
+	Data  interface{} `json:"data,omitempty"`
+	Error *ErrorBody  `json:"error,omitempty"`
+	Meta  *Meta       `json:"meta,omitempty"`
+}
+
+// ErrorBody is the error of a failed response.
+type ErrorBody struct {
+	// Code is the code of the error, e.g., "order_not_found", or the snake-cased status text for errors without
+	// code, e.g., "internal_server_error".
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Details are the fields of a CodedError or the data of a DomainError, unless hidden by the production mode.
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Meta is the metadata of a response.
+type Meta struct {
+	// RequestID is the ID of the request, set from the request context by the requestid middleware if empty.
+	RequestID  string      `json:"request_id,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination describes the page of a paginated response, with offsets or cursors.
+type Pagination struct {
+	Limit  int  `json:"limit"`
+	Offset *int `json:"offset,omitempty"`
+	// Total is the number of items of all the pages, if known.
+	Total      *int64 `json:"total,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// options holds configuration options for the Writer.
+type options struct {
+	problems *errors.ProblemWriter
+}
+
+// Option specifies Writer configuration options.
+type Option func(*options)
+
+// WithProblemWriter sets the errors.ProblemWriter giving the status, code, message and details of the errors, e.g.,
+// in production mode to hide the internal details of server errors. It defaults to errors.NewProblemWriter().
+func WithProblemWriter(problems *errors.ProblemWriter) Option {
+	return func(opts *options) {
+		if problems != nil {
+			opts.problems = problems
+		}
+	}
+}
+
+/*
+Writer writes the responses in the Envelope, so that every service responds in the same format. The package-level
+functions use a Writer with the default options.
+
+Example usage:
+
+	responses := httpresponse.NewWriter(httpresponse.WithProblemWriter(
+		errors.NewProblemWriter(errors.WithProductionMode(env == "production")),
+	))
+
+	func (h *orderHandler) Get(w http.ResponseWriter, r *http.Request) {
+		order, err := h.service.Get(r.Context(), r.PathValue("id"))
+		if err != nil {
+			responses.Error(w, r, err)
+			return
+		}
+		responses.OK(w, r, order, nil)
+	}
+*/
+type Writer struct {
+	opts options
+}
+
+// NewWriter creates a Writer.
+func NewWriter(opts ...Option) *Writer {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.problems == nil {
+		o.problems = errors.NewProblemWriter()
+	}
+	return &Writer{opts: o}
+}
+
+// JSON writes data in the envelope with status, and the metadata meta, which may be nil. r may be nil.
+func (wr *Writer) JSON(w http.ResponseWriter, r *http.Request, status int, data interface{}, meta *Meta) {
+	body, err := encode(Envelope{Data: data, Meta: withRequestID(r, meta)})
+	if err != nil {
+		wr.Error(w, r, errors.Internal(CodeEncodingFailed, "failed to encode the response").Wrap(err))
+		return
+	}
+	write(w, status, body)
+}
+
+// OK writes data in the envelope with the 200 status, and the metadata meta, which may be nil.
+func (wr *Writer) OK(w http.ResponseWriter, r *http.Request, data interface{}, meta *Meta) {
+	wr.JSON(w, r, http.StatusOK, data, meta)
+}
+
+// Created writes data in the envelope with the 201 status, and the metadata meta, which may be nil. Set the
+// Location header of the created resource before calling it.
+func (wr *Writer) Created(w http.ResponseWriter, r *http.Request, data interface{}, meta *Meta) {
+	wr.JSON(w, r, http.StatusCreated, data, meta)
+}
+
+// NoContent writes a response with the 204 status, without body.
+func (wr *Writer) NoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/*
+Error writes err in the envelope. The status, code, message and details of the error are the ones of the problem
+details of the errors.ProblemWriter: the status of the kind of a CodedError, or of a DomainError, and 500 for
+other errors.
+*/
+func (wr *Writer) Error(w http.ResponseWriter, r *http.Request, err error) {
+	problem := wr.opts.problems.Problem(r, err)
+	body := &ErrorBody{Message: problem.Detail}
+	if body.Message == "" {
+		body.Message = problem.Title
+	}
+	if code, ok := problem.Extensions["code"].(string); ok && code != "" {
+		body.Code = code
+	} else {
+		body.Code = strings.ToLower(strings.ReplaceAll(problem.Title, " ", "_"))
+	}
+	for key, value := range problem.Extensions {
+		if key == "code" {
+			continue
+		}
+		if body.Details == nil {
+			body.Details = make(map[string]interface{}, len(problem.Extensions))
+		}
+		body.Details[key] = value
+	}
+
+	encoded, encodeErr := encode(Envelope{Error: body, Meta: withRequestID(r, nil)})
+	if encodeErr != nil {
+		// The details cannot be encoded.
+		body.Details = nil
+		encoded, _ = encode(Envelope{Error: body, Meta: withRequestID(r, nil)})
+	}
+	write(w, problem.Status, encoded)
+}
+
+// withRequestID returns meta with the request ID of r, or nil if there is no metadata.
+func withRequestID(r *http.Request, meta *Meta) *Meta {
+	id := ""
+	if r != nil {
+		id, _ = requestid.FromContext(r.Context())
+	}
+	if meta == nil {
+		if id == "" {
+			return nil
+		}
+		return &Meta{RequestID: id}
+	}
+	if meta.RequestID == "" && id != "" {
+		withID := *meta
+		withID.RequestID = id
+		return &withID
+	}
+	return meta
+}
+
+// encode encodes the envelope, so that encoding errors are reported before the response is started.
+func encode(envelope Envelope) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(envelope); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func write(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// defaultWriter is the Writer of the package-level functions.
+var defaultWriter = NewWriter()
+
+// JSON writes data in the envelope with status and the metadata meta, with the default Writer.
+func JSON(w http.ResponseWriter, r *http.Request, status int, data interface{}, meta *Meta) {
+	defaultWriter.JSON(w, r, status, data, meta)
+}
+
+/*
+OK writes data in the envelope with the 200 status and the metadata meta, which may be nil, with the default
+Writer.
+
+Example usage:
+
+	httpresponse.OK(w, r, orders, &httpresponse.Meta{Pagination: &httpresponse.Pagination{Limit: 20, HasMore: true}})
+*/
+func OK(w http.ResponseWriter, r *http.Request, data interface{}, meta *Meta) {
+	defaultWriter.OK(w, r, data, meta)
+}
+
+// Created writes data in the envelope with the 201 status and the metadata meta, with the default Writer.
+func Created(w http.ResponseWriter, r *http.Request, data interface{}, meta *Meta) {
+	defaultWriter.Created(w, r, data, meta)
+}
+
+// NoContent writes a response with the 204 status, without body.
+func NoContent(w http.ResponseWriter) {
+	defaultWriter.NoContent(w)
+}
+
+// Error writes err in the envelope with the default Writer, which does not hide the internal details of the
+// errors: use a Writer with a ProblemWriter in production mode in production.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	defaultWriter.Error(w, r, err)
+}
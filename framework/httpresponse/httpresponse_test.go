@@ -0,0 +1,186 @@
+package httpresponse_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/httpresponse"
+	"github.com/kittipat1413/go-common/framework/middleware/requestid"
+)
+
+type order struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+}
+
+func newRequest(id string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	if id != "" {
+		req = req.WithContext(requestid.NewContext(req.Context(), id))
+	}
+	return req
+}
+
+func TestSuccess(t *testing.T) {
+	offset, total := 40, int64(95)
+	tests := []struct {
+		name           string
+		write          func(w http.ResponseWriter, r *http.Request)
+		requestID      string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "ok",
+			write: func(w http.ResponseWriter, r *http.Request) {
+				httpresponse.OK(w, r, order{ID: "42", Total: 100}, nil)
+			},
+			requestID:      "req-1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":{"id":"42","total":100},"meta":{"request_id":"req-1"}}`,
+		},
+		{
+			name: "ok without request id",
+			write: func(w http.ResponseWriter, r *http.Request) {
+				httpresponse.OK(w, r, []order{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":[]}`,
+		},
+		{
+			name: "pagination",
+			write: func(w http.ResponseWriter, r *http.Request) {
+				httpresponse.OK(w, r, []order{{ID: "41"}}, &httpresponse.Meta{Pagination: &httpresponse.Pagination{
+					Limit: 20, Offset: &offset, Total: &total, HasMore: true, NextCursor: "next", PrevCursor: "prev",
+				}})
+			},
+			requestID:      "req-1",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"data":[{"id":"41","total":0}],"meta":{"request_id":"req-1","pagination":` +
+				`{"limit":20,"offset":40,"total":95,"has_more":true,"next_cursor":"next","prev_cursor":"prev"}}}`,
+		},
+		{
+			name: "explicit request id",
+			write: func(w http.ResponseWriter, r *http.Request) {
+				httpresponse.Created(w, r, order{ID: "43"}, &httpresponse.Meta{RequestID: "custom"})
+			},
+			requestID:      "req-1",
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"data":{"id":"43","total":0},"meta":{"request_id":"custom"}}`,
+		},
+		{
+			name: "custom status",
+			write: func(w http.ResponseWriter, r *http.Request) {
+				httpresponse.JSON(w, r, http.StatusAccepted, map[string]string{"job": "j-1"}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `{"data":{"job":"j-1"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.write(rec, newRequest(tt.requestID))
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, httpresponse.ContentType, rec.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestNoContent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	httpresponse.NoContent(rec)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Zero(t, rec.Body.Len())
+}
+
+func TestError(t *testing.T) {
+	notFound := domain_error.NotFound("order_not_found", "order not found").WithField("order_id", "42")
+	tests := []struct {
+		name           string
+		writer         *httpresponse.Writer
+		err            error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "coded error",
+			writer:         httpresponse.NewWriter(),
+			err:            notFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":{"code":"order_not_found","message":"order not found","details":{"order_id":"42"}},"meta":{"request_id":"req-1"}}`,
+		},
+		{
+			name:           "plain error",
+			writer:         httpresponse.NewWriter(),
+			err:            errors.New("connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":{"code":"internal_server_error","message":"connection refused"},"meta":{"request_id":"req-1"}}`,
+		},
+		{
+			name: "production mode",
+			writer: httpresponse.NewWriter(httpresponse.WithProblemWriter(domain_error.NewProblemWriter(
+				domain_error.WithProductionMode(true),
+			))),
+			err:            domain_error.Internal("db_failure", "database failure").Wrap(errors.New("password authentication failed")),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":{"code":"db_failure","message":"Internal Server Error"},"meta":{"request_id":"req-1"}}`,
+		},
+		{
+			name: "status override",
+			writer: httpresponse.NewWriter(httpresponse.WithProblemWriter(domain_error.NewProblemWriter(
+				domain_error.WithProductionMode(true),
+				domain_error.WithCodeStatus("order_not_found", http.StatusGone),
+			))),
+			err:            notFound,
+			expectedStatus: http.StatusGone,
+			expectedBody:   `{"error":{"code":"order_not_found","message":"order not found","details":{"order_id":"42"}},"meta":{"request_id":"req-1"}}`,
+		},
+		{
+			name:           "unencodable details",
+			writer:         httpresponse.NewWriter(),
+			err:            domain_error.InvalidArgument("invalid_order", "invalid order").WithField("callback", func() {}),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":{"code":"invalid_order","message":"invalid order"},"meta":{"request_id":"req-1"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.writer.Error(rec, newRequest("req-1"), tt.err)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, httpresponse.ContentType, rec.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestJSON_EncodingFailure(t *testing.T) {
+	rec := httptest.NewRecorder()
+	httpresponse.OK(rec, newRequest(""), map[string]interface{}{"channel": make(chan int)}, nil)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	var envelope httpresponse.Envelope
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &envelope))
+	require.NotNil(t, envelope.Error)
+	assert.Equal(t, httpresponse.CodeEncodingFailed, envelope.Error.Code)
+	assert.Nil(t, envelope.Data)
+}
+
+func TestWriter_NilRequest(t *testing.T) {
+	rec := httptest.NewRecorder()
+	httpresponse.NewWriter().OK(rec, nil, "pong", nil)
+
+	assert.JSONEq(t, `{"data":"pong"}`, rec.Body.String())
+}
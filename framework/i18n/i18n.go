@@ -0,0 +1,287 @@
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+var (
+	// ErrInvalidLocale is returned for the locales which are not BCP 47 language tags, e.g., "th" or "en-US".
+	ErrInvalidLocale = errors.New("i18n: invalid locale")
+	// ErrInvalidMessages is returned when a message bundle cannot be parsed.
+	ErrInvalidMessages = errors.New("i18n: invalid messages")
+)
+
+/*
+Bundle holds the messages of an application per locale, and negotiates the locale of the callers. The messages are
+text/template templates, e.g., "Hello, {{.Name}}", with plural forms selected by the CLDR rules of their locale.
+Messages missing from a locale fall back to its parent locale (e.g., "pt" for "pt-BR"), then to the default locale.
+A Bundle is safe for concurrent use.
+
+Example usage:
+
+	//go:embed locales
+	var locales embed.FS
+
+	bundle, err := i18n.NewBundle("en")
+	if err != nil {
+		return err
+	}
+	if err := bundle.LoadFS(locales); err != nil { // locales/en.yaml, locales/th.yaml, ...
+		return err
+	}
+
+	l := bundle.Localizer(r.Header.Get("Accept-Language"))
+	greeting := l.T("greeting", map[string]any{"Name": user.Name})
+	summary := l.Plural("cart.items", len(items), nil)
+*/
+type Bundle struct {
+	mutex         sync.RWMutex
+	defaultLocale language.Tag
+	messages      map[language.Tag]map[string]*message
+	// locales are the locales of the bundle, the default one first, and matcher matches them.
+	locales []language.Tag
+	matcher language.Matcher
+}
+
+// NewBundle creates a Bundle whose messages fall back to defaultLocale, also selected when no locale of the bundle
+// matches the preferences of a caller.
+func NewBundle(defaultLocale string) (*Bundle, error) {
+	tag, err := parseLocale(defaultLocale)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{defaultLocale: tag, messages: make(map[language.Tag]map[string]*message)}
+	b.addLocale(tag)
+	return b, nil
+}
+
+// AddMessages adds messages of locale, keyed by message key, overriding the messages of the same key. The messages
+// have a single form, see Load for plural forms.
+func (b *Bundle) AddMessages(locale string, messages map[string]string) error {
+	tag, err := parseLocale(locale)
+	if err != nil {
+		return err
+	}
+	parsed := make(map[string]*message, len(messages))
+	for key, text := range messages {
+		if parsed[key], err = newMessage(key, map[plural.Form]string{plural.Other: text}); err != nil {
+			return err
+		}
+	}
+	b.add(tag, parsed)
+	return nil
+}
+
+/*
+Load adds the messages of locale in YAML or JSON, overriding the messages of the same key. Nested maps are
+flattened into keys joined by dots, and the maps of plural forms (zero, one, two, few, many and other, which is
+required) are plural messages:
+
+	greeting: "Hello, {{.Name}}!"
+	cart:
+	  items:
+	    one: "{{.Count}} item"
+	    other: "{{.Count}} items"
+	validation:
+	  required: "{{.Field}} is required"
+*/
+func (b *Bundle) Load(locale string, data []byte) error {
+	tag, err := parseLocale(locale)
+	if err != nil {
+		return err
+	}
+	messages, err := parseMessages(data)
+	if err != nil {
+		return err
+	}
+	b.add(tag, messages)
+	return nil
+}
+
+// LoadFS loads the YAML (.yaml, .yml) and JSON (.json) files of fsys, e.g., an embed.FS, with Load. The locale of
+// a file is the last dot-separated part of its name without extension, so that a locale may be split into several
+// files, e.g., "th.yaml" and "validation.th.yaml".
+func (b *Bundle) LoadFS(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		ext := path.Ext(name)
+		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			return nil
+		}
+		base := strings.TrimSuffix(path.Base(name), ext)
+		locale := base[strings.LastIndexByte(base, '.')+1:]
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if err := b.Load(locale, data); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// Locales returns the locales of the bundle, the default one first.
+func (b *Bundle) Locales() []string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	locales := make([]string, len(b.locales))
+	for i, tag := range b.locales {
+		locales[i] = tag.String()
+	}
+	return locales
+}
+
+// Match returns the locale of the bundle best matching preferences, see Localizer.
+func (b *Bundle) Match(preferences ...string) string {
+	return b.match(preferences).String()
+}
+
+// Localizer returns a Localizer of the locale of the bundle best matching preferences, in order of preference:
+// locales (e.g., "th-TH") or Accept-Language headers (e.g., "th-TH,th;q=0.9,en;q=0.8"). The invalid preferences
+// are ignored, and the default locale is selected if none matches.
+func (b *Bundle) Localizer(preferences ...string) *Localizer {
+	return &Localizer{bundle: b, tag: b.match(preferences)}
+}
+
+func (b *Bundle) match(preferences []string) language.Tag {
+	var tags []language.Tag
+	for _, preference := range preferences {
+		parsed, _, err := language.ParseAcceptLanguage(preference)
+		if err == nil {
+			tags = append(tags, parsed...)
+		}
+	}
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	if len(tags) == 0 {
+		return b.defaultLocale
+	}
+	_, i, confidence := b.matcher.Match(tags...)
+	if confidence == language.No {
+		return b.defaultLocale
+	}
+	return b.locales[i]
+}
+
+func (b *Bundle) add(tag language.Tag, messages map[string]*message) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.addLocale(tag)
+	for key, msg := range messages {
+		b.messages[tag][key] = msg
+	}
+}
+
+// addLocale adds tag to the locales of the bundle if needed. b.mutex must be held.
+func (b *Bundle) addLocale(tag language.Tag) {
+	if _, ok := b.messages[tag]; ok {
+		return
+	}
+	b.messages[tag] = make(map[string]*message)
+	b.locales = append(b.locales, tag)
+	b.matcher = language.NewMatcher(b.locales)
+}
+
+// lookup returns the message of key in tag, its parents, or the default locale.
+func (b *Bundle) lookup(tag language.Tag, key string) (*message, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for t := tag; ; t = t.Parent() {
+		if msg, ok := b.messages[t][key]; ok {
+			return msg, true
+		}
+		if t.IsRoot() {
+			break
+		}
+	}
+	msg, ok := b.messages[b.defaultLocale][key]
+	return msg, ok
+}
+
+// Localizer translates the messages of a Bundle in a locale. A nil Localizer returns the message keys, so that
+// the code translating messages works without the Middleware.
+type Localizer struct {
+	bundle *Bundle
+	tag    language.Tag
+}
+
+// Locale returns the locale of the localizer, e.g., "th".
+func (l *Localizer) Locale() string {
+	if l == nil {
+		return ""
+	}
+	return l.tag.String()
+}
+
+// T returns the message of key executed with data, or key if the message is missing. The plural messages are
+// executed in their "other" form.
+func (l *Localizer) T(key string, data map[string]any) string {
+	text, _ := l.translate(key, plural.Other, data)
+	return text
+}
+
+// Plural returns the message of key in the plural form of count in the locale of l, executed with data and count
+// as {{.Count}}, or key if the message is missing. The "other" form is used when the form of count is missing.
+func (l *Localizer) Plural(key string, count int, data map[string]any) string {
+	withCount := make(map[string]any, len(data)+1)
+	for k, v := range data {
+		withCount[k] = v
+	}
+	withCount["Count"] = count
+	if count < 0 {
+		count = -count
+	}
+	form := plural.Other
+	if l != nil {
+		form = plural.Cardinal.MatchPlural(l.tag, count, 0, 0, 0, 0)
+	}
+	text, _ := l.translate(key, form, withCount)
+	return text
+}
+
+// translate returns the message of key in form executed with data, or key and false if the message is missing.
+func (l *Localizer) translate(key string, form plural.Form, data map[string]any) (string, bool) {
+	if l == nil {
+		return key, false
+	}
+	msg, ok := l.bundle.lookup(l.tag, key)
+	if !ok {
+		return key, false
+	}
+	return msg.execute(form, data), true
+}
+
+// contextKey is the key of the Localizer in the request contexts.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l, e.g., as done by the Middleware.
+func NewContext(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the Localizer of ctx, or nil if it has none.
+func FromContext(ctx context.Context) *Localizer {
+	l, _ := ctx.Value(contextKey{}).(*Localizer)
+	return l
+}
+
+// parseLocale parses a BCP 47 language tag.
+func parseLocale(locale string) (language.Tag, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return language.Und, fmt.Errorf("%w %q: %w", ErrInvalidLocale, locale, err)
+	}
+	return tag, nil
+}
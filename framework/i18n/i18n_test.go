@@ -0,0 +1,170 @@
+package i18n_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kittipat1413/go-common/framework/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const enMessages = `
+greeting: "Hello, {{.Name}}!"
+farewell: Goodbye
+cart:
+  items:
+    one: "{{.Count}} item"
+    other: "{{.Count}} items"
+  empty: Your cart is empty
+retries: 3
+`
+
+const thMessages = `{
+	"greeting": "สวัสดี {{.Name}}",
+	"cart": {"items": {"other": "{{.Count}} รายการ"}}
+}`
+
+func newBundle(t *testing.T) *i18n.Bundle {
+	t.Helper()
+	bundle, err := i18n.NewBundle("en")
+	require.NoError(t, err)
+	require.NoError(t, bundle.Load("en", []byte(enMessages)))
+	require.NoError(t, bundle.Load("th", []byte(thMessages)))
+	return bundle
+}
+
+func TestLocalizer(t *testing.T) {
+	bundle := newBundle(t)
+
+	en := bundle.Localizer("en")
+	assert.Equal(t, "en", en.Locale())
+	assert.Equal(t, "Hello, Somchai!", en.T("greeting", map[string]any{"Name": "Somchai"}))
+	assert.Equal(t, "Goodbye", en.T("farewell", nil))
+	assert.Equal(t, "3", en.T("retries", nil), "the scalars are messages")
+	assert.Equal(t, "cart.unknown", en.T("cart.unknown", nil), "a missing message returns its key")
+	assert.Equal(t, "1 item", en.Plural("cart.items", 1, nil))
+	assert.Equal(t, "0 items", en.Plural("cart.items", 0, nil))
+	assert.Equal(t, "2 items", en.Plural("cart.items", 2, nil))
+	assert.Equal(t, "-1 item", en.Plural("cart.items", -1, nil))
+	assert.Equal(t, "1 items", en.T("cart.items", map[string]any{"Count": 1}), "T uses the other form")
+
+	th := bundle.Localizer("th")
+	assert.Equal(t, "th", th.Locale())
+	assert.Equal(t, "สวัสดี Somchai", th.T("greeting", map[string]any{"Name": "Somchai"}))
+	assert.Equal(t, "1 รายการ", th.Plural("cart.items", 1, nil), "Thai has no plural forms")
+	assert.Equal(t, "Goodbye", th.T("farewell", nil), "the missing messages fall back to the default locale")
+
+	thTH := bundle.Localizer("th-TH")
+	assert.Equal(t, "th", thTH.Locale())
+
+	var nilLocalizer *i18n.Localizer
+	assert.Equal(t, "greeting", nilLocalizer.T("greeting", nil))
+	assert.Equal(t, "cart.items", nilLocalizer.Plural("cart.items", 1, nil))
+	assert.Empty(t, nilLocalizer.Locale())
+}
+
+func TestBundle_Match(t *testing.T) {
+	bundle := newBundle(t)
+	assert.Equal(t, []string{"en", "th"}, bundle.Locales())
+
+	tests := []struct {
+		name        string
+		preferences []string
+		expected    string
+	}{
+		{name: "none", expected: "en"},
+		{name: "exact", preferences: []string{"th"}, expected: "th"},
+		{name: "region", preferences: []string{"th-TH"}, expected: "th"},
+		{name: "accept-language", preferences: []string{"fr-FR,fr;q=0.9,th;q=0.8,en;q=0.5"}, expected: "th"},
+		{name: "quality order", preferences: []string{"en;q=0.5,th;q=0.9"}, expected: "th"},
+		{name: "preference order", preferences: []string{"en", "th"}, expected: "en"},
+		{name: "unsupported", preferences: []string{"fr"}, expected: "en"},
+		{name: "invalid", preferences: []string{"not a locale!", "th"}, expected: "th"},
+		{name: "wildcard", preferences: []string{"*"}, expected: "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, bundle.Match(tt.preferences...))
+		})
+	}
+}
+
+func TestBundle_AddMessages(t *testing.T) {
+	bundle := newBundle(t)
+	require.NoError(t, bundle.AddMessages("pt", map[string]string{"farewell": "Tchau"}))
+	require.NoError(t, bundle.AddMessages("pt-BR", map[string]string{"greeting": "Olá, {{.Name}}!"}))
+	require.NoError(t, bundle.AddMessages("en", map[string]string{"farewell": "Bye"}))
+
+	l := bundle.Localizer("pt-BR")
+	assert.Equal(t, "pt-BR", l.Locale())
+	assert.Equal(t, "Olá, Ana!", l.T("greeting", map[string]any{"Name": "Ana"}))
+	assert.Equal(t, "Tchau", l.T("farewell", nil), "the missing messages fall back to the parent locale")
+	assert.Equal(t, "Bye", bundle.Localizer("en").T("farewell", nil), "the messages are overridden")
+
+	assert.ErrorIs(t, bundle.AddMessages("not a locale!", nil), i18n.ErrInvalidLocale)
+	assert.ErrorIs(t, bundle.AddMessages("en", map[string]string{"broken": "{{.Name"}), i18n.ErrInvalidMessages)
+}
+
+func TestBundle_LoadErrors(t *testing.T) {
+	bundle, err := i18n.NewBundle("en")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		data     string
+		expected error
+	}{
+		{name: "syntax", data: "greeting: [", expected: i18n.ErrInvalidMessages},
+		{name: "template", data: `greeting: "{{.Name"`, expected: i18n.ErrInvalidMessages},
+		{name: "no other form", data: "items:\n  one: an item\n  few: a few items", expected: i18n.ErrInvalidMessages},
+		{name: "empty", data: "greeting:", expected: i18n.ErrInvalidMessages},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, bundle.Load("en", []byte(tt.data)), tt.expected)
+		})
+	}
+	assert.ErrorIs(t, bundle.Load("", []byte(enMessages)), i18n.ErrInvalidLocale)
+
+	_, err = i18n.NewBundle("not a locale!")
+	assert.ErrorIs(t, err, i18n.ErrInvalidLocale)
+}
+
+func TestBundle_LoadFS(t *testing.T) {
+	bundle, err := i18n.NewBundle("en")
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{
+		"locales/en.yaml":            {Data: []byte(enMessages)},
+		"locales/th.json":            {Data: []byte(thMessages)},
+		"locales/validation.th.yml":  {Data: []byte("validation:\n  required: กรุณาระบุ{{.Field}}")},
+		"locales/README.md":          {Data: []byte("# Locales")},
+		"locales/fr/fr-FR.yaml":      {Data: []byte("greeting: Bonjour")},
+		"locales/fr/notes.txt":       {Data: []byte("ignored")},
+		"locales/nested/ja.yaml":     {Data: []byte("farewell: さようなら")},
+		"locales/nested/ja.yaml.bak": {Data: []byte("farewell: [")},
+	}
+	require.NoError(t, bundle.LoadFS(fsys))
+	assert.ElementsMatch(t, []string{"en", "th", "fr-FR", "ja"}, bundle.Locales())
+
+	th := bundle.Localizer("th")
+	assert.Equal(t, "สวัสดี Ana", th.T("greeting", map[string]any{"Name": "Ana"}))
+	assert.Equal(t, "กรุณาระบุอายุ", th.T("validation.required", map[string]any{"Field": "อายุ"}))
+	assert.Equal(t, "Bonjour", bundle.Localizer("fr").T("greeting", nil))
+	assert.Equal(t, "さようなら", bundle.Localizer("ja-JP").T("farewell", nil))
+
+	invalid := fstest.MapFS{"locales/en.yaml": {Data: []byte("greeting: [")}}
+	err = bundle.LoadFS(invalid)
+	assert.ErrorIs(t, err, i18n.ErrInvalidMessages)
+	assert.ErrorContains(t, err, "locales/en.yaml")
+}
+
+func TestContext(t *testing.T) {
+	assert.Nil(t, i18n.FromContext(context.Background()))
+
+	l := newBundle(t).Localizer("th")
+	ctx := i18n.NewContext(context.Background(), l)
+	assert.Same(t, l, i18n.FromContext(ctx))
+}
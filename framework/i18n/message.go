@@ -0,0 +1,134 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"golang.org/x/text/feature/plural"
+	"gopkg.in/yaml.v3"
+)
+
+// pluralForms are the plural forms of the messages, by their name in the message bundles.
+var pluralForms = map[string]plural.Form{
+	"zero":  plural.Zero,
+	"one":   plural.One,
+	"two":   plural.Two,
+	"few":   plural.Few,
+	"many":  plural.Many,
+	"other": plural.Other,
+}
+
+// message is a message in its plural forms, which always include plural.Other. The forms without template action
+// are kept as text, so that most messages are not executed.
+type message struct {
+	forms map[plural.Form]*form
+}
+
+type form struct {
+	text string
+	tmpl *template.Template
+}
+
+func newMessage(key string, texts map[plural.Form]string) (*message, error) {
+	if _, ok := texts[plural.Other]; !ok {
+		return nil, fmt.Errorf("%w: message %q has no \"other\" form", ErrInvalidMessages, key)
+	}
+	msg := &message{forms: make(map[plural.Form]*form, len(texts))}
+	for f, text := range texts {
+		parsed := &form{text: text}
+		if strings.Contains(text, "{{") {
+			tmpl, err := template.New(key).Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("%w: message %q: %w", ErrInvalidMessages, key, err)
+			}
+			parsed.tmpl = tmpl
+		}
+		msg.forms[f] = parsed
+	}
+	return msg, nil
+}
+
+// execute returns the text of the message in form f, or in plural.Other if it has no such form, executed with
+// data. A message failing to execute returns its text unexecuted.
+func (m *message) execute(f plural.Form, data map[string]any) string {
+	selected, ok := m.forms[f]
+	if !ok {
+		selected = m.forms[plural.Other]
+	}
+	if selected.tmpl == nil {
+		return selected.text
+	}
+	var b strings.Builder
+	if err := selected.tmpl.Execute(&b, data); err != nil {
+		return selected.text
+	}
+	return b.String()
+}
+
+// parseMessages parses a message bundle in YAML or JSON, a subset of YAML.
+func parseMessages(data []byte) (map[string]*message, error) {
+	var root map[string]any
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMessages, err)
+	}
+	messages := make(map[string]*message)
+	if err := flatten("", root, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// flatten adds the messages of node to messages, with their keys prefixed by prefix.
+func flatten(prefix string, node map[string]any, messages map[string]*message) error {
+	for name, value := range node {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		children, ok := value.(map[string]any)
+		if !ok {
+			if value == nil {
+				return fmt.Errorf("%w: message %q is empty", ErrInvalidMessages, key)
+			}
+			msg, err := newMessage(key, map[plural.Form]string{plural.Other: fmt.Sprint(value)})
+			if err != nil {
+				return err
+			}
+			messages[key] = msg
+			continue
+		}
+		if texts, ok := pluralTexts(children); ok {
+			msg, err := newMessage(key, texts)
+			if err != nil {
+				return err
+			}
+			messages[key] = msg
+			continue
+		}
+		if err := flatten(key, children, messages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pluralTexts returns the texts of node by plural form, or false if node is not a plural message, i.e., if it
+// is empty or one of its keys is not a plural form or its value is not a scalar.
+func pluralTexts(node map[string]any) (map[plural.Form]string, bool) {
+	if len(node) == 0 {
+		return nil, false
+	}
+	texts := make(map[plural.Form]string, len(node))
+	for name, value := range node {
+		f, ok := pluralForms[name]
+		if !ok || value == nil {
+			return nil, false
+		}
+		if _, ok := value.(map[string]any); ok {
+			return nil, false
+		}
+		texts[f] = fmt.Sprint(value)
+	}
+	return texts, true
+}
@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"net/http"
+)
+
+// middlewareOptions holds configuration options for the Middleware.
+type middlewareOptions struct {
+	queryParam string // queryParam is the query parameter overriding the Accept-Language header, if any.
+	cookie     string // cookie is the cookie overriding the Accept-Language header, if any.
+}
+
+// MiddlewareOption specifies Middleware configuration options.
+type MiddlewareOption func(*middlewareOptions)
+
+// WithQueryParam lets the callers select their locale with the query parameter name, e.g., "lang" for "?lang=th",
+// preferred over the cookie and the Accept-Language header.
+func WithQueryParam(name string) MiddlewareOption {
+	return func(opts *middlewareOptions) {
+		if name != "" {
+			opts.queryParam = name
+		}
+	}
+}
+
+// WithCookie lets the callers select their locale with the cookie name, e.g., set by a language selector,
+// preferred over the Accept-Language header.
+func WithCookie(name string) MiddlewareOption {
+	return func(opts *middlewareOptions) {
+		if name != "" {
+			opts.cookie = name
+		}
+	}
+}
+
+/*
+Middleware negotiates the locale of the requests from their Accept-Language header among the locales of bundle,
+and stores a Localizer of that locale in their context, returned by FromContext. The responses have the
+Content-Language header set to the locale, and vary by Accept-Language.
+
+Example usage:
+
+	handler := i18n.Middleware(bundle, i18n.WithQueryParam("lang"))(mux)
+
+	func (h *Handler) Greet(w http.ResponseWriter, r *http.Request) {
+		l := i18n.FromContext(r.Context())
+		fmt.Fprint(w, l.T("greeting", map[string]any{"Name": "Somchai"}))
+	}
+*/
+func Middleware(bundle *Bundle, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	o := &middlewareOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var preferences []string
+			if o.queryParam != "" {
+				if locale := r.URL.Query().Get(o.queryParam); locale != "" {
+					preferences = append(preferences, locale)
+				}
+			}
+			if o.cookie != "" {
+				if cookie, err := r.Cookie(o.cookie); err == nil && cookie.Value != "" {
+					preferences = append(preferences, cookie.Value)
+				}
+			}
+			preferences = append(preferences, r.Header.Get("Accept-Language"))
+
+			l := bundle.Localizer(preferences...)
+			w.Header().Set("Content-Language", l.Locale())
+			w.Header().Add("Vary", "Accept-Language")
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), l)))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package i18n_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	bundle := newBundle(t)
+	handler := i18n.Middleware(bundle, i18n.WithQueryParam("lang"), i18n.WithCookie("locale"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := i18n.FromContext(r.Context())
+			_, _ = w.Write([]byte(l.T("greeting", map[string]any{"Name": "Ana"})))
+		}),
+	)
+
+	tests := []struct {
+		name           string
+		target         string
+		acceptLanguage string
+		cookie         string
+		expectedLocale string
+		expectedBody   string
+	}{
+		{name: "default", target: "/", expectedLocale: "en", expectedBody: "Hello, Ana!"},
+		{name: "accept-language", target: "/", acceptLanguage: "th-TH,th;q=0.9,en;q=0.8", expectedLocale: "th", expectedBody: "สวัสดี Ana"},
+		{name: "unsupported", target: "/", acceptLanguage: "fr", expectedLocale: "en", expectedBody: "Hello, Ana!"},
+		{name: "query param", target: "/?lang=en", acceptLanguage: "th", cookie: "th", expectedLocale: "en", expectedBody: "Hello, Ana!"},
+		{name: "unsupported query param", target: "/?lang=fr", acceptLanguage: "th", expectedLocale: "th", expectedBody: "สวัสดี Ana"},
+		{name: "cookie", target: "/", acceptLanguage: "en", cookie: "th", expectedLocale: "th", expectedBody: "สวัสดี Ana"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "locale", Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+
+			assert.Equal(t, tt.expectedLocale, rec.Header().Get("Content-Language"))
+			assert.Equal(t, "Accept-Language", rec.Header().Get("Vary"))
+			assert.Equal(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+}
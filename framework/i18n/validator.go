@@ -0,0 +1,56 @@
+package i18n
+
+import (
+	stderrors "errors"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/validator"
+	"golang.org/x/text/feature/plural"
+)
+
+/*
+LocalizeValidation returns err with the messages of its violations translated by l, if err is a
+*validator.ValidationError or its *errors.CodedError, e.g., returned by Validator.ValidateRequest, and err
+unchanged otherwise. The message of a violation is the "validation.<rule>" message, executed with the name of the
+field as {{.Field}}, translated by its "fields.<field>" message if any, the parameter of the rule as {{.Param}} and
+the rule as {{.Rule}}. The violations whose rule has no message keep their English message.
+
+Example usage:
+
+	# th.yaml
+	fields:
+	  age: อายุ
+	validation:
+	  required: "กรุณาระบุ{{.Field}}"
+	  lte: "{{.Field}}ต้องไม่เกิน {{.Param}}"
+
+	if err := validator.Default().ValidateRequest(req); err != nil {
+		problems.Write(w, r, i18n.LocalizeValidation(i18n.FromContext(r.Context()), err))
+		return
+	}
+*/
+func LocalizeValidation(l *Localizer, err error) error {
+	var validationErr *validator.ValidationError
+	if l == nil || !stderrors.As(err, &validationErr) {
+		return err
+	}
+
+	localized := &validator.ValidationError{Violations: make([]validator.Violation, len(validationErr.Violations))}
+	for i, violation := range validationErr.Violations {
+		field := violation.Field
+		if name, ok := l.translate("fields."+violation.Field, plural.Other, nil); ok {
+			field = name
+		}
+		data := map[string]any{"Field": field, "Param": violation.Param, "Rule": violation.Rule}
+		if message, ok := l.translate("validation."+violation.Rule, plural.Other, data); ok {
+			violation.Message = message
+		}
+		localized.Violations[i] = violation
+	}
+
+	var codedErr *errors.CodedError
+	if stderrors.As(err, &codedErr) && codedErr.Code() == validator.CodeValidationFailed {
+		return localized.CodedError()
+	}
+	return localized
+}
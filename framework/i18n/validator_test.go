@@ -0,0 +1,66 @@
+package i18n_test
+
+import (
+	"errors"
+	"testing"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/i18n"
+	"github.com/kittipat1413/go-common/framework/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type createUserRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required,email"`
+	Age   int    `json:"age" validate:"lte=130"`
+}
+
+const thValidation = `
+fields:
+  name: ชื่อ
+validation:
+  required: "กรุณาระบุ{{.Field}}"
+  lte: "{{.Field}}ต้องไม่เกิน {{.Param}}"
+`
+
+func TestLocalizeValidation(t *testing.T) {
+	bundle := newBundle(t)
+	require.NoError(t, bundle.Load("th", []byte(thValidation)))
+	th := bundle.Localizer("th")
+
+	err := validator.Default().ValidateRequest(createUserRequest{Email: "alice", Age: 200})
+	require.Error(t, err)
+
+	localized := i18n.LocalizeValidation(th, err)
+	assert.Equal(t, validator.CodeValidationFailed, domain_error.CodeOf(localized))
+	assert.Equal(t, domain_error.KindInvalidArgument, domain_error.KindOf(localized))
+
+	var validationErr *validator.ValidationError
+	require.ErrorAs(t, localized, &validationErr)
+	assert.Equal(t, []validator.Violation{
+		{Field: "name", Rule: "required", Message: "กรุณาระบุชื่อ"},
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "age", Rule: "lte", Param: "130", Message: "ageต้องไม่เกิน 130"},
+	}, validationErr.Violations, "the fields and rules without message are not translated")
+
+	var original *validator.ValidationError
+	require.ErrorAs(t, err, &original)
+	assert.Equal(t, "name is a required field", original.Violations[0].Message, "err is not modified")
+
+	localized = i18n.LocalizeValidation(th, original)
+	require.ErrorAs(t, localized, &validationErr)
+	assert.Equal(t, "กรุณาระบุชื่อ", validationErr.Violations[0].Message)
+	assert.Empty(t, domain_error.CodeOf(localized), "a ValidationError is not turned into a coded error")
+}
+
+func TestLocalizeValidation_Unchanged(t *testing.T) {
+	th := newBundle(t).Localizer("th")
+	errOther := errors.New("other")
+	assert.Same(t, errOther, i18n.LocalizeValidation(th, errOther))
+	assert.NoError(t, i18n.LocalizeValidation(th, nil))
+
+	err := validator.Default().ValidateRequest(createUserRequest{Name: "Alice", Email: "alice"})
+	assert.Same(t, err, i18n.LocalizeValidation(nil, err), "a nil Localizer does not translate")
+}
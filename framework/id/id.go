@@ -0,0 +1,173 @@
+package id
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// maxTimestamp is the maximum timestamp of the IDs, in milliseconds since the epoch: 48 bits.
+const maxTimestamp = 1<<48 - 1
+
+// options holds configuration options for the Generator and the Snowflake.
+type options struct {
+	entropy          io.Reader        // entropy is the source of the random bits.
+	now              func() time.Time // now is the clock of the timestamps.
+	epoch            time.Time        // epoch is the origin of the timestamps of the Snowflake.
+	nodeIDs          []NodeIDSource   // nodeIDs are the sources of the node ID of the Snowflake, tried in order.
+	nodeBits         uint             // nodeBits is the size of the node ID of the Snowflake.
+	sequenceBits     uint             // sequenceBits is the size of the sequence of the Snowflake.
+	maxClockRollback time.Duration    // maxClockRollback is the clock rollback waited out by the Snowflake.
+}
+
+// Option specifies Generator and Snowflake configuration options.
+type Option func(*options)
+
+// WithEntropy sets the source of the random bits of the ULIDs and the UUIDs, e.g., a deterministic reader in tests.
+// It defaults to crypto/rand.Reader.
+func WithEntropy(entropy io.Reader) Option {
+	return func(opts *options) {
+		if entropy != nil {
+			opts.entropy = entropy
+		}
+	}
+}
+
+// WithClock sets the clock of the timestamps of the IDs, e.g., a fixed time in tests. It defaults to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(opts *options) {
+		if now != nil {
+			opts.now = now
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		entropy:          rand.Reader,
+		now:              time.Now,
+		epoch:            DefaultEpoch,
+		nodeIDs:          []NodeIDSource{NodeIDFromEnv(DefaultNodeIDEnv), NodeIDFromHostname(), NodeIDFromIP()},
+		nodeBits:         DefaultNodeBits,
+		sequenceBits:     DefaultSequenceBits,
+		maxClockRollback: DefaultMaxClockRollback,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// monotonic is the state of the IDs of a type generated within the last millisecond: the random bits of the last
+// ID are incremented for the next one, so that the IDs of a millisecond are sorted in generation order.
+type monotonic struct {
+	timestamp int64
+	hi        uint64 // hi holds the high bits of the random bits.
+	lo        uint64 // lo holds the low bits of the random bits.
+}
+
+/*
+Generator generates ULIDs and UUIDv7s, sortable by generation time: their first 48 bits are a millisecond timestamp,
+and their other bits are random. The IDs of a same millisecond are monotonic: the random bits of the last ID are
+incremented, so that the IDs generated by a Generator are strictly increasing, even if the clock goes backwards.
+
+It is safe for concurrent use. The package functions NewULID and NewUUIDv7 use a default Generator.
+
+Example usage:
+
+	generator := id.NewGenerator(id.WithEntropy(rand.New(rand.NewSource(1)))) // deterministic, in tests
+	orderID := generator.ULID()
+*/
+type Generator struct {
+	opts *options
+
+	mu   sync.Mutex
+	ulid monotonic
+	uuid monotonic
+}
+
+// NewGenerator creates a Generator.
+func NewGenerator(opts ...Option) *Generator {
+	return &Generator{opts: newOptions(opts)}
+}
+
+// next returns the timestamp and the random bits of the next ID of state, whose random bits are hiBits and
+// loBits long.
+func (g *Generator) next(state *monotonic, hiBits, loBits uint) (int64, uint64, uint64) {
+	hiMask, loMask := uint64(1)<<hiBits-1, uint64(1)<<loBits-1
+
+	timestamp := min(max(g.opts.now().UnixMilli(), 0), maxTimestamp)
+	if state.timestamp > 0 && timestamp <= state.timestamp {
+		// Same millisecond, or the clock went backwards: increment the random bits of the last ID.
+		state.lo = (state.lo + 1) & loMask
+		if state.lo == 0 {
+			state.hi++
+		}
+		if state.hi <= hiMask {
+			return state.timestamp, state.hi, state.lo
+		}
+		// The random bits overflowed: move to the next millisecond.
+		timestamp = state.timestamp + 1
+	}
+
+	var b [16]byte
+	if _, err := io.ReadFull(g.opts.entropy, b[:]); err != nil {
+		panic(fmt.Sprintf("id: reading entropy: %v", err))
+	}
+	state.timestamp = timestamp
+	state.hi = binary.BigEndian.Uint64(b[:8]) & hiMask
+	// The first bit of lo is cleared so that many IDs of a millisecond can be generated before hi is incremented.
+	state.lo = binary.BigEndian.Uint64(b[8:]) & (loMask >> 1)
+	return state.timestamp, state.hi, state.lo
+}
+
+// defaultGenerator generates the IDs of the package functions.
+var defaultGenerator = NewGenerator()
+
+// NewULID returns a new ULID of the default Generator.
+func NewULID() ULID {
+	return defaultGenerator.ULID()
+}
+
+// NewUUIDv7 returns a new UUIDv7 of the default Generator.
+func NewUUIDv7() UUID {
+	return defaultGenerator.UUIDv7()
+}
+
+// contextKey is an unexported type for context keys defined in this package.
+type contextKey struct{}
+
+/*
+Tag returns a copy of ctx carrying id as the log field, so that the entries logged with the context carry it once
+Fields is registered as a context extractor of the logger. The tags of ctx are kept.
+
+Example usage:
+
+	log, _ := logger.NewLogger(logger.Config{
+		ContextExtractors: []logger.ContextExtractor{id.Fields},
+	})
+
+	orderID := id.NewULID()
+	ctx = id.Tag(ctx, "order_id", orderID)
+	log.Info(ctx, "Order created", nil) // {"order_id": "01J0...", ...}
+*/
+func Tag(ctx context.Context, field string, id fmt.Stringer) context.Context {
+	tags := logger.Fields{}
+	for k, v := range Fields(ctx) {
+		tags[k] = v
+	}
+	tags[field] = id.String()
+	return context.WithValue(ctx, contextKey{}, tags)
+}
+
+// Fields returns the IDs tagged in ctx, by field. It is a logger.ContextExtractor.
+func Fields(ctx context.Context) logger.Fields {
+	tags, _ := ctx.Value(contextKey{}).(logger.Fields)
+	return tags
+}
@@ -0,0 +1,187 @@
+package id_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/id"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedClock returns a clock at now, moved with the returned function.
+func fixedClock(now time.Time) (func() time.Time, func(time.Duration)) {
+	var mu sync.Mutex
+	return func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return now
+		}, func(d time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			now = now.Add(d)
+		}
+}
+
+func TestGenerator_ULID(t *testing.T) {
+	now := time.UnixMilli(1700000000123)
+	clock, advance := fixedClock(now)
+	generator := id.NewGenerator(id.WithEntropy(bytes.NewReader(bytes.Repeat([]byte{0x01}, 64))), id.WithClock(clock))
+
+	first := generator.ULID()
+	assert.Equal(t, now, first.Time())
+	assert.Equal(t, "01HF7YAT3V040G2081040G2081", first.String(), "the entropy is deterministic")
+
+	// The ULIDs of a same millisecond are incremented.
+	second := generator.ULID()
+	assert.Equal(t, now, second.Time())
+	assert.Equal(t, "01HF7YAT3V040G2081040G2082", second.String())
+
+	// The ULIDs stay monotonic when the clock goes backwards.
+	advance(-time.Second)
+	third := generator.ULID()
+	assert.Greater(t, third.String(), second.String())
+	assert.Equal(t, now, third.Time())
+
+	// New entropy is read in the next milliseconds.
+	advance(2 * time.Second)
+	fourth := generator.ULID()
+	assert.Equal(t, now.Add(time.Second), fourth.Time())
+	assert.Greater(t, fourth.String(), third.String())
+}
+
+func TestGenerator_UUIDv7(t *testing.T) {
+	now := time.UnixMilli(1700000000123)
+	clock, _ := fixedClock(now)
+	generator := id.NewGenerator(id.WithEntropy(bytes.NewReader(bytes.Repeat([]byte{0xFF}, 64))), id.WithClock(clock))
+
+	first := generator.UUIDv7()
+	assert.Equal(t, 7, first.Version())
+	assert.Equal(t, now, first.Time())
+	assert.Equal(t, "018bcfe5-687b-7fff-9fff-ffffffffffff", first.String(), "the first bit of rand_b is cleared")
+
+	// The UUIDs of a same millisecond are incremented, keeping the version and the variant.
+	second := generator.UUIDv7()
+	assert.Equal(t, "018bcfe5-687b-7fff-a000-000000000000", second.String())
+
+	parsed, err := id.ParseUUID(second.String())
+	require.NoError(t, err)
+	assert.Equal(t, second, parsed)
+}
+
+func TestGenerator_Concurrent(t *testing.T) {
+	generator := id.NewGenerator()
+	const n = 1000
+	ids := make(chan string, 2*n)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range n / 10 {
+				ids <- generator.ULID().String()
+				ids <- generator.UUIDv7().String()
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := map[string]bool{}
+	for s := range ids {
+		assert.False(t, seen[s], "duplicate %s", s)
+		seen[s] = true
+	}
+	assert.Len(t, seen, 2*n)
+}
+
+func TestParseULID(t *testing.T) {
+	u := id.NewULID()
+	parsed, err := id.ParseULID(strings.ToLower(u.String()))
+	require.NoError(t, err)
+	assert.Equal(t, u, parsed)
+
+	for _, s := range []string{"", "01HF7YAT0V0081040G2081040", "81HF7YAT0V0081040G2081040G", "01HF7YAT0V0081040G2081040U"} {
+		_, err := id.ParseULID(s)
+		assert.ErrorIs(t, err, id.ErrInvalidULID, s)
+	}
+
+	parsed, err = id.ParseULID("7ZZZZZZZZZZZZZZZZZZZZZZZZZ")
+	require.NoError(t, err)
+	assert.Equal(t, id.ULID{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, parsed)
+}
+
+func TestParseUUID(t *testing.T) {
+	for _, s := range []string{
+		"f47ac10b-58cc-4372-a567-0e02b2c3d479",
+		"F47AC10B-58CC-4372-A567-0E02B2C3D479",
+		"00000000-0000-0000-0000-000000000000",
+		"ffffffff-ffff-ffff-ffff-ffffffffffff",
+	} {
+		_, err := id.ParseUUID(s)
+		assert.NoError(t, err, s)
+	}
+	u, err := id.ParseUUID("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+	require.NoError(t, err)
+	assert.Equal(t, 4, u.Version())
+	assert.True(t, u.Time().IsZero(), "only the version 7 UUIDs have a time")
+
+	for _, s := range []string{
+		"",
+		"f47ac10b58cc4372a5670e02b2c3d479",
+		"f47ac10b-58cc-4372-a567-0e02b2c3d47",
+		"f47ac10b-58cc-4372-c567-0e02b2c3d479", // variant
+		"g47ac10b-58cc-4372-a567-0e02b2c3d479",
+	} {
+		_, err := id.ParseUUID(s)
+		assert.ErrorIs(t, err, id.ErrInvalidUUID, s)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type order struct {
+		ID     id.ULID `json:"id"`
+		UserID id.UUID `json:"user_id"`
+	}
+	in := order{ID: id.NewULID(), UserID: id.NewUUIDv7()}
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"`+in.ID.String()+`","user_id":"`+in.UserID.String()+`"}`, string(data))
+
+	var out order
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"id":"invalid"}`), &out))
+}
+
+func TestTag(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, id.Fields(ctx))
+
+	orderID, userID := id.NewULID(), id.NewUUIDv7()
+	ctx = id.Tag(ctx, "order_id", orderID)
+	tagged := id.Tag(ctx, "user_id", userID)
+	assert.Equal(t, logger.Fields{"order_id": orderID.String()}, id.Fields(ctx), "the parent context is unchanged")
+	assert.Equal(t, logger.Fields{"order_id": orderID.String(), "user_id": userID.String()}, id.Fields(tagged))
+
+	var buf bytes.Buffer
+	log, err := logger.NewLogger(logger.Config{
+		Level:             logger.INFO,
+		Output:            &buf,
+		ContextExtractors: []logger.ContextExtractor{id.Fields},
+	})
+	require.NoError(t, err)
+	log.Info(tagged, "order created", nil)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, orderID.String(), entry["order_id"])
+	assert.Equal(t, userID.String(), entry["user_id"])
+}
@@ -0,0 +1,294 @@
+package id
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultNodeIDEnv is the environment variable of the node ID of the Snowflake, tried first unless set with
+	// WithNodeID.
+	DefaultNodeIDEnv = "NODE_ID"
+	// DefaultNodeBits is the size of the node ID of the Snowflake, unless set with WithBits: 1024 nodes.
+	DefaultNodeBits = 10
+	// DefaultSequenceBits is the size of the sequence of the Snowflake, unless set with WithBits: 4096 IDs per
+	// millisecond and node.
+	DefaultSequenceBits = 12
+	// DefaultMaxClockRollback is the clock rollback waited out by the Snowflake, unless set with
+	// WithMaxClockRollback.
+	DefaultMaxClockRollback = 10 * time.Millisecond
+
+	// snowflakeBits is the size of the node ID, the sequence, and the timestamp of the Snowflake IDs: the sign bit
+	// is unused, so that the IDs are positive.
+	snowflakeBits = 63
+	// minTimestampBits is the minimum size of the timestamp of the Snowflake IDs: about 35 years.
+	minTimestampBits = 40
+)
+
+// DefaultEpoch is the origin of the timestamps of the Snowflake, unless set with WithEpoch.
+var DefaultEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	// ErrInvalidNodeID is returned by NewSnowflake when no node ID source succeeds, or the node ID does not fit in
+	// the node bits.
+	ErrInvalidNodeID = errors.New("id: invalid node ID")
+	// ErrClockRollback is returned by Next when the clock went backwards by more than the maximum clock rollback.
+	ErrClockRollback = errors.New("id: clock moved backwards")
+	// ErrTimeOutOfRange is returned by Next when the clock is before the epoch, or after the last timestamp of the
+	// timestamp bits.
+	ErrTimeOutOfRange = errors.New("id: time out of the range of the epoch")
+)
+
+// NodeIDSource returns the node ID of the Snowflake, at most maxNodeID.
+type NodeIDSource func(maxNodeID int64) (int64, error)
+
+// NodeID returns a NodeIDSource of id, e.g., read from the configuration.
+func NodeID(id int64) NodeIDSource {
+	return func(maxNodeID int64) (int64, error) {
+		return checkNodeID(id, maxNodeID)
+	}
+}
+
+// NodeIDFromEnv returns a NodeIDSource reading the node ID from the environment variable key.
+func NodeIDFromEnv(key string) NodeIDSource {
+	return func(maxNodeID int64) (int64, error) {
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			return 0, fmt.Errorf("%w: %s is not set", ErrInvalidNodeID, key)
+		}
+		id, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s: %w", ErrInvalidNodeID, key, err)
+		}
+		return checkNodeID(id, maxNodeID)
+	}
+}
+
+// NodeIDFromHostname returns a NodeIDSource reading the node ID from the trailing digits of the hostname, e.g., the
+// ordinal of the pods of a Kubernetes StatefulSet: "orders-3" is the node 3.
+func NodeIDFromHostname() NodeIDSource {
+	return func(maxNodeID int64) (int64, error) {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrInvalidNodeID, err)
+		}
+		digits := len(hostname)
+		for digits > 0 && hostname[digits-1] >= '0' && hostname[digits-1] <= '9' {
+			digits--
+		}
+		if digits == len(hostname) {
+			return 0, fmt.Errorf("%w: hostname %q has no trailing digits", ErrInvalidNodeID, hostname)
+		}
+		id, err := strconv.ParseInt(hostname[digits:], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: hostname %q: %w", ErrInvalidNodeID, hostname, err)
+		}
+		return checkNodeID(id, maxNodeID)
+	}
+}
+
+// NodeIDFromIP returns a NodeIDSource reading the node ID from the low bits of the first non-loopback IPv4 address
+// of the host. The nodes whose addresses share the low bits get the same node ID: use it within a subnet of at most
+// maxNodeID+1 addresses, e.g., a /22 for the default 10 node bits.
+func NodeIDFromIP() NodeIDSource {
+	return func(maxNodeID int64) (int64, error) {
+		addrs, err := net.InterfaceAddrs()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrInvalidNodeID, err)
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.IsLoopback() {
+				continue
+			}
+			if ip := ipNet.IP.To4(); ip != nil {
+				return (int64(ip[0])<<24 | int64(ip[1])<<16 | int64(ip[2])<<8 | int64(ip[3])) & maxNodeID, nil
+			}
+		}
+		return 0, fmt.Errorf("%w: no non-loopback IPv4 address", ErrInvalidNodeID)
+	}
+}
+
+// checkNodeID returns id if it is between 0 and maxNodeID.
+func checkNodeID(id, maxNodeID int64) (int64, error) {
+	if id < 0 || id > maxNodeID {
+		return 0, fmt.Errorf("%w: %d is not between 0 and %d", ErrInvalidNodeID, id, maxNodeID)
+	}
+	return id, nil
+}
+
+// WithEpoch sets the origin of the timestamps of the Snowflake: the IDs cannot be generated before it, nor after the
+// last timestamp of the timestamp bits, about 69 years later with the default bits. It defaults to DefaultEpoch, and
+// must not change once IDs are generated.
+func WithEpoch(epoch time.Time) Option {
+	return func(opts *options) {
+		if !epoch.IsZero() {
+			opts.epoch = epoch
+		}
+	}
+}
+
+// WithNodeID sets the sources of the node ID of the Snowflake, tried in order until one succeeds. It defaults to
+// NodeIDFromEnv(DefaultNodeIDEnv), NodeIDFromHostname(), and NodeIDFromIP().
+func WithNodeID(sources ...NodeIDSource) Option {
+	return func(opts *options) {
+		if len(sources) > 0 {
+			opts.nodeIDs = sources
+		}
+	}
+}
+
+// WithBits sets the sizes of the node ID and the sequence of the Snowflake, the timestamp taking the remaining bits
+// of the 63 bits of the IDs, at least 40. It defaults to DefaultNodeBits and DefaultSequenceBits.
+func WithBits(nodeBits, sequenceBits uint) Option {
+	return func(opts *options) {
+		if sequenceBits > 0 && nodeBits+sequenceBits <= snowflakeBits-minTimestampBits {
+			opts.nodeBits = nodeBits
+			opts.sequenceBits = sequenceBits
+		}
+	}
+}
+
+// WithMaxClockRollback sets the clock rollback waited out by the Snowflake, e.g., an NTP correction: Next waits for
+// the clock to catch up on the last timestamp, and returns ErrClockRollback for larger rollbacks. It defaults to
+// DefaultMaxClockRollback.
+func WithMaxClockRollback(d time.Duration) Option {
+	return func(opts *options) {
+		if d >= 0 {
+			opts.maxClockRollback = d
+		}
+	}
+}
+
+/*
+Snowflake generates compact int64 IDs, sortable by generation time, for the keys that must fit in a BIGINT column:
+a millisecond timestamp since the epoch, the node ID, and a sequence within the millisecond. Each node of a service
+must have its own node ID so that the IDs are unique.
+
+When the sequence of a millisecond is exhausted, Next waits for the next millisecond. When the clock goes backwards,
+Next waits for it to catch up up to the maximum clock rollback, and fails beyond, so that no ID is generated twice.
+
+It is safe for concurrent use.
+
+Example usage:
+
+	// NODE_ID, or the ordinal of the hostname, or the low bits of the IPv4 address
+	snowflake, err := id.NewSnowflake()
+	if err != nil {
+		return err
+	}
+
+	orderID, err := snowflake.Next()
+*/
+type Snowflake struct {
+	opts   *options
+	epoch  int64 // epoch is the epoch in milliseconds.
+	nodeID int64
+
+	maxTimestamp int64
+	maxSequence  int64
+
+	mu        sync.Mutex
+	timestamp int64 // timestamp is the timestamp of the last ID.
+	sequence  int64 // sequence is the sequence of the last ID.
+}
+
+// NewSnowflake creates a Snowflake. It returns ErrInvalidNodeID if no node ID source succeeds.
+func NewSnowflake(opts ...Option) (*Snowflake, error) {
+	o := newOptions(opts)
+	maxNodeID := int64(1)<<o.nodeBits - 1
+
+	var errs []error
+	for _, source := range o.nodeIDs {
+		nodeID, err := source(maxNodeID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return &Snowflake{
+			opts:         o,
+			epoch:        o.epoch.UnixMilli(),
+			nodeID:       nodeID,
+			maxTimestamp: int64(1)<<(snowflakeBits-o.nodeBits-o.sequenceBits) - 1,
+			maxSequence:  int64(1)<<o.sequenceBits - 1,
+			timestamp:    -1,
+		}, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// NodeID returns the node ID of s.
+func (s *Snowflake) NodeID() int64 {
+	return s.nodeID
+}
+
+// Next returns a new ID, greater than the IDs previously returned by s. It returns ErrClockRollback when the clock
+// went backwards by more than the maximum clock rollback, and ErrTimeOutOfRange when the clock is out of the range of
+// the epoch.
+func (s *Snowflake) Next() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timestamp, err := s.now()
+	if err != nil {
+		return 0, err
+	}
+	if timestamp < s.timestamp {
+		rollback := time.Duration(s.timestamp-timestamp) * time.Millisecond
+		if rollback > s.opts.maxClockRollback {
+			return 0, fmt.Errorf("%w by %v", ErrClockRollback, rollback)
+		}
+		if timestamp, err = s.waitAfter(s.timestamp - 1); err != nil {
+			return 0, err
+		}
+	}
+
+	if timestamp == s.timestamp {
+		s.sequence = (s.sequence + 1) & s.maxSequence
+		if s.sequence == 0 {
+			// The sequence of the millisecond is exhausted.
+			if timestamp, err = s.waitAfter(s.timestamp); err != nil {
+				return 0, err
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.timestamp = timestamp
+
+	return timestamp<<(s.opts.nodeBits+s.opts.sequenceBits) | s.nodeID<<s.opts.sequenceBits | s.sequence, nil
+}
+
+// Decompose returns the time, the node ID, and the sequence of an ID of s.
+func (s *Snowflake) Decompose(id int64) (t time.Time, nodeID, sequence int64) {
+	timestamp := id >> (s.opts.nodeBits + s.opts.sequenceBits)
+	nodeID = id >> s.opts.sequenceBits & (int64(1)<<s.opts.nodeBits - 1)
+	sequence = id & s.maxSequence
+	return time.UnixMilli(s.epoch + timestamp), nodeID, sequence
+}
+
+// now returns the current timestamp since the epoch.
+func (s *Snowflake) now() (int64, error) {
+	timestamp := s.opts.now().UnixMilli() - s.epoch
+	if timestamp < 0 || timestamp > s.maxTimestamp {
+		return 0, ErrTimeOutOfRange
+	}
+	return timestamp, nil
+}
+
+// waitAfter waits for a timestamp after last, and returns it.
+func (s *Snowflake) waitAfter(last int64) (int64, error) {
+	for {
+		timestamp, err := s.now()
+		if err != nil || timestamp > last {
+			return timestamp, err
+		}
+		time.Sleep(time.Duration(last-timestamp+1) * time.Millisecond)
+	}
+}
@@ -0,0 +1,156 @@
+package id_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/id"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnowflake_Next(t *testing.T) {
+	epoch := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := epoch.Add(1500 * time.Millisecond)
+	clock, advance := fixedClock(now)
+	snowflake, err := id.NewSnowflake(id.WithNodeID(id.NodeID(5)), id.WithEpoch(epoch), id.WithClock(clock))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), snowflake.NodeID())
+
+	first, err := snowflake.Next()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500<<22|5<<12), first)
+	second, err := snowflake.Next()
+	require.NoError(t, err)
+	assert.Equal(t, first+1, second, "the sequence is incremented within a millisecond")
+
+	advance(time.Millisecond)
+	third, err := snowflake.Next()
+	require.NoError(t, err)
+	ts, nodeID, sequence := snowflake.Decompose(third)
+	assert.Equal(t, now.Add(time.Millisecond), ts.UTC())
+	assert.Equal(t, int64(5), nodeID)
+	assert.Equal(t, int64(0), sequence, "the sequence restarts in a new millisecond")
+}
+
+func TestSnowflake_SequenceOverflow(t *testing.T) {
+	// The clock moves by a quarter of millisecond per call: the 4 IDs of a millisecond are exhausted first.
+	var mu sync.Mutex
+	now := id.DefaultEpoch.Add(time.Second)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(250 * time.Microsecond)
+		return now
+	}
+	snowflake, err := id.NewSnowflake(id.WithNodeID(id.NodeID(1)), id.WithBits(4, 2), id.WithClock(clock))
+	require.NoError(t, err)
+
+	var last int64
+	for i := range 20 {
+		next, err := snowflake.Next()
+		require.NoError(t, err)
+		assert.Greater(t, next, last, i)
+		last = next
+	}
+	_, _, sequence := snowflake.Decompose(last)
+	assert.LessOrEqual(t, sequence, int64(3))
+}
+
+func TestSnowflake_ClockRollback(t *testing.T) {
+	var mu sync.Mutex
+	now := id.DefaultEpoch.Add(time.Hour)
+	step := time.Duration(0)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(step)
+		return now
+	}
+	setClock := func(d, s time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now, step = now.Add(d), s
+	}
+	snowflake, err := id.NewSnowflake(id.WithNodeID(id.NodeID(1)), id.WithClock(clock), id.WithMaxClockRollback(10*time.Millisecond))
+	require.NoError(t, err)
+
+	first, err := snowflake.Next()
+	require.NoError(t, err)
+
+	// A small rollback is waited out.
+	setClock(-5*time.Millisecond, time.Millisecond)
+	second, err := snowflake.Next()
+	require.NoError(t, err)
+	assert.Greater(t, second, first)
+
+	// A large rollback fails instead of generating duplicates.
+	setClock(-time.Minute, 0)
+	_, err = snowflake.Next()
+	assert.ErrorIs(t, err, id.ErrClockRollback)
+
+	setClock(time.Minute, 0)
+	third, err := snowflake.Next()
+	require.NoError(t, err)
+	assert.Greater(t, third, second)
+}
+
+func TestSnowflake_TimeOutOfRange(t *testing.T) {
+	snowflake, err := id.NewSnowflake(id.WithNodeID(id.NodeID(1)), id.WithEpoch(time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+	_, err = snowflake.Next()
+	assert.ErrorIs(t, err, id.ErrTimeOutOfRange)
+}
+
+func TestSnowflake_NodeID(t *testing.T) {
+	t.Setenv("ORDERS_NODE_ID", "42")
+	snowflake, err := id.NewSnowflake(id.WithNodeID(id.NodeIDFromEnv("MISSING_NODE_ID"), id.NodeIDFromEnv("ORDERS_NODE_ID")))
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), snowflake.NodeID(), "the sources are tried in order")
+
+	t.Setenv("ORDERS_NODE_ID", "1024")
+	_, err = id.NewSnowflake(id.WithNodeID(id.NodeIDFromEnv("ORDERS_NODE_ID")))
+	assert.ErrorIs(t, err, id.ErrInvalidNodeID, "the node ID must fit in the node bits")
+	snowflake, err = id.NewSnowflake(id.WithNodeID(id.NodeIDFromEnv("ORDERS_NODE_ID")), id.WithBits(11, 11))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1024), snowflake.NodeID())
+
+	t.Setenv("ORDERS_NODE_ID", "node")
+	_, err = id.NewSnowflake(id.WithNodeID(id.NodeIDFromEnv("ORDERS_NODE_ID"), id.NodeID(-1)))
+	assert.ErrorIs(t, err, id.ErrInvalidNodeID)
+
+	// The IP address is masked to the node bits, when the host has one.
+	if snowflake, err := id.NewSnowflake(id.WithNodeID(id.NodeIDFromIP()), id.WithBits(8, 12)); err == nil {
+		assert.LessOrEqual(t, snowflake.NodeID(), int64(255))
+	}
+}
+
+func TestSnowflake_Concurrent(t *testing.T) {
+	snowflake, err := id.NewSnowflake(id.WithNodeID(id.NodeID(1)), id.WithBits(10, 4))
+	require.NoError(t, err)
+
+	const n = 2000
+	ids := make(chan int64, n)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range n / 10 {
+				next, err := snowflake.Next()
+				assert.NoError(t, err)
+				ids <- next
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := map[int64]bool{}
+	for next := range ids {
+		assert.False(t, seen[next], "duplicate %d", next)
+		seen[next] = true
+	}
+	assert.Len(t, seen, n)
+}
@@ -0,0 +1,129 @@
+package id
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// ErrInvalidULID is returned when parsing a string that is not a ULID.
+var ErrInvalidULID = errors.New("id: invalid ULID")
+
+// ulidEncoding is the Crockford's base32 alphabet of the ULIDs.
+const ulidEncoding = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidDecoding maps the characters of the ULIDs to their values, 0xFF for the invalid ones. Decoding is
+// case-insensitive.
+var ulidDecoding = func() [256]byte {
+	var dec [256]byte
+	for i := range dec {
+		dec[i] = 0xFF
+	}
+	for i := 0; i < len(ulidEncoding); i++ {
+		dec[ulidEncoding[i]] = byte(i)
+		dec[ulidEncoding[i]|0x20] = byte(i) // lowercase
+	}
+	return dec
+}()
+
+/*
+ULID is a Universally Unique Lexicographically Sortable Identifier: a 48-bit millisecond timestamp followed by 80
+random bits, encoded as 26 characters of Crockford's base32. The strings of the ULIDs sort like their times.
+
+It is encoded as its string in JSON, and the text formats.
+
+Example usage:
+
+	orderID := id.NewULID() // 01J9Z3Q4N7X8Y2K5M6P0R1S2T3
+	parsed, err := id.ParseULID(orderID.String())
+*/
+type ULID [16]byte
+
+// ULID returns a new ULID, greater than the ULIDs previously returned by g. It panics if the entropy cannot be read.
+func (g *Generator) ULID() ULID {
+	g.mu.Lock()
+	timestamp, hi, lo := g.next(&g.ulid, 16, 64)
+	g.mu.Unlock()
+
+	var u ULID
+	putTimestamp(u[:], timestamp)
+	binary.BigEndian.PutUint16(u[6:], uint16(hi))
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return u
+}
+
+// ParseULID parses a ULID from its string, in any case. It returns ErrInvalidULID if s is not a ULID.
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if err := u.UnmarshalText([]byte(s)); err != nil {
+		return ULID{}, err
+	}
+	return u, nil
+}
+
+// Time returns the time of u, to the millisecond.
+func (u ULID) Time() time.Time {
+	return time.UnixMilli(readTimestamp(u[:]))
+}
+
+// IsZero reports whether u is the zero ULID.
+func (u ULID) IsZero() bool {
+	return u == ULID{}
+}
+
+// String returns the 26 characters of u.
+func (u ULID) String() string {
+	text, _ := u.MarshalText()
+	return string(text)
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (u ULID) MarshalText() ([]byte, error) {
+	// The 128 bits are encoded in 26 characters of 5 bits: the first character holds the first 3 bits.
+	text := make([]byte, 26)
+	hi := binary.BigEndian.Uint64(u[:8])
+	lo := binary.BigEndian.Uint64(u[8:])
+	for i := 25; i >= 0; i-- {
+		text[i] = ulidEncoding[lo&0x1F]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return text, nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (u *ULID) UnmarshalText(text []byte) error {
+	// The first character holds 3 bits: the greater ones overflow 128 bits.
+	if len(text) != 26 || ulidDecoding[text[0]] > 7 {
+		return ErrInvalidULID
+	}
+	var hi, lo uint64
+	for _, c := range text {
+		v := ulidDecoding[c]
+		if v == 0xFF {
+			return ErrInvalidULID
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	binary.BigEndian.PutUint64(u[:8], hi)
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return nil
+}
+
+// putTimestamp writes the 48-bit millisecond timestamp in the first 6 bytes of b.
+func putTimestamp(b []byte, timestamp int64) {
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(timestamp)
+		timestamp >>= 8
+	}
+}
+
+// readTimestamp reads the 48-bit millisecond timestamp of the first 6 bytes of b.
+func readTimestamp(b []byte) int64 {
+	var t int64
+	for _, c := range b[:6] {
+		t = t<<8 | int64(c)
+	}
+	return t
+}
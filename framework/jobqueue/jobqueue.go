@@ -0,0 +1,126 @@
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+// DefaultMaxRetries is the number of retries of a failing job, unless set with WithMaxRetries.
+const DefaultMaxRetries = 3
+
+// ErrReceiptExpired is returned by the backends when a job is settled after its visibility timeout, in which case it
+// may already be delivered again.
+var ErrReceiptExpired = errors.New("jobqueue: receipt expired")
+
+// Job is a unit of background work, enqueued with a payload and run by a Worker.
+type Job struct {
+	ID         string    // ID is unique in the queue.
+	Queue      string    // Queue is the name of the queue of the job.
+	Payload    []byte    // Payload is the input of the job, e.g., marshaled with encoding/json.
+	Attempt    int       // Attempt is the number of deliveries of the job, starting at 1.
+	MaxRetries int       // MaxRetries is the number of retries of the job before it is dead-lettered.
+	EnqueuedAt time.Time // EnqueuedAt is the time the job was enqueued.
+	LastError  string    // LastError is the error of the previous attempt, if any.
+	Receipt    string    // Receipt identifies the delivery, set by Backend.Dequeue to settle the job.
+}
+
+/*
+Backend stores the jobs of the queues, e.g., redisqueue. A job dequeued is invisible to the other workers until it is
+settled, with Ack, Retry or DeadLetter, or until its visibility timeout, after which it is delivered again, so that
+the jobs of a crashed worker are not lost. The jobs are therefore run at least once.
+
+The methods settling a job return ErrReceiptExpired if the receipt of the job is not the one of its last delivery.
+*/
+type Backend interface {
+	// Enqueue adds job, with its ID, Queue, Payload and MaxRetries set, to be delivered after delay. It sets the
+	// EnqueuedAt of job.
+	Enqueue(ctx context.Context, job *Job, delay time.Duration) error
+	// Dequeue returns the next job of queue ready to run, invisible for visibility, or nil if there is none.
+	Dequeue(ctx context.Context, queue string, visibility time.Duration) (*Job, error)
+	// Ack removes job, which succeeded.
+	Ack(ctx context.Context, job *Job) error
+	// Retry delivers job again after delay, recording its error.
+	Retry(ctx context.Context, job *Job, delay time.Duration, cause error) error
+	// DeadLetter moves job to the dead-letter set of its queue, recording its error. It is not delivered again.
+	DeadLetter(ctx context.Context, job *Job, cause error) error
+}
+
+// enqueueOptions holds configuration options for a job enqueued.
+type enqueueOptions struct {
+	delay      time.Duration // delay is the time before the job is ready to run.
+	maxRetries int           // maxRetries is the number of retries of the job.
+}
+
+// EnqueueOption specifies the configuration options of a job enqueued.
+type EnqueueOption func(*enqueueOptions)
+
+// WithDelay delays the job by d, e.g., to send a reminder later. The job is ready to run at once by default.
+func WithDelay(d time.Duration) EnqueueOption {
+	return func(opts *enqueueOptions) {
+		if d > 0 {
+			opts.delay = d
+		}
+	}
+}
+
+// WithMaxRetries sets the number of retries of the job when it fails, after which it is dead-lettered. Zero does not
+// retry the job. It defaults to DefaultMaxRetries.
+func WithMaxRetries(n int) EnqueueOption {
+	return func(opts *enqueueOptions) {
+		if n >= 0 {
+			opts.maxRetries = n
+		}
+	}
+}
+
+/*
+Queue enqueues jobs to a named queue of a Backend, run by the Workers of the queue, in the same or another service.
+
+Example usage:
+
+	queue := jobqueue.New(redisqueue.New(goredisclient.New(rdb)), "emails")
+
+	payload, err := json.Marshal(WelcomeEmail{UserID: userID})
+	if err != nil {
+		// Handle error
+	}
+	id, err := queue.Enqueue(ctx, payload, jobqueue.WithDelay(time.Hour), jobqueue.WithMaxRetries(5))
+	if err != nil {
+		// Handle error
+	}
+*/
+type Queue struct {
+	backend Backend
+	name    string
+}
+
+// New creates the queue name of backend.
+func New(backend Backend, name string) *Queue {
+	return &Queue{backend: backend, name: name}
+}
+
+// Name returns the name of the queue.
+func (q *Queue) Name() string {
+	return q.name
+}
+
+// Enqueue adds a job with payload to the queue, and returns its ID.
+func (q *Queue) Enqueue(ctx context.Context, payload []byte, opts ...EnqueueOption) (string, error) {
+	o := enqueueOptions{maxRetries: DefaultMaxRetries}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	job := &Job{
+		ID:         xid.New().String(),
+		Queue:      q.name,
+		Payload:    payload,
+		MaxRetries: o.maxRetries,
+	}
+	if err := q.backend.Enqueue(ctx, job, o.delay); err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
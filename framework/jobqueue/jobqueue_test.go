@@ -0,0 +1,152 @@
+package jobqueue_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/jobqueue"
+)
+
+// memoryBackend is a jobqueue.Backend in memory, with the visibility timeouts and receipts of the real backends.
+type memoryBackend struct {
+	mu         sync.Mutex
+	jobs       map[string]*jobqueue.Job
+	readyAt    map[string]time.Time
+	dead       map[string]*jobqueue.Job
+	deliveries int
+	err        error
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{
+		jobs:    make(map[string]*jobqueue.Job),
+		readyAt: make(map[string]time.Time),
+		dead:    make(map[string]*jobqueue.Job),
+	}
+}
+
+func (b *memoryBackend) Enqueue(ctx context.Context, job *jobqueue.Job, delay time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	job.EnqueuedAt = time.Now()
+	stored := *job
+	b.jobs[job.ID] = &stored
+	b.readyAt[job.ID] = job.EnqueuedAt.Add(delay)
+	return nil
+}
+
+func (b *memoryBackend) Dequeue(ctx context.Context, queue string, visibility time.Duration) (*jobqueue.Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return nil, b.err
+	}
+	now := time.Now()
+	for id, job := range b.jobs {
+		if job.Queue != queue || b.readyAt[id].After(now) {
+			continue
+		}
+		b.deliveries++
+		b.readyAt[id] = now.Add(visibility)
+		job.Attempt++
+		job.Receipt = strconv.Itoa(b.deliveries)
+		delivered := *job
+		return &delivered, nil
+	}
+	return nil, nil
+}
+
+// settle removes job if it is delivered with its receipt.
+func (b *memoryBackend) settle(job *jobqueue.Job) (*jobqueue.Job, error) {
+	stored, ok := b.jobs[job.ID]
+	if !ok || stored.Receipt != job.Receipt {
+		return nil, jobqueue.ErrReceiptExpired
+	}
+	stored.Receipt = ""
+	return stored, nil
+}
+
+func (b *memoryBackend) Ack(ctx context.Context, job *jobqueue.Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, err := b.settle(job); err != nil {
+		return err
+	}
+	delete(b.jobs, job.ID)
+	delete(b.readyAt, job.ID)
+	return nil
+}
+
+func (b *memoryBackend) Retry(ctx context.Context, job *jobqueue.Job, delay time.Duration, cause error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stored, err := b.settle(job)
+	if err != nil {
+		return err
+	}
+	stored.LastError = cause.Error()
+	b.readyAt[job.ID] = time.Now().Add(delay)
+	return nil
+}
+
+func (b *memoryBackend) DeadLetter(ctx context.Context, job *jobqueue.Job, cause error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stored, err := b.settle(job)
+	if err != nil {
+		return err
+	}
+	stored.LastError = cause.Error()
+	b.dead[job.ID] = stored
+	delete(b.jobs, job.ID)
+	delete(b.readyAt, job.ID)
+	return nil
+}
+
+// pending returns the number of jobs not settled for good.
+func (b *memoryBackend) pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.jobs)
+}
+
+// deadJob returns the job id of the dead-letter set.
+func (b *memoryBackend) deadJob(id string) *jobqueue.Job {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dead[id]
+}
+
+func TestQueue_Enqueue(t *testing.T) {
+	backend := newMemoryBackend()
+	queue := jobqueue.New(backend, "emails")
+	assert.Equal(t, "emails", queue.Name())
+
+	id, err := queue.Enqueue(context.Background(), []byte(`{"user_id":1}`))
+	require.NoError(t, err)
+	require.NotEmpty(t, id)
+	job := backend.jobs[id]
+	assert.Equal(t, "emails", job.Queue)
+	assert.Equal(t, []byte(`{"user_id":1}`), job.Payload)
+	assert.Equal(t, jobqueue.DefaultMaxRetries, job.MaxRetries)
+	assert.False(t, backend.readyAt[id].After(time.Now()))
+
+	id, err = queue.Enqueue(context.Background(), nil, jobqueue.WithDelay(time.Hour), jobqueue.WithMaxRetries(0))
+	require.NoError(t, err)
+	assert.Zero(t, backend.jobs[id].MaxRetries)
+	assert.True(t, backend.readyAt[id].After(time.Now().Add(59*time.Minute)))
+
+	backend.err = errors.New("connection refused")
+	_, err = queue.Enqueue(context.Background(), nil)
+	assert.ErrorIs(t, err, backend.err)
+}
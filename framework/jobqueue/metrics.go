@@ -0,0 +1,44 @@
+package jobqueue
+
+import (
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+// Values of the status label of the metrics.
+const (
+	StatusSuccess      = "success"
+	StatusRetried      = "retried"
+	StatusDeadLettered = "dead_lettered"
+)
+
+/*
+Metrics records the jobs run by the workers in a metrics.Registry, labelled by queue:
+
+	jobqueue_jobs_total{queue="emails",status="success"} 42
+	jobqueue_job_duration_seconds_bucket{queue="emails",le="1"} 40
+
+Example usage:
+
+	queueMetrics, err := jobqueue.NewMetrics(metrics.Default())
+	if err != nil {
+		// Handle error
+	}
+	worker := jobqueue.NewWorker(queue, handler, jobqueue.WithMetrics(queueMetrics))
+*/
+type Metrics struct {
+	jobs     *metrics.Counter
+	duration *metrics.Histogram
+}
+
+// NewMetrics creates the metrics of the jobs in registry, or returns the ones already created.
+func NewMetrics(registry *metrics.Registry) (*Metrics, error) {
+	jobs, err := registry.NewCounter("jobqueue_jobs_total", "Number of runs of the jobs of a queue.", "queue", "status")
+	if err != nil {
+		return nil, err
+	}
+	duration, err := registry.NewHistogram("jobqueue_job_duration_seconds", "Duration of the runs of the jobs of a queue in seconds.", nil, "queue")
+	if err != nil {
+		return nil, err
+	}
+	return &Metrics{jobs: jobs, duration: duration}, nil
+}
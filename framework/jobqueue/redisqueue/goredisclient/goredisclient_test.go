@@ -0,0 +1,54 @@
+package goredisclient_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/jobqueue"
+	"github.com/kittipat1413/go-common/framework/jobqueue/redisqueue"
+	"github.com/kittipat1413/go-common/framework/jobqueue/redisqueue/goredisclient"
+)
+
+func TestBackend(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	backend := redisqueue.New(goredisclient.New(rdb))
+
+	job := &jobqueue.Job{ID: "1", Queue: "emails", Payload: []byte(`{"to":"alice"}`), MaxRetries: 3}
+	require.NoError(t, backend.Enqueue(ctx, job, 0))
+	assert.False(t, job.EnqueuedAt.IsZero())
+
+	dequeued, err := backend.Dequeue(ctx, "emails", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, dequeued)
+	assert.Equal(t, "1", dequeued.ID)
+	assert.Equal(t, []byte(`{"to":"alice"}`), dequeued.Payload)
+	assert.Equal(t, 1, dequeued.Attempt)
+	assert.Equal(t, 3, dequeued.MaxRetries)
+	assert.Equal(t, job.EnqueuedAt, dequeued.EnqueuedAt)
+	assert.Empty(t, dequeued.LastError)
+
+	none, err := backend.Dequeue(ctx, "emails", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, none, "the job dequeued is invisible")
+
+	require.NoError(t, backend.Retry(ctx, dequeued, 0, errors.New("smtp unavailable")))
+	dequeued, err = backend.Dequeue(ctx, "emails", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, dequeued)
+	assert.Equal(t, 2, dequeued.Attempt)
+	assert.Equal(t, "smtp unavailable", dequeued.LastError)
+
+	require.NoError(t, backend.Ack(ctx, dequeued))
+	assert.ErrorIs(t, backend.Ack(ctx, dequeued), jobqueue.ErrReceiptExpired)
+	assert.False(t, server.Exists("jobqueue:{emails}:job:1"))
+}
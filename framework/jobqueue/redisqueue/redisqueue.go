@@ -0,0 +1,256 @@
+package redisqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/kittipat1413/go-common/framework/jobqueue"
+)
+
+// DefaultKeyPrefix is prepended to the keys of the queues in Redis unless WithKeyPrefix is set.
+const DefaultKeyPrefix = "jobqueue:"
+
+// ErrUnexpectedReply is returned when the reply of a script cannot be parsed.
+var ErrUnexpectedReply = errors.New("redisqueue: unexpected script reply")
+
+// nowFunction defines the now function of the scripts, returning the time of the Redis server in milliseconds.
+const nowFunction = `
+if redis.replicate_commands then redis.replicate_commands() end
+local function now()
+	local time = redis.call('TIME')
+	return tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+end
+`
+
+// enqueueScript adds the job KEYS[2] to the pending set KEYS[1]. ARGV[1] is the ID, ARGV[2] the payload, ARGV[3]
+// the max retries and ARGV[4] the delay in milliseconds. It returns the time the job was enqueued.
+const enqueueScript = nowFunction + `
+local enqueued_at = now()
+redis.call('HSET', KEYS[2], 'payload', ARGV[2], 'attempt', 0, 'max_retries', ARGV[3], 'enqueued_at', enqueued_at)
+redis.call('ZADD', KEYS[1], enqueued_at + tonumber(ARGV[4]), ARGV[1])
+return enqueued_at
+`
+
+// dequeueScript reserves the first job ready of the pending set KEYS[1]. ARGV[1] is the prefix of the keys of the
+// jobs, ARGV[2] the visibility timeout in milliseconds and ARGV[3] the receipt. It returns {ID, payload, attempt,
+// max retries, enqueued at, last error}, or an empty reply if no job is ready.
+const dequeueScript = nowFunction + `
+local time = now()
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', time, 'LIMIT', 0, 1)
+if #ids == 0 then
+	return {}
+end
+local key = ARGV[1] .. ids[1]
+if redis.call('EXISTS', key) == 0 then
+	redis.call('ZREM', KEYS[1], ids[1])
+	return {}
+end
+redis.call('ZADD', KEYS[1], time + tonumber(ARGV[2]), ids[1])
+local attempt = redis.call('HINCRBY', key, 'attempt', 1)
+redis.call('HSET', key, 'receipt', ARGV[3])
+local job = redis.call('HMGET', key, 'payload', 'max_retries', 'enqueued_at', 'last_error')
+return {ids[1], job[1], attempt, tonumber(job[2]), tonumber(job[3]), job[4] or ''}
+`
+
+// ackScript removes the job KEYS[2] from the pending set KEYS[1], if ARGV[2] is its receipt. ARGV[1] is the ID. It
+// returns 1, or 0 if the receipt expired.
+const ackScript = `
+if redis.call('HGET', KEYS[2], 'receipt') ~= ARGV[2] then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('DEL', KEYS[2])
+return 1
+`
+
+// retryScript makes the job KEYS[2] of the pending set KEYS[1] ready after a delay, if ARGV[2] is its receipt.
+// ARGV[1] is the ID, ARGV[3] the delay in milliseconds and ARGV[4] the error. It returns 1, or 0 if the receipt
+// expired.
+const retryScript = nowFunction + `
+if redis.call('HGET', KEYS[2], 'receipt') ~= ARGV[2] then
+	return 0
+end
+redis.call('HSET', KEYS[2], 'last_error', ARGV[4], 'receipt', '')
+redis.call('ZADD', KEYS[1], now() + tonumber(ARGV[3]), ARGV[1])
+return 1
+`
+
+// deadLetterScript moves the job KEYS[3] from the pending set KEYS[1] to the dead set KEYS[2], if ARGV[2] is its
+// receipt. ARGV[1] is the ID and ARGV[3] the error. It returns 1, or 0 if the receipt expired.
+const deadLetterScript = nowFunction + `
+if redis.call('HGET', KEYS[3], 'receipt') ~= ARGV[2] then
+	return 0
+end
+local time = now()
+redis.call('HSET', KEYS[3], 'last_error', ARGV[3], 'failed_at', time, 'receipt', '')
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('ZADD', KEYS[2], time, ARGV[1])
+return 1
+`
+
+// Client is the subset of Redis operations used by the backend. The goredisclient module implements it with
+// github.com/redis/go-redis.
+type Client interface {
+	// Eval runs the Lua script with keys and args, and returns its reply.
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// config holds configuration options for the backend.
+type config struct {
+	keyPrefix string
+}
+
+// Option specifies backend configuration options.
+type Option func(*config)
+
+// WithKeyPrefix sets the prefix of the keys of the queues in Redis, e.g., to share a Redis with other services.
+func WithKeyPrefix(prefix string) Option {
+	return func(c *config) {
+		c.keyPrefix = prefix
+	}
+}
+
+/*
+Backend is a jobqueue.Backend backed by Redis, using atomic Lua scripts, so that the jobs are shared by the workers
+of all replicas and survive their restarts.
+
+The jobs of a queue are stored in the hashes <prefix>{<queue>}:job:<ID>, and their IDs in the sorted set
+<prefix>{<queue>}:pending, scored by the time they are ready to run in milliseconds of the Redis server clock, so
+that all replicas share the same clock. Dequeuing a job pushes its score by the visibility timeout, so that it is
+delivered again if it is not settled in time. The jobs dead-lettered are kept for inspection, with their IDs in the
+sorted set <prefix>{<queue>}:dead, scored by the time they failed. The keys of a queue share the {<queue>} hash tag,
+so that the scripts run on a single node of a Redis Cluster.
+
+Example usage:
+
+	backend := redisqueue.New(goredisclient.New(rdb), redisqueue.WithKeyPrefix("billing:jobs:"))
+	queue := jobqueue.New(backend, "invoices")
+*/
+type Backend struct {
+	client Client
+	cfg    config
+}
+
+var _ jobqueue.Backend = (*Backend)(nil)
+
+// New creates a Backend.
+func New(client Client, opts ...Option) *Backend {
+	cfg := config{keyPrefix: DefaultKeyPrefix}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Backend{client: client, cfg: cfg}
+}
+
+// pendingKey returns the key of the pending set of queue.
+func (b *Backend) pendingKey(queue string) string {
+	return b.cfg.keyPrefix + "{" + queue + "}:pending"
+}
+
+// deadKey returns the key of the dead set of queue.
+func (b *Backend) deadKey(queue string) string {
+	return b.cfg.keyPrefix + "{" + queue + "}:dead"
+}
+
+// jobKeyPrefix returns the prefix of the keys of the jobs of queue.
+func (b *Backend) jobKeyPrefix(queue string) string {
+	return b.cfg.keyPrefix + "{" + queue + "}:job:"
+}
+
+// Enqueue adds job to its queue, ready after delay.
+func (b *Backend) Enqueue(ctx context.Context, job *jobqueue.Job, delay time.Duration) error {
+	reply, err := b.client.Eval(ctx, enqueueScript,
+		[]string{b.pendingKey(job.Queue), b.jobKeyPrefix(job.Queue) + job.ID},
+		job.ID, job.Payload, job.MaxRetries, delay.Milliseconds(),
+	)
+	if err != nil {
+		return err
+	}
+	enqueuedAt, ok := reply.(int64)
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrUnexpectedReply, reply)
+	}
+	job.EnqueuedAt = time.UnixMilli(enqueuedAt)
+	return nil
+}
+
+// Dequeue returns the first job of queue ready to run, invisible for visibility, or nil if there is none.
+func (b *Backend) Dequeue(ctx context.Context, queue string, visibility time.Duration) (*jobqueue.Job, error) {
+	receipt := xid.New().String()
+	reply, err := b.client.Eval(ctx, dequeueScript, []string{b.pendingKey(queue)},
+		b.jobKeyPrefix(queue), visibility.Milliseconds(), receipt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok || (len(values) != 0 && len(values) != 6) {
+		return nil, fmt.Errorf("%w: %v", ErrUnexpectedReply, reply)
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	id, okID := values[0].(string)
+	payload, okPayload := values[1].(string)
+	attempt, okAttempt := values[2].(int64)
+	maxRetries, okMaxRetries := values[3].(int64)
+	enqueuedAt, okEnqueuedAt := values[4].(int64)
+	lastError, okLastError := values[5].(string)
+	if !okID || !okPayload || !okAttempt || !okMaxRetries || !okEnqueuedAt || !okLastError {
+		return nil, fmt.Errorf("%w: %v", ErrUnexpectedReply, reply)
+	}
+	return &jobqueue.Job{
+		ID:         id,
+		Queue:      queue,
+		Payload:    []byte(payload),
+		Attempt:    int(attempt),
+		MaxRetries: int(maxRetries),
+		EnqueuedAt: time.UnixMilli(enqueuedAt),
+		LastError:  lastError,
+		Receipt:    receipt,
+	}, nil
+}
+
+// Ack removes job, which succeeded.
+func (b *Backend) Ack(ctx context.Context, job *jobqueue.Job) error {
+	return b.settle(ctx, ackScript,
+		[]string{b.pendingKey(job.Queue), b.jobKeyPrefix(job.Queue) + job.ID},
+		job.ID, job.Receipt,
+	)
+}
+
+// Retry makes job ready again after delay, recording cause.
+func (b *Backend) Retry(ctx context.Context, job *jobqueue.Job, delay time.Duration, cause error) error {
+	return b.settle(ctx, retryScript,
+		[]string{b.pendingKey(job.Queue), b.jobKeyPrefix(job.Queue) + job.ID},
+		job.ID, job.Receipt, delay.Milliseconds(), cause.Error(),
+	)
+}
+
+// DeadLetter moves job to the dead set of its queue, recording cause.
+func (b *Backend) DeadLetter(ctx context.Context, job *jobqueue.Job, cause error) error {
+	return b.settle(ctx, deadLetterScript,
+		[]string{b.pendingKey(job.Queue), b.deadKey(job.Queue), b.jobKeyPrefix(job.Queue) + job.ID},
+		job.ID, job.Receipt, cause.Error(),
+	)
+}
+
+// settle runs a script settling a job, which returns 0 if the receipt of the job expired.
+func (b *Backend) settle(ctx context.Context, script string, keys []string, args ...interface{}) error {
+	reply, err := b.client.Eval(ctx, script, keys, args...)
+	if err != nil {
+		return err
+	}
+	settled, ok := reply.(int64)
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrUnexpectedReply, reply)
+	}
+	if settled == 0 {
+		return jobqueue.ErrReceiptExpired
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package redisqueue_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/jobqueue"
+	"github.com/kittipat1413/go-common/framework/jobqueue/redisqueue"
+)
+
+// fakeClient emulates the scripts of the backend, with a clock moved by the tests.
+type fakeClient struct {
+	mutex  sync.Mutex
+	now    int64
+	zsets  map[string]map[string]int64
+	hashes map[string]map[string]string
+	reply  interface{}
+	err    error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		now:    time.Now().UnixMilli(),
+		zsets:  make(map[string]map[string]int64),
+		hashes: make(map[string]map[string]string),
+	}
+}
+
+// advance moves the clock of the client by d.
+func (c *fakeClient) advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now += d.Milliseconds()
+}
+
+func (c *fakeClient) zadd(key, member string, score int64) {
+	if c.zsets[key] == nil {
+		c.zsets[key] = make(map[string]int64)
+	}
+	c.zsets[key][member] = score
+}
+
+// receiptMatches reports whether the receipt of the hash key is receipt.
+func (c *fakeClient) receiptMatches(key string, receipt interface{}) bool {
+	hash, ok := c.hashes[key]
+	return ok && hash["receipt"] == str(receipt)
+}
+
+func (c *fakeClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err != nil || c.reply != nil {
+		return c.reply, c.err
+	}
+
+	switch {
+	case strings.Contains(script, "ZRANGEBYSCORE"):
+		var id string
+		for member, score := range c.zsets[keys[0]] {
+			if score <= c.now && (id == "" || score < c.zsets[keys[0]][id]) {
+				id = member
+			}
+		}
+		if id == "" {
+			return []interface{}{}, nil
+		}
+		hash := c.hashes[str(args[0])+id]
+		c.zadd(keys[0], id, c.now+integer(args[1]))
+		attempt, _ := strconv.ParseInt(hash["attempt"], 10, 64)
+		hash["attempt"] = strconv.FormatInt(attempt+1, 10)
+		hash["receipt"] = str(args[2])
+		maxRetries, _ := strconv.ParseInt(hash["max_retries"], 10, 64)
+		enqueuedAt, _ := strconv.ParseInt(hash["enqueued_at"], 10, 64)
+		return []interface{}{id, hash["payload"], attempt + 1, maxRetries, enqueuedAt, hash["last_error"]}, nil
+
+	case strings.Contains(script, "'DEL'"):
+		if !c.receiptMatches(keys[1], args[1]) {
+			return int64(0), nil
+		}
+		delete(c.zsets[keys[0]], str(args[0]))
+		delete(c.hashes, keys[1])
+		return int64(1), nil
+
+	case strings.Contains(script, "failed_at"):
+		if !c.receiptMatches(keys[2], args[1]) {
+			return int64(0), nil
+		}
+		hash := c.hashes[keys[2]]
+		hash["last_error"], hash["failed_at"], hash["receipt"] = str(args[2]), strconv.FormatInt(c.now, 10), ""
+		delete(c.zsets[keys[0]], str(args[0]))
+		c.zadd(keys[1], str(args[0]), c.now)
+		return int64(1), nil
+
+	case strings.Contains(script, "'last_error', ARGV[4]"):
+		if !c.receiptMatches(keys[1], args[1]) {
+			return int64(0), nil
+		}
+		hash := c.hashes[keys[1]]
+		hash["last_error"], hash["receipt"] = str(args[3]), ""
+		c.zadd(keys[0], str(args[0]), c.now+integer(args[2]))
+		return int64(1), nil
+
+	default:
+		c.hashes[keys[1]] = map[string]string{
+			"payload":     str(args[1]),
+			"attempt":     "0",
+			"max_retries": str(args[2]),
+			"enqueued_at": strconv.FormatInt(c.now, 10),
+		}
+		c.zadd(keys[0], str(args[0]), c.now+integer(args[3]))
+		return c.now, nil
+	}
+}
+
+// str converts an argument of a script to a string, like Redis.
+func str(arg interface{}) string {
+	if b, ok := arg.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(arg)
+}
+
+// integer converts an argument of a script to an integer, like tonumber.
+func integer(arg interface{}) int64 {
+	n, _ := strconv.ParseInt(str(arg), 10, 64)
+	return n
+}
+
+func TestBackend(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	backend := redisqueue.New(client)
+	queue := jobqueue.New(backend, "emails")
+
+	id, err := queue.Enqueue(ctx, []byte("welcome"), jobqueue.WithMaxRetries(5))
+	require.NoError(t, err)
+	_, err = queue.Enqueue(ctx, []byte("reminder"), jobqueue.WithDelay(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, client.zsets["jobqueue:{emails}:pending"], 2)
+	assert.Contains(t, client.hashes, "jobqueue:{emails}:job:"+id)
+
+	job, err := backend.Dequeue(ctx, "emails", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, id, job.ID)
+	assert.Equal(t, "emails", job.Queue)
+	assert.Equal(t, []byte("welcome"), job.Payload)
+	assert.Equal(t, 1, job.Attempt)
+	assert.Equal(t, 5, job.MaxRetries)
+	assert.Equal(t, client.now, job.EnqueuedAt.UnixMilli())
+	assert.NotEmpty(t, job.Receipt)
+
+	next, err := backend.Dequeue(ctx, "emails", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, next, "the job dequeued is invisible, and the other one delayed")
+
+	client.advance(time.Minute)
+	redelivered, err := backend.Dequeue(ctx, "emails", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, redelivered, "the job is delivered again after its visibility timeout")
+	assert.Equal(t, id, redelivered.ID)
+	assert.Equal(t, 2, redelivered.Attempt)
+	assert.NotEqual(t, job.Receipt, redelivered.Receipt)
+	assert.ErrorIs(t, backend.Ack(ctx, job), jobqueue.ErrReceiptExpired)
+
+	require.NoError(t, backend.Retry(ctx, redelivered, time.Second, errors.New("smtp unavailable")))
+	client.advance(time.Second)
+	retried, err := backend.Dequeue(ctx, "emails", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, retried)
+	assert.Equal(t, 3, retried.Attempt)
+	assert.Equal(t, "smtp unavailable", retried.LastError)
+
+	require.NoError(t, backend.DeadLetter(ctx, retried, errors.New("invalid address")))
+	assert.Contains(t, client.zsets["jobqueue:{emails}:dead"], id)
+	assert.NotContains(t, client.zsets["jobqueue:{emails}:pending"], id)
+	assert.Equal(t, "invalid address", client.hashes["jobqueue:{emails}:job:"+id]["last_error"])
+
+	client.advance(time.Hour)
+	delayed, err := backend.Dequeue(ctx, "emails", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, delayed)
+	assert.Equal(t, []byte("reminder"), delayed.Payload)
+	require.NoError(t, backend.Ack(ctx, delayed))
+	assert.NotContains(t, client.hashes, "jobqueue:{emails}:job:"+delayed.ID)
+	assert.Empty(t, client.zsets["jobqueue:{emails}:pending"])
+}
+
+func TestBackend_KeyPrefix(t *testing.T) {
+	client := newFakeClient()
+	queue := jobqueue.New(redisqueue.New(client, redisqueue.WithKeyPrefix("billing:")), "invoices")
+	_, err := queue.Enqueue(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Contains(t, client.zsets, "billing:{invoices}:pending")
+}
+
+func TestBackend_Errors(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	backend := redisqueue.New(client)
+	job := &jobqueue.Job{ID: "1", Queue: "emails"}
+
+	client.err = errors.New("connection refused")
+	assert.ErrorIs(t, backend.Enqueue(ctx, job, 0), client.err)
+	_, err := backend.Dequeue(ctx, "emails", time.Minute)
+	assert.ErrorIs(t, err, client.err)
+	assert.ErrorIs(t, backend.Ack(ctx, job), client.err)
+
+	client.err, client.reply = nil, "OK"
+	assert.ErrorIs(t, backend.Enqueue(ctx, job, 0), redisqueue.ErrUnexpectedReply)
+	_, err = backend.Dequeue(ctx, "emails", time.Minute)
+	assert.ErrorIs(t, err, redisqueue.ErrUnexpectedReply)
+	assert.ErrorIs(t, backend.Retry(ctx, job, 0, errors.New("failed")), redisqueue.ErrUnexpectedReply)
+
+	client.reply = []interface{}{"1", "payload", "1", int64(3), int64(0), ""}
+	_, err = backend.Dequeue(ctx, "emails", time.Minute)
+	assert.ErrorIs(t, err, redisqueue.ErrUnexpectedReply)
+}
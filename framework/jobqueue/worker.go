@@ -0,0 +1,331 @@
+package jobqueue
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+const (
+	// DefaultConcurrency is the number of jobs run at once by a worker, unless set with WithConcurrency.
+	DefaultConcurrency = 10
+	// DefaultVisibilityTimeout is the time a job is invisible once dequeued, unless set with WithVisibilityTimeout.
+	DefaultVisibilityTimeout = 30 * time.Second
+	// DefaultPollInterval is the time between the polls of an empty queue, unless set with WithPollInterval.
+	DefaultPollInterval = time.Second
+)
+
+// DefaultBackoff is the delay before the retries of the jobs, unless set with WithBackoff: about 1s, 2s, 4s, etc.
+var DefaultBackoff = retry.Exponential(time.Second, 2, 0.2)
+
+// ErrPanic is wrapped by the errors the panics of the handlers are converted to.
+var ErrPanic = stderrors.New("jobqueue: handler panicked")
+
+// Handler runs a job. A job whose handler returns an error is retried, unless the error is permanent, see
+// errors.IsPermanent, or the retries of the job are exhausted, in which case it is dead-lettered. A job timing out is
+// retried.
+type Handler func(ctx context.Context, job *Job) error
+
+// options holds configuration options for the worker.
+type options struct {
+	concurrency  int           // concurrency is the number of jobs run at once.
+	visibility   time.Duration // visibility is the time a job is invisible once dequeued, and the timeout of its run.
+	pollInterval time.Duration // pollInterval is the time between the polls of an empty queue.
+	backoff      retry.Backoff // backoff returns the delay before a retry.
+	logger       logger.Logger // logger logs the jobs, or the logger of the context of Run if nil.
+	metrics      *Metrics      // metrics records the jobs if set.
+}
+
+// Option specifies worker configuration options.
+type Option func(*options)
+
+// WithConcurrency sets the number of jobs run at once by the worker. It defaults to DefaultConcurrency.
+func WithConcurrency(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.concurrency = n
+		}
+	}
+}
+
+// WithVisibilityTimeout sets the time a job is invisible to the other workers once dequeued, after which it is
+// delivered again. It is also the timeout of the runs, and must exceed their duration. It defaults to
+// DefaultVisibilityTimeout.
+func WithVisibilityTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.visibility = d
+		}
+	}
+}
+
+// WithPollInterval sets the time between the polls of the queue when it is empty. It defaults to
+// DefaultPollInterval.
+func WithPollInterval(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.pollInterval = d
+		}
+	}
+}
+
+// WithBackoff sets the delay before the retries of the jobs, given the attempt failed. It defaults to
+// DefaultBackoff.
+func WithBackoff(backoff retry.Backoff) Option {
+	return func(opts *options) {
+		if backoff != nil {
+			opts.backoff = backoff
+		}
+	}
+}
+
+// WithLogger sets the logger of the worker. It defaults to the logger of the context of Run, see
+// logger.FromContext.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		if l != nil {
+			opts.logger = l
+		}
+	}
+}
+
+// WithMetrics records the jobs of the worker in m. They are not recorded by default.
+func WithMetrics(m *Metrics) Option {
+	return func(opts *options) {
+		if m != nil {
+			opts.metrics = m
+		}
+	}
+}
+
+/*
+Worker runs the jobs of a Queue with a Handler. It is a lifecycle.Runnable and lifecycle.Shutdowner: Shutdown stops
+dequeuing the jobs, and waits for the jobs running to finish. If they do not finish in time, they are canceled, and
+delivered again once their visibility timeout expires.
+
+A job succeeding is removed from the queue. A job failing is retried with the backoff of WithBackoff, until its
+retries are exhausted or its error is permanent, see errors.IsPermanent, in which case it is moved to the
+dead-letter set of the queue. The panics of the handler are recovered and logged with their stack, and the job is
+retried like on an error.
+
+Example usage:
+
+	worker := jobqueue.NewWorker(queue, func(ctx context.Context, job *jobqueue.Job) error {
+		var email WelcomeEmail
+		if err := json.Unmarshal(job.Payload, &email); err != nil {
+			return errors.MarkPermanent(err)
+		}
+		return mailer.SendWelcome(ctx, email.UserID)
+	},
+		jobqueue.WithConcurrency(5),
+		jobqueue.WithVisibilityTimeout(time.Minute),
+	)
+	manager.Add("emails", worker, lifecycle.WithTimeout(time.Minute))
+*/
+type Worker struct {
+	queue   *Queue
+	handler Handler
+	opts    options
+
+	mu      sync.Mutex
+	started bool
+	closed  bool
+	ctx     context.Context // ctx is the parent of the contexts of the jobs, canceled when the drain times out.
+	cancel  context.CancelFunc
+
+	stop     chan struct{} // stop is closed by Shutdown.
+	stopOnce sync.Once
+	loops    sync.WaitGroup // loops are the goroutines dequeuing and running the jobs.
+}
+
+// NewWorker creates a worker running the jobs of queue with handler. The jobs are run once Run is called.
+func NewWorker(queue *Queue, handler Handler, opts ...Option) *Worker {
+	o := options{
+		concurrency:  DefaultConcurrency,
+		visibility:   DefaultVisibilityTimeout,
+		pollInterval: DefaultPollInterval,
+		backoff:      DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Worker{queue: queue, handler: handler, opts: o, stop: make(chan struct{})}
+}
+
+// Run runs the jobs until Shutdown is called, or ctx is done, in which case the jobs running are canceled. It
+// returns nil. The jobs run with the values of ctx, e.g., its logger.
+func (w *Worker) Run(ctx context.Context) error {
+	w.mu.Lock()
+	if w.started || w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.started = true
+	w.ctx, w.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	w.loops.Add(w.opts.concurrency)
+	for i := 0; i < w.opts.concurrency; i++ {
+		go w.work()
+	}
+	w.mu.Unlock()
+	defer w.cancel()
+
+	select {
+	case <-w.stop:
+	case <-ctx.Done():
+		w.cancel()
+		w.close()
+	}
+	w.loops.Wait()
+	return nil
+}
+
+// Shutdown stops dequeuing the jobs, and returns once the jobs running are finished. If ctx is done first, the jobs
+// are canceled, and the error of ctx is returned.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	w.close()
+	done := make(chan struct{})
+	go func() {
+		w.loops.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		w.mu.Lock()
+		if w.cancel != nil {
+			w.cancel()
+		}
+		w.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// close stops dequeuing the jobs.
+func (w *Worker) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// work dequeues and runs the jobs until the worker stops, waiting for the poll interval while the queue is empty
+// or the backend fails.
+func (w *Worker) work() {
+	defer w.loops.Done()
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+		job, err := w.queue.backend.Dequeue(w.ctx, w.queue.name, w.opts.visibility)
+		if err != nil && w.ctx.Err() == nil {
+			w.log().Error(w.ctx, "Failed to dequeue job", err, logger.Fields{"queue": w.queue.name})
+		}
+		if job != nil {
+			w.run(job)
+			continue
+		}
+		timer := time.NewTimer(w.opts.pollInterval)
+		select {
+		case <-w.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// run runs job, and settles it according to its outcome.
+func (w *Worker) run(job *Job) {
+	fields := logger.Fields{"queue": job.Queue, "job_id": job.ID, "attempt": job.Attempt}
+	ctx, cancel := context.WithTimeout(w.ctx, w.opts.visibility)
+	defer cancel()
+
+	start := time.Now()
+	err := w.call(ctx, job)
+	duration := time.Since(start)
+	fields["duration_ms"] = duration.Milliseconds()
+	if w.ctx.Err() != nil {
+		// The worker is shut down: the job is delivered again once its visibility timeout expires.
+		w.log().Warn(w.ctx, "Job canceled by shutdown", fields)
+		return
+	}
+
+	var status string
+	switch {
+	case err == nil:
+		status = StatusSuccess
+		err = w.queue.backend.Ack(w.ctx, job)
+		if err == nil {
+			w.log().Debug(ctx, "Job finished", fields)
+		}
+	case ctx.Err() == nil && errors.IsPermanent(err), job.Attempt > job.MaxRetries:
+		status = StatusDeadLettered
+		w.log().Error(ctx, "Job dead-lettered", err, fields)
+		err = w.queue.backend.DeadLetter(w.ctx, job, err)
+	default:
+		status = StatusRetried
+		delay := w.opts.backoff(job.Attempt)
+		fields["delay_ms"] = delay.Milliseconds()
+		fields["error"] = err.Error()
+		w.log().Warn(ctx, "Retrying job", fields)
+		delete(fields, "error")
+		err = w.queue.backend.Retry(w.ctx, job, delay, err)
+	}
+	switch {
+	case stderrors.Is(err, ErrReceiptExpired):
+		w.log().Warn(w.ctx, "Job visibility timeout expired", fields)
+	case err != nil:
+		w.log().Error(w.ctx, "Failed to settle job", err, fields)
+	}
+	w.observe(job.Queue, status, duration)
+}
+
+// call calls the handler with job, converting its panic to an error.
+func (w *Worker) call(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		if recoveredErr, ok := recovered.(error); ok {
+			err = fmt.Errorf("%w: %w", ErrPanic, recoveredErr)
+		} else {
+			err = fmt.Errorf("%w: %v", ErrPanic, recovered)
+		}
+		w.log().Error(ctx, "Job panicked", err, logger.Fields{
+			"queue":                             job.Queue,
+			"job_id":                            job.ID,
+			logger.DefaultPanicKey:              fmt.Sprintf("%v", recovered),
+			logger.DefaultSJsonFmtStackTraceKey: string(debug.Stack()),
+		})
+	}()
+	return w.handler(ctx, job)
+}
+
+// observe records a job of queue finished with status after d.
+func (w *Worker) observe(queue, status string, d time.Duration) {
+	m := w.opts.metrics
+	if m == nil {
+		return
+	}
+	m.jobs.Inc(queue, status)
+	m.duration.Observe(d.Seconds(), queue)
+}
+
+// log returns the logger of the worker, or the logger of the context of Run.
+func (w *Worker) log() logger.Logger {
+	if w.opts.logger != nil {
+		return w.opts.logger
+	}
+	return logger.FromContext(w.ctx)
+}
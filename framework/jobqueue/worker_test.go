@@ -0,0 +1,260 @@
+package jobqueue_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/jobqueue"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/metrics"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+// options are the options of the workers of the tests, polling and retrying fast.
+var options = []jobqueue.Option{
+	jobqueue.WithPollInterval(time.Millisecond),
+	jobqueue.WithBackoff(retry.Constant(time.Millisecond)),
+}
+
+// start runs worker until the end of the test.
+func start(t *testing.T, worker *jobqueue.Worker) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- worker.Run(context.Background()) }()
+	t.Cleanup(func() {
+		require.NoError(t, worker.Shutdown(context.Background()))
+		require.NoError(t, <-done)
+	})
+}
+
+func TestWorker(t *testing.T) {
+	t.Run("runs and acknowledges the jobs", func(t *testing.T) {
+		backend := newMemoryBackend()
+		queue := jobqueue.New(backend, "emails")
+		payloads := make(chan string, 10)
+		worker := jobqueue.NewWorker(queue, func(ctx context.Context, job *jobqueue.Job) error {
+			payloads <- string(job.Payload)
+			return nil
+		}, options...)
+		start(t, worker)
+
+		for _, payload := range []string{"a", "b", "c"} {
+			_, err := queue.Enqueue(context.Background(), []byte(payload))
+			require.NoError(t, err)
+		}
+		assert.ElementsMatch(t, []string{"a", "b", "c"}, []string{<-payloads, <-payloads, <-payloads})
+		require.Eventually(t, func() bool { return backend.pending() == 0 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("retries the jobs failing", func(t *testing.T) {
+		backend := newMemoryBackend()
+		queue := jobqueue.New(backend, "emails")
+		log, logs := logger.NewTestLogger()
+		var mu sync.Mutex
+		var lastErrors []string
+		worker := jobqueue.NewWorker(queue, func(ctx context.Context, job *jobqueue.Job) error {
+			mu.Lock()
+			defer mu.Unlock()
+			lastErrors = append(lastErrors, job.LastError)
+			if job.Attempt < 3 {
+				return errors.New("smtp unavailable")
+			}
+			return nil
+		}, append(options, jobqueue.WithLogger(log))...)
+		start(t, worker)
+
+		_, err := queue.Enqueue(context.Background(), nil)
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return backend.pending() == 0 }, time.Second, time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, []string{"", "smtp unavailable", "smtp unavailable"}, lastErrors)
+		logs.AssertLogged(t, logger.WARN, "Retrying job", logger.HasField("queue", "emails"), logger.HasField("attempt", 2))
+	})
+
+	t.Run("dead-letters the jobs once their retries are exhausted", func(t *testing.T) {
+		backend := newMemoryBackend()
+		queue := jobqueue.New(backend, "emails")
+		log, logs := logger.NewTestLogger()
+		var attempts atomic.Int32
+		worker := jobqueue.NewWorker(queue, func(ctx context.Context, job *jobqueue.Job) error {
+			attempts.Add(1)
+			return errors.New("smtp unavailable")
+		}, append(options, jobqueue.WithLogger(log))...)
+		start(t, worker)
+
+		id, err := queue.Enqueue(context.Background(), nil, jobqueue.WithMaxRetries(2))
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return backend.deadJob(id) != nil }, time.Second, time.Millisecond)
+		assert.Equal(t, int32(3), attempts.Load())
+		assert.Equal(t, "smtp unavailable", backend.deadJob(id).LastError)
+		logs.AssertLogged(t, logger.ERROR, "Job dead-lettered", logger.HasField("job_id", id))
+	})
+
+	t.Run("dead-letters the jobs failing with a permanent error", func(t *testing.T) {
+		backend := newMemoryBackend()
+		queue := jobqueue.New(backend, "emails")
+		var attempts atomic.Int32
+		worker := jobqueue.NewWorker(queue, func(ctx context.Context, job *jobqueue.Job) error {
+			attempts.Add(1)
+			return domain_error.MarkPermanent(errors.New("invalid payload"))
+		}, options...)
+		start(t, worker)
+
+		id, err := queue.Enqueue(context.Background(), nil)
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return backend.deadJob(id) != nil }, time.Second, time.Millisecond)
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("recovers the panics", func(t *testing.T) {
+		backend := newMemoryBackend()
+		queue := jobqueue.New(backend, "emails")
+		log, logs := logger.NewTestLogger()
+		worker := jobqueue.NewWorker(queue, func(ctx context.Context, job *jobqueue.Job) error {
+			if job.Attempt == 1 {
+				panic("boom")
+			}
+			return nil
+		}, append(options, jobqueue.WithLogger(log))...)
+		start(t, worker)
+
+		_, err := queue.Enqueue(context.Background(), nil)
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return backend.pending() == 0 }, time.Second, time.Millisecond)
+		entries := logs.Find(logger.ERROR, "Job panicked")
+		require.Len(t, entries, 1)
+		assert.Contains(t, entries[0].Fields[logger.DefaultSJsonFmtStackTraceKey], "worker_test.go")
+	})
+
+	t.Run("times out the jobs after the visibility timeout", func(t *testing.T) {
+		backend := newMemoryBackend()
+		queue := jobqueue.New(backend, "emails")
+		errs := make(chan error, 1)
+		worker := jobqueue.NewWorker(queue, func(ctx context.Context, job *jobqueue.Job) error {
+			if job.Attempt == 1 {
+				<-ctx.Done()
+				errs <- ctx.Err()
+				return ctx.Err()
+			}
+			return nil
+		}, append(options, jobqueue.WithVisibilityTimeout(10*time.Millisecond))...)
+		start(t, worker)
+
+		_, err := queue.Enqueue(context.Background(), nil)
+		require.NoError(t, err)
+		assert.ErrorIs(t, <-errs, context.DeadlineExceeded)
+		require.Eventually(t, func() bool { return backend.pending() == 0 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("logs the errors of the backend", func(t *testing.T) {
+		backend := newMemoryBackend()
+		backend.err = errors.New("connection refused")
+		log, logs := logger.NewTestLogger()
+		worker := jobqueue.NewWorker(jobqueue.New(backend, "emails"), func(ctx context.Context, job *jobqueue.Job) error {
+			return nil
+		}, append(options, jobqueue.WithLogger(log), jobqueue.WithConcurrency(1))...)
+		start(t, worker)
+
+		require.Eventually(t, func() bool { return len(logs.Find(logger.ERROR, "Failed to dequeue job")) > 0 }, time.Second, time.Millisecond)
+	})
+}
+
+func TestWorker_Shutdown(t *testing.T) {
+	t.Run("waits for the jobs running", func(t *testing.T) {
+		backend := newMemoryBackend()
+		queue := jobqueue.New(backend, "emails")
+		started := make(chan struct{})
+		worker := jobqueue.NewWorker(queue, func(ctx context.Context, job *jobqueue.Job) error {
+			close(started)
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		}, options...)
+		done := make(chan error, 1)
+		go func() { done <- worker.Run(context.Background()) }()
+
+		_, err := queue.Enqueue(context.Background(), nil)
+		require.NoError(t, err)
+		<-started
+		require.NoError(t, worker.Shutdown(context.Background()))
+		assert.Zero(t, backend.pending())
+		require.NoError(t, <-done)
+	})
+
+	t.Run("cancels the jobs running when the drain times out, without settling them", func(t *testing.T) {
+		backend := newMemoryBackend()
+		queue := jobqueue.New(backend, "emails")
+		started := make(chan struct{})
+		worker := jobqueue.NewWorker(queue, func(ctx context.Context, job *jobqueue.Job) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}, options...)
+		done := make(chan error, 1)
+		go func() { done <- worker.Run(context.Background()) }()
+
+		_, err := queue.Enqueue(context.Background(), nil)
+		require.NoError(t, err)
+		<-started
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, worker.Shutdown(ctx), context.DeadlineExceeded)
+		require.NoError(t, <-done)
+		assert.Equal(t, 1, backend.pending(), "the job is delivered again after its visibility timeout")
+		assert.Equal(t, 1, backend.deliveries)
+	})
+
+	t.Run("stops when the context of Run is done", func(t *testing.T) {
+		worker := jobqueue.NewWorker(jobqueue.New(newMemoryBackend(), "emails"), func(ctx context.Context, job *jobqueue.Job) error {
+			return nil
+		}, options...)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- worker.Run(ctx) }()
+		cancel()
+		require.NoError(t, <-done)
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m, err := jobqueue.NewMetrics(registry)
+	require.NoError(t, err)
+
+	backend := newMemoryBackend()
+	queue := jobqueue.New(backend, "emails")
+	worker := jobqueue.NewWorker(queue, func(ctx context.Context, job *jobqueue.Job) error {
+		if string(job.Payload) == "fail" {
+			return errors.New("smtp unavailable")
+		}
+		return nil
+	}, append(options, jobqueue.WithMetrics(m))...)
+	start(t, worker)
+
+	_, err = queue.Enqueue(context.Background(), []byte("ok"))
+	require.NoError(t, err)
+	_, err = queue.Enqueue(context.Background(), []byte("fail"), jobqueue.WithMaxRetries(1))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return backend.pending() == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, worker.Shutdown(context.Background()))
+
+	var output bytes.Buffer
+	_, err = registry.WriteTo(&output)
+	require.NoError(t, err)
+	assert.Contains(t, output.String(), `jobqueue_jobs_total{queue="emails",status="success"} 1`)
+	assert.Contains(t, output.String(), `jobqueue_jobs_total{queue="emails",status="retried"} 1`)
+	assert.Contains(t, output.String(), `jobqueue_jobs_total{queue="emails",status="dead_lettered"} 1`)
+	assert.Contains(t, output.String(), `jobqueue_job_duration_seconds_count{queue="emails"} 3`)
+
+	_, err = jobqueue.NewMetrics(registry)
+	assert.NoError(t, err, "the metrics are shared")
+}
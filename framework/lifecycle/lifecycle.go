@@ -0,0 +1,434 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// DefaultShutdownTimeout is the time the shutdown may take, unless set with WithShutdownTimeout. It matches the
+// default termination grace period of Kubernetes.
+const DefaultShutdownTimeout = 30 * time.Second
+
+var (
+	// ErrAlreadyRun is returned by Run when the Manager already ran: a Manager runs once.
+	ErrAlreadyRun = errors.New("lifecycle: the manager already ran")
+	// ErrStopTimeout is the error of the components not stopped within their timeout.
+	ErrStopTimeout = errors.New("component did not stop in time")
+)
+
+// Readiness is implemented by the readiness reports of the service, e.g., *health.Registry. The Manager makes the
+// service ready once its runnables are started, and not ready when it shuts down.
+type Readiness interface {
+	SetReady(ready bool)
+}
+
+// options holds configuration options for the Manager.
+type options struct {
+	logger          logger.Logger
+	readiness       Readiness
+	readinessDelay  time.Duration
+	shutdownTimeout time.Duration
+	signals         []os.Signal
+	caches          []interface{}
+}
+
+// Option specifies Manager configuration options.
+type Option func(*options)
+
+// WithLogger sets the logger of the lifecycle events, flushed at the end of the shutdown. It defaults to the
+// logger of the context of Run, see logger.FromContext.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		opts.logger = l
+	}
+}
+
+// WithReadiness sets the readiness flipped to false when the shutdown starts, so that the load balancers stop
+// sending traffic before the components are drained.
+func WithReadiness(readiness Readiness) Option {
+	return func(opts *options) {
+		opts.readiness = readiness
+	}
+}
+
+// WithReadinessDelay sets the time waited between flipping the readiness to false and draining the components,
+// for the load balancers to notice it, e.g., a few readiness probe periods. It is part of the shutdown timeout.
+func WithReadinessDelay(d time.Duration) Option {
+	return func(opts *options) {
+		if d >= 0 {
+			opts.readinessDelay = d
+		}
+	}
+}
+
+// WithShutdownTimeout sets the time the whole shutdown may take, including the readiness delay. It defaults to
+// DefaultShutdownTimeout.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.shutdownTimeout = d
+		}
+	}
+}
+
+// WithSignals sets the signals starting the shutdown. It defaults to SIGTERM and SIGINT. A second signal during the
+// shutdown aborts the drain.
+func WithSignals(signals ...os.Signal) Option {
+	return func(opts *options) {
+		opts.signals = signals
+	}
+}
+
+// WithCaches adds caches closed after the closers, with cache.CloseAll, e.g., to flush write-back caches and stop
+// their goroutines.
+func WithCaches(caches ...interface{}) Option {
+	return func(opts *options) {
+		opts.caches = append(opts.caches, caches...)
+	}
+}
+
+// componentOptions holds configuration options for a component.
+type componentOptions struct {
+	timeout time.Duration
+}
+
+// ComponentOption specifies component configuration options.
+type ComponentOption func(*componentOptions)
+
+// WithTimeout sets the time the component may take to stop, or to run for the startup functions. It defaults to the
+// time left of the shutdown timeout, and to no timeout for the startup functions.
+func WithTimeout(d time.Duration) ComponentOption {
+	return func(opts *componentOptions) {
+		if d > 0 {
+			opts.timeout = d
+		}
+	}
+}
+
+// component is a runnable or a closer added to the Manager.
+type component struct {
+	name     string
+	runnable Runnable
+	close    func(ctx context.Context) error
+	start    func(ctx context.Context) error
+	componentOptions
+
+	// cancel cancels the context of Run, and done is closed once Run returned err.
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+/*
+Manager runs the components of a service, and shuts them down gracefully on SIGTERM or SIGINT, when the context
+of Run is done, or when a runnable stops on its own.
+
+Before starting the runnables, the Manager calls the startup functions in the order they were added, e.g., to
+migrate the database, and shuts down if one of them fails.
+
+On shutdown, the Manager:
+ 1. Flips the readiness to false, and waits for the readiness delay.
+ 2. Stops the runnables in the reverse order they were added, each within its timeout: Shutdown is called on the
+    runnables implementing Shutdowner, then the context of their Run is canceled.
+ 3. Calls the closers in the reverse order they were added, e.g., to close database connections.
+ 4. Closes the caches of WithCaches.
+ 5. Flushes the logger.
+
+The whole shutdown is bounded by the shutdown timeout. Add the components in dependency order: a component is
+stopped before the ones added before it, e.g., the HTTP server last so that it stops accepting requests first.
+
+Example usage:
+
+	registry := health.NewRegistry()
+	manager := lifecycle.NewManager(
+		lifecycle.WithLogger(log),
+		lifecycle.WithReadiness(registry),
+		lifecycle.WithReadinessDelay(5*time.Second),
+		lifecycle.WithCaches(users, sessions),
+	)
+	manager.AddCloser("postgres", func(ctx context.Context) error { return db.Close() })
+	manager.AddStartup("migrations", migrator.Up, lifecycle.WithTimeout(time.Minute))
+	manager.Add("orders-consumer", lifecycle.RunFunc(consumer.Run), lifecycle.WithTimeout(10*time.Second))
+	manager.Add("http", lifecycle.HTTPServer(server), lifecycle.WithTimeout(15*time.Second))
+
+	if err := manager.Run(ctx); err != nil {
+		os.Exit(1)
+	}
+*/
+type Manager struct {
+	opts options
+
+	mu        sync.Mutex
+	startups  []*component
+	runnables []*component
+	closers   []*component
+	ran       bool
+}
+
+// NewManager creates a Manager without components.
+func NewManager(opts ...Option) *Manager {
+	m := &Manager{
+		opts: options{
+			shutdownTimeout: DefaultShutdownTimeout,
+			signals:         []os.Signal{syscall.SIGTERM, os.Interrupt},
+		},
+	}
+	for _, opt := range opts {
+		opt(&m.opts)
+	}
+	return m
+}
+
+// Add adds a runnable named name, started by Run. It must be called before Run.
+func (m *Manager) Add(name string, runnable Runnable, opts ...ComponentOption) {
+	m.add(&m.runnables, &component{name: name, runnable: runnable}, opts)
+}
+
+// AddCloser adds a function releasing a resource named name, e.g., a database connection pool, called on shutdown
+// once the runnables are stopped. It must be called before Run.
+func (m *Manager) AddCloser(name string, close func(ctx context.Context) error, opts ...ComponentOption) {
+	m.add(&m.closers, &component{name: name, close: close}, opts)
+}
+
+// AddStartup adds a function named name, called by Run before the runnables are started, in the order the startup
+// functions were added, e.g., to migrate the database. If it fails, the runnables are not started, and the closers
+// are called. It must return when ctx is done, e.g., on a shutdown signal. It must be called before Run.
+func (m *Manager) AddStartup(name string, start func(ctx context.Context) error, opts ...ComponentOption) {
+	m.add(&m.startups, &component{name: name, start: start}, opts)
+}
+
+func (m *Manager) add(components *[]*component, c *component, opts []ComponentOption) {
+	for _, opt := range opts {
+		opt(&c.componentOptions)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	*components = append(*components, c)
+}
+
+/*
+Run calls the startup functions, starts the runnables, and blocks until the shutdown is complete. It returns the
+error of the startup function or of the runnable that stopped the service, if any, joined with the errors of the
+shutdown, e.g., ErrStopTimeout. It returns ErrAlreadyRun
+if it is called more than once.
+*/
+func (m *Manager) Run(ctx context.Context) error {
+	m.mu.Lock()
+	if m.ran {
+		m.mu.Unlock()
+		return ErrAlreadyRun
+	}
+	m.ran = true
+	startups, runnables, closers := m.startups, m.runnables, m.closers
+	m.mu.Unlock()
+
+	log := m.opts.logger
+	if log == nil {
+		log = logger.FromContext(ctx)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, m.opts.signals...)
+	defer signal.Stop(signals)
+
+	for _, c := range startups {
+		if err := m.start(ctx, log, signals, c); err != nil {
+			errs := []error{fmt.Errorf("lifecycle: %s: %w", c.name, err)}
+			errs = append(errs, m.shutdown(ctx, log, signals, nil, closers, nil, false)...)
+			return errors.Join(errs...)
+		}
+	}
+
+	// The runnables are stopped by the shutdown, in order, rather than all at once when ctx is done.
+	stopped := make(chan *component, len(runnables))
+	for _, c := range runnables {
+		var runCtx context.Context
+		runCtx, c.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		c.done = make(chan struct{})
+		go func(c *component) {
+			defer close(c.done)
+			c.err = run(runCtx, c.runnable)
+			stopped <- c
+		}(c)
+	}
+	if m.opts.readiness != nil {
+		m.opts.readiness.SetReady(true)
+	}
+	log.Info(ctx, "Service started", logger.Fields{"components": len(runnables)})
+
+	var cause error
+	var failed *component
+	select {
+	case sig := <-signals:
+		log.Info(ctx, "Shutdown signal received", logger.Fields{"signal": sig.String()})
+	case <-ctx.Done():
+		log.Info(ctx, "Context done, shutting down", nil)
+	case failed = <-stopped:
+		if failed.err != nil {
+			cause = fmt.Errorf("lifecycle: %s: %w", failed.name, failed.err)
+			log.Error(ctx, "Component failed, shutting down", failed.err, logger.Fields{"component": failed.name})
+		} else {
+			log.Warn(ctx, "Component stopped, shutting down", logger.Fields{"component": failed.name})
+		}
+	}
+
+	errs := []error{cause}
+	errs = append(errs, m.shutdown(ctx, log, signals, runnables, closers, failed, true)...)
+	return errors.Join(errs...)
+}
+
+// start calls the startup function c, canceling its context on a signal, and logs its result.
+func (m *Manager) start(ctx context.Context, log logger.Logger, signals <-chan os.Signal, c *component) error {
+	start := time.Now()
+	startCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if c.timeout > 0 {
+		startCtx, cancel = context.WithTimeout(startCtx, c.timeout)
+		defer cancel()
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- run(startCtx, RunFunc(c.start))
+	}()
+	var err error
+	select {
+	case err = <-errs:
+	case sig := <-signals:
+		log.Info(ctx, "Shutdown signal received", logger.Fields{"signal": sig.String(), "component": c.name})
+		cancel()
+		err = <-errs
+	}
+	if err != nil {
+		log.Error(ctx, "Component failed to start", err, logger.Fields{"component": c.name})
+		return err
+	}
+	log.Info(ctx, "Component started", logger.Fields{"component": c.name, "duration": time.Since(start).String()})
+	return nil
+}
+
+// shutdown stops the runnables, calls the closers, closes the caches and flushes the logger. failed is the
+// runnable that stopped the service, if any, and ready tells whether the service was made ready.
+func (m *Manager) shutdown(ctx context.Context, log logger.Logger, signals <-chan os.Signal, runnables, closers []*component, failed *component, ready bool) []error {
+	start := time.Now()
+	ctx = context.WithoutCancel(ctx)
+	shutdownCtx, cancel := context.WithTimeout(ctx, m.opts.shutdownTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case sig := <-signals:
+			log.Warn(ctx, "Second signal received, aborting the shutdown", logger.Fields{"signal": sig.String()})
+			cancel()
+		case <-shutdownCtx.Done():
+		}
+	}()
+
+	if m.opts.readiness != nil {
+		m.opts.readiness.SetReady(false)
+	}
+	if ready && m.opts.readinessDelay > 0 {
+		timer := time.NewTimer(m.opts.readinessDelay)
+		select {
+		case <-timer.C:
+		case <-shutdownCtx.Done():
+			timer.Stop()
+		}
+	}
+
+	var errs []error
+	for i := len(runnables) - 1; i >= 0; i-- {
+		c := runnables[i]
+		err := m.stop(shutdownCtx, c)
+		select {
+		case <-c.done:
+			// The runnables returning the error of their canceled context stopped gracefully.
+			if c != failed && c.err != nil && !errors.Is(c.err, context.Canceled) {
+				err = errors.Join(err, c.err)
+			}
+		default:
+		}
+		errs = append(errs, logStopped(ctx, log, c, err))
+	}
+	for i := len(closers) - 1; i >= 0; i-- {
+		c := closers[i]
+		errs = append(errs, logStopped(ctx, log, c, m.stop(shutdownCtx, c)))
+	}
+	if len(m.opts.caches) > 0 {
+		c := &component{name: "caches", close: func(ctx context.Context) error {
+			return cache.CloseAll(ctx, m.opts.caches...)
+		}}
+		errs = append(errs, logStopped(ctx, log, c, m.stop(shutdownCtx, c)))
+	}
+	log.Info(ctx, "Shutdown complete", logger.Fields{"duration": time.Since(start).String()})
+
+	// The logger is flushed even if the shutdown timed out, so that its entries are not lost.
+	flushCtx, cancelFlush := context.WithTimeout(ctx, logger.DefaultExitFlushTimeout)
+	defer cancelFlush()
+	if err := logger.Flush(flushCtx, log); err != nil {
+		errs = append(errs, fmt.Errorf("lifecycle: %w", err))
+	}
+	return errs
+}
+
+// stop stops the runnable or calls the closer c, within its timeout and the shutdown context.
+func (m *Manager) stop(ctx context.Context, c *component) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	if c.runnable == nil {
+		errs := make(chan error, 1)
+		go func() {
+			errs <- c.close(ctx)
+		}()
+		select {
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			return ErrStopTimeout
+		}
+	}
+
+	var err error
+	if shutdowner, ok := c.runnable.(Shutdowner); ok {
+		err = shutdowner.Shutdown(ctx)
+	}
+	c.cancel()
+	select {
+	case <-c.done:
+		return err
+	case <-ctx.Done():
+		return errors.Join(err, ErrStopTimeout)
+	}
+}
+
+// logStopped logs the result of stopping c, and returns err with the name of c.
+func logStopped(ctx context.Context, log logger.Logger, c *component, err error) error {
+	if err != nil {
+		log.Error(ctx, "Failed to stop component", err, logger.Fields{"component": c.name})
+		return fmt.Errorf("lifecycle: %s: %w", c.name, err)
+	}
+	log.Info(ctx, "Component stopped", logger.Fields{"component": c.name})
+	return nil
+}
+
+// run runs r, recovering its panics so that the other components are shut down gracefully.
+func run(ctx context.Context, r Runnable) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return r.Run(ctx)
+}
@@ -0,0 +1,361 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/health"
+	"github.com/kittipat1413/go-common/framework/lifecycle"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// events records the lifecycle events of the components, in order.
+type events struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (e *events) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+}
+
+func (e *events) list() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.events...)
+}
+
+// worker runs until its context is canceled, and records its shutdown.
+type worker struct {
+	name    string
+	events  *events
+	started chan struct{}
+}
+
+func newWorker(name string, events *events) *worker {
+	return &worker{name: name, events: events, started: make(chan struct{})}
+}
+
+func (w *worker) Run(ctx context.Context) error {
+	close(w.started)
+	<-ctx.Done()
+	w.events.add(w.name + " stopped")
+	return ctx.Err()
+}
+
+func (w *worker) Shutdown(ctx context.Context) error {
+	w.events.add(w.name + " shutdown")
+	return nil
+}
+
+// readiness records the readiness set by the manager.
+type readiness struct {
+	events *events
+	ready  chan struct{}
+}
+
+func (r *readiness) SetReady(ready bool) {
+	if ready {
+		close(r.ready)
+	}
+	r.events.add("ready=" + strconv.FormatBool(ready))
+}
+
+// closingCache is a cache implementing cache.Closer.
+type closingCache struct {
+	events *events
+}
+
+func (c *closingCache) Close() error {
+	c.events.add("cache closed")
+	return nil
+}
+
+// flushingHook records when the logger is flushed.
+type flushingHook struct {
+	events *events
+}
+
+func (h *flushingHook) Levels() []logger.LogLevel                    { return nil }
+func (h *flushingHook) Fire(_ context.Context, _ logger.Entry) error { return nil }
+func (h *flushingHook) ForceFlush(context.Context) error             { h.events.add("logger flushed"); return nil }
+
+func newLogger(t *testing.T, events *events) (logger.Logger, *logger.Recorder) {
+	t.Helper()
+	recorder := &logger.Recorder{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Output: io.Discard,
+		Hooks:  []logger.Hook{recorder, &flushingHook{events: events}},
+	})
+	require.NoError(t, err)
+	return log, recorder
+}
+
+func TestManager_Shutdown(t *testing.T) {
+	events := &events{}
+	log, recorder := newLogger(t, events)
+	ready := &readiness{events: events, ready: make(chan struct{})}
+	manager := lifecycle.NewManager(
+		lifecycle.WithLogger(log),
+		lifecycle.WithReadiness(ready),
+		lifecycle.WithReadinessDelay(10*time.Millisecond),
+		lifecycle.WithCaches(&closingCache{events: events}, "not a closer"),
+	)
+	consumer, server := newWorker("consumer", events), newWorker("server", events)
+	manager.AddCloser("database", func(ctx context.Context) error {
+		events.add("database closed")
+		return nil
+	})
+	manager.AddCloser("queue", func(ctx context.Context) error {
+		events.add("queue closed")
+		return nil
+	})
+	manager.Add("consumer", consumer)
+	manager.Add("server", server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- manager.Run(ctx) }()
+	<-consumer.started
+	<-server.started
+	<-ready.ready
+	cancel()
+
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{
+		"ready=true",
+		"ready=false",
+		"server shutdown",
+		"server stopped",
+		"consumer shutdown",
+		"consumer stopped",
+		"queue closed",
+		"database closed",
+		"cache closed",
+		"logger flushed",
+	}, events.list())
+	recorder.AssertLogged(t, logger.INFO, "Service started")
+	recorder.AssertLogged(t, logger.INFO, "Shutdown complete")
+
+	assert.ErrorIs(t, manager.Run(context.Background()), lifecycle.ErrAlreadyRun)
+}
+
+func TestManager_ComponentFailure(t *testing.T) {
+	events := &events{}
+	log, recorder := newLogger(t, events)
+	manager := lifecycle.NewManager(lifecycle.WithLogger(log))
+	server := newWorker("server", events)
+	manager.Add("server", server)
+	listenErr := errors.New("address already in use")
+	manager.Add("consumer", lifecycle.RunFunc(func(ctx context.Context) error {
+		<-server.started
+		return listenErr
+	}))
+
+	err := manager.Run(context.Background())
+	assert.ErrorIs(t, err, listenErr)
+	assert.EqualError(t, err, "lifecycle: consumer: address already in use")
+	assert.Equal(t, []string{"server shutdown", "server stopped", "logger flushed"}, events.list())
+	recorder.AssertLogged(t, logger.ERROR, "Component failed, shutting down")
+}
+
+func TestManager_Startup(t *testing.T) {
+	t.Run("calls the startup functions in order before starting the runnables", func(t *testing.T) {
+		events := &events{}
+		log, recorder := newLogger(t, events)
+		manager := lifecycle.NewManager(lifecycle.WithLogger(log))
+		manager.AddStartup("migrations", func(ctx context.Context) error {
+			events.add("migrated")
+			return nil
+		})
+		manager.AddStartup("warmup", func(ctx context.Context) error {
+			events.add("warmed up")
+			return nil
+		})
+		worker := newWorker("worker", events)
+		manager.Add("worker", lifecycle.RunFunc(func(ctx context.Context) error {
+			events.add("worker started")
+			return worker.Run(ctx)
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- manager.Run(ctx) }()
+		<-worker.started
+		cancel()
+		require.NoError(t, <-done)
+		assert.Equal(t, []string{"migrated", "warmed up", "worker started", "worker stopped", "logger flushed"}, events.list())
+		recorder.AssertLogged(t, logger.INFO, "Component started", logger.HasField("component", "migrations"))
+	})
+
+	t.Run("does not start the runnables when a startup function fails", func(t *testing.T) {
+		events := &events{}
+		log, recorder := newLogger(t, events)
+		manager := lifecycle.NewManager(lifecycle.WithLogger(log), lifecycle.WithReadinessDelay(time.Minute))
+		migrationErr := errors.New("syntax error")
+		manager.AddCloser("database", func(ctx context.Context) error {
+			events.add("database closed")
+			return nil
+		})
+		manager.AddStartup("migrations", func(ctx context.Context) error { return migrationErr })
+		manager.AddStartup("warmup", func(ctx context.Context) error {
+			events.add("warmed up")
+			return nil
+		})
+		manager.Add("worker", newWorker("worker", events))
+
+		err := manager.Run(context.Background())
+		assert.ErrorIs(t, err, migrationErr)
+		assert.EqualError(t, err, "lifecycle: migrations: syntax error")
+		assert.Equal(t, []string{"database closed", "logger flushed"}, events.list(), "the readiness delay is skipped")
+		recorder.AssertLogged(t, logger.ERROR, "Component failed to start", logger.HasField("component", "migrations"))
+	})
+
+	t.Run("cancels the startup function after its timeout", func(t *testing.T) {
+		manager := lifecycle.NewManager(lifecycle.WithLogger(logger.NewNoopLogger()))
+		manager.AddStartup("migrations", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, lifecycle.WithTimeout(10*time.Millisecond))
+
+		assert.ErrorIs(t, manager.Run(context.Background()), context.DeadlineExceeded)
+	})
+}
+
+func TestManager_Panic(t *testing.T) {
+	manager := lifecycle.NewManager(lifecycle.WithLogger(logger.NewNoopLogger()))
+	manager.Add("cron", lifecycle.RunFunc(func(ctx context.Context) error {
+		panic("boom")
+	}))
+
+	assert.EqualError(t, manager.Run(context.Background()), "lifecycle: cron: panic: boom")
+}
+
+func TestManager_Timeouts(t *testing.T) {
+	events := &events{}
+	manager := lifecycle.NewManager(
+		lifecycle.WithLogger(logger.NewNoopLogger()),
+		lifecycle.WithShutdownTimeout(time.Second),
+	)
+	manager.AddCloser("database", func(ctx context.Context) error {
+		events.add("database closed")
+		return nil
+	})
+	manager.AddCloser("stuck closer", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, lifecycle.WithTimeout(20*time.Millisecond))
+	stuck := make(chan struct{})
+	defer close(stuck)
+	manager.Add("stuck", lifecycle.RunFunc(func(ctx context.Context) error {
+		// The runnable ignores its context.
+		<-stuck
+		return nil
+	}), lifecycle.WithTimeout(20*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	err := manager.Run(ctx)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	assert.ErrorIs(t, err, lifecycle.ErrStopTimeout)
+	assert.EqualError(t, err, "lifecycle: stuck: component did not stop in time\nlifecycle: stuck closer: component did not stop in time")
+	assert.Equal(t, []string{"database closed"}, events.list(), "the shutdown goes on after a timeout")
+}
+
+func TestManager_Signal(t *testing.T) {
+	events := &events{}
+	registry := health.NewRegistry()
+	registry.SetReady(false)
+	manager := lifecycle.NewManager(
+		lifecycle.WithLogger(logger.NewNoopLogger()),
+		lifecycle.WithReadiness(registry),
+		lifecycle.WithSignals(os.Interrupt),
+	)
+	worker := newWorker("worker", events)
+	manager.Add("worker", worker)
+
+	done := make(chan error)
+	go func() { done <- manager.Run(context.Background()) }()
+	<-worker.started
+	require.Eventually(t, registry.Ready, time.Second, time.Millisecond)
+
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	require.NoError(t, process.Signal(os.Interrupt))
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the manager did not shut down on the signal")
+	}
+	assert.False(t, registry.Ready())
+	assert.Equal(t, []string{"worker shutdown", "worker stopped"}, events.list())
+}
+
+func TestHTTPServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	received := make(chan struct{})
+	release := make(chan struct{})
+	server := &http.Server{Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-release
+		_, _ = w.Write([]byte("done"))
+	})}
+	manager := lifecycle.NewManager(lifecycle.WithLogger(logger.NewNoopLogger()))
+	manager.Add("http", lifecycle.HTTPServer(server))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- manager.Run(ctx) }()
+
+	responses := make(chan string)
+	go func() {
+		var resp *http.Response
+		var err error
+		for i := 0; i < 100; i++ {
+			// Wait for the server to listen.
+			if resp, err = http.Get("http://" + addr); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if !assert.NoError(t, err) {
+			close(responses)
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		responses <- string(body)
+	}()
+	<-received
+	cancel()
+
+	// The request in flight is finished before the server stops.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	assert.Equal(t, "done", <-responses)
+	assert.NoError(t, <-done)
+	_, err = http.Get("http://" + addr)
+	assert.Error(t, err, "the server is stopped")
+}
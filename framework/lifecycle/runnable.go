@@ -0,0 +1,75 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Runnable is a long-running component of a service, e.g., an HTTP server, a message consumer or a scheduler. Run
+// blocks until the component stops, and returns once ctx is done. It returns nil if the component stopped
+// gracefully.
+type Runnable interface {
+	Run(ctx context.Context) error
+}
+
+// Shutdowner is implemented by the runnables draining their work gracefully, e.g., an HTTP server finishing its
+// requests. Shutdown is called on shutdown, before the context of Run is canceled, and must return when ctx is done.
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+/*
+RunFunc is a Runnable running the function, e.g., a consumer loop returning when ctx is done.
+
+Example usage:
+
+	manager.Add("orders-consumer", lifecycle.RunFunc(func(ctx context.Context) error {
+		return consumer.Consume(ctx, handleOrder)
+	}))
+*/
+type RunFunc func(ctx context.Context) error
+
+// Run calls f(ctx).
+func (f RunFunc) Run(ctx context.Context) error {
+	return f(ctx)
+}
+
+// httpServer runs an *http.Server.
+type httpServer struct {
+	server *http.Server
+}
+
+/*
+HTTPServer returns a Runnable listening and serving with server, over TLS if its TLSConfig has certificates. On
+shutdown, the server stops accepting connections and waits for the requests in flight, see http.Server.Shutdown,
+and closes the remaining connections if they take longer than the timeout of the component.
+
+Example usage:
+
+	manager.Add("http", lifecycle.HTTPServer(&http.Server{Addr: ":8080", Handler: mux}))
+*/
+func HTTPServer(server *http.Server) Runnable {
+	return &httpServer{server: server}
+}
+
+func (s *httpServer) Run(ctx context.Context) error {
+	// The connections still open when the drain times out are closed.
+	stop := context.AfterFunc(ctx, func() { _ = s.server.Close() })
+	defer stop()
+
+	var err error
+	if s.server.TLSConfig != nil && (len(s.server.TLSConfig.Certificates) > 0 || s.server.TLSConfig.GetCertificate != nil) {
+		err = s.server.ListenAndServeTLS("", "")
+	} else {
+		err = s.server.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *httpServer) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
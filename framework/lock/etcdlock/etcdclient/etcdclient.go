@@ -0,0 +1,84 @@
+package etcdclient
+
+import (
+	"context"
+	"errors"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/kittipat1413/go-common/framework/lock/etcdlock"
+)
+
+var _ etcdlock.Client = (*Client)(nil)
+
+/*
+Client is the etcdlock.Client of an etcd client of go.etcd.io/etcd/client/v3. It is a module of its own, so that the
+services using etcdlock with another client do not depend on the etcd client.
+
+Example usage:
+
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{"etcd:2379"}, DialTimeout: 5 * time.Second})
+	if err != nil {
+		// Handle error
+	}
+	manager.AddCloser("etcd", func(ctx context.Context) error { return client.Close() })
+
+	locker := lock.New(etcdlock.New(etcdclient.New(client)), lock.WithTTL(15*time.Second))
+*/
+type Client struct {
+	client *clientv3.Client
+}
+
+// New creates the etcdlock.Client of client. The client is not owned by it.
+func New(client *clientv3.Client) *Client {
+	return &Client{client: client}
+}
+
+// Grant creates a lease expiring after ttl seconds, and returns its ID.
+func (c *Client) Grant(ctx context.Context, ttl int64) (int64, error) {
+	resp, err := c.client.Grant(ctx, ttl)
+	if err != nil {
+		return 0, err
+	}
+	return int64(resp.ID), nil
+}
+
+// KeepAliveOnce renews the lease, and reports whether it was still alive.
+func (c *Client) KeepAliveOnce(ctx context.Context, lease int64) (bool, error) {
+	_, err := c.client.KeepAliveOnce(ctx, clientv3.LeaseID(lease))
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Revoke revokes the lease, deleting the keys attached to it. Revoking an expired lease is not an error.
+func (c *Client) Revoke(ctx context.Context, lease int64) error {
+	_, err := c.client.Revoke(ctx, clientv3.LeaseID(lease))
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return nil
+	}
+	return err
+}
+
+// PutIfAbsent sets key to value, attached to the lease, if key does not exist, and reports whether it did.
+func (c *Client) PutIfAbsent(ctx context.Context, key, value string, lease int64) (bool, error) {
+	resp, err := c.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, value, clientv3.WithLease(clientv3.LeaseID(lease)))).
+		Commit()
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+// Get returns the value of key, and whether it exists.
+func (c *Client) Get(ctx context.Context, key string) (string, bool, error) {
+	resp, err := c.client.Get(ctx, key)
+	if err != nil || len(resp.Kvs) == 0 {
+		return "", false, err
+	}
+	return string(resp.Kvs[0].Value), true, nil
+}
@@ -0,0 +1,77 @@
+package etcdclient_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+
+	"github.com/kittipat1413/go-common/framework/lock"
+	"github.com/kittipat1413/go-common/framework/lock/etcdlock"
+	"github.com/kittipat1413/go-common/framework/lock/etcdlock/etcdclient"
+)
+
+// newClient returns a client of an embedded etcd server.
+func newClient(t *testing.T) *clientv3.Client {
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	clientURL, _ := url.Parse("http://127.0.0.1:0")
+	peerURL, _ := url.Parse("http://127.0.0.1:0")
+	cfg.ListenClientUrls, cfg.AdvertiseClientUrls = []url.URL{*clientURL}, []url.URL{*clientURL}
+	cfg.ListenPeerUrls, cfg.AdvertisePeerUrls = []url.URL{*peerURL}, []url.URL{*peerURL}
+	cfg.InitialCluster = cfg.Name + "=" + peerURL.String()
+	server, err := embed.StartEtcd(cfg)
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+	select {
+	case <-server.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatal("the etcd server did not start")
+	}
+
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{server.Clients[0].Addr().String()}})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestLocker(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+	locker := lock.New(etcdlock.New(etcdclient.New(client)), lock.WithTTL(3*time.Second))
+
+	lk, err := locker.TryAcquire(ctx, "invoices")
+	require.NoError(t, err)
+	resp, err := client.Get(ctx, "/locks/invoices")
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.NotZero(t, resp.Kvs[0].Lease, "the lock is attached to a lease")
+
+	_, err = locker.TryAcquire(ctx, "invoices")
+	assert.ErrorIs(t, err, lock.ErrNotAcquired, "the lock is held")
+
+	require.NoError(t, lk.Release(ctx))
+	resp, err = client.Get(ctx, "/locks/invoices")
+	require.NoError(t, err)
+	assert.Empty(t, resp.Kvs, "releasing the lock revokes its lease")
+
+	// A lock whose lease was revoked is lost.
+	lk, err = locker.TryAcquire(ctx, "invoices")
+	require.NoError(t, err)
+	resp, err = client.Get(ctx, "/locks/invoices")
+	require.NoError(t, err)
+	_, err = client.Revoke(ctx, clientv3.LeaseID(resp.Kvs[0].Lease))
+	require.NoError(t, err)
+	select {
+	case <-lk.Done():
+		assert.ErrorIs(t, lk.Err(), lock.ErrLockLost)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the lock was not lost")
+	}
+}
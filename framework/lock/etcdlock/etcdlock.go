@@ -0,0 +1,160 @@
+package etcdlock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/lock"
+)
+
+// DefaultKeyPrefix is prepended to the keys of the locks in etcd unless WithKeyPrefix is set.
+const DefaultKeyPrefix = "/locks/"
+
+// Client is the subset of etcd operations used by the backend. The etcdclient module implements it with
+// go.etcd.io/etcd/client/v3.
+type Client interface {
+	// Grant creates a lease expiring after ttl seconds, and returns its ID.
+	Grant(ctx context.Context, ttl int64) (int64, error)
+	// KeepAliveOnce renews the lease, and reports whether it was still alive.
+	KeepAliveOnce(ctx context.Context, lease int64) (bool, error)
+	// Revoke revokes the lease, deleting the keys attached to it.
+	Revoke(ctx context.Context, lease int64) error
+	// PutIfAbsent sets key to value, attached to the lease, if key does not exist, and reports whether it did.
+	PutIfAbsent(ctx context.Context, key, value string, lease int64) (bool, error)
+	// Get returns the value of key, and whether it exists.
+	Get(ctx context.Context, key string) (string, bool, error)
+}
+
+// config holds configuration options for the backend.
+type config struct {
+	keyPrefix string
+}
+
+// Option specifies backend configuration options.
+type Option func(*config)
+
+// WithKeyPrefix sets the prefix of the keys of the locks in etcd, e.g., to share an etcd cluster with other
+// services.
+func WithKeyPrefix(prefix string) Option {
+	return func(c *config) {
+		c.keyPrefix = prefix
+	}
+}
+
+/*
+Backend is a lock.Backend backed by etcd, for the environments where Redis is not authoritative: the locks are
+replicated by Raft, so that they survive the failure of a member of the cluster.
+
+A lock is the key <prefix><key> holding the token of its holder, attached to a lease of the time to live of the
+lock, so that it is deleted when the lease expires. The lease is kept alive by the renewals of the lock, and revoked
+when it is released. A renewal finding the lease expired, or the key held by another token, reports the lock lost,
+which closes the Done channel of the lock.
+
+The leases have a time to live in whole seconds, rounded up, and etcd enforces a minimum, about 2 seconds by
+default, so that the time to live of the Locker should be of a few seconds at least.
+
+Example usage:
+
+	locker := lock.New(etcdlock.New(etcdclient.New(client)), lock.WithTTL(15*time.Second))
+*/
+type Backend struct {
+	client Client
+	cfg    config
+	mutex  sync.Mutex
+	leases map[string]int64 // leases are the leases of the locks held, by token.
+}
+
+var _ lock.Backend = (*Backend)(nil)
+
+// New creates a Backend.
+func New(client Client, opts ...Option) *Backend {
+	cfg := config{keyPrefix: DefaultKeyPrefix}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Backend{client: client, cfg: cfg, leases: make(map[string]int64)}
+}
+
+// Acquire sets the lock of key to token, attached to a lease of ttl, if it is free.
+func (b *Backend) Acquire(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+	lease, err := b.client.Grant(ctx, max(seconds, 1))
+	if err != nil {
+		return false, err
+	}
+	acquired, err := b.client.PutIfAbsent(ctx, b.cfg.keyPrefix+key, token, lease)
+	if err != nil || !acquired {
+		return false, errors.Join(err, b.client.Revoke(context.WithoutCancel(ctx), lease))
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.leases[token] = lease
+	return true, nil
+}
+
+// Renew keeps the lease of the lock of key alive if it holds token. The time to live of the lease is the one it was
+// granted with.
+func (b *Backend) Renew(ctx context.Context, key, token string, _ time.Duration) (bool, error) {
+	lease, ok := b.lease(token)
+	if !ok {
+		return false, nil
+	}
+	alive, err := b.client.KeepAliveOnce(ctx, lease)
+	if err != nil {
+		return false, err
+	}
+	if alive {
+		value, exists, err := b.client.Get(ctx, b.cfg.keyPrefix+key)
+		if err != nil {
+			return false, err
+		}
+		if exists && value == token {
+			return true, nil
+		}
+	}
+	b.forget(token)
+	return false, b.revoke(ctx, lease, alive)
+}
+
+// Release revokes the lease of the lock of key, deleting it, if it holds token.
+func (b *Backend) Release(ctx context.Context, key, token string) (bool, error) {
+	lease, ok := b.lease(token)
+	if !ok {
+		return false, nil
+	}
+	value, exists, err := b.client.Get(ctx, b.cfg.keyPrefix+key)
+	if err != nil {
+		return false, err
+	}
+	b.forget(token)
+	if err := b.client.Revoke(ctx, lease); err != nil {
+		return false, err
+	}
+	return exists && value == token, nil
+}
+
+// lease returns the lease of the lock held with token.
+func (b *Backend) lease(token string) (int64, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	lease, ok := b.leases[token]
+	return lease, ok
+}
+
+// forget removes the lease of the lock held with token.
+func (b *Backend) forget(token string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.leases, token)
+}
+
+// revoke revokes the lease of a lock lost if it is still alive.
+func (b *Backend) revoke(ctx context.Context, lease int64, alive bool) error {
+	if !alive {
+		return nil
+	}
+	return b.client.Revoke(ctx, lease)
+}
@@ -0,0 +1,177 @@
+package etcdlock_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/lock"
+	"github.com/kittipat1413/go-common/framework/lock/etcdlock"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// fakeClient emulates the leases and the keys of etcd, the leases expiring when the tests expire them.
+type fakeClient struct {
+	mutex     sync.Mutex
+	nextLease int64
+	leases    map[int64]int64 // leases are the times to live of the leases alive, by ID.
+	values    map[string]string
+	keyLeases map[string]int64
+	err       error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{leases: make(map[int64]int64), values: make(map[string]string), keyLeases: make(map[string]int64)}
+}
+
+// expire expires the lease of key, deleting the keys attached to it.
+func (c *fakeClient) expire(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.revoke(c.keyLeases[key])
+}
+
+func (c *fakeClient) revoke(lease int64) {
+	delete(c.leases, lease)
+	for key, l := range c.keyLeases {
+		if l == lease {
+			delete(c.values, key)
+			delete(c.keyLeases, key)
+		}
+	}
+}
+
+func (c *fakeClient) alive() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.leases)
+}
+
+func (c *fakeClient) Grant(_ context.Context, ttl int64) (int64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err != nil {
+		return 0, c.err
+	}
+	c.nextLease++
+	c.leases[c.nextLease] = ttl
+	return c.nextLease, nil
+}
+
+func (c *fakeClient) KeepAliveOnce(_ context.Context, lease int64) (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err != nil {
+		return false, c.err
+	}
+	_, ok := c.leases[lease]
+	return ok, nil
+}
+
+func (c *fakeClient) Revoke(_ context.Context, lease int64) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.revoke(lease)
+	return nil
+}
+
+func (c *fakeClient) PutIfAbsent(_ context.Context, key, value string, lease int64) (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.values[key]; ok {
+		return false, nil
+	}
+	c.values[key], c.keyLeases[key] = value, lease
+	return true, nil
+}
+
+func (c *fakeClient) Get(_ context.Context, key string) (string, bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	value, ok := c.values[key]
+	return value, ok, nil
+}
+
+func TestBackend(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	backend := etcdlock.New(client, etcdlock.WithKeyPrefix("/billing/locks/"))
+
+	acquired, err := backend.Acquire(ctx, "invoices", "token", 1500*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, "token", client.values["/billing/locks/invoices"])
+	assert.Equal(t, int64(2), client.leases[client.keyLeases["/billing/locks/invoices"]], "the TTL is rounded up to seconds")
+
+	acquired, err = backend.Acquire(ctx, "invoices", "other", time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Equal(t, 1, client.alive(), "the lease of a lock not acquired is revoked")
+
+	renewed, err := backend.Renew(ctx, "invoices", "token", time.Second)
+	require.NoError(t, err)
+	assert.True(t, renewed)
+	renewed, err = backend.Renew(ctx, "invoices", "other", time.Second)
+	require.NoError(t, err)
+	assert.False(t, renewed, "only the holder renews the lock")
+
+	released, err := backend.Release(ctx, "invoices", "other")
+	require.NoError(t, err)
+	assert.False(t, released, "only the holder releases the lock")
+	released, err = backend.Release(ctx, "invoices", "token")
+	require.NoError(t, err)
+	assert.True(t, released)
+	assert.Empty(t, client.values)
+	assert.Zero(t, client.alive())
+}
+
+func TestBackend_LeaseExpired(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	backend := etcdlock.New(client)
+
+	acquired, err := backend.Acquire(ctx, "invoices", "token", time.Second)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	client.expire(etcdlock.DefaultKeyPrefix + "invoices")
+
+	renewed, err := backend.Renew(ctx, "invoices", "token", time.Second)
+	require.NoError(t, err)
+	assert.False(t, renewed)
+	released, err := backend.Release(ctx, "invoices", "token")
+	require.NoError(t, err)
+	assert.False(t, released)
+
+	client.err = errors.New("connection refused")
+	_, err = backend.Acquire(ctx, "invoices", "token", time.Second)
+	assert.ErrorIs(t, err, client.err)
+}
+
+func TestBackend_Locker(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	locker := lock.New(etcdlock.New(client), lock.WithTTL(30*time.Millisecond), lock.WithLogger(logger.NewNoopLogger()))
+
+	lk, err := locker.TryAcquire(ctx, "invoices")
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-lk.Done():
+		t.Fatal("the lock is kept alive while it is held")
+	default:
+	}
+
+	client.expire(etcdlock.DefaultKeyPrefix + "invoices")
+	select {
+	case <-lk.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the done channel is closed once the lease expired")
+	}
+	assert.ErrorIs(t, lk.Err(), lock.ErrLockLost)
+	assert.ErrorIs(t, lk.Release(ctx), lock.ErrLockLost)
+}
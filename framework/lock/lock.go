@@ -0,0 +1,303 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+const (
+	// DefaultTTL is the time to live of the locks, unless set with WithTTL.
+	DefaultTTL = 30 * time.Second
+	// DefaultRetryInterval is the interval Acquire retries at while the lock is held, unless set with
+	// WithRetryInterval.
+	DefaultRetryInterval = 100 * time.Millisecond
+)
+
+var (
+	// ErrNotAcquired is returned by TryAcquire when the lock is held by someone else.
+	ErrNotAcquired = errors.New("lock: not acquired")
+	// ErrLockLost is returned when a lock expired, or was taken over, while it was held, e.g., because it could not
+	// be renewed in time.
+	ErrLockLost = errors.New("lock: lock lost")
+)
+
+/*
+Backend stores the locks, e.g., redislock. A lock is a key holding the token of its holder until it expires, so that
+the lock of a crashed holder is freed after its time to live, and only its holder can renew or release it.
+*/
+type Backend interface {
+	// Acquire sets key to token for ttl if key is free, and reports whether it did.
+	Acquire(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	// Renew resets the time to live of key to ttl if it holds token, and reports whether it does.
+	Renew(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	// Release frees key if it holds token, and reports whether it did.
+	Release(ctx context.Context, key, token string) (bool, error)
+}
+
+// options holds configuration options for the Locker.
+type options struct {
+	ttl           time.Duration // ttl is the time to live of the locks, renewed while they are held.
+	retryInterval time.Duration // retryInterval is the interval Acquire retries at.
+	logger        logger.Logger // logger logs the locks lost, or the logger of the context if nil.
+}
+
+// Option specifies Locker configuration options.
+type Option func(*options)
+
+// WithTTL sets the time to live of the locks, after which the lock of a crashed holder is freed. The locks held are
+// renewed at a third of it. It defaults to DefaultTTL.
+func WithTTL(ttl time.Duration) Option {
+	return func(opts *options) {
+		if ttl > 0 {
+			opts.ttl = ttl
+		}
+	}
+}
+
+// WithRetryInterval sets the interval Acquire retries at while the lock is held. It defaults to
+// DefaultRetryInterval.
+func WithRetryInterval(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.retryInterval = d
+		}
+	}
+}
+
+// WithLogger sets the logger of the locks lost. It defaults to the logger of the context of the acquisition.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		if l != nil {
+			opts.logger = l
+		}
+	}
+}
+
+/*
+Locker acquires distributed locks from a Backend, so that a piece of work runs on a single replica at a time, e.g.,
+a cache refresh or a migration.
+
+A lock acquired holds a unique token, and is renewed by a watchdog while it is held, so that it does not expire
+during a long piece of work, but does after its time to live if its holder crashes. A lock which cannot be renewed
+is lost: its Done channel is closed, so that its holder can abort its work.
+
+The Locker is also a cron.Locker, see TryLock.
+
+Example usage:
+
+	locker := lock.New(redislock.New(goredisclient.New(rdb)), lock.WithTTL(10*time.Second))
+
+	err := locker.WithLock(ctx, "invoices:"+customerID, func(ctx context.Context) error {
+		return billCustomer(ctx, customerID) // ctx is canceled if the lock is lost
+	})
+	if err != nil {
+		// Handle error
+	}
+*/
+type Locker struct {
+	backend Backend
+	opts    options
+}
+
+// New creates a Locker of backend.
+func New(backend Backend, opts ...Option) *Locker {
+	o := options{ttl: DefaultTTL, retryInterval: DefaultRetryInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Locker{backend: backend, opts: o}
+}
+
+// TryAcquire acquires the lock of key, or returns ErrNotAcquired if it is held. The lock must be released with
+// Release.
+func (l *Locker) TryAcquire(ctx context.Context, key string) (*Lock, error) {
+	token := xid.New().String()
+	acquired, err := l.backend.Acquire(ctx, key, token, l.opts.ttl)
+	if err != nil {
+		return nil, fmt.Errorf("lock: acquire %s: %w", key, err)
+	}
+	if !acquired {
+		return nil, fmt.Errorf("%w: %s", ErrNotAcquired, key)
+	}
+
+	log := l.opts.logger
+	if log == nil {
+		log = logger.FromContext(ctx)
+	}
+	lk := &Lock{
+		locker: l,
+		key:    key,
+		token:  token,
+		log:    log,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	lk.watchdog.Add(1)
+	go lk.watch(context.WithoutCancel(ctx))
+	return lk, nil
+}
+
+// Acquire acquires the lock of key, retrying at the interval of WithRetryInterval while it is held, until ctx is
+// done. The lock must be released with Release.
+func (l *Locker) Acquire(ctx context.Context, key string) (*Lock, error) {
+	ticker := time.NewTicker(l.opts.retryInterval)
+	defer ticker.Stop()
+	for {
+		lk, err := l.TryAcquire(ctx, key)
+		if !errors.Is(err, ErrNotAcquired) {
+			return lk, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("lock: acquire %s: %w", key, context.Cause(ctx))
+		case <-ticker.C:
+		}
+	}
+}
+
+/*
+WithLock calls fn holding the lock of key, acquired as with Acquire, and releases it once fn returns. The context
+of fn is canceled with ErrLockLost as its cause if the lock is lost, in which case WithLock returns ErrLockLost
+with the error of fn.
+
+Example usage:
+
+	err := locker.WithLock(ctx, "refresh:exchange-rates", func(ctx context.Context) error {
+		return refreshExchangeRates(ctx)
+	})
+*/
+func (l *Locker) WithLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lk, err := l.Acquire(ctx, key)
+	if err != nil {
+		return err
+	}
+	fnCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		select {
+		case <-lk.Done():
+			cancel(ErrLockLost)
+		case <-fnCtx.Done():
+		}
+	}()
+
+	err = fn(fnCtx)
+	return errors.Join(err, lk.Release(context.WithoutCancel(ctx)))
+}
+
+// TryLock acquires the lock of key for ttl, and reports whether it was acquired. The lock is neither renewed nor
+// released: it expires after ttl. It implements cron.Locker.
+func (l *Locker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return l.backend.Acquire(ctx, key, xid.New().String(), ttl)
+}
+
+// Lock is a lock held, renewed until it is released or lost.
+type Lock struct {
+	locker   *Locker
+	key      string
+	token    string
+	log      logger.Logger
+	stop     chan struct{} // stop is closed to stop the watchdog.
+	done     chan struct{} // done is closed once the lock is released or lost.
+	watchdog sync.WaitGroup
+	once     sync.Once
+	mutex    sync.Mutex
+	err      error // err is ErrLockLost once the lock is lost.
+}
+
+// Key returns the key of the lock.
+func (lk *Lock) Key() string {
+	return lk.key
+}
+
+// Done returns a channel closed once the lock is released or lost.
+func (lk *Lock) Done() <-chan struct{} {
+	return lk.done
+}
+
+// Err returns ErrLockLost, with its cause, once the lock is lost, or nil.
+func (lk *Lock) Err() error {
+	lk.mutex.Lock()
+	defer lk.mutex.Unlock()
+	return lk.err
+}
+
+// Release stops renewing the lock and frees it. It returns ErrLockLost if the lock was lost, and is a no-op once the
+// lock is released.
+func (lk *Lock) Release(ctx context.Context) error {
+	var err error
+	lk.once.Do(func() {
+		close(lk.stop)
+		lk.watchdog.Wait()
+		defer lk.finish(nil)
+		if err = lk.Err(); err != nil {
+			return
+		}
+		released, releaseErr := lk.locker.backend.Release(ctx, lk.key, lk.token)
+		switch {
+		case releaseErr != nil:
+			err = fmt.Errorf("lock: release %s: %w", lk.key, releaseErr)
+		case !released:
+			err = fmt.Errorf("%w: %s", ErrLockLost, lk.key)
+		}
+	})
+	return err
+}
+
+// watch renews the lock at a third of its time to live until it is released. A lock taken over, or not renewed
+// within its time to live, is lost.
+func (lk *Lock) watch(ctx context.Context) {
+	defer lk.watchdog.Done()
+	ttl := lk.locker.opts.ttl
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-lk.stop:
+			return
+		case <-ticker.C:
+		}
+
+		renewCtx, cancel := context.WithTimeout(ctx, ttl/3)
+		held, err := lk.locker.backend.Renew(renewCtx, lk.key, lk.token, ttl)
+		cancel()
+		switch {
+		case err == nil && held:
+			renewed = time.Now()
+			continue
+		case err != nil && time.Since(renewed)+ttl/3 < ttl:
+			// The lock may still be renewed before it expires.
+			lk.log.Warn(ctx, "Lock renewal failed", logger.Fields{"key": lk.key, "error": err.Error()})
+			continue
+		}
+
+		cause := fmt.Errorf("%w: %s", ErrLockLost, lk.key)
+		if err != nil {
+			cause = fmt.Errorf("%w: %s: %w", ErrLockLost, lk.key, err)
+		}
+		lk.log.Error(ctx, "Lock lost", cause, logger.Fields{"key": lk.key})
+		lk.finish(cause)
+		return
+	}
+}
+
+// finish records the error of the lock, if it is the first one, and closes its done channel once.
+func (lk *Lock) finish(err error) {
+	lk.mutex.Lock()
+	defer lk.mutex.Unlock()
+	select {
+	case <-lk.done:
+		return
+	default:
+	}
+	lk.err = err
+	close(lk.done)
+}
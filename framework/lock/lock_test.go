@@ -0,0 +1,270 @@
+package lock_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cron"
+	"github.com/kittipat1413/go-common/framework/lock"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+var _ cron.Locker = (*lock.Locker)(nil)
+
+// fakeBackend stores the locks in memory, with their expiry.
+type fakeBackend struct {
+	mutex    sync.Mutex
+	tokens   map[string]string
+	expiries map[string]time.Time
+	renewErr error
+	renewals int
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{tokens: make(map[string]string), expiries: make(map[string]time.Time)}
+}
+
+// holder returns the token holding key, if any.
+func (b *fakeBackend) holder(key string) string {
+	if time.Now().After(b.expiries[key]) {
+		delete(b.tokens, key)
+	}
+	return b.tokens[key]
+}
+
+// steal sets the lock of key to another token.
+func (b *fakeBackend) steal(key string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.tokens[key] = "thief"
+}
+
+func (b *fakeBackend) setRenewErr(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.renewErr = err
+}
+
+func (b *fakeBackend) Acquire(_ context.Context, key, token string, ttl time.Duration) (bool, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.holder(key) != "" {
+		return false, nil
+	}
+	b.tokens[key], b.expiries[key] = token, time.Now().Add(ttl)
+	return true, nil
+}
+
+func (b *fakeBackend) Renew(_ context.Context, key, token string, ttl time.Duration) (bool, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.renewErr != nil {
+		return false, b.renewErr
+	}
+	if b.holder(key) != token {
+		return false, nil
+	}
+	b.renewals++
+	b.expiries[key] = time.Now().Add(ttl)
+	return true, nil
+}
+
+func (b *fakeBackend) Release(_ context.Context, key, token string) (bool, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.holder(key) != token {
+		return false, nil
+	}
+	delete(b.tokens, key)
+	return true, nil
+}
+
+func TestLocker_TryAcquire(t *testing.T) {
+	ctx := context.Background()
+	locker := lock.New(newFakeBackend())
+
+	lk, err := locker.TryAcquire(ctx, "invoices")
+	require.NoError(t, err)
+	assert.Equal(t, "invoices", lk.Key())
+
+	_, err = locker.TryAcquire(ctx, "invoices")
+	assert.ErrorIs(t, err, lock.ErrNotAcquired)
+	other, err := locker.TryAcquire(ctx, "reports")
+	require.NoError(t, err, "the locks of other keys are independent")
+	require.NoError(t, other.Release(ctx))
+
+	require.NoError(t, lk.Release(ctx))
+	require.NoError(t, lk.Release(ctx), "releasing twice is a no-op")
+	select {
+	case <-lk.Done():
+	default:
+		t.Fatal("the done channel is closed once the lock is released")
+	}
+	assert.NoError(t, lk.Err())
+
+	lk, err = locker.TryAcquire(ctx, "invoices")
+	require.NoError(t, err)
+	require.NoError(t, lk.Release(ctx))
+}
+
+func TestLocker_Acquire(t *testing.T) {
+	ctx := context.Background()
+	locker := lock.New(newFakeBackend(), lock.WithRetryInterval(5*time.Millisecond))
+
+	lk, err := locker.Acquire(ctx, "invoices")
+	require.NoError(t, err)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	_, err = locker.Acquire(timeoutCtx, "invoices")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	acquired := make(chan error)
+	go func() {
+		other, err := locker.Acquire(ctx, "invoices")
+		if err == nil {
+			err = other.Release(ctx)
+		}
+		acquired <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, lk.Release(ctx))
+	select {
+	case err := <-acquired:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the lock is acquired once released")
+	}
+}
+
+func TestLock_Watchdog(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("renews the lock while it is held", func(t *testing.T) {
+		backend := newFakeBackend()
+		locker := lock.New(backend, lock.WithTTL(60*time.Millisecond))
+		lk, err := locker.TryAcquire(ctx, "invoices")
+		require.NoError(t, err)
+
+		time.Sleep(200 * time.Millisecond)
+		_, err = locker.TryAcquire(ctx, "invoices")
+		assert.ErrorIs(t, err, lock.ErrNotAcquired, "the lock does not expire while it is held")
+		require.NoError(t, lk.Release(ctx))
+		backend.mutex.Lock()
+		assert.GreaterOrEqual(t, backend.renewals, 3)
+		backend.mutex.Unlock()
+	})
+
+	t.Run("loses the lock taken over", func(t *testing.T) {
+		backend := newFakeBackend()
+		log, recorder := logger.NewTestLogger()
+		locker := lock.New(backend, lock.WithTTL(30*time.Millisecond), lock.WithLogger(log))
+		lk, err := locker.TryAcquire(ctx, "invoices")
+		require.NoError(t, err)
+
+		backend.steal("invoices")
+		select {
+		case <-lk.Done():
+		case <-time.After(time.Second):
+			t.Fatal("the done channel is closed once the lock is lost")
+		}
+		assert.ErrorIs(t, lk.Err(), lock.ErrLockLost)
+		assert.ErrorIs(t, lk.Release(ctx), lock.ErrLockLost)
+		recorder.AssertLogged(t, logger.ERROR, "Lock lost", logger.HasField("key", "invoices"))
+	})
+
+	t.Run("loses the lock not renewed within its time to live", func(t *testing.T) {
+		backend := newFakeBackend()
+		log, recorder := logger.NewTestLogger()
+		locker := lock.New(backend, lock.WithTTL(60*time.Millisecond), lock.WithLogger(log))
+		lk, err := locker.TryAcquire(ctx, "invoices")
+		require.NoError(t, err)
+
+		unavailable := errors.New("connection refused")
+		backend.setRenewErr(unavailable)
+		select {
+		case <-lk.Done():
+		case <-time.After(time.Second):
+			t.Fatal("the done channel is closed once the lock is lost")
+		}
+		assert.ErrorIs(t, lk.Err(), lock.ErrLockLost)
+		assert.ErrorIs(t, lk.Err(), unavailable)
+		recorder.AssertLogged(t, logger.WARN, "Lock renewal failed", logger.HasField("key", "invoices"))
+	})
+}
+
+func TestLocker_WithLock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("runs the functions one at a time", func(t *testing.T) {
+		locker := lock.New(newFakeBackend(), lock.WithRetryInterval(time.Millisecond))
+		var running, maxRunning atomic.Int32
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := locker.WithLock(ctx, "invoices", func(ctx context.Context) error {
+					n := running.Add(1)
+					if n > maxRunning.Load() {
+						maxRunning.Store(n)
+					}
+					time.Sleep(5 * time.Millisecond)
+					running.Add(-1)
+					return nil
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), maxRunning.Load())
+	})
+
+	t.Run("returns the error of the function and releases the lock", func(t *testing.T) {
+		locker := lock.New(newFakeBackend())
+		failed := errors.New("failed")
+		err := locker.WithLock(ctx, "invoices", func(ctx context.Context) error { return failed })
+		assert.ErrorIs(t, err, failed)
+
+		lk, err := locker.TryAcquire(ctx, "invoices")
+		require.NoError(t, err)
+		require.NoError(t, lk.Release(ctx))
+	})
+
+	t.Run("cancels the function once the lock is lost", func(t *testing.T) {
+		backend := newFakeBackend()
+		locker := lock.New(backend, lock.WithTTL(30*time.Millisecond), lock.WithLogger(logger.NewNoopLogger()))
+		err := locker.WithLock(ctx, "invoices", func(ctx context.Context) error {
+			backend.steal("invoices")
+			<-ctx.Done()
+			assert.ErrorIs(t, context.Cause(ctx), lock.ErrLockLost)
+			return ctx.Err()
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, err, lock.ErrLockLost)
+	})
+}
+
+func TestLocker_TryLock(t *testing.T) {
+	ctx := context.Background()
+	locker := lock.New(newFakeBackend())
+
+	acquired, err := locker.TryLock(ctx, "cron:report", 20*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, err = locker.TryLock(ctx, "cron:report", 20*time.Millisecond)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	time.Sleep(30 * time.Millisecond)
+	acquired, err = locker.TryLock(ctx, "cron:report", 20*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, acquired, "the lock expires after its time to live")
+}
@@ -0,0 +1,44 @@
+package goredisclient_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/lock"
+	"github.com/kittipat1413/go-common/framework/lock/redislock"
+	"github.com/kittipat1413/go-common/framework/lock/redislock/goredisclient"
+)
+
+func TestLocker(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	locker := lock.New(redislock.New(goredisclient.New(rdb)), lock.WithTTL(3*time.Second))
+
+	lk, err := locker.TryAcquire(ctx, "invoices")
+	require.NoError(t, err)
+	assert.True(t, server.Exists("lock:invoices"))
+	assert.Greater(t, server.TTL("lock:invoices"), time.Duration(0), "the lock expires")
+
+	_, err = locker.TryAcquire(ctx, "invoices")
+	assert.ErrorIs(t, err, lock.ErrNotAcquired, "the lock is held")
+
+	require.NoError(t, lk.Release(ctx))
+	assert.False(t, server.Exists("lock:invoices"))
+
+	// A lock taken over is not released by its former holder.
+	lk, err = locker.TryAcquire(ctx, "invoices")
+	require.NoError(t, err)
+	require.NoError(t, server.Set("lock:invoices", "other"))
+	assert.ErrorIs(t, lk.Release(ctx), lock.ErrLockLost)
+	value, err := server.Get("lock:invoices")
+	require.NoError(t, err)
+	assert.Equal(t, "other", value)
+}
@@ -0,0 +1,122 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/lock"
+)
+
+// DefaultKeyPrefix is prepended to the keys of the locks in Redis unless WithKeyPrefix is set.
+const DefaultKeyPrefix = "lock:"
+
+// ErrUnexpectedReply is returned when the reply of a script cannot be parsed.
+var ErrUnexpectedReply = errors.New("redislock: unexpected script reply")
+
+// acquireScript sets KEYS[1] to the token ARGV[1] for ARGV[2] milliseconds if it does not exist. It returns 1, or 0
+// if the lock is held.
+const acquireScript = `
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 1
+end
+return 0
+`
+
+// renewScript resets the time to live of KEYS[1] to ARGV[2] milliseconds if it holds the token ARGV[1]. It returns
+// 1, or 0 if the lock is not held.
+const renewScript = `
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`
+
+// releaseScript deletes KEYS[1] if it holds the token ARGV[1]. It returns 1, or 0 if the lock is not held.
+const releaseScript = `
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call('DEL', KEYS[1])
+return 1
+`
+
+// Client is the subset of Redis operations used by the backend. The goredisclient module implements it with
+// github.com/redis/go-redis.
+type Client interface {
+	// Eval runs the Lua script with keys and args, and returns its reply.
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// config holds configuration options for the backend.
+type config struct {
+	keyPrefix string
+}
+
+// Option specifies backend configuration options.
+type Option func(*config)
+
+// WithKeyPrefix sets the prefix of the keys of the locks in Redis, e.g., to share a Redis with other services.
+func WithKeyPrefix(prefix string) Option {
+	return func(c *config) {
+		c.keyPrefix = prefix
+	}
+}
+
+/*
+Backend is a lock.Backend backed by Redis. A lock is the key <prefix><key> holding the token of its holder, set with
+SET NX PX, so that it expires after its time to live. It is renewed and released with Lua scripts checking the
+token, so that a holder whose lock expired cannot renew or release the lock taken over by someone else.
+
+The locks are as reliable as the Redis they are stored in: with a replicated Redis, a lock acquired on the primary
+may be lost on a failover, before it was replicated.
+
+Example usage:
+
+	locker := lock.New(redislock.New(goredisclient.New(rdb), redislock.WithKeyPrefix("billing:lock:")))
+*/
+type Backend struct {
+	client Client
+	cfg    config
+}
+
+var _ lock.Backend = (*Backend)(nil)
+
+// New creates a Backend.
+func New(client Client, opts ...Option) *Backend {
+	cfg := config{keyPrefix: DefaultKeyPrefix}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Backend{client: client, cfg: cfg}
+}
+
+// Acquire sets the lock of key to token for ttl if it is free.
+func (b *Backend) Acquire(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	return b.eval(ctx, acquireScript, key, token, ttl.Milliseconds())
+}
+
+// Renew resets the time to live of the lock of key to ttl if it holds token.
+func (b *Backend) Renew(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	return b.eval(ctx, renewScript, key, token, ttl.Milliseconds())
+}
+
+// Release frees the lock of key if it holds token.
+func (b *Backend) Release(ctx context.Context, key, token string) (bool, error) {
+	return b.eval(ctx, releaseScript, key, token)
+}
+
+// eval runs the script on the key of the lock of key, and reports whether it returned 1.
+func (b *Backend) eval(ctx context.Context, script, key string, args ...interface{}) (bool, error) {
+	reply, err := b.client.Eval(ctx, script, []string{b.cfg.keyPrefix + key}, args...)
+	if err != nil {
+		return false, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return false, fmt.Errorf("%w: %v", ErrUnexpectedReply, reply)
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,113 @@
+package redislock_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/lock"
+	"github.com/kittipat1413/go-common/framework/lock/redislock"
+)
+
+// fakeClient emulates the scripts of the backend, recording the keys and the times to live.
+type fakeClient struct {
+	mutex  sync.Mutex
+	values map[string]string
+	ttls   map[string]int64
+	reply  interface{}
+	err    error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{values: make(map[string]string), ttls: make(map[string]int64)}
+}
+
+func (c *fakeClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err != nil || c.reply != nil {
+		return c.reply, c.err
+	}
+
+	key, token := keys[0], args[0].(string)
+	switch {
+	case strings.Contains(script, "'NX'"):
+		if _, ok := c.values[key]; ok {
+			return int64(0), nil
+		}
+		c.values[key], c.ttls[key] = token, args[1].(int64)
+	case c.values[key] != token:
+		return int64(0), nil
+	case strings.Contains(script, "PEXPIRE"):
+		c.ttls[key] = args[1].(int64)
+	case strings.Contains(script, "DEL"):
+		delete(c.values, key)
+	}
+	return int64(1), nil
+}
+
+func TestBackend(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	backend := redislock.New(client, redislock.WithKeyPrefix("billing:lock:"))
+
+	acquired, err := backend.Acquire(ctx, "invoices", "token", time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, "token", client.values["billing:lock:invoices"])
+	assert.Equal(t, int64(1000), client.ttls["billing:lock:invoices"])
+	acquired, err = backend.Acquire(ctx, "invoices", "other", time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	renewed, err := backend.Renew(ctx, "invoices", "token", 2*time.Second)
+	require.NoError(t, err)
+	assert.True(t, renewed)
+	assert.Equal(t, int64(2000), client.ttls["billing:lock:invoices"])
+	renewed, err = backend.Renew(ctx, "invoices", "other", 2*time.Second)
+	require.NoError(t, err)
+	assert.False(t, renewed, "only the holder renews the lock")
+
+	released, err := backend.Release(ctx, "invoices", "other")
+	require.NoError(t, err)
+	assert.False(t, released, "only the holder releases the lock")
+	released, err = backend.Release(ctx, "invoices", "token")
+	require.NoError(t, err)
+	assert.True(t, released)
+	assert.Empty(t, client.values)
+}
+
+func TestBackend_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	client := newFakeClient()
+	client.err = errors.New("connection refused")
+	_, err := redislock.New(client).Acquire(ctx, "invoices", "token", time.Second)
+	assert.ErrorIs(t, err, client.err)
+
+	client = newFakeClient()
+	client.reply = "OK"
+	_, err = redislock.New(client).Release(ctx, "invoices", "token")
+	assert.ErrorIs(t, err, redislock.ErrUnexpectedReply)
+}
+
+func TestBackend_Locker(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	locker := lock.New(redislock.New(client))
+
+	err := locker.WithLock(ctx, "invoices", func(ctx context.Context) error {
+		client.mutex.Lock()
+		defer client.mutex.Unlock()
+		assert.Contains(t, client.values, redislock.DefaultKeyPrefix+"invoices")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Empty(t, client.values)
+}
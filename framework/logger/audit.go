@@ -0,0 +1,254 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Keys of the audit records written by the AuditLogger.
+const (
+	AuditTimestampKey   = "timestamp"
+	AuditActorKey       = "actor"
+	AuditActionKey      = "action"
+	AuditResourceKey    = "resource"
+	AuditOutcomeKey     = "outcome"
+	AuditDetailsKey     = "details"
+	AuditTraceIDKey     = "trace_id"
+	AuditSpanIDKey      = "span_id"
+	AuditServiceNameKey = "service_name"
+	AuditEnvironmentKey = "environment"
+)
+
+// AuditOutcome is the outcome of an audited action.
+type AuditOutcome string
+
+const (
+	AuditOutcomeSuccess AuditOutcome = "success"
+	AuditOutcomeFailure AuditOutcome = "failure"
+	AuditOutcomeDenied  AuditOutcome = "denied"
+)
+
+var (
+	// ErrInvalidAuditConfig is returned when the audit logger configuration is invalid.
+	ErrInvalidAuditConfig = errors.New("invalid audit config")
+	// ErrInvalidAuditEvent is returned when an audit event does not match the audit schema.
+	ErrInvalidAuditEvent = errors.New("invalid audit event")
+	// ErrAuditDeliveryFailed is returned when an audit record could not be durably written by the sink.
+	ErrAuditDeliveryFailed = errors.New("audit delivery failed")
+)
+
+// AuditEvent is an audited action. Actor, Action, Resource and Outcome are required.
+type AuditEvent struct {
+	// Actor identifies who performed the action, e.g., a user or service account ID.
+	Actor string
+	// Action is the audited action, e.g., "user.delete".
+	Action string
+	// Resource identifies the target of the action, e.g., "users/12345".
+	Resource string
+	// Outcome is the outcome of the action.
+	Outcome AuditOutcome
+	// Time is the time of the action. Defaults to the time the event is logged.
+	Time time.Time
+	// Details are additional values written under AuditDetailsKey, they cannot override the required fields.
+	Details Fields
+}
+
+/*
+AuditSink durably delivers audit records. WriteAudit must only return once the record is persisted
+(e.g., fsync-ed) or acknowledged by the downstream system, and return an error otherwise.
+*/
+type AuditSink interface {
+	WriteAudit(ctx context.Context, record []byte) error
+}
+
+/*
+AuditSinkFunc is a function implementing AuditSink, e.g., to publish audit records to a queue
+and wait for the broker acknowledgement.
+
+Example usage:
+
+	sink := logger.AuditSinkFunc(func(ctx context.Context, record []byte) error {
+		return producer.PublishSync(ctx, "audit-events", record) // returns once acknowledged
+	})
+*/
+type AuditSinkFunc func(ctx context.Context, record []byte) error
+
+// WriteAudit implements the AuditSink interface.
+func (f AuditSinkFunc) WriteAudit(ctx context.Context, record []byte) error {
+	return f(ctx, record)
+}
+
+// AuditConfig holds the audit logger configuration.
+type AuditConfig struct {
+	// Sink durably delivers the audit records. Required.
+	Sink AuditSink
+	// ServiceName is an optional field for specifying the name of the service written with every record.
+	ServiceName string
+	// Environment is an optional field for specifying the running environment written with every record.
+	Environment string
+	// RequiredDetails is an optional list of detail keys every event must provide.
+	RequiredDetails []string
+	// Validate is an optional function for validating events against an application-specific schema.
+	Validate func(event AuditEvent) error
+}
+
+/*
+AuditLogger writes audit events to a dedicated sink with guaranteed delivery. Unlike Logger, it never samples,
+suppresses, buffers or redacts events: each event is validated against the audit schema, serialized and
+synchronously delivered, and Log returns an error if the event was not durably written.
+
+Example usage:
+
+	sink, err := logger.NewFileAuditSink("/var/log/my-service/audit.log")
+	if err != nil {
+		// Handle error
+	}
+	defer sink.Close()
+
+	auditLog, err := logger.NewAuditLogger(logger.AuditConfig{
+		Sink:            sink,
+		ServiceName:     "my-service",
+		RequiredDetails: []string{"ip_address"},
+	})
+
+	err = auditLog.Log(ctx, logger.AuditEvent{
+		Actor:    "user-123",
+		Action:   "user.delete",
+		Resource: "users/456",
+		Outcome:  logger.AuditOutcomeSuccess,
+		Details:  logger.Fields{"ip_address": "10.0.0.1"},
+	})
+*/
+type AuditLogger struct {
+	config AuditConfig
+}
+
+// NewAuditLogger creates a new audit logger with the provided configuration.
+func NewAuditLogger(config AuditConfig) (*AuditLogger, error) {
+	if config.Sink == nil {
+		return nil, ErrInvalidAuditConfig
+	}
+	return &AuditLogger{config: config}, nil
+}
+
+// Log validates the event and synchronously delivers it to the sink.
+func (a *AuditLogger) Log(ctx context.Context, event AuditEvent) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := a.validate(event); err != nil {
+		return err
+	}
+
+	record, err := json.Marshal(a.record(ctx, event))
+	if err != nil {
+		return fmt.Errorf("%w: failed to marshal audit record: %v", ErrInvalidAuditEvent, err)
+	}
+	if err := a.config.Sink.WriteAudit(ctx, record); err != nil {
+		return fmt.Errorf("%w: %v", ErrAuditDeliveryFailed, err)
+	}
+	return nil
+}
+
+// validate checks the event against the audit schema.
+func (a *AuditLogger) validate(event AuditEvent) error {
+	if event.Actor == "" || event.Action == "" || event.Resource == "" {
+		return fmt.Errorf("%w: actor, action and resource are required", ErrInvalidAuditEvent)
+	}
+	switch event.Outcome {
+	case AuditOutcomeSuccess, AuditOutcomeFailure, AuditOutcomeDenied:
+	default:
+		return fmt.Errorf("%w: unknown outcome %q", ErrInvalidAuditEvent, event.Outcome)
+	}
+	for _, key := range a.config.RequiredDetails {
+		if _, ok := event.Details[key]; !ok {
+			return fmt.Errorf("%w: missing required detail %q", ErrInvalidAuditEvent, key)
+		}
+	}
+	if a.config.Validate != nil {
+		if err := a.config.Validate(event); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidAuditEvent, err)
+		}
+	}
+	return nil
+}
+
+// record builds the audit record of the event. Details are nested, so they cannot override the required fields.
+func (a *AuditLogger) record(ctx context.Context, event AuditEvent) map[string]interface{} {
+	eventTime := event.Time
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+
+	record := map[string]interface{}{
+		AuditTimestampKey: eventTime.UTC().Format(time.RFC3339Nano),
+		AuditActorKey:     event.Actor,
+		AuditActionKey:    event.Action,
+		AuditResourceKey:  event.Resource,
+		AuditOutcomeKey:   event.Outcome,
+	}
+	if len(event.Details) > 0 {
+		details := make(map[string]interface{}, len(event.Details))
+		for k, v := range resolveLazyValues(event.Details) {
+			if err, ok := v.(error); ok {
+				v = err.Error()
+			}
+			details[k] = v
+		}
+		record[AuditDetailsKey] = details
+	}
+	if a.config.ServiceName != "" {
+		record[AuditServiceNameKey] = a.config.ServiceName
+	}
+	if a.config.Environment != "" {
+		record[AuditEnvironmentKey] = a.config.Environment
+	}
+	if traceID, spanID := extractTraceIDs(ctx); traceID != nil {
+		record[AuditTraceIDKey] = *traceID
+		record[AuditSpanIDKey] = *spanID
+	}
+	return record
+}
+
+/*
+FileAuditSink is an AuditSink appending audit records to a file, one JSON record per line.
+Every record is fsync-ed before WriteAudit returns.
+*/
+type FileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileAuditSink opens (or creates) the file at path for appending audit records.
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileAuditSink{file: file}, nil
+}
+
+// WriteAudit implements the AuditSink interface.
+func (s *FileAuditSink) WriteAudit(_ context.Context, record []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	line := make([]byte, 0, len(record)+1)
+	line = append(append(line, record...), '\n')
+	if _, err := s.file.Write(line); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close closes the underlying file.
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,162 @@
+package logger_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAuditLogger_RequiresSink(t *testing.T) {
+	_, err := logger.NewAuditLogger(logger.AuditConfig{})
+	assert.ErrorIs(t, err, logger.ErrInvalidAuditConfig)
+}
+
+func TestAuditLogger_Log(t *testing.T) {
+	var records [][]byte
+	auditLog, err := logger.NewAuditLogger(logger.AuditConfig{
+		Sink: logger.AuditSinkFunc(func(_ context.Context, record []byte) error {
+			records = append(records, record)
+			return nil
+		}),
+		ServiceName: "my-service",
+		Environment: "production",
+	})
+	require.NoError(t, err)
+
+	eventTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	err = auditLog.Log(context.Background(), logger.AuditEvent{
+		Actor:    "user-123",
+		Action:   "user.delete",
+		Resource: "users/456",
+		Outcome:  logger.AuditOutcomeSuccess,
+		Time:     eventTime,
+		Details:  logger.Fields{"ip_address": "10.0.0.1", "actor": "spoofed"},
+	})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(records[0], &record))
+	assert.Equal(t, "2024-01-02T03:04:05Z", record[logger.AuditTimestampKey])
+	assert.Equal(t, "user-123", record[logger.AuditActorKey])
+	assert.Equal(t, "user.delete", record[logger.AuditActionKey])
+	assert.Equal(t, "users/456", record[logger.AuditResourceKey])
+	assert.Equal(t, "success", record[logger.AuditOutcomeKey])
+	assert.Equal(t, "my-service", record[logger.AuditServiceNameKey])
+	assert.Equal(t, "production", record[logger.AuditEnvironmentKey])
+	assert.Equal(t, map[string]interface{}{"ip_address": "10.0.0.1", "actor": "spoofed"}, record[logger.AuditDetailsKey])
+}
+
+func TestAuditLogger_Validation(t *testing.T) {
+	delivered := 0
+	auditLog, err := logger.NewAuditLogger(logger.AuditConfig{
+		Sink: logger.AuditSinkFunc(func(context.Context, []byte) error {
+			delivered++
+			return nil
+		}),
+		RequiredDetails: []string{"ip_address"},
+		Validate: func(event logger.AuditEvent) error {
+			if event.Action == "forbidden.action" {
+				return errors.New("unknown action")
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	valid := logger.AuditEvent{
+		Actor:    "user-123",
+		Action:   "user.delete",
+		Resource: "users/456",
+		Outcome:  logger.AuditOutcomeDenied,
+		Details:  logger.Fields{"ip_address": "10.0.0.1"},
+	}
+	tests := []struct {
+		name   string
+		modify func(event *logger.AuditEvent)
+	}{
+		{name: "missing actor", modify: func(e *logger.AuditEvent) { e.Actor = "" }},
+		{name: "missing action", modify: func(e *logger.AuditEvent) { e.Action = "" }},
+		{name: "missing resource", modify: func(e *logger.AuditEvent) { e.Resource = "" }},
+		{name: "unknown outcome", modify: func(e *logger.AuditEvent) { e.Outcome = "maybe" }},
+		{name: "missing required detail", modify: func(e *logger.AuditEvent) { e.Details = nil }},
+		{name: "custom validation", modify: func(e *logger.AuditEvent) { e.Action = "forbidden.action" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := valid
+			tt.modify(&event)
+			assert.ErrorIs(t, auditLog.Log(context.Background(), event), logger.ErrInvalidAuditEvent)
+		})
+	}
+	assert.Equal(t, 0, delivered)
+
+	require.NoError(t, auditLog.Log(context.Background(), valid))
+	assert.Equal(t, 1, delivered)
+}
+
+func TestAuditLogger_DeliveryFailure(t *testing.T) {
+	sinkErr := errors.New("broker unavailable")
+	auditLog, err := logger.NewAuditLogger(logger.AuditConfig{
+		Sink: logger.AuditSinkFunc(func(context.Context, []byte) error { return sinkErr }),
+	})
+	require.NoError(t, err)
+
+	err = auditLog.Log(context.Background(), logger.AuditEvent{
+		Actor:    "user-123",
+		Action:   "user.delete",
+		Resource: "users/456",
+		Outcome:  logger.AuditOutcomeFailure,
+	})
+	assert.ErrorIs(t, err, logger.ErrAuditDeliveryFailed)
+	assert.ErrorContains(t, err, "broker unavailable")
+}
+
+func TestFileAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := logger.NewFileAuditSink(path)
+	require.NoError(t, err)
+
+	auditLog, err := logger.NewAuditLogger(logger.AuditConfig{Sink: sink})
+	require.NoError(t, err)
+	for _, action := range []string{"user.create", "user.delete"} {
+		require.NoError(t, auditLog.Log(context.Background(), logger.AuditEvent{
+			Actor:    "user-123",
+			Action:   action,
+			Resource: "users/456",
+			Outcome:  logger.AuditOutcomeSuccess,
+		}))
+	}
+	require.NoError(t, sink.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var actions []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		actions = append(actions, record[logger.AuditActionKey].(string))
+	}
+	assert.Equal(t, []string{"user.create", "user.delete"}, actions)
+
+	// Writing to a closed sink reports the delivery failure.
+	err = auditLog.Log(context.Background(), logger.AuditEvent{
+		Actor:    "user-123",
+		Action:   "user.update",
+		Resource: "users/456",
+		Outcome:  logger.AuditOutcomeSuccess,
+	})
+	assert.ErrorIs(t, err, logger.ErrAuditDeliveryFailed)
+}
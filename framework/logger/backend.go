@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"context"
+	"io"
+)
+
+/*
+Backend formats and writes the entries of a Logger. The Logger filters, samples, enriches and deduplicates entries
+and fires the hooks, then hands every remaining entry to its backend. Fatal exits and Panic panics once the
+backend returns, so backends only have to write the entry.
+
+The default backend is the FormatterBackend, which formats entries with a Formatter. Alternate backends
+(e.g., based on logrus with logrusbackend, zap or log/slog) can be provided with Config.Backend.
+
+Example usage:
+
+	type slogBackend struct {
+		handler slog.Handler
+	}
+
+	func (b *slogBackend) Write(ctx context.Context, entry logger.Entry) error {
+		record := slog.NewRecord(entry.Time, slogLevel(entry.Level), entry.Message, 0)
+		for key, value := range entry.Fields {
+			record.AddAttrs(slog.Any(key, value))
+		}
+		return b.handler.Handle(ctx, record)
+	}
+*/
+type Backend interface {
+	// Write writes the entry. ctx is the context passed to the Logger, e.g., carrying the trace span.
+	Write(ctx context.Context, entry Entry) error
+	// SetLevel sets the minimum level written by the backend. The Logger already drops entries below its level,
+	// so backends may rely on it instead of filtering themselves.
+	SetLevel(level LogLevel)
+	// SetOutput sets the destination of the entries.
+	SetOutput(output io.Writer)
+}
@@ -0,0 +1,83 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingBackend is a Backend keeping the written entries in memory.
+type recordingBackend struct {
+	level   logger.LogLevel
+	output  io.Writer
+	entries []logger.Entry
+}
+
+func (b *recordingBackend) Write(_ context.Context, entry logger.Entry) error {
+	b.entries = append(b.entries, entry)
+	return nil
+}
+
+func (b *recordingBackend) SetLevel(level logger.LogLevel) { b.level = level }
+
+func (b *recordingBackend) SetOutput(output io.Writer) { b.output = output }
+
+func TestLogger_CustomBackend(t *testing.T) {
+	backend := &recordingBackend{}
+	output := &bytes.Buffer{}
+	exitCode := -1
+	enricher := logger.NewHook(func(_ context.Context, entry logger.Entry) error {
+		entry.Fields["enriched"] = true
+		return nil
+	})
+
+	log, err := logger.NewLogger(logger.Config{
+		Level:       logger.INFO,
+		ServiceName: "orders",
+		Output:      output,
+		Backend:     backend,
+		Hooks:       []logger.Hook{enricher},
+		ExitFunc:    func(code int) { exitCode = code },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, logger.INFO, backend.level)
+	assert.Equal(t, output, backend.output)
+
+	ctx := context.Background()
+	testErr := errors.New("boom")
+	log.Debug(ctx, "dropped", nil)
+	log.Info(ctx, "info message", logger.Fields{"key": "value"})
+	log.Fatal(ctx, "fatal message", testErr, nil)
+
+	require.Len(t, backend.entries, 2)
+	assert.Equal(t, logger.INFO, backend.entries[0].Level)
+	assert.Equal(t, "info message", backend.entries[0].Message)
+	assert.Equal(t, "value", backend.entries[0].Fields["key"])
+	assert.Equal(t, "orders", backend.entries[0].Fields[logger.DefaultServiceNameKey])
+	assert.Equal(t, true, backend.entries[0].Fields["enriched"], "hooks should enrich the entry before it is written")
+	assert.False(t, backend.entries[0].Time.IsZero())
+
+	assert.Equal(t, logger.FATAL, backend.entries[1].Level)
+	assert.Equal(t, testErr, backend.entries[1].Error)
+	assert.Equal(t, 1, exitCode, "Fatal should exit after the entry is written")
+	assert.Empty(t, output.String(), "the output is managed by the backend")
+}
+
+func TestFormatterBackend(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:   logger.INFO,
+		Output:  buffer,
+		Backend: logger.NewFormatterBackend(&logger.ECSFormatter{}),
+	})
+	require.NoError(t, err)
+
+	log.Info(context.Background(), "ecs message", nil)
+	assert.Contains(t, buffer.String(), `"ecs.version"`)
+}
@@ -0,0 +1,97 @@
+package logger_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// discardFormatter skips serialization so the benchmarks measure the logger itself.
+type discardFormatter struct{}
+
+func (discardFormatter) Format(context.Context, logger.Entry) ([]byte, error) { return nil, nil }
+
+func newBenchmarkLogger(b *testing.B) logger.Logger {
+	b.Helper()
+	log, err := logger.NewLogger(logger.Config{
+		Level:       logger.INFO,
+		Formatter:   discardFormatter{},
+		Environment: "production",
+		ServiceName: "benchmark",
+		Output:      io.Discard,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return log
+}
+
+func BenchmarkLogger_InfoNoFields(b *testing.B) {
+	log := newBenchmarkLogger(b)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info(ctx, "benchmark message", nil)
+	}
+}
+
+func BenchmarkLogger_InfoWithFields(b *testing.B) {
+	log := newBenchmarkLogger(b)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info(ctx, "benchmark message", logger.Fields{"user_id": 12345, "path": "/orders"})
+	}
+}
+
+func BenchmarkLogger_InfoPersistentFields(b *testing.B) {
+	log := newBenchmarkLogger(b).WithFields(logger.Fields{"component": "orders", "request_id": "abc"})
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info(ctx, "benchmark message", nil)
+	}
+}
+
+func BenchmarkLogger_ErrorNoFields(b *testing.B) {
+	log := newBenchmarkLogger(b)
+	ctx := context.Background()
+	err := errors.New("benchmark error")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Error(ctx, "benchmark message", err, nil)
+	}
+}
+
+func BenchmarkLogger_DisabledLevel(b *testing.B) {
+	log := newBenchmarkLogger(b)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Debug(ctx, "benchmark message", logger.Fields{"user_id": 12345})
+	}
+}
+
+func BenchmarkLogger_StructuredJSONFormatter(b *testing.B) {
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Output: io.Discard,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info(ctx, "benchmark message", logger.Fields{"user_id": 12345})
+	}
+}
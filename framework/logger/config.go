@@ -1,38 +1,53 @@
 package logger
 
-import "github.com/sirupsen/logrus"
-
 type LogLevel string
 
 const (
+	TRACE LogLevel = "trace"
 	DEBUG LogLevel = "debug"
 	INFO  LogLevel = "info"
 	WARN  LogLevel = "warn"
 	ERROR LogLevel = "error"
 	FATAL LogLevel = "fatal"
+	PANIC LogLevel = "panic"
 )
 
-var logrusLevelMapper = map[LogLevel]logrus.Level{
-	DEBUG: logrus.DebugLevel,
-	INFO:  logrus.InfoLevel,
-	WARN:  logrus.WarnLevel,
-	ERROR: logrus.ErrorLevel,
-	FATAL: logrus.FatalLevel,
+// levelOrderMapper positions the built-in levels by increasing severity.
+// Custom levels are placed between them (see RegisterLevel).
+var levelOrderMapper = map[LogLevel]int{
+	TRACE: 100,
+	DEBUG: 200,
+	INFO:  300,
+	WARN:  400,
+	ERROR: 500,
+	FATAL: 600,
+	PANIC: 700,
 }
 
-func (l LogLevel) ToLogrusLevel() logrus.Level {
-	if level, ok := logrusLevelMapper[l]; ok {
-		return level
+// IsValid reports whether l is a built-in or a registered custom level.
+func (l LogLevel) IsValid() bool {
+	if _, ok := levelOrderMapper[l]; ok {
+		return true
 	}
-	// Default to InfoLevel if unknown
-	return logrus.InfoLevel
+	_, ok := lookupCustomLevel(l)
+	return ok
 }
 
-func (l LogLevel) IsValid() bool {
-	_, ok := logrusLevelMapper[l]
-	return ok
+// Order returns the position of l in the severity ordering, or -1 if l is unknown.
+// The built-in levels are ordered TRACE (100), DEBUG (200), INFO (300), WARN (400), ERROR (500), FATAL (600) and PANIC (700).
+func (l LogLevel) Order() int {
+	if order, ok := levelOrderMapper[l]; ok {
+		return order
+	}
+	if custom, ok := lookupCustomLevel(l); ok {
+		return custom.Order
+	}
+	return -1
 }
 
+// AllLevels is a list of all built-in log levels, ordered by increasing severity.
+var AllLevels = []LogLevel{TRACE, DEBUG, INFO, WARN, ERROR, FATAL, PANIC}
+
 const (
 	// DefaultEnvironmentKey is the default key used for the environment field in logs.
 	DefaultEnvironmentKey = "environment"
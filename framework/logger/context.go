@@ -9,12 +9,12 @@ type contextKey struct{}
 
 var loggerKey = &contextKey{}
 
-// FromContext retrieves the Logger from the context. It returns a default logger if the context doesn't have one.
+// FromContext retrieves the Logger from the context. It returns the global logger (see L) if the context doesn't have one.
 func FromContext(ctx context.Context) Logger {
 	if logger, ok := ctx.Value(loggerKey).(Logger); ok {
 		return logger
 	}
-	return NewDefaultLogger()
+	return L()
 }
 
 // FromRequest retrieves the Logger from the HTTP request's context.
@@ -31,3 +31,21 @@ func NewContext(ctx context.Context, logger Logger) context.Context {
 func NewRequest(r *http.Request, logger Logger) *http.Request {
 	return r.WithContext(NewContext(r.Context(), logger))
 }
+
+type callerSkipContextKey struct{}
+
+// withCallerSkip returns a new Context that carries the caller skip used by the formatters.
+func withCallerSkip(ctx context.Context, skip int) context.Context {
+	return context.WithValue(ctx, callerSkipContextKey{}, skip)
+}
+
+// callerSkipFromContext retrieves the caller skip from the context. It returns 0 if the context doesn't have one.
+func callerSkipFromContext(ctx context.Context) int {
+	if ctx == nil {
+		return 0
+	}
+	if skip, ok := ctx.Value(callerSkipContextKey{}).(int); ok {
+		return skip
+	}
+	return 0
+}
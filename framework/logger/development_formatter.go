@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/kittipat1413/go-common/util/slice"
+)
+
+// DefaultDevFmtTimestampFormat is the default short timestamp format used by the DevelopmentFormatter.
+const DefaultDevFmtTimestampFormat = "15:04:05.000"
+
+// ANSI escape sequences used by the DevelopmentFormatter.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiDim     = "\x1b[2m"
+	ansiRed     = "\x1b[31m"
+	ansiYellow  = "\x1b[33m"
+	ansiBlue    = "\x1b[34m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+	ansiGray    = "\x1b[90m"
+)
+
+// devLevelColorMapper maps the built-in levels to the colors used by the DevelopmentFormatter.
+var devLevelColorMapper = map[LogLevel]string{
+	TRACE: ansiGray,
+	DEBUG: ansiGray,
+	INFO:  ansiBlue,
+	WARN:  ansiYellow,
+	ERROR: ansiRed,
+	FATAL: ansiMagenta,
+	PANIC: ansiMagenta,
+}
+
+/*
+DevelopmentFormatter is a human-friendly formatter intended for local development.
+Each entry is written as a single header line followed by one line per field:
+
+	15:04:05.000 INFO  User logged in  (handler/user.go:42)
+	    request_id = 8f7c...
+	    user       = {
+	                   "id": 12345,
+	                   "roles": ["admin"]
+	                 }
+
+Levels are colorized, field keys are aligned, nested values are pretty-printed as indented JSON,
+and stack traces (error level or higher) are printed on multiple lines.
+
+Example usage:
+
+	logConfig := logger.Config{
+		Level:     logger.DEBUG,
+		Formatter: &logger.DevelopmentFormatter{},
+	}
+*/
+type DevelopmentFormatter struct {
+	// TimestampFormat sets the format used for timestamps. Defaults to DefaultDevFmtTimestampFormat.
+	TimestampFormat string
+	// DisableColors disables ANSI colors, e.g., when the output is not a terminal.
+	DisableColors bool
+	// DisableCaller disables the caller information in the header line.
+	DisableCaller bool
+	// DisableStackTrace disables the stack trace for error levels.
+	DisableStackTrace bool
+	// SkipPackages is a list of packages to skip when searching for the caller.
+	SkipPackages []string
+}
+
+// Format implements the Formatter interface.
+func (f *DevelopmentFormatter) Format(ctx context.Context, entry Entry) ([]byte, error) {
+	timestampFormat := f.TimestampFormat
+	if timestampFormat == "" {
+		timestampFormat = DefaultDevFmtTimestampFormat
+	}
+
+	buf := &bytes.Buffer{}
+
+	// Header: timestamp, level, message and caller.
+	buf.WriteString(f.colorize(ansiDim, entry.Time.Format(timestampFormat)))
+	buf.WriteByte(' ')
+	buf.WriteString(f.colorize(devLevelColorMapper[entry.Level.Base()], fmt.Sprintf("%-5s", strings.ToUpper(levelSeverity(entry.Level)))))
+	buf.WriteByte(' ')
+	buf.WriteString(entry.Message)
+	if !f.DisableCaller {
+		skipPackages := slice.Union(f.SkipPackages, defaultSJsonFmtSkipPackages)
+		_, file, line := getCaller(skipPackages, callerSkipFromContext(ctx))
+		if file != "" && line != 0 {
+			buf.WriteString("  ")
+			buf.WriteString(f.colorize(ansiDim, fmt.Sprintf("(%s:%d)", shortFilePath(file), line)))
+		}
+	}
+	buf.WriteByte('\n')
+
+	// Collect the fields to print, including trace information and the error.
+	fields := make(map[string]interface{}, len(entry.Fields)+3)
+	for key, value := range entry.Fields {
+		fields[key] = value
+	}
+	if chain, ok := entryErrorChain(entry); ok {
+		fields[DefaultSJsonFmtErrorChainKey] = chain
+	}
+	if ctx != nil {
+		traceID, spanID := extractTraceIDs(ctx)
+		if traceID != nil {
+			fields[DefaultSJsonFmtTraceIDKey] = *traceID
+		}
+		if spanID != nil {
+			fields[DefaultSJsonFmtSpanIDKey] = *spanID
+		}
+	}
+
+	keys := make([]string, 0, len(fields))
+	maxKeyLen := 0
+	for key := range fields {
+		keys = append(keys, key)
+		if len(key) > maxKeyLen {
+			maxKeyLen = len(key)
+		}
+	}
+	sort.Strings(keys)
+
+	const indent = "    "
+	for _, key := range keys {
+		color := ansiCyan
+		if key == DefaultErrorKey {
+			color = ansiRed
+		}
+		paddedKey := fmt.Sprintf("%-*s", maxKeyLen, key)
+		// Continuation lines of multi-line values are aligned with the first character of the value.
+		valueIndent := strings.Repeat(" ", len(indent)+maxKeyLen+3)
+		value := strings.ReplaceAll(devFormatValue(fields[key]), "\n", "\n"+valueIndent)
+
+		buf.WriteString(indent)
+		buf.WriteString(f.colorize(color, paddedKey))
+		buf.WriteString(" = ")
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+	}
+
+	// Stack trace for error levels.
+	if !f.DisableStackTrace && isErrorLevel(entry.Level) {
+		buf.WriteString(indent)
+		buf.WriteString(f.colorize(ansiRed, DefaultSJsonFmtStackTraceKey))
+		buf.WriteString(":\n")
+		for _, line := range strings.Split(strings.TrimRight(entryStackTrace(entry), "\n"), "\n") {
+			buf.WriteString(indent + indent)
+			buf.WriteString(f.colorize(ansiDim, line))
+			buf.WriteByte('\n')
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// colorize wraps s with the given ANSI color unless colors are disabled.
+func (f *DevelopmentFormatter) colorize(color, s string) string {
+	if f.DisableColors || color == "" {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+// devFormatValue renders a field value, pretty-printing composite values as indented JSON.
+func devFormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "<nil>"
+	case error:
+		return v.Error()
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		if serialized, err := json.MarshalIndent(value, "", "  "); err == nil {
+			return string(serialized)
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// shortFilePath returns the last directory and the file name of path, e.g., "handler/user.go".
+func shortFilePath(path string) string {
+	dir, file := filepath.Split(path)
+	if dir == "" {
+		return file
+	}
+	return filepath.Join(filepath.Base(dir), file)
+}
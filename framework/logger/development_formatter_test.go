@@ -0,0 +1,71 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevelopmentFormatter(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level: logger.DEBUG,
+		Formatter: &logger.DevelopmentFormatter{
+			DisableColors: true,
+			DisableCaller: true,
+		},
+		Output: buffer,
+	})
+	require.NoError(t, err)
+
+	log.Info(context.Background(), "User logged in", logger.Fields{
+		"request_id": "abc",
+		"user": map[string]interface{}{
+			"id": 12345,
+		},
+	})
+
+	lines := strings.Split(strings.TrimRight(buffer.String(), "\n"), "\n")
+	require.Len(t, lines, 5)
+	assert.Regexp(t, `^\d{2}:\d{2}:\d{2}\.\d{3} INFO  User logged in$`, lines[0])
+	assert.Equal(t, "    request_id = abc", lines[1])
+	assert.Equal(t, "    user       = {", lines[2])
+	assert.Equal(t, `                   "id": 12345`, lines[3])
+	assert.Equal(t, "                 }", lines[4])
+}
+
+func TestDevelopmentFormatter_ErrorWithStackTrace(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:     logger.INFO,
+		Formatter: &logger.DevelopmentFormatter{DisableColors: true},
+		Output:    buffer,
+	})
+	require.NoError(t, err)
+
+	log.Error(context.Background(), "Something failed", errors.New("boom"), nil)
+
+	output := buffer.String()
+	assert.Contains(t, output, "ERROR Something failed")
+	assert.Contains(t, output, "    error = boom\n")
+	assert.Contains(t, output, "    stack_trace:\n        goroutine")
+}
+
+func TestDevelopmentFormatter_Colors(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:     logger.INFO,
+		Formatter: &logger.DevelopmentFormatter{},
+		Output:    buffer,
+	})
+	require.NoError(t, err)
+
+	log.Warn(context.Background(), "Careful", nil)
+	assert.Contains(t, buffer.String(), "\x1b[33mWARN \x1b[0m")
+}
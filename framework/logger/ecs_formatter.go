@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kittipat1413/go-common/util/slice"
+)
+
+const (
+	// ECSVersion is the Elastic Common Schema version the ECSFormatter output conforms to.
+	ECSVersion = "8.11.0"
+	// ecsTimestampFormat is the @timestamp layout (RFC3339 with millisecond precision).
+	ecsTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// Field keys defined by the Elastic Common Schema.
+// See https://www.elastic.co/guide/en/ecs/current/ecs-field-reference.html
+const (
+	ECSFmtTimestampKey          = "@timestamp"
+	ECSFmtMessageKey            = "message"
+	ECSFmtLogLevelKey           = "log.level"
+	ECSFmtVersionKey            = "ecs.version"
+	ECSFmtErrorMessageKey       = "error.message"
+	ECSFmtErrorTypeKey          = "error.type"
+	ECSFmtErrorStackTraceKey    = "error.stack_trace"
+	ECSFmtErrorChainKey         = "error.chain"
+	ECSFmtTraceIDKey            = "trace.id"
+	ECSFmtSpanIDKey             = "span.id"
+	ECSFmtServiceNameKey        = "service.name"
+	ECSFmtServiceEnvironmentKey = "service.environment"
+	ECSFmtOriginFunctionKey     = "log.origin.function"
+	ECSFmtOriginFileNameKey     = "log.origin.file.name"
+	ECSFmtOriginFileLineKey     = "log.origin.file.line"
+)
+
+/*
+ECSFormatter is a formatter that emits entries following the Elastic Common Schema (ECS),
+so that logs shipped to Elasticsearch work out of the box with the standard Kibana dashboards.
+It includes the following fields:
+  - @timestamp: The log timestamp in RFC3339 format with milliseconds.
+  - log.level: The log level (e.g., info, debug, error).
+  - message: The log message.
+  - ecs.version: The ECS version.
+  - error.message, error.type: The error message and type if present.
+  - error.chain: The type and message of every error wrapped by the error, if it wraps any.
+  - error.stack_trace: The stack trace for error levels, taken from the error if it carries one.
+  - trace.id, span.id: The trace and span IDs if available.
+  - service.name, service.environment: The service name and environment from the logger configuration.
+  - log.origin.function, log.origin.file.name, log.origin.file.line: The caller's function name, file, and line number.
+
+Example usage:
+
+	logConfig := logger.Config{
+		Level:       logger.INFO,
+		Formatter:   &logger.ECSFormatter{},
+		ServiceName: "my-service",
+	}
+*/
+type ECSFormatter struct {
+	// PrettyPrint will indent all JSON logs.
+	PrettyPrint bool
+	// SkipPackages is a list of packages to skip when searching for the caller.
+	SkipPackages []string
+}
+
+// ecsFieldMapper maps the logger's built-in field keys to their ECS equivalents.
+var ecsFieldMapper = map[string]string{
+	DefaultServiceNameKey: ECSFmtServiceNameKey,
+	DefaultEnvironmentKey: ECSFmtServiceEnvironmentKey,
+}
+
+// Format implements the Formatter interface.
+func (f *ECSFormatter) Format(ctx context.Context, entry Entry) ([]byte, error) {
+	data := make(Fields, len(entry.Fields)+10)
+
+	for key, value := range entry.Fields {
+		if key == DefaultErrorKey {
+			continue // Skip the default error key
+		}
+		if ecsKey, ok := ecsFieldMapper[key]; ok {
+			key = ecsKey
+		}
+		switch v := value.(type) {
+		case error:
+			data[key] = v.Error()
+		default:
+			data[key] = v
+		}
+	}
+
+	data[ECSFmtTimestampKey] = entry.Time.UTC().Format(ecsTimestampFormat)
+	data[ECSFmtLogLevelKey] = entrySeverity(entry)
+	data[ECSFmtMessageKey] = entry.Message
+	data[ECSFmtVersionKey] = ECSVersion
+
+	// Include error message and type if present.
+	if err, ok := entry.Fields[DefaultErrorKey]; ok {
+		switch e := err.(type) {
+		case error:
+			data[ECSFmtErrorMessageKey] = e.Error()
+			data[ECSFmtErrorTypeKey] = fmt.Sprintf("%T", e)
+		default:
+			data[ECSFmtErrorMessageKey] = fmt.Sprintf("%v", e)
+		}
+	}
+	if chain, ok := entryErrorChain(entry); ok {
+		data[ECSFmtErrorChainKey] = chain
+	}
+
+	// Include trace and span IDs if available.
+	if ctx != nil {
+		traceID, spanID := extractTraceIDs(ctx)
+		if traceID != nil {
+			data[ECSFmtTraceIDKey] = *traceID
+		}
+		if spanID != nil {
+			data[ECSFmtSpanIDKey] = *spanID
+		}
+	}
+
+	// Caller's function name, file, and line number.
+	skipPackages := slice.Union(f.SkipPackages, defaultSJsonFmtSkipPackages)
+	function, file, line := getCaller(skipPackages, callerSkipFromContext(ctx))
+	if function != "" && file != "" && line != 0 {
+		data[ECSFmtOriginFunctionKey] = function
+		data[ECSFmtOriginFileNameKey] = file
+		data[ECSFmtOriginFileLineKey] = line
+	}
+
+	// Stack trace for error levels.
+	if isErrorLevel(entry.Level) {
+		data[ECSFmtErrorStackTraceKey] = entryStackTrace(entry)
+	}
+
+	// Serialize the data to JSON.
+	var serialized []byte
+	var err error
+	if f.PrettyPrint {
+		serialized, err = json.MarshalIndent(data, "", "  ")
+	} else {
+		serialized, err = json.Marshal(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON: %v", err)
+	}
+	return append(serialized, '\n'), nil
+}
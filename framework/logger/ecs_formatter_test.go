@@ -0,0 +1,70 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestECSFormatter(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:       logger.INFO,
+		Formatter:   &logger.ECSFormatter{},
+		Environment: "production",
+		ServiceName: "my-service",
+		Output:      buffer,
+	})
+	require.NoError(t, err)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	log.Info(ctx, "info message", logger.Fields{"custom_key": "custom_value"})
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+
+	assert.Equal(t, "info", logEntry[logger.ECSFmtLogLevelKey])
+	assert.Equal(t, "info message", logEntry[logger.ECSFmtMessageKey])
+	assert.Equal(t, logger.ECSVersion, logEntry[logger.ECSFmtVersionKey])
+	assert.Contains(t, logEntry, logger.ECSFmtTimestampKey)
+	assert.Equal(t, "my-service", logEntry[logger.ECSFmtServiceNameKey])
+	assert.Equal(t, "production", logEntry[logger.ECSFmtServiceEnvironmentKey])
+	assert.NotContains(t, logEntry, logger.DefaultServiceNameKey)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", logEntry[logger.ECSFmtTraceIDKey])
+	assert.Equal(t, "00f067aa0ba902b7", logEntry[logger.ECSFmtSpanIDKey])
+	assert.Equal(t, "custom_value", logEntry["custom_key"])
+	assert.Contains(t, logEntry, logger.ECSFmtOriginFunctionKey)
+	assert.NotContains(t, logEntry, logger.ECSFmtErrorStackTraceKey)
+}
+
+func TestECSFormatter_Error(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:     logger.INFO,
+		Formatter: &logger.ECSFormatter{},
+		Output:    buffer,
+	})
+	require.NoError(t, err)
+
+	log.Error(context.Background(), "error message", errors.New("boom"), nil)
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+	assert.Equal(t, "error", logEntry[logger.ECSFmtLogLevelKey])
+	assert.Equal(t, "boom", logEntry[logger.ECSFmtErrorMessageKey])
+	assert.Equal(t, "*errors.errorString", logEntry[logger.ECSFmtErrorTypeKey])
+	assert.Contains(t, logEntry, logger.ECSFmtErrorStackTraceKey)
+}
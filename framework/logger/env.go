@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Environment variables read by ConfigFromEnv.
+const (
+	EnvLogLevel    = "LOG_LEVEL"
+	EnvLogFormat   = "LOG_FORMAT"
+	EnvLogOutput   = "LOG_OUTPUT"
+	EnvServiceName = "SERVICE_NAME"
+	EnvEnvironment = "ENVIRONMENT"
+	// EnvGCPProject is used as the GCPFormatter project ID when LOG_FORMAT is "gcp".
+	EnvGCPProject = "GOOGLE_CLOUD_PROJECT"
+)
+
+// Log formats supported by the LOG_FORMAT environment variable.
+const (
+	LogFormatJSON    = "json"
+	LogFormatConsole = "console"
+	LogFormatGCP     = "gcp"
+	LogFormatECS     = "ecs"
+)
+
+var (
+	// ErrInvalidLogFormat is returned when LOG_FORMAT is not a supported format.
+	ErrInvalidLogFormat = errors.New("invalid log format")
+	// ErrInvalidLogOutput is returned when LOG_OUTPUT cannot be opened.
+	ErrInvalidLogOutput = errors.New("invalid log output")
+)
+
+/*
+ConfigFromEnv builds a validated Config from the following environment variables:
+  - LOG_LEVEL: the minimum level, e.g., "debug" (defaults to "info").
+  - LOG_FORMAT: "json" (StructuredJSONFormatter, default), "console" (DevelopmentFormatter), "gcp" (GCPFormatter) or "ecs" (ECSFormatter).
+  - LOG_OUTPUT: "stdout" (default), "stderr" or the path of a file entries are appended to.
+  - SERVICE_NAME: the service name added to every entry.
+  - ENVIRONMENT: the environment added to every entry.
+
+With the "gcp" format, GOOGLE_CLOUD_PROJECT is used as the project ID of the trace resource names.
+
+Example usage:
+
+	config, err := logger.ConfigFromEnv()
+	if err != nil {
+		// Handle error
+	}
+	log, err := logger.NewLogger(config)
+*/
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		Level:       INFO,
+		ServiceName: strings.TrimSpace(os.Getenv(EnvServiceName)),
+		Environment: strings.TrimSpace(os.Getenv(EnvEnvironment)),
+	}
+
+	if level := strings.TrimSpace(os.Getenv(EnvLogLevel)); level != "" {
+		config.Level = LogLevel(level)
+		if !config.Level.IsValid() {
+			config.Level = LogLevel(strings.ToLower(level))
+		}
+		if !config.Level.IsValid() {
+			return Config{}, fmt.Errorf("%w: %s=%q", ErrInvalidLogLevel, EnvLogLevel, level)
+		}
+	}
+
+	formatter, err := formatterFromEnv(os.Getenv(EnvLogFormat))
+	if err != nil {
+		return Config{}, err
+	}
+	config.Formatter = formatter
+
+	output := strings.TrimSpace(os.Getenv(EnvLogOutput))
+	switch strings.ToLower(output) {
+	case "", "stdout":
+		config.Output = os.Stdout
+	case "stderr":
+		config.Output = os.Stderr
+	default:
+		file, err := os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: %s=%q: %v", ErrInvalidLogOutput, EnvLogOutput, output, err)
+		}
+		config.Output = file
+	}
+
+	return config, nil
+}
+
+// formatterFromEnv returns the formatter of the LOG_FORMAT value.
+func formatterFromEnv(format string) (Formatter, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", LogFormatJSON:
+		return &StructuredJSONFormatter{
+			TimestampFormat:   time.RFC3339,
+			FieldKeyFormatter: NoopFieldKeyFormatter,
+		}, nil
+	case LogFormatConsole:
+		return &DevelopmentFormatter{}, nil
+	case LogFormatGCP:
+		return &GCPFormatter{ProjectID: strings.TrimSpace(os.Getenv(EnvGCPProject))}, nil
+	case LogFormatECS:
+		return &ECSFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s=%q", ErrInvalidLogFormat, EnvLogFormat, format)
+	}
+}
@@ -0,0 +1,104 @@
+package logger_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFromEnv_Defaults(t *testing.T) {
+	for _, key := range []string{logger.EnvLogLevel, logger.EnvLogFormat, logger.EnvLogOutput, logger.EnvServiceName, logger.EnvEnvironment} {
+		t.Setenv(key, "")
+	}
+
+	config, err := logger.ConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, logger.INFO, config.Level)
+	assert.IsType(t, &logger.StructuredJSONFormatter{}, config.Formatter)
+	assert.Equal(t, os.Stdout, config.Output)
+	assert.Empty(t, config.ServiceName)
+	assert.Empty(t, config.Environment)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		format    string
+		formatter interface{}
+	}{
+		{format: "json", formatter: &logger.StructuredJSONFormatter{}},
+		{format: "Console", formatter: &logger.DevelopmentFormatter{}},
+		{format: "gcp", formatter: &logger.GCPFormatter{}},
+		{format: "ecs", formatter: &logger.ECSFormatter{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			t.Setenv(logger.EnvLogLevel, "DEBUG")
+			t.Setenv(logger.EnvLogFormat, tt.format)
+			t.Setenv(logger.EnvLogOutput, "stderr")
+			t.Setenv(logger.EnvServiceName, "orders")
+			t.Setenv(logger.EnvEnvironment, "staging")
+			t.Setenv(logger.EnvGCPProject, "my-project")
+
+			config, err := logger.ConfigFromEnv()
+			require.NoError(t, err)
+			assert.Equal(t, logger.DEBUG, config.Level)
+			assert.IsType(t, tt.formatter, config.Formatter)
+			assert.Equal(t, os.Stderr, config.Output)
+			assert.Equal(t, "orders", config.ServiceName)
+			assert.Equal(t, "staging", config.Environment)
+			if gcp, ok := config.Formatter.(*logger.GCPFormatter); ok {
+				assert.Equal(t, "my-project", gcp.ProjectID)
+			}
+
+			_, err = logger.NewLogger(config)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestConfigFromEnv_FileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	t.Setenv(logger.EnvLogLevel, "")
+	t.Setenv(logger.EnvLogFormat, "")
+	t.Setenv(logger.EnvLogOutput, path)
+
+	config, err := logger.ConfigFromEnv()
+	require.NoError(t, err)
+	file, ok := config.Output.(*os.File)
+	require.True(t, ok)
+	defer file.Close()
+
+	log, err := logger.NewLogger(config)
+	require.NoError(t, err)
+	log.Info(context.Background(), "written to file", nil)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "written to file")
+}
+
+func TestConfigFromEnv_Invalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		expectedErr error
+	}{
+		{name: "invalid level", env: map[string]string{logger.EnvLogLevel: "verbose"}, expectedErr: logger.ErrInvalidLogLevel},
+		{name: "invalid format", env: map[string]string{logger.EnvLogFormat: "xml"}, expectedErr: logger.ErrInvalidLogFormat},
+		{name: "invalid output", env: map[string]string{logger.EnvLogOutput: filepath.Join(t.TempDir(), "missing", "app.log")}, expectedErr: logger.ErrInvalidLogOutput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{logger.EnvLogLevel, logger.EnvLogFormat, logger.EnvLogOutput} {
+				t.Setenv(key, tt.env[key])
+			}
+			_, err := logger.ConfigFromEnv()
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
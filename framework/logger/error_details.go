@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// maxErrorChainLength bounds the number of errors serialized from an error chain.
+const maxErrorChainLength = 32
+
+// errorDetail describes one error of an error chain.
+type errorDetail struct {
+	Label   string `json:"label,omitempty"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// errorChain returns the type and message of err and of every error it wraps, outermost first.
+// Errors joined with errors.Join are walked depth-first. The errors of a framework/errors.MultiError
+// are labelled with their label.
+func errorChain(err error) []errorDetail {
+	var chain []errorDetail
+	var walk func(label string, err error)
+	walk = func(label string, err error) {
+		for err != nil && len(chain) < maxErrorChainLength {
+			chain = append(chain, errorDetail{Label: label, Type: fmt.Sprintf("%T", err), Message: err.Error()})
+			label = ""
+			switch e := err.(type) {
+			case interface{ Unwrap() []error }:
+				inners := e.Unwrap()
+				var labels []string
+				if labeler, ok := err.(interface{ Labels() []string }); ok {
+					labels = labeler.Labels()
+				}
+				for i, inner := range inners {
+					var innerLabel string
+					if i < len(labels) {
+						innerLabel = labels[i]
+					}
+					walk(innerLabel, inner)
+				}
+				return
+			case interface{ Unwrap() error }:
+				err = e.Unwrap()
+			default:
+				return
+			}
+		}
+	}
+	walk("", err)
+	return chain
+}
+
+// entryErrorChain returns the error chain of the entry's error if it wraps other errors.
+func entryErrorChain(entry Entry) ([]errorDetail, bool) {
+	err, ok := entry.Fields[DefaultErrorKey].(error)
+	if !ok {
+		return nil, false
+	}
+	chain := errorChain(err)
+	return chain, len(chain) > 1
+}
+
+// entryStackTrace returns the stack trace of where the entry's error originated if the error carries one
+// (see framework/errors.WithStack and framework/errors.CodedError, or github.com/pkg/errors), otherwise the
+// stack trace of the logging call.
+func entryStackTrace(entry Entry) string {
+	if err, ok := entry.Fields[DefaultErrorKey].(error); ok {
+		if stack, ok := errorStackTrace(err); ok {
+			return stack
+		}
+	}
+	return getStackTrace()
+}
+
+// errorStackTrace returns the stack trace carried by the innermost error of the chain of err that has one.
+// Stack traces of framework/errors are preferred to the ones of other packages, e.g., a CodedError wrapping
+// an error of github.com/pkg/errors reports where the CodedError was created.
+func errorStackTrace(err error) (string, bool) {
+	var pcs, foreign []uintptr
+	for _, e := range unwrapAll(err) {
+		if tracer, ok := e.(interface{ StackTrace() []uintptr }); ok {
+			pcs = tracer.StackTrace()
+		} else if stack, ok := errorStack(e); ok {
+			foreign = stack
+		}
+	}
+	if len(pcs) == 0 {
+		pcs = foreign
+	}
+	if len(pcs) == 0 {
+		return "", false
+	}
+
+	var sb strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" {
+			fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return sb.String(), true
+}
+
+// unwrapAll returns err and every error it wraps, outermost first.
+func unwrapAll(err error) []error {
+	var errs []error
+	for err != nil && len(errs) < maxErrorChainLength {
+		errs = append(errs, err)
+		unwrapper, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		err = unwrapper.Unwrap()
+	}
+	return errs
+}
+
+// errorStack returns the program counters carried by err. Errors are expected to implement StackTrace()
+// returning a slice of program counters, e.g., errors.StackTrace (github.com/pkg/errors).
+func errorStack(err error) ([]uintptr, bool) {
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() {
+		return nil, false
+	}
+	methodType := method.Type()
+	if methodType.NumIn() != 0 || methodType.NumOut() != 1 ||
+		methodType.Out(0).Kind() != reflect.Slice || methodType.Out(0).Elem().Kind() != reflect.Uintptr {
+		return nil, false
+	}
+	stack := method.Call(nil)[0]
+	pcs := make([]uintptr, stack.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(stack.Index(i).Uint())
+	}
+	return pcs, true
+}
@@ -0,0 +1,158 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pkgFrame and pkgStackTrace mimic the github.com/pkg/errors stack trace types.
+type pkgFrame uintptr
+
+type pkgStackTrace []pkgFrame
+
+type pkgStyleError struct {
+	msg   string
+	stack []uintptr
+}
+
+func (e *pkgStyleError) Error() string { return e.msg }
+
+func (e *pkgStyleError) StackTrace() pkgStackTrace {
+	frames := make(pkgStackTrace, len(e.stack))
+	for i, pc := range e.stack {
+		frames[i] = pkgFrame(pc)
+	}
+	return frames
+}
+
+//go:noinline
+func originOfFrameworkError() error {
+	return domain_error.WithStack(errors.New("connection refused"))
+}
+
+//go:noinline
+func originOfPkgStyleError() error {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(1, pcs)
+	return &pkgStyleError{msg: "connection refused", stack: pcs[:n]}
+}
+
+func TestStructuredJSONFormatter_ErrorDetails(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectOrigin string
+	}{
+		{name: "framework errors", err: originOfFrameworkError(), expectOrigin: "originOfFrameworkError"},
+		{name: "pkg/errors style", err: originOfPkgStyleError(), expectOrigin: "originOfPkgStyleError"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			log, err := logger.NewLogger(logger.Config{Level: logger.INFO, Output: buffer})
+			require.NoError(t, err)
+
+			wrapped := fmt.Errorf("get user: %w", tt.err)
+			log.Error(context.Background(), "failed to get user", wrapped, nil)
+
+			var logEntry map[string]interface{}
+			require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+			assert.Equal(t, "get user: connection refused", logEntry[logger.DefaultSJsonFmtErrorKey])
+
+			chain, ok := logEntry[logger.DefaultSJsonFmtErrorChainKey].([]interface{})
+			require.True(t, ok)
+			require.GreaterOrEqual(t, len(chain), 2)
+			assert.Equal(t, "get user: connection refused", chain[0].(map[string]interface{})["message"])
+			assert.Equal(t, "*fmt.wrapError", chain[0].(map[string]interface{})["type"])
+
+			stackTrace, ok := logEntry[logger.DefaultSJsonFmtStackTraceKey].(string)
+			require.True(t, ok)
+			firstLine := strings.SplitN(stackTrace, "\n", 2)[0]
+			assert.Contains(t, firstLine, tt.expectOrigin, "stack trace should start where the error originated")
+		})
+	}
+}
+
+//go:noinline
+func originOfCodedError() error {
+	return domain_error.Unavailable("user.store_unavailable", "user store unavailable").Wrap(originOfPkgStyleError())
+}
+
+func TestStructuredJSONFormatter_PrefersFrameworkStackTraces(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{Level: logger.INFO, Output: buffer})
+	require.NoError(t, err)
+
+	log.Error(context.Background(), "failed to get user", originOfCodedError(), nil)
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+	stackTrace, ok := logEntry[logger.DefaultSJsonFmtStackTraceKey].(string)
+	require.True(t, ok)
+	firstLine := strings.SplitN(stackTrace, "\n", 2)[0]
+	assert.Contains(t, firstLine, "originOfCodedError", "stack trace should start where the coded error was created")
+}
+
+func TestStructuredJSONFormatter_ErrorChainJoined(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{Level: logger.INFO, Output: buffer})
+	require.NoError(t, err)
+
+	log.Error(context.Background(), "cleanup failed", errors.Join(errors.New("close file"), errors.New("remove dir")), nil)
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+	chain, ok := logEntry[logger.DefaultSJsonFmtErrorChainKey].([]interface{})
+	require.True(t, ok)
+	require.Len(t, chain, 3)
+	assert.Equal(t, "close file", chain[1].(map[string]interface{})["message"])
+	assert.Equal(t, "remove dir", chain[2].(map[string]interface{})["message"])
+	assert.Contains(t, logEntry[logger.DefaultSJsonFmtStackTraceKey], "TestStructuredJSONFormatter_ErrorChainJoined",
+		"errors without stack trace should report the logging call")
+}
+
+func TestStructuredJSONFormatter_ErrorChainMultiError(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{Level: logger.INFO, Output: buffer})
+	require.NoError(t, err)
+
+	var errs domain_error.MultiError
+	errs.Add("user-1", errors.New("not found"))
+	errs.Add("user-2", fmt.Errorf("load: %w", errors.New("timeout")))
+	log.Error(context.Background(), "sync failed", errs.Err(), nil)
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+	assert.Equal(t, "user-1: not found; user-2: load: timeout", logEntry[logger.DefaultSJsonFmtErrorKey])
+	chain, ok := logEntry[logger.DefaultSJsonFmtErrorChainKey].([]interface{})
+	require.True(t, ok)
+	require.Len(t, chain, 4)
+	assert.Equal(t, "*errors.MultiError", chain[0].(map[string]interface{})["type"])
+	assert.NotContains(t, chain[0], "label")
+	assert.Equal(t, map[string]interface{}{"label": "user-1", "type": "*errors.errorString", "message": "not found"}, chain[1])
+	assert.Equal(t, map[string]interface{}{"label": "user-2", "type": "*fmt.wrapError", "message": "load: timeout"}, chain[2])
+	assert.NotContains(t, chain[3], "label", "only the errors of the multi-error should be labelled")
+}
+
+func TestStructuredJSONFormatter_NoErrorChainForUnwrappedErrors(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{Level: logger.INFO, Output: buffer})
+	require.NoError(t, err)
+
+	log.Error(context.Background(), "failed", errors.New("plain"), nil)
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+	assert.NotContains(t, logEntry, logger.DefaultSJsonFmtErrorChainKey)
+}
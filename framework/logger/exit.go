@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// DefaultExitFlushTimeout is the maximum time spent flushing hooks and outputs before Fatal exits.
+const DefaultExitFlushTimeout = 5 * time.Second
+
+/*
+Flusher is implemented by hooks, backends and outputs that buffer entries (e.g., OTLPSink).
+Before Fatal exits, ForceFlush is called on every configured hook and on the backend and output implementing it,
+so that buffered entries, including the fatal one, are not lost.
+
+Outputs implementing Flush() error (e.g., *bufio.Writer) or Sync() error (e.g., *os.File) are flushed as well.
+*/
+type Flusher interface {
+	ForceFlush(ctx context.Context) error
+}
+
+// newExitFunc returns the exit function called by Fatal. It flushes the hooks, the backend and the output, then calls exitFunc.
+func newExitFunc(exitFunc func(int), hooks []Hook, output io.Writer, backend Backend) func(int) {
+	if exitFunc == nil {
+		exitFunc = os.Exit
+	}
+	return func(code int) {
+		flushOnExit(hooks, output, backend)
+		exitFunc(code)
+	}
+}
+
+// flushOnExit flushes the buffered hooks, backend and output, reporting failures to stderr since the logger is exiting.
+func flushOnExit(hooks []Hook, output io.Writer, backend Backend) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultExitFlushTimeout)
+	defer cancel()
+
+	if err := flushAll(ctx, hooks, output, backend); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to flush logger on exit: %v\n", err)
+	}
+}
+
+// flushAll flushes the buffered backend, hooks and output, and returns their errors.
+func flushAll(ctx context.Context, hooks []Hook, output io.Writer, backend Backend) error {
+	var errs []error
+	if flusher, ok := backend.(Flusher); ok {
+		if err := flusher.ForceFlush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush log backend: %w", err))
+		}
+	}
+
+	for _, hook := range hooks {
+		if flusher, ok := hook.(Flusher); ok {
+			if err := flusher.ForceFlush(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("failed to flush log hook: %w", err))
+			}
+		}
+	}
+
+	if err := flushWriter(ctx, output); err != nil {
+		errs = append(errs, fmt.Errorf("failed to flush log output: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+/*
+Flush flushes the buffered hooks, backend and output of l, e.g., on shutdown, so that buffered entries are not lost
+when the process exits. It does nothing if l does not implement Flusher, e.g., a no-op logger.
+
+Example usage:
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := logger.Flush(ctx, log); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to flush logs: %v\n", err)
+	}
+*/
+func Flush(ctx context.Context, l Logger) error {
+	if flusher, ok := l.(Flusher); ok {
+		return flusher.ForceFlush(ctx)
+	}
+	return nil
+}
+
+// ForceFlush flushes the buffered hooks, backend and output of the logger. It implements the Flusher interface.
+func (l *logger) ForceFlush(ctx context.Context) error {
+	return flushAll(ctx, l.flushHooks, l.output, l.backend)
+}
+
+// flushWriter flushes w if it implements Flusher, Flush() error or Sync() error.
+func flushWriter(ctx context.Context, w io.Writer) error {
+	var err error
+	switch f := w.(type) {
+	case Flusher:
+		err = f.ForceFlush(ctx)
+	case interface{ Flush() error }:
+		err = f.Flush()
+	case interface{ Sync() error }:
+		err = f.Sync()
+	}
+	// Syncing a terminal or a pipe is not supported, which is not worth reporting.
+	if w == os.Stdout || w == os.Stderr {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flushingHook records the entries it receives and whether it was flushed.
+type flushingHook struct {
+	fired   []string
+	flushed []string
+}
+
+func (h *flushingHook) Levels() []logger.LogLevel { return nil }
+
+func (h *flushingHook) Fire(_ context.Context, entry logger.Entry) error {
+	h.fired = append(h.fired, entry.Message)
+	return nil
+}
+
+func (h *flushingHook) ForceFlush(context.Context) error {
+	h.flushed = append(h.flushed, h.fired...)
+	return nil
+}
+
+// flushingWriter is an output buffering writes until Flush is called.
+type flushingWriter struct {
+	pending bytes.Buffer
+	out     bytes.Buffer
+}
+
+func (w *flushingWriter) Write(p []byte) (int, error) { return w.pending.Write(p) }
+
+func (w *flushingWriter) Flush() error {
+	_, err := w.pending.WriteTo(&w.out)
+	return err
+}
+
+func TestLogger_FatalExitFunc(t *testing.T) {
+	hook := &flushingHook{}
+	output := &flushingWriter{}
+	exitCode := -1
+	var flushedBeforeExit []string
+	var writtenBeforeExit string
+
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Output: output,
+		Hooks:  []logger.Hook{hook},
+		ExitFunc: func(code int) {
+			exitCode = code
+			flushedBeforeExit = append(flushedBeforeExit, hook.flushed...)
+			writtenBeforeExit = output.out.String()
+		},
+	})
+	require.NoError(t, err)
+
+	log.Fatal(context.Background(), "fatal message", errors.New("boom"), nil)
+
+	assert.Equal(t, 1, exitCode)
+	assert.Equal(t, []string{"fatal message"}, flushedBeforeExit, "hooks should be flushed before exiting")
+	assert.Contains(t, writtenBeforeExit, "fatal message", "output should be flushed before exiting")
+}
+
+func TestTestLogger_FatalDoesNotExit(t *testing.T) {
+	log, recorder := logger.NewTestLogger()
+
+	log.Fatal(context.Background(), "fatal message", errors.New("boom"), nil)
+
+	recorder.AssertLogged(t, logger.FATAL, "fatal message")
+}
+
+func TestFlush(t *testing.T) {
+	hook := &flushingHook{}
+	output := &flushingWriter{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Output: output,
+		Hooks:  []logger.Hook{hook},
+	})
+	require.NoError(t, err)
+
+	log.Named("shutdown").Info(context.Background(), "draining", nil)
+	assert.Empty(t, output.out.String())
+
+	require.NoError(t, logger.Flush(context.Background(), log))
+	assert.Equal(t, []string{"draining"}, hook.flushed)
+	assert.Contains(t, output.out.String(), "draining")
+
+	assert.NoError(t, logger.Flush(context.Background(), logger.NewNoopLogger()))
+}
@@ -0,0 +1,58 @@
+package logger
+
+import "context"
+
+/*
+ContextExtractor returns fields extracted from the context of an entry, such as the request ID,
+the authenticated user or the tenant. Extractors are registered with Config.ContextExtractors
+and run for every entry that is written, so call sites do not have to add these fields themselves.
+
+Example usage:
+
+	requestIDExtractor := func(ctx context.Context) logger.Fields {
+		if requestID, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return logger.Fields{"request_id": requestID}
+		}
+		return nil
+	}
+*/
+type ContextExtractor func(ctx context.Context) Fields
+
+/*
+ContextValueExtractor returns a ContextExtractor that writes the context value stored under key as field,
+if the value is present and not nil.
+
+Example usage:
+
+	logConfig := logger.Config{
+		Level: logger.INFO,
+		ContextExtractors: []logger.ContextExtractor{
+			logger.ContextValueExtractor(tenantKey{}, "tenant_id"),
+		},
+	}
+*/
+func ContextValueExtractor(key interface{}, field string) ContextExtractor {
+	return func(ctx context.Context) Fields {
+		if value := ctx.Value(key); value != nil {
+			return Fields{field: value}
+		}
+		return nil
+	}
+}
+
+// extractFields returns the fields extracted from ctx by the configured extractors.
+func (l *logger) extractFields(ctx context.Context) Fields {
+	if ctx == nil || len(l.extractors) == 0 {
+		return nil
+	}
+	var extracted Fields
+	for _, extractor := range l.extractors {
+		for k, v := range extractor(ctx) {
+			if extracted == nil {
+				extracted = make(Fields)
+			}
+			extracted[k] = v
+		}
+	}
+	return extracted
+}
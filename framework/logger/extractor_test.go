@@ -0,0 +1,67 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type requestIDKey struct{}
+
+type tenantKey struct{}
+
+func TestLogger_ContextExtractors(t *testing.T) {
+	recorder := &logger.Recorder{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Output: &bytes.Buffer{},
+		Hooks:  []logger.Hook{recorder},
+		ContextExtractors: []logger.ContextExtractor{
+			func(ctx context.Context) logger.Fields {
+				if requestID, ok := ctx.Value(requestIDKey{}).(string); ok {
+					return logger.Fields{"request_id": requestID, "tenant_id": "from-request"}
+				}
+				return nil
+			},
+			logger.ContextValueExtractor(tenantKey{}, "tenant_id"),
+		},
+	})
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-123")
+	ctx = context.WithValue(ctx, tenantKey{}, "acme")
+
+	log.Info(ctx, "with context values", nil)
+	log.Info(ctx, "caller fields win", logger.Fields{"request_id": "explicit"})
+	log.Info(context.Background(), "without context values", nil)
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "req-123", entries[0].Fields["request_id"])
+	assert.Equal(t, "acme", entries[0].Fields["tenant_id"], "later extractors should override earlier ones")
+	assert.Equal(t, "explicit", entries[1].Fields["request_id"])
+	assert.NotContains(t, entries[2].Fields, "request_id")
+	assert.NotContains(t, entries[2].Fields, "tenant_id")
+}
+
+func TestLogger_ContextExtractorsSkippedForDisabledLevels(t *testing.T) {
+	called := false
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Output: &bytes.Buffer{},
+		ContextExtractors: []logger.ContextExtractor{
+			func(ctx context.Context) logger.Fields {
+				called = true
+				return nil
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	log.Debug(context.Background(), "disabled", nil)
+	assert.False(t, called)
+}
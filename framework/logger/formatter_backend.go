@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+/*
+Formatter serializes the entries written by a FormatterBackend. ctx is the context passed to the Logger, e.g.,
+carrying the trace span.
+
+Example usage:
+
+	type messageFormatter struct{}
+
+	func (messageFormatter) Format(ctx context.Context, entry logger.Entry) ([]byte, error) {
+		return []byte(entry.Message + "\n"), nil
+	}
+*/
+type Formatter interface {
+	Format(ctx context.Context, entry Entry) ([]byte, error)
+}
+
+/*
+FormatterBackend is the default Backend. It formats entries with a Formatter, such as the StructuredJSONFormatter,
+and writes them to its output. If the output implements LevelWriter (e.g., LevelRouter), entries are routed based on their level.
+
+Example usage:
+
+	logConfig := logger.Config{
+		Level:   logger.INFO,
+		Backend: logger.NewFormatterBackend(&logger.ECSFormatter{}),
+	}
+*/
+type FormatterBackend struct {
+	formatter  Formatter
+	mutex      sync.Mutex
+	levelOrder int
+	output     io.Writer
+}
+
+// NewFormatterBackend returns a FormatterBackend using formatter, or the StructuredJSONFormatter if formatter is nil.
+func NewFormatterBackend(formatter Formatter) *FormatterBackend {
+	if formatter == nil {
+		formatter = &StructuredJSONFormatter{
+			TimestampFormat: time.RFC3339,
+			PrettyPrint:     false,
+		}
+	}
+	return &FormatterBackend{formatter: formatter, output: io.Discard}
+}
+
+// SetLevel implements the Backend interface.
+func (b *FormatterBackend) SetLevel(level LogLevel) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.levelOrder = level.Order()
+}
+
+// SetOutput implements the Backend interface.
+func (b *FormatterBackend) SetOutput(output io.Writer) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.output = output
+}
+
+// Write implements the Backend interface. Entries are formatted and written one at a time, so that formatters and
+// outputs do not have to be safe for concurrent use.
+func (b *FormatterBackend) Write(ctx context.Context, entry Entry) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if entry.Level.Order() < b.levelOrder {
+		return nil
+	}
+
+	serialized, err := b.formatter.Format(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("failed to format entry: %w", err)
+	}
+	if levelWriter, ok := b.output.(LevelWriter); ok {
+		_, err = levelWriter.WriteLevel(entry.Level, serialized)
+	} else {
+		_, err = b.output.Write(serialized)
+	}
+	return err
+}
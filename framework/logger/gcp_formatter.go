@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kittipat1413/go-common/util/slice"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Special field keys recognized by Google Cloud Logging in structured JSON logs.
+// See https://cloud.google.com/logging/docs/structured-logging#special-payload-fields
+const (
+	GCPFmtSeverityKey       = "severity"
+	GCPFmtMessageKey        = "message"
+	GCPFmtTimeKey           = "time"
+	GCPFmtErrorKey          = "error"
+	GCPFmtErrorChainKey     = "error_chain"
+	GCPFmtStackTraceKey     = "stack_trace"
+	GCPFmtTraceKey          = "logging.googleapis.com/trace"
+	GCPFmtSpanIDKey         = "logging.googleapis.com/spanId"
+	GCPFmtTraceSampledKey   = "logging.googleapis.com/trace_sampled"
+	GCPFmtSourceLocationKey = "logging.googleapis.com/sourceLocation"
+)
+
+// gcpSeverityMapper maps the built-in levels to Cloud Logging severities.
+var gcpSeverityMapper = map[LogLevel]string{
+	TRACE: "DEBUG",
+	DEBUG: "DEBUG",
+	INFO:  "INFO",
+	WARN:  "WARNING",
+	ERROR: "ERROR",
+	FATAL: "CRITICAL",
+	PANIC: "ALERT",
+}
+
+/*
+GCPFormatter is a formatter that emits entries in the structured JSON shape expected by Google Cloud Logging
+(e.g., on GKE or Cloud Run), so that severities are mapped correctly and entries are correlated with Cloud Trace.
+It includes the following fields:
+  - severity: The Cloud Logging severity (DEBUG, INFO, WARNING, ERROR, CRITICAL).
+  - message: The log message.
+  - time: The log timestamp in RFC3339 format with nanoseconds.
+  - error: The error message if present.
+  - error_chain: The type and message of every error wrapped by the error, if it wraps any.
+  - stack_trace: The stack trace for error levels, taken from the error if it carries one.
+  - logging.googleapis.com/trace: The trace resource name (projects/<ProjectID>/traces/<trace_id>) if available.
+  - logging.googleapis.com/spanId: The span ID if available.
+  - logging.googleapis.com/trace_sampled: Whether the trace is sampled.
+  - logging.googleapis.com/sourceLocation: The caller's file, line and function.
+
+Example usage:
+
+	logConfig := logger.Config{
+		Level: logger.INFO,
+		Formatter: &logger.GCPFormatter{
+			ProjectID: "my-gcp-project",
+		},
+	}
+*/
+type GCPFormatter struct {
+	// ProjectID is the Google Cloud project ID used to build the trace resource name.
+	// If empty, only the raw trace ID is written and Cloud Logging will not be able to link the entry to its trace.
+	ProjectID string
+	// PrettyPrint will indent all JSON logs.
+	PrettyPrint bool
+	// SkipPackages is a list of packages to skip when searching for the caller.
+	SkipPackages []string
+}
+
+// gcpSourceLocation is the payload of the logging.googleapis.com/sourceLocation field.
+type gcpSourceLocation struct {
+	File     string `json:"file"`
+	Line     string `json:"line"`
+	Function string `json:"function"`
+}
+
+// Format implements the Formatter interface.
+func (f *GCPFormatter) Format(ctx context.Context, entry Entry) ([]byte, error) {
+	data := make(Fields, len(entry.Fields)+9)
+
+	for key, value := range entry.Fields {
+		if key == DefaultErrorKey {
+			continue // Skip the default error key
+		}
+		switch v := value.(type) {
+		case error:
+			data[key] = v.Error()
+		default:
+			data[key] = v
+		}
+	}
+
+	data[GCPFmtSeverityKey] = gcpSeverityMapper[entry.Level.Base()]
+	data[GCPFmtMessageKey] = entry.Message
+	data[GCPFmtTimeKey] = entry.Time.Format(time.RFC3339Nano)
+
+	// Include error message if present.
+	if err, ok := entry.Fields[DefaultErrorKey]; ok {
+		switch e := err.(type) {
+		case error:
+			data[GCPFmtErrorKey] = e.Error()
+		default:
+			data[GCPFmtErrorKey] = fmt.Sprintf("%v", e)
+		}
+	}
+	if chain, ok := entryErrorChain(entry); ok {
+		data[GCPFmtErrorChainKey] = chain
+	}
+
+	// Include trace correlation fields if available.
+	if ctx != nil {
+		spanCtx := trace.SpanContextFromContext(ctx)
+		if spanCtx.IsValid() {
+			traceID := spanCtx.TraceID().String()
+			if f.ProjectID != "" {
+				traceID = fmt.Sprintf("projects/%s/traces/%s", f.ProjectID, traceID)
+			}
+			data[GCPFmtTraceKey] = traceID
+			data[GCPFmtSpanIDKey] = spanCtx.SpanID().String()
+			data[GCPFmtTraceSampledKey] = spanCtx.IsSampled()
+		}
+	}
+
+	// Caller's function name, file, and line number.
+	skipPackages := slice.Union(f.SkipPackages, defaultSJsonFmtSkipPackages)
+	function, file, line := getCaller(skipPackages, callerSkipFromContext(ctx))
+	if function != "" && file != "" && line != 0 {
+		data[GCPFmtSourceLocationKey] = gcpSourceLocation{
+			File:     file,
+			Line:     strconv.Itoa(line),
+			Function: function,
+		}
+	}
+
+	// Stack trace for error levels.
+	if isErrorLevel(entry.Level) {
+		data[GCPFmtStackTraceKey] = entryStackTrace(entry)
+	}
+
+	// Serialize the data to JSON.
+	var serialized []byte
+	var err error
+	if f.PrettyPrint {
+		serialized, err = json.MarshalIndent(data, "", "  ")
+	} else {
+		serialized, err = json.Marshal(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON: %v", err)
+	}
+	return append(serialized, '\n'), nil
+}
@@ -0,0 +1,100 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestGCPFormatter(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:     logger.DEBUG,
+		Formatter: &logger.GCPFormatter{ProjectID: "my-project"},
+		Output:    buffer,
+	})
+	require.NoError(t, err)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	log.Warn(ctx, "warn message", logger.Fields{"custom_key": "custom_value"})
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+
+	assert.Equal(t, "WARNING", logEntry[logger.GCPFmtSeverityKey])
+	assert.Equal(t, "warn message", logEntry[logger.GCPFmtMessageKey])
+	assert.Equal(t, "custom_value", logEntry["custom_key"])
+	assert.Contains(t, logEntry, logger.GCPFmtTimeKey)
+	assert.Equal(t, "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736", logEntry[logger.GCPFmtTraceKey])
+	assert.Equal(t, "00f067aa0ba902b7", logEntry[logger.GCPFmtSpanIDKey])
+	assert.Equal(t, true, logEntry[logger.GCPFmtTraceSampledKey])
+	assert.NotContains(t, logEntry, logger.GCPFmtStackTraceKey)
+
+	sourceLocation, ok := logEntry[logger.GCPFmtSourceLocationKey].(map[string]interface{})
+	require.True(t, ok, "sourceLocation should be an object")
+	assert.Contains(t, sourceLocation, "file")
+	assert.Contains(t, sourceLocation, "line")
+	assert.Contains(t, sourceLocation, "function")
+}
+
+func TestGCPFormatter_SeverityMapping(t *testing.T) {
+	tests := []struct {
+		name     string
+		logFunc  func(log logger.Logger)
+		severity string
+	}{
+		{"debug", func(log logger.Logger) { log.Debug(context.Background(), "msg", nil) }, "DEBUG"},
+		{"info", func(log logger.Logger) { log.Info(context.Background(), "msg", nil) }, "INFO"},
+		{"error", func(log logger.Logger) { log.Error(context.Background(), "msg", errors.New("boom"), nil) }, "ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			log, err := logger.NewLogger(logger.Config{
+				Level:     logger.DEBUG,
+				Formatter: &logger.GCPFormatter{},
+				Output:    buffer,
+			})
+			require.NoError(t, err)
+
+			tt.logFunc(log)
+
+			var logEntry map[string]interface{}
+			require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+			assert.Equal(t, tt.severity, logEntry[logger.GCPFmtSeverityKey])
+			assert.NotContains(t, logEntry, logger.GCPFmtTraceKey)
+		})
+	}
+}
+
+func TestGCPFormatter_Error(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:     logger.INFO,
+		Formatter: &logger.GCPFormatter{},
+		Output:    buffer,
+	})
+	require.NoError(t, err)
+
+	log.Error(context.Background(), "error message", errors.New("boom"), nil)
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+	assert.Equal(t, "boom", logEntry[logger.GCPFmtErrorKey])
+	assert.Contains(t, logEntry, logger.GCPFmtStackTraceKey)
+}
@@ -0,0 +1,62 @@
+package logger
+
+import "sync/atomic"
+
+// globalLogger holds the process-global logger.
+type globalLogger struct {
+	logger Logger
+	// replaced reports whether the logger was set with ReplaceGlobal rather than created from the default configuration.
+	replaced bool
+}
+
+var global atomic.Pointer[globalLogger]
+
+/*
+L returns the process-global logger. It is safe for concurrent use and can be swapped at any time with ReplaceGlobal.
+Until ReplaceGlobal is called, L returns a logger created from the default configuration (see SetDefaultLoggerConfig).
+
+Example usage:
+
+	logger.L().Info(ctx, "Cache warmed up", logger.Fields{"keys": n})
+*/
+func L() Logger {
+	if g := global.Load(); g != nil {
+		return g.logger
+	}
+	g := &globalLogger{logger: NewDefaultLogger()}
+	if global.CompareAndSwap(nil, g) {
+		return g.logger
+	}
+	return global.Load().logger
+}
+
+/*
+ReplaceGlobal replaces the process-global logger returned by L and returns a function restoring the previous one.
+A nil logger is replaced by a no-op logger.
+
+Example usage:
+
+	log, err := logger.NewLogger(config)
+	if err != nil {
+		// Handle error
+	}
+	restore := logger.ReplaceGlobal(log)
+	defer restore()
+*/
+func ReplaceGlobal(l Logger) (restore func()) {
+	if l == nil {
+		l = NewNoopLogger()
+	}
+	prev := global.Swap(&globalLogger{logger: l, replaced: true})
+	return func() {
+		global.Store(prev)
+	}
+}
+
+// resetDefaultGlobal discards the global logger created from the default configuration, so that L picks up
+// a new default configuration. A logger set with ReplaceGlobal is kept.
+func resetDefaultGlobal() {
+	if g := global.Load(); g != nil && !g.replaced {
+		global.CompareAndSwap(g, nil)
+	}
+}
@@ -0,0 +1,55 @@
+package logger_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobalLogger(t *testing.T) {
+	defaultGlobal := logger.L()
+	assert.NotNil(t, defaultGlobal)
+	assert.Same(t, defaultGlobal, logger.L(), "the default global logger should be created once")
+
+	log, recorder := logger.NewTestLogger()
+	restore := logger.ReplaceGlobal(log)
+
+	logger.L().Info(context.Background(), "global message", nil)
+	logger.FromContext(context.Background()).Info(context.Background(), "from context", nil)
+	recorder.AssertLogged(t, logger.INFO, "global message")
+	recorder.AssertLogged(t, logger.INFO, "from context")
+
+	restore()
+	assert.Same(t, defaultGlobal, logger.L())
+}
+
+func TestReplaceGlobal_Nil(t *testing.T) {
+	restore := logger.ReplaceGlobal(nil)
+	defer restore()
+
+	assert.NotPanics(t, func() {
+		logger.L().Info(context.Background(), "discarded", nil)
+	})
+}
+
+func TestReplaceGlobal_Concurrent(t *testing.T) {
+	log, _ := logger.NewTestLogger()
+	defer logger.ReplaceGlobal(log)()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			logger.L().Info(context.Background(), "concurrent message", nil)
+		}()
+		go func() {
+			defer wg.Done()
+			logger.ReplaceGlobal(log)
+		}()
+	}
+	wg.Wait()
+}
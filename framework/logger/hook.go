@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Entry represents a single log entry as seen by hooks.
+type Entry struct {
+	// Time is the time the entry was created.
+	Time time.Time
+	// Level is the severity of the entry.
+	Level LogLevel
+	// Message is the log message.
+	Message string
+	// Error is the error passed to Error, Fatal or Panic, if any.
+	Error error
+	// Fields contains all fields of the entry, including the logger's persistent fields.
+	// Hooks may add or modify fields to enrich the entry before it is written.
+	Fields Fields
+}
+
+/*
+Hook is a backend-agnostic extension point that is invoked for every entry matching one of its levels.
+Hooks can be used to forward errors to external services (e.g., Sentry), emit metrics, or enrich entries.
+
+A panic inside Fire is recovered and reported like a returned error, so a faulty hook never breaks logging.
+*/
+type Hook interface {
+	// Levels returns the levels the hook fires for. An empty result fires the hook for all levels.
+	Levels() []LogLevel
+	// Fire is called for every matching entry.
+	Fire(ctx context.Context, entry Entry) error
+}
+
+// HookFunc is a function that is called for every matching entry.
+type HookFunc func(ctx context.Context, entry Entry) error
+
+/*
+NewHook creates a Hook from a function that fires for the given levels (all levels if none are provided).
+
+Example usage:
+
+	errorCounter := logger.NewHook(func(ctx context.Context, entry logger.Entry) error {
+		metrics.ErrorsTotal.Inc()
+		return nil
+	}, logger.ERROR, logger.FATAL)
+*/
+func NewHook(fn HookFunc, levels ...LogLevel) Hook {
+	return &funcHook{fn: fn, levels: levels}
+}
+
+type funcHook struct {
+	fn     HookFunc
+	levels []LogLevel
+}
+
+func (h *funcHook) Levels() []LogLevel {
+	return h.levels
+}
+
+func (h *funcHook) Fire(ctx context.Context, entry Entry) error {
+	return h.fn(ctx, entry)
+}
+
+// registeredHook is a Hook registered on a logger, with its levels resolved when the logger is created.
+type registeredHook struct {
+	hook Hook
+	// accepted is the set of levels the hook fires for, nil if it fires for all levels.
+	accepted map[LogLevel]struct{}
+}
+
+func newRegisteredHook(hook Hook) *registeredHook {
+	levels := hook.Levels()
+	if len(levels) == 0 {
+		return &registeredHook{hook: hook}
+	}
+
+	h := &registeredHook{hook: hook, accepted: make(map[LogLevel]struct{}, len(levels))}
+	for _, level := range levels {
+		if level.IsValid() {
+			h.accepted[level] = struct{}{}
+		}
+	}
+	return h
+}
+
+// firesFor reports whether the hook fires for entries of the given level.
+func (h *registeredHook) firesFor(level LogLevel) bool {
+	if h.accepted == nil {
+		return true
+	}
+	_, ok := h.accepted[level]
+	return ok
+}
+
+// fire fires the hook. Panics raised by the hook are converted into errors.
+func (h *registeredHook) fire(ctx context.Context, entry Entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("logger hook panicked: %v", r)
+		}
+	}()
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return h.hook.Fire(ctx, entry)
+}
+
+// fireHooks fires the hooks matching the entry's level. Hooks share the entry's fields, so they can enrich the entry,
+// which is why the fields are copied first. A failing hook is reported to stderr and does not prevent the entry from being written.
+func (l *logger) fireHooks(ctx context.Context, entry *Entry) {
+	copied := false
+	for _, hook := range l.hooks {
+		if !hook.firesFor(entry.Level) {
+			continue
+		}
+		if !copied {
+			fields := make(Fields, len(entry.Fields))
+			for k, v := range entry.Fields {
+				fields[k] = v
+			}
+			entry.Fields = fields
+			copied = true
+		}
+		if err := hook.fire(ctx, *entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+		}
+	}
+}
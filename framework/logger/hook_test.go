@@ -0,0 +1,105 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_HookLevels(t *testing.T) {
+	var fired []logger.Entry
+	hook := logger.NewHook(func(ctx context.Context, entry logger.Entry) error {
+		fired = append(fired, entry)
+		return nil
+	}, logger.ERROR)
+
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.DEBUG,
+		Hooks:  []logger.Hook{hook},
+		Output: &bytes.Buffer{},
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	testErr := errors.New("test error")
+	log.Info(ctx, "info message", nil)
+	log.WithFields(logger.Fields{"component": "test"}).Error(ctx, "error message", testErr, logger.Fields{"key": "value"})
+
+	require.Len(t, fired, 1, "hook should only fire for ERROR entries")
+	assert.Equal(t, logger.ERROR, fired[0].Level)
+	assert.Equal(t, "error message", fired[0].Message)
+	assert.Equal(t, testErr, fired[0].Error)
+	assert.Equal(t, "value", fired[0].Fields["key"])
+	assert.Equal(t, "test", fired[0].Fields["component"])
+}
+
+func TestLogger_HookAllLevels(t *testing.T) {
+	count := 0
+	hook := logger.NewHook(func(ctx context.Context, entry logger.Entry) error {
+		count++
+		return nil
+	})
+
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.DEBUG,
+		Hooks:  []logger.Hook{hook},
+		Output: &bytes.Buffer{},
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	log.Debug(ctx, "debug", nil)
+	log.Info(ctx, "info", nil)
+	log.Warn(ctx, "warn", nil)
+	log.Error(ctx, "error", nil, nil)
+	assert.Equal(t, 4, count)
+}
+
+func TestLogger_HookEnrichesEntry(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	hook := logger.NewHook(func(ctx context.Context, entry logger.Entry) error {
+		entry.Fields["enriched"] = true
+		return nil
+	})
+
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Hooks:  []logger.Hook{hook},
+		Output: buffer,
+	})
+	require.NoError(t, err)
+
+	log.Info(context.Background(), "message", nil)
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+	assert.Equal(t, true, logEntry["enriched"])
+}
+
+func TestLogger_HookPanicIsolation(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	panickingHook := logger.NewHook(func(ctx context.Context, entry logger.Entry) error {
+		panic("boom")
+	})
+	failingHook := logger.NewHook(func(ctx context.Context, entry logger.Entry) error {
+		return errors.New("hook failed")
+	})
+
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Hooks:  []logger.Hook{panickingHook, failingHook},
+		Output: buffer,
+	})
+	require.NoError(t, err)
+
+	assert.NotPanics(t, func() {
+		log.Info(context.Background(), "message", nil)
+	})
+	assert.Equal(t, 1, countLines(buffer), "entry should still be written when hooks fail")
+}
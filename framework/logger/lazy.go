@@ -0,0 +1,47 @@
+package logger
+
+/*
+LazyValue is a field value that is computed only when the entry is actually written,
+i.e., after it passed the level filter and sampling.
+
+Example usage:
+
+	log.Debug(ctx, "Request received", logger.Fields{
+		"payload": logger.Lazy(func() interface{} {
+			return dumpPayload(req) // only called when DEBUG is enabled
+		}),
+	})
+*/
+type LazyValue func() interface{}
+
+// Lazy wraps fn in a LazyValue that is evaluated only if the entry is written.
+func Lazy(fn func() interface{}) LazyValue {
+	return LazyValue(fn)
+}
+
+// resolveLazyValues returns fields with every LazyValue replaced by its computed value.
+// fields is not modified, a copy is returned if it contains lazy values.
+func resolveLazyValues(fields Fields) Fields {
+	var resolved Fields
+	for key, value := range fields {
+		lazy, ok := value.(LazyValue)
+		if !ok {
+			continue
+		}
+		if resolved == nil {
+			resolved = make(Fields, len(fields))
+			for k, v := range fields {
+				resolved[k] = v
+			}
+		}
+		if lazy != nil {
+			resolved[key] = lazy()
+		} else {
+			resolved[key] = nil
+		}
+	}
+	if resolved == nil {
+		return fields
+	}
+	return resolved
+}
@@ -0,0 +1,60 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_LazyFields(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Output: buffer,
+	})
+	require.NoError(t, err)
+
+	calls := 0
+	payload := logger.Lazy(func() interface{} {
+		calls++
+		return map[string]interface{}{"id": 1}
+	})
+
+	ctx := context.Background()
+	log.Debug(ctx, "Debug message", logger.Fields{"payload": payload})
+	assert.Equal(t, 0, calls, "lazy field should not be evaluated for disabled levels")
+	assert.Equal(t, 0, buffer.Len())
+
+	log.Info(ctx, "Info message", logger.Fields{"payload": payload})
+	assert.Equal(t, 1, calls)
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+	assert.Equal(t, map[string]interface{}{"id": float64(1)}, logEntry["payload"])
+}
+
+func TestLogger_LazyFieldsSampledOut(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{
+		Level:   logger.INFO,
+		Sampler: &logger.Sampler{Initial: 1, Thereafter: 0, Tick: time.Minute},
+		Output:  &bytes.Buffer{},
+	})
+	require.NoError(t, err)
+
+	calls := 0
+	log = log.WithFields(logger.Fields{"payload": logger.Lazy(func() interface{} {
+		calls++
+		return "value"
+	})})
+
+	for i := 0; i < 5; i++ {
+		log.Info(context.Background(), "Repeated message", nil)
+	}
+	assert.Equal(t, 1, calls, "lazy field should not be evaluated for sampled out entries")
+}
@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"errors"
+	"io"
+)
+
+// LevelWriter is an io.Writer that is aware of the level of the entry being written.
+// When Config.Output implements LevelWriter, the logger calls WriteLevel instead of Write for every entry.
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level LogLevel, p []byte) (n int, err error)
+}
+
+// LevelRoute routes entries with one of the given levels to Writer.
+type LevelRoute struct {
+	// Levels is the list of levels written to Writer. An empty list matches all levels.
+	Levels []LogLevel
+	// Writer is the destination for matching entries.
+	Writer io.Writer
+}
+
+// LevelRouter is a LevelWriter that routes entries to one or more writers based on their level.
+// An entry matching several routes is written to each of them.
+type LevelRouter struct {
+	routes []LevelRoute
+}
+
+/*
+NewLevelRouter creates a LevelRouter from the given routes.
+
+Example usage:
+
+	errorFile, _ := os.OpenFile("errors.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	router := logger.NewLevelRouter(
+		logger.LevelRoute{Levels: []logger.LogLevel{logger.DEBUG, logger.INFO}, Writer: os.Stdout},
+		logger.LevelRoute{Levels: logger.LevelsAtLeast(logger.WARN), Writer: os.Stderr},
+		logger.LevelRoute{Levels: logger.LevelsAtLeast(logger.ERROR), Writer: errorFile},
+	)
+
+	logConfig := logger.Config{
+		Level:  logger.DEBUG,
+		Output: router,
+	}
+*/
+func NewLevelRouter(routes ...LevelRoute) *LevelRouter {
+	return &LevelRouter{routes: routes}
+}
+
+// Write writes p to every route, as if it was an INFO entry.
+func (r *LevelRouter) Write(p []byte) (int, error) {
+	return r.WriteLevel(INFO, p)
+}
+
+// WriteLevel writes p to every route matching the given level.
+func (r *LevelRouter) WriteLevel(level LogLevel, p []byte) (int, error) {
+	var errs []error
+	for _, route := range r.routes {
+		if route.Writer == nil || !route.matches(level) {
+			continue
+		}
+		if _, err := route.Writer.Write(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(p), errors.Join(errs...)
+}
+
+func (route LevelRoute) matches(level LogLevel) bool {
+	if len(route.Levels) == 0 {
+		return true
+	}
+	for _, l := range route.Levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// LevelsAtLeast returns all levels, including the registered custom levels, with a severity greater than or equal to min.
+func LevelsAtLeast(min LogLevel) []LogLevel {
+	levels := allLevels()
+	for i, level := range levels {
+		if level == min {
+			return levels[i:]
+		}
+	}
+	return nil
+}
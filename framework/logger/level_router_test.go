@@ -0,0 +1,66 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelRouter(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	errorFile := &bytes.Buffer{}
+
+	router := logger.NewLevelRouter(
+		logger.LevelRoute{Levels: []logger.LogLevel{logger.DEBUG, logger.INFO}, Writer: stdout},
+		logger.LevelRoute{Levels: logger.LevelsAtLeast(logger.WARN), Writer: stderr},
+		logger.LevelRoute{Levels: logger.LevelsAtLeast(logger.ERROR), Writer: errorFile},
+	)
+
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.DEBUG,
+		Output: router,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	log.Debug(ctx, "debug message", nil)
+	log.Info(ctx, "info message", nil)
+	log.Warn(ctx, "warn message", nil)
+	log.Error(ctx, "error message", errors.New("error"), nil)
+
+	assert.Equal(t, 2, countLines(stdout))
+	assert.Contains(t, stdout.String(), "debug message")
+	assert.Contains(t, stdout.String(), "info message")
+
+	assert.Equal(t, 2, countLines(stderr))
+	assert.Contains(t, stderr.String(), "warn message")
+	assert.Contains(t, stderr.String(), "error message")
+
+	assert.Equal(t, 1, countLines(errorFile))
+	assert.Contains(t, errorFile.String(), "error message")
+}
+
+func TestLevelRouter_EmptyLevelsMatchAll(t *testing.T) {
+	all := &bytes.Buffer{}
+	router := logger.NewLevelRouter(logger.LevelRoute{Writer: all})
+
+	n, err := router.WriteLevel(logger.WARN, []byte("entry\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+
+	_, err = router.Write([]byte("entry\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, countLines(all))
+}
+
+func TestLevelsAtLeast(t *testing.T) {
+	assert.Equal(t, []logger.LogLevel{logger.ERROR, logger.FATAL, logger.PANIC}, logger.LevelsAtLeast(logger.ERROR))
+	assert.Equal(t, logger.AllLevels[1:], logger.LevelsAtLeast(logger.DEBUG))
+	assert.Nil(t, logger.LevelsAtLeast(logger.LogLevel("unknown")))
+}
@@ -0,0 +1,160 @@
+package logger
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrInvalidCustomLevel is returned when registering a custom level with an invalid or conflicting definition.
+var ErrInvalidCustomLevel = errors.New("invalid custom level")
+
+/*
+CustomLevel defines a user-defined log level placed between the built-in levels.
+Entries logged at a custom level are filtered by its Order and written with its Severity,
+other behaviors (sampling, stack traces, severity mappings of GCP, syslog and OTLP) follow its base level,
+i.e., the built-in level right below it (or TRACE if it is below all built-in levels).
+
+Example usage:
+
+	// PROTOCOL sits between TRACE and DEBUG.
+	const PROTOCOL logger.LogLevel = "protocol"
+
+	err := logger.RegisterLevel(logger.CustomLevel{
+		Name:     PROTOCOL,
+		Order:    logger.TRACE.Order() + 50,
+		Severity: "proto",
+	})
+
+	log.Log(ctx, PROTOCOL, "Frame received", logger.Fields{"opcode": 2})
+*/
+type CustomLevel struct {
+	// Name is the level name used in configurations, e.g., Config.Level.
+	Name LogLevel
+	// Order positions the level in the severity ordering (see LogLevel.Order). It must be positive,
+	// lower than the order of FATAL and different from the order of every other level.
+	Order int
+	// Severity is the severity written by the formatters. Defaults to Name.
+	Severity string
+}
+
+// customLevel is a registered CustomLevel.
+type customLevel struct {
+	CustomLevel
+	base LogLevel
+}
+
+var (
+	customLevels   = map[LogLevel]*customLevel{}
+	customLevelsMu sync.RWMutex
+)
+
+/*
+RegisterLevel registers a custom level. Custom levels must be registered before the loggers,
+hooks and level routes using them are created, typically in an init function.
+
+Example usage:
+
+	func init() {
+		if err := logger.RegisterLevel(logger.CustomLevel{Name: "notice", Order: logger.INFO.Order() + 50}); err != nil {
+			panic(err)
+		}
+	}
+*/
+func RegisterLevel(level CustomLevel) error {
+	if level.Name == "" || level.Order <= 0 || level.Order >= levelOrderMapper[FATAL] {
+		return ErrInvalidCustomLevel
+	}
+	if level.Severity == "" {
+		level.Severity = string(level.Name)
+	}
+
+	customLevelsMu.Lock()
+	defer customLevelsMu.Unlock()
+
+	if _, ok := levelOrderMapper[level.Name]; ok {
+		return ErrInvalidCustomLevel
+	}
+	if _, ok := customLevels[level.Name]; ok {
+		return ErrInvalidCustomLevel
+	}
+	for _, order := range levelOrderMapper {
+		if order == level.Order {
+			return ErrInvalidCustomLevel
+		}
+	}
+	for _, custom := range customLevels {
+		if custom.Order == level.Order {
+			return ErrInvalidCustomLevel
+		}
+	}
+
+	// The base level is the highest built-in level ordered below the custom level.
+	base := TRACE
+	for _, builtin := range AllLevels {
+		if levelOrderMapper[builtin] < level.Order {
+			base = builtin
+		}
+	}
+
+	customLevels[level.Name] = &customLevel{CustomLevel: level, base: base}
+	return nil
+}
+
+// lookupCustomLevel returns the registered custom level with the given name.
+func lookupCustomLevel(level LogLevel) (*customLevel, bool) {
+	customLevelsMu.RLock()
+	defer customLevelsMu.RUnlock()
+	custom, ok := customLevels[level]
+	return custom, ok
+}
+
+// allLevels returns the built-in and custom levels ordered by increasing severity.
+func allLevels() []LogLevel {
+	customLevelsMu.RLock()
+	levels := make([]LogLevel, 0, len(AllLevels)+len(customLevels))
+	levels = append(levels, AllLevels...)
+	for name := range customLevels {
+		levels = append(levels, name)
+	}
+	customLevelsMu.RUnlock()
+
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i].Order() < levels[j].Order()
+	})
+	return levels
+}
+
+// Base returns the built-in level whose behaviors apply to l, i.e., l itself or the base level of a custom level.
+// Unknown levels default to INFO.
+func (l LogLevel) Base() LogLevel {
+	if _, ok := levelOrderMapper[l]; ok {
+		return l
+	}
+	if custom, ok := lookupCustomLevel(l); ok {
+		return custom.base
+	}
+	return INFO
+}
+
+// isErrorLevel reports whether entries of level are errors, i.e., whether its base level is ERROR or more severe.
+func isErrorLevel(level LogLevel) bool {
+	return level.Base().Order() >= ERROR.Order()
+}
+
+// entrySeverity returns the severity written by the formatters for the entry, i.e., the Severity of custom levels
+// or the level name, WARN being written as "warning".
+func entrySeverity(entry Entry) string {
+	if entry.Level == WARN {
+		return "warning"
+	}
+	return levelSeverity(entry.Level)
+}
+
+// levelSeverity returns the severity of level, i.e., the Severity of custom levels or the level name.
+func levelSeverity(level LogLevel) string {
+	if custom, ok := lookupCustomLevel(level); ok {
+		return custom.Severity
+	}
+	return string(level)
+}
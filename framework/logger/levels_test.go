@@ -0,0 +1,130 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// protocolLevel is a custom level between TRACE and DEBUG, registered once for the package tests.
+const protocolLevel logger.LogLevel = "protocol"
+
+func init() {
+	if err := logger.RegisterLevel(logger.CustomLevel{
+		Name:     protocolLevel,
+		Order:    logger.TRACE.Order() + 50,
+		Severity: "proto",
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestLogger_TraceLevel(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{Level: logger.TRACE, Output: buffer})
+	require.NoError(t, err)
+
+	log.Trace(context.Background(), "trace message", nil)
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+	assert.Equal(t, "trace", logEntry["severity"])
+	assert.Equal(t, "trace message", logEntry["message"])
+
+	buffer.Reset()
+	log, err = logger.NewLogger(logger.Config{Level: logger.DEBUG, Output: buffer})
+	require.NoError(t, err)
+	log.Trace(context.Background(), "trace message", nil)
+	assert.Empty(t, buffer.String(), "TRACE should be disabled at DEBUG")
+}
+
+func TestLogger_CustomLevel(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name           string
+		level          logger.LogLevel
+		expectProtocol bool
+		expectTrace    bool
+	}{
+		{name: "TRACE enables protocol", level: logger.TRACE, expectProtocol: true, expectTrace: true},
+		{name: "protocol enables protocol and above", level: protocolLevel, expectProtocol: true},
+		{name: "DEBUG disables protocol", level: logger.DEBUG},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			log, err := logger.NewLogger(logger.Config{Level: tt.level, Output: buffer})
+			require.NoError(t, err)
+
+			log.Trace(ctx, "trace message", nil)
+			log.Log(ctx, protocolLevel, "protocol message", logger.Fields{"opcode": 2})
+			log.Debug(ctx, "debug message", nil)
+
+			output := buffer.String()
+			assert.Equal(t, tt.expectTrace, bytes.Contains(buffer.Bytes(), []byte("trace message")))
+			assert.Equal(t, tt.expectProtocol, bytes.Contains(buffer.Bytes(), []byte("protocol message")))
+			assert.Contains(t, output, "debug message")
+			if tt.expectProtocol {
+				assert.Contains(t, output, `"severity":"proto"`)
+			}
+		})
+	}
+}
+
+func TestLogger_CustomLevelHooksAndRoutes(t *testing.T) {
+	var fired []logger.Entry
+	hook := logger.NewHook(func(_ context.Context, entry logger.Entry) error {
+		fired = append(fired, entry)
+		return nil
+	}, protocolLevel)
+	protocolOutput := &bytes.Buffer{}
+
+	log, err := logger.NewLogger(logger.Config{
+		Level: logger.TRACE,
+		Hooks: []logger.Hook{hook},
+		Output: logger.NewLevelRouter(
+			logger.LevelRoute{Levels: []logger.LogLevel{protocolLevel}, Writer: protocolOutput},
+		),
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	log.Trace(ctx, "trace message", nil)
+	log.Log(ctx, protocolLevel, "protocol message", nil)
+
+	require.Len(t, fired, 1, "hook should only fire for the custom level, not for its base level")
+	assert.Equal(t, protocolLevel, fired[0].Level)
+	assert.Equal(t, 1, countLines(protocolOutput))
+	assert.Contains(t, protocolOutput.String(), "protocol message")
+}
+
+func TestRegisterLevel(t *testing.T) {
+	assert.True(t, protocolLevel.IsValid())
+	assert.Equal(t, 150, protocolLevel.Order())
+	assert.Equal(t, -1, logger.LogLevel("unknown").Order())
+	assert.Equal(t, append([]logger.LogLevel{protocolLevel}, logger.AllLevels[1:]...), logger.LevelsAtLeast(protocolLevel))
+
+	tests := []struct {
+		name  string
+		level logger.CustomLevel
+	}{
+		{name: "empty name", level: logger.CustomLevel{Order: 250}},
+		{name: "built-in name", level: logger.CustomLevel{Name: logger.INFO, Order: 250}},
+		{name: "already registered", level: logger.CustomLevel{Name: protocolLevel, Order: 250}},
+		{name: "order of a built-in level", level: logger.CustomLevel{Name: "other", Order: logger.INFO.Order()}},
+		{name: "order of a custom level", level: logger.CustomLevel{Name: "other", Order: protocolLevel.Order()}},
+		{name: "not positive order", level: logger.CustomLevel{Name: "other", Order: 0}},
+		{name: "order above ERROR levels", level: logger.CustomLevel{Name: "other", Order: logger.FATAL.Order() + 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, logger.RegisterLevel(tt.level), logger.ErrInvalidCustomLevel)
+		})
+	}
+}
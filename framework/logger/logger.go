@@ -3,26 +3,40 @@ package logger
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"sync"
 	"time"
-
-	"github.com/sirupsen/logrus"
 )
 
 //go:generate mockgen -source=./logger.go -destination=./mocks/logger.go -package=logger_mocks
 type Logger interface {
 	WithFields(fields Fields) Logger
+	WithField(key string, value interface{}) Logger
+	WithError(err error) Logger
+	WithString(key string, value string) Logger
+	WithInt(key string, value int) Logger
+	WithDuration(key string, value time.Duration) Logger
+	WithCallerSkip(skip int) Logger
+	Named(name string) Logger
+	Log(ctx context.Context, level LogLevel, msg string, fields Fields)
+	Trace(ctx context.Context, msg string, fields Fields)
 	Debug(ctx context.Context, msg string, fields Fields)
 	Info(ctx context.Context, msg string, fields Fields)
 	Warn(ctx context.Context, msg string, fields Fields)
 	Error(ctx context.Context, msg string, err error, fields Fields)
 	Fatal(ctx context.Context, msg string, err error, fields Fields)
+	Panic(ctx context.Context, msg string, err error, fields Fields)
 }
 
 var (
-	ErrInvalidLogLevel = errors.New("invalid log level")
+	ErrInvalidLogLevel      = errors.New("invalid log level")
+	ErrInvalidSamplerConfig = errors.New("invalid sampler config")
+	// ErrInvalidDuplicateSuppressionConfig is returned when the duplicate suppression configuration is invalid.
+	ErrInvalidDuplicateSuppressionConfig = errors.New("invalid duplicate suppression config")
+	// ErrInvalidSizeLimitsConfig is returned when the size limits configuration is invalid.
+	ErrInvalidSizeLimitsConfig = errors.New("invalid size limits config")
 )
 
 var (
@@ -55,6 +69,7 @@ func SetDefaultLoggerConfig(config Config) error {
 	}
 	// If logger creation is successful, update the default configuration.
 	defaultLoggerConfig = config
+	resetDefaultGlobal()
 	return nil
 }
 
@@ -83,9 +98,21 @@ func NewDefaultLogger() Logger {
 
 // logger is the implementation of the Logger interface.
 type logger struct {
-	baselogger *logrus.Logger
+	backend    Backend
+	hooks      []*registeredHook
+	flushHooks []Hook
+	output     io.Writer
+	exit       func(code int)
 	logLevel   LogLevel
+	levelOrder int
+	name       string
+	registry   *LevelRegistry
+	extractors []ContextExtractor
 	fields     Fields
+	sampler    *sampler
+	suppressor *suppressor
+	sizeLimits *SizeLimits
+	callerSkip int
 }
 
 // Config holds the logger configuration.
@@ -93,9 +120,10 @@ type Config struct {
 	// Level determines the minimum log level that will be processed by the logger.
 	// Logs with a level lower than this will be ignored.
 	Level LogLevel
-	// Formatter is an optional field for specifying a custom logrus formatter.
+	// Formatter is an optional field for specifying a custom formatter.
 	// If not provided, the logger will use the StructuredJSONFormatter by default.
-	Formatter logrus.Formatter
+	// It is ignored if Backend is provided.
+	Formatter Formatter
 	// Environment is an optional field for specifying the running environment (e.g., "production", "staging").
 	// This field is used for adding environment-specific fields to logs.
 	Environment string
@@ -103,35 +131,93 @@ type Config struct {
 	// This field is used for adding service-specific fields to logs.
 	ServiceName string
 	// Output is an optional field for specifying the output destination for logs (e.g., os.Stdout, file).
+	// If the output implements LevelWriter (e.g., LevelRouter), entries are routed based on their level.
 	// If not provided, logs will be written to stdout by default.
 	Output io.Writer
+	// Sampler is an optional field for sampling repetitive DEBUG and INFO entries with identical messages.
+	// If not provided, all entries are logged.
+	Sampler *Sampler
+	// DuplicateSuppression is an optional field for suppressing identical entries within a time window.
+	// If not provided, duplicate entries are not suppressed.
+	DuplicateSuppression *DuplicateSuppression
+	// CallerSkip is an optional field for skipping additional stack frames when reporting the caller.
+	// Use it when the logger is wrapped by a helper, so that the caller of the helper is reported instead.
+	CallerSkip int
+	// Hooks is an optional list of hooks that are fired for every entry matching the hook's levels.
+	// A failing or panicking hook does not prevent the entry from being written.
+	Hooks []Hook
+	// ExitFunc is an optional field for overriding the function called by Fatal after the entry is written.
+	// Hooks and outputs implementing Flusher are flushed before it is called. Defaults to os.Exit.
+	// Tests can use it to assert Fatal without terminating the process.
+	ExitFunc func(code int)
+	// LevelRegistry is an optional field for overriding the level of named loggers (see Logger.Named).
+	// If not provided, named loggers use Level.
+	LevelRegistry *LevelRegistry
+	// ContextExtractors is an optional list of functions adding fields extracted from the entry's context
+	// (e.g., request ID, user ID, tenant) to every entry. Fields passed by the caller or added with WithFields
+	// take precedence over extracted fields.
+	ContextExtractors []ContextExtractor
+	// Backend is an optional field for specifying the backend formatting and writing the entries.
+	// If not provided, a FormatterBackend using Formatter is used.
+	Backend Backend
+	// SizeLimits is an optional field for truncating oversized messages, field values and entries.
+	// If not provided, entries are written whatever their size.
+	SizeLimits *SizeLimits
 }
 
 // NewLogger creates a new logger instance with the provided configuration.
 func NewLogger(config Config) (Logger, error) {
-	logrusLogger := logrus.New()
-
-	// Set custom formatter if provided, otherwise use StructuredJSONFormatter.
-	if config.Formatter != nil {
-		logrusLogger.SetFormatter(config.Formatter)
-	} else {
-		logrusLogger.SetFormatter(&StructuredJSONFormatter{
-			TimestampFormat: time.RFC3339,
-			PrettyPrint:     false,
-		})
+	// Use a FormatterBackend with the provided formatter if no backend is provided.
+	backend := config.Backend
+	if backend == nil {
+		backend = NewFormatterBackend(config.Formatter)
 	}
 
 	// Set log level.
 	if !config.Level.IsValid() {
 		return nil, ErrInvalidLogLevel
 	}
-	logrusLogger.SetLevel(config.Level.ToLogrusLevel())
+	backend.SetLevel(config.Level)
+	if config.LevelRegistry != nil {
+		// Named loggers may be more verbose than Level, entries are filtered by logWithContext instead.
+		backend.SetLevel(TRACE)
+	}
+
+	// Register hooks.
+	var hooks []*registeredHook
+	for _, hook := range config.Hooks {
+		if hook != nil {
+			hooks = append(hooks, newRegisteredHook(hook))
+		}
+	}
+
+	// Set up sampling if configured.
+	var logSampler *sampler
+	if config.Sampler != nil {
+		if !config.Sampler.isValid() {
+			return nil, ErrInvalidSamplerConfig
+		}
+		logSampler = newSampler(config.Sampler)
+	}
+
+	// Set up duplicate suppression if configured.
+	var logSuppressor *suppressor
+	if config.DuplicateSuppression != nil {
+		if !config.DuplicateSuppression.isValid() {
+			return nil, ErrInvalidDuplicateSuppressionConfig
+		}
+		logSuppressor = newSuppressor(config.DuplicateSuppression)
+	}
+
+	if config.SizeLimits != nil && !config.SizeLimits.isValid() {
+		return nil, ErrInvalidSizeLimitsConfig
+	}
 
 	// Set output to the provided output or default to stdout.
 	if config.Output != nil {
-		logrusLogger.SetOutput(config.Output)
+		backend.SetOutput(config.Output)
 	} else {
-		logrusLogger.SetOutput(os.Stdout)
+		backend.SetOutput(os.Stdout)
 	}
 
 	// Add environment and service name fields to the logger.
@@ -144,9 +230,21 @@ func NewLogger(config Config) (Logger, error) {
 	}
 
 	return &logger{
-		baselogger: logrusLogger,
+		backend: backend,
+		hooks:   hooks,
+		// Flush buffered hooks, backend and output before Fatal exits.
+		exit:       newExitFunc(config.ExitFunc, config.Hooks, config.Output, backend),
+		flushHooks: config.Hooks,
+		output:     config.Output,
 		logLevel:   config.Level,
+		levelOrder: config.Level.Order(),
+		registry:   config.LevelRegistry,
+		extractors: config.ContextExtractors,
 		fields:     fields,
+		sampler:    logSampler,
+		suppressor: logSuppressor,
+		sizeLimits: config.SizeLimits,
+		callerSkip: config.CallerSkip,
 	}, nil
 }
 
@@ -174,69 +272,241 @@ func (l *logger) WithFields(fields Fields) Logger {
 	return clone
 }
 
+// WithField returns a new logger that includes the provided field.
+func (l *logger) WithField(key string, value interface{}) Logger {
+	clone := l.clone()
+	clone.fields[key] = value
+	return clone
+}
+
+// WithError returns a new logger that includes the provided error under the DefaultErrorKey.
+func (l *logger) WithError(err error) Logger {
+	return l.WithField(DefaultErrorKey, err)
+}
+
+// WithString returns a new logger that includes the provided string field.
+func (l *logger) WithString(key string, value string) Logger {
+	return l.WithField(key, value)
+}
+
+// WithInt returns a new logger that includes the provided integer field.
+func (l *logger) WithInt(key string, value int) Logger {
+	return l.WithField(key, value)
+}
+
+// WithDuration returns a new logger that includes the provided duration field, formatted as a string (e.g., "1.5s").
+func (l *logger) WithDuration(key string, value time.Duration) Logger {
+	return l.WithField(key, value.String())
+}
+
+// WithCallerSkip returns a new logger that skips skip additional stack frames when reporting the caller.
+// The skip is added to the current one, so nested wrappers can each account for their own frame.
+func (l *logger) WithCallerSkip(skip int) Logger {
+	clone := l.clone()
+	clone.callerSkip += skip
+	return clone
+}
+
+/*
+Named returns a new logger that adds name to the logger's name and writes it under DefaultLoggerNameKey.
+Names of nested named loggers are joined with dots, e.g., log.Named("payments").Named("stripe") is named "payments.stripe".
+The level of named loggers can be overridden with Config.LevelRegistry.
+
+Example usage:
+
+	paymentsLog := log.Named("payments")
+	paymentsLog.Debug(ctx, "Charge created", nil) // {"logger": "payments", ...}
+*/
+func (l *logger) Named(name string) Logger {
+	if name == "" {
+		return l
+	}
+	clone := l.clone()
+	if clone.name != "" {
+		name = clone.name + "." + name
+	}
+	clone.name = name
+	clone.fields[DefaultLoggerNameKey] = name
+	return clone
+}
+
+/*
+Log logs a message at the given level, typically a custom level registered with RegisterLevel.
+FATAL and PANIC behave like Fatal and Panic without an error, unknown levels are logged at the Info level.
+
+Example usage:
+
+	log.Log(ctx, PROTOCOL, "Frame received", logger.Fields{"opcode": 2})
+*/
+func (l *logger) Log(ctx context.Context, level LogLevel, msg string, fields Fields) {
+	switch level {
+	case FATAL:
+		l.Fatal(ctx, msg, nil, fields)
+		return
+	case PANIC:
+		l.Panic(ctx, msg, nil, fields)
+		return
+	}
+
+	if !level.IsValid() {
+		level = INFO
+	}
+	l.logWithContext(ctx, level, msg, nil, fields)
+}
+
+// Trace logs a message at the Trace level.
+func (l *logger) Trace(ctx context.Context, msg string, fields Fields) {
+	l.logWithContext(ctx, TRACE, msg, nil, fields)
+}
+
 // Debug logs a message at the Debug level.
 func (l *logger) Debug(ctx context.Context, msg string, fields Fields) {
-	l.logWithContext(ctx, logrus.DebugLevel, msg, fields)
+	l.logWithContext(ctx, DEBUG, msg, nil, fields)
 }
 
 // Info logs a message at the Info level.
 func (l *logger) Info(ctx context.Context, msg string, fields Fields) {
-	l.logWithContext(ctx, logrus.InfoLevel, msg, fields)
+	l.logWithContext(ctx, INFO, msg, nil, fields)
 }
 
 // Warn logs a message at the Warn level.
 func (l *logger) Warn(ctx context.Context, msg string, fields Fields) {
-	l.logWithContext(ctx, logrus.WarnLevel, msg, fields)
+	l.logWithContext(ctx, WARN, msg, nil, fields)
 }
 
 // Error logs a message at the Error level.
 func (l *logger) Error(ctx context.Context, msg string, err error, fields Fields) {
-	if fields == nil {
-		fields = Fields{}
-	}
-	if err != nil {
-		fields[DefaultErrorKey] = err
-	}
-	l.logWithContext(ctx, logrus.ErrorLevel, msg, fields)
+	l.logWithContext(ctx, ERROR, msg, err, fields)
 }
 
 // Fatal logs a message at the Fatal level and exits the application.
 func (l *logger) Fatal(ctx context.Context, msg string, err error, fields Fields) {
-	if fields == nil {
-		fields = Fields{}
-	}
+	l.logWithContext(ctx, FATAL, msg, err, fields)
+}
+
+// Panic logs a message at the Panic level and then panics with err, or with msg if err is nil.
+func (l *logger) Panic(ctx context.Context, msg string, err error, fields Fields) {
+	l.logWithContext(ctx, PANIC, msg, err, fields)
 	if err != nil {
-		fields[DefaultErrorKey] = err
+		panic(err)
 	}
-	l.logWithContext(ctx, logrus.FatalLevel, msg, fields)
+	panic(msg)
 }
 
-// logWithContext logs a message with the provided context and fields.
-func (l *logger) logWithContext(ctx context.Context, level logrus.Level, msg string, fields Fields) {
-	entry := l.baselogger.WithContext(ctx)
+// logWithContext logs a message with the provided context, error and fields.
+func (l *logger) logWithContext(ctx context.Context, level LogLevel, msg string, err error, fields Fields) {
+	// Drop disabled entries before doing any work, Fatal still has to exit and Panic to panic.
+	// Levels are compared by order, so that custom levels are filtered by their own position.
+	if level.Order() < l.minLevelOrder() {
+		if level == FATAL {
+			l.exit(1)
+		}
+		return
+	}
+
+	// Drop sampled out entries.
+	if l.sampler != nil && !l.sampler.sample(level.Base(), msg) {
+		return
+	}
+
+	data := l.mergeFields(l.extractFields(ctx), fields, err)
+
+	// Evaluate lazy fields only for entries that will be written.
+	data = resolveLazyValues(data)
+
+	// Truncate oversized values before they reach the formatter.
+	if l.sizeLimits != nil {
+		msg, data = l.sizeLimits.apply(msg, data)
+	}
+
+	// Drop duplicates of a recently logged entry, a summary is logged when the window closes.
+	if l.suppressor != nil &&
+		!l.suppressor.allow(level.Base(), msg, data, func(count int, fingerprintFields Fields) {
+			l.logSuppressedSummary(level, msg, fingerprintFields, count)
+		}) {
+		return
+	}
+
+	l.write(ctx, level, msg, data)
+	if level == FATAL {
+		l.exit(1)
+	}
+}
 
-	// Merge logger's fields with input fields.
-	mergedFields := make(Fields, len(l.fields)+len(fields))
+// minLevelOrder returns the order of the minimum level logged, taking the level overrides of named loggers into account.
+func (l *logger) minLevelOrder() int {
+	if l.registry != nil && l.name != "" {
+		if level, ok := l.registry.Level(l.name); ok {
+			return level.Order()
+		}
+	}
+	return l.levelOrder
+}
+
+// mergeFields merges the fields extracted from the context, the logger's fields, the input fields and error.
+// The returned map must not be modified, since it is the logger's own fields map when there is nothing to merge.
+func (l *logger) mergeFields(extracted Fields, fields Fields, err error) Fields {
+	if len(extracted) == 0 && len(fields) == 0 && err == nil {
+		return l.fields
+	}
+
+	merged := make(Fields, len(extracted)+len(l.fields)+len(fields)+1)
+	for k, v := range extracted {
+		merged[k] = v
+	}
 	for k, v := range l.fields {
-		mergedFields[k] = v
+		merged[k] = v
 	}
 	for k, v := range fields {
-		mergedFields[k] = v
+		merged[k] = v
 	}
-	entry = entry.WithFields(logrus.Fields(mergedFields))
+	if err != nil {
+		merged[DefaultErrorKey] = err
+	}
+	return merged
+}
 
-	// Log the message at the specified level.
-	switch level {
-	case logrus.DebugLevel:
-		entry.Debug(msg)
-	case logrus.InfoLevel:
-		entry.Info(msg)
-	case logrus.WarnLevel:
-		entry.Warn(msg)
-	case logrus.ErrorLevel:
-		entry.Error(msg)
-	case logrus.FatalLevel:
-		entry.Fatal(msg)
+// logSuppressedSummary logs the number of entries suppressed by the duplicate suppression.
+func (l *logger) logSuppressedSummary(level LogLevel, msg string, fingerprintFields Fields, count int) {
+	fields := make(Fields, len(l.fields)+len(fingerprintFields)+2)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	// Keep the fingerprint fields so the summary can be correlated with the suppressed entries.
+	for k, v := range fingerprintFields {
+		fields[k] = v
+	}
+	fields[DefaultSuppressedCountKey] = count
+	fields[DefaultSuppressedMessageKey] = msg
+	l.write(context.Background(), level, fmt.Sprintf("suppressed %d similar messages", count), fields)
+}
+
+// write fires the hooks and writes the entry with the backend.
+func (l *logger) write(ctx context.Context, level LogLevel, msg string, fields Fields) {
+	// The caller is resolved by the formatter, pass the caller skip through the entry's context.
+	if l.callerSkip > 0 {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx = withCallerSkip(ctx, l.callerSkip)
+	}
+
+	entry := Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+		Fields:  fields,
+	}
+	if entryErr, ok := fields[DefaultErrorKey].(error); ok {
+		entry.Error = entryErr
+	}
+
+	if len(l.hooks) > 0 {
+		l.fireHooks(ctx, &entry)
+	}
+
+	if err := l.backend.Write(ctx, entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
 	}
 }
 
@@ -246,9 +516,19 @@ type noopLogger struct{}
 func NewNoopLogger() Logger {
 	return &noopLogger{}
 }
-func (n *noopLogger) WithFields(fields Fields) Logger                                 { return n }
-func (n *noopLogger) Debug(ctx context.Context, msg string, fields Fields)            {}
-func (n *noopLogger) Info(ctx context.Context, msg string, fields Fields)             {}
-func (n *noopLogger) Warn(ctx context.Context, msg string, fields Fields)             {}
-func (n *noopLogger) Error(ctx context.Context, msg string, err error, fields Fields) {}
-func (n *noopLogger) Fatal(ctx context.Context, msg string, err error, fields Fields) {}
+func (n *noopLogger) WithFields(fields Fields) Logger                                    { return n }
+func (n *noopLogger) WithField(key string, value interface{}) Logger                     { return n }
+func (n *noopLogger) WithError(err error) Logger                                         { return n }
+func (n *noopLogger) WithString(key string, value string) Logger                         { return n }
+func (n *noopLogger) WithInt(key string, value int) Logger                               { return n }
+func (n *noopLogger) WithDuration(key string, value time.Duration) Logger                { return n }
+func (n *noopLogger) WithCallerSkip(skip int) Logger                                     { return n }
+func (n *noopLogger) Named(name string) Logger                                           { return n }
+func (n *noopLogger) Log(ctx context.Context, level LogLevel, msg string, fields Fields) {}
+func (n *noopLogger) Trace(ctx context.Context, msg string, fields Fields)               {}
+func (n *noopLogger) Debug(ctx context.Context, msg string, fields Fields)               {}
+func (n *noopLogger) Info(ctx context.Context, msg string, fields Fields)                {}
+func (n *noopLogger) Warn(ctx context.Context, msg string, fields Fields)                {}
+func (n *noopLogger) Error(ctx context.Context, msg string, err error, fields Fields)    {}
+func (n *noopLogger) Fatal(ctx context.Context, msg string, err error, fields Fields)    {}
+func (n *noopLogger) Panic(ctx context.Context, msg string, err error, fields Fields)    {}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/kittipat1413/go-common/framework/logger"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewDefaultLogger(t *testing.T) {
@@ -325,6 +326,40 @@ func TestLogger_WithFields(t *testing.T) {
 	assert.Equal(t, "info", logEntry["severity"], "severity should match")
 }
 
+func TestLogger_WithTypedFields(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Output: buffer,
+	})
+	require.NoError(t, err)
+
+	base := log.WithField("component", "orders")
+	base.
+		WithError(errors.New("test error")).
+		WithString("order_id", "ord_123").
+		WithInt("attempt", 3).
+		WithDuration("elapsed", 1500*time.Millisecond).
+		Warn(context.Background(), "Retrying order", nil)
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+	assert.Equal(t, "orders", logEntry["component"])
+	assert.Equal(t, "test error", logEntry["error"])
+	assert.Equal(t, "ord_123", logEntry["order_id"])
+	assert.Equal(t, float64(3), logEntry["attempt"])
+	assert.Equal(t, "1.5s", logEntry["elapsed"])
+
+	// The base logger is not modified by the derived loggers.
+	buffer.Reset()
+	base.Info(context.Background(), "Base message", nil)
+	logEntry = nil
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+	assert.Equal(t, "orders", logEntry["component"])
+	assert.NotContains(t, logEntry, "error")
+	assert.NotContains(t, logEntry, "order_id")
+}
+
 func TestNoopLogger(t *testing.T) {
 	log := logger.NewNoopLogger()
 	assert.NotNil(t, log, "noopLogger should not be nil")
@@ -338,6 +373,8 @@ func TestNoopLogger(t *testing.T) {
 		log.Info(ctx, "Info message", fields)
 		log.Warn(ctx, "Warn message", fields)
 		log.Error(ctx, "Error message", errors.New("test error"), fields)
+		log.WithField("key", "value").WithError(errors.New("test error")).Info(ctx, "Info message", nil)
+		log.Panic(ctx, "Panic message", errors.New("test error"), fields)
 		// Commenting out Fatal to avoid calling os.Exit in tests
 		// log.Fatal(ctx, "Fatal message", errors.New("test error"), fields)
 	}, "noopLogger methods should not panic")
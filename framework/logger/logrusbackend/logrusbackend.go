@@ -0,0 +1,149 @@
+package logrusbackend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/util/pool"
+	"github.com/sirupsen/logrus"
+)
+
+var _ logger.Backend = (*Backend)(nil)
+
+// levelMapper maps the built-in levels to the logrus levels.
+var levelMapper = map[logger.LogLevel]logrus.Level{
+	logger.TRACE: logrus.TraceLevel,
+	logger.DEBUG: logrus.DebugLevel,
+	logger.INFO:  logrus.InfoLevel,
+	logger.WARN:  logrus.WarnLevel,
+	logger.ERROR: logrus.ErrorLevel,
+	logger.FATAL: logrus.FatalLevel,
+	logger.PANIC: logrus.PanicLevel,
+}
+
+// Level returns the logrus level of level. Custom levels are written at the logrus level of their base level.
+func Level(level logger.LogLevel) logrus.Level {
+	return levelMapper[level.Base()]
+}
+
+/*
+Backend is the logger.Backend of logrus, for the services already relying on logrus formatters or hooks. It formats
+entries with a logrus.Formatter and writes them to its output. If the output implements logger.LevelWriter (e.g.,
+logger.LevelRouter), entries are routed based on their level. It is a package of its own, so that the services using
+the other backends do not depend on logrus.
+
+Example usage:
+
+	logConfig := logger.Config{
+		Level:   logger.INFO,
+		Backend: logrusbackend.New(&logrus.JSONFormatter{}),
+	}
+*/
+type Backend struct {
+	logger    *logrus.Logger
+	formatter logrus.Formatter
+}
+
+// New returns a Backend using formatter, or a logrus.JSONFormatter if formatter is nil.
+func New(formatter logrus.Formatter) *Backend {
+	if formatter == nil {
+		formatter = &logrus.JSONFormatter{}
+	}
+	logrusLogger := logrus.New()
+	logrusLogger.SetFormatter(formatter)
+	return &Backend{logger: logrusLogger, formatter: formatter}
+}
+
+// SetLevel implements the logger.Backend interface. Custom levels are written at the logrus level of their base level.
+func (b *Backend) SetLevel(level logger.LogLevel) {
+	b.logger.SetLevel(Level(level))
+}
+
+// SetOutput implements the logger.Backend interface.
+func (b *Backend) SetOutput(output io.Writer) {
+	// Level-aware outputs receive the formatted entry together with its level.
+	if levelWriter, ok := output.(logger.LevelWriter); ok {
+		b.logger.SetFormatter(&levelWriterFormatter{
+			formatter: b.formatter,
+			writer:    levelWriter,
+		})
+		b.logger.SetOutput(io.Discard)
+		return
+	}
+	b.logger.SetFormatter(b.formatter)
+	b.logger.SetOutput(output)
+}
+
+// entryPool reuses logrus entries across writes.
+var entryPool = pool.New(
+	func() *logrus.Entry { return &logrus.Entry{} },
+	func(e *logrus.Entry) {
+		e.Data = nil
+		e.Context = nil
+	},
+)
+
+// Write implements the logger.Backend interface.
+func (b *Backend) Write(ctx context.Context, entry logger.Entry) error {
+	level := Level(entry.Level)
+
+	// The level router reads custom levels from the entry's context, since logrus only knows its own levels.
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = context.WithValue(ctx, levelContextKey{}, entry.Level)
+
+	// logrus copies the entry before formatting it, so entries can be reused and
+	// the fields map can be shared without being modified.
+	e := entryPool.Get()
+	e.Logger = b.logger
+	e.Data = logrus.Fields(entry.Fields)
+	e.Context = ctx
+	e.Time = entry.Time
+
+	if level == logrus.PanicLevel {
+		// logrus panics with its own *logrus.Entry after writing, the Logger raises the caller-facing panic instead.
+		defer func() {
+			if r := recover(); r != nil {
+				if _, ok := r.(*logrus.Entry); !ok {
+					panic(r)
+				}
+			}
+		}()
+	}
+	e.Log(level, entry.Message)
+
+	entryPool.Put(e)
+	return nil
+}
+
+type levelContextKey struct{}
+
+// levelWriterFormatter wraps a formatter and writes the formatted entry to a LevelWriter,
+// since logrus only passes the serialized bytes to its output.
+type levelWriterFormatter struct {
+	formatter logrus.Formatter
+	writer    logger.LevelWriter
+}
+
+// Format implements the logrus.Formatter interface.
+func (f *levelWriterFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	serialized, err := f.formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+	level := logger.INFO
+	if entry.Context != nil {
+		if entryLevel, ok := entry.Context.Value(levelContextKey{}).(logger.LogLevel); ok {
+			level = entryLevel
+		}
+	}
+	if _, err := f.writer.WriteLevel(level, serialized); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+	}
+	// The entry has already been written, nothing is left for the logrus output.
+	return nil, nil
+}
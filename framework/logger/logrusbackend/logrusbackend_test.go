@@ -0,0 +1,88 @@
+package logrusbackend_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/logger/logrusbackend"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noticeLevel is a custom level between INFO and WARN, registered once for the package tests.
+const noticeLevel logger.LogLevel = "notice"
+
+func init() {
+	if err := logger.RegisterLevel(logger.CustomLevel{
+		Name:  noticeLevel,
+		Order: logger.INFO.Order() + 50,
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestBackend(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:       logger.INFO,
+		ServiceName: "orders",
+		Output:      buffer,
+		Backend:     logrusbackend.New(nil),
+	})
+	require.NoError(t, err)
+
+	log.Debug(context.Background(), "dropped", nil)
+	log.Error(context.Background(), "error message", errors.New("boom"), logger.Fields{"order_id": 42})
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &entry))
+	assert.Equal(t, "error", entry["level"])
+	assert.Equal(t, "error message", entry["msg"])
+	assert.Equal(t, "boom", entry["error"])
+	assert.Equal(t, float64(42), entry["order_id"])
+	assert.Equal(t, "orders", entry[logger.DefaultServiceNameKey])
+}
+
+func TestBackend_LevelWriter(t *testing.T) {
+	info, notice := &bytes.Buffer{}, &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level: logger.INFO,
+		Output: logger.NewLevelRouter(
+			logger.LevelRoute{Levels: []logger.LogLevel{logger.INFO}, Writer: info},
+			logger.LevelRoute{Levels: []logger.LogLevel{noticeLevel}, Writer: notice},
+		),
+		Backend: logrusbackend.New(&logrus.TextFormatter{DisableTimestamp: true}),
+	})
+	require.NoError(t, err)
+
+	log.Info(context.Background(), "info message", nil)
+	log.Log(context.Background(), noticeLevel, "notice message", nil)
+
+	assert.Equal(t, "level=info msg=\"info message\"\n", info.String())
+	assert.Equal(t, "level=info msg=\"notice message\"\n", notice.String(), "custom levels are routed by their own level")
+}
+
+func TestBackend_Panic(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{Level: logger.INFO, Output: buffer, Backend: logrusbackend.New(nil)})
+	require.NoError(t, err)
+
+	errPanic := errors.New("boom")
+	assert.PanicsWithValue(t, errPanic, func() {
+		log.Panic(context.Background(), "panic message", errPanic, nil)
+	}, "the Logger panics with the error rather than the logrus entry")
+	assert.Contains(t, buffer.String(), `"level":"panic"`)
+}
+
+func TestLevel(t *testing.T) {
+	assert.Equal(t, logrus.TraceLevel, logrusbackend.Level(logger.TRACE))
+	assert.Equal(t, logrus.WarnLevel, logrusbackend.Level(logger.WARN))
+	assert.Equal(t, logrus.PanicLevel, logrusbackend.Level(logger.PANIC))
+	assert.Equal(t, logrus.InfoLevel, logrusbackend.Level(noticeLevel), "custom levels map to their base level")
+	assert.Equal(t, logrus.InfoLevel, logrusbackend.Level("unknown"))
+}
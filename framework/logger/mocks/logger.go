@@ -7,6 +7,7 @@ package logger_mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	logger "github.com/kittipat1413/go-common/framework/logger"
@@ -83,6 +84,56 @@ func (mr *MockLoggerMockRecorder) Info(ctx, msg, fields interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockLogger)(nil).Info), ctx, msg, fields)
 }
 
+// Log mocks base method.
+func (m *MockLogger) Log(ctx context.Context, level logger.LogLevel, msg string, fields logger.Fields) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Log", ctx, level, msg, fields)
+}
+
+// Log indicates an expected call of Log.
+func (mr *MockLoggerMockRecorder) Log(ctx, level, msg, fields interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Log", reflect.TypeOf((*MockLogger)(nil).Log), ctx, level, msg, fields)
+}
+
+// Named mocks base method.
+func (m *MockLogger) Named(name string) logger.Logger {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Named", name)
+	ret0, _ := ret[0].(logger.Logger)
+	return ret0
+}
+
+// Named indicates an expected call of Named.
+func (mr *MockLoggerMockRecorder) Named(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Named", reflect.TypeOf((*MockLogger)(nil).Named), name)
+}
+
+// Panic mocks base method.
+func (m *MockLogger) Panic(ctx context.Context, msg string, err error, fields logger.Fields) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Panic", ctx, msg, err, fields)
+}
+
+// Panic indicates an expected call of Panic.
+func (mr *MockLoggerMockRecorder) Panic(ctx, msg, err, fields interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Panic", reflect.TypeOf((*MockLogger)(nil).Panic), ctx, msg, err, fields)
+}
+
+// Trace mocks base method.
+func (m *MockLogger) Trace(ctx context.Context, msg string, fields logger.Fields) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Trace", ctx, msg, fields)
+}
+
+// Trace indicates an expected call of Trace.
+func (mr *MockLoggerMockRecorder) Trace(ctx, msg, fields interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trace", reflect.TypeOf((*MockLogger)(nil).Trace), ctx, msg, fields)
+}
+
 // Warn mocks base method.
 func (m *MockLogger) Warn(ctx context.Context, msg string, fields logger.Fields) {
 	m.ctrl.T.Helper()
@@ -95,6 +146,62 @@ func (mr *MockLoggerMockRecorder) Warn(ctx, msg, fields interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warn", reflect.TypeOf((*MockLogger)(nil).Warn), ctx, msg, fields)
 }
 
+// WithCallerSkip mocks base method.
+func (m *MockLogger) WithCallerSkip(skip int) logger.Logger {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithCallerSkip", skip)
+	ret0, _ := ret[0].(logger.Logger)
+	return ret0
+}
+
+// WithCallerSkip indicates an expected call of WithCallerSkip.
+func (mr *MockLoggerMockRecorder) WithCallerSkip(skip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithCallerSkip", reflect.TypeOf((*MockLogger)(nil).WithCallerSkip), skip)
+}
+
+// WithDuration mocks base method.
+func (m *MockLogger) WithDuration(key string, value time.Duration) logger.Logger {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithDuration", key, value)
+	ret0, _ := ret[0].(logger.Logger)
+	return ret0
+}
+
+// WithDuration indicates an expected call of WithDuration.
+func (mr *MockLoggerMockRecorder) WithDuration(key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithDuration", reflect.TypeOf((*MockLogger)(nil).WithDuration), key, value)
+}
+
+// WithError mocks base method.
+func (m *MockLogger) WithError(err error) logger.Logger {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithError", err)
+	ret0, _ := ret[0].(logger.Logger)
+	return ret0
+}
+
+// WithError indicates an expected call of WithError.
+func (mr *MockLoggerMockRecorder) WithError(err interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithError", reflect.TypeOf((*MockLogger)(nil).WithError), err)
+}
+
+// WithField mocks base method.
+func (m *MockLogger) WithField(key string, value interface{}) logger.Logger {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithField", key, value)
+	ret0, _ := ret[0].(logger.Logger)
+	return ret0
+}
+
+// WithField indicates an expected call of WithField.
+func (mr *MockLoggerMockRecorder) WithField(key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithField", reflect.TypeOf((*MockLogger)(nil).WithField), key, value)
+}
+
 // WithFields mocks base method.
 func (m *MockLogger) WithFields(fields logger.Fields) logger.Logger {
 	m.ctrl.T.Helper()
@@ -108,3 +215,31 @@ func (mr *MockLoggerMockRecorder) WithFields(fields interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithFields", reflect.TypeOf((*MockLogger)(nil).WithFields), fields)
 }
+
+// WithInt mocks base method.
+func (m *MockLogger) WithInt(key string, value int) logger.Logger {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithInt", key, value)
+	ret0, _ := ret[0].(logger.Logger)
+	return ret0
+}
+
+// WithInt indicates an expected call of WithInt.
+func (mr *MockLoggerMockRecorder) WithInt(key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithInt", reflect.TypeOf((*MockLogger)(nil).WithInt), key, value)
+}
+
+// WithString mocks base method.
+func (m *MockLogger) WithString(key, value string) logger.Logger {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithString", key, value)
+	ret0, _ := ret[0].(logger.Logger)
+	return ret0
+}
+
+// WithString indicates an expected call of WithString.
+func (mr *MockLoggerMockRecorder) WithString(key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithString", reflect.TypeOf((*MockLogger)(nil).WithString), key, value)
+}
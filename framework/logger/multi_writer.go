@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultFailoverRetryInterval is the delay before a FailoverWriter retries a failed primary writer.
+const DefaultFailoverRetryInterval = 5 * time.Second
+
+/*
+MultiWriter is a LevelWriter duplicating entries to several writers, like io.MultiWriter, except that
+writers are isolated from each other: a failing or panicking writer does not prevent the entry from
+being written to the others. Writers implementing LevelWriter receive the level of the entry.
+
+Example usage:
+
+	logConfig := logger.Config{
+		Level:  logger.INFO,
+		Output: logger.NewMultiWriter(os.Stdout, syslogWriter),
+	}
+*/
+type MultiWriter struct {
+	writers []io.Writer
+}
+
+// NewMultiWriter creates a MultiWriter writing to every writer.
+func NewMultiWriter(writers ...io.Writer) *MultiWriter {
+	return &MultiWriter{writers: writers}
+}
+
+// Write writes p to every writer, as if it was an INFO entry.
+func (w *MultiWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(INFO, p)
+}
+
+// WriteLevel writes p to every writer and returns the errors of the failing ones.
+func (w *MultiWriter) WriteLevel(level LogLevel, p []byte) (int, error) {
+	var errs []error
+	for _, writer := range w.writers {
+		if err := safeWriteLevel(writer, level, p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(p), errors.Join(errs...)
+}
+
+// ForceFlush flushes every writer. It implements the Flusher interface.
+func (w *MultiWriter) ForceFlush(ctx context.Context) error {
+	var errs []error
+	for _, writer := range w.writers {
+		if err := flushWriter(ctx, writer); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+/*
+FailoverWriter is a LevelWriter writing entries to a primary writer, such as a TCP collector, and degrading
+to a secondary writer, such as os.Stderr, when the primary fails. Entries are never silently dropped:
+an entry failing on the primary is written to the secondary instead.
+
+Once the primary failed, entries go straight to the secondary, so a dead collector does not slow down every
+write, and the primary is retried after the retry interval. Writers with a blocking Write should enforce
+their own write timeouts (e.g., net.Conn write deadlines).
+
+Example usage:
+
+	syslogWriter, err := logger.NewSyslogWriter(logger.SyslogConfig{Network: "tcp", Address: "syslog.internal:514"})
+	if err != nil {
+		// Handle error
+	}
+
+	logConfig := logger.Config{
+		Level:  logger.INFO,
+		Output: logger.NewFailoverWriter(syslogWriter, os.Stderr),
+	}
+*/
+type FailoverWriter struct {
+	primary       io.Writer
+	secondary     io.Writer
+	retryInterval time.Duration
+
+	mu          sync.Mutex
+	failedUntil time.Time
+	failovers   atomic.Uint64
+}
+
+// NewFailoverWriter creates a FailoverWriter retrying the primary after DefaultFailoverRetryInterval.
+func NewFailoverWriter(primary, secondary io.Writer) *FailoverWriter {
+	return NewFailoverWriterWithRetryInterval(primary, secondary, DefaultFailoverRetryInterval)
+}
+
+// NewFailoverWriterWithRetryInterval creates a FailoverWriter retrying the primary after retryInterval.
+func NewFailoverWriterWithRetryInterval(primary, secondary io.Writer, retryInterval time.Duration) *FailoverWriter {
+	if retryInterval <= 0 {
+		retryInterval = DefaultFailoverRetryInterval
+	}
+	return &FailoverWriter{
+		primary:       primary,
+		secondary:     secondary,
+		retryInterval: retryInterval,
+	}
+}
+
+// Write writes p, as if it was an INFO entry.
+func (w *FailoverWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(INFO, p)
+}
+
+// WriteLevel writes p to the primary writer, or to the secondary writer if the primary fails or is degraded.
+func (w *FailoverWriter) WriteLevel(level LogLevel, p []byte) (int, error) {
+	if !w.Degraded() {
+		err := safeWriteLevel(w.primary, level, p)
+		if err == nil {
+			return len(p), nil
+		}
+		w.mu.Lock()
+		w.failedUntil = time.Now().Add(w.retryInterval)
+		w.mu.Unlock()
+		w.failovers.Add(1)
+		fmt.Fprintf(os.Stderr, "Primary log output failed, writing to the secondary output: %v\n", err)
+	}
+
+	if err := safeWriteLevel(w.secondary, level, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Degraded reports whether entries are currently written to the secondary writer.
+func (w *FailoverWriter) Degraded() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return time.Now().Before(w.failedUntil)
+}
+
+// Failovers returns the number of times the primary writer failed and entries were written to the secondary.
+func (w *FailoverWriter) Failovers() uint64 {
+	return w.failovers.Load()
+}
+
+// ForceFlush flushes both writers. It implements the Flusher interface.
+func (w *FailoverWriter) ForceFlush(ctx context.Context) error {
+	return errors.Join(flushWriter(ctx, w.primary), flushWriter(ctx, w.secondary))
+}
+
+// safeWriteLevel writes p to w, turning a panic of the writer into an error.
+func safeWriteLevel(w io.Writer, level LogLevel, p []byte) (err error) {
+	if w == nil {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("log writer panicked: %v", r)
+		}
+	}()
+	if levelWriter, ok := w.(LevelWriter); ok {
+		_, err = levelWriter.WriteLevel(level, p)
+	} else {
+		_, err = w.Write(p)
+	}
+	return err
+}
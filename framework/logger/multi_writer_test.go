@@ -0,0 +1,121 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingWriter struct {
+	err   error
+	calls int
+}
+
+func (w *failingWriter) Write([]byte) (int, error) {
+	w.calls++
+	if w.err != nil {
+		return 0, w.err
+	}
+	return 0, nil
+}
+
+type panickingWriter struct{}
+
+func (panickingWriter) Write([]byte) (int, error) {
+	panic("writer exploded")
+}
+
+type levelRecordingWriter struct {
+	levels []logger.LogLevel
+}
+
+func (w *levelRecordingWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(logger.INFO, p)
+}
+
+func (w *levelRecordingWriter) WriteLevel(level logger.LogLevel, p []byte) (int, error) {
+	w.levels = append(w.levels, level)
+	return len(p), nil
+}
+
+func TestMultiWriter_IsolatesWriters(t *testing.T) {
+	first := &bytes.Buffer{}
+	last := &levelRecordingWriter{}
+	writer := logger.NewMultiWriter(
+		first,
+		&failingWriter{err: errors.New("connection refused")},
+		panickingWriter{},
+		last,
+	)
+
+	n, err := writer.WriteLevel(logger.ERROR, []byte("entry\n"))
+	assert.Equal(t, 6, n)
+	assert.ErrorContains(t, err, "connection refused")
+	assert.ErrorContains(t, err, "writer exploded")
+	assert.Equal(t, "entry\n", first.String())
+	assert.Equal(t, []logger.LogLevel{logger.ERROR}, last.levels)
+}
+
+func TestFailoverWriter(t *testing.T) {
+	primary := &failingWriter{err: errors.New("connection refused")}
+	secondary := &bytes.Buffer{}
+	writer := logger.NewFailoverWriterWithRetryInterval(primary, secondary, 50*time.Millisecond)
+
+	n, err := writer.Write([]byte("first\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+	assert.True(t, writer.Degraded())
+
+	// While degraded, the primary is not retried.
+	_, err = writer.Write([]byte("second\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, "first\nsecond\n", secondary.String())
+	assert.Equal(t, uint64(1), writer.Failovers())
+
+	// The primary is retried after the retry interval and used again once it recovers.
+	primary.err = nil
+	assert.Eventually(t, func() bool { return !writer.Degraded() }, time.Second, 10*time.Millisecond)
+	_, err = writer.Write([]byte("third\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, primary.calls)
+	assert.Equal(t, "first\nsecond\n", secondary.String())
+	assert.Equal(t, uint64(1), writer.Failovers())
+}
+
+func TestFailoverWriter_PanickingPrimary(t *testing.T) {
+	secondary := &levelRecordingWriter{}
+	writer := logger.NewFailoverWriter(panickingWriter{}, secondary)
+
+	_, err := writer.WriteLevel(logger.WARN, []byte("entry\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []logger.LogLevel{logger.WARN}, secondary.levels)
+}
+
+func TestFailoverWriter_SecondaryFailure(t *testing.T) {
+	writer := logger.NewFailoverWriter(
+		&failingWriter{err: errors.New("connection refused")},
+		&failingWriter{err: errors.New("disk full")},
+	)
+
+	_, err := writer.Write([]byte("entry\n"))
+	assert.ErrorContains(t, err, "disk full")
+}
+
+func TestFailoverWriter_AsLoggerOutput(t *testing.T) {
+	secondary := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Output: logger.NewFailoverWriter(&failingWriter{err: errors.New("connection refused")}, secondary),
+	})
+	require.NoError(t, err)
+
+	log.Info(context.Background(), "degraded message", nil)
+	assert.Contains(t, secondary.String(), `"message":"degraded message"`)
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// DefaultLoggerNameKey is the default key used for the name of named loggers in logs.
+const DefaultLoggerNameKey = "logger"
+
+// ErrInvalidLevelSpec is returned when a level spec cannot be parsed.
+var ErrInvalidLevelSpec = errors.New("invalid level spec")
+
+/*
+LevelRegistry holds per-name level overrides for named loggers (see Logger.Named).
+Names are hierarchical: a logger named "payments.stripe" uses the override of "payments.stripe",
+then of "payments", and falls back to Config.Level. Overrides can be changed at any time and
+apply to existing loggers, e.g., from an admin endpoint.
+
+Example usage:
+
+	registry := logger.NewLevelRegistry()
+	if err := registry.SetSpec("payments=debug, http=warn"); err != nil {
+		// Handle error
+	}
+
+	log, err := logger.NewLogger(logger.Config{
+		Level:         logger.INFO,
+		LevelRegistry: registry,
+	})
+	paymentsLog := log.Named("payments") // logs DEBUG and above
+	httpLog := log.Named("http")         // logs WARN and above
+*/
+type LevelRegistry struct {
+	mu     sync.RWMutex
+	levels map[string]LogLevel
+}
+
+// NewLevelRegistry returns an empty LevelRegistry.
+func NewLevelRegistry() *LevelRegistry {
+	return &LevelRegistry{levels: make(map[string]LogLevel)}
+}
+
+// SetLevel sets the level of the loggers named name and of their descendants without their own override.
+func (r *LevelRegistry) SetLevel(name string, level LogLevel) error {
+	if name == "" || !level.IsValid() {
+		return ErrInvalidLevelSpec
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.levels[name] = level
+	return nil
+}
+
+// UnsetLevel removes the override of name.
+func (r *LevelRegistry) UnsetLevel(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.levels, name)
+}
+
+/*
+SetSpec replaces all overrides with the ones of spec, a comma-separated list of name=level pairs.
+If spec is invalid, the current overrides are kept.
+
+Example usage:
+
+	err := registry.SetSpec(os.Getenv("LOG_LEVELS")) // e.g., "payments=debug,http=warn"
+*/
+func (r *LevelRegistry) SetSpec(spec string) error {
+	levels, err := parseLevelSpec(spec)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.levels = levels
+	return nil
+}
+
+// Level returns the level override of the logger named name, looking up its ancestors if it has none.
+func (r *LevelRegistry) Level(name string) (LogLevel, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for name != "" {
+		if level, ok := r.levels[name]; ok {
+			return level, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return "", false
+}
+
+// parseLevelSpec parses a comma-separated list of name=level pairs.
+func parseLevelSpec(spec string) (map[string]LogLevel, error) {
+	levels := make(map[string]LogLevel)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, level, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		logLevel := LogLevel(strings.TrimSpace(level))
+		if !logLevel.IsValid() {
+			logLevel = LogLevel(strings.ToLower(string(logLevel)))
+		}
+		if !ok || name == "" || !logLevel.IsValid() {
+			return nil, ErrInvalidLevelSpec
+		}
+		levels[name] = logLevel
+	}
+	return levels, nil
+}
@@ -0,0 +1,89 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_Named(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{Level: logger.INFO, Output: buffer})
+	require.NoError(t, err)
+
+	log.Named("payments").Named("stripe").Info(context.Background(), "charge created", nil)
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+	assert.Equal(t, "payments.stripe", logEntry[logger.DefaultLoggerNameKey])
+}
+
+func TestLogger_NamedLevelOverrides(t *testing.T) {
+	registry := logger.NewLevelRegistry()
+	require.NoError(t, registry.SetSpec("payments=debug, http=WARN"))
+
+	log, recorder := newRecordingLogger(t, logger.INFO, registry)
+	ctx := context.Background()
+
+	log.Debug(ctx, "root debug", nil)
+	log.Named("payments").Debug(ctx, "payments debug", nil)
+	log.Named("payments").Named("stripe").Debug(ctx, "stripe debug", nil)
+	log.Named("http").Info(ctx, "http info", nil)
+	log.Named("http").Warn(ctx, "http warn", nil)
+	log.Named("orders").Info(ctx, "orders info", nil)
+
+	recorder.AssertNotLogged(t, logger.DEBUG, "root debug")
+	recorder.AssertLogged(t, logger.DEBUG, "payments debug", logger.HasField(logger.DefaultLoggerNameKey, "payments"))
+	recorder.AssertLogged(t, logger.DEBUG, "stripe debug", logger.HasField(logger.DefaultLoggerNameKey, "payments.stripe"))
+	recorder.AssertNotLogged(t, logger.INFO, "http info")
+	recorder.AssertLogged(t, logger.WARN, "http warn")
+	recorder.AssertLogged(t, logger.INFO, "orders info")
+
+	// Overrides apply to existing loggers.
+	paymentsLog := log.Named("payments")
+	registry.UnsetLevel("payments")
+	recorder.Reset()
+	paymentsLog.Debug(ctx, "payments debug", nil)
+	assert.Equal(t, 0, recorder.Len())
+}
+
+func TestLevelRegistry_SetSpec(t *testing.T) {
+	registry := logger.NewLevelRegistry()
+	require.NoError(t, registry.SetSpec("payments=debug,http=warn,"))
+
+	level, ok := registry.Level("payments.stripe")
+	assert.True(t, ok)
+	assert.Equal(t, logger.DEBUG, level)
+	_, ok = registry.Level("orders")
+	assert.False(t, ok)
+
+	for _, spec := range []string{"payments", "=debug", "payments=verbose"} {
+		assert.ErrorIs(t, registry.SetSpec(spec), logger.ErrInvalidLevelSpec, spec)
+	}
+	// Invalid specs keep the current overrides.
+	level, ok = registry.Level("http")
+	assert.True(t, ok)
+	assert.Equal(t, logger.WARN, level)
+
+	assert.ErrorIs(t, registry.SetLevel("", logger.INFO), logger.ErrInvalidLevelSpec)
+	assert.ErrorIs(t, registry.SetLevel("http", logger.LogLevel("verbose")), logger.ErrInvalidLevelSpec)
+}
+
+// newRecordingLogger returns a logger capturing its entries in a Recorder.
+func newRecordingLogger(t *testing.T, level logger.LogLevel, registry *logger.LevelRegistry) (logger.Logger, *logger.Recorder) {
+	t.Helper()
+	recorder := &logger.Recorder{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:         level,
+		Output:        &bytes.Buffer{},
+		Hooks:         []logger.Hook{recorder},
+		LevelRegistry: registry,
+	})
+	require.NoError(t, err)
+	return log, recorder
+}
@@ -0,0 +1,236 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
+)
+
+// otlpSinkInstrumentationName is the instrumentation scope name of the records emitted by the OTLPSink.
+const otlpSinkInstrumentationName = "github.com/kittipat1413/go-common/framework/logger"
+
+// otlpSeverityMapper maps log levels to OpenTelemetry severities.
+var otlpSeverityMapper = map[LogLevel]otellog.Severity{
+	TRACE: otellog.SeverityTrace,
+	DEBUG: otellog.SeverityDebug,
+	INFO:  otellog.SeverityInfo,
+	WARN:  otellog.SeverityWarn,
+	ERROR: otellog.SeverityError,
+	FATAL: otellog.SeverityFatal,
+	PANIC: otellog.SeverityFatal4,
+}
+
+// OTLPSinkConfig holds the configuration of an OTLPSink.
+type OTLPSinkConfig struct {
+	// Endpoint is the host and port of the OTLP gRPC collector (e.g., "otel-collector:4317").
+	// If empty, the exporter uses the OTEL_EXPORTER_OTLP_LOGS_ENDPOINT / OTEL_EXPORTER_OTLP_ENDPOINT environment variables.
+	Endpoint string
+	// Insecure disables TLS for the connection to the collector.
+	Insecure bool
+	// Headers are additional gRPC headers sent with every export request (e.g., authentication).
+	Headers map[string]string
+	// ServiceName is the service.name resource attribute. Can be overridden by the environment variable `OTEL_SERVICE_NAME`.
+	ServiceName string
+	// Levels is the list of levels shipped to the collector. An empty list ships all levels.
+	Levels []LogLevel
+	// ExportInterval is the maximum delay between two batch exports. Defaults to one second.
+	ExportInterval time.Duration
+	// MaxQueueSize is the maximum number of records buffered before new records are dropped. Defaults to 2048.
+	MaxQueueSize int
+	// MaxBatchSize is the maximum number of records sent in a single export. Defaults to 512.
+	MaxBatchSize int
+	// Exporter overrides the OTLP gRPC exporter, e.g., to use the HTTP exporter or an in-memory exporter in tests.
+	// When set, Endpoint, Insecure and Headers are ignored.
+	Exporter sdklog.Exporter
+}
+
+/*
+OTLPSink is a Hook that converts log entries to OpenTelemetry LogRecords and ships them to an OTLP collector.
+Records are batched in the background and failed exports are retried with exponential backoff.
+The trace and span IDs are taken from the context of the entry, and Fields are converted to record attributes.
+
+Shutdown must be called before the application exits to flush the buffered records.
+
+Example usage:
+
+	sink, err := logger.NewOTLPSink(ctx, logger.OTLPSinkConfig{
+		Endpoint:    "otel-collector:4317",
+		Insecure:    true,
+		ServiceName: "my-service",
+	})
+	if err != nil {
+		// Handle error
+	}
+	defer sink.Shutdown(context.Background())
+
+	logConfig := logger.Config{
+		Level: logger.INFO,
+		Hooks: []logger.Hook{sink},
+	}
+*/
+type OTLPSink struct {
+	provider *sdklog.LoggerProvider
+	logger   otellog.Logger
+	levels   []LogLevel
+}
+
+// NewOTLPSink creates an OTLPSink and its underlying exporter.
+func NewOTLPSink(ctx context.Context, config OTLPSinkConfig) (*OTLPSink, error) {
+	exporter := config.Exporter
+	if exporter == nil {
+		opts := []otlploggrpc.Option{
+			otlploggrpc.WithRetry(otlploggrpc.RetryConfig{
+				Enabled:         true,
+				InitialInterval: 5 * time.Second,
+				MaxInterval:     30 * time.Second,
+				MaxElapsedTime:  time.Minute,
+			}),
+		}
+		if config.Endpoint != "" {
+			opts = append(opts, otlploggrpc.WithEndpoint(config.Endpoint))
+		}
+		if config.Insecure {
+			opts = append(opts, otlploggrpc.WithInsecure())
+		}
+		if len(config.Headers) > 0 {
+			opts = append(opts, otlploggrpc.WithHeaders(config.Headers))
+		}
+
+		var err error
+		exporter, err = otlploggrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize OTLP log exporter: %w", err)
+		}
+	}
+
+	serviceName := config.ServiceName
+	if envServiceName := os.Getenv("OTEL_SERVICE_NAME"); envServiceName != "" {
+		serviceName = envServiceName
+	}
+	res := resource.Default()
+	if serviceName != "" {
+		var err error
+		res, err = resource.Merge(
+			resource.Default(),
+			resource.NewSchemaless(semconv.ServiceNameKey.String(serviceName)),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create resource: %w", err)
+		}
+	}
+
+	var batchOpts []sdklog.BatchProcessorOption
+	if config.ExportInterval > 0 {
+		batchOpts = append(batchOpts, sdklog.WithExportInterval(config.ExportInterval))
+	}
+	if config.MaxQueueSize > 0 {
+		batchOpts = append(batchOpts, sdklog.WithMaxQueueSize(config.MaxQueueSize))
+	}
+	if config.MaxBatchSize > 0 {
+		batchOpts = append(batchOpts, sdklog.WithExportMaxBatchSize(config.MaxBatchSize))
+	}
+
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter, batchOpts...)),
+	)
+
+	return &OTLPSink{
+		provider: provider,
+		logger:   provider.Logger(otlpSinkInstrumentationName),
+		levels:   config.Levels,
+	}, nil
+}
+
+// Levels implements the Hook interface.
+func (s *OTLPSink) Levels() []LogLevel {
+	return s.levels
+}
+
+// Fire implements the Hook interface by converting the entry to a LogRecord and queueing it for export.
+func (s *OTLPSink) Fire(ctx context.Context, entry Entry) error {
+	var record otellog.Record
+	record.SetTimestamp(entry.Time)
+	record.SetObservedTimestamp(time.Now())
+	// Custom levels use the severity number of their base level.
+	record.SetSeverity(otlpSeverityMapper[entry.Level.Base()])
+	record.SetSeverityText(strings.ToUpper(levelSeverity(entry.Level)))
+	record.SetBody(otellog.StringValue(entry.Message))
+
+	attributes := make([]otellog.KeyValue, 0, len(entry.Fields))
+	for key, value := range entry.Fields {
+		attributes = append(attributes, otellog.KeyValue{Key: key, Value: otlpValue(value)})
+	}
+	record.AddAttributes(attributes...)
+
+	// The SDK extracts the trace and span IDs from ctx.
+	s.logger.Emit(ctx, record)
+	return nil
+}
+
+// ForceFlush exports all buffered records.
+func (s *OTLPSink) ForceFlush(ctx context.Context) error {
+	return s.provider.ForceFlush(ctx)
+}
+
+// Shutdown flushes all buffered records and shuts down the exporter.
+func (s *OTLPSink) Shutdown(ctx context.Context) error {
+	return s.provider.Shutdown(ctx)
+}
+
+// otlpValue converts a field value to a log attribute value.
+func otlpValue(value interface{}) otellog.Value {
+	switch v := value.(type) {
+	case nil:
+		return otellog.Value{}
+	case string:
+		return otellog.StringValue(v)
+	case bool:
+		return otellog.BoolValue(v)
+	case int:
+		return otellog.IntValue(v)
+	case int8:
+		return otellog.Int64Value(int64(v))
+	case int16:
+		return otellog.Int64Value(int64(v))
+	case int32:
+		return otellog.Int64Value(int64(v))
+	case int64:
+		return otellog.Int64Value(v)
+	case uint8:
+		return otellog.Int64Value(int64(v))
+	case uint16:
+		return otellog.Int64Value(int64(v))
+	case uint32:
+		return otellog.Int64Value(int64(v))
+	case float32:
+		return otellog.Float64Value(float64(v))
+	case float64:
+		return otellog.Float64Value(v)
+	case []byte:
+		return otellog.BytesValue(v)
+	case time.Duration:
+		return otellog.StringValue(v.String())
+	case time.Time:
+		return otellog.StringValue(v.Format(time.RFC3339Nano))
+	case error:
+		return otellog.StringValue(v.Error())
+	case fmt.Stringer:
+		return otellog.StringValue(v.String())
+	}
+
+	// Fall back to the JSON representation for composite values.
+	if serialized, err := json.Marshal(value); err == nil {
+		return otellog.StringValue(string(serialized))
+	}
+	return otellog.StringValue(fmt.Sprintf("%v", value))
+}
@@ -0,0 +1,100 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// inMemoryExporter is a sdklog.Exporter that records exported records.
+type inMemoryExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *inMemoryExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *inMemoryExporter) Shutdown(context.Context) error   { return nil }
+func (e *inMemoryExporter) ForceFlush(context.Context) error { return nil }
+
+func (e *inMemoryExporter) Records() []sdklog.Record {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.records
+}
+
+func TestOTLPSink(t *testing.T) {
+	exporter := &inMemoryExporter{}
+	sink, err := logger.NewOTLPSink(context.Background(), logger.OTLPSinkConfig{
+		ServiceName: "my-service",
+		Levels:      logger.LevelsAtLeast(logger.INFO),
+		Exporter:    exporter,
+	})
+	require.NoError(t, err)
+
+	log, err := logger.NewLogger(logger.Config{
+		Level:       logger.DEBUG,
+		ServiceName: "my-service",
+		Hooks:       []logger.Hook{sink},
+		Output:      &bytes.Buffer{},
+	})
+	require.NoError(t, err)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	log.Debug(ctx, "debug message", nil)
+	log.Error(ctx, "error message", errors.New("boom"), logger.Fields{
+		"user_id": 12345,
+		"retry":   true,
+		"tags":    []string{"a", "b"},
+	})
+
+	require.NoError(t, sink.Shutdown(context.Background()))
+
+	records := exporter.Records()
+	require.Len(t, records, 1, "DEBUG entries should not be shipped")
+
+	record := records[0]
+	assert.Equal(t, "error message", record.Body().AsString())
+	assert.Equal(t, otellog.SeverityError, record.Severity())
+	assert.Equal(t, "ERROR", record.SeverityText())
+	assert.Equal(t, traceID, record.TraceID())
+	assert.Equal(t, spanID, record.SpanID())
+
+	attributes := map[string]otellog.Value{}
+	record.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attributes[kv.Key] = kv.Value
+		return true
+	})
+	assert.Equal(t, int64(12345), attributes["user_id"].AsInt64())
+	assert.True(t, attributes["retry"].AsBool())
+	assert.Equal(t, `["a","b"]`, attributes["tags"].AsString())
+	assert.Equal(t, "boom", attributes[logger.DefaultErrorKey].AsString())
+	assert.Equal(t, "my-service", attributes[logger.DefaultServiceNameKey].AsString())
+
+	res := record.Resource()
+	serviceName, ok := res.Set().Value("service.name")
+	require.True(t, ok)
+	assert.Equal(t, "my-service", serviceName.AsString())
+}
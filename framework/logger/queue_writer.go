@@ -0,0 +1,301 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultQueueWriterBatchSize is the maximum number of entries published in a single batch.
+	DefaultQueueWriterBatchSize = 100
+	// DefaultQueueWriterFlushInterval is the maximum delay before buffered entries are published.
+	DefaultQueueWriterFlushInterval = time.Second
+	// DefaultQueueWriterMaxBufferedBytes is the maximum size of the entries waiting to be published.
+	DefaultQueueWriterMaxBufferedBytes = 8 * 1024 * 1024
+	// DefaultQueueWriterMaxRetries is the number of times a failed batch is retried before it is dropped.
+	DefaultQueueWriterMaxRetries = 3
+	// DefaultQueueWriterRetryBackoff is the delay before the first retry, doubled after every attempt.
+	DefaultQueueWriterRetryBackoff = 100 * time.Millisecond
+)
+
+var (
+	// ErrInvalidQueueWriterConfig is returned when the queue writer configuration is invalid.
+	ErrInvalidQueueWriterConfig = errors.New("invalid queue writer config")
+	// ErrQueueWriterClosed is returned when writing to a closed queue writer.
+	ErrQueueWriterClosed = errors.New("queue writer closed")
+)
+
+// QueueMessage is a serialized log entry published to a message queue.
+type QueueMessage struct {
+	// Topic is the topic the message is published to.
+	Topic string
+	// Key is the partition key of the message.
+	Key []byte
+	// Value is the serialized log entry.
+	Value []byte
+}
+
+/*
+QueuePublisher publishes messages to a message queue such as Kafka. Publish must return once the batch is
+acknowledged, and return an error if it should be retried.
+
+Example usage (github.com/segmentio/kafka-go):
+
+	type kafkaPublisher struct {
+		writer *kafka.Writer
+	}
+
+	func (p *kafkaPublisher) Publish(ctx context.Context, messages []logger.QueueMessage) error {
+		kafkaMessages := make([]kafka.Message, len(messages))
+		for i, m := range messages {
+			kafkaMessages[i] = kafka.Message{Topic: m.Topic, Key: m.Key, Value: m.Value}
+		}
+		return p.writer.WriteMessages(ctx, kafkaMessages...)
+	}
+*/
+type QueuePublisher interface {
+	Publish(ctx context.Context, messages []QueueMessage) error
+}
+
+// QueueWriterConfig holds the configuration of a QueueWriter.
+type QueueWriterConfig struct {
+	// Publisher publishes the batches of entries. Required.
+	Publisher QueuePublisher
+	// Topic is the topic entries are published to. Required.
+	Topic string
+	// Key is the partition key of every message, typically the service name, so that the entries of a service stay ordered.
+	Key string
+	// BatchSize is the maximum number of entries published in a single batch. Defaults to DefaultQueueWriterBatchSize.
+	BatchSize int
+	// FlushInterval is the maximum delay before buffered entries are published. Defaults to DefaultQueueWriterFlushInterval.
+	FlushInterval time.Duration
+	// MaxBufferedBytes bounds the memory used by entries waiting to be published; entries beyond it are dropped.
+	// Defaults to DefaultQueueWriterMaxBufferedBytes.
+	MaxBufferedBytes int
+	// MaxRetries is the number of times a failed batch is retried before it is dropped. Defaults to DefaultQueueWriterMaxRetries.
+	MaxRetries *int
+	// RetryBackoff is the delay before the first retry, doubled after every attempt. Defaults to DefaultQueueWriterRetryBackoff.
+	RetryBackoff time.Duration
+}
+
+// QueueWriterStats holds the counters of a QueueWriter.
+type QueueWriterStats struct {
+	// Published is the number of entries acknowledged by the publisher.
+	Published uint64
+	// Dropped is the number of entries dropped because the buffer was full, the writer was closed,
+	// or their batch still failed after all retries.
+	Dropped uint64
+}
+
+/*
+QueueWriter is an io.Writer publishing serialized entries to a message queue such as Kafka, for environments
+where stdout is not scraped. Entries are buffered and published in batches in the background, so writing
+never blocks on the queue. Memory is bounded by MaxBufferedBytes: entries are dropped rather than buffered
+beyond it, and counted in Stats.
+
+Close must be called before the application exits to publish the buffered entries. QueueWriter implements
+Flusher, so buffered entries are published before Fatal exits.
+
+Example usage:
+
+	writer, err := logger.NewQueueWriter(logger.QueueWriterConfig{
+		Publisher: &kafkaPublisher{writer: kafkaWriter},
+		Topic:     "logs",
+		Key:       "my-service",
+	})
+	if err != nil {
+		// Handle error
+	}
+	defer writer.Close(context.Background())
+
+	logConfig := logger.Config{
+		Level:       logger.INFO,
+		ServiceName: "my-service",
+		Output:      writer,
+	}
+*/
+type QueueWriter struct {
+	config QueueWriterConfig
+	key    []byte
+
+	mu          sync.Mutex
+	pending     [][]byte
+	pendingSize int
+	closed      bool
+
+	publishMu sync.Mutex
+	flushCh   chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+
+	published atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// NewQueueWriter creates a QueueWriter and starts publishing in the background.
+func NewQueueWriter(config QueueWriterConfig) (*QueueWriter, error) {
+	if config.Publisher == nil || config.Topic == "" {
+		return nil, ErrInvalidQueueWriterConfig
+	}
+	if config.MaxRetries != nil && *config.MaxRetries < 0 {
+		return nil, ErrInvalidQueueWriterConfig
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultQueueWriterBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultQueueWriterFlushInterval
+	}
+	if config.MaxBufferedBytes <= 0 {
+		config.MaxBufferedBytes = DefaultQueueWriterMaxBufferedBytes
+	}
+	if config.MaxRetries == nil {
+		maxRetries := DefaultQueueWriterMaxRetries
+		config.MaxRetries = &maxRetries
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = DefaultQueueWriterRetryBackoff
+	}
+
+	w := &QueueWriter{
+		config:  config,
+		flushCh: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if config.Key != "" {
+		w.key = []byte(config.Key)
+	}
+	go w.run()
+	return w, nil
+}
+
+// Write implements the io.Writer interface by buffering a copy of p, or dropping it if the buffer is full.
+func (w *QueueWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		w.dropped.Add(1)
+		return 0, ErrQueueWriterClosed
+	}
+	if w.pendingSize+len(p) > w.config.MaxBufferedBytes {
+		w.mu.Unlock()
+		w.dropped.Add(1)
+		return len(p), nil
+	}
+	// The caller may reuse p once Write returns.
+	w.pending = append(w.pending, append([]byte(nil), p...))
+	w.pendingSize += len(p)
+	full := len(w.pending) >= w.config.BatchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Stats returns the counters of the writer.
+func (w *QueueWriter) Stats() QueueWriterStats {
+	return QueueWriterStats{
+		Published: w.published.Load(),
+		Dropped:   w.dropped.Load(),
+	}
+}
+
+// ForceFlush publishes all buffered entries. It implements the Flusher interface.
+func (w *QueueWriter) ForceFlush(ctx context.Context) error {
+	return w.flush(ctx)
+}
+
+// Close stops the background publishing and publishes the buffered entries. Entries written afterwards are dropped.
+func (w *QueueWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.done)
+	<-w.stopped
+	return w.flush(ctx)
+}
+
+// run publishes the buffered entries every FlushInterval, or as soon as a batch is full.
+func (w *QueueWriter) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		case <-w.flushCh:
+		}
+		if err := w.flush(context.Background()); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to publish log entries: %v\n", err)
+		}
+	}
+}
+
+// flush publishes the buffered entries in batches of BatchSize. Batches failing after all retries are dropped.
+func (w *QueueWriter) flush(ctx context.Context) error {
+	// Serialize publishing, so that entries are published in order.
+	w.publishMu.Lock()
+	defer w.publishMu.Unlock()
+
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = nil
+	w.pendingSize = 0
+	w.mu.Unlock()
+
+	var errs []error
+	for len(pending) > 0 {
+		n := min(len(pending), w.config.BatchSize)
+		if err := w.publish(ctx, pending[:n]); err != nil {
+			w.dropped.Add(uint64(n))
+			errs = append(errs, err)
+		} else {
+			w.published.Add(uint64(n))
+		}
+		pending = pending[n:]
+	}
+	return errors.Join(errs...)
+}
+
+// publish publishes a batch, retrying with exponential backoff.
+func (w *QueueWriter) publish(ctx context.Context, batch [][]byte) error {
+	messages := make([]QueueMessage, len(batch))
+	for i, value := range batch {
+		messages[i] = QueueMessage{Topic: w.config.Topic, Key: w.key, Value: value}
+	}
+
+	backoff := w.config.RetryBackoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = w.config.Publisher.Publish(ctx, messages); err == nil {
+			return nil
+		}
+		if attempt >= *w.config.MaxRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %v", ctx.Err(), err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
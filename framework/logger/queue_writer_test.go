@@ -0,0 +1,187 @@
+package logger_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePublisher struct {
+	mu       sync.Mutex
+	batches  [][]logger.QueueMessage
+	failures int
+	calls    int
+}
+
+func (p *fakePublisher) Publish(_ context.Context, messages []logger.QueueMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	p.batches = append(p.batches, messages)
+	return nil
+}
+
+func (p *fakePublisher) values() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var values []string
+	for _, batch := range p.batches {
+		for _, message := range batch {
+			values = append(values, string(message.Value))
+		}
+	}
+	return values
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestNewQueueWriter_InvalidConfig(t *testing.T) {
+	_, err := logger.NewQueueWriter(logger.QueueWriterConfig{Topic: "logs"})
+	assert.ErrorIs(t, err, logger.ErrInvalidQueueWriterConfig)
+
+	_, err = logger.NewQueueWriter(logger.QueueWriterConfig{Publisher: &fakePublisher{}})
+	assert.ErrorIs(t, err, logger.ErrInvalidQueueWriterConfig)
+
+	_, err = logger.NewQueueWriter(logger.QueueWriterConfig{Publisher: &fakePublisher{}, Topic: "logs", MaxRetries: intPtr(-1)})
+	assert.ErrorIs(t, err, logger.ErrInvalidQueueWriterConfig)
+}
+
+func TestQueueWriter_Batching(t *testing.T) {
+	publisher := &fakePublisher{}
+	writer, err := logger.NewQueueWriter(logger.QueueWriterConfig{
+		Publisher:     publisher,
+		Topic:         "logs",
+		Key:           "my-service",
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	buf := []byte("entry-1")
+	_, err = writer.Write(buf)
+	require.NoError(t, err)
+	copy(buf, "reused!") // the writer must not retain the caller's buffer
+	_, err = writer.Write([]byte("entry-2"))
+	require.NoError(t, err)
+
+	// A full batch is published without waiting for the flush interval.
+	assert.Eventually(t, func() bool { return len(publisher.values()) == 2 }, time.Second, time.Millisecond)
+
+	_, err = writer.Write([]byte("entry-3"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close(context.Background()))
+
+	assert.Equal(t, []string{"entry-1", "entry-2", "entry-3"}, publisher.values())
+	for _, batch := range publisher.batches {
+		assert.LessOrEqual(t, len(batch), 2)
+		for _, message := range batch {
+			assert.Equal(t, "logs", message.Topic)
+			assert.Equal(t, []byte("my-service"), message.Key)
+		}
+	}
+	assert.Equal(t, logger.QueueWriterStats{Published: 3}, writer.Stats())
+
+	_, err = writer.Write([]byte("entry-4"))
+	assert.ErrorIs(t, err, logger.ErrQueueWriterClosed)
+	assert.Equal(t, uint64(1), writer.Stats().Dropped)
+}
+
+func TestQueueWriter_BoundedMemory(t *testing.T) {
+	publisher := &fakePublisher{}
+	writer, err := logger.NewQueueWriter(logger.QueueWriterConfig{
+		Publisher:        publisher,
+		Topic:            "logs",
+		FlushInterval:    time.Hour,
+		MaxBufferedBytes: 10,
+	})
+	require.NoError(t, err)
+
+	for _, entry := range []string{"12345", "67890", "dropped"} {
+		n, err := writer.Write([]byte(entry))
+		require.NoError(t, err)
+		assert.Equal(t, len(entry), n)
+	}
+	require.NoError(t, writer.ForceFlush(context.Background()))
+
+	assert.Equal(t, []string{"12345", "67890"}, publisher.values())
+	assert.Equal(t, logger.QueueWriterStats{Published: 2, Dropped: 1}, writer.Stats())
+	require.NoError(t, writer.Close(context.Background()))
+}
+
+func TestQueueWriter_Retries(t *testing.T) {
+	t.Run("succeeds after retries", func(t *testing.T) {
+		publisher := &fakePublisher{failures: 2}
+		writer, err := logger.NewQueueWriter(logger.QueueWriterConfig{
+			Publisher:     publisher,
+			Topic:         "logs",
+			FlushInterval: time.Hour,
+			MaxRetries:    intPtr(2),
+			RetryBackoff:  time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		_, err = writer.Write([]byte("entry"))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close(context.Background()))
+
+		assert.Equal(t, 3, publisher.calls)
+		assert.Equal(t, []string{"entry"}, publisher.values())
+		assert.Equal(t, logger.QueueWriterStats{Published: 1}, writer.Stats())
+	})
+
+	t.Run("drops the batch after all retries", func(t *testing.T) {
+		publisher := &fakePublisher{failures: 3}
+		writer, err := logger.NewQueueWriter(logger.QueueWriterConfig{
+			Publisher:     publisher,
+			Topic:         "logs",
+			FlushInterval: time.Hour,
+			MaxRetries:    intPtr(2),
+			RetryBackoff:  time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		_, err = writer.Write([]byte("entry"))
+		require.NoError(t, err)
+		assert.ErrorContains(t, writer.Close(context.Background()), "broker unavailable")
+
+		assert.Equal(t, 3, publisher.calls)
+		assert.Empty(t, publisher.values())
+		assert.Equal(t, logger.QueueWriterStats{Dropped: 1}, writer.Stats())
+	})
+}
+
+func TestQueueWriter_AsLoggerOutput(t *testing.T) {
+	publisher := &fakePublisher{}
+	writer, err := logger.NewQueueWriter(logger.QueueWriterConfig{
+		Publisher:     publisher,
+		Topic:         "logs",
+		FlushInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	exitCode := -1
+	log, err := logger.NewLogger(logger.Config{
+		Level:    logger.INFO,
+		Output:   writer,
+		ExitFunc: func(code int) { exitCode = code },
+	})
+	require.NoError(t, err)
+
+	// Fatal flushes the output before exiting.
+	log.Fatal(context.Background(), "fatal message", nil, nil)
+	assert.Equal(t, 1, exitCode)
+	values := publisher.values()
+	require.Len(t, values, 1)
+	assert.Contains(t, values[0], `"message":"fatal message"`)
+	require.NoError(t, writer.Close(context.Background()))
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+const (
+	// DefaultPanicKey is the key used for the recovered panic value in logs.
+	DefaultPanicKey = "panic"
+	// defaultRecoverMessage is the message logged by RecoverAndLog when no message is configured.
+	defaultRecoverMessage = "recovered from panic"
+)
+
+// RecoverOption is a function that configures RecoverAndLog.
+type RecoverOption func(*recoverOptions)
+
+type recoverOptions struct {
+	message string
+	fields  Fields
+	repanic bool
+}
+
+// WithRecoverMessage sets the message logged when a panic is recovered.
+func WithRecoverMessage(msg string) RecoverOption {
+	return func(o *recoverOptions) {
+		o.message = msg
+	}
+}
+
+// WithRecoverFields adds fields to the entry logged when a panic is recovered.
+func WithRecoverFields(fields Fields) RecoverOption {
+	return func(o *recoverOptions) {
+		o.fields = fields
+	}
+}
+
+// WithRepanic re-panics with the recovered value after logging it.
+func WithRepanic() RecoverOption {
+	return func(o *recoverOptions) {
+		o.repanic = true
+	}
+}
+
+/*
+RecoverAndLog recovers from a panic and logs the recovered value with a stack trace at the ERROR level.
+It must be deferred directly (not called from another deferred function), otherwise recover has no effect.
+
+Example usage:
+
+	go func() {
+		defer logger.RecoverAndLog(ctx, log, logger.WithRecoverFields(logger.Fields{"worker": "billing"}))
+		processJobs(ctx)
+	}()
+*/
+func RecoverAndLog(ctx context.Context, l Logger, opts ...RecoverOption) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	options := recoverOptions{message: defaultRecoverMessage}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	err, ok := r.(error)
+	if !ok {
+		err = fmt.Errorf("panic: %v", r)
+	}
+
+	fields := make(Fields, len(options.fields)+2)
+	for k, v := range options.fields {
+		fields[k] = v
+	}
+	fields[DefaultPanicKey] = fmt.Sprintf("%v", r)
+	fields[DefaultSJsonFmtStackTraceKey] = string(debug.Stack())
+
+	if l == nil {
+		l = NewDefaultLogger()
+	}
+	l.Error(ctx, options.message, err, fields)
+
+	if options.repanic {
+		panic(r)
+	}
+}
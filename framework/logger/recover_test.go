@@ -0,0 +1,79 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverAndLog(t *testing.T) {
+	log, recorder := logger.NewTestLogger()
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		defer logger.RecoverAndLog(ctx, log, logger.WithRecoverFields(logger.Fields{"worker": "billing"}))
+		panic("something went wrong")
+	})
+
+	entries := recorder.EntriesAtLevel(logger.ERROR)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "recovered from panic", entries[0].Message)
+	assert.EqualError(t, entries[0].Error, "panic: something went wrong")
+	assert.Equal(t, "something went wrong", entries[0].Fields[logger.DefaultPanicKey])
+	assert.Equal(t, "billing", entries[0].Fields["worker"])
+	assert.Contains(t, entries[0].Fields[logger.DefaultSJsonFmtStackTraceKey], "TestRecoverAndLog")
+}
+
+func TestRecoverAndLog_Repanic(t *testing.T) {
+	log, recorder := logger.NewTestLogger()
+	panicErr := errors.New("boom")
+
+	assert.PanicsWithError(t, "boom", func() {
+		defer logger.RecoverAndLog(context.Background(), log, logger.WithRepanic(), logger.WithRecoverMessage("worker crashed"))
+		panic(panicErr)
+	})
+
+	recorder.AssertLogged(t, logger.ERROR, "worker crashed")
+	assert.Equal(t, panicErr, recorder.Entries()[0].Error)
+}
+
+func TestRecoverAndLog_NoPanic(t *testing.T) {
+	log, recorder := logger.NewTestLogger()
+
+	func() {
+		defer logger.RecoverAndLog(context.Background(), log)
+	}()
+
+	assert.Equal(t, 0, recorder.Len())
+}
+
+func TestLogger_Panic(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Output: buffer,
+	})
+	require.NoError(t, err)
+
+	panicErr := errors.New("boom")
+	assert.PanicsWithError(t, "boom", func() {
+		log.Panic(context.Background(), "panic message", panicErr, nil)
+	})
+	assert.PanicsWithValue(t, "panic without error", func() {
+		log.Panic(context.Background(), "panic without error", nil, nil)
+	})
+
+	lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[0], &logEntry))
+	assert.Equal(t, "panic", logEntry["severity"])
+	assert.Equal(t, "panic message", logEntry["message"])
+	assert.Equal(t, "boom", logEntry["error"])
+}
@@ -0,0 +1,240 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultRedactionMask is the replacement used for values that must be fully hidden.
+const DefaultRedactionMask = "[REDACTED]"
+
+// DefaultRedactKeyPatterns is a list of commonly sensitive field key patterns.
+// Patterns are matched case-insensitively as substrings of the field key (e.g., "token" matches "access_token").
+var DefaultRedactKeyPatterns = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"authorization",
+	"api_key",
+	"apikey",
+	"cookie",
+}
+
+// ValueMatcher detects sensitive data inside string values and masks every match.
+type ValueMatcher struct {
+	// Pattern is the regular expression used to find sensitive data.
+	Pattern *regexp.Regexp
+	// Mask returns the replacement for a single match.
+	// If nil, the whole match is replaced with the formatter's mask.
+	Mask func(match string) string
+}
+
+var (
+	// CreditCardMatcher finds credit card like numbers (13 to 19 digits, optionally separated by spaces or dashes)
+	// and keeps only the last 4 digits visible.
+	CreditCardMatcher = ValueMatcher{
+		Pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Mask:    MaskAllButLast(4),
+	}
+	// EmailMatcher finds email addresses and keeps only the first character of the local part and the domain visible.
+	EmailMatcher = ValueMatcher{
+		Pattern: regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`),
+		Mask:    maskEmail,
+	}
+)
+
+/*
+MaskAllButLast returns a mask function that replaces every digit and letter except the last n with '*'.
+Separators (spaces, dashes, etc.) are kept so the masked value keeps its shape.
+
+Example:
+
+	MaskAllButLast(4)("4111-1111-1111-1111") // "****-****-****-1111"
+*/
+func MaskAllButLast(n int) func(string) string {
+	return func(s string) string {
+		runes := []rune(s)
+		visible := 0
+		for i := len(runes) - 1; i >= 0; i-- {
+			if !isAlphaNumeric(runes[i]) {
+				continue
+			}
+			if visible < n {
+				visible++
+				continue
+			}
+			runes[i] = '*'
+		}
+		return string(runes)
+	}
+}
+
+/*
+RedactingFormatter is a formatter that wraps another formatter and hides sensitive data before the entry is formatted.
+
+  - Fields whose key matches one of the KeyPatterns are replaced entirely with the Mask.
+  - String values are scanned with the ValueMatchers and every match is masked (fully or partially).
+  - Nested Fields, maps, slices and structs are redacted recursively.
+
+Example usage:
+
+	formatter := &logger.RedactingFormatter{
+		Formatter:     &logger.StructuredJSONFormatter{TimestampFormat: time.RFC3339},
+		KeyPatterns:   logger.DefaultRedactKeyPatterns,
+		ValueMatchers: []logger.ValueMatcher{logger.CreditCardMatcher, logger.EmailMatcher},
+	}
+*/
+type RedactingFormatter struct {
+	// Formatter is the underlying formatter used once the entry has been redacted.
+	// If not provided, the StructuredJSONFormatter is used.
+	Formatter Formatter
+	// KeyPatterns is a list of case-insensitive substrings; a field whose key contains any of them is redacted.
+	KeyPatterns []string
+	// ValueMatchers are applied to every string value to mask sensitive data embedded in the value.
+	ValueMatchers []ValueMatcher
+	// Mask is the replacement for redacted fields. Defaults to DefaultRedactionMask.
+	Mask string
+}
+
+// Format implements the Formatter interface.
+func (f *RedactingFormatter) Format(ctx context.Context, entry Entry) ([]byte, error) {
+	formatter := f.Formatter
+	if formatter == nil {
+		formatter = &StructuredJSONFormatter{TimestampFormat: time.RFC3339}
+	}
+
+	// Redact a copy so the original entry fields (which may be shared with hooks) are not modified.
+	redacted := entry
+	redacted.Fields = make(Fields, len(entry.Fields))
+	for key, value := range entry.Fields {
+		redacted.Fields[key] = f.redactField(key, value)
+	}
+
+	return formatter.Format(ctx, redacted)
+}
+
+// redactField redacts a single key/value pair.
+func (f *RedactingFormatter) redactField(key string, value interface{}) interface{} {
+	if f.isSensitiveKey(key) {
+		return f.mask()
+	}
+	return f.redactValue(value)
+}
+
+// redactValue walks the value and redacts nested keys and string values.
+func (f *RedactingFormatter) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case error:
+		// Errors are rendered by the formatters; keep them untouched.
+		return v
+	case string:
+		return f.redactString(v)
+	case Fields:
+		return f.redactMap(v)
+	case map[string]interface{}:
+		return f.redactMap(v)
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for key, val := range v {
+			if f.isSensitiveKey(key) {
+				out[key] = f.mask()
+			} else {
+				out[key] = f.redactString(val)
+			}
+		}
+		return out
+	case []string:
+		out := make([]string, len(v))
+		for i, val := range v {
+			out[i] = f.redactString(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			out[i] = f.redactValue(val)
+		}
+		return out
+	}
+
+	// Structs, pointers and other composite values (e.g., raw request payloads) are converted to
+	// their JSON representation so their keys can be inspected.
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		if _, ok := value.(json.Marshaler); ok {
+			return value
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return value
+		}
+		var generic interface{}
+		if err := json.Unmarshal(raw, &generic); err != nil {
+			return value
+		}
+		return f.redactValue(generic)
+	}
+	return value
+}
+
+// redactMap redacts every entry of a string keyed map into a new map.
+func (f *RedactingFormatter) redactMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for key, val := range m {
+		out[key] = f.redactField(key, val)
+	}
+	return out
+}
+
+// redactString masks every match of the configured value matchers.
+func (f *RedactingFormatter) redactString(s string) string {
+	for _, matcher := range f.ValueMatchers {
+		if matcher.Pattern == nil {
+			continue
+		}
+		mask := matcher.Mask
+		if mask == nil {
+			mask = func(string) string { return f.mask() }
+		}
+		s = matcher.Pattern.ReplaceAllStringFunc(s, mask)
+	}
+	return s
+}
+
+// isSensitiveKey reports whether the key matches one of the key patterns.
+func (f *RedactingFormatter) isSensitiveKey(key string) bool {
+	lowerKey := strings.ToLower(key)
+	for _, pattern := range f.KeyPatterns {
+		if pattern != "" && strings.Contains(lowerKey, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *RedactingFormatter) mask() string {
+	if f.Mask == "" {
+		return DefaultRedactionMask
+	}
+	return f.Mask
+}
+
+// maskEmail keeps the first character of the local part and the domain, e.g. "john@example.com" -> "j***@example.com".
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return DefaultRedactionMask
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+func isAlphaNumeric(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
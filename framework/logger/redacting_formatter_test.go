@@ -0,0 +1,108 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactingFormatter_KeyPatterns(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level: logger.INFO,
+		Formatter: &logger.RedactingFormatter{
+			Formatter:   &logger.StructuredJSONFormatter{TimestampFormat: time.RFC3339},
+			KeyPatterns: logger.DefaultRedactKeyPatterns,
+		},
+		Output: buffer,
+	})
+	require.NoError(t, err)
+
+	type loginRequest struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	log.Info(context.Background(), "Login", logger.Fields{
+		"password": "hunter2",
+		"request": logger.Fields{
+			"headers": map[string]string{
+				"Authorization": "Bearer abc",
+				"Accept":        "application/json",
+			},
+			"body": loginRequest{Username: "john", Password: "hunter2"},
+		},
+		"user": "john",
+	})
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+
+	assert.Equal(t, logger.DefaultRedactionMask, logEntry["password"])
+	assert.Equal(t, "john", logEntry["user"])
+
+	request := logEntry["request"].(map[string]interface{})
+	headers := request["headers"].(map[string]interface{})
+	assert.Equal(t, logger.DefaultRedactionMask, headers["Authorization"])
+	assert.Equal(t, "application/json", headers["Accept"])
+
+	body := request["body"].(map[string]interface{})
+	assert.Equal(t, "john", body["username"])
+	assert.Equal(t, logger.DefaultRedactionMask, body["password"])
+}
+
+func TestRedactingFormatter_ValueMatchers(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level: logger.INFO,
+		Formatter: &logger.RedactingFormatter{
+			ValueMatchers: []logger.ValueMatcher{logger.CreditCardMatcher, logger.EmailMatcher},
+			Mask:          "***",
+		},
+		Output: buffer,
+	})
+	require.NoError(t, err)
+
+	log.Info(context.Background(), "Payment", logger.Fields{
+		"card":    "4111-1111-1111-1111",
+		"contact": "reach me at john.doe@example.com",
+		"notes":   []string{"card 4111111111111111"},
+		"amount":  100,
+	})
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+
+	assert.Equal(t, "****-****-****-1111", logEntry["card"])
+	assert.Equal(t, "reach me at j***@example.com", logEntry["contact"])
+	assert.Equal(t, []interface{}{"card ************1111"}, logEntry["notes"])
+	assert.Equal(t, float64(100), logEntry["amount"])
+}
+
+func TestRedactingFormatter_DoesNotMutateFields(t *testing.T) {
+	log, err := logger.NewLogger(logger.Config{
+		Level: logger.INFO,
+		Formatter: &logger.RedactingFormatter{
+			KeyPatterns: []string{"secret"},
+		},
+		Output: &bytes.Buffer{},
+	})
+	require.NoError(t, err)
+
+	fields := logger.Fields{"secret": "value"}
+	log.Info(context.Background(), "message", fields)
+
+	assert.Equal(t, "value", fields["secret"], "caller's fields should not be modified")
+}
+
+func TestMaskAllButLast(t *testing.T) {
+	assert.Equal(t, "****-****-****-1111", logger.MaskAllButLast(4)("4111-1111-1111-1111"))
+	assert.Equal(t, "ab", logger.MaskAllButLast(4)("ab"))
+	assert.Equal(t, "***", logger.MaskAllButLast(0)("abc"))
+}
@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultSamplerTick is the sampling window used when Sampler.Tick is not set.
+	defaultSamplerTick = time.Second
+	// samplerCounters is the number of counters used to track distinct messages.
+	// Messages are hashed into a fixed number of counters to keep memory usage bounded.
+	samplerCounters = 4096
+)
+
+/*
+Sampler configures log sampling for repetitive entries.
+
+Within each Tick, the first Initial entries with the same level and message are logged,
+after that only every Thereafter-th entry is logged. Sampling only applies to DEBUG and INFO entries;
+WARN and above are always logged.
+
+Example usage:
+
+	logConfig := logger.Config{
+		Level: logger.INFO,
+		Sampler: &logger.Sampler{
+			Initial:    100,  // log the first 100 identical messages per second
+			Thereafter: 1000, // then log every 1000th message
+			Tick:       time.Second,
+		},
+	}
+*/
+type Sampler struct {
+	// Initial is the number of identical entries logged during each Tick before sampling starts.
+	Initial int
+	// Thereafter is the sampling rate once Initial has been reached (every Thereafter-th entry is logged).
+	// If zero, all entries after Initial are dropped until the next Tick.
+	Thereafter int
+	// Tick is the sampling window. Defaults to one second.
+	Tick time.Duration
+}
+
+func (s *Sampler) isValid() bool {
+	return s.Initial >= 0 && s.Thereafter >= 0 && s.Tick >= 0
+}
+
+// sampler holds the runtime state of a Sampler configuration. It is shared by all loggers derived from the same root logger.
+type sampler struct {
+	initial    uint64
+	thereafter uint64
+	tick       time.Duration
+	counters   [samplerCounters]samplerCounter
+}
+
+type samplerCounter struct {
+	resetAt atomic.Int64
+	count   atomic.Uint64
+}
+
+func newSampler(cfg *Sampler) *sampler {
+	tick := cfg.Tick
+	if tick == 0 {
+		tick = defaultSamplerTick
+	}
+	return &sampler{
+		initial:    uint64(cfg.Initial),
+		thereafter: uint64(cfg.Thereafter),
+		tick:       tick,
+	}
+}
+
+// sample reports whether the entry with the given built-in level and message should be logged.
+func (s *sampler) sample(level LogLevel, msg string) bool {
+	// Only DEBUG and INFO (and below) are sampled, warnings and errors always pass.
+	if level.Order() >= WARN.Order() {
+		return true
+	}
+
+	counter := &s.counters[samplerKey(level, msg)%samplerCounters]
+	n := counter.incCheckReset(time.Now(), s.tick)
+	if n <= s.initial {
+		return true
+	}
+	if s.thereafter == 0 {
+		return false
+	}
+	return (n-s.initial)%s.thereafter == 0
+}
+
+// incCheckReset increments the counter, resetting it first if the current window has elapsed.
+func (c *samplerCounter) incCheckReset(now time.Time, tick time.Duration) uint64 {
+	nowNano := now.UnixNano()
+	resetAt := c.resetAt.Load()
+	if resetAt > nowNano {
+		return c.count.Add(1)
+	}
+
+	c.count.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, nowNano+tick.Nanoseconds()) {
+		// Another goroutine reset the window concurrently, count this entry in the new window.
+		return c.count.Add(1)
+	}
+	return 1
+}
+
+// samplerKey hashes the level and message into a counter index.
+func samplerKey(level LogLevel, msg string) uint32 {
+	// Inlined 32-bit FNV-1a to avoid allocating a hash.Hash per entry.
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	h ^= uint32(level.Order())
+	h *= prime32
+	for i := 0; i < len(msg); i++ {
+		h ^= uint32(msg[i])
+		h *= prime32
+	}
+	return h
+}
@@ -0,0 +1,82 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_Sampler(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level: logger.DEBUG,
+		Sampler: &logger.Sampler{
+			Initial:    2,
+			Thereafter: 3,
+			Tick:       time.Minute,
+		},
+		Output: buffer,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		log.Info(ctx, "repeated info", nil)
+	}
+	// Entries 1, 2 (initial) and 5, 8 (every 3rd after initial) are logged.
+	assert.Equal(t, 4, countLines(buffer))
+
+	// A different message has its own counter.
+	buffer.Reset()
+	log.Debug(ctx, "another message", nil)
+	assert.Equal(t, 1, countLines(buffer))
+
+	// Warnings and errors are never sampled.
+	buffer.Reset()
+	for i := 0; i < 10; i++ {
+		log.Warn(ctx, "repeated warn", nil)
+		log.Error(ctx, "repeated error", errors.New("error"), nil)
+	}
+	assert.Equal(t, 20, countLines(buffer))
+}
+
+func TestLogger_SamplerTickReset(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level: logger.INFO,
+		Sampler: &logger.Sampler{
+			Initial:    1,
+			Thereafter: 0,
+			Tick:       50 * time.Millisecond,
+		},
+		Output: buffer,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	log.Info(ctx, "message", nil)
+	log.Info(ctx, "message", nil)
+	assert.Equal(t, 1, countLines(buffer), "entries after Initial should be dropped when Thereafter is zero")
+
+	time.Sleep(60 * time.Millisecond)
+	log.Info(ctx, "message", nil)
+	assert.Equal(t, 2, countLines(buffer), "counter should reset after the tick")
+}
+
+func TestLogger_InvalidSampler(t *testing.T) {
+	_, err := logger.NewLogger(logger.Config{
+		Level:   logger.INFO,
+		Sampler: &logger.Sampler{Initial: -1},
+	})
+	assert.ErrorIs(t, err, logger.ErrInvalidSamplerConfig)
+}
+
+func countLines(buffer *bytes.Buffer) int {
+	return bytes.Count(buffer.Bytes(), []byte("\n"))
+}
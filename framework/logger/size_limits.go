@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// truncationMarkerFormat is appended to truncated values, with the number of bytes removed.
+const truncationMarkerFormat = "...[truncated %d bytes]"
+
+/*
+SizeLimits caps the size of field values and entries, so that an oversized entry (e.g., a whole response body)
+is truncated instead of being rejected by the log pipeline. Truncated values end with a marker such as
+"...[truncated 1024 bytes]".
+
+Limits apply to the message and to string, []byte and json.RawMessage field values; other values and
+the error of the entry are kept as they are and not counted towards MaxEntryBytes.
+
+Example usage:
+
+	limits := &logger.SizeLimits{
+		MaxFieldBytes: 8 * 1024,
+		MaxEntryBytes: 64 * 1024,
+	}
+	logConfig := logger.Config{
+		Level:      logger.INFO,
+		SizeLimits: limits,
+	}
+	// ...
+	truncated := limits.TruncatedEntries()
+*/
+type SizeLimits struct {
+	// MaxFieldBytes is the maximum size of a single field value. Zero means no limit.
+	MaxFieldBytes int
+	// MaxEntryBytes is the maximum size of the message and field keys and values of an entry.
+	// The largest values are truncated first. Zero means no limit.
+	MaxEntryBytes int
+
+	truncated atomic.Uint64
+}
+
+func (s *SizeLimits) isValid() bool {
+	return s.MaxFieldBytes >= 0 && s.MaxEntryBytes >= 0
+}
+
+// TruncatedEntries returns the number of entries with a truncated message or field value.
+func (s *SizeLimits) TruncatedEntries() uint64 {
+	return s.truncated.Load()
+}
+
+// truncatableValue is a message or field value subject to the size limits.
+type truncatableValue struct {
+	key       string
+	value     string
+	message   bool
+	truncated bool
+}
+
+// apply returns msg and fields truncated to the limits. fields is not modified, a copy is returned if it is truncated.
+func (s *SizeLimits) apply(msg string, fields Fields) (string, Fields) {
+	values := make([]truncatableValue, 0, len(fields)+1)
+	values = append(values, truncatableValue{value: msg, message: true})
+	size := len(msg)
+	for key, value := range fields {
+		size += len(key)
+		if key == DefaultErrorKey {
+			continue
+		}
+		var str string
+		switch v := value.(type) {
+		case string:
+			str = v
+		case []byte:
+			str = string(v)
+		case json.RawMessage:
+			str = string(v)
+		default:
+			continue
+		}
+		values = append(values, truncatableValue{key: key, value: str})
+		size += len(str)
+	}
+
+	truncated := false
+	if s.MaxFieldBytes > 0 {
+		for i := range values {
+			if len(values[i].value) > s.MaxFieldBytes {
+				size -= len(values[i].value)
+				values[i].value = truncateValue(values[i].value, s.MaxFieldBytes)
+				values[i].truncated = true
+				size += len(values[i].value)
+				truncated = true
+			}
+		}
+	}
+	if s.MaxEntryBytes > 0 && size > s.MaxEntryBytes {
+		// Truncate the largest values first, so that small fields such as IDs are kept intact.
+		sort.SliceStable(values, func(i, j int) bool { return len(values[i].value) > len(values[j].value) })
+		for i := range values {
+			excess := size - s.MaxEntryBytes
+			if excess <= 0 {
+				break
+			}
+			length := len(values[i].value)
+			// Leave room for the marker appended to the value.
+			keep := length - excess - len(fmt.Sprintf(truncationMarkerFormat, length))
+			if keep < 0 {
+				keep = 0
+			}
+			if keep >= length {
+				continue
+			}
+			values[i].value = truncateValue(values[i].value, keep)
+			values[i].truncated = true
+			size += len(values[i].value) - length
+			truncated = true
+		}
+	}
+	if !truncated {
+		return msg, fields
+	}
+
+	s.truncated.Add(1)
+	result := make(Fields, len(fields))
+	for k, v := range fields {
+		result[k] = v
+	}
+	for _, v := range values {
+		switch {
+		case !v.truncated:
+		case v.message:
+			msg = v.value
+		default:
+			// Truncated values are no longer valid JSON or binary data, they are written as strings.
+			result[v.key] = v.value
+		}
+	}
+	return msg, result
+}
+
+// truncateValue truncates value to at most max bytes, without splitting a UTF-8 character, and appends the marker.
+func truncateValue(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut] + fmt.Sprintf(truncationMarkerFormat, len(value)-cut)
+}
@@ -0,0 +1,101 @@
+package logger_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger_InvalidSizeLimits(t *testing.T) {
+	_, err := logger.NewLogger(logger.Config{
+		Level:      logger.INFO,
+		SizeLimits: &logger.SizeLimits{MaxFieldBytes: -1},
+	})
+	assert.ErrorIs(t, err, logger.ErrInvalidSizeLimitsConfig)
+}
+
+func TestSizeLimits_MaxFieldBytes(t *testing.T) {
+	limits := &logger.SizeLimits{MaxFieldBytes: 10}
+	log, recorder := newSizeLimitedLogger(t, limits)
+
+	body := strings.Repeat("a", 25)
+	log.Info(context.Background(), "short", logger.Fields{
+		"body":      body,
+		"raw":       json.RawMessage(`{"key":"a long json value"}`),
+		"bytes":     []byte("0123456789"),
+		"status":    200,
+		"small":     "ok",
+		"multibyte": "ééééééé", // 14 bytes
+	})
+	log.Info(context.Background(), "untouched", logger.Fields{"small": "ok"})
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 2)
+	fields := entries[0].Fields
+	assert.Equal(t, "aaaaaaaaaa...[truncated 15 bytes]", fields["body"])
+	assert.Equal(t, `{"key":"a ...[truncated 17 bytes]`, fields["raw"])
+	assert.Equal(t, []byte("0123456789"), fields["bytes"])
+	assert.Equal(t, 200, fields["status"])
+	assert.Equal(t, "ok", fields["small"])
+	// A multi-byte character is never split.
+	assert.Equal(t, "ééééé...[truncated 4 bytes]", fields["multibyte"])
+
+	assert.Equal(t, "untouched", entries[1].Message)
+	assert.Equal(t, uint64(1), limits.TruncatedEntries())
+}
+
+func TestSizeLimits_MaxEntryBytes(t *testing.T) {
+	limits := &logger.SizeLimits{MaxEntryBytes: 200}
+	log, recorder := newSizeLimitedLogger(t, limits)
+
+	err := errors.New(strings.Repeat("e", 500))
+	log.Error(context.Background(), "request failed", err, logger.Fields{
+		"request_id":    "req-123",
+		"response_body": strings.Repeat("b", 1000),
+	})
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "request failed", entry.Message)
+	assert.Equal(t, "req-123", entry.Fields["request_id"])
+	// The error is never truncated.
+	assert.Equal(t, err, entry.Error)
+
+	body, ok := entry.Fields["response_body"].(string)
+	require.True(t, ok)
+	assert.Contains(t, body, "...[truncated ")
+	size := len(entry.Message) + len(body) + len("response_body") + len("request_id") + len("req-123") + len("error")
+	assert.LessOrEqual(t, size, 200)
+	assert.Equal(t, uint64(1), limits.TruncatedEntries())
+}
+
+func TestSizeLimits_Message(t *testing.T) {
+	limits := &logger.SizeLimits{MaxFieldBytes: 5}
+	log, recorder := newSizeLimitedLogger(t, limits)
+
+	log.Info(context.Background(), "a very long message", nil)
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "a ver...[truncated 14 bytes]", entries[0].Message)
+}
+
+func newSizeLimitedLogger(t *testing.T, limits *logger.SizeLimits) (logger.Logger, *logger.Recorder) {
+	t.Helper()
+	recorder := &logger.Recorder{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:      logger.INFO,
+		Backend:    logger.NewFormatterBackend(&discardFormatter{}),
+		Hooks:      []logger.Hook{recorder},
+		SizeLimits: limits,
+	})
+	require.NoError(t, err)
+	return log, recorder
+}
@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	"github.com/kittipat1413/go-common/util/slice"
-	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -17,30 +16,58 @@ const (
 	DefaultSJsonFmtSeverityKey   = "severity"
 	DefaultSJsonFmtMessageKey    = "message"
 	DefaultSJsonFmtErrorKey      = "error"
+	DefaultSJsonFmtErrorChainKey = "error_chain"
 	DefaultSJsonFmtTraceIDKey    = "trace_id"
 	DefaultSJsonFmtSpanIDKey     = "span_id"
 	DefaultSJsonFmtCallerKey     = "caller"
 	DefaultSJsonFmtCallerFuncKey = "function"
 	DefaultSJsonFmtCallerFileKey = "file"
 	DefaultSJsonFmtStackTraceKey = "stack_trace"
+	// DefaultSJsonFmtConflictPrefix is prepended to user field keys colliding with reserved keys under KeyConflictPrefixField.
+	DefaultSJsonFmtConflictPrefix = "fields."
+)
+
+// sJsonFmtReservedKeys are the keys written by the StructuredJSONFormatter itself.
+var sJsonFmtReservedKeys = []string{
+	DefaultSJsonFmtTimestampKey,
+	DefaultSJsonFmtSeverityKey,
+	DefaultSJsonFmtMessageKey,
+	DefaultSJsonFmtErrorKey,
+	DefaultSJsonFmtErrorChainKey,
+	DefaultSJsonFmtTraceIDKey,
+	DefaultSJsonFmtSpanIDKey,
+	DefaultSJsonFmtCallerKey,
+	DefaultSJsonFmtStackTraceKey,
+}
+
+// KeyConflictPolicy defines how user fields colliding with the reserved keys of a formatter are written.
+type KeyConflictPolicy int
+
+const (
+	// KeyConflictDropField drops user fields colliding with reserved keys, which always win. This is the default.
+	KeyConflictDropField KeyConflictPolicy = iota
+	// KeyConflictPrefixField writes user fields colliding with reserved keys under DefaultSJsonFmtConflictPrefix + key.
+	KeyConflictPrefixField
 )
 
 var defaultSJsonFmtSkipPackages = []string{
-	"github.com/sirupsen/logrus",
 	"github.com/kittipat1413/go-common/framework/logger",
 }
 
 /*
-StructuredJSONFormatter is a custom logrus formatter for structured JSON logs.
+StructuredJSONFormatter is a formatter for structured JSON logs.
 It includes the following fields:
   - timestamp: The log timestamp in the specified format.
   - severity: The log severity level (e.g., info, debug, error).
   - message: The log message.
   - error: The error message if present.
+  - error_chain: The type and message of every error wrapped by the error, if it wraps any.
   - trace_id: The trace ID if available.
   - span_id: The span ID if available.
   - caller: The caller's function name, file, and line number.
-  - stack_trace: The stack trace for error levels.
+  - stack_trace: The stack trace for error levels, taken from the error if it carries one (see framework/errors.WithStack).
+
+Keys can be renamed with FieldKeyMap or FieldKeyFormatter.
 */
 type StructuredJSONFormatter struct {
 	// TimestampFormat sets the format used for marshaling timestamps.
@@ -51,6 +78,11 @@ type StructuredJSONFormatter struct {
 	SkipPackages []string
 	// FieldKeyFormatter is a function type that allows users to customize log field keys.
 	FieldKeyFormatter FieldKeyFormatter
+	// FieldKeyMap renames output keys, e.g., {"timestamp": "ts", "severity": "level", "message": "msg"}.
+	// Keys found in the map are not passed to FieldKeyFormatter.
+	FieldKeyMap map[string]string
+	// KeyConflictPolicy defines how user fields colliding with reserved keys (after renaming) are written.
+	KeyConflictPolicy KeyConflictPolicy
 }
 
 /*
@@ -70,22 +102,25 @@ func NoopFieldKeyFormatter(defaultKey string) string {
 	return defaultKey
 }
 
-// Format implements the logrus.Formatter interface.
-func (f *StructuredJSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+// Format implements the Formatter interface.
+func (f *StructuredJSONFormatter) Format(ctx context.Context, entry Entry) ([]byte, error) {
 	// Use the default field key formatter if not provided.
 	if f.FieldKeyFormatter == nil {
 		f.FieldKeyFormatter = NoopFieldKeyFormatter
 	}
 
 	// Prepare the data map for JSON serialization.
-	data := make(logrus.Fields, len(entry.Data)+7)
+	data := make(Fields, len(entry.Fields)+7)
 
-	// Apply FieldKeyFormatter to keys in entry.Data and copy them to data.
-	for key, value := range entry.Data {
+	// Rename keys in entry.Fields and copy them to data.
+	for key, value := range entry.Fields {
 		if key == DefaultErrorKey {
 			continue // Skip the default error key
 		}
-		formattedKey := f.FieldKeyFormatter(key)
+		formattedKey := f.key(key)
+		if f.KeyConflictPolicy == KeyConflictPrefixField && f.isReservedKey(formattedKey) {
+			formattedKey = DefaultSJsonFmtConflictPrefix + formattedKey
+		}
 		switch v := value.(type) {
 		case error:
 			data[formattedKey] = v.Error()
@@ -95,13 +130,13 @@ func (f *StructuredJSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 	}
 
 	// Add predefined keys with formatted keys.
-	data[f.FieldKeyFormatter(DefaultSJsonFmtTimestampKey)] = entry.Time.Format(f.TimestampFormat)
-	data[f.FieldKeyFormatter(DefaultSJsonFmtSeverityKey)] = entry.Level.String()
-	data[f.FieldKeyFormatter(DefaultSJsonFmtMessageKey)] = entry.Message
+	data[f.key(DefaultSJsonFmtTimestampKey)] = entry.Time.Format(f.TimestampFormat)
+	data[f.key(DefaultSJsonFmtSeverityKey)] = entrySeverity(entry)
+	data[f.key(DefaultSJsonFmtMessageKey)] = entry.Message
 
 	// Include error message if present.
-	if err, ok := entry.Data[DefaultErrorKey]; ok {
-		formattedErrorKey := f.FieldKeyFormatter(DefaultSJsonFmtErrorKey)
+	if err, ok := entry.Fields[DefaultErrorKey]; ok {
+		formattedErrorKey := f.key(DefaultSJsonFmtErrorKey)
 		switch e := err.(type) {
 		case error:
 			data[formattedErrorKey] = e.Error()
@@ -109,15 +144,18 @@ func (f *StructuredJSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 			data[formattedErrorKey] = fmt.Sprintf("%v", e)
 		}
 	}
+	if chain, ok := entryErrorChain(entry); ok {
+		data[f.key(DefaultSJsonFmtErrorChainKey)] = chain
+	}
 
 	// Include trace and span IDs if available.
-	if entry.Context != nil {
-		traceID, spanID := extractTraceIDs(entry.Context)
+	if ctx != nil {
+		traceID, spanID := extractTraceIDs(ctx)
 		if traceID != nil {
-			data[f.FieldKeyFormatter(DefaultSJsonFmtTraceIDKey)] = *traceID
+			data[f.key(DefaultSJsonFmtTraceIDKey)] = *traceID
 		}
 		if spanID != nil {
-			data[f.FieldKeyFormatter(DefaultSJsonFmtSpanIDKey)] = *spanID
+			data[f.key(DefaultSJsonFmtSpanIDKey)] = *spanID
 		}
 	}
 
@@ -125,18 +163,18 @@ func (f *StructuredJSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 	skipPackages := slice.Union(f.SkipPackages, defaultSJsonFmtSkipPackages)
 
 	// Caller's function name, file, and line number.
-	function, file, line := getCaller(skipPackages)
+	function, file, line := getCaller(skipPackages, callerSkipFromContext(ctx))
 	if function != "" && file != "" && line != 0 {
 		callerInfo := map[string]string{
-			f.FieldKeyFormatter(DefaultSJsonFmtCallerFuncKey): function,
-			f.FieldKeyFormatter(DefaultSJsonFmtCallerFileKey): fmt.Sprintf("%s:%d", file, line),
+			f.key(DefaultSJsonFmtCallerFuncKey): function,
+			f.key(DefaultSJsonFmtCallerFileKey): fmt.Sprintf("%s:%d", file, line),
 		}
-		data[f.FieldKeyFormatter(DefaultSJsonFmtCallerKey)] = callerInfo
+		data[f.key(DefaultSJsonFmtCallerKey)] = callerInfo
 	}
 
 	// Stack trace for error levels.
-	if entry.Level <= logrus.ErrorLevel {
-		data[f.FieldKeyFormatter(DefaultSJsonFmtStackTraceKey)] = getStackTrace()
+	if isErrorLevel(entry.Level) {
+		data[f.key(DefaultSJsonFmtStackTraceKey)] = entryStackTrace(entry)
 	}
 
 	// Serialize the data to JSON.
@@ -153,6 +191,24 @@ func (f *StructuredJSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 	return append(serialized, '\n'), nil
 }
 
+// key returns the output key of defaultKey, renamed by FieldKeyMap or FieldKeyFormatter.
+func (f *StructuredJSONFormatter) key(defaultKey string) string {
+	if key, ok := f.FieldKeyMap[defaultKey]; ok {
+		return key
+	}
+	return f.FieldKeyFormatter(defaultKey)
+}
+
+// isReservedKey reports whether key is the output key of one of the keys written by the formatter.
+func (f *StructuredJSONFormatter) isReservedKey(key string) bool {
+	for _, reserved := range sJsonFmtReservedKeys {
+		if key == f.key(reserved) {
+			return true
+		}
+	}
+	return false
+}
+
 // extractTraceIDs retrieves the trace and span IDs from the context.
 func extractTraceIDs(ctx context.Context) (*string, *string) {
 	span := trace.SpanFromContext(ctx)
@@ -193,8 +249,8 @@ func getStackTrace() string {
 }
 
 // getCaller retrieves the caller's function name, file, and line number,
-// skipping frames from the specified packages.
-func getCaller(skipPackages []string) (function string, file string, line int) {
+// skipping frames from the specified packages and then callerSkip additional frames.
+func getCaller(skipPackages []string, callerSkip int) (function string, file string, line int) {
 	const maxDepth = 25
 	pcs := make([]uintptr, maxDepth)
 	depth := runtime.Callers(3, pcs)
@@ -212,12 +268,18 @@ func getCaller(skipPackages []string) (function string, file string, line int) {
 
 		skip := false
 		for _, pkg := range skipPackages {
-			if strings.HasPrefix(frame.Function, pkg) {
+			if inPackage(frame.Function, pkg) {
 				skip = true
 				break
 			}
 		}
 
+		if !skip && callerSkip > 0 {
+			// Skip frames of wrappers around the logger (see Config.CallerSkip).
+			callerSkip--
+			skip = true
+		}
+
 		if !skip {
 			function = frame.Function
 			file = frame.File
@@ -231,3 +293,13 @@ func getCaller(skipPackages []string) (function string, file string, line int) {
 	}
 	return
 }
+
+// inPackage reports whether the fully qualified function name belongs to pkg or one of its sub-packages.
+// The test package of pkg (e.g., "logger_test") is not considered part of pkg.
+func inPackage(function string, pkg string) bool {
+	if !strings.HasPrefix(function, pkg) {
+		return false
+	}
+	rest := function[len(pkg):]
+	return rest == "" || rest[0] == '.' || rest[0] == '/'
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/kittipat1413/go-common/framework/logger"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
@@ -114,3 +115,109 @@ func TestStructuredJSONFormatter_WithTraceAndSpanIDs(t *testing.T) {
 	assert.Equal(t, "Info message with trace and span IDs", logEntry["message"], "message should match")
 	assert.Equal(t, "info", logEntry["severity"], "severity should match")
 }
+
+// logThroughWrapper simulates a helper that wraps the logger.
+func logThroughWrapper(ctx context.Context, log logger.Logger) {
+	log.Info(ctx, "Wrapped message", nil)
+}
+
+func TestStructuredJSONFormatter_CallerSkip(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Output: buffer,
+	})
+	require.NoError(t, err)
+
+	callerFunction := func() string {
+		var logEntry map[string]interface{}
+		require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+		buffer.Reset()
+		caller, ok := logEntry["caller"].(map[string]interface{})
+		require.True(t, ok, "log should contain caller information")
+		return caller["function"].(string)
+	}
+
+	ctx := context.Background()
+	logThroughWrapper(ctx, log)
+	assert.True(t, strings.HasSuffix(callerFunction(), ".logThroughWrapper"), "caller should be the wrapper without skip")
+
+	logThroughWrapper(ctx, log.WithCallerSkip(1))
+	assert.True(t, strings.HasSuffix(callerFunction(), ".TestStructuredJSONFormatter_CallerSkip"), "caller should be the wrapper's caller")
+
+	// Config.CallerSkip and WithCallerSkip are cumulative.
+	skipLog, err := logger.NewLogger(logger.Config{
+		Level:      logger.INFO,
+		Output:     buffer,
+		CallerSkip: 1,
+	})
+	require.NoError(t, err)
+	func() {
+		logThroughWrapper(ctx, skipLog.WithCallerSkip(1))
+	}()
+	assert.True(t, strings.HasSuffix(callerFunction(), ".TestStructuredJSONFormatter_CallerSkip"), "caller should skip the wrapper and the closure")
+}
+
+func TestStructuredJSONFormatter_WithFieldKeyMap(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         logger.KeyConflictPolicy
+		expectedFields map[string]interface{}
+		missingFields  []string
+	}{
+		{
+			name:   "drop conflicting field",
+			policy: logger.KeyConflictDropField,
+			expectedFields: map[string]interface{}{
+				"level":   "info",
+				"msg":     "Info message",
+				"service": "my-service",
+			},
+			missingFields: []string{"timestamp", "severity", "message", "fields.msg"},
+		},
+		{
+			name:   "prefix conflicting field",
+			policy: logger.KeyConflictPrefixField,
+			expectedFields: map[string]interface{}{
+				"level":      "info",
+				"msg":        "Info message",
+				"fields.msg": "user value",
+				"service":    "my-service",
+			},
+			missingFields: []string{"timestamp", "severity", "message"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			log, err := logger.NewLogger(logger.Config{
+				Level:       logger.INFO,
+				ServiceName: "my-service",
+				Formatter: &logger.StructuredJSONFormatter{
+					TimestampFormat: time.RFC3339,
+					FieldKeyMap: map[string]string{
+						logger.DefaultSJsonFmtTimestampKey: "ts",
+						logger.DefaultSJsonFmtSeverityKey:  "level",
+						logger.DefaultSJsonFmtMessageKey:   "msg",
+						logger.DefaultServiceNameKey:       "service",
+					},
+					KeyConflictPolicy: tt.policy,
+				},
+				Output: buffer,
+			})
+			require.NoError(t, err)
+
+			log.Info(context.Background(), "Info message", logger.Fields{"msg": "user value"})
+
+			var logEntry map[string]interface{}
+			require.NoError(t, json.Unmarshal(buffer.Bytes(), &logEntry))
+			assert.Contains(t, logEntry, "ts")
+			for key, value := range tt.expectedFields {
+				assert.Equal(t, value, logEntry[key], key)
+			}
+			for _, key := range tt.missingFields {
+				assert.NotContains(t, logEntry, key)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSuppressedCountKey is the key used for the number of suppressed entries in the summary entry.
+	DefaultSuppressedCountKey = "suppressed_count"
+	// DefaultSuppressedMessageKey is the key used for the message of the suppressed entries in the summary entry.
+	DefaultSuppressedMessageKey = "suppressed_message"
+	// defaultSuppressionWindow is the window used when DuplicateSuppression.Window is not set.
+	defaultSuppressionWindow = time.Minute
+)
+
+/*
+DuplicateSuppression configures the suppression of duplicate entries.
+
+Entries are fingerprinted by their level, message and the values of the configured Fields.
+The first entry with a given fingerprint is logged, identical entries within Window are suppressed,
+and once the window closes a summary entry "suppressed N similar messages" is logged at the same level.
+FATAL and PANIC entries are never suppressed.
+
+Example usage:
+
+	logConfig := logger.Config{
+		Level: logger.INFO,
+		DuplicateSuppression: &logger.DuplicateSuppression{
+			Window: time.Minute,
+			Fields: []string{"endpoint"}, // entries for different endpoints are not duplicates
+		},
+	}
+*/
+type DuplicateSuppression struct {
+	// Window is the period during which duplicates of a logged entry are suppressed. Defaults to one minute.
+	Window time.Duration
+	// Fields is the list of field keys included in the fingerprint in addition to the level and message.
+	Fields []string
+}
+
+func (d *DuplicateSuppression) isValid() bool {
+	return d.Window >= 0
+}
+
+// suppressor holds the runtime state of a DuplicateSuppression configuration.
+// It is shared by all loggers derived from the same root logger.
+type suppressor struct {
+	window time.Duration
+	fields []string
+
+	mu      sync.Mutex
+	entries map[string]*suppressedEntry
+}
+
+// suppressedEntry tracks the duplicates of a fingerprint within the current window.
+type suppressedEntry struct {
+	count int
+}
+
+func newSuppressor(cfg *DuplicateSuppression) *suppressor {
+	window := cfg.Window
+	if window == 0 {
+		window = defaultSuppressionWindow
+	}
+	return &suppressor{
+		window:  window,
+		fields:  cfg.Fields,
+		entries: make(map[string]*suppressedEntry),
+	}
+}
+
+// allow reports whether the entry should be logged. When the window of a logged entry closes
+// and duplicates were suppressed, summarize is called with the number of suppressed entries
+// and the fingerprint fields of the logged entry.
+func (s *suppressor) allow(level LogLevel, msg string, fields Fields, summarize func(count int, fingerprintFields Fields)) bool {
+	if level.Order() >= FATAL.Order() {
+		return true
+	}
+
+	key := s.fingerprint(level, msg, fields)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok {
+		entry.count++
+		return false
+	}
+
+	// Copy the fingerprint fields, the summary is logged after the caller may have reused its fields.
+	fingerprintFields := make(Fields, len(s.fields))
+	for _, k := range s.fields {
+		if v, ok := fields[k]; ok {
+			fingerprintFields[k] = v
+		}
+	}
+
+	entry := &suppressedEntry{}
+	s.entries[key] = entry
+	time.AfterFunc(s.window, func() {
+		s.mu.Lock()
+		delete(s.entries, key)
+		count := entry.count
+		s.mu.Unlock()
+
+		if count > 0 {
+			summarize(count, fingerprintFields)
+		}
+	})
+	return true
+}
+
+// fingerprint builds the key identifying duplicates of an entry.
+func (s *suppressor) fingerprint(level LogLevel, msg string, fields Fields) string {
+	var sb strings.Builder
+	sb.WriteString(string(level))
+	sb.WriteByte(0)
+	sb.WriteString(msg)
+	for _, key := range s.fields {
+		sb.WriteByte(0)
+		sb.WriteString(key)
+		sb.WriteByte('=')
+		if value, ok := fields[key]; ok {
+			fmt.Fprintf(&sb, "%v", value)
+		}
+	}
+	return sb.String()
+}
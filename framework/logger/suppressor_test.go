@@ -0,0 +1,90 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use, summaries are written from a timer goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Lines() [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.Split(bytes.TrimSpace(b.buf.Bytes()), []byte("\n"))
+}
+
+func TestLogger_DuplicateSuppression(t *testing.T) {
+	buffer := &syncBuffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level: logger.INFO,
+		DuplicateSuppression: &logger.DuplicateSuppression{
+			Window: 50 * time.Millisecond,
+			Fields: []string{"endpoint"},
+		},
+		Output: buffer,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		log.Error(ctx, "request failed", errors.New("timeout"), logger.Fields{"endpoint": "/orders", "attempt": i})
+	}
+	// A different fingerprint field value is not a duplicate.
+	log.Error(ctx, "request failed", errors.New("timeout"), logger.Fields{"endpoint": "/users"})
+	require.Len(t, buffer.Lines(), 2)
+
+	require.Eventually(t, func() bool { return len(buffer.Lines()) == 3 }, time.Second, 10*time.Millisecond)
+
+	var summary map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Lines()[2], &summary))
+	assert.Equal(t, "suppressed 4 similar messages", summary["message"])
+	assert.Equal(t, "error", summary["severity"])
+	assert.Equal(t, float64(4), summary[logger.DefaultSuppressedCountKey])
+	assert.Equal(t, "request failed", summary[logger.DefaultSuppressedMessageKey])
+	assert.Equal(t, "/orders", summary["endpoint"])
+
+	// After the window closes, the entry is logged again.
+	log.Error(ctx, "request failed", errors.New("timeout"), logger.Fields{"endpoint": "/orders"})
+	assert.Len(t, buffer.Lines(), 4)
+}
+
+func TestLogger_DuplicateSuppressionNoSummaryWithoutDuplicates(t *testing.T) {
+	buffer := &syncBuffer{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:                logger.INFO,
+		DuplicateSuppression: &logger.DuplicateSuppression{Window: 20 * time.Millisecond},
+		Output:               buffer,
+	})
+	require.NoError(t, err)
+
+	log.Info(context.Background(), "single message", nil)
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, buffer.Lines(), 1)
+}
+
+func TestLogger_InvalidDuplicateSuppression(t *testing.T) {
+	_, err := logger.NewLogger(logger.Config{
+		Level:                logger.INFO,
+		DuplicateSuppression: &logger.DuplicateSuppression{Window: -time.Second},
+	})
+	assert.ErrorIs(t, err, logger.ErrInvalidDuplicateSuppressionConfig)
+}
@@ -0,0 +1,265 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SyslogFacility is a syslog facility code as defined by RFC 5424.
+type SyslogFacility int
+
+const (
+	FacilityUser     SyslogFacility = 1
+	FacilityMail     SyslogFacility = 2
+	FacilityDaemon   SyslogFacility = 3
+	FacilityAuth     SyslogFacility = 4
+	FacilitySyslog   SyslogFacility = 5
+	FacilityLPR      SyslogFacility = 6
+	FacilityNews     SyslogFacility = 7
+	FacilityUUCP     SyslogFacility = 8
+	FacilityCron     SyslogFacility = 9
+	FacilityAuthPriv SyslogFacility = 10
+	FacilityFTP      SyslogFacility = 11
+	FacilityLocal0   SyslogFacility = 16
+	FacilityLocal1   SyslogFacility = 17
+	FacilityLocal2   SyslogFacility = 18
+	FacilityLocal3   SyslogFacility = 19
+	FacilityLocal4   SyslogFacility = 20
+	FacilityLocal5   SyslogFacility = 21
+	FacilityLocal6   SyslogFacility = 22
+	FacilityLocal7   SyslogFacility = 23
+)
+
+const (
+	// defaultSyslogDialTimeout is the timeout used when SyslogConfig.DialTimeout is not set.
+	defaultSyslogDialTimeout = 5 * time.Second
+	// syslogTimestampFormat is the RFC 5424 timestamp layout (RFC3339 with microsecond precision).
+	syslogTimestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+	// syslogNilValue is the RFC 5424 NILVALUE used for empty header fields.
+	syslogNilValue = "-"
+)
+
+// syslogSeverityMapper maps log levels to RFC 5424 severities.
+var syslogSeverityMapper = map[LogLevel]int{
+	TRACE: 7, // Debug
+	DEBUG: 7, // Debug
+	INFO:  6, // Informational
+	WARN:  4, // Warning
+	ERROR: 3, // Error
+	FATAL: 2, // Critical
+	PANIC: 1, // Alert
+}
+
+// defaultSyslogSockets are the local syslog sockets tried when SyslogConfig.Network is empty.
+// systemd-journald also listens on /dev/log, so entries end up in the journal on systemd hosts.
+var defaultSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+var (
+	ErrInvalidSyslogNetwork = errors.New("invalid syslog network")
+	ErrSyslogUnavailable    = errors.New("no local syslog socket available")
+)
+
+// SyslogConfig holds the configuration of a SyslogWriter.
+type SyslogConfig struct {
+	// Network is the network used to reach the syslog collector: "tcp", "udp", "unix" or "unixgram".
+	// If empty, the writer connects to the local syslog socket (e.g., /dev/log).
+	Network string
+	// Address is the address of the syslog collector (e.g., "syslog.internal:514" or a socket path).
+	Address string
+	// Facility is the syslog facility of the entries. Defaults to FacilityUser.
+	Facility SyslogFacility
+	// AppName is the APP-NAME header field. Defaults to the name of the executable.
+	AppName string
+	// Hostname is the HOSTNAME header field. Defaults to the host name reported by the kernel.
+	Hostname string
+	// DialTimeout is the timeout for connecting to the collector. Defaults to 5 seconds.
+	DialTimeout time.Duration
+}
+
+/*
+SyslogWriter is a LevelWriter that ships formatted entries to a syslog collector using the RFC 5424 format.
+The syslog severity is derived from the entry level and the formatted entry is used as the MSG part.
+Stream connections (tcp, unix) use octet-counting framing as described in RFC 6587.
+If a write fails, the writer reconnects once and retries.
+
+Example usage:
+
+	syslogWriter, err := logger.NewSyslogWriter(logger.SyslogConfig{
+		Network:  "tcp",
+		Address:  "syslog.internal:514",
+		Facility: logger.FacilityLocal0,
+	})
+	if err != nil {
+		// Handle error
+	}
+	defer syslogWriter.Close()
+
+	logConfig := logger.Config{
+		Level:  logger.INFO,
+		Output: syslogWriter,
+	}
+*/
+type SyslogWriter struct {
+	config SyslogConfig
+	pid    string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	network string
+}
+
+// NewSyslogWriter creates a SyslogWriter and connects to the syslog collector.
+func NewSyslogWriter(config SyslogConfig) (*SyslogWriter, error) {
+	switch config.Network {
+	case "", "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSyslogNetwork, config.Network)
+	}
+
+	if config.Facility <= 0 || config.Facility > FacilityLocal7 {
+		config.Facility = FacilityUser
+	}
+	if config.AppName == "" {
+		config.AppName = filepath.Base(os.Args[0])
+	}
+	if config.Hostname == "" {
+		config.Hostname, _ = os.Hostname()
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = defaultSyslogDialTimeout
+	}
+
+	w := &SyslogWriter{
+		config: config,
+		pid:    strconv.Itoa(os.Getpid()),
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write writes p as an INFO entry.
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(INFO, p)
+}
+
+// WriteLevel writes p as a syslog message with the severity derived from level.
+func (w *SyslogWriter) WriteLevel(level LogLevel, p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		if _, err := w.conn.Write(w.buildMessage(level, p)); err == nil {
+			return len(p), nil
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	// Reconnect and retry once.
+	if err := w.dial(); err != nil {
+		return 0, err
+	}
+	if _, err := w.conn.Write(w.buildMessage(level, p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to the syslog collector.
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// buildMessage formats p as an RFC 5424 message, framed for the underlying transport. Must be called with mu held.
+func (w *SyslogWriter) buildMessage(level LogLevel, p []byte) []byte {
+	// Custom levels use the severity of their base level.
+	severity := syslogSeverityMapper[level.Base()]
+	priority := int(w.config.Facility)*8 + severity
+
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	msg := fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
+		priority,
+		time.Now().Format(syslogTimestampFormat),
+		syslogHeaderValue(w.config.Hostname),
+		syslogHeaderValue(w.config.AppName),
+		w.pid,
+		syslogNilValue,
+		syslogNilValue,
+		bytes.TrimRight(p, "\n"),
+	)
+
+	if w.isStream() {
+		// Octet-counting framing (RFC 6587).
+		return []byte(strconv.Itoa(len(msg)) + " " + msg)
+	}
+	return []byte(msg)
+}
+
+// connect establishes the initial connection.
+func (w *SyslogWriter) connect() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dial()
+}
+
+// dial connects to the configured collector, or to the first available local socket. Must be called with mu held.
+func (w *SyslogWriter) dial() error {
+	if w.config.Network != "" {
+		conn, err := net.DialTimeout(w.config.Network, w.config.Address, w.config.DialTimeout)
+		if err != nil {
+			return err
+		}
+		w.conn, w.network = conn, w.config.Network
+		return nil
+	}
+
+	sockets := defaultSyslogSockets
+	if w.config.Address != "" {
+		sockets = []string{w.config.Address}
+	}
+	for _, socket := range sockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.DialTimeout(network, socket, w.config.DialTimeout)
+			if err == nil {
+				w.conn, w.network = conn, network
+				return nil
+			}
+		}
+	}
+	return ErrSyslogUnavailable
+}
+
+// isStream reports whether the current connection is stream oriented.
+func (w *SyslogWriter) isStream() bool {
+	switch w.network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return true
+	default:
+		return false
+	}
+}
+
+// syslogHeaderValue returns value, or the NILVALUE if it is empty.
+func syslogHeaderValue(value string) string {
+	if value == "" {
+		return syslogNilValue
+	}
+	return value
+}
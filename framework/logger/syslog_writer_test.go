@@ -0,0 +1,119 @@
+package logger_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogWriter_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	writer, err := logger.NewSyslogWriter(logger.SyslogConfig{
+		Network:  "udp",
+		Address:  conn.LocalAddr().String(),
+		Facility: logger.FacilityLocal0,
+		AppName:  "my-app",
+		Hostname: "my-host",
+	})
+	require.NoError(t, err)
+	defer writer.Close()
+
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Output: writer,
+	})
+	require.NoError(t, err)
+
+	log.Error(context.Background(), "error message", errors.New("boom"), nil)
+
+	buf := make([]byte, 64*1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	msg := string(buf[:n])
+
+	// PRI = Local0 (16) * 8 + Error (3) = 131
+	assert.True(t, strings.HasPrefix(msg, "<131>1 "), "unexpected message: %s", msg)
+	fields := strings.SplitN(msg, " ", 8)
+	require.Len(t, fields, 8)
+	assert.Equal(t, "my-host", fields[2])
+	assert.Equal(t, "my-app", fields[3])
+	assert.Equal(t, "-", fields[5])
+	assert.Equal(t, "-", fields[6])
+	assert.Contains(t, fields[7], `"message":"error message"`)
+	assert.False(t, strings.HasSuffix(msg, "\n"))
+}
+
+func TestSyslogWriter_TCPOctetCounting(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(length))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(reader, msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	writer, err := logger.NewSyslogWriter(logger.SyslogConfig{
+		Network: "tcp",
+		Address: listener.Addr().String(),
+	})
+	require.NoError(t, err)
+	defer writer.Close()
+
+	_, err = writer.WriteLevel(logger.DEBUG, []byte("first\n"))
+	require.NoError(t, err)
+	_, err = writer.WriteLevel(logger.WARN, []byte("second\n"))
+	require.NoError(t, err)
+
+	for _, expected := range []struct {
+		prefix string
+		suffix string
+	}{
+		{"<15>1 ", " - - first"},  // User (1) * 8 + Debug (7)
+		{"<12>1 ", " - - second"}, // User (1) * 8 + Warning (4)
+	} {
+		select {
+		case msg := <-received:
+			assert.True(t, strings.HasPrefix(msg, expected.prefix), "unexpected message: %s", msg)
+			assert.True(t, strings.HasSuffix(msg, expected.suffix), "unexpected message: %s", msg)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for syslog message")
+		}
+	}
+}
+
+func TestSyslogWriter_InvalidNetwork(t *testing.T) {
+	_, err := logger.NewSyslogWriter(logger.SyslogConfig{Network: "http"})
+	assert.ErrorIs(t, err, logger.ErrInvalidSyslogNetwork)
+}
@@ -0,0 +1,177 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TestingT is the subset of testing.TB used by the Recorder assertions.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// FieldMatcher reports whether the fields of a recorded entry match an expectation.
+type FieldMatcher func(fields Fields) bool
+
+// HasField returns a FieldMatcher that matches entries whose field key equals value.
+func HasField(key string, value interface{}) FieldMatcher {
+	return func(fields Fields) bool {
+		actual, ok := fields[key]
+		return ok && reflect.DeepEqual(actual, value)
+	}
+}
+
+// HasFieldKey returns a FieldMatcher that matches entries containing the field key.
+func HasFieldKey(key string) FieldMatcher {
+	return func(fields Fields) bool {
+		_, ok := fields[key]
+		return ok
+	}
+}
+
+/*
+NewTestLogger returns a Logger that captures every entry (at all levels) in a Recorder instead of writing it,
+so unit tests can verify logging behavior without parsing JSON output.
+Fatal does not exit the process, the entry is recorded and Fatal returns.
+
+Example usage:
+
+	log, recorder := logger.NewTestLogger()
+	service := NewService(log)
+
+	service.DoSomething(ctx)
+
+	recorder.AssertLogged(t, logger.ERROR, "failed to process", logger.HasField("order_id", 42))
+*/
+func NewTestLogger() (Logger, *Recorder) {
+	recorder := &Recorder{}
+	log, err := NewLogger(Config{
+		Level:  DEBUG,
+		Output: io.Discard,
+		Hooks:  []Hook{recorder},
+		// Fatal entries are recorded without exiting the test binary.
+		ExitFunc: func(int) {},
+	})
+	if err != nil {
+		// The configuration is static and always valid.
+		panic(err)
+	}
+	return log, recorder
+}
+
+// Recorder is a Hook that captures log entries. It is safe for concurrent use.
+type Recorder struct {
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+// Levels implements the Hook interface. The Recorder captures all levels.
+func (r *Recorder) Levels() []LogLevel {
+	return nil
+}
+
+// Fire implements the Hook interface by capturing a copy of the entry.
+func (r *Recorder) Fire(_ context.Context, entry Entry) error {
+	fields := make(Fields, len(entry.Fields))
+	for k, v := range entry.Fields {
+		fields[k] = v
+	}
+	entry.Fields = fields
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+// Entries returns all captured entries in the order they were logged.
+func (r *Recorder) Entries() []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// EntriesAtLevel returns the captured entries with the given level.
+func (r *Recorder) EntriesAtLevel(level LogLevel) []Entry {
+	var entries []Entry
+	for _, entry := range r.Entries() {
+		if entry.Level == level {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Len returns the number of captured entries.
+func (r *Recorder) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.entries)
+}
+
+// Reset discards all captured entries.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// Find returns the captured entries with the given level whose message contains msgContains and whose fields
+// match all the given matchers.
+func (r *Recorder) Find(level LogLevel, msgContains string, fieldMatchers ...FieldMatcher) []Entry {
+	var found []Entry
+	for _, entry := range r.Entries() {
+		if entry.Level != level || !strings.Contains(entry.Message, msgContains) {
+			continue
+		}
+		matched := true
+		for _, matcher := range fieldMatchers {
+			if matcher != nil && !matcher(entry.Fields) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+// AssertLogged asserts that at least one entry with the given level, message substring and fields was logged.
+func (r *Recorder) AssertLogged(t TestingT, level LogLevel, msgContains string, fieldMatchers ...FieldMatcher) bool {
+	t.Helper()
+	if len(r.Find(level, msgContains, fieldMatchers...)) == 0 {
+		t.Errorf("expected a %s entry containing %q to be logged, got:\n%s", level, msgContains, r.dump())
+		return false
+	}
+	return true
+}
+
+// AssertNotLogged asserts that no entry with the given level, message substring and fields was logged.
+func (r *Recorder) AssertNotLogged(t TestingT, level LogLevel, msgContains string, fieldMatchers ...FieldMatcher) bool {
+	t.Helper()
+	if found := r.Find(level, msgContains, fieldMatchers...); len(found) > 0 {
+		t.Errorf("expected no %s entry containing %q to be logged, found %d", level, msgContains, len(found))
+		return false
+	}
+	return true
+}
+
+// dump returns a human-readable list of the captured entries for assertion failures.
+func (r *Recorder) dump() string {
+	entries := r.Entries()
+	if len(entries) == 0 {
+		return "  (no entries)"
+	}
+	var sb strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&sb, "  [%s] %s %v\n", entry.Level, entry.Message, entry.Fields)
+	}
+	return sb.String()
+}
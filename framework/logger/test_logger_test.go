@@ -0,0 +1,59 @@
+package logger_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeT records assertion failures instead of failing the test.
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestNewTestLogger(t *testing.T) {
+	log, recorder := logger.NewTestLogger()
+	ctx := context.Background()
+	testErr := errors.New("boom")
+
+	log.Debug(ctx, "debug message", nil)
+	log.WithFields(logger.Fields{"component": "orders"}).Error(ctx, "failed to process order", testErr, logger.Fields{"order_id": 42})
+
+	require.Equal(t, 2, recorder.Len())
+	entries := recorder.EntriesAtLevel(logger.ERROR)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "failed to process order", entries[0].Message)
+	assert.Equal(t, testErr, entries[0].Error)
+	assert.Equal(t, 42, entries[0].Fields["order_id"])
+	assert.Equal(t, "orders", entries[0].Fields["component"])
+
+	assert.True(t, recorder.AssertLogged(t, logger.ERROR, "process order", logger.HasField("order_id", 42), logger.HasFieldKey("component")))
+	assert.True(t, recorder.AssertLogged(t, logger.DEBUG, "debug"))
+	assert.True(t, recorder.AssertNotLogged(t, logger.INFO, ""))
+
+	recorder.Reset()
+	assert.Equal(t, 0, recorder.Len())
+}
+
+func TestRecorder_AssertionFailures(t *testing.T) {
+	log, recorder := logger.NewTestLogger()
+	log.Info(context.Background(), "hello", logger.Fields{"key": "value"})
+
+	ft := &fakeT{}
+	assert.False(t, recorder.AssertLogged(ft, logger.INFO, "hello", logger.HasField("key", "other")))
+	assert.False(t, recorder.AssertLogged(ft, logger.WARN, "hello"))
+	assert.False(t, recorder.AssertNotLogged(ft, logger.INFO, "hell"))
+	require.Len(t, ft.errors, 3)
+	assert.Contains(t, ft.errors[0], "[info] hello")
+}
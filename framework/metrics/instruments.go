@@ -0,0 +1,316 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds of the buckets of the histograms created without buckets, suited to
+// latencies in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+)
+
+// helpReplacer escapes the help texts as required by the Prometheus text format.
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// atomicFloat is a float64 updated atomically.
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) Load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+func (f *atomicFloat) Store(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) Add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// series is the value of a metric for a combination of label values.
+type series struct {
+	values []string
+	value  atomicFloat
+	// buckets counts the observations of every bucket of a histogram, not cumulatively, the last one being +Inf.
+	buckets []atomic.Uint64
+}
+
+// metric is a registered metric and its series.
+type metric struct {
+	name      string
+	help      string
+	kind      string
+	labels    []string
+	buckets   []float64
+	maxSeries int
+	mutex     sync.RWMutex
+	series    map[string]*series
+}
+
+// validate checks the name and the labels of the metric.
+func (m *metric) validate(constLabels map[string]string) error {
+	if err := validateName(m.kind, m.name); err != nil {
+		return err
+	}
+	return validateLabels(m.labels, constLabels)
+}
+
+// compatible tells whether other has the type, the labels and the buckets of the metric.
+func (m *metric) compatible(other *metric) bool {
+	if m.kind != other.kind || len(m.labels) != len(other.labels) || len(m.buckets) != len(other.buckets) {
+		return false
+	}
+	for i := range m.labels {
+		if m.labels[i] != other.labels[i] {
+			return false
+		}
+	}
+	for i := range m.buckets {
+		if m.buckets[i] != other.buckets[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// seriesFor returns the series of the label values, created on first use. It panics if the number of values does
+// not match the labels of the metric, like the Prometheus client does.
+func (m *metric) seriesFor(values []string) *series {
+	if len(values) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels %v, got %d values %v", m.name, len(m.labels), m.labels, len(values), values))
+	}
+	key := strings.Join(values, "\xff")
+	m.mutex.RLock()
+	s, ok := m.series[key]
+	m.mutex.RUnlock()
+	if ok {
+		return s
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if s, ok = m.series[key]; ok {
+		return s
+	}
+	if len(m.series) >= m.maxSeries {
+		// The values beyond the limit are recorded in the overflow series, which does not count in the limit.
+		values = make([]string, len(m.labels))
+		for i := range values {
+			values[i] = OverflowLabelValue
+		}
+		key = strings.Join(values, "\xff")
+		if s, ok = m.series[key]; ok {
+			return s
+		}
+	}
+	s = &series{values: append([]string(nil), values...)}
+	if m.kind == kindHistogram {
+		s.buckets = make([]atomic.Uint64, len(m.buckets)+1)
+	}
+	m.series[key] = s
+	return s
+}
+
+// write writes the metric to buf in the Prometheus text format, with the constant labels of the registry.
+func (m *metric) write(buf *bytes.Buffer, constLabels []string) {
+	m.mutex.RLock()
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	series := make([]*series, len(keys))
+	for i, key := range keys {
+		series[i] = m.series[key]
+	}
+	m.mutex.RUnlock()
+
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", m.name, helpReplacer.Replace(m.help), m.name, m.kind)
+	for _, s := range series {
+		labels := append([]string(nil), constLabels...)
+		for i, label := range m.labels {
+			labels = append(labels, fmt.Sprintf(`%s="%s"`, label, labelValueReplacer.Replace(s.values[i])))
+		}
+		if m.kind != kindHistogram {
+			fmt.Fprintf(buf, "%s%s %v\n", m.name, formatLabels(labels), s.value.Load())
+			continue
+		}
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += s.buckets[i].Load()
+			fmt.Fprintf(buf, "%s_bucket%s %d\n", m.name, formatLabels(append(labels, fmt.Sprintf(`le="%v"`, bound))), cumulative)
+		}
+		cumulative += s.buckets[len(m.buckets)].Load()
+		fmt.Fprintf(buf, "%s_bucket%s %d\n", m.name, formatLabels(append(labels, `le="+Inf"`)), cumulative)
+		fmt.Fprintf(buf, "%s_sum%s %v\n", m.name, formatLabels(labels), s.value.Load())
+		fmt.Fprintf(buf, "%s_count%s %d\n", m.name, formatLabels(labels), cumulative)
+	}
+}
+
+// formatLabels returns the labels between braces, or nothing without labels.
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+// Counter is a metric whose values only increase, e.g., the number of requests.
+type Counter struct {
+	metric *metric
+}
+
+// NewCounter creates a counter named name, ending with _total, with the label names labels, or returns the
+// registered one.
+func (r *Registry) NewCounter(name, help string, labels ...string) (*Counter, error) {
+	m, err := r.newMetric(name, help, kindCounter, labels, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Counter{metric: m}, nil
+}
+
+// Inc increments the series of the label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v to the series of the label values. The negative values are ignored, since a counter only increases.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.metric.seriesFor(labelValues).value.Add(v)
+}
+
+// Gauge is a metric whose values go up and down, e.g., the number of requests in flight.
+type Gauge struct {
+	metric *metric
+}
+
+// NewGauge creates a gauge named name with the label names labels, or returns the registered one.
+func (r *Registry) NewGauge(name, help string, labels ...string) (*Gauge, error) {
+	m, err := r.newMetric(name, help, kindGauge, labels, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Gauge{metric: m}, nil
+}
+
+// Set sets the series of the label values to v.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.metric.seriesFor(labelValues).value.Store(v)
+}
+
+// Add adds v, which may be negative, to the series of the label values.
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.metric.seriesFor(labelValues).value.Add(v)
+}
+
+// Inc increments the series of the label values.
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec decrements the series of the label values.
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+// Histogram is a metric counting the observations in buckets, e.g., the latency of the requests.
+type Histogram struct {
+	metric *metric
+}
+
+// NewHistogram creates a histogram named name with the upper bounds buckets, DefaultBuckets if empty, and the
+// label names labels, or returns the registered one.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) (*Histogram, error) {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	m, err := r.newMetric(name, help, kindHistogram, labels, buckets)
+	if err != nil {
+		return nil, err
+	}
+	return &Histogram{metric: m}, nil
+}
+
+// Observe records v in the series of the label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	s := h.metric.seriesFor(labelValues)
+	s.buckets[sort.SearchFloat64s(h.metric.buckets, v)].Add(1)
+	s.value.Add(v)
+}
+
+/*
+Measure starts a timer, and returns the function recording the seconds elapsed since in the series of the label
+values of h.
+
+Example usage:
+
+	func (s *Service) Charge(ctx context.Context, req ChargeRequest) error {
+		defer metrics.Measure(chargeDuration, req.Currency)()
+		// ...
+	}
+*/
+func Measure(h *Histogram, labelValues ...string) func() {
+	start := time.Now()
+	return func() {
+		h.Observe(time.Since(start).Seconds(), labelValues...)
+	}
+}
+
+// newMetric registers the metric, or returns the registered one, creating the series of the metrics without
+// labels, which are written from the start.
+func (r *Registry) newMetric(name, help, kind string, labels []string, buckets []float64) (*metric, error) {
+	m, err := r.register(&metric{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  append([]string(nil), labels...),
+		buckets: buckets,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(m.labels) == 0 {
+		m.seriesFor(nil)
+	}
+	return m, nil
+}
+
+// NewCounter creates a counter in the default registry, see Registry.NewCounter.
+func NewCounter(name, help string, labels ...string) (*Counter, error) {
+	return defaultRegistry.NewCounter(name, help, labels...)
+}
+
+// NewGauge creates a gauge in the default registry, see Registry.NewGauge.
+func NewGauge(name, help string, labels ...string) (*Gauge, error) {
+	return defaultRegistry.NewGauge(name, help, labels...)
+}
+
+// NewHistogram creates a histogram in the default registry, see Registry.NewHistogram.
+func NewHistogram(name, help string, buckets []float64, labels ...string) (*Histogram, error) {
+	return defaultRegistry.NewHistogram(name, help, buckets, labels...)
+}
@@ -0,0 +1,81 @@
+package metrics_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+func TestCounter(t *testing.T) {
+	registry := metrics.NewRegistry()
+	requests := metrics.Must(registry.NewCounter("requests_total", "Requests.", "method"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			requests.Inc("GET")
+		}()
+	}
+	wg.Wait()
+	requests.Add(2.5, "POST")
+	requests.Add(-1, "POST")
+
+	output := write(t, registry)
+	assert.Contains(t, output, `requests_total{method="GET"} 100`)
+	assert.Contains(t, output, `requests_total{method="POST"} 2.5`, "the negative values are ignored")
+	assert.Panics(t, func() { requests.Inc() }, "the label values must match the labels")
+}
+
+func TestGauge(t *testing.T) {
+	registry := metrics.NewRegistry()
+	inFlight := metrics.Must(registry.NewGauge("in_flight", "Requests in flight.", "method"))
+
+	inFlight.Inc("GET")
+	inFlight.Inc("GET")
+	inFlight.Dec("GET")
+	inFlight.Add(-3, "POST")
+	inFlight.Set(7, "PUT")
+
+	output := write(t, registry)
+	assert.Contains(t, output, `in_flight{method="GET"} 1`)
+	assert.Contains(t, output, `in_flight{method="POST"} -3`)
+	assert.Contains(t, output, `in_flight{method="PUT"} 7`)
+}
+
+func TestHistogram(t *testing.T) {
+	registry := metrics.NewRegistry()
+	latency := metrics.Must(registry.NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1}, "method"))
+
+	latency.Observe(0.05, "GET")
+	latency.Observe(0.5, "GET")
+	latency.Observe(5, "GET")
+
+	assert.Equal(t, `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{method="GET",le="0.1"} 1
+latency_seconds_bucket{method="GET",le="1"} 2
+latency_seconds_bucket{method="GET",le="+Inf"} 3
+latency_seconds_sum{method="GET"} 5.55
+latency_seconds_count{method="GET"} 3
+`, write(t, registry))
+}
+
+func TestMeasure(t *testing.T) {
+	registry := metrics.NewRegistry()
+	latency := metrics.Must(registry.NewHistogram("latency_seconds", "Latency.", []float64{0.01, 10}))
+
+	func() {
+		defer metrics.Measure(latency)()
+		time.Sleep(20 * time.Millisecond)
+	}()
+
+	output := write(t, registry)
+	assert.Contains(t, output, `latency_seconds_bucket{le="0.01"} 0`)
+	assert.Contains(t, output, `latency_seconds_bucket{le="10"} 1`)
+}
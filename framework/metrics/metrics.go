@@ -0,0 +1,259 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxSeries is the number of label value combinations a metric may have, unless set with WithMaxSeries.
+const DefaultMaxSeries = 1000
+
+// OverflowLabelValue is the value of every label of the series recording the values of the label value
+// combinations beyond the maximum number of series of a metric, see WithMaxSeries.
+const OverflowLabelValue = "_overflow"
+
+var (
+	// ErrInvalidName is returned when creating a metric with a name which is not in snake_case, or whose suffix
+	// does not match its type: the names of the counters end with _total, and the others do not.
+	ErrInvalidName = errors.New("metrics: invalid metric name")
+	// ErrInvalidLabel is returned when creating a metric with a label name which is not in snake_case, reserved,
+	// or duplicated.
+	ErrInvalidLabel = errors.New("metrics: invalid label name")
+	// ErrConflict is returned when creating a metric with the name of a registered metric of another type, or with
+	// other labels or buckets.
+	ErrConflict = errors.New("metrics: a metric is already registered with this name")
+)
+
+var (
+	nameRegexp  = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	labelRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	// reservedLabels are the label names written by the Prometheus text format or by the Prometheus server.
+	reservedLabels = map[string]bool{"le": true, "quantile": true, "job": true, "instance": true}
+)
+
+// labelValueReplacer escapes label values as required by the Prometheus text format.
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Collector writes metrics in the Prometheus text format, e.g., a cache.StatsExporter, an httpclient.Metrics or a
+// grpc server.Metrics.
+type Collector interface {
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// options holds configuration options for the Registry.
+type options struct {
+	namespace   string
+	constLabels map[string]string
+	maxSeries   int
+}
+
+// Option specifies Registry configuration options.
+type Option func(*options)
+
+// WithNamespace prefixes the names of the metrics of the registry with namespace and an underscore, e.g., the name
+// of the service.
+func WithNamespace(namespace string) Option {
+	return func(opts *options) {
+		opts.namespace = namespace
+	}
+}
+
+// WithConstLabels adds the labels to every series of the registry, e.g., the version of the service. The labels
+// whose names are not in snake_case, or are reserved, are ignored.
+func WithConstLabels(labels map[string]string) Option {
+	return func(opts *options) {
+		for name, value := range labels {
+			if labelRegexp.MatchString(name) && !reservedLabels[name] {
+				opts.constLabels[name] = value
+			}
+		}
+	}
+}
+
+// WithMaxSeries sets the number of label value combinations a metric may have. It defaults to DefaultMaxSeries.
+// The values of the combinations beyond it are recorded in a series whose labels are all OverflowLabelValue, so
+// that a label with unbounded values, e.g., a user ID, does not exhaust the memory of the service and of the
+// Prometheus server.
+func WithMaxSeries(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.maxSeries = n
+		}
+	}
+}
+
+/*
+Registry holds the metrics of a service, and exposes them in the Prometheus text format. Like
+cache.StatsExporter, it has no dependency on a Prometheus client library: serve it on the metrics endpoint, or
+write it with WriteTo.
+
+The metrics are created with names and labels in snake_case, the names of the counters ending with _total, so
+that the metrics of the services are consistent. Creating a metric with the name of a registered one returns the
+registered one if it has the same type, labels and buckets, so that the packages may create the metrics they
+share.
+
+Example usage:
+
+	registry := metrics.NewRegistry(metrics.WithNamespace("payments"))
+	charges := metrics.Must(registry.NewCounter("charges_total", "Number of charges.", "currency", "status"))
+	charges.Inc("EUR", "succeeded")
+	http.Handle("/metrics", registry)
+
+exposes metrics such as:
+
+	payments_charges_total{currency="EUR",status="succeeded"} 1
+*/
+type Registry struct {
+	opts       *options
+	constNames []string
+	mutex      sync.RWMutex
+	metrics    map[string]*metric
+	collectors []Collector
+}
+
+// NewRegistry creates a Registry.
+func NewRegistry(opts ...Option) *Registry {
+	o := &options{constLabels: make(map[string]string), maxSeries: DefaultMaxSeries}
+	for _, opt := range opts {
+		opt(o)
+	}
+	constNames := make([]string, 0, len(o.constLabels))
+	for name := range o.constLabels {
+		constNames = append(constNames, name)
+	}
+	sort.Strings(constNames)
+	return &Registry{opts: o, constNames: constNames, metrics: make(map[string]*metric)}
+}
+
+// defaultRegistry is the registry of the package-level functions.
+var defaultRegistry = NewRegistry()
+
+// Default returns the default registry, used by the package-level functions, e.g., NewCounter.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Handler returns the handler writing the metrics of the default registry.
+func Handler() http.Handler {
+	return defaultRegistry
+}
+
+// Must returns m, and panics if err is not nil. It is meant for the metrics created by the variable
+// declarations of a package, whose names and labels are constant.
+func Must[M any](m M, err error) M {
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// RegisterCollector adds the metrics written by c to the ones of the registry, e.g., the metrics of the gRPC
+// servers, so that a single endpoint serves every metric of the service.
+func (r *Registry) RegisterCollector(c Collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// register returns the metric named name, registering m if there is none.
+func (r *Registry) register(m *metric) (*metric, error) {
+	if err := m.validate(r.opts.constLabels); err != nil {
+		return nil, err
+	}
+	if r.opts.namespace != "" {
+		m.name = r.opts.namespace + "_" + m.name
+	}
+	m.maxSeries = r.opts.maxSeries
+	m.series = make(map[string]*series)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if registered, found := r.metrics[m.name]; found {
+		if !registered.compatible(m) {
+			return nil, fmt.Errorf("%w: %s", ErrConflict, m.name)
+		}
+		return registered, nil
+	}
+	r.metrics[m.name] = m
+	return m, nil
+}
+
+// WriteTo writes the metrics of the registry, then the ones of its collectors, to w in the Prometheus text
+// format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mutex.RLock()
+	metrics := make([]*metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mutex.RUnlock()
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].name < metrics[j].name })
+
+	var buf bytes.Buffer
+	constLabels := r.constLabels()
+	for _, m := range metrics {
+		m.write(&buf, constLabels)
+	}
+	for _, c := range collectors {
+		if _, err := c.WriteTo(&buf); err != nil {
+			return 0, err
+		}
+	}
+	return buf.WriteTo(w)
+}
+
+// ServeHTTP writes the metrics of the registry in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+// constLabels returns the constant labels of the series, formatted for the Prometheus text format.
+func (r *Registry) constLabels() []string {
+	labels := make([]string, 0, len(r.constNames))
+	for _, name := range r.constNames {
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, name, labelValueReplacer.Replace(r.opts.constLabels[name])))
+	}
+	return labels
+}
+
+// validateLabels checks the label names of a metric, with the constant labels of the registry.
+func validateLabels(labels []string, constLabels map[string]string) error {
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		switch {
+		case !labelRegexp.MatchString(label):
+			return fmt.Errorf("%w: %q is not in snake_case", ErrInvalidLabel, label)
+		case reservedLabels[label]:
+			return fmt.Errorf("%w: %q is reserved", ErrInvalidLabel, label)
+		case seen[label]:
+			return fmt.Errorf("%w: %q is duplicated", ErrInvalidLabel, label)
+		}
+		if _, found := constLabels[label]; found {
+			return fmt.Errorf("%w: %q is a constant label of the registry", ErrInvalidLabel, label)
+		}
+		seen[label] = true
+	}
+	return nil
+}
+
+// validateName checks the name of a metric of kind.
+func validateName(kind, name string) error {
+	switch {
+	case !nameRegexp.MatchString(name):
+		return fmt.Errorf("%w: %q is not in snake_case", ErrInvalidName, name)
+	case kind == kindCounter && !strings.HasSuffix(name, "_total"):
+		return fmt.Errorf("%w: the name of the counter %q must end with _total", ErrInvalidName, name)
+	case kind != kindCounter && strings.HasSuffix(name, "_total"):
+		return fmt.Errorf("%w: only the names of the counters end with _total, not the one of the %s %q", ErrInvalidName, kind, name)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+// write returns the metrics of registry in the Prometheus text format.
+func write(t *testing.T, registry *metrics.Registry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	_, err := registry.WriteTo(&buf)
+	require.NoError(t, err)
+	return buf.String()
+}
+
+func TestRegistry_Hygiene(t *testing.T) {
+	registry := metrics.NewRegistry(metrics.WithConstLabels(map[string]string{"version": "1.2.3"}))
+
+	tests := []struct {
+		name   string
+		create func() error
+		err    error
+	}{
+		{name: "counter", create: func() error { _, err := registry.NewCounter("requests_total", "Requests.", "method"); return err }},
+		{name: "gauge", create: func() error { _, err := registry.NewGauge("in_flight", "Requests in flight."); return err }},
+		{name: "camelCase name", create: func() error { _, err := registry.NewGauge("inFlight", "Requests in flight."); return err }, err: metrics.ErrInvalidName},
+		{name: "dashed name", create: func() error { _, err := registry.NewGauge("in-flight", "Requests in flight."); return err }, err: metrics.ErrInvalidName},
+		{name: "counter without _total", create: func() error { _, err := registry.NewCounter("requests", "Requests."); return err }, err: metrics.ErrInvalidName},
+		{name: "gauge with _total", create: func() error { _, err := registry.NewGauge("requests_total", "Requests."); return err }, err: metrics.ErrInvalidName},
+		{name: "camelCase label", create: func() error { _, err := registry.NewCounter("calls_total", "Calls.", "statusCode"); return err }, err: metrics.ErrInvalidLabel},
+		{name: "reserved label", create: func() error { _, err := registry.NewHistogram("latency_seconds", "Latency.", nil, "le"); return err }, err: metrics.ErrInvalidLabel},
+		{name: "duplicated label", create: func() error { _, err := registry.NewCounter("calls_total", "Calls.", "code", "code"); return err }, err: metrics.ErrInvalidLabel},
+		{name: "constant label", create: func() error { _, err := registry.NewCounter("calls_total", "Calls.", "version"); return err }, err: metrics.ErrInvalidLabel},
+		{name: "same metric", create: func() error { _, err := registry.NewCounter("requests_total", "Requests.", "method"); return err }},
+		{name: "other labels", create: func() error { _, err := registry.NewCounter("requests_total", "Requests.", "path"); return err }, err: metrics.ErrConflict},
+		{name: "other type", create: func() error { _, err := registry.NewHistogram("in_flight", "Requests in flight.", nil); return err }, err: metrics.ErrConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.create()
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRegistry_SharedMetric(t *testing.T) {
+	registry := metrics.NewRegistry()
+	first := metrics.Must(registry.NewCounter("requests_total", "Requests.", "method"))
+	second := metrics.Must(registry.NewCounter("requests_total", "Requests.", "method"))
+
+	first.Inc("GET")
+	second.Inc("GET")
+	assert.Contains(t, write(t, registry), `requests_total{method="GET"} 2`)
+}
+
+func TestRegistry_WriteTo(t *testing.T) {
+	registry := metrics.NewRegistry(
+		metrics.WithNamespace("payments"),
+		metrics.WithConstLabels(map[string]string{"version": "1.2.3", "Invalid": "ignored"}),
+	)
+	charges := metrics.Must(registry.NewCounter("charges_total", "Number of charges.", "currency"))
+	metrics.Must(registry.NewGauge("workers", "Number of\nworkers."))
+	charges.Inc("E\"UR")
+
+	assert.Equal(t, `# HELP payments_charges_total Number of charges.
+# TYPE payments_charges_total counter
+payments_charges_total{version="1.2.3",currency="E\"UR"} 1
+# HELP payments_workers Number of\nworkers.
+# TYPE payments_workers gauge
+payments_workers{version="1.2.3"} 0
+`, write(t, registry))
+}
+
+func TestRegistry_MaxSeries(t *testing.T) {
+	registry := metrics.NewRegistry(metrics.WithMaxSeries(2))
+	requests := metrics.Must(registry.NewCounter("requests_total", "Requests.", "user", "method"))
+
+	for _, user := range []string{"alice", "bob", "carol", "dave", "alice"} {
+		requests.Inc(user, "GET")
+	}
+
+	output := write(t, registry)
+	assert.Contains(t, output, `requests_total{user="alice",method="GET"} 2`)
+	assert.Contains(t, output, `requests_total{user="bob",method="GET"} 1`)
+	assert.Contains(t, output, `requests_total{user="_overflow",method="_overflow"} 2`)
+	assert.NotContains(t, output, "carol")
+}
+
+func TestRegistry_RegisterCollector(t *testing.T) {
+	registry := metrics.NewRegistry()
+	metrics.Must(registry.NewCounter("requests_total", "Requests."))
+	exporter := cache.NewStatsExporter("myapp")
+	registry.RegisterCollector(exporter)
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), "requests_total 0\n")
+	assert.Contains(t, recorder.Body.String(), "# TYPE myapp_cache_hits_total counter")
+}
+
+func TestDefault(t *testing.T) {
+	workers := metrics.Must(metrics.NewGauge("default_workers", "Workers."))
+	workers.Set(1)
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, recorder.Body.String(), "default_workers 1\n")
+	assert.Contains(t, write(t, metrics.Default()), "default_workers 1\n")
+}
+
+func TestMust(t *testing.T) {
+	assert.Panics(t, func() {
+		metrics.Must(metrics.NewRegistry().NewCounter("requests", "Requests."))
+	})
+}
@@ -0,0 +1,255 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// DefaultRuntimeInterval is the interval the runtime metrics are sampled at, unless set with WithRuntimeInterval.
+const DefaultRuntimeInterval = 15 * time.Second
+
+// Names of the thresholds of the runtime metrics, in the logs of the RuntimeCollector.
+const (
+	ThresholdGoroutines = "goroutines"
+	ThresholdHeapBytes  = "heap_alloc_bytes"
+	ThresholdOpenFDs    = "open_fds"
+)
+
+// runtimeOptions holds configuration options for the RuntimeCollector.
+type runtimeOptions struct {
+	interval   time.Duration
+	logger     logger.Logger
+	thresholds map[string]float64
+}
+
+// RuntimeOption specifies RuntimeCollector configuration options.
+type RuntimeOption func(*runtimeOptions)
+
+// WithRuntimeInterval sets the interval the metrics are sampled at by Run. It defaults to DefaultRuntimeInterval.
+func WithRuntimeInterval(d time.Duration) RuntimeOption {
+	return func(opts *runtimeOptions) {
+		if d > 0 {
+			opts.interval = d
+		}
+	}
+}
+
+// WithRuntimeLogger sets the logger of the thresholds crossed. It defaults to the logger of the context of Run, see
+// logger.FromContext.
+func WithRuntimeLogger(l logger.Logger) RuntimeOption {
+	return func(opts *runtimeOptions) {
+		opts.logger = l
+	}
+}
+
+// WithGoroutineThreshold logs a warning when the number of goroutines exceeds n, e.g., to detect goroutine leaks.
+func WithGoroutineThreshold(n int) RuntimeOption {
+	return func(opts *runtimeOptions) {
+		if n > 0 {
+			opts.thresholds[ThresholdGoroutines] = float64(n)
+		}
+	}
+}
+
+// WithHeapThreshold logs a warning when the bytes of the allocated heap objects exceed n.
+func WithHeapThreshold(n uint64) RuntimeOption {
+	return func(opts *runtimeOptions) {
+		if n > 0 {
+			opts.thresholds[ThresholdHeapBytes] = float64(n)
+		}
+	}
+}
+
+// WithOpenFDsThreshold logs a warning when the number of open file descriptors exceeds n, e.g., to detect
+// connection leaks before the limit of the process is reached.
+func WithOpenFDsThreshold(n int) RuntimeOption {
+	return func(opts *runtimeOptions) {
+		if n > 0 {
+			opts.thresholds[ThresholdOpenFDs] = float64(n)
+		}
+	}
+}
+
+/*
+RuntimeCollector samples the metrics of the Go runtime and of the process into a registry: the goroutines, the
+heap, the allocations, the garbage collections and, on Linux, the open file descriptors. Run samples them
+periodically, and logs a warning when a threshold is crossed, and an info entry when the metric is back below it.
+
+Example usage:
+
+	collector, err := metrics.NewRuntimeCollector(metrics.Default(),
+		metrics.WithGoroutineThreshold(10000),
+		metrics.WithRuntimeLogger(log),
+	)
+	if err != nil {
+		// Handle error
+	}
+	manager.Add("runtime-metrics", collector)
+
+exposes metrics such as:
+
+	go_goroutines 42
+	go_gc_pause_seconds_total 0.0125
+*/
+type RuntimeCollector struct {
+	opts            *runtimeOptions
+	goroutines      *Gauge
+	threads         *Gauge
+	heapAlloc       *Gauge
+	heapSys         *Gauge
+	heapObjects     *Gauge
+	allocated       *Counter
+	gcCycles        *Counter
+	gcPause         *Counter
+	gcLastPause     *Gauge
+	openFDs         *Gauge
+	mutex           sync.Mutex
+	last            runtime.MemStats
+	exceeded        map[string]bool
+	openFDsReadable bool
+}
+
+// NewRuntimeCollector creates a RuntimeCollector sampling the metrics into registry, and samples them once.
+func NewRuntimeCollector(registry *Registry, opts ...RuntimeOption) (*RuntimeCollector, error) {
+	o := &runtimeOptions{interval: DefaultRuntimeInterval, thresholds: make(map[string]float64)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	c := &RuntimeCollector{opts: o, exceeded: make(map[string]bool)}
+
+	var err error
+	gauges := []struct {
+		gauge **Gauge
+		name  string
+		help  string
+	}{
+		{&c.goroutines, "go_goroutines", "Number of goroutines that currently exist."},
+		{&c.threads, "go_threads", "Number of OS threads created."},
+		{&c.heapAlloc, "go_memstats_heap_alloc_bytes", "Number of heap bytes allocated and still in use."},
+		{&c.heapSys, "go_memstats_heap_sys_bytes", "Number of heap bytes obtained from system."},
+		{&c.heapObjects, "go_memstats_heap_objects", "Number of allocated objects."},
+		{&c.gcLastPause, "go_gc_last_pause_seconds", "Duration of the last garbage collection pause in seconds."},
+	}
+	for _, g := range gauges {
+		if *g.gauge, err = registry.NewGauge(g.name, g.help); err != nil {
+			return nil, err
+		}
+	}
+	counters := []struct {
+		counter **Counter
+		name    string
+		help    string
+	}{
+		{&c.allocated, "go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed."},
+		{&c.gcCycles, "go_gc_cycles_total", "Number of completed garbage collection cycles."},
+		{&c.gcPause, "go_gc_pause_seconds_total", "Total duration of the garbage collection pauses in seconds."},
+	}
+	for _, counter := range counters {
+		if *counter.counter, err = registry.NewCounter(counter.name, counter.help); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := countOpenFDs(); err == nil {
+		c.openFDsReadable = true
+		if c.openFDs, err = registry.NewGauge("process_open_fds", "Number of open file descriptors."); err != nil {
+			return nil, err
+		}
+	}
+
+	c.Collect(context.Background())
+	return c, nil
+}
+
+// Run samples the metrics at the interval of WithRuntimeInterval until ctx is done. It implements
+// lifecycle.Runnable.
+func (c *RuntimeCollector) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			c.Collect(ctx)
+		}
+	}
+}
+
+// Collect samples the metrics once, and logs the thresholds crossed with the logger of WithRuntimeLogger, or the
+// one of ctx.
+func (c *RuntimeCollector) Collect(ctx context.Context) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	goroutines := runtime.NumGoroutine()
+	threads, _ := runtime.ThreadCreateProfile(nil)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.goroutines.Set(float64(goroutines))
+	c.threads.Set(float64(threads))
+	c.heapAlloc.Set(float64(stats.HeapAlloc))
+	c.heapSys.Set(float64(stats.HeapSys))
+	c.heapObjects.Set(float64(stats.HeapObjects))
+	// The counters are incremented by the changes of the cumulative statistics since the last sample.
+	c.allocated.Add(float64(stats.TotalAlloc - c.last.TotalAlloc))
+	c.gcCycles.Add(float64(stats.NumGC - c.last.NumGC))
+	c.gcPause.Add(time.Duration(stats.PauseTotalNs - c.last.PauseTotalNs).Seconds())
+	if stats.NumGC > 0 {
+		c.gcLastPause.Set(time.Duration(stats.PauseNs[(stats.NumGC+255)%256]).Seconds())
+	}
+	c.last = stats
+
+	values := map[string]float64{
+		ThresholdGoroutines: float64(goroutines),
+		ThresholdHeapBytes:  float64(stats.HeapAlloc),
+	}
+	if c.openFDsReadable {
+		if fds, err := countOpenFDs(); err == nil {
+			c.openFDs.Set(float64(fds))
+			values[ThresholdOpenFDs] = float64(fds)
+		}
+	}
+	c.checkThresholds(ctx, values)
+}
+
+// checkThresholds logs the thresholds crossed since the last sample.
+func (c *RuntimeCollector) checkThresholds(ctx context.Context, values map[string]float64) {
+	for name, threshold := range c.opts.thresholds {
+		value, found := values[name]
+		if !found {
+			continue
+		}
+		exceeded := value > threshold
+		if exceeded == c.exceeded[name] {
+			continue
+		}
+		c.exceeded[name] = exceeded
+
+		log := c.opts.logger
+		if log == nil {
+			log = logger.FromContext(ctx)
+		}
+		fields := logger.Fields{"metric": name, "value": value, "threshold": threshold}
+		if exceeded {
+			log.Warn(ctx, "Runtime threshold exceeded", fields)
+		} else {
+			log.Info(ctx, "Runtime metric back below threshold", fields)
+		}
+	}
+}
+
+// countOpenFDs returns the number of open file descriptors of the process, read from /proc, so only on Linux.
+func countOpenFDs() (int, error) {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0, err
+	}
+	// The directory read is an open file descriptor too.
+	return len(entries) - 1, nil
+}
@@ -0,0 +1,102 @@
+package metrics_test
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+func TestRuntimeCollector(t *testing.T) {
+	registry := metrics.NewRegistry(metrics.WithNamespace("myapp"))
+	_, err := metrics.NewRuntimeCollector(registry)
+	require.NoError(t, err)
+
+	output := write(t, registry)
+	for _, name := range []string{
+		"myapp_go_goroutines",
+		"myapp_go_threads",
+		"myapp_go_memstats_heap_alloc_bytes",
+		"myapp_go_memstats_heap_sys_bytes",
+		"myapp_go_memstats_heap_objects",
+		"myapp_go_memstats_alloc_bytes_total",
+		"myapp_go_gc_cycles_total",
+		"myapp_go_gc_pause_seconds_total",
+		"myapp_go_gc_last_pause_seconds",
+	} {
+		assert.Contains(t, output, "\n"+name+" ")
+	}
+	assert.NotContains(t, output, "myapp_go_goroutines 0\n")
+	if runtime.GOOS == "linux" {
+		assert.Contains(t, output, "\nmyapp_process_open_fds ")
+	}
+
+	_, err = metrics.NewRuntimeCollector(metrics.NewRegistry(), metrics.WithRuntimeInterval(time.Second))
+	assert.NoError(t, err)
+}
+
+func TestRuntimeCollector_Conflict(t *testing.T) {
+	registry := metrics.NewRegistry()
+	metrics.Must(registry.NewHistogram("go_threads", "Threads.", nil))
+
+	_, err := metrics.NewRuntimeCollector(registry)
+	assert.ErrorIs(t, err, metrics.ErrConflict)
+}
+
+func TestRuntimeCollector_Thresholds(t *testing.T) {
+	log, recorder := logger.NewTestLogger()
+	ctx := context.Background()
+	collector, err := metrics.NewRuntimeCollector(metrics.NewRegistry(),
+		metrics.WithGoroutineThreshold(runtime.NumGoroutine()+5),
+		metrics.WithRuntimeLogger(log),
+	)
+	require.NoError(t, err)
+	assert.Zero(t, recorder.Len())
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-release
+		}()
+	}
+	collector.Collect(ctx)
+	collector.Collect(ctx)
+	require.Equal(t, 1, recorder.Len(), "the threshold is logged when it is crossed")
+	recorder.AssertLogged(t, logger.WARN, "Runtime threshold exceeded", func(fields logger.Fields) bool {
+		return fields["metric"] == metrics.ThresholdGoroutines
+	})
+
+	close(release)
+	wg.Wait()
+	collector.Collect(ctx)
+	require.Equal(t, 2, recorder.Len())
+	recorder.AssertLogged(t, logger.INFO, "Runtime metric back below threshold")
+}
+
+func TestRuntimeCollector_Run(t *testing.T) {
+	registry := metrics.NewRegistry()
+	collector, err := metrics.NewRuntimeCollector(registry, metrics.WithRuntimeInterval(time.Millisecond))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error)
+	go func() { done <- collector.Run(ctx) }()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return once the context was done")
+	}
+}
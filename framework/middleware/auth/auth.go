@@ -0,0 +1,215 @@
+package auth
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/auth/jwt"
+	"github.com/kittipat1413/go-common/framework/errors"
+)
+
+// DefaultClockSkew is the default tolerance of the time claims of the tokens, for the clocks of the identity
+// provider and the service not being exactly synchronized.
+const DefaultClockSkew = jwt.DefaultClockSkew
+
+// Codes of the errors returned by the Middleware and RequireScopes.
+const (
+	CodeMissingToken      = "missing_token"
+	CodeInvalidToken      = "invalid_token"
+	CodeInsufficientScope = "insufficient_scope"
+	CodeKeysUnavailable   = "keys_unavailable"
+)
+
+var (
+	// ErrMissingToken is returned for requests without bearer token, with the 401 status code.
+	ErrMissingToken = errors.NewCodedError(errors.KindUnauthenticated, CodeMissingToken, "missing bearer token")
+	// ErrInvalidToken is returned for requests with a malformed, forged, expired or unexpected token, with the 401
+	// status code. It wraps the reason of the rejection.
+	ErrInvalidToken = errors.NewCodedError(errors.KindUnauthenticated, CodeInvalidToken, "invalid bearer token")
+	// ErrInsufficientScope is returned for requests whose token does not grant the required scopes, with the 403
+	// status code.
+	ErrInsufficientScope = errors.NewCodedError(errors.KindPermissionDenied, CodeInsufficientScope, "insufficient scope")
+	// ErrKeysUnavailable is returned when the signing keys cannot be fetched, with the 503 status code, since the
+	// token may be valid.
+	ErrKeysUnavailable = errors.NewCodedError(errors.KindUnavailable, CodeKeysUnavailable, "signing keys are unavailable")
+)
+
+// ErrorHandler writes the response of the requests rejected with err.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// options holds configuration options for the Middleware and RequireScopes.
+type options struct {
+	verification []jwt.Option // verification are the options of the jwt.Verifier of the tokens.
+	extractor    func(r *http.Request) string
+	handler      ErrorHandler
+}
+
+// Option specifies Middleware and RequireScopes configuration options.
+type Option func(*options)
+
+// WithIssuer accepts only the tokens issued by one of issuers, the "iss" claim.
+func WithIssuer(issuers ...string) Option {
+	return func(opts *options) {
+		opts.verification = append(opts.verification, jwt.WithIssuer(issuers...))
+	}
+}
+
+// WithAudience accepts only the tokens intended for one of audiences, the "aud" claim, e.g., the identifier of
+// the API. Without it, tokens issued for other services are accepted.
+func WithAudience(audiences ...string) Option {
+	return func(opts *options) {
+		opts.verification = append(opts.verification, jwt.WithAudience(audiences...))
+	}
+}
+
+// WithClockSkew sets the tolerance of the "exp", "nbf" and "iat" claims. It defaults to DefaultClockSkew.
+func WithClockSkew(d time.Duration) Option {
+	return func(opts *options) {
+		opts.verification = append(opts.verification, jwt.WithClockSkew(d))
+	}
+}
+
+// WithAlgorithms restricts the signing algorithms accepted, e.g., "RS256". It defaults to all the Algorithms.
+func WithAlgorithms(algorithms ...string) Option {
+	return func(opts *options) {
+		opts.verification = append(opts.verification, jwt.WithAlgorithms(algorithms...))
+	}
+}
+
+// WithTokenExtractor sets the function extracting the token of the requests, e.g., from a cookie. It defaults to
+// the bearer token of the Authorization header.
+func WithTokenExtractor(extractor func(r *http.Request) string) Option {
+	return func(opts *options) {
+		if extractor != nil {
+			opts.extractor = extractor
+		}
+	}
+}
+
+// WithProblemWriter writes the responses of the rejected requests with problems. It defaults to an
+// errors.ProblemWriter without options.
+func WithProblemWriter(problems *errors.ProblemWriter) Option {
+	return func(opts *options) {
+		if problems != nil {
+			opts.handler = problems.Write
+		}
+	}
+}
+
+// WithErrorHandler sets the function writing the responses of the rejected requests, instead of a ProblemWriter.
+func WithErrorHandler(handler ErrorHandler) Option {
+	return func(opts *options) {
+		if handler != nil {
+			opts.handler = handler
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		extractor: BearerToken,
+		handler:   errors.NewProblemWriter().Write,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// BearerToken returns the bearer token of the Authorization header of r, or "" if there is none.
+func BearerToken(r *http.Request) string {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+/*
+Middleware authenticates the requests with the bearer JWTs of their Authorization header: it verifies the
+signature of the token with the keys of keys, usually a JWKS, its expiration and not-before times with the clock
+skew, and its issuer and audience with a jwt.Verifier, and stores its Claims in the request context, see
+ClaimsFromContext. Requests
+without valid token are rejected with ErrMissingToken or ErrInvalidToken, and a 401 status code, and the
+WWW-Authenticate header of RFC 6750. Tokens without expiration are rejected. When keys fail to return the keys,
+e.g., when the JWKS endpoint is down, the requests are rejected with ErrKeysUnavailable and a 503 status code.
+
+Example usage:
+
+	keys := auth.NewJWKS("https://auth.example.com/.well-known/jwks.json")
+	authenticate := auth.Middleware(keys,
+		auth.WithIssuer("https://auth.example.com/"),
+		auth.WithAudience("orders-api"),
+		auth.WithProblemWriter(problems),
+	)
+	mux.Handle("/orders", authenticate(auth.RequireScopes([]string{"orders:read"})(ordersHandler)))
+
+	func (h *orderHandler) List(w http.ResponseWriter, r *http.Request) {
+		userID, _ := auth.SubjectFromContext(r.Context())
+		...
+	}
+*/
+func Middleware(keys KeySource, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+	verifier := jwt.NewVerifier(keys, o.verification...)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := o.extractor(r)
+			if token == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				o.handler(w, r, ErrMissingToken)
+				return
+			}
+
+			claims, err := verify(r.Context(), verifier, token)
+			if stderrors.Is(err, jwt.ErrKeysUnavailable) {
+				o.handler(w, r, ErrKeysUnavailable.Wrap(err))
+				return
+			}
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				o.handler(w, r, ErrInvalidToken.Wrap(err))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), claims)))
+		})
+	}
+}
+
+/*
+RequireScopes rejects the requests whose claims, stored by the Middleware, do not grant all of scopes, with
+ErrInsufficientScope and a 403 status code. Requests without claims are rejected with ErrMissingToken and a 401
+status code. Only the WithProblemWriter and WithErrorHandler options apply.
+
+Example usage:
+
+	mux.Handle("/admin/", authenticate(auth.RequireScopes([]string{"admin"}, auth.WithProblemWriter(problems))(adminHandler)))
+*/
+func RequireScopes(scopes []string, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				o.handler(w, r, ErrMissingToken)
+				return
+			}
+			var missing []string
+			for _, scope := range scopes {
+				if !claims.HasScope(scope) {
+					missing = append(missing, scope)
+				}
+			}
+			if len(missing) > 0 {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, strings.Join(scopes, " ")))
+				o.handler(w, r, ErrInsufficientScope.WithField("required_scopes", missing))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,467 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/middleware/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	issuer   = "https://auth.example.com/"
+	audience = "orders-api"
+)
+
+var (
+	rsaKey, _          = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _           = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPublic, edKey, _ = ed25519.GenerateKey(rand.Reader)
+)
+
+// sign returns a token of claims signed with key for the algorithm alg.
+func sign(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	switch alg {
+	case "EdDSA":
+		signature, err = key.Sign(rand.Reader, []byte(signed), crypto.Hash(0))
+	case "ES256":
+		digest := sha256.Sum256([]byte(signed))
+		r, s, signErr := ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		require.NoError(t, signErr)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case "PS256":
+		digest := sha256.Sum256([]byte(signed))
+		signature, err = rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:], nil)
+	default:
+		digest := sha256.Sum256([]byte(signed))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	}
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims returns the claims of a valid token, modified by modify.
+func validClaims(modify func(claims map[string]interface{})) map[string]interface{} {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":   issuer,
+		"sub":   "user-1",
+		"aud":   audience,
+		"exp":   now.Add(time.Hour).Unix(),
+		"iat":   now.Unix(),
+		"scope": "orders:read orders:write",
+	}
+	if modify != nil {
+		modify(claims)
+	}
+	return claims
+}
+
+var staticKeys = auth.StaticKeys{
+	"rsa": &rsaKey.PublicKey,
+	"ec":  &ecKey.PublicKey,
+	"ed":  edPublic,
+}
+
+// subjectHandler responds with the subject of the claims of the request context.
+var subjectHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	subject, _ := auth.SubjectFromContext(r.Context())
+	_, _ = w.Write([]byte(subject))
+})
+
+func TestMiddleware(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		options        []auth.Option
+		token          func(t *testing.T) string
+		expectedStatus int
+		expectedCode   string
+		expectedDetail string
+	}{
+		{
+			name:           "valid RS256",
+			token:          func(t *testing.T) string { return sign(t, "RS256", "rsa", rsaKey, validClaims(nil)) },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "valid PS256",
+			token:          func(t *testing.T) string { return sign(t, "PS256", "rsa", rsaKey, validClaims(nil)) },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "valid ES256",
+			token:          func(t *testing.T) string { return sign(t, "ES256", "ec", ecKey, validClaims(nil)) },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "valid EdDSA",
+			token:          func(t *testing.T) string { return sign(t, "EdDSA", "ed", edKey, validClaims(nil)) },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "audience list",
+			token: func(t *testing.T) string {
+				return sign(t, "RS256", "rsa", rsaKey, validClaims(func(c map[string]interface{}) { c["aud"] = []string{"other", audience} }))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "expired within clock skew",
+			token: func(t *testing.T) string {
+				return sign(t, "RS256", "rsa", rsaKey, validClaims(func(c map[string]interface{}) { c["exp"] = time.Now().Add(-30 * time.Second).Unix() }))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing token",
+			token:          func(t *testing.T) string { return "" },
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeMissingToken,
+		},
+		{
+			name:           "malformed token",
+			token:          func(t *testing.T) string { return "not-a-token" },
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeInvalidToken,
+			expectedDetail: "malformed token",
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T) string {
+				return sign(t, "RS256", "rsa", rsaKey, validClaims(func(c map[string]interface{}) { c["exp"] = time.Now().Add(-2 * time.Minute).Unix() }))
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeInvalidToken,
+			expectedDetail: "token is expired",
+		},
+		{
+			name:    "expired without clock skew",
+			options: []auth.Option{auth.WithClockSkew(0)},
+			token: func(t *testing.T) string {
+				return sign(t, "RS256", "rsa", rsaKey, validClaims(func(c map[string]interface{}) { c["exp"] = time.Now().Add(-30 * time.Second).Unix() }))
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeInvalidToken,
+			expectedDetail: "token is expired",
+		},
+		{
+			name: "no expiration",
+			token: func(t *testing.T) string {
+				return sign(t, "RS256", "rsa", rsaKey, validClaims(func(c map[string]interface{}) { delete(c, "exp") }))
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeInvalidToken,
+			expectedDetail: "token has no expiration",
+		},
+		{
+			name: "not valid yet",
+			token: func(t *testing.T) string {
+				return sign(t, "RS256", "rsa", rsaKey, validClaims(func(c map[string]interface{}) { c["nbf"] = time.Now().Add(10 * time.Minute).Unix() }))
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeInvalidToken,
+			expectedDetail: "token is not valid yet",
+		},
+		{
+			name: "unexpected issuer",
+			token: func(t *testing.T) string {
+				return sign(t, "RS256", "rsa", rsaKey, validClaims(func(c map[string]interface{}) { c["iss"] = "https://evil.example.com/" }))
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeInvalidToken,
+			expectedDetail: `unexpected issuer "https://evil.example.com/"`,
+		},
+		{
+			name: "unexpected audience",
+			token: func(t *testing.T) string {
+				return sign(t, "RS256", "rsa", rsaKey, validClaims(func(c map[string]interface{}) { c["aud"] = "billing-api" }))
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeInvalidToken,
+			expectedDetail: "unexpected audience",
+		},
+		{
+			name:           "forged signature",
+			token:          func(t *testing.T) string { return sign(t, "RS256", "rsa", otherKey, validClaims(nil)) },
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeInvalidToken,
+			expectedDetail: "invalid signature",
+		},
+		{
+			name:           "unknown key",
+			token:          func(t *testing.T) string { return sign(t, "RS256", "unknown", rsaKey, validClaims(nil)) },
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeInvalidToken,
+			expectedDetail: `signing key not found "unknown"`,
+		},
+		{
+			name:           "key of another algorithm",
+			token:          func(t *testing.T) string { return sign(t, "RS256", "ec", rsaKey, validClaims(nil)) },
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeInvalidToken,
+			expectedDetail: "key does not match algorithm RS256",
+		},
+		{
+			name: "none algorithm",
+			token: func(t *testing.T) string {
+				token := sign(t, "RS256", "rsa", rsaKey, validClaims(nil))
+				parts := strings.Split(token, ".")
+				header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"rsa"}`))
+				return header + "." + parts[1] + "."
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeInvalidToken,
+			expectedDetail: `unsupported algorithm "none"`,
+		},
+		{
+			name:           "algorithm not allowed",
+			options:        []auth.Option{auth.WithAlgorithms("ES256")},
+			token:          func(t *testing.T) string { return sign(t, "RS256", "rsa", rsaKey, validClaims(nil)) },
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeInvalidToken,
+			expectedDetail: `unsupported algorithm "RS256"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]auth.Option{auth.WithIssuer(issuer), auth.WithAudience(audience)}, tt.options...)
+			handler := auth.Middleware(staticKeys, options...)(subjectHandler)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if token := tt.token(t); token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "user-1", rec.Body.String())
+				return
+			}
+			assert.Equal(t, domain_error.ProblemContentType, rec.Header().Get("Content-Type"))
+			assert.True(t, strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Bearer"))
+			var problem map[string]interface{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, tt.expectedCode, problem["code"])
+			assert.Contains(t, problem["detail"], tt.expectedDetail)
+		})
+	}
+}
+
+func TestMiddleware_ProductionMode(t *testing.T) {
+	problems := domain_error.NewProblemWriter(domain_error.WithProductionMode(true))
+	handler := auth.Middleware(staticKeys, auth.WithProblemWriter(problems))(subjectHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+sign(t, "RS256", "unknown", rsaKey, validClaims(nil)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, rec.Header().Get("WWW-Authenticate"))
+	var problem map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, "invalid bearer token", problem["detail"], "the reason of the rejection is not revealed")
+}
+
+func TestMiddleware_TokenExtractor(t *testing.T) {
+	handler := auth.Middleware(staticKeys, auth.WithTokenExtractor(func(r *http.Request) string {
+		cookie, err := r.Cookie("session")
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	}))(subjectHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: sign(t, "RS256", "rsa", rsaKey, validClaims(nil))})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "user-1", rec.Body.String())
+}
+
+func TestClaims(t *testing.T) {
+	var claims *auth.Claims
+	handler := auth.Middleware(staticKeys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ = auth.ClaimsFromContext(r.Context())
+	}))
+
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "bearer "+sign(t, "RS256", "rsa", rsaKey, map[string]interface{}{
+		"iss":       issuer,
+		"sub":       "user-1",
+		"aud":       []string{audience, "billing-api"},
+		"exp":       exp.Unix(),
+		"jti":       "token-1",
+		"scp":       []string{"orders:read", "orders:write"},
+		"tenant_id": "tenant-1",
+		"roles":     []string{"admin"},
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.NotNil(t, claims)
+	assert.Equal(t, issuer, claims.Issuer)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, []string{audience, "billing-api"}, claims.Audience)
+	assert.True(t, exp.Equal(claims.ExpiresAt))
+	assert.True(t, claims.IssuedAt.IsZero())
+	assert.Equal(t, "token-1", claims.ID)
+	assert.True(t, claims.HasScope("orders:write"))
+	assert.False(t, claims.HasScope("admin"))
+
+	tenantID, found := claims.Get("tenant_id")
+	assert.True(t, found)
+	assert.Equal(t, "tenant-1", tenantID)
+
+	var custom struct {
+		TenantID string   `json:"tenant_id"`
+		Roles    []string `json:"roles"`
+	}
+	require.NoError(t, claims.Decode(&custom))
+	assert.Equal(t, "tenant-1", custom.TenantID)
+	assert.Equal(t, []string{"admin"}, custom.Roles)
+}
+
+func TestClaimsFromContext(t *testing.T) {
+	_, ok := auth.ClaimsFromContext(context.Background())
+	assert.False(t, ok)
+	_, ok = auth.SubjectFromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := auth.NewContext(context.Background(), &auth.Claims{Subject: "user-1"})
+	claims, ok := auth.ClaimsFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "user-1", claims.Subject)
+	subject, ok := auth.SubjectFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "user-1", subject)
+}
+
+func TestRequireScopes(t *testing.T) {
+	tests := []struct {
+		name           string
+		claims         *auth.Claims
+		expectedStatus int
+		expectedCode   string
+		expectedHeader string
+	}{
+		{
+			name:           "granted",
+			claims:         &auth.Claims{Subject: "user-1", Scopes: []string{"orders:read", "orders:write"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "insufficient scope",
+			claims:         &auth.Claims{Subject: "user-1", Scopes: []string{"orders:read"}},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   auth.CodeInsufficientScope,
+			expectedHeader: `Bearer error="insufficient_scope", scope="orders:read orders:write"`,
+		},
+		{
+			name:           "unauthenticated",
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   auth.CodeMissingToken,
+			expectedHeader: "Bearer",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := auth.RequireScopes([]string{"orders:read", "orders:write"})(subjectHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+			if tt.claims != nil {
+				req = req.WithContext(auth.NewContext(req.Context(), tt.claims))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedHeader, rec.Header().Get("WWW-Authenticate"))
+			if tt.expectedCode != "" {
+				var problem map[string]interface{}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+				assert.Equal(t, tt.expectedCode, problem["code"])
+			}
+		})
+	}
+}
+
+func TestRequireScopes_ErrorHandler(t *testing.T) {
+	var handled error
+	handler := auth.RequireScopes([]string{"admin"}, auth.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		handled = err
+		w.WriteHeader(http.StatusNotFound)
+	}))(subjectHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req = req.WithContext(auth.NewContext(req.Context(), &auth.Claims{Scopes: []string{"orders:read"}}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.ErrorIs(t, handled, auth.ErrInsufficientScope)
+	var coded *domain_error.CodedError
+	require.ErrorAs(t, handled, &coded)
+	assert.Equal(t, []string{"admin"}, coded.Fields()["required_scopes"])
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{header: "Bearer abc", expected: "abc"},
+		{header: "bearer  abc ", expected: "abc"},
+		{header: "Basic abc", expected: ""},
+		{header: "Bearer", expected: ""},
+		{header: "", expected: ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", tt.header)
+		assert.Equal(t, tt.expected, auth.BearerToken(req), tt.header)
+	}
+}
+
+// rsaJWK returns the JSON Web Key of key.
+func rsaJWK(kid string, key *rsa.PublicKey) auth.JSONWebKey {
+	return auth.JSONWebKey{
+		KeyType:   "RSA",
+		KeyID:     kid,
+		Use:       "sig",
+		Algorithm: "RS256",
+		N:         base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+/*
+Claims are the claims of a verified token. The registered claims are typed fields, and the other claims are
+available with Get, or Decode for the claims of a specific identity provider.
+
+Example usage:
+
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		...
+	}
+
+	var custom struct {
+		TenantID string   `json:"tenant_id"`
+		Roles    []string `json:"roles"`
+	}
+	if err := claims.Decode(&custom); err != nil {
+		...
+	}
+*/
+type Claims struct {
+	Issuer    string
+	Subject   string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	ID        string
+	// Scopes are the scopes of the "scope" claim, space-separated, or of the "scp" claim, a list or a
+	// space-separated string.
+	Scopes []string
+
+	raw map[string]interface{}
+	// payload is the JSON payload of the token, decoded by Decode.
+	payload []byte
+}
+
+// registeredClaims is the JSON form of the registered claims.
+type registeredClaims struct {
+	Issuer    string      `json:"iss"`
+	Subject   string      `json:"sub"`
+	Audience  stringList  `json:"aud"`
+	ExpiresAt json.Number `json:"exp"`
+	NotBefore json.Number `json:"nbf"`
+	IssuedAt  json.Number `json:"iat"`
+	ID        string      `json:"jti"`
+	Scope     string      `json:"scope"`
+	Scp       stringList  `json:"scp"`
+}
+
+// stringList is a claim holding a string or a list of strings, e.g., "aud".
+type stringList []string
+
+// UnmarshalJSON accepts a string or a list of strings.
+func (l *stringList) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		if s != "" {
+			*l = stringList{s}
+		}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// parseClaims parses the JSON payload of a token.
+func parseClaims(payload []byte) (*Claims, error) {
+	var registered registeredClaims
+	if err := json.Unmarshal(payload, &registered); err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var raw map[string]interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{
+		Issuer:   registered.Issuer,
+		Subject:  registered.Subject,
+		Audience: registered.Audience,
+		ID:       registered.ID,
+		Scopes:   strings.Fields(registered.Scope),
+		raw:      raw,
+		payload:  payload,
+	}
+	if len(claims.Scopes) == 0 {
+		for _, scp := range registered.Scp {
+			claims.Scopes = append(claims.Scopes, strings.Fields(scp)...)
+		}
+	}
+	var err error
+	if claims.ExpiresAt, err = numericDate(registered.ExpiresAt); err != nil {
+		return nil, err
+	}
+	if claims.NotBefore, err = numericDate(registered.NotBefore); err != nil {
+		return nil, err
+	}
+	if claims.IssuedAt, err = numericDate(registered.IssuedAt); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// HasScope reports whether the claims grant scope.
+func (c *Claims) HasScope(scope string) bool {
+	return contains(c.Scopes, scope)
+}
+
+// Get returns the claim name, as decoded from JSON. Numbers are json.Number values.
+func (c *Claims) Get(name string) (interface{}, bool) {
+	value, found := c.raw[name]
+	return value, found
+}
+
+// Decode decodes the claims into dst, e.g., a struct with json tags.
+func (c *Claims) Decode(dst interface{}) error {
+	return json.Unmarshal(c.payload, dst)
+}
+
+// contextKey is an unexported type for context keys defined in this package.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying claims.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims carried by ctx, stored by the Middleware, if any.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
+
+// SubjectFromContext returns the subject of the claims carried by ctx, e.g., the user ID, if any.
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok || claims.Subject == "" {
+		return "", false
+	}
+	return claims.Subject, true
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/auth/jwt"
+	"github.com/kittipat1413/go-common/framework/cache"
+)
+
+// The key sources of the Middleware are the ones of the jwt package, so that the HTTP and gRPC authentication share
+// them.
+
+const (
+	// DefaultJWKSExpiration is the duration the key sets are cached for, unless the JWKS endpoint responds
+	// with a Cache-Control max-age, or another cache is set with WithCache.
+	DefaultJWKSExpiration = jwt.DefaultJWKSExpiration
+	// DefaultJWKSRefreshInterval is the minimum interval between two fetches of the key set triggered by
+	// tokens signed with unknown keys.
+	DefaultJWKSRefreshInterval = jwt.DefaultJWKSRefreshInterval
+)
+
+// ErrKeyNotFound is returned by KeySources when no key has the ID of a token.
+var ErrKeyNotFound = jwt.ErrKeyNotFound
+
+type (
+	// KeySource returns the public keys verifying the signatures of the tokens, see jwt.KeySource.
+	KeySource = jwt.KeySource
+	// StaticKeys is a KeySource of fixed keys, by key ID, see jwt.StaticKeys.
+	StaticKeys = jwt.StaticKeys
+	// JSONWebKey is a public key of a KeySet, see jwt.JSONWebKey.
+	JSONWebKey = jwt.JSONWebKey
+	// KeySet is a JSON Web Key Set, see jwt.KeySet.
+	KeySet = jwt.KeySet
+	// JWKS is a KeySource fetching the keys from the JWKS endpoint of an identity provider, see jwt.JWKS.
+	JWKS = jwt.JWKS
+	// JWKSOption specifies JWKS configuration options.
+	JWKSOption = jwt.JWKSOption
+)
+
+// WithHTTPClient sets the client fetching the key sets, see jwt.WithHTTPClient.
+func WithHTTPClient(client *http.Client) JWKSOption {
+	return jwt.WithHTTPClient(client)
+}
+
+// WithCache sets the cache of the key sets, see jwt.WithCache.
+func WithCache(c cache.Cache[KeySet]) JWKSOption {
+	return jwt.WithCache(c)
+}
+
+// WithRefreshInterval sets the minimum interval between two fetches of the key set triggered by tokens signed
+// with unknown keys, see jwt.WithRefreshInterval.
+func WithRefreshInterval(d time.Duration) JWKSOption {
+	return jwt.WithRefreshInterval(d)
+}
+
+/*
+NewJWKS creates a JWKS fetching the key set from url, see jwt.NewJWKS.
+
+Example usage:
+
+	keys := auth.NewJWKS("https://auth.example.com/.well-known/jwks.json")
+	handler := auth.Middleware(keys,
+		auth.WithIssuer("https://auth.example.com/"),
+		auth.WithAudience("orders-api"),
+	)(mux)
+*/
+func NewJWKS(url string, opts ...JWKSOption) *JWKS {
+	return jwt.NewJWKS(url, opts...)
+}
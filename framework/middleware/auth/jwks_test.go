@@ -0,0 +1,149 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+	"github.com/kittipat1413/go-common/framework/middleware/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksServer serves a key set that can be replaced, and counts the fetches.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	set     auth.KeySet
+	status  int
+	fetches atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, keys ...auth.JSONWebKey) *jwksServer {
+	s := &jwksServer{set: auth.KeySet{Keys: keys}, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.WriteHeader(s.status)
+		_ = json.NewEncoder(w).Encode(s.set)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) update(status int, keys ...auth.JSONWebKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+	s.set = auth.KeySet{Keys: keys}
+}
+
+func TestJWKS(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK("rsa", &rsaKey.PublicKey))
+	keys := auth.NewJWKS(server.URL)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		key, err := keys.Key(ctx, "rsa")
+		require.NoError(t, err)
+		assert.True(t, rsaKey.PublicKey.Equal(key))
+	}
+	assert.Equal(t, int32(1), server.fetches.Load(), "the key set is cached")
+
+	// A token without key ID matches the only key.
+	key, err := keys.Key(ctx, "")
+	require.NoError(t, err)
+	assert.True(t, rsaKey.PublicKey.Equal(key))
+
+	// Unknown keys trigger a single refresh per interval.
+	_, err = keys.Key(ctx, "unknown")
+	assert.ErrorIs(t, err, auth.ErrKeyNotFound)
+	_, err = keys.Key(ctx, "unknown")
+	assert.ErrorIs(t, err, auth.ErrKeyNotFound)
+	assert.Equal(t, int32(2), server.fetches.Load())
+}
+
+func TestJWKS_Rotation(t *testing.T) {
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	server := newJWKSServer(t, rsaJWK("old", &rsaKey.PublicKey))
+	keys := auth.NewJWKS(server.URL, auth.WithRefreshInterval(0))
+	handler := auth.Middleware(keys)(subjectHandler)
+
+	serve := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(sign(t, "RS256", "old", rsaKey, validClaims(nil))))
+
+	server.update(http.StatusOK, rsaJWK("old", &rsaKey.PublicKey), rsaJWK("new", &newKey.PublicKey))
+	assert.Equal(t, http.StatusOK, serve(sign(t, "RS256", "new", newKey, validClaims(nil))))
+	assert.Equal(t, int32(2), server.fetches.Load())
+}
+
+func TestJWKS_Unavailable(t *testing.T) {
+	server := newJWKSServer(t)
+	server.update(http.StatusInternalServerError)
+	keys := auth.NewJWKS(server.URL, auth.WithCache(localcache.New[auth.KeySet](localcache.WithLazyExpiration())))
+	handler := auth.Middleware(keys)(subjectHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+sign(t, "RS256", "rsa", rsaKey, validClaims(nil)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Empty(t, rec.Header().Get("WWW-Authenticate"))
+	var problem map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, auth.CodeKeysUnavailable, problem["code"])
+	assert.Contains(t, problem["detail"], "unexpected status 500")
+}
+
+func TestJSONWebKey_PublicKey(t *testing.T) {
+	encode := base64.RawURLEncoding.EncodeToString
+	ecJWK := auth.JSONWebKey{KeyType: "EC", Curve: "P-256", X: encode(ecKey.X.Bytes()), Y: encode(ecKey.Y.Bytes())}
+	edJWK := auth.JSONWebKey{KeyType: "OKP", Curve: "Ed25519", X: encode(edPublic)}
+
+	key, err := rsaJWK("rsa", &rsaKey.PublicKey).PublicKey()
+	require.NoError(t, err)
+	assert.True(t, rsaKey.PublicKey.Equal(key))
+
+	key, err = ecJWK.PublicKey()
+	require.NoError(t, err)
+	assert.True(t, ecKey.PublicKey.Equal(key.(*ecdsa.PublicKey)))
+
+	key, err = edJWK.PublicKey()
+	require.NoError(t, err)
+	assert.Equal(t, edPublic, key.(ed25519.PublicKey))
+
+	invalid := []auth.JSONWebKey{
+		{KeyType: "oct"},
+		{KeyType: "RSA", N: "", E: "AQAB"},
+		{KeyType: "RSA", N: encode(rsaKey.N.Bytes()), E: ""},
+		{KeyType: "EC", Curve: "P-256", X: ecJWK.X, Y: ecJWK.X},
+		{KeyType: "EC", Curve: "secp256k1", X: ecJWK.X, Y: ecJWK.Y},
+		{KeyType: "OKP", Curve: "Ed25519", X: encode([]byte("short"))},
+		{KeyType: "OKP", Curve: "X25519", X: edJWK.X},
+	}
+	for _, jwk := range invalid {
+		_, err := jwk.PublicKey()
+		assert.Error(t, err, "%+v", jwk)
+	}
+}
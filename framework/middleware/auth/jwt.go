@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/auth/jwt"
+)
+
+// Algorithms lists the supported signing algorithms, see RFC 7518. Symmetric algorithms are not supported, since
+// the verifiers would be able to sign tokens too.
+var Algorithms = jwt.Algorithms
+
+// tokenClaims are the claims of a token verified by a jwt.Verifier, keeping the JSON payload to parse the Claims.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	payload []byte
+}
+
+// UnmarshalJSON decodes the registered claims, and keeps the payload.
+func (c *tokenClaims) UnmarshalJSON(b []byte) error {
+	c.payload = append([]byte(nil), b...)
+	return json.Unmarshal(b, &c.RegisteredClaims)
+}
+
+// verify verifies token with verifier, and returns its claims.
+func verify(ctx context.Context, verifier *jwt.Verifier, token string) (*Claims, error) {
+	verified, err := jwt.Verify[tokenClaims](ctx, verifier, token)
+	if err != nil {
+		return nil, err
+	}
+	return parseClaims(verified.payload)
+}
+
+// contains reports whether values contains value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// numericDate converts a NumericDate, in seconds since the epoch, to a time.
+func numericDate(n json.Number) (time.Time, error) {
+	if n == "" {
+		return time.Time{}, nil
+	}
+	seconds, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec, frac := math.Modf(seconds)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+}
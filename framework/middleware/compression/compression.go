@@ -0,0 +1,223 @@
+package compression
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMinSize is the default minimum size of the responses compressed, in bytes. Smaller responses are not
+// worth the overhead of the compression.
+const DefaultMinSize = 1024
+
+// DefaultContentTypes are the content types compressed by default. A "*" matches any sequence of characters.
+var DefaultContentTypes = []string{
+	"text/*",
+	"application/json",
+	"application/*+json",
+	"application/javascript",
+	"application/xml",
+	"application/*+xml",
+	"application/x-www-form-urlencoded",
+	"image/svg+xml",
+}
+
+// Encoder creates the writers compressing the responses with a content coding, writing the compressed data to w.
+// The writers are pooled if they implement Reset(io.Writer), and flushed when the handlers flush the responses if
+// they implement Flush() error.
+type Encoder func(w io.Writer) io.WriteCloser
+
+// options holds configuration options for the Middleware.
+type options struct {
+	level        int
+	minSize      int
+	contentTypes []string
+	// encodings are the names of the encoders, by order of preference.
+	encodings []string
+	encoders  map[string]Encoder
+}
+
+// Option specifies Middleware configuration options.
+type Option func(*options)
+
+// WithLevel sets the level of the gzip and deflate compressions, from flate.HuffmanOnly to flate.BestCompression.
+// It defaults to flate.DefaultCompression.
+func WithLevel(level int) Option {
+	return func(opts *options) {
+		if level >= flate.HuffmanOnly && level <= flate.BestCompression {
+			opts.level = level
+		}
+	}
+}
+
+// WithMinSize sets the minimum size of the responses compressed, in bytes. It defaults to DefaultMinSize.
+// Responses flushed before reaching it are compressed, since their size is unknown.
+func WithMinSize(size int) Option {
+	return func(opts *options) {
+		if size >= 0 {
+			opts.minSize = size
+		}
+	}
+}
+
+// WithContentTypes sets the content types compressed, e.g., "application/vnd.api+json" or "text/*". It defaults
+// to DefaultContentTypes. Already compressed formats, e.g., images or archives, should not be added.
+func WithContentTypes(contentTypes ...string) Option {
+	return func(opts *options) {
+		opts.contentTypes = contentTypes
+	}
+}
+
+/*
+WithEncoder adds an encoder for the content coding name, preferred to gzip and deflate and to the encoders added
+before, when the clients accept them equally. The level of WithLevel does not apply to it.
+
+Example usage:
+
+	import "github.com/andybalholm/brotli"
+
+	compression.WithEncoder("br", func(w io.Writer) io.WriteCloser {
+		return brotli.NewWriterLevel(w, brotli.DefaultCompression)
+	})
+*/
+func WithEncoder(name string, encoder Encoder) Option {
+	return func(opts *options) {
+		name = strings.ToLower(name)
+		encodings := []string{name}
+		for _, encoding := range opts.encodings {
+			if encoding != name {
+				encodings = append(encodings, encoding)
+			}
+		}
+		opts.encodings = encodings
+		opts.encoders[name] = encoder
+	}
+}
+
+/*
+Middleware compresses the responses with gzip or deflate, or the encoders added with WithEncoder, according to the
+Accept-Encoding header of the requests. Only the responses of the allowed content types, at least as large as the
+minimum size, and not already encoded are compressed. Streaming handlers work as usual: when they flush the
+response, the data compressed so far is sent to the client.
+
+The Content-Length header of the compressed responses is removed, their strong ETag is made weak, and the
+responses that may be compressed get a "Vary: Accept-Encoding" header, so that caches store a variant per
+encoding. Partial responses (206) are not compressed.
+
+Example usage:
+
+	handler := compression.Middleware(
+		compression.WithLevel(gzip.BestSpeed),
+		compression.WithMinSize(512),
+	)(mux)
+*/
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	o := &options{
+		level:        flate.DefaultCompression,
+		minSize:      DefaultMinSize,
+		contentTypes: DefaultContentTypes,
+		encodings:    []string{"gzip", "deflate"},
+		encoders:     make(map[string]Encoder),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if _, found := o.encoders["gzip"]; !found {
+		level := o.level
+		o.encoders["gzip"] = func(w io.Writer) io.WriteCloser {
+			gw, _ := gzip.NewWriterLevel(w, level)
+			return gw
+		}
+	}
+	if _, found := o.encoders["deflate"]; !found {
+		level := o.level
+		o.encoders["deflate"] = func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, level)
+			return fw
+		}
+	}
+	pools := make(map[string]*sync.Pool, len(o.encoders))
+	for name := range o.encoders {
+		pools[name] = &sync.Pool{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := o.negotiate(r.Header.Get("Accept-Encoding"))
+			cw := &compressWriter{ResponseWriter: w, opts: o, encoding: encoding, status: http.StatusOK}
+			if encoding != "" {
+				cw.pool = pools[encoding]
+			}
+			next.ServeHTTP(cw, r)
+			// On panics, the buffered response is dropped, so that the recovery middleware can respond.
+			cw.close()
+		})
+	}
+}
+
+// negotiate returns the encoding of the responses to a request with the Accept-Encoding header acceptEncoding,
+// or "" if the response must not be compressed.
+func (o *options) negotiate(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	accepted := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if name == "*" {
+			wildcard = q
+		} else {
+			accepted[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, name := range o.encodings {
+		q, found := accepted[name]
+		if !found {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressible reports whether the responses of contentType may be compressed.
+func (o *options) compressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "" {
+		return false
+	}
+	for _, pattern := range o.contentTypes {
+		if match(strings.ToLower(pattern), mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether s matches pattern, where a single "*" matches any sequence of characters.
+func match(pattern, s string) bool {
+	prefix, suffix, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return pattern == s
+	}
+	return len(s) >= len(prefix)+len(suffix) && strings.HasPrefix(s, prefix) && strings.HasSuffix(s, suffix)
+}
@@ -0,0 +1,280 @@
+package compression_test
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/middleware/compression"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var largeJSON = `{"items":[` + strings.Repeat(`{"name":"item","price":100},`, 100) + `{}]}`
+
+// staticHandler responds with body, with the headers set by setHeaders.
+func staticHandler(status int, body string, setHeaders func(h http.Header)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if setHeaders != nil {
+			setHeaders(w.Header())
+		}
+		w.WriteHeader(status)
+		// Write in small chunks, like streaming encoders do.
+		for i := 0; i < len(body); i += 100 {
+			_, _ = w.Write([]byte(body[i:min(i+100, len(body))]))
+		}
+	})
+}
+
+// decode returns the body of rec, decoded according to its Content-Encoding.
+func decode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var reader io.Reader = rec.Body
+	switch rec.Header().Get("Content-Encoding") {
+	case "gzip":
+		gr, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		reader = gr
+	case "deflate":
+		reader = flate.NewReader(rec.Body)
+	}
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func jsonHeaders(h http.Header) {
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	h.Set("Content-Length", "100000")
+	h.Set("ETag", `"v1"`)
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name             string
+		acceptEncoding   string
+		handler          http.Handler
+		expectedEncoding string
+		expectedVary     string
+		expectedBody     string
+	}{
+		{
+			name:             "gzip",
+			acceptEncoding:   "gzip, deflate, br",
+			handler:          staticHandler(http.StatusOK, largeJSON, jsonHeaders),
+			expectedEncoding: "gzip",
+			expectedVary:     "Accept-Encoding",
+			expectedBody:     largeJSON,
+		},
+		{
+			name:             "deflate preferred by client",
+			acceptEncoding:   "gzip;q=0.5, deflate",
+			handler:          staticHandler(http.StatusOK, largeJSON, jsonHeaders),
+			expectedEncoding: "deflate",
+			expectedVary:     "Accept-Encoding",
+			expectedBody:     largeJSON,
+		},
+		{
+			name:             "wildcard",
+			acceptEncoding:   "*",
+			handler:          staticHandler(http.StatusOK, largeJSON, jsonHeaders),
+			expectedEncoding: "gzip",
+			expectedVary:     "Accept-Encoding",
+			expectedBody:     largeJSON,
+		},
+		{
+			name:           "refused encodings",
+			acceptEncoding: "gzip;q=0, deflate;q=0, *;q=0",
+			handler:        staticHandler(http.StatusOK, largeJSON, jsonHeaders),
+			expectedVary:   "Accept-Encoding",
+			expectedBody:   largeJSON,
+		},
+		{
+			name:         "no accept encoding",
+			handler:      staticHandler(http.StatusOK, largeJSON, jsonHeaders),
+			expectedVary: "Accept-Encoding",
+			expectedBody: largeJSON,
+		},
+		{
+			name:           "small response",
+			acceptEncoding: "gzip",
+			handler:        staticHandler(http.StatusOK, `{"id":1}`, jsonHeaders),
+			expectedVary:   "Accept-Encoding",
+			expectedBody:   `{"id":1}`,
+		},
+		{
+			name:           "content type not allowed",
+			acceptEncoding: "gzip",
+			handler: staticHandler(http.StatusOK, strings.Repeat("x", 2048), func(h http.Header) {
+				h.Set("Content-Type", "image/png")
+			}),
+			expectedBody: strings.Repeat("x", 2048),
+		},
+		{
+			name:           "already encoded",
+			acceptEncoding: "gzip",
+			handler: staticHandler(http.StatusOK, largeJSON, func(h http.Header) {
+				h.Set("Content-Type", "application/json")
+				h.Set("Content-Encoding", "identity")
+			}),
+			expectedEncoding: "identity",
+			expectedBody:     largeJSON,
+		},
+		{
+			name:           "partial content",
+			acceptEncoding: "gzip",
+			handler: staticHandler(http.StatusPartialContent, largeJSON, func(h http.Header) {
+				h.Set("Content-Type", "application/json")
+			}),
+			expectedBody: largeJSON,
+		},
+		{
+			name:           "no content",
+			acceptEncoding: "gzip",
+			handler: staticHandler(http.StatusNoContent, "", func(h http.Header) {
+				h.Set("Content-Type", "application/json")
+			}),
+		},
+		{
+			name:             "sniffed content type",
+			acceptEncoding:   "gzip",
+			handler:          staticHandler(http.StatusOK, "<html>"+strings.Repeat("<p>hello</p>", 200)+"</html>", nil),
+			expectedEncoding: "gzip",
+			expectedVary:     "Accept-Encoding",
+			expectedBody:     "<html>" + strings.Repeat("<p>hello</p>", 200) + "</html>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := compression.Middleware()(tt.handler)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedEncoding, rec.Header().Get("Content-Encoding"))
+			assert.Equal(t, tt.expectedVary, rec.Header().Get("Vary"))
+			assert.Equal(t, tt.expectedBody, decode(t, rec))
+			if tt.expectedEncoding == "gzip" || tt.expectedEncoding == "deflate" {
+				assert.Empty(t, rec.Header().Get("Content-Length"))
+				assert.NotEmpty(t, rec.Header().Get("Content-Type"))
+				assert.Less(t, rec.Body.Len(), len(tt.expectedBody))
+			}
+		})
+	}
+}
+
+func TestMiddleware_Headers(t *testing.T) {
+	handler := compression.Middleware()(staticHandler(http.StatusOK, largeJSON, func(h http.Header) {
+		jsonHeaders(h)
+		h.Set("Vary", "Origin, accept-encoding")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, []string{"Origin, accept-encoding"}, rec.Header().Values("Vary"))
+	assert.Equal(t, `W/"v1"`, rec.Header().Get("ETag"))
+	assert.Empty(t, rec.Header().Get("Content-Length"))
+}
+
+func TestMiddleware_Options(t *testing.T) {
+	// prefixEncoder is a fake encoding prefixing the response.
+	prefixEncoder := func(w io.Writer) io.WriteCloser {
+		_, _ = w.Write([]byte("prefixed:"))
+		return nopCloser{w}
+	}
+	handler := compression.Middleware(
+		compression.WithLevel(flate.BestSpeed),
+		compression.WithMinSize(10),
+		compression.WithContentTypes("application/vnd.api+json", "text/*"),
+		compression.WithEncoder("x-prefix", prefixEncoder),
+	)(staticHandler(http.StatusOK, `{"data":{"id":"1"}}`, func(h http.Header) {
+		h.Set("Content-Type", "application/vnd.api+json")
+	}))
+
+	tests := []struct {
+		acceptEncoding   string
+		expectedEncoding string
+		expectedBody     string
+	}{
+		{acceptEncoding: "gzip, x-prefix", expectedEncoding: "x-prefix", expectedBody: `prefixed:{"data":{"id":"1"}}`},
+		{acceptEncoding: "gzip, x-prefix;q=0.1", expectedEncoding: "gzip", expectedBody: `{"data":{"id":"1"}}`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, tt.expectedEncoding, rec.Header().Get("Content-Encoding"), tt.acceptEncoding)
+		assert.Equal(t, tt.expectedBody, decode(t, rec), tt.acceptEncoding)
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestMiddleware_Streaming(t *testing.T) {
+	next := make(chan struct{})
+	server := httptest.NewServer(compression.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 3; i++ {
+			_, _ = w.Write([]byte("data: event\n\n"))
+			assert.NoError(t, http.NewResponseController(w).Flush())
+			<-next
+		}
+	})))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	// The transport does not decompress the responses of requests with an explicit Accept-Encoding.
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+
+	gr, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	reader := bufio.NewReader(gr)
+	for i := 0; i < 3; i++ {
+		// Each event is received before the handler writes the next one.
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "data: event\n", line)
+		_, err = reader.ReadString('\n')
+		require.NoError(t, err)
+		next <- struct{}{}
+	}
+	_, err = reader.ReadByte()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestMiddleware_NothingWritten(t *testing.T) {
+	handler := compression.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Zero(t, rec.Body.Len())
+}
@@ -0,0 +1,175 @@
+package compression
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// resetter is implemented by the encoders that can be reused, e.g., *gzip.Writer.
+type resetter interface {
+	Reset(w io.Writer)
+}
+
+// flusher is implemented by the encoders that can flush the data compressed so far, e.g., *gzip.Writer.
+type flusher interface {
+	Flush() error
+}
+
+/*
+compressWriter buffers the beginning of a response until it reaches the minimum size, is flushed or ends, and then
+decides whether to compress it, from its status, headers and size.
+*/
+type compressWriter struct {
+	http.ResponseWriter
+	opts *options
+	// encoding is the negotiated content coding, or "" if the client does not accept any.
+	encoding string
+	pool     *sync.Pool
+
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         []byte
+	encoder     io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.decided || w.wroteHeader {
+		// Superfluous calls are reported by the underlying http.ResponseWriter.
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if status < http.StatusOK {
+		// Informational responses, e.g., 103 Early Hints, are followed by the final one.
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	w.wroteHeader = true
+	if !bodyAllowed(status) {
+		_ = w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.opts.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush compresses and sends the data written so far, if the underlying http.ResponseWriter supports flushing.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		// The size of streamed responses is unknown.
+		_ = w.decide(true)
+	}
+	if f, ok := w.encoder.(flusher); ok {
+		_ = f.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the header of the response, compressed if it is eligible and large enough, and the buffered data.
+func (w *compressWriter) decide(largeEnough bool) error {
+	w.decided = true
+	header := w.Header()
+	if _, found := header["Content-Type"]; !found && len(w.buf) > 0 && header.Get("Content-Encoding") == "" {
+		// The compressed data cannot be sniffed by the http.ResponseWriter.
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	compressible := bodyAllowed(w.status) &&
+		w.status != http.StatusPartialContent &&
+		header.Get("Content-Encoding") == "" &&
+		w.opts.compressible(header.Get("Content-Type"))
+	if compressible {
+		addVary(header, "Accept-Encoding")
+	}
+	if compressible && largeEnough && w.encoding != "" {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			// The compressed representation is not byte-for-byte identical.
+			header.Set("ETag", "W/"+etag)
+		}
+		w.encoder = w.newEncoder()
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close ends the response once the handler returns.
+func (w *compressWriter) close() {
+	if !w.decided {
+		if !w.wroteHeader {
+			// Nothing was written: the http.ResponseWriter sends an empty 200 response.
+			return
+		}
+		_ = w.decide(len(w.buf) > 0 && len(w.buf) >= w.opts.minSize)
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+		if _, ok := w.encoder.(resetter); ok {
+			w.pool.Put(w.encoder)
+		}
+		w.encoder = nil
+	}
+}
+
+// newEncoder returns an encoder of the negotiated encoding writing to the response, from the pool if possible.
+func (w *compressWriter) newEncoder() io.WriteCloser {
+	if pooled, ok := w.pool.Get().(io.WriteCloser); ok {
+		pooled.(resetter).Reset(w.ResponseWriter)
+		return pooled
+	}
+	return w.opts.encoders[w.encoding](w.ResponseWriter)
+}
+
+// bodyAllowed reports whether the responses with status have a body.
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// addVary adds name to the Vary header, unless it is already there.
+func addVary(header http.Header, name string) {
+	for _, value := range header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "*" || strings.EqualFold(field, name) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", name)
+}
@@ -4,23 +4,19 @@ import (
 	"context"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kittipat1413/go-common/framework/middleware/requestid"
 	"github.com/rs/xid"
 )
 
-// requestIDKey is an unexported type for context keys defined in this package.
-type requestIDKey struct{}
-
-// requestIDContextKey is the key for request ID values in context.
-var requestIDContextKey = &requestIDKey{}
-
 // GetRequestIDFromContext retrieves the request ID from the context.
+// The request ID is stored like the requestid package does, so that requestid.FromContext and the
+// requestid.Extractor of the loggers find it too.
 func GetRequestIDFromContext(ctx context.Context) (string, bool) {
-	requestID, ok := ctx.Value(requestIDContextKey).(string)
-	return requestID, ok
+	return requestid.FromContext(ctx)
 }
 
 // DefaultRequestIDHeader is the default header name where the request ID is stored.
-const DefaultRequestIDHeader = "X-Request-ID"
+const DefaultRequestIDHeader = requestid.DefaultHeader
 
 // requestIDOptions holds configuration options for the RequestID middleware.
 type requestIDOptions struct {
@@ -110,7 +106,7 @@ func RequestID(opts ...RequestIDOption) gin.HandlerFunc {
 		c.Writer.Header().Set(options.headerName, requestID)
 
 		// Store the request ID in the context for downstream handlers.
-		ctx := context.WithValue(c.Request.Context(), requestIDContextKey, requestID)
+		ctx := requestid.NewContext(c.Request.Context(), requestID)
 		c.Request = c.Request.WithContext(ctx)
 
 		// Continue processing the request.
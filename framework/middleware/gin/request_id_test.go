@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	middleware "github.com/kittipat1413/go-common/framework/middleware/gin"
+	"github.com/kittipat1413/go-common/framework/middleware/requestid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -135,3 +136,22 @@ func TestRequestID_LimitExistingIDLength(t *testing.T) {
 	assert.NotEqual(t, existingID, requestID)
 	assert.Equal(t, requestID, w.Header().Get(middleware.DefaultRequestIDHeader))
 }
+
+func TestRequestID_SharedWithRequestIDPackage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID())
+
+	router.GET("/test", func(c *gin.Context) {
+		requestID, _ := requestid.FromContext(c.Request.Context())
+		c.String(http.StatusOK, requestID)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set(middleware.DefaultRequestIDHeader, "existing-request-id-123")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "existing-request-id-123", w.Body.String())
+}
@@ -0,0 +1,223 @@
+package httplog
+
+import (
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// DefaultMaxBodyBytes is the maximum number of bytes of the bodies captured, unless set with WithBodyCapture.
+const DefaultMaxBodyBytes = 4096
+
+// options holds configuration options for the Middleware.
+type options struct {
+	logger        logger.Logger
+	skipPaths     map[string]bool
+	filters       []func(r *http.Request) bool
+	slowThreshold time.Duration
+	headers       []string
+	sampleRate    float64
+	maxBodyBytes  int
+	redactor      *redactor
+}
+
+// Option specifies Middleware configuration options.
+type Option func(*options)
+
+// WithLogger sets the logger of the requests. It defaults to the logger of the request context, see
+// logger.FromContext.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		opts.logger = l
+	}
+}
+
+// WithSkipPaths skips logging the requests to paths, e.g., health checks.
+func WithSkipPaths(paths ...string) Option {
+	return func(opts *options) {
+		for _, path := range paths {
+			opts.skipPaths[path] = true
+		}
+	}
+}
+
+// WithFilter adds filters telling whether a request is logged. A request is logged only if all the filters
+// return true.
+func WithFilter(filters ...func(r *http.Request) bool) Option {
+	return func(opts *options) {
+		opts.filters = append(opts.filters, filters...)
+	}
+}
+
+// WithSlowThreshold logs the requests taking at least d at the WARN level instead of INFO, with the "slow" field.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(opts *options) {
+		opts.slowThreshold = d
+	}
+}
+
+// WithHeaders logs the request headers names, e.g., "Content-Type" or "X-Forwarded-For". Headers with sensitive
+// names, e.g., "Authorization", are redacted, see WithRedaction.
+func WithHeaders(names ...string) Option {
+	return func(opts *options) {
+		opts.headers = append(opts.headers, names...)
+	}
+}
+
+// WithBodyCapture logs the request and response bodies of the sampleRate fraction of the requests, between 0 and
+// 1, truncated to maxBytes, or DefaultMaxBodyBytes if maxBytes is not positive. Only textual bodies, e.g., JSON,
+// forms or text, are captured, and they are redacted, see WithRedaction.
+func WithBodyCapture(sampleRate float64, maxBytes int) Option {
+	return func(opts *options) {
+		opts.sampleRate = sampleRate
+		if maxBytes > 0 {
+			opts.maxBodyBytes = maxBytes
+		}
+	}
+}
+
+// WithRedaction sets how the headers and bodies are redacted: the headers and the JSON or form fields whose name
+// contains one of keyPatterns, case-insensitively, are replaced with logger.DefaultRedactionMask, and the matches
+// of matchers are masked in the other values. It defaults to logger.DefaultRedactKeyPatterns with the
+// logger.CreditCardMatcher and logger.EmailMatcher matchers.
+func WithRedaction(keyPatterns []string, matchers ...logger.ValueMatcher) Option {
+	return func(opts *options) {
+		opts.redactor = newRedactor(keyPatterns, matchers)
+	}
+}
+
+/*
+Middleware logs every request with the logger.Logger: its method, path, query, client IP, user agent, selected
+headers and size, and the status, latency and size of its response, at the INFO level, or WARN for slow requests.
+The fields extracted from the request context by the logger, e.g., the request ID with requestid.Extractor, are
+added too, so install the middleware after the requestid middleware.
+
+Example usage:
+
+	handler := requestid.Middleware()(httplog.Middleware(
+		httplog.WithLogger(log),
+		httplog.WithSkipPaths("/health", "/ready"),
+		httplog.WithSlowThreshold(time.Second),
+		httplog.WithHeaders("Content-Type", "X-Forwarded-For"),
+		httplog.WithBodyCapture(0.01, 2048), // 1% of the bodies, up to 2 KB
+	)(mux))
+*/
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	o := &options{
+		skipPaths:    make(map[string]bool),
+		maxBodyBytes: DefaultMaxBodyBytes,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.redactor == nil {
+		o.redactor = newRedactor(logger.DefaultRedactKeyPatterns, []logger.ValueMatcher{logger.CreditCardMatcher, logger.EmailMatcher})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !o.logged(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			capture := o.sampleRate > 0 && rand.Float64() < o.sampleRate
+			body := &bodyRecorder{body: r.Body}
+			if r.Body != nil && r.Body != http.NoBody {
+				if capture && textual(r.Header.Get("Content-Type")) {
+					body.captured = &limitedBuffer{limit: o.maxBodyBytes}
+				}
+				r.Body = body
+			}
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK, maxBodyBytes: o.maxBodyBytes, capture: capture}
+
+			next.ServeHTTP(rw, r)
+
+			latency := time.Since(start)
+			o.log(r, rw, body, latency)
+		})
+	}
+}
+
+// logged reports whether r is logged.
+func (o *options) logged(r *http.Request) bool {
+	if o.skipPaths[r.URL.Path] {
+		return false
+	}
+	for _, filter := range o.filters {
+		if !filter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// log writes the entry of the request r.
+func (o *options) log(r *http.Request, rw *responseWriter, body *bodyRecorder, latency time.Duration) {
+	request := logger.Fields{
+		"method":     r.Method,
+		"path":       r.URL.Path,
+		"query":      o.redactor.redactString(r.URL.RawQuery),
+		"client_ip":  clientIP(r),
+		"user_agent": r.UserAgent(),
+		"size":       body.size,
+	}
+	if len(o.headers) > 0 {
+		headers := logger.Fields{}
+		for _, name := range o.headers {
+			if value := r.Header.Get(name); value != "" {
+				headers[strings.ToLower(name)] = o.redactor.redactField(name, value)
+			}
+		}
+		request["headers"] = headers
+	}
+	if body.captured != nil {
+		request["body"] = o.redactor.redactBody(body.captured)
+	}
+
+	response := logger.Fields{
+		"status_code": rw.status,
+		"latency_ms":  latency.Milliseconds(),
+		"latency_s":   latency.Seconds(),
+		"size":        rw.size,
+	}
+	if rw.captured != nil {
+		response["body"] = o.redactor.redactBody(rw.captured)
+	}
+
+	fields := logger.Fields{"request": request, "response": response}
+	level := logger.INFO
+	if o.slowThreshold > 0 && latency >= o.slowThreshold {
+		level = logger.WARN
+		fields["slow"] = true
+	}
+
+	l := o.logger
+	if l == nil {
+		l = logger.FromContext(r.Context())
+	}
+	l.Log(r.Context(), level, "HTTP request", fields)
+}
+
+// clientIP returns the IP address of the client connection of r.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// textual reports whether the bodies of contentType are text, and may be captured.
+func textual(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "json") ||
+		strings.Contains(contentType, "xml") ||
+		strings.HasPrefix(contentType, "application/x-www-form-urlencoded")
+}
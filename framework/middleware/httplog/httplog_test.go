@@ -0,0 +1,240 @@
+package httplog_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/middleware/httplog"
+	"github.com/kittipat1413/go-common/framework/middleware/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLogger(t *testing.T) (logger.Logger, *logger.Recorder) {
+	t.Helper()
+	recorder := &logger.Recorder{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:             logger.INFO,
+		Output:            &bytes.Buffer{},
+		Hooks:             []logger.Hook{recorder},
+		ContextExtractors: []logger.ContextExtractor{requestid.Extractor()},
+	})
+	require.NoError(t, err)
+	return log, recorder
+}
+
+// echoHandler reads the request body and writes it back as JSON.
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(body)
+})
+
+func TestMiddleware(t *testing.T) {
+	log, recorder := newLogger(t)
+	handler := requestid.Middleware()(httplog.Middleware(
+		httplog.WithLogger(log),
+		httplog.WithHeaders("Content-Type", "Authorization", "X-Missing"),
+	)(echoHandler))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders?page=2&token=secret", strings.NewReader(`{"id":1}`))
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set(requestid.DefaultHeader, "req-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, logger.INFO, entry.Level)
+	assert.Equal(t, "HTTP request", entry.Message)
+	assert.Equal(t, "req-123", entry.Fields[requestid.LogField])
+	assert.NotContains(t, entry.Fields, "slow")
+
+	request := entry.Fields["request"].(logger.Fields)
+	assert.Equal(t, http.MethodPost, request["method"])
+	assert.Equal(t, "/orders", request["path"])
+	assert.Equal(t, "page=2&token=[REDACTED]", request["query"])
+	assert.Equal(t, "10.0.0.1", request["client_ip"])
+	assert.Equal(t, "test-agent", request["user_agent"])
+	assert.Equal(t, int64(8), request["size"])
+	assert.Equal(t, logger.Fields{"content-type": "application/json", "authorization": "[REDACTED]"}, request["headers"])
+	assert.NotContains(t, request, "body")
+
+	response := entry.Fields["response"].(logger.Fields)
+	assert.Equal(t, http.StatusCreated, response["status_code"])
+	assert.Equal(t, int64(8), response["size"])
+	assert.NotContains(t, response, "body")
+}
+
+func TestMiddleware_Skip(t *testing.T) {
+	log, recorder := newLogger(t)
+	handler := httplog.Middleware(
+		httplog.WithLogger(log),
+		httplog.WithSkipPaths("/health"),
+		httplog.WithFilter(func(r *http.Request) bool { return r.Method != http.MethodOptions }),
+	)(echoHandler)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/health", nil),
+		httptest.NewRequest(http.MethodOptions, "/orders", nil),
+		httptest.NewRequest(http.MethodGet, "/orders", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "/orders", entries[0].Fields["request"].(logger.Fields)["path"])
+}
+
+func TestMiddleware_SlowThreshold(t *testing.T) {
+	log, recorder := newLogger(t)
+	handler := httplog.Middleware(httplog.WithLogger(log), httplog.WithSlowThreshold(20*time.Millisecond))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/slow" {
+				time.Sleep(30 * time.Millisecond)
+			}
+			_, _ = w.Write([]byte("ok"))
+		}),
+	)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fast", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, logger.INFO, entries[0].Level)
+	assert.Equal(t, http.StatusOK, entries[0].Fields["response"].(logger.Fields)["status_code"])
+	assert.Equal(t, logger.WARN, entries[1].Level)
+	assert.Equal(t, true, entries[1].Fields["slow"])
+}
+
+func TestMiddleware_BodyCapture(t *testing.T) {
+	tests := []struct {
+		name                 string
+		options              []httplog.Option
+		contentType          string
+		body                 string
+		expectedRequestBody  interface{}
+		expectedResponseBody interface{}
+	}{
+		{
+			name:        "redacted JSON",
+			options:     []httplog.Option{httplog.WithBodyCapture(1, 0)},
+			contentType: "application/json",
+			body:        `{"user":{"email":"alice@example.com","password":"secret"},"cards":["4111 1111 1111 1111"]}`,
+			expectedRequestBody: map[string]interface{}{
+				"user":  map[string]interface{}{"email": "a***@example.com", "password": "[REDACTED]"},
+				"cards": []interface{}{"**** **** **** 1111"},
+			},
+		},
+		{
+			name:                 "truncated JSON",
+			options:              []httplog.Option{httplog.WithBodyCapture(1, 32)},
+			contentType:          "application/json",
+			body:                 `{"name":"alice","api_key":"abc","description":"a long description"}`,
+			expectedRequestBody:  `{"name":"alice","api_key":[REDACTED],...[TRUNCATED]`,
+			expectedResponseBody: `{"name":"alice","api_key":[REDACTED],...[TRUNCATED]`,
+		},
+		{
+			name:                 "form",
+			options:              []httplog.Option{httplog.WithBodyCapture(1, 0)},
+			contentType:          "application/x-www-form-urlencoded",
+			body:                 "username=alice&password=secret",
+			expectedRequestBody:  "username=alice&password=[REDACTED]",
+			expectedResponseBody: "username=alice&password=[REDACTED]",
+		},
+		{
+			name:        "custom redaction",
+			options:     []httplog.Option{httplog.WithBodyCapture(1, 0), httplog.WithRedaction([]string{"ssn"})},
+			contentType: "application/json",
+			body:        `{"ssn":"123-45-6789","password":"secret"}`,
+			expectedRequestBody: map[string]interface{}{
+				"ssn":      "[REDACTED]",
+				"password": "secret",
+			},
+		},
+		{
+			name:        "binary",
+			options:     []httplog.Option{httplog.WithBodyCapture(1, 0)},
+			contentType: "application/octet-stream",
+			body:        "binary",
+		},
+		{
+			name:        "not sampled",
+			options:     []httplog.Option{httplog.WithBodyCapture(0, 0)},
+			contentType: "application/json",
+			body:        `{"name":"alice"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, recorder := newLogger(t)
+			handler := httplog.Middleware(append([]httplog.Option{httplog.WithLogger(log)}, tt.options...)...)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					body, _ := io.ReadAll(r.Body)
+					w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+					_, _ = w.Write(body)
+				}),
+			)
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.body, rec.Body.String(), "the bodies are not modified")
+
+			entries := recorder.Entries()
+			require.Len(t, entries, 1)
+			request := entries[0].Fields["request"].(logger.Fields)
+			response := entries[0].Fields["response"].(logger.Fields)
+			assert.Equal(t, int64(len(tt.body)), request["size"])
+			if tt.expectedRequestBody == nil {
+				assert.NotContains(t, request, "body")
+				assert.NotContains(t, response, "body")
+				return
+			}
+			assert.Equal(t, tt.expectedRequestBody, request["body"])
+			expectedResponseBody := tt.expectedResponseBody
+			if expectedResponseBody == nil {
+				expectedResponseBody = tt.expectedRequestBody
+			}
+			assert.Equal(t, expectedResponseBody, response["body"])
+		})
+	}
+}
+
+func TestMiddleware_ContextLogger(t *testing.T) {
+	log, recorder := newLogger(t)
+	handler := httplog.Middleware()(echoHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(logger.NewContext(context.Background(), log))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Len(t, recorder.Entries(), 1)
+}
+
+func TestMiddleware_Flush(t *testing.T) {
+	log, _ := newLogger(t)
+	handler := httplog.Middleware(httplog.WithLogger(log))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("chunk"))
+		flusher, ok := w.(http.Flusher)
+		require.True(t, ok)
+		flusher.Flush()
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, rec.Flushed)
+}
@@ -0,0 +1,116 @@
+package httplog
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// truncatedSuffix is appended to the bodies truncated by the capture.
+const truncatedSuffix = "...[TRUNCATED]"
+
+// redactor hides the sensitive data of the headers, queries and bodies logged.
+type redactor struct {
+	keyPatterns []string
+	matchers    []logger.ValueMatcher
+	// keyValue matches the "key": value and key=value pairs with sensitive keys, to redact the values of the bodies
+	// which cannot be parsed, e.g., forms or truncated JSON.
+	keyValue *regexp.Regexp
+}
+
+func newRedactor(keyPatterns []string, matchers []logger.ValueMatcher) *redactor {
+	r := &redactor{matchers: matchers}
+	quoted := make([]string, 0, len(keyPatterns))
+	for _, pattern := range keyPatterns {
+		if pattern != "" {
+			r.keyPatterns = append(r.keyPatterns, strings.ToLower(pattern))
+			quoted = append(quoted, regexp.QuoteMeta(pattern))
+		}
+	}
+	if len(quoted) > 0 {
+		r.keyValue = regexp.MustCompile(`(?i)("?[\w.-]*(?:` + strings.Join(quoted, "|") + `)[\w.-]*"?\s*[:=]\s*)("(?:[^"\\]|\\.)*"?|[^\s&,;}\]]*)`)
+	}
+	return r
+}
+
+// sensitive reports whether the values of key must be hidden.
+func (r *redactor) sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range r.keyPatterns {
+		if strings.Contains(key, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactField returns the value of key, hidden if key is sensitive, or else masked by the matchers.
+func (r *redactor) redactField(key, value string) string {
+	if r.sensitive(key) {
+		return logger.DefaultRedactionMask
+	}
+	return r.mask(value)
+}
+
+// redactString hides the values of the sensitive keys of s, and masks the matches of the matchers.
+func (r *redactor) redactString(s string) string {
+	if r.keyValue != nil {
+		s = r.keyValue.ReplaceAllString(s, "${1}"+logger.DefaultRedactionMask)
+	}
+	return r.mask(s)
+}
+
+// mask masks the matches of the matchers in s.
+func (r *redactor) mask(s string) string {
+	for _, matcher := range r.matchers {
+		s = matcher.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if matcher.Mask == nil {
+				return logger.DefaultRedactionMask
+			}
+			return matcher.Mask(match)
+		})
+	}
+	return s
+}
+
+// redactBody returns the redacted body: the JSON value for complete JSON bodies, so that it is logged as a
+// structure, or else the text.
+func (r *redactor) redactBody(body *limitedBuffer) interface{} {
+	if !body.truncated && json.Valid(body.data) {
+		var value interface{}
+		if err := json.Unmarshal(body.data, &value); err == nil {
+			return r.redactJSON(value)
+		}
+	}
+	text := r.redactString(string(body.data))
+	if body.truncated {
+		text += truncatedSuffix
+	}
+	return text
+}
+
+// redactJSON redacts a decoded JSON value.
+func (r *redactor) redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if r.sensitive(key) {
+				v[key] = logger.DefaultRedactionMask
+			} else {
+				v[key] = r.redactJSON(item)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.redactJSON(item)
+		}
+		return v
+	case string:
+		return r.mask(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,99 @@
+package httplog
+
+import (
+	"io"
+	"net/http"
+)
+
+// limitedBuffer keeps the first limit bytes written to it.
+type limitedBuffer struct {
+	limit     int
+	data      []byte
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.data); room < len(p) {
+		b.data = append(b.data, p[:max(room, 0)]...)
+		b.truncated = true
+	} else {
+		b.data = append(b.data, p...)
+	}
+	return len(p), nil
+}
+
+// bodyRecorder counts the bytes of a request body read by the handler, and captures them if captured is set.
+type bodyRecorder struct {
+	body     io.ReadCloser
+	size     int64
+	captured *limitedBuffer
+}
+
+func (b *bodyRecorder) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.size += int64(n)
+	if b.captured != nil {
+		_, _ = b.captured.Write(p[:n])
+	}
+	return n, err
+}
+
+func (b *bodyRecorder) Close() error {
+	return b.body.Close()
+}
+
+// responseWriter records the status and the size of a response, and captures its body if capture is set and the
+// body is textual.
+type responseWriter struct {
+	http.ResponseWriter
+	status       int
+	size         int64
+	wroteHeader  bool
+	capture      bool
+	maxBodyBytes int
+	captured     *limitedBuffer
+}
+
+// startBody records the status of the response, and starts capturing its body of contentType.
+func (w *responseWriter) startBody(status int, contentType string) {
+	w.wroteHeader = true
+	w.status = status
+	if w.capture && textual(contentType) {
+		w.captured = &limitedBuffer{limit: w.maxBodyBytes}
+	}
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	// Informational responses are followed by the final one.
+	if !w.wroteHeader && status >= http.StatusOK {
+		w.startBody(status, w.Header().Get("Content-Type"))
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		contentType := w.Header().Get("Content-Type")
+		if contentType == "" {
+			// Like the content type sniffed by the http.ResponseWriter.
+			contentType = http.DetectContentType(p)
+		}
+		w.startBody(http.StatusOK, contentType)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	if w.captured != nil {
+		_, _ = w.captured.Write(p[:n])
+	}
+	return n, err
+}
+
+// Flush flushes the response if the underlying http.ResponseWriter supports it, e.g., for streaming responses.
+func (w *responseWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
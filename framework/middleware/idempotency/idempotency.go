@@ -0,0 +1,358 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/errors"
+)
+
+const (
+	// DefaultHeader is the default header carrying the idempotency keys.
+	DefaultHeader = "Idempotency-Key"
+	// ReplayedHeader is set to "true" in the responses replayed from the cache.
+	ReplayedHeader = "Idempotent-Replayed"
+	// DefaultTTL is the default duration the responses are stored for.
+	DefaultTTL = 24 * time.Hour
+	// DefaultLockTimeout is the default duration a key stays locked by a request being processed, so that the
+	// key is released if the instance processing it crashes.
+	DefaultLockTimeout = time.Minute
+	// MaxKeyLength is the maximum length of the idempotency keys.
+	MaxKeyLength = 255
+	// DefaultMaxBodySize is the maximum size of the bodies of the requests with an idempotency key, read in memory
+	// to fingerprint them, unless set with WithMaxBodySize.
+	DefaultMaxBodySize = 1 << 20
+)
+
+// Codes of the errors returned by the Middleware.
+const (
+	CodeKeyMissing   = "idempotency_key_missing"
+	CodeKeyInvalid   = "idempotency_key_invalid"
+	CodeKeyReused    = "idempotency_key_reused"
+	CodeInProgress   = "idempotency_request_in_progress"
+	CodeInvalidBody  = "idempotency_invalid_body"
+	CodeBodyTooLarge = "idempotency_body_too_large"
+	CodeUnavailable  = "idempotency_unavailable"
+)
+
+var (
+	// ErrKeyMissing is returned for requests without idempotency key when WithKeyRequired is set, with the 400
+	// status code.
+	ErrKeyMissing = errors.InvalidArgument(CodeKeyMissing, "missing idempotency key")
+	// ErrKeyInvalid is returned for idempotency keys longer than MaxKeyLength, with the 400 status code.
+	ErrKeyInvalid = errors.InvalidArgument(CodeKeyInvalid, "invalid idempotency key")
+	// ErrKeyReused is returned for requests reusing the idempotency key of a request with a different method,
+	// URL or body, with the 422 status code with the default ProblemWriter, and 400 otherwise.
+	ErrKeyReused = errors.InvalidArgument(CodeKeyReused, "idempotency key reused with a different request")
+	// ErrInProgress is returned for requests whose idempotency key is used by a request being processed, with
+	// the 409 status code. They may be retried later.
+	ErrInProgress = errors.NewCodedError(errors.KindConflict, CodeInProgress, "a request with the same idempotency key is in progress")
+	// ErrInvalidBody is returned for requests whose body cannot be read, with the 400 status code.
+	ErrInvalidBody = errors.InvalidArgument(CodeInvalidBody, "cannot read request body")
+	// ErrBodyTooLarge is returned for requests whose body is larger than the maximum body size, with the 413 status
+	// code with the default ProblemWriter, and 400 otherwise.
+	ErrBodyTooLarge = errors.InvalidArgument(CodeBodyTooLarge, "request body is too large")
+	// ErrUnavailable is returned when the cache fails, with the 503 status code, since the request cannot be
+	// safely processed.
+	ErrUnavailable = errors.Unavailable(CodeUnavailable, "idempotency store is unavailable")
+)
+
+/*
+StoredResponse is the response of a request stored under its idempotency key, with the fingerprint of the request.
+Its fields are exported, so that remote caches can encode it.
+*/
+type StoredResponse struct {
+	// Fingerprint is the hash of the method, URL and body of the request.
+	Fingerprint string
+	// Completed is false while the request is processed.
+	Completed  bool
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// options holds configuration options for the Middleware.
+type options struct {
+	header      string
+	methods     map[string]bool
+	required    bool
+	ttl         time.Duration
+	lockTimeout time.Duration
+	maxBodySize int64
+	scope       func(r *http.Request) string
+	handler     func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Option specifies Middleware configuration options.
+type Option func(*options)
+
+// WithHeader sets the header carrying the idempotency keys. It defaults to DefaultHeader.
+func WithHeader(header string) Option {
+	return func(opts *options) {
+		if header != "" {
+			opts.header = header
+		}
+	}
+}
+
+// WithMethods sets the methods of the requests the idempotency keys apply to. It defaults to POST and PATCH, the
+// methods that are not idempotent by definition.
+func WithMethods(methods ...string) Option {
+	return func(opts *options) {
+		opts.methods = make(map[string]bool, len(methods))
+		for _, method := range methods {
+			opts.methods[method] = true
+		}
+	}
+}
+
+// WithKeyRequired rejects the requests without idempotency key with ErrKeyMissing, instead of processing them
+// as usual.
+func WithKeyRequired() Option {
+	return func(opts *options) {
+		opts.required = true
+	}
+}
+
+// WithTTL sets the duration the responses are stored for. It defaults to DefaultTTL.
+func WithTTL(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.ttl = d
+		}
+	}
+}
+
+// WithLockTimeout sets the duration a key stays locked by a request being processed. It defaults to
+// DefaultLockTimeout, and should be longer than the requests take.
+func WithLockTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.lockTimeout = d
+		}
+	}
+}
+
+// WithMaxBodySize sets the maximum size of the bodies of the requests with an idempotency key, read in memory to
+// fingerprint them. Larger requests are rejected with ErrBodyTooLarge. It defaults to DefaultMaxBodySize.
+func WithMaxBodySize(size int64) Option {
+	return func(opts *options) {
+		if size > 0 {
+			opts.maxBodySize = size
+		}
+	}
+}
+
+// WithScope sets the function returning the scope of the idempotency keys of a request, e.g., the authenticated
+// user, so that the keys of different users do not collide.
+func WithScope(scope func(r *http.Request) string) Option {
+	return func(opts *options) {
+		opts.scope = scope
+	}
+}
+
+// WithProblemWriter writes the responses of the rejected requests with problems. It defaults to an
+// errors.ProblemWriter reporting ErrKeyReused with the 422 status code, and ErrBodyTooLarge with 413.
+func WithProblemWriter(problems *errors.ProblemWriter) Option {
+	return func(opts *options) {
+		if problems != nil {
+			opts.handler = problems.Write
+		}
+	}
+}
+
+// WithErrorHandler sets the function writing the responses of the rejected requests, instead of a ProblemWriter.
+func WithErrorHandler(handler func(w http.ResponseWriter, r *http.Request, err error)) Option {
+	return func(opts *options) {
+		if handler != nil {
+			opts.handler = handler
+		}
+	}
+}
+
+/*
+Middleware implements the Idempotency-Key pattern, so that clients can safely retry requests with side effects,
+e.g., payments: the response of the first request with a key is stored in c, with the fingerprint of the request,
+and replayed for the next requests with the same key, with the ReplayedHeader header, without calling the
+handler again.
+
+  - A request reusing a key with a different method, URL or body is rejected with ErrKeyReused.
+  - A request whose key is used by a request still being processed is rejected with ErrInProgress. The key is
+    locked atomically if c implements cache.ConditionalSetter.
+  - Server errors (5xx) and panics are not stored, so that the request can be retried.
+  - A failure of c rejects the request with ErrUnavailable.
+
+Example usage:
+
+	responses := localcache.New[idempotency.StoredResponse](localcache.WithMaxEntries(100000))
+	mux.Handle("/payments", authenticate(idempotency.Middleware(responses,
+		idempotency.WithKeyRequired(),
+		idempotency.WithScope(func(r *http.Request) string {
+			userID, _ := auth.SubjectFromContext(r.Context())
+			return userID
+		}),
+	)(paymentsHandler)))
+*/
+func Middleware(c cache.Cache[StoredResponse], opts ...Option) func(http.Handler) http.Handler {
+	o := &options{
+		header:      DefaultHeader,
+		methods:     map[string]bool{http.MethodPost: true, http.MethodPatch: true},
+		ttl:         DefaultTTL,
+		lockTimeout: DefaultLockTimeout,
+		maxBodySize: DefaultMaxBodySize,
+		handler: errors.NewProblemWriter(
+			errors.WithCodeStatus(CodeKeyReused, http.StatusUnprocessableEntity),
+			errors.WithCodeStatus(CodeBodyTooLarge, http.StatusRequestEntityTooLarge),
+		).Write,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !o.methods[r.Method] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := r.Header.Get(o.header)
+			if key == "" {
+				if o.required {
+					o.handler(w, r, ErrKeyMissing)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > MaxKeyLength {
+				o.handler(w, r, ErrKeyInvalid)
+				return
+			}
+
+			fingerprint, err := fingerprintOf(r, o.maxBodySize)
+			if stderrors.Is(err, ErrBodyTooLarge) {
+				o.handler(w, r, ErrBodyTooLarge)
+				return
+			}
+			if err != nil {
+				o.handler(w, r, ErrInvalidBody.Wrap(err))
+				return
+			}
+			if o.scope != nil {
+				key = o.scope(r) + ":" + key
+			}
+
+			ctx := r.Context()
+			stored, err := c.Get(ctx, key, nil)
+			if err == nil {
+				o.replay(w, r, stored, fingerprint)
+				return
+			}
+			if !stderrors.Is(err, cache.ErrCacheMiss) {
+				o.handler(w, r, ErrUnavailable.Wrap(err))
+				return
+			}
+			if !o.lock(ctx, c, key, fingerprint) {
+				// Another request took the key since the Get.
+				if stored, err := c.Get(ctx, key, nil); err == nil {
+					o.replay(w, r, stored, fingerprint)
+					return
+				}
+				o.handler(w, r, ErrInProgress)
+				return
+			}
+
+			// The response is stored, or the key released, even if the request is cancelled.
+			ctx = context.WithoutCancel(ctx)
+			completed := false
+			defer func() {
+				if !completed {
+					_ = c.Invalidate(ctx, key)
+				}
+			}()
+
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+			if rw.status >= http.StatusInternalServerError {
+				return
+			}
+			header := rw.header
+			if header == nil {
+				header = w.Header().Clone()
+			}
+			c.Set(ctx, key, StoredResponse{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				StatusCode:  rw.status,
+				Header:      header,
+				Body:        rw.body.Bytes(),
+			}, &o.ttl)
+			completed = true
+		})
+	}
+}
+
+// lock stores an uncompleted response under key, so that the concurrent requests with key are rejected, and
+// reports whether it did.
+func (o *options) lock(ctx context.Context, c cache.Cache[StoredResponse], key, fingerprint string) bool {
+	pending := StoredResponse{Fingerprint: fingerprint}
+	if setter, ok := c.(cache.ConditionalSetter[StoredResponse]); ok {
+		return setter.SetIfAbsent(ctx, key, pending, &o.lockTimeout)
+	}
+	c.Set(ctx, key, pending, &o.lockTimeout)
+	return true
+}
+
+// replay writes the stored response of a request with fingerprint.
+func (o *options) replay(w http.ResponseWriter, r *http.Request, stored StoredResponse, fingerprint string) {
+	if stored.Fingerprint != fingerprint {
+		o.handler(w, r, ErrKeyReused)
+		return
+	}
+	if !stored.Completed {
+		o.handler(w, r, ErrInProgress)
+		return
+	}
+	header := w.Header()
+	for name, values := range stored.Header {
+		header[name] = append([]string(nil), values...)
+	}
+	header.Set(ReplayedHeader, "true")
+	header.Set("Content-Length", strconv.Itoa(len(stored.Body)))
+	w.WriteHeader(stored.StatusCode)
+	_, _ = w.Write(stored.Body)
+}
+
+// fingerprintOf returns the hash of the method, URL and body of r, and restores the body of r for the handler. It
+// returns ErrBodyTooLarge without reading more than maxBodySize bytes of a larger body.
+func fingerprintOf(r *http.Request, maxBodySize int64) (string, error) {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		if r.ContentLength > maxBodySize {
+			return "", ErrBodyTooLarge
+		}
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		if err != nil {
+			return "", err
+		}
+		if int64(len(body)) > maxBodySize {
+			return "", ErrBodyTooLarge
+		}
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,295 @@
+package idempotency_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/cache"
+	"github.com/kittipat1413/go-common/framework/cache/localcache"
+	cache_mocks "github.com/kittipat1413/go-common/framework/cache/mocks"
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/middleware/idempotency"
+)
+
+// paymentHandler creates a payment with a new ID for every call, so that replayed responses can be told apart.
+type paymentHandler struct {
+	calls  atomic.Int32
+	status int
+}
+
+func (h *paymentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	call := h.calls.Add(1)
+	body, _ := io.ReadAll(r.Body)
+	status := h.status
+	if status == 0 {
+		status = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/payments/"+string(rune('0'+call)))
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": call, "request": string(body)})
+}
+
+func newRequest(method, key, body string) *http.Request {
+	req := httptest.NewRequest(method, "/payments", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotency.DefaultHeader, key)
+	}
+	return req
+}
+
+func problemCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var problem map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	code, _ := problem["code"].(string)
+	return code
+}
+
+func TestMiddleware_Replay(t *testing.T) {
+	caches := map[string]cache.Cache[idempotency.StoredResponse]{
+		"conditional setter": localcache.New[idempotency.StoredResponse](localcache.WithLazyExpiration()),
+	}
+	caches["plain cache"], _ = cache.NewTestCache[idempotency.StoredResponse]()
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			handler := &paymentHandler{}
+			middleware := idempotency.Middleware(c)(handler)
+
+			first := httptest.NewRecorder()
+			middleware.ServeHTTP(first, newRequest(http.MethodPost, "key-1", `{"amount":100}`))
+			assert.Equal(t, http.StatusCreated, first.Code)
+			assert.Empty(t, first.Header().Get(idempotency.ReplayedHeader))
+
+			second := httptest.NewRecorder()
+			middleware.ServeHTTP(second, newRequest(http.MethodPost, "key-1", `{"amount":100}`))
+			assert.Equal(t, http.StatusCreated, second.Code)
+			assert.Equal(t, "true", second.Header().Get(idempotency.ReplayedHeader))
+			assert.Equal(t, "/payments/1", second.Header().Get("Location"))
+			assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+			assert.Equal(t, first.Body.String(), second.Body.String())
+			assert.Equal(t, int32(1), handler.calls.Load(), "the handler is called once")
+
+			// Other keys are processed.
+			third := httptest.NewRecorder()
+			middleware.ServeHTTP(third, newRequest(http.MethodPost, "key-2", `{"amount":100}`))
+			assert.Equal(t, http.StatusCreated, third.Code)
+			assert.Equal(t, int32(2), handler.calls.Load())
+		})
+	}
+}
+
+func TestMiddleware_KeyReused(t *testing.T) {
+	c := localcache.New[idempotency.StoredResponse](localcache.WithLazyExpiration())
+	handler := &paymentHandler{}
+	middleware := idempotency.Middleware(c)(handler)
+
+	middleware.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodPost, "key-1", `{"amount":100}`))
+
+	for _, req := range []*http.Request{
+		newRequest(http.MethodPost, "key-1", `{"amount":200}`),
+		newRequest(http.MethodPatch, "key-1", `{"amount":100}`),
+		httptest.NewRequest(http.MethodPost, "/refunds", strings.NewReader(`{"amount":100}`)),
+	} {
+		req.Header.Set(idempotency.DefaultHeader, "key-1")
+		rec := httptest.NewRecorder()
+		middleware.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, idempotency.CodeKeyReused, problemCode(t, rec))
+	}
+	assert.Equal(t, int32(1), handler.calls.Load())
+}
+
+func TestMiddleware_InProgress(t *testing.T) {
+	c := localcache.New[idempotency.StoredResponse](localcache.WithLazyExpiration())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	middleware := idempotency.Middleware(c)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		middleware.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodPost, "key-1", "{}"))
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	middleware.ServeHTTP(rec, newRequest(http.MethodPost, "key-1", "{}"))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, idempotency.CodeInProgress, problemCode(t, rec))
+
+	close(release)
+	<-done
+	rec = httptest.NewRecorder()
+	middleware.ServeHTTP(rec, newRequest(http.MethodPost, "key-1", "{}"))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get(idempotency.ReplayedHeader))
+}
+
+func TestMiddleware_NotStored(t *testing.T) {
+	t.Run("server error", func(t *testing.T) {
+		c := localcache.New[idempotency.StoredResponse](localcache.WithLazyExpiration())
+		handler := &paymentHandler{status: http.StatusBadGateway}
+		middleware := idempotency.Middleware(c)(handler)
+
+		for i := 0; i < 2; i++ {
+			rec := httptest.NewRecorder()
+			middleware.ServeHTTP(rec, newRequest(http.MethodPost, "key-1", "{}"))
+			assert.Equal(t, http.StatusBadGateway, rec.Code)
+			assert.Empty(t, rec.Header().Get(idempotency.ReplayedHeader))
+		}
+		assert.Equal(t, int32(2), handler.calls.Load(), "server errors can be retried")
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		c := localcache.New[idempotency.StoredResponse](localcache.WithLazyExpiration())
+		middleware := idempotency.Middleware(c)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+
+		assert.Panics(t, func() {
+			middleware.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodPost, "key-1", "{}"))
+		})
+		_, err := c.Get(context.Background(), "key-1", nil)
+		assert.ErrorIs(t, err, cache.ErrCacheMiss, "the key is released")
+	})
+}
+
+func TestMiddleware_Skipped(t *testing.T) {
+	c, recorder := cache.NewTestCache[idempotency.StoredResponse]()
+	handler := &paymentHandler{}
+	middleware := idempotency.Middleware(c)(handler)
+
+	for _, req := range []*http.Request{
+		newRequest(http.MethodGet, "key-1", ""),
+		newRequest(http.MethodGet, "key-1", ""),
+		newRequest(http.MethodPost, "", "{}"),
+		newRequest(http.MethodPost, "", "{}"),
+	} {
+		rec := httptest.NewRecorder()
+		middleware.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	}
+	assert.Equal(t, int32(4), handler.calls.Load())
+	assert.Zero(t, recorder.Len())
+}
+
+func TestMiddleware_InvalidRequests(t *testing.T) {
+	c := localcache.New[idempotency.StoredResponse](localcache.WithLazyExpiration())
+	handler := &paymentHandler{}
+	middleware := idempotency.Middleware(c, idempotency.WithKeyRequired())(handler)
+
+	tests := []struct {
+		name         string
+		key          string
+		expectedCode string
+	}{
+		{name: "missing key", expectedCode: idempotency.CodeKeyMissing},
+		{name: "key too long", key: strings.Repeat("k", idempotency.MaxKeyLength+1), expectedCode: idempotency.CodeKeyInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			middleware.ServeHTTP(rec, newRequest(http.MethodPost, tt.key, "{}"))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, tt.expectedCode, problemCode(t, rec))
+		})
+	}
+	assert.Zero(t, handler.calls.Load())
+}
+
+func TestMiddleware_BodyTooLarge(t *testing.T) {
+	c, recorder := cache.NewTestCache[idempotency.StoredResponse]()
+	handler := &paymentHandler{}
+	middleware := idempotency.Middleware(c, idempotency.WithMaxBodySize(16))(handler)
+
+	rec := httptest.NewRecorder()
+	middleware.ServeHTTP(rec, newRequest(http.MethodPost, "key-1", `{"amount":100}`))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	recorder.Reset()
+
+	tests := []struct {
+		name    string
+		request func() *http.Request
+	}{
+		{name: "content length", request: func() *http.Request {
+			return newRequest(http.MethodPost, "key-2", `{"amount":100000}`)
+		}},
+		{name: "chunked", request: func() *http.Request {
+			req := newRequest(http.MethodPost, "key-2", `{"amount":100000}`)
+			req.ContentLength = -1
+			return req
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			middleware.ServeHTTP(rec, tt.request())
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+			assert.Equal(t, idempotency.CodeBodyTooLarge, problemCode(t, rec))
+		})
+	}
+	assert.Equal(t, int32(1), handler.calls.Load())
+	assert.Zero(t, recorder.Len())
+}
+
+func TestMiddleware_Scope(t *testing.T) {
+	c, recorder := cache.NewTestCache[idempotency.StoredResponse]()
+	handler := &paymentHandler{}
+	middleware := idempotency.Middleware(c,
+		idempotency.WithHeader("X-Idempotency-Key"),
+		idempotency.WithTTL(time.Hour),
+		idempotency.WithScope(func(r *http.Request) string { return r.Header.Get("X-User") }),
+	)(handler)
+
+	for _, user := range []string{"alice", "bob", "alice"} {
+		req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader("{}"))
+		req.Header.Set("X-Idempotency-Key", "key-1")
+		req.Header.Set("X-User", user)
+		middleware.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, int32(2), handler.calls.Load())
+	recorder.AssertCalled(t, cache.OpSet, "bob:key-1")
+	sets := recorder.Find(cache.OpSet, "alice:key-1")
+	require.Len(t, sets, 2, "the key is locked, then the response is stored")
+	assert.Equal(t, idempotency.DefaultLockTimeout, *sets[0].Duration)
+	assert.Equal(t, time.Hour, *sets[1].Duration)
+	assert.True(t, sets[1].Value.(idempotency.StoredResponse).Completed)
+}
+
+func TestMiddleware_CacheFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	c := cache_mocks.NewMockCache[idempotency.StoredResponse](ctrl)
+	c.EXPECT().Get(gomock.Any(), "key-1", gomock.Nil()).Return(idempotency.StoredResponse{}, errors.New("connection refused"))
+
+	var handled error
+	handler := &paymentHandler{}
+	middleware := idempotency.Middleware(c, idempotency.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		handled = err
+		domain_error.NewProblemWriter().Write(w, r, err)
+	}))(handler)
+
+	rec := httptest.NewRecorder()
+	middleware.ServeHTTP(rec, newRequest(http.MethodPost, "key-1", "{}"))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.ErrorIs(t, handled, idempotency.ErrUnavailable)
+	assert.EqualError(t, handled, "idempotency store is unavailable: connection refused")
+	assert.Zero(t, handler.calls.Load())
+}
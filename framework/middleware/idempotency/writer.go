@@ -0,0 +1,43 @@
+package idempotency
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// responseWriter records the status, the headers and the body of a response, to store it.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	// header is a copy of the headers of the response when it was started.
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	// Informational responses are followed by the final one.
+	if w.header == nil && status >= http.StatusOK {
+		w.status = status
+		w.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.header == nil {
+		w.header = w.Header().Clone()
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.body.Write(p[:n])
+	return n, err
+}
+
+// Flush flushes the response if the underlying http.ResponseWriter supports it, e.g., for streaming responses.
+func (w *responseWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
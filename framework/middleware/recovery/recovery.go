@@ -0,0 +1,144 @@
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// CodeInternal is the code of the errors the panics are converted to.
+const CodeInternal = "internal_error"
+
+// options holds configuration options for the Middleware.
+type options struct {
+	logger  logger.Logger
+	handler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Option specifies Middleware configuration options.
+type Option func(*options)
+
+// WithLogger sets the logger of the panics. It defaults to the logger of the request context, see
+// logger.FromContext.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		opts.logger = l
+	}
+}
+
+// WithProblemWriter writes the responses of the panics with problems. It defaults to an errors.ProblemWriter
+// without options, e.g., use one in production mode, so that the responses do not reveal the panics.
+func WithProblemWriter(problems *errors.ProblemWriter) Option {
+	return func(opts *options) {
+		if problems != nil {
+			opts.handler = problems.Write
+		}
+	}
+}
+
+// WithErrorHandler sets the function writing the responses of the panics, instead of a ProblemWriter.
+func WithErrorHandler(handler func(w http.ResponseWriter, r *http.Request, err error)) Option {
+	return func(opts *options) {
+		if handler != nil {
+			opts.handler = handler
+		}
+	}
+}
+
+/*
+Middleware recovers the panics of the handlers, so that they do not kill the connection: it converts the panic
+to an Internal *errors.CodedError with the CodeInternal code, wrapping the panic value if it is an error, logs it
+with the stack of the panic at the ERROR level, and writes the standard error response, unless the handler already
+started writing the response. Panics with http.ErrAbortHandler are not recovered, so that they abort the response
+as intended.
+
+Example usage:
+
+	problems := errors.NewProblemWriter(errors.WithProductionMode(true))
+	handler := requestid.Middleware()(recovery.Middleware(
+		recovery.WithLogger(log),
+		recovery.WithProblemWriter(problems),
+	)(mux))
+*/
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	o := &options{
+		handler: errors.NewProblemWriter().Write,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseWriter{ResponseWriter: w}
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				// The error is created in the deferred function, while the stack of the panic is still there.
+				err := errors.Internal(CodeInternal, "internal server error").Wrap(panicError(recovered))
+				l := o.logger
+				if l == nil {
+					l = logger.FromContext(r.Context())
+				}
+				l.Error(r.Context(), "Panic recovered", err, logger.Fields{
+					logger.DefaultPanicKey: fmt.Sprintf("%v", recovered),
+					"request": logger.Fields{
+						"method": r.Method,
+						"path":   r.URL.Path,
+					},
+					"response_started": rw.wroteHeader,
+				})
+
+				if !rw.wroteHeader {
+					o.handler(rw, r, err)
+				}
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// panicError returns the panic value recovered as an error.
+func panicError(recovered interface{}) error {
+	if err, ok := recovered.(error); ok {
+		return err
+	}
+	return fmt.Errorf("panic: %v", recovered)
+}
+
+// responseWriter records whether the response was started, in which case the error response cannot be written.
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if status >= http.StatusOK {
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush flushes the response if the underlying http.ResponseWriter supports it, e.g., for streaming responses.
+func (w *responseWriter) Flush() {
+	w.wroteHeader = true
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,162 @@
+package recovery_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/middleware/recovery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLogger(t *testing.T) (logger.Logger, *logger.Recorder) {
+	t.Helper()
+	recorder := &logger.Recorder{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:  logger.INFO,
+		Output: &bytes.Buffer{},
+		Hooks:  []logger.Hook{recorder},
+	})
+	require.NoError(t, err)
+	return log, recorder
+}
+
+func panickingHandler(value interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(value)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name          string
+		value         interface{}
+		expectedPanic string
+	}{
+		{name: "string", value: "nil map", expectedPanic: "nil map"},
+		{name: "error", value: errBoom, expectedPanic: "boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, recorder := newLogger(t)
+			handler := recovery.Middleware(recovery.WithLogger(log))(panickingHandler(tt.value))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.Equal(t, domain_error.ProblemContentType, rec.Header().Get("Content-Type"))
+			var problem map[string]interface{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, recovery.CodeInternal, problem["code"])
+			assert.Equal(t, "/orders", problem["instance"])
+
+			entries := recorder.Entries()
+			require.Len(t, entries, 1)
+			entry := entries[0]
+			assert.Equal(t, logger.ERROR, entry.Level)
+			assert.Equal(t, tt.expectedPanic, entry.Fields[logger.DefaultPanicKey])
+			assert.Equal(t, logger.Fields{"method": http.MethodGet, "path": "/orders"}, entry.Fields["request"])
+			assert.Equal(t, recovery.CodeInternal, domain_error.CodeOf(entry.Error))
+			assert.Equal(t, domain_error.KindInternal, domain_error.KindOf(entry.Error))
+			if err, ok := tt.value.(error); ok {
+				assert.ErrorIs(t, entry.Error, err)
+			}
+
+			// The stack of the error includes the panicking handler.
+			var coded *domain_error.CodedError
+			require.True(t, errors.As(entry.Error, &coded))
+			frames := runtime.CallersFrames(coded.StackTrace())
+			found := false
+			for {
+				frame, more := frames.Next()
+				if strings.Contains(frame.Function, "panickingHandler") {
+					found = true
+				}
+				if !more {
+					break
+				}
+			}
+			assert.True(t, found, "the stack should include the panicking handler")
+		})
+	}
+}
+
+func TestMiddleware_ResponseStarted(t *testing.T) {
+	log, recorder := newLogger(t)
+	handler := recovery.Middleware(recovery.WithLogger(log))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("partial"))
+		panic("too late")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "partial", rec.Body.String())
+	require.Len(t, recorder.Entries(), 1)
+	assert.Equal(t, true, recorder.Entries()[0].Fields["response_started"])
+}
+
+func TestMiddleware_ErrorResponse(t *testing.T) {
+	log, _ := newLogger(t)
+
+	t.Run("problem writer", func(t *testing.T) {
+		problems := domain_error.NewProblemWriter(domain_error.WithProductionMode(true))
+		handler := recovery.Middleware(recovery.WithLogger(log), recovery.WithProblemWriter(problems))(panickingHandler("secret details"))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "secret details")
+	})
+
+	t.Run("error handler", func(t *testing.T) {
+		var handled error
+		handler := recovery.Middleware(recovery.WithLogger(log), recovery.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			handled = err
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))(panickingHandler("boom"))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, recovery.CodeInternal, domain_error.CodeOf(handled))
+	})
+}
+
+func TestMiddleware_AbortHandler(t *testing.T) {
+	log, recorder := newLogger(t)
+	handler := recovery.Middleware(recovery.WithLogger(log))(panickingHandler(http.ErrAbortHandler))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Empty(t, recorder.Entries())
+}
+
+func TestMiddleware_NoPanic(t *testing.T) {
+	log, recorder := newLogger(t)
+	handler := recovery.Middleware(recovery.WithLogger(log))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", rec.Body.String())
+	assert.Empty(t, recorder.Entries())
+}
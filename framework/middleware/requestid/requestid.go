@@ -0,0 +1,171 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/rs/xid"
+)
+
+const (
+	// DefaultHeader is the default header carrying the request ID.
+	DefaultHeader = "X-Request-ID"
+	// LogField is the field the request ID is written to by the Extractor.
+	LogField = "request_id"
+	// MaxLength is the maximum length of the request IDs accepted from the clients.
+	MaxLength = 64
+)
+
+// contextKey is an unexported type for context keys defined in this package.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the request ID id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
+
+/*
+Extractor returns a logger.ContextExtractor writing the request ID of the context of the entries as the
+"request_id" field, so that every entry logged while handling a request can be correlated.
+
+Example usage:
+
+	log, err := logger.NewLogger(logger.Config{
+		Level:             logger.INFO,
+		ContextExtractors: []logger.ContextExtractor{requestid.Extractor()},
+	})
+*/
+func Extractor() logger.ContextExtractor {
+	return func(ctx context.Context) logger.Fields {
+		if id, ok := FromContext(ctx); ok {
+			return logger.Fields{LogField: id}
+		}
+		return nil
+	}
+}
+
+// Generator is a function generating unique request IDs.
+type Generator func() string
+
+// options holds configuration options for the Middleware and the Transport.
+type options struct {
+	header    string
+	generator Generator
+}
+
+// Option specifies Middleware and Transport configuration options.
+type Option func(*options)
+
+// WithHeader sets the header carrying the request ID. It defaults to DefaultHeader.
+func WithHeader(header string) Option {
+	return func(opts *options) {
+		if header != "" {
+			opts.header = header
+		}
+	}
+}
+
+// WithGenerator sets the function generating the request IDs. It defaults to xid, which generates compact and
+// globally unique IDs.
+func WithGenerator(generator Generator) Option {
+	return func(opts *options) {
+		if generator != nil {
+			opts.generator = generator
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		header: DefaultHeader,
+		generator: func() string {
+			return xid.New().String()
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// valid reports whether a request ID received from a client can be used: it must not be longer than MaxLength,
+// and must only contain visible ASCII characters, so that it cannot forge log lines or headers.
+func valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Middleware propagates the request ID of every request: it takes the ID of the request header, or generates one
+if the header is missing or invalid, sets it in the response header, and stores it in the request context, where
+FromContext, the Extractor of the loggers and the Transport of outgoing requests find it. Install it before the
+other middlewares, so that their logs carry the request ID.
+
+Example usage:
+
+	handler := requestid.Middleware()(mux)
+
+	func (h *orderHandler) Get(w http.ResponseWriter, r *http.Request) {
+		id, _ := requestid.FromContext(r.Context())
+		...
+	}
+*/
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(o.header)
+			if !valid(id) {
+				id = o.generator()
+			}
+			w.Header().Set(o.header, id)
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+		})
+	}
+}
+
+/*
+Transport is an http.RoundTripper setting the request ID of the context of the outgoing requests in their header,
+so that the downstream services log the same request ID. Requests already carrying the header are left unchanged.
+
+Example usage:
+
+	client := &http.Client{Transport: requestid.NewTransport(http.DefaultTransport)}
+*/
+type Transport struct {
+	base   http.RoundTripper
+	header string
+}
+
+// NewTransport creates a Transport sending the requests with base, or http.DefaultTransport if base is nil.
+// Only the WithHeader option applies.
+func NewTransport(base http.RoundTripper, opts ...Option) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base, header: newOptions(opts).header}
+}
+
+// RoundTrip sends req with the request ID of its context, see http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if id, ok := FromContext(req.Context()); ok && req.Header.Get(t.header) == "" {
+		// RoundTrippers must not modify the request.
+		req = req.Clone(req.Context())
+		req.Header.Set(t.header, id)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,142 @@
+package requestid_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/middleware/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoHandler responds with the request ID of the request context.
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	id, _ := requestid.FromContext(r.Context())
+	_, _ = w.Write([]byte(id))
+})
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []requestid.Option
+		header     string
+		incoming   string
+		expectedID string
+	}{
+		{name: "generated", header: requestid.DefaultHeader, expectedID: "generated"},
+		{name: "propagated", header: requestid.DefaultHeader, incoming: "req-123", expectedID: "req-123"},
+		{name: "too long", header: requestid.DefaultHeader, incoming: strings.Repeat("a", requestid.MaxLength+1), expectedID: "generated"},
+		{name: "invalid characters", header: requestid.DefaultHeader, incoming: "req 123é", expectedID: "generated"},
+		{
+			name:       "custom header",
+			options:    []requestid.Option{requestid.WithHeader("X-Correlation-ID")},
+			header:     "X-Correlation-ID",
+			incoming:   "corr-456",
+			expectedID: "corr-456",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]requestid.Option{requestid.WithGenerator(func() string { return "generated" })}, tt.options...)
+			handler := requestid.Middleware(options...)(echoHandler)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(tt.header, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedID, rec.Body.String())
+			assert.Equal(t, tt.expectedID, rec.Header().Get(tt.header))
+		})
+	}
+}
+
+func TestMiddleware_DefaultGenerator(t *testing.T) {
+	handler := requestid.Middleware()(echoHandler)
+
+	ids := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.NotEmpty(t, rec.Body.String())
+		ids[rec.Body.String()] = true
+	}
+	assert.Len(t, ids, 10)
+}
+
+func TestFromContext(t *testing.T) {
+	_, ok := requestid.FromContext(context.Background())
+	assert.False(t, ok)
+
+	_, ok = requestid.FromContext(requestid.NewContext(context.Background(), ""))
+	assert.False(t, ok)
+
+	id, ok := requestid.FromContext(requestid.NewContext(context.Background(), "req-123"))
+	assert.True(t, ok)
+	assert.Equal(t, "req-123", id)
+}
+
+func TestExtractor(t *testing.T) {
+	recorder := &logger.Recorder{}
+	log, err := logger.NewLogger(logger.Config{
+		Level:             logger.INFO,
+		Output:            &bytes.Buffer{},
+		Hooks:             []logger.Hook{recorder},
+		ContextExtractors: []logger.ContextExtractor{requestid.Extractor()},
+	})
+	require.NoError(t, err)
+
+	handler := requestid.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Info(r.Context(), "handling request", nil)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(requestid.DefaultHeader, "req-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	log.Info(context.Background(), "outside of a request", nil)
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "req-123", entries[0].Fields[requestid.LogField])
+	assert.NotContains(t, entries[1].Fields, requestid.LogField)
+}
+
+func TestTransport(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get(requestid.DefaultHeader))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: requestid.NewTransport(nil)}
+	ctx := requestid.NewContext(context.Background(), "req-123")
+
+	// Request ID of the context
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Empty(t, req.Header.Get(requestid.DefaultHeader), "the original request is not modified")
+
+	// Request ID already set
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(requestid.DefaultHeader, "explicit")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// No request ID
+	resp, err = client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, []string{"req-123", "explicit", ""}, received)
+}
@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// routeHolder holds the route template of a request, recorded by its handler.
+type routeHolder struct {
+	route string
+}
+
+type routeContextKey struct{}
+
+// newRouteContext returns a copy of ctx carrying holder.
+func newRouteContext(ctx context.Context, holder *routeHolder) context.Context {
+	return context.WithValue(ctx, routeContextKey{}, holder)
+}
+
+/*
+SetRoute records the route template of the request traced with ctx, e.g., "/orders/{id}", to name its span after
+it. It must be called before the handler returns, and does nothing if the request is not traced by the Middleware.
+
+Example usage:
+
+	func (h *OrderHandler) Get(w http.ResponseWriter, r *http.Request) {
+		tracing.SetRoute(r.Context(), "/orders/{id}")
+		...
+	}
+*/
+func SetRoute(ctx context.Context, route string) {
+	if holder, ok := ctx.Value(routeContextKey{}).(*routeHolder); ok {
+		holder.route = route
+	}
+}
+
+/*
+Route returns a handler recording the route template of pattern, a http.ServeMux pattern such as
+"GET /orders/{id}", before calling next. The method and the host of the pattern are not part of the route.
+
+Example usage:
+
+	mux.Handle("GET /orders/{id}", tracing.Route("GET /orders/{id}", ordersHandler))
+*/
+func Route(pattern string, next http.Handler) http.Handler {
+	route := routeOf(pattern)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetRoute(r.Context(), route)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routeOf returns the path of the http.ServeMux pattern "[METHOD ][HOST]/[PATH]".
+func routeOf(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	if _, path, found := strings.Cut(pattern, " "); found {
+		pattern = strings.TrimSpace(path)
+	}
+	if i := strings.Index(pattern, "/"); i > 0 {
+		pattern = pattern[i:]
+	}
+	return pattern
+}
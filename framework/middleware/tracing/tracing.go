@@ -0,0 +1,257 @@
+package tracing
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation name of the tracer of the Middleware.
+const tracerName = "github.com/kittipat1413/go-common/framework/middleware/tracing"
+
+// options holds configuration options for the Middleware.
+type options struct {
+	tracerProvider oteltrace.TracerProvider
+	propagators    propagation.TextMapPropagator
+	filters        []func(r *http.Request) bool
+	routeFunc      func(r *http.Request) string
+}
+
+// Option specifies Middleware configuration options.
+type Option func(*options)
+
+// WithTracerProvider sets the tracer provider creating the spans. It defaults to the global tracer provider.
+func WithTracerProvider(provider oteltrace.TracerProvider) Option {
+	return func(opts *options) {
+		if provider != nil {
+			opts.tracerProvider = provider
+		}
+	}
+}
+
+// WithPropagators sets the propagators extracting the parent span and the baggage from the request headers. It
+// defaults to the global propagators, or the W3C Trace Context and Baggage propagators if none are set.
+func WithPropagators(propagators propagation.TextMapPropagator) Option {
+	return func(opts *options) {
+		if propagators != nil {
+			opts.propagators = propagators
+		}
+	}
+}
+
+// WithFilter adds filters telling whether a request is traced. A request is traced only if all the filters return
+// true.
+func WithFilter(filters ...func(r *http.Request) bool) Option {
+	return func(opts *options) {
+		opts.filters = append(opts.filters, filters...)
+	}
+}
+
+/*
+WithRoute sets the function returning the route template of the requests, e.g., "/orders/{id}", for routers which
+resolve it before the Middleware runs. It returns "" if the route is unknown. The routes recorded with Route or
+SetRoute by the handlers take precedence.
+
+Example usage:
+
+	tracing.WithRoute(func(r *http.Request) string {
+		return router.Match(r).Template
+	})
+*/
+func WithRoute(routeFunc func(r *http.Request) string) Option {
+	return func(opts *options) {
+		opts.routeFunc = routeFunc
+	}
+}
+
+/*
+Middleware starts a server span for every request, as the child of the span propagated in its headers, e.g., the
+W3C traceparent header, and passes it to the handler in the request context with the propagated baggage.
+
+The span is named after the method and the route template of the request, e.g., "GET /orders/{id}", recorded with
+Route, SetRoute or WithRoute, and "HTTP GET" while the route is unknown: raw paths are never used, since they would
+make a distinct span name per resource. The span records the HTTP attributes of the request and the status of the
+response, and its status is an error for server errors (5xx) and panics, which are recorded with their stack trace
+and re-panicked.
+
+The logs written with the request context carry the trace_id and span_id of the span, so install the Middleware
+before (outside of) the middlewares logging the requests, e.g., httplog and recovery.
+
+Example usage:
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /orders/{id}", tracing.Route("GET /orders/{id}", ordersHandler))
+
+	handler := tracing.Middleware(
+		tracing.WithTracerProvider(tracerProvider),
+		tracing.WithFilter(func(r *http.Request) bool {
+			return r.URL.Path != "/health" // Skip health checks
+		}),
+	)(httplog.Middleware()(recovery.Middleware()(mux)))
+*/
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.tracerProvider == nil {
+		o.tracerProvider = otel.GetTracerProvider()
+	}
+	if o.propagators == nil {
+		o.propagators = otel.GetTextMapPropagator()
+		if len(o.propagators.Fields()) == 0 {
+			// No global propagators are set: the default one propagates nothing.
+			o.propagators = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+		}
+	}
+	tracer := o.tracerProvider.Tracer(tracerName)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !o.traced(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := o.propagators.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			holder := &routeHolder{}
+			if o.routeFunc != nil {
+				holder.route = o.routeFunc(r)
+			}
+			ctx = newRouteContext(ctx, holder)
+			ctx, span := tracer.Start(ctx, spanName(r.Method, holder.route),
+				oteltrace.WithSpanKind(oteltrace.SpanKindServer),
+				oteltrace.WithAttributes(requestAttributes(r)...),
+			)
+			rw := &responseWriter{ResponseWriter: w}
+
+			defer func() {
+				recovered := recover()
+				finish(span, r.Method, holder.route, rw, recovered)
+				span.End()
+				if recovered != nil {
+					panic(recovered)
+				}
+			}()
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
+
+// traced reports whether r is traced.
+func (o *options) traced(r *http.Request) bool {
+	for _, filter := range o.filters {
+		if !filter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// finish records the route and the response of a request on its span, or the value recovered from the panic of
+// its handler.
+func finish(span oteltrace.Span, method, route string, rw *responseWriter, recovered interface{}) {
+	if route != "" {
+		span.SetName(spanName(method, route))
+		span.SetAttributes(semconv.HTTPRoute(route))
+	}
+
+	if recovered != nil {
+		err, ok := recovered.(error)
+		if !ok {
+			err = fmt.Errorf("%v", recovered)
+		}
+		span.RecordError(err, oteltrace.WithStackTrace(true))
+		span.SetStatus(codes.Error, "panic: "+err.Error())
+		return
+	}
+
+	status := rw.status
+	if status == 0 {
+		// Nothing was written: the http.ResponseWriter sends an empty 200 response.
+		status = http.StatusOK
+	}
+	span.SetAttributes(semconv.HTTPStatusCode(status))
+	if rw.written > 0 {
+		span.SetAttributes(semconv.HTTPResponseContentLength(int(rw.written)))
+	}
+	// Client errors (4xx) are not errors of the server.
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
+// knownMethods are the methods used in the span names. The other ones are replaced with "_OTHER", so that
+// arbitrary methods do not make a distinct span name each.
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// spanName returns the name of the span of a request with method to route, or to an unknown route if route is "".
+func spanName(method, route string) string {
+	if !knownMethods[method] {
+		method = "_OTHER"
+	}
+	if route == "" {
+		return "HTTP " + method
+	}
+	return method + " " + route
+}
+
+// requestAttributes returns the attributes of the span of r.
+func requestAttributes(r *http.Request) []attribute.KeyValue {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	attributes := []attribute.KeyValue{
+		semconv.HTTPMethod(r.Method),
+		semconv.HTTPScheme(scheme),
+		semconv.HTTPTarget(r.URL.RequestURI()),
+	}
+
+	if host, port, err := net.SplitHostPort(r.Host); err == nil {
+		attributes = append(attributes, semconv.NetHostName(host))
+		if portNum, err := strconv.Atoi(port); err == nil {
+			attributes = append(attributes, semconv.NetHostPort(portNum))
+		}
+	} else if r.Host != "" {
+		attributes = append(attributes, semconv.NetHostName(r.Host))
+	}
+
+	if ip, port, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		attributes = append(attributes, semconv.NetSockPeerAddr(ip))
+		if portNum, err := strconv.Atoi(port); err == nil {
+			attributes = append(attributes, semconv.NetSockPeerPort(portNum))
+		}
+	}
+
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		clientIP, _, _ := strings.Cut(forwardedFor, ",")
+		attributes = append(attributes, semconv.HTTPClientIP(strings.TrimSpace(clientIP)))
+	}
+	if userAgent := r.UserAgent(); userAgent != "" {
+		attributes = append(attributes, semconv.UserAgentOriginal(userAgent))
+	}
+	if r.ContentLength > 0 {
+		attributes = append(attributes, semconv.HTTPRequestContentLength(int(r.ContentLength)))
+	}
+	return attributes
+}
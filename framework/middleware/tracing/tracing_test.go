@@ -0,0 +1,254 @@
+package tracing_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/middleware/httplog"
+	"github.com/kittipat1413/go-common/framework/middleware/tracing"
+)
+
+func newTracerProvider() (*tracesdk.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	return tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder)), recorder
+}
+
+func attributes(span tracesdk.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func statusHandler(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("body"))
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	tp, recorder := newTracerProvider()
+	mux := http.NewServeMux()
+	mux.Handle("GET /orders/{id}", tracing.Route("GET /orders/{id}", statusHandler(http.StatusOK)))
+	handler := tracing.Middleware(tracing.WithTracerProvider(tp))(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com:8080/orders/42?expand=items", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("X-Forwarded-For", "203.0.113.1, 10.0.0.2")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /orders/{id}", span.Name())
+	assert.Equal(t, oteltrace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+	assert.False(t, span.Parent().IsValid())
+
+	attrs := attributes(span)
+	assert.Equal(t, "GET", attrs[semconv.HTTPMethodKey].AsString())
+	assert.Equal(t, "/orders/{id}", attrs[semconv.HTTPRouteKey].AsString())
+	assert.Equal(t, "/orders/42?expand=items", attrs[semconv.HTTPTargetKey].AsString())
+	assert.Equal(t, "http", attrs[semconv.HTTPSchemeKey].AsString())
+	assert.Equal(t, "example.com", attrs[semconv.NetHostNameKey].AsString())
+	assert.Equal(t, int64(8080), attrs[semconv.NetHostPortKey].AsInt64())
+	assert.Equal(t, "10.0.0.1", attrs[semconv.NetSockPeerAddrKey].AsString())
+	assert.Equal(t, "203.0.113.1", attrs[semconv.HTTPClientIPKey].AsString())
+	assert.Equal(t, "test-agent", attrs[semconv.UserAgentOriginalKey].AsString())
+	assert.Equal(t, int64(http.StatusOK), attrs[semconv.HTTPStatusCodeKey].AsInt64())
+	assert.Equal(t, int64(len("body")), attrs[semconv.HTTPResponseContentLengthKey].AsInt64())
+}
+
+func TestMiddleware_SpanName(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		opts         []tracing.Option
+		handler      http.Handler
+		expectedName string
+	}{
+		{
+			name:         "unknown route",
+			method:       http.MethodGet,
+			handler:      statusHandler(http.StatusOK),
+			expectedName: "HTTP GET",
+		},
+		{
+			name:         "unknown method",
+			method:       "PURGE",
+			handler:      statusHandler(http.StatusOK),
+			expectedName: "HTTP _OTHER",
+		},
+		{
+			name:   "route set by the handler",
+			method: http.MethodPost,
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tracing.SetRoute(r.Context(), "/orders/{id}/items")
+			}),
+			expectedName: "POST /orders/{id}/items",
+		},
+		{
+			name:   "route function",
+			method: http.MethodDelete,
+			opts: []tracing.Option{tracing.WithRoute(func(r *http.Request) string {
+				return "/orders/{id}"
+			})},
+			handler:      statusHandler(http.StatusNoContent),
+			expectedName: "DELETE /orders/{id}",
+		},
+		{
+			name:   "route with a host",
+			method: http.MethodGet,
+			handler: tracing.Route("GET api.example.com/orders/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			})),
+			expectedName: "GET /orders/{id}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, recorder := newTracerProvider()
+			handler := tracing.Middleware(append(tt.opts, tracing.WithTracerProvider(tp))...)(tt.handler)
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, "/orders/42/items", nil))
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.expectedName, spans[0].Name())
+		})
+	}
+}
+
+func TestMiddleware_Status(t *testing.T) {
+	tests := []struct {
+		name           string
+		handler        http.Handler
+		expectedStatus int64
+		expectedCode   codes.Code
+	}{
+		{name: "nothing written", handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), expectedStatus: 200, expectedCode: codes.Unset},
+		{name: "client error", handler: statusHandler(http.StatusNotFound), expectedStatus: 404, expectedCode: codes.Unset},
+		{name: "server error", handler: statusHandler(http.StatusBadGateway), expectedStatus: 502, expectedCode: codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, recorder := newTracerProvider()
+			handler := tracing.Middleware(tracing.WithTracerProvider(tp))(tt.handler)
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.expectedStatus, attributes(spans[0])[semconv.HTTPStatusCodeKey].AsInt64())
+			assert.Equal(t, tt.expectedCode, spans[0].Status().Code)
+		})
+	}
+}
+
+func TestMiddleware_Panic(t *testing.T) {
+	tp, recorder := newTracerProvider()
+	boom := errors.New("boom")
+	handler := tracing.Middleware(tracing.WithTracerProvider(tp))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(boom)
+	}))
+
+	assert.PanicsWithValue(t, boom, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "panic: boom", spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1)
+	event := spans[0].Events()[0]
+	assert.Equal(t, semconv.ExceptionEventName, event.Name)
+	eventAttrs := make(map[attribute.Key]string)
+	for _, kv := range event.Attributes {
+		eventAttrs[kv.Key] = kv.Value.Emit()
+	}
+	assert.Equal(t, "boom", eventAttrs[semconv.ExceptionMessageKey])
+	assert.Contains(t, eventAttrs[semconv.ExceptionStacktraceKey], "tracing_test.TestMiddleware_Panic")
+}
+
+func TestMiddleware_Propagation(t *testing.T) {
+	tp, recorder := newTracerProvider()
+	var handlerSpan oteltrace.SpanContext
+	var tenant string
+	handler := tracing.Middleware(tracing.WithTracerProvider(tp))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = oteltrace.SpanContextFromContext(r.Context())
+		tenant = baggage.FromContext(r.Context()).Member("tenant").Value()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("baggage", "tenant=acme")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.True(t, span.Parent().IsRemote())
+	assert.Equal(t, span.SpanContext(), handlerSpan, "the handler gets the server span")
+	assert.Equal(t, "acme", tenant)
+}
+
+func TestMiddleware_Filter(t *testing.T) {
+	tp, recorder := newTracerProvider()
+	handler := tracing.Middleware(
+		tracing.WithTracerProvider(tp),
+		tracing.WithFilter(func(r *http.Request) bool { return r.URL.Path != "/health" }),
+	)(statusHandler(http.StatusOK))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Empty(t, recorder.Ended())
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.Len(t, recorder.Ended(), 1)
+}
+
+func TestMiddleware_Logs(t *testing.T) {
+	tp, recorder := newTracerProvider()
+	output := &bytes.Buffer{}
+	log, err := logger.NewLogger(logger.Config{Level: logger.INFO, Output: output})
+	require.NoError(t, err)
+
+	handler := tracing.Middleware(tracing.WithTracerProvider(tp))(httplog.Middleware(httplog.WithLogger(log))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log.Info(r.Context(), "Handling order", nil)
+		}),
+	))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	scanner := bufio.NewScanner(strings.NewReader(output.String()))
+	var lines int
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		assert.Equal(t, spans[0].SpanContext().TraceID().String(), entry[logger.DefaultSJsonFmtTraceIDKey], entry["message"])
+		assert.Equal(t, spans[0].SpanContext().SpanID().String(), entry[logger.DefaultSJsonFmtSpanIDKey], entry["message"])
+		lines++
+	}
+	assert.Equal(t, 2, lines, "the handler and the request logs")
+}
@@ -0,0 +1,76 @@
+package migrate
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// ErrUsage is returned by Command for invalid arguments.
+var ErrUsage = stderrors.New("migrate: usage: up | down [steps] | status")
+
+/*
+Command runs the migration command of the arguments, e.g., of a "migrate" subcommand of the service, and writes its
+output to w:
+  - up applies the migrations not applied yet.
+  - down [steps] rolls back the last steps migrations applied, one by default.
+  - status writes the status of the migrations.
+
+It returns ErrUsage for invalid arguments.
+
+Example usage:
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrator.Command(ctx, os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(ctx, "Migration failed", err, nil)
+		}
+		return
+	}
+*/
+func (m *Migrator) Command(ctx context.Context, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+	switch command := args[0]; {
+	case command == "up" && len(args) == 1:
+		return m.Up(ctx)
+	case command == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("%w: invalid steps %q", ErrUsage, args[1])
+			}
+			steps = n
+		}
+		return m.Down(ctx, steps)
+	case command == "status" && len(args) == 1:
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return writeStatuses(w, statuses)
+	}
+	return ErrUsage
+}
+
+// writeStatuses writes the statuses as a table.
+func writeStatuses(w io.Writer, statuses []Status) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+	for _, status := range statuses {
+		state := "pending"
+		if status.Applied {
+			state = "applied at " + status.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		if status.Unknown {
+			state += " (unknown)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", status.Version, status.Name, state)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,39 @@
+package migrate_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/migrate"
+)
+
+func TestMigrator_Command(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDB()
+	migrator := newMigrator(t, db)
+
+	var out bytes.Buffer
+	require.NoError(t, migrator.Command(ctx, []string{"up"}, &out))
+	assert.Len(t, db.applied, 3)
+
+	assert.ErrorIs(t, migrator.Command(ctx, []string{"down"}, &out), migrate.ErrNoDownMigration)
+	delete(db.applied, 10)
+	require.NoError(t, migrator.Command(ctx, []string{"down"}, &out))
+	assert.Len(t, db.applied, 1)
+	require.NoError(t, migrator.Command(ctx, []string{"down", "5"}, &out))
+	assert.Empty(t, db.applied)
+
+	require.NoError(t, migrator.Command(ctx, []string{"status"}, &out))
+	assert.Equal(t, "VERSION  NAME           STATUS\n"+
+		"1        create_users   pending\n"+
+		"2        create_orders  pending\n"+
+		"10       index_orders   pending\n", out.String())
+
+	for _, args := range [][]string{nil, {"sideways"}, {"down", "zero"}, {"down", "0"}, {"up", "1"}} {
+		assert.ErrorIs(t, migrator.Command(ctx, args, &out), migrate.ErrUsage, args)
+	}
+}
@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/kittipat1413/go-common/framework/dbutil"
+)
+
+/*
+Locker guards the migrations against concurrent runs, e.g., by the replicas of a service starting at once. Lock
+blocks until the lock is acquired on conn, the connection the migrations run on, or ctx is done, and returns the
+function releasing it.
+
+The default Locker takes an advisory lock of the database, named after the migration table: pg_advisory_lock with
+PostgreSQL, and GET_LOCK with MySQL. Set another one with WithLocker, e.g., for a database without advisory locks.
+*/
+type Locker interface {
+	Lock(ctx context.Context, conn *sql.Conn) (unlock func(ctx context.Context) error, err error)
+}
+
+// advisoryLocker is the Locker taking an advisory lock of the database.
+type advisoryLocker struct {
+	dialect dbutil.Dialect
+	name    string // name is the name of the lock, hashed into a key with PostgreSQL.
+}
+
+func (l advisoryLocker) Lock(ctx context.Context, conn *sql.Conn) (func(ctx context.Context) error, error) {
+	if l.dialect == dbutil.MySQL {
+		// GET_LOCK returns 1 once acquired, and waits forever with a negative timeout.
+		var acquired sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", l.name).Scan(&acquired); err != nil {
+			return nil, fmt.Errorf("migrate: lock: %w", err)
+		}
+		if acquired.Int64 != 1 {
+			return nil, fmt.Errorf("%w: %s", ErrLockFailed, l.name)
+		}
+		return func(ctx context.Context) error {
+			_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", l.name)
+			return err
+		}, nil
+	}
+
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(l.name))
+	key := int64(hash.Sum64())
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		return nil, fmt.Errorf("migrate: lock: %w", err)
+	}
+	return func(ctx context.Context) error {
+		_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", key)
+		return err
+	}, nil
+}
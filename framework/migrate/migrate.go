@@ -0,0 +1,347 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/dbutil"
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// DefaultTable is the name of the table recording the migrations applied, unless set with WithTable.
+const DefaultTable = "schema_migrations"
+
+var (
+	// ErrInvalidMigration is returned by New when the migration files are invalid, e.g., two migrations with the
+	// same version, or a down migration without its up migration.
+	ErrInvalidMigration = stderrors.New("migrate: invalid migration")
+	// ErrChecksumMismatch is returned when an applied migration was modified since it was applied.
+	ErrChecksumMismatch = stderrors.New("migrate: checksum mismatch")
+	// ErrNoDownMigration is returned by Down when a migration to roll back has no down migration, or is unknown.
+	ErrNoDownMigration = stderrors.New("migrate: no down migration")
+	// ErrLockFailed is returned when the advisory lock could not be acquired.
+	ErrLockFailed = stderrors.New("migrate: lock failed")
+)
+
+// options holds configuration options for the Migrator.
+type options struct {
+	table   string         // table is the name of the table recording the migrations applied.
+	dir     string         // dir is the directory of the migration files in the source.
+	dialect dbutil.Dialect // dialect is the SQL dialect of the database.
+	locker  Locker         // locker guards the migrations, or the advisory lock of the dialect if nil.
+	logger  logger.Logger  // logger logs the migrations, or the logger of the context if nil.
+}
+
+// Option specifies Migrator configuration options.
+type Option func(*options)
+
+// WithTable sets the name of the table recording the migrations applied. It defaults to DefaultTable.
+func WithTable(table string) Option {
+	return func(opts *options) {
+		if table != "" {
+			opts.table = table
+		}
+	}
+}
+
+// WithDirectory sets the directory of the migration files in the source, e.g., the directory embedded. It defaults
+// to the root of the source.
+func WithDirectory(dir string) Option {
+	return func(opts *options) {
+		if dir != "" {
+			opts.dir = dir
+		}
+	}
+}
+
+// WithDialect sets the SQL dialect of the database. It defaults to dbutil.Postgres.
+func WithDialect(dialect dbutil.Dialect) Option {
+	return func(opts *options) {
+		opts.dialect = dialect
+	}
+}
+
+// WithLocker sets the Locker guarding the migrations. It defaults to an advisory lock of the database.
+func WithLocker(locker Locker) Option {
+	return func(opts *options) {
+		if locker != nil {
+			opts.locker = locker
+		}
+	}
+}
+
+// WithLogger sets the logger of the migrations. It defaults to the logger of the context.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		if l != nil {
+			opts.logger = l
+		}
+	}
+}
+
+// Status is the status of a migration.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time // AppliedAt is the time the migration was applied, if it was.
+	// Unknown tells that the migration was applied, but is not in the source, e.g., by a newer version of the
+	// service.
+	Unknown bool
+}
+
+// appliedMigration is a migration recorded in the migration table.
+type appliedMigration struct {
+	Version   int64     `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+/*
+Migrator applies the SQL migrations of a source, e.g., an embed.FS, to a database, and records them in a migration
+table.
+
+The migrations are files named <version>_<name>.up.sql, and optionally <version>_<name>.down.sql to roll them back,
+e.g., 0001_create_users.up.sql, applied in the order of their versions. Each migration runs in a transaction with
+its record, unless its first line is "-- migrate:no-transaction". The checksum of the applied migrations is
+verified, so that a migration modified after it was applied fails with ErrChecksumMismatch. The migrations applied
+which are not in the source, e.g., by a newer version of the service during a rolling update, are ignored.
+
+The migrations are guarded by a lock, an advisory lock of the database by default, so that the replicas of a
+service starting at once do not apply them concurrently.
+
+Example usage:
+
+	//go:embed migrations/*.sql
+	var migrations embed.FS
+
+	migrator, err := migrate.New(db, migrations, migrate.WithDirectory("migrations"))
+	if err != nil {
+		// Handle error
+	}
+	manager.AddStartup("migrations", migrator.Up, lifecycle.WithTimeout(time.Minute))
+*/
+type Migrator struct {
+	db         *sql.DB
+	opts       options
+	migrations []*migration
+}
+
+// New creates a Migrator applying the migrations of fsys to db. It returns ErrInvalidMigration if the migration
+// files are invalid.
+func New(db *sql.DB, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	o := options{table: DefaultTable, dir: "."}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.locker == nil {
+		o.locker = advisoryLocker{dialect: o.dialect, name: "migrate:" + o.table}
+	}
+	migrations, err := readMigrations(fsys, o.dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, opts: o, migrations: migrations}, nil
+}
+
+// Up applies the migrations not applied yet, in the order of their versions, after verifying the checksums of the
+// migrations applied. It stops at the first migration failing, and returns its error.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sql.Conn, applied map[int64]appliedMigration) error {
+		for _, mig := range m.migrations {
+			if a, ok := applied[mig.version]; ok && a.Checksum != mig.checksum {
+				return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, mig.version, mig.name)
+			}
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, mig, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the last steps migrations applied, in the reverse order of their versions. It returns
+// ErrNoDownMigration, before rolling back any, if one of them has no down migration, or is not in the source.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.locked(ctx, func(conn *sql.Conn, applied map[int64]appliedMigration) error {
+		var statuses []Status
+		for _, status := range m.statuses(applied) {
+			if status.Applied {
+				statuses = append(statuses, status)
+			}
+		}
+		// The last migrations applied are rolled back first.
+		steps = min(max(steps, 0), len(statuses))
+		statuses = statuses[len(statuses)-steps:]
+		rollbacks := make([]*migration, 0, len(statuses))
+		for i := len(statuses) - 1; i >= 0; i-- {
+			mig := m.migration(statuses[i].Version)
+			if mig == nil || !mig.hasDown {
+				return fmt.Errorf("%w: %d_%s", ErrNoDownMigration, statuses[i].Version, statuses[i].Name)
+			}
+			rollbacks = append(rollbacks, mig)
+		}
+		for _, mig := range rollbacks {
+			if err := m.apply(ctx, conn, mig, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status returns the status of the migrations of the source, and of the unknown migrations applied, in the order of
+// their versions.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.locked(ctx, func(_ *sql.Conn, applied map[int64]appliedMigration) error {
+		statuses = m.statuses(applied)
+		return nil
+	})
+	return statuses, err
+}
+
+// locked calls fn with a connection holding the lock of the migrations, and the migrations applied, by version.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, applied map[int64]appliedMigration) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	unlock, err := m.opts.locker.Lock(ctx, conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer func() {
+		if unlockErr := unlock(context.WithoutCancel(ctx)); unlockErr != nil {
+			// The connection still holding the lock is discarded rather than returned to the pool.
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+			err = stderrors.Join(err, fmt.Errorf("migrate: unlock: %w", unlockErr))
+		}
+		conn.Close()
+	}()
+
+	timestamp := "TIMESTAMP"
+	if m.opts.dialect == dbutil.MySQL {
+		timestamp = "DATETIME(6)"
+	}
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version BIGINT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	checksum VARCHAR(64) NOT NULL,
+	applied_at %s NOT NULL
+)`, m.opts.table, timestamp))
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	rows, err := dbutil.Select[appliedMigration](ctx, conn,
+		fmt.Sprintf("SELECT version, name, checksum, applied_at FROM %s", m.opts.table))
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	applied := make(map[int64]appliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return fn(conn, applied)
+}
+
+// statuses returns the status of the migrations of the source and of the unknown migrations applied, by version.
+func (m *Migrator) statuses(applied map[int64]appliedMigration) []Status {
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		a, ok := applied[mig.version]
+		statuses = append(statuses, Status{Version: mig.version, Name: mig.name, Applied: ok, AppliedAt: a.AppliedAt})
+	}
+	for _, a := range applied {
+		if m.migration(a.Version) == nil {
+			statuses = append(statuses, Status{Version: a.Version, Name: a.Name, Applied: true, AppliedAt: a.AppliedAt, Unknown: true})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses
+}
+
+// migration returns the migration of the source with the version, or nil.
+func (m *Migrator) migration(version int64) *migration {
+	i := sort.Search(len(m.migrations), func(i int) bool { return m.migrations[i].version >= version })
+	if i < len(m.migrations) && m.migrations[i].version == version {
+		return m.migrations[i]
+	}
+	return nil
+}
+
+// apply applies the up or down migration, and records it in the migration table, in a transaction unless the
+// migration opts out.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mig *migration, up bool) error {
+	start := time.Now()
+	query, record, args := mig.up, "INSERT INTO %s (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+		[]any{mig.version, mig.name, mig.checksum, start.UTC()}
+	if !up {
+		query, record, args = mig.down, "DELETE FROM %s WHERE version = ?", []any{mig.version}
+	}
+	record = dbutil.Rebind(m.opts.dialect, fmt.Sprintf(record, m.opts.table))
+	run := func(q dbutil.Querier) error {
+		if strings.TrimSpace(query) != "" {
+			if _, err := q.ExecContext(ctx, query); err != nil {
+				return err
+			}
+		}
+		_, err := q.ExecContext(ctx, record, args...)
+		return err
+	}
+
+	var err error
+	if noTransaction(query) {
+		err = run(conn)
+	} else {
+		err = inTx(ctx, conn, run)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate: %d_%s: %w", mig.version, mig.name, err)
+	}
+
+	msg := "Migration applied"
+	if !up {
+		msg = "Migration rolled back"
+	}
+	m.logger(ctx).Info(ctx, msg, logger.Fields{
+		"version":     mig.version,
+		"name":        mig.name,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+	return nil
+}
+
+// inTx calls fn in a transaction of conn, committed if fn succeeds.
+func inTx(ctx context.Context, conn *sql.Conn, fn func(q dbutil.Querier) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return stderrors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+// logger returns the logger of the migrations.
+func (m *Migrator) logger(ctx context.Context) logger.Logger {
+	if m.opts.logger != nil {
+		return m.opts.logger
+	}
+	return logger.FromContext(ctx)
+}
@@ -0,0 +1,304 @@
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/dbutil"
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/migrate"
+)
+
+// fakeDB is a database/sql driver recording the statements executed, and the migrations applied in the migration
+// table.
+type fakeDB struct {
+	mu         sync.Mutex
+	applied    map[int64][]driver.Value // applied are the rows of the migration table, by version.
+	failOn     string                   // failOn fails the statements containing it.
+	statements []string
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{applied: make(map[int64][]driver.Value)}
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+func (db *fakeDB) record(statement string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, statement)
+}
+
+func (db *fakeDB) executed() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]string(nil), db.statements...)
+}
+
+// migrations returns the statements executed, other than the ones managing the lock and the migration table.
+func (db *fakeDB) migrations() []string {
+	var statements []string
+	for _, statement := range db.executed() {
+		if !strings.Contains(strings.ToUpper(statement), "LOCK") && !strings.Contains(statement, "schema_migrations") {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) {
+	c.db.record("BEGIN")
+	return fakeTx(c), nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query)
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if c.db.failOn != "" && strings.Contains(query, c.db.failOn) {
+		return nil, errors.New("syntax error")
+	}
+	switch {
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+		row := make([]driver.Value, len(args))
+		for i, arg := range args {
+			row[i] = arg.Value
+		}
+		c.db.applied[args[0].Value.(int64)] = row
+	case strings.HasPrefix(query, "DELETE FROM schema_migrations"):
+		delete(c.db.applied, args[0].Value.(int64))
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.db.record(query)
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if strings.Contains(query, "GET_LOCK") {
+		return &fakeRows{columns: []string{"acquired"}, values: [][]driver.Value{{int64(1)}}}, nil
+	}
+	rows := &fakeRows{columns: []string{"version", "name", "checksum", "applied_at"}}
+	for _, row := range c.db.applied {
+		rows.values = append(rows.values, row)
+	}
+	sort.Slice(rows.values, func(i, j int) bool { return rows.values[i][0].(int64) < rows.values[j][0].(int64) })
+	return rows, nil
+}
+
+type fakeTx fakeConn
+
+func (tx fakeTx) Commit() error   { tx.db.record("COMMIT"); return nil }
+func (tx fakeTx) Rollback() error { tx.db.record("ROLLBACK"); return nil }
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// source returns the migrations of the tests.
+func source() fstest.MapFS {
+	return fstest.MapFS{
+		"migrations/0001_create_users.up.sql":    {Data: []byte("CREATE TABLE users (id BIGINT)")},
+		"migrations/0001_create_users.down.sql":  {Data: []byte("DROP TABLE users")},
+		"migrations/0002_create_orders.up.sql":   {Data: []byte("CREATE TABLE orders (id BIGINT)")},
+		"migrations/0002_create_orders.down.sql": {Data: []byte("DROP TABLE orders")},
+		"migrations/0010_index_orders.up.sql":    {Data: []byte("-- migrate:no-transaction\nCREATE INDEX CONCURRENTLY orders_id ON orders (id)")},
+		"migrations/README.md":                   {Data: []byte("not a migration")},
+	}
+}
+
+func newMigrator(t *testing.T, db *fakeDB, opts ...migrate.Option) *migrate.Migrator {
+	t.Helper()
+	opts = append([]migrate.Option{migrate.WithDirectory("migrations"), migrate.WithLogger(logger.NewNoopLogger())}, opts...)
+	migrator, err := migrate.New(sql.OpenDB(db), source(), opts...)
+	require.NoError(t, err)
+	return migrator
+}
+
+func TestMigrator_Up(t *testing.T) {
+	t.Run("applies the migrations in order under the lock", func(t *testing.T) {
+		db := newFakeDB()
+		log, recorder := logger.NewTestLogger()
+		migrator := newMigrator(t, db, migrate.WithLogger(log))
+
+		require.NoError(t, migrator.Up(context.Background()))
+		statements := db.executed()
+		assert.Equal(t, "SELECT pg_advisory_lock($1)", statements[0])
+		assert.Equal(t, "SELECT pg_advisory_unlock($1)", statements[len(statements)-1])
+		assert.Equal(t, []string{
+			"BEGIN", "CREATE TABLE users (id BIGINT)", "COMMIT",
+			"BEGIN", "CREATE TABLE orders (id BIGINT)", "COMMIT",
+			"-- migrate:no-transaction\nCREATE INDEX CONCURRENTLY orders_id ON orders (id)",
+		}, db.migrations(), "the migrations opting out run outside a transaction")
+		assert.Contains(t, statements, "INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)")
+		assert.Len(t, db.applied, 3)
+		recorder.AssertLogged(t, logger.INFO, "Migration applied", logger.HasField("version", int64(2)), logger.HasField("name", "create_orders"))
+
+		db.statements = nil
+		require.NoError(t, migrator.Up(context.Background()))
+		assert.Empty(t, db.migrations(), "the migrations are applied once")
+	})
+
+	t.Run("fails on a modified migration", func(t *testing.T) {
+		db := newFakeDB()
+		db.applied[1] = []driver.Value{int64(1), "create_users", "modified", time.Now()}
+		migrator := newMigrator(t, db)
+
+		err := migrator.Up(context.Background())
+		assert.ErrorIs(t, err, migrate.ErrChecksumMismatch)
+		assert.Empty(t, db.migrations())
+	})
+
+	t.Run("stops at the first migration failing", func(t *testing.T) {
+		db := newFakeDB()
+		db.failOn = "CREATE TABLE orders"
+		migrator := newMigrator(t, db)
+
+		err := migrator.Up(context.Background())
+		assert.EqualError(t, err, "migrate: 2_create_orders: syntax error")
+		assert.Equal(t, []string{
+			"BEGIN", "CREATE TABLE users (id BIGINT)", "COMMIT",
+			"BEGIN", "CREATE TABLE orders (id BIGINT)", "ROLLBACK",
+		}, db.migrations())
+		assert.Len(t, db.applied, 1)
+		assert.Equal(t, "SELECT pg_advisory_unlock($1)", db.executed()[len(db.executed())-1], "the lock is released")
+	})
+
+	t.Run("uses the placeholders and the lock of MySQL", func(t *testing.T) {
+		db := newFakeDB()
+		migrator := newMigrator(t, db, migrate.WithDialect(dbutil.MySQL))
+
+		require.NoError(t, migrator.Up(context.Background()))
+		statements := db.executed()
+		assert.Equal(t, "SELECT GET_LOCK(?, -1)", statements[0])
+		assert.Equal(t, "SELECT RELEASE_LOCK(?)", statements[len(statements)-1])
+		assert.Contains(t, statements, "INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)")
+	})
+
+	t.Run("uses the locker set", func(t *testing.T) {
+		db := newFakeDB()
+		locker := &recordingLocker{}
+		migrator := newMigrator(t, db, migrate.WithLocker(locker))
+
+		require.NoError(t, migrator.Up(context.Background()))
+		assert.Equal(t, 1, locker.locks)
+		assert.Equal(t, 1, locker.unlocks)
+		assert.NotContains(t, db.executed(), "SELECT pg_advisory_lock($1)")
+	})
+}
+
+// recordingLocker is a Locker counting the locks and unlocks.
+type recordingLocker struct {
+	locks, unlocks int
+}
+
+func (l *recordingLocker) Lock(ctx context.Context, conn *sql.Conn) (func(ctx context.Context) error, error) {
+	l.locks++
+	return func(ctx context.Context) error {
+		l.unlocks++
+		return nil
+	}, nil
+}
+
+func TestMigrator_Down(t *testing.T) {
+	t.Run("rolls back the last migrations in reverse order", func(t *testing.T) {
+		db := newFakeDB()
+		migrator := newMigrator(t, db)
+		require.NoError(t, migrator.Down(context.Background(), 1), "nothing to roll back")
+
+		db.failOn = "INDEX"
+		require.Error(t, migrator.Up(context.Background()))
+		db.failOn = ""
+		db.statements = nil
+		require.NoError(t, migrator.Down(context.Background(), 5))
+		assert.Equal(t, []string{
+			"BEGIN", "DROP TABLE orders", "COMMIT",
+			"BEGIN", "DROP TABLE users", "COMMIT",
+		}, db.migrations())
+		assert.Contains(t, db.executed(), "DELETE FROM schema_migrations WHERE version = $1")
+		assert.Empty(t, db.applied)
+	})
+
+	t.Run("fails on a migration without down migration", func(t *testing.T) {
+		db := newFakeDB()
+		migrator := newMigrator(t, db)
+		require.NoError(t, migrator.Up(context.Background()))
+		db.statements = nil
+
+		err := migrator.Down(context.Background(), 2)
+		assert.ErrorIs(t, err, migrate.ErrNoDownMigration)
+		assert.Empty(t, db.migrations(), "no migration is rolled back")
+	})
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db := newFakeDB()
+	appliedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	migrator := newMigrator(t, db)
+	db.failOn = "orders"
+	require.Error(t, migrator.Up(context.Background()))
+	db.applied[20] = []driver.Value{int64(20), "from_newer_version", "checksum", appliedAt}
+
+	statuses, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 4)
+	assert.True(t, statuses[0].Applied)
+	assert.Equal(t, "create_users", statuses[0].Name)
+	assert.False(t, statuses[1].Applied)
+	assert.False(t, statuses[2].Applied)
+	assert.Equal(t, migrate.Status{Version: 20, Name: "from_newer_version", Applied: true, AppliedAt: appliedAt, Unknown: true}, statuses[3])
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		source fstest.MapFS
+	}{
+		{"down migration without up migration", fstest.MapFS{"0001_a.down.sql": {Data: []byte("DROP TABLE a")}}},
+		{"two names for a version", fstest.MapFS{
+			"0001_a.up.sql": {Data: []byte("CREATE TABLE a")},
+			"0001_b.up.sql": {Data: []byte("CREATE TABLE b")},
+		}},
+		{"zero version", fstest.MapFS{"0000_a.up.sql": {Data: []byte("CREATE TABLE a")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := migrate.New(sql.OpenDB(newFakeDB()), tt.source)
+			assert.ErrorIs(t, err, migrate.ErrInvalidMigration)
+		})
+	}
+
+	_, err := migrate.New(sql.OpenDB(newFakeDB()), source(), migrate.WithDirectory("missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// noTransactionDirective, on the first line of a migration, runs it outside a transaction, e.g., for the
+// statements which cannot run in one, like CREATE INDEX CONCURRENTLY of PostgreSQL.
+const noTransactionDirective = "-- migrate:no-transaction"
+
+// fileName matches the names of the migration files, e.g., 0001_create_users.up.sql.
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// migration is a migration read from the source.
+type migration struct {
+	version  int64
+	name     string
+	up       string
+	down     string
+	hasDown  bool
+	checksum string // checksum is the hex SHA-256 of the up migration.
+}
+
+// noTransaction reports whether the SQL runs outside a transaction.
+func noTransaction(sql string) bool {
+	return strings.HasPrefix(strings.TrimSpace(sql), noTransactionDirective)
+}
+
+// readMigrations returns the migrations of the directory dir of fsys, ordered by version. The files which are not
+// migrations are ignored.
+func readMigrations(fsys fs.FS, dir string) ([]*migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	byVersion := make(map[int64]*migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%w: invalid version of %s", ErrInvalidMigration, entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("migrate: %w", err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: match[2]}
+			byVersion[version] = m
+		}
+		switch {
+		case m.name != match[2]:
+			return nil, fmt.Errorf("%w: version %d of both %s and %s", ErrInvalidMigration, version, m.name, match[2])
+		case match[3] == "up":
+			m.up = string(content)
+			sum := sha256.Sum256(content)
+			m.checksum = hex.EncodeToString(sum[:])
+		default:
+			m.down, m.hasDown = string(content), true
+		}
+	}
+
+	migrations := make([]*migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.checksum == "" {
+			return nil, fmt.Errorf("%w: no up migration for version %d", ErrInvalidMigration, m.version)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
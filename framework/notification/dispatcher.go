@@ -0,0 +1,163 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/ratelimit"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+// DefaultMaxAttempts is the number of attempts to send a message, including the first one, unless set with
+// WithRetry.
+const DefaultMaxAttempts = 3
+
+// DefaultBackoff is the backoff between the attempts to send a message, unless set with WithRetry: about 1s, then
+// 2s.
+var DefaultBackoff = retry.Exponential(time.Second, 2, 0.2)
+
+// channelOptions holds configuration options for a channel of the Dispatcher.
+type channelOptions struct {
+	retry   []retry.Option    // retry are the options of the retries of the messages.
+	limiter ratelimit.Limiter // limiter limits the rate of the attempts, keyed by channel.
+}
+
+// ChannelOption specifies configuration options of a channel of the Dispatcher.
+type ChannelOption func(*channelOptions)
+
+// WithRetry sets the options of the retries of the messages of the channel, applied after DefaultMaxAttempts and
+// DefaultBackoff. By default, all errors are retried except the permanent ones, such as ErrRejected.
+func WithRetry(opts ...retry.Option) ChannelOption {
+	return func(o *channelOptions) {
+		o.retry = append(o.retry, opts...)
+	}
+}
+
+// WithRateLimit limits the rate of the attempts to send the messages of the channel with limiter, keyed by the
+// channel, e.g., to respect the quota of the provider. The attempts wait for the limiter, until the deadline of
+// their context.
+func WithRateLimit(limiter ratelimit.Limiter) ChannelOption {
+	return func(o *channelOptions) {
+		o.limiter = limiter
+	}
+}
+
+// channel is a channel of the Dispatcher.
+type channel struct {
+	provider Provider
+	opts     channelOptions
+}
+
+// options holds configuration options for the Dispatcher.
+type options struct {
+	channels  map[Channel]*channel // channels are the providers of the channels.
+	templates *Templates           // templates are the templates of SendTemplate.
+}
+
+// Option specifies Dispatcher configuration options.
+type Option func(*options)
+
+// WithProvider sends the messages of ch with provider, replacing the provider set before, if any.
+func WithProvider(ch Channel, provider Provider, opts ...ChannelOption) Option {
+	return func(o *options) {
+		if provider == nil {
+			return
+		}
+		c := &channel{provider: provider}
+		c.opts.retry = []retry.Option{retry.WithMaxAttempts(DefaultMaxAttempts), retry.WithBackoff(DefaultBackoff)}
+		for _, opt := range opts {
+			opt(&c.opts)
+		}
+		o.channels[ch] = c
+	}
+}
+
+// WithTemplates sets the templates of the messages sent with SendTemplate.
+func WithTemplates(templates *Templates) Option {
+	return func(o *options) {
+		if templates != nil {
+			o.templates = templates
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{channels: make(map[Channel]*channel)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.templates == nil {
+		o.templates = NewTemplates()
+	}
+	return o
+}
+
+/*
+Dispatcher sends the messages of every channel with its provider, retrying the attempts failing with a transient
+error, and limiting their rate, per channel. The messages may be rendered from templates.
+
+Example usage:
+
+	templates := notification.NewTemplates()
+	if err := templates.LoadFS(files); err != nil {
+		// Handle error
+	}
+	dispatcher := notification.NewDispatcher(
+		notification.WithTemplates(templates),
+		notification.WithProvider(notification.ChannelEmail, email.NewSES(&sesClient{client: client}, "no-reply@example.com")),
+		notification.WithProvider(notification.ChannelSMS, sms.NewTwilio(accountSID, authToken, "+15005550006"),
+			notification.WithRateLimit(ratelimit.NewTokenBucket(1, 5)),
+			notification.WithRetry(retry.WithMaxAttempts(5)),
+		),
+	)
+
+	id, err := dispatcher.SendTemplate(ctx, notification.Message{
+		Channel: notification.ChannelEmail,
+		To:      []string{user.Email},
+	}, "welcome", user)
+	if err != nil {
+		// Handle error
+	}
+*/
+type Dispatcher struct {
+	opts *options
+}
+
+// NewDispatcher creates a Dispatcher. The channels without a provider set with WithProvider fail with
+// ErrNoProvider.
+func NewDispatcher(opts ...Option) *Dispatcher {
+	return &Dispatcher{opts: newOptions(opts)}
+}
+
+// Send validates msg and sends it with the provider of its channel, and returns the ID given by the provider. The
+// returned error is a *retry.Error if the provider failed.
+func (d *Dispatcher) Send(ctx context.Context, msg Message) (string, error) {
+	if err := msg.Validate(); err != nil {
+		return "", err
+	}
+	c, ok := d.opts.channels[msg.Channel]
+	if !ok {
+		return "", ErrNoProvider.WithField("channel", string(msg.Channel))
+	}
+	return retry.DoValue(ctx, func(ctx context.Context) (string, error) {
+		if c.opts.limiter != nil {
+			if err := c.opts.limiter.Wait(ctx, string(msg.Channel)); err != nil {
+				// Waiting again would not be allowed either.
+				return "", errors.MarkPermanent(err)
+			}
+		}
+		return c.provider.Send(ctx, msg)
+	}, c.opts.retry...)
+}
+
+// SendTemplate renders the template name of the channel of msg with data, see Templates.Render, and sends msg
+// with the Subject, the Text and the HTML rendered.
+func (d *Dispatcher) SendTemplate(ctx context.Context, msg Message, name string, data any) (string, error) {
+	rendered, err := d.opts.templates.Render(msg.Channel, name, data)
+	if err != nil {
+		return "", err
+	}
+	msg.Subject, msg.Text, msg.HTML = rendered.Subject, rendered.Text, rendered.HTML
+	return d.Send(ctx, msg)
+}
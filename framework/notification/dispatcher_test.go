@@ -0,0 +1,125 @@
+package notification_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/notification"
+	"github.com/kittipat1413/go-common/framework/ratelimit"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+var noDelay = notification.WithRetry(retry.WithBackoff(retry.Constant(0)))
+
+func TestDispatcher_Send(t *testing.T) {
+	ctx := context.Background()
+	emails := notification.NewDryRun()
+	dispatcher := notification.NewDispatcher(notification.WithProvider(notification.ChannelEmail, emails))
+
+	msg := notification.Message{Channel: notification.ChannelEmail, To: []string{"ada@example.com"}, Subject: "Hi", Text: "Hello"}
+	id, err := dispatcher.Send(ctx, msg)
+	require.NoError(t, err)
+	assert.Equal(t, "1", id)
+	assert.Equal(t, []notification.Message{msg}, emails.Messages())
+
+	_, err = dispatcher.Send(ctx, notification.Message{Channel: notification.ChannelEmail, To: []string{"ada@example.com"}})
+	assert.ErrorIs(t, err, notification.ErrInvalidMessage)
+	_, err = dispatcher.Send(ctx, notification.Message{Channel: notification.ChannelSMS, To: []string{"+15005550006"}, Text: "Hi"})
+	assert.ErrorIs(t, err, notification.ErrNoProvider)
+	assert.Len(t, emails.Messages(), 1)
+
+	emails.Reset()
+	assert.Empty(t, emails.Messages())
+}
+
+func TestDispatcher_Send_Retry(t *testing.T) {
+	ctx := context.Background()
+	var attempts atomic.Int32
+	provider := notification.ProviderFunc(func(ctx context.Context, msg notification.Message) (string, error) {
+		if attempts.Add(1) < 3 {
+			return "", domain_error.MarkRetryable(notification.ErrSendFailed.Wrap(errors.New("connection reset")))
+		}
+		return "SM123", nil
+	})
+	dispatcher := notification.NewDispatcher(notification.WithProvider(notification.ChannelSMS, provider, noDelay))
+	msg := notification.Message{Channel: notification.ChannelSMS, To: []string{"+15005550006"}, Text: "Hi"}
+
+	id, err := dispatcher.Send(ctx, msg)
+	require.NoError(t, err)
+	assert.Equal(t, "SM123", id)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	attempts.Store(-10)
+	_, err = dispatcher.Send(ctx, msg)
+	assert.ErrorIs(t, err, notification.ErrSendFailed)
+	var retryErr *retry.Error
+	require.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, notification.DefaultMaxAttempts, retryErr.Attempts)
+
+	rejecting := notification.NewDryRun()
+	rejecting.FailWith(notification.ErrRejected.Wrap(errors.New("invalid number")))
+	dispatcher = notification.NewDispatcher(notification.WithProvider(notification.ChannelSMS, rejecting, noDelay,
+		notification.WithRetry(retry.WithMaxAttempts(5)),
+	))
+	_, err = dispatcher.Send(ctx, msg)
+	assert.ErrorIs(t, err, notification.ErrRejected)
+	require.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, 1, retryErr.Attempts, "the rejected messages are not retried")
+	assert.Empty(t, rejecting.Messages())
+}
+
+func TestDispatcher_Send_RateLimit(t *testing.T) {
+	limiter := ratelimit.NewTokenBucket(0.1, 2)
+	defer limiter.Close()
+	push := notification.NewDryRun()
+	dispatcher := notification.NewDispatcher(notification.WithProvider(notification.ChannelPush, push, notification.WithRateLimit(limiter)))
+	msg := notification.Message{Channel: notification.ChannelPush, To: []string{"token"}, Subject: "Hi"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		_, err := dispatcher.Send(ctx, msg)
+		require.NoError(t, err)
+	}
+	_, err := dispatcher.Send(ctx, msg)
+	assert.ErrorIs(t, err, ratelimit.ErrLimitExceeded, "the next token is not available before the deadline")
+	assert.Len(t, push.Messages(), 2)
+}
+
+func TestDispatcher_SendTemplate(t *testing.T) {
+	ctx := context.Background()
+	templates := notification.NewTemplates()
+	require.NoError(t, templates.Add(notification.ChannelEmail, "welcome", notification.Template{
+		Subject: "Welcome, {{.Name}}",
+		Text:    "Hello {{.Name}}",
+	}))
+	emails := notification.NewDryRun()
+	dispatcher := notification.NewDispatcher(
+		notification.WithTemplates(templates),
+		notification.WithProvider(notification.ChannelEmail, emails),
+	)
+
+	_, err := dispatcher.SendTemplate(ctx, notification.Message{
+		Channel: notification.ChannelEmail,
+		To:      []string{"ada@example.com"},
+		From:    "team@example.com",
+	}, "welcome", map[string]string{"Name": "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, []notification.Message{{
+		Channel: notification.ChannelEmail,
+		To:      []string{"ada@example.com"},
+		From:    "team@example.com",
+		Subject: "Welcome, Ada",
+		Text:    "Hello Ada",
+	}}, emails.Messages())
+
+	_, err = dispatcher.SendTemplate(ctx, notification.Message{Channel: notification.ChannelEmail, To: []string{"ada@example.com"}}, "goodbye", nil)
+	assert.ErrorIs(t, err, notification.ErrTemplateNotFound)
+}
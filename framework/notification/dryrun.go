@@ -0,0 +1,69 @@
+package notification
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+/*
+DryRun is a Provider recording the messages instead of sending them, for the tests and the environments which must
+not notify real users. It is safe for concurrent use.
+
+Example usage:
+
+	provider := notification.NewDryRun()
+	dispatcher := notification.NewDispatcher(notification.WithProvider(notification.ChannelEmail, provider))
+
+	// Exercise the code sending the emails
+
+	messages := provider.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, []string{"ada@example.com"}, messages[0].To)
+*/
+type DryRun struct {
+	mutex    sync.Mutex
+	messages []Message
+	err      error
+}
+
+// NewDryRun creates a DryRun.
+func NewDryRun() *DryRun {
+	return &DryRun{}
+}
+
+// Send implements the Provider interface. It records msg and returns its number, "1" for the first message, or
+// the error set with FailWith.
+func (p *DryRun) Send(_ context.Context, msg Message) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.err != nil {
+		return "", p.err
+	}
+	msg.To = slices.Clone(msg.To)
+	p.messages = append(p.messages, msg)
+	return strconv.Itoa(len(p.messages)), nil
+}
+
+// FailWith makes the next messages fail with err, e.g., ErrSendFailed, without recording them, or succeed again if
+// err is nil.
+func (p *DryRun) FailWith(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.err = err
+}
+
+// Messages returns the messages recorded, in the order they were sent.
+func (p *DryRun) Messages() []Message {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return slices.Clone(p.messages)
+}
+
+// Reset forgets the messages recorded.
+func (p *DryRun) Reset() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.messages = nil
+}
@@ -0,0 +1,112 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/kittipat1413/go-common/framework/notification"
+)
+
+// DefaultTimeout is the time limit of the requests of the providers, unless their context has an earlier
+// deadline, or their HTTP client is set.
+const DefaultTimeout = 10 * time.Second
+
+// envelope is the sender and the recipients of an email, parsed.
+type envelope struct {
+	from *mail.Address
+	to   []*mail.Address
+}
+
+// parseEnvelope parses the sender of msg, or defaultFrom, and its recipients, which are addresses such as
+// "ada@example.com" or "Ada Lovelace <ada@example.com>".
+func parseEnvelope(msg notification.Message, defaultFrom string) (*envelope, error) {
+	sender := msg.From
+	if sender == "" {
+		sender = defaultFrom
+	}
+	from, err := mail.ParseAddress(sender)
+	if err != nil {
+		return nil, notification.ErrInvalidMessage.Wrap(fmt.Errorf("the sender %q is invalid: %w", sender, err))
+	}
+	e := &envelope{from: from, to: make([]*mail.Address, len(msg.To))}
+	for i, recipient := range msg.To {
+		if e.to[i], err = mail.ParseAddress(recipient); err != nil {
+			return nil, notification.ErrInvalidMessage.Wrap(fmt.Errorf("the recipient %q is invalid: %w", recipient, err))
+		}
+	}
+	return e, nil
+}
+
+// recipients returns the bare addresses of the recipients.
+func (e *envelope) recipients() []string {
+	addresses := make([]string, len(e.to))
+	for i, to := range e.to {
+		addresses[i] = to.Address
+	}
+	return addresses
+}
+
+// newMessageID returns a unique Message-ID in the domain of the sender.
+func (e *envelope) newMessageID() string {
+	_, domain, _ := strings.Cut(e.from.Address, "@")
+	return "<" + xid.New().String() + "@" + domain + ">"
+}
+
+/*
+buildMessage returns msg as a MIME message, with the text and the HTML bodies as the alternative parts of a
+multipart/alternative body if both are set, encoded as quoted-printable.
+*/
+func buildMessage(msg notification.Message, e *envelope, messageID string, date time.Time) []byte {
+	var buf bytes.Buffer
+	to := make([]string, len(e.to))
+	for i, address := range e.to {
+		to[i] = address.String()
+	}
+	fmt.Fprintf(&buf, "From: %s\r\n", e.from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", messageID)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	switch {
+	case msg.Text != "" && msg.HTML != "":
+		writer := multipart.NewWriter(&buf)
+		fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain; charset=utf-8", msg.Text},
+			{"text/html; charset=utf-8", msg.HTML},
+		} {
+			w, _ := writer.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {part.contentType},
+				"Content-Transfer-Encoding": {"quoted-printable"},
+			})
+			writeQuotedPrintable(w, part.body)
+		}
+		_ = writer.Close()
+	case msg.HTML != "":
+		buf.WriteString("Content-Type: text/html; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+		writeQuotedPrintable(&buf, msg.HTML)
+	default:
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+		writeQuotedPrintable(&buf, msg.Text)
+	}
+	return buf.Bytes()
+}
+
+// writeQuotedPrintable writes body to w encoded as quoted-printable.
+func writeQuotedPrintable(w io.Writer, body string) {
+	qp := quotedprintable.NewWriter(w)
+	_, _ = qp.Write([]byte(body))
+	_ = qp.Close()
+}
@@ -0,0 +1,145 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/httpclient"
+	"github.com/kittipat1413/go-common/framework/notification"
+)
+
+// DefaultSendGridBaseURL is the base URL of the SendGrid API, unless set with WithSendGridBaseURL.
+const DefaultSendGridBaseURL = "https://api.sendgrid.com"
+
+// sendGridOptions holds configuration options for the SendGrid provider.
+type sendGridOptions struct {
+	client  *http.Client // client sends the requests to the API.
+	baseURL string       // baseURL is the base URL of the API.
+}
+
+// SendGridOption specifies SendGrid provider configuration options.
+type SendGridOption func(*sendGridOptions)
+
+// WithSendGridHTTPClient sets the client sending the requests to the API, e.g., created with httpclient.New. It
+// defaults to an httpclient with DefaultTimeout.
+func WithSendGridHTTPClient(client *http.Client) SendGridOption {
+	return func(opts *sendGridOptions) {
+		if client != nil {
+			opts.client = client
+		}
+	}
+}
+
+// WithSendGridBaseURL sets the base URL of the API, e.g., "https://api.eu.sendgrid.com" for the EU regional
+// subusers.
+func WithSendGridBaseURL(baseURL string) SendGridOption {
+	return func(opts *sendGridOptions) {
+		if baseURL != "" {
+			opts.baseURL = strings.TrimSuffix(baseURL, "/")
+		}
+	}
+}
+
+/*
+SendGrid is a notification.Provider sending the emails with the v3 Mail Send API of SendGrid. Send returns the
+X-Message-Id of the response.
+
+Example usage:
+
+	provider := email.NewSendGrid(apiKey, "Example <no-reply@example.com>")
+	dispatcher := notification.NewDispatcher(notification.WithProvider(notification.ChannelEmail, provider))
+*/
+type SendGrid struct {
+	apiKey string
+	from   string
+	opts   *sendGridOptions
+}
+
+var _ notification.Provider = (*SendGrid)(nil)
+
+// NewSendGrid creates a SendGrid provider authenticated with apiKey. from is the sender of the emails without one,
+// which must be a verified sender of SendGrid.
+func NewSendGrid(apiKey, from string, opts ...SendGridOption) *SendGrid {
+	o := &sendGridOptions{baseURL: DefaultSendGridBaseURL}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.client == nil {
+		o.client = httpclient.New(httpclient.WithTimeout(DefaultTimeout))
+	}
+	return &SendGrid{apiKey: apiKey, from: from, opts: o}
+}
+
+// sendGridAddress is an email address of the Mail Send API.
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+// sendGridContent is a body of the Mail Send API.
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// sendGridPersonalization is the recipients of an email of the Mail Send API.
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+// sendGridMail is the request body of the Mail Send API.
+type sendGridMail struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+// Send implements the notification.Provider interface.
+func (p *SendGrid) Send(ctx context.Context, msg notification.Message) (string, error) {
+	e, err := parseEnvelope(msg, p.from)
+	if err != nil {
+		return "", err
+	}
+	personalization := sendGridPersonalization{To: make([]sendGridAddress, len(e.to))}
+	for i, to := range e.to {
+		personalization.To[i] = sendGridAddress{Email: to.Address, Name: to.Name}
+	}
+	mail := sendGridMail{
+		Personalizations: []sendGridPersonalization{personalization},
+		From:             sendGridAddress{Email: e.from.Address, Name: e.from.Name},
+		Subject:          msg.Subject,
+	}
+	// The text body must come before the HTML body.
+	if msg.Text != "" {
+		mail.Content = append(mail.Content, sendGridContent{Type: "text/plain", Value: msg.Text})
+	}
+	if msg.HTML != "" {
+		mail.Content = append(mail.Content, sendGridContent{Type: "text/html", Value: msg.HTML})
+	}
+	body, err := json.Marshal(mail)
+	if err != nil {
+		return "", errors.MarkPermanent(fmt.Errorf("email: failed to marshal the email: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.opts.baseURL+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return "", errors.MarkPermanent(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.opts.client.Do(req)
+	if err != nil {
+		return "", errors.MarkRetryable(notification.ErrSendFailed.Wrap(err))
+	}
+	defer resp.Body.Close()
+	if err := notification.CheckResponse(resp); err != nil {
+		return "", err
+	}
+	return resp.Header.Get("X-Message-Id"), nil
+}
@@ -0,0 +1,60 @@
+package email_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/notification"
+	"github.com/kittipat1413/go-common/framework/notification/email"
+)
+
+func TestSendGrid(t *testing.T) {
+	ctx := context.Background()
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/mail/send", r.URL.Path)
+		assert.Equal(t, "Bearer SG.key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		if request["subject"] == "Invalid" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors": [{"message": "invalid"}]}`))
+			return
+		}
+		w.Header().Set("X-Message-Id", "sg-123")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	provider := email.NewSendGrid("SG.key", "Example <no-reply@example.com>", email.WithSendGridBaseURL(server.URL+"/"))
+
+	id, err := provider.Send(ctx, notification.Message{
+		Channel: notification.ChannelEmail,
+		To:      []string{"Ada <ada@example.com>", "bob@example.com"},
+		Subject: "Hi",
+		Text:    "Hello",
+		HTML:    "<p>Hello</p>",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "sg-123", id)
+	assert.Equal(t, map[string]any{
+		"personalizations": []any{map[string]any{"to": []any{
+			map[string]any{"email": "ada@example.com", "name": "Ada"},
+			map[string]any{"email": "bob@example.com"},
+		}}},
+		"from":    map[string]any{"email": "no-reply@example.com", "name": "Example"},
+		"subject": "Hi",
+		"content": []any{
+			map[string]any{"type": "text/plain", "value": "Hello"},
+			map[string]any{"type": "text/html", "value": "<p>Hello</p>"},
+		},
+	}, request)
+
+	_, err = provider.Send(ctx, notification.Message{Channel: notification.ChannelEmail, To: []string{"ada@example.com"}, Subject: "Invalid", Text: "Hello"})
+	assert.ErrorIs(t, err, notification.ErrRejected)
+	assert.Contains(t, err.Error(), "invalid")
+}
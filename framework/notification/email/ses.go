@@ -0,0 +1,81 @@
+package email
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/notification"
+)
+
+/*
+SESClient is the Amazon SES operation used by the SES provider, sending a raw MIME email. It returns
+notification.ErrRejected for the emails rejected by SES. Wrap a *sesv2.Client of
+github.com/aws/aws-sdk-go-v2/service/sesv2 to use it:
+
+	type sesClient struct {
+		client *sesv2.Client
+	}
+
+	func (c *sesClient) SendRawEmail(ctx context.Context, from string, to []string, raw []byte) (string, error) {
+		out, err := c.client.SendEmail(ctx, &sesv2.SendEmailInput{
+			FromEmailAddress: aws.String(from),
+			Destination:      &types.Destination{ToAddresses: to},
+			Content:          &types.EmailContent{Raw: &types.RawMessage{Data: raw}},
+		})
+		var rejected *types.MessageRejected
+		var notVerified *types.MailFromDomainNotVerifiedException
+		if errors.As(err, &rejected) || errors.As(err, &notVerified) {
+			return "", notification.ErrRejected.Wrap(err)
+		}
+		if err != nil {
+			return "", err
+		}
+		return aws.ToString(out.MessageId), nil
+	}
+*/
+type SESClient interface {
+	// SendRawEmail sends the MIME message raw from the address from to the addresses to, and returns the ID given
+	// to the email by SES.
+	SendRawEmail(ctx context.Context, from string, to []string, raw []byte) (string, error)
+}
+
+/*
+SES is a notification.Provider sending the emails with Amazon SES, as raw MIME messages with a text and an HTML body
+if both are set. Send returns the message ID of SES.
+
+Example usage:
+
+	provider := email.NewSES(&sesClient{client: sesv2.NewFromConfig(cfg)}, "Example <no-reply@example.com>")
+	dispatcher := notification.NewDispatcher(notification.WithProvider(notification.ChannelEmail, provider))
+*/
+type SES struct {
+	client SESClient
+	from   string
+}
+
+var _ notification.Provider = (*SES)(nil)
+
+// NewSES creates an SES provider. from is the sender of the emails without one, whose address or domain must be
+// verified in SES.
+func NewSES(client SESClient, from string) *SES {
+	return &SES{client: client, from: from}
+}
+
+// Send implements the notification.Provider interface.
+func (p *SES) Send(ctx context.Context, msg notification.Message) (string, error) {
+	e, err := parseEnvelope(msg, p.from)
+	if err != nil {
+		return "", err
+	}
+	raw := buildMessage(msg, e, e.newMessageID(), time.Now())
+	id, err := p.client.SendRawEmail(ctx, e.from.Address, e.recipients(), raw)
+	if err != nil {
+		if stderrors.Is(err, notification.ErrRejected) {
+			return "", err
+		}
+		return "", errors.MarkRetryable(notification.ErrSendFailed.Wrap(err))
+	}
+	return id, nil
+}
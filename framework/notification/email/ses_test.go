@@ -0,0 +1,68 @@
+package email_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/mail"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/notification"
+	"github.com/kittipat1413/go-common/framework/notification/email"
+)
+
+// fakeSESClient records the emails sent.
+type fakeSESClient struct {
+	from string
+	to   []string
+	raw  []byte
+	err  error
+}
+
+func (c *fakeSESClient) SendRawEmail(_ context.Context, from string, to []string, raw []byte) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	c.from, c.to, c.raw = from, to, raw
+	return "0100018f-ses", nil
+}
+
+func TestSES(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeSESClient{}
+	provider := email.NewSES(client, "Example <no-reply@example.com>")
+	msg := notification.Message{
+		Channel: notification.ChannelEmail,
+		To:      []string{"Ada <ada@example.com>"},
+		Subject: "Receipt",
+		HTML:    "<p>Thanks</p>",
+	}
+
+	id, err := provider.Send(ctx, msg)
+	require.NoError(t, err)
+	assert.Equal(t, "0100018f-ses", id)
+	assert.Equal(t, "no-reply@example.com", client.from)
+	assert.Equal(t, []string{"ada@example.com"}, client.to)
+	message, err := mail.ReadMessage(bytes.NewReader(client.raw))
+	require.NoError(t, err)
+	assert.Equal(t, "Receipt", message.Header.Get("Subject"))
+	assert.Equal(t, "text/html; charset=utf-8", message.Header.Get("Content-Type"))
+	body, err := io.ReadAll(message.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "<p>Thanks</p>", string(body))
+
+	client.err = notification.ErrRejected.Wrap(errors.New("MessageRejected"))
+	_, err = provider.Send(ctx, msg)
+	assert.ErrorIs(t, err, notification.ErrRejected)
+	assert.True(t, domain_error.IsPermanent(err))
+
+	client.err = errors.New("throttled")
+	_, err = provider.Send(ctx, msg)
+	assert.ErrorIs(t, err, notification.ErrSendFailed)
+	assert.True(t, domain_error.Retryable(err))
+}
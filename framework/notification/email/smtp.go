@@ -0,0 +1,170 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	stderrors "errors"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/notification"
+)
+
+// smtpOptions holds configuration options for the SMTP provider.
+type smtpOptions struct {
+	tlsConfig   *tls.Config // tlsConfig configures the TLS connections, with the host of the server by default.
+	implicitTLS bool        // implicitTLS connects with TLS instead of upgrading the connection with STARTTLS.
+	helloName   string      // helloName is the host name sent in the HELO/EHLO command.
+}
+
+// SMTPOption specifies SMTP provider configuration options.
+type SMTPOption func(*smtpOptions)
+
+// WithTLSConfig sets the configuration of the TLS connections, e.g., with the root CAs of the server.
+func WithTLSConfig(config *tls.Config) SMTPOption {
+	return func(opts *smtpOptions) {
+		if config != nil {
+			opts.tlsConfig = config
+		}
+	}
+}
+
+// WithImplicitTLS connects to the server with TLS, e.g., on port 465, instead of upgrading the connection with
+// STARTTLS when the server supports it.
+func WithImplicitTLS() SMTPOption {
+	return func(opts *smtpOptions) {
+		opts.implicitTLS = true
+	}
+}
+
+// WithHelloName sets the host name sent in the HELO/EHLO command, "localhost" by default.
+func WithHelloName(name string) SMTPOption {
+	return func(opts *smtpOptions) {
+		if name != "" {
+			opts.helloName = name
+		}
+	}
+}
+
+/*
+SMTP is a notification.Provider sending the emails to an SMTP server, upgrading the connection with STARTTLS when
+the server supports it. The emails have a text and an HTML body if both are set. Send returns the Message-ID of
+the email.
+
+The emails refused by the server with a permanent (5xx) error fail with notification.ErrRejected, and the
+others fail with notification.ErrSendFailed.
+
+Example usage:
+
+	provider := email.NewSMTP("smtp.example.com:587",
+		smtp.PlainAuth("", username, password, "smtp.example.com"),
+		"Example <no-reply@example.com>",
+	)
+	dispatcher := notification.NewDispatcher(notification.WithProvider(notification.ChannelEmail, provider))
+*/
+type SMTP struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from string
+	opts *smtpOptions
+}
+
+var _ notification.Provider = (*SMTP)(nil)
+
+// NewSMTP creates an SMTP provider sending the emails to the server at addr, "host:port", authenticated with
+// auth, if not nil. from is the sender of the emails without one.
+func NewSMTP(addr string, auth smtp.Auth, from string, opts ...SMTPOption) *SMTP {
+	o := &smtpOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	if o.tlsConfig == nil {
+		o.tlsConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	}
+	return &SMTP{addr: addr, host: host, auth: auth, from: from, opts: o}
+}
+
+// Send implements the notification.Provider interface.
+func (p *SMTP) Send(ctx context.Context, msg notification.Message) (string, error) {
+	e, err := parseEnvelope(msg, p.from)
+	if err != nil {
+		return "", err
+	}
+	messageID := e.newMessageID()
+	if err := p.send(ctx, e, buildMessage(msg, e, messageID, time.Now())); err != nil {
+		var protocolErr *textproto.Error
+		if stderrors.As(err, &protocolErr) && protocolErr.Code >= 500 {
+			return "", notification.ErrRejected.Wrap(err)
+		}
+		return "", errors.MarkRetryable(notification.ErrSendFailed.Wrap(err))
+	}
+	return messageID, nil
+}
+
+// send sends the MIME message body to the recipients of e in an SMTP session, closed when ctx is done.
+func (p *SMTP) send(ctx context.Context, e *envelope, body []byte) error {
+	dialer := &net.Dialer{Timeout: DefaultTimeout}
+	var conn net.Conn
+	var err error
+	if p.opts.implicitTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: p.opts.tlsConfig}).DialContext(ctx, "tcp", p.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", p.addr)
+	}
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+	_ = conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, p.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if p.opts.helloName != "" {
+		if err := client.Hello(p.opts.helloName); err != nil {
+			return err
+		}
+	}
+	if ok, _ := client.Extension("STARTTLS"); ok && !p.opts.implicitTLS {
+		if err := client.StartTLS(p.opts.tlsConfig); err != nil {
+			return err
+		}
+	}
+	if p.auth != nil {
+		if err := client.Auth(p.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(e.from.Address); err != nil {
+		return err
+	}
+	for _, to := range e.recipients() {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
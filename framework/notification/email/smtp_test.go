@@ -0,0 +1,191 @@
+package email_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/notification"
+	"github.com/kittipat1413/go-common/framework/notification/email"
+)
+
+// smtpServer is an SMTP server recording the emails, which rejects the recipients starting with "unknown", and
+// fails temporarily the senders starting with "busy".
+type smtpServer struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	auth     string
+	from     string
+	to       []string
+	data     string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpServer{listener: listener}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *smtpServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimSpace(line)
+		s.mutex.Lock()
+		switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(command, "AUTH PLAIN "))
+			s.auth = string(credentials)
+			reply("235 Authenticated")
+		case "MAIL":
+			s.from = strings.TrimSuffix(strings.TrimPrefix(command, "MAIL FROM:<"), ">")
+			if strings.HasPrefix(s.from, "busy") {
+				reply("451 Try again later")
+			} else {
+				reply("250 OK")
+			}
+		case "RCPT":
+			to := strings.TrimSuffix(strings.TrimPrefix(command, "RCPT TO:<"), ">")
+			if strings.HasPrefix(to, "unknown") {
+				reply("550 No such user")
+			} else {
+				s.to = append(s.to, to)
+				reply("250 OK")
+			}
+		case "DATA":
+			reply("354 Go ahead")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.data = data.String()
+			reply("250 Queued")
+		case "QUIT":
+			reply("221 Bye")
+			s.mutex.Unlock()
+			return
+		default:
+			reply("250 OK")
+		}
+		s.mutex.Unlock()
+	}
+}
+
+func (s *smtpServer) message(t *testing.T) *mail.Message {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	message, err := mail.ReadMessage(strings.NewReader(s.data))
+	require.NoError(t, err)
+	return message
+}
+
+func TestSMTP(t *testing.T) {
+	ctx := context.Background()
+	server := newSMTPServer(t)
+	provider := email.NewSMTP(server.listener.Addr().String(),
+		smtp.PlainAuth("", "user", "password", "127.0.0.1"),
+		"Example <no-reply@example.com>",
+	)
+
+	id, err := provider.Send(ctx, notification.Message{
+		Channel: notification.ChannelEmail,
+		To:      []string{"Ada Lovelace <ada@example.com>", "bob@example.com"},
+		Subject: "Héllo",
+		Text:    "Hello Ada",
+		HTML:    "<p>Hello Ada</p>",
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `^<\w+@example\.com>$`, id)
+	assert.Equal(t, "\x00user\x00password", server.auth)
+	assert.Equal(t, "no-reply@example.com", server.from)
+	assert.Equal(t, []string{"ada@example.com", "bob@example.com"}, server.to)
+
+	message := server.message(t)
+	assert.Equal(t, `"Example" <no-reply@example.com>`, message.Header.Get("From"))
+	assert.Equal(t, `"Ada Lovelace" <ada@example.com>, <bob@example.com>`, message.Header.Get("To"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Héllo", subject)
+	assert.Equal(t, id, message.Header.Get("Message-ID"))
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	parts := multipart.NewReader(message.Body, params["boundary"])
+	for _, expected := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", "Hello Ada"},
+		{"text/html; charset=utf-8", "<p>Hello Ada</p>"},
+	} {
+		part, err := parts.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, expected.contentType, part.Header.Get("Content-Type"))
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		assert.Equal(t, expected.body, string(body), "the part is decoded from quoted-printable")
+	}
+}
+
+func TestSMTP_Errors(t *testing.T) {
+	ctx := context.Background()
+	server := newSMTPServer(t)
+	provider := email.NewSMTP(server.listener.Addr().String(), nil, "no-reply@example.com")
+	msg := notification.Message{Channel: notification.ChannelEmail, To: []string{"unknown@example.com"}, Subject: "Hi", Text: "Hello"}
+
+	_, err := provider.Send(ctx, msg)
+	assert.ErrorIs(t, err, notification.ErrRejected, "a permanent error of the server")
+	assert.True(t, domain_error.IsPermanent(err))
+
+	msg.To, msg.From = []string{"ada@example.com"}, "busy@example.com"
+	_, err = provider.Send(ctx, msg)
+	assert.ErrorIs(t, err, notification.ErrSendFailed, "a temporary error of the server")
+	assert.True(t, domain_error.Retryable(err))
+
+	msg.From = "not an address"
+	_, err = provider.Send(ctx, msg)
+	assert.ErrorIs(t, err, notification.ErrInvalidMessage)
+
+	server.listener.Close()
+	msg.From = ""
+	_, err = provider.Send(ctx, msg)
+	assert.ErrorIs(t, err, notification.ErrSendFailed, "the server is unreachable")
+	assert.True(t, domain_error.Retryable(err))
+}
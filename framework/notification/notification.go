@@ -0,0 +1,151 @@
+package notification
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+)
+
+// Channel is the medium a message is sent on.
+type Channel string
+
+// Channels of the messages.
+const (
+	ChannelEmail Channel = "email"
+	ChannelSMS   Channel = "sms"
+	ChannelPush  Channel = "push"
+)
+
+// Codes of the errors returned by the Dispatcher and the providers.
+const (
+	CodeInvalidMessage   = "notification_invalid_message"
+	CodeNoProvider       = "notification_no_provider"
+	CodeTemplateNotFound = "notification_template_not_found"
+	CodeRejected         = "notification_rejected"
+	CodeSendFailed       = "notification_send_failed"
+)
+
+var (
+	// ErrInvalidMessage is returned for the messages missing a recipient or a content, see Message.Validate.
+	ErrInvalidMessage = errors.InvalidArgument(CodeInvalidMessage, "invalid notification message")
+	// ErrNoProvider is returned by the Dispatcher for the channels without a provider.
+	ErrNoProvider = errors.Internal(CodeNoProvider, "no notification provider for the channel")
+	// ErrTemplateNotFound is returned for the templates not added to the Templates.
+	ErrTemplateNotFound = errors.NotFound(CodeTemplateNotFound, "notification template not found")
+	// ErrRejected is returned by the providers for the messages rejected by their service, e.g., for an invalid
+	// recipient, which are not retried.
+	ErrRejected = errors.InvalidArgument(CodeRejected, "notification rejected by the provider")
+	// ErrSendFailed is returned by the providers for the messages not sent because of a network error or an error
+	// of their service, which are retried.
+	ErrSendFailed = errors.Unavailable(CodeSendFailed, "notification sending failed")
+)
+
+// Message is a notification sent to its recipients on a channel.
+type Message struct {
+	Channel Channel           // Channel is the channel the message is sent on.
+	To      []string          // To are the email addresses, the phone number in E.164 format or the device token.
+	From    string            // From is the sender, or the default sender of the provider if empty.
+	Subject string            // Subject is the subject of an email, or the title of a push notification.
+	Text    string            // Text is the plain text body of the message.
+	HTML    string            // HTML is the HTML body of an email, sent along with Text if both are set.
+	Data    map[string]string // Data is the custom data of a push notification.
+}
+
+/*
+Validate returns ErrInvalidMessage if the message cannot be sent on its channel:
+  - Emails need at least one recipient, a subject, and a text or HTML body.
+  - SMS need a single recipient and a text.
+  - Push notifications need a single recipient, and a subject, a text or some data.
+
+The SMS and the push notifications have a single recipient so that retrying a message does not send it again to
+the recipients it was sent to.
+*/
+func (m *Message) Validate() error {
+	for _, to := range m.To {
+		if strings.TrimSpace(to) == "" {
+			return ErrInvalidMessage.Wrap(stderrors.New("a recipient is empty"))
+		}
+	}
+	switch m.Channel {
+	case ChannelEmail:
+		switch {
+		case len(m.To) == 0:
+			return ErrInvalidMessage.Wrap(stderrors.New("the email has no recipients"))
+		case m.Subject == "":
+			return ErrInvalidMessage.Wrap(stderrors.New("the email has no subject"))
+		case m.Text == "" && m.HTML == "":
+			return ErrInvalidMessage.Wrap(stderrors.New("the email has no body"))
+		}
+	case ChannelSMS:
+		switch {
+		case len(m.To) != 1:
+			return ErrInvalidMessage.Wrap(fmt.Errorf("the SMS has %d recipients instead of 1", len(m.To)))
+		case m.Text == "":
+			return ErrInvalidMessage.Wrap(stderrors.New("the SMS has no text"))
+		}
+	case ChannelPush:
+		switch {
+		case len(m.To) != 1:
+			return ErrInvalidMessage.Wrap(fmt.Errorf("the push notification has %d recipients instead of 1", len(m.To)))
+		case m.Subject == "" && m.Text == "" && len(m.Data) == 0:
+			return ErrInvalidMessage.Wrap(stderrors.New("the push notification has no content"))
+		}
+	default:
+		return ErrInvalidMessage.Wrap(fmt.Errorf("the channel %q is unknown", m.Channel))
+	}
+	return nil
+}
+
+/*
+Provider sends the messages of a channel through a service, e.g., email.NewSMTP, sms.NewTwilio or push.NewFCM.
+Send returns the ID given to the message by the service, if any. The messages rejected by the service, which would
+be rejected again, fail with ErrRejected, and the ones which may be sent by a later attempt fail with ErrSendFailed.
+*/
+type Provider interface {
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// ProviderFunc is a function implementing Provider.
+type ProviderFunc func(ctx context.Context, msg Message) (string, error)
+
+// Send implements the Provider interface.
+func (f ProviderFunc) Send(ctx context.Context, msg Message) (string, error) {
+	return f(ctx, msg)
+}
+
+// maxErrorBodySize is the size of the beginning of the error responses included in the errors of CheckResponse.
+const maxErrorBodySize = 512
+
+/*
+CheckResponse returns the error of the response of the HTTP API of a provider, or nil for a 2xx status. The client
+errors, other than 408 and 429, are ErrRejected, and the other statuses are ErrSendFailed, retryable. The errors
+include the beginning of the body, which CheckResponse reads.
+
+Example usage:
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", errors.MarkRetryable(notification.ErrSendFailed.Wrap(err))
+	}
+	defer resp.Body.Close()
+	if err := notification.CheckResponse(resp); err != nil {
+		return "", err
+	}
+*/
+func CheckResponse(resp *http.Response) error {
+	status := resp.StatusCode
+	if status >= 200 && status < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	err := fmt.Errorf("the provider responded %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	if status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests {
+		return ErrRejected.Wrap(err)
+	}
+	return errors.MarkRetryable(ErrSendFailed.Wrap(err))
+}
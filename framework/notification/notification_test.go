@@ -0,0 +1,70 @@
+package notification_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/notification"
+)
+
+func TestMessage_Validate(t *testing.T) {
+	valid := []notification.Message{
+		{Channel: notification.ChannelEmail, To: []string{"a@example.com", "b@example.com"}, Subject: "Hi", Text: "Hello"},
+		{Channel: notification.ChannelEmail, To: []string{"a@example.com"}, Subject: "Hi", HTML: "<p>Hello</p>"},
+		{Channel: notification.ChannelSMS, To: []string{"+15005550006"}, Text: "Your code is 1234"},
+		{Channel: notification.ChannelPush, To: []string{"token"}, Subject: "New message"},
+		{Channel: notification.ChannelPush, To: []string{"token"}, Data: map[string]string{"sync": "1"}},
+	}
+	for _, msg := range valid {
+		assert.NoError(t, msg.Validate(), msg)
+	}
+
+	invalid := []notification.Message{
+		{Channel: notification.ChannelEmail, Subject: "Hi", Text: "Hello"},
+		{Channel: notification.ChannelEmail, To: []string{"a@example.com", " "}, Subject: "Hi", Text: "Hello"},
+		{Channel: notification.ChannelEmail, To: []string{"a@example.com"}, Text: "Hello"},
+		{Channel: notification.ChannelEmail, To: []string{"a@example.com"}, Subject: "Hi"},
+		{Channel: notification.ChannelSMS, To: []string{"+15005550006", "+15005550007"}, Text: "Hello"},
+		{Channel: notification.ChannelSMS, To: []string{"+15005550006"}, Subject: "Hello"},
+		{Channel: notification.ChannelPush, Subject: "Hello"},
+		{Channel: notification.ChannelPush, To: []string{"token"}},
+		{Channel: "fax", To: []string{"+15005550006"}, Text: "Hello"},
+	}
+	for _, msg := range invalid {
+		err := msg.Validate()
+		assert.ErrorIs(t, err, notification.ErrInvalidMessage, msg)
+		assert.True(t, domain_error.IsPermanent(err), msg)
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	for _, tc := range []struct {
+		status    int
+		expected  error
+		permanent bool
+	}{
+		{http.StatusAccepted, nil, false},
+		{http.StatusBadRequest, notification.ErrRejected, true},
+		{http.StatusNotFound, notification.ErrRejected, true},
+		{http.StatusTooManyRequests, notification.ErrSendFailed, false},
+		{http.StatusRequestTimeout, notification.ErrSendFailed, false},
+		{http.StatusBadGateway, notification.ErrSendFailed, false},
+	} {
+		recorder := httptest.NewRecorder()
+		recorder.WriteHeader(tc.status)
+		recorder.WriteString(`{"error": "details"}`)
+		err := notification.CheckResponse(recorder.Result())
+		if tc.expected == nil {
+			assert.NoError(t, err)
+			continue
+		}
+		assert.ErrorIs(t, err, tc.expected, tc.status)
+		assert.Contains(t, err.Error(), `{"error": "details"}`)
+		assert.Equal(t, tc.permanent, domain_error.IsPermanent(err), tc.status)
+		assert.Equal(t, !tc.permanent, domain_error.Retryable(err), tc.status)
+	}
+}
@@ -0,0 +1,131 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/notification"
+)
+
+const (
+	// DefaultTimeout is the time limit of the requests to the API, unless their context has an earlier deadline.
+	// It is set on the HTTP clients without a timeout.
+	DefaultTimeout = 10 * time.Second
+	// DefaultFCMBaseURL is the base URL of the Firebase Cloud Messaging API, unless set with WithBaseURL.
+	DefaultFCMBaseURL = "https://fcm.googleapis.com"
+)
+
+// options holds configuration options for the providers.
+type options struct {
+	baseURL string // baseURL is the base URL of the API.
+}
+
+// Option specifies provider configuration options.
+type Option func(*options)
+
+// WithBaseURL sets the base URL of the API, e.g., of a mock server.
+func WithBaseURL(baseURL string) Option {
+	return func(opts *options) {
+		if baseURL != "" {
+			opts.baseURL = strings.TrimSuffix(baseURL, "/")
+		}
+	}
+}
+
+/*
+FCM is a notification.Provider sending the push notifications with the HTTP v1 API of Firebase Cloud Messaging,
+to the registration token of a device. The Subject and the Text of the messages are the title and the body of the
+notification, and their Data is the data of the message. Send returns the name of the message given by FCM.
+
+The messages to the tokens not registered anymore fail with notification.ErrRejected: remove them from the
+devices of the user.
+
+The HTTP client must authorize the requests with an OAuth 2.0 token of a service account of the project, with the
+https://www.googleapis.com/auth/firebase.messaging scope, e.g., created with golang.org/x/oauth2/google:
+
+	client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/firebase.messaging")
+	if err != nil {
+		// Handle error
+	}
+	provider := push.NewFCM("my-project", client)
+	dispatcher := notification.NewDispatcher(notification.WithProvider(notification.ChannelPush, provider))
+*/
+type FCM struct {
+	projectID string
+	client    *http.Client
+	opts      *options
+}
+
+var _ notification.Provider = (*FCM)(nil)
+
+// NewFCM creates an FCM provider of the Firebase project projectID, sending the requests with client.
+func NewFCM(projectID string, client *http.Client, opts ...Option) *FCM {
+	o := &options{baseURL: DefaultFCMBaseURL}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if client.Timeout == 0 {
+		withTimeout := *client
+		withTimeout.Timeout = DefaultTimeout
+		client = &withTimeout
+	}
+	return &FCM{projectID: projectID, client: client, opts: o}
+}
+
+// fcmNotification is the notification of a message of the HTTP v1 API.
+type fcmNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+// fcmMessage is a message of the HTTP v1 API.
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification *fcmNotification  `json:"notification,omitempty"`
+	Data         map[string]string `json:"data,omitempty"`
+}
+
+// Send implements the notification.Provider interface.
+func (p *FCM) Send(ctx context.Context, msg notification.Message) (string, error) {
+	if len(msg.To) != 1 {
+		return "", notification.ErrInvalidMessage.Wrap(fmt.Errorf("the push notification has %d recipients instead of 1", len(msg.To)))
+	}
+	message := fcmMessage{Token: msg.To[0], Data: msg.Data}
+	if msg.Subject != "" || msg.Text != "" {
+		message.Notification = &fcmNotification{Title: msg.Subject, Body: msg.Text}
+	}
+	body, err := json.Marshal(map[string]fcmMessage{"message": message})
+	if err != nil {
+		return "", errors.MarkPermanent(fmt.Errorf("push: failed to marshal the message: %w", err))
+	}
+
+	endpoint := p.opts.baseURL + "/v1/projects/" + url.PathEscape(p.projectID) + "/messages:send"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", errors.MarkPermanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", errors.MarkRetryable(notification.ErrSendFailed.Wrap(err))
+	}
+	defer resp.Body.Close()
+	if err := notification.CheckResponse(resp); err != nil {
+		return "", err
+	}
+	var sent struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&sent); err != nil {
+		// The message is sent, and must not be sent again.
+		return "", errors.MarkPermanent(fmt.Errorf("push: failed to decode the response of FCM: %w", err))
+	}
+	return sent.Name, nil
+}
@@ -0,0 +1,59 @@
+package push_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/notification"
+	"github.com/kittipat1413/go-common/framework/notification/push"
+)
+
+func TestFCM(t *testing.T) {
+	ctx := context.Background()
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/projects/my-project/messages:send", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		if request["message"].(map[string]any)["token"] == "stale" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"status": "NOT_FOUND", "details": [{"errorCode": "UNREGISTERED"}]}}`))
+			return
+		}
+		w.Write([]byte(`{"name": "projects/my-project/messages/0:123"}`))
+	}))
+	defer server.Close()
+	provider := push.NewFCM("my-project", http.DefaultClient, push.WithBaseURL(server.URL))
+
+	id, err := provider.Send(ctx, notification.Message{
+		Channel: notification.ChannelPush,
+		To:      []string{"token"},
+		Subject: "New message",
+		Text:    "Ada sent you a message",
+		Data:    map[string]string{"conversation": "42"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "projects/my-project/messages/0:123", id)
+	assert.Equal(t, map[string]any{"message": map[string]any{
+		"token":        "token",
+		"notification": map[string]any{"title": "New message", "body": "Ada sent you a message"},
+		"data":         map[string]any{"conversation": "42"},
+	}}, request)
+
+	_, err = provider.Send(ctx, notification.Message{Channel: notification.ChannelPush, To: []string{"token"}, Data: map[string]string{"sync": "1"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"message": map[string]any{
+		"token": "token",
+		"data":  map[string]any{"sync": "1"},
+	}}, request, "a data message has no notification")
+
+	_, err = provider.Send(ctx, notification.Message{Channel: notification.ChannelPush, To: []string{"stale"}, Subject: "Hi"})
+	assert.ErrorIs(t, err, notification.ErrRejected)
+	assert.Contains(t, err.Error(), "UNREGISTERED")
+	assert.Zero(t, http.DefaultClient.Timeout, "the client is not modified")
+}
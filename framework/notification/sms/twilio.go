@@ -0,0 +1,124 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/httpclient"
+	"github.com/kittipat1413/go-common/framework/notification"
+)
+
+const (
+	// DefaultTimeout is the time limit of the requests to the API, unless their context has an earlier deadline,
+	// or the HTTP client is set with WithHTTPClient.
+	DefaultTimeout = 10 * time.Second
+	// DefaultTwilioBaseURL is the base URL of the Twilio API, unless set with WithBaseURL.
+	DefaultTwilioBaseURL = "https://api.twilio.com"
+)
+
+// options holds configuration options for the providers.
+type options struct {
+	client  *http.Client // client sends the requests to the API.
+	baseURL string       // baseURL is the base URL of the API.
+}
+
+// Option specifies provider configuration options.
+type Option func(*options)
+
+// WithHTTPClient sets the client sending the requests to the API, e.g., created with httpclient.New. It defaults to
+// an httpclient with DefaultTimeout.
+func WithHTTPClient(client *http.Client) Option {
+	return func(opts *options) {
+		if client != nil {
+			opts.client = client
+		}
+	}
+}
+
+// WithBaseURL sets the base URL of the API, e.g., of a mock server.
+func WithBaseURL(baseURL string) Option {
+	return func(opts *options) {
+		if baseURL != "" {
+			opts.baseURL = strings.TrimSuffix(baseURL, "/")
+		}
+	}
+}
+
+/*
+Twilio is a notification.Provider sending the SMS with the Programmable Messaging API of Twilio. Send returns the
+SID of the message. The messages to invalid or unreachable numbers fail with notification.ErrRejected.
+
+Example usage:
+
+	provider := sms.NewTwilio(accountSID, authToken, "+15005550006")
+	dispatcher := notification.NewDispatcher(notification.WithProvider(notification.ChannelSMS, provider))
+*/
+type Twilio struct {
+	accountSID string
+	authToken  string
+	from       string
+	opts       *options
+}
+
+var _ notification.Provider = (*Twilio)(nil)
+
+// NewTwilio creates a Twilio provider of the account accountSID, authenticated with authToken, or with the SID and
+// the secret of an API key. from is the sender of the messages without one: a phone number in E.164 format, an
+// alphanumeric sender ID, or the SID of a messaging service, starting with "MG".
+func NewTwilio(accountSID, authToken, from string, opts ...Option) *Twilio {
+	o := &options{baseURL: DefaultTwilioBaseURL}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.client == nil {
+		o.client = httpclient.New(httpclient.WithTimeout(DefaultTimeout))
+	}
+	return &Twilio{accountSID: accountSID, authToken: authToken, from: from, opts: o}
+}
+
+// Send implements the notification.Provider interface.
+func (p *Twilio) Send(ctx context.Context, msg notification.Message) (string, error) {
+	if len(msg.To) != 1 {
+		return "", notification.ErrInvalidMessage.Wrap(fmt.Errorf("the SMS has %d recipients instead of 1", len(msg.To)))
+	}
+	from := msg.From
+	if from == "" {
+		from = p.from
+	}
+	form := url.Values{"To": {msg.To[0]}, "Body": {msg.Text}}
+	if strings.HasPrefix(from, "MG") {
+		form.Set("MessagingServiceSid", from)
+	} else {
+		form.Set("From", from)
+	}
+
+	endpoint := p.opts.baseURL + "/2010-04-01/Accounts/" + url.PathEscape(p.accountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", errors.MarkPermanent(err)
+	}
+	req.SetBasicAuth(p.accountSID, p.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.opts.client.Do(req)
+	if err != nil {
+		return "", errors.MarkRetryable(notification.ErrSendFailed.Wrap(err))
+	}
+	defer resp.Body.Close()
+	if err := notification.CheckResponse(resp); err != nil {
+		return "", err
+	}
+	var message struct {
+		SID string `json:"sid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
+		// The message is sent, and must not be sent again.
+		return "", errors.MarkPermanent(fmt.Errorf("sms: failed to decode the response of Twilio: %w", err))
+	}
+	return message.SID, nil
+}
@@ -0,0 +1,68 @@
+package sms_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/notification"
+	"github.com/kittipat1413/go-common/framework/notification/sms"
+)
+
+func TestTwilio(t *testing.T) {
+	ctx := context.Background()
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+		username, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "AC123", username)
+		assert.Equal(t, "token", password)
+		require.NoError(t, r.ParseForm())
+		form = r.PostForm
+		switch form.Get("To") {
+		case "+15005550001":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": 21211, "message": "The 'To' number is not a valid phone number."}`))
+		case "+15005550002":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"sid": "SM123", "status": "queued"}`))
+		}
+	}))
+	defer server.Close()
+	provider := sms.NewTwilio("AC123", "token", "+15005550006", sms.WithBaseURL(server.URL))
+	msg := notification.Message{Channel: notification.ChannelSMS, To: []string{"+14155550100"}, Text: "Your code is 1234"}
+
+	id, err := provider.Send(ctx, msg)
+	require.NoError(t, err)
+	assert.Equal(t, "SM123", id)
+	assert.Equal(t, url.Values{"To": {"+14155550100"}, "From": {"+15005550006"}, "Body": {"Your code is 1234"}}, form)
+
+	msg.From = "MG456"
+	_, err = provider.Send(ctx, msg)
+	require.NoError(t, err)
+	assert.Equal(t, "MG456", form.Get("MessagingServiceSid"), "the sender is a messaging service")
+	assert.Empty(t, form.Get("From"))
+
+	msg.To = []string{"+15005550001"}
+	_, err = provider.Send(ctx, msg)
+	assert.ErrorIs(t, err, notification.ErrRejected)
+	assert.Contains(t, err.Error(), "21211")
+
+	msg.To = []string{"+15005550002"}
+	_, err = provider.Send(ctx, msg)
+	assert.ErrorIs(t, err, notification.ErrSendFailed)
+	assert.True(t, domain_error.Retryable(err))
+
+	msg.To = []string{"+15005550001", "+15005550002"}
+	_, err = provider.Send(ctx, msg)
+	assert.ErrorIs(t, err, notification.ErrInvalidMessage)
+}
@@ -0,0 +1,183 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Template is the content of a message, rendered with the data of the message. The Subject and the Text are
+// text/template templates, and the HTML is an html/template template, escaping the data. The parts not needed by
+// a channel may be empty.
+type Template struct {
+	Subject string // Subject is the template of Message.Subject.
+	Text    string // Text is the template of Message.Text.
+	HTML    string // HTML is the template of Message.HTML.
+}
+
+// templateKey identifies a template by channel and name.
+type templateKey struct {
+	channel Channel
+	name    string
+}
+
+// parsedTemplate is a Template parsed, with nil templates for the empty parts.
+type parsedTemplate struct {
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+}
+
+/*
+Templates holds the templates of the messages, by channel and name, so that a notification, e.g., "welcome", has
+an email, an SMS and a push notification template. It is safe for concurrent use.
+
+The templates fail to render if the data misses a key they use.
+
+Example usage:
+
+	templates := notification.NewTemplates()
+	err := templates.Add(notification.ChannelEmail, "welcome", notification.Template{
+		Subject: "Welcome, {{.Name}}",
+		Text:    "Hello {{.Name}}, your account is ready.",
+		HTML:    "<p>Hello {{.Name}}, your account is ready.</p>",
+	})
+	if err != nil {
+		// Handle error
+	}
+	msg, err := templates.Render(notification.ChannelEmail, "welcome", map[string]any{"Name": "Ada"})
+*/
+type Templates struct {
+	mutex     sync.RWMutex
+	templates map[templateKey]*parsedTemplate
+}
+
+// NewTemplates creates an empty Templates.
+func NewTemplates() *Templates {
+	return &Templates{templates: make(map[templateKey]*parsedTemplate)}
+}
+
+// Add parses tmpl as the template name of channel, replacing the one added before, if any.
+func (t *Templates) Add(channel Channel, name string, tmpl Template) error {
+	id := string(channel) + "/" + name
+	parsed := &parsedTemplate{}
+	var err error
+	if tmpl.Subject != "" {
+		if parsed.subject, err = template.New(id + ".subject").Option("missingkey=error").Parse(tmpl.Subject); err != nil {
+			return fmt.Errorf("notification: failed to parse the subject of the template %s: %w", id, err)
+		}
+	}
+	if tmpl.Text != "" {
+		if parsed.text, err = template.New(id + ".text").Option("missingkey=error").Parse(tmpl.Text); err != nil {
+			return fmt.Errorf("notification: failed to parse the text of the template %s: %w", id, err)
+		}
+	}
+	if tmpl.HTML != "" {
+		if parsed.html, err = htmltemplate.New(id + ".html").Option("missingkey=error").Parse(tmpl.HTML); err != nil {
+			return fmt.Errorf("notification: failed to parse the HTML of the template %s: %w", id, err)
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.templates[templateKey{channel: channel, name: name}] = parsed
+	return nil
+}
+
+/*
+LoadFS adds the templates of the files of fsys named <channel>/<name>.<part>.tmpl, where the part is subject, text
+or html, e.g., "email/welcome.subject.tmpl" and "email/welcome.html.tmpl". The other files are ignored.
+
+Example usage:
+
+	//go:embed templates
+	var files embed.FS
+
+	sub, _ := fs.Sub(files, "templates")
+	err := templates.LoadFS(sub)
+*/
+func (t *Templates) LoadFS(fsys fs.FS) error {
+	loaded := make(map[templateKey]*Template)
+	err := fs.WalkDir(fsys, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		channel, file := path.Split(filePath)
+		channel = strings.TrimSuffix(channel, "/")
+		name, part, ok := strings.Cut(strings.TrimSuffix(file, ".tmpl"), ".")
+		if channel == "" || strings.Contains(channel, "/") || !strings.HasSuffix(file, ".tmpl") || !ok {
+			return nil
+		}
+		key := templateKey{channel: Channel(channel), name: name}
+		tmpl, exists := loaded[key]
+		if !exists {
+			tmpl = &Template{}
+		}
+		content, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return fmt.Errorf("notification: failed to read the template file %s: %w", filePath, err)
+		}
+		switch part {
+		case "subject":
+			tmpl.Subject = string(content)
+		case "text":
+			tmpl.Text = string(content)
+		case "html":
+			tmpl.HTML = string(content)
+		default:
+			return nil
+		}
+		loaded[key] = tmpl
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for key, tmpl := range loaded {
+		if err := t.Add(key.channel, key.name, *tmpl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render returns a message of channel with the Subject, the Text and the HTML of the template name rendered with
+// data, or ErrTemplateNotFound.
+func (t *Templates) Render(channel Channel, name string, data any) (Message, error) {
+	t.mutex.RLock()
+	parsed, ok := t.templates[templateKey{channel: channel, name: name}]
+	t.mutex.RUnlock()
+	if !ok {
+		return Message{}, ErrTemplateNotFound.WithField("channel", string(channel)).WithField("template", name)
+	}
+
+	msg := Message{Channel: channel}
+	var buf bytes.Buffer
+	if parsed.subject != nil {
+		if err := parsed.subject.Execute(&buf, data); err != nil {
+			return Message{}, fmt.Errorf("notification: failed to render the template: %w", err)
+		}
+		// The subjects are single lines, even if the template file ends with a newline.
+		msg.Subject = strings.Join(strings.Fields(buf.String()), " ")
+	}
+	if parsed.text != nil {
+		buf.Reset()
+		if err := parsed.text.Execute(&buf, data); err != nil {
+			return Message{}, fmt.Errorf("notification: failed to render the template: %w", err)
+		}
+		msg.Text = buf.String()
+	}
+	if parsed.html != nil {
+		buf.Reset()
+		if err := parsed.html.Execute(&buf, data); err != nil {
+			return Message{}, fmt.Errorf("notification: failed to render the template: %w", err)
+		}
+		msg.HTML = buf.String()
+	}
+	return msg, nil
+}
@@ -0,0 +1,71 @@
+package notification_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/notification"
+)
+
+func TestTemplates(t *testing.T) {
+	templates := notification.NewTemplates()
+	require.NoError(t, templates.Add(notification.ChannelEmail, "welcome", notification.Template{
+		Subject: "Welcome, {{.Name}}",
+		Text:    "Hello {{.Name}}!",
+		HTML:    "<p>Hello {{.Name}}!</p>",
+	}))
+	require.NoError(t, templates.Add(notification.ChannelSMS, "welcome", notification.Template{
+		Text: "Welcome {{.Name}}",
+	}))
+
+	msg, err := templates.Render(notification.ChannelEmail, "welcome", map[string]any{"Name": "<Ada>"})
+	require.NoError(t, err)
+	assert.Equal(t, notification.Message{
+		Channel: notification.ChannelEmail,
+		Subject: "Welcome, <Ada>",
+		Text:    "Hello <Ada>!",
+		HTML:    "<p>Hello &lt;Ada&gt;!</p>",
+	}, msg, "the HTML is escaped")
+
+	msg, err = templates.Render(notification.ChannelSMS, "welcome", struct{ Name string }{"Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, notification.Message{Channel: notification.ChannelSMS, Text: "Welcome Ada"}, msg)
+
+	_, err = templates.Render(notification.ChannelPush, "welcome", nil)
+	assert.ErrorIs(t, err, notification.ErrTemplateNotFound)
+	_, err = templates.Render(notification.ChannelEmail, "welcome", map[string]any{})
+	assert.Error(t, err, "the data misses a key")
+
+	err = templates.Add(notification.ChannelEmail, "broken", notification.Template{Text: "{{.Name"})
+	assert.Error(t, err)
+}
+
+func TestTemplates_LoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"email/reset.subject.tmpl": {Data: []byte("Reset your\npassword\n")},
+		"email/reset.text.tmpl":    {Data: []byte("Open {{.URL}}\n")},
+		"email/reset.html.tmpl":    {Data: []byte(`<a href="{{.URL}}">Reset</a>`)},
+		"sms/reset.text.tmpl":      {Data: []byte("Code: {{.Code}}")},
+		"sms/reset.footer.tmpl":    {Data: []byte("ignored")},
+		"README.md":                {Data: []byte("ignored")},
+		"email/legacy/reset.tmpl":  {Data: []byte("ignored")},
+	}
+	templates := notification.NewTemplates()
+	require.NoError(t, templates.LoadFS(fsys))
+
+	msg, err := templates.Render(notification.ChannelEmail, "reset", map[string]string{"URL": "https://example.com/reset?t=1&u=2"})
+	require.NoError(t, err)
+	assert.Equal(t, "Reset your password", msg.Subject, "the subject is a single line")
+	assert.Equal(t, "Open https://example.com/reset?t=1&u=2\n", msg.Text)
+	assert.Equal(t, `<a href="https://example.com/reset?t=1&amp;u=2">Reset</a>`, msg.HTML)
+
+	msg, err = templates.Render(notification.ChannelSMS, "reset", map[string]string{"Code": "1234"})
+	require.NoError(t, err)
+	assert.Equal(t, "Code: 1234", msg.Text)
+
+	err = notification.NewTemplates().LoadFS(fstest.MapFS{"sms/broken.text.tmpl": {Data: []byte("{{")}})
+	assert.Error(t, err)
+}
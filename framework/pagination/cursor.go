@@ -0,0 +1,187 @@
+package pagination
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kittipat1413/go-common/framework/httpresponse"
+	"github.com/kittipat1413/go-common/util/cursor"
+)
+
+// MinSecretLength is the minimum length of the secrets signing the cursors.
+const MinSecretLength = cursor.MinSecretLength
+
+// ErrSecretTooShort is returned by NewCursorCodec when the secret is shorter than MinSecretLength.
+var ErrSecretTooShort = errors.New("pagination: the cursor secret must be at least 32 bytes")
+
+// Direction is the direction of a cursor from the item it points to.
+type Direction string
+
+const (
+	// Next is the direction of the items after the item of the cursor.
+	Next Direction = "next"
+	// Prev is the direction of the items before the item of the cursor.
+	Prev Direction = "prev"
+)
+
+// Cursor points to an item of a list with the values K of its sort keys, e.g., its creation time and ID, and
+// selects the items after or before it.
+type Cursor[K any] struct {
+	Keys      K         `json:"k"`
+	Direction Direction `json:"d"`
+}
+
+/*
+CursorCodec encodes the cursors of the sort keys K into opaque strings, and decodes them. The cursors are encoded
+by a codec of the [cursor] package: the MessagePack encoding of the sort keys, signed with HMAC-SHA256, so that the
+clients cannot forge them: the sort keys can be used in the queries as is. The cursors are not encrypted unless
+cursor.WithEncryption is passed: do not put confidential values in the sort keys otherwise. The sort keys are
+encoded as maps keyed by the `codec`, then `json` tag of their fields.
+
+Example usage:
+
+	type orderKeys struct {
+		CreatedAt time.Time `json:"c"`
+		ID        string    `json:"i"`
+	}
+
+	codec, err := pagination.NewCursorCodec[orderKeys]([]byte(cfg.CursorSecret), cursor.WithTTL(24*time.Hour))
+*/
+type CursorCodec[K any] struct {
+	codec *cursor.Codec[Cursor[K]]
+}
+
+// NewCursorCodec creates a CursorCodec signing the cursors with secret, which must be at least MinSecretLength
+// bytes long. The secret must be the same for all the instances of a service. The options of the cursor package
+// configure the encryption, expiry, versioning and secret rotation of the cursors.
+func NewCursorCodec[K any](secret []byte, opts ...cursor.Option) (*CursorCodec[K], error) {
+	codec, err := cursor.New[Cursor[K]](secret, opts...)
+	if errors.Is(err, cursor.ErrSecretTooShort) {
+		return nil, ErrSecretTooShort
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &CursorCodec[K]{codec: codec}, nil
+}
+
+// Encode encodes cur into an opaque string.
+func (c *CursorCodec[K]) Encode(cur Cursor[K]) (string, error) {
+	return c.codec.Encode(cur)
+}
+
+// Decode decodes a cursor encoded by Encode. It returns ErrInvalidCursor if s is malformed, its signature is
+// invalid, or it expired, wrapping the error of the cursor package, e.g., cursor.ErrExpired.
+func (c *CursorCodec[K]) Decode(s string) (Cursor[K], error) {
+	cur, err := c.codec.Decode(s)
+	if err != nil {
+		return Cursor[K]{}, ErrInvalidCursor.Wrap(err)
+	}
+	if cur.Direction != Next && cur.Direction != Prev {
+		return Cursor[K]{}, ErrInvalidCursor
+	}
+	return cur, nil
+}
+
+// CursorPage is a page of a list paginated with cursors.
+type CursorPage[K any] struct {
+	Limit int
+	// Cursor selects the items of the page, or is nil for the first page.
+	Cursor *Cursor[K]
+}
+
+// FetchLimit returns the number of items to fetch for the page: one more than its limit, to tell whether there
+// are more items in the direction of its cursor without counting them.
+func (p CursorPage[K]) FetchLimit() int {
+	return p.Limit + 1
+}
+
+/*
+Parse parses the page of the limit and cursor query parameters of r, e.g., "?limit=20&cursor=AQAAgqFr...". It
+returns ErrInvalidLimit or ErrInvalidCursor if they are invalid.
+
+Example usage:
+
+	page, err := codec.Parse(r)
+	if err != nil {
+		httpresponse.Error(w, r, err)
+		return
+	}
+	// Without cursor, fetch the first items. With a Next cursor, fetch the items after its keys in order; with a
+	// Prev cursor, the items before its keys in reverse order, then reverse them.
+	orders, err := h.repository.List(ctx, page.Cursor, page.FetchLimit())
+	...
+	orders, meta, err := pagination.CursorResult(codec, page, orders, func(o Order) orderKeys {
+		return orderKeys{CreatedAt: o.CreatedAt, ID: o.ID}
+	}, nil)
+*/
+func (c *CursorCodec[K]) Parse(r *http.Request, opts ...Option) (CursorPage[K], error) {
+	o := newOptions(opts)
+	limit, err := o.parseLimit(r)
+	if err != nil {
+		return CursorPage[K]{}, err
+	}
+	page := CursorPage[K]{Limit: limit}
+	if value := r.URL.Query().Get(CursorParam); value != "" {
+		cur, err := c.Decode(value)
+		if err != nil {
+			return CursorPage[K]{}, err
+		}
+		page.Cursor = &cur
+	}
+	return page, nil
+}
+
+/*
+CursorResult returns the items of page, and its pagination metadata for the response envelope with the cursors of
+the next and previous pages.
+
+items are the items fetched for the page in the order of the list, up to its FetchLimit: the extra item, last for
+the first page and Next cursors, and first for Prev cursors, tells that there are more items in the direction of
+the cursor, and is removed. keys returns the sort keys of an item. total is the number of items of all the pages,
+or nil if it is not counted.
+*/
+func CursorResult[T, K any](
+	codec *CursorCodec[K],
+	page CursorPage[K],
+	items []T,
+	keys func(T) K,
+	total *int64,
+) ([]T, *httpresponse.Pagination, error) {
+	backward := page.Cursor != nil && page.Cursor.Direction == Prev
+	more := len(items) > page.Limit
+	if more {
+		if backward {
+			items = items[len(items)-page.Limit:]
+		} else {
+			items = items[:page.Limit]
+		}
+	}
+
+	meta := &httpresponse.Pagination{Limit: page.Limit, Total: total}
+	if len(items) == 0 {
+		return items, meta, nil
+	}
+	// Going forward, there are items before the page unless it is the first one; going backward, there are items
+	// after the page, from which the cursor comes.
+	hasPrev, hasNext := page.Cursor != nil, true
+	if backward {
+		hasPrev = more
+	} else {
+		hasNext = more
+	}
+
+	meta.HasMore = hasNext
+	var err error
+	if hasNext {
+		if meta.NextCursor, err = codec.Encode(Cursor[K]{Keys: keys(items[len(items)-1]), Direction: Next}); err != nil {
+			return nil, nil, err
+		}
+	}
+	if hasPrev {
+		if meta.PrevCursor, err = codec.Encode(Cursor[K]{Keys: keys(items[0]), Direction: Prev}); err != nil {
+			return nil, nil, err
+		}
+	}
+	return items, meta, nil
+}
@@ -0,0 +1,223 @@
+package pagination_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/pagination"
+	"github.com/kittipat1413/go-common/util/clock"
+	"github.com/kittipat1413/go-common/util/cursor"
+)
+
+type orderKeys struct {
+	CreatedAt time.Time `json:"c"`
+	ID        int       `json:"i"`
+}
+
+type order struct {
+	ID        int
+	CreatedAt time.Time
+}
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func newCodec(t *testing.T) *pagination.CursorCodec[orderKeys] {
+	t.Helper()
+	codec, err := pagination.NewCursorCodec[orderKeys](secret)
+	require.NoError(t, err)
+	return codec
+}
+
+func keysOf(o order) orderKeys {
+	return orderKeys{CreatedAt: o.CreatedAt, ID: o.ID}
+}
+
+func TestNewCursorCodec_SecretTooShort(t *testing.T) {
+	_, err := pagination.NewCursorCodec[orderKeys]([]byte("secret"))
+	assert.ErrorIs(t, err, pagination.ErrSecretTooShort)
+}
+
+func TestCursorCodec_EncodeDecode(t *testing.T) {
+	codec := newCodec(t)
+	cursor := pagination.Cursor[orderKeys]{
+		Keys:      orderKeys{CreatedAt: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), ID: 42},
+		Direction: pagination.Next,
+	}
+
+	encoded, err := codec.Encode(cursor)
+	require.NoError(t, err)
+	assert.NotContains(t, encoded, "=", "the cursors are safe in URLs")
+
+	decoded, err := codec.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+}
+
+func TestCursorCodec_DecodeInvalid(t *testing.T) {
+	codec := newCodec(t)
+	encoded, err := codec.Encode(pagination.Cursor[orderKeys]{Keys: orderKeys{ID: 42}, Direction: pagination.Next})
+	require.NoError(t, err)
+	payload, signature, _ := strings.Cut(encoded, ".")
+
+	otherCodec, err := pagination.NewCursorCodec[orderKeys]([]byte("fedcba9876543210fedcba9876543210"))
+	require.NoError(t, err)
+	otherEncoded, err := otherCodec.Encode(pagination.Cursor[orderKeys]{Keys: orderKeys{ID: 42}, Direction: pagination.Next})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "no signature", cursor: payload},
+		{name: "malformed payload", cursor: "!!." + signature},
+		{name: "malformed signature", cursor: payload + ".!!"},
+		{
+			name:   "forged payload",
+			cursor: base64.RawURLEncoding.EncodeToString([]byte(`{"k":{"i":1},"d":"next"}`)) + "." + signature,
+		},
+		{name: "other secret", cursor: otherEncoded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := codec.Decode(tt.cursor)
+			assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+		})
+	}
+}
+
+func TestCursorCodec_Expired(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC))
+	codec, err := pagination.NewCursorCodec[orderKeys](secret, cursor.WithTTL(time.Hour), cursor.WithClock(fake))
+	require.NoError(t, err)
+	encoded, err := codec.Encode(pagination.Cursor[orderKeys]{Keys: orderKeys{ID: 42}, Direction: pagination.Next})
+	require.NoError(t, err)
+
+	fake.Advance(time.Hour)
+	_, err = codec.Decode(encoded)
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+	assert.ErrorIs(t, err, cursor.ErrExpired)
+}
+
+func TestCursorCodec_Parse(t *testing.T) {
+	codec := newCodec(t)
+	cursor := pagination.Cursor[orderKeys]{Keys: orderKeys{ID: 42}, Direction: pagination.Prev}
+	encoded, err := codec.Encode(cursor)
+	require.NoError(t, err)
+
+	page, err := codec.Parse(newRequest(""))
+	require.NoError(t, err)
+	assert.Equal(t, pagination.CursorPage[orderKeys]{Limit: pagination.DefaultLimit}, page)
+
+	page, err = codec.Parse(newRequest("limit=5&cursor=" + encoded))
+	require.NoError(t, err)
+	assert.Equal(t, 5, page.Limit)
+	assert.Equal(t, &cursor, page.Cursor)
+
+	_, err = codec.Parse(newRequest("cursor=invalid"))
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	_, err = codec.Parse(newRequest("limit=-5&cursor=" + encoded))
+	assert.ErrorIs(t, err, pagination.ErrInvalidLimit)
+}
+
+// list is a list of orders, sorted by ID, queried like a repository would with a cursor page.
+func list(page pagination.CursorPage[orderKeys], orders []order) []order {
+	if page.Cursor == nil {
+		return orders[:min(page.FetchLimit(), len(orders))]
+	}
+	if page.Cursor.Direction == pagination.Next {
+		var after []order
+		for _, o := range orders {
+			if o.ID > page.Cursor.Keys.ID && len(after) < page.FetchLimit() {
+				after = append(after, o)
+			}
+		}
+		return after
+	}
+	var before []order
+	for i := len(orders) - 1; i >= 0; i-- {
+		if orders[i].ID < page.Cursor.Keys.ID && len(before) < page.FetchLimit() {
+			before = append([]order{orders[i]}, before...)
+		}
+	}
+	return before
+}
+
+func ids(orders []order) []int {
+	result := make([]int, 0, len(orders))
+	for _, o := range orders {
+		result = append(result, o.ID)
+	}
+	return result
+}
+
+func TestCursorResult_Walk(t *testing.T) {
+	codec := newCodec(t)
+	orders := make([]order, 7)
+	for i := range orders {
+		orders[i] = order{ID: i + 1, CreatedAt: time.Date(2024, 3, 1, 0, 0, i, 0, time.UTC)}
+	}
+	fetch := func(cursor string) ([]int, string, string, bool) {
+		t.Helper()
+		query := "limit=3"
+		if cursor != "" {
+			query += "&cursor=" + cursor
+		}
+		page, err := codec.Parse(newRequest(query))
+		require.NoError(t, err)
+		items, meta, err := pagination.CursorResult(codec, page, list(page, orders), keysOf, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 3, meta.Limit)
+		assert.Nil(t, meta.Offset)
+		return ids(items), meta.NextCursor, meta.PrevCursor, meta.HasMore
+	}
+
+	// Forward.
+	items, next, prev, hasMore := fetch("")
+	assert.Equal(t, []int{1, 2, 3}, items)
+	assert.Empty(t, prev)
+	assert.True(t, hasMore)
+
+	items, next, prev, hasMore = fetch(next)
+	assert.Equal(t, []int{4, 5, 6}, items)
+	assert.NotEmpty(t, prev)
+	assert.True(t, hasMore)
+
+	items, next, prev, hasMore = fetch(next)
+	assert.Equal(t, []int{7}, items)
+	assert.Empty(t, next)
+	assert.NotEmpty(t, prev)
+	assert.False(t, hasMore)
+
+	// Backward.
+	items, next, prev, hasMore = fetch(prev)
+	assert.Equal(t, []int{4, 5, 6}, items)
+	assert.NotEmpty(t, next)
+	assert.True(t, hasMore)
+
+	items, next, prev, hasMore = fetch(prev)
+	assert.Equal(t, []int{1, 2, 3}, items)
+	assert.Empty(t, prev)
+	assert.True(t, hasMore)
+
+	items, _, _, _ = fetch(next)
+	assert.Equal(t, []int{4, 5, 6}, items)
+}
+
+func TestCursorResult_Empty(t *testing.T) {
+	codec := newCodec(t)
+	total := int64(0)
+
+	items, meta, err := pagination.CursorResult(codec, pagination.CursorPage[orderKeys]{Limit: 10}, []order{}, keysOf, &total)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	assert.Equal(t, &total, meta.Total)
+	assert.False(t, meta.HasMore)
+	assert.Empty(t, meta.NextCursor)
+	assert.Empty(t, meta.PrevCursor)
+}
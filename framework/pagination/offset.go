@@ -0,0 +1,74 @@
+package pagination
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/kittipat1413/go-common/framework/httpresponse"
+)
+
+// OffsetPage is a page of a list paginated with limit and offset.
+type OffsetPage struct {
+	Limit  int
+	Offset int
+}
+
+// FetchLimit returns the number of items to fetch for the page: one more than its limit, to tell whether there
+// are more items after it without counting them.
+func (p OffsetPage) FetchLimit() int {
+	return p.Limit + 1
+}
+
+/*
+ParseOffset parses the page of the limit and offset query parameters of r, e.g., "?limit=20&offset=40". It returns
+ErrInvalidLimit or ErrInvalidOffset if they are invalid.
+
+Example usage:
+
+	page, err := pagination.ParseOffset(r, pagination.WithMaxLimit(50))
+	if err != nil {
+		httpresponse.Error(w, r, err)
+		return
+	}
+	orders, err := h.repository.List(ctx, page.FetchLimit(), page.Offset)
+	...
+	orders, meta := pagination.OffsetResult(page, orders, nil)
+	httpresponse.OK(w, r, orders, &httpresponse.Meta{Pagination: meta})
+*/
+func ParseOffset(r *http.Request, opts ...Option) (OffsetPage, error) {
+	o := newOptions(opts)
+	limit, err := o.parseLimit(r)
+	if err != nil {
+		return OffsetPage{}, err
+	}
+	offset := 0
+	if value := r.URL.Query().Get(OffsetParam); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return OffsetPage{}, ErrInvalidOffset
+		}
+	}
+	return OffsetPage{Limit: limit, Offset: offset}, nil
+}
+
+/*
+OffsetResult returns the items of page, and its pagination metadata for the response envelope. items are the
+items fetched for the page, up to its FetchLimit: the extra item tells that there are more items, and is
+removed. total is the number of items of all the pages, or nil if it is not counted.
+*/
+func OffsetResult[T any](page OffsetPage, items []T, total *int64) ([]T, *httpresponse.Pagination) {
+	hasMore := len(items) > page.Limit
+	if hasMore {
+		items = items[:page.Limit]
+	}
+	if total != nil {
+		hasMore = int64(page.Offset+len(items)) < *total
+	}
+	offset := page.Offset
+	return items, &httpresponse.Pagination{
+		Limit:   page.Limit,
+		Offset:  &offset,
+		Total:   total,
+		HasMore: hasMore,
+	}
+}
@@ -0,0 +1,146 @@
+package pagination_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/pagination"
+)
+
+func newRequest(query string) *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/orders?"+query, nil)
+}
+
+func TestParseOffset(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		opts          []pagination.Option
+		expectedPage  pagination.OffsetPage
+		expectedError error
+	}{
+		{
+			name:         "defaults",
+			expectedPage: pagination.OffsetPage{Limit: pagination.DefaultLimit},
+		},
+		{
+			name:         "limit and offset",
+			query:        "limit=50&offset=100",
+			expectedPage: pagination.OffsetPage{Limit: 50, Offset: 100},
+		},
+		{
+			name:         "custom default limit",
+			opts:         []pagination.Option{pagination.WithDefaultLimit(10)},
+			expectedPage: pagination.OffsetPage{Limit: 10},
+		},
+		{
+			name:         "default limit above the max limit",
+			opts:         []pagination.Option{pagination.WithMaxLimit(5)},
+			expectedPage: pagination.OffsetPage{Limit: 5},
+		},
+		{
+			name:          "limit above the max limit",
+			query:         "limit=101",
+			expectedError: pagination.ErrInvalidLimit,
+		},
+		{
+			name:          "zero limit",
+			query:         "limit=0",
+			expectedError: pagination.ErrInvalidLimit,
+		},
+		{
+			name:          "non-integer limit",
+			query:         "limit=ten",
+			expectedError: pagination.ErrInvalidLimit,
+		},
+		{
+			name:          "negative offset",
+			query:         "offset=-1",
+			expectedError: pagination.ErrInvalidOffset,
+		},
+		{
+			name:          "non-integer offset",
+			query:         "offset=1.5",
+			expectedError: pagination.ErrInvalidOffset,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := pagination.ParseOffset(newRequest(tt.query), tt.opts...)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Equal(t, http.StatusBadRequest, domain_error.HTTPStatusOf(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedPage, page)
+			assert.Equal(t, tt.expectedPage.Limit+1, page.FetchLimit())
+		})
+	}
+}
+
+func TestParseOffset_LimitErrorFields(t *testing.T) {
+	_, err := pagination.ParseOffset(newRequest("limit=11"), pagination.WithMaxLimit(10))
+
+	var coded *domain_error.CodedError
+	require.ErrorAs(t, err, &coded)
+	assert.Equal(t, map[string]interface{}{"min": 1, "max": 10}, coded.Fields())
+}
+
+func TestOffsetResult(t *testing.T) {
+	total := int64(12)
+	tests := []struct {
+		name            string
+		page            pagination.OffsetPage
+		items           []int
+		total           *int64
+		expectedItems   []int
+		expectedHasMore bool
+	}{
+		{
+			name:            "extra item",
+			page:            pagination.OffsetPage{Limit: 3},
+			items:           []int{1, 2, 3, 4},
+			expectedItems:   []int{1, 2, 3},
+			expectedHasMore: true,
+		},
+		{
+			name:          "last page",
+			page:          pagination.OffsetPage{Limit: 3, Offset: 9},
+			items:         []int{10, 11},
+			expectedItems: []int{10, 11},
+		},
+		{
+			name:            "total",
+			page:            pagination.OffsetPage{Limit: 3, Offset: 6},
+			items:           []int{7, 8, 9},
+			total:           &total,
+			expectedItems:   []int{7, 8, 9},
+			expectedHasMore: true,
+		},
+		{
+			name:          "total of the last page",
+			page:          pagination.OffsetPage{Limit: 3, Offset: 9},
+			items:         []int{10, 11, 12},
+			total:         &total,
+			expectedItems: []int{10, 11, 12},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, meta := pagination.OffsetResult(tt.page, tt.items, tt.total)
+
+			assert.Equal(t, tt.expectedItems, items)
+			assert.Equal(t, tt.page.Limit, meta.Limit)
+			require.NotNil(t, meta.Offset)
+			assert.Equal(t, tt.page.Offset, *meta.Offset)
+			assert.Equal(t, tt.total, meta.Total)
+			assert.Equal(t, tt.expectedHasMore, meta.HasMore)
+		})
+	}
+}
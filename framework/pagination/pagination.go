@@ -0,0 +1,89 @@
+package pagination
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+)
+
+const (
+	// DefaultLimit is the number of items of the pages when the limit is not given, unless set with
+	// WithDefaultLimit.
+	DefaultLimit = 20
+	// DefaultMaxLimit is the maximum number of items of the pages, unless set with WithMaxLimit.
+	DefaultMaxLimit = 100
+)
+
+// Query parameters of the pages.
+const (
+	LimitParam  = "limit"
+	OffsetParam = "offset"
+	CursorParam = "cursor"
+)
+
+// Codes of the errors of the invalid query parameters.
+const (
+	CodeInvalidLimit  = "invalid_limit"
+	CodeInvalidOffset = "invalid_offset"
+	CodeInvalidCursor = "invalid_cursor"
+)
+
+var (
+	// ErrInvalidLimit is returned when the limit is not an integer within 1 and the maximum limit.
+	ErrInvalidLimit = errors.InvalidArgument(CodeInvalidLimit, "the limit must be an integer within 1 and the maximum limit")
+	// ErrInvalidOffset is returned when the offset is not a non-negative integer.
+	ErrInvalidOffset = errors.InvalidArgument(CodeInvalidOffset, "the offset must be a non-negative integer")
+	// ErrInvalidCursor is returned when the cursor is malformed, or was not signed with the secret of the
+	// CursorCodec, e.g., forged by the client.
+	ErrInvalidCursor = errors.InvalidArgument(CodeInvalidCursor, "the cursor is invalid")
+)
+
+// options holds configuration options for the parsing of the pages.
+type options struct {
+	defaultLimit int
+	maxLimit     int
+}
+
+// Option specifies the parsing of the pages configuration options.
+type Option func(*options)
+
+// WithDefaultLimit sets the number of items of the pages when the limit is not given. It defaults to DefaultLimit.
+func WithDefaultLimit(limit int) Option {
+	return func(opts *options) {
+		if limit > 0 {
+			opts.defaultLimit = limit
+		}
+	}
+}
+
+// WithMaxLimit sets the maximum number of items of the pages. It defaults to DefaultMaxLimit.
+func WithMaxLimit(limit int) Option {
+	return func(opts *options) {
+		if limit > 0 {
+			opts.maxLimit = limit
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{defaultLimit: DefaultLimit, maxLimit: DefaultMaxLimit}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.defaultLimit = min(o.defaultLimit, o.maxLimit)
+	return o
+}
+
+// parseLimit parses the limit query parameter of r.
+func (o *options) parseLimit(r *http.Request) (int, error) {
+	value := r.URL.Query().Get(LimitParam)
+	if value == "" {
+		return o.defaultLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > o.maxLimit {
+		return 0, ErrInvalidLimit.WithFields(map[string]interface{}{"min": 1, "max": o.maxLimit})
+	}
+	return limit, nil
+}
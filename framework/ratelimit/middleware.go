@@ -0,0 +1,184 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers written by the Middleware.
+const (
+	HeaderRetryAfter         = "Retry-After"
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+)
+
+// KeyFunc returns the key a request is limited by. Requests it returns false for are not limited.
+type KeyFunc func(r *http.Request) (string, bool)
+
+// KeyByIP limits the requests by the IP address of the client connection.
+func KeyByIP() KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		return host, host != ""
+	}
+}
+
+// KeyByForwardedIP limits the requests by the address of the client in the X-Forwarded-For header, set behind
+// trustedProxies proxies, e.g., 1 behind a single load balancer, or 2 behind a CDN and a load balancer. Every proxy
+// appends the address it received the request from to the header, after the addresses sent by the client, which it
+// can forge: the address of the client is the trustedProxies-th from the right, the leftmost ones are ignored. The
+// requests with fewer addresses than trustedProxies, or an invalid one, are limited by the IP address of the client
+// connection. trustedProxies defaults to 1.
+func KeyByForwardedIP(trustedProxies int) KeyFunc {
+	if trustedProxies < 1 {
+		trustedProxies = 1
+	}
+	byIP := KeyByIP()
+	return func(r *http.Request) (string, bool) {
+		var addrs []string
+		// The header may be repeated, each proxy appending its own line.
+		for _, value := range r.Header.Values("X-Forwarded-For") {
+			addrs = append(addrs, strings.Split(value, ",")...)
+		}
+		if len(addrs) >= trustedProxies {
+			ip := strings.TrimSpace(addrs[len(addrs)-trustedProxies])
+			if net.ParseIP(ip) != nil {
+				return ip, true
+			}
+		}
+		return byIP(r)
+	}
+}
+
+// KeyByHeader limits the requests by the value of the header name, e.g., an API key. Requests without the header
+// are not limited.
+func KeyByHeader(name string) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		value := r.Header.Get(name)
+		return value, value != ""
+	}
+}
+
+// KeyByUser limits the requests by the authenticated user, returned by user from the request context, e.g., as
+// stored by the authentication middleware. Requests without user are not limited: chain KeyByUser with KeyByIP
+// using FirstKey to limit them by IP address.
+func KeyByUser(user func(ctx context.Context) (string, bool)) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		return user(r.Context())
+	}
+}
+
+// FirstKey returns the key of the first KeyFunc returning one, prefixed with its index so that the keys of
+// different KeyFuncs do not collide.
+func FirstKey(keyFuncs ...KeyFunc) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		for i, keyFunc := range keyFuncs {
+			if key, ok := keyFunc(r); ok {
+				return strconv.Itoa(i) + ":" + key, true
+			}
+		}
+		return "", false
+	}
+}
+
+// middlewareOptions holds configuration options for the Middleware.
+type middlewareOptions struct {
+	keyFunc  KeyFunc
+	rejected func(w http.ResponseWriter, r *http.Request, result Result)
+}
+
+// MiddlewareOption specifies Middleware configuration options.
+type MiddlewareOption func(*middlewareOptions)
+
+// WithKeyFunc sets the key requests are limited by. It defaults to KeyByIP.
+func WithKeyFunc(keyFunc KeyFunc) MiddlewareOption {
+	return func(opts *middlewareOptions) {
+		if keyFunc != nil {
+			opts.keyFunc = keyFunc
+		}
+	}
+}
+
+// WithRejectionHandler sets the function writing the response to the rejected requests, after the rate limit
+// headers are set. It defaults to a 429 Too Many Requests plain text response.
+func WithRejectionHandler(handler func(w http.ResponseWriter, r *http.Request, result Result)) MiddlewareOption {
+	return func(opts *middlewareOptions) {
+		if handler != nil {
+			opts.rejected = handler
+		}
+	}
+}
+
+/*
+Middleware limits the rate of the requests of every key with limiter, rejecting the requests over the limit. If
+limiter implements ResultLimiter, the responses have the X-RateLimit-Limit, X-RateLimit-Remaining and
+X-RateLimit-Reset headers, in seconds, and the rejected ones the Retry-After header, in seconds.
+
+Example usage:
+
+	limiter := ratelimit.NewTokenBucket(10, 20)
+	defer limiter.Close()
+
+	handler := ratelimit.Middleware(limiter,
+		ratelimit.WithKeyFunc(ratelimit.FirstKey(ratelimit.KeyByUser(auth.UserID), ratelimit.KeyByIP())),
+		ratelimit.WithRejectionHandler(func(w http.ResponseWriter, r *http.Request, result ratelimit.Result) {
+			problems.Write(w, r, ErrTooManyRequests)
+		}),
+	)(mux)
+*/
+func Middleware(limiter Limiter, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	o := &middlewareOptions{
+		keyFunc: KeyByIP(),
+		rejected: func(w http.ResponseWriter, r *http.Request, result Result) {
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := o.keyFunc(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var result Result
+			if resultLimiter, ok := limiter.(ResultLimiter); ok {
+				result = resultLimiter.AllowResult(key)
+				if result.Limit > 0 {
+					w.Header().Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+					w.Header().Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+					w.Header().Set(HeaderRateLimitReset, seconds(result.ResetAfter))
+				}
+			} else {
+				result = Result{Allowed: limiter.Allow(key)}
+			}
+
+			if !result.Allowed {
+				if result.RetryAfter > 0 {
+					w.Header().Set(HeaderRetryAfter, seconds(result.RetryAfter))
+				}
+				o.rejected(w, r, result)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// seconds formats d as a number of seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,139 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+type userKey struct{}
+
+func userFromContext(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(userKey{}).(string)
+	return user, ok
+}
+
+// allowLimiter is a Limiter not implementing ResultLimiter.
+type allowLimiter struct {
+	allowed bool
+}
+
+func (l *allowLimiter) Allow(key string) bool                      { return l.allowed }
+func (l *allowLimiter) Wait(ctx context.Context, key string) error { return nil }
+
+func TestMiddleware(t *testing.T) {
+	limiter := ratelimit.NewTokenBucket(1, 2)
+	defer limiter.Close()
+	handler := ratelimit.Middleware(limiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/users", nil)
+		request.RemoteAddr = remoteAddr
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve("10.0.0.1:1234")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "2", recorder.Header().Get(ratelimit.HeaderRateLimitLimit))
+	assert.Equal(t, "1", recorder.Header().Get(ratelimit.HeaderRateLimitRemaining))
+	assert.Equal(t, "1", recorder.Header().Get(ratelimit.HeaderRateLimitReset))
+
+	assert.Equal(t, http.StatusNoContent, serve("10.0.0.1:1235").Code)
+	recorder = serve("10.0.0.1:1236")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "0", recorder.Header().Get(ratelimit.HeaderRateLimitRemaining))
+	assert.Equal(t, "1", recorder.Header().Get(ratelimit.HeaderRetryAfter))
+
+	assert.Equal(t, http.StatusNoContent, serve("10.0.0.2:1234").Code, "other clients should not be limited")
+}
+
+func TestMiddleware_Options(t *testing.T) {
+	var rejected ratelimit.Result
+	handler := ratelimit.Middleware(&allowLimiter{allowed: false},
+		ratelimit.WithKeyFunc(ratelimit.KeyByHeader("X-API-Key")),
+		ratelimit.WithRejectionHandler(func(w http.ResponseWriter, r *http.Request, result ratelimit.Result) {
+			rejected = result
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNoContent, recorder.Code, "requests without key should not be limited")
+
+	recorder = httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("X-API-Key", "key-1")
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.False(t, rejected.Allowed)
+	assert.Empty(t, recorder.Header().Get(ratelimit.HeaderRateLimitLimit))
+}
+
+func TestKeyByForwardedIP(t *testing.T) {
+	newRequest := func(forwardedFor ...string) *http.Request {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.RemoteAddr = "10.0.0.2:1234"
+		for _, value := range forwardedFor {
+			request.Header.Add("X-Forwarded-For", value)
+		}
+		return request
+	}
+	behindLoadBalancer := ratelimit.KeyByForwardedIP(1)
+	behindCDN := ratelimit.KeyByForwardedIP(2)
+
+	// The addresses sent by the client do not change its key.
+	for _, forged := range []string{"", "198.51.100.1, ", "198.51.100.2, 198.51.100.3, ", "not an address, "} {
+		key, ok := behindLoadBalancer(newRequest(forged + "203.0.113.7"))
+		assert.True(t, ok)
+		assert.Equal(t, "203.0.113.7", key, forged)
+
+		key, _ = behindCDN(newRequest(forged + "203.0.113.7, 192.0.2.10"))
+		assert.Equal(t, "203.0.113.7", key, forged)
+	}
+
+	// The addresses appended by every proxy are read across the repeated headers.
+	key, _ := behindCDN(newRequest("198.51.100.1, 203.0.113.7", "192.0.2.10"))
+	assert.Equal(t, "203.0.113.7", key)
+
+	// Without the addresses of all the proxies, or with an invalid one, the connection address is used.
+	key, _ = behindCDN(newRequest("203.0.113.7"))
+	assert.Equal(t, "10.0.0.2", key)
+	key, _ = behindLoadBalancer(newRequest("198.51.100.1, unknown"))
+	assert.Equal(t, "10.0.0.2", key)
+	key, _ = ratelimit.KeyByForwardedIP(0)(newRequest("198.51.100.1, 203.0.113.7"))
+	assert.Equal(t, "203.0.113.7", key)
+}
+
+func TestKeyFuncs(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+
+	key, ok := ratelimit.KeyByIP()(request)
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.1", key)
+
+	key, _ = ratelimit.KeyByForwardedIP(1)(request)
+	assert.Equal(t, "10.0.0.1", key)
+	request.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	key, _ = ratelimit.KeyByForwardedIP(1)(request)
+	assert.Equal(t, "10.0.0.1", key)
+	key, _ = ratelimit.KeyByForwardedIP(2)(request)
+	assert.Equal(t, "203.0.113.7", key)
+
+	byUserOrIP := ratelimit.FirstKey(ratelimit.KeyByUser(userFromContext), ratelimit.KeyByIP())
+	key, _ = byUserOrIP(request)
+	assert.Equal(t, "1:10.0.0.1", key)
+	key, _ = byUserOrIP(request.WithContext(context.WithValue(request.Context(), userKey{}, "user-1")))
+	assert.Equal(t, "0:user-1", key)
+}
@@ -0,0 +1,195 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultIdleTimeout is the time after which the limiter of a key not used is removed, unless
+	// WithIdleTimeout is set.
+	DefaultIdleTimeout = 10 * time.Minute
+	// DefaultCleanupInterval is the interval between removals of idle limiters, unless WithCleanupInterval is set.
+	DefaultCleanupInterval = time.Minute
+)
+
+// ErrLimitExceeded is returned by Wait when the key would not be allowed before the deadline of the context.
+var ErrLimitExceeded = errors.New("rate limit exceeded")
+
+/*
+Limiter limits the rate of events per key, e.g., a user ID or an IP address.
+
+Allow reports whether an event is allowed now, and records it if so. Wait blocks until an event is allowed, and
+returns ErrLimitExceeded at once if it would not be allowed before the deadline of ctx, or ctx.Err() if ctx is
+done while waiting.
+*/
+type Limiter interface {
+	Allow(key string) bool
+	Wait(ctx context.Context, key string) error
+}
+
+// Result describes the state of the limiter of a key after an event.
+type Result struct {
+	// Allowed tells whether the event is allowed.
+	Allowed bool
+	// Limit is the number of events allowed at once, e.g., the burst of a TokenBucket.
+	Limit int
+	// Remaining is the number of events still allowed at once.
+	Remaining int
+	// RetryAfter is the delay until an event may be allowed, if the event is not allowed.
+	RetryAfter time.Duration
+	// ResetAfter is the delay until the limiter of the key is fully reset.
+	ResetAfter time.Duration
+}
+
+// ResultLimiter is implemented by limiters describing their state, e.g., for the X-RateLimit-* headers of the
+// Middleware. AllowResult is like Allow, and returns the state of the limiter of key.
+type ResultLimiter interface {
+	Limiter
+	AllowResult(key string) Result
+}
+
+// config holds configuration options for the limiters.
+type config struct {
+	idleTimeout     time.Duration
+	cleanupInterval time.Duration
+}
+
+// Option specifies limiter configuration options.
+type Option func(*config)
+
+// WithIdleTimeout sets the time after which the limiter of a key not used is removed to free its memory.
+// It is raised to the time the limiter of a key takes to be reset, so that removing it does not allow more events.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(c *config) {
+		if d > 0 {
+			c.idleTimeout = d
+		}
+	}
+}
+
+// WithCleanupInterval sets the interval between removals of idle limiters. A negative interval disables the
+// removals.
+func WithCleanupInterval(d time.Duration) Option {
+	return func(c *config) {
+		if d != 0 {
+			c.cleanupInterval = d
+		}
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		idleTimeout:     DefaultIdleTimeout,
+		cleanupInterval: DefaultCleanupInterval,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// keyed holds the state of every key of a limiter, and removes the idle ones in the background.
+type keyed[S any] struct {
+	mutex       sync.Mutex
+	entries     map[string]*entry[S]
+	idleTimeout time.Duration
+	stop        chan struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+type entry[S any] struct {
+	state    S
+	lastSeen time.Time
+}
+
+func newKeyed[S any](cfg *config, resetTime time.Duration) *keyed[S] {
+	k := &keyed[S]{
+		entries:     make(map[string]*entry[S]),
+		idleTimeout: cfg.idleTimeout,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if k.idleTimeout < resetTime {
+		k.idleTimeout = resetTime
+	}
+	if cfg.cleanupInterval > 0 {
+		go k.cleanupLoop(cfg.cleanupInterval)
+	} else {
+		close(k.done)
+	}
+	return k
+}
+
+// get returns the state of key, created with init if missing. It must be called with the mutex held.
+func (k *keyed[S]) get(key string, now time.Time, init func() S) *S {
+	e, found := k.entries[key]
+	if !found {
+		e = &entry[S]{state: init()}
+		k.entries[key] = e
+	}
+	e.lastSeen = now
+	return &e.state
+}
+
+func (k *keyed[S]) cleanupLoop(interval time.Duration) {
+	defer close(k.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			k.cleanup(time.Now())
+		case <-k.stop:
+			return
+		}
+	}
+}
+
+// cleanup removes the keys idle since before now minus the idle timeout.
+func (k *keyed[S]) cleanup(now time.Time) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	for key, e := range k.entries {
+		if now.Sub(e.lastSeen) > k.idleTimeout {
+			delete(k.entries, key)
+		}
+	}
+}
+
+// len returns the number of keys tracked.
+func (k *keyed[S]) len() int {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	return len(k.entries)
+}
+
+// close stops the removal of idle keys and waits for it to return.
+func (k *keyed[S]) close() error {
+	k.closeOnce.Do(func() {
+		close(k.stop)
+	})
+	<-k.done
+	return nil
+}
+
+// wait waits for delay, unless ctx is done first. It returns ErrLimitExceeded at once if the deadline of ctx
+// is before the end of delay.
+func wait(ctx context.Context, delay time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return ErrLimitExceeded
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
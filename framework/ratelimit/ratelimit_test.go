@@ -0,0 +1,96 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestTokenBucket(t *testing.T) {
+	limiter := ratelimit.NewTokenBucket(20, 2)
+	defer limiter.Close()
+
+	assert.True(t, limiter.Allow("user-1"))
+	assert.True(t, limiter.Allow("user-1"))
+	assert.False(t, limiter.Allow("user-1"), "the burst should be exhausted")
+	assert.True(t, limiter.Allow("user-2"), "keys should be limited independently")
+
+	start := time.Now()
+	require.NoError(t, limiter.Wait(context.Background(), "user-1"))
+	assert.InDelta(t, 50*time.Millisecond, time.Since(start), float64(40*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx, "user-1"), ratelimit.ErrLimitExceeded)
+
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, limiter.Allow("user-1"), "the token of the failed wait should be refunded")
+}
+
+func TestSlidingWindow(t *testing.T) {
+	limiter := ratelimit.NewSlidingWindow(3, 100*time.Millisecond)
+	defer limiter.Close()
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.Allow("ip-1"))
+	}
+	assert.False(t, limiter.Allow("ip-1"))
+	assert.True(t, limiter.Allow("ip-2"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	require.NoError(t, limiter.Wait(ctx, "ip-1"))
+	assert.Less(t, time.Since(start), 250*time.Millisecond)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	for limiter.Allow("ip-1") {
+	}
+	assert.ErrorIs(t, limiter.Wait(ctx, "ip-1"), ratelimit.ErrLimitExceeded)
+
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	assert.ErrorIs(t, limiter.Wait(cancelled, "ip-1"), context.Canceled)
+}
+
+func TestSlidingWindow_Rate(t *testing.T) {
+	limiter := ratelimit.NewSlidingWindow(5, 50*time.Millisecond)
+	defer limiter.Close()
+
+	allowed := 0
+	for deadline := time.Now().Add(200 * time.Millisecond); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if limiter.Allow("ip-1") {
+			allowed++
+		}
+	}
+	// 4 windows of 5 events, plus the events allowed as the first window slides.
+	assert.GreaterOrEqual(t, allowed, 15)
+	assert.LessOrEqual(t, allowed, 26)
+}
+
+func TestIdleKeysCleanup(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	limiters := []interface {
+		ratelimit.Limiter
+		Len() int
+		Close() error
+	}{
+		ratelimit.NewTokenBucket(1000, 1, ratelimit.WithIdleTimeout(10*time.Millisecond), ratelimit.WithCleanupInterval(5*time.Millisecond)),
+		ratelimit.NewSlidingWindow(1, time.Millisecond, ratelimit.WithIdleTimeout(10*time.Millisecond), ratelimit.WithCleanupInterval(5*time.Millisecond)),
+	}
+	for _, limiter := range limiters {
+		limiter.Allow("user-1")
+		limiter.Allow("user-2")
+		assert.Equal(t, 2, limiter.Len())
+		assert.Eventually(t, func() bool { return limiter.Len() == 0 }, time.Second, 5*time.Millisecond)
+		require.NoError(t, limiter.Close())
+		require.NoError(t, limiter.Close())
+	}
+}
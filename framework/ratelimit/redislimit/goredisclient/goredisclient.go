@@ -0,0 +1,44 @@
+package goredisclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kittipat1413/go-common/framework/ratelimit/redislimit"
+)
+
+var _ redislimit.Client = (*Client)(nil)
+
+/*
+Client is the redislimit.Client of a go-redis client, e.g., a *redis.Client, a *redis.ClusterClient or a
+*redis.Ring. It is a module of its own, so that the services using redislimit with another client do not depend on
+go-redis.
+
+The scripts are run with EVALSHA, and loaded with EVAL the first time a server runs them, so that the script of the
+limiter is not sent with every event.
+
+Example usage:
+
+	rdb := redis.NewClient(&redis.Options{Addr: "redis:6379"})
+	limiter := redislimit.New(goredisclient.New(rdb), 10, 20)
+*/
+type Client struct {
+	client  redis.Scripter
+	scripts sync.Map // scripts maps the source of the scripts to their *redis.Script.
+}
+
+// New creates the redislimit.Client of client. The client is not owned by it.
+func New(client redis.Scripter) *Client {
+	return &Client{client: client}
+}
+
+// Eval runs the Lua script with keys and args, and returns its reply.
+func (c *Client) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	s, ok := c.scripts.Load(script)
+	if !ok {
+		s, _ = c.scripts.LoadOrStore(script, redis.NewScript(script))
+	}
+	return s.(*redis.Script).Run(ctx, c.client, keys, args...).Result()
+}
@@ -0,0 +1,44 @@
+package goredisclient_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/ratelimit/redislimit"
+	"github.com/kittipat1413/go-common/framework/ratelimit/redislimit/goredisclient"
+)
+
+func TestLimiter(t *testing.T) {
+	server := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	limiter := redislimit.New(goredisclient.New(rdb), 20, 2, redislimit.WithKeyPrefix("api:"))
+
+	result := limiter.AllowResult("user-1")
+	require.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Limit)
+	assert.Equal(t, 1, result.Remaining)
+	assert.True(t, limiter.Allow("user-1"))
+	result = limiter.AllowResult("user-1")
+	assert.False(t, result.Allowed, "the burst should be exhausted")
+	assert.InDelta(t, 50*time.Millisecond, result.RetryAfter, float64(10*time.Millisecond))
+	assert.True(t, limiter.Allow("user-2"))
+
+	// The TAT is stored in microseconds, to the microsecond.
+	value, err := server.Get("api:user-1")
+	require.NoError(t, err)
+	tat, err := strconv.ParseInt(value, 10, 64)
+	require.NoError(t, err, "the TAT should be stored as an integer: %s", value)
+	assert.InDelta(t, time.Now().Add(100*time.Millisecond).UnixMicro(), tat, float64(time.Second/time.Microsecond))
+
+	allowed, err := limiter.AllowContext(context.Background(), "user-3")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
@@ -0,0 +1,243 @@
+package redislimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/ratelimit"
+)
+
+const (
+	// DefaultKeyPrefix is prepended to the keys of the limiter in Redis unless WithKeyPrefix is set.
+	DefaultKeyPrefix = "ratelimit:"
+	// DefaultTimeout bounds the Redis calls of Allow unless WithTimeout is set.
+	DefaultTimeout = 100 * time.Millisecond
+)
+
+// ErrUnexpectedReply is returned when the reply of the script cannot be parsed.
+var ErrUnexpectedReply = errors.New("redislimit: unexpected script reply")
+
+/*
+gcraScript implements the generic cell rate algorithm: the key holds the theoretical arrival time (TAT) of the next
+event, in microseconds of the Redis server clock, so that all replicas share the same clock. An event is allowed if
+the TAT, once pushed by the emission interval, is within the burst tolerance of now. The TAT is stored formatted
+as an integer, since Redis converts the Lua numbers to strings with 14 significant digits, fewer than the 16 digits
+of the current time in microseconds.
+
+ARGV[1] is the emission interval and ARGV[2] the burst tolerance, in microseconds. It returns {allowed, retry after,
+remaining events, reset after}, with the delays in microseconds.
+*/
+const gcraScript = `
+if redis.replicate_commands then redis.replicate_commands() end
+local emission = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then tat = now end
+local new_tat = tat + emission
+local allow_at = new_tat - tolerance
+if allow_at > now then
+	return {0, allow_at - now, 0, tat - now}
+end
+redis.call('SET', KEYS[1], string.format('%d', new_tat), 'PX', math.max(1, math.ceil((new_tat - now) / 1000)))
+return {1, 0, math.floor((tolerance - (new_tat - now)) / emission), new_tat - now}
+`
+
+// Client is the subset of Redis operations used by the limiter. The goredisclient module implements it with
+// github.com/redis/go-redis.
+type Client interface {
+	// Eval runs the Lua script with keys and args, and returns its reply.
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// FailureMode tells how the limiter behaves when Redis is unavailable.
+type FailureMode int
+
+const (
+	// FailOpen allows the events when Redis is unavailable, so that an outage of Redis does not reject all traffic.
+	FailOpen FailureMode = iota
+	// FailClosed rejects the events when Redis is unavailable, to enforce the limit strictly.
+	FailClosed
+)
+
+// config holds configuration options for the limiter.
+type config struct {
+	keyPrefix    string
+	timeout      time.Duration
+	failureMode  FailureMode
+	errorHandler func(key string, err error)
+}
+
+// Option specifies limiter configuration options.
+type Option func(*config)
+
+// WithKeyPrefix sets the prefix of the keys of the limiter in Redis, e.g., to share a Redis with other limiters.
+func WithKeyPrefix(prefix string) Option {
+	return func(c *config) {
+		c.keyPrefix = prefix
+	}
+}
+
+// WithTimeout bounds the Redis calls of Allow, which has no context.
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) {
+		if d > 0 {
+			c.timeout = d
+		}
+	}
+}
+
+// WithFailureMode sets how the limiter behaves when Redis is unavailable. It defaults to FailOpen.
+func WithFailureMode(mode FailureMode) Option {
+	return func(c *config) {
+		c.failureMode = mode
+	}
+}
+
+// WithErrorHandler sets a function called with the errors of Redis, e.g., to log them, since Allow does not
+// return errors.
+func WithErrorHandler(handler func(key string, err error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
+
+/*
+Limiter is a ratelimit.Limiter backed by Redis, so that the limits are enforced across replicas. It allows rate
+events per second per key, with bursts of up to burst events, like ratelimit.TokenBucket, using an atomic Lua
+script implementing the generic cell rate algorithm with a single key per limited key. The keys expire in Redis
+once their limiter is reset, so that idle keys do not use memory.
+
+Example usage:
+
+	limiter := redislimit.New(goredisclient.New(rdb), 10, 20,
+		redislimit.WithFailureMode(redislimit.FailOpen),
+	)
+
+	if !limiter.Allow(userID) {
+		// Reject the request
+	}
+*/
+type Limiter struct {
+	client    Client
+	emission  int64
+	tolerance int64
+	cfg       config
+}
+
+var _ ratelimit.ResultLimiter = (*Limiter)(nil)
+
+// New creates a Limiter. rate must be positive, and burst is raised to 1.
+func New(client Client, rate float64, burst int, opts ...Option) *Limiter {
+	cfg := config{
+		keyPrefix: DefaultKeyPrefix,
+		timeout:   DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	emission := int64(math.Max(1, float64(time.Second/time.Microsecond)/rate))
+	return &Limiter{
+		client:    client,
+		emission:  emission,
+		tolerance: emission * int64(burst),
+		cfg:       cfg,
+	}
+}
+
+// reserve runs the script for key, and returns the state of the limiter of key.
+func (l *Limiter) reserve(ctx context.Context, key string) (ratelimit.Result, error) {
+	reply, err := l.client.Eval(ctx, gcraScript, []string{l.cfg.keyPrefix + key}, l.emission, l.tolerance)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 4 {
+		return ratelimit.Result{}, fmt.Errorf("%w: %v", ErrUnexpectedReply, reply)
+	}
+	var integers [4]int64
+	for i, value := range values {
+		if integers[i], ok = value.(int64); !ok {
+			return ratelimit.Result{}, fmt.Errorf("%w: %v", ErrUnexpectedReply, reply)
+		}
+	}
+	return ratelimit.Result{
+		Allowed:    integers[0] == 1,
+		Limit:      int(l.tolerance / l.emission),
+		Remaining:  int(integers[2]),
+		RetryAfter: time.Duration(integers[1]) * time.Microsecond,
+		ResetAfter: time.Duration(integers[3]) * time.Microsecond,
+	}, nil
+}
+
+// handleError reports err, and returns whether the event is allowed by the failure mode.
+func (l *Limiter) handleError(key string, err error) bool {
+	if l.cfg.errorHandler != nil {
+		l.cfg.errorHandler(key, err)
+	}
+	return l.cfg.failureMode == FailOpen
+}
+
+// Allow reports whether an event of key is allowed now, and records it if so. If Redis is unavailable, the event
+// is allowed or rejected according to the failure mode.
+func (l *Limiter) Allow(key string) bool {
+	return l.AllowResult(key).Allowed
+}
+
+// AllowResult is like Allow, and returns the state of the limiter of key, see ratelimit.ResultLimiter. If Redis is
+// unavailable, the result only tells whether the event is allowed according to the failure mode.
+func (l *Limiter) AllowResult(key string) ratelimit.Result {
+	ctx, cancel := context.WithTimeout(context.Background(), l.cfg.timeout)
+	defer cancel()
+	result, err := l.reserve(ctx, key)
+	if err != nil {
+		return ratelimit.Result{Allowed: l.handleError(key, err)}
+	}
+	return result
+}
+
+// AllowContext is like Allow with a context, and returns the error of Redis instead of applying the failure mode.
+func (l *Limiter) AllowContext(ctx context.Context, key string) (bool, error) {
+	result, err := l.reserve(ctx, key)
+	return result.Allowed, err
+}
+
+// Wait blocks until an event of key is allowed, see ratelimit.Limiter. If Redis is unavailable, Wait returns nil
+// with FailOpen, and the error of Redis with FailClosed.
+func (l *Limiter) Wait(ctx context.Context, key string) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result, err := l.reserve(ctx, key)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if l.handleError(key, err) {
+				return nil
+			}
+			return fmt.Errorf("redislimit: %w", err)
+		}
+		if result.Allowed {
+			return nil
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < result.RetryAfter {
+			return ratelimit.ErrLimitExceeded
+		}
+		timer := time.NewTimer(result.RetryAfter)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package redislimit_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/ratelimit"
+	"github.com/kittipat1413/go-common/framework/ratelimit/redislimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient emulates the GCRA script of the limiter with the local clock.
+type fakeClient struct {
+	mutex sync.Mutex
+	tats  map[string]int64
+	keys  []string
+	err   error
+}
+
+func (c *fakeClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.keys = append(c.keys, keys[0])
+	emission, tolerance := args[0].(int64), args[1].(int64)
+	now := time.Now().UnixMicro()
+	tat, found := c.tats[keys[0]]
+	if !found || tat < now {
+		tat = now
+	}
+	newTAT := tat + emission
+	if allowAt := newTAT - tolerance; allowAt > now {
+		return []interface{}{int64(0), allowAt - now, int64(0), tat - now}, nil
+	}
+	c.tats[keys[0]] = newTAT
+	return []interface{}{int64(1), int64(0), (tolerance - (newTAT - now)) / emission, newTAT - now}, nil
+}
+
+func TestLimiter(t *testing.T) {
+	client := &fakeClient{tats: make(map[string]int64)}
+	limiter := redislimit.New(client, 20, 2, redislimit.WithKeyPrefix("api:"))
+
+	result := limiter.AllowResult("user-1")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Limit)
+	assert.Equal(t, 1, result.Remaining)
+	assert.InDelta(t, 50*time.Millisecond, result.ResetAfter, float64(10*time.Millisecond))
+	assert.True(t, limiter.Allow("user-1"))
+	result = limiter.AllowResult("user-1")
+	assert.False(t, result.Allowed, "the burst should be exhausted")
+	assert.Zero(t, result.Remaining)
+	assert.InDelta(t, 50*time.Millisecond, result.RetryAfter, float64(10*time.Millisecond))
+	assert.True(t, limiter.Allow("user-2"))
+	assert.Equal(t, "api:user-1", client.keys[0])
+
+	start := time.Now()
+	require.NoError(t, limiter.Wait(context.Background(), "user-1"))
+	assert.InDelta(t, 50*time.Millisecond, time.Since(start), float64(40*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx, "user-1"), ratelimit.ErrLimitExceeded)
+}
+
+func TestLimiter_FailureModes(t *testing.T) {
+	unavailable := errors.New("connection refused")
+	client := &fakeClient{err: unavailable}
+
+	var reported []error
+	open := redislimit.New(client, 10, 1, redislimit.WithErrorHandler(func(key string, err error) {
+		reported = append(reported, err)
+	}))
+	assert.True(t, open.Allow("user-1"))
+	assert.NoError(t, open.Wait(context.Background(), "user-1"))
+	assert.Equal(t, []error{unavailable, unavailable}, reported)
+	_, err := open.AllowContext(context.Background(), "user-1")
+	assert.ErrorIs(t, err, unavailable)
+
+	closed := redislimit.New(client, 10, 1, redislimit.WithFailureMode(redislimit.FailClosed))
+	assert.False(t, closed.Allow("user-1"))
+	assert.ErrorIs(t, closed.Wait(context.Background(), "user-1"), unavailable)
+}
+
+type replyClient struct {
+	reply interface{}
+}
+
+func (c *replyClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return c.reply, nil
+}
+
+func TestLimiter_UnexpectedReply(t *testing.T) {
+	limiter := redislimit.New(&replyClient{reply: "OK"}, 10, 1)
+	_, err := limiter.AllowContext(context.Background(), "user-1")
+	assert.ErrorIs(t, err, redislimit.ErrUnexpectedReply)
+}
@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// windowState is the state of a key of a SlidingWindow.
+type windowState struct {
+	start    time.Time
+	previous int
+	current  int
+}
+
+/*
+SlidingWindow is a Limiter allowing limit events per window per key over any sliding window. It counts the events
+of the current and previous fixed windows, and weights the previous count by its overlap with the sliding window,
+so that it needs two counters per key and does not allow twice the limit around window boundaries. Idle keys are
+removed in the background: call Close to stop the removals.
+
+Example usage:
+
+	limiter := ratelimit.NewSlidingWindow(100, time.Minute) // 100 requests per minute
+	defer limiter.Close()
+
+	if err := limiter.Wait(ctx, clientIP); err != nil {
+		// Handle error
+	}
+*/
+type SlidingWindow struct {
+	limit  int
+	window time.Duration
+	keys   *keyed[windowState]
+}
+
+// NewSlidingWindow creates a SlidingWindow. window must be positive, and limit is raised to 1.
+func NewSlidingWindow(limit int, window time.Duration, opts ...Option) *SlidingWindow {
+	if limit < 1 {
+		limit = 1
+	}
+	return &SlidingWindow{
+		limit:  limit,
+		window: window,
+		keys:   newKeyed[windowState](newConfig(opts), 2*window),
+	}
+}
+
+// reserve records an event of key if it is allowed now, and returns 0, or the delay until it may be allowed
+// otherwise. It must be called with the mutex held.
+func (l *SlidingWindow) reserve(key string, now time.Time) time.Duration {
+	delay, _ := l.reserveResult(key, now)
+	return delay
+}
+
+// reserveResult is like reserve, and returns the state of the window of key. It must be called with the mutex held.
+func (l *SlidingWindow) reserveResult(key string, now time.Time) (time.Duration, Result) {
+	w := l.keys.get(key, now, func() windowState {
+		return windowState{start: now.Truncate(l.window)}
+	})
+	if elapsed := now.Sub(w.start); elapsed >= 2*l.window {
+		w.start, w.previous, w.current = now.Truncate(l.window), 0, 0
+	} else if elapsed >= l.window {
+		w.start, w.previous, w.current = w.start.Add(l.window), w.current, 0
+	}
+
+	elapsed := now.Sub(w.start)
+	weight := 1 - float64(elapsed)/float64(l.window)
+	result := Result{Limit: l.limit, ResetAfter: l.window - elapsed}
+	if float64(w.previous)*weight+float64(w.current)+1 <= float64(l.limit) {
+		w.current++
+		result.Allowed = true
+		result.Remaining = int(math.Max(0, math.Floor(float64(l.limit)-float64(w.previous)*weight-float64(w.current))))
+		result.ResetAfter += l.window
+		return 0, result
+	}
+	if w.current > 0 {
+		result.ResetAfter += l.window
+	}
+
+	// Delay until the weighted count of the previous window leaves room for one event, in this window if the
+	// events of this window leave room, or else in the next one.
+	free := float64(l.limit - 1 - w.current)
+	if free >= 0 && w.previous > 0 {
+		result.RetryAfter = l.untilWeight(free/float64(w.previous)) - elapsed
+		return result.RetryAfter, result
+	}
+	result.RetryAfter = l.window - elapsed
+	if w.current > 0 {
+		result.RetryAfter += l.untilWeight(float64(l.limit-1) / float64(w.current))
+	}
+	return result.RetryAfter, result
+}
+
+// untilWeight returns the time elapsed in a window when the previous window has weight.
+func (l *SlidingWindow) untilWeight(weight float64) time.Duration {
+	if weight > 1 {
+		weight = 1
+	}
+	return time.Duration((1 - weight) * float64(l.window))
+}
+
+// Allow reports whether an event of key is allowed now, and records it if so.
+func (l *SlidingWindow) Allow(key string) bool {
+	return l.AllowResult(key).Allowed
+}
+
+// AllowResult is like Allow, and returns the state of the window of key, see ResultLimiter.
+func (l *SlidingWindow) AllowResult(key string) Result {
+	l.keys.mutex.Lock()
+	defer l.keys.mutex.Unlock()
+
+	_, result := l.reserveResult(key, time.Now())
+	return result
+}
+
+// Wait blocks until an event of key is allowed, see Limiter.
+func (l *SlidingWindow) Wait(ctx context.Context, key string) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		l.keys.mutex.Lock()
+		delay := l.reserve(key, time.Now())
+		l.keys.mutex.Unlock()
+		if delay == 0 {
+			return nil
+		}
+		// Other events may be recorded meanwhile, so the event is reserved again after the delay.
+		if err := wait(ctx, max(delay, time.Millisecond)); err != nil {
+			return err
+		}
+	}
+}
+
+// Len returns the number of keys tracked.
+func (l *SlidingWindow) Len() int {
+	return l.keys.len()
+}
+
+// Close stops the removal of idle keys. The limiter remains usable.
+func (l *SlidingWindow) Close() error {
+	return l.keys.close()
+}
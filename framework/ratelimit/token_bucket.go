@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// bucket is the state of a key of a TokenBucket.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+/*
+TokenBucket is a Limiter allowing rate events per second per key, with bursts of up to burst events: every key
+has a bucket of burst tokens, refilled at rate tokens per second, and every event takes a token. Idle keys are
+removed in the background: call Close to stop the removals.
+
+Example usage:
+
+	limiter := ratelimit.NewTokenBucket(10, 20) // 10 requests per second, bursts of 20
+	defer limiter.Close()
+
+	if !limiter.Allow(userID) {
+		// Reject the request
+	}
+*/
+type TokenBucket struct {
+	rate  float64
+	burst float64
+	keys  *keyed[bucket]
+}
+
+// NewTokenBucket creates a TokenBucket. rate must be positive, and burst is raised to 1.
+func NewTokenBucket(rate float64, burst int, opts ...Option) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	fill := time.Duration(float64(burst) / rate * float64(time.Second))
+	return &TokenBucket{
+		rate:  rate,
+		burst: float64(burst),
+		keys:  newKeyed[bucket](newConfig(opts), fill),
+	}
+}
+
+// take takes a token from the bucket of key, even if it is empty, and returns the delay until the token is
+// available. It must be called with the mutex held.
+func (l *TokenBucket) take(key string, now time.Time) time.Duration {
+	b := l.keys.get(key, now, func() bucket {
+		return bucket{tokens: l.burst, last: now}
+	})
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / l.rate * float64(time.Second))
+}
+
+// refund returns a token taken by take to the bucket of key.
+func (l *TokenBucket) refund(key string) {
+	l.keys.mutex.Lock()
+	defer l.keys.mutex.Unlock()
+
+	if e, found := l.keys.entries[key]; found {
+		e.state.tokens++
+		if e.state.tokens > l.burst {
+			e.state.tokens = l.burst
+		}
+	}
+}
+
+// Allow reports whether an event of key is allowed now, and takes a token if so.
+func (l *TokenBucket) Allow(key string) bool {
+	return l.AllowResult(key).Allowed
+}
+
+// AllowResult is like Allow, and returns the state of the bucket of key, see ResultLimiter.
+func (l *TokenBucket) AllowResult(key string) Result {
+	l.keys.mutex.Lock()
+	defer l.keys.mutex.Unlock()
+
+	delay := l.take(key, time.Now())
+	b := &l.keys.entries[key].state
+	if delay > 0 {
+		b.tokens++
+	}
+	remaining := int(math.Max(0, math.Floor(b.tokens)))
+	return Result{
+		Allowed:    delay == 0,
+		Limit:      int(l.burst),
+		Remaining:  remaining,
+		RetryAfter: delay,
+		ResetAfter: time.Duration((l.burst - b.tokens) / l.rate * float64(time.Second)),
+	}
+}
+
+// Wait blocks until an event of key is allowed, see Limiter.
+func (l *TokenBucket) Wait(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.keys.mutex.Lock()
+	delay := l.take(key, time.Now())
+	l.keys.mutex.Unlock()
+	if delay == 0 {
+		return nil
+	}
+	if err := wait(ctx, delay); err != nil {
+		l.refund(key)
+		return err
+	}
+	return nil
+}
+
+// Len returns the number of keys tracked.
+func (l *TokenBucket) Len() int {
+	return l.keys.len()
+}
+
+// Close stops the removal of idle keys. The limiter remains usable.
+func (l *TokenBucket) Close() error {
+	return l.keys.close()
+}
@@ -0,0 +1,112 @@
+package requestcontext
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// metadataCarrier is the metadata of a gRPC request as a carrier, with the headers in lower case.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// outgoingContext returns a copy of ctx whose outgoing metadata carries the values of ctx.
+func (o *options) outgoingContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	if o.inject(ctx, metadataCarrier(md)) {
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+	return ctx
+}
+
+// incomingContext returns a copy of ctx carrying the values of its incoming metadata and its client.
+func (o *options) incomingContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = o.extract(ctx, metadataCarrier(md))
+	client := Client{UserAgent: metadataCarrier(md).Get("user-agent")}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if ip, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			client.IP = ip
+		}
+	}
+	return WithClient(ctx, client)
+}
+
+/*
+UnaryClientInterceptor sets the values of the context of the outgoing requests in their metadata, like the
+Transport. Only the WithPrincipalPropagation option applies.
+
+Example usage:
+
+	conn, err := client.Dial(target, client.WithDialOptions(
+		grpc.WithChainUnaryInterceptor(requestcontext.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(requestcontext.StreamClientInterceptor()),
+	))
+*/
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		return invoker(o.outgoingContext(ctx), method, req, reply, cc, callOpts...)
+	}
+}
+
+// StreamClientInterceptor sets the values of the context of the outgoing streams in their metadata, like
+// UnaryClientInterceptor.
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(o.outgoingContext(ctx), desc, cc, method, callOpts...)
+	}
+}
+
+/*
+UnaryServerInterceptor stores the values of every request in its context, like the Middleware: the tenant ID, the
+locale and the flags of its metadata, the client of its connection, and its principal if WithPrincipalPropagation
+is set. Install it before the logging interceptor, so that its logs carry the values of the request.
+
+Example usage:
+
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		server.UnaryTracingInterceptor(),
+		requestcontext.UnaryServerInterceptor(),
+		server.UnaryLoggingInterceptor(),
+	))
+*/
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(o.incomingContext(ctx), req)
+	}
+}
+
+// StreamServerInterceptor stores the values of every stream in its context, like UnaryServerInterceptor.
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: o.incomingContext(ss.Context())})
+	}
+}
+
+// serverStream is a grpc.ServerStream with the context of the interceptor.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,105 @@
+package requestcontext_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/requestcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	ctx := requestcontext.WithPrincipal(context.Background(), requestcontext.Principal{ID: "user-1"})
+	ctx = requestcontext.WithTenantID(ctx, "acme")
+	ctx = requestcontext.WithFlags(ctx, "beta")
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-tenant-id", "globex", "authorization", "Bearer token")
+
+	var md metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	interceptor := requestcontext.UnaryClientInterceptor(requestcontext.WithPrincipalPropagation())
+	require.NoError(t, interceptor(ctx, "/orders.Orders/Get", nil, nil, nil, invoker))
+
+	assert.Equal(t, []string{"globex"}, md.Get(requestcontext.HeaderTenantID), "the metadata set is left unchanged")
+	assert.Equal(t, []string{"beta"}, md.Get(requestcontext.HeaderFlags))
+	assert.Equal(t, []string{"user-1"}, md.Get(requestcontext.HeaderPrincipalID))
+	assert.Equal(t, []string{"Bearer token"}, md.Get("authorization"))
+
+	original, _ := metadata.FromOutgoingContext(ctx)
+	assert.Empty(t, original.Get(requestcontext.HeaderFlags), "the metadata of ctx is not modified")
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	ctx := requestcontext.WithLocale(context.Background(), "th")
+
+	var md metadata.MD
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil, nil
+	}
+	_, err := requestcontext.StreamClientInterceptor()(ctx, &grpc.StreamDesc{}, nil, "/orders.Orders/Watch", streamer)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"th"}, md.Get(requestcontext.HeaderLocale))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"x-tenant-id", "acme",
+		"accept-language", "th",
+		"x-request-flags", "beta",
+		"x-principal-id", "admin",
+		"user-agent", "grpc-go/1.67.1",
+	))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.7"), Port: 51234}})
+
+	var handled context.Context
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handled = ctx
+		return nil, nil
+	}
+	_, err := requestcontext.UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Get"}, handler)
+	require.NoError(t, err)
+
+	tenantID, _ := requestcontext.TenantIDFromContext(handled)
+	assert.Equal(t, "acme", tenantID)
+	locale, _ := requestcontext.LocaleFromContext(handled)
+	assert.Equal(t, "th", locale)
+	assert.True(t, requestcontext.HasFlag(handled, "beta"))
+	client, _ := requestcontext.ClientFromContext(handled)
+	assert.Equal(t, requestcontext.Client{IP: "10.0.0.7", UserAgent: "grpc-go/1.67.1"}, client)
+	_, ok := requestcontext.PrincipalFromContext(handled)
+	assert.False(t, ok, "the principal metadata is not trusted by default")
+
+	_, err = requestcontext.UnaryServerInterceptor(requestcontext.WithPrincipalPropagation())(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+	p, _ := requestcontext.PrincipalFromContext(handled)
+	assert.Equal(t, "admin", p.ID)
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant-id", "acme"))
+
+	var handled context.Context
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		handled = stream.Context()
+		return nil
+	}
+	err := requestcontext.StreamServerInterceptor()(nil, &serverStream{ctx: ctx}, &grpc.StreamServerInfo{}, handler)
+	require.NoError(t, err)
+	tenantID, _ := requestcontext.TenantIDFromContext(handled)
+	assert.Equal(t, "acme", tenantID)
+}
@@ -0,0 +1,213 @@
+package requestcontext
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Headers carrying the values of the requests between services.
+const (
+	HeaderTenantID    = "X-Tenant-ID"
+	HeaderPrincipalID = "X-Principal-ID"
+	HeaderLocale      = "Accept-Language"
+	// HeaderFlags carries the flags, separated by commas.
+	HeaderFlags = "X-Request-Flags"
+)
+
+// MaxLength is the maximum length of the values accepted from the headers.
+const MaxLength = 128
+
+// options holds configuration options for the Middleware, the Transport and the gRPC interceptors.
+type options struct {
+	propagatePrincipal bool                         // propagatePrincipal trusts and sends the principal headers.
+	clientIP           func(r *http.Request) string // clientIP returns the IP address of the client of a request.
+}
+
+// Option specifies Middleware, Transport and gRPC interceptor configuration options.
+type Option func(*options)
+
+// WithPrincipalPropagation propagates the ID of the principal in the HeaderPrincipalID header: the Transport and
+// the client interceptors send it, and the Middleware and the server interceptors trust it. Only use it between
+// internal services, behind a gateway removing the header from the requests of the clients, since the clients
+// could impersonate any principal otherwise.
+func WithPrincipalPropagation() Option {
+	return func(opts *options) {
+		opts.propagatePrincipal = true
+	}
+}
+
+// WithClientIP sets the function returning the IP address of the client of a request, e.g., from the
+// X-Forwarded-For header behind a proxy. It defaults to the IP address of the client connection.
+func WithClientIP(clientIP func(r *http.Request) string) Option {
+	return func(opts *options) {
+		if clientIP != nil {
+			opts.clientIP = clientIP
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{clientIP: remoteIP}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// remoteIP returns the IP address of the client connection of r.
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// carrier is a set of headers, e.g., http.Header or the metadata of a gRPC request.
+type carrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// inject sets the values of ctx in the headers of c which are not set yet, and reports whether it set any.
+func (o *options) inject(ctx context.Context, c carrier) bool {
+	injected := false
+	set := func(key, value string) {
+		if value != "" && c.Get(key) == "" {
+			c.Set(key, value)
+			injected = true
+		}
+	}
+	if id, ok := TenantIDFromContext(ctx); ok {
+		set(HeaderTenantID, id)
+	}
+	if locale, ok := LocaleFromContext(ctx); ok {
+		set(HeaderLocale, locale)
+	}
+	if flags := FlagsFromContext(ctx); len(flags) > 0 {
+		set(HeaderFlags, strings.Join(flags, ","))
+	}
+	if o.propagatePrincipal {
+		if p, ok := PrincipalFromContext(ctx); ok {
+			set(HeaderPrincipalID, p.ID)
+		}
+	}
+	return injected
+}
+
+// extract returns a copy of ctx carrying the valid values of the headers of c.
+func (o *options) extract(ctx context.Context, c carrier) context.Context {
+	if id := c.Get(HeaderTenantID); valid(id) {
+		ctx = WithTenantID(ctx, id)
+	}
+	if locale := preferredLocale(c.Get(HeaderLocale)); locale != "" {
+		ctx = WithLocale(ctx, locale)
+	}
+	if header := c.Get(HeaderFlags); header != "" {
+		var flags []string
+		for _, flag := range strings.Split(header, ",") {
+			if flag = strings.TrimSpace(flag); valid(flag) {
+				flags = append(flags, flag)
+			}
+		}
+		ctx = WithFlags(ctx, flags...)
+	}
+	if o.propagatePrincipal {
+		if id := c.Get(HeaderPrincipalID); valid(id) {
+			ctx = WithPrincipal(ctx, Principal{ID: id})
+		}
+	}
+	return ctx
+}
+
+// preferredLocale returns the locale of the highest quality of an Accept-Language header, if any.
+func preferredLocale(header string) string {
+	if header == "" {
+		return ""
+	}
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil || len(tags) == 0 || tags[0] == language.Und {
+		return ""
+	}
+	return tags[0].String()
+}
+
+// valid reports whether a value received in a header can be used: it must not be longer than MaxLength, and must
+// only contain visible ASCII characters, so that it cannot forge log lines or headers.
+func valid(value string) bool {
+	if value == "" || len(value) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] <= ' ' || value[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Middleware stores the values of every request in its context: the tenant ID, the locale and the flags of its
+headers, the client of its connection, and its principal if WithPrincipalPropagation is set. The principal of the
+requests authenticated by the auth.Middleware is returned by PrincipalFromContext without it. Install it before
+the other middlewares, so that their logs carry the values of the request.
+
+Example usage:
+
+	handler := requestid.Middleware()(requestcontext.Middleware()(authenticate(mux)))
+
+	func (h *orderHandler) List(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := requestcontext.TenantIDFromContext(r.Context())
+		...
+	}
+*/
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := o.extract(r.Context(), r.Header)
+			ctx = WithClient(ctx, Client{IP: o.clientIP(r), UserAgent: r.UserAgent()})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+/*
+Transport is an http.RoundTripper setting the values of the context of the outgoing requests in their headers,
+so that the downstream services handle them for the same tenant, locale and flags. The headers already set are
+left unchanged. The client is not propagated.
+
+Example usage:
+
+	client := &http.Client{Transport: requestcontext.NewTransport(http.DefaultTransport)}
+*/
+type Transport struct {
+	base    http.RoundTripper
+	options *options
+}
+
+// NewTransport creates a Transport sending the requests with base, or http.DefaultTransport if base is nil.
+// Only the WithPrincipalPropagation option applies.
+func NewTransport(base http.RoundTripper, opts ...Option) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base, options: newOptions(opts)}
+}
+
+// RoundTrip sends req with the values of its context, see http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	header := req.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	if t.options.inject(req.Context(), header) {
+		// RoundTrippers must not modify the request.
+		req = req.Clone(req.Context())
+		req.Header = header
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,130 @@
+package requestcontext_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/requestcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	var ctx context.Context
+	handler := requestcontext.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	r.RemoteAddr = "203.0.113.7:51234"
+	r.Header.Set("User-Agent", "curl/8.0")
+	r.Header.Set(requestcontext.HeaderTenantID, "acme")
+	r.Header.Set(requestcontext.HeaderLocale, "en;q=0.5,th-TH")
+	r.Header.Set(requestcontext.HeaderFlags, "beta, debug,,bad flag")
+	r.Header.Set(requestcontext.HeaderPrincipalID, "admin")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	tenantID, _ := requestcontext.TenantIDFromContext(ctx)
+	assert.Equal(t, "acme", tenantID)
+	locale, _ := requestcontext.LocaleFromContext(ctx)
+	assert.Equal(t, "th-TH", locale, "the locale of the highest quality")
+	assert.Equal(t, []string{"beta", "debug"}, requestcontext.FlagsFromContext(ctx), "the invalid flags are ignored")
+	client, _ := requestcontext.ClientFromContext(ctx)
+	assert.Equal(t, requestcontext.Client{IP: "203.0.113.7", UserAgent: "curl/8.0"}, client)
+	_, ok := requestcontext.PrincipalFromContext(ctx)
+	assert.False(t, ok, "the principal header is not trusted by default")
+}
+
+func TestMiddleware_Options(t *testing.T) {
+	var ctx context.Context
+	handler := requestcontext.Middleware(
+		requestcontext.WithPrincipalPropagation(),
+		requestcontext.WithClientIP(func(r *http.Request) string {
+			return strings.Split(r.Header.Get("X-Forwarded-For"), ",")[0]
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	r.Header.Set("X-Forwarded-For", "198.51.100.1,10.0.0.1")
+	r.Header.Set(requestcontext.HeaderPrincipalID, "service-1")
+	r.Header.Set(requestcontext.HeaderTenantID, strings.Repeat("a", requestcontext.MaxLength+1))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	p, ok := requestcontext.PrincipalFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "service-1", p.ID)
+	client, _ := requestcontext.ClientFromContext(ctx)
+	assert.Equal(t, "198.51.100.1", client.IP)
+	_, ok = requestcontext.TenantIDFromContext(ctx)
+	assert.False(t, ok, "the values longer than MaxLength are ignored")
+	_, ok = requestcontext.LocaleFromContext(ctx)
+	assert.False(t, ok)
+}
+
+func TestTransport(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	ctx := requestcontext.WithPrincipal(context.Background(), requestcontext.Principal{ID: "user-1"})
+	ctx = requestcontext.WithTenantID(ctx, "acme")
+	ctx = requestcontext.WithLocale(ctx, "th")
+	ctx = requestcontext.WithFlags(ctx, "beta", "debug")
+	ctx = requestcontext.WithClient(ctx, requestcontext.Client{IP: "203.0.113.7"})
+
+	client := &http.Client{Transport: requestcontext.NewTransport(nil)}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(requestcontext.HeaderLocale, "en")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "acme", received.Get(requestcontext.HeaderTenantID))
+	assert.Equal(t, "en", received.Get(requestcontext.HeaderLocale), "the headers set are left unchanged")
+	assert.Equal(t, "beta,debug", received.Get(requestcontext.HeaderFlags))
+	assert.Empty(t, received.Get(requestcontext.HeaderPrincipalID), "the principal is not propagated by default")
+	assert.Empty(t, req.Header.Get(requestcontext.HeaderTenantID), "the request is not modified")
+
+	client = &http.Client{Transport: requestcontext.NewTransport(nil, requestcontext.WithPrincipalPropagation())}
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "user-1", received.Get(requestcontext.HeaderPrincipalID))
+	assert.Equal(t, "th", received.Get(requestcontext.HeaderLocale))
+}
+
+func TestMiddlewareTransport_RoundTrip(t *testing.T) {
+	var ctx context.Context
+	downstream := httptest.NewServer(requestcontext.Middleware(requestcontext.WithPrincipalPropagation())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx = r.Context()
+		}),
+	))
+	defer downstream.Close()
+
+	upstream := requestcontext.WithPrincipal(context.Background(), requestcontext.Principal{ID: "user-1"})
+	upstream = requestcontext.WithTenantID(upstream, "acme")
+	upstream = requestcontext.WithFlags(upstream, "beta")
+	client := &http.Client{Transport: requestcontext.NewTransport(nil, requestcontext.WithPrincipalPropagation())}
+	req, err := http.NewRequestWithContext(upstream, http.MethodGet, downstream.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	p, _ := requestcontext.PrincipalFromContext(ctx)
+	assert.Equal(t, "user-1", p.ID)
+	tenantID, _ := requestcontext.TenantIDFromContext(ctx)
+	assert.Equal(t, "acme", tenantID)
+	assert.True(t, requestcontext.HasFlag(ctx, "beta"))
+}
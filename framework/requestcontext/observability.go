@@ -0,0 +1,126 @@
+package requestcontext
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// Fields the values are written to by the Extractor.
+const (
+	LogFieldPrincipalID = "principal_id"
+	LogFieldTenantID    = "tenant_id"
+	LogFieldLocale      = "locale"
+	LogFieldClientIP    = "client_ip"
+	LogFieldFlags       = "flags"
+)
+
+// Attributes the values are recorded as by the SpanProcessor.
+const (
+	AttributeTenantID = attribute.Key("tenant.id")
+	AttributeLocale   = attribute.Key("locale")
+	AttributeFlags    = attribute.Key("request.flags")
+)
+
+/*
+Extractor returns a logger.ContextExtractor writing the values of the context of the entries as the
+"principal_id", "tenant_id", "locale", "client_ip" and "flags" fields, so that every entry logged while handling
+a request tells who it was handled for.
+
+Example usage:
+
+	log, err := logger.NewLogger(logger.Config{
+		Level: logger.INFO,
+		ContextExtractors: []logger.ContextExtractor{
+			requestid.Extractor(),
+			requestcontext.Extractor(),
+		},
+	})
+*/
+func Extractor() logger.ContextExtractor {
+	return func(ctx context.Context) logger.Fields {
+		var fields logger.Fields
+		add := func(field string, value interface{}) {
+			if fields == nil {
+				fields = make(logger.Fields)
+			}
+			fields[field] = value
+		}
+		if p, ok := PrincipalFromContext(ctx); ok {
+			add(LogFieldPrincipalID, p.ID)
+		}
+		if id, ok := TenantIDFromContext(ctx); ok {
+			add(LogFieldTenantID, id)
+		}
+		if locale, ok := LocaleFromContext(ctx); ok {
+			add(LogFieldLocale, locale)
+		}
+		if c, ok := ClientFromContext(ctx); ok && c.IP != "" {
+			add(LogFieldClientIP, c.IP)
+		}
+		if flags := FlagsFromContext(ctx); len(flags) > 0 {
+			add(LogFieldFlags, flags)
+		}
+		return fields
+	}
+}
+
+// attributes returns the attributes of the values of ctx.
+func attributes(ctx context.Context) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if p, ok := PrincipalFromContext(ctx); ok {
+		attrs = append(attrs, semconv.EnduserID(p.ID))
+	}
+	if id, ok := TenantIDFromContext(ctx); ok {
+		attrs = append(attrs, AttributeTenantID.String(id))
+	}
+	if locale, ok := LocaleFromContext(ctx); ok {
+		attrs = append(attrs, AttributeLocale.String(locale))
+	}
+	if c, ok := ClientFromContext(ctx); ok {
+		if c.IP != "" {
+			attrs = append(attrs, semconv.HTTPClientIP(c.IP))
+		}
+		if c.UserAgent != "" {
+			attrs = append(attrs, semconv.UserAgentOriginal(c.UserAgent))
+		}
+	}
+	if flags := FlagsFromContext(ctx); len(flags) > 0 {
+		attrs = append(attrs, AttributeFlags.StringSlice(flags))
+	}
+	return attrs
+}
+
+/*
+SpanProcessor returns an sdktrace.SpanProcessor recording the values of the context a span is started with as
+its attributes: "enduser.id", "tenant.id", "locale", "http.client_ip", "user_agent.original" and
+"request.flags", so that the traces of a tenant or a user can be searched. Register it with the
+trace.Config.SpanProcessors of trace.Setup, or sdktrace.WithSpanProcessor.
+
+Example usage:
+
+	tracerProvider, err := trace.Setup(ctx, trace.Config{
+		ServiceName:    "payments",
+		SpanProcessors: []sdktrace.SpanProcessor{requestcontext.SpanProcessor()},
+	})
+*/
+func SpanProcessor() sdktrace.SpanProcessor {
+	return spanProcessor{}
+}
+
+// spanProcessor is the SpanProcessor recording the values of the contexts.
+type spanProcessor struct{}
+
+func (spanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if attrs := attributes(parent); len(attrs) > 0 {
+		s.SetAttributes(attrs...)
+	}
+}
+
+func (spanProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (spanProcessor) Shutdown(context.Context) error   { return nil }
+func (spanProcessor) ForceFlush(context.Context) error { return nil }
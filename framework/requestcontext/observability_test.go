@@ -0,0 +1,66 @@
+package requestcontext_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/requestcontext"
+)
+
+func newContext() context.Context {
+	ctx := requestcontext.WithPrincipal(context.Background(), requestcontext.Principal{ID: "user-1"})
+	ctx = requestcontext.WithTenantID(ctx, "acme")
+	ctx = requestcontext.WithLocale(ctx, "th")
+	ctx = requestcontext.WithClient(ctx, requestcontext.Client{IP: "203.0.113.7", UserAgent: "curl/8.0"})
+	return requestcontext.WithFlags(ctx, "beta")
+}
+
+func TestExtractor(t *testing.T) {
+	extractor := requestcontext.Extractor()
+	assert.Nil(t, extractor(context.Background()))
+	assert.Equal(t, logger.Fields{
+		requestcontext.LogFieldPrincipalID: "user-1",
+		requestcontext.LogFieldTenantID:    "acme",
+		requestcontext.LogFieldLocale:      "th",
+		requestcontext.LogFieldClientIP:    "203.0.113.7",
+		requestcontext.LogFieldFlags:       []string{"beta"},
+	}, extractor(newContext()))
+}
+
+func TestSpanProcessor(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(requestcontext.SpanProcessor()),
+		sdktrace.WithSyncer(exporter),
+	)
+	defer func() { _ = tracerProvider.Shutdown(context.Background()) }()
+	tracer := tracerProvider.Tracer("test")
+
+	ctx, parent := tracer.Start(newContext(), "handle")
+	_, child := tracer.Start(ctx, "query")
+	child.End()
+	parent.End()
+	_, other := tracer.Start(context.Background(), "background")
+	other.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	expected := []attribute.KeyValue{
+		attribute.String("enduser.id", "user-1"),
+		attribute.String("tenant.id", "acme"),
+		attribute.String("locale", "th"),
+		attribute.String("http.client_ip", "203.0.113.7"),
+		attribute.String("user_agent.original", "curl/8.0"),
+		attribute.StringSlice("request.flags", []string{"beta"}),
+	}
+	assert.ElementsMatch(t, expected, spans[0].Attributes, "the child span")
+	assert.ElementsMatch(t, expected, spans[1].Attributes, "the parent span")
+	assert.Empty(t, spans[2].Attributes)
+}
@@ -0,0 +1,138 @@
+package requestcontext
+
+import (
+	"context"
+	"slices"
+
+	"github.com/kittipat1413/go-common/framework/i18n"
+	"github.com/kittipat1413/go-common/framework/middleware/auth"
+)
+
+// Principal is the authenticated caller of a request, a user or a service.
+type Principal struct {
+	// ID identifies the principal, e.g., the subject of its token.
+	ID string
+	// Scopes are the scopes granted to the principal, if known.
+	Scopes []string
+}
+
+// Client describes the client of a request.
+type Client struct {
+	// IP is the IP address of the client, e.g., "203.0.113.7".
+	IP string
+	// UserAgent is the user agent of the client, e.g., "Mozilla/5.0 ..." or "grpc-go/1.67.1".
+	UserAgent string
+}
+
+// values are the values carried by a context. They are never modified once stored, so that the contexts derived
+// from a context do not affect it.
+type values struct {
+	principal *Principal
+	tenantID  string
+	locale    string
+	client    *Client
+	flags     []string
+}
+
+// contextKey is an unexported type for context keys defined in this package.
+type contextKey struct{}
+
+// load returns a copy of the values carried by ctx.
+func load(ctx context.Context) values {
+	if v, ok := ctx.Value(contextKey{}).(*values); ok {
+		return *v
+	}
+	return values{}
+}
+
+func store(ctx context.Context, v values) context.Context {
+	return context.WithValue(ctx, contextKey{}, &v)
+}
+
+// WithPrincipal returns a copy of ctx carrying the principal p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	v := load(ctx)
+	v.principal = &p
+	return store(ctx, v)
+}
+
+// PrincipalFromContext returns the principal carried by ctx, or else the subject and scopes of the claims stored
+// by the auth.Middleware, if any.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	if p := load(ctx).principal; p != nil {
+		return *p, true
+	}
+	if claims, ok := auth.ClaimsFromContext(ctx); ok && claims.Subject != "" {
+		return Principal{ID: claims.Subject, Scopes: claims.Scopes}, true
+	}
+	return Principal{}, false
+}
+
+// WithTenantID returns a copy of ctx carrying the ID of the tenant of the request.
+func WithTenantID(ctx context.Context, id string) context.Context {
+	v := load(ctx)
+	v.tenantID = id
+	return store(ctx, v)
+}
+
+// TenantIDFromContext returns the tenant ID carried by ctx, if any.
+func TenantIDFromContext(ctx context.Context) (string, bool) {
+	id := load(ctx).tenantID
+	return id, id != ""
+}
+
+// WithLocale returns a copy of ctx carrying the locale of the caller, e.g., "th" or "en-US".
+func WithLocale(ctx context.Context, locale string) context.Context {
+	v := load(ctx)
+	v.locale = locale
+	return store(ctx, v)
+}
+
+// LocaleFromContext returns the locale carried by ctx, or else the locale of the i18n.Localizer stored by the
+// i18n.Middleware, if any.
+func LocaleFromContext(ctx context.Context) (string, bool) {
+	if locale := load(ctx).locale; locale != "" {
+		return locale, true
+	}
+	locale := i18n.FromContext(ctx).Locale()
+	return locale, locale != ""
+}
+
+// WithClient returns a copy of ctx carrying the client c.
+func WithClient(ctx context.Context, c Client) context.Context {
+	v := load(ctx)
+	v.client = &c
+	return store(ctx, v)
+}
+
+// ClientFromContext returns the client carried by ctx, if any.
+func ClientFromContext(ctx context.Context) (Client, bool) {
+	if c := load(ctx).client; c != nil {
+		return *c, true
+	}
+	return Client{}, false
+}
+
+// WithFlags returns a copy of ctx carrying flags in addition to its flags, e.g., "debug" or "beta" enabled for a
+// request and the requests it makes.
+func WithFlags(ctx context.Context, flags ...string) context.Context {
+	v := load(ctx)
+	// The flags of ctx are shared: append to a copy.
+	v.flags = slices.Clip(v.flags)
+	for _, flag := range flags {
+		if flag != "" && !slices.Contains(v.flags, flag) {
+			v.flags = append(v.flags, flag)
+		}
+	}
+	return store(ctx, v)
+}
+
+// FlagsFromContext returns the flags carried by ctx.
+func FlagsFromContext(ctx context.Context) []string {
+	return slices.Clone(load(ctx).flags)
+}
+
+// HasFlag reports whether ctx carries flag.
+func HasFlag(ctx context.Context, flag string) bool {
+	return slices.Contains(load(ctx).flags, flag)
+}
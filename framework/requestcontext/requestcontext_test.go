@@ -0,0 +1,85 @@
+package requestcontext_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/i18n"
+	"github.com/kittipat1413/go-common/framework/middleware/auth"
+	"github.com/kittipat1413/go-common/framework/requestcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	_, ok := requestcontext.PrincipalFromContext(ctx)
+	assert.False(t, ok)
+	_, ok = requestcontext.TenantIDFromContext(ctx)
+	assert.False(t, ok)
+	_, ok = requestcontext.LocaleFromContext(ctx)
+	assert.False(t, ok)
+	_, ok = requestcontext.ClientFromContext(ctx)
+	assert.False(t, ok)
+	assert.Empty(t, requestcontext.FlagsFromContext(ctx))
+
+	ctx = requestcontext.WithPrincipal(ctx, requestcontext.Principal{ID: "user-1", Scopes: []string{"orders:read"}})
+	ctx = requestcontext.WithTenantID(ctx, "acme")
+	ctx = requestcontext.WithLocale(ctx, "th")
+	ctx = requestcontext.WithClient(ctx, requestcontext.Client{IP: "203.0.113.7", UserAgent: "curl/8.0"})
+	ctx = requestcontext.WithFlags(ctx, "beta", "debug", "beta", "")
+
+	p, ok := requestcontext.PrincipalFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, requestcontext.Principal{ID: "user-1", Scopes: []string{"orders:read"}}, p)
+	tenantID, ok := requestcontext.TenantIDFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "acme", tenantID)
+	locale, ok := requestcontext.LocaleFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "th", locale)
+	client, ok := requestcontext.ClientFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, requestcontext.Client{IP: "203.0.113.7", UserAgent: "curl/8.0"}, client)
+	assert.Equal(t, []string{"beta", "debug"}, requestcontext.FlagsFromContext(ctx))
+	assert.True(t, requestcontext.HasFlag(ctx, "debug"))
+	assert.False(t, requestcontext.HasFlag(ctx, "canary"))
+}
+
+func TestContext_Immutable(t *testing.T) {
+	parent := requestcontext.WithFlags(requestcontext.WithTenantID(context.Background(), "acme"), "beta")
+	first := requestcontext.WithFlags(parent, "debug")
+	second := requestcontext.WithFlags(requestcontext.WithTenantID(parent, "globex"), "canary")
+
+	assert.Equal(t, []string{"beta"}, requestcontext.FlagsFromContext(parent))
+	assert.Equal(t, []string{"beta", "debug"}, requestcontext.FlagsFromContext(first))
+	assert.Equal(t, []string{"beta", "canary"}, requestcontext.FlagsFromContext(second))
+	tenantID, _ := requestcontext.TenantIDFromContext(parent)
+	assert.Equal(t, "acme", tenantID, "the derived contexts do not affect their parent")
+
+	requestcontext.FlagsFromContext(parent)[0] = "modified"
+	assert.True(t, requestcontext.HasFlag(parent, "beta"), "the flags returned are a copy")
+}
+
+func TestContext_Fallbacks(t *testing.T) {
+	ctx := auth.NewContext(context.Background(), &auth.Claims{Subject: "user-1", Scopes: []string{"orders:read"}})
+	p, ok := requestcontext.PrincipalFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, requestcontext.Principal{ID: "user-1", Scopes: []string{"orders:read"}}, p, "the principal of the auth claims")
+
+	ctx = requestcontext.WithPrincipal(ctx, requestcontext.Principal{ID: "service-1"})
+	p, _ = requestcontext.PrincipalFromContext(ctx)
+	assert.Equal(t, "service-1", p.ID, "the principal of the context is preferred")
+
+	bundle, err := i18n.NewBundle("en")
+	require.NoError(t, err)
+	require.NoError(t, bundle.AddMessages("th", map[string]string{"greeting": "สวัสดี"}))
+	ctx = i18n.NewContext(context.Background(), bundle.Localizer("th-TH"))
+	locale, ok := requestcontext.LocaleFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "th", locale, "the locale negotiated by i18n")
+
+	ctx = requestcontext.WithLocale(ctx, "en-GB")
+	locale, _ = requestcontext.LocaleFromContext(ctx)
+	assert.Equal(t, "en-GB", locale, "the locale of the context is preferred")
+}
@@ -0,0 +1,44 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff returns the delay to wait before the retry following attempt, where attempt starts at 1.
+type Backoff func(attempt int) time.Duration
+
+// Constant returns a Backoff waiting delay between attempts.
+func Constant(delay time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		return delay
+	}
+}
+
+/*
+Exponential returns a Backoff waiting initial after the first attempt, and multiplier times longer after every
+other attempt. jitter, between 0 and 1, randomizes every delay by up to this fraction, e.g., 0.2 gives delays
+between 80% and 120% of the exponential delay, so that clients failing together do not retry together.
+
+Example usage:
+
+	// Waits about 100ms, 200ms, 400ms, 800ms between attempts.
+	backoff := retry.Exponential(100*time.Millisecond, 2.0, 0.2)
+*/
+func Exponential(initial time.Duration, multiplier, jitter float64) Backoff {
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	jitter = math.Max(0, math.Min(jitter, 1))
+	return func(attempt int) time.Duration {
+		delay := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+		if jitter > 0 {
+			delay *= 1 + jitter*(2*rand.Float64()-1)
+		}
+		if delay >= math.MaxInt64 {
+			return time.Duration(math.MaxInt64)
+		}
+		return time.Duration(delay)
+	}
+}
@@ -0,0 +1,221 @@
+package retry
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/util/clock"
+)
+
+// DefaultHedgeDelay is the delay before launching a hedged attempt unless WithHedgeDelay is set.
+const DefaultHedgeDelay = 50 * time.Millisecond
+
+// minLatencySamples is the number of latencies a LatencyTracker needs to report percentiles.
+const minLatencySamples = 10
+
+/*
+LatencyTracker records the latencies of the latest calls to report their percentiles, e.g., to hedge the calls
+slower than the 95th percentile with WithHedgePercentile. It is safe for concurrent use.
+*/
+type LatencyTracker struct {
+	mutex     sync.Mutex
+	latencies []time.Duration
+	next      int
+	full      bool
+}
+
+// NewLatencyTracker creates a LatencyTracker keeping the latest size latencies. size defaults to 1000.
+func NewLatencyTracker(size int) *LatencyTracker {
+	if size <= 0 {
+		size = 1000
+	}
+	return &LatencyTracker{latencies: make([]time.Duration, size)}
+}
+
+// Record records the latency of a call.
+func (t *LatencyTracker) Record(latency time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.latencies[t.next] = latency
+	t.next = (t.next + 1) % len(t.latencies)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// Percentile returns the p-th percentile, between 0 and 100, of the recorded latencies. It returns false until
+// enough latencies are recorded.
+func (t *LatencyTracker) Percentile(p float64) (time.Duration, bool) {
+	t.mutex.Lock()
+	n := t.next
+	if t.full {
+		n = len(t.latencies)
+	}
+	sorted := append([]time.Duration(nil), t.latencies[:n]...)
+	t.mutex.Unlock()
+
+	if n < minLatencySamples {
+		return 0, false
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	p = math.Max(0, math.Min(p, 100))
+	index := int(math.Ceil(p/100*float64(n))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index], true
+}
+
+// hedgeOptions holds configuration options for Hedge.
+type hedgeOptions struct {
+	delay      time.Duration
+	maxHedges  int
+	tracker    *LatencyTracker
+	percentile float64
+	clock      clock.Clock
+}
+
+// HedgeOption specifies hedging configuration options.
+type HedgeOption func(*hedgeOptions)
+
+// WithHedgeDelay sets the delay before launching every hedged attempt, and the delay used by WithHedgePercentile
+// until its tracker recorded enough latencies.
+func WithHedgeDelay(d time.Duration) HedgeOption {
+	return func(opts *hedgeOptions) {
+		if d > 0 {
+			opts.delay = d
+		}
+	}
+}
+
+// WithMaxHedges sets the number of hedged attempts launched in addition to the first one, 1 by default.
+// Values lower than 1 are ignored.
+func WithMaxHedges(n int) HedgeOption {
+	return func(opts *hedgeOptions) {
+		if n >= 1 {
+			opts.maxHedges = n
+		}
+	}
+}
+
+// WithHedgePercentile launches the hedged attempts after the p-th percentile of the latencies recorded by tracker,
+// e.g., 95 to hedge the slowest 5% of the calls. Hedge records the latencies of the successful attempts in tracker,
+// so share tracker between the calls to the same downstream.
+func WithHedgePercentile(tracker *LatencyTracker, p float64) HedgeOption {
+	return func(opts *hedgeOptions) {
+		opts.tracker = tracker
+		opts.percentile = p
+	}
+}
+
+// WithHedgeClock sets the clock of the hedge delays and of the latencies recorded, e.g., a clock.Fake in tests.
+func WithHedgeClock(c clock.Clock) HedgeOption {
+	return func(opts *hedgeOptions) {
+		if c != nil {
+			opts.clock = c
+		}
+	}
+}
+
+// hedgeDelay returns the delay before launching the next hedged attempt.
+func (o *hedgeOptions) hedgeDelay() time.Duration {
+	if o.tracker != nil {
+		if delay, ok := o.tracker.Percentile(o.percentile); ok {
+			return delay
+		}
+	}
+	return o.delay
+}
+
+/*
+Hedge calls fn, and calls it again if it has not returned after the hedge delay, up to WithMaxHedges times, to
+protect the tail latency of calls to flaky downstreams. It returns the result of the first successful attempt and
+cancels the context of the other attempts, so fn must be idempotent and should honor the context cancellation.
+
+An attempt failing launches the next hedged attempt at once. A permanent error, see framework/errors.IsPermanent,
+is returned at once. If all attempts fail, Hedge returns the error of the last one.
+
+Example usage:
+
+	tracker := retry.NewLatencyTracker(1000)
+
+	user, err := retry.Hedge(ctx, func(ctx context.Context) (*User, error) {
+		return client.GetUser(ctx, id)
+	}, retry.WithHedgePercentile(tracker, 95), retry.WithMaxHedges(2))
+*/
+func Hedge[T any](ctx context.Context, fn func(ctx context.Context) (T, error), opts ...HedgeOption) (T, error) {
+	o := &hedgeOptions{
+		delay:     DefaultHedgeDelay,
+		maxHedges: 1,
+		clock:     clock.Real(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+	attempts := 1 + o.maxHedges
+	// Buffered so that the attempts still running on return do not block.
+	results := make(chan result, attempts)
+	launched, pending := 0, 0
+	launch := func() {
+		launched++
+		pending++
+		start := o.clock.Now()
+		go func() {
+			value, err := fn(ctx)
+			if err == nil && o.tracker != nil {
+				o.tracker.Record(o.clock.Since(start))
+			}
+			results <- result{value: value, err: err}
+		}()
+	}
+
+	launch()
+	timer := o.clock.NewTimer(o.hedgeDelay())
+	defer timer.Stop()
+
+	var zero T
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.value, nil
+			}
+			if errors.IsPermanent(r.err) {
+				return zero, r.err
+			}
+			if launched < attempts {
+				launch()
+				if !timer.Stop() {
+					select {
+					case <-timer.C():
+					default:
+					}
+				}
+				timer.Reset(o.hedgeDelay())
+			} else if pending == 0 {
+				return zero, r.err
+			}
+		case <-timer.C():
+			if launched < attempts {
+				launch()
+				timer.Reset(o.hedgeDelay())
+			}
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedge(t *testing.T) {
+	t.Run("fast call is not hedged", func(t *testing.T) {
+		var calls atomic.Int32
+		value, err := retry.Hedge(context.Background(), func(ctx context.Context) (string, error) {
+			calls.Add(1)
+			return "ok", nil
+		}, retry.WithHedgeDelay(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, "ok", value)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("slow call is hedged and cancelled", func(t *testing.T) {
+		var calls atomic.Int32
+		cancelled := make(chan struct{})
+		value, err := retry.Hedge(context.Background(), func(ctx context.Context) (int32, error) {
+			call := calls.Add(1)
+			if call == 1 {
+				<-ctx.Done()
+				close(cancelled)
+				return 0, ctx.Err()
+			}
+			return call, nil
+		}, retry.WithHedgeDelay(10*time.Millisecond))
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), value)
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Fatal("the slow attempt should be cancelled")
+		}
+	})
+
+	t.Run("failure launches the next attempt at once", func(t *testing.T) {
+		var calls atomic.Int32
+		start := time.Now()
+		value, err := retry.Hedge(context.Background(), func(ctx context.Context) (int32, error) {
+			if call := calls.Add(1); call < 3 {
+				return 0, errors.New("unavailable")
+			}
+			return 3, nil
+		}, retry.WithHedgeDelay(time.Hour), retry.WithMaxHedges(2))
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), value)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("all attempts fail", func(t *testing.T) {
+		var calls atomic.Int32
+		_, err := retry.Hedge(context.Background(), func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			return 0, errors.New("unavailable")
+		}, retry.WithMaxHedges(2))
+		assert.EqualError(t, err, "unavailable")
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("permanent error is not hedged", func(t *testing.T) {
+		var calls atomic.Int32
+		notFound := domain_error.NotFound("user.not_found", "user not found")
+		_, err := retry.Hedge(context.Background(), func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			return 0, notFound
+		})
+		assert.ErrorIs(t, err, notFound)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := retry.Hedge(ctx, func(ctx context.Context) (int, error) {
+			time.Sleep(time.Second)
+			return 0, nil
+		}, retry.WithHedgeDelay(time.Millisecond))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestLatencyTracker(t *testing.T) {
+	tracker := retry.NewLatencyTracker(100)
+	_, ok := tracker.Percentile(95)
+	assert.False(t, ok)
+
+	for i := 1; i <= 100; i++ {
+		tracker.Record(time.Duration(i) * time.Millisecond)
+	}
+	p95, ok := tracker.Percentile(95)
+	require.True(t, ok)
+	assert.Equal(t, 95*time.Millisecond, p95)
+
+	// The oldest latencies are replaced.
+	for i := 0; i < 100; i++ {
+		tracker.Record(time.Millisecond)
+	}
+	p95, _ = tracker.Percentile(95)
+	assert.Equal(t, time.Millisecond, p95)
+
+	value, err := retry.Hedge(context.Background(), func(ctx context.Context) (string, error) {
+		return "ok", nil
+	}, retry.WithHedgePercentile(tracker, 95))
+	require.NoError(t, err)
+	assert.Equal(t, "ok", value)
+}
@@ -0,0 +1,174 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/util/clock"
+)
+
+const (
+	// DefaultMaxAttempts is the number of attempts made by Do unless WithMaxAttempts is set.
+	DefaultMaxAttempts = 3
+	// DefaultDelay is the delay between attempts of Do unless WithBackoff is set.
+	DefaultDelay = 100 * time.Millisecond
+)
+
+// options holds configuration options for Do.
+type options struct {
+	maxAttempts int
+	backoff     Backoff
+	maxDelay    time.Duration
+	retryIf     func(err error) bool
+	onRetry     func(attempt int, err error, delay time.Duration)
+	clock       clock.Clock
+}
+
+// Option specifies retry configuration options.
+type Option func(*options)
+
+// WithMaxAttempts sets the maximum number of attempts, including the first one. Values lower than 1 are ignored.
+func WithMaxAttempts(n int) Option {
+	return func(opts *options) {
+		if n >= 1 {
+			opts.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff sets the delays between attempts, e.g., Exponential.
+func WithBackoff(backoff Backoff) Option {
+	return func(opts *options) {
+		if backoff != nil {
+			opts.backoff = backoff
+		}
+	}
+}
+
+// WithMaxDelay caps the delays returned by the backoff to d.
+func WithMaxDelay(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.maxDelay = d
+		}
+	}
+}
+
+// WithRetryIf sets the function telling whether an error is retried. By default, all errors are retried except
+// the permanent ones, see framework/errors.IsPermanent. Use errors.Retryable to retry known transient errors only.
+func WithRetryIf(retryIf func(err error) bool) Option {
+	return func(opts *options) {
+		if retryIf != nil {
+			opts.retryIf = retryIf
+		}
+	}
+}
+
+// WithOnRetry sets a function called before waiting for the next attempt, e.g., to log the error.
+func WithOnRetry(onRetry func(attempt int, err error, delay time.Duration)) Option {
+	return func(opts *options) {
+		opts.onRetry = onRetry
+	}
+}
+
+// WithClock sets the clock waiting between attempts, e.g., a clock.Fake to test the backoff without sleeping.
+func WithClock(c clock.Clock) Option {
+	return func(opts *options) {
+		if c != nil {
+			opts.clock = c
+		}
+	}
+}
+
+// Error is returned by Do when fn did not succeed. It wraps the error of the last attempt, and the context error if
+// the context was done before the last attempt.
+type Error struct {
+	// Attempts is the number of attempts made.
+	Attempts int
+	// Err is the error returned by the last attempt.
+	Err error
+	// ContextErr is the error of the context if it was done before the last attempt.
+	ContextErr error
+}
+
+func (e *Error) Error() string {
+	if e.ContextErr != nil {
+		return fmt.Sprintf("retry: %v after %d attempts: %v", e.ContextErr, e.Attempts, e.Err)
+	}
+	return fmt.Sprintf("retry: giving up after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the error of the last attempt and the context error, for errors.Is and errors.As.
+func (e *Error) Unwrap() []error {
+	if e.ContextErr != nil {
+		return []error{e.Err, e.ContextErr}
+	}
+	return []error{e.Err}
+}
+
+/*
+Do calls fn until it succeeds, returns an error not retried, or the attempts are exhausted, waiting between attempts
+as given by the backoff. It stops waiting as soon as ctx is done. If fn does not succeed, Do returns an *Error with
+the number of attempts and the error of the last attempt.
+
+Example usage:
+
+	err := retry.Do(ctx, func(ctx context.Context) error {
+		return client.Charge(ctx, req)
+	},
+		retry.WithMaxAttempts(5),
+		retry.WithBackoff(retry.Exponential(100*time.Millisecond, 2.0, 0.2)),
+		retry.WithRetryIf(errors.Retryable),
+	)
+*/
+func Do(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	_, err := DoValue(ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	}, opts...)
+	return err
+}
+
+// DoValue is like Do for functions returning a value, and returns the value of the successful attempt.
+func DoValue[T any](ctx context.Context, fn func(ctx context.Context) (T, error), opts ...Option) (T, error) {
+	o := &options{
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     Constant(DefaultDelay),
+		retryIf:     func(err error) bool { return !errors.IsPermanent(err) },
+		clock:       clock.Real(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var zero T
+	for attempt := 1; ; attempt++ {
+		value, err := fn(ctx)
+		if err == nil {
+			return value, nil
+		}
+		if attempt >= o.maxAttempts || !o.retryIf(err) {
+			return zero, &Error{Attempts: attempt, Err: err}
+		}
+		if ctx.Err() != nil {
+			return zero, &Error{Attempts: attempt, Err: err, ContextErr: ctx.Err()}
+		}
+
+		delay := o.backoff(attempt)
+		if o.maxDelay > 0 && delay > o.maxDelay {
+			delay = o.maxDelay
+		}
+		if o.onRetry != nil {
+			o.onRetry(attempt, err, delay)
+		}
+
+		timer := o.clock.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, &Error{Attempts: attempt, Err: err, ContextErr: ctx.Err()}
+		case <-timer.C():
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/retry"
+	"github.com/kittipat1413/go-common/util/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDo(t *testing.T) {
+	unavailable := errors.New("unavailable")
+	tests := []struct {
+		name             string
+		errs             []error
+		options          []retry.Option
+		expectedAttempts int
+		expectedErr      error
+	}{
+		{name: "success", errs: nil, expectedAttempts: 1},
+		{name: "success after retries", errs: []error{unavailable, unavailable}, expectedAttempts: 3},
+		{
+			name:             "attempts exhausted",
+			errs:             []error{unavailable, unavailable, unavailable, unavailable},
+			options:          []retry.Option{retry.WithMaxAttempts(2)},
+			expectedAttempts: 2,
+			expectedErr:      unavailable,
+		},
+		{
+			name:             "permanent error",
+			errs:             []error{domain_error.MarkPermanent(unavailable)},
+			expectedAttempts: 1,
+			expectedErr:      unavailable,
+		},
+		{
+			name:             "retry if",
+			errs:             []error{domain_error.MarkRetryable(unavailable), unavailable},
+			options:          []retry.Option{retry.WithRetryIf(domain_error.Retryable)},
+			expectedAttempts: 2,
+			expectedErr:      unavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			options := append([]retry.Option{retry.WithBackoff(retry.Constant(time.Millisecond))}, tt.options...)
+			err := retry.Do(context.Background(), func(ctx context.Context) error {
+				attempts++
+				if attempts <= len(tt.errs) {
+					return tt.errs[attempts-1]
+				}
+				return nil
+			}, options...)
+
+			assert.Equal(t, tt.expectedAttempts, attempts)
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.expectedErr)
+			var retryErr *retry.Error
+			require.ErrorAs(t, err, &retryErr)
+			assert.Equal(t, tt.expectedAttempts, retryErr.Attempts)
+		})
+	}
+}
+
+func TestDo_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	unavailable := errors.New("unavailable")
+	var retried []time.Duration
+	err := retry.Do(ctx, func(ctx context.Context) error {
+		return unavailable
+	},
+		retry.WithMaxAttempts(10),
+		retry.WithBackoff(retry.Constant(time.Hour)),
+		retry.WithOnRetry(func(attempt int, err error, delay time.Duration) {
+			retried = append(retried, delay)
+			cancel()
+		}),
+	)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, err, unavailable)
+	assert.Equal(t, "retry: context canceled after 1 attempts: unavailable", err.Error())
+	assert.Equal(t, []time.Duration{time.Hour}, retried)
+}
+
+func TestDo_Clock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	attempts := 0
+	done := make(chan error)
+	go func() {
+		done <- retry.Do(context.Background(), func(ctx context.Context) error {
+			attempts++
+			return errors.New("unavailable")
+		}, retry.WithBackoff(retry.Constant(time.Hour)), retry.WithClock(fake))
+	}()
+
+	// The attempts wait for the clock, without sleeping.
+	for range retry.DefaultMaxAttempts - 1 {
+		fake.BlockUntil(1)
+		fake.Advance(time.Hour)
+	}
+	err := <-done
+	assert.Equal(t, retry.DefaultMaxAttempts, attempts)
+	var retryErr *retry.Error
+	require.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, retry.DefaultMaxAttempts, retryErr.Attempts)
+}
+
+func TestDoValue(t *testing.T) {
+	attempts := 0
+	value, err := retry.DoValue(context.Background(), func(ctx context.Context) (string, error) {
+		attempts++
+		if attempts < 2 {
+			return "", errors.New("unavailable")
+		}
+		return "ok", nil
+	}, retry.WithBackoff(retry.Constant(0)))
+	require.NoError(t, err)
+	assert.Equal(t, "ok", value)
+}
+
+func TestBackoff(t *testing.T) {
+	exponential := retry.Exponential(100*time.Millisecond, 2.0, 0)
+	assert.Equal(t, 100*time.Millisecond, exponential(1))
+	assert.Equal(t, 200*time.Millisecond, exponential(2))
+	assert.Equal(t, 800*time.Millisecond, exponential(4))
+
+	jittered := retry.Exponential(100*time.Millisecond, 2.0, 0.2)
+	for i := 0; i < 100; i++ {
+		delay := jittered(2)
+		assert.GreaterOrEqual(t, delay, 160*time.Millisecond)
+		assert.LessOrEqual(t, delay, 240*time.Millisecond)
+	}
+
+	var delays []time.Duration
+	_ = retry.Do(context.Background(), func(ctx context.Context) error {
+		return errors.New("unavailable")
+	},
+		retry.WithMaxAttempts(3),
+		retry.WithBackoff(retry.Exponential(time.Millisecond, 10, 0)),
+		retry.WithMaxDelay(5*time.Millisecond),
+		retry.WithOnRetry(func(attempt int, err error, delay time.Duration) { delays = append(delays, delay) }),
+	)
+	assert.Equal(t, []time.Duration{time.Millisecond, 5 * time.Millisecond}, delays)
+}
@@ -0,0 +1,130 @@
+package gcsclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	gcs "cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+
+	"github.com/kittipat1413/go-common/framework/storage"
+	"github.com/kittipat1413/go-common/framework/storage/gcsstorage"
+)
+
+var _ gcsstorage.Client = (*Client)(nil)
+
+/*
+Client is the gcsstorage.Client of the Google Cloud Storage client. It is a module of its own, so that the services
+using gcsstorage with another client do not depend on the Google Cloud libraries.
+
+The signed URLs are signed with the credentials of the client, see gcs.BucketHandle.SignedURL.
+
+Example usage:
+
+	client, err := gcs.NewClient(ctx)
+	if err != nil {
+		// Handle error
+	}
+	store := gcsstorage.New(gcsclient.New(client), "uploads", gcsstorage.WithNamePrefix("billing/"))
+*/
+type Client struct {
+	client *gcs.Client
+}
+
+// New creates the gcsstorage.Client of client. The client is not owned by it: close it after the last call.
+func New(client *gcs.Client) *Client {
+	return &Client{client: client}
+}
+
+// Upload writes body as the object name of bucket, and returns its description.
+func (c *Client) Upload(ctx context.Context, bucket, name string, body io.Reader, opts storage.PutOptions) (storage.ObjectInfo, error) {
+	writer := c.client.Bucket(bucket).Object(name).NewWriter(ctx)
+	writer.ContentType = opts.ContentType
+	writer.CacheControl = opts.CacheControl
+	writer.Metadata = opts.Metadata
+	if _, err := io.Copy(writer, body); err != nil {
+		writer.CloseWithError(err)
+		return storage.ObjectInfo{}, err
+	}
+	if err := writer.Close(); err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	return objectInfo(writer.Attrs()), nil
+}
+
+// Download returns the content and the description of the object name of bucket, or storage.ErrNotFound.
+func (c *Client) Download(ctx context.Context, bucket, name string) (io.ReadCloser, storage.ObjectInfo, error) {
+	object := c.client.Bucket(bucket).Object(name)
+	attrs, err := object.Attrs(ctx)
+	if err != nil {
+		return nil, storage.ObjectInfo{}, notFound(err)
+	}
+	// Read the generation described, even if the object is replaced meanwhile.
+	reader, err := object.Generation(attrs.Generation).NewReader(ctx)
+	if err != nil {
+		return nil, storage.ObjectInfo{}, notFound(err)
+	}
+	return reader, objectInfo(attrs), nil
+}
+
+// Attrs returns the description of the object name of bucket, or storage.ErrNotFound.
+func (c *Client) Attrs(ctx context.Context, bucket, name string) (storage.ObjectInfo, error) {
+	attrs, err := c.client.Bucket(bucket).Object(name).Attrs(ctx)
+	if err != nil {
+		return storage.ObjectInfo{}, notFound(err)
+	}
+	return objectInfo(attrs), nil
+}
+
+// Delete deletes the object name of bucket, or returns storage.ErrNotFound.
+func (c *Client) Delete(ctx context.Context, bucket, name string) error {
+	return notFound(c.client.Bucket(bucket).Object(name).Delete(ctx))
+}
+
+// List returns up to limit objects of bucket whose name starts with prefix, from the page token, and the token of
+// the next page, or empty on the last page.
+func (c *Client) List(ctx context.Context, bucket, prefix, pageToken string, limit int) ([]storage.ObjectInfo, string, error) {
+	var attrs []*gcs.ObjectAttrs
+	it := c.client.Bucket(bucket).Objects(ctx, &gcs.Query{Prefix: prefix})
+	next, err := iterator.NewPager(it, limit, pageToken).NextPage(&attrs)
+	if err != nil {
+		return nil, "", err
+	}
+	objects := make([]storage.ObjectInfo, len(attrs))
+	for i, a := range attrs {
+		objects[i] = objectInfo(a)
+	}
+	return objects, next, nil
+}
+
+// SignedURL returns a V4 signed URL of the object name of bucket for method, GET or PUT, valid for expiry.
+func (c *Client) SignedURL(ctx context.Context, bucket, name, method string, expiry time.Duration) (string, error) {
+	return c.client.Bucket(bucket).SignedURL(name, &gcs.SignedURLOptions{
+		Scheme:  gcs.SigningSchemeV4,
+		Method:  method,
+		Expires: time.Now().Add(expiry),
+	})
+}
+
+// objectInfo returns the description of the object of attrs.
+func objectInfo(attrs *gcs.ObjectAttrs) storage.ObjectInfo {
+	return storage.ObjectInfo{
+		Key:          attrs.Name,
+		Size:         attrs.Size,
+		ContentType:  attrs.ContentType,
+		CacheControl: attrs.CacheControl,
+		ETag:         attrs.Etag,
+		LastModified: attrs.Updated,
+		Metadata:     attrs.Metadata,
+	}
+}
+
+// notFound returns storage.ErrNotFound for the errors of the missing objects, or err.
+func notFound(err error) error {
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return storage.ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package gcsclient_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/storage"
+	"github.com/kittipat1413/go-common/framework/storage/gcsstorage"
+	"github.com/kittipat1413/go-common/framework/storage/gcsstorage/gcsclient"
+)
+
+// newStore returns a store of the bucket "uploads" of an in-memory Google Cloud Storage server.
+func newStore(t *testing.T) *gcsstorage.Store {
+	server, err := fakestorage.NewServerWithOptions(fakestorage.Options{NoListener: false, Scheme: "http"})
+	require.NoError(t, err)
+	t.Cleanup(server.Stop)
+	server.CreateBucketWithOpts(fakestorage.CreateBucketOpts{Name: "uploads"})
+	return gcsstorage.New(gcsclient.New(server.Client()), "uploads", gcsstorage.WithNamePrefix("billing/"))
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t)
+
+	info, err := store.Put(ctx, "invoices/1.txt", strings.NewReader("hello, world"),
+		storage.WithContentType("text/plain"),
+		storage.WithCacheControl("max-age=60"),
+		storage.WithMetadata(map[string]string{"owner": "billing"}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "invoices/1.txt", info.Key)
+	assert.Equal(t, int64(12), info.Size)
+	assert.NotEmpty(t, info.ETag)
+
+	object, err := store.Get(ctx, "invoices/1.txt")
+	require.NoError(t, err)
+	content, err := io.ReadAll(object)
+	require.NoError(t, err)
+	require.NoError(t, object.Close())
+	assert.Equal(t, "hello, world", string(content))
+	assert.Equal(t, "invoices/1.txt", object.Info.Key)
+	assert.Equal(t, "text/plain", object.Info.ContentType)
+
+	stat, err := store.Stat(ctx, "invoices/1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), stat.Size)
+	assert.Equal(t, "max-age=60", stat.CacheControl)
+	assert.Equal(t, "billing", stat.Metadata["owner"])
+	assert.Equal(t, info.ETag, stat.ETag)
+
+	require.NoError(t, store.Delete(ctx, "invoices/1.txt"))
+	_, err = store.Get(ctx, "invoices/1.txt")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = store.Stat(ctx, "invoices/1.txt")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	require.NoError(t, store.Delete(ctx, "invoices/1.txt"), "deleting a missing object succeeds")
+}
+
+func TestStore_List(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t)
+	for _, key := range []string{"invoices/1.txt", "invoices/2.txt", "invoices/3.txt", "receipts/1.txt"} {
+		_, err := store.Put(ctx, key, strings.NewReader(key))
+		require.NoError(t, err)
+	}
+
+	page, err := store.List(ctx, "invoices/", storage.WithLimit(2))
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 2)
+	assert.Equal(t, "invoices/1.txt", page.Objects[0].Key)
+	assert.Equal(t, "invoices/2.txt", page.Objects[1].Key)
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = store.List(ctx, "invoices/", storage.WithLimit(2), storage.WithCursor(page.NextCursor))
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, "invoices/3.txt", page.Objects[0].Key)
+	assert.Empty(t, page.NextCursor)
+}
@@ -0,0 +1,157 @@
+package gcsstorage
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/storage"
+)
+
+/*
+Client is the subset of Google Cloud Storage operations used by the store, on the objects of a bucket. Download,
+Attrs and Delete return storage.ErrNotFound for the missing objects. It is implemented with the Google Cloud Storage
+client by the gcsclient module:
+
+	import "github.com/kittipat1413/go-common/framework/storage/gcsstorage/gcsclient"
+
+	store := gcsstorage.New(gcsclient.New(client), "uploads")
+*/
+type Client interface {
+	// Upload writes body as the object name of bucket, and returns its description.
+	Upload(ctx context.Context, bucket, name string, body io.Reader, opts storage.PutOptions) (storage.ObjectInfo, error)
+	// Download returns the content and the description of the object name of bucket, or storage.ErrNotFound.
+	Download(ctx context.Context, bucket, name string) (io.ReadCloser, storage.ObjectInfo, error)
+	// Attrs returns the description of the object name of bucket, or storage.ErrNotFound.
+	Attrs(ctx context.Context, bucket, name string) (storage.ObjectInfo, error)
+	// Delete deletes the object name of bucket, or returns storage.ErrNotFound.
+	Delete(ctx context.Context, bucket, name string) error
+	// List returns up to limit objects of bucket whose name starts with prefix, sorted by name, from the page
+	// token, or the first one if empty, and the token of the next page, or empty on the last page.
+	List(ctx context.Context, bucket, prefix, pageToken string, limit int) ([]storage.ObjectInfo, string, error)
+	// SignedURL returns a V4 signed URL of the object name of bucket for method, GET or PUT, valid for expiry.
+	SignedURL(ctx context.Context, bucket, name, method string, expiry time.Duration) (string, error)
+}
+
+// config holds configuration options for the store.
+type config struct {
+	namePrefix string
+}
+
+// Option specifies store configuration options.
+type Option func(*config)
+
+// WithNamePrefix prepends prefix to the names of the objects in the bucket, e.g., "billing/" to share a bucket with
+// other services. The keys of the store do not include it.
+func WithNamePrefix(prefix string) Option {
+	return func(c *config) {
+		c.namePrefix = prefix
+	}
+}
+
+/*
+Store is a storage.Store keeping the objects in a Google Cloud Storage bucket.
+
+Example usage:
+
+	client, err := gcs.NewClient(ctx)
+	if err != nil {
+		// Handle error
+	}
+	store := gcsstorage.New(gcsclient.New(client), "uploads", gcsstorage.WithNamePrefix("billing/"))
+*/
+type Store struct {
+	client Client
+	bucket string
+	cfg    config
+}
+
+var _ storage.Store = (*Store)(nil)
+
+// New creates a Store of the objects of bucket.
+func New(client Client, bucket string, opts ...Option) *Store {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Store{client: client, bucket: bucket, cfg: cfg}
+}
+
+// Put implements the storage.Store interface.
+func (s *Store) Put(ctx context.Context, key string, body io.Reader, opts ...storage.PutOption) (storage.ObjectInfo, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	info, err := s.client.Upload(ctx, s.bucket, s.cfg.namePrefix+key, body, storage.NewPutOptions(opts...))
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	return s.info(info), nil
+}
+
+// Get implements the storage.Store interface.
+func (s *Store) Get(ctx context.Context, key string) (*storage.Object, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return nil, err
+	}
+	body, info, err := s.client.Download(ctx, s.bucket, s.cfg.namePrefix+key)
+	if err != nil {
+		return nil, err
+	}
+	return &storage.Object{ReadCloser: body, Info: s.info(info)}, nil
+}
+
+// Stat implements the storage.Store interface.
+func (s *Store) Stat(ctx context.Context, key string) (storage.ObjectInfo, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	info, err := s.client.Attrs(ctx, s.bucket, s.cfg.namePrefix+key)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	return s.info(info), nil
+}
+
+// Delete implements the storage.Store interface.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	if err := storage.ValidateKey(key); err != nil {
+		return err
+	}
+	err := s.client.Delete(ctx, s.bucket, s.cfg.namePrefix+key)
+	if stderrors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// List implements the storage.Store interface. The cursor is the page token of GCS.
+func (s *Store) List(ctx context.Context, prefix string, opts ...storage.ListOption) (storage.ListPage, error) {
+	o := storage.NewListOptions(opts...)
+	objects, token, err := s.client.List(ctx, s.bucket, s.cfg.namePrefix+prefix, o.Cursor, o.Limit)
+	if err != nil {
+		return storage.ListPage{}, err
+	}
+	page := storage.ListPage{Objects: make([]storage.ObjectInfo, len(objects)), NextCursor: token}
+	for i, info := range objects {
+		page.Objects[i] = s.info(info)
+	}
+	return page, nil
+}
+
+// SignedURL implements the storage.Store interface, with a V4 signed URL.
+func (s *Store) SignedURL(ctx context.Context, key string, opts ...storage.SignOption) (string, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return "", err
+	}
+	o := storage.NewSignOptions(opts...)
+	return s.client.SignedURL(ctx, s.bucket, s.cfg.namePrefix+key, o.Method, o.Expiry)
+}
+
+// info returns info with the key of the store.
+func (s *Store) info(info storage.ObjectInfo) storage.ObjectInfo {
+	info.Key = strings.TrimPrefix(info.Key, s.cfg.namePrefix)
+	return info
+}
@@ -0,0 +1,192 @@
+package gcsstorage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/storage"
+	"github.com/kittipat1413/go-common/framework/storage/gcsstorage"
+)
+
+// fakeClient emulates the objects of a GCS bucket, with the offsets of the objects as page tokens.
+type fakeClient struct {
+	mutex   sync.Mutex
+	objects map[string]storage.ObjectInfo // objects are the descriptions of the objects, by name.
+	content map[string]string
+	err     error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{objects: make(map[string]storage.ObjectInfo), content: make(map[string]string)}
+}
+
+func (c *fakeClient) Upload(_ context.Context, _, name string, body io.Reader, opts storage.PutOptions) (storage.ObjectInfo, error) {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	info := storage.ObjectInfo{
+		Key:          name,
+		Size:         int64(len(content)),
+		ContentType:  opts.ContentType,
+		CacheControl: opts.CacheControl,
+		ETag:         fmt.Sprintf("CJ%d", len(c.objects)+1),
+		LastModified: time.Now(),
+		Metadata:     opts.Metadata,
+	}
+	c.objects[name] = info
+	c.content[name] = string(content)
+	return info, nil
+}
+
+func (c *fakeClient) Download(ctx context.Context, bucket, name string) (io.ReadCloser, storage.ObjectInfo, error) {
+	info, err := c.Attrs(ctx, bucket, name)
+	if err != nil {
+		return nil, storage.ObjectInfo{}, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return io.NopCloser(strings.NewReader(c.content[name])), info, nil
+}
+
+func (c *fakeClient) Attrs(_ context.Context, _, name string) (storage.ObjectInfo, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	info, ok := c.objects[name]
+	if !ok {
+		return storage.ObjectInfo{}, storage.ErrNotFound
+	}
+	return info, nil
+}
+
+func (c *fakeClient) Delete(_ context.Context, _, name string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	if _, ok := c.objects[name]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(c.objects, name)
+	delete(c.content, name)
+	return nil
+}
+
+func (c *fakeClient) List(_ context.Context, _, prefix, pageToken string, limit int) ([]storage.ObjectInfo, string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var objects []storage.ObjectInfo
+	for name, info := range c.objects {
+		if strings.HasPrefix(name, prefix) {
+			objects = append(objects, info)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	offset := 0
+	if pageToken != "" {
+		var err error
+		if offset, err = strconv.Atoi(pageToken); err != nil {
+			return nil, "", err
+		}
+	}
+	objects = objects[offset:]
+	if len(objects) <= limit {
+		return objects, "", nil
+	}
+	return objects[:limit], strconv.Itoa(offset + limit), nil
+}
+
+func (c *fakeClient) SignedURL(_ context.Context, bucket, name, method string, expiry time.Duration) (string, error) {
+	return fmt.Sprintf("https://storage.googleapis.com/%s/%s?method=%s&expires=%d", bucket, name, method, int(expiry.Seconds())), nil
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	store := gcsstorage.New(client, "uploads", gcsstorage.WithNamePrefix("billing/"))
+
+	info, err := store.Put(ctx, "invoices/1.pdf", strings.NewReader("%PDF"),
+		storage.WithContentType("application/pdf"),
+		storage.WithCacheControl("private"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "invoices/1.pdf", info.Key, "the key does not include the prefix")
+	assert.Equal(t, int64(4), info.Size)
+	assert.Equal(t, "application/pdf", info.ContentType)
+	assert.Equal(t, "private", info.CacheControl)
+	assert.Equal(t, "CJ1", info.ETag)
+	assert.Contains(t, client.objects, "billing/invoices/1.pdf", "the name is prefixed in the bucket")
+
+	object, err := store.Get(ctx, "invoices/1.pdf")
+	require.NoError(t, err)
+	content, err := io.ReadAll(object)
+	require.NoError(t, err)
+	require.NoError(t, object.Close())
+	assert.Equal(t, "%PDF", string(content))
+	assert.Equal(t, info, object.Info)
+
+	stat, err := store.Stat(ctx, "invoices/1.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, info, stat)
+	_, err = store.Stat(ctx, "invoices/2.pdf")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	require.NoError(t, store.Delete(ctx, "invoices/1.pdf"))
+	require.NoError(t, store.Delete(ctx, "invoices/1.pdf"), "deleting a missing object succeeds")
+	client.err = errors.New("unavailable")
+	assert.ErrorIs(t, store.Delete(ctx, "invoices/1.pdf"), client.err)
+}
+
+func TestStore_List(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	store := gcsstorage.New(client, "uploads", gcsstorage.WithNamePrefix("billing/"))
+	for _, key := range []string{"invoices/3.pdf", "invoices/1.pdf", "invoices/2.pdf", "receipts/1.pdf"} {
+		_, err := store.Put(ctx, key, strings.NewReader(key))
+		require.NoError(t, err)
+	}
+
+	var keys []string
+	err := storage.Walk(ctx, store, "invoices/", func(info storage.ObjectInfo) error {
+		keys = append(keys, info.Key)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"invoices/1.pdf", "invoices/2.pdf", "invoices/3.pdf"}, keys)
+
+	page, err := store.List(ctx, "", storage.WithLimit(3))
+	require.NoError(t, err)
+	assert.Len(t, page.Objects, 3)
+	assert.Equal(t, "3", page.NextCursor, "the page token of GCS")
+	page, err = store.List(ctx, "", storage.WithLimit(3), storage.WithCursor(page.NextCursor))
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, "receipts/1.pdf", page.Objects[0].Key)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestStore_SignedURL(t *testing.T) {
+	ctx := context.Background()
+	store := gcsstorage.New(newFakeClient(), "uploads")
+
+	signed, err := store.SignedURL(ctx, "invoices/1.pdf", storage.WithMethod(http.MethodPut), storage.WithExpiry(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "https://storage.googleapis.com/uploads/invoices/1.pdf?method=PUT&expires=3600", signed)
+	_, err = store.SignedURL(ctx, "invoices/")
+	assert.ErrorIs(t, err, storage.ErrInvalidKey)
+}
@@ -0,0 +1,435 @@
+package localstorage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/storage"
+)
+
+// Query parameters of the signed URLs.
+const (
+	paramExpires   = "X-Expires"
+	paramMethod    = "X-Method"
+	paramSignature = "X-Signature"
+)
+
+// Directories of the root directory of a Store.
+const (
+	objectsDir  = "objects"
+	metadataDir = "metadata"
+	tmpDir      = "tmp"
+)
+
+// options holds configuration options for the Store.
+type options struct {
+	baseURL string // baseURL is the URL of the Handler, to sign URLs.
+	secret  []byte // secret signs the URLs.
+}
+
+// Option specifies Store configuration options.
+type Option func(*options)
+
+// WithURLSigning enables SignedURL: the URLs are baseURL followed by the key, e.g.,
+// "https://files.example.com/download/avatars/42.png?X-Expires=...", signed with an HMAC-SHA256 of secret, and
+// served by the Handler mounted at baseURL.
+func WithURLSigning(baseURL string, secret []byte) Option {
+	return func(opts *options) {
+		if baseURL != "" && len(secret) > 0 {
+			opts.baseURL = strings.TrimSuffix(baseURL, "/")
+			opts.secret = secret
+		}
+	}
+}
+
+// metadata is the JSON file of the metadata of an object.
+type metadata struct {
+	ContentType  string            `json:"content_type"`
+	CacheControl string            `json:"cache_control,omitempty"`
+	ETag         string            `json:"etag"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+/*
+Store is a storage.Store keeping the objects in a local directory, e.g., for the development environment, the
+tests, or a mounted network volume. The content of the object of a key is the file <dir>/objects/<key>, and its
+metadata are the JSON file <dir>/metadata/<key>.json. The objects are written to a temporary file first, and
+renamed, so that they are never read partially written.
+
+Since the keys are file paths, a key cannot be both an object and the parent of other objects, e.g., "a" and "a/b".
+
+Example usage:
+
+	store, err := localstorage.New("/var/lib/uploads",
+		localstorage.WithURLSigning("https://files.example.com/files", secret),
+	)
+	if err != nil {
+		// Handle error
+	}
+	mux.Handle("/files/", http.StripPrefix("/files", store.Handler()))
+*/
+type Store struct {
+	dir  string
+	opts options
+}
+
+var _ storage.Store = (*Store)(nil)
+
+// New creates a Store in dir, creating its directories if needed.
+func New(dir string, opts ...Option) (*Store, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	for _, sub := range []string{objectsDir, metadataDir, tmpDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{dir: dir, opts: o}, nil
+}
+
+// objectPath returns the path of the file of key.
+func (s *Store) objectPath(key string) string {
+	return filepath.Join(s.dir, objectsDir, filepath.FromSlash(key))
+}
+
+// metadataPath returns the path of the metadata file of key.
+func (s *Store) metadataPath(key string) string {
+	return filepath.Join(s.dir, metadataDir, filepath.FromSlash(key)+".json")
+}
+
+// Put implements the storage.Store interface.
+func (s *Store) Put(ctx context.Context, key string, body io.Reader, opts ...storage.PutOption) (storage.ObjectInfo, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	o := storage.NewPutOptions(opts...)
+
+	hash := md5.New()
+	file, err := os.CreateTemp(filepath.Join(s.dir, tmpDir), "object-*")
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	defer os.Remove(file.Name())
+	_, err = io.Copy(io.MultiWriter(file, hash), &contextReader{ctx: ctx, r: body})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+
+	meta := metadata{
+		ContentType:  o.ContentType,
+		CacheControl: o.CacheControl,
+		ETag:         hex.EncodeToString(hash.Sum(nil)),
+		Metadata:     o.Metadata,
+	}
+	encoded, err := json.Marshal(meta)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	if err := s.write(s.metadataPath(key), encoded); err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	objectPath := s.objectPath(key)
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0o755); err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	if err := os.Rename(file.Name(), objectPath); err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	return s.Stat(ctx, key)
+}
+
+// write writes data to the file path atomically.
+func (s *Store) write(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Join(s.dir, tmpDir), "metadata-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// Get implements the storage.Store interface.
+func (s *Store) Get(ctx context.Context, key string) (*storage.Object, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(s.objectPath(key))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	stat, err := file.Stat()
+	if err == nil && stat.IsDir() {
+		err = storage.ErrNotFound
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &storage.Object{ReadCloser: file, Info: s.info(key, stat)}, nil
+}
+
+// Stat implements the storage.Store interface.
+func (s *Store) Stat(ctx context.Context, key string) (storage.ObjectInfo, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	stat, err := os.Stat(s.objectPath(key))
+	if err != nil {
+		return storage.ObjectInfo{}, notFound(err)
+	}
+	if stat.IsDir() {
+		return storage.ObjectInfo{}, storage.ErrNotFound
+	}
+	return s.info(key, stat), nil
+}
+
+// info returns the description of the object of key, whose file is described by stat. The objects without
+// metadata file, e.g., copied in the directory, have the content type of their extension.
+func (s *Store) info(key string, stat fs.FileInfo) storage.ObjectInfo {
+	info := storage.ObjectInfo{Key: key, Size: stat.Size(), LastModified: stat.ModTime()}
+	var meta metadata
+	if data, err := os.ReadFile(s.metadataPath(key)); err == nil && json.Unmarshal(data, &meta) == nil {
+		info.ContentType = meta.ContentType
+		info.CacheControl = meta.CacheControl
+		info.ETag = meta.ETag
+		info.Metadata = meta.Metadata
+	}
+	if info.ContentType == "" {
+		info.ContentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if info.ContentType == "" {
+		info.ContentType = storage.DefaultContentType
+	}
+	return info
+}
+
+// Delete implements the storage.Store interface. The directories left empty are removed.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	if err := storage.ValidateKey(key); err != nil {
+		return err
+	}
+	if stat, err := os.Stat(s.objectPath(key)); err == nil && stat.IsDir() {
+		// The key is the parent of other objects, not an object.
+		return nil
+	}
+	for _, p := range []string{s.objectPath(key), s.metadataPath(key)} {
+		if err := os.Remove(p); err != nil && !stderrors.Is(notFound(err), storage.ErrNotFound) {
+			return err
+		}
+	}
+	for dir := path.Dir(key); dir != "."; dir = path.Dir(dir) {
+		// Removing a directory fails if it is not empty.
+		if os.Remove(s.objectPath(dir)) != nil {
+			break
+		}
+		_ = os.Remove(filepath.Join(s.dir, metadataDir, filepath.FromSlash(dir)))
+	}
+	return nil
+}
+
+// List implements the storage.Store interface. The cursor is the key of the last object of the previous page.
+func (s *Store) List(ctx context.Context, prefix string, opts ...storage.ListOption) (storage.ListPage, error) {
+	o := storage.NewListOptions(opts...)
+	root := filepath.Join(s.dir, objectsDir)
+	// Walk the deepest directory containing all the keys with prefix.
+	start := root
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		start = s.objectPath(prefix[:i])
+	}
+
+	var keys []string
+	err := filepath.WalkDir(start, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if stderrors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) && key > o.Cursor {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return storage.ListPage{}, err
+	}
+	sort.Strings(keys)
+
+	var page storage.ListPage
+	if len(keys) > o.Limit {
+		keys = keys[:o.Limit]
+		page.NextCursor = keys[len(keys)-1]
+	}
+	for _, key := range keys {
+		info, err := s.Stat(ctx, key)
+		if stderrors.Is(err, storage.ErrNotFound) {
+			// Deleted since the walk.
+			continue
+		}
+		if err != nil {
+			return storage.ListPage{}, err
+		}
+		page.Objects = append(page.Objects, info)
+	}
+	return page, nil
+}
+
+// SignedURL implements the storage.Store interface. It returns storage.ErrNotSupported without WithURLSigning.
+func (s *Store) SignedURL(ctx context.Context, key string, opts ...storage.SignOption) (string, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return "", err
+	}
+	if s.opts.secret == nil {
+		return "", storage.ErrNotSupported
+	}
+	o := storage.NewSignOptions(opts...)
+	expires := strconv.FormatInt(time.Now().Add(o.Expiry).Unix(), 10)
+	query := url.Values{
+		paramExpires:   {expires},
+		paramMethod:    {o.Method},
+		paramSignature: {s.signature(o.Method, key, expires)},
+	}
+	return s.opts.baseURL + "/" + escapeKey(key) + "?" + query.Encode(), nil
+}
+
+// signature returns the signature of the URLs allowing method on key until expires.
+func (s *Store) signature(method, key, expires string) string {
+	mac := hmac.New(sha256.New, s.opts.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s", method, key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+/*
+Handler returns an http.Handler serving the URLs signed by SignedURL: GET downloads the object, with support for
+the range and conditional requests, and PUT uploads it with the Content-Type of the request. The requests whose
+signature is invalid or expired are forbidden. Mount it at the path of the base URL of WithURLSigning, stripped with
+http.StripPrefix.
+*/
+func (s *Store) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		query := r.URL.Query()
+		expires, err := strconv.ParseInt(query.Get(paramExpires), 10, 64)
+		if s.opts.secret == nil || err != nil || time.Now().Unix() > expires || query.Get(paramMethod) != r.Method ||
+			!hmac.Equal([]byte(query.Get(paramSignature)), []byte(s.signature(r.Method, key, query.Get(paramExpires)))) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			object, err := s.Get(r.Context(), key)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			defer object.Close()
+			w.Header().Set("Content-Type", object.Info.ContentType)
+			if object.Info.ETag != "" {
+				w.Header().Set("ETag", `"`+object.Info.ETag+`"`)
+			}
+			if object.Info.CacheControl != "" {
+				w.Header().Set("Cache-Control", object.Info.CacheControl)
+			}
+			http.ServeContent(w, r, "", object.Info.LastModified, object.ReadCloser.(io.ReadSeeker))
+		case http.MethodPut:
+			info, err := s.Put(r.Context(), key, r.Body, storage.WithContentType(r.Header.Get("Content-Type")))
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			w.Header().Set("ETag", `"`+info.ETag+`"`)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// writeError writes the status of err.
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case stderrors.Is(err, storage.ErrNotFound):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case stderrors.Is(err, storage.ErrInvalidKey):
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// escapeKey escapes the elements of key for a URL path.
+func escapeKey(key string) string {
+	elements := strings.Split(key, "/")
+	for i, element := range elements {
+		elements[i] = url.PathEscape(element)
+	}
+	return strings.Join(elements, "/")
+}
+
+// notFound returns storage.ErrNotFound for the errors of the missing files, and err otherwise.
+func notFound(err error) error {
+	if stderrors.Is(err, fs.ErrNotExist) || stderrors.Is(err, syscall.ENOTDIR) {
+		return storage.ErrNotFound
+	}
+	return err
+}
+
+// contextReader stops reading r once ctx is done, so that the long copies are canceled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,182 @@
+package localstorage_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/storage"
+	"github.com/kittipat1413/go-common/framework/storage/localstorage"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := localstorage.New(dir)
+	require.NoError(t, err)
+
+	info, err := store.Put(ctx, "avatars/42.png", strings.NewReader("png"),
+		storage.WithContentType("image/png"),
+		storage.WithCacheControl("max-age=60"),
+		storage.WithMetadata(map[string]string{"Uploader": "user-1"}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "avatars/42.png", info.Key)
+	assert.Equal(t, int64(3), info.Size)
+	assert.Equal(t, "image/png", info.ContentType)
+	assert.Equal(t, "max-age=60", info.CacheControl)
+	assert.Equal(t, "bff139fa05ac583f685a523ab3d110a0", info.ETag, "the MD5 of the content")
+	assert.Equal(t, map[string]string{"uploader": "user-1"}, info.Metadata)
+	assert.False(t, info.LastModified.IsZero())
+
+	object, err := store.Get(ctx, "avatars/42.png")
+	require.NoError(t, err)
+	content, err := io.ReadAll(object)
+	require.NoError(t, err)
+	require.NoError(t, object.Close())
+	assert.Equal(t, "png", string(content))
+	assert.Equal(t, info, object.Info)
+
+	_, err = store.Put(ctx, "avatars/42.png", strings.NewReader("new png"))
+	require.NoError(t, err)
+	stat, err := store.Stat(ctx, "avatars/42.png")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), stat.Size, "the object is replaced")
+	assert.Equal(t, storage.DefaultContentType, stat.ContentType)
+	assert.NotEqual(t, info.ETag, stat.ETag)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "objects", "readme.txt"), []byte("copied"), 0o644))
+	stat, err = store.Stat(ctx, "readme.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", stat.ContentType, "the type of the extension without metadata")
+
+	for _, key := range []string{"avatars", "avatars/42.png/x", "missing"} {
+		_, err = store.Get(ctx, key)
+		assert.ErrorIs(t, err, storage.ErrNotFound, key)
+		_, err = store.Stat(ctx, key)
+		assert.ErrorIs(t, err, storage.ErrNotFound, key)
+	}
+	_, err = store.Put(ctx, "../escape", strings.NewReader(""))
+	assert.ErrorIs(t, err, storage.ErrInvalidKey)
+
+	require.NoError(t, store.Delete(ctx, "avatars"), "a parent is not an object")
+	require.NoError(t, store.Delete(ctx, "avatars/42.png"))
+	require.NoError(t, store.Delete(ctx, "avatars/42.png"), "deleting a missing object succeeds")
+	_, err = store.Get(ctx, "avatars/42.png")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.NoDirExists(t, filepath.Join(dir, "objects", "avatars"), "the empty directories are removed")
+}
+
+func TestStore_Put_Canceled(t *testing.T) {
+	store, err := localstorage.New(t.TempDir())
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = store.Put(ctx, "a", strings.NewReader("content"))
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = store.Stat(context.Background(), "a")
+	assert.ErrorIs(t, err, storage.ErrNotFound, "nothing is written")
+}
+
+func TestStore_List(t *testing.T) {
+	ctx := context.Background()
+	store, err := localstorage.New(t.TempDir())
+	require.NoError(t, err)
+	for _, key := range []string{"logs/2024/02.log", "logs/2024/01.log", "logs/2023.log", "logs.txt", "other/x"} {
+		_, err := store.Put(ctx, key, strings.NewReader(key))
+		require.NoError(t, err)
+	}
+
+	page, err := store.List(ctx, "logs", storage.WithLimit(2))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs.txt", "logs/2023.log"}, keys(page))
+	assert.NotEmpty(t, page.NextCursor)
+	page, err = store.List(ctx, "logs", storage.WithLimit(2), storage.WithCursor(page.NextCursor))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs/2024/01.log", "logs/2024/02.log"}, keys(page))
+	assert.Empty(t, page.NextCursor, "the last page")
+
+	page, err = store.List(ctx, "logs/2024/0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs/2024/01.log", "logs/2024/02.log"}, keys(page))
+	page, err = store.List(ctx, "missing/")
+	require.NoError(t, err)
+	assert.Empty(t, page.Objects)
+	page, err = store.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, page.Objects, 5)
+}
+
+func keys(page storage.ListPage) []string {
+	keys := make([]string, len(page.Objects))
+	for i, info := range page.Objects {
+		keys[i] = info.Key
+	}
+	return keys
+}
+
+func TestStore_SignedURL(t *testing.T) {
+	ctx := context.Background()
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	store, err := localstorage.New(t.TempDir(), localstorage.WithURLSigning(server.URL+"/files/", []byte("secret")))
+	require.NoError(t, err)
+	mux.Handle("/files/", http.StripPrefix("/files", store.Handler()))
+
+	upload, err := store.SignedURL(ctx, "reports/q1 2024.csv", storage.WithMethod(http.MethodPut))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(upload, server.URL+"/files/reports/q1%202024.csv?"), upload)
+	req, err := http.NewRequest(http.MethodPut, upload, strings.NewReader("a,b\n"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "text/csv")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	download, err := store.SignedURL(ctx, "reports/q1 2024.csv")
+	require.NoError(t, err)
+	resp, err = http.Get(download)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+	assert.NotEmpty(t, resp.Header.Get("ETag"))
+	assert.Equal(t, "a,b\n", string(body))
+
+	resp, err = http.Post(download, "text/csv", strings.NewReader("x"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "the URL allows a single method")
+
+	tampered, err := url.Parse(download)
+	require.NoError(t, err)
+	tampered.Path = "/files/reports/other.csv"
+	resp, err = http.Get(tampered.String())
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	missing, err := store.SignedURL(ctx, "reports/missing.csv")
+	require.NoError(t, err)
+	resp, err = http.Get(missing)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	unsigned, err := localstorage.New(t.TempDir())
+	require.NoError(t, err)
+	_, err = unsigned.SignedURL(ctx, "a")
+	assert.ErrorIs(t, err, storage.ErrNotSupported)
+}
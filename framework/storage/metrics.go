@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+// Values of the status label of the metrics.
+const (
+	StatusSuccess  = "success"
+	StatusNotFound = "not_found"
+	StatusError    = "error"
+)
+
+// Values of the direction label of the metrics.
+const (
+	DirectionUpload   = "upload"
+	DirectionDownload = "download"
+)
+
+/*
+Metrics records the operations of the stores decorated by WithMetrics in a metrics.Registry, labelled by store:
+
+	storage_operations_total{store="uploads",operation="put",status="success"} 42
+	storage_operation_duration_seconds_bucket{store="uploads",operation="put",le="0.1"} 40
+	storage_bytes_total{store="uploads",direction="download"} 1048576
+
+Example usage:
+
+	storageMetrics, err := storage.NewMetrics(metrics.Default())
+	if err != nil {
+		// Handle error
+	}
+	store := storage.WithMetrics(s3storage.New(client, "uploads"), storageMetrics, "uploads")
+*/
+type Metrics struct {
+	operations *metrics.Counter
+	duration   *metrics.Histogram
+	bytes      *metrics.Counter
+}
+
+// NewMetrics creates the metrics of the stores in registry, or returns the ones already created.
+func NewMetrics(registry *metrics.Registry) (*Metrics, error) {
+	operations, err := registry.NewCounter("storage_operations_total", "Number of operations of a store.", "store", "operation", "status")
+	if err != nil {
+		return nil, err
+	}
+	duration, err := registry.NewHistogram("storage_operation_duration_seconds", "Duration of the operations of a store in seconds.", nil, "store", "operation")
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := registry.NewCounter("storage_bytes_total", "Number of bytes uploaded to and downloaded from a store.", "store", "direction")
+	if err != nil {
+		return nil, err
+	}
+	return &Metrics{operations: operations, duration: duration, bytes: bytes}, nil
+}
+
+// WithMetrics wraps s to record its operations in m, labelled with name. The bytes downloaded are recorded as the
+// objects returned by Get are read.
+func WithMetrics(s Store, m *Metrics, name string) Store {
+	return &meteredStore{store: s, metrics: m, name: name}
+}
+
+type meteredStore struct {
+	store   Store
+	metrics *Metrics
+	name    string
+}
+
+func (s *meteredStore) Put(ctx context.Context, key string, body io.Reader, opts ...PutOption) (ObjectInfo, error) {
+	defer s.observe("put", time.Now())
+	info, err := s.store.Put(ctx, key, body, opts...)
+	s.record("put", err)
+	if err == nil {
+		s.metrics.bytes.Add(float64(info.Size), s.name, DirectionUpload)
+	}
+	return info, err
+}
+
+func (s *meteredStore) Get(ctx context.Context, key string) (*Object, error) {
+	defer s.observe("get", time.Now())
+	object, err := s.store.Get(ctx, key)
+	s.record("get", err)
+	if err != nil {
+		return nil, err
+	}
+	object.ReadCloser = &meteredReader{ReadCloser: object.ReadCloser, store: s}
+	return object, nil
+}
+
+func (s *meteredStore) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	defer s.observe("stat", time.Now())
+	info, err := s.store.Stat(ctx, key)
+	s.record("stat", err)
+	return info, err
+}
+
+func (s *meteredStore) Delete(ctx context.Context, key string) error {
+	defer s.observe("delete", time.Now())
+	err := s.store.Delete(ctx, key)
+	s.record("delete", err)
+	return err
+}
+
+func (s *meteredStore) List(ctx context.Context, prefix string, opts ...ListOption) (ListPage, error) {
+	defer s.observe("list", time.Now())
+	page, err := s.store.List(ctx, prefix, opts...)
+	s.record("list", err)
+	return page, err
+}
+
+func (s *meteredStore) SignedURL(ctx context.Context, key string, opts ...SignOption) (string, error) {
+	defer s.observe("signed_url", time.Now())
+	signed, err := s.store.SignedURL(ctx, key, opts...)
+	s.record("signed_url", err)
+	return signed, err
+}
+
+// observe records the duration of operation started at start.
+func (s *meteredStore) observe(operation string, start time.Time) {
+	s.metrics.duration.Observe(time.Since(start).Seconds(), s.name, operation)
+}
+
+// record records operation with the status of err.
+func (s *meteredStore) record(operation string, err error) {
+	status := StatusSuccess
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = StatusNotFound
+	case err != nil:
+		status = StatusError
+	}
+	s.metrics.operations.Inc(s.name, operation, status)
+}
+
+// meteredReader records the bytes read from the content of an object.
+type meteredReader struct {
+	io.ReadCloser
+	store *meteredStore
+}
+
+func (r *meteredReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.store.metrics.bytes.Add(float64(n), r.store.name, DirectionDownload)
+	}
+	return n, err
+}
@@ -0,0 +1,50 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/metrics"
+	"github.com/kittipat1413/go-common/framework/storage"
+)
+
+func TestWithMetrics(t *testing.T) {
+	ctx := context.Background()
+	registry := metrics.NewRegistry()
+	m, err := storage.NewMetrics(registry)
+	require.NoError(t, err)
+	store := storage.WithMetrics(newStore(t), m, "uploads")
+
+	_, err = store.Put(ctx, "report.csv", strings.NewReader("a,b\n1,2\n"))
+	require.NoError(t, err)
+	object, err := store.Get(ctx, "report.csv")
+	require.NoError(t, err)
+	content, err := io.ReadAll(object)
+	require.NoError(t, err)
+	require.NoError(t, object.Close())
+	assert.Equal(t, "a,b\n1,2\n", string(content))
+	_, err = store.Get(ctx, "missing.csv")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = store.SignedURL(ctx, "report.csv")
+	assert.ErrorIs(t, err, storage.ErrNotSupported)
+
+	var output bytes.Buffer
+	_, err = registry.WriteTo(&output)
+	require.NoError(t, err)
+	assert.Contains(t, output.String(), `storage_operations_total{store="uploads",operation="put",status="success"} 1`)
+	assert.Contains(t, output.String(), `storage_operations_total{store="uploads",operation="get",status="success"} 1`)
+	assert.Contains(t, output.String(), `storage_operations_total{store="uploads",operation="get",status="not_found"} 1`)
+	assert.Contains(t, output.String(), `storage_operations_total{store="uploads",operation="signed_url",status="error"} 1`)
+	assert.Contains(t, output.String(), `storage_operation_duration_seconds_count{store="uploads",operation="get"} 2`)
+	assert.Contains(t, output.String(), `storage_bytes_total{store="uploads",direction="upload"} 8`)
+	assert.Contains(t, output.String(), `storage_bytes_total{store="uploads",direction="download"} 8`)
+
+	_, err = storage.NewMetrics(registry)
+	assert.NoError(t, err, "the metrics are shared")
+}
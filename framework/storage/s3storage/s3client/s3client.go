@@ -0,0 +1,161 @@
+package s3client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/kittipat1413/go-common/framework/storage"
+	"github.com/kittipat1413/go-common/framework/storage/s3storage"
+)
+
+var _ s3storage.Client = (*Client)(nil)
+
+/*
+Client is the s3storage.Client of the S3 client of the AWS SDK, e.g., for AWS S3, MinIO or Cloudflare R2. It is a
+module of its own, so that the services using s3storage with another client do not depend on the AWS SDK.
+
+The objects are uploaded with the upload manager of the SDK, in parts for the large ones, so that the bodies of
+unknown length are accepted.
+
+Example usage:
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		// Handle error
+	}
+	store := s3storage.New(s3client.New(s3.NewFromConfig(cfg)), "uploads", s3storage.WithKeyPrefix("billing/"))
+*/
+type Client struct {
+	client    *s3.Client
+	uploader  *manager.Uploader
+	presigner *s3.PresignClient
+}
+
+// New creates the s3storage.Client of client.
+func New(client *s3.Client) *Client {
+	return &Client{
+		client:    client,
+		uploader:  manager.NewUploader(client),
+		presigner: s3.NewPresignClient(client),
+	}
+}
+
+// PutObject uploads body as the object key of bucket, and returns its ETag.
+func (c *Client) PutObject(ctx context.Context, bucket, key string, body io.Reader, opts storage.PutOptions) (string, error) {
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(opts.ContentType),
+		Metadata:    opts.Metadata,
+	}
+	if opts.CacheControl != "" {
+		input.CacheControl = aws.String(opts.CacheControl)
+	}
+	out, err := c.uploader.Upload(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.ETag), nil
+}
+
+// GetObject returns the content and the description of the object key of bucket, or storage.ErrNotFound.
+func (c *Client) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, storage.ObjectInfo, error) {
+	out, err := c.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, storage.ObjectInfo{}, notFound(err)
+	}
+	return out.Body, storage.ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		ContentType:  aws.ToString(out.ContentType),
+		CacheControl: aws.ToString(out.CacheControl),
+		ETag:         aws.ToString(out.ETag),
+		LastModified: aws.ToTime(out.LastModified),
+		Metadata:     out.Metadata,
+	}, nil
+}
+
+// HeadObject returns the description of the object key of bucket, or storage.ErrNotFound.
+func (c *Client) HeadObject(ctx context.Context, bucket, key string) (storage.ObjectInfo, error) {
+	out, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return storage.ObjectInfo{}, notFound(err)
+	}
+	return storage.ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		ContentType:  aws.ToString(out.ContentType),
+		CacheControl: aws.ToString(out.CacheControl),
+		ETag:         aws.ToString(out.ETag),
+		LastModified: aws.ToTime(out.LastModified),
+		Metadata:     out.Metadata,
+	}, nil
+}
+
+// DeleteObject deletes the object key of bucket. Deleting a missing object succeeds.
+func (c *Client) DeleteObject(ctx context.Context, bucket, key string) error {
+	_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	return err
+}
+
+// ListObjects returns up to limit objects of bucket whose key starts with prefix, from the continuation token, and
+// the token of the next page, or empty on the last page.
+func (c *Client) ListObjects(ctx context.Context, bucket, prefix, token string, limit int) ([]storage.ObjectInfo, string, error) {
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(bucket), Prefix: aws.String(prefix), MaxKeys: aws.Int32(int32(limit))}
+	if token != "" {
+		input.ContinuationToken = aws.String(token)
+	}
+	out, err := c.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, "", err
+	}
+	objects := make([]storage.ObjectInfo, len(out.Contents))
+	for i, object := range out.Contents {
+		objects[i] = storage.ObjectInfo{
+			Key:          aws.ToString(object.Key),
+			Size:         aws.ToInt64(object.Size),
+			ETag:         aws.ToString(object.ETag),
+			LastModified: aws.ToTime(object.LastModified),
+		}
+	}
+	return objects, aws.ToString(out.NextContinuationToken), nil
+}
+
+// Presign returns a URL of the object key of bucket presigned for method, GET or PUT, valid for expiry.
+func (c *Client) Presign(ctx context.Context, bucket, key, method string, expiry time.Duration) (string, error) {
+	var (
+		request *v4.PresignedHTTPRequest
+		err     error
+	)
+	if method == http.MethodPut {
+		request, err = c.presigner.PresignPutObject(ctx, &s3.PutObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)}, s3.WithPresignExpires(expiry))
+	} else {
+		request, err = c.presigner.PresignGetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)}, s3.WithPresignExpires(expiry))
+	}
+	if err != nil {
+		return "", err
+	}
+	return request.URL, nil
+}
+
+// notFound returns storage.ErrNotFound for the errors of the missing objects, or err.
+func notFound(err error) error {
+	var (
+		noSuchKey *types.NoSuchKey
+		notFound  *types.NotFound
+	)
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return storage.ErrNotFound
+	}
+	return err
+}
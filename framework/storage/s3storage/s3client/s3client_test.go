@@ -0,0 +1,125 @@
+package s3client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/storage"
+	"github.com/kittipat1413/go-common/framework/storage/s3storage"
+	"github.com/kittipat1413/go-common/framework/storage/s3storage/s3client"
+)
+
+// newStore returns a store of the bucket "uploads" of an in-memory S3 server.
+func newStore(t *testing.T) *s3storage.Store {
+	backend := s3mem.New()
+	require.NoError(t, backend.CreateBucket("uploads"))
+	server := httptest.NewServer(gofakes3.New(backend).Server())
+	t.Cleanup(server.Close)
+
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "access-key", SecretAccessKey: "secret-key"}, nil
+		}),
+	})
+	return s3storage.New(s3client.New(client), "uploads", s3storage.WithKeyPrefix("billing/"))
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t)
+
+	// The bodies of unknown length are uploaded.
+	body := io.MultiReader(strings.NewReader("hello, "), strings.NewReader("world"))
+	info, err := store.Put(ctx, "invoices/1.txt", body,
+		storage.WithContentType("text/plain"),
+		storage.WithCacheControl("max-age=60"),
+		storage.WithMetadata(map[string]string{"owner": "billing"}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), info.Size)
+	assert.NotEmpty(t, info.ETag)
+	assert.NotContains(t, info.ETag, `"`)
+
+	object, err := store.Get(ctx, "invoices/1.txt")
+	require.NoError(t, err)
+	content, err := io.ReadAll(object)
+	require.NoError(t, err)
+	require.NoError(t, object.Close())
+	assert.Equal(t, "hello, world", string(content))
+	assert.Equal(t, "invoices/1.txt", object.Info.Key)
+	assert.Equal(t, "text/plain", object.Info.ContentType)
+	assert.Equal(t, info.ETag, object.Info.ETag)
+
+	stat, err := store.Stat(ctx, "invoices/1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), stat.Size)
+	assert.Equal(t, "billing", stat.Metadata["owner"])
+
+	require.NoError(t, store.Delete(ctx, "invoices/1.txt"))
+	_, err = store.Get(ctx, "invoices/1.txt")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = store.Stat(ctx, "invoices/1.txt")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	require.NoError(t, store.Delete(ctx, "invoices/1.txt"), "deleting a missing object succeeds")
+}
+
+func TestStore_List(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t)
+	for _, key := range []string{"invoices/1.txt", "invoices/2.txt", "invoices/3.txt", "receipts/1.txt"} {
+		_, err := store.Put(ctx, key, strings.NewReader(key))
+		require.NoError(t, err)
+	}
+
+	page, err := store.List(ctx, "invoices/", storage.WithLimit(2))
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 2)
+	assert.Equal(t, "invoices/1.txt", page.Objects[0].Key)
+	assert.Equal(t, "invoices/2.txt", page.Objects[1].Key)
+	assert.Equal(t, int64(len("invoices/1.txt")), page.Objects[0].Size)
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = store.List(ctx, "invoices/", storage.WithLimit(2), storage.WithCursor(page.NextCursor))
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, "invoices/3.txt", page.Objects[0].Key)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestStore_SignedURL(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t)
+	_, err := store.Put(ctx, "invoices/1.txt", strings.NewReader("hello"))
+	require.NoError(t, err)
+
+	url, err := store.SignedURL(ctx, "invoices/1.txt")
+	require.NoError(t, err)
+	assert.Contains(t, url, "/uploads/billing/invoices/1.txt")
+	assert.Contains(t, url, "X-Amz-Signature=")
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hello", string(content))
+
+	url, err = store.SignedURL(ctx, "invoices/2.txt", storage.WithMethod(http.MethodPut))
+	require.NoError(t, err)
+	assert.Contains(t, url, "X-Amz-Signature=")
+}
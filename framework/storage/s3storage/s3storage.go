@@ -0,0 +1,177 @@
+package s3storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/storage"
+)
+
+/*
+Client is the subset of S3 operations used by the store, on the objects of a bucket. GetObject and HeadObject return
+storage.ErrNotFound for the missing objects. It is implemented with the S3 client of the AWS SDK by the s3client
+module, e.g., for AWS S3, MinIO or Cloudflare R2:
+
+	import "github.com/kittipat1413/go-common/framework/storage/s3storage/s3client"
+
+	store := s3storage.New(s3client.New(s3.NewFromConfig(cfg)), "uploads")
+*/
+type Client interface {
+	// PutObject uploads body as the object key of bucket, and returns its ETag.
+	PutObject(ctx context.Context, bucket, key string, body io.Reader, opts storage.PutOptions) (string, error)
+	// GetObject returns the content and the description of the object key of bucket, or storage.ErrNotFound.
+	GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, storage.ObjectInfo, error)
+	// HeadObject returns the description of the object key of bucket, or storage.ErrNotFound.
+	HeadObject(ctx context.Context, bucket, key string) (storage.ObjectInfo, error)
+	// DeleteObject deletes the object key of bucket. Deleting a missing object succeeds.
+	DeleteObject(ctx context.Context, bucket, key string) error
+	// ListObjects returns up to limit objects of bucket whose key starts with prefix, sorted by key, from the
+	// continuation token, or the first one if empty, and the token of the next page, or empty on the last page.
+	ListObjects(ctx context.Context, bucket, prefix, token string, limit int) ([]storage.ObjectInfo, string, error)
+	// Presign returns a URL of the object key of bucket presigned for method, GET or PUT, valid for expiry.
+	Presign(ctx context.Context, bucket, key, method string, expiry time.Duration) (string, error)
+}
+
+// config holds configuration options for the store.
+type config struct {
+	keyPrefix string
+}
+
+// Option specifies store configuration options.
+type Option func(*config)
+
+// WithKeyPrefix prepends prefix to the keys of the objects in the bucket, e.g., "billing/" to share a bucket with
+// other services. The keys of the store do not include it.
+func WithKeyPrefix(prefix string) Option {
+	return func(c *config) {
+		c.keyPrefix = prefix
+	}
+}
+
+/*
+Store is a storage.Store keeping the objects in an S3 bucket, or in any S3-compatible store. The ETags of the
+objects are unquoted.
+
+Example usage:
+
+	store := s3storage.New(s3client.New(s3.NewFromConfig(cfg)), "uploads", s3storage.WithKeyPrefix("billing/"))
+*/
+type Store struct {
+	client Client
+	bucket string
+	cfg    config
+}
+
+var _ storage.Store = (*Store)(nil)
+
+// New creates a Store of the objects of bucket.
+func New(client Client, bucket string, opts ...Option) *Store {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Store{client: client, bucket: bucket, cfg: cfg}
+}
+
+// Put implements the storage.Store interface.
+func (s *Store) Put(ctx context.Context, key string, body io.Reader, opts ...storage.PutOption) (storage.ObjectInfo, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	o := storage.NewPutOptions(opts...)
+	counter := &countingReader{r: body}
+	etag, err := s.client.PutObject(ctx, s.bucket, s.cfg.keyPrefix+key, counter, o)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	return storage.ObjectInfo{
+		Key:          key,
+		Size:         counter.n,
+		ContentType:  o.ContentType,
+		CacheControl: o.CacheControl,
+		ETag:         unquote(etag),
+		LastModified: time.Now(),
+		Metadata:     o.Metadata,
+	}, nil
+}
+
+// Get implements the storage.Store interface.
+func (s *Store) Get(ctx context.Context, key string) (*storage.Object, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return nil, err
+	}
+	body, info, err := s.client.GetObject(ctx, s.bucket, s.cfg.keyPrefix+key)
+	if err != nil {
+		return nil, err
+	}
+	return &storage.Object{ReadCloser: body, Info: s.info(info)}, nil
+}
+
+// Stat implements the storage.Store interface.
+func (s *Store) Stat(ctx context.Context, key string) (storage.ObjectInfo, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	info, err := s.client.HeadObject(ctx, s.bucket, s.cfg.keyPrefix+key)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	return s.info(info), nil
+}
+
+// Delete implements the storage.Store interface.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	if err := storage.ValidateKey(key); err != nil {
+		return err
+	}
+	return s.client.DeleteObject(ctx, s.bucket, s.cfg.keyPrefix+key)
+}
+
+// List implements the storage.Store interface. The cursor is the continuation token of S3.
+func (s *Store) List(ctx context.Context, prefix string, opts ...storage.ListOption) (storage.ListPage, error) {
+	o := storage.NewListOptions(opts...)
+	objects, token, err := s.client.ListObjects(ctx, s.bucket, s.cfg.keyPrefix+prefix, o.Cursor, o.Limit)
+	if err != nil {
+		return storage.ListPage{}, err
+	}
+	page := storage.ListPage{Objects: make([]storage.ObjectInfo, len(objects)), NextCursor: token}
+	for i, info := range objects {
+		page.Objects[i] = s.info(info)
+	}
+	return page, nil
+}
+
+// SignedURL implements the storage.Store interface, with a presigned URL.
+func (s *Store) SignedURL(ctx context.Context, key string, opts ...storage.SignOption) (string, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return "", err
+	}
+	o := storage.NewSignOptions(opts...)
+	return s.client.Presign(ctx, s.bucket, s.cfg.keyPrefix+key, o.Method, o.Expiry)
+}
+
+// info returns info with the key of the store, and the ETag unquoted.
+func (s *Store) info(info storage.ObjectInfo) storage.ObjectInfo {
+	info.Key = strings.TrimPrefix(info.Key, s.cfg.keyPrefix)
+	info.ETag = unquote(info.ETag)
+	return info
+}
+
+// unquote removes the quotes of the ETags of S3.
+func unquote(etag string) string {
+	return strings.Trim(etag, `"`)
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
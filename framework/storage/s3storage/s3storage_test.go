@@ -0,0 +1,181 @@
+package s3storage_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/storage"
+	"github.com/kittipat1413/go-common/framework/storage/s3storage"
+)
+
+// fakeClient emulates the objects of S3 buckets, with quoted ETags.
+type fakeClient struct {
+	mutex   sync.Mutex
+	objects map[string]storage.ObjectInfo // objects are the descriptions of the objects, by bucket and key.
+	content map[string]string
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{objects: make(map[string]storage.ObjectInfo), content: make(map[string]string)}
+}
+
+func (c *fakeClient) PutObject(_ context.Context, bucket, key string, body io.Reader, opts storage.PutOptions) (string, error) {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	etag := fmt.Sprintf(`"etag-%d"`, len(c.objects)+1)
+	c.objects[bucket+"/"+key] = storage.ObjectInfo{
+		Key:          key,
+		Size:         int64(len(content)),
+		ContentType:  opts.ContentType,
+		CacheControl: opts.CacheControl,
+		ETag:         etag,
+		LastModified: time.Now(),
+		Metadata:     opts.Metadata,
+	}
+	c.content[bucket+"/"+key] = string(content)
+	return etag, nil
+}
+
+func (c *fakeClient) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, storage.ObjectInfo, error) {
+	info, err := c.HeadObject(ctx, bucket, key)
+	if err != nil {
+		return nil, storage.ObjectInfo{}, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return io.NopCloser(strings.NewReader(c.content[bucket+"/"+key])), info, nil
+}
+
+func (c *fakeClient) HeadObject(_ context.Context, bucket, key string) (storage.ObjectInfo, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	info, ok := c.objects[bucket+"/"+key]
+	if !ok {
+		return storage.ObjectInfo{}, storage.ErrNotFound
+	}
+	return info, nil
+}
+
+func (c *fakeClient) DeleteObject(_ context.Context, bucket, key string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.objects, bucket+"/"+key)
+	delete(c.content, bucket+"/"+key)
+	return nil
+}
+
+// ListObjects uses the last key of the page as the continuation token.
+func (c *fakeClient) ListObjects(_ context.Context, bucket, prefix, token string, limit int) ([]storage.ObjectInfo, string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var objects []storage.ObjectInfo
+	for name, info := range c.objects {
+		if strings.HasPrefix(name, bucket+"/"+prefix) && info.Key > token {
+			objects = append(objects, info)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	if len(objects) <= limit {
+		return objects, "", nil
+	}
+	return objects[:limit], objects[limit-1].Key, nil
+}
+
+func (c *fakeClient) Presign(_ context.Context, bucket, key, method string, expiry time.Duration) (string, error) {
+	return fmt.Sprintf("https://%s.s3.amazonaws.com/%s?method=%s&expires=%d", bucket, key, method, int(expiry.Seconds())), nil
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	store := s3storage.New(client, "uploads", s3storage.WithKeyPrefix("billing/"))
+
+	info, err := store.Put(ctx, "invoices/1.pdf", strings.NewReader("%PDF"),
+		storage.WithContentType("application/pdf"),
+		storage.WithMetadata(map[string]string{"customer": "42"}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "invoices/1.pdf", info.Key)
+	assert.Equal(t, int64(4), info.Size, "the bytes uploaded")
+	assert.Equal(t, "application/pdf", info.ContentType)
+	assert.Equal(t, "etag-1", info.ETag, "the ETag is unquoted")
+	assert.Equal(t, map[string]string{"customer": "42"}, info.Metadata)
+	assert.Contains(t, client.objects, "uploads/billing/invoices/1.pdf", "the key is prefixed in the bucket")
+
+	object, err := store.Get(ctx, "invoices/1.pdf")
+	require.NoError(t, err)
+	content, err := io.ReadAll(object)
+	require.NoError(t, err)
+	require.NoError(t, object.Close())
+	assert.Equal(t, "%PDF", string(content))
+	assert.Equal(t, "invoices/1.pdf", object.Info.Key)
+	assert.Equal(t, "etag-1", object.Info.ETag)
+
+	stat, err := store.Stat(ctx, "invoices/1.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, "invoices/1.pdf", stat.Key)
+	assert.Equal(t, "etag-1", stat.ETag)
+
+	_, err = store.Get(ctx, "invoices/2.pdf")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = store.Put(ctx, "/invoices", strings.NewReader(""))
+	assert.ErrorIs(t, err, storage.ErrInvalidKey)
+
+	require.NoError(t, store.Delete(ctx, "invoices/1.pdf"))
+	_, err = store.Stat(ctx, "invoices/1.pdf")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestStore_List(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	store := s3storage.New(client, "uploads", s3storage.WithKeyPrefix("billing/"))
+	for _, key := range []string{"invoices/3.pdf", "invoices/1.pdf", "invoices/2.pdf", "receipts/1.pdf"} {
+		_, err := store.Put(ctx, key, strings.NewReader(key))
+		require.NoError(t, err)
+	}
+	_, err := client.PutObject(ctx, "uploads", "other/invoices/1.pdf", strings.NewReader(""), storage.PutOptions{})
+	require.NoError(t, err)
+
+	page, err := store.List(ctx, "invoices/", storage.WithLimit(2))
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 2)
+	assert.Equal(t, "invoices/1.pdf", page.Objects[0].Key)
+	assert.Equal(t, "invoices/2.pdf", page.Objects[1].Key)
+	assert.Equal(t, `etag-3`, page.Objects[1].ETag)
+	assert.Equal(t, "billing/invoices/2.pdf", page.NextCursor, "the continuation token of S3")
+
+	page, err = store.List(ctx, "invoices/", storage.WithLimit(2), storage.WithCursor(page.NextCursor))
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, "invoices/3.pdf", page.Objects[0].Key)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestStore_SignedURL(t *testing.T) {
+	ctx := context.Background()
+	store := s3storage.New(newFakeClient(), "uploads", s3storage.WithKeyPrefix("billing/"))
+
+	signed, err := store.SignedURL(ctx, "invoices/1.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, "https://uploads.s3.amazonaws.com/billing/invoices/1.pdf?method=GET&expires=900", signed)
+	signed, err = store.SignedURL(ctx, "invoices/2.pdf", storage.WithMethod(http.MethodPut), storage.WithExpiry(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "https://uploads.s3.amazonaws.com/billing/invoices/2.pdf?method=PUT&expires=60", signed)
+	_, err = store.SignedURL(ctx, "")
+	assert.ErrorIs(t, err, storage.ErrInvalidKey)
+}
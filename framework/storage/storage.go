@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+)
+
+// Codes of the errors returned by the Stores.
+const (
+	CodeObjectNotFound = "storage_object_not_found"
+	CodeInvalidKey     = "storage_invalid_key"
+	CodeNotSupported   = "storage_not_supported"
+)
+
+var (
+	// ErrNotFound is returned for the keys without object.
+	ErrNotFound = errors.NotFound(CodeObjectNotFound, "object not found")
+	// ErrInvalidKey is returned for the invalid keys, see ValidateKey.
+	ErrInvalidKey = errors.InvalidArgument(CodeInvalidKey, "invalid object key")
+	// ErrNotSupported is returned by the Stores not supporting an operation, e.g., SignedURL without a signing key.
+	ErrNotSupported = errors.Internal(CodeNotSupported, "operation not supported by the store")
+)
+
+const (
+	// DefaultContentType is the content type of the objects put without WithContentType.
+	DefaultContentType = "application/octet-stream"
+	// DefaultSignedURLExpiry is the lifetime of the signed URLs, unless set with WithExpiry.
+	DefaultSignedURLExpiry = 15 * time.Minute
+	// DefaultListLimit is the maximum number of objects of a ListPage, unless set with WithLimit.
+	DefaultListLimit = 1000
+)
+
+// ObjectInfo describes an object.
+type ObjectInfo struct {
+	Key          string            // Key is the key of the object, e.g., "avatars/42.png".
+	Size         int64             // Size is the size of the object in bytes.
+	ContentType  string            // ContentType is the media type of the object, e.g., "image/png".
+	CacheControl string            // CacheControl is the Cache-Control of the object when it is served, if any.
+	ETag         string            // ETag identifies the content of the object, changing when it is replaced.
+	LastModified time.Time         // LastModified is the time the object was put.
+	Metadata     map[string]string // Metadata are the user-defined metadata of the object, with lowercase keys.
+}
+
+// Object is an object read by Get: its content is streamed from the store, and must be closed.
+type Object struct {
+	io.ReadCloser
+	Info ObjectInfo // Info describes the object.
+}
+
+// ListPage is a page of the objects listed by List.
+type ListPage struct {
+	Objects    []ObjectInfo // Objects are the objects of the page, sorted by key.
+	NextCursor string       // NextCursor lists the next page with WithCursor, or is empty on the last page.
+}
+
+// PutOptions holds the options of Put, resolved by the Stores with NewPutOptions.
+type PutOptions struct {
+	ContentType  string            // ContentType defaults to DefaultContentType.
+	CacheControl string            // CacheControl is the Cache-Control of the object when it is served, if any.
+	Metadata     map[string]string // Metadata are stored with lowercase keys.
+}
+
+// PutOption specifies Put options.
+type PutOption func(*PutOptions)
+
+// WithContentType sets the media type of the object, e.g., "image/png". It defaults to DefaultContentType.
+func WithContentType(contentType string) PutOption {
+	return func(opts *PutOptions) {
+		if contentType != "" {
+			opts.ContentType = contentType
+		}
+	}
+}
+
+// WithCacheControl sets the Cache-Control of the object when it is served, e.g., "public, max-age=86400".
+func WithCacheControl(cacheControl string) PutOption {
+	return func(opts *PutOptions) {
+		opts.CacheControl = cacheControl
+	}
+}
+
+// WithMetadata adds user-defined metadata to the object, e.g., the ID of its uploader. Their keys are lowercased,
+// since some stores are case-insensitive.
+func WithMetadata(metadata map[string]string) PutOption {
+	return func(opts *PutOptions) {
+		if opts.Metadata == nil {
+			opts.Metadata = make(map[string]string, len(metadata))
+		}
+		for key, value := range metadata {
+			opts.Metadata[strings.ToLower(key)] = value
+		}
+	}
+}
+
+// NewPutOptions resolves opts, for the implementations of Store.
+func NewPutOptions(opts ...PutOption) PutOptions {
+	o := PutOptions{ContentType: DefaultContentType}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.Metadata = maps.Clone(o.Metadata)
+	return o
+}
+
+// ListOptions holds the options of List, resolved by the Stores with NewListOptions.
+type ListOptions struct {
+	Cursor string // Cursor is the NextCursor of the previous page, or empty for the first page.
+	Limit  int    // Limit is the maximum number of objects of the page, defaulting to DefaultListLimit.
+}
+
+// ListOption specifies List options.
+type ListOption func(*ListOptions)
+
+// WithCursor lists the page following the one whose NextCursor is cursor.
+func WithCursor(cursor string) ListOption {
+	return func(opts *ListOptions) {
+		opts.Cursor = cursor
+	}
+}
+
+// WithLimit sets the maximum number of objects of the page. It defaults to DefaultListLimit, which is also the
+// maximum of some stores.
+func WithLimit(n int) ListOption {
+	return func(opts *ListOptions) {
+		if n > 0 {
+			opts.Limit = n
+		}
+	}
+}
+
+// NewListOptions resolves opts, for the implementations of Store.
+func NewListOptions(opts ...ListOption) ListOptions {
+	o := ListOptions{Limit: DefaultListLimit}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// SignOptions holds the options of SignedURL, resolved by the Stores with NewSignOptions.
+type SignOptions struct {
+	Method string        // Method is the method allowed by the URL, GET or PUT.
+	Expiry time.Duration // Expiry is the lifetime of the URL.
+}
+
+// SignOption specifies SignedURL options.
+type SignOption func(*SignOptions)
+
+// WithMethod sets the method allowed by the URL: http.MethodGet to download the object, the default, or
+// http.MethodPut to upload it.
+func WithMethod(method string) SignOption {
+	return func(opts *SignOptions) {
+		if method == http.MethodGet || method == http.MethodPut {
+			opts.Method = method
+		}
+	}
+}
+
+// WithExpiry sets the lifetime of the URL. It defaults to DefaultSignedURLExpiry.
+func WithExpiry(d time.Duration) SignOption {
+	return func(opts *SignOptions) {
+		if d > 0 {
+			opts.Expiry = d
+		}
+	}
+}
+
+// NewSignOptions resolves opts, for the implementations of Store.
+func NewSignOptions(opts ...SignOption) SignOptions {
+	o := SignOptions{Method: http.MethodGet, Expiry: DefaultSignedURLExpiry}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+/*
+Store is a blob store, e.g., an S3 or GCS bucket, or a local directory, so that the services handling files do not
+bind to one cloud SDK. The contents are streamed in and out. The keys are slash-separated paths, see ValidateKey.
+
+Implementations: localstorage, s3storage and gcsstorage. WithTracing and WithMetrics decorate any Store.
+
+Example usage:
+
+	var store storage.Store = s3storage.New(&s3Client{client: s3.NewFromConfig(cfg)}, "uploads")
+
+	info, err := store.Put(ctx, "avatars/42.png", r.Body, storage.WithContentType("image/png"))
+	if err != nil {
+		// Handle error
+	}
+
+	object, err := store.Get(ctx, "avatars/42.png")
+	if errors.Is(err, storage.ErrNotFound) {
+		// Handle missing object
+	}
+	defer object.Close()
+	w.Header().Set("Content-Type", object.Info.ContentType)
+	_, _ = io.Copy(w, object)
+*/
+type Store interface {
+	// Put creates or replaces the object of key with the content of body, read until EOF.
+	Put(ctx context.Context, key string, body io.Reader, opts ...PutOption) (ObjectInfo, error)
+	// Get returns the object of key, to be closed, or ErrNotFound.
+	Get(ctx context.Context, key string) (*Object, error)
+	// Stat returns the description of the object of key, or ErrNotFound.
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Delete deletes the object of key. Deleting a missing object succeeds.
+	Delete(ctx context.Context, key string) error
+	// List returns a page of the objects whose key starts with prefix, sorted by key.
+	List(ctx context.Context, prefix string, opts ...ListOption) (ListPage, error)
+	// SignedURL returns a URL granting access to the object of key without credentials, until it expires, e.g.,
+	// for the browsers to download or upload it directly. It returns ErrNotSupported if the store cannot sign URLs.
+	SignedURL(ctx context.Context, key string, opts ...SignOption) (string, error)
+}
+
+// ValidateKey returns ErrInvalidKey unless key is a valid object key: a non-empty, unrooted, slash-separated path
+// without "." or ".." elements nor empty elements, see fs.ValidPath.
+func ValidateKey(key string) error {
+	if key == "." || !fs.ValidPath(key) {
+		return ErrInvalidKey.WithField("key", key)
+	}
+	return nil
+}
+
+// Walk calls fn with the objects of store whose key starts with prefix, sorted by key, listing them page by page.
+// It stops at the first error of List or fn, and returns it.
+func Walk(ctx context.Context, store Store, prefix string, fn func(info ObjectInfo) error) error {
+	var opts []ListOption
+	for {
+		page, err := store.List(ctx, prefix, opts...)
+		if err != nil {
+			return err
+		}
+		for _, info := range page.Objects {
+			if err := fn(info); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		opts = []ListOption{WithCursor(page.NextCursor)}
+	}
+}
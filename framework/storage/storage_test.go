@@ -0,0 +1,94 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/storage"
+	"github.com/kittipat1413/go-common/framework/storage/localstorage"
+)
+
+func newStore(t *testing.T) *localstorage.Store {
+	t.Helper()
+	store, err := localstorage.New(t.TempDir())
+	require.NoError(t, err)
+	return store
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"a", "avatars/42.png", "a/b/c.tar.gz", ".hidden"} {
+		assert.NoError(t, storage.ValidateKey(key), key)
+	}
+	for _, key := range []string{"", ".", "/a", "a/", "a//b", "../a", "a/../b", "a/./b"} {
+		assert.ErrorIs(t, storage.ValidateKey(key), storage.ErrInvalidKey, key)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	put := storage.NewPutOptions()
+	assert.Equal(t, storage.DefaultContentType, put.ContentType)
+	put = storage.NewPutOptions(
+		storage.WithContentType("image/png"),
+		storage.WithCacheControl("no-cache"),
+		storage.WithMetadata(map[string]string{"Uploader-ID": "42"}),
+		storage.WithMetadata(map[string]string{"source": "api"}),
+	)
+	assert.Equal(t, storage.PutOptions{
+		ContentType:  "image/png",
+		CacheControl: "no-cache",
+		Metadata:     map[string]string{"uploader-id": "42", "source": "api"},
+	}, put)
+
+	list := storage.NewListOptions(storage.WithLimit(-1))
+	assert.Equal(t, storage.ListOptions{Limit: storage.DefaultListLimit}, list)
+
+	sign := storage.NewSignOptions(storage.WithMethod(http.MethodDelete), storage.WithExpiry(0))
+	assert.Equal(t, storage.SignOptions{Method: http.MethodGet, Expiry: storage.DefaultSignedURLExpiry}, sign)
+	sign = storage.NewSignOptions(storage.WithMethod(http.MethodPut), storage.WithExpiry(time.Hour))
+	assert.Equal(t, storage.SignOptions{Method: http.MethodPut, Expiry: time.Hour}, sign)
+}
+
+// pagedStore lists 2 objects per page.
+type pagedStore struct {
+	storage.Store
+}
+
+func (s pagedStore) List(ctx context.Context, prefix string, opts ...storage.ListOption) (storage.ListPage, error) {
+	return s.Store.List(ctx, prefix, append(opts, storage.WithLimit(2))...)
+}
+
+func TestWalk(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t)
+	for _, key := range []string{"logs/1", "logs/2", "logs/3", "logs/4", "logs/5", "other"} {
+		_, err := store.Put(ctx, key, strings.NewReader(key))
+		require.NoError(t, err)
+	}
+
+	var keys []string
+	err := storage.Walk(ctx, pagedStore{store}, "logs/", func(info storage.ObjectInfo) error {
+		keys = append(keys, info.Key)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs/1", "logs/2", "logs/3", "logs/4", "logs/5"}, keys)
+
+	errStop := errors.New("stop")
+	keys = nil
+	err = storage.Walk(ctx, pagedStore{store}, "", func(info storage.ObjectInfo) error {
+		keys = append(keys, info.Key)
+		if len(keys) == 3 {
+			return errStop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Len(t, keys, 3)
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"io"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/kittipat1413/go-common/framework/storage"
+
+// Span attributes recorded by WithTracing.
+const (
+	AttributeStoreName   = attribute.Key("storage.name")
+	AttributeKeyHash     = attribute.Key("storage.key_hash")
+	AttributePrefixHash  = attribute.Key("storage.prefix_hash")
+	AttributeSize        = attribute.Key("storage.size")
+	AttributeContentType = attribute.Key("storage.content_type")
+	AttributeObjectCount = attribute.Key("storage.object_count")
+	AttributeMethod      = attribute.Key("storage.method")
+)
+
+// tracingOptions holds configuration options for the tracing decorator.
+type tracingOptions struct {
+	tracerProvider oteltrace.TracerProvider // tracerProvider is the OpenTelemetry tracer provider to use.
+}
+
+// TracingOption specifies tracing configuration options.
+type TracingOption func(*tracingOptions)
+
+// WithTracerProvider specifies a tracer provider to use for creating a tracer. Defaults to the global tracer provider.
+func WithTracerProvider(provider oteltrace.TracerProvider) TracingOption {
+	return func(opts *tracingOptions) {
+		if provider != nil {
+			opts.tracerProvider = provider
+		}
+	}
+}
+
+/*
+WithTracing wraps s to record an OpenTelemetry span for every operation. Spans carry the store name, a hash of the
+key (keys may contain personal data), and the size and content type of the objects. The span of Get ends when the
+object is returned, before its content is read. A missing object is not an error of the span.
+
+Example usage:
+
+	store := storage.WithTracing(s3storage.New(client, "uploads"), "uploads")
+*/
+func WithTracing(s Store, name string, options ...TracingOption) Store {
+	opts := &tracingOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	if opts.tracerProvider == nil {
+		opts.tracerProvider = otel.GetTracerProvider()
+	}
+	return &tracedStore{store: s, name: name, tracer: opts.tracerProvider.Tracer(tracerName)}
+}
+
+type tracedStore struct {
+	store  Store
+	name   string
+	tracer oteltrace.Tracer
+}
+
+func (s *tracedStore) Put(ctx context.Context, key string, body io.Reader, opts ...PutOption) (ObjectInfo, error) {
+	ctx, span := s.start(ctx, "storage.Put", AttributeKeyHash.String(hashKey(key)))
+	defer span.End()
+
+	info, err := s.store.Put(ctx, key, body, opts...)
+	if err == nil {
+		span.SetAttributes(AttributeSize.Int64(info.Size), AttributeContentType.String(info.ContentType))
+	}
+	recordError(span, err)
+	return info, err
+}
+
+func (s *tracedStore) Get(ctx context.Context, key string) (*Object, error) {
+	ctx, span := s.start(ctx, "storage.Get", AttributeKeyHash.String(hashKey(key)))
+	defer span.End()
+
+	object, err := s.store.Get(ctx, key)
+	if err == nil {
+		span.SetAttributes(AttributeSize.Int64(object.Info.Size), AttributeContentType.String(object.Info.ContentType))
+	}
+	recordError(span, err)
+	return object, err
+}
+
+func (s *tracedStore) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	ctx, span := s.start(ctx, "storage.Stat", AttributeKeyHash.String(hashKey(key)))
+	defer span.End()
+
+	info, err := s.store.Stat(ctx, key)
+	recordError(span, err)
+	return info, err
+}
+
+func (s *tracedStore) Delete(ctx context.Context, key string) error {
+	ctx, span := s.start(ctx, "storage.Delete", AttributeKeyHash.String(hashKey(key)))
+	defer span.End()
+
+	err := s.store.Delete(ctx, key)
+	recordError(span, err)
+	return err
+}
+
+func (s *tracedStore) List(ctx context.Context, prefix string, opts ...ListOption) (ListPage, error) {
+	ctx, span := s.start(ctx, "storage.List", AttributePrefixHash.String(hashKey(prefix)))
+	defer span.End()
+
+	page, err := s.store.List(ctx, prefix, opts...)
+	span.SetAttributes(AttributeObjectCount.Int(len(page.Objects)))
+	recordError(span, err)
+	return page, err
+}
+
+func (s *tracedStore) SignedURL(ctx context.Context, key string, opts ...SignOption) (string, error) {
+	ctx, span := s.start(ctx, "storage.SignedURL",
+		AttributeKeyHash.String(hashKey(key)),
+		AttributeMethod.String(NewSignOptions(opts...).Method),
+	)
+	defer span.End()
+
+	signed, err := s.store.SignedURL(ctx, key, opts...)
+	recordError(span, err)
+	return signed, err
+}
+
+func (s *tracedStore) start(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, oteltrace.Span) {
+	return s.tracer.Start(ctx, operation,
+		oteltrace.WithSpanKind(oteltrace.SpanKindClient),
+		oteltrace.WithAttributes(append(attributes, AttributeStoreName.String(s.name))...),
+	)
+}
+
+// recordError records err on span, if any, except ErrNotFound.
+func recordError(span oteltrace.Span, err error) {
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// hashKey returns the FNV-1a hash of key, so that spans can be correlated by key without exposing it.
+func hashKey(key string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
@@ -0,0 +1,52 @@
+package storage_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/kittipat1413/go-common/framework/storage"
+)
+
+func TestWithTracing(t *testing.T) {
+	ctx := context.Background()
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	store := storage.WithTracing(newStore(t), "uploads", storage.WithTracerProvider(tracerProvider))
+
+	_, err := store.Put(ctx, "avatars/42.png", strings.NewReader("png"), storage.WithContentType("image/png"))
+	require.NoError(t, err)
+	object, err := store.Get(ctx, "avatars/42.png")
+	require.NoError(t, err)
+	object.Close()
+	_, err = store.Stat(ctx, "avatars/missing.png")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = store.Stat(ctx, "../escape")
+	assert.ErrorIs(t, err, storage.ErrInvalidKey)
+	_, err = store.List(ctx, "avatars/")
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 5)
+	assert.Equal(t, "storage.Put", spans[0].Name)
+	attributes := make(map[string]interface{})
+	for _, attribute := range spans[0].Attributes {
+		attributes[string(attribute.Key)] = attribute.Value.AsInterface()
+	}
+	assert.Equal(t, "uploads", attributes["storage.name"])
+	assert.Equal(t, int64(3), attributes["storage.size"])
+	assert.Equal(t, "image/png", attributes["storage.content_type"])
+	assert.NotEmpty(t, attributes["storage.key_hash"])
+	assert.NotContains(t, attributes, "storage.key", "the keys are hashed")
+
+	assert.Equal(t, "storage.Get", spans[1].Name)
+	assert.Equal(t, codes.Unset, spans[2].Status.Code, "a missing object is not an error")
+	assert.Equal(t, codes.Error, spans[3].Status.Code)
+	assert.Equal(t, "storage.List", spans[4].Name)
+}
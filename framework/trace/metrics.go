@@ -0,0 +1,176 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+/*
+SetupMeterProvider creates a meter provider exporting the metrics with the exporter of cfg, periodically, and
+registers it as the global meter provider. The exporter, the collector and the resource are selected like the ones
+of Setup, so that the spans and the metrics of the service reach the same collector with the same attributes. The
+meter provider must be shut down before the service exits to export the last metrics, e.g., as a closer of the
+lifecycle manager.
+
+Example usage:
+
+	// OTEL_METRICS_EXPORTER=otlp OTEL_EXPORTER_OTLP_ENDPOINT=http://otel-collector:4317
+	meterProvider, err := trace.SetupMeterProvider(ctx, trace.Config{
+		ServiceName:    "payments",
+		ServiceVersion: version,
+		Environment:    "production",
+	})
+	if err != nil {
+		// Handle error
+	}
+	manager.AddCloser("meter-provider", meterProvider.Shutdown)
+*/
+func SetupMeterProvider(ctx context.Context, cfg Config) (*sdkmetric.MeterProvider, error) {
+	exporter := cfg.MetricExporter
+	if exporter == nil {
+		exporterType := cfg.Exporter
+		if exporterType == "" {
+			var err error
+			if exporterType, err = exporterTypeFromEnv(EnvMetricsExporter, EnvOTLPMetricsProtocol); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if exporter, err = newMetricExporter(ctx, exporterType, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := newResource(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdkmetric.Option{sdkmetric.WithResource(res)}
+	if exporter != nil {
+		var readerOpts []sdkmetric.PeriodicReaderOption
+		if cfg.MetricInterval > 0 {
+			readerOpts = append(readerOpts, sdkmetric.WithInterval(cfg.MetricInterval))
+		}
+		opts = append(opts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, readerOpts...)))
+	}
+	for _, reader := range cfg.MetricReaders {
+		opts = append(opts, sdkmetric.WithReader(reader))
+	}
+	meterProvider := sdkmetric.NewMeterProvider(opts...)
+
+	otel.SetMeterProvider(meterProvider)
+	return meterProvider, nil
+}
+
+// newMetricExporter returns the metric exporter of exporterType configured with cfg, nil for ExporterNone.
+func newMetricExporter(ctx context.Context, exporterType ExporterType, cfg Config) (sdkmetric.Exporter, error) {
+	switch exporterType {
+	case ExporterGRPC:
+		var opts []otlpmetricgrpc.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlpmetricgrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.Headers))
+		}
+		exporter, err := otlpmetricgrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize gRPC metric exporter: %w", err)
+		}
+		return exporter, nil
+	case ExporterHTTP:
+		var opts []otlpmetrichttp.Option
+		switch {
+		case strings.Contains(cfg.Endpoint, "://"):
+			opts = append(opts, otlpmetrichttp.WithEndpointURL(metricsURL(cfg.Endpoint)))
+		case cfg.Endpoint != "":
+			opts = append(opts, otlpmetrichttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(cfg.Headers))
+		}
+		exporter, err := otlpmetrichttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize HTTP metric exporter: %w", err)
+		}
+		return exporter, nil
+	case ExporterStdout:
+		exporter, err := stdoutmetric.New(stdoutmetric.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize stdout metric exporter: %w", err)
+		}
+		return exporter, nil
+	case ExporterNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedExporter, exporterType)
+	}
+}
+
+// metricsURL returns the URL of the metrics of the OTLP/HTTP collector at url, which may be the URL of its spans,
+// e.g., "http://otel-collector:4318/v1/traces", as Config.Endpoint is shared by the spans and the metrics.
+func metricsURL(url string) string {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return url
+	}
+	switch path := strings.TrimSuffix(u.Path, "/"); {
+	case path == "":
+		u.Path = "/v1/metrics"
+	case strings.HasSuffix(path, "/v1/traces"):
+		u.Path = strings.TrimSuffix(path, "/v1/traces") + "/v1/metrics"
+	}
+	return u.String()
+}
+
+// Telemetry holds the tracer and meter providers of the service, shut down together.
+type Telemetry struct {
+	TracerProvider *sdktrace.TracerProvider
+	MeterProvider  *sdkmetric.MeterProvider
+}
+
+/*
+SetupTelemetry creates the tracer provider of Setup and the meter provider of SetupMeterProvider with cfg, and
+registers them as the global providers.
+
+Example usage:
+
+	telemetry, err := trace.SetupTelemetry(ctx, trace.Config{ServiceName: "payments"})
+	if err != nil {
+		// Handle error
+	}
+	manager.AddCloser("telemetry", telemetry.Shutdown)
+*/
+func SetupTelemetry(ctx context.Context, cfg Config) (*Telemetry, error) {
+	tracerProvider, err := Setup(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	meterProvider, err := SetupMeterProvider(ctx, cfg)
+	if err != nil {
+		return nil, errors.Join(err, tracerProvider.Shutdown(ctx))
+	}
+	return &Telemetry{TracerProvider: tracerProvider, MeterProvider: meterProvider}, nil
+}
+
+// Shutdown shuts down the tracer and meter providers, exporting the buffered spans and the last metrics.
+func (t *Telemetry) Shutdown(ctx context.Context) error {
+	return errors.Join(t.TracerProvider.Shutdown(ctx), t.MeterProvider.Shutdown(ctx))
+}
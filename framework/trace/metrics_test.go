@@ -0,0 +1,270 @@
+package trace_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/kittipat1413/go-common/framework/trace"
+)
+
+// metricExporter is an in-memory sdkmetric.Exporter.
+type metricExporter struct {
+	mu       sync.Mutex
+	exported []metricdata.ResourceMetrics
+	shutdown bool
+	err      error
+}
+
+func (e *metricExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(kind)
+}
+
+func (e *metricExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (e *metricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.exported = append(e.exported, *rm)
+	return e.err
+}
+
+func (e *metricExporter) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+func (e *metricExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.shutdown = true
+	return nil
+}
+
+// counterValue returns the value of the counter name in rm.
+func counterValue(t *testing.T, rm metricdata.ResourceMetrics, name string) int64 {
+	t.Helper()
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name == name {
+				sum, ok := m.Data.(metricdata.Sum[int64])
+				require.True(t, ok)
+				require.Len(t, sum.DataPoints, 1)
+				return sum.DataPoints[0].Value
+			}
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return 0
+}
+
+func TestSetupMeterProvider(t *testing.T) {
+	ctx := context.Background()
+	reader := sdkmetric.NewManualReader()
+
+	meterProvider, err := trace.SetupMeterProvider(ctx, trace.Config{
+		ServiceName:    "payments",
+		ServiceVersion: "1.2.3",
+		Environment:    "production",
+		Attributes:     []attribute.KeyValue{attribute.String("team", "billing")},
+		MetricReaders:  []sdkmetric.Reader{reader},
+	})
+	require.NoError(t, err)
+	defer meterProvider.Shutdown(ctx)
+	assert.Equal(t, meterProvider, otel.GetMeterProvider())
+
+	counter, err := otel.Meter("test").Int64Counter("charges")
+	require.NoError(t, err)
+	counter.Add(ctx, 2)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	assert.Equal(t, int64(2), counterValue(t, rm, "charges"))
+	attributes := make(map[attribute.Key]string)
+	for _, kv := range rm.Resource.Attributes() {
+		attributes[kv.Key] = kv.Value.Emit()
+	}
+	assert.Equal(t, "payments", attributes["service.name"])
+	assert.Equal(t, "1.2.3", attributes["service.version"])
+	assert.Equal(t, "production", attributes["deployment.environment"])
+	assert.Equal(t, "billing", attributes["team"])
+}
+
+func TestSetupMeterProvider_MetricExporter(t *testing.T) {
+	ctx := context.Background()
+	exporter := &metricExporter{}
+
+	meterProvider, err := trace.SetupMeterProvider(ctx, trace.Config{MetricExporter: exporter})
+	require.NoError(t, err)
+	counter, err := meterProvider.Meter("test").Int64Counter("charges")
+	require.NoError(t, err)
+	counter.Add(ctx, 1)
+
+	// Shutdown exports the last metrics.
+	require.NoError(t, meterProvider.Shutdown(ctx))
+	require.NotEmpty(t, exporter.exported)
+	assert.Equal(t, int64(1), counterValue(t, exporter.exported[len(exporter.exported)-1], "charges"))
+	assert.True(t, exporter.shutdown)
+}
+
+func TestSetupMeterProvider_ExporterFromEnv(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		exporter string
+		protocol string
+		err      error
+	}{
+		{name: "none by default"},
+		{name: "none", exporter: "none"},
+		{name: "otlp", exporter: "otlp"},
+		{name: "otlp grpc", exporter: "otlp", protocol: "grpc"},
+		{name: "otlp http", exporter: "otlp", protocol: "http/protobuf"},
+		{name: "console", exporter: "console"},
+		{name: "stdout", exporter: "stdout"},
+		{name: "unsupported exporter", exporter: "prometheus", err: trace.ErrUnsupportedExporter},
+		{name: "unsupported protocol", exporter: "otlp", protocol: "http/json", err: trace.ErrUnsupportedExporter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_METRICS_EXPORTER", tt.exporter)
+			t.Setenv("OTEL_EXPORTER_OTLP_METRICS_PROTOCOL", tt.protocol)
+
+			meterProvider, err := trace.SetupMeterProvider(ctx, trace.Config{Endpoint: "localhost:4317", Insecure: true})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, meterProvider)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, meterProvider)
+			// The last export fails without a collector.
+			shutdownCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			_ = meterProvider.Shutdown(shutdownCtx)
+		})
+	}
+
+	// The exporter of the spans does not select the one of the metrics.
+	t.Setenv("OTEL_TRACES_EXPORTER", "zipkin")
+	t.Setenv("OTEL_METRICS_EXPORTER", "")
+	meterProvider, err := trace.SetupMeterProvider(ctx, trace.Config{})
+	require.NoError(t, err)
+	_ = meterProvider.Shutdown(ctx)
+
+	_, err = trace.SetupMeterProvider(ctx, trace.Config{Exporter: trace.ExporterType("zipkin")})
+	assert.ErrorIs(t, err, trace.ErrUnsupportedExporter)
+}
+
+func TestSetupMeterProvider_HTTPExporter(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		endpoint func(collector *httptest.Server) string
+	}{
+		{name: "host and port", endpoint: func(collector *httptest.Server) string { return collector.Listener.Addr().String() }},
+		{name: "URL", endpoint: func(collector *httptest.Server) string { return collector.URL }},
+		{name: "URL of the spans", endpoint: func(collector *httptest.Server) string { return collector.URL + "/v1/traces" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				request *colmetricpb.ExportMetricsServiceRequest
+				headers http.Header
+			)
+			collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v1/metrics", r.URL.Path)
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				mu.Lock()
+				defer mu.Unlock()
+				headers = r.Header
+				request = &colmetricpb.ExportMetricsServiceRequest{}
+				require.NoError(t, proto.Unmarshal(body, request))
+			}))
+			defer collector.Close()
+
+			meterProvider, err := trace.SetupMeterProvider(ctx, trace.Config{
+				ServiceName: "payments",
+				Exporter:    trace.ExporterHTTP,
+				Endpoint:    tt.endpoint(collector),
+				Insecure:    true,
+				Headers:     map[string]string{"Authorization": "Bearer token"},
+			})
+			require.NoError(t, err)
+			counter, err := meterProvider.Meter("test").Int64Counter("charges")
+			require.NoError(t, err)
+			counter.Add(ctx, 3)
+			require.NoError(t, meterProvider.Shutdown(ctx))
+
+			mu.Lock()
+			defer mu.Unlock()
+			require.NotNil(t, request)
+			assert.Equal(t, "Bearer token", headers.Get("Authorization"))
+			require.Len(t, request.GetResourceMetrics(), 1)
+			scopeMetrics := request.GetResourceMetrics()[0].GetScopeMetrics()
+			require.Len(t, scopeMetrics, 1)
+			require.Len(t, scopeMetrics[0].GetMetrics(), 1)
+			metric := scopeMetrics[0].GetMetrics()[0]
+			assert.Equal(t, "charges", metric.GetName())
+			assert.Equal(t, int64(3), metric.GetSum().GetDataPoints()[0].GetAsInt())
+		})
+	}
+}
+
+func TestSetupTelemetry(t *testing.T) {
+	ctx := context.Background()
+	spanExporter := tracetest.NewInMemoryExporter()
+	recorder := tracetest.NewSpanRecorder()
+	metricExporter := &metricExporter{}
+
+	telemetry, err := trace.SetupTelemetry(ctx, trace.Config{
+		ServiceName:    "payments",
+		SpanExporter:   spanExporter,
+		SpanProcessors: []sdktrace.SpanProcessor{recorder},
+		MetricExporter: metricExporter,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, telemetry.TracerProvider, otel.GetTracerProvider())
+	assert.Equal(t, telemetry.MeterProvider, otel.GetMeterProvider())
+
+	_, span := otel.Tracer("test").Start(ctx, "charge")
+	span.End()
+	counter, err := otel.Meter("test").Int64Counter("charges")
+	require.NoError(t, err)
+	counter.Add(ctx, 1)
+
+	// Shutdown flushes both providers, and returns their errors.
+	errExport := errors.New("collector unavailable")
+	metricExporter.err = errExport
+	assert.ErrorIs(t, telemetry.Shutdown(ctx), errExport)
+	assert.Len(t, recorder.Ended(), 1)
+	assert.NotEmpty(t, metricExporter.exported)
+	assert.True(t, metricExporter.shutdown)
+	_, span = telemetry.TracerProvider.Tracer("test").Start(ctx, "after shutdown")
+	assert.False(t, span.IsRecording(), "the tracer provider is shut down")
+
+	// The tracer provider is shut down when the meter provider cannot be created.
+	_, err = trace.SetupTelemetry(ctx, trace.Config{SpanExporter: spanExporter, Exporter: trace.ExporterType("zipkin")})
+	assert.ErrorIs(t, err, trace.ErrUnsupportedExporter)
+}
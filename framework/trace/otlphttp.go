@@ -0,0 +1,118 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+	"time"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// defaultHTTPEndpoint is the URL of the OTLP/HTTP exporter when neither Config.Endpoint nor the environment
+	// variables set it.
+	defaultHTTPEndpoint = "http://localhost:4318/v1/traces"
+	// httpExportTimeout is the time an export request may take.
+	httpExportTimeout = 10 * time.Second
+)
+
+// httpClient is the otlptrace.Client sending the spans to an OTLP/HTTP collector, encoded in protobuf.
+type httpClient struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// newHTTPClient returns the client sending the spans to endpoint, a URL or a host and port, or to the URL of the
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT and OTEL_EXPORTER_OTLP_ENDPOINT environment variables if endpoint is empty. The
+// headers default to the ones of the OTEL_EXPORTER_OTLP_TRACES_HEADERS and OTEL_EXPORTER_OTLP_HEADERS variables.
+func newHTTPClient(endpoint string, insecure bool, headers map[string]string) *httpClient {
+	url := endpoint
+	switch {
+	case url != "":
+	case os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "":
+		url = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	case os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "":
+		url = strings.TrimSuffix(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "/") + "/v1/traces"
+	default:
+		url = defaultHTTPEndpoint
+	}
+	if !strings.Contains(url, "://") {
+		// A host and port, like the endpoints of the gRPC exporter.
+		scheme := "https://"
+		if insecure {
+			scheme = "http://"
+		}
+		url = scheme + url + "/v1/traces"
+	}
+	if len(headers) == 0 {
+		headers = headersFromEnv()
+	}
+	return &httpClient{url: url, headers: headers, client: &http.Client{Timeout: httpExportTimeout}}
+}
+
+// headersFromEnv returns the headers of the OTEL_EXPORTER_OTLP_TRACES_HEADERS or OTEL_EXPORTER_OTLP_HEADERS
+// environment variable, a list of URL-encoded key=value pairs separated by commas.
+func headersFromEnv() map[string]string {
+	value := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS")
+	if value == "" {
+		value = os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")
+	}
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(pair, "=")
+		if !found {
+			continue
+		}
+		key, keyErr := neturl.QueryUnescape(strings.TrimSpace(key))
+		val, valErr := neturl.QueryUnescape(strings.TrimSpace(val))
+		if keyErr != nil || valErr != nil || key == "" {
+			continue
+		}
+		headers[key] = val
+	}
+	return headers
+}
+
+func (c *httpClient) Start(ctx context.Context) error {
+	return nil
+}
+
+func (c *httpClient) Stop(ctx context.Context) error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+func (c *httpClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	body, err := proto.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return fmt.Errorf("failed to encode the spans: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create the export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export the spans: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to export the spans: %s", resp.Status)
+	}
+	return nil
+}
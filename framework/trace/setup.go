@@ -0,0 +1,267 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+const (
+	// ExporterHTTP exports the spans to an OTLP/HTTP collector, encoded in protobuf.
+	ExporterHTTP ExporterType = "http"
+	// ExporterNone does not export the spans: they are still created, e.g., for the trace IDs of the logs, and
+	// propagated to the other services.
+	ExporterNone ExporterType = "none"
+)
+
+// Environment variables read by Setup and SetupMeterProvider.
+const (
+	// EnvTracesExporter selects the exporter: "otlp", "grpc", "http", "stdout", "console" or "none".
+	EnvTracesExporter = "OTEL_TRACES_EXPORTER"
+	// EnvMetricsExporter selects the exporter of the metrics, with the same values as EnvTracesExporter.
+	EnvMetricsExporter = "OTEL_METRICS_EXPORTER"
+	// EnvOTLPProtocol selects the protocol of the "otlp" exporter: "grpc" (default) or "http/protobuf".
+	EnvOTLPProtocol = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	// EnvOTLPTracesProtocol overrides EnvOTLPProtocol for the spans.
+	EnvOTLPTracesProtocol = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
+	// EnvOTLPMetricsProtocol overrides EnvOTLPProtocol for the metrics.
+	EnvOTLPMetricsProtocol = "OTEL_EXPORTER_OTLP_METRICS_PROTOCOL"
+	// EnvServiceVersion is the version of the service, e.g., the tag of its image.
+	EnvServiceVersion = "SERVICE_VERSION"
+)
+
+// ErrUnsupportedExporter is returned when the exporter type, or the value of OTEL_TRACES_EXPORTER or
+// OTEL_METRICS_EXPORTER, is not supported.
+var ErrUnsupportedExporter = errors.New("unsupported exporter type")
+
+// Config configures the tracer provider of Setup, and the meter provider of SetupMeterProvider.
+type Config struct {
+	// ServiceName is the service.name resource attribute. It defaults to the SERVICE_NAME environment variable, like
+	// the service name of logger.ConfigFromEnv, and is overridden by OTEL_SERVICE_NAME.
+	ServiceName string
+	// ServiceVersion is the service.version resource attribute. It defaults to the SERVICE_VERSION environment
+	// variable.
+	ServiceVersion string
+	// Environment is the deployment.environment resource attribute. It defaults to the ENVIRONMENT environment
+	// variable, like the environment of logger.ConfigFromEnv.
+	Environment string
+	// Attributes are additional resource attributes. The OTEL_RESOURCE_ATTRIBUTES environment variable adds more.
+	Attributes []attribute.KeyValue
+	// Exporter is the exporter of the spans and the metrics. It defaults to the OTEL_TRACES_EXPORTER environment
+	// variable for the spans, and to OTEL_METRICS_EXPORTER for the metrics, or to ExporterNone if it is not set, so
+	// that the services running without a collector do not fail to export.
+	Exporter ExporterType
+	// Endpoint is the collector of the OTLP exporters: the host and port, e.g., "otel-collector:4317", or the URL of
+	// the HTTP exporter. It defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment variables.
+	Endpoint string
+	// Insecure disables TLS for the connection to the collector.
+	Insecure bool
+	// Headers are additional headers sent with every export request of the OTLP exporters, e.g., authentication.
+	Headers map[string]string
+	// Sampler samples the spans, e.g., sdktrace.ParentBased(sdktrace.TraceIDRatioBased(0.1)). It defaults to the
+	// OTEL_TRACES_SAMPLER and OTEL_TRACES_SAMPLER_ARG environment variables, or to sampling every trace whose parent
+	// is sampled.
+	Sampler sdktrace.Sampler
+	// SpanExporter overrides the exporter of Exporter, e.g., with an in-memory exporter in tests.
+	SpanExporter sdktrace.SpanExporter
+	// SpanProcessors are additional span processors, e.g., requestcontext.SpanProcessor() recording the tenant and
+	// the user of the requests on their spans.
+	SpanProcessors []sdktrace.SpanProcessor
+	// MetricExporter overrides the exporter of Exporter for the metrics, e.g., with an in-memory exporter in tests.
+	MetricExporter sdkmetric.Exporter
+	// MetricInterval is the interval between the exports of the metrics. It defaults to the
+	// OTEL_METRIC_EXPORT_INTERVAL environment variable, or to 1 minute.
+	MetricInterval time.Duration
+	// MetricReaders are additional metric readers, e.g., a Prometheus exporter, or sdkmetric.NewManualReader() in
+	// tests.
+	MetricReaders []sdkmetric.Reader
+}
+
+/*
+Setup creates a tracer provider exporting the spans with the exporter of cfg, batched, and registers it as the
+global tracer provider, with the W3C Trace Context and Baggage propagators. The tracer provider must be shut down
+before the service exits to flush the buffered spans, e.g., as a closer of the lifecycle manager.
+
+Example usage:
+
+	// OTEL_TRACES_EXPORTER=otlp OTEL_EXPORTER_OTLP_ENDPOINT=http://otel-collector:4317
+	tracerProvider, err := trace.Setup(ctx, trace.Config{
+		ServiceName:    "payments",
+		ServiceVersion: version,
+		Environment:    "production",
+	})
+	if err != nil {
+		// Handle error
+	}
+	manager.AddCloser("tracer-provider", tracerProvider.Shutdown)
+*/
+func Setup(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	exporter := cfg.SpanExporter
+	if exporter == nil {
+		exporterType := cfg.Exporter
+		if exporterType == "" {
+			var err error
+			if exporterType, err = exporterTypeFromEnv(EnvTracesExporter, EnvOTLPTracesProtocol); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if exporter, err = newExporter(ctx, exporterType, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := newResource(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	for _, processor := range cfg.SpanProcessors {
+		opts = append(opts, sdktrace.WithSpanProcessor(processor))
+	}
+	if cfg.Sampler != nil {
+		opts = append(opts, sdktrace.WithSampler(cfg.Sampler))
+	}
+	tracerProvider := sdktrace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(
+		propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+	)
+	return tracerProvider, nil
+}
+
+// exporterTypeFromEnv returns the exporter type of the environment variable envExporter, OTEL_TRACES_EXPORTER or
+// OTEL_METRICS_EXPORTER, following the OpenTelemetry conventions, or ExporterNone if it is not set. The protocol
+// of the "otlp" exporter is read from envProtocol, or else from OTEL_EXPORTER_OTLP_PROTOCOL.
+func exporterTypeFromEnv(envExporter, envProtocol string) (ExporterType, error) {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv(envExporter)))
+	switch value {
+	case "", string(ExporterNone):
+		return ExporterNone, nil
+	case "otlp":
+		protocol := os.Getenv(envProtocol)
+		if protocol == "" {
+			protocol = os.Getenv(EnvOTLPProtocol)
+		}
+		switch strings.ToLower(strings.TrimSpace(protocol)) {
+		case "", "grpc":
+			return ExporterGRPC, nil
+		case "http/protobuf":
+			return ExporterHTTP, nil
+		default:
+			return "", fmt.Errorf("%w: %s=%q", ErrUnsupportedExporter, EnvOTLPProtocol, protocol)
+		}
+	case "console", string(ExporterStdout):
+		return ExporterStdout, nil
+	case string(ExporterGRPC), string(ExporterHTTP):
+		return ExporterType(value), nil
+	default:
+		return "", fmt.Errorf("%w: %s=%q", ErrUnsupportedExporter, envExporter, value)
+	}
+}
+
+// newExporter returns the exporter of exporterType configured with cfg, nil for ExporterNone.
+func newExporter(ctx context.Context, exporterType ExporterType, cfg Config) (sdktrace.SpanExporter, error) {
+	switch exporterType {
+	case ExporterGRPC:
+		var opts []otlptracegrpc.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+		}
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize gRPC trace exporter: %w", err)
+		}
+		return exporter, nil
+	case ExporterHTTP:
+		exporter, err := otlptrace.New(ctx, newHTTPClient(cfg.Endpoint, cfg.Insecure, cfg.Headers))
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize HTTP trace exporter: %w", err)
+		}
+		return exporter, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize stdout trace exporter: %w", err)
+		}
+		return exporter, nil
+	case ExporterNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedExporter, exporterType)
+	}
+}
+
+// newResource returns the resource describing the service of cfg, merged with the default resource.
+func newResource(ctx context.Context, cfg Config) (*resource.Resource, error) {
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = strings.TrimSpace(os.Getenv(logger.EnvServiceName))
+	}
+	serviceVersion := cfg.ServiceVersion
+	if serviceVersion == "" {
+		serviceVersion = strings.TrimSpace(os.Getenv(EnvServiceVersion))
+	}
+	environment := cfg.Environment
+	if environment == "" {
+		environment = strings.TrimSpace(os.Getenv(logger.EnvEnvironment))
+	}
+
+	attributes := append([]attribute.KeyValue(nil), cfg.Attributes...)
+	if serviceName != "" {
+		attributes = append(attributes, semconv.ServiceName(serviceName))
+	}
+	if serviceVersion != "" {
+		attributes = append(attributes, semconv.ServiceVersion(serviceVersion))
+	}
+	if environment != "" {
+		attributes = append(attributes, semconv.DeploymentEnvironment(environment))
+	}
+
+	serviceResource, err := resource.New(ctx,
+		resource.WithOS(),
+		resource.WithProcessRuntimeName(),
+		resource.WithProcessRuntimeVersion(),
+		resource.WithProcessRuntimeDescription(),
+		resource.WithAttributes(attributes...),
+		resource.WithFromEnv(), // OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the attributes.
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create system resource: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), serviceResource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,221 @@
+package trace_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/kittipat1413/go-common/framework/trace"
+)
+
+// resourceAttributes returns the resource attributes of the span.
+func resourceAttributes(span tracetest.SpanStub) map[attribute.Key]string {
+	attributes := make(map[attribute.Key]string)
+	for _, kv := range span.Resource.Attributes() {
+		attributes[kv.Key] = kv.Value.Emit()
+	}
+	return attributes
+}
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+	exporter := tracetest.NewInMemoryExporter()
+
+	tracerProvider, err := trace.Setup(ctx, trace.Config{
+		ServiceName:    "payments",
+		ServiceVersion: "1.2.3",
+		Environment:    "production",
+		Attributes:     []attribute.KeyValue{attribute.String("team", "billing")},
+		SpanExporter:   exporter,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, tracerProvider, otel.GetTracerProvider())
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+
+	_, span := otel.Tracer("test").Start(ctx, "charge")
+	span.End()
+	require.NoError(t, tracerProvider.ForceFlush(ctx))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	attributes := resourceAttributes(spans[0])
+	assert.Equal(t, "payments", attributes["service.name"])
+	assert.Equal(t, "1.2.3", attributes["service.version"])
+	assert.Equal(t, "production", attributes["deployment.environment"])
+	assert.Equal(t, "billing", attributes["team"])
+}
+
+func TestSetup_ResourceFromEnv(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("SERVICE_NAME", "payments")
+	t.Setenv("SERVICE_VERSION", "1.2.3")
+	t.Setenv("ENVIRONMENT", "staging")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "team=billing")
+	exporter := tracetest.NewInMemoryExporter()
+
+	tracerProvider, err := trace.Setup(ctx, trace.Config{SpanExporter: exporter})
+	require.NoError(t, err)
+	_, span := tracerProvider.Tracer("test").Start(ctx, "charge")
+	span.End()
+	require.NoError(t, tracerProvider.ForceFlush(ctx))
+
+	attributes := resourceAttributes(exporter.GetSpans()[0])
+	assert.Equal(t, "payments", attributes["service.name"])
+	assert.Equal(t, "1.2.3", attributes["service.version"])
+	assert.Equal(t, "staging", attributes["deployment.environment"])
+	assert.Equal(t, "billing", attributes["team"])
+
+	t.Setenv("OTEL_SERVICE_NAME", "payments-worker")
+	exporter.Reset()
+	tracerProvider, err = trace.Setup(ctx, trace.Config{ServiceName: "payments", SpanExporter: exporter})
+	require.NoError(t, err)
+	_, span = tracerProvider.Tracer("test").Start(ctx, "charge")
+	span.End()
+	require.NoError(t, tracerProvider.ForceFlush(ctx))
+	assert.Equal(t, "payments-worker", resourceAttributes(exporter.GetSpans()[0])["service.name"], "OTEL_SERVICE_NAME overrides the service name")
+}
+
+func TestSetup_Sampler(t *testing.T) {
+	ctx := context.Background()
+	exporter := tracetest.NewInMemoryExporter()
+
+	tracerProvider, err := trace.Setup(ctx, trace.Config{Sampler: sdktrace.NeverSample(), SpanExporter: exporter})
+	require.NoError(t, err)
+	_, span := tracerProvider.Tracer("test").Start(ctx, "charge")
+	span.End()
+	require.NoError(t, tracerProvider.ForceFlush(ctx))
+	assert.Empty(t, exporter.GetSpans())
+}
+
+func TestSetup_SpanProcessors(t *testing.T) {
+	ctx := context.Background()
+	exporter := tracetest.NewInMemoryExporter()
+	recorder := tracetest.NewSpanRecorder()
+
+	tracerProvider, err := trace.Setup(ctx, trace.Config{
+		SpanExporter:   exporter,
+		SpanProcessors: []sdktrace.SpanProcessor{recorder},
+	})
+	require.NoError(t, err)
+	_, span := tracerProvider.Tracer("test").Start(ctx, "charge")
+	span.End()
+	require.NoError(t, tracerProvider.ForceFlush(ctx))
+
+	require.Len(t, recorder.Started(), 1)
+	require.Len(t, recorder.Ended(), 1)
+	assert.Len(t, exporter.GetSpans(), 1, "the spans are still exported")
+}
+
+func TestSetup_ExporterFromEnv(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		exporter string
+		protocol string
+		err      error
+	}{
+		{name: "none by default"},
+		{name: "none", exporter: "none"},
+		{name: "otlp", exporter: "otlp"},
+		{name: "otlp grpc", exporter: "otlp", protocol: "grpc"},
+		{name: "otlp http", exporter: "otlp", protocol: "http/protobuf"},
+		{name: "console", exporter: "console"},
+		{name: "stdout", exporter: "stdout"},
+		{name: "unsupported exporter", exporter: "zipkin", err: trace.ErrUnsupportedExporter},
+		{name: "unsupported protocol", exporter: "otlp", protocol: "http/json", err: trace.ErrUnsupportedExporter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_TRACES_EXPORTER", tt.exporter)
+			t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", tt.protocol)
+
+			tracerProvider, err := trace.Setup(ctx, trace.Config{Endpoint: "localhost:4317", Insecure: true})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, tracerProvider)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, tracerProvider)
+			_ = tracerProvider.Shutdown(ctx)
+		})
+	}
+
+	_, err := trace.Setup(ctx, trace.Config{Exporter: trace.ExporterType("zipkin")})
+	assert.ErrorIs(t, err, trace.ErrUnsupportedExporter)
+}
+
+func TestSetup_HTTPExporter(t *testing.T) {
+	ctx := context.Background()
+	var (
+		request *coltracepb.ExportTraceServiceRequest
+		headers http.Header
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		headers = r.Header
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		request = &coltracepb.ExportTraceServiceRequest{}
+		require.NoError(t, proto.Unmarshal(body, request))
+	}))
+	defer collector.Close()
+
+	tracerProvider, err := trace.Setup(ctx, trace.Config{
+		ServiceName: "payments",
+		Exporter:    trace.ExporterHTTP,
+		Endpoint:    collector.Listener.Addr().String(),
+		Insecure:    true,
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+	})
+	require.NoError(t, err)
+	_, span := tracerProvider.Tracer("test").Start(ctx, "charge")
+	span.End()
+	require.NoError(t, tracerProvider.Shutdown(ctx))
+
+	require.NotNil(t, request)
+	assert.Equal(t, "application/x-protobuf", headers.Get("Content-Type"))
+	assert.Equal(t, "Bearer token", headers.Get("Authorization"))
+	require.Len(t, request.GetResourceSpans(), 1)
+	scopeSpans := request.GetResourceSpans()[0].GetScopeSpans()
+	require.Len(t, scopeSpans, 1)
+	require.Len(t, scopeSpans[0].GetSpans(), 1)
+	assert.Equal(t, "charge", scopeSpans[0].GetSpans()[0].GetName())
+}
+
+func TestSetup_HTTPExporterFromEnv(t *testing.T) {
+	ctx := context.Background()
+	var headers http.Header
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		headers = r.Header
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+	t.Setenv("OTEL_TRACES_EXPORTER", "otlp")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL)
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=se%20cret")
+
+	tracerProvider, err := trace.Setup(ctx, trace.Config{})
+	require.NoError(t, err)
+	_, span := tracerProvider.Tracer("test").Start(ctx, "charge")
+	span.End()
+	assert.Error(t, tracerProvider.ForceFlush(ctx), "the errors of the collector are returned")
+	_ = tracerProvider.Shutdown(ctx)
+
+	require.NotNil(t, headers)
+	assert.Equal(t, "se cret", headers.Get("Api-Key"))
+}
@@ -0,0 +1,40 @@
+package customval
+
+import (
+	ut "github.com/go-playground/universal-translator"
+	validator "github.com/go-playground/validator/v10"
+	v "github.com/kittipat1413/go-common/framework/validator"
+)
+
+// Ensure CountryValidator implements the CustomValidator interface.
+var _ v.CustomValidator = (*CountryValidator)(nil)
+
+// CountryValidatorTag is the tag identifier for country code validation (`validate:"country"`).
+const CountryValidatorTag = "country"
+
+// CountryValidator implements the CustomValidator interface for ISO 3166-1 alpha-2 country codes, e.g., "TH".
+type CountryValidator struct{}
+
+// Tag returns the tag identifier for the country validator.
+func (*CountryValidator) Tag() string {
+	return CountryValidatorTag
+}
+
+// Func returns the validation function for country codes, the built-in ISO 3166-1 alpha-2 validation of
+// validator/v10.
+func (*CountryValidator) Func() validator.Func {
+	return builtin("iso3166_1_alpha2")
+}
+
+// Translation returns the translation text and custom translation function for the country validator.
+func (*CountryValidator) Translation() (string, validator.TranslationFunc) {
+	translationText := "{0} must be a valid ISO 3166-1 alpha-2 country code"
+
+	customTransFunc := func(ut ut.Translator, fe validator.FieldError) string {
+		// {0} will be replaced with fe.Field()
+		t, _ := ut.T(fe.Tag(), fe.Field())
+		return t
+	}
+
+	return translationText, customTransFunc
+}
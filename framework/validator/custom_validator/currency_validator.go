@@ -0,0 +1,39 @@
+package customval
+
+import (
+	ut "github.com/go-playground/universal-translator"
+	validator "github.com/go-playground/validator/v10"
+	v "github.com/kittipat1413/go-common/framework/validator"
+)
+
+// Ensure CurrencyValidator implements the CustomValidator interface.
+var _ v.CustomValidator = (*CurrencyValidator)(nil)
+
+// CurrencyValidatorTag is the tag identifier for currency code validation (`validate:"currency"`).
+const CurrencyValidatorTag = "currency"
+
+// CurrencyValidator implements the CustomValidator interface for ISO 4217 currency codes, e.g., "THB".
+type CurrencyValidator struct{}
+
+// Tag returns the tag identifier for the currency validator.
+func (*CurrencyValidator) Tag() string {
+	return CurrencyValidatorTag
+}
+
+// Func returns the validation function for currency codes, the built-in ISO 4217 validation of validator/v10.
+func (*CurrencyValidator) Func() validator.Func {
+	return builtin("iso4217")
+}
+
+// Translation returns the translation text and custom translation function for the currency validator.
+func (*CurrencyValidator) Translation() (string, validator.TranslationFunc) {
+	translationText := "{0} must be a valid ISO 4217 currency code"
+
+	customTransFunc := func(ut ut.Translator, fe validator.FieldError) string {
+		// {0} will be replaced with fe.Field()
+		t, _ := ut.T(fe.Tag(), fe.Field())
+		return t
+	}
+
+	return translationText, customTransFunc
+}
@@ -0,0 +1,80 @@
+package customval
+
+import (
+	ut "github.com/go-playground/universal-translator"
+	validator "github.com/go-playground/validator/v10"
+	v "github.com/kittipat1413/go-common/framework/validator"
+)
+
+// Ensure NationalIDValidator implements the CustomValidator interface.
+var _ v.CustomValidator = (*NationalIDValidator)(nil)
+
+const (
+	// NationalIDValidatorTag is the tag identifier for national ID validation (`validate:"national_id={country}"`).
+	NationalIDValidatorTag = "national_id"
+
+	// Supported countries
+	NationalIDThailand = "TH" // NationalIDThailand represents the 13-digit Thai citizen ID (`validate:"national_id=TH"`).
+)
+
+// nationalIDCheckers holds the checksum validation of the national IDs of every supported country.
+var nationalIDCheckers = map[string]func(id string) bool{
+	NationalIDThailand: isThaiNationalID,
+}
+
+// NationalIDValidator implements the CustomValidator interface for national IDs, including their checksum.
+type NationalIDValidator struct{}
+
+// Tag returns the tag identifier for the national ID validator.
+func (*NationalIDValidator) Tag() string {
+	return NationalIDValidatorTag
+}
+
+// Func returns the validation function for national IDs.
+func (*NationalIDValidator) Func() validator.Func {
+	return validateNationalID
+}
+
+// Translation returns the translation text and custom translation function for the national ID validator.
+func (*NationalIDValidator) Translation() (string, validator.TranslationFunc) {
+	translationText := "{0} must be a valid national ID of '{1}'"
+
+	customTransFunc := func(ut ut.Translator, fe validator.FieldError) string {
+		// {0} will be replaced with fe.Field(), {1} with fe.Param()
+		t, _ := ut.T(fe.Tag(), fe.Field(), fe.Param())
+		return t
+	}
+
+	return translationText, customTransFunc
+}
+
+// validateNationalID validates a national ID field for the country specified as parameter.
+// Supported countries:
+//   - "TH": Validates 13-digit Thai citizen IDs and their check digit.
+//
+// Returns false if the country is unrecognized or if the ID is invalid.
+func validateNationalID(fl validator.FieldLevel) bool {
+	checker, found := nationalIDCheckers[fl.Param()]
+	if !found {
+		return false
+	}
+	return checker(fl.Field().String())
+}
+
+// isThaiNationalID reports whether id is a 13-digit Thai citizen ID whose last digit is the check digit:
+// (11 - (sum of the first 12 digits weighted from 13 down to 2) mod 11) mod 10.
+func isThaiNationalID(id string) bool {
+	if len(id) != 13 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 13; i++ {
+		if id[i] < '0' || id[i] > '9' {
+			return false
+		}
+		if i < 12 {
+			sum += int(id[i]-'0') * (13 - i)
+		}
+	}
+	return int(id[12]-'0') == (11-sum%11)%10
+}
@@ -0,0 +1,39 @@
+package customval
+
+import (
+	ut "github.com/go-playground/universal-translator"
+	validator "github.com/go-playground/validator/v10"
+	v "github.com/kittipat1413/go-common/framework/validator"
+)
+
+// Ensure PhoneValidator implements the CustomValidator interface.
+var _ v.CustomValidator = (*PhoneValidator)(nil)
+
+// PhoneValidatorTag is the tag identifier for phone number validation (`validate:"phone"`).
+const PhoneValidatorTag = "phone"
+
+// PhoneValidator implements the CustomValidator interface for phone numbers in the E.164 format, e.g., "+66812345678".
+type PhoneValidator struct{}
+
+// Tag returns the tag identifier for the phone validator.
+func (*PhoneValidator) Tag() string {
+	return PhoneValidatorTag
+}
+
+// Func returns the validation function for phone numbers, the built-in E.164 validation of validator/v10.
+func (*PhoneValidator) Func() validator.Func {
+	return builtin("e164")
+}
+
+// Translation returns the translation text and custom translation function for the phone validator.
+func (*PhoneValidator) Translation() (string, validator.TranslationFunc) {
+	translationText := "{0} must be a valid phone number in E.164 format"
+
+	customTransFunc := func(ut ut.Translator, fe validator.FieldError) string {
+		// {0} will be replaced with fe.Field()
+		t, _ := ut.T(fe.Tag(), fe.Field())
+		return t
+	}
+
+	return translationText, customTransFunc
+}
@@ -0,0 +1,56 @@
+package customval
+
+import (
+	"sync"
+
+	validator "github.com/go-playground/validator/v10"
+	v "github.com/kittipat1413/go-common/framework/validator"
+)
+
+// Validators returns all the custom validators of the package, so that every service validates dates, phone
+// numbers, currency and country codes and national IDs with the same tags and messages.
+func Validators() []v.CustomValidator {
+	return []v.CustomValidator{
+		new(DateValidator),
+		new(NotBlankValidator),
+		new(PhoneValidator),
+		new(CurrencyValidator),
+		new(CountryValidator),
+		new(NationalIDValidator),
+	}
+}
+
+// Register registers all the custom validators of the package on val. It must be called before val is used,
+// typically at init.
+//
+// Example:
+//
+//	func init() {
+//	    if err := customval.Register(validator.Default()); err != nil {
+//	        panic(err)
+//	    }
+//	}
+func Register(val *v.Validator) error {
+	for _, cv := range Validators() {
+		if err := val.RegisterCustomValidator(cv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	builtinOnce     sync.Once
+	builtinValidate *validator.Validate
+)
+
+// builtin returns a validation function running the built-in validation tag of validator/v10, so that the custom
+// validators reuse its well-tested rules under the tags and messages of the package.
+func builtin(tag string) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		builtinOnce.Do(func() {
+			builtinValidate = validator.New()
+		})
+		return builtinValidate.Var(fl.Field().String(), tag) == nil
+	}
+}
@@ -0,0 +1,86 @@
+package customval_test
+
+import (
+	"testing"
+
+	"github.com/kittipat1413/go-common/framework/validator"
+	custom_validator "github.com/kittipat1413/go-common/framework/validator/custom_validator"
+	"github.com/kittipat1413/go-common/util/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type CustomerStruct struct {
+	Phone      string  `validate:"phone"`
+	Currency   string  `validate:"currency"`
+	Country    string  `validate:"country"`
+	NationalID string  `validate:"national_id=TH"`
+	Nickname   *string `validate:"omitempty,notblank"`
+}
+
+func validCustomer() CustomerStruct {
+	return CustomerStruct{
+		Phone:      "+66812345678",
+		Currency:   "THB",
+		Country:    "TH",
+		NationalID: "1101700203077",
+	}
+}
+
+func TestRegister(t *testing.T) {
+	v, err := validator.NewValidator()
+	require.NoError(t, err)
+	require.NoError(t, custom_validator.Register(v))
+
+	testCases := []struct {
+		name    string
+		modify  func(c *CustomerStruct)
+		wantMsg string
+	}{
+		{name: "Valid customer", modify: func(c *CustomerStruct) {}},
+		{name: "Phone without plus sign", modify: func(c *CustomerStruct) { c.Phone = "0812345678" }, wantMsg: "Phone must be a valid phone number in E.164 format"},
+		{name: "Phone with letters", modify: func(c *CustomerStruct) { c.Phone = "+66abc" }, wantMsg: "Phone must be a valid phone number in E.164 format"},
+		{name: "Unknown currency", modify: func(c *CustomerStruct) { c.Currency = "XYZ" }, wantMsg: "Currency must be a valid ISO 4217 currency code"},
+		{name: "Lowercase currency", modify: func(c *CustomerStruct) { c.Currency = "thb" }, wantMsg: "Currency must be a valid ISO 4217 currency code"},
+		{name: "Alpha-3 country", modify: func(c *CustomerStruct) { c.Country = "THA" }, wantMsg: "Country must be a valid ISO 3166-1 alpha-2 country code"},
+		{name: "National ID with wrong check digit", modify: func(c *CustomerStruct) { c.NationalID = "1101700203078" }, wantMsg: "NationalID must be a valid national ID of 'TH'"},
+		{name: "National ID too short", modify: func(c *CustomerStruct) { c.NationalID = "110170020307" }, wantMsg: "NationalID must be a valid national ID of 'TH'"},
+		{name: "National ID with letters", modify: func(c *CustomerStruct) { c.NationalID = "110170020307A" }, wantMsg: "NationalID must be a valid national ID of 'TH'"},
+		{name: "Blank nickname", modify: func(c *CustomerStruct) { c.Nickname = pointer.ToPointer("  ") }, wantMsg: "Nickname cannot be blank"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			customer := validCustomer()
+			tc.modify(&customer)
+			err := v.ValidateStruct(customer)
+			if tc.wantMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.wantMsg)
+			}
+		})
+	}
+}
+
+func TestValidateNationalIDUnsupportedCountry(t *testing.T) {
+	v, _ := validator.NewValidator(
+		validator.WithCustomValidator(new(custom_validator.NationalIDValidator)),
+	)
+
+	type UnsupportedCountryStruct struct {
+		NationalID string `validate:"national_id=XX"`
+	}
+
+	err := v.ValidateStruct(UnsupportedCountryStruct{NationalID: "1101700203077"})
+	assert.EqualError(t, err, "NationalID must be a valid national ID of 'XX'")
+}
+
+func TestValidators(t *testing.T) {
+	tags := make(map[string]bool)
+	for _, cv := range custom_validator.Validators() {
+		assert.False(t, tags[cv.Tag()], "duplicate tag %q", cv.Tag())
+		tags[cv.Tag()] = true
+	}
+	assert.Len(t, tags, 6)
+}
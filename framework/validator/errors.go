@@ -0,0 +1,59 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+)
+
+const (
+	// CodeValidationFailed is the code of the errors of the requests failing validation.
+	CodeValidationFailed = "validation_failed"
+	// CodeInvalidBody is the code of the errors of the request bodies which cannot be decoded.
+	CodeInvalidBody = "invalid_body"
+)
+
+// Violation describes a field failing a validation rule.
+type Violation struct {
+	// Field is the path of the field from the validated struct, e.g., "address.city".
+	Field string `json:"field"`
+	// Rule is the tag of the rule failed, e.g., "required" or "max".
+	Rule string `json:"rule"`
+	// Param is the parameter of the rule, e.g., "130" for "max=130".
+	Param string `json:"param,omitempty"`
+	// Message is the translated message of the violation, e.g., "age must be 130 or less".
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by ValidateStruct when the struct fails validation, with every violation.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error returns the messages of the violations, separated by commas.
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return strings.Join(messages, ", ")
+}
+
+/*
+CodedError returns e as an InvalidArgument *errors.CodedError with the CodeValidationFailed code and the
+violations in the "violations" field, so that the errors.ProblemWriter renders them in the problem details:
+
+	{
+		"type": "about:blank",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "validation failed: age must be 130 or less",
+		"code": "validation_failed",
+		"violations": [{"field": "age", "rule": "lte", "param": "130", "message": "age must be 130 or less"}]
+	}
+*/
+func (e *ValidationError) CodedError() *errors.CodedError {
+	return errors.InvalidArgument(CodeValidationFailed, "validation failed").
+		WithField("violations", e.Violations).
+		Wrap(e)
+}
@@ -0,0 +1,60 @@
+package validator
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+)
+
+// ValidateRequest validates a request DTO like ValidateStruct, and returns the violations as an InvalidArgument
+// *errors.CodedError, see ValidationError.CodedError, ready to be written with an errors.ProblemWriter.
+//
+// Example:
+//
+//	if err := c.ShouldBindQuery(&query); err != nil { ... }
+//	if err := validator.Default().ValidateRequest(query); err != nil {
+//	    problems.Write(c.Writer, c.Request, err)
+//	    return
+//	}
+func (v *Validator) ValidateRequest(s interface{}) error {
+	err := v.ValidateStruct(s)
+	var validationErr *ValidationError
+	if stderrors.As(err, &validationErr) {
+		return validationErr.CodedError()
+	}
+	return err
+}
+
+// DecodeJSON decodes the JSON body of r into dst and validates it with ValidateRequest. It returns an
+// InvalidArgument *errors.CodedError with the CodeInvalidBody code if the body is empty or is not a single JSON
+// value of the type of dst. Limit the size of the bodies upstream, e.g., with http.MaxBytesHandler.
+//
+// Example:
+//
+//	func (h *userHandler) Create(w http.ResponseWriter, r *http.Request) {
+//	    var req CreateUserRequest
+//	    if err := validator.Default().DecodeJSON(r, &req); err != nil {
+//	        problems.Write(w, r, err)
+//	        return
+//	    }
+//	    ...
+//	}
+func (v *Validator) DecodeJSON(r *http.Request, dst interface{}) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return errors.InvalidArgument(CodeInvalidBody, "request body is empty")
+	}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(dst); err != nil {
+		if stderrors.Is(err, io.EOF) {
+			return errors.InvalidArgument(CodeInvalidBody, "request body is empty")
+		}
+		return errors.InvalidArgument(CodeInvalidBody, "invalid request body").Wrap(err)
+	}
+	if decoder.More() {
+		return errors.InvalidArgument(CodeInvalidBody, "request body must contain a single JSON value")
+	}
+	return v.ValidateRequest(dst)
+}
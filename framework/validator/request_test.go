@@ -0,0 +1,71 @@
+package validator_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type createUserRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required,email"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode string
+	}{
+		{name: "valid", body: `{"name": "Alice", "email": "alice@example.com"}`},
+		{name: "empty body", body: "", expectedCode: validator.CodeInvalidBody},
+		{name: "malformed JSON", body: `{"name": `, expectedCode: validator.CodeInvalidBody},
+		{name: "wrong type", body: `{"name": 42}`, expectedCode: validator.CodeInvalidBody},
+		{name: "multiple values", body: `{"name": "Alice"} {}`, expectedCode: validator.CodeInvalidBody},
+		{name: "validation failed", body: `{"name": "Alice", "email": "alice"}`, expectedCode: validator.CodeValidationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+
+			var req createUserRequest
+			err := validator.Default().DecodeJSON(r, &req)
+			if tt.expectedCode == "" {
+				require.NoError(t, err)
+				assert.Equal(t, createUserRequest{Name: "Alice", Email: "alice@example.com"}, req)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.expectedCode, domain_error.CodeOf(err))
+			assert.Equal(t, domain_error.KindInvalidArgument, domain_error.KindOf(err))
+		})
+	}
+}
+
+func TestValidateRequest_Problem(t *testing.T) {
+	err := validator.Default().ValidateRequest(createUserRequest{Email: "alice"})
+	require.Error(t, err)
+
+	problems := domain_error.NewProblemWriter(domain_error.WithProductionMode(true))
+	rec := httptest.NewRecorder()
+	problems.Write(rec, httptest.NewRequest(http.MethodPost, "/users", nil), err)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, validator.CodeValidationFailed, body["code"])
+	assert.Equal(t, "validation failed", body["detail"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "name", "rule": "required", "message": "name is a required field"},
+		map[string]interface{}{"field": "email", "rule": "email", "message": "email must be a valid email address"},
+	}, body["violations"])
+
+	assert.NoError(t, validator.Default().ValidateRequest(createUserRequest{Name: "Alice", Email: "alice@example.com"}))
+}
@@ -1,10 +1,10 @@
 package validator
 
 import (
-	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
@@ -61,6 +61,32 @@ func NewValidator(opts ...ValidatorOption) (*Validator, error) {
 	}, nil
 }
 
+var (
+	defaultValidator *Validator
+	defaultOnce      sync.Once
+)
+
+// Default returns the Validator shared by the application, created on first use, which names the fields of the
+// validation errors after their `json` tags, see JSONTagNameFunc. Register custom validators on it with
+// RegisterCustomValidator.
+//
+// Example:
+//
+//	if err := validator.Default().ValidateStruct(req); err != nil {
+//	    // handle error
+//	}
+func Default() *Validator {
+	defaultOnce.Do(func() {
+		v, err := NewValidator(WithTagNameFunc(JSONTagNameFunc))
+		if err != nil {
+			// The default translations are static, so this cannot happen short of a bug.
+			panic(fmt.Sprintf("validator: cannot create the default validator: %v", err))
+		}
+		defaultValidator = v
+	})
+	return defaultValidator
+}
+
 // WithTagNameFunc registers a custom function to derive field names in validation errors.
 // For example, you can use this to specify that validation errors should display `json` tag names.
 func WithTagNameFunc(tagNameFunc func(fld reflect.StructField) string) ValidatorOption {
@@ -107,7 +133,7 @@ func WithCustomValidator(cv CustomValidator) ValidatorOption {
 }
 
 // ValidateStruct validates the provided struct using the validator instance.
-// It returns an error containing all validation errors with messages translated using the translator.
+// It returns a *ValidationError containing all validation errors with messages translated using the translator.
 //
 // Example:
 //
@@ -118,13 +144,51 @@ func WithCustomValidator(cv CustomValidator) ValidatorOption {
 func (v *Validator) ValidateStruct(s interface{}) error {
 	if err := v.validate.Struct(s); err != nil {
 		if ve, ok := err.(validator.ValidationErrors); ok {
-			errMsgs := make([]string, len(ve))
+			violations := make([]Violation, len(ve))
 			for i, fe := range ve {
-				errMsgs[i] = fe.Translate(v.translator)
+				violations[i] = Violation{
+					Field:   fieldPath(fe.Namespace()),
+					Rule:    fe.Tag(),
+					Param:   fe.Param(),
+					Message: fe.Translate(v.translator),
+				}
 			}
-			return errors.New(strings.Join(errMsgs, ", "))
+			return &ValidationError{Violations: violations}
 		}
 		return err
 	}
 	return nil
 }
+
+// RegisterCustomValidator registers a custom validator along with its translation, like WithCustomValidator, e.g.,
+// on the Default validator. It must be called before the validator is used, typically at init.
+//
+// Example:
+//
+//	func init() {
+//	    if err := validator.Default().RegisterCustomValidator(new(custom_validator.DateValidator)); err != nil {
+//	        panic(err)
+//	    }
+//	}
+func (v *Validator) RegisterCustomValidator(cv CustomValidator) error {
+	return WithCustomValidator(cv)(v.validate, v.translator)
+}
+
+// Engine returns the underlying validator/v10 instance. Together with ValidateStruct, it implements the
+// binding.StructValidator interface of Gin, so that a Validator can replace the validator of Gin.
+//
+// Example:
+//
+//	binding.Validator = validator.Default()
+func (v *Validator) Engine() interface{} {
+	return v.validate
+}
+
+// fieldPath returns the path of a field from the namespace of a field error, without the name of the validated struct,
+// e.g., "address.city" for "CreateUserRequest.address.city".
+func fieldPath(namespace string) string {
+	if _, path, found := strings.Cut(namespace, "."); found {
+		return path
+	}
+	return namespace
+}
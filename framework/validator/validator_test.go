@@ -1,12 +1,14 @@
 package validator_test
 
 import (
+	"errors"
 	"testing"
 
 	ut "github.com/go-playground/universal-translator"
 	validatorV10 "github.com/go-playground/validator/v10"
 	"github.com/kittipat1413/go-common/framework/validator"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type MockCustomValidator struct{}
@@ -199,3 +201,54 @@ func TestWithTagNameFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateStruct_Violations(t *testing.T) {
+	v, err := validator.NewValidator(validator.WithTagNameFunc(validator.JSONTagNameFunc))
+	require.NoError(t, err)
+
+	type Address struct {
+		City string `json:"city" validate:"required"`
+	}
+	type TestStruct struct {
+		Age     int     `json:"age" validate:"lte=130"`
+		Address Address `json:"address"`
+	}
+
+	err = v.ValidateStruct(TestStruct{Age: 150})
+	var validationErr *validator.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []validator.Violation{
+		{Field: "age", Rule: "lte", Param: "130", Message: "age must be 130 or less"},
+		{Field: "address.city", Rule: "required", Message: "city is a required field"},
+	}, validationErr.Violations)
+	assert.Equal(t, "age must be 130 or less, city is a required field", err.Error())
+
+	// Errors other than violations are returned as is.
+	err = v.ValidateStruct(42)
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &validationErr))
+}
+
+func TestDefault(t *testing.T) {
+	v := validator.Default()
+	require.NotNil(t, v)
+	assert.Same(t, v, validator.Default())
+
+	type TestStruct struct {
+		FullName string `json:"full_name" validate:"required"`
+	}
+	err := v.ValidateStruct(TestStruct{})
+	assert.EqualError(t, err, "full_name is a required field")
+	assert.IsType(t, &validatorV10.Validate{}, v.Engine())
+}
+
+func TestRegisterCustomValidator(t *testing.T) {
+	v, err := validator.NewValidator()
+	require.NoError(t, err)
+	require.NoError(t, v.RegisterCustomValidator(new(MockCustomValidatorWithTranslation)))
+
+	type TestStruct struct {
+		Field string `validate:"mock"`
+	}
+	assert.NoError(t, v.ValidateStruct(TestStruct{Field: "value"}))
+}
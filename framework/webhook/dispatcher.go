@@ -0,0 +1,364 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/kittipat1413/go-common/framework/crypto/hmacsign"
+	"github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/httpclient"
+	"github.com/kittipat1413/go-common/framework/jobqueue"
+	"github.com/kittipat1413/go-common/framework/retry"
+)
+
+const (
+	// DefaultTimeout is the time limit of the attempts, unless set with WithHTTPClient. It must be shorter than the
+	// visibility timeout of the Worker.
+	DefaultTimeout = 10 * time.Second
+	// DefaultMaxRetries is the number of retries of a failing delivery, unless set with WithMaxRetries. With
+	// DefaultBackoff, the last retry is about 2 hours after the first attempt.
+	DefaultMaxRetries = 8
+	// MaxResponseSize is the maximum size of the response bodies recorded in the attempts.
+	MaxResponseSize = 1024
+)
+
+// DefaultBackoff is the backoff of the retries of the Worker, unless set with jobqueue.WithBackoff: about 30s, 1m,
+// 2m, 4m, and so on.
+var DefaultBackoff = retry.Exponential(30*time.Second, 2, 0.2)
+
+// options holds configuration options for the Dispatcher.
+type options struct {
+	client     *http.Client // client sends the deliveries.
+	maxRetries int          // maxRetries is the number of retries of a failing delivery.
+}
+
+// Option specifies Dispatcher configuration options.
+type Option func(*options)
+
+// WithHTTPClient sets the client sending the deliveries, e.g., created with httpclient.New. It defaults to an
+// httpclient with DefaultTimeout, not following the redirects, which are failed attempts.
+func WithHTTPClient(client *http.Client) Option {
+	return func(opts *options) {
+		if client != nil {
+			opts.client = client
+		}
+	}
+}
+
+// WithMaxRetries sets the number of retries of a failing delivery, after which it fails. Zero does not retry the
+// deliveries. It defaults to DefaultMaxRetries.
+func WithMaxRetries(n int) Option {
+	return func(opts *options) {
+		if n >= 0 {
+			opts.maxRetries = n
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{maxRetries: DefaultMaxRetries}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.client == nil {
+		o.client = httpclient.New(httpclient.WithTimeout(DefaultTimeout))
+		o.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return o
+}
+
+/*
+Dispatcher delivers the events published to the endpoints subscribing to their type. Every delivery of an event to
+an endpoint is tracked in a Store, and queued in a jobqueue.Queue, from which the Worker posts the event as JSON,
+signed with the secret of the endpoint, see hmacsign.Signer:
+
+	POST <endpoint URL>
+	Content-Type: application/json
+	X-Webhook-ID: <event ID>
+	X-Webhook-Event: <event type>
+	X-Webhook-Delivery: <delivery ID>
+	X-Webhook-Attempt: <attempt number>
+	X-Signature: t=<unix seconds>,v1=<hex>
+
+	{"id": "<event ID>", "type": "order.created", "data": {...}, "created_at": "2024-05-01T10:00:00Z"}
+
+A 2xx response succeeds. A client error status other than 408 and 429 fails the delivery at once, and the other
+statuses and network errors are retried with an exponential backoff, until the retries are exhausted. The attempts,
+with their status and the beginning of their response, are recorded in the delivery. The failed deliveries, e.g.,
+after an outage of the endpoint, can be delivered again with Redeliver and RedeliverFailed.
+
+The events are delivered at least once, and possibly out of order: the receivers should ignore the event IDs
+already handled.
+
+Example usage:
+
+	queue := jobqueue.New(redisqueue.New(&goRedisClient{client: rdb}), "webhooks")
+	dispatcher := webhook.NewDispatcher(store, queue)
+	manager.Add("webhooks", dispatcher.Worker(jobqueue.WithConcurrency(20)), lifecycle.WithTimeout(time.Minute))
+
+	endpoint, err := dispatcher.RegisterEndpoint(ctx, webhook.Endpoint{
+		URL:    "https://partner.example.com/webhooks",
+		Secret: secret,
+		Events: []string{"order.created", "order.shipped"},
+	})
+	if err != nil {
+		// Handle error
+	}
+
+	deliveries, err := dispatcher.Publish(ctx, "order.created", order)
+	if err != nil {
+		// Handle error
+	}
+*/
+type Dispatcher struct {
+	store Store
+	queue *jobqueue.Queue
+	opts  *options
+}
+
+// NewDispatcher creates a Dispatcher tracking the deliveries in store, and queuing them in queue.
+func NewDispatcher(store Store, queue *jobqueue.Queue, opts ...Option) *Dispatcher {
+	return &Dispatcher{store: store, queue: queue, opts: newOptions(opts)}
+}
+
+// RegisterEndpoint validates and saves endpoint, generating its ID if empty, and returns it. Registering an endpoint
+// with the ID of another replaces it, e.g., to rotate its secret or to disable it. It returns ErrInvalidEndpoint if
+// its URL is not an absolute HTTP(S) URL, or if its secret is empty.
+func (d *Dispatcher) RegisterEndpoint(ctx context.Context, endpoint Endpoint) (Endpoint, error) {
+	u, err := url.Parse(endpoint.URL)
+	switch {
+	case err != nil:
+		return Endpoint{}, ErrInvalidEndpoint.Wrap(err)
+	case (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+		return Endpoint{}, ErrInvalidEndpoint.Wrap(fmt.Errorf("the URL %q is not an absolute HTTP(S) URL", endpoint.URL))
+	case len(endpoint.Secret) == 0:
+		return Endpoint{}, ErrInvalidEndpoint.Wrap(stderrors.New("the secret is empty"))
+	}
+
+	if endpoint.ID == "" {
+		endpoint.ID = xid.New().String()
+	} else if existing, err := d.store.GetEndpoint(ctx, endpoint.ID); err == nil {
+		endpoint.CreatedAt = existing.CreatedAt
+	} else if !stderrors.Is(err, ErrEndpointNotFound) {
+		return Endpoint{}, err
+	}
+	if endpoint.CreatedAt.IsZero() {
+		endpoint.CreatedAt = time.Now()
+	}
+	if err := d.store.SaveEndpoint(ctx, endpoint); err != nil {
+		return Endpoint{}, err
+	}
+	return endpoint, nil
+}
+
+// RemoveEndpoint deletes the endpoint with id, or returns ErrEndpointNotFound. Its pending deliveries fail.
+func (d *Dispatcher) RemoveEndpoint(ctx context.Context, id string) error {
+	return d.store.DeleteEndpoint(ctx, id)
+}
+
+// Endpoints returns the registered endpoints, sorted by ID.
+func (d *Dispatcher) Endpoints(ctx context.Context) ([]Endpoint, error) {
+	return d.store.ListEndpoints(ctx)
+}
+
+/*
+Publish publishes an event of eventType with data marshaled as JSON, and queues its deliveries to the enabled
+endpoints subscribing to eventType. It returns the deliveries queued, and the errors of the ones which could not
+be queued, which are failed so that they can be delivered again with RedeliverFailed.
+*/
+func (d *Dispatcher) Publish(ctx context.Context, eventType string, data any) ([]Delivery, error) {
+	if eventType == "" {
+		return nil, ErrInvalidEventType
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: failed to marshal the event data: %w", err)
+	}
+	endpoints, err := d.store.ListEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	event := Event{ID: xid.New().String(), Type: eventType, Data: payload, CreatedAt: now}
+	var deliveries []Delivery
+	var errs []error
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribes(eventType) {
+			continue
+		}
+		delivery := Delivery{
+			ID:         xid.New().String(),
+			EndpointID: endpoint.ID,
+			Event:      event,
+			Status:     StatusPending,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if err := d.enqueue(ctx, &delivery); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: failed to queue the delivery to the endpoint %q: %w", endpoint.ID, err))
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, stderrors.Join(errs...)
+}
+
+// enqueue saves delivery as pending, and queues it. If it cannot be queued, it is saved as failed.
+func (d *Dispatcher) enqueue(ctx context.Context, delivery *Delivery) error {
+	delivery.Status = StatusPending
+	if err := d.store.SaveDelivery(ctx, *delivery); err != nil {
+		return err
+	}
+	_, err := d.queue.Enqueue(ctx, []byte(delivery.ID), jobqueue.WithMaxRetries(d.opts.maxRetries))
+	if err != nil {
+		delivery.Status = StatusFailed
+		delivery.UpdatedAt = time.Now()
+		return stderrors.Join(err, d.store.SaveDelivery(ctx, *delivery))
+	}
+	return nil
+}
+
+// Delivery returns the delivery with id, with its attempts, or ErrDeliveryNotFound.
+func (d *Dispatcher) Delivery(ctx context.Context, id string) (Delivery, error) {
+	return d.store.GetDelivery(ctx, id)
+}
+
+// Deliveries returns the deliveries selected by filter, the most recently created first.
+func (d *Dispatcher) Deliveries(ctx context.Context, filter DeliveryFilter) ([]Delivery, error) {
+	return d.store.ListDeliveries(ctx, filter)
+}
+
+// Redeliver queues the delivery with id again, e.g., once the endpoint is fixed, and returns it. Its attempts are
+// kept, and numbered on. It returns ErrDeliveryNotFound, or ErrDeliveryPending if the delivery is still pending.
+func (d *Dispatcher) Redeliver(ctx context.Context, id string) (Delivery, error) {
+	delivery, err := d.store.GetDelivery(ctx, id)
+	if err != nil {
+		return Delivery{}, err
+	}
+	if delivery.Status == StatusPending {
+		return Delivery{}, ErrDeliveryPending
+	}
+	delivery.UpdatedAt = time.Now()
+	if err := d.enqueue(ctx, &delivery); err != nil {
+		return Delivery{}, err
+	}
+	return delivery, nil
+}
+
+// RedeliverFailed queues again the failed deliveries selected by filter, e.g., the ones of an endpoint after its
+// outage, whatever the Status of filter. It returns the number of deliveries queued.
+func (d *Dispatcher) RedeliverFailed(ctx context.Context, filter DeliveryFilter) (int, error) {
+	filter.Status = StatusFailed
+	deliveries, err := d.store.ListDeliveries(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	for i := range deliveries {
+		deliveries[i].UpdatedAt = time.Now()
+		if err := d.enqueue(ctx, &deliveries[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// Worker creates the jobqueue.Worker delivering the events queued, with DefaultBackoff unless set with
+// jobqueue.WithBackoff. Its visibility timeout must exceed the timeout of the HTTP client.
+func (d *Dispatcher) Worker(opts ...jobqueue.Option) *jobqueue.Worker {
+	return jobqueue.NewWorker(d.queue, d.Deliver, append([]jobqueue.Option{jobqueue.WithBackoff(DefaultBackoff)}, opts...)...)
+}
+
+/*
+Deliver is the jobqueue.Handler of the Worker: it attempts the delivery of job, and records the attempt. It returns
+ErrDeliveryFailed for the attempts to retry, and a permanent error, see errors.IsPermanent, for the ones failing
+the delivery at once, e.g., ErrDeliveryRejected. The deliveries no longer pending, e.g., settled by a previous run
+of the job, are ignored.
+*/
+func (d *Dispatcher) Deliver(ctx context.Context, job *jobqueue.Job) error {
+	delivery, err := d.store.GetDelivery(ctx, string(job.Payload))
+	if err != nil {
+		return err
+	}
+	if delivery.Status != StatusPending {
+		return nil
+	}
+
+	attempt := Attempt{Number: len(delivery.Attempts) + 1, StartedAt: time.Now()}
+	endpoint, err := d.store.GetEndpoint(ctx, delivery.EndpointID)
+	if err == nil && endpoint.Disabled {
+		err = ErrEndpointDisabled
+	}
+	if err == nil {
+		err = d.send(ctx, &endpoint, &delivery, &attempt)
+	}
+	attempt.Duration = time.Since(attempt.StartedAt)
+
+	status := StatusPending
+	switch {
+	case err == nil:
+		status = StatusSucceeded
+	case ctx.Err() == nil && errors.IsPermanent(err), job.Attempt > job.MaxRetries:
+		status = StatusFailed
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	if recordErr := d.store.RecordAttempt(ctx, delivery.ID, attempt, status); recordErr != nil {
+		return stderrors.Join(err, recordErr)
+	}
+	return err
+}
+
+// send posts the event of delivery to endpoint, recording the response in attempt.
+func (d *Dispatcher) send(ctx context.Context, endpoint *Endpoint, delivery *Delivery, attempt *Attempt) error {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return errors.MarkPermanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.MarkPermanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, delivery.Event.ID)
+	req.Header.Set(HeaderEventType, delivery.Event.Type)
+	req.Header.Set(HeaderDeliveryID, delivery.ID)
+	req.Header.Set(HeaderAttempt, strconv.Itoa(attempt.Number))
+	if err := hmacsign.NewSigner(endpoint.Secret).Sign(req); err != nil {
+		return errors.MarkPermanent(err)
+	}
+
+	resp, err := d.opts.client.Do(req)
+	if err != nil {
+		// The timeouts of the client are context errors, which would be permanent.
+		return errors.MarkRetryable(ErrDeliveryFailed.Wrap(err))
+	}
+	defer resp.Body.Close()
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSize))
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	attempt.StatusCode = resp.StatusCode
+	attempt.Response = string(response)
+
+	status := resp.StatusCode
+	switch {
+	case status >= 200 && status < 300:
+		return nil
+	case status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests:
+		return ErrDeliveryRejected.Wrap(fmt.Errorf("the endpoint responded %s", resp.Status))
+	default:
+		return ErrDeliveryFailed.Wrap(fmt.Errorf("the endpoint responded %s", resp.Status))
+	}
+}
@@ -0,0 +1,370 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/kittipat1413/go-common/framework/crypto/hmacsign"
+	domain_error "github.com/kittipat1413/go-common/framework/errors"
+	"github.com/kittipat1413/go-common/framework/jobqueue"
+	"github.com/kittipat1413/go-common/framework/retry"
+	"github.com/kittipat1413/go-common/framework/webhook"
+)
+
+// memoryBackend is a jobqueue.Backend in memory, without visibility timeouts.
+type memoryBackend struct {
+	mu      sync.Mutex
+	jobs    map[string]*jobqueue.Job
+	readyAt map[string]time.Time
+	dead    int
+	err     error
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{jobs: make(map[string]*jobqueue.Job), readyAt: make(map[string]time.Time)}
+}
+
+func (b *memoryBackend) Enqueue(ctx context.Context, job *jobqueue.Job, delay time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	job.EnqueuedAt = time.Now()
+	stored := *job
+	b.jobs[job.ID] = &stored
+	b.readyAt[job.ID] = job.EnqueuedAt.Add(delay)
+	return nil
+}
+
+func (b *memoryBackend) Dequeue(ctx context.Context, queue string, visibility time.Duration) (*jobqueue.Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	for id, job := range b.jobs {
+		if job.Queue != queue || b.readyAt[id].After(now) {
+			continue
+		}
+		b.readyAt[id] = now.Add(visibility)
+		job.Attempt++
+		job.Receipt = strconv.Itoa(job.Attempt)
+		delivered := *job
+		return &delivered, nil
+	}
+	return nil, nil
+}
+
+func (b *memoryBackend) Ack(ctx context.Context, job *jobqueue.Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.jobs, job.ID)
+	return nil
+}
+
+func (b *memoryBackend) Retry(ctx context.Context, job *jobqueue.Job, delay time.Duration, cause error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.readyAt[job.ID] = time.Now().Add(delay)
+	return nil
+}
+
+func (b *memoryBackend) DeadLetter(ctx context.Context, job *jobqueue.Job, cause error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.jobs, job.ID)
+	b.dead++
+	return nil
+}
+
+// pending returns the number of jobs not settled for good.
+func (b *memoryBackend) pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.jobs)
+}
+
+// deliverNext runs the next job of queue with the dispatcher, and settles it like a worker would.
+func deliverNext(t *testing.T, backend *memoryBackend, dispatcher *webhook.Dispatcher) error {
+	t.Helper()
+	job, err := backend.Dequeue(context.Background(), "webhooks", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, job, "a job is queued")
+	err = dispatcher.Deliver(context.Background(), job)
+	switch {
+	case err == nil:
+		require.NoError(t, backend.Ack(context.Background(), job))
+	case domain_error.IsPermanent(err), job.Attempt > job.MaxRetries:
+		require.NoError(t, backend.DeadLetter(context.Background(), job, err))
+	default:
+		require.NoError(t, backend.Retry(context.Background(), job, 0, err))
+	}
+	return err
+}
+
+func TestDispatcher_RegisterEndpoint(t *testing.T) {
+	ctx := context.Background()
+	dispatcher := webhook.NewDispatcher(webhook.NewMemoryStore(), jobqueue.New(newMemoryBackend(), "webhooks"))
+
+	endpoint, err := dispatcher.RegisterEndpoint(ctx, webhook.Endpoint{URL: "https://example.com/hooks", Secret: []byte("secret")})
+	require.NoError(t, err)
+	assert.NotEmpty(t, endpoint.ID)
+	assert.False(t, endpoint.CreatedAt.IsZero())
+
+	endpoint.Disabled = true
+	updated, err := dispatcher.RegisterEndpoint(ctx, endpoint)
+	require.NoError(t, err)
+	assert.Equal(t, endpoint.ID, updated.ID)
+	assert.True(t, updated.CreatedAt.Equal(endpoint.CreatedAt), "the creation time is kept")
+	endpoints, err := dispatcher.Endpoints(ctx)
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	assert.True(t, endpoints[0].Disabled)
+
+	for _, invalid := range []webhook.Endpoint{
+		{URL: "/hooks", Secret: []byte("secret")},
+		{URL: "ftp://example.com/hooks", Secret: []byte("secret")},
+		{URL: "https://example.com/hooks"},
+	} {
+		_, err := dispatcher.RegisterEndpoint(ctx, invalid)
+		assert.ErrorIs(t, err, webhook.ErrInvalidEndpoint, invalid.URL)
+	}
+
+	require.NoError(t, dispatcher.RemoveEndpoint(ctx, endpoint.ID))
+	assert.ErrorIs(t, dispatcher.RemoveEndpoint(ctx, endpoint.ID), webhook.ErrEndpointNotFound)
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("secret")
+	verifier := hmacsign.NewVerifier(hmacsign.SchemeRequest, secret)
+	var received []*http.Request
+	var bodies [][]byte
+	var statuses = []int{http.StatusServiceUnavailable, http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := verifier.Verify(r)
+		if !assert.NoError(t, err, "the delivery is signed") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received = append(received, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(statuses[0])
+		_, _ = io.WriteString(w, http.StatusText(statuses[0]))
+		statuses = statuses[1:]
+	}))
+	defer server.Close()
+
+	backend := newMemoryBackend()
+	dispatcher := webhook.NewDispatcher(webhook.NewMemoryStore(), jobqueue.New(backend, "webhooks"))
+	subscribed, err := dispatcher.RegisterEndpoint(ctx, webhook.Endpoint{URL: server.URL + "/hooks", Secret: secret, Events: []string{"order.created"}})
+	require.NoError(t, err)
+	_, err = dispatcher.RegisterEndpoint(ctx, webhook.Endpoint{URL: server.URL, Secret: secret, Events: []string{"order.shipped"}})
+	require.NoError(t, err)
+	_, err = dispatcher.RegisterEndpoint(ctx, webhook.Endpoint{URL: server.URL, Secret: secret, Disabled: true})
+	require.NoError(t, err)
+
+	deliveries, err := dispatcher.Publish(ctx, "order.created", map[string]string{"order_id": "42"})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1, "only the enabled endpoints subscribing to the type")
+	delivery := deliveries[0]
+	assert.Equal(t, subscribed.ID, delivery.EndpointID)
+	assert.Equal(t, webhook.StatusPending, delivery.Status)
+	assert.Equal(t, 1, backend.pending())
+
+	err = deliverNext(t, backend, dispatcher)
+	assert.ErrorIs(t, err, webhook.ErrDeliveryFailed, "a 503 is retried")
+	assert.False(t, domain_error.IsPermanent(err))
+	require.NoError(t, deliverNext(t, backend, dispatcher))
+	assert.Zero(t, backend.pending())
+
+	require.Len(t, received, 2)
+	assert.Equal(t, "/hooks", received[0].URL.Path)
+	assert.Equal(t, "application/json", received[0].Header.Get("Content-Type"))
+	assert.Equal(t, delivery.Event.ID, received[0].Header.Get(webhook.HeaderEventID))
+	assert.Equal(t, "order.created", received[0].Header.Get(webhook.HeaderEventType))
+	assert.Equal(t, delivery.ID, received[0].Header.Get(webhook.HeaderDeliveryID))
+	assert.Equal(t, "1", received[0].Header.Get(webhook.HeaderAttempt))
+	assert.Equal(t, "2", received[1].Header.Get(webhook.HeaderAttempt))
+	var event webhook.Event
+	require.NoError(t, json.Unmarshal(bodies[1], &event))
+	assert.Equal(t, delivery.Event.ID, event.ID)
+	assert.Equal(t, "order.created", event.Type)
+	assert.JSONEq(t, `{"order_id": "42"}`, string(event.Data))
+
+	tracked, err := dispatcher.Delivery(ctx, delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, webhook.StatusSucceeded, tracked.Status)
+	require.Len(t, tracked.Attempts, 2)
+	assert.Equal(t, 1, tracked.Attempts[0].Number)
+	assert.Equal(t, http.StatusServiceUnavailable, tracked.Attempts[0].StatusCode)
+	assert.Equal(t, "Service Unavailable", tracked.Attempts[0].Response)
+	assert.NotEmpty(t, tracked.Attempts[0].Error)
+	assert.Equal(t, http.StatusOK, tracked.Attempts[1].StatusCode)
+	assert.Empty(t, tracked.Attempts[1].Error)
+}
+
+func TestDispatcher_Deliver_Failures(t *testing.T) {
+	ctx := context.Background()
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	backend := newMemoryBackend()
+	dispatcher := webhook.NewDispatcher(webhook.NewMemoryStore(), jobqueue.New(backend, "webhooks"), webhook.WithMaxRetries(1))
+	endpoint, err := dispatcher.RegisterEndpoint(ctx, webhook.Endpoint{URL: server.URL, Secret: []byte("secret")})
+	require.NoError(t, err)
+
+	t.Run("rejected", func(t *testing.T) {
+		deliveries, err := dispatcher.Publish(ctx, "order.created", nil)
+		require.NoError(t, err)
+		err = deliverNext(t, backend, dispatcher)
+		assert.ErrorIs(t, err, webhook.ErrDeliveryRejected)
+		assert.True(t, domain_error.IsPermanent(err), "a 400 is not retried")
+		delivery, err := dispatcher.Delivery(ctx, deliveries[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, webhook.StatusFailed, delivery.Status)
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		status = http.StatusTooManyRequests
+		deliveries, err := dispatcher.Publish(ctx, "order.created", nil)
+		require.NoError(t, err)
+		assert.ErrorIs(t, deliverNext(t, backend, dispatcher), webhook.ErrDeliveryFailed)
+		delivery, _ := dispatcher.Delivery(ctx, deliveries[0].ID)
+		assert.Equal(t, webhook.StatusPending, delivery.Status)
+		assert.ErrorIs(t, deliverNext(t, backend, dispatcher), webhook.ErrDeliveryFailed)
+		delivery, _ = dispatcher.Delivery(ctx, deliveries[0].ID)
+		assert.Equal(t, webhook.StatusFailed, delivery.Status)
+		assert.Len(t, delivery.Attempts, 2)
+		assert.Zero(t, backend.pending())
+	})
+
+	t.Run("endpoint disabled", func(t *testing.T) {
+		deliveries, err := dispatcher.Publish(ctx, "order.created", nil)
+		require.NoError(t, err)
+		endpoint.Disabled = true
+		_, err = dispatcher.RegisterEndpoint(ctx, endpoint)
+		require.NoError(t, err)
+		assert.ErrorIs(t, deliverNext(t, backend, dispatcher), webhook.ErrEndpointDisabled)
+		delivery, _ := dispatcher.Delivery(ctx, deliveries[0].ID)
+		assert.Equal(t, webhook.StatusFailed, delivery.Status)
+	})
+}
+
+func TestDispatcher_Redeliver(t *testing.T) {
+	ctx := context.Background()
+	var available atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available.Load() {
+			w.WriteHeader(http.StatusGone)
+		}
+	}))
+	defer server.Close()
+
+	backend := newMemoryBackend()
+	dispatcher := webhook.NewDispatcher(webhook.NewMemoryStore(), jobqueue.New(backend, "webhooks"))
+	endpoint, err := dispatcher.RegisterEndpoint(ctx, webhook.Endpoint{URL: server.URL, Secret: []byte("secret")})
+	require.NoError(t, err)
+
+	first, err := dispatcher.Publish(ctx, "order.created", nil)
+	require.NoError(t, err)
+	_, err = dispatcher.Redeliver(ctx, first[0].ID)
+	assert.ErrorIs(t, err, webhook.ErrDeliveryPending)
+	assert.Error(t, deliverNext(t, backend, dispatcher))
+	second, err := dispatcher.Publish(ctx, "order.shipped", nil)
+	require.NoError(t, err)
+	assert.Error(t, deliverNext(t, backend, dispatcher))
+
+	failed, err := dispatcher.Deliveries(ctx, webhook.DeliveryFilter{EndpointID: endpoint.ID, Status: webhook.StatusFailed})
+	require.NoError(t, err)
+	require.Len(t, failed, 2)
+	assert.Equal(t, second[0].ID, failed[0].ID, "the most recent first")
+
+	available.Store(true)
+	redelivered, err := dispatcher.Redeliver(ctx, first[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, webhook.StatusPending, redelivered.Status)
+	require.NoError(t, deliverNext(t, backend, dispatcher))
+	delivery, _ := dispatcher.Delivery(ctx, first[0].ID)
+	assert.Equal(t, webhook.StatusSucceeded, delivery.Status)
+	require.Len(t, delivery.Attempts, 2)
+	assert.Equal(t, 2, delivery.Attempts[1].Number, "the attempts are numbered on")
+
+	n, err := dispatcher.RedeliverFailed(ctx, webhook.DeliveryFilter{EndpointID: endpoint.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.NoError(t, deliverNext(t, backend, dispatcher))
+	delivery, _ = dispatcher.Delivery(ctx, second[0].ID)
+	assert.Equal(t, webhook.StatusSucceeded, delivery.Status)
+
+	_, err = dispatcher.Redeliver(ctx, "unknown")
+	assert.ErrorIs(t, err, webhook.ErrDeliveryNotFound)
+}
+
+func TestDispatcher_Publish_QueueFailure(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryBackend()
+	backend.err = errors.New("queue unavailable")
+	dispatcher := webhook.NewDispatcher(webhook.NewMemoryStore(), jobqueue.New(backend, "webhooks"))
+	_, err := dispatcher.RegisterEndpoint(ctx, webhook.Endpoint{URL: "https://example.com/hooks", Secret: []byte("secret")})
+	require.NoError(t, err)
+
+	deliveries, err := dispatcher.Publish(ctx, "order.created", nil)
+	assert.ErrorContains(t, err, "queue unavailable")
+	assert.Empty(t, deliveries)
+	failed, err := dispatcher.Deliveries(ctx, webhook.DeliveryFilter{Status: webhook.StatusFailed})
+	require.NoError(t, err)
+	assert.Len(t, failed, 1, "the delivery not queued can be delivered again")
+
+	_, err = dispatcher.Publish(ctx, "", nil)
+	assert.ErrorIs(t, err, webhook.ErrInvalidEventType)
+}
+
+func TestDispatcher_Worker(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	ctx := context.Background()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	backend := newMemoryBackend()
+	dispatcher := webhook.NewDispatcher(webhook.NewMemoryStore(), jobqueue.New(backend, "webhooks"), webhook.WithHTTPClient(server.Client()))
+	_, err := dispatcher.RegisterEndpoint(ctx, webhook.Endpoint{URL: server.URL, Secret: []byte("secret")})
+	require.NoError(t, err)
+	deliveries, err := dispatcher.Publish(ctx, "order.created", nil)
+	require.NoError(t, err)
+
+	worker := dispatcher.Worker(jobqueue.WithPollInterval(time.Millisecond), jobqueue.WithBackoff(retry.Constant(0)))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = worker.Run(ctx)
+	}()
+	assert.Eventually(t, func() bool {
+		delivery, err := dispatcher.Delivery(ctx, deliveries[0].ID)
+		return err == nil && delivery.Status == webhook.StatusSucceeded
+	}, time.Second, time.Millisecond)
+	require.NoError(t, worker.Shutdown(ctx))
+	<-done
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+MemoryStore is a Store keeping the endpoints and the deliveries in memory. It is meant for tests, and for the
+services running a single instance which can lose their deliveries on restart. The endpoints and the deliveries
+are copied in and out, so that they are not shared with the callers.
+
+Example usage:
+
+	dispatcher := webhook.NewDispatcher(webhook.NewMemoryStore(), queue)
+*/
+type MemoryStore struct {
+	mutex      sync.RWMutex
+	endpoints  map[string]Endpoint
+	deliveries map[string]Delivery
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		endpoints:  make(map[string]Endpoint),
+		deliveries: make(map[string]Delivery),
+	}
+}
+
+// SaveEndpoint implements the Store interface.
+func (s *MemoryStore) SaveEndpoint(ctx context.Context, endpoint Endpoint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.endpoints[endpoint.ID] = cloneEndpoint(endpoint)
+	return nil
+}
+
+// GetEndpoint implements the Store interface.
+func (s *MemoryStore) GetEndpoint(ctx context.Context, id string) (Endpoint, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	endpoint, found := s.endpoints[id]
+	if !found {
+		return Endpoint{}, ErrEndpointNotFound
+	}
+	return cloneEndpoint(endpoint), nil
+}
+
+// DeleteEndpoint implements the Store interface.
+func (s *MemoryStore) DeleteEndpoint(ctx context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, found := s.endpoints[id]; !found {
+		return ErrEndpointNotFound
+	}
+	delete(s.endpoints, id)
+	return nil
+}
+
+// ListEndpoints implements the Store interface.
+func (s *MemoryStore) ListEndpoints(ctx context.Context) ([]Endpoint, error) {
+	s.mutex.RLock()
+	endpoints := make([]Endpoint, 0, len(s.endpoints))
+	for _, endpoint := range s.endpoints {
+		endpoints = append(endpoints, cloneEndpoint(endpoint))
+	}
+	s.mutex.RUnlock()
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].ID < endpoints[j].ID })
+	return endpoints, nil
+}
+
+// SaveDelivery implements the Store interface.
+func (s *MemoryStore) SaveDelivery(ctx context.Context, delivery Delivery) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.deliveries[delivery.ID] = cloneDelivery(delivery)
+	return nil
+}
+
+// GetDelivery implements the Store interface.
+func (s *MemoryStore) GetDelivery(ctx context.Context, id string) (Delivery, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	delivery, found := s.deliveries[id]
+	if !found {
+		return Delivery{}, ErrDeliveryNotFound
+	}
+	return cloneDelivery(delivery), nil
+}
+
+// ListDeliveries implements the Store interface.
+func (s *MemoryStore) ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]Delivery, error) {
+	s.mutex.RLock()
+	var deliveries []Delivery
+	for _, delivery := range s.deliveries {
+		if filter.Matches(&delivery) {
+			deliveries = append(deliveries, cloneDelivery(delivery))
+		}
+	}
+	s.mutex.RUnlock()
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID > deliveries[j].ID
+	})
+	if filter.Limit > 0 && len(deliveries) > filter.Limit {
+		deliveries = deliveries[:filter.Limit]
+	}
+	return deliveries, nil
+}
+
+// RecordAttempt implements the Store interface.
+func (s *MemoryStore) RecordAttempt(ctx context.Context, id string, attempt Attempt, status DeliveryStatus) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delivery, found := s.deliveries[id]
+	if !found {
+		return ErrDeliveryNotFound
+	}
+	delivery.Attempts = append(slices.Clip(delivery.Attempts), attempt)
+	delivery.Status = status
+	delivery.UpdatedAt = time.Now()
+	s.deliveries[id] = delivery
+	return nil
+}
+
+func cloneEndpoint(endpoint Endpoint) Endpoint {
+	endpoint.Secret = slices.Clone(endpoint.Secret)
+	endpoint.Events = slices.Clone(endpoint.Events)
+	return endpoint
+}
+
+func cloneDelivery(delivery Delivery) Delivery {
+	delivery.Event.Data = slices.Clone(delivery.Event.Data)
+	delivery.Attempts = slices.Clone(delivery.Attempts)
+	return delivery
+}
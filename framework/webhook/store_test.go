@@ -0,0 +1,86 @@
+package webhook_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/webhook"
+)
+
+func TestMemoryStore_Endpoints(t *testing.T) {
+	ctx := context.Background()
+	store := webhook.NewMemoryStore()
+
+	endpoint := webhook.Endpoint{ID: "b", URL: "https://example.com", Secret: []byte("secret"), Events: []string{"order.created"}}
+	require.NoError(t, store.SaveEndpoint(ctx, endpoint))
+	require.NoError(t, store.SaveEndpoint(ctx, webhook.Endpoint{ID: "a"}))
+	endpoint.Secret[0] = 'S'
+	endpoint.Events[0] = "order.shipped"
+
+	stored, err := store.GetEndpoint(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), stored.Secret, "the endpoints are copied")
+	assert.Equal(t, []string{"order.created"}, stored.Events)
+	assert.True(t, stored.Subscribes("order.created"))
+	assert.False(t, stored.Subscribes("order.shipped"))
+
+	endpoints, err := store.ListEndpoints(ctx)
+	require.NoError(t, err)
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, "a", endpoints[0].ID)
+
+	require.NoError(t, store.DeleteEndpoint(ctx, "b"))
+	_, err = store.GetEndpoint(ctx, "b")
+	assert.ErrorIs(t, err, webhook.ErrEndpointNotFound)
+	assert.ErrorIs(t, store.DeleteEndpoint(ctx, "b"), webhook.ErrEndpointNotFound)
+}
+
+func TestMemoryStore_Deliveries(t *testing.T) {
+	ctx := context.Background()
+	store := webhook.NewMemoryStore()
+	now := time.Now()
+	for i, id := range []string{"d1", "d2", "d3"} {
+		require.NoError(t, store.SaveDelivery(ctx, webhook.Delivery{
+			ID:         id,
+			EndpointID: "e1",
+			Event:      webhook.Event{ID: "ev" + id},
+			Status:     webhook.StatusPending,
+			CreatedAt:  now.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	require.NoError(t, store.RecordAttempt(ctx, "d2", webhook.Attempt{Number: 1, StatusCode: 200}, webhook.StatusSucceeded))
+	assert.ErrorIs(t, store.RecordAttempt(ctx, "unknown", webhook.Attempt{}, webhook.StatusFailed), webhook.ErrDeliveryNotFound)
+	delivery, err := store.GetDelivery(ctx, "d2")
+	require.NoError(t, err)
+	assert.Equal(t, webhook.StatusSucceeded, delivery.Status)
+	assert.Equal(t, []webhook.Attempt{{Number: 1, StatusCode: 200}}, delivery.Attempts)
+	assert.False(t, delivery.UpdatedAt.IsZero())
+
+	delivery.Attempts[0].StatusCode = 500
+	stored, _ := store.GetDelivery(ctx, "d2")
+	assert.Equal(t, 200, stored.Attempts[0].StatusCode, "the deliveries are copied")
+
+	deliveries, err := store.ListDeliveries(ctx, webhook.DeliveryFilter{EndpointID: "e1", Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, "d3", deliveries[0].ID, "the most recent first")
+	assert.Equal(t, "d2", deliveries[1].ID)
+
+	deliveries, err = store.ListDeliveries(ctx, webhook.DeliveryFilter{Status: webhook.StatusPending})
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+	deliveries, err = store.ListDeliveries(ctx, webhook.DeliveryFilter{EventID: "evd1"})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	deliveries, err = store.ListDeliveries(ctx, webhook.DeliveryFilter{EndpointID: "e2"})
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	_, err = store.GetDelivery(ctx, "unknown")
+	assert.ErrorIs(t, err, webhook.ErrDeliveryNotFound)
+}
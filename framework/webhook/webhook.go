@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/errors"
+)
+
+// Headers of the deliveries, besides the hmacsign.SignatureHeader carrying their signature.
+const (
+	// HeaderEventID carries the ID of the event, identical for all the attempts and redeliveries of the event, so
+	// that the receivers can ignore the events already handled.
+	HeaderEventID = "X-Webhook-ID"
+	// HeaderEventType carries the type of the event, e.g., "order.created".
+	HeaderEventType = "X-Webhook-Event"
+	// HeaderDeliveryID carries the ID of the delivery of the event to the endpoint.
+	HeaderDeliveryID = "X-Webhook-Delivery"
+	// HeaderAttempt carries the number of the attempt of the delivery, starting at 1.
+	HeaderAttempt = "X-Webhook-Attempt"
+)
+
+// Codes of the errors returned by the Dispatcher and the Stores.
+const (
+	CodeInvalidEndpoint  = "webhook_invalid_endpoint"
+	CodeEndpointNotFound = "webhook_endpoint_not_found"
+	CodeDeliveryNotFound = "webhook_delivery_not_found"
+	CodeDeliveryPending  = "webhook_delivery_pending"
+	CodeDeliveryRejected = "webhook_delivery_rejected"
+	CodeDeliveryFailed   = "webhook_delivery_failed"
+	CodeEndpointDisabled = "webhook_endpoint_disabled"
+	CodeInvalidEventType = "webhook_invalid_event_type"
+)
+
+var (
+	// ErrInvalidEndpoint is returned for the endpoints without an absolute HTTP(S) URL or a secret.
+	ErrInvalidEndpoint = errors.InvalidArgument(CodeInvalidEndpoint, "invalid webhook endpoint")
+	// ErrInvalidEventType is returned for the events published without a type.
+	ErrInvalidEventType = errors.InvalidArgument(CodeInvalidEventType, "invalid webhook event type")
+	// ErrEndpointNotFound is returned by the Stores for the endpoints not registered.
+	ErrEndpointNotFound = errors.NotFound(CodeEndpointNotFound, "webhook endpoint not found")
+	// ErrDeliveryNotFound is returned by the Stores for the deliveries not found.
+	ErrDeliveryNotFound = errors.NotFound(CodeDeliveryNotFound, "webhook delivery not found")
+	// ErrDeliveryPending is returned by Redeliver for the deliveries still pending, which are queued already.
+	ErrDeliveryPending = errors.NewCodedError(errors.KindConflict, CodeDeliveryPending, "webhook delivery is pending")
+	// ErrDeliveryRejected is recorded for the attempts whose response has a client error status, other than 408 and
+	// 429, which are not retried.
+	ErrDeliveryRejected = errors.InvalidArgument(CodeDeliveryRejected, "webhook delivery rejected by the endpoint")
+	// ErrDeliveryFailed is recorded for the attempts failing with a network error or another non-2xx status, which
+	// are retried.
+	ErrDeliveryFailed = errors.Unavailable(CodeDeliveryFailed, "webhook delivery failed")
+	// ErrEndpointDisabled is recorded for the deliveries to a disabled endpoint, which are not retried.
+	ErrEndpointDisabled = errors.NewCodedError(errors.KindConflict, CodeEndpointDisabled, "webhook endpoint is disabled")
+)
+
+// Endpoint is a URL receiving the events of some types, signed with its secret.
+type Endpoint struct {
+	ID        string    // ID is unique among the endpoints, generated by RegisterEndpoint if empty.
+	URL       string    // URL is the absolute HTTP(S) URL the events are posted to.
+	Secret    []byte    // Secret signs the deliveries, see hmacsign.NewVerifier with hmacsign.SchemeRequest.
+	Events    []string  // Events are the types of the events delivered to the endpoint, or all of them if empty.
+	Disabled  bool      // Disabled endpoints receive no events, and their pending deliveries fail.
+	CreatedAt time.Time // CreatedAt is the time the endpoint was first registered.
+}
+
+// Subscribes reports whether the events of eventType are delivered to the endpoint.
+func (e *Endpoint) Subscribes(eventType string) bool {
+	return !e.Disabled && (len(e.Events) == 0 || slices.Contains(e.Events, eventType))
+}
+
+// Event is an event published to the endpoints subscribing to its type.
+type Event struct {
+	ID        string          `json:"id"`         // ID is unique among the events.
+	Type      string          `json:"type"`       // Type is the type of the event, e.g., "order.created".
+	Data      json.RawMessage `json:"data"`       // Data is the JSON payload of the event.
+	CreatedAt time.Time       `json:"created_at"` // CreatedAt is the time the event was published.
+}
+
+// DeliveryStatus is the status of a Delivery.
+type DeliveryStatus string
+
+const (
+	// StatusPending is the status of the deliveries queued or being retried.
+	StatusPending DeliveryStatus = "pending"
+	// StatusSucceeded is the status of the deliveries answered with a 2xx status.
+	StatusSucceeded DeliveryStatus = "succeeded"
+	// StatusFailed is the status of the deliveries whose retries are exhausted, or whose error is permanent. They
+	// may be delivered again with Redeliver.
+	StatusFailed DeliveryStatus = "failed"
+)
+
+// Attempt is an attempt of a Delivery.
+type Attempt struct {
+	Number     int           // Number is the number of the attempt, starting at 1, across the redeliveries.
+	StartedAt  time.Time     // StartedAt is the time the request was sent.
+	Duration   time.Duration // Duration is the time until the response, or the error.
+	StatusCode int           // StatusCode is the status of the response, or 0 on a network error.
+	Response   string        // Response is the beginning of the response body, up to MaxResponseSize bytes.
+	Error      string        // Error is the error of the attempt, empty if it succeeded.
+}
+
+// Delivery tracks the delivery of an event to an endpoint.
+type Delivery struct {
+	ID         string         // ID is unique among the deliveries.
+	EndpointID string         // EndpointID is the ID of the endpoint.
+	Event      Event          // Event is the event delivered.
+	Status     DeliveryStatus // Status is the status of the delivery.
+	Attempts   []Attempt      // Attempts are the attempts of the delivery, oldest first.
+	CreatedAt  time.Time      // CreatedAt is the time the event was published to the endpoint.
+	UpdatedAt  time.Time      // UpdatedAt is the time of the last change of the status.
+}
+
+// DeliveryFilter selects the deliveries listed by a Store. Its zero fields match all the deliveries.
+type DeliveryFilter struct {
+	EndpointID string         // EndpointID selects the deliveries to an endpoint.
+	EventID    string         // EventID selects the deliveries of an event.
+	Status     DeliveryStatus // Status selects the deliveries with a status.
+	Limit      int            // Limit is the maximum number of deliveries listed, or no limit if zero.
+}
+
+// Matches reports whether delivery is selected by the filter, ignoring its Limit.
+func (f DeliveryFilter) Matches(delivery *Delivery) bool {
+	return (f.EndpointID == "" || f.EndpointID == delivery.EndpointID) &&
+		(f.EventID == "" || f.EventID == delivery.Event.ID) &&
+		(f.Status == "" || f.Status == delivery.Status)
+}
+
+/*
+Store stores the endpoints and the deliveries of a Dispatcher, e.g., in a SQL database shared by the replicas of a
+service. MemoryStore stores them in memory. The methods of a Store must be safe for concurrent use.
+*/
+type Store interface {
+	// SaveEndpoint creates endpoint, or replaces the endpoint with its ID.
+	SaveEndpoint(ctx context.Context, endpoint Endpoint) error
+	// GetEndpoint returns the endpoint with id, or ErrEndpointNotFound.
+	GetEndpoint(ctx context.Context, id string) (Endpoint, error)
+	// DeleteEndpoint deletes the endpoint with id, or returns ErrEndpointNotFound. Its deliveries are kept.
+	DeleteEndpoint(ctx context.Context, id string) error
+	// ListEndpoints returns the endpoints, sorted by ID.
+	ListEndpoints(ctx context.Context) ([]Endpoint, error)
+
+	// SaveDelivery creates delivery, or replaces the delivery with its ID.
+	SaveDelivery(ctx context.Context, delivery Delivery) error
+	// GetDelivery returns the delivery with id, or ErrDeliveryNotFound.
+	GetDelivery(ctx context.Context, id string) (Delivery, error)
+	// ListDeliveries returns the deliveries selected by filter, the most recently created first.
+	ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]Delivery, error)
+	// RecordAttempt appends attempt to the delivery with id, and sets its status, or returns ErrDeliveryNotFound.
+	RecordAttempt(ctx context.Context, id string, attempt Attempt, status DeliveryStatus) error
+}
@@ -0,0 +1,64 @@
+package workerpool
+
+import (
+	"github.com/kittipat1413/go-common/framework/metrics"
+)
+
+// Values of the status label of the metrics.
+const (
+	StatusSuccess   = "success"
+	StatusError     = "error"
+	StatusPanic     = "panic"
+	StatusTimeout   = "timeout"
+	StatusDiscarded = "discarded"
+)
+
+/*
+Metrics records the queue depth, the tasks and their latency of the pools in a metrics.Registry, labelled by pool:
+
+	workerpool_queue_depth{pool="thumbnails"} 12
+	workerpool_active_workers{pool="thumbnails"} 8
+	workerpool_tasks_total{pool="thumbnails",status="timeout"} 3
+	workerpool_task_wait_seconds_bucket{pool="thumbnails",le="0.1"} 1019
+	workerpool_task_duration_seconds_bucket{pool="thumbnails",le="1"} 998
+
+Example usage:
+
+	poolMetrics, err := workerpool.NewMetrics(metrics.Default())
+	if err != nil {
+		// Handle error
+	}
+	pool := workerpool.New(workerpool.WithName("thumbnails"), workerpool.WithMetrics(poolMetrics))
+*/
+type Metrics struct {
+	queueDepth *metrics.Gauge
+	active     *metrics.Gauge
+	tasks      *metrics.Counter
+	wait       *metrics.Histogram
+	duration   *metrics.Histogram
+}
+
+// NewMetrics creates the metrics of the pools in registry, or returns the ones already created.
+func NewMetrics(registry *metrics.Registry) (*Metrics, error) {
+	queueDepth, err := registry.NewGauge("workerpool_queue_depth", "Number of tasks waiting for a worker.", "pool")
+	if err != nil {
+		return nil, err
+	}
+	active, err := registry.NewGauge("workerpool_active_workers", "Number of workers running a task.", "pool")
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := registry.NewCounter("workerpool_tasks_total", "Number of tasks finished.", "pool", "status")
+	if err != nil {
+		return nil, err
+	}
+	wait, err := registry.NewHistogram("workerpool_task_wait_seconds", "Time the tasks waited in the queue in seconds.", nil, "pool")
+	if err != nil {
+		return nil, err
+	}
+	duration, err := registry.NewHistogram("workerpool_task_duration_seconds", "Duration of the tasks in seconds.", nil, "pool")
+	if err != nil {
+		return nil, err
+	}
+	return &Metrics{queueDepth: queueDepth, active: active, tasks: tasks, wait: wait, duration: duration}, nil
+}
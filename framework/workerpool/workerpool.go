@@ -0,0 +1,376 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+)
+
+// Defaults of the pool, unless set with the options.
+const (
+	DefaultSize      = 10
+	DefaultQueueSize = 100
+	DefaultName      = "default"
+)
+
+var (
+	// ErrClosed is returned by Submit once the pool is shut down.
+	ErrClosed = errors.New("workerpool: the pool is closed")
+	// ErrQueueFull is returned by TrySubmit when the queue is full.
+	ErrQueueFull = errors.New("workerpool: the queue is full")
+	// ErrPanic is wrapped by the errors the panics of the tasks are converted to.
+	ErrPanic = errors.New("workerpool: task panicked")
+	// ErrDiscarded is passed to the error handler with the tasks left in the queue when the drain times out.
+	ErrDiscarded = errors.New("workerpool: task discarded on shutdown")
+)
+
+// Task is a unit of work run by the pool. It must return when ctx is done.
+type Task func(ctx context.Context) error
+
+// options holds configuration options for the pool.
+type options struct {
+	name      string                               // name is the label of the metrics and the field of the logs.
+	size      int                                  // size is the number of workers.
+	queueSize int                                  // queueSize is the number of tasks waiting for a worker.
+	timeout   time.Duration                        // timeout bounds every task, or zero for no timeout.
+	logger    logger.Logger                        // logger logs the panics, or the logger of the context if nil.
+	metrics   *Metrics                             // metrics records the tasks if set.
+	onError   func(ctx context.Context, err error) // onError is called with the errors of the tasks.
+}
+
+// Option specifies pool configuration options.
+type Option func(*options)
+
+// WithName sets the name of the pool, used as pool label of the metrics and logged. It defaults to DefaultName.
+func WithName(name string) Option {
+	return func(opts *options) {
+		if name != "" {
+			opts.name = name
+		}
+	}
+}
+
+// WithSize sets the number of workers, i.e., of tasks run concurrently. It defaults to DefaultSize.
+func WithSize(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.size = n
+		}
+	}
+}
+
+// WithQueueSize sets the number of tasks waiting for a worker, beyond which Submit blocks and TrySubmit fails. It
+// defaults to DefaultQueueSize; zero hands the tasks over to the workers directly.
+func WithQueueSize(n int) Option {
+	return func(opts *options) {
+		if n >= 0 {
+			opts.queueSize = n
+		}
+	}
+}
+
+// WithTaskTimeout bounds the duration of every task, whose context is canceled after d. There is no timeout by
+// default.
+func WithTaskTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.timeout = d
+		}
+	}
+}
+
+// WithLogger sets the logger of the panics and, by default, of the errors of the tasks. It defaults to the logger
+// of the context of the tasks, see logger.FromContext.
+func WithLogger(l logger.Logger) Option {
+	return func(opts *options) {
+		if l != nil {
+			opts.logger = l
+		}
+	}
+}
+
+// WithMetrics records the queue depth, the tasks and their latency in m. They are not recorded by default.
+func WithMetrics(m *Metrics) Option {
+	return func(opts *options) {
+		if m != nil {
+			opts.metrics = m
+		}
+	}
+}
+
+// WithErrorHandler sets the function called with the errors of the tasks, including their panics and timeouts,
+// e.g., to report them. It defaults to logging them at error level.
+func WithErrorHandler(onError func(ctx context.Context, err error)) Option {
+	return func(opts *options) {
+		if onError != nil {
+			opts.onError = onError
+		}
+	}
+}
+
+// job is a task waiting in the queue.
+type job struct {
+	ctx    context.Context
+	task   Task
+	queued time.Time
+}
+
+/*
+Pool runs tasks on a fixed number of workers, queuing the tasks submitted while they are all busy. A task that
+panics does not kill its worker: the panic is logged with its stack and converted to an error wrapping ErrPanic.
+
+The pool is a lifecycle.Runnable and lifecycle.Shutdowner: Shutdown stops accepting tasks, and waits for the tasks
+queued and running to finish. If they do not finish in time, the tasks running are canceled, and the ones queued
+are discarded.
+
+Example usage:
+
+	pool := workerpool.New(
+		workerpool.WithName("thumbnails"),
+		workerpool.WithSize(8),
+		workerpool.WithTaskTimeout(30*time.Second),
+		workerpool.WithMetrics(poolMetrics),
+	)
+	manager.Add("thumbnails", pool, lifecycle.WithTimeout(time.Minute))
+
+	err := pool.Submit(ctx, func(ctx context.Context) error {
+		return thumbnails.Generate(ctx, imageID)
+	})
+*/
+type Pool struct {
+	opts  options
+	queue chan job
+
+	ctx    context.Context // ctx is the parent of the contexts of the tasks, canceled when the drain times out.
+	cancel context.CancelFunc
+
+	mu         sync.Mutex
+	closed     bool
+	closing    chan struct{}  // closing is closed once the pool stops accepting tasks.
+	submitters sync.WaitGroup // submitters are the calls to Submit in progress.
+	workers    sync.WaitGroup
+	done       chan struct{} // done is closed once the workers returned.
+}
+
+// New creates a pool and starts its workers.
+func New(opts ...Option) *Pool {
+	o := options{name: DefaultName, size: DefaultSize, queueSize: DefaultQueueSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.onError == nil {
+		o.onError = func(ctx context.Context, err error) {
+			loggerOrContext(ctx, o.logger).Error(ctx, "Task failed", err, logger.Fields{"pool": o.name})
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		opts:    o,
+		queue:   make(chan job, o.queueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	p.workers.Add(o.size)
+	for i := 0; i < o.size; i++ {
+		go p.work()
+	}
+	go func() {
+		p.workers.Wait()
+		close(p.done)
+	}()
+	return p
+}
+
+// Submit queues task, blocking while the queue is full, and returns once it is queued, or with the error of ctx if
+// it is done first, or ErrClosed if the pool is shut down. The task runs with the values of ctx, but is not canceled
+// with it.
+func (p *Pool) Submit(ctx context.Context, task Task) error {
+	return p.submit(ctx, task, true)
+}
+
+// TrySubmit queues task if the queue is not full, and returns ErrQueueFull otherwise, e.g., to shed the load.
+func (p *Pool) TrySubmit(ctx context.Context, task Task) error {
+	return p.submit(ctx, task, false)
+}
+
+func (p *Pool) submit(ctx context.Context, task Task, wait bool) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrClosed
+	}
+	p.submitters.Add(1)
+	p.mu.Unlock()
+	defer p.submitters.Done()
+
+	j := job{ctx: context.WithoutCancel(ctx), task: task, queued: time.Now()}
+	if !wait {
+		select {
+		case p.queue <- j:
+			p.observeQueue()
+			return nil
+		default:
+			return ErrQueueFull
+		}
+	}
+	select {
+	case p.queue <- j:
+		p.observeQueue()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.closing:
+		return ErrClosed
+	}
+}
+
+// Len returns the number of tasks waiting for a worker.
+func (p *Pool) Len() int {
+	return len(p.queue)
+}
+
+// Run blocks until the pool is shut down, or ctx is done, in which case the tasks running are canceled and the ones
+// queued are discarded. It returns nil.
+func (p *Pool) Run(ctx context.Context) error {
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		p.cancel()
+		p.close()
+		<-p.done
+	}
+	return nil
+}
+
+// Shutdown stops accepting tasks, and returns once the tasks queued and running finished. If ctx is done first, the
+// tasks running are canceled, the ones queued are discarded, and the error of ctx is returned.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.close()
+	select {
+	case <-p.done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+// close stops accepting tasks, and closes the queue once the calls to Submit in progress returned.
+func (p *Pool) close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.closing)
+	p.mu.Unlock()
+
+	p.submitters.Wait()
+	close(p.queue)
+}
+
+// work runs the tasks of the queue until it is closed.
+func (p *Pool) work() {
+	defer p.workers.Done()
+	for j := range p.queue {
+		p.observeQueue()
+		if p.ctx.Err() != nil {
+			p.observeTask(StatusDiscarded, 0)
+			p.opts.onError(j.ctx, ErrDiscarded)
+			continue
+		}
+		if m := p.opts.metrics; m != nil {
+			m.wait.Observe(time.Since(j.queued).Seconds(), p.opts.name)
+		}
+		p.run(j)
+	}
+}
+
+// run runs the task of j, and reports its error.
+func (p *Pool) run(j job) {
+	ctx, cancel := context.WithCancel(j.ctx)
+	defer cancel()
+	stop := context.AfterFunc(p.ctx, cancel)
+	defer stop()
+	if p.opts.timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, p.opts.timeout)
+		defer cancelTimeout()
+	}
+
+	if m := p.opts.metrics; m != nil {
+		m.active.Inc(p.opts.name)
+		defer m.active.Dec(p.opts.name)
+	}
+	start := time.Now()
+	err := p.call(ctx, j.task)
+	status := StatusSuccess
+	switch {
+	case errors.Is(err, ErrPanic):
+		status = StatusPanic
+	case err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		status = StatusTimeout
+	case err != nil:
+		status = StatusError
+	}
+	p.observeTask(status, time.Since(start))
+	if err != nil {
+		p.opts.onError(ctx, err)
+	}
+}
+
+// call calls task, converting its panic to an error.
+func (p *Pool) call(ctx context.Context, task Task) (err error) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		if recoveredErr, ok := recovered.(error); ok {
+			err = fmt.Errorf("%w: %w", ErrPanic, recoveredErr)
+		} else {
+			err = fmt.Errorf("%w: %v", ErrPanic, recovered)
+		}
+		loggerOrContext(ctx, p.opts.logger).Error(ctx, "Task panicked", err, logger.Fields{
+			"pool":                              p.opts.name,
+			logger.DefaultPanicKey:              fmt.Sprintf("%v", recovered),
+			logger.DefaultSJsonFmtStackTraceKey: string(debug.Stack()),
+		})
+	}()
+	return task(ctx)
+}
+
+// observeQueue records the depth of the queue.
+func (p *Pool) observeQueue() {
+	if m := p.opts.metrics; m != nil {
+		m.queueDepth.Set(float64(len(p.queue)), p.opts.name)
+	}
+}
+
+// observeTask records a task finished with status after d.
+func (p *Pool) observeTask(status string, d time.Duration) {
+	if m := p.opts.metrics; m != nil {
+		m.tasks.Inc(p.opts.name, status)
+		if status != StatusDiscarded {
+			m.duration.Observe(d.Seconds(), p.opts.name)
+		}
+	}
+}
+
+// loggerOrContext returns l, or the logger of ctx if l is nil.
+func loggerOrContext(ctx context.Context, l logger.Logger) logger.Logger {
+	if l != nil {
+		return l
+	}
+	return logger.FromContext(ctx)
+}
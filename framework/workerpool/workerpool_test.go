@@ -0,0 +1,236 @@
+package workerpool_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kittipat1413/go-common/framework/logger"
+	"github.com/kittipat1413/go-common/framework/metrics"
+	"github.com/kittipat1413/go-common/framework/workerpool"
+)
+
+// errorRecorder records the errors of the tasks.
+type errorRecorder struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (r *errorRecorder) record(ctx context.Context, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, err)
+}
+
+func (r *errorRecorder) errors() []error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]error(nil), r.errs...)
+}
+
+func TestPool_BoundsConcurrency(t *testing.T) {
+	pool := workerpool.New(workerpool.WithSize(3))
+
+	var running, maxRunning atomic.Int32
+	for i := 0; i < 20; i++ {
+		require.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				max := maxRunning.Load()
+				if n <= max || maxRunning.CompareAndSwap(max, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		}))
+	}
+	require.NoError(t, pool.Shutdown(context.Background()))
+	assert.Equal(t, int32(3), maxRunning.Load())
+}
+
+func TestPool_Submit(t *testing.T) {
+	t.Run("runs the task with the values of the context, not its cancellation", func(t *testing.T) {
+		pool := workerpool.New()
+		type key struct{}
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+		release := make(chan struct{})
+		done := make(chan error, 1)
+		require.NoError(t, pool.Submit(ctx, func(ctx context.Context) error {
+			<-release
+			assert.Equal(t, "value", ctx.Value(key{}))
+			done <- ctx.Err()
+			return nil
+		}))
+		cancel()
+		close(release)
+		require.NoError(t, pool.Shutdown(context.Background()))
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("blocks while the queue is full", func(t *testing.T) {
+		pool := workerpool.New(workerpool.WithSize(1), workerpool.WithQueueSize(1))
+		release := make(chan struct{})
+		blocked := func(ctx context.Context) error {
+			<-release
+			return nil
+		}
+		require.NoError(t, pool.Submit(context.Background(), blocked))
+		require.Eventually(t, func() bool { return pool.Len() == 0 }, time.Second, time.Millisecond)
+		require.NoError(t, pool.Submit(context.Background(), blocked))
+		assert.Equal(t, 1, pool.Len())
+
+		assert.ErrorIs(t, pool.TrySubmit(context.Background(), blocked), workerpool.ErrQueueFull)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, pool.Submit(ctx, blocked), context.DeadlineExceeded)
+
+		close(release)
+		require.NoError(t, pool.Shutdown(context.Background()))
+	})
+
+	t.Run("fails once the pool is shut down", func(t *testing.T) {
+		pool := workerpool.New()
+		require.NoError(t, pool.Shutdown(context.Background()))
+		assert.ErrorIs(t, pool.Submit(context.Background(), func(ctx context.Context) error { return nil }), workerpool.ErrClosed)
+		assert.ErrorIs(t, pool.TrySubmit(context.Background(), func(ctx context.Context) error { return nil }), workerpool.ErrClosed)
+	})
+}
+
+func TestPool_Errors(t *testing.T) {
+	t.Run("reports the errors of the tasks", func(t *testing.T) {
+		recorder := &errorRecorder{}
+		pool := workerpool.New(workerpool.WithErrorHandler(recorder.record))
+		errTask := errors.New("task failed")
+		require.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error { return errTask }))
+		require.NoError(t, pool.Shutdown(context.Background()))
+		assert.Equal(t, []error{errTask}, recorder.errors())
+	})
+
+	t.Run("logs the errors by default", func(t *testing.T) {
+		log, logs := logger.NewTestLogger()
+		pool := workerpool.New(workerpool.WithName("thumbnails"), workerpool.WithLogger(log))
+		require.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error { return errors.New("task failed") }))
+		require.NoError(t, pool.Shutdown(context.Background()))
+		logs.AssertLogged(t, logger.ERROR, "Task failed", logger.HasField("pool", "thumbnails"))
+	})
+
+	t.Run("recovers the panics", func(t *testing.T) {
+		log, logs := logger.NewTestLogger()
+		recorder := &errorRecorder{}
+		pool := workerpool.New(workerpool.WithSize(1), workerpool.WithLogger(log), workerpool.WithErrorHandler(recorder.record))
+		require.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error { panic("boom") }))
+		ran := make(chan struct{})
+		require.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error {
+			close(ran)
+			return nil
+		}))
+		require.NoError(t, pool.Shutdown(context.Background()))
+
+		<-ran
+		errs := recorder.errors()
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], workerpool.ErrPanic)
+		entries := logs.Find(logger.ERROR, "Task panicked")
+		require.Len(t, entries, 1)
+		assert.Contains(t, entries[0].Fields[logger.DefaultSJsonFmtStackTraceKey], "workerpool_test.go")
+	})
+
+	t.Run("times out the tasks", func(t *testing.T) {
+		recorder := &errorRecorder{}
+		pool := workerpool.New(workerpool.WithTaskTimeout(10*time.Millisecond), workerpool.WithErrorHandler(recorder.record))
+		require.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+		require.NoError(t, pool.Shutdown(context.Background()))
+		assert.Equal(t, []error{context.DeadlineExceeded}, recorder.errors())
+	})
+}
+
+func TestPool_Shutdown(t *testing.T) {
+	t.Run("drains the tasks queued", func(t *testing.T) {
+		pool := workerpool.New(workerpool.WithSize(1))
+		var done atomic.Int32
+		for i := 0; i < 5; i++ {
+			require.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error {
+				time.Sleep(time.Millisecond)
+				done.Add(1)
+				return nil
+			}))
+		}
+		require.NoError(t, pool.Shutdown(context.Background()))
+		assert.Equal(t, int32(5), done.Load())
+	})
+
+	t.Run("cancels the tasks running and discards the ones queued when the drain times out", func(t *testing.T) {
+		recorder := &errorRecorder{}
+		pool := workerpool.New(workerpool.WithSize(1), workerpool.WithErrorHandler(recorder.record))
+		started := make(chan struct{})
+		require.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+		require.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error {
+			t.Error("the task queued must be discarded")
+			return nil
+		}))
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, pool.Shutdown(ctx), context.DeadlineExceeded)
+		require.NoError(t, pool.Run(context.Background()))
+
+		errs := recorder.errors()
+		require.Len(t, errs, 2)
+		assert.ErrorIs(t, errs[0], context.Canceled)
+		assert.ErrorIs(t, errs[1], workerpool.ErrDiscarded)
+	})
+
+	t.Run("stops when the context of Run is done", func(t *testing.T) {
+		pool := workerpool.New()
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- pool.Run(ctx) }()
+		cancel()
+		require.NoError(t, <-done)
+		assert.ErrorIs(t, pool.Submit(context.Background(), func(ctx context.Context) error { return nil }), workerpool.ErrClosed)
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m, err := workerpool.NewMetrics(registry)
+	require.NoError(t, err)
+
+	pool := workerpool.New(workerpool.WithName("thumbnails"), workerpool.WithMetrics(m),
+		workerpool.WithErrorHandler(func(ctx context.Context, err error) {}))
+	require.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error { return nil }))
+	require.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error { return errors.New("task failed") }))
+	require.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error { panic("boom") }))
+	require.NoError(t, pool.Shutdown(context.Background()))
+
+	var output bytes.Buffer
+	_, err = registry.WriteTo(&output)
+	require.NoError(t, err)
+	assert.Contains(t, output.String(), `workerpool_tasks_total{pool="thumbnails",status="success"} 1`)
+	assert.Contains(t, output.String(), `workerpool_tasks_total{pool="thumbnails",status="error"} 1`)
+	assert.Contains(t, output.String(), `workerpool_tasks_total{pool="thumbnails",status="panic"} 1`)
+	assert.Contains(t, output.String(), `workerpool_task_wait_seconds_count{pool="thumbnails"} 3`)
+	assert.Contains(t, output.String(), `workerpool_task_duration_seconds_count{pool="thumbnails"} 3`)
+	assert.Contains(t, output.String(), `workerpool_queue_depth{pool="thumbnails"} 0`)
+	assert.Contains(t, output.String(), `workerpool_active_workers{pool="thumbnails"} 0`)
+
+	_, err = workerpool.NewMetrics(registry)
+	assert.NoError(t, err, "the metrics are shared")
+}
@@ -0,0 +1,176 @@
+package containers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// EnvRequired fails the tests, instead of skipping them, when a service is neither provided nor startable, e.g., in
+// CI where the tests must not be skipped silently.
+const EnvRequired = "TEST_CONTAINERS_REQUIRED"
+
+// DefaultStartTimeout bounds the start of a container, including the pull of its image, unless set with
+// WithStartTimeout.
+const DefaultStartTimeout = 2 * time.Minute
+
+// options holds configuration options for the containers.
+type options struct {
+	image   string        // image is the image of the container, or the default one of the service if empty.
+	timeout time.Duration // timeout bounds the start of the container.
+}
+
+// Option specifies container configuration options.
+type Option func(*options)
+
+// WithImage sets the image of the container, e.g., "postgres:15-alpine" to test against the version in production.
+func WithImage(image string) Option {
+	return func(opts *options) {
+		if image != "" {
+			opts.image = image
+		}
+	}
+}
+
+// WithStartTimeout bounds the start of the container, including the pull of its image. It defaults to
+// DefaultStartTimeout.
+func WithStartTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.timeout = d
+		}
+	}
+}
+
+func newOptions(image string, opts []Option) options {
+	o := options{image: image, timeout: DefaultStartTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// shared holds the containers shared by the tests of the package, see Run.
+var shared struct {
+	mu        sync.Mutex
+	enabled   bool
+	instances map[string]*instance // instances are the containers started, by service and image.
+}
+
+// instance is a container started for a service, with the address of its port.
+type instance struct {
+	container testcontainers.Container
+	addr      string
+	err       error
+}
+
+/*
+Run runs the tests of m, sharing the containers between them: a container is started by the first test needing
+it, and removed once all the tests ran. Without Run, every test starts its own containers, removed when it ends.
+Call it from TestMain:
+
+	func TestMain(m *testing.M) {
+		os.Exit(containers.Run(m))
+	}
+
+The tests sharing a container should isolate their data, e.g., with Postgres.NewDatabase or Kafka.Topic.
+*/
+func Run(m *testing.M) int {
+	shared.mu.Lock()
+	shared.enabled = true
+	shared.instances = map[string]*instance{}
+	shared.mu.Unlock()
+
+	defer func() {
+		shared.mu.Lock()
+		defer shared.mu.Unlock()
+		for _, inst := range shared.instances {
+			terminate(inst.container)
+		}
+		shared.instances = nil
+		shared.enabled = false
+	}()
+	return m.Run()
+}
+
+// acquire returns the address of the container of the service key, started with start, or skips the test if
+// Docker is not available. The address of an instance shared with Run is returned without starting a container.
+func acquire(tb testing.TB, key, env string, o options, start func(ctx context.Context) (testcontainers.Container, string, error)) string {
+	tb.Helper()
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+	if shared.enabled {
+		if inst, ok := shared.instances[key]; ok {
+			if inst.err != nil {
+				tb.Fatalf("containers: %v", inst.err)
+			}
+			return inst.addr
+		}
+	}
+	if err := dockerAvailable(); err != nil {
+		skip(tb, fmt.Sprintf("containers: %v, and %s is not set", err, env))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+	defer cancel()
+	inst := &instance{}
+	inst.container, inst.addr, inst.err = start(ctx)
+	if inst.err != nil {
+		// The container is returned along with the error of its start, e.g., when it is not ready in time.
+		inst.err = fmt.Errorf("%s: %w", key, inst.err)
+		terminate(inst.container)
+		inst.container = nil
+	}
+	if shared.enabled {
+		shared.instances[key] = inst
+	} else if inst.err == nil {
+		container := inst.container
+		tb.Cleanup(func() { terminate(container) })
+	}
+	if inst.err != nil {
+		tb.Fatalf("containers: %v", inst.err)
+	}
+	return inst.addr
+}
+
+// skip skips the test with msg, or fails it if EnvRequired is set.
+func skip(tb testing.TB, msg string) {
+	tb.Helper()
+	if os.Getenv(EnvRequired) != "" {
+		tb.Fatal(msg)
+	}
+	tb.Skip(msg)
+}
+
+// dockerAvailable returns an error if the Docker daemon configured for testcontainers, e.g., with DOCKER_HOST, is
+// not reachable.
+func dockerAvailable() (err error) {
+	defer func() {
+		// The provider panics when the Docker host cannot be found.
+		if r := recover(); r != nil {
+			err = fmt.Errorf("docker is not available: %v", r)
+		}
+	}()
+	provider, err := testcontainers.ProviderDocker.GetProvider()
+	if err != nil {
+		return fmt.Errorf("docker is not available: %w", err)
+	}
+	defer provider.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := provider.Health(ctx); err != nil {
+		return fmt.Errorf("docker is not available: %w", err)
+	}
+	return nil
+}
+
+// terminate removes container, if any, with its volumes. The containers of the tests killed before are removed by the
+// reaper of testcontainers.
+func terminate(container testcontainers.Container) {
+	_ = testcontainers.TerminateContainer(container)
+}
@@ -0,0 +1,82 @@
+package containers
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/rs/xid"
+	"github.com/testcontainers/testcontainers-go"
+	tckafka "github.com/testcontainers/testcontainers-go/modules/kafka"
+)
+
+const (
+	// EnvKafkaBrokers is the comma-separated addresses of the Kafka brokers of the tests, e.g., a service of the CI,
+	// used instead of a container. The brokers must create the topics on their first use.
+	EnvKafkaBrokers = "TEST_KAFKA_BROKERS"
+	// DefaultKafkaImage is the image of the Kafka containers, unless set with WithImage. It runs a single broker
+	// in KRaft mode, without ZooKeeper. The kafka module of testcontainers supports the confluentinc/confluent-local
+	// images from 7.4.0.
+	DefaultKafkaImage = "confluentinc/confluent-local:7.5.0"
+)
+
+// Kafka is a Kafka cluster of the tests.
+type Kafka struct {
+	// Brokers are the addresses of the brokers, e.g., ["localhost:32768"].
+	Brokers []string
+}
+
+/*
+NewKafka returns the Kafka brokers of EnvKafkaBrokers if it is set, or starts a container with a single broker with
+the kafka module of testcontainers, removed when the test ends, see Run to share it between the tests. It skips the test if Docker is not available,
+unless EnvRequired is set.
+
+The package does not depend on a Kafka client: the tests create the Producer and the Consumers of the kafkabus
+package with the client of the service, connected to the brokers.
+
+Example usage:
+
+	func TestOrderEvents(t *testing.T) {
+		kafka := containers.NewKafka(t)
+		client, err := kgo.NewClient(kgo.SeedBrokers(kafka.Brokers...))
+		...
+		topic := kafka.Topic(t)
+	}
+*/
+func NewKafka(tb testing.TB, opts ...Option) *Kafka {
+	tb.Helper()
+	if brokers := os.Getenv(EnvKafkaBrokers); brokers != "" {
+		return &Kafka{Brokers: strings.Split(brokers, ",")}
+	}
+	o := newOptions(DefaultKafkaImage, opts)
+	brokers := acquire(tb, "kafka:"+o.image, EnvKafkaBrokers, o, func(ctx context.Context) (testcontainers.Container, string, error) {
+		container, err := tckafka.Run(ctx, o.image,
+			tckafka.WithClusterID("test-cluster"),
+			testcontainers.WithEnv(map[string]string{
+				"KAFKA_AUTO_CREATE_TOPICS_ENABLE":        "true",
+				"KAFKA_GROUP_INITIAL_REBALANCE_DELAY_MS": "0",
+			}),
+		)
+		if err != nil {
+			return container, "", err
+		}
+		brokers, err := container.Brokers(ctx)
+		return container, strings.Join(brokers, ","), err
+	})
+	return &Kafka{Brokers: strings.Split(brokers, ",")}
+}
+
+// topicChars matches the characters not allowed in the names of the topics.
+var topicChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// Topic returns a unique topic name for the test, e.g., "test-TestOrderEvents-cq0fd3ufl5hc73d1mhag", so that the
+// tests sharing the brokers are isolated. The topic is created by the brokers on its first use.
+func (k *Kafka) Topic(tb testing.TB) string {
+	name := topicChars.ReplaceAllString(tb.Name(), "_")
+	if len(name) > 200 {
+		name = name[:200]
+	}
+	return "test-" + name + "-" + xid.New().String()
+}
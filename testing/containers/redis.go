@@ -0,0 +1,57 @@
+package containers
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go"
+	tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
+)
+
+const (
+	// EnvRedisAddr is the address of the Redis of the tests, e.g., a service of the CI, used instead of a container.
+	EnvRedisAddr = "TEST_REDIS_ADDR"
+	// DefaultRedisImage is the image of the Redis containers, unless set with WithImage.
+	DefaultRedisImage = "redis:7-alpine"
+)
+
+// Redis is a Redis server of the tests.
+type Redis struct {
+	// Addr is the address of the server, e.g., "localhost:32768".
+	Addr string
+}
+
+/*
+NewRedis returns the Redis server at EnvRedisAddr if it is set, or starts a container with the redis module of
+testcontainers, removed when the test ends, see Run to share it between the tests. It skips the test if Docker is
+not available, unless EnvRequired is set.
+
+The package does not depend on a Redis client: the tests connect the client of the service to Addr, e.g., wrapped
+in the Client of the redislock package.
+
+Example usage:
+
+	func TestLocks(t *testing.T) {
+		redis := containers.NewRedis(t)
+		client := goredis.NewClient(&goredis.Options{Addr: redis.Addr})
+		...
+	}
+*/
+func NewRedis(tb testing.TB, opts ...Option) *Redis {
+	tb.Helper()
+	if addr := os.Getenv(EnvRedisAddr); addr != "" {
+		return &Redis{Addr: addr}
+	}
+	o := newOptions(DefaultRedisImage, opts)
+	addr := acquire(tb, "redis:"+o.image, EnvRedisAddr, o, func(ctx context.Context) (testcontainers.Container, string, error) {
+		// The module waits until the server logs that it accepts connections.
+		container, err := tcredis.Run(ctx, o.image)
+		if err != nil {
+			return container, "", err
+		}
+		addr, err := container.Endpoint(ctx, "")
+		return container, addr, err
+	})
+	return &Redis{Addr: addr}
+}
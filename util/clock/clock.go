@@ -0,0 +1,83 @@
+package clock
+
+import "time"
+
+/*
+Clock tells the time and waits, so that the time-dependent code can be tested with a Fake instead of sleeping.
+
+Example usage:
+
+	type Session struct {
+		clock     clock.Clock
+		expiresAt time.Time
+	}
+
+	func (s *Session) Expired() bool {
+		return !s.clock.Now().Before(s.expiresAt)
+	}
+
+	// In production
+	session := &Session{clock: clock.Real(), expiresAt: expiresAt}
+*/
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Since returns the time elapsed since t.
+	Since(t time.Time) time.Duration
+	// NewTimer creates a Timer sending the current time on its channel after d.
+	NewTimer(d time.Duration) Timer
+	// NewTicker creates a Ticker sending the current time on its channel every d. It panics if d is not positive.
+	NewTicker(d time.Duration) Ticker
+	// Sleep pauses the current goroutine for d.
+	Sleep(d time.Duration)
+}
+
+// Timer is a time.Timer of a Clock.
+type Timer interface {
+	// C returns the channel of the timer.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, and reports whether it stopped it.
+	Stop() bool
+	// Reset changes the timer to fire after d, and reports whether it was active.
+	Reset(d time.Duration) bool
+}
+
+// Ticker is a time.Ticker of a Clock.
+type Ticker interface {
+	// C returns the channel of the ticker.
+	C() <-chan time.Time
+	// Stop turns off the ticker.
+	Stop()
+	// Reset changes the period of the ticker to d.
+	Reset(d time.Duration)
+}
+
+// Real returns the Clock of the time package.
+func Real() Clock {
+	return realClock{}
+}
+
+// realClock is the Clock of the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+func (realClock) Sleep(d time.Duration)           { time.Sleep(d) }
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+// realTimer is a Timer of the time package.
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+// realTicker is a Ticker of the time package.
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
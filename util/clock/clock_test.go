@@ -0,0 +1,140 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/util/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// received returns the value received on ch, or false if none is pending.
+func received(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-ch:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestReal(t *testing.T) {
+	c := clock.Real()
+	before := time.Now()
+	assert.False(t, c.Now().Before(before))
+	assert.GreaterOrEqual(t, c.Since(before), time.Duration(0))
+
+	timer := c.NewTimer(time.Millisecond)
+	<-timer.C()
+	assert.False(t, timer.Stop())
+
+	ticker := c.NewTicker(time.Millisecond)
+	<-ticker.C()
+	ticker.Stop()
+
+	c.Sleep(time.Millisecond)
+	assert.GreaterOrEqual(t, c.Since(before), 2*time.Millisecond)
+}
+
+func TestFake_Timer(t *testing.T) {
+	fake := clock.NewFake(start)
+	assert.Equal(t, start, fake.Now())
+
+	timer := fake.NewTimer(time.Minute)
+	fake.Advance(59 * time.Second)
+	_, ok := received(timer.C())
+	assert.False(t, ok)
+	assert.Equal(t, 59*time.Second, fake.Since(start))
+
+	fake.Advance(time.Hour)
+	fired, ok := received(timer.C())
+	require.True(t, ok)
+	assert.Equal(t, start.Add(time.Minute), fired, "the timer sends its deadline")
+	assert.False(t, timer.Stop(), "the timer fired")
+
+	assert.False(t, timer.Reset(time.Second))
+	assert.True(t, timer.Stop())
+	fake.Advance(time.Second)
+	_, ok = received(timer.C())
+	assert.False(t, ok, "the timer is stopped")
+
+	timer.Reset(0)
+	_, ok = received(timer.C())
+	assert.True(t, ok, "a non-positive duration fires immediately")
+}
+
+func TestFake_Ticker(t *testing.T) {
+	fake := clock.NewFake(start)
+	ticker := fake.NewTicker(time.Second)
+
+	fake.Advance(time.Second)
+	tick, ok := received(ticker.C())
+	require.True(t, ok)
+	assert.Equal(t, start.Add(time.Second), tick)
+
+	// The ticks not received are dropped.
+	fake.Advance(5 * time.Second)
+	tick, ok = received(ticker.C())
+	require.True(t, ok)
+	assert.Equal(t, start.Add(2*time.Second), tick)
+	_, ok = received(ticker.C())
+	assert.False(t, ok)
+
+	ticker.Reset(time.Minute)
+	fake.Advance(time.Second)
+	_, ok = received(ticker.C())
+	assert.False(t, ok)
+	fake.Advance(time.Minute)
+	_, ok = received(ticker.C())
+	assert.True(t, ok)
+
+	ticker.Stop()
+	fake.Advance(time.Hour)
+	_, ok = received(ticker.C())
+	assert.False(t, ok)
+
+	assert.Panics(t, func() { fake.NewTicker(0) })
+}
+
+func TestFake_Sleep(t *testing.T) {
+	fake := clock.NewFake(start)
+	done := make(chan struct{})
+	go func() {
+		fake.Sleep(time.Minute)
+		close(done)
+	}()
+
+	fake.BlockUntil(1)
+	fake.Advance(30 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("the sleeper woke up early")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	fake.Advance(30 * time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the sleeper did not wake up")
+	}
+
+	fake.Sleep(0) // Returns immediately
+}
+
+func TestFake_Set(t *testing.T) {
+	fake := clock.NewFake(start)
+	timer := fake.NewTimer(time.Minute)
+
+	fake.Set(start.Add(-time.Hour))
+	assert.Equal(t, start.Add(-time.Hour), fake.Now())
+	_, ok := received(timer.C())
+	assert.False(t, ok, "moving backwards fires nothing")
+
+	fake.Set(start.Add(time.Minute))
+	_, ok = received(timer.C())
+	assert.True(t, ok)
+}
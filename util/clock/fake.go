@@ -0,0 +1,193 @@
+package clock
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+/*
+Fake is a Clock whose time only moves with Advance and Set, firing the timers, the tickers and the sleepers whose
+time has come. It is safe for concurrent use.
+
+Example usage:
+
+	fake := clock.NewFake(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	c := localcache.New[string](localcache.WithClock(fake), localcache.WithDefaultExpiration(time.Minute))
+	c.Set(ctx, "key", "value", nil)
+
+	fake.Advance(time.Minute)
+	_, err := c.Get(ctx, "key", nil) // cache.ErrCacheMiss
+
+	// Wait for a goroutine to sleep before advancing the clock past it
+	go worker.Run(ctx)
+	fake.BlockUntil(1)
+	fake.Advance(worker.Interval)
+*/
+type Fake struct {
+	mu      sync.Mutex
+	changed *sync.Cond // changed is broadcast when the waiters change.
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// NewFake creates a Fake at now.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.changed = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the time of f.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the time elapsed since t at the time of f.
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// NewTimer creates a Timer firing once the time of f reaches d later.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	w := &fakeWaiter{fake: f, ch: make(chan time.Time, 1)}
+	w.Reset(d)
+	return w
+}
+
+// NewTicker creates a Ticker firing every d of the time of f. Like time.Ticker, it drops the ticks of the slow
+// receivers.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &fakeWaiter{fake: f, ch: make(chan time.Time, 1), deadline: f.now.Add(d), period: d}
+	f.add(w)
+	return fakeTicker{w}
+}
+
+// Sleep blocks until the time of f moves d later.
+func (f *Fake) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	<-f.NewTimer(d).C()
+}
+
+// Advance moves the time of f by d, firing the timers, the tickers and the sleepers whose time has come.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.set(f.now.Add(d))
+}
+
+// Set sets the time of f, firing the timers, the tickers and the sleepers whose time has come. Setting an earlier
+// time fires nothing, e.g., to simulate a clock rollback.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.set(now)
+}
+
+// BlockUntil blocks until at least n timers, tickers and sleepers wait for f, e.g., until the goroutine under test
+// started waiting before advancing the time.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.changed.Wait()
+	}
+}
+
+// set sets the time of f and fires the waiters, in the order of their deadlines. f.mu must be held.
+func (f *Fake) set(now time.Time) {
+	f.now = now
+	for {
+		i := slices.IndexFunc(f.waiters, func(w *fakeWaiter) bool { return !w.deadline.After(now) })
+		if i < 0 {
+			return
+		}
+		for j, w := range f.waiters {
+			if w.deadline.Before(f.waiters[i].deadline) {
+				i = j
+			}
+		}
+		w := f.waiters[i]
+		select {
+		case w.ch <- w.deadline:
+		default: // A tick not received yet: dropped.
+		}
+		if w.period > 0 {
+			w.deadline = w.deadline.Add(w.period)
+		} else {
+			f.remove(w)
+		}
+	}
+}
+
+// add adds w to the waiters. f.mu must be held.
+func (f *Fake) add(w *fakeWaiter) {
+	f.waiters = append(f.waiters, w)
+	f.changed.Broadcast()
+}
+
+// remove removes w from the waiters, and reports whether it was waiting. f.mu must be held.
+func (f *Fake) remove(w *fakeWaiter) bool {
+	i := slices.Index(f.waiters, w)
+	if i < 0 {
+		return false
+	}
+	f.waiters = slices.Delete(f.waiters, i, i+1)
+	f.changed.Broadcast()
+	return true
+}
+
+// fakeWaiter is a timer or a ticker of a Fake.
+type fakeWaiter struct {
+	fake     *Fake
+	ch       chan time.Time
+	deadline time.Time
+	period   time.Duration // period is the period of the tickers, or 0 for the timers.
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.ch
+}
+
+func (w *fakeWaiter) Stop() bool {
+	w.fake.mu.Lock()
+	defer w.fake.mu.Unlock()
+	return w.fake.remove(w)
+}
+
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	f := w.fake
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	active := f.remove(w)
+	w.deadline = f.now.Add(d)
+	if w.period > 0 {
+		w.period = d
+	}
+	f.add(w)
+	f.set(f.now) // Fires the timers reset to a non-positive duration.
+	return active
+}
+
+// fakeTicker is a Ticker of a Fake.
+type fakeTicker struct{ *fakeWaiter }
+
+func (t fakeTicker) Stop() {
+	t.fakeWaiter.Stop()
+}
+
+func (t fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	t.fakeWaiter.Reset(d)
+}
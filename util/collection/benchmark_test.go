@@ -0,0 +1,77 @@
+package collection_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/kittipat1413/go-common/util/collection"
+)
+
+// benchmarkInput returns n integers with n/2 distinct values.
+func benchmarkInput(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i % (n / 2)
+	}
+	return s
+}
+
+func BenchmarkMap(b *testing.B) {
+	s := benchmarkInput(1000)
+	b.ReportAllocs()
+	for range b.N {
+		collection.Map(s, strconv.Itoa)
+	}
+}
+
+// BenchmarkFilter compares Filter, allocating a new slice, to FilterInPlace, allocating nothing.
+func BenchmarkFilter(b *testing.B) {
+	isEven := func(i int) bool { return i%2 == 0 }
+	s := benchmarkInput(1000)
+	b.Run("Filter", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			collection.Filter(s, isEven)
+		}
+	})
+	b.Run("FilterInPlace", func(b *testing.B) {
+		buf := make([]int, len(s))
+		b.ReportAllocs()
+		for range b.N {
+			copy(buf, s)
+			collection.FilterInPlace(buf, isEven)
+		}
+	})
+}
+
+func BenchmarkUnique(b *testing.B) {
+	s := benchmarkInput(1000)
+	b.ReportAllocs()
+	for range b.N {
+		collection.Unique(s)
+	}
+}
+
+func BenchmarkChunk(b *testing.B) {
+	s := benchmarkInput(1000)
+	b.ReportAllocs()
+	for range b.N {
+		collection.Chunk(s, 100)
+	}
+}
+
+func BenchmarkGroupBy(b *testing.B) {
+	s := benchmarkInput(1000)
+	b.ReportAllocs()
+	for range b.N {
+		collection.GroupBy(s, func(i int) int { return i % 10 })
+	}
+}
+
+func BenchmarkIntersection(b *testing.B) {
+	s1, s2 := benchmarkInput(1000), benchmarkInput(500)
+	b.ReportAllocs()
+	for range b.N {
+		collection.Intersection(s1, s2)
+	}
+}
@@ -0,0 +1,154 @@
+package collection
+
+// Map returns the results of f for the elements of s, in order.
+func Map[S ~[]E, E, R any](s S, f func(E) R) []R {
+	result := make([]R, len(s))
+	for i, e := range s {
+		result[i] = f(e)
+	}
+	return result
+}
+
+// Filter returns the elements of s for which keep returns true, in order, in a new slice.
+func Filter[S ~[]E, E any](s S, keep func(E) bool) S {
+	result := make(S, 0)
+	for _, e := range s {
+		if keep(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// FilterInPlace is like Filter, but reuses the backing array of s instead of allocating: s must not be used
+// afterwards. The elements removed are zeroed so that they can be garbage collected.
+func FilterInPlace[S ~[]E, E any](s S, keep func(E) bool) S {
+	n := 0
+	for _, e := range s {
+		if keep(e) {
+			s[n] = e
+			n++
+		}
+	}
+	clear(s[n:])
+	return s[:n]
+}
+
+// Reduce folds the elements of s into an accumulator, starting from initial.
+func Reduce[S ~[]E, E, A any](s S, initial A, f func(acc A, e E) A) A {
+	acc := initial
+	for _, e := range s {
+		acc = f(acc, e)
+	}
+	return acc
+}
+
+// Unique returns the elements of s without duplicates, keeping the first occurrences in order.
+func Unique[S ~[]E, E comparable](s S) S {
+	return UniqueBy(s, func(e E) E { return e })
+}
+
+// UniqueBy returns the elements of s without the ones whose key is a duplicate, keeping the first occurrences in
+// order, e.g., the users of a list by ID.
+func UniqueBy[S ~[]E, E any, K comparable](s S, key func(E) K) S {
+	seen := make(map[K]struct{}, len(s))
+	result := make(S, 0, len(s))
+	for _, e := range s {
+		k := key(e)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		result = append(result, e)
+	}
+	return result
+}
+
+// Chunk splits s into consecutive chunks of size elements, the last one being shorter if needed, e.g., to batch the
+// rows of an insert. The chunks share the backing array of s, with their capacity capped so that appending to a
+// chunk does not overwrite the next one. It panics if size is not positive.
+func Chunk[S ~[]E, E any](s S, size int) []S {
+	if size <= 0 {
+		panic("collection: chunk size must be positive")
+	}
+	chunks := make([]S, 0, (len(s)+size-1)/size)
+	for i := 0; i < len(s); i += size {
+		end := min(i+size, len(s))
+		chunks = append(chunks, s[i:end:end])
+	}
+	return chunks
+}
+
+// GroupBy groups the elements of s by key, keeping their order within each group.
+func GroupBy[S ~[]E, E any, K comparable](s S, key func(E) K) map[K]S {
+	groups := make(map[K]S)
+	for _, e := range s {
+		k := key(e)
+		groups[k] = append(groups[k], e)
+	}
+	return groups
+}
+
+// KeyBy indexes the elements of s by key, the last element winning for duplicate keys.
+func KeyBy[S ~[]E, E any, K comparable](s S, key func(E) K) map[K]E {
+	index := make(map[K]E, len(s))
+	for _, e := range s {
+		index[key(e)] = e
+	}
+	return index
+}
+
+// Keys returns the keys of m, in an unspecified order.
+func Keys[M ~map[K]V, K comparable, V any](m M) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Values returns the values of m, in an unspecified order.
+func Values[M ~map[K]V, K comparable, V any](m M) []V {
+	values := make([]V, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
+
+// Difference returns the unique elements of a that are not in b, in their order in a.
+func Difference[S ~[]E, E comparable](a, b S) S {
+	// The elements kept are added to the excluded ones, so that they are kept once.
+	exclude := toSet(b)
+	result := make(S, 0)
+	for _, e := range a {
+		if _, ok := exclude[e]; !ok {
+			exclude[e] = struct{}{}
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Intersection returns the unique elements of a that are also in b, in their order in a.
+func Intersection[S ~[]E, E comparable](a, b S) S {
+	// The elements kept are removed from the included ones, so that they are kept once.
+	include := toSet(b)
+	result := make(S, 0, min(len(a), len(include)))
+	for _, e := range a {
+		if _, ok := include[e]; ok {
+			delete(include, e)
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// toSet returns the set of the elements of s.
+func toSet[S ~[]E, E comparable](s S) map[E]struct{} {
+	set := make(map[E]struct{}, len(s))
+	for _, e := range s {
+		set[e] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,128 @@
+package collection_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kittipat1413/go-common/util/collection"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID   int
+	Team string
+}
+
+func TestMap(t *testing.T) {
+	assert.Equal(t, []string{"1", "2", "3"}, collection.Map([]int{1, 2, 3}, strconv.Itoa))
+	assert.Equal(t, []string{}, collection.Map([]int(nil), strconv.Itoa))
+}
+
+func TestFilter(t *testing.T) {
+	isEven := func(i int) bool { return i%2 == 0 }
+	tests := []struct {
+		name     string
+		input    []int
+		expected []int
+	}{
+		{name: "Some kept", input: []int{1, 2, 3, 4}, expected: []int{2, 4}},
+		{name: "None kept", input: []int{1, 3}, expected: []int{}},
+		{name: "Empty slice", input: nil, expected: []int{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := append([]int(nil), test.input...)
+			assert.Equal(t, test.expected, collection.Filter(input, isEven))
+			assert.Equal(t, test.input, input, "the input is not modified")
+			assert.Equal(t, test.expected, append([]int{}, collection.FilterInPlace(input, isEven)...))
+		})
+	}
+
+	s := []*user{{ID: 1}, {ID: 2}, {ID: 3}}
+	kept := collection.FilterInPlace(s, func(u *user) bool { return u.ID == 2 })
+	assert.Equal(t, []*user{{ID: 2}}, kept)
+	assert.Equal(t, []*user{{ID: 2}, nil, nil}, s, "the elements removed are zeroed")
+}
+
+func TestReduce(t *testing.T) {
+	assert.Equal(t, 10, collection.Reduce([]int{1, 2, 3, 4}, 0, func(acc, i int) int { return acc + i }))
+	assert.Equal(t, "a,b", collection.Reduce([]string{"a", "b"}, "", func(acc, s string) string {
+		return strings.TrimPrefix(acc+","+s, ",")
+	}))
+	assert.Equal(t, 7, collection.Reduce([]int(nil), 7, func(acc, i int) int { return acc + i }))
+}
+
+func TestUnique(t *testing.T) {
+	assert.Equal(t, []int{3, 1, 2}, collection.Unique([]int{3, 1, 3, 2, 1}))
+	assert.Equal(t, []int{}, collection.Unique([]int{}))
+
+	users := []user{{ID: 1, Team: "a"}, {ID: 2, Team: "b"}, {ID: 1, Team: "c"}}
+	assert.Equal(t, []user{{ID: 1, Team: "a"}, {ID: 2, Team: "b"}}, collection.UniqueBy(users, func(u user) int { return u.ID }))
+}
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []int
+		size     int
+		expected [][]int
+	}{
+		{name: "Even", input: []int{1, 2, 3, 4}, size: 2, expected: [][]int{{1, 2}, {3, 4}}},
+		{name: "Shorter last chunk", input: []int{1, 2, 3, 4, 5}, size: 2, expected: [][]int{{1, 2}, {3, 4}, {5}}},
+		{name: "Larger size", input: []int{1, 2}, size: 5, expected: [][]int{{1, 2}}},
+		{name: "Empty slice", input: nil, size: 3, expected: [][]int{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, collection.Chunk(test.input, test.size))
+		})
+	}
+
+	s := []int{1, 2, 3, 4}
+	chunks := collection.Chunk(s, 2)
+	_ = append(chunks[0], 9)
+	assert.Equal(t, []int{1, 2, 3, 4}, s, "appending to a chunk does not overwrite the next one")
+
+	assert.Panics(t, func() { collection.Chunk(s, 0) })
+}
+
+func TestGroupBy(t *testing.T) {
+	users := []user{{ID: 1, Team: "a"}, {ID: 2, Team: "b"}, {ID: 3, Team: "a"}}
+	assert.Equal(t, map[string][]user{
+		"a": {{ID: 1, Team: "a"}, {ID: 3, Team: "a"}},
+		"b": {{ID: 2, Team: "b"}},
+	}, collection.GroupBy(users, func(u user) string { return u.Team }))
+
+	assert.Equal(t, map[string]user{
+		"a": {ID: 3, Team: "a"},
+		"b": {ID: 2, Team: "b"},
+	}, collection.KeyBy(users, func(u user) string { return u.Team }), "the last element wins")
+}
+
+func TestKeysValues(t *testing.T) {
+	m := map[string]int{"a": 1, "b": 2}
+	assert.ElementsMatch(t, []string{"a", "b"}, collection.Keys(m))
+	assert.ElementsMatch(t, []int{1, 2}, collection.Values(m))
+	assert.Equal(t, []string{}, collection.Keys(map[string]int(nil)))
+}
+
+func TestSetOperations(t *testing.T) {
+	tests := []struct {
+		name         string
+		a, b         []int
+		difference   []int
+		intersection []int
+	}{
+		{name: "Overlap", a: []int{1, 2, 3, 4}, b: []int{4, 2, 6}, difference: []int{1, 3}, intersection: []int{2, 4}},
+		{name: "Duplicates", a: []int{1, 1, 2, 2}, b: []int{2}, difference: []int{1}, intersection: []int{2}},
+		{name: "No overlap", a: []int{1, 2}, b: []int{3}, difference: []int{1, 2}, intersection: []int{}},
+		{name: "Empty slices", a: nil, b: nil, difference: []int{}, intersection: []int{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.difference, collection.Difference(test.a, test.b))
+			assert.Equal(t, test.intersection, collection.Intersection(test.a, test.b))
+		})
+	}
+}
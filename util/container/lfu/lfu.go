@@ -0,0 +1,204 @@
+package lfu
+
+// options holds configuration options for the Cache.
+type options[K comparable, V any] struct {
+	onEvict func(key K, value V) // onEvict is called with the items evicted to make room.
+}
+
+// Option specifies Cache configuration options.
+type Option[K comparable, V any] func(*options[K, V])
+
+// WithOnEvict calls fn with the items evicted by Put to make room for another, e.g., to close them. It is not
+// called by Remove and Clear.
+func WithOnEvict[K comparable, V any](fn func(key K, value V)) Option[K, V] {
+	return func(opts *options[K, V]) {
+		opts.onEvict = fn
+	}
+}
+
+// entry is an item of the Cache, an element of the recency list of its frequency.
+type entry[K comparable, V any] struct {
+	key        K
+	value      V
+	bucket     *bucket[K, V]
+	prev, next *entry[K, V]
+}
+
+// bucket holds the items used count times, from the most to the least recently used, and is an element of the
+// list of the buckets of the Cache, sorted by count.
+type bucket[K comparable, V any] struct {
+	count      int
+	root       entry[K, V] // root is the sentinel of the items: root.next is the most recently used.
+	prev, next *bucket[K, V]
+}
+
+/*
+Cache is a fixed-capacity map evicting its least frequently used item to make room for a new one, the least
+recently used among them on ties, without expiry. Unlike an LRU, a scan of many keys used once does not evict the
+hot keys. All the operations are O(1). A Cache is not safe for concurrent use: guard it with a mutex when it is
+shared.
+
+Example usage:
+
+	limiters := lfu.New[string, *rate.Limiter](10000)
+
+	limiter, ok := limiters.Get(clientIP)
+	if !ok {
+		limiter = rate.NewLimiter(10, 20)
+		limiters.Put(clientIP, limiter)
+	}
+*/
+type Cache[K comparable, V any] struct {
+	capacity int
+	items    map[K]*entry[K, V]
+	// buckets is the sentinel of the list of the buckets holding items, from the lowest to the highest count.
+	buckets bucket[K, V]
+	opts    *options[K, V]
+}
+
+// New creates a Cache of up to capacity items. It panics if capacity is not positive.
+func New[K comparable, V any](capacity int, opts ...Option[K, V]) *Cache[K, V] {
+	if capacity <= 0 {
+		panic("lfu: capacity must be positive")
+	}
+	c := &Cache[K, V]{
+		capacity: capacity,
+		items:    make(map[K]*entry[K, V], capacity),
+		opts:     &options[K, V]{},
+	}
+	for _, opt := range opts {
+		opt(c.opts)
+	}
+	c.buckets.prev, c.buckets.next = &c.buckets, &c.buckets
+	return c
+}
+
+// Get returns the value of key, and counts a use of it.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	e, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.increment(e)
+	return e.value, true
+}
+
+// Peek returns the value of key, without counting a use of it.
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	e, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Count returns the number of uses of key, counting Put and Get, or 0 if it is not present.
+func (c *Cache[K, V]) Count(key K) int {
+	e, ok := c.items[key]
+	if !ok {
+		return 0
+	}
+	return e.bucket.count
+}
+
+// Put sets the value of key, and counts a use of it. If the cache is full, the least frequently used item is
+// evicted to make room, and Put reports whether it did.
+func (c *Cache[K, V]) Put(key K, value V) bool {
+	if e, ok := c.items[key]; ok {
+		e.value = value
+		c.increment(e)
+		return false
+	}
+	evicted := false
+	if len(c.items) >= c.capacity {
+		victim := c.buckets.next.root.prev
+		c.unlink(victim)
+		delete(c.items, victim.key)
+		if c.opts.onEvict != nil {
+			c.opts.onEvict(victim.key, victim.value)
+		}
+		evicted = true
+	}
+	first := c.buckets.next
+	if first == &c.buckets || first.count != 1 {
+		first = c.insertBucket(&c.buckets, 1)
+	}
+	e := &entry[K, V]{key: key, value: value}
+	c.items[key] = e
+	c.pushFront(first, e)
+	return evicted
+}
+
+// Remove removes key, and reports whether it was present.
+func (c *Cache[K, V]) Remove(key K) bool {
+	e, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	c.unlink(e)
+	delete(c.items, key)
+	return true
+}
+
+// Len returns the number of items.
+func (c *Cache[K, V]) Len() int {
+	return len(c.items)
+}
+
+// Keys returns the keys from the most to the least frequently used, the most recently used first on ties.
+func (c *Cache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.items))
+	for b := c.buckets.prev; b != &c.buckets; b = b.prev {
+		for e := b.root.next; e != &b.root; e = e.next {
+			keys = append(keys, e.key)
+		}
+	}
+	return keys
+}
+
+// Clear removes all the items.
+func (c *Cache[K, V]) Clear() {
+	clear(c.items)
+	c.buckets.prev, c.buckets.next = &c.buckets, &c.buckets
+}
+
+// increment moves e to the bucket of the next count, created if needed.
+func (c *Cache[K, V]) increment(e *entry[K, V]) {
+	current := e.bucket
+	next := current.next
+	if next == &c.buckets || next.count != current.count+1 {
+		next = c.insertBucket(current, current.count+1)
+	}
+	c.unlink(e)
+	c.pushFront(next, e)
+}
+
+// insertBucket inserts an empty bucket of count after prev.
+func (c *Cache[K, V]) insertBucket(prev *bucket[K, V], count int) *bucket[K, V] {
+	b := &bucket[K, V]{count: count, prev: prev, next: prev.next}
+	b.root.prev, b.root.next = &b.root, &b.root
+	prev.next.prev = b
+	prev.next = b
+	return b
+}
+
+func (c *Cache[K, V]) pushFront(b *bucket[K, V], e *entry[K, V]) {
+	e.bucket = b
+	e.prev, e.next = &b.root, b.root.next
+	b.root.next.prev = e
+	b.root.next = e
+}
+
+// unlink removes e from its bucket, and removes the bucket if it is left empty.
+func (c *Cache[K, V]) unlink(e *entry[K, V]) {
+	b := e.bucket
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.prev, e.next, e.bucket = nil, nil, nil
+	if b.root.next == &b.root {
+		b.prev.next = b.next
+		b.next.prev = b.prev
+	}
+}
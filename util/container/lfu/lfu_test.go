@@ -0,0 +1,85 @@
+package lfu_test
+
+import (
+	"testing"
+
+	"github.com/kittipat1413/go-common/util/container/lfu"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	var evicted []string
+	c := lfu.New(3, lfu.WithOnEvict(func(key string, value int) {
+		evicted = append(evicted, key)
+	}))
+
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	assert.Equal(t, 3, c.Count("a"))
+	assert.Equal(t, 2, c.Count("b"))
+	assert.Equal(t, 1, c.Count("c"))
+	assert.Equal(t, []string{"a", "b", "c"}, c.Keys())
+
+	value, ok := c.Peek("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+	assert.Equal(t, 1, c.Count("c"), "Peek does not count a use")
+
+	assert.True(t, c.Put("d", 4))
+	assert.Equal(t, []string{"c"}, evicted, "the least frequently used item is evicted")
+	assert.Zero(t, c.Count("c"))
+
+	// On ties, the least recently used item is evicted.
+	c.Get("d")
+	assert.True(t, c.Put("e", 5))
+	assert.Equal(t, []string{"c", "b"}, evicted, "b and d were used twice, b less recently")
+	assert.Equal(t, []string{"a", "d", "e"}, c.Keys())
+
+	assert.False(t, c.Put("e", 50), "an update does not evict")
+	value, _ = c.Get("e")
+	assert.Equal(t, 50, value)
+	assert.Equal(t, 3, c.Count("e"))
+
+	assert.True(t, c.Remove("a"))
+	assert.False(t, c.Remove("a"))
+	assert.Equal(t, 2, c.Len())
+	assert.Len(t, evicted, 2, "Remove does not call the callback")
+
+	c.Clear()
+	assert.Zero(t, c.Len())
+	assert.Empty(t, c.Keys())
+	c.Put("f", 6)
+	assert.Equal(t, []string{"f"}, c.Keys())
+
+	assert.Panics(t, func() { lfu.New[string, int](0) })
+}
+
+func TestCache_Scan(t *testing.T) {
+	c := lfu.New[int, int](10)
+	for i := range 5 {
+		c.Put(i, i)
+		c.Get(i)
+	}
+	// A scan of keys used once does not evict the hot keys.
+	for i := 100; i < 200; i++ {
+		c.Put(i, i)
+	}
+	for i := range 5 {
+		_, ok := c.Peek(i)
+		assert.True(t, ok, i)
+	}
+	assert.Equal(t, 10, c.Len())
+}
+
+func BenchmarkCache(b *testing.B) {
+	c := lfu.New[int, int](1000)
+	for i := 0; i < b.N; i++ {
+		if _, ok := c.Get(i % 2000); !ok {
+			c.Put(i%2000, i)
+		}
+	}
+}
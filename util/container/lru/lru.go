@@ -0,0 +1,159 @@
+package lru
+
+// options holds configuration options for the Cache.
+type options[K comparable, V any] struct {
+	onEvict func(key K, value V) // onEvict is called with the items evicted to make room.
+}
+
+// Option specifies Cache configuration options.
+type Option[K comparable, V any] func(*options[K, V])
+
+// WithOnEvict calls fn with the items evicted by Put to make room for another, e.g., to close them. It is not
+// called by Remove and Clear.
+func WithOnEvict[K comparable, V any](fn func(key K, value V)) Option[K, V] {
+	return func(opts *options[K, V]) {
+		opts.onEvict = fn
+	}
+}
+
+// entry is an item of the Cache, an element of its recency list.
+type entry[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next *entry[K, V]
+}
+
+/*
+Cache is a fixed-capacity map evicting its least recently used item to make room for a new one, without expiry.
+All the operations are O(1). A Cache is not safe for concurrent use: guard it with a mutex when it is shared.
+
+Example usage:
+
+	keys := lru.New[string, *rsa.PublicKey](100, lru.WithOnEvict(func(kid string, _ *rsa.PublicKey) {
+		log.Debug(ctx, "JWKS key evicted", logger.Fields{"kid": kid})
+	}))
+
+	keys.Put("key-2024", publicKey)
+	if key, ok := keys.Get(kid); ok {
+		...
+	}
+*/
+type Cache[K comparable, V any] struct {
+	capacity int
+	items    map[K]*entry[K, V]
+	root     entry[K, V] // root is the sentinel of the recency list: root.next is the most recently used item.
+	opts     *options[K, V]
+}
+
+// New creates a Cache of up to capacity items. It panics if capacity is not positive.
+func New[K comparable, V any](capacity int, opts ...Option[K, V]) *Cache[K, V] {
+	if capacity <= 0 {
+		panic("lru: capacity must be positive")
+	}
+	c := &Cache[K, V]{
+		capacity: capacity,
+		items:    make(map[K]*entry[K, V], capacity),
+		opts:     &options[K, V]{},
+	}
+	for _, opt := range opts {
+		opt(c.opts)
+	}
+	c.root.prev, c.root.next = &c.root, &c.root
+	return c
+}
+
+// Get returns the value of key, and marks it as the most recently used.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	e, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.moveToFront(e)
+	return e.value, true
+}
+
+// Peek returns the value of key, without marking it as used.
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	e, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Put sets the value of key, and marks it as the most recently used. If the cache is full, the least recently used
+// item is evicted to make room, and Put reports whether it did.
+func (c *Cache[K, V]) Put(key K, value V) bool {
+	if e, ok := c.items[key]; ok {
+		e.value = value
+		c.moveToFront(e)
+		return false
+	}
+	evicted := false
+	if len(c.items) >= c.capacity {
+		oldest := c.root.prev
+		c.unlink(oldest)
+		delete(c.items, oldest.key)
+		if c.opts.onEvict != nil {
+			c.opts.onEvict(oldest.key, oldest.value)
+		}
+		evicted = true
+	}
+	e := &entry[K, V]{key: key, value: value}
+	c.items[key] = e
+	c.pushFront(e)
+	return evicted
+}
+
+// Remove removes key, and reports whether it was present.
+func (c *Cache[K, V]) Remove(key K) bool {
+	e, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	c.unlink(e)
+	delete(c.items, key)
+	return true
+}
+
+// Len returns the number of items.
+func (c *Cache[K, V]) Len() int {
+	return len(c.items)
+}
+
+// Keys returns the keys from the most to the least recently used.
+func (c *Cache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.items))
+	for e := c.root.next; e != &c.root; e = e.next {
+		keys = append(keys, e.key)
+	}
+	return keys
+}
+
+// Clear removes all the items.
+func (c *Cache[K, V]) Clear() {
+	clear(c.items)
+	c.root.prev, c.root.next = &c.root, &c.root
+}
+
+func (c *Cache[K, V]) moveToFront(e *entry[K, V]) {
+	if c.root.next == e {
+		return
+	}
+	c.unlink(e)
+	c.pushFront(e)
+}
+
+func (c *Cache[K, V]) pushFront(e *entry[K, V]) {
+	e.prev, e.next = &c.root, c.root.next
+	c.root.next.prev = e
+	c.root.next = e
+}
+
+func (c *Cache[K, V]) unlink(e *entry[K, V]) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.prev, e.next = nil, nil
+}
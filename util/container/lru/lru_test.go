@@ -0,0 +1,62 @@
+package lru_test
+
+import (
+	"testing"
+
+	"github.com/kittipat1413/go-common/util/container/lru"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	var evicted []string
+	c := lru.New(3, lru.WithOnEvict(func(key string, value int) {
+		evicted = append(evicted, key)
+	}))
+
+	assert.False(t, c.Put("a", 1))
+	assert.False(t, c.Put("b", 2))
+	assert.False(t, c.Put("c", 3))
+	assert.Equal(t, []string{"c", "b", "a"}, c.Keys())
+
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, []string{"a", "c", "b"}, c.Keys(), "Get marks the item as used")
+
+	value, ok = c.Peek("b")
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, []string{"a", "c", "b"}, c.Keys(), "Peek does not")
+
+	assert.True(t, c.Put("d", 4))
+	assert.Equal(t, []string{"b"}, evicted, "the least recently used item is evicted")
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	assert.False(t, c.Put("c", 30), "an update does not evict")
+	value, _ = c.Peek("c")
+	assert.Equal(t, 30, value)
+	assert.Equal(t, []string{"c", "d", "a"}, c.Keys())
+
+	assert.True(t, c.Remove("d"))
+	assert.False(t, c.Remove("d"))
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, []string{"b"}, evicted, "Remove does not call the callback")
+
+	c.Clear()
+	assert.Zero(t, c.Len())
+	assert.Empty(t, c.Keys())
+	c.Put("e", 5)
+	assert.Equal(t, []string{"e"}, c.Keys())
+
+	assert.Panics(t, func() { lru.New[string, int](0) })
+}
+
+func BenchmarkCache(b *testing.B) {
+	c := lru.New[int, int](1000)
+	for i := 0; i < b.N; i++ {
+		if _, ok := c.Get(i % 2000); !ok {
+			c.Put(i%2000, i)
+		}
+	}
+}
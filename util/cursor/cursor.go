@@ -0,0 +1,289 @@
+package cursor
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kittipat1413/go-common/util/clock"
+	"github.com/ugorji/go/codec"
+)
+
+// MinSecretLength is the minimum length of the secrets of a Codec.
+const MinSecretLength = 32
+
+// formatVersion is the version of the format of the cursors, the first byte of their plaintext: the format
+// version, the payload version as a uvarint, the expiry in Unix milliseconds as a varint (0 if the cursor does
+// not expire), and the MessagePack encoding of the payload.
+const formatVersion byte = 1
+
+var (
+	// ErrSecretTooShort is returned by New when a secret is shorter than MinSecretLength.
+	ErrSecretTooShort = errors.New("cursor: the secret must be at least 32 bytes")
+	// ErrInvalid is returned by Decode when the cursor is malformed, or was not encoded with one of the secrets of
+	// the Codec.
+	ErrInvalid = errors.New("cursor: invalid cursor")
+	// ErrExpired is returned by Decode when the cursor is past its WithTTL lifetime.
+	ErrExpired = errors.New("cursor: expired cursor")
+	// ErrVersionMismatch is returned by Decode when the cursor was encoded with another WithVersion version.
+	ErrVersionMismatch = errors.New("cursor: version mismatch")
+)
+
+// msgpackHandle configures the MessagePack encoding of the payloads. Struct fields are encoded as maps keyed by
+// field name, named after their `codec`, then `json` tag.
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// options holds configuration options for the Codec.
+type options struct {
+	encrypt         bool          // encrypt seals the cursors with AES-256-GCM instead of signing them.
+	ttl             time.Duration // ttl is the lifetime of the cursors, or 0 if they do not expire.
+	version         uint64        // version is the version of the payloads.
+	previousSecrets [][]byte      // previousSecrets are the secrets still accepted by Decode.
+	clock           clock.Clock   // clock tells the time of the expiries.
+}
+
+// Option specifies Codec configuration options.
+type Option func(*options)
+
+// WithEncryption encrypts the cursors with AES-256-GCM, so that the clients cannot read the payloads either, e.g.,
+// when they hold internal IDs or filters. By default, the cursors are signed with HMAC-SHA256 only. The cursors of
+// one mode cannot be decoded in the other.
+func WithEncryption() Option {
+	return func(opts *options) {
+		opts.encrypt = true
+	}
+}
+
+// WithTTL makes the cursors expire d after they are encoded, e.g., so that the clients cannot keep paging through
+// a snapshot for days. By default, the cursors do not expire.
+func WithTTL(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.ttl = d
+		}
+	}
+}
+
+// WithVersion sets the version of the payloads, 0 by default. Bump it when the payload changes incompatibly, e.g.,
+// when the sort keys of a list change: Decode then returns ErrVersionMismatch for the cursors of the former
+// version instead of decoding them into the wrong fields.
+func WithVersion(v uint64) Option {
+	return func(opts *options) {
+		opts.version = v
+	}
+}
+
+// WithPreviousSecrets accepts the cursors of former secrets in Decode, to rotate the secret without invalidating
+// the cursors already handed out. The cursors are always encoded with the current secret.
+func WithPreviousSecrets(secrets ...[]byte) Option {
+	return func(opts *options) {
+		opts.previousSecrets = append(opts.previousSecrets, secrets...)
+	}
+}
+
+// WithClock sets the clock of the expiries, clock.Real() by default.
+func WithClock(c clock.Clock) Option {
+	return func(opts *options) {
+		if c != nil {
+			opts.clock = c
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{clock: clock.Real()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+/*
+Codec encodes payloads T, e.g., the sort keys of a list, into opaque cursors safe to hand out to clients, and
+decodes them. The payloads are encoded with MessagePack, and the cursors are signed with HMAC-SHA256, or encrypted
+with AES-256-GCM with WithEncryption, so that the clients cannot forge them: the decoded payloads can be used in
+the queries as is. The cursors are URL-safe.
+
+Example usage:
+
+	type orderKeys struct {
+		CreatedAt time.Time `json:"c"`
+		ID        string    `json:"i"`
+	}
+
+	codec, err := cursor.New[orderKeys]([]byte(cfg.CursorSecret),
+		cursor.WithEncryption(),
+		cursor.WithTTL(24*time.Hour),
+	)
+	if err != nil {
+		return err
+	}
+
+	next, err := codec.Encode(orderKeys{CreatedAt: last.CreatedAt, ID: last.ID})
+	...
+	keys, err := codec.Decode(r.URL.Query().Get("cursor"))
+	if errors.Is(err, cursor.ErrExpired) {
+		// Restart from the first page
+	}
+*/
+type Codec[T any] struct {
+	keys []codecKey // keys are the keys of the secrets, the current one first.
+	opts *options
+}
+
+// codecKey holds the keys derived from a secret.
+type codecKey struct {
+	mac  []byte
+	aead cipher.AEAD
+}
+
+// New creates a Codec of the payloads T keyed with secret, which must be at least MinSecretLength bytes long, and
+// the same for all the instances of a service.
+func New[T any](secret []byte, opts ...Option) (*Codec[T], error) {
+	o := newOptions(opts)
+	c := &Codec[T]{opts: o}
+	for _, s := range append([][]byte{secret}, o.previousSecrets...) {
+		if len(s) < MinSecretLength {
+			return nil, ErrSecretTooShort
+		}
+		key, err := deriveKey(s)
+		if err != nil {
+			return nil, err
+		}
+		c.keys = append(c.keys, key)
+	}
+	return c, nil
+}
+
+// deriveKey derives the HMAC and the AES-256 keys of a secret, so that the secret is never used as is.
+func deriveKey(secret []byte) (codecKey, error) {
+	block, err := aes.NewCipher(derive(secret, "cursor/aes-256-gcm"))
+	if err != nil {
+		return codecKey{}, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return codecKey{}, err
+	}
+	return codecKey{mac: derive(secret, "cursor/hmac-sha256"), aead: aead}, nil
+}
+
+// derive returns the HMAC-SHA256 of label keyed with secret.
+func derive(secret []byte, label string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+// Encode encodes payload into an opaque cursor.
+func (c *Codec[T]) Encode(payload T) (string, error) {
+	var expiresAt int64
+	if c.opts.ttl > 0 {
+		expiresAt = c.opts.clock.Now().Add(c.opts.ttl).UnixMilli()
+	}
+	plaintext := []byte{formatVersion}
+	plaintext = binary.AppendUvarint(plaintext, c.opts.version)
+	plaintext = binary.AppendVarint(plaintext, expiresAt)
+	var encoded []byte
+	if err := codec.NewEncoderBytes(&encoded, msgpackHandle).Encode(payload); err != nil {
+		return "", fmt.Errorf("cursor: encoding the payload: %w", err)
+	}
+	plaintext = append(plaintext, encoded...)
+
+	key := c.keys[0]
+	if c.opts.encrypt {
+		nonce := make([]byte, key.aead.NonceSize(), key.aead.NonceSize()+len(plaintext)+key.aead.Overhead())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		return base64.RawURLEncoding.EncodeToString(key.aead.Seal(nonce, nonce, plaintext, nil)), nil
+	}
+	return base64.RawURLEncoding.EncodeToString(plaintext) + "." +
+		base64.RawURLEncoding.EncodeToString(sign(key.mac, plaintext)), nil
+}
+
+// Decode decodes a cursor encoded by Encode. It returns ErrInvalid if s is malformed or was not encoded with one
+// of the secrets of c, ErrVersionMismatch if it was encoded with another version, and ErrExpired if it expired.
+func (c *Codec[T]) Decode(s string) (T, error) {
+	var payload T
+	plaintext, ok := c.open(s)
+	if !ok || len(plaintext) == 0 || plaintext[0] != formatVersion {
+		return payload, ErrInvalid
+	}
+	rest := plaintext[1:]
+	version, n := binary.Uvarint(rest)
+	if n <= 0 {
+		return payload, ErrInvalid
+	}
+	rest = rest[n:]
+	expiresAt, n := binary.Varint(rest)
+	if n <= 0 {
+		return payload, ErrInvalid
+	}
+	rest = rest[n:]
+
+	if version != c.opts.version {
+		return payload, fmt.Errorf("%w: got %d, want %d", ErrVersionMismatch, version, c.opts.version)
+	}
+	if expiresAt != 0 && !c.opts.clock.Now().Before(time.UnixMilli(expiresAt)) {
+		return payload, ErrExpired
+	}
+	if err := codec.NewDecoderBytes(rest, msgpackHandle).Decode(&payload); err != nil {
+		return payload, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	return payload, nil
+}
+
+// open returns the plaintext of a cursor, verified or decrypted with the keys of c in turn.
+func (c *Codec[T]) open(s string) ([]byte, bool) {
+	if c.opts.encrypt {
+		sealed, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, false
+		}
+		for _, key := range c.keys {
+			nonceSize := key.aead.NonceSize()
+			if len(sealed) < nonceSize {
+				return nil, false
+			}
+			if plaintext, err := key.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil); err == nil {
+				return plaintext, true
+			}
+		}
+		return nil, false
+	}
+
+	encodedPlaintext, encodedSignature, ok := strings.Cut(s, ".")
+	if !ok {
+		return nil, false
+	}
+	plaintext, err := base64.RawURLEncoding.DecodeString(encodedPlaintext)
+	if err != nil {
+		return nil, false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, false
+	}
+	for _, key := range c.keys {
+		if hmac.Equal(signature, sign(key.mac, plaintext)) {
+			return plaintext, true
+		}
+	}
+	return nil, false
+}
+
+// sign returns the HMAC-SHA256 of plaintext keyed with key.
+func sign(key, plaintext []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(plaintext)
+	return mac.Sum(nil)
+}
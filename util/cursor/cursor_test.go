@@ -0,0 +1,181 @@
+package cursor_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/util/clock"
+	"github.com/kittipat1413/go-common/util/cursor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderKeys struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+	Filters   []string  `json:"f,omitempty"`
+}
+
+var (
+	secret      = []byte("0123456789abcdef0123456789abcdef")
+	otherSecret = []byte("fedcba9876543210fedcba9876543210")
+	keys        = orderKeys{CreatedAt: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), ID: "order-42", Filters: []string{"paid"}}
+)
+
+func TestNew_SecretTooShort(t *testing.T) {
+	_, err := cursor.New[orderKeys]([]byte("secret"))
+	assert.ErrorIs(t, err, cursor.ErrSecretTooShort)
+
+	_, err = cursor.New[orderKeys](secret, cursor.WithPreviousSecrets([]byte("secret")))
+	assert.ErrorIs(t, err, cursor.ErrSecretTooShort)
+}
+
+func TestCodec_EncodeDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []cursor.Option
+	}{
+		{name: "signed"},
+		{name: "encrypted", options: []cursor.Option{cursor.WithEncryption()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := cursor.New[orderKeys](secret, tt.options...)
+			require.NoError(t, err)
+
+			encoded, err := codec.Encode(keys)
+			require.NoError(t, err)
+			assert.NotContains(t, encoded, "=", "the cursors are safe in URLs")
+			assert.NotContains(t, encoded, "+")
+			assert.NotContains(t, encoded, "/")
+
+			decoded, err := codec.Decode(encoded)
+			require.NoError(t, err)
+			assert.Equal(t, keys, decoded)
+		})
+	}
+}
+
+func TestCodec_Encryption(t *testing.T) {
+	signed, err := cursor.New[orderKeys](secret)
+	require.NoError(t, err)
+	encrypted, err := cursor.New[orderKeys](secret, cursor.WithEncryption())
+	require.NoError(t, err)
+
+	encoded, err := signed.Encode(keys)
+	require.NoError(t, err)
+	plaintext, err := base64.RawURLEncoding.DecodeString(strings.Split(encoded, ".")[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(plaintext), "order-42", "the signed cursors are readable")
+
+	encoded, err = encrypted.Encode(keys)
+	require.NoError(t, err)
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "order-42", "the encrypted cursors are not")
+
+	again, err := encrypted.Encode(keys)
+	require.NoError(t, err)
+	assert.NotEqual(t, encoded, again, "the nonces are random")
+
+	_, err = signed.Decode(encoded)
+	assert.ErrorIs(t, err, cursor.ErrInvalid, "the modes are not interchangeable")
+}
+
+func TestCodec_DecodeInvalid(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		var opts []cursor.Option
+		if encrypt {
+			opts = append(opts, cursor.WithEncryption())
+		}
+		codec, err := cursor.New[orderKeys](secret, opts...)
+		require.NoError(t, err)
+		otherCodec, err := cursor.New[orderKeys](otherSecret, opts...)
+		require.NoError(t, err)
+
+		encoded, err := codec.Encode(keys)
+		require.NoError(t, err)
+		otherEncoded, err := otherCodec.Encode(keys)
+		require.NoError(t, err)
+		raw, err := base64.RawURLEncoding.DecodeString(strings.Split(encoded, ".")[0])
+		require.NoError(t, err)
+		raw[len(raw)-1] ^= 1
+		tampered := base64.RawURLEncoding.EncodeToString(raw)
+		if !encrypt {
+			tampered += encoded[strings.Index(encoded, "."):]
+		}
+
+		for name, s := range map[string]string{
+			"empty":        "",
+			"malformed":    "!!.!!",
+			"truncated":    encoded[:10],
+			"tampered":     tampered,
+			"other secret": otherEncoded,
+		} {
+			_, err := codec.Decode(s)
+			assert.ErrorIs(t, err, cursor.ErrInvalid, "encrypt=%v %s", encrypt, name)
+		}
+	}
+}
+
+func TestCodec_TTL(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC))
+	codec, err := cursor.New[orderKeys](secret, cursor.WithTTL(time.Hour), cursor.WithClock(fake))
+	require.NoError(t, err)
+
+	encoded, err := codec.Encode(keys)
+	require.NoError(t, err)
+
+	fake.Advance(time.Hour - time.Millisecond)
+	_, err = codec.Decode(encoded)
+	assert.NoError(t, err)
+
+	fake.Advance(time.Millisecond)
+	_, err = codec.Decode(encoded)
+	assert.ErrorIs(t, err, cursor.ErrExpired)
+
+	// The cursors without TTL do not expire.
+	noTTL, err := cursor.New[orderKeys](secret, cursor.WithClock(fake))
+	require.NoError(t, err)
+	encoded, err = noTTL.Encode(keys)
+	require.NoError(t, err)
+	fake.Advance(365 * 24 * time.Hour)
+	_, err = noTTL.Decode(encoded)
+	assert.NoError(t, err)
+}
+
+func TestCodec_Version(t *testing.T) {
+	v1, err := cursor.New[orderKeys](secret, cursor.WithVersion(1))
+	require.NoError(t, err)
+	v2, err := cursor.New[orderKeys](secret, cursor.WithVersion(2))
+	require.NoError(t, err)
+
+	encoded, err := v1.Encode(keys)
+	require.NoError(t, err)
+	_, err = v2.Decode(encoded)
+	assert.ErrorIs(t, err, cursor.ErrVersionMismatch)
+	_, err = v1.Decode(encoded)
+	assert.NoError(t, err)
+}
+
+func TestCodec_PreviousSecrets(t *testing.T) {
+	for _, opts := range [][]cursor.Option{nil, {cursor.WithEncryption()}} {
+		old, err := cursor.New[orderKeys](otherSecret, opts...)
+		require.NoError(t, err)
+		rotated, err := cursor.New[orderKeys](secret, append(opts, cursor.WithPreviousSecrets(otherSecret))...)
+		require.NoError(t, err)
+
+		encoded, err := old.Encode(keys)
+		require.NoError(t, err)
+		decoded, err := rotated.Decode(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, keys, decoded)
+
+		encoded, err = rotated.Encode(keys)
+		require.NoError(t, err)
+		_, err = old.Decode(encoded)
+		assert.ErrorIs(t, err, cursor.ErrInvalid, "the cursors are encoded with the current secret")
+	}
+}
@@ -0,0 +1,111 @@
+package keyedmutex
+
+import (
+	"sync"
+	"time"
+)
+
+// entry is the lock of a key, with the number of goroutines holding or waiting for it.
+type entry struct {
+	sem  chan struct{} // sem holds a value while the key is locked.
+	refs int
+}
+
+/*
+Mutex is a mutual exclusion lock per key, e.g., to serialize the updates of the balance of each user within a
+process, without serializing the updates of different users behind a global mutex. The lock of a key is removed once
+it is neither held nor waited for, so that the idle keys use no memory.
+
+The zero value is an unlocked Mutex. A Mutex must not be copied after first use.
+
+Example usage:
+
+	var balances keyedmutex.Mutex[string]
+
+	func credit(userID string, amount int64) {
+		balances.Lock(userID)
+		defer balances.Unlock(userID)
+		// Read, update and write the balance of the user
+	}
+*/
+type Mutex[K comparable] struct {
+	mutex   sync.Mutex
+	entries map[K]*entry
+}
+
+// Lock locks key, blocking until it is available.
+func (m *Mutex[K]) Lock(key K) {
+	m.acquire(key).sem <- struct{}{}
+}
+
+// TryLock locks key, waiting at most timeout for it to be available, and reports whether it did. A timeout of 0 or
+// less does not wait.
+func (m *Mutex[K]) TryLock(key K, timeout time.Duration) bool {
+	e := m.acquire(key)
+	if timeout <= 0 {
+		select {
+		case e.sem <- struct{}{}:
+			return true
+		default:
+		}
+	} else {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case e.sem <- struct{}{}:
+			return true
+		case <-timer.C:
+		}
+	}
+	m.release(key, e)
+	return false
+}
+
+// Unlock unlocks key. It panics if key is not locked.
+func (m *Mutex[K]) Unlock(key K) {
+	m.mutex.Lock()
+	e := m.entries[key]
+	m.mutex.Unlock()
+	if e == nil {
+		panic("keyedmutex: unlock of unlocked key")
+	}
+	select {
+	case <-e.sem:
+	default:
+		panic("keyedmutex: unlock of unlocked key")
+	}
+	m.release(key, e)
+}
+
+// Len returns the number of keys locked or waited for.
+func (m *Mutex[K]) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.entries)
+}
+
+// acquire returns the entry of key, created if needed, counting a reference to it.
+func (m *Mutex[K]) acquire(key K) *entry {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.entries == nil {
+		m.entries = make(map[K]*entry)
+	}
+	e, ok := m.entries[key]
+	if !ok {
+		e = &entry{sem: make(chan struct{}, 1)}
+		m.entries[key] = e
+	}
+	e.refs++
+	return e
+}
+
+// release removes a reference to the entry of key, and removes the entry once it has none.
+func (m *Mutex[K]) release(key K, e *entry) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	e.refs--
+	if e.refs == 0 {
+		delete(m.entries, key)
+	}
+}
@@ -0,0 +1,72 @@
+package keyedmutex_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/util/keyedmutex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutex_Lock(t *testing.T) {
+	var m keyedmutex.Mutex[string]
+	balances := map[string]int{}
+	var balancesMutex sync.Mutex
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user := []string{"alice", "bob"}[i%2]
+			m.Lock(user)
+			defer m.Unlock(user)
+
+			// The read and the write of a balance are not atomic, but serialized per user.
+			balancesMutex.Lock()
+			balance := balances[user]
+			balancesMutex.Unlock()
+			time.Sleep(time.Microsecond)
+			balancesMutex.Lock()
+			balances[user] = balance + 1
+			balancesMutex.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[string]int{"alice": 50, "bob": 50}, balances)
+	assert.Zero(t, m.Len(), "the idle keys are removed")
+}
+
+func TestMutex_TryLock(t *testing.T) {
+	var m keyedmutex.Mutex[int]
+
+	assert.True(t, m.TryLock(1, 0))
+	assert.False(t, m.TryLock(1, 0))
+	assert.True(t, m.TryLock(2, 0), "the keys are locked independently")
+
+	start := time.Now()
+	assert.False(t, m.TryLock(1, 20*time.Millisecond))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Equal(t, 2, m.Len(), "the keys failing to lock are not kept")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.Unlock(1)
+	}()
+	assert.True(t, m.TryLock(1, time.Second), "the key is locked once unlocked")
+
+	m.Unlock(1)
+	m.Unlock(2)
+	assert.Zero(t, m.Len())
+}
+
+func TestMutex_Unlock(t *testing.T) {
+	var m keyedmutex.Mutex[string]
+	assert.PanicsWithValue(t, "keyedmutex: unlock of unlocked key", func() { m.Unlock("alice") })
+
+	m.Lock("alice")
+	m.Unlock("alice")
+	assert.Panics(t, func() { m.Unlock("alice") })
+}
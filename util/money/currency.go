@@ -0,0 +1,68 @@
+package money
+
+// Currency is an ISO 4217 currency code, e.g., "THB".
+type Currency string
+
+// Currencies of the ISO 4217 standard known by the package.
+const (
+	AED Currency = "AED"
+	AUD Currency = "AUD"
+	BHD Currency = "BHD"
+	BRL Currency = "BRL"
+	CAD Currency = "CAD"
+	CHF Currency = "CHF"
+	CNY Currency = "CNY"
+	CZK Currency = "CZK"
+	DKK Currency = "DKK"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	HKD Currency = "HKD"
+	HUF Currency = "HUF"
+	IDR Currency = "IDR"
+	ILS Currency = "ILS"
+	INR Currency = "INR"
+	JOD Currency = "JOD"
+	JPY Currency = "JPY"
+	KHR Currency = "KHR"
+	KRW Currency = "KRW"
+	KWD Currency = "KWD"
+	LAK Currency = "LAK"
+	MMK Currency = "MMK"
+	MXN Currency = "MXN"
+	MYR Currency = "MYR"
+	NOK Currency = "NOK"
+	NZD Currency = "NZD"
+	OMR Currency = "OMR"
+	PHP Currency = "PHP"
+	PLN Currency = "PLN"
+	SAR Currency = "SAR"
+	SEK Currency = "SEK"
+	SGD Currency = "SGD"
+	THB Currency = "THB"
+	TRY Currency = "TRY"
+	TWD Currency = "TWD"
+	USD Currency = "USD"
+	VND Currency = "VND"
+	ZAR Currency = "ZAR"
+)
+
+// currencyDigits holds the number of digits after the decimal separator of the known currencies, the minor units
+// of ISO 4217.
+var currencyDigits = map[Currency]int{
+	AED: 2, AUD: 2, BHD: 3, BRL: 2, CAD: 2, CHF: 2, CNY: 2, CZK: 2, DKK: 2, EUR: 2,
+	GBP: 2, HKD: 2, HUF: 2, IDR: 2, ILS: 2, INR: 2, JOD: 3, JPY: 0, KHR: 2, KRW: 0,
+	KWD: 3, LAK: 2, MMK: 2, MXN: 2, MYR: 2, NOK: 2, NZD: 2, OMR: 3, PHP: 2, PLN: 2,
+	SAR: 2, SEK: 2, SGD: 2, THB: 2, TRY: 2, TWD: 2, USD: 2, VND: 0, ZAR: 2,
+}
+
+// IsValid reports whether c is a currency known by the package.
+func (c Currency) IsValid() bool {
+	_, ok := currencyDigits[c]
+	return ok
+}
+
+// Digits returns the number of digits after the decimal separator of c, e.g., 2 for THB (satang) and 0 for JPY.
+// It returns 0 for the currencies not known by the package.
+func (c Currency) Digits() int {
+	return currencyDigits[c]
+}
@@ -0,0 +1,350 @@
+package money
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnknownCurrency is returned when a currency is not known by the package.
+	ErrUnknownCurrency = errors.New("money: unknown currency")
+	// ErrCurrencyMismatch is returned when amounts of different currencies are combined or compared.
+	ErrCurrencyMismatch = errors.New("money: currency mismatch")
+	// ErrOverflow is returned when the result of an operation does not fit in an int64 of minor units.
+	ErrOverflow = errors.New("money: amount overflow")
+	// ErrInvalidAmount is returned when an amount cannot be parsed, or has more decimals than its currency.
+	ErrInvalidAmount = errors.New("money: invalid amount")
+	// ErrInvalidRatios is returned by Allocate and Split when the shares cannot be computed.
+	ErrInvalidRatios = errors.New("money: invalid ratios")
+)
+
+/*
+Money is an amount of a currency, stored as an integer number of minor units (e.g., satang for THB) so that the
+arithmetic is exact, unlike float64. Money values are immutable, and the operations combining two amounts fail with
+ErrCurrencyMismatch if their currencies differ.
+
+Example usage:
+
+	price, err := money.Parse("100.00", money.THB)
+	if err != nil {
+		return err
+	}
+	shipping, _ := money.New(4500, money.THB) // 45.00 THB
+	total, err := price.Add(shipping)
+	if err != nil {
+		return err
+	}
+
+	// Splits 145.00 THB between 3 payees without losing a satang: 48.34, 48.33 and 48.33 THB.
+	shares, err := total.Split(3)
+*/
+type Money struct {
+	amount   int64
+	currency Currency
+}
+
+// New returns the amount of minor units of currency, e.g., New(1050, money.THB) is 10.50 THB.
+func New(amount int64, currency Currency) (Money, error) {
+	if !currency.IsValid() {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return Money{amount: amount, currency: currency}, nil
+}
+
+// Parse parses a decimal amount of currency, e.g., "-10.50". The amount may have fewer decimals than the currency,
+// but not more, unless the extra ones are zeros: Parse("0.001", money.THB) fails rather than rounding.
+func Parse(amount string, currency Currency) (Money, error) {
+	if !currency.IsValid() {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	minor, err := parseDecimal(amount, currency.Digits())
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{amount: minor, currency: currency}, nil
+}
+
+// Amount returns the amount in minor units, e.g., 1050 for 10.50 THB.
+func (m Money) Amount() int64 {
+	return m.amount
+}
+
+// Currency returns the currency of the amount.
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+// IsPositive reports whether the amount is greater than zero.
+func (m Money) IsPositive() bool {
+	return m.amount > 0
+}
+
+// IsNegative reports whether the amount is lower than zero.
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+// Add returns m + o.
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+	sum := m.amount + o.amount
+	if (o.amount > 0 && sum < m.amount) || (o.amount < 0 && sum > m.amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: sum, currency: m.currency}, nil
+}
+
+// Sub returns m - o.
+func (m Money) Sub(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+	diff := m.amount - o.amount
+	if (o.amount > 0 && diff > m.amount) || (o.amount < 0 && diff < m.amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: diff, currency: m.currency}, nil
+}
+
+// Multiply returns m * n, e.g., the price of n items.
+func (m Money) Multiply(n int64) (Money, error) {
+	product := m.amount * n
+	if m.amount != 0 && (product/m.amount != n || (m.amount == -1 && n == math.MinInt64)) {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: product, currency: m.currency}, nil
+}
+
+// Allocate splits m into shares proportional to ratios, e.g., Allocate(70, 30) for a 70/30 revenue split. The
+// shares always sum to m: the minor units left over by the rounding down of the shares go one by one to the shares
+// with the largest remainders, the first ones on ties. The ratios must not be negative, and one must be positive.
+func (m Money) Allocate(ratios ...int) ([]Money, error) {
+	var total uint64
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, fmt.Errorf("%w: negative ratio %d", ErrInvalidRatios, ratio)
+		}
+		next := total + uint64(ratio)
+		if next < total {
+			return nil, fmt.Errorf("%w: the ratios overflow", ErrInvalidRatios)
+		}
+		total = next
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("%w: no positive ratio", ErrInvalidRatios)
+	}
+
+	// The shares are computed on the absolute value of the amount, in 128 bits since amount * ratio may overflow.
+	// As ratio <= total, each share fits in 64 bits.
+	abs := uint64(m.amount)
+	if m.amount < 0 {
+		abs = -abs
+	}
+	shares := make([]uint64, len(ratios))
+	remainders := make([]uint64, len(ratios))
+	leftover := abs
+	for i, ratio := range ratios {
+		hi, lo := bits.Mul64(abs, uint64(ratio))
+		shares[i], remainders[i] = bits.Div64(hi, lo, total)
+		leftover -= shares[i]
+	}
+	// The remainders sum to leftover * total, so at least leftover shares have a remainder.
+	order := make([]int, len(ratios))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(remainders[b], remainders[a])
+	})
+	for _, i := range order[:leftover] {
+		shares[i]++
+	}
+
+	result := make([]Money, len(ratios))
+	for i, share := range shares {
+		amount := int64(share)
+		if m.amount < 0 {
+			amount = -amount
+		}
+		result[i] = Money{amount: amount, currency: m.currency}
+	}
+	return result, nil
+}
+
+// Split splits m into n shares differing by one minor unit at most, the first shares getting the units left over,
+// e.g., 100.00 THB into 33.34, 33.33 and 33.33 THB.
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: %d shares", ErrInvalidRatios, n)
+	}
+	ratios := make([]int, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+// Compare returns -1 if m is lower than o, 0 if they are equal, and +1 if m is greater than o.
+func (m Money) Compare(o Money) (int, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return 0, err
+	}
+	return cmp.Compare(m.amount, o.amount), nil
+}
+
+// Equal reports whether m and o have the same amount and currency.
+func (m Money) Equal(o Money) bool {
+	return m == o
+}
+
+// Decimal returns the amount as a decimal number with the digits of its currency, e.g., "10.50".
+func (m Money) Decimal() string {
+	abs := uint64(m.amount)
+	if m.amount < 0 {
+		abs = -abs
+	}
+	s := strconv.FormatUint(abs, 10)
+	if digits := m.currency.Digits(); digits > 0 {
+		if len(s) <= digits {
+			s = strings.Repeat("0", digits-len(s)+1) + s
+		}
+		s = s[:len(s)-digits] + "." + s[len(s)-digits:]
+	}
+	if m.amount < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// String returns the amount followed by its currency, e.g., "10.50 THB".
+func (m Money) String() string {
+	return m.Decimal() + " " + string(m.currency)
+}
+
+// moneyJSON is the JSON representation of Money. The amount is a string so that decoders do not read it as a
+// float.
+type moneyJSON struct {
+	Amount   string   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// MarshalJSON encodes m as {"amount":"10.50","currency":"THB"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.currency})
+}
+
+// UnmarshalJSON decodes the representation of MarshalJSON. The amount may also be a JSON number, which is parsed
+// as a decimal, never as a float.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Amount   json.RawMessage `json:"amount"`
+		Currency Currency        `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	amount := string(raw.Amount)
+	if strings.HasPrefix(amount, `"`) {
+		if err := json.Unmarshal(raw.Amount, &amount); err != nil {
+			return err
+		}
+	}
+	parsed, err := Parse(amount, raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, storing m as its String, e.g., "10.50 THB", in a text column. To store the amount
+// and the currency in separate columns, use Amount and Currency instead.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan implements sql.Scanner, reading the representation of Value.
+func (m *Money) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	amount, currency, ok := strings.Cut(s, " ")
+	if !ok {
+		return fmt.Errorf("%w: %q has no currency", ErrInvalidAmount, s)
+	}
+	parsed, err := Parse(amount, Currency(currency))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// checkCurrency returns ErrCurrencyMismatch if o is not of the currency of m.
+func (m Money) checkCurrency(o Money) error {
+	if m.currency != o.currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, o.currency)
+	}
+	return nil
+}
+
+// parseDecimal parses a decimal number into an integer number of minor units with the given digits.
+func parseDecimal(s string, digits int) (int64, error) {
+	unsigned, negative := strings.CutPrefix(s, "-")
+	if !negative {
+		unsigned = strings.TrimPrefix(s, "+")
+	}
+	intPart, fracPart, hasDot := strings.Cut(unsigned, ".")
+	if !isDigits(intPart) || (hasDot && !isDigits(fracPart)) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if len(fracPart) > digits {
+		if strings.Trim(fracPart[digits:], "0") != "" {
+			return 0, fmt.Errorf("%w: %q has more than %d decimals", ErrInvalidAmount, s, digits)
+		}
+		fracPart = fracPart[:digits]
+	}
+	minor := intPart + fracPart + strings.Repeat("0", digits-len(fracPart))
+	if negative {
+		minor = "-" + minor
+	}
+	amount, err := strconv.ParseInt(minor, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	return amount, nil
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
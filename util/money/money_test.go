@@ -0,0 +1,224 @@
+package money_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/kittipat1413/go-common/util/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mustParse parses an amount of currency, failing the test on error.
+func mustParse(t *testing.T, amount string, currency money.Currency) money.Money {
+	t.Helper()
+	m, err := money.Parse(amount, currency)
+	require.NoError(t, err)
+	return m
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount        string
+		currency      money.Currency
+		expectedMinor int64
+		expectedErr   error
+	}{
+		{amount: "10.50", currency: money.THB, expectedMinor: 1050},
+		{amount: "10.5", currency: money.THB, expectedMinor: 1050},
+		{amount: "10", currency: money.THB, expectedMinor: 1000},
+		{amount: "-0.05", currency: money.THB, expectedMinor: -5},
+		{amount: "+1.00", currency: money.THB, expectedMinor: 100},
+		{amount: "1.2300", currency: money.THB, expectedMinor: 123},
+		{amount: "1500", currency: money.JPY, expectedMinor: 1500},
+		{amount: "1.234", currency: money.KWD, expectedMinor: 1234},
+		{amount: "92233720368547758.07", currency: money.USD, expectedMinor: math.MaxInt64},
+		{amount: "-92233720368547758.08", currency: money.USD, expectedMinor: math.MinInt64},
+		{amount: "92233720368547758.08", currency: money.USD, expectedErr: money.ErrOverflow},
+		{amount: "0.001", currency: money.THB, expectedErr: money.ErrInvalidAmount},
+		{amount: "1.5", currency: money.JPY, expectedErr: money.ErrInvalidAmount},
+		{amount: "", currency: money.THB, expectedErr: money.ErrInvalidAmount},
+		{amount: ".5", currency: money.THB, expectedErr: money.ErrInvalidAmount},
+		{amount: "1.", currency: money.THB, expectedErr: money.ErrInvalidAmount},
+		{amount: "1,000", currency: money.THB, expectedErr: money.ErrInvalidAmount},
+		{amount: "+-1", currency: money.THB, expectedErr: money.ErrInvalidAmount},
+		{amount: "1e2", currency: money.THB, expectedErr: money.ErrInvalidAmount},
+		{amount: "1", currency: "XXX", expectedErr: money.ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.amount+" "+string(tt.currency), func(t *testing.T) {
+			m, err := money.Parse(tt.amount, tt.currency)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMinor, m.Amount())
+			assert.Equal(t, tt.currency, m.Currency())
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		currency money.Currency
+		expected string
+	}{
+		{amount: 1050, currency: money.THB, expected: "10.50 THB"},
+		{amount: -5, currency: money.THB, expected: "-0.05 THB"},
+		{amount: 0, currency: money.THB, expected: "0.00 THB"},
+		{amount: 1500, currency: money.JPY, expected: "1500 JPY"},
+		{amount: 1, currency: money.KWD, expected: "0.001 KWD"},
+		{amount: math.MinInt64, currency: money.USD, expected: "-92233720368547758.08 USD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			m, err := money.New(tt.amount, tt.currency)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, m.String())
+		})
+	}
+
+	_, err := money.New(1, "thb")
+	assert.ErrorIs(t, err, money.ErrUnknownCurrency)
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	price := mustParse(t, "100.00", money.THB)
+	shipping := mustParse(t, "45.50", money.THB)
+
+	total, err := price.Add(shipping)
+	require.NoError(t, err)
+	assert.Equal(t, "145.50 THB", total.String())
+
+	diff, err := shipping.Sub(price)
+	require.NoError(t, err)
+	assert.Equal(t, "-54.50 THB", diff.String())
+	assert.True(t, diff.IsNegative())
+
+	triple, err := shipping.Multiply(3)
+	require.NoError(t, err)
+	assert.Equal(t, "136.50 THB", triple.String())
+
+	cmp, err := price.Compare(shipping)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cmp)
+	assert.True(t, price.Equal(mustParse(t, "100", money.THB)))
+	assert.False(t, price.Equal(mustParse(t, "100", money.USD)))
+
+	// Amounts of different currencies are not combined.
+	_, err = price.Add(mustParse(t, "1", money.USD))
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+	_, err = price.Sub(mustParse(t, "1", money.USD))
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+	_, err = price.Compare(mustParse(t, "1", money.USD))
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	largest, _ := money.New(math.MaxInt64, money.THB)
+	smallest, _ := money.New(math.MinInt64, money.THB)
+	one, _ := money.New(1, money.THB)
+	minusOne, _ := money.New(-1, money.THB)
+	_, err = largest.Add(one)
+	assert.ErrorIs(t, err, money.ErrOverflow)
+	_, err = smallest.Sub(one)
+	assert.ErrorIs(t, err, money.ErrOverflow)
+	_, err = largest.Sub(minusOne)
+	assert.ErrorIs(t, err, money.ErrOverflow)
+	_, err = largest.Multiply(2)
+	assert.ErrorIs(t, err, money.ErrOverflow)
+	_, err = smallest.Multiply(-1)
+	assert.ErrorIs(t, err, money.ErrOverflow)
+	_, err = minusOne.Multiply(math.MinInt64)
+	assert.ErrorIs(t, err, money.ErrOverflow)
+}
+
+func TestMoney_Allocate(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		ratios   []int
+		expected []string
+	}{
+		{name: "even", amount: "100.00", ratios: []int{1, 1}, expected: []string{"50.00", "50.00"}},
+		{name: "leftover to the first shares", amount: "100.00", ratios: []int{1, 1, 1}, expected: []string{"33.34", "33.33", "33.33"}},
+		{name: "leftover to the largest remainder", amount: "0.10", ratios: []int{1, 99}, expected: []string{"0.00", "0.10"}},
+		{name: "percentages", amount: "0.05", ratios: []int{70, 30}, expected: []string{"0.04", "0.01"}},
+		{name: "zero ratio", amount: "1.00", ratios: []int{0, 1, 2}, expected: []string{"0.00", "0.33", "0.67"}},
+		{name: "negative amount", amount: "-100.00", ratios: []int{1, 1, 1}, expected: []string{"-33.34", "-33.33", "-33.33"}},
+		{name: "largest amount", amount: "92233720368547758.07", ratios: []int{math.MaxInt, math.MaxInt}, expected: []string{"46116860184273879.04", "46116860184273879.03"}},
+		{name: "smallest amount", amount: "-92233720368547758.08", ratios: []int{1, 1}, expected: []string{"-46116860184273879.04", "-46116860184273879.04"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mustParse(t, tt.amount, money.USD)
+			shares, err := m.Allocate(tt.ratios...)
+			require.NoError(t, err)
+
+			actual := make([]string, len(shares))
+			sum, _ := money.New(0, money.USD)
+			for i, share := range shares {
+				actual[i] = share.Decimal()
+				sum, err = sum.Add(share)
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, actual)
+			assert.Equal(t, m, sum, "no minor unit is lost")
+		})
+	}
+
+	m := mustParse(t, "1", money.USD)
+	for _, ratios := range [][]int{nil, {0, 0}, {1, -1}} {
+		_, err := m.Allocate(ratios...)
+		assert.ErrorIs(t, err, money.ErrInvalidRatios, ratios)
+	}
+}
+
+func TestMoney_Split(t *testing.T) {
+	shares, err := mustParse(t, "100", money.JPY).Split(3)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{34, 33, 33}, []int64{shares[0].Amount(), shares[1].Amount(), shares[2].Amount()})
+
+	_, err = mustParse(t, "100", money.JPY).Split(0)
+	assert.ErrorIs(t, err, money.ErrInvalidRatios)
+}
+
+func TestMoney_JSON(t *testing.T) {
+	type order struct {
+		Total money.Money `json:"total"`
+	}
+	data, err := json.Marshal(order{Total: mustParse(t, "10.50", money.THB)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"total":{"amount":"10.50","currency":"THB"}}`, string(data))
+
+	var decoded order
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, mustParse(t, "10.50", money.THB), decoded.Total)
+
+	// A number is parsed as a decimal, not as a float.
+	require.NoError(t, json.Unmarshal([]byte(`{"total":{"amount":0.3,"currency":"USD"}}`), &decoded))
+	assert.Equal(t, int64(30), decoded.Total.Amount())
+
+	err = json.Unmarshal([]byte(`{"total":{"amount":"0.001","currency":"USD"}}`), &decoded)
+	assert.ErrorIs(t, err, money.ErrInvalidAmount)
+	err = json.Unmarshal([]byte(`{"total":{"amount":"1","currency":"XXX"}}`), &decoded)
+	assert.ErrorIs(t, err, money.ErrUnknownCurrency)
+}
+
+func TestMoney_SQL(t *testing.T) {
+	m := mustParse(t, "-10.50", money.THB)
+	value, err := m.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "-10.50 THB", value)
+
+	var scanned money.Money
+	require.NoError(t, scanned.Scan([]byte("-10.50 THB")))
+	assert.Equal(t, m, scanned)
+	require.NoError(t, scanned.Scan("1500 JPY"))
+	assert.Equal(t, mustParse(t, "1500", money.JPY), scanned)
+
+	assert.ErrorIs(t, scanned.Scan("10.50"), money.ErrInvalidAmount)
+	assert.Error(t, scanned.Scan(nil))
+	assert.Error(t, scanned.Scan(10.5))
+}
@@ -0,0 +1,220 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kittipat1413/go-common/util/clock"
+)
+
+// options holds configuration options for the Pool.
+type options struct {
+	maxIdle        int           // maxIdle bounds the idle objects, or 0 to keep them in a sync.Pool.
+	shrinkInterval time.Duration // shrinkInterval is the interval of the shrinks of the idle objects, or 0.
+	clock          clock.Clock   // clock ticks the shrinks.
+}
+
+// Option specifies Pool configuration options.
+type Option func(*options)
+
+// WithMaxIdle keeps at most n idle objects, in a free list of the pool instead of a sync.Pool: the objects put back
+// beyond n are dropped. By default, the idle objects are kept in a sync.Pool, unbounded but released by the garbage
+// collector.
+func WithMaxIdle(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.maxIdle = n
+		}
+	}
+}
+
+// WithShrinkInterval drops the idle objects which were not needed during the last interval d, so that the pool
+// shrinks back after a burst. It applies with WithMaxIdle only, and runs a goroutine stopped by Close.
+func WithShrinkInterval(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.shrinkInterval = d
+		}
+	}
+}
+
+// WithClock sets the clock ticking the shrinks, clock.Real() by default.
+func WithClock(c clock.Clock) Option {
+	return func(opts *options) {
+		if c != nil {
+			opts.clock = c
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{clock: clock.Real()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Stats holds the usage metrics of a Pool.
+type Stats struct {
+	Gets    uint64 // Gets is the number of calls of Get.
+	Puts    uint64 // Puts is the number of calls of Put.
+	News    uint64 // News is the number of objects created because none was idle.
+	Dropped uint64 // Dropped is the number of objects dropped by WithMaxIdle and Shrink.
+	Idle    int    // Idle is the number of idle objects, with WithMaxIdle only.
+}
+
+/*
+Pool is a typed pool of reusable objects, e.g., buffers or request-scoped scratch structs of hot paths, to save
+their allocations. Objects are created by newFn when none is idle, and reset by reset when they are put back, so
+that Get always returns a clean object. T should be a pointer type, since storing other types in a sync.Pool
+allocates.
+
+Example usage:
+
+	var buffers = pool.New(
+		func() *bytes.Buffer { return new(bytes.Buffer) },
+		func(b *bytes.Buffer) { b.Reset() },
+	)
+
+	func encode(v any) ([]byte, error) {
+		buf := buffers.Get()
+		defer buffers.Put(buf)
+		if err := json.NewEncoder(buf).Encode(v); err != nil {
+			return nil, err
+		}
+		return bytes.Clone(buf.Bytes()), nil
+	}
+*/
+type Pool[T any] struct {
+	newFn func() T
+	reset func(T)
+	opts  *options
+
+	pool sync.Pool // pool holds the idle objects without WithMaxIdle.
+
+	mutex    sync.Mutex
+	idle     []T // idle holds the idle objects with WithMaxIdle, the most recently put last.
+	lowWater int // lowWater is the lowest number of idle objects since the last shrink.
+
+	gets, puts, news, dropped atomic.Uint64
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New creates a Pool of the objects created by newFn, and reset by reset, which may be nil, when they are put back.
+func New[T any](newFn func() T, reset func(T), opts ...Option) *Pool[T] {
+	p := &Pool[T]{
+		newFn: newFn,
+		reset: reset,
+		opts:  newOptions(opts),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if p.opts.maxIdle > 0 && p.opts.shrinkInterval > 0 {
+		go p.shrinkLoop()
+	} else {
+		close(p.done)
+	}
+	return p
+}
+
+// Get returns an idle object, or a new one if none is idle.
+func (p *Pool[T]) Get() T {
+	p.gets.Add(1)
+	if p.opts.maxIdle == 0 {
+		if v, ok := p.pool.Get().(T); ok {
+			return v
+		}
+	} else if v, ok := p.pop(); ok {
+		return v
+	}
+	p.news.Add(1)
+	return p.newFn()
+}
+
+// Put resets v and puts it back into the pool. v must not be used afterwards.
+func (p *Pool[T]) Put(v T) {
+	p.puts.Add(1)
+	if p.reset != nil {
+		p.reset(v)
+	}
+	if p.opts.maxIdle == 0 {
+		p.pool.Put(v)
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.idle) >= p.opts.maxIdle {
+		p.dropped.Add(1)
+		return
+	}
+	p.idle = append(p.idle, v)
+}
+
+// Stats returns the usage metrics of the pool.
+func (p *Pool[T]) Stats() Stats {
+	p.mutex.Lock()
+	idle := len(p.idle)
+	p.mutex.Unlock()
+	return Stats{
+		Gets:    p.gets.Load(),
+		Puts:    p.puts.Load(),
+		News:    p.news.Load(),
+		Dropped: p.dropped.Load(),
+		Idle:    idle,
+	}
+}
+
+// Close stops the shrinks of WithShrinkInterval. The pool remains usable.
+func (p *Pool[T]) Close() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+	})
+	<-p.done
+}
+
+// pop removes the most recently put idle object, so that the least recently used ones are left to be shrunk.
+func (p *Pool[T]) pop() (T, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.idle) == 0 {
+		var zero T
+		return zero, false
+	}
+	v := p.idle[len(p.idle)-1]
+	clear(p.idle[len(p.idle)-1:])
+	p.idle = p.idle[:len(p.idle)-1]
+	p.lowWater = min(p.lowWater, len(p.idle))
+	return v, true
+}
+
+func (p *Pool[T]) shrinkLoop() {
+	defer close(p.done)
+	ticker := p.opts.clock.NewTicker(p.opts.shrinkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C():
+			p.Shrink()
+		}
+	}
+}
+
+// Shrink drops the idle objects which were not needed since the last shrink, the least recently used ones, e.g.,
+// after a known burst. It is called every WithShrinkInterval, and has no effect without WithMaxIdle.
+func (p *Pool[T]) Shrink() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	unused := p.lowWater
+	n := copy(p.idle, p.idle[unused:])
+	clear(p.idle[n:])
+	p.idle = p.idle[:n]
+	p.lowWater = n
+	p.dropped.Add(uint64(unused))
+}
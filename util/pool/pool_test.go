@@ -0,0 +1,98 @@
+package pool_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/util/clock"
+	"github.com/kittipat1413/go-common/util/pool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBuffers(opts ...pool.Option) *pool.Pool[*bytes.Buffer] {
+	return pool.New(
+		func() *bytes.Buffer { return new(bytes.Buffer) },
+		func(b *bytes.Buffer) { b.Reset() },
+		opts...,
+	)
+}
+
+func TestPool(t *testing.T) {
+	buffers := newBuffers()
+	defer buffers.Close()
+
+	buf := buffers.Get()
+	buf.WriteString("hello")
+	buffers.Put(buf)
+
+	// sync.Pool may drop the object, so only its reset is checked.
+	again := buffers.Get()
+	assert.Zero(t, again.Len(), "the objects are reset")
+
+	stats := buffers.Stats()
+	assert.Equal(t, uint64(2), stats.Gets)
+	assert.Equal(t, uint64(1), stats.Puts)
+	assert.GreaterOrEqual(t, stats.News, uint64(1))
+}
+
+func TestPool_MaxIdle(t *testing.T) {
+	buffers := newBuffers(pool.WithMaxIdle(2))
+	defer buffers.Close()
+
+	a, b, c := buffers.Get(), buffers.Get(), buffers.Get()
+	a.WriteString("a")
+	buffers.Put(a)
+	buffers.Put(b)
+	buffers.Put(c)
+
+	assert.Equal(t, pool.Stats{Gets: 3, Puts: 3, News: 3, Dropped: 1, Idle: 2}, buffers.Stats())
+	assert.Same(t, b, buffers.Get(), "the most recently put object is reused first")
+	got := buffers.Get()
+	assert.Same(t, a, got)
+	assert.Zero(t, got.Len())
+	assert.Equal(t, pool.Stats{Gets: 5, Puts: 3, News: 3, Dropped: 1, Idle: 0}, buffers.Stats())
+}
+
+func TestPool_Shrink(t *testing.T) {
+	buffers := newBuffers(pool.WithMaxIdle(10))
+
+	// A burst needs 5 buffers.
+	burst := make([]*bytes.Buffer, 5)
+	for i := range burst {
+		burst[i] = buffers.Get()
+	}
+	for _, buf := range burst {
+		buffers.Put(buf)
+	}
+	buffers.Shrink()
+	assert.Equal(t, 5, buffers.Stats().Idle, "the buffers were needed")
+
+	// Then the load needs 2 buffers at most: the 3 others are not needed until the next shrink.
+	for range 3 {
+		a, b := buffers.Get(), buffers.Get()
+		buffers.Put(a)
+		buffers.Put(b)
+	}
+	buffers.Shrink()
+	assert.Equal(t, 2, buffers.Stats().Idle)
+	assert.Equal(t, uint64(3), buffers.Stats().Dropped)
+
+	// Without load, the pool shrinks to nothing.
+	buffers.Shrink()
+	assert.Zero(t, buffers.Stats().Idle)
+}
+
+func TestPool_ShrinkInterval(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	buffers := newBuffers(pool.WithMaxIdle(10), pool.WithShrinkInterval(time.Minute), pool.WithClock(fake))
+	defer buffers.Close()
+
+	buffers.Put(buffers.Get())
+	require.Equal(t, 1, buffers.Stats().Idle)
+	assert.Eventually(t, func() bool {
+		fake.Advance(time.Minute)
+		return buffers.Stats().Idle == 0
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,113 @@
+package ptr
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+/*
+Optional is a value that distinguishes an absent JSON field from a null one, e.g., in the DTOs of PATCH requests:
+an absent field is left unchanged, a null field is cleared, and a field with a value is updated. A pointer cannot
+tell the first two apart.
+
+The zero value is absent. A struct field is not omitted by the omitempty tag option, so that the absent fields are
+marshaled as null: use a *Optional[T] field with omitempty to omit them, a nil pointer being absent. The fields
+decoded are Optional[T] values, as a null pointer cannot tell an absent field from a null one either.
+
+Example usage:
+
+	type UpdateUserRequest struct {
+		Name     ptr.Optional[string] `json:"name"`
+		Nickname ptr.Optional[string] `json:"nickname"`
+	}
+
+	// {"nickname": null}
+	if req.Name.IsSet() && !req.Name.IsNull() {
+		user.Name = req.Name.Value()
+	}
+	if req.Nickname.IsSet() {
+		user.Nickname = req.Nickname.Ptr() // nil: cleared
+	}
+
+	type UserPatch struct {
+		Name     *ptr.Optional[string] `json:"name,omitempty"`
+		Nickname *ptr.Optional[string] `json:"nickname,omitempty"`
+	}
+
+	// {"nickname": null}
+	body, err := json.Marshal(UserPatch{Nickname: ptr.To(ptr.Null[string]())})
+*/
+type Optional[T any] struct {
+	value T
+	set   bool
+	null  bool
+}
+
+// Some returns an Optional holding v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{value: v, set: true}
+}
+
+// Null returns a null Optional.
+func Null[T any]() Optional[T] {
+	return Optional[T]{set: true, null: true}
+}
+
+// IsSet reports whether o is present: null, or holding a value.
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// IsNull reports whether o is present and null.
+func (o Optional[T]) IsNull() bool {
+	return o.null
+}
+
+// Get returns the value of o, and whether o holds one.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.set && !o.null
+}
+
+// Value returns the value of o, or the zero value if o is absent or null.
+func (o Optional[T]) Value() T {
+	return o.value
+}
+
+// OrElse returns the value of o, or defaultValue if o is absent or null.
+func (o Optional[T]) OrElse(defaultValue T) T {
+	if v, ok := o.Get(); ok {
+		return v
+	}
+	return defaultValue
+}
+
+// Ptr returns a pointer to a copy of the value of o, or nil if o is absent or null.
+func (o Optional[T]) Ptr() *T {
+	if v, ok := o.Get(); ok {
+		return &v
+	}
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface: the absent and null Optionals are null.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if v, ok := o.Get(); ok {
+		return json.Marshal(v)
+	}
+	return []byte("null"), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. It is called for the present fields only, so that the
+// absent ones stay absent.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = Null[T]()
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*o = Some(v)
+	return nil
+}
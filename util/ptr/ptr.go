@@ -0,0 +1,22 @@
+package ptr
+
+// To returns a pointer to v, e.g., for the optional fields of a struct literal.
+func To[T any](v T) *T {
+	return &v
+}
+
+// Deref returns the value p points to, or defaultValue if p is nil.
+func Deref[T any](p *T, defaultValue T) T {
+	if p == nil {
+		return defaultValue
+	}
+	return *p
+}
+
+// Equal reports whether a and b are both nil, or point to equal values.
+func Equal[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,85 @@
+package ptr_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kittipat1413/go-common/util/ptr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTo(t *testing.T) {
+	p := ptr.To(42)
+	require.NotNil(t, p)
+	assert.Equal(t, 42, *p)
+	assert.NotSame(t, ptr.To(42), ptr.To(42))
+}
+
+func TestDeref(t *testing.T) {
+	assert.Equal(t, "value", ptr.Deref(ptr.To("value"), "default"))
+	assert.Equal(t, "default", ptr.Deref(nil, "default"))
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, ptr.Equal[int](nil, nil))
+	assert.True(t, ptr.Equal(ptr.To(1), ptr.To(1)))
+	assert.False(t, ptr.Equal(ptr.To(1), ptr.To(2)))
+	assert.False(t, ptr.Equal(ptr.To(1), nil))
+}
+
+func TestOptional(t *testing.T) {
+	var absent ptr.Optional[int]
+	assert.False(t, absent.IsSet())
+	assert.False(t, absent.IsNull())
+	assert.Nil(t, absent.Ptr())
+	assert.Equal(t, 7, absent.OrElse(7))
+
+	null := ptr.Null[int]()
+	assert.True(t, null.IsSet())
+	assert.True(t, null.IsNull())
+	_, ok := null.Get()
+	assert.False(t, ok)
+	assert.Equal(t, 7, null.OrElse(7))
+
+	some := ptr.Some(42)
+	assert.True(t, some.IsSet())
+	assert.False(t, some.IsNull())
+	v, ok := some.Get()
+	assert.True(t, ok)
+	assert.Equal(t, 42, v)
+	assert.Equal(t, 42, some.Value())
+	assert.Equal(t, ptr.To(42), some.Ptr())
+}
+
+func TestOptional_JSON(t *testing.T) {
+	type patch struct {
+		Name     ptr.Optional[string] `json:"name"`
+		Nickname ptr.Optional[string] `json:"nickname"`
+		Age      ptr.Optional[int]    `json:"age"`
+	}
+
+	var p patch
+	require.NoError(t, json.Unmarshal([]byte(`{"nickname": null, "age": 30}`), &p))
+	assert.False(t, p.Name.IsSet(), "an absent field is absent")
+	assert.True(t, p.Nickname.IsSet())
+	assert.True(t, p.Nickname.IsNull(), "a null field is null")
+	assert.Equal(t, ptr.Some(30), p.Age)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"age": "thirty"}`), &p))
+
+	data, err := json.Marshal(patch{Nickname: ptr.Null[string](), Age: ptr.Some(30)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": null, "nickname": null, "age": 30}`, string(data))
+
+	type omitted struct {
+		Name *ptr.Optional[string] `json:"name,omitempty"`
+		Age  *ptr.Optional[int]    `json:"age,omitempty"`
+	}
+	data, err = json.Marshal(omitted{Age: ptr.To(ptr.Some(30))})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"age": 30}`, string(data), "the nil pointers tagged omitempty are omitted")
+	data, err = json.Marshal(omitted{Name: ptr.To(ptr.Null[string]())})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": null}`, string(data))
+}
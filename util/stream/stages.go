@@ -0,0 +1,213 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kittipat1413/go-common/util/clock"
+)
+
+// Map emits the results of fn for the items of in. An error of fn fails the pipeline. With WithWorkers, fn is
+// called concurrently, and the results are emitted in the order they are completed.
+func Map[T, R any](p *Pipeline, in <-chan T, fn func(ctx context.Context, item T) (R, error), opts ...Option) <-chan R {
+	o := newOptions(opts)
+	out := make(chan R, o.buffer)
+	var workers sync.WaitGroup
+	workers.Add(o.workers)
+	for range o.workers {
+		p.run(func() error {
+			defer workers.Done()
+			for {
+				item, ok, err := receive(p.ctx, in)
+				if err != nil || !ok {
+					return err
+				}
+				result, err := fn(p.ctx, item)
+				if err != nil {
+					return err
+				}
+				if err := send(p.ctx, out, result); err != nil {
+					return err
+				}
+			}
+		})
+	}
+	p.run(func() error {
+		workers.Wait()
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// Filter emits the items of in for which keep returns true.
+func Filter[T any](p *Pipeline, in <-chan T, keep func(item T) bool, opts ...Option) <-chan T {
+	out := make(chan T, newOptions(opts).buffer)
+	p.run(func() error {
+		defer close(out)
+		for {
+			item, ok, err := receive(p.ctx, in)
+			if err != nil || !ok {
+				return err
+			}
+			if !keep(item) {
+				continue
+			}
+			if err := send(p.ctx, out, item); err != nil {
+				return err
+			}
+		}
+	})
+	return out
+}
+
+// Batch groups the items of in into batches of up to size items, e.g., for bulk inserts. A batch is emitted when
+// it is full, when maxWait has elapsed since its first item, so that a slow input does not hold the items back
+// indefinitely, or when in is closed. A maxWait of 0 or less only emits full batches and the last one. It panics if
+// size is not positive.
+func Batch[T any](p *Pipeline, in <-chan T, size int, maxWait time.Duration, opts ...Option) <-chan []T {
+	if size <= 0 {
+		panic("stream: batch size must be positive")
+	}
+	o := newOptions(opts)
+	out := make(chan []T, o.buffer)
+	p.run(func() error {
+		defer close(out)
+		var batch []T
+		var timer clock.Timer
+		var timeout <-chan time.Time
+		stopTimer := func() {
+			if timer != nil {
+				timer.Stop()
+				timer, timeout = nil, nil
+			}
+		}
+		defer stopTimer()
+
+		flush := func() error {
+			stopTimer()
+			if len(batch) == 0 {
+				return nil
+			}
+			full := batch
+			batch = nil
+			return send(p.ctx, out, full)
+		}
+		for {
+			select {
+			case item, ok := <-in:
+				if !ok {
+					return flush()
+				}
+				if batch == nil {
+					batch = make([]T, 0, size)
+					if maxWait > 0 {
+						timer = o.clock.NewTimer(maxWait)
+						timeout = timer.C()
+					}
+				}
+				batch = append(batch, item)
+				if len(batch) == size {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			case <-timeout:
+				if err := flush(); err != nil {
+					return err
+				}
+			case <-p.ctx.Done():
+				return p.ctx.Err()
+			}
+		}
+	})
+	return out
+}
+
+// Throttle emits the items of in at most once every interval, e.g., to respect the rate limit of a downstream
+// service.
+func Throttle[T any](p *Pipeline, in <-chan T, interval time.Duration, opts ...Option) <-chan T {
+	o := newOptions(opts)
+	out := make(chan T, o.buffer)
+	p.run(func() error {
+		defer close(out)
+		var next time.Time
+		for {
+			item, ok, err := receive(p.ctx, in)
+			if err != nil || !ok {
+				return err
+			}
+			if wait := next.Sub(o.clock.Now()); wait > 0 {
+				timer := o.clock.NewTimer(wait)
+				select {
+				case <-timer.C():
+				case <-p.ctx.Done():
+					timer.Stop()
+					return p.ctx.Err()
+				}
+			}
+			if err := send(p.ctx, out, item); err != nil {
+				return err
+			}
+			next = o.clock.Now().Add(interval)
+		}
+	})
+	return out
+}
+
+// Merge emits the items of all the inputs, in the order they are received, and closes its output once all the
+// inputs are closed.
+func Merge[T any](p *Pipeline, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	var inputs sync.WaitGroup
+	inputs.Add(len(ins))
+	for _, in := range ins {
+		p.run(func() error {
+			defer inputs.Done()
+			for {
+				item, ok, err := receive(p.ctx, in)
+				if err != nil || !ok {
+					return err
+				}
+				if err := send(p.ctx, out, item); err != nil {
+					return err
+				}
+			}
+		})
+	}
+	p.run(func() error {
+		inputs.Wait()
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// FanOut distributes the items of in between n outputs, each item to a single output: the outputs take the items
+// as fast as they are consumed, so that faster consumers get more items. The outputs are closed once in is closed.
+// It panics if n is not positive.
+func FanOut[T any](p *Pipeline, in <-chan T, n int, opts ...Option) []<-chan T {
+	if n <= 0 {
+		panic("stream: fan-out must have at least one output")
+	}
+	o := newOptions(opts)
+	outs := make([]<-chan T, n)
+	for i := range outs {
+		out := make(chan T, o.buffer)
+		outs[i] = out
+		p.run(func() error {
+			defer close(out)
+			for {
+				item, ok, err := receive(p.ctx, in)
+				if err != nil || !ok {
+					return err
+				}
+				if err := send(p.ctx, out, item); err != nil {
+					return err
+				}
+			}
+		})
+	}
+	return outs
+}
@@ -0,0 +1,208 @@
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/kittipat1413/go-common/util/clock"
+)
+
+// options holds configuration options for the stages.
+type options struct {
+	buffer  int         // buffer is the capacity of the output channel of the stage.
+	workers int         // workers is the number of goroutines of Map.
+	clock   clock.Clock // clock times Batch and Throttle.
+}
+
+// Option specifies stage configuration options.
+type Option func(*options)
+
+// WithBuffer sets the capacity of the output channel of the stage, 0 (unbuffered) by default, so that a stage can
+// run ahead of a slower consumer by up to n items.
+func WithBuffer(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.buffer = n
+		}
+	}
+}
+
+// WithWorkers runs n goroutines for Map, 1 by default. The items are then emitted in the order they are completed,
+// not in the order of the input.
+func WithWorkers(n int) Option {
+	return func(opts *options) {
+		if n > 0 {
+			opts.workers = n
+		}
+	}
+}
+
+// WithClock sets the clock of Batch and Throttle, clock.Real() by default.
+func WithClock(c clock.Clock) Option {
+	return func(opts *options) {
+		if c != nil {
+			opts.clock = c
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{workers: 1, clock: clock.Real()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+/*
+Pipeline runs stages connected by channels, e.g., reading rows, transforming them and writing them in batches.
+Each stage runs in its own goroutines, started by the functions of the package, and stops when its input is
+closed, when a stage fails, or when the context of the pipeline is done. The first error of a stage cancels the
+pipeline and is returned by Wait, so that no goroutine is left blocked.
+
+Example usage:
+
+	p := stream.New(ctx)
+	rows := stream.Generate(p, func(ctx context.Context, emit func(Row) bool) error {
+		return reader.Each(ctx, func(row Row) bool { return emit(row) })
+	})
+	orders := stream.Map(p, rows, parseOrder, stream.WithWorkers(4), stream.WithBuffer(100))
+	paid := stream.Filter(p, orders, func(o Order) bool { return o.Paid })
+	batches := stream.Batch(p, paid, 500, time.Second)
+	stream.ForEach(p, batches, func(ctx context.Context, batch []Order) error {
+		return repository.InsertOrders(ctx, batch)
+	})
+	if err := p.Wait(); err != nil {
+		return err
+	}
+*/
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+// New creates a Pipeline canceled when ctx is done.
+func New(ctx context.Context) *Pipeline {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Pipeline{ctx: ctx, cancel: cancel}
+}
+
+// Context returns the context of the pipeline, done once it is canceled.
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Wait waits for the stages to stop, and returns the first error of a stage, or the error of the context if the
+// pipeline was canceled before its stages completed.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.cancel()
+	return p.err
+}
+
+// Fail cancels the pipeline with err, returned by Wait unless a stage failed first, e.g., when a consumer of the
+// output of the pipeline stops early.
+func (p *Pipeline) Fail(err error) {
+	p.once.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+// run runs a stage in a goroutine, failing the pipeline if it returns an error.
+func (p *Pipeline) run(fn func() error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if err := fn(); err != nil {
+			p.Fail(err)
+		}
+	}()
+}
+
+// FromSlice emits the items, then closes its output.
+func FromSlice[T any](p *Pipeline, items []T, opts ...Option) <-chan T {
+	out := make(chan T, newOptions(opts).buffer)
+	p.run(func() error {
+		defer close(out)
+		for _, item := range items {
+			if err := send(p.ctx, out, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// Generate emits the items passed to emit by fn, then closes its output when fn returns. emit returns false once
+// the pipeline is canceled, in which case fn should return. An error of fn fails the pipeline.
+func Generate[T any](p *Pipeline, fn func(ctx context.Context, emit func(T) bool) error, opts ...Option) <-chan T {
+	out := make(chan T, newOptions(opts).buffer)
+	p.run(func() error {
+		defer close(out)
+		var canceled error
+		err := fn(p.ctx, func(item T) bool {
+			canceled = send(p.ctx, out, item)
+			return canceled == nil
+		})
+		if err != nil {
+			return err
+		}
+		return canceled
+	})
+	return out
+}
+
+// ForEach calls fn with the items of in until in is closed. An error of fn fails the pipeline. Call Wait to wait
+// for the items to be consumed.
+func ForEach[T any](p *Pipeline, in <-chan T, fn func(ctx context.Context, item T) error) {
+	p.run(func() error {
+		for {
+			item, ok, err := receive(p.ctx, in)
+			if err != nil || !ok {
+				return err
+			}
+			if err := fn(p.ctx, item); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// Collect returns the items of in once the pipeline completes, or the error of Wait.
+func Collect[T any](p *Pipeline, in <-chan T) ([]T, error) {
+	var items []T
+	ForEach(p, in, func(ctx context.Context, item T) error {
+		items = append(items, item)
+		return nil
+	})
+	if err := p.Wait(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// receive returns the next item of in, or false once in is closed, or the error of ctx once it is done.
+func receive[T any](ctx context.Context, in <-chan T) (T, bool, error) {
+	select {
+	case item, ok := <-in:
+		return item, ok, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, false, ctx.Err()
+	}
+}
+
+// send sends item to out, or returns the error of ctx once it is done.
+func send[T any](ctx context.Context, out chan<- T, item T) error {
+	select {
+	case out <- item:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
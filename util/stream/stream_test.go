@@ -0,0 +1,188 @@
+package stream_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/util/clock"
+	"github.com/kittipat1413/go-common/util/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestPipeline(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	p := stream.New(context.Background())
+	numbers := stream.FromSlice(p, []int{1, 2, 3, 4, 5, 6, 7})
+	even := stream.Filter(p, numbers, func(n int) bool { return n%2 == 0 })
+	labels := stream.Map(p, even, func(ctx context.Context, n int) (string, error) {
+		return strconv.Itoa(n * 10), nil
+	}, stream.WithBuffer(2))
+	batches := stream.Batch(p, labels, 2, 0)
+
+	result, err := stream.Collect(p, batches)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"20", "40"}, {"60"}}, result)
+}
+
+func TestMap_Workers(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	p := stream.New(context.Background())
+	numbers := stream.Generate(p, func(ctx context.Context, emit func(int) bool) error {
+		for i := range 100 {
+			if !emit(i) {
+				return nil
+			}
+		}
+		return nil
+	})
+	squares := stream.Map(p, numbers, func(ctx context.Context, n int) (int, error) {
+		return n * n, nil
+	}, stream.WithWorkers(4))
+
+	result, err := stream.Collect(p, squares)
+	require.NoError(t, err)
+	slices.Sort(result)
+	require.Len(t, result, 100)
+	assert.Equal(t, 99*99, result[99])
+}
+
+func TestPipeline_Error(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	errInvalid := errors.New("invalid row")
+	p := stream.New(context.Background())
+	// The source never ends by itself: the error must cancel it.
+	numbers := stream.Generate(p, func(ctx context.Context, emit func(int) bool) error {
+		for i := 0; ; i++ {
+			if !emit(i) {
+				return nil
+			}
+		}
+	})
+	parsed := stream.Map(p, numbers, func(ctx context.Context, n int) (int, error) {
+		if n == 10 {
+			return 0, errInvalid
+		}
+		return n, nil
+	}, stream.WithWorkers(2))
+	merged := stream.Merge(p, stream.FanOut(p, parsed, 3)...)
+
+	_, err := stream.Collect(p, merged)
+	assert.ErrorIs(t, err, errInvalid)
+}
+
+func TestPipeline_Canceled(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := stream.New(ctx)
+	numbers := stream.Generate(p, func(ctx context.Context, emit func(int) bool) error {
+		for i := 0; ; i++ {
+			if i == 5 {
+				cancel()
+			}
+			if !emit(i) {
+				return nil
+			}
+		}
+	})
+	var consumed []int
+	stream.ForEach(p, numbers, func(ctx context.Context, n int) error {
+		consumed = append(consumed, n)
+		return nil
+	})
+	assert.ErrorIs(t, p.Wait(), context.Canceled, "the pipeline did not complete")
+	assert.LessOrEqual(t, len(consumed), 6)
+}
+
+func TestPipeline_Fail(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	errEnough := errors.New("enough")
+	p := stream.New(context.Background())
+	numbers := stream.Generate(p, func(ctx context.Context, emit func(int) bool) error {
+		for i := 0; emit(i); i++ {
+		}
+		return nil
+	})
+	for n := range numbers {
+		if n == 3 {
+			p.Fail(errEnough)
+			break
+		}
+	}
+	assert.ErrorIs(t, p.Wait(), errEnough)
+}
+
+func TestMergeFanOut(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	p := stream.New(context.Background())
+	outs := stream.FanOut(p, stream.FromSlice(p, []int{1, 2, 3, 4, 5, 6}), 3, stream.WithBuffer(1))
+	require.Len(t, outs, 3)
+	merged := stream.Merge(p, outs[0], outs[1], outs[2], stream.FromSlice(p, []int{7}))
+
+	result, err := stream.Collect(p, merged)
+	require.NoError(t, err)
+	slices.Sort(result)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7}, result, "each item is emitted once")
+}
+
+func TestBatch_MaxWait(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	fake := clock.NewFake(time.Now())
+	p := stream.New(context.Background())
+	in := make(chan int)
+	batches := stream.Batch(p, in, 10, time.Second, stream.WithClock(fake))
+
+	in <- 1
+	in <- 2
+	fake.BlockUntil(1)
+	fake.Advance(time.Second)
+	assert.Equal(t, []int{1, 2}, <-batches, "the batch is emitted after maxWait")
+
+	in <- 3
+	close(in)
+	assert.Equal(t, []int{3}, <-batches, "the last batch is emitted when the input is closed")
+	_, ok := <-batches
+	assert.False(t, ok)
+	require.NoError(t, p.Wait())
+}
+
+func TestThrottle(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	fake := clock.NewFake(time.Now())
+	p := stream.New(context.Background())
+	throttled := stream.Throttle(p, stream.FromSlice(p, []int{1, 2, 3}), time.Second, stream.WithClock(fake))
+
+	start := fake.Now()
+	assert.Equal(t, 1, <-throttled, "the first item is not delayed")
+	for _, expected := range []int{2, 3} {
+		fake.BlockUntil(1)
+		select {
+		case <-throttled:
+			t.Fatal("the item is not throttled")
+		default:
+		}
+		fake.Advance(time.Second)
+		assert.Equal(t, expected, <-throttled)
+	}
+	assert.Equal(t, 2*time.Second, fake.Since(start))
+	require.NoError(t, p.Wait())
+}
+
+func TestPanics(t *testing.T) {
+	p := stream.New(context.Background())
+	assert.Panics(t, func() { stream.Batch(p, make(chan int), 0, 0) })
+	assert.Panics(t, func() { stream.FanOut(p, make(chan int), 0) })
+}
@@ -0,0 +1,84 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// ErrWeightTooLarge is returned by Semaphore.Acquire when the weight exceeds the size of the semaphore, which
+// would otherwise wait forever.
+var ErrWeightTooLarge = errors.New("sync: weight exceeds the size of the semaphore")
+
+/*
+Semaphore bounds the total weight of the operations running at once, e.g., the bytes of the images decoded at once
+rather than their number, so that a few large images do not exhaust the memory. Acquire waits for enough weight to
+be available until its context is done, and the waiters are served in order, so that a heavy operation is not
+starved by lighter ones.
+
+Example usage:
+
+	var memory = sync.NewSemaphore(512 << 20) // 512 MiB
+
+	func decode(ctx context.Context, data []byte, width, height int) (image.Image, error) {
+		weight := int64(width) * int64(height) * 4
+		if err := memory.Acquire(ctx, weight); err != nil {
+			return nil, err
+		}
+		defer memory.Release(weight)
+		...
+	}
+*/
+type Semaphore struct {
+	size     int64
+	inUse    atomic.Int64
+	weighted *semaphore.Weighted
+}
+
+// NewSemaphore creates a Semaphore of the given total weight. It panics if size is not positive.
+func NewSemaphore(size int64) *Semaphore {
+	if size <= 0 {
+		panic("sync: semaphore size must be positive")
+	}
+	return &Semaphore{size: size, weighted: semaphore.NewWeighted(size)}
+}
+
+// Acquire acquires a weight of n, waiting until it is available or ctx is done, in which case it returns the error
+// of ctx and acquires nothing. It returns ErrWeightTooLarge if n exceeds the size of the semaphore.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	if n > s.size {
+		return ErrWeightTooLarge
+	}
+	if err := s.weighted.Acquire(ctx, n); err != nil {
+		return err
+	}
+	s.inUse.Add(n)
+	return nil
+}
+
+// TryAcquire acquires a weight of n if it is available without waiting, and reports whether it did.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	if !s.weighted.TryAcquire(n) {
+		return false
+	}
+	s.inUse.Add(n)
+	return true
+}
+
+// Release releases a weight of n. It panics if more weight is released than acquired.
+func (s *Semaphore) Release(n int64) {
+	s.inUse.Add(-n)
+	s.weighted.Release(n)
+}
+
+// Size returns the total weight of the semaphore.
+func (s *Semaphore) Size() int64 {
+	return s.size
+}
+
+// InUse returns the weight acquired and not released yet, e.g., to export it as a gauge.
+func (s *Semaphore) InUse() int64 {
+	return s.inUse.Load()
+}
@@ -0,0 +1,57 @@
+package sync_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/util/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemaphore(t *testing.T) {
+	sem := sync.NewSemaphore(10)
+	assert.Equal(t, int64(10), sem.Size())
+
+	require.NoError(t, sem.Acquire(context.Background(), 6))
+	assert.True(t, sem.TryAcquire(4))
+	assert.False(t, sem.TryAcquire(1))
+	assert.Equal(t, int64(10), sem.InUse())
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- sem.Acquire(context.Background(), 5)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("the weight is not available")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	sem.Release(4)
+	select {
+	case <-acquired:
+		t.Fatal("the weight is still not available")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	sem.Release(6)
+	require.NoError(t, <-acquired)
+	assert.Equal(t, int64(5), sem.InUse())
+	sem.Release(5)
+	assert.Zero(t, sem.InUse())
+}
+
+func TestSemaphore_AcquireContext(t *testing.T) {
+	sem := sync.NewSemaphore(1)
+	require.True(t, sem.TryAcquire(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, sem.Acquire(ctx, 1), context.DeadlineExceeded)
+	assert.Equal(t, int64(1), sem.InUse(), "nothing is acquired on error")
+
+	assert.ErrorIs(t, sem.Acquire(context.Background(), 2), sync.ErrWeightTooLarge, "the weight is never available")
+	assert.Panics(t, func() { sync.NewSemaphore(0) })
+}
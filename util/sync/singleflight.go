@@ -0,0 +1,120 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdsync "sync"
+)
+
+// ErrPanic is wrapped by the errors the panics of the functions of a Group are converted to.
+var ErrPanic = errors.New("sync: function panicked")
+
+// call is a call of a function in flight or completed.
+type call[V any] struct {
+	done  chan struct{} // done is closed once the call completes.
+	value V
+	err   error
+	dups  int // dups is the number of callers which joined the call.
+}
+
+/*
+Group deduplicates the concurrent calls of a function per key: while a call of key is in flight, the callers of the
+same key wait for it and share its result instead of calling the function again, e.g., so that the identical
+downstream calls of concurrent requests hit the downstream service once. Unlike golang.org/x/sync/singleflight,
+the keys and the values are typed, and the callers can give up waiting with DoContext.
+
+The zero value is ready to use. A Group must not be copied after first use.
+
+Example usage:
+
+	var profiles sync.Group[string, *Profile]
+
+	func (c *Client) Profile(ctx context.Context, userID string) (*Profile, error) {
+		profile, err, _ := profiles.DoContext(ctx, userID, func() (*Profile, error) {
+			// The call outlives the callers giving up, so it must not use their context.
+			return c.fetchProfile(context.WithoutCancel(ctx), userID)
+		})
+		return profile, err
+	}
+*/
+type Group[K comparable, V any] struct {
+	mutex stdsync.Mutex
+	calls map[K]*call[V]
+}
+
+// Do calls fn, unless a call of key is in flight, in which case it waits for it. It returns the result of the
+// call, and whether it was shared with other callers. A panic of fn is converted to an error wrapping ErrPanic,
+// returned to all the callers.
+func (g *Group[K, V]) Do(key K, fn func() (V, error)) (V, error, bool) {
+	c, leader := g.join(key)
+	if leader {
+		g.run(key, c, fn)
+	} else {
+		<-c.done
+	}
+	return c.value, c.err, c.dups > 0
+}
+
+// DoContext is like Do, but waits for the result until ctx is done, in which case it returns the error of ctx.
+// fn runs in its own goroutine and completes even if all the callers gave up, so that the callers joining it in
+// the meantime get its result.
+func (g *Group[K, V]) DoContext(ctx context.Context, key K, fn func() (V, error)) (V, error, bool) {
+	c, leader := g.join(key)
+	if leader {
+		go g.run(key, c, fn)
+	}
+	select {
+	case <-c.done:
+		return c.value, c.err, c.dups > 0
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err(), false
+	}
+}
+
+// Forget makes the next callers of key call the function again rather than wait for the call in flight, e.g.,
+// after an update of the value it loads.
+func (g *Group[K, V]) Forget(key K) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	delete(g.calls, key)
+}
+
+// join returns the call in flight of key, or a new call and true if there is none.
+func (g *Group[K, V]) join(key K) (*call[V], bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		return c, false
+	}
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	c := &call[V]{done: make(chan struct{})}
+	g.calls[key] = c
+	return c, true
+}
+
+// run calls fn for c, then removes c from the calls in flight and releases its callers.
+func (g *Group[K, V]) run(key K, c *call[V], fn func() (V, error)) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			var zero V
+			c.value = zero
+			if recoveredErr, ok := recovered.(error); ok {
+				c.err = fmt.Errorf("%w: %w", ErrPanic, recoveredErr)
+			} else {
+				c.err = fmt.Errorf("%w: %v", ErrPanic, recovered)
+			}
+		}
+		g.mutex.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mutex.Unlock()
+		close(c.done)
+	}()
+	c.value, c.err = fn()
+}
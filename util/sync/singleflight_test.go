@@ -0,0 +1,110 @@
+package sync_test
+
+import (
+	"context"
+	"errors"
+	stdsync "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/util/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroup_Do(t *testing.T) {
+	var group sync.Group[int, string]
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func() (string, error) {
+		calls.Add(1)
+		<-release
+		return "profile", nil
+	}
+
+	const callers = 10
+	var wg stdsync.WaitGroup
+	results := make([]string, callers)
+	shared := make([]bool, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _, shared[i] = group.Do(42, fn)
+		}()
+	}
+	// Let the callers join the call before releasing it.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for i := range callers {
+		assert.Equal(t, "profile", results[i])
+		assert.True(t, shared[i])
+	}
+
+	// The calls of other keys, and the calls after completion, are not deduplicated.
+	value, err, isShared := group.Do(7, func() (string, error) { return "", errors.New("not found") })
+	assert.Empty(t, value)
+	assert.EqualError(t, err, "not found")
+	assert.False(t, isShared)
+	_, _, _ = group.Do(42, fn)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestGroup_DoContext(t *testing.T) {
+	var group sync.Group[string, int]
+	release := make(chan struct{})
+	fn := func() (int, error) {
+		<-release
+		return 42, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err, _ := group.DoContext(ctx, "user-1", fn)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The call keeps running after its caller gave up, and the next callers join it.
+	done := make(chan int)
+	go func() {
+		value, _, _ := group.DoContext(context.Background(), "user-1", func() (int, error) {
+			t.Error("the call in flight is joined")
+			return 0, nil
+		})
+		done <- value
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	assert.Equal(t, 42, <-done)
+}
+
+func TestGroup_Forget(t *testing.T) {
+	var group sync.Group[string, int]
+	release := make(chan struct{})
+	go group.Do("key", func() (int, error) {
+		<-release
+		return 1, nil
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	group.Forget("key")
+	value, _, shared := group.Do("key", func() (int, error) { return 2, nil })
+	assert.Equal(t, 2, value, "the call in flight is forgotten")
+	assert.False(t, shared)
+	close(release)
+}
+
+func TestGroup_Panic(t *testing.T) {
+	var group sync.Group[string, int]
+	_, err, _ := group.Do("key", func() (int, error) { panic("boom") })
+	require.ErrorIs(t, err, sync.ErrPanic)
+	assert.EqualError(t, err, "sync: function panicked: boom")
+
+	cause := errors.New("boom")
+	_, err, _ = group.DoContext(context.Background(), "key", func() (int, error) { panic(cause) })
+	assert.ErrorIs(t, err, sync.ErrPanic)
+	assert.ErrorIs(t, err, cause)
+}
@@ -0,0 +1,108 @@
+package timeoutbudget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBudgetExhausted is returned by Sub when the remaining budget is lower than the WithMinimum duration.
+var ErrBudgetExhausted = errors.New("timeout budget exhausted")
+
+// New starts a budget of total for a request: the returned context expires after total, or at the deadline of
+// ctx if it is earlier.
+func New(ctx context.Context, total time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, total)
+}
+
+// Remaining returns the time left until the deadline of ctx, or 0 if it has passed, e.g., to configure the timeout
+// of a downstream call or to propagate it in a header. It returns false if ctx has no deadline.
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	if remaining := time.Until(deadline); remaining > 0 {
+		return remaining, true
+	}
+	return 0, true
+}
+
+// options holds configuration options for Sub.
+type options struct {
+	reserve time.Duration
+	minimum time.Duration
+}
+
+// Option specifies Sub configuration options.
+type Option func(*options)
+
+// WithReserve keeps d of the budget for after the sub-call, e.g., to write the response: the sub-call expires
+// d before the deadline of the budget.
+func WithReserve(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.reserve = d
+		}
+	}
+}
+
+// WithMinimum makes Sub fail with ErrBudgetExhausted when less than d is left for the sub-call, rather than
+// starting a call that cannot complete in time.
+func WithMinimum(d time.Duration) Option {
+	return func(opts *options) {
+		if d > 0 {
+			opts.minimum = d
+		}
+	}
+}
+
+/*
+Sub carves a sub-timeout out of the budget of ctx: the returned context expires after timeout, or at the deadline
+of ctx minus the WithReserve duration if it is earlier. A timeout of 0 or less only applies the reserve. Since the
+sub-context has the carved deadline, the sub-timeouts carved out of it respect its reserve too.
+
+Example usage:
+
+	ctx, cancel := timeoutbudget.New(r.Context(), 2*time.Second)
+	defer cancel()
+
+	// Keep 50ms to write the response, and do not call the downstream with less than 100ms left.
+	callCtx, callCancel, err := timeoutbudget.Sub(ctx, time.Second,
+		timeoutbudget.WithReserve(50*time.Millisecond),
+		timeoutbudget.WithMinimum(100*time.Millisecond),
+	)
+	if err != nil {
+		// Handle error
+	}
+	defer callCancel()
+	user, err := client.GetUser(callCtx, id)
+*/
+func Sub(ctx context.Context, timeout time.Duration, opts ...Option) (context.Context, context.CancelFunc, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	now := time.Now()
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = now.Add(timeout)
+	}
+	if parent, ok := ctx.Deadline(); ok {
+		if reserved := parent.Add(-o.reserve); deadline.IsZero() || reserved.Before(deadline) {
+			deadline = reserved
+		}
+	}
+
+	if deadline.IsZero() {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, nil
+	}
+	if available := deadline.Sub(now); available < o.minimum {
+		return nil, nil, fmt.Errorf("%w: %v left, %v required", ErrBudgetExhausted, available.Round(time.Millisecond), o.minimum)
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	return ctx, cancel, nil
+}
@@ -0,0 +1,100 @@
+package timeoutbudget_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kittipat1413/go-common/util/timeoutbudget"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemaining(t *testing.T) {
+	_, ok := timeoutbudget.Remaining(context.Background())
+	assert.False(t, ok)
+
+	ctx, cancel := timeoutbudget.New(context.Background(), time.Second)
+	defer cancel()
+	remaining, ok := timeoutbudget.Remaining(ctx)
+	require.True(t, ok)
+	assert.InDelta(t, time.Second, remaining, float64(100*time.Millisecond))
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	remaining, ok = timeoutbudget.Remaining(expired)
+	require.True(t, ok)
+	assert.Zero(t, remaining)
+}
+
+func TestSub(t *testing.T) {
+	budget, cancel := timeoutbudget.New(context.Background(), time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name              string
+		ctx               context.Context
+		timeout           time.Duration
+		options           []timeoutbudget.Option
+		expectedRemaining time.Duration
+		expectedDeadline  bool
+		expectedErr       error
+	}{
+		{name: "timeout within budget", ctx: budget, timeout: 200 * time.Millisecond, expectedRemaining: 200 * time.Millisecond, expectedDeadline: true},
+		{name: "timeout beyond budget", ctx: budget, timeout: time.Hour, expectedRemaining: time.Second, expectedDeadline: true},
+		{
+			name:              "reserve",
+			ctx:               budget,
+			timeout:           time.Hour,
+			options:           []timeoutbudget.Option{timeoutbudget.WithReserve(300 * time.Millisecond)},
+			expectedRemaining: 700 * time.Millisecond,
+			expectedDeadline:  true,
+		},
+		{
+			name:              "reserve only",
+			ctx:               budget,
+			options:           []timeoutbudget.Option{timeoutbudget.WithReserve(300 * time.Millisecond)},
+			expectedRemaining: 700 * time.Millisecond,
+			expectedDeadline:  true,
+		},
+		{
+			name:        "minimum not available",
+			ctx:         budget,
+			timeout:     time.Hour,
+			options:     []timeoutbudget.Option{timeoutbudget.WithReserve(900 * time.Millisecond), timeoutbudget.WithMinimum(500 * time.Millisecond)},
+			expectedErr: timeoutbudget.ErrBudgetExhausted,
+		},
+		{name: "no budget", ctx: context.Background(), timeout: 200 * time.Millisecond, expectedRemaining: 200 * time.Millisecond, expectedDeadline: true},
+		{name: "no budget nor timeout", ctx: context.Background(), options: []timeoutbudget.Option{timeoutbudget.WithReserve(time.Second)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel, err := timeoutbudget.Sub(tt.ctx, tt.timeout, tt.options...)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			defer cancel()
+			remaining, ok := timeoutbudget.Remaining(ctx)
+			require.Equal(t, tt.expectedDeadline, ok)
+			assert.InDelta(t, tt.expectedRemaining, remaining, float64(100*time.Millisecond))
+		})
+	}
+}
+
+func TestSub_Nested(t *testing.T) {
+	budget, cancel := timeoutbudget.New(context.Background(), time.Second)
+	defer cancel()
+
+	handler, cancelHandler, err := timeoutbudget.Sub(budget, 0, timeoutbudget.WithReserve(200*time.Millisecond))
+	require.NoError(t, err)
+	defer cancelHandler()
+
+	call, cancelCall, err := timeoutbudget.Sub(handler, time.Hour, timeoutbudget.WithReserve(300*time.Millisecond))
+	require.NoError(t, err)
+	defer cancelCall()
+
+	remaining, _ := timeoutbudget.Remaining(call)
+	assert.InDelta(t, 500*time.Millisecond, remaining, float64(100*time.Millisecond), "both reserves should be kept")
+}